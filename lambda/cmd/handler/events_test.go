@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/steverhoton/location-lambda/internal/handler"
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/permissions"
+	"github.com/steverhoton/location-lambda/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// replayRepository records the repository calls of replayed events, serving
+// one stored coordinates location; calls it doesn't implement panic on the
+// nil embedded Repository.
+type replayRepository struct {
+	repository.Repository
+	mu    sync.Mutex
+	calls []string
+}
+
+func (r *replayRepository) record(format string, args ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, fmt.Sprintf(format, args...))
+}
+
+func (r *replayRepository) Get(_ context.Context, accountID, locationID string) (*repository.LocationEnvelope, error) {
+	r.record("Get %s %s", accountID, locationID)
+	if locationID == "loc-missing" {
+		return nil, errors.New("location not found or access denied")
+	}
+	return &repository.LocationEnvelope{LocationID: locationID, Location: models.CoordinatesLocation{
+		LocationBase: models.LocationBase{AccountID: accountID, LocationType: models.LocationTypeCoordinates},
+		Coordinates:  models.Coordinates{Latitude: 40.7128, Longitude: -74.006},
+	}}, nil
+}
+
+func (r *replayRepository) List(_ context.Context, accountID string, options *repository.ListOptions) (*repository.ListResult, error) {
+	r.record("List %s limit=%d", accountID, *options.Limit)
+	return &repository.ListResult{Items: []repository.LocationEnvelope{}}, nil
+}
+
+func (r *replayRepository) Create(_ context.Context, location models.Location) (*repository.LocationEnvelope, error) {
+	r.record("Create %s %s", location.GetAccountID(), location.GetLocationType())
+	return &repository.LocationEnvelope{LocationID: "loc-new", Location: location}, nil
+}
+
+func (r *replayRepository) Delete(_ context.Context, accountID, locationID string) error {
+	r.record("Delete %s %s", accountID, locationID)
+	return nil
+}
+
+// TestRecordedEvents replays AppSync invocation payloads, as AppSync sends
+// them for each resolver kind and authorization mode, through lambdaHandler,
+// so a change to how events are decoded can't silently drop a field name,
+// identity, or arguments.
+func TestRecordedEvents(t *testing.T) {
+	// Dispatchers may do anything; callers without a role, such as those of a
+	// Lambda authorizer, may do nothing
+	policy, err := permissions.Parse(`{"roles": {"dispatchers": {"operations": ["*"]}}}`)
+	require.NoError(t, err)
+
+	tests := []struct {
+		event         string
+		opts          []handler.Option
+		expectedCalls []string
+		expectedError string
+		check         func(t *testing.T, result interface{})
+	}{
+		{
+			event:         "direct-cognito-getLocation.json",
+			opts:          []handler.Option{handler.WithPermissions(policy)},
+			expectedCalls: []string{"Get acc-12345 loc-001"},
+			check: func(t *testing.T, result interface{}) {
+				assert.Equal(t, "loc-001", result.(map[string]interface{})["locationId"])
+			},
+		},
+		{
+			event:         "direct-oidc-getLocation.json",
+			expectedCalls: []string{"Get acc-12345 loc-001"},
+		},
+		{
+			// The key in the request headers keeps the caller to the public fields
+			event:         "direct-apikey-listLocations.json",
+			expectedError: "listLocations is not available with API key authorization",
+		},
+		{
+			event:         "direct-iam-createCoordinatesLocation.json",
+			expectedCalls: []string{"Create acc-12345 coordinates"},
+			check: func(t *testing.T, result interface{}) {
+				assert.Equal(t, "loc-new", result.(map[string]interface{})["locationId"])
+			},
+		},
+		{
+			event:         "direct-lambda-authorizer-deleteLocation.json",
+			opts:          []handler.Option{handler.WithPermissions(policy)},
+			expectedError: "permission denied: deleteLocation is not allowed for the caller's roles",
+		},
+		{
+			event:         "pipeline-deleteLocation.json",
+			opts:          []handler.Option{handler.WithPermissions(policy)},
+			expectedCalls: []string{"Delete acc-12345 loc-001"},
+			check: func(t *testing.T, result interface{}) {
+				assert.True(t, result.(*handler.DeleteResponse).Success)
+			},
+		},
+		{
+			event:         "template-listLocations.json",
+			opts:          []handler.Option{handler.WithPermissions(policy)},
+			expectedCalls: []string{"List acc-12345 limit=25"},
+		},
+		{
+			event:         "batch-getLocation.json",
+			expectedCalls: []string{"Get acc-12345 loc-001", "Get acc-12345 loc-002", "Get acc-12345 loc-missing"},
+			check: func(t *testing.T, result interface{}) {
+				results := result.([]batchResult)
+				require.Len(t, results, 3)
+				assert.Equal(t, "loc-001", results[0].Data.(map[string]interface{})["locationId"])
+				assert.Equal(t, "loc-002", results[1].Data.(map[string]interface{})["locationId"])
+				assert.Nil(t, results[2].Data)
+				assert.Equal(t, "failed to get location: location not found or access denied", results[2].ErrorMessage)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.event, func(t *testing.T) {
+			payload, err := os.ReadFile(filepath.Join("testdata", "events", tt.event))
+			require.NoError(t, err)
+
+			repo := &replayRepository{}
+			original := newHandler
+			newHandler = func(context.Context) (*handler.AppSyncHandler, error) {
+				return handler.NewAppSyncHandler(repo, tt.opts...), nil
+			}
+			defer func() { newHandler = original }()
+
+			result, err := lambdaHandler(context.Background(), payload)
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+				assert.Empty(t, repo.calls)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCalls, repo.calls)
+			if tt.check != nil {
+				tt.check(t, result)
+			}
+		})
+	}
+}
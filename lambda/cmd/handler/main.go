@@ -2,16 +2,46 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"sync"
+	"time"
+	// Shop hours name IANA zones, which the Lambda runtime image lacks
+	_ "time/tzdata"
 
+	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
-	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-lambda-go/lambda/messages"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/steverhoton/location-lambda/internal/assets"
+	"github.com/steverhoton/location-lambda/internal/changeexport"
+	"github.com/steverhoton/location-lambda/internal/config"
+	"github.com/steverhoton/location-lambda/internal/elevation"
+	"github.com/steverhoton/location-lambda/internal/geocode"
+	"github.com/steverhoton/location-lambda/internal/geofence"
 	"github.com/steverhoton/location-lambda/internal/handler"
+	"github.com/steverhoton/location-lambda/internal/iotpush"
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/notify"
+	"github.com/steverhoton/location-lambda/internal/permissions"
+	"github.com/steverhoton/location-lambda/internal/pii"
+	"github.com/steverhoton/location-lambda/internal/places"
 	"github.com/steverhoton/location-lambda/internal/repository"
+	"github.com/steverhoton/location-lambda/internal/routing"
+	"github.com/steverhoton/location-lambda/internal/staticmap"
+	"github.com/steverhoton/location-lambda/internal/verify"
+	"github.com/steverhoton/location-lambda/internal/weather"
 )
 
 // getEnvVar retrieves an environment variable or returns a default value.
@@ -31,25 +61,482 @@ func initializeHandler(ctx context.Context) (*handler.AppSyncHandler, error) {
 	}
 
 	// Load AWS configuration
-	cfg, err := config.LoadDefaultConfig(ctx)
+	cfg, err := awsconfig.LoadDefaultConfig(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load AWS config: %w", err)
 	}
 
-	// Create DynamoDB client
-	dynamoClient := dynamodb.NewFromConfig(cfg)
+	// Create DynamoDB client, reporting consumed capacity when CAPACITY_METRICS=true
+	var dynamoClient repository.DynamoDBClient = dynamodb.NewFromConfig(cfg)
+	if capacityMetricsEnabled() {
+		dynamoClient = repository.NewCapacityClient(dynamoClient)
+	}
+
+	// Configure read consistency, e.g. DYNAMODB_CONSISTENT_READS=get,list
+	readConsistency := repository.ParseConsistentReadOperations(
+		getEnvVar("DYNAMODB_CONSISTENT_READS", ""),
+		getEnvVar("DYNAMODB_STALE_READ_FALLBACK", "true") == "true",
+	)
+
+	// Configure optional GSI write sharding for large accounts
+	shardCount, err := strconv.Atoi(getEnvVar("DYNAMODB_SHARD_COUNT", "0"))
+	if err != nil || shardCount < 0 {
+		return nil, fmt.Errorf("DYNAMODB_SHARD_COUNT must be a non-negative integer")
+	}
+	sharding := repository.ShardConfig{
+		ShardCount: shardCount,
+		IndexName:  getEnvVar("DYNAMODB_SHARD_INDEX_NAME", "AccountShardIndex"),
+	}
+
+	// Serve GSI reads from the base table while an index can't be read, e.g. DYNAMODB_INDEX_FALLBACK_SEGMENTS=4
+	fallbackSegments, err := strconv.Atoi(getEnvVar("DYNAMODB_INDEX_FALLBACK_SEGMENTS", "0"))
+	if err != nil || fallbackSegments < 0 {
+		return nil, fmt.Errorf("DYNAMODB_INDEX_FALLBACK_SEGMENTS must be a non-negative integer")
+	}
+
+	// Split adminListAllLocations scans into parallel segments, e.g. ADMIN_SCAN_SEGMENTS=8
+	scanSegments, err := strconv.Atoi(getEnvVar("ADMIN_SCAN_SEGMENTS", "4"))
+	if err != nil || scanSegments < 1 || scanSegments > 64 {
+		return nil, fmt.Errorf("ADMIN_SCAN_SEGMENTS must be an integer between 1 and 64")
+	}
+
+	// Configure how locations are keyed, e.g. DYNAMODB_KEY_LAYOUT=single-table
+	keyLayout, err := repository.ParseKeyLayout(os.Getenv("DYNAMODB_KEY_LAYOUT"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid DYNAMODB_KEY_LAYOUT: %w", err)
+	}
+
+	// Configure PII scanning, e.g. PII_POLICY=tag and PII_ACCOUNT_POLICIES=acct-1:reject
+	piiConfig, err := pii.ParseConfig(os.Getenv("PII_POLICY"), os.Getenv("PII_ACCOUNT_POLICIES"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid PII configuration: %w", err)
+	}
+
+	// Configure list page sizes, e.g. LIST_DEFAULT_LIMIT=50 and LIST_MAX_LIMIT=500
+	listLimits, err := config.LoadListLimits(os.Getenv)
+	if err != nil {
+		return nil, err
+	}
+
+	// Options shared by the home and data residency tables
+	regionalOpts := []repository.Option{
+		repository.WithDefaultLimit(listLimits.Default),
+		repository.WithReadConsistency(readConsistency),
+		repository.WithSharding(sharding),
+		repository.WithKeyLayout(keyLayout),
+		repository.WithIndexFallback(fallbackSegments),
+		repository.WithScanSegments(scanSegments),
+		repository.WithPIIPolicy(piiConfig),
+	}
+	// Resolve external IDs with the sparse external ID GSI when it exists
+	if indexName := os.Getenv("DYNAMODB_EXTERNAL_ID_INDEX_NAME"); indexName != "" {
+		regionalOpts = append(regionalOpts, repository.WithExternalIDIndex(indexName))
+	}
+	// Look locations up by ID alone for admin tooling
+	if indexName := os.Getenv("DYNAMODB_LOCATION_ID_INDEX_NAME"); indexName != "" {
+		regionalOpts = append(regionalOpts, repository.WithLocationIDIndex(indexName))
+	}
+	// Find shops by website host with the sparse website GSI
+	if indexName := os.Getenv("DYNAMODB_WEBSITE_INDEX_NAME"); indexName != "" {
+		regionalOpts = append(regionalOpts, repository.WithWebsiteIndex(indexName))
+	}
+	// List a contact's shops with the sparse contact GSI
+	if indexName := os.Getenv("DYNAMODB_CONTACT_INDEX_NAME"); indexName != "" {
+		regionalOpts = append(regionalOpts, repository.WithContactIndex(indexName))
+	}
+	// Find sub-accounts with the sparse parent account GSI
+	if indexName := os.Getenv("DYNAMODB_PARENT_ACCOUNT_INDEX_NAME"); indexName != "" {
+		regionalOpts = append(regionalOpts, repository.WithParentAccountIndex(indexName))
+	}
+	// Serve syncLocations with the GSI keyed on syncAccount and updatedAt,
+	// keeping deletions for SYNC_TOMBSTONE_RETENTION, e.g. 720h
+	if indexName := os.Getenv("DYNAMODB_SYNC_INDEX_NAME"); indexName != "" {
+		var retention time.Duration
+		if value := os.Getenv("SYNC_TOMBSTONE_RETENTION"); value != "" {
+			if retention, err = time.ParseDuration(value); err != nil || retention <= 0 {
+				return nil, fmt.Errorf("invalid SYNC_TOMBSTONE_RETENTION %q: must be a positive duration", value)
+			}
+		}
+		regionalOpts = append(regionalOpts, repository.WithSyncIndex(indexName, retention))
+	}
+	// Fail reads of records whose content no longer matches their hash
+	if os.Getenv("VERIFY_CONTENT_HASH") == "true" {
+		regionalOpts = append(regionalOpts, repository.WithContentHashVerification())
+	}
+	opts := append([]repository.Option{}, regionalOpts...)
+
+	// Configure optional S3 overflow storage for oversized extendedAttributes
+	if bucket := os.Getenv("OVERFLOW_S3_BUCKET"); bucket != "" {
+		threshold, err := strconv.Atoi(getEnvVar("OVERFLOW_THRESHOLD_BYTES", "0"))
+		if err != nil || threshold < 0 {
+			return nil, fmt.Errorf("OVERFLOW_THRESHOLD_BYTES must be a non-negative integer")
+		}
+		opts = append(opts, repository.WithOverflow(s3.NewFromConfig(cfg), repository.OverflowConfig{
+			Bucket:         bucket,
+			KeyPrefix:      getEnvVar("OVERFLOW_S3_KEY_PREFIX", "overflow/"),
+			ThresholdBytes: threshold,
+		}))
+	}
+
+	// Configure optional client-side encryption of sensitive extendedAttributes
+	if keyID := os.Getenv("ENCRYPTION_KMS_KEY_ID"); keyID != "" {
+		opts = append(opts, repository.WithEncryption(kms.NewFromConfig(cfg), repository.EncryptionConfig{
+			KeyID:      keyID,
+			Attributes: repository.ParseEncryptedAttributes(os.Getenv("ENCRYPTED_ATTRIBUTES")),
+		}))
+	}
 
 	// Create repository
-	repo := repository.NewDynamoDBRepository(dynamoClient, tableName)
+	var repo repository.Repository = repository.NewDynamoDBRepository(dynamoClient, tableName, opts...)
+
+	// Route accounts with data residency requirements to regional tables,
+	// e.g. ACCOUNT_REGIONS=acct-1:eu-west-1 and REGIONAL_TABLES=eu-west-1:locations-eu
+	if accountRegions := os.Getenv("ACCOUNT_REGIONS"); accountRegions != "" {
+		repo, err = newRoutingRepository(cfg, repo, accountRegions, os.Getenv("REGIONAL_TABLES"), regionalOpts)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Create handler, exposing template, custom field, and settings operations when the repository stores them
+	var handlerOpts []handler.Option
+	if store, ok := repo.(repository.TemplateStore); ok {
+		handlerOpts = append(handlerOpts, handler.WithTemplateStore(store))
+	}
+	if store, ok := repo.(repository.CustomFieldStore); ok {
+		handlerOpts = append(handlerOpts, handler.WithCustomFieldStore(store))
+	}
+	if store, ok := repo.(repository.GeofenceStore); ok {
+		handlerOpts = append(handlerOpts, handler.WithGeofenceStore(store))
+		// Publish geofence crossings of position updates, e.g. GEOFENCE_EVENT_BUS=fleet-events
+		if bus := os.Getenv("GEOFENCE_EVENT_BUS"); bus != "" {
+			handlerOpts = append(handlerOpts, handler.WithGeofencePublisher(geofence.NewEventBridgePublisher(cfg, bus)))
+		}
+	}
+	if store, ok := repo.(repository.SettingsStore); ok {
+		// Accounts opt into notices by setting webhookUrl
+		handlerOpts = append(handlerOpts, handler.WithSettingsStore(store), handler.WithNotifier(notify.NewWebhookNotifier(10*time.Second)))
+	}
+	if store, ok := repo.(repository.ProposalStore); ok {
+		handlerOpts = append(handlerOpts, handler.WithProposalStore(store))
+	}
+	if publisher, ok := repo.(repository.DraftPublisher); ok {
+		handlerOpts = append(handlerOpts, handler.WithDraftPublisher(publisher))
+	}
+	if changer, ok := repo.(repository.TypeChanger); ok {
+		handlerOpts = append(handlerOpts, handler.WithTypeChanger(changer))
+	}
+	if deleter, ok := repo.(repository.CascadeDeleter); ok {
+		handlerOpts = append(handlerOpts, handler.WithCascadeDeleter(deleter))
+	}
+	if finder, ok := repo.(repository.WebsiteFinder); ok && os.Getenv("DYNAMODB_WEBSITE_INDEX_NAME") != "" {
+		handlerOpts = append(handlerOpts, handler.WithWebsiteFinder(finder))
+	}
+	if finder, ok := repo.(repository.ContactFinder); ok && os.Getenv("DYNAMODB_CONTACT_INDEX_NAME") != "" {
+		handlerOpts = append(handlerOpts, handler.WithContactFinder(finder))
+	}
+	if syncer, ok := repo.(repository.LocationSyncer); ok && os.Getenv("DYNAMODB_SYNC_INDEX_NAME") != "" {
+		handlerOpts = append(handlerOpts, handler.WithLocationSyncer(syncer))
+	}
+	if reader, ok := repo.(repository.StatsReader); ok {
+		handlerOpts = append(handlerOpts, handler.WithStatsReader(reader))
+	}
+	if reader, ok := repo.(repository.HeatmapReader); ok {
+		handlerOpts = append(handlerOpts, handler.WithHeatmapReader(reader))
+	}
+	if hierarchy, ok := repo.(repository.AccountHierarchy); ok && os.Getenv("DYNAMODB_PARENT_ACCOUNT_INDEX_NAME") != "" {
+		handlerOpts = append(handlerOpts, handler.WithAccountHierarchy(hierarchy))
+	}
+	if finder, ok := repo.(repository.NearbyShopFinder); ok {
+		handlerOpts = append(handlerOpts, handler.WithNearbyShopFinder(finder))
+	}
+	if finder, ok := repo.(repository.NearestShopFinder); ok {
+		handlerOpts = append(handlerOpts, handler.WithNearestShopFinder(finder))
+	}
+	if assigner, ok := repo.(repository.NearestShopAssigner); ok {
+		handlerOpts = append(handlerOpts, handler.WithNearestShopAssigner(assigner))
+	}
+	if finder, ok := repo.(repository.DeliveryZoneFinder); ok {
+		handlerOpts = append(handlerOpts, handler.WithDeliveryZoneFinder(finder))
+	}
+	if checker, ok := repo.(repository.HealthChecker); ok {
+		handlerOpts = append(handlerOpts, handler.WithHealthChecker(checker))
+	}
+	// Problems that leave the function running with a feature unusable are
+	// reported by healthCheck rather than failing startup
+	var configIssues []string
+	// Enable the admin* operations for members of a Cognito group, e.g. ADMIN_GROUP=location-admins
+	if group := os.Getenv("ADMIN_GROUP"); group != "" {
+		if store, ok := repo.(repository.AdminStore); ok {
+			handlerOpts = append(handlerOpts, handler.WithAdminStore(store, group))
+		} else {
+			configIssues = append(configIssues, "ADMIN_GROUP is set but the repository does not support admin operations")
+		}
+		if scanner, ok := repo.(repository.LocationScanner); ok {
+			handlerOpts = append(handlerOpts, handler.WithLocationScanner(scanner))
+		}
+		if os.Getenv("DYNAMODB_LOCATION_ID_INDEX_NAME") == "" {
+			configIssues = append(configIssues, "ADMIN_GROUP is set without DYNAMODB_LOCATION_ID_INDEX_NAME, so adminGetLocationById is unavailable")
+		}
+	}
+	// Limit operations by role, e.g. PERMISSIONS_POLICY={"roles":{"viewer":{"operations":["read"]}}}
+	if data := os.Getenv("PERMISSIONS_POLICY"); data != "" {
+		policy, err := permissions.Parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PERMISSIONS_POLICY: %w", err)
+		}
+		handlerOpts = append(handlerOpts, handler.WithPermissions(policy))
+	}
+	// Let accounts require HMAC-signed mutations by rotating a signing secret, e.g. REQUEST_SIGNING=true
+	if os.Getenv("REQUEST_SIGNING") == "true" {
+		maxAge, err := time.ParseDuration(getEnvVar("REQUEST_SIGNING_MAX_AGE", handler.DefaultSignatureMaxAge.String()))
+		if err != nil || maxAge <= 0 {
+			return nil, fmt.Errorf("invalid REQUEST_SIGNING_MAX_AGE %q: must be a positive duration", os.Getenv("REQUEST_SIGNING_MAX_AGE"))
+		}
+		if store, ok := repo.(repository.SigningSecretStore); ok {
+			handlerOpts = append(handlerOpts, handler.WithRequestSigning(store, maxAge))
+		} else {
+			configIssues = append(configIssues, "REQUEST_SIGNING is set but the repository does not support signing secrets")
+		}
+	}
+	// Count each account's operations for getUsage, e.g. USAGE_METERING=true
+	if os.Getenv("USAGE_METERING") == "true" {
+		if meter, ok := repo.(repository.UsageMeter); ok {
+			handlerOpts = append(handlerOpts, handler.WithUsageMeter(meter))
+		} else {
+			configIssues = append(configIssues, "USAGE_METERING is set but the repository does not support usage metering")
+		}
+	}
+	// Report each operation's consumed capacity as embedded metrics, e.g. CAPACITY_METRICS=true
+	if capacityMetricsEnabled() {
+		handlerOpts = append(handlerOpts, handler.WithCapacityMetrics(os.Stdout, getEnvVar("CAPACITY_METRICS_NAMESPACE", handler.DefaultCapacityNamespace)))
+	}
+	// Let accounts reject repeated request IDs on mutations, e.g. REPLAY_PROTECTION=true
+	if os.Getenv("REPLAY_PROTECTION") == "true" {
+		window, err := time.ParseDuration(getEnvVar("REPLAY_WINDOW", handler.DefaultReplayWindow.String()))
+		if err != nil || window <= 0 {
+			return nil, fmt.Errorf("invalid REPLAY_WINDOW %q: must be a positive duration", os.Getenv("REPLAY_WINDOW"))
+		}
+		_, hasSettings := repo.(repository.SettingsStore)
+		if guard, ok := repo.(repository.ReplayGuard); ok && hasSettings {
+			handlerOpts = append(handlerOpts, handler.WithReplayGuard(guard, window))
+		} else {
+			configIssues = append(configIssues, "REPLAY_PROTECTION is set but the repository does not support replay protection")
+		}
+	}
+	// Keep position updates for stop detection, e.g. POSITION_HISTORY_RETENTION=720h
+	if value := os.Getenv("POSITION_HISTORY_RETENTION"); value != "" {
+		retention, err := time.ParseDuration(value)
+		if err != nil || retention <= 0 {
+			return nil, fmt.Errorf("invalid POSITION_HISTORY_RETENTION %q: must be a positive duration", value)
+		}
+		if history, ok := repo.(repository.PositionHistory); ok {
+			handlerOpts = append(handlerOpts, handler.WithPositionHistory(history, retention))
+		} else {
+			configIssues = append(configIssues, "POSITION_HISTORY_RETENTION is set but the repository does not support position history")
+		}
+	}
+	handlerOpts = append(handlerOpts, handler.WithConfigIssues(configIssues), handler.WithListLimits(listLimits))
+
+	// Compress large list pages for clients that send x-accept-payload-encoding: gzip
+	compressionThreshold, err := strconv.Atoi(getEnvVar("RESPONSE_COMPRESSION_THRESHOLD_BYTES", "1048576"))
+	if err != nil || compressionThreshold < 0 {
+		return nil, fmt.Errorf("RESPONSE_COMPRESSION_THRESHOLD_BYTES must be a non-negative integer")
+	}
+	handlerOpts = append(handlerOpts, handler.WithResponseCompression(compressionThreshold))
+	fastListBudget, err := time.ParseDuration(getEnvVar("LIST_FAST_BUDGET", handler.DefaultFastListBudget.String()))
+	if err != nil || fastListBudget <= 0 {
+		return nil, fmt.Errorf("invalid LIST_FAST_BUDGET %q: must be a positive duration", os.Getenv("LIST_FAST_BUDGET"))
+	}
+	handlerOpts = append(handlerOpts, handler.WithFastListBudget(fastListBudget))
+
+	// Configure optional geocoding with an Amazon Location Service place index
+	if placeIndex := os.Getenv("GEOCODER_PLACE_INDEX"); placeIndex != "" {
+		handlerOpts = append(handlerOpts, handler.WithGeocoder(geocode.NewAmazonLocationGeocoder(cfg, placeIndex)))
+		if writer, ok := repo.(repository.GeocodeWriter); ok {
+			handlerOpts = append(handlerOpts, handler.WithGeocodeWriter(writer))
+		}
+	}
+	// Configure optional address verification, e.g. ADDRESS_VERIFIER=smartystreets
+	if provider := os.Getenv("ADDRESS_VERIFIER"); provider != "" {
+		verifier, err := verify.New(provider, verify.Credentials{
+			ID:     os.Getenv("ADDRESS_VERIFIER_ID"),
+			Secret: os.Getenv("ADDRESS_VERIFIER_SECRET"),
+		}, repository.SystemClock)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ADDRESS_VERIFIER: %w", err)
+		}
+		handlerOpts = append(handlerOpts, handler.WithVerifier(verifier))
+	}
+
+	// Configure optional shop enrichment, e.g. PLACES_PROVIDER=amazon
+	switch provider := os.Getenv("PLACES_PROVIDER"); provider {
+	case "":
+	case "amazon":
+		handlerOpts = append(handlerOpts, handler.WithPlacesProvider(places.NewAmazonPlacesProvider(cfg)))
+	case "google":
+		apiKey := os.Getenv("GOOGLE_PLACES_API_KEY")
+		if apiKey == "" {
+			return nil, fmt.Errorf("GOOGLE_PLACES_API_KEY is required when PLACES_PROVIDER is google")
+		}
+		handlerOpts = append(handlerOpts, handler.WithPlacesProvider(places.NewGooglePlacesProvider(apiKey)))
+	default:
+		return nil, fmt.Errorf("invalid PLACES_PROVIDER %q: must be amazon or google", provider)
+	}
+
+	// Configure optional location context, e.g. WEATHER_PROVIDER=open-meteo
+	switch provider := os.Getenv("WEATHER_PROVIDER"); provider {
+	case "":
+	case "open-meteo":
+		ttl, err := time.ParseDuration(getEnvVar("WEATHER_CACHE_TTL", "10m"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid WEATHER_CACHE_TTL: %w", err)
+		}
+		handlerOpts = append(handlerOpts, handler.WithWeatherProvider(weather.NewCachingProvider(weather.NewOpenMeteoProvider(), ttl)))
+	default:
+		return nil, fmt.Errorf("invalid WEATHER_PROVIDER %q: must be open-meteo", provider)
+	}
+
+	// Configure optional altitude enrichment, e.g. ELEVATION_PROVIDER=open-meteo
+	switch provider := os.Getenv("ELEVATION_PROVIDER"); provider {
+	case "":
+	case "open-meteo":
+		ttl, err := time.ParseDuration(getEnvVar("ELEVATION_CACHE_TTL", "24h"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid ELEVATION_CACHE_TTL: %w", err)
+		}
+		handlerOpts = append(handlerOpts, handler.WithElevationProvider(elevation.NewCachingProvider(elevation.NewOpenMeteoProvider(), ttl)))
+	default:
+		return nil, fmt.Errorf("invalid ELEVATION_PROVIDER %q: must be open-meteo", provider)
+	}
+
+	// Configure optional ETAs to stored locations, e.g. ROUTING_PROVIDER=amazon
+	switch provider := os.Getenv("ROUTING_PROVIDER"); provider {
+	case "":
+	case "amazon":
+		ttl, err := time.ParseDuration(getEnvVar("ROUTING_CACHE_TTL", "5m"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid ROUTING_CACHE_TTL: %w", err)
+		}
+		handlerOpts = append(handlerOpts, handler.WithRoutingProvider(routing.NewCachingProvider(routing.NewAmazonRoutesProvider(cfg), ttl)))
+	default:
+		return nil, fmt.Errorf("invalid ROUTING_PROVIDER %q: must be amazon", provider)
+	}
+
+	// Configure optional map thumbnails, e.g. STATIC_MAP_PROVIDER=amazon
+	switch provider := os.Getenv("STATIC_MAP_PROVIDER"); provider {
+	case "":
+	case "amazon":
+		expiry, err := time.ParseDuration(getEnvVar("STATIC_MAP_URL_EXPIRY", "1h"))
+		if err != nil || expiry <= 0 {
+			return nil, fmt.Errorf("invalid STATIC_MAP_URL_EXPIRY %q: must be a positive duration", os.Getenv("STATIC_MAP_URL_EXPIRY"))
+		}
+		handlerOpts = append(handlerOpts, handler.WithStaticMapProvider(staticmap.NewAmazonProvider(cfg, expiry)))
+	default:
+		return nil, fmt.Errorf("invalid STATIC_MAP_PROVIDER %q: must be amazon", provider)
+	}
+
+	// Configure optional shop asset URLs, served by a CDN when one fronts the bucket
+	if baseURL := os.Getenv("ASSET_CDN_BASE_URL"); baseURL != "" {
+		resolver, err := assets.NewCDNResolver(baseURL)
+		if err != nil {
+			return nil, err
+		}
+		handlerOpts = append(handlerOpts, handler.WithAssetResolver(resolver))
+	} else if bucket := os.Getenv("ASSET_S3_BUCKET"); bucket != "" {
+		expiry, err := time.ParseDuration(getEnvVar("ASSET_URL_EXPIRY", "1h"))
+		if err != nil || expiry <= 0 {
+			return nil, fmt.Errorf("invalid ASSET_URL_EXPIRY %q: must be a positive duration", os.Getenv("ASSET_URL_EXPIRY"))
+		}
+		presigner := s3.NewPresignClient(s3.NewFromConfig(cfg))
+		handlerOpts = append(handlerOpts, handler.WithAssetResolver(assets.NewS3Resolver(presigner, bucket, expiry)))
+	}
+
+	return handler.NewAppSyncHandler(repo, handlerOpts...), nil
+}
+
+// capacityMetricsEnabled reports whether DynamoDB calls return, and the
+// handler reports, their consumed capacity.
+func capacityMetricsEnabled() bool {
+	return os.Getenv("CAPACITY_METRICS") == "true"
+}
+
+// newRoutingRepository wraps the home repository with per-region repositories.
+// Regional tables do not use the home region's overflow bucket or KMS key.
+func newRoutingRepository(cfg aws.Config, home repository.Repository, accountRegions, regionalTables string, opts []repository.Option) (repository.Repository, error) {
+	accounts, err := repository.ParseRegionMap(accountRegions)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ACCOUNT_REGIONS: %w", err)
+	}
+	tables, err := repository.ParseRegionMap(regionalTables)
+	if err != nil {
+		return nil, fmt.Errorf("invalid REGIONAL_TABLES: %w", err)
+	}
 
-	// Create handler
-	return handler.NewAppSyncHandler(repo), nil
+	regional := make(map[string]repository.Repository, len(tables))
+	for region, table := range tables {
+		var client repository.DynamoDBClient = dynamodb.NewFromConfig(cfg, func(o *dynamodb.Options) {
+			o.Region = region
+		})
+		if capacityMetricsEnabled() {
+			client = repository.NewCapacityClient(client)
+		}
+		regional[region] = repository.NewDynamoDBRepository(client, table, opts...)
+	}
+
+	for account, region := range accounts {
+		if _, ok := regional[region]; !ok {
+			return nil, fmt.Errorf("account %s is mapped to region %s, which has no entry in REGIONAL_TABLES", account, region)
+		}
+	}
+
+	return repository.NewRoutingRepository(home, regional, accounts), nil
+}
+
+// newHandler returns the handler of each invocation; tests replace it to
+// replay events against an in-memory repository.
+var newHandler = reuseHandler(initializeHandler)
+
+// reuseHandler builds the handler on the first invocation and returns it to
+// every later one in the same container, so warm invocations share its AWS
+// clients and its providers' caches. A failed build is retried on the next
+// invocation rather than remembered.
+func reuseHandler(build func(context.Context) (*handler.AppSyncHandler, error)) func(context.Context) (*handler.AppSyncHandler, error) {
+	var mu sync.Mutex
+	var built *handler.AppSyncHandler
+	return func(ctx context.Context) (*handler.AppSyncHandler, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if built != nil {
+			return built, nil
+		}
+		h, err := build(ctx)
+		if err != nil {
+			return nil, err
+		}
+		built = h
+		return built, nil
+	}
 }
 
-// lambdaHandler handles the Lambda invocation.
-func lambdaHandler(ctx context.Context, event handler.AppSyncEvent) (interface{}, error) {
+// lambdaHandler handles the Lambda invocation: an AppSync or EventBridge
+// field, a batch of AppSync fields, or a batch from the table's DynamoDB
+// stream to export or publish.
+func lambdaHandler(ctx context.Context, payload json.RawMessage) (interface{}, error) {
+	if stream, ok := streamEvent(payload); ok {
+		return nil, processStream(ctx, stream)
+	}
+	if batch, ok := batchEvents(payload); ok {
+		return handleBatch(ctx, batch)
+	}
+
+	var event handler.AppSyncEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal event: %w", err)
+	}
+
 	// Initialize handler
-	h, err := initializeHandler(ctx)
+	h, err := newHandler(ctx)
 	if err != nil {
 		log.Printf("ERROR: Failed to initialize handler: %v", err)
 		return nil, fmt.Errorf("initialization error: %w", err)
@@ -62,14 +549,254 @@ func lambdaHandler(ctx context.Context, event handler.AppSyncEvent) (interface{}
 	result, err := h.Handle(ctx, event)
 	if err != nil {
 		log.Printf("ERROR: Failed to handle event: %v", err)
-		return nil, err
+		if failure, ok := validationFailure(err); ok && os.Getenv("VALIDATION_ERROR_INFO") == "true" {
+			return failure, nil
+		}
+		return nil, invokeError(err)
 	}
 
 	log.Printf("INFO: Successfully processed event")
 	return result, nil
 }
 
+// batchResult is the result of one event of a batch invocation. AppSync
+// matches results to events by position, so a failed event takes its place
+// in the list with its error rather than failing the batch; the resolver's
+// response mapping template raises the error with $util.error.
+type batchResult struct {
+	Data         interface{} `json:"data"`
+	ErrorMessage string      `json:"errorMessage,omitempty"`
+	ErrorType    string      `json:"errorType,omitempty"`
+	// ErrorInfo lists a validation failure's invalid fields
+	ErrorInfo interface{} `json:"errorInfo,omitempty"`
+}
+
+// batchEvents decodes the events of a batch invocation, which AppSync sends
+// as a JSON array for resolvers with batching enabled, reporting false for
+// any other payload.
+func batchEvents(payload json.RawMessage) ([]handler.AppSyncEvent, bool) {
+	trimmed := bytes.TrimSpace(payload)
+	if len(trimmed) == 0 || trimmed[0] != '[' {
+		return nil, false
+	}
+	var batch []handler.AppSyncEvent
+	if err := json.Unmarshal(trimmed, &batch); err != nil {
+		return nil, false
+	}
+	return batch, true
+}
+
+// handleBatch handles each event of a batch invocation in turn, returning
+// one result per event in the same order.
+func handleBatch(ctx context.Context, batch []handler.AppSyncEvent) ([]batchResult, error) {
+	h, err := newHandler(ctx)
+	if err != nil {
+		log.Printf("ERROR: Failed to initialize handler: %v", err)
+		return nil, fmt.Errorf("initialization error: %w", err)
+	}
+
+	log.Printf("INFO: Processing AppSync batch of %d events", len(batch))
+	results := make([]batchResult, len(batch))
+	for i, event := range batch {
+		result, err := h.Handle(ctx, event)
+		if err != nil {
+			log.Printf("ERROR: Failed to handle event %d of batch (%s): %v", i, event.Field, err)
+			results[i] = batchResult{ErrorMessage: err.Error()}
+			if failure, ok := validationFailure(err); ok {
+				results[i].ErrorType = failure.ErrorType
+				results[i].ErrorInfo = failure.ErrorInfo
+			}
+			continue
+		}
+		results[i] = batchResult{Data: result}
+	}
+	return results, nil
+}
+
+// validationFailureResult is a validation failure returned as the result of
+// the invocation, for a response mapping template to raise with $util.error,
+// since AppSync passes a failed invocation's error type and message on but
+// not its errorInfo.
+type validationFailureResult struct {
+	ErrorMessage string `json:"errorMessage"`
+	ErrorType    string `json:"errorType"`
+	ErrorInfo    struct {
+		// Fields lists each invalid field's path, such as
+		// shop.contacts[1].email, and what is wrong with it
+		Fields models.ValidationErrors `json:"fields"`
+	} `json:"errorInfo"`
+}
+
+// validationFailure reports whether err is a validation failure, whatever
+// wraps it, and describes it.
+func validationFailure(err error) (*validationFailureResult, bool) {
+	var validationErrs models.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return nil, false
+	}
+	failure := &validationFailureResult{ErrorMessage: err.Error(), ErrorType: "ValidationError"}
+	failure.ErrorInfo.Fields = validationErrs
+	return failure, true
+}
+
+// invokeError gives validation failures the ValidationError error type, so
+// clients can tell them from other failures whatever wraps them.
+func invokeError(err error) error {
+	if failure, ok := validationFailure(err); ok {
+		return messages.InvokeResponse_Error{Message: failure.ErrorMessage, Type: failure.ErrorType}
+	}
+	return err
+}
+
+// streamEvent decodes a DynamoDB stream batch, reporting false for any other payload.
+func streamEvent(payload json.RawMessage) (events.DynamoDBEvent, bool) {
+	var stream events.DynamoDBEvent
+	if err := json.Unmarshal(payload, &stream); err != nil || len(stream.Records) == 0 || stream.Records[0].EventSource != "aws:dynamodb" {
+		return events.DynamoDBEvent{}, false
+	}
+	return stream, true
+}
+
+// processStream sends a stream batch to each configured sink: the
+// CHANGE_EXPORT_BUCKET data lake and the IOT_DATA_ENDPOINT topics. An error
+// makes Lambda retry the batch, whose objects are then overwritten and whose
+// messages are published again.
+func processStream(ctx context.Context, stream events.DynamoDBEvent) error {
+	bucket := os.Getenv("CHANGE_EXPORT_BUCKET")
+	iotEndpoint := os.Getenv("IOT_DATA_ENDPOINT")
+	if bucket == "" && iotEndpoint == "" {
+		return fmt.Errorf("received a DynamoDB stream batch but neither CHANGE_EXPORT_BUCKET nor IOT_DATA_ENDPOINT is set")
+	}
+	cfg, err := awsconfig.LoadDefaultConfig(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	if bucket != "" {
+		if err := exportChanges(ctx, cfg, bucket, stream); err != nil {
+			return err
+		}
+	}
+	if iotEndpoint != "" {
+		if err := publishChanges(ctx, cfg, iotEndpoint, stream); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exportChanges writes a stream batch to the data lake in bucket.
+func exportChanges(ctx context.Context, cfg aws.Config, bucket string, stream events.DynamoDBEvent) error {
+	format, err := changeexport.ParseFormat(os.Getenv("CHANGE_EXPORT_FORMAT"))
+	if err != nil {
+		return fmt.Errorf("invalid CHANGE_EXPORT_FORMAT: %w", err)
+	}
+
+	exporter := changeexport.NewExporter(s3.NewFromConfig(cfg), bucket, getEnvVar("CHANGE_EXPORT_PREFIX", "changes/"), format)
+	exported, err := exporter.Export(ctx, stream)
+	if err != nil {
+		log.Printf("ERROR: Failed to export changes: %v", err)
+		return err
+	}
+	log.Printf("INFO: Exported %d of %d stream records", exported, len(stream.Records))
+	return nil
+}
+
+// publishChanges republishes a stream batch's location changes to each
+// account's IoT Core topic, for devices that can't hold a GraphQL
+// subscription.
+func publishChanges(ctx context.Context, cfg aws.Config, endpoint string, stream events.DynamoDBEvent) error {
+	changes, err := changeexport.Changes(stream)
+	if err != nil {
+		log.Printf("ERROR: Failed to read changes: %v", err)
+		return err
+	}
+
+	publisher := iotpush.NewPublisher(cfg, endpoint, getEnvVar("IOT_TOPIC_PREFIX", iotpush.DefaultTopicPrefix))
+	published, err := publisher.Publish(ctx, changes)
+	if err != nil {
+		log.Printf("ERROR: Failed to publish changes after %d of %d: %v", published, len(changes), err)
+		return err
+	}
+	log.Printf("INFO: Published %d of %d stream records to IoT Core", published, len(stream.Records))
+	return nil
+}
+
+// tableSpec describes the table the handler is configured for by its
+// environment, with the same defaults it runs with.
+func tableSpec(getenv func(string) string) (repository.TableSpec, error) {
+	spec := repository.TableSpec{
+		TableName:          getenv("DYNAMODB_TABLE_NAME"),
+		AccountIndex:       getenv("DYNAMODB_GSI_NAME"),
+		ExternalIDIndex:    getenv("DYNAMODB_EXTERNAL_ID_INDEX_NAME"),
+		LocationIDIndex:    getenv("DYNAMODB_LOCATION_ID_INDEX_NAME"),
+		WebsiteIndex:       getenv("DYNAMODB_WEBSITE_INDEX_NAME"),
+		ContactIndex:       getenv("DYNAMODB_CONTACT_INDEX_NAME"),
+		ParentAccountIndex: getenv("DYNAMODB_PARENT_ACCOUNT_INDEX_NAME"),
+		SyncIndex:          getenv("DYNAMODB_SYNC_INDEX_NAME"),
+		ShardIndex:         getenv("DYNAMODB_SHARD_INDEX_NAME"),
+		Stream:             getenv("CHANGE_EXPORT_BUCKET") != "" || getenv("IOT_DATA_ENDPOINT") != "",
+	}
+	if spec.TableName == "" {
+		return spec, fmt.Errorf("DYNAMODB_TABLE_NAME environment variable is required")
+	}
+	if spec.AccountIndex == "" {
+		spec.AccountIndex = "AccountIndex"
+	}
+	if spec.ShardIndex == "" {
+		spec.ShardIndex = "AccountShardIndex"
+	}
+	if value := getenv("DYNAMODB_SHARD_COUNT"); value != "" {
+		shardCount, err := strconv.Atoi(value)
+		if err != nil || shardCount < 0 {
+			return spec, fmt.Errorf("DYNAMODB_SHARD_COUNT must be a non-negative integer")
+		}
+		spec.ShardCount = shardCount
+	}
+	return spec, nil
+}
+
+// bootstrapTable creates the configured table, its indexes, and its TTL for
+// a local or ephemeral environment without the Terraform stack. It refuses
+// when ENVIRONMENT or the table name marks it as production.
+func bootstrapTable(ctx context.Context) error {
+	spec, err := tableSpec(os.Getenv)
+	if err != nil {
+		return err
+	}
+	if err := repository.CheckNonProduction(os.Getenv("ENVIRONMENT"), spec.TableName); err != nil {
+		return err
+	}
+	cfg, err := awsconfig.LoadDefaultConfig(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	result, err := repository.BootstrapTable(ctx, dynamodb.NewFromConfig(cfg), spec)
+	if err != nil {
+		return err
+	}
+	if result.Created {
+		log.Printf("INFO: Created table %s", spec.TableName)
+	} else {
+		log.Printf("INFO: Table %s already exists; left its indexes unchanged", spec.TableName)
+	}
+	if result.TTLEnabled {
+		log.Printf("INFO: Enabled expiresAt TTL on table %s", spec.TableName)
+	}
+	return nil
+}
+
 func main() {
+	bootstrap := flag.Bool("bootstrap", false, "create the configured DynamoDB table, indexes, and TTL, then exit (non-production only)")
+	flag.Parse()
+	if *bootstrap {
+		if err := bootstrapTable(context.Background()); err != nil {
+			log.Fatalf("ERROR: Failed to bootstrap table: %v", err)
+		}
+		return
+	}
+
 	// Start the Lambda handler
 	lambda.Start(lambdaHandler)
 }
@@ -2,9 +2,17 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"testing"
 
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda/messages"
+	"github.com/steverhoton/location-lambda/internal/handler"
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/repository"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -85,3 +93,198 @@ func TestInitializeHandler(t *testing.T) {
 		}
 	})
 }
+
+func TestReuseHandler(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Builds once per container", func(t *testing.T) {
+		builds := 0
+		get := reuseHandler(func(context.Context) (*handler.AppSyncHandler, error) {
+			builds++
+			return handler.NewAppSyncHandler(&replayRepository{}), nil
+		})
+
+		first, err := get(ctx)
+		require.NoError(t, err)
+		second, err := get(ctx)
+		require.NoError(t, err)
+		assert.Same(t, first, second)
+		assert.Equal(t, 1, builds)
+	})
+
+	t.Run("Retries a failed build", func(t *testing.T) {
+		builds := 0
+		get := reuseHandler(func(context.Context) (*handler.AppSyncHandler, error) {
+			builds++
+			if builds == 1 {
+				return nil, errors.New("throttled")
+			}
+			return handler.NewAppSyncHandler(&replayRepository{}), nil
+		})
+
+		_, err := get(ctx)
+		assert.EqualError(t, err, "throttled")
+		h, err := get(ctx)
+		require.NoError(t, err)
+		assert.NotNil(t, h)
+		assert.Equal(t, 2, builds)
+	})
+
+	// Each provider's cache lives in the handler, so a configured container
+	// keeps one handler across invocations
+	tests := []struct {
+		name string
+		env  map[string]string
+	}{
+		{name: "Weather cache", env: map[string]string{"WEATHER_PROVIDER": "open-meteo"}},
+		{name: "Elevation cache", env: map[string]string{"ELEVATION_PROVIDER": "open-meteo"}},
+		{name: "Routing cache", env: map[string]string{"ROUTING_PROVIDER": "amazon"}},
+		{name: "USPS access token", env: map[string]string{"ADDRESS_VERIFIER": "usps", "ADDRESS_VERIFIER_ID": "client-id", "ADDRESS_VERIFIER_SECRET": "client-secret"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("DYNAMODB_TABLE_NAME", "test-table")
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			get := reuseHandler(initializeHandler)
+
+			first, err := get(ctx)
+			if err != nil {
+				// Without AWS configuration the handler cannot be built at all
+				assert.Contains(t, err.Error(), "failed to load AWS config")
+				return
+			}
+			second, err := get(ctx)
+			require.NoError(t, err)
+			assert.Same(t, first, second)
+		})
+	}
+}
+
+func TestStreamEvent(t *testing.T) {
+	t.Run("DynamoDB stream batch", func(t *testing.T) {
+		stream, ok := streamEvent(json.RawMessage(`{"Records": [{"eventID": "evt-1", "eventName": "INSERT", "eventSource": "aws:dynamodb", "dynamodb": {"SequenceNumber": "100"}}]}`))
+		require.True(t, ok)
+		assert.Equal(t, "evt-1", stream.Records[0].EventID)
+	})
+
+	t.Run("AppSync event", func(t *testing.T) {
+		_, ok := streamEvent(json.RawMessage(`{"field": "getLocation", "arguments": {"accountId": "acc-12345"}}`))
+		assert.False(t, ok)
+	})
+
+	t.Run("Other event sources", func(t *testing.T) {
+		_, ok := streamEvent(json.RawMessage(`{"Records": [{"eventSource": "aws:sqs"}]}`))
+		assert.False(t, ok)
+	})
+}
+
+func TestBatchEvents(t *testing.T) {
+	t.Run("AppSync batch", func(t *testing.T) {
+		batch, ok := batchEvents(json.RawMessage(` [{"info": {"fieldName": "getLocation"}}, {"field": "listLocations"}]`))
+		require.True(t, ok)
+		require.Len(t, batch, 2)
+		assert.Equal(t, "getLocation", batch[0].Field)
+		assert.Equal(t, "listLocations", batch[1].Field)
+	})
+
+	t.Run("Empty batch", func(t *testing.T) {
+		batch, ok := batchEvents(json.RawMessage(`[]`))
+		require.True(t, ok)
+		assert.Empty(t, batch)
+	})
+
+	t.Run("Single event", func(t *testing.T) {
+		_, ok := batchEvents(json.RawMessage(`{"field": "getLocation"}`))
+		assert.False(t, ok)
+	})
+
+	t.Run("Array of other values", func(t *testing.T) {
+		_, ok := batchEvents(json.RawMessage(`["getLocation"]`))
+		assert.False(t, ok)
+	})
+}
+
+func TestProcessStreamRequiresASink(t *testing.T) {
+	t.Setenv("CHANGE_EXPORT_BUCKET", "")
+	t.Setenv("IOT_DATA_ENDPOINT", "")
+
+	err := processStream(context.Background(), events.DynamoDBEvent{Records: []events.DynamoDBEventRecord{{EventSource: "aws:dynamodb"}}})
+	assert.EqualError(t, err, "received a DynamoDB stream batch but neither CHANGE_EXPORT_BUCKET nor IOT_DATA_ENDPOINT is set")
+}
+
+func TestTableSpec(t *testing.T) {
+	t.Run("Reads the configured indexes with the handler's defaults", func(t *testing.T) {
+		env := map[string]string{
+			"DYNAMODB_TABLE_NAME":             "location-dev-locations",
+			"DYNAMODB_EXTERNAL_ID_INDEX_NAME": "ExternalIdIndex",
+			"DYNAMODB_SYNC_INDEX_NAME":        "SyncIndex",
+			"DYNAMODB_SHARD_COUNT":            "4",
+			"IOT_DATA_ENDPOINT":               "abc123-ats.iot.us-east-1.amazonaws.com",
+		}
+		spec, err := tableSpec(func(key string) string { return env[key] })
+		require.NoError(t, err)
+		assert.Equal(t, repository.TableSpec{
+			TableName:       "location-dev-locations",
+			AccountIndex:    "AccountIndex",
+			ExternalIDIndex: "ExternalIdIndex",
+			SyncIndex:       "SyncIndex",
+			ShardIndex:      "AccountShardIndex",
+			ShardCount:      4,
+			Stream:          true,
+		}, spec)
+	})
+
+	t.Run("Requires a table name", func(t *testing.T) {
+		_, err := tableSpec(func(string) string { return "" })
+		assert.EqualError(t, err, "DYNAMODB_TABLE_NAME environment variable is required")
+	})
+
+	t.Run("Rejects a bad shard count", func(t *testing.T) {
+		env := map[string]string{"DYNAMODB_TABLE_NAME": "locations", "DYNAMODB_SHARD_COUNT": "-1"}
+		_, err := tableSpec(func(key string) string { return env[key] })
+		assert.EqualError(t, err, "DYNAMODB_SHARD_COUNT must be a non-negative integer")
+	})
+}
+
+func TestInvokeError(t *testing.T) {
+	t.Run("Validation failure", func(t *testing.T) {
+		err := invokeError(fmt.Errorf("validation failed: %w", models.ValidationErrors{
+			{Field: "accountId", Message: "accountId is required"},
+			{Field: "address.city", Message: "city is required"},
+		}))
+		assert.Equal(t, messages.InvokeResponse_Error{
+			Message: "validation failed: accountId is required; address: city is required",
+			Type:    "ValidationError",
+		}, err)
+	})
+
+	t.Run("Other failures", func(t *testing.T) {
+		err := errors.New("failed to get location: throttled")
+		assert.Equal(t, err, invokeError(err))
+	})
+}
+
+func TestValidationFailure(t *testing.T) {
+	t.Run("Validation failure", func(t *testing.T) {
+		failure, ok := validationFailure(fmt.Errorf("validation failed: %w", models.ValidationErrors{
+			{Field: "shop.contacts[1].role", Message: `role must be one of manager, billing, or emergency, got "owner"`},
+		}))
+		require.True(t, ok)
+
+		body, err := json.Marshal(failure)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{
+			"errorMessage": "validation failed: shop.contacts[1]: role must be one of manager, billing, or emergency, got \"owner\"",
+			"errorType": "ValidationError",
+			"errorInfo": {"fields": [{"field": "shop.contacts[1].role", "message": "role must be one of manager, billing, or emergency, got \"owner\""}]}
+		}`, string(body))
+	})
+
+	t.Run("Other failures", func(t *testing.T) {
+		_, ok := validationFailure(errors.New("failed to get location: throttled"))
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,485 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/steverhoton/location-lambda/internal/geocode"
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/repository"
+)
+
+// maxImportLineBytes bounds one exported record, which can carry large extendedAttributes.
+const maxImportLineBytes = 4 << 20
+
+// app holds state shared by locctl's commands.
+type app struct {
+	configPath string
+	profile    string
+	// newRepository connects to a profile's table; tests replace it with a fake
+	newRepository func(ctx context.Context, profile Profile) (repository.Repository, error)
+	// newGeocoder builds a profile's geocoder; tests replace it with a fake
+	newGeocoder func(ctx context.Context, profile Profile) (geocode.Geocoder, error)
+}
+
+// importedLocation is one line of an export file, written from a
+// repository.LocationEnvelope, as read back for import.
+type importedLocation struct {
+	LocationID string          `json:"locationId"`
+	Location   json.RawMessage `json:"location"`
+}
+
+// newRootCommand builds the locctl command tree.
+func newRootCommand(a *app) *cobra.Command {
+	root := &cobra.Command{
+		Use:          "locctl",
+		Short:        "Inspect and repair location records",
+		SilenceUsage: true,
+	}
+	root.PersistentFlags().StringVar(&a.configPath, "config", defaultConfigPath(), "config file ($LOCCTL_CONFIG)")
+	root.PersistentFlags().StringVarP(&a.profile, "profile", "p", "", "profile to use ($LOCCTL_PROFILE, or the config's defaultProfile)")
+
+	root.AddCommand(
+		a.getCommand(),
+		a.listCommand(),
+		a.createCommand(),
+		a.deleteCommand(),
+		a.checkReferencesCommand(),
+		a.migrateShopsCommand(),
+		a.geocodeBackfillCommand(),
+		a.exportCommand(),
+		a.importCommand(),
+	)
+	return root
+}
+
+// repository resolves the selected profile and connects to its table.
+func (a *app) repository(ctx context.Context) (repository.Repository, error) {
+	profile, err := a.selectedProfile()
+	if err != nil {
+		return nil, err
+	}
+	return a.newRepository(ctx, profile)
+}
+
+// geocoder resolves the selected profile and builds its geocoder.
+func (a *app) geocoder(ctx context.Context) (geocode.Geocoder, error) {
+	profile, err := a.selectedProfile()
+	if err != nil {
+		return nil, err
+	}
+	return a.newGeocoder(ctx, profile)
+}
+
+// selectedProfile loads the config file and resolves the selected profile.
+func (a *app) selectedProfile() (Profile, error) {
+	cfg, err := loadConfig(a.configPath)
+	if err != nil {
+		return Profile{}, err
+	}
+	return cfg.resolve(a.profile)
+}
+
+func (a *app) getCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "get ACCOUNT_ID LOCATION_ID",
+		Short: "Print a location as JSON",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			repo, err := a.repository(cmd.Context())
+			if err != nil {
+				return err
+			}
+			envelope, err := repo.Get(cmd.Context(), args[0], args[1])
+			if err != nil {
+				return err
+			}
+			return writeJSON(cmd.OutOrStdout(), envelope, true)
+		},
+	}
+}
+
+func (a *app) listCommand() *cobra.Command {
+	var limit int32
+	var cursor string
+	cmd := &cobra.Command{
+		Use:   "list ACCOUNT_ID",
+		Short: "Print one page of an account's locations, one JSON object per line",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			repo, err := a.repository(cmd.Context())
+			if err != nil {
+				return err
+			}
+			options := &repository.ListOptions{Limit: &limit}
+			if cursor != "" {
+				options.Cursor = &cursor
+			}
+			result, err := repo.List(cmd.Context(), args[0], options)
+			if err != nil {
+				return err
+			}
+			if err := writeLocations(cmd.OutOrStdout(), result); err != nil {
+				return err
+			}
+			if result.NextCursor != nil {
+				fmt.Fprintf(cmd.ErrOrStderr(), "next cursor: %s\n", *result.NextCursor)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().Int32Var(&limit, "limit", 20, "page size")
+	cmd.Flags().StringVar(&cursor, "cursor", "", "cursor printed by the previous page")
+	return cmd
+}
+
+func (a *app) createCommand() *cobra.Command {
+	var file string
+	cmd := &cobra.Command{
+		Use:   "create ACCOUNT_ID",
+		Short: "Create a location from a JSON document and print its ID",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, err := readInput(cmd, file)
+			if err != nil {
+				return err
+			}
+			location, err := locationForAccount(data, args[0])
+			if err != nil {
+				return err
+			}
+			repo, err := a.repository(cmd.Context())
+			if err != nil {
+				return err
+			}
+			created, err := repo.Create(cmd.Context(), location)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), created.LocationID)
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&file, "file", "f", "-", "JSON document to create, or - for stdin")
+	return cmd
+}
+
+func (a *app) deleteCommand() *cobra.Command {
+	var confirmed bool
+	cmd := &cobra.Command{
+		Use:   "delete ACCOUNT_ID LOCATION_ID",
+		Short: "Delete a location",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !confirmed {
+				return fmt.Errorf("refusing to delete location %s without --yes", args[1])
+			}
+			repo, err := a.repository(cmd.Context())
+			if err != nil {
+				return err
+			}
+			if err := repo.Delete(cmd.Context(), args[0], args[1]); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "deleted %s\n", args[1])
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&confirmed, "yes", false, "confirm the deletion")
+	return cmd
+}
+
+func (a *app) checkReferencesCommand() *cobra.Command {
+	var repair bool
+	cmd := &cobra.Command{
+		Use:   "check-references ACCOUNT_ID",
+		Short: "Report merge tombstones and external ID claims pointing at missing locations",
+		Long: "Report merge tombstones and external ID claims pointing at missing locations. " +
+			"With --repair, such tombstones are deleted and such claims released. " +
+			"Exits non-zero while unrepaired references remain, so it can run as a scheduled check.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			repo, err := a.repository(cmd.Context())
+			if err != nil {
+				return err
+			}
+			checker, ok := repo.(repository.ReferenceChecker)
+			if !ok {
+				return fmt.Errorf("the repository does not support reference checks")
+			}
+			report, err := checker.CheckReferences(cmd.Context(), args[0], repair)
+			if err != nil {
+				return err
+			}
+			if err := writeJSON(cmd.OutOrStdout(), report, true); err != nil {
+				return err
+			}
+			if len(report.Dangling) > 0 && !repair {
+				return fmt.Errorf("found %d dangling references; rerun with --repair to fix them", len(report.Dangling))
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&repair, "repair", false, "delete or release the dangling records")
+	return cmd
+}
+
+func (a *app) migrateShopsCommand() *cobra.Command {
+	var dryRun bool
+	cmd := &cobra.Command{
+		Use:   "migrate-shops ACCOUNT_ID",
+		Short: "Rewrite shops stored with a flat address into the nested shape",
+		Long: "Rewrite shops stored with a flat address into the nested shape, printing each migrated location ID. " +
+			"Flat shops read correctly either way; migrating them keeps the stored items uniform. " +
+			"With --dry-run, the shops are listed without being rewritten.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			repo, err := a.repository(cmd.Context())
+			if err != nil {
+				return err
+			}
+			migrator, ok := repo.(repository.ShopMigrator)
+			if !ok {
+				return fmt.Errorf("the repository does not support shop migrations")
+			}
+			migrated, err := migrator.MigrateShopAddresses(cmd.Context(), args[0], dryRun)
+			for _, id := range migrated {
+				fmt.Fprintln(cmd.OutOrStdout(), id)
+			}
+			if err != nil {
+				return err
+			}
+			verb := "migrated"
+			if dryRun {
+				verb = "would migrate"
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "%s %d shops\n", verb, len(migrated))
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "list the shops without rewriting them")
+	return cmd
+}
+
+func (a *app) geocodeBackfillCommand() *cobra.Command {
+	var options geocode.BackfillOptions
+	cmd := &cobra.Command{
+		Use:   "geocode-backfill ACCOUNT_ID",
+		Short: "Geocode address locations stored without coordinates",
+		Long: "Geocode an account's address locations that have no coordinates with the profile's geocoderPlaceIndex, " +
+			"storing each match with its provider and time, and print a JSON report of the geocoded, unmatched, and skipped location IDs. " +
+			"Requests are spread out to --rate per second. Rerunning after a failure picks up the locations still missing coordinates. " +
+			"With --dry-run, the locations are listed without being geocoded.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			repo, err := a.repository(cmd.Context())
+			if err != nil {
+				return err
+			}
+			store, ok := repo.(geocode.BackfillStore)
+			if !ok {
+				return fmt.Errorf("the repository does not support geocode backfills")
+			}
+			var geocoder geocode.Geocoder
+			if !options.DryRun {
+				if geocoder, err = a.geocoder(cmd.Context()); err != nil {
+					return err
+				}
+			}
+
+			result, runErr := geocode.NewBackfiller(geocoder, store).Run(cmd.Context(), args[0], options)
+			if result != nil {
+				if err := writeJSON(cmd.OutOrStdout(), result, true); err != nil {
+					return err
+				}
+			}
+			return runErr
+		},
+	}
+	cmd.Flags().Int32Var(&options.BatchSize, "batch-size", 25, "locations listed per batch")
+	cmd.Flags().Float64Var(&options.Rate, "rate", 5, "geocode requests per second, or 0 for no limit")
+	cmd.Flags().BoolVar(&options.DryRun, "dry-run", false, "list the locations without geocoding them")
+	return cmd
+}
+
+func (a *app) exportCommand() *cobra.Command {
+	var output string
+	cmd := &cobra.Command{
+		Use:   "export ACCOUNT_ID",
+		Short: "Write all of an account's locations as JSON lines",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			repo, err := a.repository(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			if output != "-" {
+				f, err := os.Create(output)
+				if err != nil {
+					return fmt.Errorf("failed to create %s: %w", output, err)
+				}
+				defer f.Close()
+				out = f
+			}
+
+			count := 0
+			options := &repository.ListOptions{}
+			for {
+				result, err := repo.List(cmd.Context(), args[0], options)
+				if err != nil {
+					return err
+				}
+				if err := writeLocations(out, result); err != nil {
+					return err
+				}
+				count += len(result.Items)
+				if result.NextCursor == nil {
+					break
+				}
+				options.Cursor = result.NextCursor
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "exported %d locations\n", count)
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&output, "output", "o", "-", "file to write, or - for stdout")
+	return cmd
+}
+
+func (a *app) importCommand() *cobra.Command {
+	var file string
+	var dryRun bool
+	cmd := &cobra.Command{
+		Use:   "import ACCOUNT_ID",
+		Short: "Create an account's locations from an export file",
+		Long: "Create an account's locations from an export file. Locations get new IDs; " +
+			"those with an externalId are upserted, so re-running an import does not duplicate them.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			in := cmd.InOrStdin()
+			if file != "-" {
+				f, err := os.Open(file)
+				if err != nil {
+					return fmt.Errorf("failed to open %s: %w", file, err)
+				}
+				defer f.Close()
+				in = f
+			}
+
+			var repo repository.Repository
+			if !dryRun {
+				var err error
+				if repo, err = a.repository(cmd.Context()); err != nil {
+					return err
+				}
+			}
+
+			scanner := bufio.NewScanner(in)
+			scanner.Buffer(make([]byte, 64*1024), maxImportLineBytes)
+			count := 0
+			for line := 1; scanner.Scan(); line++ {
+				if len(scanner.Bytes()) == 0 {
+					continue
+				}
+				var record importedLocation
+				if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+					return fmt.Errorf("line %d: %w", line, err)
+				}
+				location, err := locationForAccount(record.Location, args[0])
+				if err != nil {
+					return fmt.Errorf("line %d: %w", line, err)
+				}
+				if !dryRun {
+					if err := importLocation(cmd.Context(), repo, location); err != nil {
+						return fmt.Errorf("line %d (location %s): %w", line, record.LocationID, err)
+					}
+				}
+				count++
+			}
+			if err := scanner.Err(); err != nil {
+				return fmt.Errorf("failed to read import file: %w", err)
+			}
+
+			verb := "imported"
+			if dryRun {
+				verb = "validated"
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%s %d locations\n", verb, count)
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&file, "file", "f", "-", "export file to read, or - for stdin")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "validate the file without writing")
+	return cmd
+}
+
+// importLocation upserts locations with an external ID and creates the rest.
+func importLocation(ctx context.Context, repo repository.Repository, location models.Location) error {
+	if location.GetExternalID() != "" {
+		_, err := repo.Upsert(ctx, location)
+		return err
+	}
+	_, err := repo.Create(ctx, location)
+	return err
+}
+
+// locationForAccount parses a location document and assigns it to accountID,
+// so records can be moved between accounts and environments.
+func locationForAccount(data []byte, accountID string) (models.Location, error) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("invalid location JSON: %w", err)
+	}
+	fields["accountId"] = accountID
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal location: %w", err)
+	}
+	location, err := models.UnmarshalLocation(data)
+	if err != nil {
+		return nil, err
+	}
+	return location, location.Validate()
+}
+
+// readInput reads a file, or stdin for "-".
+func readInput(cmd *cobra.Command, file string) ([]byte, error) {
+	if file == "-" {
+		return io.ReadAll(cmd.InOrStdin())
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", file, err)
+	}
+	return data, nil
+}
+
+// writeLocations writes a page of locations as JSON lines.
+func writeLocations(w io.Writer, result *repository.ListResult) error {
+	for _, item := range result.Items {
+		if err := writeJSON(w, item, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeJSON writes v followed by a newline, indented when pretty is set.
+func writeJSON(w io.Writer, v interface{}, pretty bool) error {
+	encoder := json.NewEncoder(w)
+	if pretty {
+		encoder.SetIndent("", "  ")
+	}
+	if err := encoder.Encode(v); err != nil {
+		return fmt.Errorf("failed to write JSON: %w", err)
+	}
+	return nil
+}
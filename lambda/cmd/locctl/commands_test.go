@@ -0,0 +1,437 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/steverhoton/location-lambda/internal/geocode"
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockRepository is a mock implementation of the repository.Repository interface.
+type mockRepository struct {
+	mock.Mock
+}
+
+// Create returns the location it was given under the ID the test configures.
+func (m *mockRepository) Create(ctx context.Context, location models.Location) (*repository.LocationEnvelope, error) {
+	args := m.Called(ctx, location)
+	if err := args.Error(1); err != nil {
+		return nil, err
+	}
+	return &repository.LocationEnvelope{LocationID: args.String(0), Location: location}, nil
+}
+
+func (m *mockRepository) Get(ctx context.Context, accountID, locationID string) (*repository.LocationEnvelope, error) {
+	args := m.Called(ctx, accountID, locationID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.LocationEnvelope), args.Error(1)
+}
+
+func (m *mockRepository) Update(ctx context.Context, location models.Location, locationID string) error {
+	args := m.Called(ctx, location, locationID)
+	return args.Error(0)
+}
+
+func (m *mockRepository) Delete(ctx context.Context, accountID, locationID string) error {
+	args := m.Called(ctx, accountID, locationID)
+	return args.Error(0)
+}
+
+func (m *mockRepository) List(ctx context.Context, accountID string, options *repository.ListOptions) (*repository.ListResult, error) {
+	args := m.Called(ctx, accountID, options)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.ListResult), args.Error(1)
+}
+
+func (m *mockRepository) Erase(ctx context.Context, accountID, locationID string) (*repository.ErasureCertificate, error) {
+	args := m.Called(ctx, accountID, locationID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.ErasureCertificate), args.Error(1)
+}
+
+func (m *mockRepository) Merge(ctx context.Context, accountID, survivorID string, duplicateIDs []string) (*repository.MergeResult, error) {
+	args := m.Called(ctx, accountID, survivorID, duplicateIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.MergeResult), args.Error(1)
+}
+
+func (m *mockRepository) Upsert(ctx context.Context, location models.Location) (*repository.UpsertResult, error) {
+	args := m.Called(ctx, location)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.UpsertResult), args.Error(1)
+}
+
+func (m *mockRepository) GetByExternalID(ctx context.Context, accountID, externalID string) (*repository.LocationEnvelope, error) {
+	args := m.Called(ctx, accountID, externalID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.LocationEnvelope), args.Error(1)
+}
+
+const testConfig = `
+defaultProfile: staging
+profiles:
+  staging:
+    region: us-east-1
+    table: locations-staging
+  prod:
+    region: us-west-2
+    table: locations-prod
+`
+
+// coordinates returns a coordinates location in accountID.
+func coordinates(accountID, externalID string) models.CoordinatesLocation {
+	return models.CoordinatesLocation{
+		LocationBase: models.LocationBase{AccountID: accountID, LocationType: models.LocationTypeCoordinates, ExternalID: externalID},
+		Coordinates:  models.Coordinates{Latitude: 40.7128, Longitude: -74.006},
+	}
+}
+
+// runCommand runs locctl with args against repo, returning stdout.
+func runCommand(t *testing.T, repo repository.Repository, stdin string, args ...string) (string, *Profile, error) {
+	t.Helper()
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(testConfig), 0o600))
+
+	var used *Profile
+	root := newRootCommand(&app{newRepository: func(ctx context.Context, profile Profile) (repository.Repository, error) {
+		used = &profile
+		return repo, nil
+	}})
+	var stdout, stderr bytes.Buffer
+	root.SetOut(&stdout)
+	root.SetErr(&stderr)
+	root.SetIn(strings.NewReader(stdin))
+	root.SetArgs(append([]string{"--config", configPath}, args...))
+
+	err := root.Execute()
+	return stdout.String(), used, err
+}
+
+func TestConfigResolve(t *testing.T) {
+	cfg := &Config{
+		DefaultProfile: "staging",
+		Profiles: map[string]Profile{
+			"staging": {Table: "locations-staging"},
+			"prod":    {Table: "locations-prod"},
+			"broken":  {Region: "us-east-1"},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		profile       string
+		env           string
+		expectedTable string
+		expectedError string
+	}{
+		{name: "Configured default", expectedTable: "locations-staging"},
+		{name: "Flag wins", profile: "prod", env: "staging", expectedTable: "locations-prod"},
+		{name: "Environment", env: "prod", expectedTable: "locations-prod"},
+		{name: "Unknown profile", profile: "dev", expectedError: `profile "dev" is not defined`},
+		{name: "Profile without table", profile: "broken", expectedError: `profile "broken" has no table`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("LOCCTL_PROFILE", tt.env)
+
+			profile, err := cfg.resolve(tt.profile)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedTable, profile.Table)
+		})
+	}
+}
+
+func TestGetCommand(t *testing.T) {
+	t.Setenv("LOCCTL_PROFILE", "")
+	repo := new(mockRepository)
+	repo.On("Get", mock.Anything, "acc-12345", "loc-001").Return(&repository.LocationEnvelope{LocationID: "loc-001", Location: coordinates("acc-12345", "")}, nil).Once()
+
+	out, profile, err := runCommand(t, repo, "", "--profile", "prod", "get", "acc-12345", "loc-001")
+	require.NoError(t, err)
+	assert.Equal(t, "locations-prod", profile.Table)
+	assert.Contains(t, out, `"locationId": "loc-001"`)
+	assert.Contains(t, out, `"latitude": 40.7128`)
+}
+
+func TestCreateCommandAssignsAccount(t *testing.T) {
+	t.Setenv("LOCCTL_PROFILE", "")
+	repo := new(mockRepository)
+	repo.On("Create", mock.Anything, mock.MatchedBy(func(l models.Location) bool {
+		return l.GetAccountID() == "acc-12345"
+	})).Return("loc-new", nil).Once()
+
+	stdin := `{"accountId": "acc-other", "locationType": "coordinates", "coordinates": {"latitude": 1, "longitude": 2}}`
+	out, _, err := runCommand(t, repo, stdin, "create", "acc-12345")
+	require.NoError(t, err)
+	assert.Equal(t, "loc-new\n", out)
+	repo.AssertExpectations(t)
+}
+
+func TestDeleteCommandRequiresConfirmation(t *testing.T) {
+	t.Setenv("LOCCTL_PROFILE", "")
+	repo := new(mockRepository)
+
+	_, _, err := runCommand(t, repo, "", "delete", "acc-12345", "loc-001")
+	assert.EqualError(t, err, "refusing to delete location loc-001 without --yes")
+	repo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+
+	repo.On("Delete", mock.Anything, "acc-12345", "loc-001").Return(nil).Once()
+	out, _, err := runCommand(t, repo, "", "delete", "acc-12345", "loc-001", "--yes")
+	require.NoError(t, err)
+	assert.Equal(t, "deleted loc-001\n", out)
+}
+
+// referenceCheckingRepository is a mock repository that also checks references.
+type referenceCheckingRepository struct {
+	mockRepository
+}
+
+func (m *referenceCheckingRepository) CheckReferences(ctx context.Context, accountID string, repair bool) (*repository.ReferenceReport, error) {
+	args := m.Called(ctx, accountID, repair)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.ReferenceReport), args.Error(1)
+}
+
+func TestCheckReferencesCommand(t *testing.T) {
+	t.Setenv("LOCCTL_PROFILE", "")
+	dangling := []repository.DanglingReference{{Kind: repository.ReferenceMergedInto, From: "loc-002", To: "loc-001"}}
+
+	t.Run("Fails while dangling references remain", func(t *testing.T) {
+		repo := new(referenceCheckingRepository)
+		repo.On("CheckReferences", mock.Anything, "acc-12345", false).Return(&repository.ReferenceReport{
+			AccountID: "acc-12345", Checked: 3, Dangling: dangling,
+		}, nil).Once()
+
+		out, _, err := runCommand(t, repo, "", "check-references", "acc-12345")
+		assert.EqualError(t, err, "found 1 dangling references; rerun with --repair to fix them")
+		assert.Contains(t, out, `"from": "loc-002"`)
+	})
+
+	t.Run("Succeeds once repaired", func(t *testing.T) {
+		repaired := []repository.DanglingReference{dangling[0]}
+		repaired[0].Repaired = true
+		repo := new(referenceCheckingRepository)
+		repo.On("CheckReferences", mock.Anything, "acc-12345", true).Return(&repository.ReferenceReport{
+			AccountID: "acc-12345", Checked: 3, Dangling: repaired,
+		}, nil).Once()
+
+		out, _, err := runCommand(t, repo, "", "check-references", "acc-12345", "--repair")
+		require.NoError(t, err)
+		assert.Contains(t, out, `"repaired": true`)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Requires repository support", func(t *testing.T) {
+		_, _, err := runCommand(t, new(mockRepository), "", "check-references", "acc-12345")
+		assert.EqualError(t, err, "the repository does not support reference checks")
+	})
+}
+
+// shopMigratingRepository is a mock repository that also migrates shops.
+type shopMigratingRepository struct {
+	mockRepository
+}
+
+func (m *shopMigratingRepository) MigrateShopAddresses(ctx context.Context, accountID string, dryRun bool) ([]string, error) {
+	args := m.Called(ctx, accountID, dryRun)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func TestMigrateShopsCommand(t *testing.T) {
+	t.Setenv("LOCCTL_PROFILE", "")
+
+	t.Run("Prints the migrated shops", func(t *testing.T) {
+		repo := new(shopMigratingRepository)
+		repo.On("MigrateShopAddresses", mock.Anything, "acc-12345", false).Return([]string{"loc-001", "loc-002"}, nil).Once()
+
+		out, _, err := runCommand(t, repo, "", "migrate-shops", "acc-12345")
+		require.NoError(t, err)
+		assert.Equal(t, "loc-001\nloc-002\n", out)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Dry run", func(t *testing.T) {
+		repo := new(shopMigratingRepository)
+		repo.On("MigrateShopAddresses", mock.Anything, "acc-12345", true).Return([]string{"loc-001"}, nil).Once()
+
+		out, _, err := runCommand(t, repo, "", "migrate-shops", "acc-12345", "--dry-run")
+		require.NoError(t, err)
+		assert.Equal(t, "loc-001\n", out)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Prints shops migrated before a failure", func(t *testing.T) {
+		repo := new(shopMigratingRepository)
+		repo.On("MigrateShopAddresses", mock.Anything, "acc-12345", false).Return([]string{"loc-001"}, errors.New("throttled")).Once()
+
+		out, _, err := runCommand(t, repo, "", "migrate-shops", "acc-12345")
+		assert.EqualError(t, err, "throttled")
+		assert.Equal(t, "loc-001\n", out)
+	})
+
+	t.Run("Requires repository support", func(t *testing.T) {
+		_, _, err := runCommand(t, new(mockRepository), "", "migrate-shops", "acc-12345")
+		assert.EqualError(t, err, "the repository does not support shop migrations")
+	})
+}
+
+// geocodingRepository is a mock repository that also stores geocodes.
+type geocodingRepository struct {
+	mockRepository
+}
+
+func (m *geocodingRepository) SetGeocode(ctx context.Context, accountID, locationID string, address models.Address, coordinates models.Coordinates, info models.GeocodeInfo) (bool, error) {
+	args := m.Called(ctx, accountID, locationID, address, coordinates, info)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *geocodingRepository) RefreshGeocode(ctx context.Context, accountID, locationID string, previous models.GeocodeInfo, address models.Address, coordinates models.Coordinates, info models.GeocodeInfo) (bool, error) {
+	args := m.Called(ctx, accountID, locationID, previous, address, coordinates, info)
+	return args.Bool(0), args.Error(1)
+}
+
+// stubGeocoder places every address at the same point.
+type stubGeocoder struct{}
+
+func (stubGeocoder) Name() string {
+	return "stub"
+}
+
+func (stubGeocoder) Geocode(context.Context, string) (*geocode.Result, error) {
+	return &geocode.Result{Coordinates: models.Coordinates{Latitude: 39.7817, Longitude: -89.6501}}, nil
+}
+
+func TestGeocodeBackfillCommand(t *testing.T) {
+	t.Setenv("LOCCTL_PROFILE", "")
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(testConfig), 0o600))
+	ungeocoded := models.AddressLocation{
+		LocationBase: models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeAddress},
+		Address:      models.Address{StreetAddress: "123 Main St", City: "Springfield", PostalCode: "62704", Country: "US"},
+	}
+	run := func(repo repository.Repository, args ...string) (string, bool, error) {
+		geocoderBuilt := false
+		root := newRootCommand(&app{
+			newRepository: func(context.Context, Profile) (repository.Repository, error) { return repo, nil },
+			newGeocoder: func(context.Context, Profile) (geocode.Geocoder, error) {
+				geocoderBuilt = true
+				return stubGeocoder{}, nil
+			},
+		})
+		var stdout bytes.Buffer
+		root.SetOut(&stdout)
+		root.SetErr(&bytes.Buffer{})
+		root.SetArgs(append([]string{"--config", configPath}, args...))
+		err := root.Execute()
+		return stdout.String(), geocoderBuilt, err
+	}
+
+	t.Run("Geocodes and reports the locations", func(t *testing.T) {
+		repo := new(geocodingRepository)
+		repo.On("List", mock.Anything, "acc-12345", mock.MatchedBy(func(options *repository.ListOptions) bool {
+			return options.MissingCoordinates && *options.Limit == 10
+		})).Return(&repository.ListResult{Items: []repository.LocationEnvelope{{LocationID: "loc-001", Location: ungeocoded}}}, nil).Once()
+		repo.On("SetGeocode", mock.Anything, "acc-12345", "loc-001", ungeocoded.Address, models.Coordinates{Latitude: 39.7817, Longitude: -89.6501}, mock.MatchedBy(func(info models.GeocodeInfo) bool {
+			return info.Provider == "stub" && !info.GeocodedAt.IsZero()
+		})).Return(true, nil).Once()
+
+		out, _, err := run(repo, "geocode-backfill", "acc-12345", "--batch-size", "10", "--rate", "0")
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"geocoded": ["loc-001"], "unmatched": [], "skipped": []}`, out)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Dry run needs no geocoder", func(t *testing.T) {
+		repo := new(geocodingRepository)
+		repo.On("List", mock.Anything, "acc-12345", mock.Anything).Return(&repository.ListResult{Items: []repository.LocationEnvelope{{LocationID: "loc-001", Location: ungeocoded}}}, nil).Once()
+
+		out, geocoderBuilt, err := run(repo, "geocode-backfill", "acc-12345", "--dry-run")
+		require.NoError(t, err)
+		assert.False(t, geocoderBuilt)
+		assert.JSONEq(t, `{"geocoded": ["loc-001"], "unmatched": [], "skipped": []}`, out)
+		repo.AssertNotCalled(t, "SetGeocode", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Requires repository support", func(t *testing.T) {
+		_, _, err := run(new(mockRepository), "geocode-backfill", "acc-12345")
+		assert.EqualError(t, err, "the repository does not support geocode backfills")
+	})
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	t.Setenv("LOCCTL_PROFILE", "")
+	source := new(mockRepository)
+	next := "page-2"
+	source.On("List", mock.Anything, "acc-12345", mock.MatchedBy(func(o *repository.ListOptions) bool {
+		return o.Cursor == nil
+	})).Return(&repository.ListResult{
+		Items:      []repository.LocationEnvelope{{LocationID: "loc-001", Location: coordinates("acc-12345", "ERP-1")}},
+		NextCursor: &next,
+	}, nil).Once()
+	source.On("List", mock.Anything, "acc-12345", mock.MatchedBy(func(o *repository.ListOptions) bool {
+		return o.Cursor != nil && *o.Cursor == "page-2"
+	})).Return(&repository.ListResult{
+		Items: []repository.LocationEnvelope{{LocationID: "loc-002", Location: coordinates("acc-12345", "")}},
+	}, nil).Once()
+
+	exported, _, err := runCommand(t, source, "", "export", "acc-12345")
+	require.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(exported, "\n"))
+	source.AssertExpectations(t)
+
+	// Locations with an external ID are upserted so imports can be repeated
+	target := new(mockRepository)
+	target.On("Upsert", mock.Anything, mock.MatchedBy(func(l models.Location) bool {
+		return l.GetAccountID() == "acc-67890" && l.GetExternalID() == "ERP-1"
+	})).Return(&repository.UpsertResult{LocationID: "loc-101", Created: true}, nil).Once()
+	target.On("Create", mock.Anything, mock.MatchedBy(func(l models.Location) bool {
+		return l.GetAccountID() == "acc-67890"
+	})).Return("loc-102", nil).Once()
+
+	out, _, err := runCommand(t, target, exported, "import", "acc-67890")
+	require.NoError(t, err)
+	assert.Equal(t, "imported 2 locations\n", out)
+	target.AssertExpectations(t)
+}
+
+func TestImportCommandDryRun(t *testing.T) {
+	t.Setenv("LOCCTL_PROFILE", "")
+	repo := new(mockRepository)
+
+	stdin := `{"locationId": "loc-001", "location": {"locationType": "coordinates", "coordinates": {"latitude": 1, "longitude": 2}}}
+{"locationId": "loc-002", "location": {"locationType": "coordinates", "coordinates": {"latitude": 95, "longitude": 2}}}
+`
+	_, _, err := runCommand(t, repo, stdin, "import", "acc-12345", "--dry-run")
+	require.Error(t, err)
+	assert.True(t, strings.HasPrefix(err.Error(), "line 2: "), err.Error())
+	repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
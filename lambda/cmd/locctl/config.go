@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Profile describes how to reach one deployment's locations table. It mirrors
+// the Lambda's environment so the CLI reads and writes records the same way.
+type Profile struct {
+	Region              string   `yaml:"region"`
+	AWSProfile          string   `yaml:"awsProfile"`
+	Table               string   `yaml:"table"`
+	ExternalIDIndex     string   `yaml:"externalIdIndex"`
+	OverflowBucket      string   `yaml:"overflowBucket"`
+	OverflowKeyPrefix   string   `yaml:"overflowKeyPrefix"`
+	KMSKeyID            string   `yaml:"kmsKeyId"`
+	EncryptedAttributes []string `yaml:"encryptedAttributes"`
+	GeocoderPlaceIndex  string   `yaml:"geocoderPlaceIndex"`
+}
+
+// Config is the locctl configuration file.
+type Config struct {
+	DefaultProfile string             `yaml:"defaultProfile"`
+	Profiles       map[string]Profile `yaml:"profiles"`
+}
+
+// defaultConfigPath returns $LOCCTL_CONFIG, or config.yaml in the user's locctl config directory.
+func defaultConfigPath() string {
+	if path := os.Getenv("LOCCTL_CONFIG"); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "locctl.yaml"
+	}
+	return filepath.Join(dir, "locctl", "config.yaml")
+}
+
+// loadConfig reads the configuration file at path.
+func loadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("config file %s not found; create it with a profiles section", path)
+		}
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// resolve returns the named profile, falling back to $LOCCTL_PROFILE, the
+// configured default, and finally "default".
+func (c *Config) resolve(name string) (Profile, error) {
+	if name == "" {
+		name = os.Getenv("LOCCTL_PROFILE")
+	}
+	if name == "" {
+		name = c.DefaultProfile
+	}
+	if name == "" {
+		name = "default"
+	}
+
+	profile, ok := c.Profiles[name]
+	if !ok {
+		return Profile{}, fmt.Errorf("profile %q is not defined", name)
+	}
+	if profile.Table == "" {
+		return Profile{}, fmt.Errorf("profile %q has no table", name)
+	}
+	return profile, nil
+}
@@ -0,0 +1,85 @@
+// Package main provides locctl, an operator CLI that reads and repairs
+// location records directly through the repository.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/steverhoton/location-lambda/internal/geocode"
+	"github.com/steverhoton/location-lambda/internal/repository"
+)
+
+// loadAWSConfig loads the AWS configuration for a profile's region and credentials.
+func loadAWSConfig(ctx context.Context, profile Profile) (aws.Config, error) {
+	var loadOpts []func(*config.LoadOptions) error
+	if profile.Region != "" {
+		loadOpts = append(loadOpts, config.WithRegion(profile.Region))
+	}
+	if profile.AWSProfile != "" {
+		loadOpts = append(loadOpts, config.WithSharedConfigProfile(profile.AWSProfile))
+	}
+	cfg, err := config.LoadDefaultConfig(ctx, loadOpts...)
+	if err != nil {
+		return aws.Config{}, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	return cfg, nil
+}
+
+// newRepository builds a repository for a profile with the same storage
+// options as the Lambda, so overflow payloads and encrypted attributes are
+// read and written transparently.
+func newRepository(ctx context.Context, profile Profile) (repository.Repository, error) {
+	cfg, err := loadAWSConfig(ctx, profile)
+	if err != nil {
+		return nil, err
+	}
+
+	var opts []repository.Option
+	if profile.ExternalIDIndex != "" {
+		opts = append(opts, repository.WithExternalIDIndex(profile.ExternalIDIndex))
+	}
+	if profile.OverflowBucket != "" {
+		prefix := profile.OverflowKeyPrefix
+		if prefix == "" {
+			prefix = "overflow/"
+		}
+		opts = append(opts, repository.WithOverflow(s3.NewFromConfig(cfg), repository.OverflowConfig{
+			Bucket:    profile.OverflowBucket,
+			KeyPrefix: prefix,
+		}))
+	}
+	if profile.KMSKeyID != "" {
+		opts = append(opts, repository.WithEncryption(kms.NewFromConfig(cfg), repository.EncryptionConfig{
+			KeyID:      profile.KMSKeyID,
+			Attributes: profile.EncryptedAttributes,
+		}))
+	}
+
+	return repository.NewDynamoDBRepository(dynamodb.NewFromConfig(cfg), profile.Table, opts...), nil
+}
+
+// newGeocoder builds the geocoder of a profile's place index.
+func newGeocoder(ctx context.Context, profile Profile) (geocode.Geocoder, error) {
+	if profile.GeocoderPlaceIndex == "" {
+		return nil, fmt.Errorf("the profile has no geocoderPlaceIndex")
+	}
+	cfg, err := loadAWSConfig(ctx, profile)
+	if err != nil {
+		return nil, err
+	}
+	return geocode.NewAmazonLocationGeocoder(cfg, profile.GeocoderPlaceIndex), nil
+}
+
+func main() {
+	root := newRootCommand(&app{newRepository: newRepository, newGeocoder: newGeocoder})
+	if err := root.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
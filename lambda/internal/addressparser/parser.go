@@ -0,0 +1,397 @@
+// Package addressparser splits free-text postal addresses into structured fields.
+//
+// The parser is a rule-based approximation of libpostal-style labelling: it
+// peels the country, postal code, and state off the end of the text, then
+// splits the remainder into street, unit, and city using street suffixes,
+// unit designators, and comma boundaries. It covers common US, Canadian, UK,
+// Australian, and continental European layouts.
+package addressparser
+
+import (
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+)
+
+// Result is a parsed address with an estimate of how reliable the split is.
+type Result struct {
+	Address    models.Address `json:"address"`
+	Confidence float64        `json:"confidence"`        // 0-1 heuristic confidence in the field assignment
+	Missing    []string       `json:"missing,omitempty"` // Required Address fields that could not be found
+}
+
+var (
+	postalPatterns = map[string]*regexp.Regexp{
+		"US": regexp.MustCompile(`^\d{5}(?:-\d{4})?$`),
+		"CA": regexp.MustCompile(`^[A-Za-z]\d[A-Za-z] ?\d[A-Za-z]\d$`),
+		"GB": regexp.MustCompile(`^[A-Za-z]{1,2}\d[A-Za-z\d]? ?\d[A-Za-z]{2}$`),
+	}
+	genericPostalPattern = regexp.MustCompile(`^\d{4,6}$`)
+	houseNumberPattern   = regexp.MustCompile(`^\d+[A-Za-z]?(?:-\d+[A-Za-z]?)?$`)
+)
+
+// token is a whitespace-delimited word with the comma-separated segment it came from.
+type token struct {
+	text    string
+	norm    string
+	segment int
+}
+
+// Parse splits text into address fields. countryHint, an ISO 3166-1 alpha-2
+// code or country name, is used when the text does not name a country.
+func Parse(text, countryHint string) Result {
+	p := &parser{tokens: tokenize(text)}
+	p.end = len(p.tokens)
+	address := &p.address
+
+	p.parseCountry()
+	if address.Country == "" && countryHint != "" {
+		address.Country = lookupCountry(countryHint)
+	}
+	p.parsePostalCode()
+	p.parseState()
+	if address.PostalCode == "" {
+		p.parsePostalFirst()
+	}
+	p.parseStreetAndCity()
+
+	// A state from the US table implies a US address when nothing else says otherwise
+	if address.Country == "" && address.StateProvince != "" && p.stateCountry != "" {
+		address.Country = p.stateCountry
+	}
+
+	return p.result()
+}
+
+// parser holds the state of a single Parse call. Fields are consumed from the
+// end of tokens, so end marks the exclusive bound of the unconsumed prefix.
+type parser struct {
+	tokens       []token
+	end          int
+	address      models.Address
+	stateCountry string
+	guessed      bool
+}
+
+// tokenize splits text into tokens, recording comma and newline boundaries.
+func tokenize(text string) []token {
+	var tokens []token
+	segments := strings.FieldsFunc(text, func(r rune) bool { return r == ',' || r == '\n' || r == ';' })
+	for i, segment := range segments {
+		for _, word := range strings.Fields(segment) {
+			tokens = append(tokens, token{text: word, norm: normalize(word), segment: i})
+		}
+	}
+	return tokens
+}
+
+// normalize lowercases a word and strips periods for table lookups.
+func normalize(word string) string {
+	return strings.ReplaceAll(lower(word), ".", "")
+}
+
+// lower lowercases s.
+func lower(s string) string {
+	return strings.ToLower(s)
+}
+
+// lookupCountry resolves a country code or name to an ISO alpha-2 code.
+func lookupCountry(value string) string {
+	value = strings.TrimSpace(value)
+	if code, ok := countryNames[normalize(value)]; ok {
+		return code
+	}
+	if len(value) == 2 {
+		return strings.ToUpper(value)
+	}
+	return ""
+}
+
+// span joins the normalized text of tokens[start:end] when they share a segment.
+func (p *parser) span(start, end int) (string, bool) {
+	if start < 0 || start >= end {
+		return "", false
+	}
+	parts := make([]string, 0, end-start)
+	for _, t := range p.tokens[start:end] {
+		if t.segment != p.tokens[start].segment {
+			return "", false
+		}
+		parts = append(parts, t.norm)
+	}
+	return strings.Join(parts, " "), true
+}
+
+// original joins the original text of tokens[start:end].
+func (p *parser) original(start, end int) string {
+	parts := make([]string, 0, end-start)
+	for _, t := range p.tokens[start:end] {
+		parts = append(parts, t.text)
+	}
+	return strings.Join(parts, " ")
+}
+
+// parseCountry consumes a trailing country name or code.
+func (p *parser) parseCountry() {
+	for n := 4; n >= 1; n-- {
+		joined, ok := p.span(p.end-n, p.end)
+		if !ok {
+			continue
+		}
+		code, ok := countryNames[joined]
+		if !ok {
+			continue
+		}
+		// Two-letter codes such as CA or IN are also state codes; treat them as a
+		// country only when they follow a postal code.
+		if n == 1 && isRegionCode(joined) && !p.postalBefore(p.end-1) {
+			continue
+		}
+		p.address.Country = code
+		p.end -= n
+		return
+	}
+}
+
+// isRegionCode reports whether a normalized word is a state or province code.
+func isRegionCode(word string) bool {
+	for _, table := range regionTables {
+		if code, ok := table.regions[word]; ok && lower(code) == word {
+			return true
+		}
+	}
+	return false
+}
+
+// postalBefore reports whether a postal code ends just before index i.
+func (p *parser) postalBefore(i int) bool {
+	for n := 2; n >= 1; n-- {
+		if i-n < 0 {
+			continue
+		}
+		if _, ok := matchPostal(p.original(i-n, i), ""); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPostal reports whether value is a postal code for country, or for any
+// supported country when country is empty, and returns the country implied by
+// its format when that format is unique to one country.
+func matchPostal(value, country string) (string, bool) {
+	if pattern, ok := postalPatterns[country]; ok {
+		return country, pattern.MatchString(value)
+	}
+	if country != "" {
+		return country, genericPostalPattern.MatchString(value)
+	}
+	for _, c := range []string{"CA", "GB"} {
+		if postalPatterns[c].MatchString(value) {
+			return c, true
+		}
+	}
+	return "", postalPatterns["US"].MatchString(value) || genericPostalPattern.MatchString(value)
+}
+
+// parsePostalCode consumes a trailing postal code, which may span two tokens.
+func (p *parser) parsePostalCode() {
+	for n := 2; n >= 1; n-- {
+		if _, ok := p.span(p.end-n, p.end); !ok {
+			continue
+		}
+		value := p.original(p.end-n, p.end)
+		implied, ok := matchPostal(value, p.address.Country)
+		if !ok {
+			continue
+		}
+		p.address.PostalCode = strings.ToUpper(value)
+		if p.address.Country == "" {
+			p.address.Country = implied
+		}
+		p.end -= n
+		return
+	}
+}
+
+// parseState consumes a trailing state or province code or name.
+func (p *parser) parseState() {
+	for n := 3; n >= 1; n-- {
+		joined, ok := p.span(p.end-n, p.end)
+		// Leave at least one token for the street
+		if !ok || p.end-n < 1 {
+			continue
+		}
+		for _, table := range regionTables {
+			if p.address.Country != "" && p.address.Country != table.country {
+				continue
+			}
+			if code, ok := table.regions[joined]; ok {
+				p.address.StateProvince = code
+				p.stateCountry = table.country
+				p.end -= n
+				return
+			}
+		}
+	}
+}
+
+// parsePostalFirst handles layouts where the postal code precedes the city,
+// such as "Hauptstrasse 5, 10115 Berlin".
+func (p *parser) parsePostalFirst() {
+	for i := p.end - 2; i >= 1; i-- {
+		if _, ok := matchPostal(p.tokens[i].text, p.address.Country); !ok {
+			continue
+		}
+		// The city must follow within the same segment and contain no digits
+		if _, ok := p.span(i, p.end); !ok {
+			continue
+		}
+		if strings.ContainsAny(p.original(i+1, p.end), "0123456789") {
+			continue
+		}
+		p.address.PostalCode = strings.ToUpper(p.tokens[i].text)
+		p.address.City = p.original(i+1, p.end)
+		p.end = i
+		return
+	}
+}
+
+// parseStreetAndCity assigns the remaining tokens to street, unit, and city.
+func (p *parser) parseStreetAndCity() {
+	remaining := p.tokens[:p.end]
+	if len(remaining) == 0 {
+		return
+	}
+
+	// Comma-separated input: first segment is the street, last is the city
+	segments := splitSegments(remaining)
+	if len(segments) > 1 {
+		street := segments[0]
+		rest := segments[1:]
+		if p.address.City == "" {
+			p.address.City = joinTokens(rest[len(rest)-1])
+			rest = rest[:len(rest)-1]
+		}
+		p.address.StreetAddress, p.address.StreetAddress2 = splitUnit(street)
+		var extra []string
+		if p.address.StreetAddress2 != "" {
+			extra = append(extra, p.address.StreetAddress2)
+		}
+		for _, segment := range rest {
+			extra = append(extra, joinTokens(segment))
+		}
+		p.address.StreetAddress2 = strings.Join(extra, ", ")
+		return
+	}
+
+	if p.address.City != "" {
+		p.address.StreetAddress, p.address.StreetAddress2 = splitUnit(remaining)
+		return
+	}
+
+	// Single run of words: end the street at a suffix or unit designator
+	streetEnd := -1
+	for i := 1; i < len(remaining); i++ {
+		if streetSuffixes[remaining[i].norm] {
+			streetEnd = i + 1
+			if streetEnd < len(remaining) && directionals[remaining[streetEnd].norm] {
+				streetEnd++
+			}
+		}
+		if unitDesignators[remaining[i].norm] || strings.HasPrefix(remaining[i].text, "#") {
+			if streetEnd < 0 {
+				streetEnd = i
+			}
+			break
+		}
+	}
+	if streetEnd < 0 {
+		// No structural hint; assume the last word is the city
+		p.guessed = true
+		streetEnd = len(remaining) - 1
+	}
+
+	unitEnd := streetEnd
+	if unitEnd < len(remaining) {
+		switch {
+		case strings.HasPrefix(remaining[unitEnd].text, "#"):
+			unitEnd++
+		case unitDesignators[remaining[unitEnd].norm] && unitEnd+1 < len(remaining):
+			unitEnd += 2
+		}
+	}
+
+	p.address.StreetAddress = joinTokens(remaining[:streetEnd])
+	p.address.StreetAddress2 = joinTokens(remaining[streetEnd:unitEnd])
+	p.address.City = joinTokens(remaining[unitEnd:])
+	if streetEnd > 0 && !houseNumberPattern.MatchString(remaining[0].text) {
+		p.guessed = true
+	}
+}
+
+// splitSegments groups tokens by comma-separated segment.
+func splitSegments(tokens []token) [][]token {
+	var segments [][]token
+	for i, t := range tokens {
+		if i == 0 || t.segment != tokens[i-1].segment {
+			segments = append(segments, nil)
+		}
+		segments[len(segments)-1] = append(segments[len(segments)-1], t)
+	}
+	return segments
+}
+
+// splitUnit separates a trailing unit designator from a street segment.
+func splitUnit(tokens []token) (string, string) {
+	for i := 1; i < len(tokens); i++ {
+		if unitDesignators[tokens[i].norm] || strings.HasPrefix(tokens[i].text, "#") {
+			return joinTokens(tokens[:i]), joinTokens(tokens[i:])
+		}
+	}
+	if len(tokens) > 0 && (unitDesignators[tokens[0].norm] || strings.HasPrefix(tokens[0].text, "#")) {
+		return "", joinTokens(tokens)
+	}
+	return joinTokens(tokens), ""
+}
+
+// joinTokens joins the original text of tokens.
+func joinTokens(tokens []token) string {
+	parts := make([]string, len(tokens))
+	for i, t := range tokens {
+		parts[i] = t.text
+	}
+	return strings.Join(parts, " ")
+}
+
+// result reports the parsed address, missing fields, and confidence.
+func (p *parser) result() Result {
+	var missing []string
+	for _, field := range []struct {
+		name  string
+		value string
+	}{
+		{"streetAddress", p.address.StreetAddress},
+		{"city", p.address.City},
+		{"postalCode", p.address.PostalCode},
+		{"country", p.address.Country},
+	} {
+		if field.value == "" {
+			missing = append(missing, field.name)
+		}
+	}
+
+	confidence := 1 - 0.25*float64(len(missing))
+	if p.guessed {
+		confidence -= 0.2
+	}
+	confidence = math.Round(math.Max(confidence, 0)*100) / 100
+
+	return Result{
+		Address:    p.address,
+		Confidence: confidence,
+		Missing:    missing,
+	}
+}
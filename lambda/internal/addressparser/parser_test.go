@@ -0,0 +1,133 @@
+package addressparser
+
+import (
+	"testing"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name            string
+		text            string
+		country         string
+		expected        models.Address
+		expectedMissing []string
+	}{
+		{
+			name: "US address without commas",
+			text: "123 Main St Apt 4 Springfield IL 62704",
+			expected: models.Address{
+				StreetAddress:  "123 Main St",
+				StreetAddress2: "Apt 4",
+				City:           "Springfield",
+				StateProvince:  "IL",
+				PostalCode:     "62704",
+				Country:        "US",
+			},
+		},
+		{
+			name: "US address with commas and full state name",
+			text: "500 Elm Avenue, Suite 200, Des Moines, Iowa 50309-1234, USA",
+			expected: models.Address{
+				StreetAddress:  "500 Elm Avenue",
+				StreetAddress2: "Suite 200",
+				City:           "Des Moines",
+				StateProvince:  "IA",
+				PostalCode:     "50309-1234",
+				Country:        "US",
+			},
+		},
+		{
+			name: "Multi-word city and hash unit",
+			text: "77 Massachusetts Ave #3 New York NY 10001",
+			expected: models.Address{
+				StreetAddress:  "77 Massachusetts Ave",
+				StreetAddress2: "#3",
+				City:           "New York",
+				StateProvince:  "NY",
+				PostalCode:     "10001",
+				Country:        "US",
+			},
+		},
+		{
+			name: "Canadian address with trailing ambiguous country code",
+			text: "100 Queen St W, Toronto, ON M5H 2N2, CA",
+			expected: models.Address{
+				StreetAddress: "100 Queen St W",
+				City:          "Toronto",
+				StateProvince: "ON",
+				PostalCode:    "M5H 2N2",
+				Country:       "CA",
+			},
+		},
+		{
+			name: "California is a state, not Canada",
+			text: "1 Market St, San Francisco, CA 94105",
+			expected: models.Address{
+				StreetAddress: "1 Market St",
+				City:          "San Francisco",
+				StateProvince: "CA",
+				PostalCode:    "94105",
+				Country:       "US",
+			},
+		},
+		{
+			name: "UK address",
+			text: "10 Downing St, London SW1A 2AA, United Kingdom",
+			expected: models.Address{
+				StreetAddress: "10 Downing St",
+				City:          "London",
+				PostalCode:    "SW1A 2AA",
+				Country:       "GB",
+			},
+		},
+		{
+			name:    "Postal code before city with country hint",
+			text:    "Hauptstrasse 5, 10115 Berlin",
+			country: "DE",
+			expected: models.Address{
+				StreetAddress: "Hauptstrasse 5",
+				City:          "Berlin",
+				PostalCode:    "10115",
+				Country:       "DE",
+			},
+		},
+		{
+			name: "Missing postal code and country",
+			text: "42 Wallaby Way Sydney",
+			expected: models.Address{
+				StreetAddress: "42 Wallaby Way",
+				City:          "Sydney",
+			},
+			expectedMissing: []string{"postalCode", "country"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Parse(tt.text, tt.country)
+			assert.Equal(t, tt.expected, result.Address)
+			assert.Equal(t, tt.expectedMissing, result.Missing)
+			if len(tt.expectedMissing) == 0 {
+				assert.Equal(t, 1.0, result.Confidence)
+			} else {
+				assert.Less(t, result.Confidence, 1.0)
+			}
+		})
+	}
+}
+
+func TestParseGuessedSplitLowersConfidence(t *testing.T) {
+	result := Parse("Main Springfield 62704", "US")
+	assert.Equal(t, "Main", result.Address.StreetAddress)
+	assert.Equal(t, "Springfield", result.Address.City)
+	assert.Less(t, result.Confidence, 1.0)
+}
+
+func TestParseEmpty(t *testing.T) {
+	result := Parse("  ", "")
+	assert.Equal(t, []string{"streetAddress", "city", "postalCode", "country"}, result.Missing)
+	assert.Equal(t, 0.0, result.Confidence)
+}
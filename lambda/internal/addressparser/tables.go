@@ -0,0 +1,103 @@
+package addressparser
+
+// usStates maps US state and territory codes and names to their USPS code.
+var usStates = buildRegionTable(map[string]string{
+	"AL": "Alabama", "AK": "Alaska", "AZ": "Arizona", "AR": "Arkansas", "CA": "California",
+	"CO": "Colorado", "CT": "Connecticut", "DE": "Delaware", "DC": "District of Columbia",
+	"FL": "Florida", "GA": "Georgia", "HI": "Hawaii", "ID": "Idaho", "IL": "Illinois",
+	"IN": "Indiana", "IA": "Iowa", "KS": "Kansas", "KY": "Kentucky", "LA": "Louisiana",
+	"ME": "Maine", "MD": "Maryland", "MA": "Massachusetts", "MI": "Michigan", "MN": "Minnesota",
+	"MS": "Mississippi", "MO": "Missouri", "MT": "Montana", "NE": "Nebraska", "NV": "Nevada",
+	"NH": "New Hampshire", "NJ": "New Jersey", "NM": "New Mexico", "NY": "New York",
+	"NC": "North Carolina", "ND": "North Dakota", "OH": "Ohio", "OK": "Oklahoma", "OR": "Oregon",
+	"PA": "Pennsylvania", "RI": "Rhode Island", "SC": "South Carolina", "SD": "South Dakota",
+	"TN": "Tennessee", "TX": "Texas", "UT": "Utah", "VT": "Vermont", "VA": "Virginia",
+	"WA": "Washington", "WV": "West Virginia", "WI": "Wisconsin", "WY": "Wyoming",
+	"PR": "Puerto Rico", "GU": "Guam", "VI": "Virgin Islands",
+})
+
+// caProvinces maps Canadian province and territory codes and names to their code.
+var caProvinces = buildRegionTable(map[string]string{
+	"AB": "Alberta", "BC": "British Columbia", "MB": "Manitoba", "NB": "New Brunswick",
+	"NL": "Newfoundland and Labrador", "NS": "Nova Scotia", "NT": "Northwest Territories",
+	"NU": "Nunavut", "ON": "Ontario", "PE": "Prince Edward Island", "QC": "Quebec",
+	"SK": "Saskatchewan", "YT": "Yukon",
+})
+
+// auStates maps Australian state and territory codes and names to their code.
+var auStates = buildRegionTable(map[string]string{
+	"ACT": "Australian Capital Territory", "NSW": "New South Wales", "NT": "Northern Territory",
+	"QLD": "Queensland", "SA": "South Australia", "TAS": "Tasmania", "VIC": "Victoria",
+	"WA": "Western Australia",
+})
+
+// regionTables lists the state tables by country, in the order tried when the country is unknown.
+var regionTables = []struct {
+	country string
+	regions map[string]string
+}{
+	{"US", usStates},
+	{"CA", caProvinces},
+	{"AU", auStates},
+}
+
+// countryNames maps common country names and codes, lowercased, to ISO 3166-1 alpha-2 codes.
+var countryNames = map[string]string{
+	"us": "US", "usa": "US", "united states": "US", "united states of america": "US", "america": "US",
+	"ca": "CA", "can": "CA", "canada": "CA",
+	"gb": "GB", "uk": "GB", "united kingdom": "GB", "great britain": "GB", "england": "GB", "scotland": "GB", "wales": "GB",
+	"au": "AU", "aus": "AU", "australia": "AU",
+	"nz": "NZ", "new zealand": "NZ",
+	"ie": "IE", "ireland": "IE",
+	"de": "DE", "germany": "DE", "deutschland": "DE",
+	"fr": "FR", "france": "FR",
+	"es": "ES", "spain": "ES", "españa": "ES",
+	"it": "IT", "italy": "IT", "italia": "IT",
+	"nl": "NL", "netherlands": "NL", "the netherlands": "NL",
+	"be": "BE", "belgium": "BE",
+	"ch": "CH", "switzerland": "CH",
+	"at": "AT", "austria": "AT",
+	"se": "SE", "sweden": "SE",
+	"no": "NO", "norway": "NO",
+	"dk": "DK", "denmark": "DK",
+	"fi": "FI", "finland": "FI",
+	"pl": "PL", "poland": "PL",
+	"pt": "PT", "portugal": "PT",
+	"mx": "MX", "mexico": "MX",
+	"br": "BR", "brazil": "BR",
+	"jp": "JP", "japan": "JP",
+	"in": "IN", "india": "IN",
+	"sg": "SG", "singapore": "SG",
+}
+
+// streetSuffixes lists common street type words and abbreviations, lowercased without periods.
+var streetSuffixes = map[string]bool{
+	"st": true, "street": true, "ave": true, "av": true, "avenue": true, "rd": true, "road": true,
+	"blvd": true, "boulevard": true, "ln": true, "lane": true, "dr": true, "drive": true,
+	"ct": true, "court": true, "way": true, "pl": true, "place": true, "ter": true, "terrace": true,
+	"cir": true, "circle": true, "pkwy": true, "parkway": true, "hwy": true, "highway": true,
+	"sq": true, "square": true, "trl": true, "trail": true, "cres": true, "crescent": true,
+	"row": true, "close": true, "plz": true, "plaza": true, "loop": true, "pike": true,
+}
+
+// directionals lists compass directions that may follow a street suffix.
+var directionals = map[string]bool{
+	"n": true, "s": true, "e": true, "w": true, "ne": true, "nw": true, "se": true, "sw": true,
+	"north": true, "south": true, "east": true, "west": true,
+}
+
+// unitDesignators lists words that introduce a secondary unit, lowercased without periods.
+var unitDesignators = map[string]bool{
+	"apt": true, "apartment": true, "suite": true, "ste": true, "unit": true, "floor": true,
+	"fl": true, "rm": true, "room": true, "bldg": true, "building": true, "dept": true, "lot": true,
+}
+
+// buildRegionTable indexes regions by lowercased code and name.
+func buildRegionTable(regions map[string]string) map[string]string {
+	table := make(map[string]string, len(regions)*2)
+	for code, name := range regions {
+		table[lower(code)] = code
+		table[lower(name)] = code
+	}
+	return table
+}
@@ -0,0 +1,86 @@
+// Package assets resolves the object keys of stored images, such as shop
+// logos, to URLs clients can fetch directly.
+package assets
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// Asset is a stored image and the URL it can be fetched from.
+type Asset struct {
+	Key string `json:"key"`
+	URL string `json:"url"`
+	// ExpiresAt is when a presigned URL stops working; CDN URLs don't expire
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// Resolver resolves object keys to URLs.
+type Resolver interface {
+	Resolve(ctx context.Context, key string) (*Asset, error)
+}
+
+// CDNResolver serves assets from a CDN in front of the asset bucket, such as
+// a CloudFront distribution, so URLs are stable and cacheable.
+type CDNResolver struct {
+	baseURL string
+}
+
+// NewCDNResolver creates a resolver for a CDN serving the bucket at baseURL.
+func NewCDNResolver(baseURL string) (*CDNResolver, error) {
+	parsed, err := url.Parse(baseURL)
+	if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid CDN base URL %q: must be an absolute http or https URL", baseURL)
+	}
+	return &CDNResolver{baseURL: strings.TrimSuffix(baseURL, "/")}, nil
+}
+
+// Resolve returns the CDN URL of key.
+func (r *CDNResolver) Resolve(_ context.Context, key string) (*Asset, error) {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return &Asset{Key: key, URL: r.baseURL + "/" + strings.Join(segments, "/")}, nil
+}
+
+// Presigner presigns S3 GetObject requests; *s3.PresignClient implements it.
+type Presigner interface {
+	PresignGetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.PresignOptions)) (*v4.PresignedHTTPRequest, error)
+}
+
+// S3Resolver presigns GetObject URLs for a private asset bucket with the
+// Lambda's own credentials. A URL stops working at its expiry or when the
+// signing session expires, whichever comes first.
+type S3Resolver struct {
+	presigner Presigner
+	bucket    string
+	expiry    time.Duration
+	now       func() time.Time
+}
+
+// NewS3Resolver creates a resolver for bucket whose URLs are valid for expiry.
+func NewS3Resolver(presigner Presigner, bucket string, expiry time.Duration) *S3Resolver {
+	return &S3Resolver{presigner: presigner, bucket: bucket, expiry: expiry, now: time.Now}
+}
+
+// Resolve returns a presigned URL for key.
+func (r *S3Resolver) Resolve(ctx context.Context, key string) (*Asset, error) {
+	signedAt := r.now()
+	request, err := r.presigner.PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(r.bucket),
+		Key:    aws.String(key),
+	}, s3.WithPresignExpires(r.expiry))
+	if err != nil {
+		return nil, fmt.Errorf("failed to presign asset %s: %w", key, err)
+	}
+	expiresAt := signedAt.Add(r.expiry).UTC()
+	return &Asset{Key: key, URL: request.URL, ExpiresAt: &expiresAt}, nil
+}
@@ -0,0 +1,55 @@
+package assets
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCDNResolver(t *testing.T) {
+	t.Run("Joins the base URL and escaped key", func(t *testing.T) {
+		r, err := NewCDNResolver("https://cdn.example.com/assets/")
+		require.NoError(t, err)
+
+		asset, err := r.Resolve(context.Background(), "acc-12345/logos/main street.png")
+		require.NoError(t, err)
+		assert.Equal(t, "https://cdn.example.com/assets/acc-12345/logos/main%20street.png", asset.URL)
+		assert.Equal(t, "acc-12345/logos/main street.png", asset.Key)
+		assert.Nil(t, asset.ExpiresAt)
+	})
+
+	t.Run("Rejects relative base URLs", func(t *testing.T) {
+		for _, baseURL := range []string{"cdn.example.com", "ftp://cdn.example.com", "https://"} {
+			_, err := NewCDNResolver(baseURL)
+			assert.Error(t, err, baseURL)
+		}
+	})
+}
+
+func TestS3Resolver(t *testing.T) {
+	signedAt := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	client := s3.New(s3.Options{
+		Region:      "us-east-1",
+		Credentials: credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""),
+	})
+	r := NewS3Resolver(s3.NewPresignClient(client), "brand-assets", 15*time.Minute)
+	r.now = func() time.Time { return signedAt }
+
+	asset, err := r.Resolve(context.Background(), "acc-12345/logo.png")
+	require.NoError(t, err)
+
+	parsed, err := url.Parse(asset.URL)
+	require.NoError(t, err)
+	assert.Contains(t, parsed.Host, "brand-assets")
+	assert.Equal(t, "/acc-12345/logo.png", parsed.Path)
+	assert.Equal(t, "900", parsed.Query().Get("X-Amz-Expires"))
+	assert.NotEmpty(t, parsed.Query().Get("X-Amz-Signature"))
+	require.NotNil(t, asset.ExpiresAt)
+	assert.Equal(t, signedAt.Add(15*time.Minute), *asset.ExpiresAt)
+}
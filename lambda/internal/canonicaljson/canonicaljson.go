@@ -0,0 +1,160 @@
+// Package canonicaljson encodes JSON deterministically, so records holding
+// the same values always compare and hash the same, whatever order their
+// maps were built in or however their numbers were written.
+package canonicaljson
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Marshal returns v as compact JSON with object keys sorted, numbers in
+// their shortest exact form, and no HTML escaping. Numbers are normalized as
+// decimals rather than through float64, so DynamoDB's 38-digit numbers keep
+// every digit: 1.50, 1.5e0, and 15E-1 all encode as 1.5.
+func Marshal(v interface{}) ([]byte, error) {
+	data, err := encode(v)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("failed to decode JSON: %w", err)
+	}
+	normalized, err := normalize(value)
+	if err != nil {
+		return nil, err
+	}
+	return encode(normalized)
+}
+
+// Hash returns the hex SHA-256 of v's canonical JSON.
+func Hash(v interface{}) (string, error) {
+	data, err := Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Equal reports whether a and b have the same canonical JSON.
+func Equal(a, b interface{}) (bool, error) {
+	left, err := Marshal(a)
+	if err != nil {
+		return false, err
+	}
+	right, err := Marshal(b)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(left, right), nil
+}
+
+// encode marshals v without HTML escaping or a trailing newline. Maps are
+// written with their keys sorted.
+func encode(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return nil, fmt.Errorf("failed to encode JSON: %w", err)
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// normalize rewrites the numbers within a decoded JSON value.
+func normalize(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case json.Number:
+		return normalizeNumber(v)
+	case map[string]interface{}:
+		for key, element := range v {
+			normalized, err := normalize(element)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = normalized
+		}
+	case []interface{}:
+		for i, element := range v {
+			normalized, err := normalize(element)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = normalized
+		}
+	}
+	return value, nil
+}
+
+// normalizeNumber returns the shortest exact form of a JSON number. Like
+// JavaScript's number formatting, it writes plain decimals for exponents
+// from -7 to 20 and scientific notation, as in 1.5e+21, outside them.
+func normalizeNumber(number json.Number) (json.Number, error) {
+	text := string(number)
+	negative := strings.HasPrefix(text, "-")
+	text = strings.TrimPrefix(text, "-")
+
+	exponent := 0
+	if i := strings.IndexAny(text, "eE"); i >= 0 {
+		e, err := strconv.Atoi(strings.TrimPrefix(text[i+1:], "+"))
+		if err != nil {
+			return "", fmt.Errorf("invalid number %q", number)
+		}
+		exponent, text = e, text[:i]
+	}
+	digits := text
+	if i := strings.IndexByte(text, '.'); i >= 0 {
+		digits = text[:i] + text[i+1:]
+		exponent -= len(text) - i - 1
+	}
+
+	// digits × 10^exponent, without leading or trailing zeros
+	digits = strings.TrimLeft(digits, "0")
+	if digits == "" {
+		return "0", nil
+	}
+	trimmed := strings.TrimRight(digits, "0")
+	exponent += len(digits) - len(trimmed)
+	digits = trimmed
+
+	var b strings.Builder
+	if negative {
+		b.WriteByte('-')
+	}
+	// point is the decimal point's position after the first digit
+	point := len(digits) + exponent
+	switch {
+	case exponent >= 0 && point <= 21:
+		b.WriteString(digits)
+		b.WriteString(strings.Repeat("0", exponent))
+	case point > 0 && point <= 21:
+		b.WriteString(digits[:point])
+		b.WriteByte('.')
+		b.WriteString(digits[point:])
+	case point > -6 && point <= 0:
+		b.WriteString("0.")
+		b.WriteString(strings.Repeat("0", -point))
+		b.WriteString(digits)
+	default:
+		b.WriteString(digits[:1])
+		if len(digits) > 1 {
+			b.WriteByte('.')
+			b.WriteString(digits[1:])
+		}
+		b.WriteByte('e')
+		if point-1 > 0 {
+			b.WriteByte('+')
+		}
+		b.WriteString(strconv.Itoa(point - 1))
+	}
+	return json.Number(b.String()), nil
+}
@@ -0,0 +1,74 @@
+package canonicaljson
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshal(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  string
+	}{
+		{
+			name:  "Sorts keys at every level",
+			value: map[string]interface{}{"b": 1, "a": map[string]interface{}{"z": true, "y": nil}},
+			want:  `{"a":{"y":null,"z":true},"b":1}`,
+		},
+		{
+			name: "Sorts struct fields",
+			value: struct {
+				Name     string  `json:"name"`
+				Latitude float64 `json:"latitude"`
+			}{Name: "Main Street Store", Latitude: 39.7},
+			want: `{"latitude":39.7,"name":"Main Street Store"}`,
+		},
+		{
+			name: "Normalizes numbers",
+			value: []json.Number{
+				"40.7128000", "1.5e0", "15E-1", "-0.0", "100", "1e2", "0.000001", "1e-7", "123456789012345678901234567890123456789", "1.5e21", "-2.50E-10",
+			},
+			want: `[40.7128,1.5,1.5,0,100,100,0.000001,1e-7,1.23456789012345678901234567890123456789e+38,1.5e+21,-2.5e-10]`,
+		},
+		{
+			name:  "Leaves HTML unescaped",
+			value: map[string]string{"name": "Fish & Chips <Downtown>"},
+			want:  `{"name":"Fish & Chips <Downtown>"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Marshal(tt.value)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestHash(t *testing.T) {
+	first, err := Hash(map[string]interface{}{"latitude": json.Number("40.7128000"), "longitude": json.Number("-74.006")})
+	require.NoError(t, err)
+	second, err := Hash(map[string]interface{}{"longitude": json.Number("-74.0060"), "latitude": json.Number("40.7128")})
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+	assert.Len(t, first, 64)
+
+	third, err := Hash(map[string]interface{}{"latitude": json.Number("40.7129"), "longitude": json.Number("-74.006")})
+	require.NoError(t, err)
+	assert.NotEqual(t, first, third)
+}
+
+func TestEqual(t *testing.T) {
+	equal, err := Equal(map[string]interface{}{"floor": json.Number("2.0")}, map[string]int{"floor": 2})
+	require.NoError(t, err)
+	assert.True(t, equal)
+
+	equal, err = Equal([]int{1, 2}, []int{2, 1})
+	require.NoError(t, err)
+	assert.False(t, equal, "arrays keep their order")
+}
@@ -0,0 +1,299 @@
+// Package changeexport writes location changes from the table's DynamoDB
+// stream to an S3 data lake that Athena can query.
+package changeexport
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/steverhoton/location-lambda/internal/canonicaljson"
+	"github.com/steverhoton/location-lambda/internal/repository"
+)
+
+// S3Client defines the interface for S3 operations used to write changes.
+type S3Client interface {
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+}
+
+// Change is one exported change to a location, a line of an export object.
+// The images are the DynamoDB items before and after the change, as plain
+// JSON; INSERT has no old image and REMOVE no new one.
+type Change struct {
+	EventID        string                 `json:"eventId"`
+	EventName      string                 `json:"eventName"` // INSERT, MODIFY, or REMOVE
+	AccountID      string                 `json:"accountId"`
+	LocationID     string                 `json:"locationId"`
+	ChangedAt      time.Time              `json:"changedAt"`
+	SequenceNumber string                 `json:"sequenceNumber"`
+	OldImage       map[string]interface{} `json:"oldImage,omitempty"`
+	NewImage       map[string]interface{} `json:"newImage,omitempty"`
+	// OldHash and NewHash are the SHA-256 of each image's canonical JSON, so
+	// versions of a location holding the same values share a hash
+	OldHash string `json:"oldHash,omitempty"`
+	NewHash string `json:"newHash,omitempty"`
+	// ChangedAttributes are the top-level attributes whose values a MODIFY
+	// changed, in order; rewriting an attribute with an equal value, such as
+	// 1.50 for 1.5, is not a change
+	ChangedAttributes []string `json:"changedAttributes,omitempty"`
+	// image is the stream's image of the location, its new one unless removed
+	image map[string]events.DynamoDBAttributeValue
+}
+
+// Exporter writes stream batches to S3 as gzipped JSON Lines, one object per
+// account and day under prefix/accountId=.../date=YYYY-MM-DD/, the Hive
+// partition layout Athena reads.
+type Exporter struct {
+	client S3Client
+	bucket string
+	prefix string
+	format Format
+}
+
+// NewExporter creates an exporter writing changes in format under prefix in
+// bucket.
+func NewExporter(client S3Client, bucket, prefix string, format Format) *Exporter {
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return &Exporter{client: client, bucket: bucket, prefix: prefix, format: format}
+}
+
+// partition is the account and day of a group of changes.
+type partition struct {
+	accountID string
+	date      string
+}
+
+// Export writes a stream batch's location changes, skipping the table's other
+// items. Each object is named after its first change's event ID, so a retried
+// batch overwrites its objects rather than duplicating them.
+func (e *Exporter) Export(ctx context.Context, event events.DynamoDBEvent) (int, error) {
+	changes, err := Changes(event)
+	if err != nil {
+		return 0, err
+	}
+	groups := map[partition][]Change{}
+	var order []partition
+	for _, change := range changes {
+		key := partition{accountID: change.AccountID, date: change.ChangedAt.Format("2006-01-02")}
+		if _, seen := groups[key]; !seen {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], change)
+	}
+
+	exported := 0
+	for _, key := range order {
+		changes := groups[key]
+		body, err := e.encode(changes)
+		if err != nil {
+			return exported, err
+		}
+		objectKey := fmt.Sprintf("%saccountId=%s/date=%s/%s.json.gz", e.prefix, key.accountID, key.date, changes[0].EventID)
+		_, err = e.client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket:          aws.String(e.bucket),
+			Key:             aws.String(objectKey),
+			Body:            bytes.NewReader(body),
+			ContentType:     aws.String("application/x-ndjson"),
+			ContentEncoding: aws.String("gzip"),
+		})
+		if err != nil {
+			return exported, fmt.Errorf("failed to put change export %s: %w", objectKey, err)
+		}
+		exported += len(changes)
+	}
+	return exported, nil
+}
+
+// Changes returns a stream batch's location changes in stream order, with
+// their hashes and changed attributes, skipping the table's other items.
+func Changes(event events.DynamoDBEvent) ([]Change, error) {
+	var changes []Change
+	for _, record := range event.Records {
+		change, ok := locationChange(record)
+		if !ok {
+			continue
+		}
+		change, err := withDiff(change)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// Location decodes the location from the change's new image, or its old one
+// when it was removed.
+func (c Change) Location() (*repository.LocationEnvelope, error) {
+	item := make(map[string]types.AttributeValue, len(c.image))
+	for name, value := range c.image {
+		item[name] = toAttributeValue(value)
+	}
+	envelope, err := repository.UnmarshalLocationItem(item)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read change %s: %w", c.EventID, err)
+	}
+	return envelope, nil
+}
+
+// locationChange converts a stream record to a Change, reporting false for
+// items other than locations, such as settings or templates. Locations are
+// recognised in either key layout.
+func locationChange(record events.DynamoDBEventRecord) (Change, bool) {
+	pk, sk := record.Change.Keys["PK"], record.Change.Keys["SK"]
+	if pk.DataType() != events.DataTypeString || sk.DataType() != events.DataTypeString {
+		return Change{}, false
+	}
+	accountID, locationID, ok := repository.LocationKeys(pk.String(), sk.String())
+	if !ok {
+		return Change{}, false
+	}
+	image := record.Change.NewImage
+	if image == nil {
+		image = record.Change.OldImage
+	}
+	if _, ok := image["locationType"]; !ok {
+		return Change{}, false
+	}
+
+	return Change{
+		EventID:        record.EventID,
+		EventName:      record.EventName,
+		AccountID:      accountID,
+		LocationID:     locationID,
+		ChangedAt:      record.Change.ApproximateCreationDateTime.UTC(),
+		SequenceNumber: record.Change.SequenceNumber,
+		OldImage:       plainMap(record.Change.OldImage),
+		NewImage:       plainMap(record.Change.NewImage),
+		image:          image,
+	}, true
+}
+
+// withDiff sets a change's image hashes and changed attributes, comparing
+// the images' canonical JSON so map ordering and number formatting never
+// show as changes.
+func withDiff(change Change) (Change, error) {
+	var err error
+	change.ChangedAttributes = nil
+	if change.OldImage != nil {
+		if change.OldHash, err = canonicaljson.Hash(change.OldImage); err != nil {
+			return change, fmt.Errorf("failed to hash change %s: %w", change.EventID, err)
+		}
+	}
+	if change.NewImage != nil {
+		if change.NewHash, err = canonicaljson.Hash(change.NewImage); err != nil {
+			return change, fmt.Errorf("failed to hash change %s: %w", change.EventID, err)
+		}
+	}
+	if change.OldImage == nil || change.NewImage == nil || change.OldHash == change.NewHash {
+		return change, nil
+	}
+
+	names := make(map[string]bool, len(change.NewImage))
+	for name := range change.OldImage {
+		names[name] = true
+	}
+	for name := range change.NewImage {
+		names[name] = true
+	}
+	for name := range names {
+		old, inOld := change.OldImage[name]
+		updated, inNew := change.NewImage[name]
+		equal := inOld == inNew
+		if equal && inOld {
+			if equal, err = canonicaljson.Equal(old, updated); err != nil {
+				return change, fmt.Errorf("failed to compare %s of change %s: %w", name, change.EventID, err)
+			}
+		}
+		if !equal {
+			change.ChangedAttributes = append(change.ChangedAttributes, name)
+		}
+	}
+	sort.Strings(change.ChangedAttributes)
+	return change, nil
+}
+
+// encode gzips changes as JSON Lines in the exporter's format.
+func (e *Exporter) encode(changes []Change) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	encoder := json.NewEncoder(gz)
+	for _, change := range changes {
+		var line interface{} = change
+		if e.format == FormatFlat {
+			row, err := flatRow(change)
+			if err != nil {
+				return nil, err
+			}
+			line = row
+		}
+		if err := encoder.Encode(line); err != nil {
+			return nil, fmt.Errorf("failed to encode change %s: %w", change.EventID, err)
+		}
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress changes: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// plainMap converts a DynamoDB item to plain JSON values, or nil for no item.
+func plainMap(item map[string]events.DynamoDBAttributeValue) map[string]interface{} {
+	if item == nil {
+		return nil
+	}
+	plain := make(map[string]interface{}, len(item))
+	for name, value := range item {
+		plain[name] = plainValue(value)
+	}
+	return plain
+}
+
+// plainValue converts a DynamoDB attribute value to a plain JSON value.
+// Numbers keep their exact text, binary values become base64 strings, and
+// sets become sorted arrays.
+func plainValue(value events.DynamoDBAttributeValue) interface{} {
+	switch value.DataType() {
+	case events.DataTypeString:
+		return value.String()
+	case events.DataTypeNumber:
+		return json.Number(value.Number())
+	case events.DataTypeBoolean:
+		return value.Boolean()
+	case events.DataTypeBinary:
+		return value.Binary()
+	case events.DataTypeMap:
+		return plainMap(value.Map())
+	case events.DataTypeList:
+		list := make([]interface{}, 0, len(value.List()))
+		for _, element := range value.List() {
+			list = append(list, plainValue(element))
+		}
+		return list
+	case events.DataTypeStringSet:
+		set := append([]string(nil), value.StringSet()...)
+		sort.Strings(set)
+		return set
+	case events.DataTypeNumberSet:
+		numbers := make([]json.Number, 0, len(value.NumberSet()))
+		for _, number := range value.NumberSet() {
+			numbers = append(numbers, json.Number(number))
+		}
+		return numbers
+	case events.DataTypeBinarySet:
+		return value.BinarySet()
+	default:
+		return nil
+	}
+}
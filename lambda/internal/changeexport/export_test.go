@@ -0,0 +1,222 @@
+package changeexport
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockS3Client is a mock implementation of the S3Client interface.
+type mockS3Client struct {
+	mock.Mock
+}
+
+func (m *mockS3Client) PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*s3.PutObjectOutput), args.Error(1)
+}
+
+// streamEvent is a DynamoDB stream batch as Lambda delivers it.
+const streamEvent = `{"Records": [
+	{
+		"eventID": "evt-1", "eventName": "INSERT", "eventSource": "aws:dynamodb",
+		"dynamodb": {
+			"ApproximateCreationDateTime": 1717243200,
+			"Keys": {"PK": {"S": "acc-12345"}, "SK": {"S": "loc-001"}},
+			"NewImage": {
+				"PK": {"S": "acc-12345"}, "SK": {"S": "loc-001"}, "locationType": {"S": "coordinates"},
+				"coordinates": {"M": {"latitude": {"N": "40.7128000"}, "longitude": {"N": "-74.006"}}},
+				"draft": {"BOOL": false}, "tags": {"SS": ["b", "a"]}, "units": {"L": [{"S": "1A"}]}
+			},
+			"SequenceNumber": "100"
+		}
+	},
+	{
+		"eventID": "evt-2", "eventName": "MODIFY", "eventSource": "aws:dynamodb",
+		"dynamodb": {
+			"ApproximateCreationDateTime": 1717243260,
+			"Keys": {"PK": {"S": "ACCOUNT#acc-12345"}, "SK": {"S": "settings"}},
+			"NewImage": {"PK": {"S": "ACCOUNT#acc-12345"}, "SK": {"S": "settings"}},
+			"SequenceNumber": "101"
+		}
+	},
+	{
+		"eventID": "evt-3", "eventName": "REMOVE", "eventSource": "aws:dynamodb",
+		"dynamodb": {
+			"ApproximateCreationDateTime": 1717329700,
+			"Keys": {"PK": {"S": "acc-12345"}, "SK": {"S": "loc-002"}},
+			"OldImage": {"PK": {"S": "acc-12345"}, "SK": {"S": "loc-002"}, "locationType": {"S": "shop"}},
+			"SequenceNumber": "102"
+		}
+	},
+	{
+		"eventID": "evt-4", "eventName": "MODIFY", "eventSource": "aws:dynamodb",
+		"dynamodb": {
+			"ApproximateCreationDateTime": 1717243300,
+			"Keys": {"PK": {"S": "acc-67890"}, "SK": {"S": "loc-003"}},
+			"OldImage": {"PK": {"S": "acc-67890"}, "SK": {"S": "loc-003"}, "locationType": {"S": "address"}},
+			"NewImage": {"PK": {"S": "acc-67890"}, "SK": {"S": "loc-003"}, "locationType": {"S": "address"}, "externalId": {"S": "store-9"}},
+			"SequenceNumber": "103"
+		}
+	}
+]}`
+
+// readChanges decompresses an export object's changes.
+func readChanges(t *testing.T, body io.Reader) []Change {
+	gz, err := gzip.NewReader(body)
+	require.NoError(t, err)
+	var changes []Change
+	scanner := bufio.NewScanner(gz)
+	for scanner.Scan() {
+		var change Change
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &change))
+		changes = append(changes, change)
+	}
+	require.NoError(t, scanner.Err())
+	return changes
+}
+
+func TestChanges(t *testing.T) {
+	var event events.DynamoDBEvent
+	require.NoError(t, json.Unmarshal([]byte(streamEvent), &event))
+
+	changes, err := Changes(event)
+	require.NoError(t, err)
+	require.Len(t, changes, 3)
+	assert.Equal(t, []string{"evt-1", "evt-3", "evt-4"}, []string{changes[0].EventID, changes[1].EventID, changes[2].EventID})
+	assert.Equal(t, []string{"externalId"}, changes[2].ChangedAttributes)
+}
+
+func TestExporterExport(t *testing.T) {
+	ctx := context.Background()
+	var event events.DynamoDBEvent
+	require.NoError(t, json.Unmarshal([]byte(streamEvent), &event))
+
+	t.Run("Partitions location changes by account and day", func(t *testing.T) {
+		client := new(mockS3Client)
+		exporter := NewExporter(client, "lake", "locations", FormatImages)
+
+		objects := map[string][]Change{}
+		client.On("PutObject", ctx, mock.Anything).Run(func(args mock.Arguments) {
+			input := args.Get(1).(*s3.PutObjectInput)
+			assert.Equal(t, "lake", aws.ToString(input.Bucket))
+			assert.Equal(t, "gzip", aws.ToString(input.ContentEncoding))
+			objects[aws.ToString(input.Key)] = readChanges(t, input.Body)
+		}).Return(&s3.PutObjectOutput{}, nil)
+
+		exported, err := exporter.Export(ctx, event)
+		require.NoError(t, err)
+		assert.Equal(t, 3, exported)
+		require.Len(t, objects, 3)
+
+		inserted := objects["locations/accountId=acc-12345/date=2024-06-01/evt-1.json.gz"]
+		require.Len(t, inserted, 1)
+		assert.Equal(t, "INSERT", inserted[0].EventName)
+		assert.Equal(t, "loc-001", inserted[0].LocationID)
+		assert.Equal(t, time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC), inserted[0].ChangedAt)
+		assert.Nil(t, inserted[0].OldImage)
+		assert.Equal(t, map[string]interface{}{"latitude": 40.7128, "longitude": -74.006}, inserted[0].NewImage["coordinates"])
+		assert.Equal(t, []interface{}{"a", "b"}, inserted[0].NewImage["tags"])
+
+		removed := objects["locations/accountId=acc-12345/date=2024-06-02/evt-3.json.gz"]
+		require.Len(t, removed, 1)
+		assert.Equal(t, "REMOVE", removed[0].EventName)
+		assert.Nil(t, removed[0].NewImage)
+		assert.Equal(t, "shop", removed[0].OldImage["locationType"])
+
+		modified := objects["locations/accountId=acc-67890/date=2024-06-01/evt-4.json.gz"]
+		require.Len(t, modified, 1)
+		assert.Equal(t, "store-9", modified[0].NewImage["externalId"])
+		assert.Equal(t, []string{"externalId"}, modified[0].ChangedAttributes)
+		assert.NotEqual(t, modified[0].OldHash, modified[0].NewHash)
+	})
+
+	t.Run("Ignores reformatted numbers and reordered maps in diffs", func(t *testing.T) {
+		var modify events.DynamoDBEvent
+		require.NoError(t, json.Unmarshal([]byte(`{"Records": [{
+			"eventID": "evt-5", "eventName": "MODIFY", "eventSource": "aws:dynamodb",
+			"dynamodb": {
+				"ApproximateCreationDateTime": 1717243200,
+				"Keys": {"PK": {"S": "acc-12345"}, "SK": {"S": "loc-001"}},
+				"OldImage": {
+					"PK": {"S": "acc-12345"}, "SK": {"S": "loc-001"}, "locationType": {"S": "coordinates"},
+					"coordinates": {"M": {"latitude": {"N": "40.7128000"}, "longitude": {"N": "-74.006"}}}, "floor": {"S": "1"}
+				},
+				"NewImage": {
+					"PK": {"S": "acc-12345"}, "SK": {"S": "loc-001"}, "locationType": {"S": "coordinates"},
+					"coordinates": {"M": {"longitude": {"N": "-74.0060"}, "latitude": {"N": "40.7128"}}}, "floor": {"S": "2"}
+				},
+				"SequenceNumber": "104"
+			}
+		}]}`), &modify))
+
+		change, ok := locationChange(modify.Records[0])
+		require.True(t, ok)
+		change, err := withDiff(change)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"floor"}, change.ChangedAttributes)
+
+		change.NewImage["floor"] = "1"
+		change, err = withDiff(change)
+		require.NoError(t, err)
+		assert.Equal(t, change.OldHash, change.NewHash)
+		assert.Empty(t, change.ChangedAttributes)
+	})
+
+	t.Run("Keeps numbers as written", func(t *testing.T) {
+		change, ok := locationChange(event.Records[0])
+		require.True(t, ok)
+		line, err := json.Marshal(change.NewImage["coordinates"])
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"latitude":40.7128000,"longitude":-74.006}`, string(line))
+		assert.Contains(t, string(line), "40.7128000")
+	})
+
+	t.Run("Fails the batch when a write fails", func(t *testing.T) {
+		client := new(mockS3Client)
+		exporter := NewExporter(client, "lake", "", FormatImages)
+		client.On("PutObject", ctx, mock.Anything).Return(nil, errors.New("access denied")).Once()
+
+		_, err := exporter.Export(ctx, event)
+		assert.EqualError(t, err, "failed to put change export accountId=acc-12345/date=2024-06-01/evt-1.json.gz: access denied")
+	})
+}
+
+func TestChangesSingleTableKeys(t *testing.T) {
+	event := events.DynamoDBEvent{Records: []events.DynamoDBEventRecord{{
+		EventID:   "evt-1",
+		EventName: "REMOVE",
+		Change: events.DynamoDBStreamRecord{
+			Keys: map[string]events.DynamoDBAttributeValue{
+				"PK": events.NewStringAttribute("ACCOUNT#acc-12345"),
+				"SK": events.NewStringAttribute("LOCATION#loc-001"),
+			},
+			OldImage: map[string]events.DynamoDBAttributeValue{
+				"PK":           events.NewStringAttribute("ACCOUNT#acc-12345"),
+				"SK":           events.NewStringAttribute("LOCATION#loc-001"),
+				"locationType": events.NewStringAttribute("shop"),
+			},
+		},
+	}}}
+
+	changes, err := Changes(event)
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.Equal(t, "acc-12345", changes[0].AccountID)
+	assert.Equal(t, "loc-001", changes[0].LocationID)
+}
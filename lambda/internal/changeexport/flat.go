@@ -0,0 +1,321 @@
+package changeexport
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/steverhoton/location-lambda/internal/canonicaljson"
+	"github.com/steverhoton/location-lambda/internal/models"
+)
+
+// Format is the shape of exported changes.
+type Format string
+
+// Export formats.
+const (
+	// FormatImages writes each change's DynamoDB images as nested JSON.
+	FormatImages Format = "images"
+	// FormatFlat writes each change as one row of the columns from Columns,
+	// for a Glue table that needs no per-team ETL.
+	FormatFlat Format = "flat"
+)
+
+// ParseFormat parses a format name, defaulting to FormatImages when empty.
+func ParseFormat(name string) (Format, error) {
+	switch Format(name) {
+	case "", FormatImages:
+		return FormatImages, nil
+	case FormatFlat:
+		return FormatFlat, nil
+	default:
+		return "", fmt.Errorf("change export format must be %s or %s, got %q", FormatImages, FormatFlat, name)
+	}
+}
+
+// Glue column types used by the flat format.
+const (
+	ColumnString    = "string"
+	ColumnDouble    = "double"
+	ColumnBigint    = "bigint"
+	ColumnBoolean   = "boolean"
+	ColumnTimestamp = "timestamp"
+)
+
+// timestampLayout is the text form of timestamp columns that Athena's JSON
+// SerDes read.
+const timestampLayout = "2006-01-02 15:04:05.000"
+
+// Column is a column of the flat format's Glue table.
+type Column struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	// path is the column's field path in a location's JSON
+	path []string
+}
+
+// TableDefinition is the flat format's Glue table: its columns and the
+// partition keys taken from the object keys.
+type TableDefinition struct {
+	Columns       []Column `json:"columns"`
+	PartitionKeys []Column `json:"partitionKeys"`
+}
+
+// changeColumns lead every flat row, describing the change itself.
+var changeColumns = []Column{
+	{Name: "event_id", Type: ColumnString},
+	{Name: "event_name", Type: ColumnString},
+	{Name: "location_id", Type: ColumnString},
+	{Name: "changed_at", Type: ColumnTimestamp},
+	{Name: "sequence_number", Type: ColumnString},
+}
+
+// locationModels are the location types whose fields become columns, in
+// column order.
+var locationModels = []interface{}{
+	models.AddressLocation{},
+	models.CoordinatesLocation{},
+	models.ShopLocation{},
+	models.EventLocation{},
+}
+
+// locationColumns are the columns generated from locationModels.
+var locationColumns = generateColumns()
+
+// Columns returns the flat format's columns: the change's own, then every
+// field of every location type, nested fields joined with underscores, such
+// as shop_address_city. Lists and maps, such as units or customFields, are
+// JSON text columns. accountId is left out as it is a partition key.
+func Columns() []Column {
+	return append(append([]Column(nil), changeColumns...), locationColumns...)
+}
+
+// Table returns the flat format's Glue table definition.
+func Table() TableDefinition {
+	return TableDefinition{
+		Columns: Columns(),
+		PartitionKeys: []Column{
+			{Name: "accountid", Type: ColumnString},
+			{Name: "date", Type: ColumnString},
+		},
+	}
+}
+
+// generateColumns walks the location models' JSON fields. The first type to
+// declare a column gives its type; a field shared by several types, such as
+// coordinates, is one column.
+func generateColumns() []Column {
+	var columns []Column
+	seen := map[string]bool{"account_id": true}
+	for _, model := range locationModels {
+		for _, column := range structColumns(reflect.TypeOf(model), nil, nil) {
+			if !seen[column.Name] {
+				seen[column.Name] = true
+				columns = append(columns, column)
+			}
+		}
+	}
+	return columns
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// structColumns returns the columns of a struct's JSON fields under path.
+// ancestors holds the structs already being walked, so a recursive type such
+// as an address's standardized address becomes a JSON text column.
+func structColumns(t reflect.Type, path []string, ancestors []reflect.Type) []Column {
+	ancestors = append(ancestors, t)
+	var columns []Column
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && fieldType.Kind() == reflect.Struct {
+			columns = append(columns, structColumns(fieldType, path, ancestors)...)
+			continue
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" || name == "" {
+			continue
+		}
+		fieldPath := append(append([]string(nil), path...), name)
+
+		if fieldType.Kind() == reflect.Struct && fieldType != timeType && !containsType(ancestors, fieldType) {
+			columns = append(columns, structColumns(fieldType, fieldPath, ancestors)...)
+			continue
+		}
+		columns = append(columns, Column{Name: columnName(fieldPath), Type: columnType(fieldType), path: fieldPath})
+	}
+	return columns
+}
+
+// containsType reports whether t is among types.
+func containsType(types []reflect.Type, t reflect.Type) bool {
+	for _, candidate := range types {
+		if candidate == t {
+			return true
+		}
+	}
+	return false
+}
+
+// columnType returns the Glue type of a field, JSON text for anything
+// without a scalar one.
+func columnType(t reflect.Type) string {
+	if t == timeType {
+		return ColumnTimestamp
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return ColumnBoolean
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return ColumnBigint
+	case reflect.Float32, reflect.Float64:
+		return ColumnDouble
+	default:
+		return ColumnString
+	}
+}
+
+// columnName joins a field path in snake case, the case Athena folds to.
+func columnName(path []string) string {
+	parts := make([]string, 0, len(path))
+	for _, name := range path {
+		var b strings.Builder
+		for i, r := range name {
+			if unicode.IsUpper(r) {
+				if i > 0 && !unicode.IsUpper(rune(name[i-1])) {
+					b.WriteByte('_')
+				}
+				r = unicode.ToLower(r)
+			}
+			b.WriteRune(r)
+		}
+		parts = append(parts, b.String())
+	}
+	return strings.Join(parts, "_")
+}
+
+// flatRow converts a change to a row of the flat format. Empty columns are
+// left out of the row, which Athena reads as null.
+func flatRow(change Change) (map[string]interface{}, error) {
+	envelope, err := change.Location()
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(envelope.Location)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode change %s: %w", change.EventID, err)
+	}
+	var location map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&location); err != nil {
+		return nil, fmt.Errorf("failed to decode change %s: %w", change.EventID, err)
+	}
+
+	row := map[string]interface{}{
+		"event_id":        change.EventID,
+		"event_name":      change.EventName,
+		"location_id":     change.LocationID,
+		"changed_at":      change.ChangedAt.Format(timestampLayout),
+		"sequence_number": change.SequenceNumber,
+	}
+	for _, column := range locationColumns {
+		value, ok := lookup(location, column.path)
+		if !ok {
+			continue
+		}
+		cell, err := flatValue(column, value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to flatten %s of change %s: %w", column.Name, change.EventID, err)
+		}
+		row[column.Name] = cell
+	}
+	return row, nil
+}
+
+// lookup returns the value at path in a decoded JSON object.
+func lookup(object map[string]interface{}, path []string) (interface{}, bool) {
+	var value interface{} = object
+	for _, name := range path {
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = fields[name]; !ok || value == nil {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// flatValue converts a decoded JSON value to a cell of column.
+func flatValue(column Column, value interface{}) (interface{}, error) {
+	switch column.Type {
+	case ColumnTimestamp:
+		text, _ := value.(string)
+		at, err := time.Parse(time.RFC3339Nano, text)
+		if err != nil {
+			return nil, err
+		}
+		return at.UTC().Format(timestampLayout), nil
+	case ColumnString:
+		if text, ok := value.(string); ok {
+			return text, nil
+		}
+		data, err := canonicaljson.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		return string(data), nil
+	default:
+		return value, nil
+	}
+}
+
+// toAttributeValue converts a stream attribute value to the SDK's type.
+func toAttributeValue(value events.DynamoDBAttributeValue) types.AttributeValue {
+	switch value.DataType() {
+	case events.DataTypeString:
+		return &types.AttributeValueMemberS{Value: value.String()}
+	case events.DataTypeNumber:
+		return &types.AttributeValueMemberN{Value: value.Number()}
+	case events.DataTypeBoolean:
+		return &types.AttributeValueMemberBOOL{Value: value.Boolean()}
+	case events.DataTypeBinary:
+		return &types.AttributeValueMemberB{Value: value.Binary()}
+	case events.DataTypeMap:
+		fields := make(map[string]types.AttributeValue, len(value.Map()))
+		for name, field := range value.Map() {
+			fields[name] = toAttributeValue(field)
+		}
+		return &types.AttributeValueMemberM{Value: fields}
+	case events.DataTypeList:
+		list := make([]types.AttributeValue, 0, len(value.List()))
+		for _, element := range value.List() {
+			list = append(list, toAttributeValue(element))
+		}
+		return &types.AttributeValueMemberL{Value: list}
+	case events.DataTypeStringSet:
+		return &types.AttributeValueMemberSS{Value: value.StringSet()}
+	case events.DataTypeNumberSet:
+		return &types.AttributeValueMemberNS{Value: value.NumberSet()}
+	case events.DataTypeBinarySet:
+		return &types.AttributeValueMemberBS{Value: value.BinarySet()}
+	default:
+		return &types.AttributeValueMemberNULL{Value: true}
+	}
+}
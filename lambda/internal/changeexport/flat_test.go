@@ -0,0 +1,201 @@
+package changeexport
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// updateTable rewrites the published Glue table definition from the models:
+//
+//	go test ./internal/changeexport -run TestPublishedTable -update
+var updateTable = flag.Bool("update", false, "rewrite the published Glue table definition")
+
+// publishedTable is the table definition Terraform creates the Glue table from.
+var publishedTable = filepath.Join("..", "..", "..", "terraform", "change_export_table.json")
+
+// readRows decompresses a flat export object's rows.
+func readRows(t *testing.T, body io.Reader) []map[string]interface{} {
+	gz, err := gzip.NewReader(body)
+	require.NoError(t, err)
+	var rows []map[string]interface{}
+	scanner := bufio.NewScanner(gz)
+	for scanner.Scan() {
+		var row map[string]interface{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &row))
+		rows = append(rows, row)
+	}
+	require.NoError(t, scanner.Err())
+	return rows
+}
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		name   string
+		want   Format
+		errMsg string
+	}{
+		{name: "", want: FormatImages},
+		{name: "images", want: FormatImages},
+		{name: "flat", want: FormatFlat},
+		{name: "parquet", errMsg: `change export format must be images or flat, got "parquet"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format, err := ParseFormat(tt.name)
+			if tt.errMsg != "" {
+				assert.EqualError(t, err, tt.errMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, format)
+		})
+	}
+}
+
+func TestColumns(t *testing.T) {
+	types := map[string]string{}
+	for _, column := range Columns() {
+		_, repeated := types[column.Name]
+		assert.False(t, repeated, "column %s is repeated", column.Name)
+		types[column.Name] = column.Type
+	}
+
+	tests := []struct {
+		column string
+		want   string
+	}{
+		{column: "event_id", want: ColumnString},
+		{column: "changed_at", want: ColumnTimestamp},
+		{column: "location_type", want: ColumnString},
+		{column: "draft", want: ColumnBoolean},
+		{column: "active_from", want: ColumnTimestamp},
+		{column: "address_city", want: ColumnString},
+		{column: "address_verification_verified_at", want: ColumnTimestamp},
+		{column: "address_verification_standardized_address", want: ColumnString},
+		{column: "coordinates_latitude", want: ColumnDouble},
+		{column: "coordinates_altitude", want: ColumnDouble},
+		{column: "coordinates_locked", want: ColumnBoolean},
+		{column: "indoor_coordinates_floor_plan_key", want: ColumnString},
+		{column: "shop_name", want: ColumnString},
+		{column: "shop_address_postal_code", want: ColumnString},
+		{column: "shop_coordinates_longitude", want: ColumnDouble},
+		{column: "shop_categories", want: ColumnString},
+		{column: "units", want: ColumnString},
+		{column: "custom_fields", want: ColumnString},
+		{column: "starts_at", want: ColumnTimestamp},
+		{column: "recurrence_rule", want: ColumnString},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.column, func(t *testing.T) {
+			assert.Equal(t, tt.want, types[tt.column])
+		})
+	}
+
+	t.Run("Leaves out the partition key", func(t *testing.T) {
+		assert.NotContains(t, types, "account_id")
+	})
+}
+
+func TestPublishedTable(t *testing.T) {
+	actual, err := json.MarshalIndent(Table(), "", "  ")
+	require.NoError(t, err)
+	actual = append(actual, '\n')
+
+	if *updateTable {
+		require.NoError(t, os.WriteFile(publishedTable, actual, 0o644))
+	}
+	expected, err := os.ReadFile(publishedTable)
+	require.NoError(t, err, "missing table definition; run with -update to create it")
+	assert.Equal(t, string(expected), string(actual), "the location models changed the flat columns; run with -update to publish them")
+}
+
+func TestExporterExportFlat(t *testing.T) {
+	ctx := context.Background()
+	var event events.DynamoDBEvent
+	require.NoError(t, json.Unmarshal([]byte(streamEvent), &event))
+	// The flat format reads images through the models, so they must be
+	// shaped like locations: the fixture's units aren't, and its shop and
+	// address locations lack their shop and address
+	delete(event.Records[0].Change.NewImage, "units")
+	event.Records[2].Change.OldImage["shop"] = events.NewMapAttribute(map[string]events.DynamoDBAttributeValue{
+		"name": events.NewStringAttribute("Downtown Store"),
+	})
+	event.Records[3].Change.NewImage["address"] = events.NewMapAttribute(map[string]events.DynamoDBAttributeValue{
+		"city":    events.NewStringAttribute("Springfield"),
+		"country": events.NewStringAttribute("US"),
+	})
+
+	client := new(mockS3Client)
+	exporter := NewExporter(client, "lake", "flat/", FormatFlat)
+	objects := map[string][]map[string]interface{}{}
+	client.On("PutObject", ctx, mock.Anything).Run(func(args mock.Arguments) {
+		input := args.Get(1).(*s3.PutObjectInput)
+		objects[aws.ToString(input.Key)] = readRows(t, input.Body)
+	}).Return(&s3.PutObjectOutput{}, nil)
+
+	exported, err := exporter.Export(ctx, event)
+	require.NoError(t, err)
+	assert.Equal(t, 3, exported)
+
+	t.Run("Flattens nested fields", func(t *testing.T) {
+		inserted := objects["flat/accountId=acc-12345/date=2024-06-01/evt-1.json.gz"]
+		require.Len(t, inserted, 1)
+		assert.Equal(t, map[string]interface{}{
+			"event_id":              "evt-1",
+			"event_name":            "INSERT",
+			"location_id":           "loc-001",
+			"changed_at":            "2024-06-01 12:00:00.000",
+			"sequence_number":       "100",
+			"location_type":         "coordinates",
+			"coordinates_latitude":  40.7128,
+			"coordinates_longitude": -74.006,
+		}, inserted[0])
+	})
+
+	t.Run("Writes the old image of a removed location", func(t *testing.T) {
+		removed := objects["flat/accountId=acc-12345/date=2024-06-02/evt-3.json.gz"]
+		require.Len(t, removed, 1)
+		assert.Equal(t, "REMOVE", removed[0]["event_name"])
+		assert.Equal(t, "shop", removed[0]["location_type"])
+		assert.Equal(t, "Downtown Store", removed[0]["shop_name"])
+	})
+
+	t.Run("Writes lists and maps as JSON text", func(t *testing.T) {
+		change, ok := locationChange(event.Records[3])
+		require.True(t, ok)
+		change.image["units"] = events.NewListAttribute([]events.DynamoDBAttributeValue{
+			events.NewMapAttribute(map[string]events.DynamoDBAttributeValue{"number": events.NewStringAttribute("101")}),
+		})
+
+		row, err := flatRow(change)
+		require.NoError(t, err)
+		assert.Equal(t, `[{"number":"101"}]`, row["units"])
+		assert.Equal(t, "Springfield", row["address_city"])
+		assert.Equal(t, "store-9", row["external_id"])
+	})
+
+	t.Run("Fails the batch on a malformed location", func(t *testing.T) {
+		change, ok := locationChange(event.Records[0])
+		require.True(t, ok)
+		change.image["draft"] = events.NewStringAttribute("yes")
+
+		_, err := flatRow(change)
+		assert.ErrorContains(t, err, "failed to read change evt-1")
+	})
+}
@@ -0,0 +1,66 @@
+// Package config loads deployment settings that shape the API's behavior
+// from environment variables.
+package config
+
+import (
+	"fmt"
+	"strconv"
+)
+
+const (
+	// DefaultListLimit is the page size used when a list request gives no limit.
+	DefaultListLimit int32 = 20
+	// DefaultMaxListLimit is the largest page size a list request may ask for.
+	DefaultMaxListLimit int32 = 100
+)
+
+// ListLimits bounds the page size of list operations.
+type ListLimits struct {
+	Default int32 // Page size when the request gives no limit
+	Max     int32 // Largest page size a request may ask for
+}
+
+// DefaultListLimits returns the limits used when none are configured.
+func DefaultListLimits() ListLimits {
+	return ListLimits{Default: DefaultListLimit, Max: DefaultMaxListLimit}
+}
+
+// Resolve returns the page size for a requested limit, applying the default
+// when requested is nil and rejecting limits outside 1 to Max.
+func (l ListLimits) Resolve(requested *int32) (int32, error) {
+	if requested == nil {
+		return l.Default, nil
+	}
+	if *requested <= 0 || *requested > l.Max {
+		return 0, fmt.Errorf("limit must be between 1 and %d", l.Max)
+	}
+	return *requested, nil
+}
+
+// LoadListLimits reads LIST_DEFAULT_LIMIT and LIST_MAX_LIMIT with getenv,
+// falling back to DefaultListLimits for unset values.
+func LoadListLimits(getenv func(string) string) (ListLimits, error) {
+	limits := DefaultListLimits()
+	if value := getenv("LIST_MAX_LIMIT"); value != "" {
+		max, err := strconv.ParseInt(value, 10, 32)
+		if err != nil || max <= 0 {
+			return ListLimits{}, fmt.Errorf("LIST_MAX_LIMIT must be a positive integer")
+		}
+		limits.Max = int32(max)
+		// Keep the built-in default within a lowered cap
+		if limits.Default > limits.Max {
+			limits.Default = limits.Max
+		}
+	}
+	if value := getenv("LIST_DEFAULT_LIMIT"); value != "" {
+		def, err := strconv.ParseInt(value, 10, 32)
+		if err != nil || def <= 0 {
+			return ListLimits{}, fmt.Errorf("LIST_DEFAULT_LIMIT must be a positive integer")
+		}
+		limits.Default = int32(def)
+	}
+	if limits.Default > limits.Max {
+		return ListLimits{}, fmt.Errorf("LIST_DEFAULT_LIMIT (%d) must not exceed LIST_MAX_LIMIT (%d)", limits.Default, limits.Max)
+	}
+	return limits, nil
+}
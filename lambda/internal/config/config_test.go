@@ -0,0 +1,77 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadListLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    ListLimits
+		wantErr string
+	}{
+		{
+			name: "Defaults",
+			want: ListLimits{Default: 20, Max: 100},
+		},
+		{
+			name: "Both configured",
+			env:  map[string]string{"LIST_DEFAULT_LIMIT": "50", "LIST_MAX_LIMIT": "500"},
+			want: ListLimits{Default: 50, Max: 500},
+		},
+		{
+			name: "Lower cap pulls the built-in default down",
+			env:  map[string]string{"LIST_MAX_LIMIT": "10"},
+			want: ListLimits{Default: 10, Max: 10},
+		},
+		{
+			name:    "Default above cap",
+			env:     map[string]string{"LIST_DEFAULT_LIMIT": "200"},
+			wantErr: "LIST_DEFAULT_LIMIT (200) must not exceed LIST_MAX_LIMIT (100)",
+		},
+		{
+			name:    "Non-numeric default",
+			env:     map[string]string{"LIST_DEFAULT_LIMIT": "many"},
+			wantErr: "LIST_DEFAULT_LIMIT must be a positive integer",
+		},
+		{
+			name:    "Zero cap",
+			env:     map[string]string{"LIST_MAX_LIMIT": "0"},
+			wantErr: "LIST_MAX_LIMIT must be a positive integer",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LoadListLimits(func(key string) string { return tt.env[key] })
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestListLimitsResolve(t *testing.T) {
+	limits := ListLimits{Default: 20, Max: 100}
+
+	got, err := limits.Resolve(nil)
+	require.NoError(t, err)
+	assert.Equal(t, int32(20), got)
+
+	got, err = limits.Resolve(aws.Int32(100))
+	require.NoError(t, err)
+	assert.Equal(t, int32(100), got)
+
+	for _, limit := range []int32{0, -1, 101} {
+		_, err := limits.Resolve(aws.Int32(limit))
+		assert.EqualError(t, err, "limit must be between 1 and 100", "limit %d", limit)
+	}
+}
@@ -0,0 +1,219 @@
+// Package dedupe scores pairs of locations that are likely to describe the same place.
+package dedupe
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/steverhoton/location-lambda/internal/geo"
+	"github.com/steverhoton/location-lambda/internal/models"
+)
+
+// ProximityRadiusMeters is the distance within which two coordinate locations
+// are considered candidates. Pairs at the same point score 1, falling to 0.5
+// at the radius.
+const ProximityRadiusMeters = 50.0
+
+// Entry is a stored location with its ID.
+type Entry struct {
+	LocationID string
+	Location   models.Location
+}
+
+// Candidate is a pair of locations that may be duplicates.
+type Candidate struct {
+	LocationIDs    []string `json:"locationIds"` // The pair, ordered by location ID
+	Score          float64  `json:"score"`       // 0-1 likelihood that the pair is a duplicate
+	Reasons        []string `json:"reasons"`
+	DistanceMeters *float64 `json:"distanceMeters,omitempty"`
+}
+
+// abbreviations maps common address words to a canonical short form.
+var abbreviations = map[string]string{
+	"street": "st", "avenue": "ave", "av": "ave", "road": "rd", "boulevard": "blvd",
+	"drive": "dr", "lane": "ln", "court": "ct", "place": "pl", "terrace": "ter",
+	"circle": "cir", "parkway": "pkwy", "highway": "hwy", "square": "sq", "trail": "trl",
+	"plaza": "plz", "north": "n", "south": "s", "east": "e", "west": "w",
+	"northeast": "ne", "northwest": "nw", "southeast": "se", "southwest": "sw",
+	"suite": "ste", "apartment": "apt", "unit": "apt", "floor": "fl", "room": "rm",
+	"building": "bldg", "saint": "st", "mount": "mt",
+}
+
+// NormalizeAddress returns a canonical form of an address for comparison:
+// lowercased, punctuation removed, common words abbreviated, and ZIP+4
+// extensions dropped.
+func NormalizeAddress(a models.Address) string {
+	return strings.Join([]string{
+		strings.Join(normalizeTokens(a.StreetAddress+" "+a.StreetAddress2), " "),
+		strings.Join(normalizeTokens(a.City), " "),
+		strings.ToLower(strings.TrimSpace(a.StateProvince)),
+		normalizePostalCode(a.PostalCode),
+		strings.ToLower(strings.TrimSpace(a.Country)),
+	}, "|")
+}
+
+// normalizeTokens splits text into lowercased, abbreviated words.
+func normalizeTokens(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, word := range words {
+		if short, ok := abbreviations[word]; ok {
+			words[i] = short
+		}
+	}
+	return words
+}
+
+// normalizePostalCode uppercases a postal code, removes spaces, and drops a ZIP+4 extension.
+func normalizePostalCode(postalCode string) string {
+	postalCode = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(postalCode), " ", ""))
+	if i := strings.Index(postalCode, "-"); i > 0 {
+		postalCode = postalCode[:i]
+	}
+	return postalCode
+}
+
+// FindCandidates compares every pair of entries and returns those scoring at
+// least threshold, highest score first.
+func FindCandidates(entries []Entry, threshold float64) []Candidate {
+	var candidates []Candidate
+	for i := 0; i < len(entries); i++ {
+		for j := i + 1; j < len(entries); j++ {
+			candidate, ok := Compare(entries[i], entries[j])
+			if ok && candidate.Score >= threshold {
+				candidates = append(candidates, candidate)
+			}
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Score != candidates[j].Score {
+			return candidates[i].Score > candidates[j].Score
+		}
+		if candidates[i].LocationIDs[0] != candidates[j].LocationIDs[0] {
+			return candidates[i].LocationIDs[0] < candidates[j].LocationIDs[0]
+		}
+		return candidates[i].LocationIDs[1] < candidates[j].LocationIDs[1]
+	})
+	return candidates
+}
+
+// Compare scores a pair of entries. It reports false when the pair shares no
+// comparable data or nothing matches.
+func Compare(a, b Entry) (Candidate, bool) {
+	ids := []string{a.LocationID, b.LocationID}
+	sort.Strings(ids)
+	candidate := Candidate{LocationIDs: ids}
+
+	var addressScore, proximityScore float64
+	addressA, okA := addressOf(a.Location)
+	addressB, okB := addressOf(b.Location)
+	if okA && okB {
+		var reason string
+		addressScore, reason = compareAddresses(addressA, addressB)
+		if reason != "" {
+			candidate.Reasons = append(candidate.Reasons, reason)
+		}
+	}
+
+	coordsA, okA := coordinatesOf(a.Location)
+	coordsB, okB := coordinatesOf(b.Location)
+	if okA && okB {
+		distance := geo.Distance(coordsA, coordsB)
+		if distance <= ProximityRadiusMeters {
+			proximityScore = 1 - distance/(2*ProximityRadiusMeters)
+			rounded := math.Round(distance*10) / 10
+			candidate.DistanceMeters = &rounded
+			candidate.Reasons = append(candidate.Reasons, "coordinates within proximity radius")
+		}
+	}
+
+	if addressScore == 0 && proximityScore == 0 {
+		return Candidate{}, false
+	}
+	// Independent signals reinforce each other
+	score := 1 - (1-addressScore)*(1-proximityScore)
+	candidate.Score = math.Round(score*1000) / 1000
+	return candidate, true
+}
+
+// compareAddresses scores two addresses and describes why they match.
+func compareAddresses(a, b models.Address) (float64, string) {
+	if NormalizeAddress(a) == NormalizeAddress(b) {
+		return 1, "normalized addresses match"
+	}
+	if !strings.EqualFold(strings.TrimSpace(a.Country), strings.TrimSpace(b.Country)) {
+		return 0, ""
+	}
+
+	var locality float64
+	switch {
+	case normalizePostalCode(a.PostalCode) == normalizePostalCode(b.PostalCode):
+		locality = 1
+	case strings.Join(normalizeTokens(a.City), " ") == strings.Join(normalizeTokens(b.City), " "):
+		locality = 0.9
+	default:
+		return 0, ""
+	}
+
+	similarity := jaccard(
+		normalizeTokens(a.StreetAddress+" "+a.StreetAddress2),
+		normalizeTokens(b.StreetAddress+" "+b.StreetAddress2),
+	)
+	if similarity < 0.5 {
+		return 0, ""
+	}
+	return similarity * locality * 0.95, "similar street address in the same locality"
+}
+
+// jaccard returns the Jaccard similarity of two word lists.
+func jaccard(a, b []string) float64 {
+	set := make(map[string]int, len(a)+len(b))
+	for _, word := range a {
+		set[word] |= 1
+	}
+	for _, word := range b {
+		set[word] |= 2
+	}
+	if len(set) == 0 {
+		return 0
+	}
+	var shared int
+	for _, mask := range set {
+		if mask == 3 {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(set))
+}
+
+// addressOf returns the mailing address of a location, if it has one.
+func addressOf(location models.Location) (models.Address, bool) {
+	switch loc := location.(type) {
+	case models.AddressLocation:
+		return loc.Address, true
+	case models.ShopLocation:
+		return loc.Shop.Address, true
+	case models.EventLocation:
+		if loc.Address != nil {
+			return *loc.Address, true
+		}
+	}
+	return models.Address{}, false
+}
+
+// coordinatesOf returns the coordinates of a location, if it has them.
+func coordinatesOf(location models.Location) (models.Coordinates, bool) {
+	switch loc := location.(type) {
+	case models.CoordinatesLocation:
+		return loc.Coordinates, true
+	case models.EventLocation:
+		if loc.Coordinates != nil {
+			return *loc.Coordinates, true
+		}
+	}
+	return models.Coordinates{}, false
+}
@@ -0,0 +1,126 @@
+package dedupe
+
+import (
+	"testing"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func addressEntry(id string, address models.Address) Entry {
+	return Entry{LocationID: id, Location: models.AddressLocation{
+		LocationBase: models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeAddress},
+		Address:      address,
+	}}
+}
+
+func coordinatesEntry(id string, lat, lon float64) Entry {
+	return Entry{LocationID: id, Location: models.CoordinatesLocation{
+		LocationBase: models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeCoordinates},
+		Coordinates:  models.Coordinates{Latitude: lat, Longitude: lon},
+	}}
+}
+
+func TestNormalizeAddress(t *testing.T) {
+	a := models.Address{StreetAddress: "123 North Main Street", StreetAddress2: "Suite 4", City: "Springfield", PostalCode: "62704-1234", Country: "US"}
+	b := models.Address{StreetAddress: "123 N. Main St.", StreetAddress2: "Ste 4", City: "SPRINGFIELD", PostalCode: "62704", Country: "us"}
+	assert.Equal(t, NormalizeAddress(a), NormalizeAddress(b))
+	assert.Equal(t, "123 n main st ste 4|springfield||62704|us", NormalizeAddress(a))
+}
+
+func TestCompare(t *testing.T) {
+	base := models.Address{StreetAddress: "123 Main Street", City: "Springfield", PostalCode: "62704", Country: "US"}
+
+	tests := []struct {
+		name      string
+		a, b      Entry
+		wantMatch bool
+		wantScore float64
+	}{
+		{
+			name:      "Equivalent addresses",
+			a:         addressEntry("loc-002", base),
+			b:         addressEntry("loc-001", models.Address{StreetAddress: "123 Main St.", City: "Springfield", PostalCode: "62704", Country: "US"}),
+			wantMatch: true,
+			wantScore: 1,
+		},
+		{
+			name:      "Similar street in same postal code",
+			a:         addressEntry("loc-001", base),
+			b:         addressEntry("loc-002", models.Address{StreetAddress: "123 Main St", StreetAddress2: "Apt 2", City: "Springfield", PostalCode: "62704", Country: "US"}),
+			wantMatch: true,
+			wantScore: 0.57,
+		},
+		{
+			name:      "Different country",
+			a:         addressEntry("loc-001", base),
+			b:         addressEntry("loc-002", models.Address{StreetAddress: "123 Main Street", City: "Springfield", PostalCode: "62704", Country: "CA"}),
+			wantMatch: false,
+		},
+		{
+			name:      "Different street",
+			a:         addressEntry("loc-001", base),
+			b:         addressEntry("loc-002", models.Address{StreetAddress: "9 Oak Avenue", City: "Springfield", PostalCode: "62704", Country: "US"}),
+			wantMatch: false,
+		},
+		{
+			name:      "Identical coordinates",
+			a:         coordinatesEntry("loc-001", 40.7128, -74.0060),
+			b:         coordinatesEntry("loc-002", 40.7128, -74.0060),
+			wantMatch: true,
+			wantScore: 1,
+		},
+		{
+			name:      "Coordinates outside radius",
+			a:         coordinatesEntry("loc-001", 40.7128, -74.0060),
+			b:         coordinatesEntry("loc-002", 40.7138, -74.0060),
+			wantMatch: false,
+		},
+		{
+			name:      "Address and coordinates are not comparable",
+			a:         addressEntry("loc-001", base),
+			b:         coordinatesEntry("loc-002", 40.7128, -74.0060),
+			wantMatch: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			candidate, ok := Compare(tt.a, tt.b)
+			assert.Equal(t, tt.wantMatch, ok)
+			if tt.wantMatch {
+				assert.Equal(t, []string{"loc-001", "loc-002"}, candidate.LocationIDs)
+				assert.InDelta(t, tt.wantScore, candidate.Score, 0.001)
+				assert.NotEmpty(t, candidate.Reasons)
+			}
+		})
+	}
+}
+
+func TestCompareProximityScore(t *testing.T) {
+	// Roughly 22 meters apart
+	candidate, ok := Compare(coordinatesEntry("loc-001", 40.7128, -74.0060), coordinatesEntry("loc-002", 40.7130, -74.0060))
+	require.True(t, ok)
+	require.NotNil(t, candidate.DistanceMeters)
+	assert.InDelta(t, 22.2, *candidate.DistanceMeters, 0.1)
+	assert.InDelta(t, 0.778, candidate.Score, 0.001)
+}
+
+func TestFindCandidates(t *testing.T) {
+	entries := []Entry{
+		coordinatesEntry("loc-001", 40.7128, -74.0060),
+		coordinatesEntry("loc-002", 40.7130, -74.0060),
+		coordinatesEntry("loc-003", 40.7128, -74.0060),
+		coordinatesEntry("loc-004", 51.5072, -0.1276),
+	}
+
+	candidates := FindCandidates(entries, 0.5)
+	require.Len(t, candidates, 3)
+	assert.Equal(t, []string{"loc-001", "loc-003"}, candidates[0].LocationIDs)
+	assert.Equal(t, 1.0, candidates[0].Score)
+	assert.Equal(t, []string{"loc-001", "loc-002"}, candidates[1].LocationIDs)
+	assert.Equal(t, []string{"loc-002", "loc-003"}, candidates[2].LocationIDs)
+
+	assert.Len(t, FindCandidates(entries, 0.9), 1)
+}
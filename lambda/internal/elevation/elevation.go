@@ -0,0 +1,80 @@
+// Package elevation looks up the terrain elevation at coordinates.
+package elevation
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+)
+
+// Provider returns the elevation of the ground at coordinates, in meters
+// above mean sea level.
+type Provider interface {
+	Elevation(ctx context.Context, coords models.Coordinates) (float64, error)
+}
+
+// cacheEntry is a cached elevation and when it expires.
+type cacheEntry struct {
+	meters  float64
+	expires time.Time
+}
+
+// CachingProvider caches another provider's elevations, keyed by coordinates
+// rounded to about 10 meters, finer than the terrain models providers sample.
+// Terrain barely changes, so the TTL only bounds how long entries use memory
+// in the Lambda execution environment.
+type CachingProvider struct {
+	provider Provider
+	ttl      time.Duration
+	now      func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+// NewCachingProvider wraps provider with a cache of the given TTL.
+func NewCachingProvider(provider Provider, ttl time.Duration) *CachingProvider {
+	return &CachingProvider{
+		provider: provider,
+		ttl:      ttl,
+		now:      time.Now,
+		entries:  make(map[string]cacheEntry),
+	}
+}
+
+// Elevation returns a cached elevation when fresh, otherwise queries the provider.
+func (c *CachingProvider) Elevation(ctx context.Context, coords models.Coordinates) (float64, error) {
+	key := cacheKey(coords)
+	now := c.now()
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && now.Before(entry.expires) {
+		return entry.meters, nil
+	}
+
+	meters, err := c.provider.Elevation(ctx, coords)
+	if err != nil {
+		return 0, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k, e := range c.entries {
+		if !now.Before(e.expires) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = cacheEntry{meters: meters, expires: now.Add(c.ttl)}
+	return meters, nil
+}
+
+// cacheKey rounds coordinates to four decimal places.
+func cacheKey(coords models.Coordinates) string {
+	return fmt.Sprintf("%.4f,%.4f", math.Round(coords.Latitude*10000)/10000, math.Round(coords.Longitude*10000)/10000)
+}
@@ -0,0 +1,66 @@
+package elevation
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingProvider returns a fixed elevation and counts calls.
+type countingProvider struct {
+	calls int
+	err   error
+}
+
+func (p *countingProvider) Elevation(ctx context.Context, coords models.Coordinates) (float64, error) {
+	p.calls++
+	if p.err != nil {
+		return 0, p.err
+	}
+	return 1609, nil
+}
+
+func TestCachingProvider(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Serves nearby coordinates from cache until the TTL expires", func(t *testing.T) {
+		inner := &countingProvider{}
+		cache := NewCachingProvider(inner, 24*time.Hour)
+		cache.now = func() time.Time { return now }
+
+		meters, err := cache.Elevation(ctx, models.Coordinates{Latitude: 39.73921, Longitude: -104.99031})
+		require.NoError(t, err)
+		assert.Equal(t, 1609.0, meters)
+
+		meters, err = cache.Elevation(ctx, models.Coordinates{Latitude: 39.73919, Longitude: -104.99029})
+		require.NoError(t, err)
+		assert.Equal(t, 1609.0, meters)
+		assert.Equal(t, 1, inner.calls)
+
+		_, err = cache.Elevation(ctx, models.Coordinates{Latitude: 39.7402, Longitude: -104.9903})
+		require.NoError(t, err)
+		assert.Equal(t, 2, inner.calls)
+
+		cache.now = func() time.Time { return now.Add(25 * time.Hour) }
+		_, err = cache.Elevation(ctx, models.Coordinates{Latitude: 39.7392, Longitude: -104.9903})
+		require.NoError(t, err)
+		assert.Equal(t, 3, inner.calls)
+	})
+
+	t.Run("Errors are not cached", func(t *testing.T) {
+		inner := &countingProvider{err: errors.New("unavailable")}
+		cache := NewCachingProvider(inner, 24*time.Hour)
+
+		_, err := cache.Elevation(ctx, models.Coordinates{Latitude: 1, Longitude: 1})
+		assert.EqualError(t, err, "unavailable")
+		_, err = cache.Elevation(ctx, models.Coordinates{Latitude: 1, Longitude: 1})
+		assert.Error(t, err)
+		assert.Equal(t, 2, inner.calls)
+	})
+}
@@ -0,0 +1,73 @@
+package elevation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+)
+
+// HTTPClient is the subset of http.Client used by the elevation providers.
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// OpenMeteoProvider reads elevations from the Open-Meteo elevation API, which
+// samples the Copernicus 90 m digital elevation model and needs no API key.
+type OpenMeteoProvider struct {
+	httpClient HTTPClient
+	endpoint   string
+}
+
+// NewOpenMeteoProvider creates an Open-Meteo provider.
+func NewOpenMeteoProvider() *OpenMeteoProvider {
+	return &OpenMeteoProvider{
+		httpClient: http.DefaultClient,
+		endpoint:   "https://api.open-meteo.com",
+	}
+}
+
+// openMeteoResponse holds one elevation per requested point.
+type openMeteoResponse struct {
+	Elevation []float64 `json:"elevation"`
+}
+
+// Elevation returns the terrain elevation at coords.
+func (p *OpenMeteoProvider) Elevation(ctx context.Context, coords models.Coordinates) (float64, error) {
+	query := url.Values{}
+	query.Set("latitude", strconv.FormatFloat(coords.Latitude, 'f', -1, 64))
+	query.Set("longitude", strconv.FormatFloat(coords.Longitude, 'f', -1, 64))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.endpoint+"/v1/elevation?"+query.Encode(), nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to build elevation request: %w", err)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch elevation: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read elevation response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("elevation request failed with status %d: %s", resp.StatusCode, body)
+	}
+
+	var parsed openMeteoResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return 0, fmt.Errorf("failed to unmarshal elevation response: %w", err)
+	}
+	if len(parsed.Elevation) != 1 {
+		return 0, fmt.Errorf("elevation response has %d elevations, expected 1", len(parsed.Elevation))
+	}
+	return parsed.Elevation[0], nil
+}
@@ -0,0 +1,56 @@
+package elevation
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestOpenMeteoProvider(t *testing.T, handler http.HandlerFunc) *OpenMeteoProvider {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	p := NewOpenMeteoProvider()
+	p.endpoint = server.URL
+	return p
+}
+
+func TestOpenMeteoProvider(t *testing.T) {
+	t.Run("Elevation", func(t *testing.T) {
+		p := newTestOpenMeteoProvider(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/v1/elevation", r.URL.Path)
+			assert.Equal(t, "39.7392", r.URL.Query().Get("latitude"))
+			assert.Equal(t, "-104.9903", r.URL.Query().Get("longitude"))
+			_, _ = w.Write([]byte(`{"elevation": [1596.0]}`))
+		})
+
+		meters, err := p.Elevation(context.Background(), models.Coordinates{Latitude: 39.7392, Longitude: -104.9903})
+		require.NoError(t, err)
+		assert.Equal(t, 1596.0, meters)
+	})
+
+	t.Run("Empty response", func(t *testing.T) {
+		p := newTestOpenMeteoProvider(t, func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"elevation": []}`))
+		})
+
+		_, err := p.Elevation(context.Background(), models.Coordinates{Latitude: 39.7392, Longitude: -104.9903})
+		assert.EqualError(t, err, "elevation response has 0 elevations, expected 1")
+	})
+
+	t.Run("Service error", func(t *testing.T) {
+		p := newTestOpenMeteoProvider(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":true,"reason":"Latitude must be in range"}`))
+		})
+
+		_, err := p.Elevation(context.Background(), models.Coordinates{Latitude: 39.7392, Longitude: -104.9903})
+		assert.EqualError(t, err, `elevation request failed with status 400: {"error":true,"reason":"Latitude must be in range"}`)
+	})
+}
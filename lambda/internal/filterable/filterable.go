@@ -0,0 +1,69 @@
+// Package filterable flattens locations into the fields AppSync enhanced
+// subscription filters match on, which can't reach type-specific fields
+// such as a shop's categories or an address location's coordinates.
+package filterable
+
+import (
+	"github.com/steverhoton/location-lambda/internal/geo"
+	"github.com/steverhoton/location-lambda/internal/models"
+)
+
+// GeohashPrecision is the length of the geohash in Fields, a cell about
+// 1.2 km by 0.6 km.
+const GeohashPrecision = 6
+
+// Fields are a location's subscription filter fields. Lists are empty
+// rather than null, as filters never match a null field.
+type Fields struct {
+	AccountID    string `json:"accountId"`
+	LocationType string `json:"locationType,omitempty"`
+	// Tags are a shop's categories; other location types have none
+	Tags []string `json:"tags"`
+	// Geohash is the cell of the location's coordinates, or its shop's, or
+	// empty when it has none; a beginsWith filter matches a larger cell
+	Geohash string `json:"geohash,omitempty"`
+	// GeohashPrefixes are Geohash and each shorter prefix of it, so a
+	// containsAny filter can match a region covering cells of several sizes
+	GeohashPrefixes []string `json:"geohashPrefixes"`
+}
+
+// For returns the filter fields of location.
+func For(location models.Location) Fields {
+	fields := Fields{
+		AccountID:       location.GetAccountID(),
+		LocationType:    string(location.GetLocationType()),
+		Tags:            []string{},
+		GeohashPrefixes: []string{},
+	}
+	if shop, ok := location.(models.ShopLocation); ok && len(shop.Shop.Categories) > 0 {
+		fields.Tags = append(fields.Tags, shop.Shop.Categories...)
+	}
+	if coordinates := pointOf(location); coordinates != nil {
+		fields.Geohash = geo.Geohash(*coordinates, GeohashPrecision)
+		for i := 1; i <= len(fields.Geohash); i++ {
+			fields.GeohashPrefixes = append(fields.GeohashPrefixes, fields.Geohash[:i])
+		}
+	}
+	return fields
+}
+
+// ForAccount returns the filter fields known without the location, as after
+// it is deleted: only the account.
+func ForAccount(accountID string) Fields {
+	return Fields{AccountID: accountID, Tags: []string{}, GeohashPrefixes: []string{}}
+}
+
+// pointOf returns where a location is pinned, or nil when it isn't.
+func pointOf(location models.Location) *models.Coordinates {
+	switch loc := location.(type) {
+	case models.AddressLocation:
+		return loc.Coordinates
+	case models.CoordinatesLocation:
+		return &loc.Coordinates
+	case models.ShopLocation:
+		return loc.Shop.Coordinates
+	case models.EventLocation:
+		return loc.Coordinates
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package filterable
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFor(t *testing.T) {
+	tests := []struct {
+		name     string
+		location models.Location
+		want     Fields
+	}{
+		{
+			name: "Coordinates",
+			location: models.CoordinatesLocation{
+				LocationBase: models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeCoordinates},
+				Coordinates:  models.Coordinates{Latitude: 40.7128, Longitude: -74.006},
+			},
+			want: Fields{
+				AccountID:       "acc-12345",
+				LocationType:    "coordinates",
+				Tags:            []string{},
+				Geohash:         "dr5reg",
+				GeohashPrefixes: []string{"d", "dr", "dr5", "dr5r", "dr5re", "dr5reg"},
+			},
+		},
+		{
+			name: "Shop with categories and a map pin",
+			location: models.ShopLocation{
+				LocationBase: models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeShop},
+				Shop: models.Shop{
+					Name:        "Coffee Shop",
+					Categories:  []string{"coffee", "bakery"},
+					Coordinates: &models.Coordinates{Latitude: 39.7392, Longitude: -104.9903},
+				},
+			},
+			want: Fields{
+				AccountID:       "acc-12345",
+				LocationType:    "shop",
+				Tags:            []string{"coffee", "bakery"},
+				Geohash:         "9xj64f",
+				GeohashPrefixes: []string{"9", "9x", "9xj", "9xj6", "9xj64", "9xj64f"},
+			},
+		},
+		{
+			name: "Address without coordinates",
+			location: models.AddressLocation{
+				LocationBase: models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeAddress},
+				Address:      models.Address{StreetAddress: "123 Main St", City: "Springfield", PostalCode: "62704", Country: "US"},
+			},
+			want: Fields{AccountID: "acc-12345", LocationType: "address", Tags: []string{}, GeohashPrefixes: []string{}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, For(tt.location))
+		})
+	}
+}
+
+func TestFieldsEncodeEmptyListsAsArrays(t *testing.T) {
+	data, err := json.Marshal(ForAccount("acc-12345"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"accountId": "acc-12345", "tags": [], "geohashPrefixes": []}`, string(data))
+}
@@ -0,0 +1,98 @@
+package geo
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+)
+
+// GRS80, the ellipsoid NAD83 is defined on, differs from WGS84 only in its flattening.
+const grs80Flattening = 1 / 298.257222101
+
+// milliarcsecond is a milliarcsecond in radians.
+const milliarcsecond = math.Pi / (180 * 3600 * 1000)
+
+// helmert is a 7-parameter coordinate frame transformation between datums:
+// translations in meters, rotations in radians, and a scale difference.
+type helmert struct {
+	tx, ty, tz float64
+	rx, ry, rz float64
+	scale      float64
+}
+
+// itrf2008ToNAD83 transforms ITRF2008, which WGS84 (G1762) agrees with to a
+// few centimeters, to NAD83(2011) at epoch 1997.0, as published by the NGS.
+// Plate motion since then is not modeled.
+var itrf2008ToNAD83 = helmert{
+	tx: 0.99343, ty: -1.90331, tz: -0.52655,
+	rx: 25.91467 * milliarcsecond, ry: -9.42645 * milliarcsecond, rz: -11.59935 * milliarcsecond,
+	scale: 1.71504e-9,
+}
+
+// apply transforms Earth-centered, Earth-fixed coordinates in meters.
+func (h helmert) apply(x, y, z float64) (float64, float64, float64) {
+	return h.tx + (1+h.scale)*x + h.rz*y - h.ry*z,
+		h.ty - h.rz*x + (1+h.scale)*y + h.rx*z,
+		h.tz + h.ry*x - h.rx*y + (1+h.scale)*z
+}
+
+// inverse returns the reverse transformation, exact to well under a millimeter
+// for parameters this small.
+func (h helmert) inverse() helmert {
+	return helmert{tx: -h.tx, ty: -h.ty, tz: -h.tz, rx: -h.rx, ry: -h.ry, rz: -h.rz, scale: -h.scale}
+}
+
+// ToWGS84 converts coordinates entered in another datum to WGS84, returning
+// them with CRS set to WGS84. Coordinates without a CRS are taken to be WGS84
+// already. The ellipsoid height used is the altitude, or zero without one;
+// the altitude itself is left as entered.
+func ToWGS84(coordinates models.Coordinates) (models.Coordinates, error) {
+	switch coordinates.CRS {
+	case "", models.CRSWGS84:
+		return coordinates, nil
+	case models.CRSNAD83:
+		height := 0.0
+		if coordinates.Altitude != nil {
+			height = *coordinates.Altitude
+		}
+		x, y, z := toECEF(coordinates.Latitude, coordinates.Longitude, height, grs80Flattening)
+		x, y, z = itrf2008ToNAD83.inverse().apply(x, y, z)
+		coordinates.Latitude, coordinates.Longitude = fromECEF(x, y, z, wgs84Flattening)
+		coordinates.CRS = models.CRSWGS84
+		return coordinates, nil
+	default:
+		return models.Coordinates{}, fmt.Errorf("crs must be %s or %s, got %q", models.CRSWGS84, models.CRSNAD83, coordinates.CRS)
+	}
+}
+
+// toECEF converts a latitude and longitude in degrees and an ellipsoid height
+// in meters to Earth-centered, Earth-fixed coordinates on the given ellipsoid.
+func toECEF(latitude, longitude, height, flattening float64) (x, y, z float64) {
+	e2 := flattening * (2 - flattening)
+	phi, lambda := latitude*math.Pi/180, longitude*math.Pi/180
+	n := wgs84SemiMajorAxis / math.Sqrt(1-e2*math.Sin(phi)*math.Sin(phi))
+	return (n + height) * math.Cos(phi) * math.Cos(lambda),
+		(n + height) * math.Cos(phi) * math.Sin(lambda),
+		(n*(1-e2) + height) * math.Sin(phi)
+}
+
+// fromECEF converts Earth-centered, Earth-fixed coordinates to a latitude and
+// longitude in degrees on the given ellipsoid, iterating to sub-millimeter
+// convergence.
+func fromECEF(x, y, z, flattening float64) (latitude, longitude float64) {
+	e2 := flattening * (2 - flattening)
+	p := math.Hypot(x, y)
+	phi := math.Atan2(z, p*(1-e2))
+	for i := 0; i < 10; i++ {
+		n := wgs84SemiMajorAxis / math.Sqrt(1-e2*math.Sin(phi)*math.Sin(phi))
+		height := p/math.Cos(phi) - n
+		next := math.Atan2(z, p*(1-e2*n/(n+height)))
+		if math.Abs(next-phi) < 1e-12 {
+			phi = next
+			break
+		}
+		phi = next
+	}
+	return phi * 180 / math.Pi, math.Atan2(y, x) * 180 / math.Pi
+}
@@ -0,0 +1,73 @@
+package geo
+
+import (
+	"testing"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"pgregory.net/rapid"
+)
+
+func TestToWGS84(t *testing.T) {
+	t.Run("Leaves WGS84 alone", func(t *testing.T) {
+		for _, crs := range []string{"", models.CRSWGS84} {
+			coordinates := models.Coordinates{Latitude: 39.7392, Longitude: -104.9903, CRS: crs}
+			converted, err := ToWGS84(coordinates)
+			require.NoError(t, err)
+			assert.Equal(t, coordinates, converted)
+		}
+	})
+
+	t.Run("Shifts NAD83 by a meter or two across the US", func(t *testing.T) {
+		tests := []struct {
+			name      string
+			latitude  float64
+			longitude float64
+		}{
+			{name: "Denver", latitude: 39.7392, longitude: -104.9903},
+			{name: "Washington", latitude: 38.8895, longitude: -77.0353},
+			{name: "Anchorage", latitude: 61.2181, longitude: -149.9003},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				nad83 := models.Coordinates{Latitude: tt.latitude, Longitude: tt.longitude, CRS: models.CRSNAD83}
+				converted, err := ToWGS84(nad83)
+				require.NoError(t, err)
+				assert.Equal(t, models.CRSWGS84, converted.CRS)
+				shift := Distance(nad83, converted)
+				assert.Greater(t, shift, 1.0)
+				assert.Less(t, shift, 2.5)
+			})
+		}
+	})
+
+	t.Run("Keeps the altitude", func(t *testing.T) {
+		altitude := 1609.0
+		converted, err := ToWGS84(models.Coordinates{Latitude: 39.7392, Longitude: -104.9903, Altitude: &altitude, CRS: models.CRSNAD83})
+		require.NoError(t, err)
+		assert.Equal(t, 1609.0, *converted.Altitude)
+	})
+
+	t.Run("Rejects other datums", func(t *testing.T) {
+		_, err := ToWGS84(models.Coordinates{Latitude: 39.7392, Longitude: -104.9903, CRS: "NAD27"})
+		assert.EqualError(t, err, `crs must be WGS84 or NAD83, got "NAD27"`)
+	})
+}
+
+func TestECEFRoundTrip(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		latitude := rapid.Float64Range(-89.9, 89.9).Draw(t, "latitude")
+		longitude := rapid.Float64Range(-180, 180).Draw(t, "longitude")
+
+		x, y, z := toECEF(latitude, longitude, 0, grs80Flattening)
+		gotLatitude, gotLongitude := fromECEF(x, y, z, grs80Flattening)
+		if distance := Distance(
+			models.Coordinates{Latitude: latitude, Longitude: longitude},
+			models.Coordinates{Latitude: gotLatitude, Longitude: gotLongitude},
+		); distance > 1e-3 {
+			t.Fatalf("round trip moved the point %gm", distance)
+		}
+	})
+}
@@ -0,0 +1,140 @@
+// Package geo provides the coordinate math behind distance and area queries,
+// including boxes that cross the antimeridian or reach a pole.
+package geo
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+)
+
+// EarthRadiusMeters is the mean Earth radius.
+const EarthRadiusMeters = 6371008.8
+
+// Distance returns the great-circle distance in meters between two points.
+// It uses the Vincenty form of the spherical law, which unlike haversine stays
+// accurate for nearly antipodal points.
+func Distance(a, b models.Coordinates) float64 {
+	lat1 := a.Latitude * math.Pi / 180
+	lat2 := b.Latitude * math.Pi / 180
+	dLon := (b.Longitude - a.Longitude) * math.Pi / 180
+
+	y := math.Hypot(math.Cos(lat2)*math.Sin(dLon), math.Cos(lat1)*math.Sin(lat2)-math.Sin(lat1)*math.Cos(lat2)*math.Cos(dLon))
+	x := math.Sin(lat1)*math.Sin(lat2) + math.Cos(lat1)*math.Cos(lat2)*math.Cos(dLon)
+	return EarthRadiusMeters * math.Atan2(y, x)
+}
+
+// BoundingBox is a latitude/longitude rectangle. A box whose MinLongitude is
+// greater than its MaxLongitude crosses the antimeridian, e.g. 170 to -170
+// spans the 20 degrees around ±180.
+type BoundingBox struct {
+	MinLatitude  float64 `json:"minLatitude"`
+	MinLongitude float64 `json:"minLongitude"`
+	MaxLatitude  float64 `json:"maxLatitude"`
+	MaxLongitude float64 `json:"maxLongitude"`
+}
+
+// Validate validates the bounding box.
+func (b BoundingBox) Validate() error {
+	if b.MinLatitude < -90 || b.MaxLatitude > 90 {
+		return fmt.Errorf("latitudes must be between -90 and 90, got %f to %f", b.MinLatitude, b.MaxLatitude)
+	}
+	if b.MinLatitude > b.MaxLatitude {
+		return errors.New("minLatitude must not be greater than maxLatitude")
+	}
+	if b.MinLongitude < -180 || b.MinLongitude > 180 || b.MaxLongitude < -180 || b.MaxLongitude > 180 {
+		return fmt.Errorf("longitudes must be between -180 and 180, got %f to %f", b.MinLongitude, b.MaxLongitude)
+	}
+	return nil
+}
+
+// CrossesAntimeridian reports whether the box wraps around ±180 longitude.
+func (b BoundingBox) CrossesAntimeridian() bool {
+	return b.MinLongitude > b.MaxLongitude
+}
+
+// Contains reports whether c lies inside the box, edges included.
+func (b BoundingBox) Contains(c models.Coordinates) bool {
+	if c.Latitude < b.MinLatitude || c.Latitude > b.MaxLatitude {
+		return false
+	}
+	// Every longitude meets at a pole
+	if math.Abs(c.Latitude) == 90 {
+		return true
+	}
+	if b.CrossesAntimeridian() {
+		return c.Longitude >= b.MinLongitude || c.Longitude <= b.MaxLongitude
+	}
+	return c.Longitude >= b.MinLongitude && c.Longitude <= b.MaxLongitude
+}
+
+// Split returns boxes covering b that do not cross the antimeridian, so each
+// can be searched as one contiguous longitude range. A crossing box becomes
+// its eastern part up to 180 and its western part from -180.
+func (b BoundingBox) Split() []BoundingBox {
+	if !b.CrossesAntimeridian() {
+		return []BoundingBox{b}
+	}
+	east := b
+	east.MaxLongitude = 180
+	west := b
+	west.MinLongitude = -180
+	return []BoundingBox{east, west}
+}
+
+// RadiusBox returns the smallest bounding box containing every point within
+// meters of center. A circle reaching a pole covers all longitudes; one
+// reaching across ±180 yields a box that crosses the antimeridian.
+func RadiusBox(center models.Coordinates, meters float64) BoundingBox {
+	angular := meters / EarthRadiusMeters // radians
+	latitude := center.Latitude * math.Pi / 180
+
+	minLat := (latitude - angular) * 180 / math.Pi
+	maxLat := (latitude + angular) * 180 / math.Pi
+	if minLat <= -90 || maxLat >= 90 {
+		return BoundingBox{
+			MinLatitude:  math.Max(minLat, -90),
+			MinLongitude: -180,
+			MaxLatitude:  math.Min(maxLat, 90),
+			MaxLongitude: 180,
+		}
+	}
+
+	// Longitude half-width of the circle at its widest point
+	ratio := math.Sin(angular) / math.Cos(latitude)
+	if ratio >= 1 {
+		return BoundingBox{MinLatitude: minLat, MinLongitude: -180, MaxLatitude: maxLat, MaxLongitude: 180}
+	}
+	dLon := math.Asin(ratio) * 180 / math.Pi
+
+	minLon := center.Longitude - dLon
+	maxLon := center.Longitude + dLon
+	if minLon < -180 {
+		minLon += 360
+	}
+	if maxLon > 180 {
+		maxLon -= 360
+	}
+	return BoundingBox{MinLatitude: minLat, MinLongitude: minLon, MaxLatitude: maxLat, MaxLongitude: maxLon}
+}
+
+// PolygonContains reports whether c lies inside the polygon ring, treating
+// edges as straight lines in latitude and longitude, which is accurate for
+// city-sized areas. The ring may omit its closing point. Points exactly on
+// an edge may fall either way.
+func PolygonContains(ring []models.Coordinates, c models.Coordinates) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		a, b := ring[i], ring[j]
+		if (a.Latitude > c.Latitude) != (b.Latitude > c.Latitude) {
+			// Longitude where the edge crosses the point's latitude
+			crossing := a.Longitude + (c.Latitude-a.Latitude)*(b.Longitude-a.Longitude)/(b.Latitude-a.Latitude)
+			if c.Longitude < crossing {
+				inside = !inside
+			}
+		}
+	}
+	return inside
+}
@@ -0,0 +1,256 @@
+package geo
+
+import (
+	"math"
+	"testing"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"pgregory.net/rapid"
+)
+
+// coordinatesGen draws any valid coordinates, including the poles and both
+// sides of the antimeridian.
+func coordinatesGen() *rapid.Generator[models.Coordinates] {
+	return rapid.Custom(func(t *rapid.T) models.Coordinates {
+		return models.Coordinates{
+			Latitude:  rapid.Float64Range(-90, 90).Draw(t, "latitude"),
+			Longitude: rapid.Float64Range(-180, 180).Draw(t, "longitude"),
+		}
+	})
+}
+
+// distanceTolerance absorbs floating-point error, in meters.
+const distanceTolerance = 1e-6
+
+func TestDistanceProperties(t *testing.T) {
+	halfCircumference := math.Pi * EarthRadiusMeters
+
+	t.Run("Is zero from a point to itself", rapid.MakeCheck(func(t *rapid.T) {
+		a := coordinatesGen().Draw(t, "a")
+		assert.InDelta(t, 0, Distance(a, a), distanceTolerance)
+	}))
+
+	t.Run("Is symmetric", rapid.MakeCheck(func(t *rapid.T) {
+		a, b := coordinatesGen().Draw(t, "a"), coordinatesGen().Draw(t, "b")
+		assert.InDelta(t, Distance(a, b), Distance(b, a), distanceTolerance)
+	}))
+
+	t.Run("Is bounded by half the circumference", rapid.MakeCheck(func(t *rapid.T) {
+		a, b := coordinatesGen().Draw(t, "a"), coordinatesGen().Draw(t, "b")
+		d := Distance(a, b)
+		assert.GreaterOrEqual(t, d, 0.0)
+		assert.LessOrEqual(t, d, halfCircumference+distanceTolerance)
+	}))
+
+	t.Run("Satisfies the triangle inequality", rapid.MakeCheck(func(t *rapid.T) {
+		a, b, c := coordinatesGen().Draw(t, "a"), coordinatesGen().Draw(t, "b"), coordinatesGen().Draw(t, "c")
+		assert.LessOrEqual(t, Distance(a, c), Distance(a, b)+Distance(b, c)+distanceTolerance)
+	}))
+
+	t.Run("Treats both sides of the antimeridian as one meridian", rapid.MakeCheck(func(t *rapid.T) {
+		latitude := rapid.Float64Range(-90, 90).Draw(t, "latitude")
+		east := models.Coordinates{Latitude: latitude, Longitude: 180}
+		west := models.Coordinates{Latitude: latitude, Longitude: -180}
+		assert.InDelta(t, 0, Distance(east, west), distanceTolerance)
+	}))
+
+	t.Run("Measures short hops across the antimeridian directly", rapid.MakeCheck(func(t *rapid.T) {
+		latitude := rapid.Float64Range(-80, 80).Draw(t, "latitude")
+		offset := rapid.Float64Range(0, 0.5).Draw(t, "offset")
+		east := models.Coordinates{Latitude: latitude, Longitude: 180 - offset}
+		west := models.Coordinates{Latitude: latitude, Longitude: -180 + offset}
+		// At most 1 degree of longitude apart, never the long way round the globe
+		assert.LessOrEqual(t, Distance(east, west), 2*math.Pi*EarthRadiusMeters/360+distanceTolerance)
+	}))
+
+	t.Run("Ignores longitude at the poles", rapid.MakeCheck(func(t *rapid.T) {
+		pole := rapid.SampledFrom([]float64{-90, 90}).Draw(t, "pole")
+		a := models.Coordinates{Latitude: pole, Longitude: rapid.Float64Range(-180, 180).Draw(t, "a")}
+		b := models.Coordinates{Latitude: pole, Longitude: rapid.Float64Range(-180, 180).Draw(t, "b")}
+		assert.InDelta(t, 0, Distance(a, b), distanceTolerance)
+	}))
+
+	t.Run("Is unchanged by rotating both points in longitude", rapid.MakeCheck(func(t *rapid.T) {
+		a, b := coordinatesGen().Draw(t, "a"), coordinatesGen().Draw(t, "b")
+		shift := rapid.Float64Range(-180, 180).Draw(t, "shift")
+		rotate := func(c models.Coordinates) models.Coordinates {
+			c.Longitude = math.Mod(c.Longitude+shift+540, 360) - 180
+			return c
+		}
+		assert.InDelta(t, Distance(a, b), Distance(rotate(a), rotate(b)), 1e-3)
+	}))
+}
+
+func TestBoundingBoxValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		box     BoundingBox
+		wantErr string
+	}{
+		{name: "Valid box", box: BoundingBox{MinLatitude: 10, MinLongitude: 20, MaxLatitude: 30, MaxLongitude: 40}},
+		{name: "Crossing the antimeridian", box: BoundingBox{MinLatitude: -20, MinLongitude: 170, MaxLatitude: -10, MaxLongitude: -170}},
+		{name: "Latitude out of range", box: BoundingBox{MinLatitude: -91, MaxLatitude: 0}, wantErr: "latitudes must be between -90 and 90, got -91.000000 to 0.000000"},
+		{name: "Latitudes reversed", box: BoundingBox{MinLatitude: 30, MaxLatitude: 10}, wantErr: "minLatitude must not be greater than maxLatitude"},
+		{name: "Longitude out of range", box: BoundingBox{MinLongitude: 170, MaxLongitude: 190}, wantErr: "longitudes must be between -180 and 180, got 170.000000 to 190.000000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.box.Validate()
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestBoundingBoxContains(t *testing.T) {
+	fiji := BoundingBox{MinLatitude: -20, MinLongitude: 175, MaxLatitude: -15, MaxLongitude: -178}
+
+	tests := []struct {
+		name   string
+		box    BoundingBox
+		point  models.Coordinates
+		inside bool
+	}{
+		{name: "Inside a plain box", box: BoundingBox{MinLatitude: 40, MinLongitude: -75, MaxLatitude: 41, MaxLongitude: -73}, point: models.Coordinates{Latitude: 40.7, Longitude: -74}, inside: true},
+		{name: "East of the antimeridian", box: fiji, point: models.Coordinates{Latitude: -18, Longitude: 178}, inside: true},
+		{name: "West of the antimeridian", box: fiji, point: models.Coordinates{Latitude: -18, Longitude: -179}, inside: true},
+		{name: "On the antimeridian", box: fiji, point: models.Coordinates{Latitude: -18, Longitude: -180}, inside: true},
+		{name: "Between the edges the long way round", box: fiji, point: models.Coordinates{Latitude: -18, Longitude: 0}, inside: false},
+		{name: "Outside the latitudes", box: fiji, point: models.Coordinates{Latitude: -21, Longitude: 178}, inside: false},
+		{name: "A pole at any longitude", box: BoundingBox{MinLatitude: 80, MinLongitude: 10, MaxLatitude: 90, MaxLongitude: 20}, point: models.Coordinates{Latitude: 90, Longitude: -150}, inside: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.inside, tt.box.Contains(tt.point))
+		})
+	}
+}
+
+func TestBoundingBoxSplit(t *testing.T) {
+	t.Run("A box not crossing the antimeridian is one range", func(t *testing.T) {
+		box := BoundingBox{MinLatitude: 10, MinLongitude: 20, MaxLatitude: 30, MaxLongitude: 40}
+		assert.Equal(t, []BoundingBox{box}, box.Split())
+	})
+
+	t.Run("A crossing box splits at ±180", func(t *testing.T) {
+		box := BoundingBox{MinLatitude: -20, MinLongitude: 175, MaxLatitude: -15, MaxLongitude: -178}
+		assert.Equal(t, []BoundingBox{
+			{MinLatitude: -20, MinLongitude: 175, MaxLatitude: -15, MaxLongitude: 180},
+			{MinLatitude: -20, MinLongitude: -180, MaxLatitude: -15, MaxLongitude: -178},
+		}, box.Split())
+	})
+}
+
+func TestRadiusBox(t *testing.T) {
+	t.Run("Small radius around New York", func(t *testing.T) {
+		box := RadiusBox(models.Coordinates{Latitude: 40.7128, Longitude: -74.006}, 10000)
+		assert.InDelta(t, 40.6229, box.MinLatitude, 1e-4)
+		assert.InDelta(t, 40.8027, box.MaxLatitude, 1e-4)
+		assert.InDelta(t, -74.1247, box.MinLongitude, 1e-4)
+		assert.InDelta(t, -73.8873, box.MaxLongitude, 1e-4)
+		assert.False(t, box.CrossesAntimeridian())
+	})
+
+	t.Run("Radius across the antimeridian wraps", func(t *testing.T) {
+		box := RadiusBox(models.Coordinates{Latitude: -18, Longitude: 179.9}, 50000)
+		assert.True(t, box.CrossesAntimeridian())
+		assert.Less(t, box.MaxLongitude, -179.0)
+		assert.Greater(t, box.MinLongitude, 179.0)
+		assert.Len(t, box.Split(), 2)
+	})
+
+	t.Run("Radius reaching a pole covers every longitude", func(t *testing.T) {
+		box := RadiusBox(models.Coordinates{Latitude: 89.9, Longitude: 45}, 50000)
+		assert.Equal(t, 90.0, box.MaxLatitude)
+		assert.Equal(t, -180.0, box.MinLongitude)
+		assert.Equal(t, 180.0, box.MaxLongitude)
+	})
+}
+
+// destination returns the point meters from start along an initial bearing in radians.
+func destination(start models.Coordinates, bearing, meters float64) models.Coordinates {
+	angular := meters / EarthRadiusMeters
+	lat1 := start.Latitude * math.Pi / 180
+	lon1 := start.Longitude * math.Pi / 180
+
+	lat2 := math.Asin(math.Sin(lat1)*math.Cos(angular) + math.Cos(lat1)*math.Sin(angular)*math.Cos(bearing))
+	lon2 := lon1 + math.Atan2(math.Sin(bearing)*math.Sin(angular)*math.Cos(lat1), math.Cos(angular)-math.Sin(lat1)*math.Sin(lat2))
+
+	longitude := math.Mod(lon2*180/math.Pi+540, 360) - 180
+	return models.Coordinates{Latitude: lat2 * 180 / math.Pi, Longitude: longitude}
+}
+
+func TestRadiusBoxProperties(t *testing.T) {
+	t.Run("Contains every point within the radius", rapid.MakeCheck(func(t *rapid.T) {
+		center := coordinatesGen().Draw(t, "center")
+		// A margin below the radius keeps rounding near the poles from counting
+		radius := rapid.Float64Range(100, 2000000).Draw(t, "radius")
+		bearing := rapid.Float64Range(0, 2*math.Pi).Draw(t, "bearing")
+		point := destination(center, bearing, radius*rapid.Float64Range(0, 0.99).Draw(t, "fraction"))
+
+		box := RadiusBox(center, radius)
+		assert.True(t, box.Contains(point), "box %+v does not contain %+v", box, point)
+	}))
+
+	t.Run("Splits into ranges that agree on containment", rapid.MakeCheck(func(t *rapid.T) {
+		box := RadiusBox(coordinatesGen().Draw(t, "center"), rapid.Float64Range(1, 2000000).Draw(t, "radius"))
+		point := coordinatesGen().Draw(t, "point")
+
+		inParts := 0
+		for _, part := range box.Split() {
+			assert.False(t, part.CrossesAntimeridian())
+			assert.NoError(t, part.Validate())
+			if part.Contains(point) {
+				inParts++
+			}
+		}
+		assert.Equal(t, box.Contains(point), inParts > 0)
+	}))
+}
+
+func TestPolygonContains(t *testing.T) {
+	// A square around downtown Springfield, IL, and an L-shaped zone
+	square := []models.Coordinates{
+		{Latitude: 39.77, Longitude: -89.67},
+		{Latitude: 39.77, Longitude: -89.63},
+		{Latitude: 39.80, Longitude: -89.63},
+		{Latitude: 39.80, Longitude: -89.67},
+	}
+	lShape := []models.Coordinates{
+		{Latitude: 0, Longitude: 0},
+		{Latitude: 0, Longitude: 2},
+		{Latitude: 1, Longitude: 2},
+		{Latitude: 1, Longitude: 1},
+		{Latitude: 2, Longitude: 1},
+		{Latitude: 2, Longitude: 0},
+		{Latitude: 0, Longitude: 0},
+	}
+
+	tests := []struct {
+		name  string
+		ring  []models.Coordinates
+		point models.Coordinates
+		want  bool
+	}{
+		{name: "Inside the square", ring: square, point: models.Coordinates{Latitude: 39.7817, Longitude: -89.6501}, want: true},
+		{name: "North of the square", ring: square, point: models.Coordinates{Latitude: 39.81, Longitude: -89.65}},
+		{name: "West of the square", ring: square, point: models.Coordinates{Latitude: 39.78, Longitude: -89.70}},
+		{name: "Inside the L", ring: lShape, point: models.Coordinates{Latitude: 1.5, Longitude: 0.5}, want: true},
+		{name: "In the L's notch", ring: lShape, point: models.Coordinates{Latitude: 1.5, Longitude: 1.5}},
+		{name: "Southern hemisphere", ring: []models.Coordinates{
+			{Latitude: -34.0, Longitude: 151.0}, {Latitude: -34.0, Longitude: 151.3}, {Latitude: -33.7, Longitude: 151.3}, {Latitude: -33.7, Longitude: 151.0},
+		}, point: models.Coordinates{Latitude: -33.87, Longitude: 151.21}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, PolygonContains(tt.ring, tt.point))
+		})
+	}
+}
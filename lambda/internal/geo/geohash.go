@@ -0,0 +1,101 @@
+package geo
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+)
+
+// MaxGeohashPrecision is the longest geohash Geohash produces, a cell a few
+// centimeters across.
+const MaxGeohashPrecision = 12
+
+// geohashAlphabet is the base-32 alphabet of geohashes, which skips a, i, l, and o.
+const geohashAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// Geohash returns the geohash of c with precision characters, from 1, a cell
+// about 5,000 km across, to MaxGeohashPrecision. Each character halves the
+// cell five times, alternately by longitude and latitude.
+func Geohash(c models.Coordinates, precision int) string {
+	if precision < 1 {
+		precision = 1
+	}
+	if precision > MaxGeohashPrecision {
+		precision = MaxGeohashPrecision
+	}
+	minLat, maxLat := -90.0, 90.0
+	minLon, maxLon := -180.0, 180.0
+	hash := make([]byte, 0, precision)
+	bits, index, even := 0, 0, true
+	for len(hash) < precision {
+		if even {
+			mid := (minLon + maxLon) / 2
+			index <<= 1
+			if c.Longitude >= mid {
+				index |= 1
+				minLon = mid
+			} else {
+				maxLon = mid
+			}
+		} else {
+			mid := (minLat + maxLat) / 2
+			index <<= 1
+			if c.Latitude >= mid {
+				index |= 1
+				minLat = mid
+			} else {
+				maxLat = mid
+			}
+		}
+		even = !even
+		if bits++; bits == 5 {
+			hash = append(hash, geohashAlphabet[index])
+			bits, index = 0, 0
+		}
+	}
+	return string(hash)
+}
+
+// GeohashBox returns the cell a geohash names.
+func GeohashBox(hash string) (BoundingBox, error) {
+	if hash == "" || len(hash) > MaxGeohashPrecision {
+		return BoundingBox{}, fmt.Errorf("geohash must have 1 to %d characters, got %d", MaxGeohashPrecision, len(hash))
+	}
+	box := BoundingBox{MinLatitude: -90, MinLongitude: -180, MaxLatitude: 90, MaxLongitude: 180}
+	even := true
+	for _, r := range strings.ToLower(hash) {
+		index := strings.IndexRune(geohashAlphabet, r)
+		if index < 0 {
+			return BoundingBox{}, fmt.Errorf("geohash %q has an invalid character %q", hash, r)
+		}
+		for bit := 4; bit >= 0; bit-- {
+			set := index>>bit&1 == 1
+			if even {
+				mid := (box.MinLongitude + box.MaxLongitude) / 2
+				if set {
+					box.MinLongitude = mid
+				} else {
+					box.MaxLongitude = mid
+				}
+			} else {
+				mid := (box.MinLatitude + box.MaxLatitude) / 2
+				if set {
+					box.MinLatitude = mid
+				} else {
+					box.MaxLatitude = mid
+				}
+			}
+			even = !even
+		}
+	}
+	return box, nil
+}
+
+// Center returns the middle of a box that doesn't cross the antimeridian.
+func (b BoundingBox) Center() models.Coordinates {
+	return models.Coordinates{
+		Latitude:  (b.MinLatitude + b.MaxLatitude) / 2,
+		Longitude: (b.MinLongitude + b.MaxLongitude) / 2,
+	}
+}
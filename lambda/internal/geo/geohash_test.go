@@ -0,0 +1,74 @@
+package geo
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"pgregory.net/rapid"
+)
+
+func TestGeohash(t *testing.T) {
+	tests := []struct {
+		name      string
+		point     models.Coordinates
+		precision int
+		want      string
+	}{
+		{name: "Jutland", point: models.Coordinates{Latitude: 57.64911, Longitude: 10.40744}, precision: 11, want: "u4pruydqqvj"},
+		{name: "León", point: models.Coordinates{Latitude: 42.6, Longitude: -5.6}, precision: 5, want: "ezs42"},
+		{name: "Southwest corner", point: models.Coordinates{Latitude: -90, Longitude: -180}, precision: 3, want: "000"},
+		{name: "Northeast corner", point: models.Coordinates{Latitude: 90, Longitude: 180}, precision: 3, want: "zzz"},
+		{name: "Precision is clamped", point: models.Coordinates{Latitude: 42.6, Longitude: -5.6}, precision: 0, want: "e"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Geohash(tt.point, tt.precision))
+		})
+	}
+}
+
+func TestGeohashBox(t *testing.T) {
+	t.Run("Decodes a cell", func(t *testing.T) {
+		box, err := GeohashBox("ezs42")
+		require.NoError(t, err)
+		assert.InDelta(t, 42.583, box.MinLatitude, 1e-3)
+		assert.InDelta(t, 42.627, box.MaxLatitude, 1e-3)
+		assert.InDelta(t, -5.625, box.MinLongitude, 1e-3)
+		assert.InDelta(t, -5.581, box.MaxLongitude, 1e-3)
+		assert.True(t, box.Contains(models.Coordinates{Latitude: 42.6, Longitude: -5.6}))
+	})
+
+	t.Run("Rejects invalid geohashes", func(t *testing.T) {
+		_, err := GeohashBox("ezs4a")
+		assert.EqualError(t, err, `geohash "ezs4a" has an invalid character 'a'`)
+
+		_, err = GeohashBox("")
+		assert.EqualError(t, err, "geohash must have 1 to 12 characters, got 0")
+	})
+}
+
+func TestGeohashProperties(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		point := coordinatesGen().Draw(t, "point")
+		precision := rapid.IntRange(1, MaxGeohashPrecision).Draw(t, "precision")
+
+		hash := Geohash(point, precision)
+		if len(hash) != precision {
+			t.Fatalf("geohash %q has %d characters, want %d", hash, len(hash), precision)
+		}
+		box, err := GeohashBox(hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !box.Contains(point) {
+			t.Fatalf("cell %s %+v does not contain %+v", hash, box, point)
+		}
+		if coarser := Geohash(point, precision-1); precision > 1 && !strings.HasPrefix(hash, coarser) {
+			t.Fatalf("geohash %q does not extend %q", hash, coarser)
+		}
+	})
+}
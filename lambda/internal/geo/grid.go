@@ -0,0 +1,193 @@
+package geo
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+)
+
+// WGS84 ellipsoid and UTM projection constants.
+const (
+	wgs84SemiMajorAxis = 6378137.0
+	wgs84Flattening    = 1 / 298.257223563
+	utmScaleFactor     = 0.9996
+	utmFalseEasting    = 500000.0
+	utmFalseNorthing   = 10000000.0 // added to southern hemisphere northings
+)
+
+// latitudeBands are the 8° MGRS latitude bands from 80°S, the last, X, being 12° tall.
+const latitudeBands = "CDEFGHJKLMNPQRSTUVWX"
+
+// mgrsColumnLetters are the 100 km column letters, repeating every three zones.
+var mgrsColumnLetters = [3]string{"ABCDEFGH", "JKLMNPQR", "STUVWXYZ"}
+
+// mgrsRowLetters are the 100 km row letters, repeating every 2,000 km north.
+const mgrsRowLetters = "ABCDEFGHJKLMNPQRSTUV"
+
+var (
+	// utmPattern matches a zone, latitude band, easting, and northing, such as 18T 585628 4511322.
+	utmPattern = regexp.MustCompile(`^(\d{1,2}) ?([C-HJ-NP-X]) +(\d+(?:\.\d+)?) +(\d+(?:\.\d+)?)$`)
+	// mgrsPattern matches a zone, latitude band, 100 km square, and up to ten
+	// digits, such as 18TWL8562811322 or 18T WL 85628 11322.
+	mgrsPattern = regexp.MustCompile(`^(\d{1,2}) ?([A-Z]) ?([A-Z])([A-Z]) ?(\d*) ?(\d*)$`)
+)
+
+// eccentricity returns the WGS84 first and second eccentricities squared.
+func eccentricity() (e2, ep2 float64) {
+	e2 = wgs84Flattening * (2 - wgs84Flattening)
+	return e2, e2 / (1 - e2)
+}
+
+// ParseUTM converts a UTM reference such as "18T 585628 4511322", a zone,
+// latitude band, easting, and northing in meters, to WGS84 coordinates. The
+// band only picks the hemisphere: N and later are north of the equator.
+func ParseUTM(reference string) (models.Coordinates, error) {
+	match := utmPattern.FindStringSubmatch(strings.ToUpper(strings.TrimSpace(reference)))
+	if match == nil {
+		return models.Coordinates{}, fmt.Errorf("utm %q must be a zone, latitude band, easting, and northing, such as 18T 585628 4511322", reference)
+	}
+	zone, _ := strconv.Atoi(match[1])
+	if zone < 1 || zone > 60 {
+		return models.Coordinates{}, fmt.Errorf("utm zone must be from 1 to 60, got %d", zone)
+	}
+	easting, _ := strconv.ParseFloat(match[3], 64)
+	northing, _ := strconv.ParseFloat(match[4], 64)
+	if easting < 100000 || easting > 900000 {
+		return models.Coordinates{}, fmt.Errorf("utm easting must be from 100000 to 900000, got %g", easting)
+	}
+	if northing > utmFalseNorthing {
+		return models.Coordinates{}, fmt.Errorf("utm northing must be at most %g, got %g", utmFalseNorthing, northing)
+	}
+	return fromUTM(zone, match[2][0] >= 'N', easting, northing), nil
+}
+
+// ParseMGRS converts an MGRS reference such as "18TWL8562811322" to WGS84
+// coordinates. A reference coarser than 1 m converts to the center of the
+// square it names. The polar UPS regions are not supported.
+func ParseMGRS(reference string) (models.Coordinates, error) {
+	match := mgrsPattern.FindStringSubmatch(strings.ToUpper(strings.TrimSpace(reference)))
+	if match == nil {
+		return models.Coordinates{}, fmt.Errorf("mgrs %q must be a zone, latitude band, 100 km square, and digits, such as 18TWL8562811322", reference)
+	}
+	zone, _ := strconv.Atoi(match[1])
+	if zone < 1 || zone > 60 {
+		return models.Coordinates{}, fmt.Errorf("mgrs zone must be from 1 to 60, got %d", zone)
+	}
+	band := strings.IndexByte(latitudeBands, match[2][0])
+	if band < 0 {
+		return models.Coordinates{}, fmt.Errorf("mgrs latitude band %s is not supported; polar UPS references are not", match[2])
+	}
+
+	column := strings.IndexByte(mgrsColumnLetters[(zone-1)%3], match[3][0])
+	if column < 0 {
+		return models.Coordinates{}, fmt.Errorf("mgrs column letter %s is not used in zone %d", match[3], zone)
+	}
+	row := strings.IndexByte(mgrsRowLetters, match[4][0])
+	if row < 0 {
+		return models.Coordinates{}, fmt.Errorf("mgrs row letter %s is not valid", match[4])
+	}
+	// Even zones start their rows at F
+	if zone%2 == 0 {
+		row = (row - 5 + len(mgrsRowLetters)) % len(mgrsRowLetters)
+	}
+
+	digits := match[5] + match[6]
+	if match[6] != "" && len(match[5]) != len(match[6]) {
+		return models.Coordinates{}, fmt.Errorf("mgrs easting and northing must have the same number of digits")
+	}
+	if len(digits)%2 != 0 || len(digits) > 10 {
+		return models.Coordinates{}, fmt.Errorf("mgrs must have an even number of digits, at most 10, got %d", len(digits))
+	}
+	precision := len(digits) / 2
+	resolution := math.Pow10(5 - precision)
+	easting, northing := 0.0, 0.0
+	if precision > 0 {
+		e, _ := strconv.Atoi(digits[:precision])
+		n, _ := strconv.Atoi(digits[precision:])
+		easting, northing = float64(e)*resolution, float64(n)*resolution
+	}
+	easting += float64(column+1)*100000 + resolution/2
+	northing += float64(row)*100000 + resolution/2
+
+	// Row letters repeat every 2,000 km, so step north until the northing
+	// reaches the band's southern edge, allowing for the bottom row's square
+	bandLatitude := float64(band*8 - 80)
+	north := band >= strings.IndexByte(latitudeBands, 'N')
+	_, bandNorthing := toUTM(bandLatitude, 0, 0, north)
+	bandNorthing = math.Floor(bandNorthing/100000) * 100000
+	for northing < bandNorthing {
+		northing += 2000000
+	}
+	return fromUTM(zone, north, easting, northing), nil
+}
+
+// fromUTM inverts the transverse Mercator projection of the given zone.
+func fromUTM(zone int, north bool, easting, northing float64) models.Coordinates {
+	e2, ep2 := eccentricity()
+	x := easting - utmFalseEasting
+	y := northing
+	if !north {
+		y -= utmFalseNorthing
+	}
+
+	mu := y / utmScaleFactor / (wgs84SemiMajorAxis * (1 - e2/4 - 3*e2*e2/64 - 5*e2*e2*e2/256))
+	e1 := (1 - math.Sqrt(1-e2)) / (1 + math.Sqrt(1-e2))
+	phi := mu + (3*e1/2-27*math.Pow(e1, 3)/32)*math.Sin(2*mu) +
+		(21*e1*e1/16-55*math.Pow(e1, 4)/32)*math.Sin(4*mu) +
+		(151*math.Pow(e1, 3)/96)*math.Sin(6*mu) +
+		(1097*math.Pow(e1, 4)/512)*math.Sin(8*mu)
+
+	sinPhi, cosPhi, tanPhi := math.Sin(phi), math.Cos(phi), math.Tan(phi)
+	n := wgs84SemiMajorAxis / math.Sqrt(1-e2*sinPhi*sinPhi)
+	t := tanPhi * tanPhi
+	c := ep2 * cosPhi * cosPhi
+	r := wgs84SemiMajorAxis * (1 - e2) / math.Pow(1-e2*sinPhi*sinPhi, 1.5)
+	d := x / (n * utmScaleFactor)
+
+	latitude := phi - (n*tanPhi/r)*(d*d/2-
+		(5+3*t+10*c-4*c*c-9*ep2)*math.Pow(d, 4)/24+
+		(61+90*t+298*c+45*t*t-252*ep2-3*c*c)*math.Pow(d, 6)/720)
+	longitude := (d - (1+2*t+c)*math.Pow(d, 3)/6 +
+		(5-2*c+28*t-3*c*c+8*ep2+24*t*t)*math.Pow(d, 5)/120) / cosPhi
+
+	return models.Coordinates{
+		Latitude:  latitude * 180 / math.Pi,
+		Longitude: centralMeridian(zone) + longitude*180/math.Pi,
+	}
+}
+
+// toUTM projects a latitude and a longitude offset from a zone's central
+// meridian, both in degrees, to that zone's easting and northing.
+func toUTM(latitude, longitude, meridian float64, north bool) (easting, northing float64) {
+	e2, ep2 := eccentricity()
+	phi := latitude * math.Pi / 180
+	sinPhi, cosPhi, tanPhi := math.Sin(phi), math.Cos(phi), math.Tan(phi)
+
+	n := wgs84SemiMajorAxis / math.Sqrt(1-e2*sinPhi*sinPhi)
+	t := tanPhi * tanPhi
+	c := ep2 * cosPhi * cosPhi
+	a := cosPhi * (longitude - meridian) * math.Pi / 180
+	m := wgs84SemiMajorAxis * ((1-e2/4-3*e2*e2/64-5*e2*e2*e2/256)*phi -
+		(3*e2/8+3*e2*e2/32+45*e2*e2*e2/1024)*math.Sin(2*phi) +
+		(15*e2*e2/256+45*e2*e2*e2/1024)*math.Sin(4*phi) -
+		(35*e2*e2*e2/3072)*math.Sin(6*phi))
+
+	easting = utmScaleFactor*n*(a+(1-t+c)*math.Pow(a, 3)/6+
+		(5-18*t+t*t+72*c-58*ep2)*math.Pow(a, 5)/120) + utmFalseEasting
+	northing = utmScaleFactor * (m + n*tanPhi*(a*a/2+
+		(5-t+9*c+4*c*c)*math.Pow(a, 4)/24+
+		(61-58*t+t*t+600*c-330*ep2)*math.Pow(a, 6)/720))
+	if !north {
+		northing += utmFalseNorthing
+	}
+	return easting, northing
+}
+
+// centralMeridian returns the longitude of a UTM zone's central meridian.
+func centralMeridian(zone int) float64 {
+	return float64(zone)*6 - 183
+}
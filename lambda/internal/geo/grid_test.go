@@ -0,0 +1,104 @@
+package geo
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"pgregory.net/rapid"
+)
+
+// gridTolerance is about a meter, in degrees.
+const gridTolerance = 1e-5
+
+func TestParseUTM(t *testing.T) {
+	tests := []struct {
+		name      string
+		reference string
+		latitude  float64
+		longitude float64
+		errMsg    string
+	}{
+		{name: "Northern hemisphere", reference: "18T 585628 4511322", latitude: 40.748396, longitude: -73.985705},
+		{name: "Southern hemisphere", reference: "56H 334873 6252266", latitude: -33.857001, longitude: 151.214998},
+		{name: "Lowercase without a space", reference: "18t 585628 4511322", latitude: 40.748396, longitude: -73.985705},
+		{name: "Malformed", reference: "18T 585628", errMsg: `utm "18T 585628" must be a zone, latitude band, easting, and northing, such as 18T 585628 4511322`},
+		{name: "Polar band", reference: "18Z 585628 4511322", errMsg: "must be a zone, latitude band, easting, and northing"},
+		{name: "Zone out of range", reference: "61T 585628 4511322", errMsg: "utm zone must be from 1 to 60, got 61"},
+		{name: "Easting out of range", reference: "18T 85628 4511322", errMsg: "utm easting must be from 100000 to 900000, got 85628"},
+		{name: "Northing out of range", reference: "18T 585628 14511322", errMsg: "utm northing must be at most 1e+07, got 1.4511322e+07"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			coordinates, err := ParseUTM(tt.reference)
+			if tt.errMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.InDelta(t, tt.latitude, coordinates.Latitude, gridTolerance)
+			assert.InDelta(t, tt.longitude, coordinates.Longitude, gridTolerance)
+		})
+	}
+}
+
+func TestParseMGRS(t *testing.T) {
+	tests := []struct {
+		name      string
+		reference string
+		latitude  float64
+		longitude float64
+		errMsg    string
+	}{
+		{name: "1 m precision", reference: "18TWL8562811322", latitude: 40.748400, longitude: -73.985699},
+		{name: "Spaced", reference: "18T WL 85628 11322", latitude: 40.748400, longitude: -73.985699},
+		{name: "Southern hemisphere", reference: "56HLH3487352266", latitude: -33.856996, longitude: 151.215003},
+		{name: "10 m precision", reference: "4QFJ12345678", latitude: 21.309478, longitude: -157.916819},
+		{name: "Far north", reference: "33XVG74594359", latitude: 77.865508, longitude: 13.917310},
+		{name: "Malformed", reference: "18TWL856", errMsg: "mgrs must have an even number of digits, at most 10, got 3"},
+		{name: "Uneven groups", reference: "18TWL 856 11322", errMsg: "mgrs easting and northing must have the same number of digits"},
+		{name: "Polar", reference: "ZGC1234567890", errMsg: `mgrs "ZGC1234567890" must be a zone`},
+		{name: "Polar band", reference: "18ZWL8562811322", errMsg: "mgrs latitude band Z is not supported"},
+		{name: "Column of another zone", reference: "18TAL8562811322", errMsg: "mgrs column letter A is not used in zone 18"},
+		{name: "Unknown row", reference: "18TWW8562811322", errMsg: "mgrs row letter W is not valid"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			coordinates, err := ParseMGRS(tt.reference)
+			if tt.errMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.InDelta(t, tt.latitude, coordinates.Latitude, gridTolerance)
+			assert.InDelta(t, tt.longitude, coordinates.Longitude, gridTolerance)
+		})
+	}
+
+	t.Run("Coarse references convert to the center of their square", func(t *testing.T) {
+		square, err := ParseMGRS("18TWL")
+		require.NoError(t, err)
+		center, err := ParseMGRS("18TWL5000050000")
+		require.NoError(t, err)
+		assert.InDelta(t, center.Latitude, square.Latitude, gridTolerance)
+		assert.InDelta(t, center.Longitude, square.Longitude, gridTolerance)
+	})
+}
+
+func TestUTMRoundTrip(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		latitude := rapid.Float64Range(-80, 84).Draw(t, "latitude")
+		longitude := rapid.Float64Range(-180, 179.999).Draw(t, "longitude")
+		zone := int(math.Floor((longitude+180)/6)) + 1
+
+		easting, northing := toUTM(latitude, longitude, centralMeridian(zone), latitude >= 0)
+		coordinates := fromUTM(zone, latitude >= 0, easting, northing)
+		assert.InDelta(t, latitude, coordinates.Latitude, gridTolerance)
+		assert.InDelta(t, longitude, coordinates.Longitude, gridTolerance)
+	})
+}
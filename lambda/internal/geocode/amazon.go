@@ -0,0 +1,125 @@
+package geocode
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/steverhoton/location-lambda/internal/models"
+)
+
+// HTTPClient is the subset of http.Client used by the Amazon Location geocoder.
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// AmazonLocationGeocoder geocodes with an Amazon Location Service place index.
+type AmazonLocationGeocoder struct {
+	httpClient  HTTPClient
+	signer      *v4.Signer
+	credentials aws.CredentialsProvider
+	region      string
+	indexName   string
+	endpoint    string
+}
+
+// NewAmazonLocationGeocoder creates a geocoder for the given place index.
+func NewAmazonLocationGeocoder(cfg aws.Config, indexName string) *AmazonLocationGeocoder {
+	return &AmazonLocationGeocoder{
+		httpClient:  http.DefaultClient,
+		signer:      v4.NewSigner(),
+		credentials: cfg.Credentials,
+		region:      cfg.Region,
+		indexName:   indexName,
+		endpoint:    fmt.Sprintf("https://places.geo.%s.amazonaws.com", cfg.Region),
+	}
+}
+
+// searchTextRequest is the SearchPlaceIndexForText request body.
+type searchTextRequest struct {
+	Text       string `json:"Text"`
+	MaxResults int    `json:"MaxResults"`
+}
+
+// searchTextResponse is the subset of the SearchPlaceIndexForText response used here.
+type searchTextResponse struct {
+	Results []struct {
+		Place struct {
+			Label    string `json:"Label"`
+			Geometry struct {
+				Point []float64 `json:"Point"` // [longitude, latitude]
+			} `json:"Geometry"`
+		} `json:"Place"`
+		Relevance float64 `json:"Relevance"`
+	} `json:"Results"`
+}
+
+// Name identifies the provider.
+func (g *AmazonLocationGeocoder) Name() string {
+	return "amazon_location"
+}
+
+// Geocode returns the best match for query.
+func (g *AmazonLocationGeocoder) Geocode(ctx context.Context, query string) (*Result, error) {
+	body, err := json.Marshal(searchTextRequest{Text: query, MaxResults: 1})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal geocode request: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("%s/places/v0/indexes/%s/search/text", g.endpoint, url.PathEscape(g.indexName))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build geocode request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	creds, err := g.credentials.Retrieve(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve credentials: %w", err)
+	}
+	hash := sha256.Sum256(body)
+	if err := g.signer.SignHTTP(ctx, creds, req, hex.EncodeToString(hash[:]), "geo", g.region, time.Now()); err != nil {
+		return nil, fmt.Errorf("failed to sign geocode request: %w", err)
+	}
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to geocode: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read geocode response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("geocode request failed with status %d: %s", resp.StatusCode, respBody)
+	}
+
+	var parsed searchTextResponse
+	if err := json.Unmarshal(respBody, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal geocode response: %w", err)
+	}
+	if len(parsed.Results) == 0 || len(parsed.Results[0].Place.Geometry.Point) != 2 {
+		return nil, ErrNoMatch
+	}
+
+	best := parsed.Results[0]
+	return &Result{
+		Coordinates: models.Coordinates{
+			Latitude:  best.Place.Geometry.Point[1],
+			Longitude: best.Place.Geometry.Point[0],
+		},
+		Label:     best.Place.Label,
+		Relevance: best.Relevance,
+	}, nil
+}
@@ -0,0 +1,69 @@
+package geocode
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestGeocoder(t *testing.T, handler http.HandlerFunc) *AmazonLocationGeocoder {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	g := NewAmazonLocationGeocoder(aws.Config{
+		Region:      "us-east-1",
+		Credentials: credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""),
+	}, "places")
+	g.endpoint = server.URL
+	return g
+}
+
+func TestAmazonLocationGeocoder(t *testing.T) {
+	t.Run("Best match", func(t *testing.T) {
+		g := newTestGeocoder(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/places/v0/indexes/places/search/text", r.URL.Path)
+			assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256"))
+
+			var req searchTextRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			assert.Equal(t, "123 Main St, Springfield", req.Text)
+
+			_, _ = w.Write([]byte(`{"Results":[{"Place":{"Label":"123 Main St, Springfield, IL","Geometry":{"Point":[-89.65,39.78]}},"Relevance":0.97}]}`))
+		})
+
+		result, err := g.Geocode(context.Background(), "123 Main St, Springfield")
+		require.NoError(t, err)
+		assert.Equal(t, 39.78, result.Coordinates.Latitude)
+		assert.Equal(t, -89.65, result.Coordinates.Longitude)
+		assert.Equal(t, "123 Main St, Springfield, IL", result.Label)
+		assert.Equal(t, 0.97, result.Relevance)
+	})
+
+	t.Run("No match", func(t *testing.T) {
+		g := newTestGeocoder(t, func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"Results":[]}`))
+		})
+
+		_, err := g.Geocode(context.Background(), "nowhere")
+		assert.ErrorIs(t, err, ErrNoMatch)
+	})
+
+	t.Run("Service error", func(t *testing.T) {
+		g := newTestGeocoder(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"message":"denied"}`))
+		})
+
+		_, err := g.Geocode(context.Background(), "123 Main St")
+		assert.EqualError(t, err, `geocode request failed with status 403: {"message":"denied"}`)
+	})
+}
@@ -0,0 +1,189 @@
+package geocode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/repository"
+)
+
+// defaultBackfillBatchSize is how many locations a backfill lists at a time.
+const defaultBackfillBatchSize = 25
+
+// BackfillStore lists address locations and stores the coordinates geocoded
+// for them.
+type BackfillStore interface {
+	List(ctx context.Context, accountID string, options *repository.ListOptions) (*repository.ListResult, error)
+	repository.GeocodeWriter
+}
+
+// BackfillOptions controls a backfill run.
+type BackfillOptions struct {
+	// BatchSize is how many locations are listed at a time; 0 uses 25
+	BatchSize int32
+	// Rate caps geocode requests per second; 0 leaves them unlimited
+	Rate float64
+	// DryRun lists the locations that would be geocoded without calling the geocoder
+	DryRun bool
+}
+
+// BackfillResult reports what a backfill did, by location ID.
+type BackfillResult struct {
+	// Geocoded locations were given coordinates, or would be with DryRun
+	Geocoded []string `json:"geocoded"`
+	// Unmatched locations' addresses were not found by the geocoder
+	Unmatched []string `json:"unmatched"`
+	// Skipped locations changed while being geocoded and were left alone
+	Skipped []string `json:"skipped"`
+}
+
+// Backfiller geocodes address locations stored without coordinates.
+type Backfiller struct {
+	geocoder Geocoder
+	store    BackfillStore
+	now      func() time.Time
+	sleep    func(ctx context.Context, d time.Duration) error
+}
+
+// NewBackfiller creates a backfiller geocoding with geocoder.
+func NewBackfiller(geocoder Geocoder, store BackfillStore) *Backfiller {
+	return &Backfiller{
+		geocoder: geocoder,
+		store:    store,
+		now:      time.Now,
+		sleep:    sleepContext,
+	}
+}
+
+// Run geocodes the account's address locations that have no coordinates,
+// one batch at a time and no faster than options.Rate, storing each match
+// with its provenance and confidence. Addresses the geocoder cannot find are reported and
+// left as they are, so a later run tries them again. Any other geocoder or
+// storage error stops the run; what was done so far is returned with it, and
+// rerunning resumes with the locations still missing coordinates.
+func (b *Backfiller) Run(ctx context.Context, accountID string, options BackfillOptions) (*BackfillResult, error) {
+	if options.BatchSize <= 0 {
+		options.BatchSize = defaultBackfillBatchSize
+	}
+	pace := newPacer(options.Rate, b.now, b.sleep)
+
+	result := &BackfillResult{Geocoded: []string{}, Unmatched: []string{}, Skipped: []string{}}
+	// Drafts and inactive locations are geocoded too, so they are placed before apps see them
+	listOptions := &repository.ListOptions{Limit: aws.Int32(options.BatchSize), MissingCoordinates: true, IncludeDrafts: true, IncludeInactive: true}
+	for {
+		page, err := b.store.List(ctx, accountID, listOptions)
+		if err != nil {
+			return result, err
+		}
+		for _, envelope := range page.Items {
+			location, ok := envelope.Location.(models.AddressLocation)
+			if !ok || location.Coordinates != nil {
+				continue
+			}
+			if options.DryRun {
+				result.Geocoded = append(result.Geocoded, envelope.LocationID)
+				continue
+			}
+
+			if err := pace.wait(ctx); err != nil {
+				return result, err
+			}
+			match, err := b.geocoder.Geocode(ctx, addressQuery(location.Address))
+			if errors.Is(err, ErrNoMatch) {
+				result.Unmatched = append(result.Unmatched, envelope.LocationID)
+				continue
+			}
+			if err != nil {
+				return result, fmt.Errorf("failed to geocode location %s: %w", envelope.LocationID, err)
+			}
+
+			info := geocodeInfo(b.geocoder, match, location.Address, b.now())
+			stored, err := b.store.SetGeocode(ctx, accountID, envelope.LocationID, location.Address, match.Coordinates, info)
+			if err != nil {
+				return result, fmt.Errorf("failed to store geocode of location %s: %w", envelope.LocationID, err)
+			}
+			if stored {
+				result.Geocoded = append(result.Geocoded, envelope.LocationID)
+			} else {
+				result.Skipped = append(result.Skipped, envelope.LocationID)
+			}
+		}
+		if page.NextCursor == nil {
+			return result, nil
+		}
+		listOptions.Cursor = page.NextCursor
+	}
+}
+
+// geocodeInfo records the provenance of a match for address.
+func geocodeInfo(geocoder Geocoder, match *Result, address models.Address, now time.Time) models.GeocodeInfo {
+	return models.GeocodeInfo{
+		Provider:    geocoder.Name(),
+		Label:       match.Label,
+		Confidence:  match.Relevance,
+		GeocodedAt:  now.UTC(),
+		AddressHash: models.AddressHash(address),
+	}
+}
+
+// addressQuery formats an address as a single geocoding query.
+func addressQuery(a models.Address) string {
+	parts := make([]string, 0, 5)
+	for _, part := range []string{
+		a.StreetAddress,
+		a.StreetAddress2,
+		a.City,
+		strings.TrimSpace(a.StateProvince + " " + a.PostalCode),
+		a.Country,
+	} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// pacer spaces geocode requests to at most a rate per second.
+type pacer struct {
+	interval time.Duration
+	last     time.Time
+	now      func() time.Time
+	sleep    func(ctx context.Context, d time.Duration) error
+}
+
+// newPacer creates a pacer for rate requests per second; 0 leaves them unlimited.
+func newPacer(rate float64, now func() time.Time, sleep func(ctx context.Context, d time.Duration) error) *pacer {
+	p := &pacer{now: now, sleep: sleep}
+	if rate > 0 {
+		p.interval = time.Duration(float64(time.Second) / rate)
+	}
+	return p
+}
+
+// wait returns once a request may be made, or when ctx is done.
+func (p *pacer) wait(ctx context.Context) error {
+	if wait := p.interval - p.now().Sub(p.last); !p.last.IsZero() && wait > 0 {
+		if err := p.sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+	p.last = p.now()
+	return nil
+}
+
+// sleepContext waits for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,148 @@
+package geocode
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBackfillStore serves fixed pages and records stored geocodes.
+type fakeBackfillStore struct {
+	pages  []*repository.ListResult
+	listed []*repository.ListOptions
+	stored map[string]models.GeocodeInfo
+	stale  map[string]bool
+	// previous holds the geocode each refresh replaced
+	previous map[string]models.GeocodeInfo
+}
+
+func (s *fakeBackfillStore) List(_ context.Context, _ string, options *repository.ListOptions) (*repository.ListResult, error) {
+	copied := *options
+	s.listed = append(s.listed, &copied)
+	return s.pages[len(s.listed)-1], nil
+}
+
+func (s *fakeBackfillStore) SetGeocode(_ context.Context, _, locationID string, _ models.Address, _ models.Coordinates, info models.GeocodeInfo) (bool, error) {
+	if s.stale[locationID] {
+		return false, nil
+	}
+	if s.stored == nil {
+		s.stored = map[string]models.GeocodeInfo{}
+	}
+	s.stored[locationID] = info
+	return true, nil
+}
+
+func (s *fakeBackfillStore) RefreshGeocode(ctx context.Context, accountID, locationID string, previous models.GeocodeInfo, address models.Address, coordinates models.Coordinates, info models.GeocodeInfo) (bool, error) {
+	if s.previous == nil {
+		s.previous = map[string]models.GeocodeInfo{}
+	}
+	s.previous[locationID] = previous
+	return s.SetGeocode(ctx, accountID, locationID, address, coordinates, info)
+}
+
+// fakeGeocoder matches every query but those listed in unmatched.
+type fakeGeocoder struct {
+	queries   []string
+	unmatched map[string]bool
+	err       error
+}
+
+func (g *fakeGeocoder) Name() string {
+	return "fake"
+}
+
+func (g *fakeGeocoder) Geocode(_ context.Context, query string) (*Result, error) {
+	g.queries = append(g.queries, query)
+	if g.err != nil {
+		return nil, g.err
+	}
+	if g.unmatched[query] {
+		return nil, ErrNoMatch
+	}
+	return &Result{Coordinates: models.Coordinates{Latitude: 39.7817, Longitude: -89.6501}, Label: query, Relevance: 0.9}, nil
+}
+
+func addressEnvelope(locationID, street string) repository.LocationEnvelope {
+	return repository.LocationEnvelope{LocationID: locationID, Location: models.AddressLocation{
+		LocationBase: models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeAddress},
+		Address:      models.Address{StreetAddress: street, City: "Springfield", StateProvince: "IL", PostalCode: "62704", Country: "US"},
+	}}
+}
+
+func TestBackfillerRun(t *testing.T) {
+	now := time.Date(2024, time.June, 4, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Geocodes every batch at the configured rate", func(t *testing.T) {
+		store := &fakeBackfillStore{
+			pages: []*repository.ListResult{
+				{Items: []repository.LocationEnvelope{addressEnvelope("loc-001", "1 Main St"), addressEnvelope("loc-002", "2 Main St")}, NextCursor: aws.String("cursor-1")},
+				{Items: []repository.LocationEnvelope{addressEnvelope("loc-003", "3 Main St"), addressEnvelope("loc-004", "4 Main St")}},
+			},
+			stale: map[string]bool{"loc-004": true},
+		}
+		geocoder := &fakeGeocoder{unmatched: map[string]bool{"2 Main St, Springfield, IL 62704, US": true}}
+		backfiller := NewBackfiller(geocoder, store)
+		backfiller.now = func() time.Time { return now }
+		var waits []time.Duration
+		backfiller.sleep = func(_ context.Context, d time.Duration) error {
+			waits = append(waits, d)
+			return nil
+		}
+
+		result, err := backfiller.Run(context.Background(), "acc-12345", BackfillOptions{BatchSize: 2, Rate: 4})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"loc-001", "loc-003"}, result.Geocoded)
+		assert.Equal(t, []string{"loc-002"}, result.Unmatched)
+		assert.Equal(t, []string{"loc-004"}, result.Skipped)
+
+		require.Len(t, store.listed, 2)
+		assert.True(t, store.listed[0].MissingCoordinates)
+		assert.Equal(t, int32(2), *store.listed[0].Limit)
+		assert.Equal(t, "cursor-1", *store.listed[1].Cursor)
+
+		assert.Equal(t, "1 Main St, Springfield, IL 62704, US", geocoder.queries[0])
+		assert.Equal(t, models.GeocodeInfo{
+			Provider:    "fake",
+			Label:       "1 Main St, Springfield, IL 62704, US",
+			Confidence:  0.9,
+			GeocodedAt:  now,
+			AddressHash: models.AddressHash(addressEnvelope("loc-001", "1 Main St").Location.(models.AddressLocation).Address),
+		}, store.stored["loc-001"])
+		// The clock stands still, so every request after the first waits a full interval
+		assert.Equal(t, []time.Duration{250 * time.Millisecond, 250 * time.Millisecond, 250 * time.Millisecond}, waits)
+	})
+
+	t.Run("Lists without geocoding on a dry run", func(t *testing.T) {
+		store := &fakeBackfillStore{pages: []*repository.ListResult{
+			{Items: []repository.LocationEnvelope{addressEnvelope("loc-001", "1 Main St")}},
+		}}
+		geocoder := &fakeGeocoder{}
+
+		result, err := NewBackfiller(geocoder, store).Run(context.Background(), "acc-12345", BackfillOptions{DryRun: true})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"loc-001"}, result.Geocoded)
+		assert.Empty(t, geocoder.queries)
+		assert.Empty(t, store.stored)
+		assert.Equal(t, int32(defaultBackfillBatchSize), *store.listed[0].Limit)
+	})
+
+	t.Run("Stops on geocoder errors with the progress so far", func(t *testing.T) {
+		store := &fakeBackfillStore{pages: []*repository.ListResult{
+			{Items: []repository.LocationEnvelope{addressEnvelope("loc-001", "1 Main St")}},
+		}}
+		geocoder := &fakeGeocoder{err: errors.New("throttled")}
+
+		result, err := NewBackfiller(geocoder, store).Run(context.Background(), "acc-12345", BackfillOptions{})
+		assert.EqualError(t, err, "failed to geocode location loc-001: throttled")
+		require.NotNil(t, result)
+		assert.Empty(t, result.Geocoded)
+	})
+}
@@ -0,0 +1,26 @@
+// Package geocode resolves free-text addresses to coordinates.
+package geocode
+
+import (
+	"context"
+	"errors"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+)
+
+// ErrNoMatch is returned when the provider finds no place for a query.
+var ErrNoMatch = errors.New("no geocoding match")
+
+// Result is the best match for a geocoding query.
+type Result struct {
+	Coordinates models.Coordinates `json:"coordinates"`
+	Label       string             `json:"label,omitempty"`     // Provider's formatted address
+	Relevance   float64            `json:"relevance,omitempty"` // 0-1 match confidence reported by the provider
+}
+
+// Geocoder resolves a free-text address to coordinates.
+type Geocoder interface {
+	// Name identifies the provider in geocode provenance.
+	Name() string
+	Geocode(ctx context.Context, query string) (*Result, error)
+}
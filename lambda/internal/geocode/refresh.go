@@ -0,0 +1,127 @@
+package geocode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/repository"
+)
+
+// DefaultRefreshMaxAge is how old a geocode may get before a refresh replaces it.
+const DefaultRefreshMaxAge = 90 * 24 * time.Hour
+
+// RefreshOptions controls a refresh run.
+type RefreshOptions struct {
+	// MaxAge is the age at which a geocode is stale; 0 refreshes only
+	// geocodes of addresses that have since changed
+	MaxAge time.Duration
+	// BatchSize is how many locations are listed at a time; 0 uses 25
+	BatchSize int32
+	// Rate caps geocode requests per second; 0 leaves them unlimited
+	Rate float64
+	// DryRun lists the stale locations without calling the geocoder
+	DryRun bool
+}
+
+// RefreshResult reports what a refresh did, by location ID.
+type RefreshResult struct {
+	// Refreshed locations were geocoded again, or would be with DryRun
+	Refreshed []string `json:"refreshed"`
+	// Unmatched locations' addresses were not found by the geocoder and
+	// keep their previous coordinates
+	Unmatched []string `json:"unmatched"`
+	// Skipped locations changed while being geocoded and were left alone
+	Skipped []string `json:"skipped"`
+}
+
+// Refresher geocodes address locations again when their geocode is stale.
+type Refresher struct {
+	geocoder Geocoder
+	store    BackfillStore
+	now      func() time.Time
+	sleep    func(ctx context.Context, d time.Duration) error
+}
+
+// NewRefresher creates a refresher geocoding with geocoder.
+func NewRefresher(geocoder Geocoder, store BackfillStore) *Refresher {
+	return &Refresher{
+		geocoder: geocoder,
+		store:    store,
+		now:      time.Now,
+		sleep:    sleepContext,
+	}
+}
+
+// Run geocodes the account's geocoded address locations again when their
+// geocode is older than options.MaxAge or their address no longer hashes to
+// the geocode's addressHash, pacing requests like a backfill. Hand-entered
+// and locked coordinates are never touched. An address the geocoder cannot
+// find keeps its coordinates and is reported, to be tried again next run.
+// Any other error stops the run with what was done so far; as refreshed
+// geocodes are no longer stale, rerunning picks up where it stopped.
+func (r *Refresher) Run(ctx context.Context, accountID string, options RefreshOptions) (*RefreshResult, error) {
+	if options.BatchSize <= 0 {
+		options.BatchSize = defaultBackfillBatchSize
+	}
+	pace := newPacer(options.Rate, r.now, r.sleep)
+
+	result := &RefreshResult{Refreshed: []string{}, Unmatched: []string{}, Skipped: []string{}}
+	listOptions := &repository.ListOptions{Limit: aws.Int32(options.BatchSize), Geocoded: true, IncludeDrafts: true, IncludeInactive: true}
+	for {
+		page, err := r.store.List(ctx, accountID, listOptions)
+		if err != nil {
+			return result, err
+		}
+		for _, envelope := range page.Items {
+			location, ok := envelope.Location.(models.AddressLocation)
+			if !ok || location.Geocode == nil || location.CoordinatesLocked || !stale(*location.Geocode, location.Address, r.now(), options.MaxAge) {
+				continue
+			}
+			if options.DryRun {
+				result.Refreshed = append(result.Refreshed, envelope.LocationID)
+				continue
+			}
+
+			if err := pace.wait(ctx); err != nil {
+				return result, err
+			}
+			match, err := r.geocoder.Geocode(ctx, addressQuery(location.Address))
+			if errors.Is(err, ErrNoMatch) {
+				result.Unmatched = append(result.Unmatched, envelope.LocationID)
+				continue
+			}
+			if err != nil {
+				return result, fmt.Errorf("failed to geocode location %s: %w", envelope.LocationID, err)
+			}
+
+			info := geocodeInfo(r.geocoder, match, location.Address, r.now())
+			stored, err := r.store.RefreshGeocode(ctx, accountID, envelope.LocationID, *location.Geocode, location.Address, match.Coordinates, info)
+			if err != nil {
+				return result, fmt.Errorf("failed to store geocode of location %s: %w", envelope.LocationID, err)
+			}
+			if stored {
+				result.Refreshed = append(result.Refreshed, envelope.LocationID)
+			} else {
+				result.Skipped = append(result.Skipped, envelope.LocationID)
+			}
+		}
+		if page.NextCursor == nil {
+			return result, nil
+		}
+		listOptions.Cursor = page.NextCursor
+	}
+}
+
+// stale reports whether a geocode of address is older than maxAge, or was
+// made for a different address. Geocodes stored before addresses were hashed
+// go stale by age alone.
+func stale(info models.GeocodeInfo, address models.Address, now time.Time, maxAge time.Duration) bool {
+	if info.AddressHash != "" && info.AddressHash != models.AddressHash(address) {
+		return true
+	}
+	return maxAge > 0 && now.Sub(info.GeocodedAt) >= maxAge
+}
@@ -0,0 +1,111 @@
+package geocode
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// geocodedEnvelope is addressEnvelope geocoded at geocodedAt from the
+// address at geocodedStreet.
+func geocodedEnvelope(locationID, street, geocodedStreet string, geocodedAt time.Time) repository.LocationEnvelope {
+	envelope := addressEnvelope(locationID, street)
+	location := envelope.Location.(models.AddressLocation)
+	geocodedAddress := location.Address
+	geocodedAddress.StreetAddress = geocodedStreet
+	location.Coordinates = &models.Coordinates{Latitude: 39.79, Longitude: -89.64}
+	location.Geocode = &models.GeocodeInfo{Provider: "fake", Confidence: 0.7, GeocodedAt: geocodedAt, AddressHash: models.AddressHash(geocodedAddress)}
+	envelope.Location = location
+	return envelope
+}
+
+func TestRefresherRun(t *testing.T) {
+	now := time.Date(2024, time.June, 4, 12, 0, 0, 0, time.UTC)
+	recent := now.Add(-24 * time.Hour)
+	old := now.Add(-100 * 24 * time.Hour)
+
+	t.Run("Geocodes old geocodes and changed addresses again", func(t *testing.T) {
+		unhashed := addressEnvelope("loc-004", "4 Main St")
+		location := unhashed.Location.(models.AddressLocation)
+		location.Coordinates = &models.Coordinates{Latitude: 39.79, Longitude: -89.64}
+		location.Geocode = &models.GeocodeInfo{Provider: "fake", Confidence: 0.7, GeocodedAt: recent}
+		unhashed.Location = location
+
+		store := &fakeBackfillStore{
+			pages: []*repository.ListResult{
+				{Items: []repository.LocationEnvelope{
+					geocodedEnvelope("loc-001", "1 Main St", "1 Main St", old),
+					geocodedEnvelope("loc-002", "2 Main St", "2 Main St", recent),
+				}, NextCursor: aws.String("cursor-1")},
+				{Items: []repository.LocationEnvelope{
+					geocodedEnvelope("loc-003", "3 Oak Ave", "3 Main St", recent),
+					unhashed,
+					geocodedEnvelope("loc-005", "5 Main St", "5 Main St", old),
+				}},
+			},
+			stale: map[string]bool{"loc-005": true},
+		}
+		refresher := NewRefresher(&fakeGeocoder{}, store)
+		refresher.now = func() time.Time { return now }
+		refresher.sleep = func(context.Context, time.Duration) error { return nil }
+
+		result, err := refresher.Run(context.Background(), "acc-12345", RefreshOptions{MaxAge: DefaultRefreshMaxAge, BatchSize: 2})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"loc-001", "loc-003"}, result.Refreshed)
+		assert.Equal(t, []string{"loc-005"}, result.Skipped)
+		assert.Empty(t, result.Unmatched)
+
+		assert.True(t, store.listed[0].Geocoded)
+		assert.Equal(t, old, store.previous["loc-001"].GeocodedAt)
+		assert.Equal(t, now, store.stored["loc-003"].GeocodedAt)
+		assert.Equal(t, models.AddressHash(models.Address{StreetAddress: "3 Oak Ave", City: "Springfield", StateProvince: "IL", PostalCode: "62704", Country: "US"}), store.stored["loc-003"].AddressHash)
+	})
+
+	t.Run("Refreshes only changed addresses without a maximum age", func(t *testing.T) {
+		store := &fakeBackfillStore{pages: []*repository.ListResult{
+			{Items: []repository.LocationEnvelope{
+				geocodedEnvelope("loc-001", "1 Main St", "1 Main St", old),
+				geocodedEnvelope("loc-002", "2 Oak Ave", "2 Main St", old),
+			}},
+		}}
+		refresher := NewRefresher(&fakeGeocoder{}, store)
+		refresher.now = func() time.Time { return now }
+
+		result, err := refresher.Run(context.Background(), "acc-12345", RefreshOptions{DryRun: true})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"loc-002"}, result.Refreshed)
+		assert.Empty(t, store.stored)
+	})
+
+	t.Run("Keeps coordinates the geocoder no longer finds", func(t *testing.T) {
+		store := &fakeBackfillStore{pages: []*repository.ListResult{
+			{Items: []repository.LocationEnvelope{geocodedEnvelope("loc-001", "1 Main St", "1 Main St", old)}},
+		}}
+		geocoder := &fakeGeocoder{unmatched: map[string]bool{"1 Main St, Springfield, IL 62704, US": true}}
+		refresher := NewRefresher(geocoder, store)
+		refresher.now = func() time.Time { return now }
+
+		result, err := refresher.Run(context.Background(), "acc-12345", RefreshOptions{MaxAge: DefaultRefreshMaxAge})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"loc-001"}, result.Unmatched)
+		assert.Empty(t, store.stored)
+	})
+
+	t.Run("Stops on geocoder errors", func(t *testing.T) {
+		store := &fakeBackfillStore{pages: []*repository.ListResult{
+			{Items: []repository.LocationEnvelope{geocodedEnvelope("loc-001", "1 Main St", "1 Main St", old)}},
+		}}
+		refresher := NewRefresher(&fakeGeocoder{err: errors.New("throttled")}, store)
+		refresher.now = func() time.Time { return now }
+
+		_, err := refresher.Run(context.Background(), "acc-12345", RefreshOptions{MaxAge: DefaultRefreshMaxAge})
+		assert.EqualError(t, err, "failed to geocode location loc-001: throttled")
+	})
+}
@@ -0,0 +1,156 @@
+package geofence
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+const (
+	// EventSource is the source of published geofence events.
+	EventSource = "location-lambda.geofences"
+	// maxEntries is the most entries a PutEvents request can carry.
+	maxEntries = 10
+)
+
+// detailTypes are the detail-type of each transition's event.
+var detailTypes = map[string]string{
+	Enter: "GeofenceEntered",
+	Exit:  "GeofenceExited",
+}
+
+// HTTPClient is the subset of http.Client used by EventBridgePublisher.
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// EventBridgePublisher puts transitions on an EventBridge bus, where rules
+// can route them to consumers, such as an SNS topic.
+type EventBridgePublisher struct {
+	httpClient  HTTPClient
+	signer      *v4.Signer
+	credentials aws.CredentialsProvider
+	region      string
+	endpoint    string
+	busName     string
+}
+
+// NewEventBridgePublisher creates a publisher to the named bus in the configured region.
+func NewEventBridgePublisher(cfg aws.Config, busName string) *EventBridgePublisher {
+	return &EventBridgePublisher{
+		httpClient:  http.DefaultClient,
+		signer:      v4.NewSigner(),
+		credentials: cfg.Credentials,
+		region:      cfg.Region,
+		endpoint:    fmt.Sprintf("https://events.%s.amazonaws.com/", cfg.Region),
+		busName:     busName,
+	}
+}
+
+// putEventsEntry is an event of a PutEvents request.
+type putEventsEntry struct {
+	Source       string `json:"Source"`
+	DetailType   string `json:"DetailType"`
+	Detail       string `json:"Detail"`
+	EventBusName string `json:"EventBusName"`
+	Time         int64  `json:"Time"`
+}
+
+// putEventsResponse is the subset of the PutEvents response used here.
+type putEventsResponse struct {
+	FailedEntryCount int `json:"FailedEntryCount"`
+	Entries          []struct {
+		ErrorCode    string `json:"ErrorCode"`
+		ErrorMessage string `json:"ErrorMessage"`
+	} `json:"Entries"`
+}
+
+// Publish puts each transition on the bus as an event whose detail is the
+// transition, ten to a request. It fails if any event is not accepted.
+func (p *EventBridgePublisher) Publish(ctx context.Context, transitions []Transition) error {
+	for start := 0; start < len(transitions); start += maxEntries {
+		end := start + maxEntries
+		if end > len(transitions) {
+			end = len(transitions)
+		}
+		entries := make([]putEventsEntry, 0, end-start)
+		for _, transition := range transitions[start:end] {
+			detail, err := json.Marshal(transition)
+			if err != nil {
+				return fmt.Errorf("failed to marshal geofence event: %w", err)
+			}
+			entries = append(entries, putEventsEntry{
+				Source:       EventSource,
+				DetailType:   detailTypes[transition.Type],
+				Detail:       string(detail),
+				EventBusName: p.busName,
+				Time:         transition.At.Unix(),
+			})
+		}
+		if err := p.putEvents(ctx, entries); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// putEvents sends a SigV4-signed PutEvents request.
+func (p *EventBridgePublisher) putEvents(ctx context.Context, entries []putEventsEntry) error {
+	body, err := json.Marshal(map[string]interface{}{"Entries": entries})
+	if err != nil {
+		return fmt.Errorf("failed to marshal geofence events: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build geofence events request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "AWSEvents.PutEvents")
+
+	creds, err := p.credentials.Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve credentials: %w", err)
+	}
+	hash := sha256.Sum256(body)
+	if err := p.signer.SignHTTP(ctx, creds, req, hex.EncodeToString(hash[:]), "events", p.region, time.Now()); err != nil {
+		return fmt.Errorf("failed to sign geofence events request: %w", err)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to publish geofence events: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read geofence events response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("geofence events request failed with status %d: %s", resp.StatusCode, respBody)
+	}
+
+	var result putEventsResponse
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return fmt.Errorf("failed to unmarshal geofence events response: %w", err)
+	}
+	if result.FailedEntryCount > 0 {
+		for _, entry := range result.Entries {
+			if entry.ErrorCode != "" {
+				return fmt.Errorf("%d geofence events were not published: %s: %s", result.FailedEntryCount, entry.ErrorCode, entry.ErrorMessage)
+			}
+		}
+		return fmt.Errorf("%d geofence events were not published", result.FailedEntryCount)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package geofence
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestPublisher(t *testing.T, handler http.HandlerFunc) *EventBridgePublisher {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	p := NewEventBridgePublisher(aws.Config{
+		Region:      "us-west-2",
+		Credentials: credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""),
+	}, "fleet-events")
+	p.endpoint = server.URL
+	return p
+}
+
+func TestEventBridgePublisher(t *testing.T) {
+	transition := Transition{Type: Enter, AccountID: "acc-12345", LocationID: "loc-001", GeofenceID: "depot", GeofenceName: "Denver depot", Coordinates: inDepot, At: evaluatedAt}
+
+	t.Run("Puts ten events a request", func(t *testing.T) {
+		var batches [][]putEventsEntry
+		p := newTestPublisher(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.True(t, strings.Contains(r.Header.Get("Authorization"), "/events/aws4_request"))
+			assert.Equal(t, "AWSEvents.PutEvents", r.Header.Get("X-Amz-Target"))
+			var req struct{ Entries []putEventsEntry }
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			batches = append(batches, req.Entries)
+			_, _ = w.Write([]byte(`{"FailedEntryCount":0,"Entries":[]}`))
+		})
+
+		transitions := make([]Transition, 12)
+		for i := range transitions {
+			transitions[i] = transition
+		}
+		require.NoError(t, p.Publish(context.Background(), transitions))
+		require.Len(t, batches, 2)
+		assert.Len(t, batches[0], 10)
+		assert.Len(t, batches[1], 2)
+
+		entry := batches[0][0]
+		assert.Equal(t, EventSource, entry.Source)
+		assert.Equal(t, "GeofenceEntered", entry.DetailType)
+		assert.Equal(t, "fleet-events", entry.EventBusName)
+		assert.Equal(t, evaluatedAt.Unix(), entry.Time)
+		var detail Transition
+		require.NoError(t, json.Unmarshal([]byte(entry.Detail), &detail))
+		assert.Equal(t, transition, detail)
+	})
+
+	t.Run("Fails on rejected entries", func(t *testing.T) {
+		p := newTestPublisher(t, func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"FailedEntryCount":1,"Entries":[{"ErrorCode":"InternalFailure","ErrorMessage":"try again"}]}`))
+		})
+
+		err := p.Publish(context.Background(), []Transition{transition})
+		assert.EqualError(t, err, "1 geofence events were not published: InternalFailure: try again")
+	})
+
+	t.Run("Fails on error responses", func(t *testing.T) {
+		p := newTestPublisher(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = fmt.Fprint(w, `{"__type":"ResourceNotFoundException"}`)
+		})
+
+		err := p.Publish(context.Background(), []Transition{transition})
+		assert.EqualError(t, err, `geofence events request failed with status 400: {"__type":"ResourceNotFoundException"}`)
+	})
+}
@@ -0,0 +1,94 @@
+// Package geofence turns the position updates of an account's locations into
+// enter and exit events for its geofences.
+package geofence
+
+import (
+	"context"
+	"time"
+
+	"github.com/steverhoton/location-lambda/internal/filterable"
+	"github.com/steverhoton/location-lambda/internal/geo"
+	"github.com/steverhoton/location-lambda/internal/models"
+)
+
+// Transition types.
+const (
+	Enter = "enter"
+	Exit  = "exit"
+)
+
+// Transition is a location crossing a geofence's boundary.
+type Transition struct {
+	Type         string `json:"type"`
+	AccountID    string `json:"accountId"`
+	LocationID   string `json:"locationId"`
+	GeofenceID   string `json:"geofenceId"`
+	GeofenceName string `json:"geofenceName"`
+	// Coordinates are the position that confirmed the crossing
+	Coordinates models.Coordinates `json:"coordinates"`
+	// At is when the location first reported the new side, its observedAt
+	// when it had one, before any debounce
+	At time.Time `json:"at"`
+	// FilterableFields are the location's subscription filter fields, set
+	// by the caller that has the location
+	FilterableFields *filterable.Fields `json:"filterableFields,omitempty"`
+}
+
+// Publisher delivers transitions to their consumers.
+type Publisher interface {
+	Publish(ctx context.Context, transitions []Transition) error
+}
+
+// Contains reports whether c lies within the geofence.
+func Contains(geofence models.Geofence, c models.Coordinates) bool {
+	if geofence.Center != nil {
+		return geo.Distance(*geofence.Center, c) <= geofence.RadiusMeters
+	}
+	return geo.PolygonContains(geofence.Boundary, c)
+}
+
+// Evaluate compares a location's position at a time with its presence in the
+// account's geofences, returning the crossings it confirms and the location's
+// new presence. A location with no presence in a geofence is outside it, so
+// an update inside one it was never seen in enters it. Crossings wait out the
+// geofence's debounce: the location must report the new side on an update at
+// least DebounceSeconds after the first, without reporting the old side in
+// between. Presences in geofences that no longer exist are dropped.
+func Evaluate(geofences []models.Geofence, presence map[string]models.GeofencePresence, accountID, locationID string, position models.Coordinates, at time.Time) ([]Transition, map[string]models.GeofencePresence) {
+	var transitions []Transition
+	next := make(map[string]models.GeofencePresence)
+	for _, geofence := range geofences {
+		current := presence[geofence.GeofenceID]
+		inside := Contains(geofence, position)
+
+		switch {
+		case inside == current.Inside:
+			current.PendingSince = nil
+		case current.PendingSince == nil:
+			since := at
+			current.PendingSince = &since
+		}
+		if current.PendingSince != nil && !at.Before(current.PendingSince.Add(time.Duration(geofence.DebounceSeconds)*time.Second)) {
+			transition := Transition{
+				Type:         Exit,
+				AccountID:    accountID,
+				LocationID:   locationID,
+				GeofenceID:   geofence.GeofenceID,
+				GeofenceName: geofence.Name,
+				Coordinates:  position,
+				At:           *current.PendingSince,
+			}
+			if inside {
+				transition.Type = Enter
+			}
+			transitions = append(transitions, transition)
+			current = models.GeofencePresence{Inside: inside}
+		}
+
+		// Outside with nothing pending is the same as no presence
+		if current.Inside || current.PendingSince != nil {
+			next[geofence.GeofenceID] = current
+		}
+	}
+	return transitions, next
+}
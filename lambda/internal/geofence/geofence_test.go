@@ -0,0 +1,105 @@
+package geofence
+
+import (
+	"testing"
+	"time"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// depot is a square around downtown Denver.
+var depot = models.Geofence{
+	GeofenceID: "depot",
+	Name:       "Denver depot",
+	Boundary: []models.Coordinates{
+		{Latitude: 39.73, Longitude: -105.00},
+		{Latitude: 39.73, Longitude: -104.98},
+		{Latitude: 39.75, Longitude: -104.98},
+		{Latitude: 39.75, Longitude: -105.00},
+	},
+}
+
+var (
+	inDepot     = models.Coordinates{Latitude: 39.74, Longitude: -104.99}
+	outOfDepot  = models.Coordinates{Latitude: 39.76, Longitude: -104.99}
+	evaluatedAt = time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+)
+
+func TestContains(t *testing.T) {
+	assert.True(t, Contains(depot, inDepot))
+	assert.False(t, Contains(depot, outOfDepot))
+
+	yard := models.Geofence{GeofenceID: "yard", Name: "Yard", Center: &inDepot, RadiusMeters: 500}
+	assert.True(t, Contains(yard, models.Coordinates{Latitude: 39.743, Longitude: -104.99}))
+	assert.False(t, Contains(yard, models.Coordinates{Latitude: 39.746, Longitude: -104.99}))
+}
+
+func TestEvaluate(t *testing.T) {
+	t.Run("Enters a geofence it was never seen in", func(t *testing.T) {
+		transitions, presence := Evaluate([]models.Geofence{depot}, nil, "acc-12345", "loc-001", inDepot, evaluatedAt)
+		require.Len(t, transitions, 1)
+		assert.Equal(t, Transition{
+			Type:         Enter,
+			AccountID:    "acc-12345",
+			LocationID:   "loc-001",
+			GeofenceID:   "depot",
+			GeofenceName: "Denver depot",
+			Coordinates:  inDepot,
+			At:           evaluatedAt,
+		}, transitions[0])
+		assert.Equal(t, map[string]models.GeofencePresence{"depot": {Inside: true}}, presence)
+	})
+
+	t.Run("Staying put publishes nothing", func(t *testing.T) {
+		transitions, presence := Evaluate([]models.Geofence{depot}, map[string]models.GeofencePresence{"depot": {Inside: true}}, "acc-12345", "loc-001", inDepot, evaluatedAt)
+		assert.Empty(t, transitions)
+		assert.Equal(t, map[string]models.GeofencePresence{"depot": {Inside: true}}, presence)
+
+		transitions, presence = Evaluate([]models.Geofence{depot}, nil, "acc-12345", "loc-001", outOfDepot, evaluatedAt)
+		assert.Empty(t, transitions)
+		assert.Empty(t, presence)
+	})
+
+	t.Run("Exits", func(t *testing.T) {
+		transitions, presence := Evaluate([]models.Geofence{depot}, map[string]models.GeofencePresence{"depot": {Inside: true}}, "acc-12345", "loc-001", outOfDepot, evaluatedAt)
+		require.Len(t, transitions, 1)
+		assert.Equal(t, Exit, transitions[0].Type)
+		assert.Empty(t, presence)
+	})
+
+	t.Run("Debounces crossings", func(t *testing.T) {
+		debounced := depot
+		debounced.DebounceSeconds = 60
+		geofences := []models.Geofence{debounced}
+		inside := map[string]models.GeofencePresence{"depot": {Inside: true}}
+
+		// The first report outside starts the wait
+		transitions, pending := Evaluate(geofences, inside, "acc-12345", "loc-001", outOfDepot, evaluatedAt)
+		assert.Empty(t, transitions)
+		require.NotNil(t, pending["depot"].PendingSince)
+		assert.True(t, pending["depot"].Inside)
+
+		// Jitter back inside cancels it
+		transitions, presence := Evaluate(geofences, pending, "acc-12345", "loc-001", inDepot, evaluatedAt.Add(30*time.Second))
+		assert.Empty(t, transitions)
+		assert.Equal(t, inside, presence)
+
+		// Still outside once the debounce has passed confirms the exit, as of the first report
+		_, pending = Evaluate(geofences, inside, "acc-12345", "loc-001", outOfDepot, evaluatedAt)
+		transitions, _ = Evaluate(geofences, pending, "acc-12345", "loc-001", outOfDepot, evaluatedAt.Add(30*time.Second))
+		assert.Empty(t, transitions)
+		transitions, presence = Evaluate(geofences, pending, "acc-12345", "loc-001", outOfDepot, evaluatedAt.Add(time.Minute))
+		require.Len(t, transitions, 1)
+		assert.Equal(t, Exit, transitions[0].Type)
+		assert.Equal(t, evaluatedAt, transitions[0].At)
+		assert.Empty(t, presence)
+	})
+
+	t.Run("Drops presences in deleted geofences", func(t *testing.T) {
+		transitions, presence := Evaluate(nil, map[string]models.GeofencePresence{"depot": {Inside: true}}, "acc-12345", "loc-001", inDepot, evaluatedAt)
+		assert.Empty(t, transitions)
+		assert.Empty(t, presence)
+	})
+}
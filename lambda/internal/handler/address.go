@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/steverhoton/location-lambda/internal/addressparser"
+)
+
+// ParseAddressArguments represents arguments for parsing a free-text address.
+type ParseAddressArguments struct {
+	Text    string `json:"text"`
+	Country string `json:"country,omitempty"` // Optional ISO 3166-1 alpha-2 hint used when the text names no country
+}
+
+func (h *AppSyncHandler) handleParseAddress(_ context.Context, arguments json.RawMessage) (*addressparser.Result, error) {
+	var args ParseAddressArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+
+	if strings.TrimSpace(args.Text) == "" {
+		return nil, fmt.Errorf("text is required")
+	}
+
+	result := addressparser.Parse(args.Text, args.Country)
+	return &result, nil
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/steverhoton/location-lambda/internal/addressparser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppSyncHandlerParseAddress(t *testing.T) {
+	ctx := context.Background()
+	handler := NewAppSyncHandler(new(mockRepository))
+
+	t.Run("Parses address", func(t *testing.T) {
+		result, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "parseAddress",
+			Arguments: json.RawMessage(`{"text": "123 Main St Apt 4 Springfield IL 62704"}`),
+		})
+		require.NoError(t, err)
+
+		parsed, ok := result.(*addressparser.Result)
+		require.True(t, ok)
+		assert.Equal(t, "123 Main St", parsed.Address.StreetAddress)
+		assert.Equal(t, "Apt 4", parsed.Address.StreetAddress2)
+		assert.Equal(t, "Springfield", parsed.Address.City)
+		assert.Equal(t, "US", parsed.Address.Country)
+	})
+
+	t.Run("Country hint", func(t *testing.T) {
+		result, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "parseAddress",
+			Arguments: json.RawMessage(`{"text": "Hauptstrasse 5, 10115 Berlin", "country": "DE"}`),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "DE", result.(*addressparser.Result).Address.Country)
+	})
+
+	t.Run("Empty text", func(t *testing.T) {
+		_, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "parseAddress",
+			Arguments: json.RawMessage(`{"text": " "}`),
+		})
+		assert.EqualError(t, err, "text is required")
+	})
+}
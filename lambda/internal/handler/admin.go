@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/steverhoton/location-lambda/internal/repository"
+)
+
+// groupsClaim is the Cognito claim listing the caller's groups.
+const groupsClaim = "cognito:groups"
+
+// AdminGetLocationByIDArguments represents arguments for looking a location up by ID alone.
+type AdminGetLocationByIDArguments struct {
+	LocationID   string `json:"locationId"`
+	IncludeLinks bool   `json:"includeLinks,omitempty"`
+}
+
+// AdminTransferLocationArguments represents arguments for moving a location to another account.
+type AdminTransferLocationArguments struct {
+	AccountID   string `json:"accountId"`
+	LocationID  string `json:"locationId"`
+	ToAccountID string `json:"toAccountId"`
+}
+
+// AdminListAllLocationsArguments represents arguments for paging through every account's locations.
+type AdminListAllLocationsArguments struct {
+	Limit        *int32  `json:"limit,omitempty"`
+	Cursor       *string `json:"cursor,omitempty"`
+	IncludeLinks bool    `json:"includeLinks,omitempty"`
+}
+
+// WithAdminStore enables the admin* operations for callers in the given group.
+func WithAdminStore(store repository.AdminStore, group string) Option {
+	return func(h *AppSyncHandler) {
+		h.admin = store
+		h.adminGroup = group
+	}
+}
+
+// WithLocationScanner enables adminListAllLocations, which like the other
+// admin* operations is only served to the WithAdminStore group.
+func WithLocationScanner(scanner repository.LocationScanner) Option {
+	return func(h *AppSyncHandler) {
+		h.scanner = scanner
+	}
+}
+
+// adminStore returns the admin store when the caller may use it. Admin fields
+// cross account boundaries, so they require membership of the admin group.
+func (h *AppSyncHandler) adminStore(identity AppSyncIdentity) (repository.AdminStore, error) {
+	if h.admin == nil {
+		return nil, fmt.Errorf("admin operations are not configured")
+	}
+	if err := h.requireAdmin(identity); err != nil {
+		return nil, err
+	}
+	return h.admin, nil
+}
+
+// requireAdmin checks that the caller is in the admin group.
+func (h *AppSyncHandler) requireAdmin(identity AppSyncIdentity) error {
+	if h.adminGroup == "" {
+		return fmt.Errorf("admin operations are not configured")
+	}
+	if !inGroup(identity, h.adminGroup) {
+		return fmt.Errorf("admin access required")
+	}
+	return nil
+}
+
+// inGroup reports whether the identity's groups claim includes group.
+func inGroup(identity AppSyncIdentity, group string) bool {
+	for _, g := range claimValues(identity, groupsClaim) {
+		if g == group {
+			return true
+		}
+	}
+	return false
+}
+
+// claimValues returns the identity's values for a list claim. AppSync passes
+// such claims as a list, or as a string for some token sources.
+func claimValues(identity AppSyncIdentity, claim string) []string {
+	switch values := identity.Claims[claim].(type) {
+	case []interface{}:
+		var strs []string
+		for _, v := range values {
+			if s, ok := v.(string); ok {
+				strs = append(strs, s)
+			}
+		}
+		return strs
+	case []string:
+		return values
+	case string:
+		return []string{values}
+	}
+	return nil
+}
+
+func (h *AppSyncHandler) handleAdminGetLocationByID(ctx context.Context, event AppSyncEvent) (map[string]interface{}, error) {
+	store, err := h.adminStore(event.Identity)
+	if err != nil {
+		return nil, err
+	}
+
+	var args AdminGetLocationByIDArguments
+	if err := json.Unmarshal(event.Arguments, &args); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+
+	envelope, err := store.FindByID(ctx, args.LocationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get location: %w", err)
+	}
+
+	return toLocationMap(*envelope, args.IncludeLinks)
+}
+
+func (h *AppSyncHandler) handleAdminListAccountLocations(ctx context.Context, event AppSyncEvent) (*ListLocationsResponse, error) {
+	if _, err := h.adminStore(event.Identity); err != nil {
+		return nil, err
+	}
+	return h.handleListLocations(ctx, event)
+}
+
+func (h *AppSyncHandler) handleAdminTransferLocation(ctx context.Context, event AppSyncEvent) (bool, error) {
+	store, err := h.adminStore(event.Identity)
+	if err != nil {
+		return false, err
+	}
+
+	var args AdminTransferLocationArguments
+	if err := json.Unmarshal(event.Arguments, &args); err != nil {
+		return false, fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+
+	if err := store.Transfer(ctx, args.AccountID, args.LocationID, args.ToAccountID); err != nil {
+		return false, fmt.Errorf("failed to transfer location: %w", err)
+	}
+
+	return true, nil
+}
+
+// handleAdminListAllLocations pages through the locations of every account,
+// for data-quality tooling that has to visit the whole table.
+func (h *AppSyncHandler) handleAdminListAllLocations(ctx context.Context, event AppSyncEvent) (*ListLocationsResponse, error) {
+	if h.scanner == nil {
+		return nil, fmt.Errorf("admin operations are not configured")
+	}
+	if err := h.requireAdmin(event.Identity); err != nil {
+		return nil, err
+	}
+
+	var args AdminListAllLocationsArguments
+	if err := json.Unmarshal(event.Arguments, &args); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+	limit, err := h.listLimits.Resolve(args.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := h.scanner.ScanLocations(ctx, limit, args.Cursor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list locations: %w", err)
+	}
+
+	locationMaps := make([]map[string]interface{}, len(result.Items))
+	for i, item := range result.Items {
+		if locationMaps[i], err = toLocationMap(item, args.IncludeLinks); err != nil {
+			return nil, err
+		}
+	}
+	response := &ListLocationsResponse{Locations: locationMaps, NextCursor: result.NextCursor}
+	if err := h.compressLocations(event.Request, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
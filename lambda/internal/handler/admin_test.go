@@ -0,0 +1,195 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockAdminStore is a mock implementation of the repository.AdminStore interface.
+type mockAdminStore struct {
+	mock.Mock
+}
+
+func (m *mockAdminStore) FindByID(ctx context.Context, locationID string) (*repository.LocationEnvelope, error) {
+	args := m.Called(ctx, locationID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.LocationEnvelope), args.Error(1)
+}
+
+func (m *mockAdminStore) Transfer(ctx context.Context, fromAccountID, locationID, toAccountID string) error {
+	args := m.Called(ctx, fromAccountID, locationID, toAccountID)
+	return args.Error(0)
+}
+
+// mockLocationScanner is a mock implementation of the repository.LocationScanner interface.
+type mockLocationScanner struct {
+	mock.Mock
+}
+
+func (m *mockLocationScanner) ScanLocations(ctx context.Context, limit int32, cursor *string) (*repository.ListResult, error) {
+	args := m.Called(ctx, limit, cursor)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.ListResult), args.Error(1)
+}
+
+func TestAppSyncHandlerAdminAccess(t *testing.T) {
+	ctx := context.Background()
+	arguments := json.RawMessage(`{"locationId": "loc-001"}`)
+	location := models.CoordinatesLocation{
+		LocationBase: models.LocationBase{AccountID: "acc-other", LocationType: models.LocationTypeCoordinates},
+		Coordinates:  models.Coordinates{Latitude: 40.7128, Longitude: -74.006},
+	}
+
+	tests := []struct {
+		name          string
+		configured    bool
+		claims        map[string]interface{}
+		expectedError string
+	}{
+		{
+			name:       "Admin group member",
+			configured: true,
+			claims:     map[string]interface{}{"cognito:groups": []interface{}{"staff", "location-admins"}},
+		},
+		{
+			name:       "Single group claim",
+			configured: true,
+			claims:     map[string]interface{}{"cognito:groups": "location-admins"},
+		},
+		{
+			name:          "Tenant user",
+			configured:    true,
+			claims:        map[string]interface{}{"cognito:groups": []interface{}{"staff"}},
+			expectedError: "admin access required",
+		},
+		{
+			name:          "No groups claim",
+			configured:    true,
+			expectedError: "admin access required",
+		},
+		{
+			name:          "Not configured",
+			claims:        map[string]interface{}{"cognito:groups": []interface{}{"location-admins"}},
+			expectedError: "admin operations are not configured",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := new(mockAdminStore)
+			var opts []Option
+			if tt.configured {
+				opts = append(opts, WithAdminStore(store, "location-admins"))
+			}
+			handler := NewAppSyncHandler(new(mockRepository), opts...)
+
+			if tt.expectedError == "" {
+				store.On("FindByID", ctx, "loc-001").Return(withID("loc-001", location), nil).Once()
+			}
+
+			result, err := handler.Handle(ctx, AppSyncEvent{
+				Field:     "adminGetLocationById",
+				Arguments: arguments,
+				Identity:  AppSyncIdentity{Claims: tt.claims},
+			})
+
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				store.AssertNotCalled(t, "FindByID", mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			locationMap := result.(map[string]interface{})
+			assert.Equal(t, "loc-001", locationMap["locationId"])
+			assert.Equal(t, "acc-other", locationMap["accountId"])
+			assert.Equal(t, "CoordinatesLocation", locationMap["__typename"])
+		})
+	}
+}
+
+func TestAppSyncHandlerAdminOperations(t *testing.T) {
+	ctx := context.Background()
+	identity := AppSyncIdentity{Claims: map[string]interface{}{"cognito:groups": []interface{}{"location-admins"}}}
+
+	t.Run("Transfer location", func(t *testing.T) {
+		store := new(mockAdminStore)
+		handler := NewAppSyncHandler(new(mockRepository), WithAdminStore(store, "location-admins"))
+
+		store.On("Transfer", ctx, "acc-12345", "loc-001", "acc-67890").Return(nil).Once()
+
+		result, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "adminTransferLocation",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "locationId": "loc-001", "toAccountId": "acc-67890"}`),
+			Identity:  identity,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, true, result)
+		store.AssertExpectations(t)
+	})
+
+	t.Run("List any account's locations", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		handler := NewAppSyncHandler(mockRepo, WithAdminStore(new(mockAdminStore), "location-admins"))
+
+		mockRepo.On("List", ctx, "acc-67890", mock.Anything).Return(&repository.ListResult{}, nil).Once()
+
+		result, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "adminListAccountLocations",
+			Arguments: json.RawMessage(`{"accountId": "acc-67890"}`),
+			Identity:  identity,
+		})
+		require.NoError(t, err)
+		assert.Empty(t, result.(*ListLocationsResponse).Locations)
+		mockRepo.AssertExpectations(t)
+	})
+	t.Run("List every account's locations", func(t *testing.T) {
+		scanner := new(mockLocationScanner)
+		handler := NewAppSyncHandler(new(mockRepository), WithAdminStore(new(mockAdminStore), "location-admins"), WithLocationScanner(scanner))
+		location := models.CoordinatesLocation{
+			LocationBase: models.LocationBase{AccountID: "acc-other", LocationType: models.LocationTypeCoordinates},
+			Coordinates:  models.Coordinates{Latitude: 40.7128, Longitude: -74.006},
+		}
+		cursor, next := "page-2", "page-3"
+
+		scanner.On("ScanLocations", ctx, int32(25), &cursor).Return(&repository.ListResult{
+			Items:      []repository.LocationEnvelope{*withID("loc-001", location)},
+			NextCursor: &next,
+		}, nil).Once()
+
+		result, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "adminListAllLocations",
+			Arguments: json.RawMessage(`{"limit": 25, "cursor": "page-2"}`),
+			Identity:  identity,
+		})
+		require.NoError(t, err)
+		response := result.(*ListLocationsResponse)
+		require.Len(t, response.Locations, 1)
+		assert.Equal(t, "acc-other", response.Locations[0]["accountId"])
+		assert.Equal(t, &next, response.NextCursor)
+		scanner.AssertExpectations(t)
+	})
+
+	t.Run("Listing every account's locations requires the admin group", func(t *testing.T) {
+		scanner := new(mockLocationScanner)
+		handler := NewAppSyncHandler(new(mockRepository), WithAdminStore(new(mockAdminStore), "location-admins"), WithLocationScanner(scanner))
+
+		_, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "adminListAllLocations",
+			Arguments: json.RawMessage(`{}`),
+			Identity:  AppSyncIdentity{Claims: map[string]interface{}{"cognito:groups": "staff"}},
+		})
+		assert.EqualError(t, err, "admin access required")
+		scanner.AssertNotCalled(t, "ScanLocations", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
@@ -4,10 +4,26 @@ package handler
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
 
+	"github.com/steverhoton/location-lambda/internal/assets"
+	"github.com/steverhoton/location-lambda/internal/config"
+	"github.com/steverhoton/location-lambda/internal/elevation"
+	"github.com/steverhoton/location-lambda/internal/filterable"
+	"github.com/steverhoton/location-lambda/internal/geocode"
+	"github.com/steverhoton/location-lambda/internal/geofence"
+	"github.com/steverhoton/location-lambda/internal/links"
 	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/notify"
+	"github.com/steverhoton/location-lambda/internal/permissions"
+	"github.com/steverhoton/location-lambda/internal/places"
 	"github.com/steverhoton/location-lambda/internal/repository"
+	"github.com/steverhoton/location-lambda/internal/routing"
+	"github.com/steverhoton/location-lambda/internal/staticmap"
+	"github.com/steverhoton/location-lambda/internal/verify"
+	"github.com/steverhoton/location-lambda/internal/weather"
 )
 
 // AppSyncEvent represents an event from AWS AppSync.
@@ -17,15 +33,46 @@ type AppSyncEvent struct {
 	Source    json.RawMessage `json:"source"`
 	Identity  AppSyncIdentity `json:"identity"`
 	Request   AppSyncRequest  `json:"request"`
+	// Info describes the resolved field; direct Lambda resolvers send it
+	// instead of field
+	Info AppSyncInfo `json:"info"`
+	// Invoker is set by the function's own EventBridge rules; AppSync never
+	// sends it
+	Invoker string `json:"invoker"`
 }
 
-// AppSyncIdentity represents the identity information from AppSync.
+// AppSyncInfo represents the resolved field's information from AppSync.
+type AppSyncInfo struct {
+	FieldName      string `json:"fieldName"`
+	ParentTypeName string `json:"parentTypeName"`
+}
+
+// UnmarshalJSON decodes an event from a request mapping template, which
+// names the field, or AppSync's own context, which direct Lambda resolvers
+// send and which names it in info.fieldName.
+func (e *AppSyncEvent) UnmarshalJSON(data []byte) error {
+	type plainEvent AppSyncEvent
+	var event plainEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		return err
+	}
+	if event.Field == "" {
+		event.Field = event.Info.FieldName
+	}
+	*e = AppSyncEvent(event)
+	return nil
+}
+
+// AppSyncIdentity represents the identity information from AppSync. It is
+// empty for API key callers, whose request carries the key instead.
 type AppSyncIdentity struct {
 	UserArn             string                 `json:"userArn"`
 	Username            string                 `json:"username"`
 	Claims              map[string]interface{} `json:"claims"`
 	SourceIP            []string               `json:"sourceIp"`
 	DefaultAuthStrategy string                 `json:"defaultAuthStrategy"`
+	// ResolverContext is what a Lambda authorizer returned for the caller
+	ResolverContext map[string]interface{} `json:"resolverContext"`
 }
 
 // AppSyncRequest represents request headers from AppSync.
@@ -36,24 +83,77 @@ type AppSyncRequest struct {
 // CreateLocationArguments represents arguments for creating a location.
 type CreateLocationArguments struct {
 	Input json.RawMessage `json:"input"`
+	// VerifyAddress verifies the address with the configured provider before storing it
+	VerifyAddress bool `json:"verifyAddress,omitempty"`
+	// IncludeLinks adds map deep links to the returned location
+	IncludeLinks bool `json:"includeLinks,omitempty"`
+	// ValidationMode overrides the account's validationStrictness; lenient
+	// drops invalid optional fields instead of rejecting the location
+	ValidationMode *models.ValidationStrictness `json:"validationMode,omitempty"`
 }
 
 // GetLocationArguments represents arguments for getting a location.
 type GetLocationArguments struct {
 	AccountID  string `json:"accountId"`
 	LocationID string `json:"locationId"`
+	// IncludeLinks adds map deep links to the response
+	IncludeLinks bool `json:"includeLinks,omitempty"`
+	// IncludeAssets resolves a shop's logo and photos, or a floor plan, to URLs
+	IncludeAssets bool `json:"includeAssets,omitempty"`
+	// IfNoneMatch is the etag the caller already holds; when the location
+	// still has it, only a NotModified result is returned
+	IfNoneMatch string `json:"ifNoneMatch,omitempty"`
+}
+
+// GetLocationByExternalIDArguments represents arguments for getting a location by external ID.
+type GetLocationByExternalIDArguments struct {
+	AccountID    string `json:"accountId"`
+	ExternalID   string `json:"externalId"`
+	IncludeLinks bool   `json:"includeLinks,omitempty"`
 }
 
 // UpdateLocationArguments represents arguments for updating a location.
 type UpdateLocationArguments struct {
+	// AccountID is the account the location belongs to; when set, the input's
+	// accountId must match it
+	AccountID  string          `json:"accountId,omitempty"`
 	LocationID string          `json:"locationId"`
 	Input      json.RawMessage `json:"input"`
+	// AllowTypeChange permits converting the location into another type
+	AllowTypeChange bool `json:"allowTypeChange,omitempty"`
+	// ValidationMode overrides the account's validationStrictness
+	ValidationMode *models.ValidationStrictness `json:"validationMode,omitempty"`
+	// IfMatch makes the update conditional on the location's etag still
+	// being this one, as read by getLocation
+	IfMatch string `json:"ifMatch,omitempty"`
 }
 
 // DeleteLocationArguments represents arguments for deleting a location.
 type DeleteLocationArguments struct {
 	AccountID  string `json:"accountId"`
 	LocationID string `json:"locationId"`
+	// Cascade also deletes locations merged into this one
+	Cascade bool `json:"cascade,omitempty"`
+}
+
+// EraseLocationDataArguments represents arguments for erasing a location's data.
+type EraseLocationDataArguments struct {
+	AccountID  string `json:"accountId"`
+	LocationID string `json:"locationId"`
+}
+
+// MergeLocationsArguments represents arguments for merging duplicate locations.
+type MergeLocationsArguments struct {
+	AccountID    string   `json:"accountId"`
+	SurvivorID   string   `json:"survivorId"`
+	DuplicateIDs []string `json:"duplicateIds"`
+}
+
+// UpsertLocationByExternalIDArguments represents arguments for creating or updating a location by external ID.
+type UpsertLocationByExternalIDArguments struct {
+	Input json.RawMessage `json:"input"`
+	// ValidationMode overrides the account's validationStrictness
+	ValidationMode *models.ValidationStrictness `json:"validationMode,omitempty"`
 }
 
 // ListLocationsArguments represents arguments for listing locations.
@@ -61,6 +161,26 @@ type ListLocationsArguments struct {
 	AccountID string  `json:"accountId"`
 	Limit     *int32  `json:"limit,omitempty"`
 	Cursor    *string `json:"cursor,omitempty"`
+	// IncludeLinks adds map deep links to each location
+	IncludeLinks bool `json:"includeLinks,omitempty"`
+	// IncludeAssets resolves each shop's logo and photos, and floor plans, to URLs
+	IncludeAssets bool `json:"includeAssets,omitempty"`
+	// Category restricts the list to shops in it, for listLocationsByCategory
+	Category string `json:"category,omitempty"`
+	// MinGeocodeConfidence drops address locations geocoded with a lower
+	// confidence, or without coordinates
+	MinGeocodeConfidence *float64 `json:"minGeocodeConfidence,omitempty"`
+	// IncludeDrafts lists unpublished drafts along with published locations
+	IncludeDrafts bool `json:"includeDrafts,omitempty"`
+	// IncludeInactive lists locations outside their activeFrom/activeUntil window
+	IncludeInactive bool `json:"includeInactive,omitempty"`
+	// ActiveOn, a YYYY-MM-DD date, restricts the list to events running that day
+	ActiveOn string `json:"activeOn,omitempty"`
+	// MaxAccuracyMeters leaves out locations whose coordinates are less
+	// accurate, or of unknown accuracy
+	MaxAccuracyMeters *float64 `json:"maxAccuracyMeters,omitempty"`
+	// IncludeSubAccounts follows the account's locations with those of its sub-accounts
+	IncludeSubAccounts bool `json:"includeSubAccounts,omitempty"`
 }
 
 // LocationResponse wraps a location with metadata.
@@ -71,63 +191,380 @@ type LocationResponse struct {
 
 // DeleteResponse represents the response for a delete operation.
 type DeleteResponse struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
+	Success    bool   `json:"success"`
+	Message    string `json:"message"`
+	LocationID string `json:"locationId"`
+	// CascadeDeleted lists the merged locations deleted along with this one
+	CascadeDeleted []string `json:"cascadeDeleted,omitempty"`
+	// FilterableFields carry only the account, as the location is gone
+	FilterableFields filterable.Fields `json:"filterableFields"`
+}
+
+// UpdateResponse represents the response for an update operation.
+type UpdateResponse struct {
+	Success    bool   `json:"success"`
+	Message    string `json:"message"`
+	LocationID string `json:"locationId"`
+	// PositionAnomaly is set when the account's position checks flagged the update
+	PositionAnomaly *models.PositionAnomaly `json:"positionAnomaly,omitempty"`
+	// ValidationWarnings lists the optional fields lenient validation dropped
+	ValidationWarnings models.ValidationErrors `json:"validationWarnings,omitempty"`
+	// FilterableFields are the updated location's subscription filter fields
+	FilterableFields filterable.Fields `json:"filterableFields"`
 }
 
 // ListLocationsResponse represents the response for listing locations with pagination.
 type ListLocationsResponse struct {
 	Locations  []map[string]interface{} `json:"locations"`
 	NextCursor *string                  `json:"nextCursor,omitempty"`
+	StaleRead  bool                     `json:"staleRead,omitempty"`
+	// Partial is true when listLocationsFast ran out of time before filling the page
+	Partial bool `json:"partial,omitempty"`
+	// IndexFallback is true when the page was read without its GSI, which
+	// could not be read
+	IndexFallback bool `json:"indexFallback,omitempty"`
+	// Encoding is "gzip" when Locations were moved into CompressedLocations
+	Encoding string `json:"encoding,omitempty"`
+	// CompressedLocations is the base64-encoded gzip of the locations JSON array
+	CompressedLocations string `json:"compressedLocations,omitempty"`
 }
 
 // AppSyncHandler handles AppSync events for location operations.
 type AppSyncHandler struct {
-	repo repository.Repository
+	repo         repository.Repository
+	templates    repository.TemplateStore
+	customFields repository.CustomFieldStore
+	settings     repository.SettingsStore
+	admin        repository.AdminStore
+	adminGroup   string
+	scanner      repository.LocationScanner
+	typeChanger  repository.TypeChanger
+	cascade      repository.CascadeDeleter
+	websites     repository.WebsiteFinder
+	contacts     repository.ContactFinder
+	stats        repository.StatsReader
+	heatmaps     repository.HeatmapReader
+	geofences    repository.GeofenceStore
+	notifier     notify.Notifier
+	hierarchy    repository.AccountHierarchy
+	nearby       repository.NearbyShopFinder
+	geocoder     geocode.Geocoder
+	verifier     verify.Verifier
+	places       places.Provider
+	weather      weather.Provider
+	elevation    elevation.Provider
+	staticMap    staticmap.Provider
+	assets       assets.Resolver
+	// compressionThreshold is the locations JSON size above which list pages
+	// are compressed; 0 disables compression
+	compressionThreshold int
+	fastListBudget       time.Duration
+	health               repository.HealthChecker
+	configIssues         []string
+	listLimits           config.ListLimits
+	deliveryZones        repository.DeliveryZoneFinder
+	nearest              repository.NearestShopFinder
+	geocodes             repository.GeocodeWriter
+	proposals            repository.ProposalStore
+	drafts               repository.DraftPublisher
+	permissions          *permissions.Policy
+	signingSecrets       repository.SigningSecretStore
+	signatureMaxAge      time.Duration
+	replayGuard          repository.ReplayGuard
+	replayWindow         time.Duration
+	// geofencePublisher receives the geofence crossings of position updates
+	geofencePublisher geofence.Publisher
+	// positions records coordinates locations' position updates for
+	// positionRetention
+	positions         repository.PositionHistory
+	positionRetention time.Duration
+	router            routing.Provider
+	assigner          repository.NearestShopAssigner
+	// syncer serves syncLocations
+	syncer repository.LocationSyncer
+	// capacity reports each operation's consumed DynamoDB capacity
+	capacity *capacityMetrics
+	// usage counts each account's operations
+	usage repository.UsageMeter
+	// clock tells the time of signatures, stops, and other stamped results
+	clock repository.Clock
+}
+
+// Option configures optional AppSyncHandler dependencies.
+type Option func(*AppSyncHandler)
+
+// WithTemplateStore enables the location template operations.
+func WithTemplateStore(store repository.TemplateStore) Option {
+	return func(h *AppSyncHandler) {
+		h.templates = store
+	}
+}
+
+// WithTypeChanger lets updateLocation change a location's type when asked to.
+func WithTypeChanger(changer repository.TypeChanger) Option {
+	return func(h *AppSyncHandler) {
+		h.typeChanger = changer
+	}
+}
+
+// WithCascadeDeleter lets deleteLocation delete merged locations along with their survivor.
+func WithCascadeDeleter(deleter repository.CascadeDeleter) Option {
+	return func(h *AppSyncHandler) {
+		h.cascade = deleter
+	}
+}
+
+// WithGeocoder enables geocoding for operations that resolve free-text addresses.
+func WithGeocoder(geocoder geocode.Geocoder) Option {
+	return func(h *AppSyncHandler) {
+		h.geocoder = geocoder
+	}
+}
+
+// WithListLimits sets the default and maximum page sizes of the list operations.
+func WithListLimits(limits config.ListLimits) Option {
+	return func(h *AppSyncHandler) {
+		h.listLimits = limits
+	}
+}
+
+// WithClock takes the time from clock instead of the wall clock, so tests can
+// pin the timestamps and windows operations depend on. listLocationsFast's
+// budget is always measured on the wall clock.
+func WithClock(clock repository.Clock) Option {
+	return func(h *AppSyncHandler) {
+		h.clock = clock
+	}
 }
 
 // NewAppSyncHandler creates a new AppSync handler.
-func NewAppSyncHandler(repo repository.Repository) *AppSyncHandler {
-	return &AppSyncHandler{
-		repo: repo,
+func NewAppSyncHandler(repo repository.Repository, opts ...Option) *AppSyncHandler {
+	h := &AppSyncHandler{
+		repo:       repo,
+		listLimits: config.DefaultListLimits(),
+		clock:      repository.SystemClock,
 	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
 }
 
 // Handle processes an AppSync event and returns the appropriate response.
-func (h *AppSyncHandler) Handle(ctx context.Context, event AppSyncEvent) (interface{}, error) {
+func (h *AppSyncHandler) Handle(ctx context.Context, event AppSyncEvent) (result interface{}, err error) {
+	ctx, reportCapacity := h.trackCapacity(ctx, event)
+	defer reportCapacity()
+	defer func() {
+		if err == nil {
+			h.meterUsage(ctx, event)
+		}
+	}()
+
+	if isAPIKeyCaller(event) {
+		return h.handleAPIKeyRequest(ctx, event)
+	}
+	if err := h.authorize(ctx, event); err != nil {
+		return nil, err
+	}
+	if err := h.verifySignature(ctx, event); err != nil {
+		return nil, err
+	}
+	accountID, requestID, err := h.recordRequest(ctx, event)
+	if err != nil {
+		return nil, err
+	}
+	if requestID != "" {
+		defer func() {
+			if err != nil {
+				h.releaseRequest(ctx, accountID, requestID)
+			}
+		}()
+	}
+
 	switch event.Field {
-	case "createLocation", "createAddressLocation", "createCoordinatesLocation", "createShopLocation":
+	case "createLocation", "createAddressLocation", "createCoordinatesLocation", "createShopLocation", "createEventLocation":
 		return h.handleCreateLocation(ctx, event.Arguments)
 	case "getLocation":
 		return h.handleGetLocation(ctx, event.Arguments)
-	case "updateLocation", "updateAddressLocation", "updateCoordinatesLocation", "updateShopLocation":
+	case "getLocationByExternalId":
+		return h.handleGetLocationByExternalID(ctx, event.Arguments)
+	case "updateLocation", "updateAddressLocation", "updateCoordinatesLocation", "updateShopLocation", "updateEventLocation":
 		return h.handleUpdateLocation(ctx, event.Arguments)
 	case "deleteLocation":
 		return h.handleDeleteLocation(ctx, event.Arguments)
 	case "listLocations":
-		return h.handleListLocations(ctx, event.Arguments)
+		return h.handleListLocations(ctx, event)
+	case "listLocationsFast":
+		return h.handleListLocationsFast(ctx, event)
+	case "eraseLocationData":
+		return h.handleEraseLocationData(ctx, event.Arguments)
+	case "mergeLocations":
+		return h.handleMergeLocations(ctx, event.Arguments)
+	case "upsertLocationByExternalId":
+		return h.handleUpsertLocationByExternalID(ctx, event.Arguments)
+	case "findDuplicateCandidates":
+		return h.handleFindDuplicateCandidates(ctx, event.Arguments)
+	case "getCustomFieldDefinitions":
+		return h.handleGetCustomFieldDefinitions(ctx, event.Arguments)
+	case "putCustomFieldDefinitions":
+		return h.handlePutCustomFieldDefinitions(ctx, event.Arguments)
+	case "getGeofences":
+		return h.handleGetGeofences(ctx, event.Arguments)
+	case "putGeofences":
+		return h.handlePutGeofences(ctx, event.Arguments)
+	case "listDetectedStops":
+		return h.handleListDetectedStops(ctx, event.Arguments)
+	case "getAccountSettings":
+		return h.handleGetAccountSettings(ctx, event.Arguments)
+	case "updateAccountSettings":
+		return h.handleUpdateAccountSettings(ctx, event.Arguments)
+	case "rotateSigningSecret":
+		return h.handleRotateSigningSecret(ctx, event.Arguments)
+	case "removeSigningSecret":
+		return h.handleRemoveSigningSecret(ctx, event.Arguments)
+	case "createLocationTemplate":
+		return h.handleCreateLocationTemplate(ctx, event.Arguments)
+	case "getLocationTemplate":
+		return h.handleGetLocationTemplate(ctx, event.Arguments)
+	case "listLocationTemplates":
+		return h.handleListLocationTemplates(ctx, event.Arguments)
+	case "deleteLocationTemplate":
+		return h.handleDeleteLocationTemplate(ctx, event.Arguments)
+	case "createLocationFromTemplate":
+		return h.handleCreateLocationFromTemplate(ctx, event.Arguments)
+	case "inferLocation":
+		return h.handleInferLocation(ctx, event.Arguments)
+	case "parseAddress":
+		return h.handleParseAddress(ctx, event.Arguments)
+	case "verifyAddress":
+		return h.handleVerifyAddress(ctx, event.Arguments)
+	case "enrichLocation":
+		return h.handleEnrichLocation(ctx, event.Arguments)
+	case "unlockCoordinates":
+		return h.handleUnlockCoordinates(ctx, event.Arguments)
+	case "refreshStaleGeocodes":
+		return h.handleRefreshStaleGeocodes(ctx, event.Arguments)
+	case "detectStops":
+		return h.handleDetectStops(ctx, event.Arguments)
+	case "contactDeleted":
+		return h.handleContactDeleted(ctx, event.Arguments)
+	case "occurrencesBetween":
+		return h.handleOccurrencesBetween(ctx, event.Arguments)
+	case "publishLocation":
+		return h.handlePublishLocation(ctx, event.Arguments)
+	case "proposeLocationUpdate":
+		return h.handleProposeLocationUpdate(ctx, event)
+	case "listProposals":
+		return h.handleListProposals(ctx, event)
+	case "reviewProposal":
+		return h.handleReviewProposal(ctx, event)
+	case "listLocationsByCategory":
+		return h.handleListLocationsByCategory(ctx, event)
+	case "findShopsByWebsite":
+		return h.handleFindShopsByWebsite(ctx, event.Arguments)
+	case "listLocationsByContactId":
+		return h.handleListLocationsByContactID(ctx, event.Arguments)
+	case "syncLocations":
+		return h.handleSyncLocations(ctx, event.Arguments)
+	case "locationStats":
+		return h.handleLocationStats(ctx, event.Arguments)
+	case "locationHeatmap":
+		return h.handleLocationHeatmap(ctx, event.Arguments)
+	case "publicNearbyShops":
+		return h.handlePublicNearbyShops(ctx, event.Arguments)
+	case "publicShop":
+		return h.handlePublicShop(ctx, event.Arguments)
+	case "assignNearest":
+		return h.handleAssignNearest(ctx, event.Arguments)
+	case "nearestLocationsByCategory":
+		return h.handleNearestLocationsByCategory(ctx, event.Arguments)
+	case "quoteDeliveryForPoint":
+		return h.handleQuoteDeliveryForPoint(ctx, event.Arguments)
+	case "addShopContact":
+		return h.handleAddShopContact(ctx, event.Arguments)
+	case "removeShopContact":
+		return h.handleRemoveShopContact(ctx, event.Arguments)
+	case "addLocationUnit":
+		return h.handleAddLocationUnit(ctx, event.Arguments)
+	case "updateLocationUnit":
+		return h.handleUpdateLocationUnit(ctx, event.Arguments)
+	case "removeLocationUnit":
+		return h.handleRemoveLocationUnit(ctx, event.Arguments)
+	case "getLocationContext":
+		return h.handleGetLocationContext(ctx, event.Arguments)
+	case "etaToLocation":
+		return h.handleEtaToLocation(ctx, event.Arguments)
+	case "getLocationMapImageURL":
+		return h.handleGetLocationMapImageURL(ctx, event.Arguments)
+	case "adminGetLocationById":
+		return h.handleAdminGetLocationByID(ctx, event)
+	case "adminListAccountLocations":
+		return h.handleAdminListAccountLocations(ctx, event)
+	case "adminTransferLocation":
+		return h.handleAdminTransferLocation(ctx, event)
+	case "adminListAllLocations":
+		return h.handleAdminListAllLocations(ctx, event)
+	case "healthCheck":
+		return h.handleHealthCheck(ctx)
+	case "getUsage":
+		return h.handleGetUsage(ctx, event)
 	default:
 		return nil, fmt.Errorf("unknown field: %s", event.Field)
 	}
 }
 
-func (h *AppSyncHandler) handleCreateLocation(ctx context.Context, arguments json.RawMessage) (string, error) {
+// handleCreateLocation stores a new location and returns it as persisted, in
+// the same shape as getLocation, so clients need not read it back.
+func (h *AppSyncHandler) handleCreateLocation(ctx context.Context, arguments json.RawMessage) (map[string]interface{}, error) {
 	var args CreateLocationArguments
 	if err := json.Unmarshal(arguments, &args); err != nil {
-		return "", fmt.Errorf("failed to unmarshal arguments: %w", err)
+		return nil, fmt.Errorf("failed to unmarshal arguments: %w", err)
 	}
 
 	location, err := models.UnmarshalLocation(args.Input)
 	if err != nil {
-		return "", fmt.Errorf("failed to unmarshal location: %w", err)
+		return nil, fmt.Errorf("failed to unmarshal location: %w", err)
+	}
+	location, err = withConvertedCoordinates(normalizeContactDetails(withoutProviderData(location)))
+	if err != nil {
+		return nil, err
+	}
+	if location, err = h.withElevation(ctx, location); err != nil {
+		return nil, err
+	}
+	location, warnings, err := h.relaxLocation(ctx, location, args.ValidationMode)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.validateCustomFields(ctx, location); err != nil {
+		return nil, err
+	}
+	if err := h.validateCategories(ctx, location); err != nil {
+		return nil, err
+	}
+
+	if args.VerifyAddress {
+		location, _, err = h.verifyLocation(ctx, location)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	locationID, err := h.repo.Create(ctx, location)
+	created, err := h.repo.Create(ctx, location)
 	if err != nil {
-		return "", fmt.Errorf("failed to create location: %w", err)
+		return nil, fmt.Errorf("failed to create location: %w", err)
 	}
 
-	return locationID, nil
+	result, err := toLocationMap(*created, args.IncludeLinks)
+	if err != nil {
+		return nil, err
+	}
+	if len(warnings) > 0 {
+		result["validationWarnings"] = warnings
+	}
+	result["filterableFields"] = filterable.For(created.Location)
+	return result, nil
 }
 
 func (h *AppSyncHandler) handleGetLocation(ctx context.Context, arguments json.RawMessage) (map[string]interface{}, error) {
@@ -135,118 +572,470 @@ func (h *AppSyncHandler) handleGetLocation(ctx context.Context, arguments json.R
 	if err := json.Unmarshal(arguments, &args); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal arguments: %w", err)
 	}
+	if err := h.requireAssets(args.IncludeAssets); err != nil {
+		return nil, err
+	}
 
-	location, err := h.repo.Get(ctx, args.AccountID, args.LocationID)
+	ctx, readInfo := repository.WithReadInfo(ctx)
+	envelope, err := h.repo.Get(ctx, args.AccountID, args.LocationID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get location: %w", err)
 	}
 
-	// Convert location to map and add __typename
-	locationBytes, err := json.Marshal(location)
+	if args.IfNoneMatch != "" && args.IfNoneMatch == envelope.ETag {
+		return notModified(envelope, readInfo.StaleRead), nil
+	}
+
+	result, err := toLocationMap(*envelope, args.IncludeLinks)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal location: %w", err)
+		return nil, err
+	}
+	if args.IncludeAssets {
+		if err := h.addAssets(ctx, envelope.Location, result); err != nil {
+			return nil, err
+		}
 	}
 
-	var result map[string]interface{}
-	if err := json.Unmarshal(locationBytes, &result); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal location to map: %w", err)
+	// Hint that the record may be stale when the read fell back to eventual consistency
+	if readInfo.StaleRead {
+		result["staleRead"] = true
 	}
 
-	// Add locationId to the result
-	result["locationId"] = args.LocationID
+	return result, nil
+}
 
-	// Add __typename based on location type
-	switch location.GetLocationType() {
-	case models.LocationTypeAddress:
-		result["__typename"] = "AddressLocation"
-	case models.LocationTypeCoordinates:
-		result["__typename"] = "CoordinatesLocation"
-	case models.LocationTypeShop:
-		result["__typename"] = "ShopLocation"
+// notModified is the result of a conditional read whose caller already holds
+// the location's current etag, carrying no location data.
+func notModified(envelope *repository.LocationEnvelope, staleRead bool) map[string]interface{} {
+	result := map[string]interface{}{
+		"__typename":  "NotModified",
+		"notModified": true,
+		"locationId":  envelope.LocationID,
+		"etag":        envelope.ETag,
 	}
+	if staleRead {
+		result["staleRead"] = true
+	}
+	return result
+}
 
-	return result, nil
+func (h *AppSyncHandler) handleGetLocationByExternalID(ctx context.Context, arguments json.RawMessage) (map[string]interface{}, error) {
+	var args GetLocationByExternalIDArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+
+	envelope, err := h.repo.GetByExternalID(ctx, args.AccountID, args.ExternalID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get location: %w", err)
+	}
+
+	return toLocationMap(*envelope, args.IncludeLinks)
 }
 
-func (h *AppSyncHandler) handleUpdateLocation(ctx context.Context, arguments json.RawMessage) (bool, error) {
+func (h *AppSyncHandler) handleUpdateLocation(ctx context.Context, arguments json.RawMessage) (*UpdateResponse, error) {
 	var args UpdateLocationArguments
 	if err := json.Unmarshal(arguments, &args); err != nil {
-		return false, fmt.Errorf("failed to unmarshal arguments: %w", err)
+		return nil, fmt.Errorf("failed to unmarshal arguments: %w", err)
 	}
 
-	location, err := models.UnmarshalLocation(args.Input)
+	location, warnings, err := h.updateInput(ctx, args.Input, args.AccountID, args.ValidationMode)
+	if err != nil {
+		return nil, err
+	}
+	if location, err = h.checkPosition(ctx, location, args.LocationID); err != nil {
+		return nil, err
+	}
+	if args.IfMatch != "" {
+		ctx = repository.WithIfMatch(ctx, args.IfMatch)
+	}
+
+	err = h.repo.Update(ctx, location, args.LocationID)
+	var typeErr *repository.LocationTypeError
+	if errors.As(err, &typeErr) {
+		response, err := h.changeLocationType(ctx, location, args, typeErr)
+		if err != nil {
+			return nil, err
+		}
+		response.ValidationWarnings = warnings
+		response.FilterableFields = filterable.For(location)
+		return response, nil
+	}
 	if err != nil {
-		return false, fmt.Errorf("failed to unmarshal location: %w", err)
+		return nil, fmt.Errorf("failed to update location: %w", err)
+	}
+
+	h.evaluateGeofences(ctx, location, args.LocationID)
+	h.recordPosition(ctx, location, args.LocationID)
+
+	if coordinates, ok := location.(models.CoordinatesLocation); ok && coordinates.PositionAnomaly != nil {
+		return &UpdateResponse{
+			Success:            true,
+			Message:            "location updated; position flagged as anomalous",
+			LocationID:         args.LocationID,
+			PositionAnomaly:    coordinates.PositionAnomaly,
+			ValidationWarnings: warnings,
+			FilterableFields:   filterable.For(location),
+		}, nil
+	}
+	return &UpdateResponse{
+		Success:            true,
+		Message:            "location updated",
+		LocationID:         args.LocationID,
+		ValidationWarnings: warnings,
+		FilterableFields:   filterable.For(location),
+	}, nil
+}
+
+// updateInput parses and checks the replacement location of an update. When
+// accountID is set, the input must belong to that account. The optional
+// fields dropped under a lenient validation mode are returned as warnings.
+func (h *AppSyncHandler) updateInput(ctx context.Context, input json.RawMessage, accountID string, mode *models.ValidationStrictness) (models.Location, models.ValidationErrors, error) {
+	location, err := models.UnmarshalLocation(input)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal location: %w", err)
+	}
+	location, err = withConvertedCoordinates(normalizeContactDetails(withoutProviderData(location)))
+	if err != nil {
+		return nil, nil, err
+	}
+	if location, err = h.withElevation(ctx, location); err != nil {
+		return nil, nil, err
+	}
+
+	// Locations change accounts only through adminTransferLocation
+	if accountID != "" && location.GetAccountID() != accountID {
+		return nil, nil, fmt.Errorf("input accountId must match accountId %s; use adminTransferLocation to move a location between accounts", accountID)
 	}
 
-	if err := h.repo.Update(ctx, location, args.LocationID); err != nil {
-		return false, fmt.Errorf("failed to update location: %w", err)
+	location, warnings, err := h.relaxLocation(ctx, location, mode)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := h.validateCustomFields(ctx, location); err != nil {
+		return nil, nil, err
+	}
+	if err := h.validateCategories(ctx, location); err != nil {
+		return nil, nil, err
 	}
+	return location, warnings, nil
+}
 
-	return true, nil
+// changeLocationType finishes an update that would change the stored
+// location's type, which callers must ask for with allowTypeChange.
+func (h *AppSyncHandler) changeLocationType(ctx context.Context, location models.Location, args UpdateLocationArguments, typeErr *repository.LocationTypeError) (*UpdateResponse, error) {
+	if !args.AllowTypeChange {
+		return nil, fmt.Errorf("failed to update location: %w; set allowTypeChange to convert it", typeErr)
+	}
+	if h.typeChanger == nil {
+		return nil, fmt.Errorf("failed to update location: location type changes are not configured")
+	}
+	change, err := h.typeChanger.ChangeType(ctx, location, args.LocationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update location: %w", err)
+	}
+	return &UpdateResponse{
+		Success:    true,
+		Message:    fmt.Sprintf("location converted from %s to %s", change.FromType, change.ToType),
+		LocationID: args.LocationID,
+	}, nil
 }
 
-func (h *AppSyncHandler) handleDeleteLocation(ctx context.Context, arguments json.RawMessage) (bool, error) {
+func (h *AppSyncHandler) handleDeleteLocation(ctx context.Context, arguments json.RawMessage) (*DeleteResponse, error) {
 	var args DeleteLocationArguments
 	if err := json.Unmarshal(arguments, &args); err != nil {
-		return false, fmt.Errorf("failed to unmarshal arguments: %w", err)
+		return nil, fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+
+	if args.Cascade {
+		if h.cascade == nil {
+			return nil, fmt.Errorf("failed to delete location: cascading deletes are not configured")
+		}
+		deleted, err := h.cascade.DeleteCascade(ctx, args.AccountID, args.LocationID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to delete location: %w", err)
+		}
+		return &DeleteResponse{
+			Success:          true,
+			Message:          fmt.Sprintf("location and %d merged locations deleted", len(deleted)),
+			LocationID:       args.LocationID,
+			CascadeDeleted:   deleted,
+			FilterableFields: filterable.ForAccount(args.AccountID),
+		}, nil
 	}
 
-	if err := h.repo.Delete(ctx, args.AccountID, args.LocationID); err != nil {
-		return false, fmt.Errorf("failed to delete location: %w", err)
+	err := h.repo.Delete(ctx, args.AccountID, args.LocationID)
+	var conflict *repository.ReferenceConflictError
+	if errors.As(err, &conflict) {
+		return nil, fmt.Errorf("failed to delete location: %w; set cascade to delete them too", conflict)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete location: %w", err)
 	}
 
-	return true, nil
+	return &DeleteResponse{
+		Success:          true,
+		Message:          "location deleted",
+		LocationID:       args.LocationID,
+		FilterableFields: filterable.ForAccount(args.AccountID),
+	}, nil
 }
 
-func (h *AppSyncHandler) handleListLocations(ctx context.Context, arguments json.RawMessage) (*ListLocationsResponse, error) {
-	var args ListLocationsArguments
+func (h *AppSyncHandler) handleEraseLocationData(ctx context.Context, arguments json.RawMessage) (*repository.ErasureCertificate, error) {
+	var args EraseLocationDataArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+
+	if args.AccountID == "" || args.LocationID == "" {
+		return nil, fmt.Errorf("accountId and locationId are required")
+	}
+
+	cert, err := h.repo.Erase(ctx, args.AccountID, args.LocationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to erase location data: %w", err)
+	}
+
+	return cert, nil
+}
+
+func (h *AppSyncHandler) handleMergeLocations(ctx context.Context, arguments json.RawMessage) (*repository.MergeResult, error) {
+	var args MergeLocationsArguments
 	if err := json.Unmarshal(arguments, &args); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal arguments: %w", err)
 	}
 
+	if args.AccountID == "" || args.SurvivorID == "" || len(args.DuplicateIDs) == 0 {
+		return nil, fmt.Errorf("accountId, survivorId, and duplicateIds are required")
+	}
+
+	result, err := h.repo.Merge(ctx, args.AccountID, args.SurvivorID, args.DuplicateIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge locations: %w", err)
+	}
+
+	return result, nil
+}
+
+func (h *AppSyncHandler) handleUpsertLocationByExternalID(ctx context.Context, arguments json.RawMessage) (*repository.UpsertResult, error) {
+	var args UpsertLocationByExternalIDArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+
+	location, err := models.UnmarshalLocation(args.Input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal location: %w", err)
+	}
+	if location.GetExternalID() == "" {
+		return nil, fmt.Errorf("externalId is required")
+	}
+	location, err = withConvertedCoordinates(normalizeContactDetails(withoutProviderData(location)))
+	if err != nil {
+		return nil, err
+	}
+	if location, err = h.withElevation(ctx, location); err != nil {
+		return nil, err
+	}
+	location, warnings, err := h.relaxLocation(ctx, location, args.ValidationMode)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.validateCustomFields(ctx, location); err != nil {
+		return nil, err
+	}
+	if err := h.validateCategories(ctx, location); err != nil {
+		return nil, err
+	}
+
+	result, err := h.repo.Upsert(ctx, location)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert location: %w", err)
+	}
+	result.ValidationWarnings = warnings
+	fields := filterable.For(location)
+	result.FilterableFields = &fields
+
+	return result, nil
+}
+
+func (h *AppSyncHandler) handleListLocations(ctx context.Context, event AppSyncEvent) (*ListLocationsResponse, error) {
+	var args ListLocationsArguments
+	if err := json.Unmarshal(event.Arguments, &args); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+
+	if err := h.requireAssets(args.IncludeAssets); err != nil {
+		return nil, err
+	}
+
+	if c := args.MinGeocodeConfidence; c != nil && (*c < 0 || *c > 1) {
+		return nil, fmt.Errorf("minGeocodeConfidence must be between 0 and 1")
+	}
+	if m := args.MaxAccuracyMeters; m != nil && *m <= 0 {
+		return nil, fmt.Errorf("maxAccuracyMeters must be greater than 0")
+	}
+	var activeOn *time.Time
+	if args.ActiveOn != "" {
+		date, err := time.Parse(time.DateOnly, args.ActiveOn)
+		if err != nil {
+			return nil, fmt.Errorf("activeOn must be a date such as 2024-06-04")
+		}
+		activeOn = &date
+	}
+
+	limit, err := h.listLimits.Resolve(args.Limit)
+	if err != nil {
+		return nil, err
+	}
 	options := &repository.ListOptions{
-		Limit:  args.Limit,
-		Cursor: args.Cursor,
+		Limit:                &limit,
+		Cursor:               args.Cursor,
+		Category:             args.Category,
+		MinGeocodeConfidence: args.MinGeocodeConfidence,
+		IncludeDrafts:        args.IncludeDrafts,
+		IncludeInactive:      args.IncludeInactive,
+		ActiveOn:             activeOn,
+		MaxAccuracyMeters:    args.MaxAccuracyMeters,
 	}
 
-	result, err := h.repo.List(ctx, args.AccountID, options)
+	var result *repository.ListResult
+	if args.IncludeSubAccounts {
+		result, err = h.listWithSubAccounts(ctx, args.AccountID, options)
+	} else {
+		result, err = h.repo.List(ctx, args.AccountID, options)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to list locations: %w", err)
 	}
 
 	// Convert each location to map and add __typename
-	locationMaps := make([]map[string]interface{}, len(result.Locations))
-	for i, location := range result.Locations {
-		// Convert location to map and add __typename
-		locationBytes, err := json.Marshal(location)
+	locationMaps := make([]map[string]interface{}, len(result.Items))
+	for i, item := range result.Items {
+		locationMap, err := toLocationMap(item, args.IncludeLinks)
 		if err != nil {
-			return nil, fmt.Errorf("failed to marshal location: %w", err)
+			return nil, err
 		}
-
-		var locationMap map[string]interface{}
-		if err := json.Unmarshal(locationBytes, &locationMap); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal location to map: %w", err)
+		if args.IncludeAssets {
+			if err := h.addAssets(ctx, item.Location, locationMap); err != nil {
+				return nil, err
+			}
 		}
+		locationMaps[i] = locationMap
+	}
+
+	response := &ListLocationsResponse{
+		Locations:     locationMaps,
+		NextCursor:    result.NextCursor,
+		StaleRead:     result.StaleRead,
+		IndexFallback: result.IndexFallback,
+	}
+	if err := h.compressLocations(event.Request, response); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// toLocationMap converts a stored location to the map returned to AppSync,
+// adding its locationId, __typename, and optionally map links.
+func toLocationMap(envelope repository.LocationEnvelope, includeLinks bool) (map[string]interface{}, error) {
+	location := envelope.Location
+	locationBytes, err := json.Marshal(location)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal location: %w", err)
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(locationBytes, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal location to map: %w", err)
+	}
+
+	result["locationId"] = envelope.LocationID
+	if envelope.ETag != "" {
+		result["etag"] = envelope.ETag
+	}
+
+	if includeLinks {
+		result["links"] = links.For(location)
+	}
+
+	// Add __typename based on location type
+	switch location.GetLocationType() {
+	case models.LocationTypeAddress:
+		result["__typename"] = "AddressLocation"
+	case models.LocationTypeCoordinates:
+		result["__typename"] = "CoordinatesLocation"
+	case models.LocationTypeShop:
+		result["__typename"] = "ShopLocation"
+	case models.LocationTypeEvent:
+		result["__typename"] = "EventLocation"
+	}
+
+	return result, nil
+}
 
-		// Add locationId to the result
-		locationMap["locationId"] = result.LocationIDs[i]
-
-		// Add __typename based on location type
-		switch location.GetLocationType() {
-		case models.LocationTypeAddress:
-			locationMap["__typename"] = "AddressLocation"
-		case models.LocationTypeCoordinates:
-			locationMap["__typename"] = "CoordinatesLocation"
-		case models.LocationTypeShop:
-			locationMap["__typename"] = "ShopLocation"
+// withoutProviderData drops any client-supplied verification, enrichment, or
+// geocode provenance so those fields only ever hold provider results. A
+// replaced location must be verified, enriched, or geocoded again. A shop's
+// contactDeleted flag is likewise only set by a contactDeleted event, and
+// saving the shop clears it.
+func withoutProviderData(location models.Location) models.Location {
+	switch loc := location.(type) {
+	case models.AddressLocation:
+		loc.Address.Verification = nil
+		loc.Geocode = nil
+		loc.Addresses = unverifiedAddresses(loc.Addresses)
+		return loc
+	case models.CoordinatesLocation:
+		loc.Addresses = unverifiedAddresses(loc.Addresses)
+		loc.PositionAnomaly = nil
+		return loc
+	case models.ShopLocation:
+		loc.Shop.Address.Verification = nil
+		loc.Shop.Enrichment = nil
+		loc.Shop.ContactDeleted = false
+		loc.Addresses = unverifiedAddresses(loc.Addresses)
+		return loc
+	case models.EventLocation:
+		if loc.Address != nil {
+			address := *loc.Address
+			address.Verification = nil
+			loc.Address = &address
 		}
+		loc.Addresses = unverifiedAddresses(loc.Addresses)
+		return loc
+	}
+	return location
+}
 
-		locationMaps[i] = locationMap
+// unverifiedAddresses returns a copy of typed addresses without verification,
+// which only verifyAddress sets, and only on a location's primary address.
+func unverifiedAddresses(addresses map[models.AddressKind]models.Address) map[models.AddressKind]models.Address {
+	if addresses == nil {
+		return nil
+	}
+	unverified := make(map[models.AddressKind]models.Address, len(addresses))
+	for kind, address := range addresses {
+		address.Verification = nil
+		unverified[kind] = address
 	}
+	return unverified
+}
 
-	return &ListLocationsResponse{
-		Locations:  locationMaps,
-		NextCursor: result.NextCursor,
-	}, nil
+// normalizeContactDetails converts a shop phone entered as a national number
+// into E.164 using the shop's country, and normalizes its website and social
+// links. Values that cannot be normalized are left for validation to reject.
+func normalizeContactDetails(location models.Location) models.Location {
+	loc, ok := location.(models.ShopLocation)
+	if !ok {
+		return location
+	}
+	if loc.Shop.Phone != "" && models.ValidatePhone(loc.Shop.Phone) != nil {
+		if phone, err := models.NormalizePhone(loc.Shop.Phone, loc.Shop.Address.Country); err == nil {
+			loc.Shop.Phone = phone
+		}
+	}
+	loc.Shop.NormalizeLinks()
+	return loc
 }
@@ -5,7 +5,11 @@ import (
 	"encoding/json"
 	"errors"
 	"testing"
+	"time"
 
+	"github.com/steverhoton/location-lambda/internal/config"
+	"github.com/steverhoton/location-lambda/internal/filterable"
+	"github.com/steverhoton/location-lambda/internal/links"
 	"github.com/steverhoton/location-lambda/internal/models"
 	"github.com/steverhoton/location-lambda/internal/repository"
 	"github.com/stretchr/testify/assert"
@@ -18,17 +22,21 @@ type mockRepository struct {
 	mock.Mock
 }
 
-func (m *mockRepository) Create(ctx context.Context, location models.Location) (string, error) {
+// Create returns the location it was given under the ID the test configures.
+func (m *mockRepository) Create(ctx context.Context, location models.Location) (*repository.LocationEnvelope, error) {
 	args := m.Called(ctx, location)
-	return args.String(0), args.Error(1)
+	if err := args.Error(1); err != nil {
+		return nil, err
+	}
+	return &repository.LocationEnvelope{LocationID: args.String(0), Location: location}, nil
 }
 
-func (m *mockRepository) Get(ctx context.Context, accountID, locationID string) (models.Location, error) {
+func (m *mockRepository) Get(ctx context.Context, accountID, locationID string) (*repository.LocationEnvelope, error) {
 	args := m.Called(ctx, accountID, locationID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).(models.Location), args.Error(1)
+	return args.Get(0).(*repository.LocationEnvelope), args.Error(1)
 }
 
 func (m *mockRepository) Update(ctx context.Context, location models.Location, locationID string) error {
@@ -49,6 +57,43 @@ func (m *mockRepository) List(ctx context.Context, accountID string, options *re
 	return args.Get(0).(*repository.ListResult), args.Error(1)
 }
 
+func (m *mockRepository) Erase(ctx context.Context, accountID, locationID string) (*repository.ErasureCertificate, error) {
+	args := m.Called(ctx, accountID, locationID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.ErasureCertificate), args.Error(1)
+}
+
+func (m *mockRepository) Merge(ctx context.Context, accountID, survivorID string, duplicateIDs []string) (*repository.MergeResult, error) {
+	args := m.Called(ctx, accountID, survivorID, duplicateIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.MergeResult), args.Error(1)
+}
+
+func (m *mockRepository) Upsert(ctx context.Context, location models.Location) (*repository.UpsertResult, error) {
+	args := m.Called(ctx, location)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.UpsertResult), args.Error(1)
+}
+
+func (m *mockRepository) GetByExternalID(ctx context.Context, accountID, externalID string) (*repository.LocationEnvelope, error) {
+	args := m.Called(ctx, accountID, externalID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.LocationEnvelope), args.Error(1)
+}
+
+// withID wraps a location as the repository returns it.
+func withID(locationID string, location models.Location) *repository.LocationEnvelope {
+	return &repository.LocationEnvelope{LocationID: locationID, Location: location}
+}
+
 func TestAppSyncHandlerCreateLocation(t *testing.T) {
 	ctx := context.Background()
 	mockRepo := new(mockRepository)
@@ -81,9 +126,32 @@ func TestAppSyncHandlerCreateLocation(t *testing.T) {
 		result, err := handler.Handle(ctx, event)
 		require.NoError(t, err)
 
-		locationID, ok := result.(string)
+		created, ok := result.(map[string]interface{})
 		require.True(t, ok)
-		assert.NotEmpty(t, locationID)
+		assert.Equal(t, "test-location-id-123", created["locationId"])
+		assert.Equal(t, "AddressLocation", created["__typename"])
+		assert.Equal(t, "acc-12345", created["accountId"])
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Normalizes national shop phone numbers", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		handler := NewAppSyncHandler(mockRepo)
+
+		mockRepo.On("Create", ctx, mock.MatchedBy(func(loc models.Location) bool {
+			shop, ok := loc.(models.ShopLocation)
+			return ok && shop.Shop.Phone == "+15551234567" && shop.Shop.Email == "shop@example.com"
+		})).Return("loc-shop", nil).Once()
+
+		result, err := handler.Handle(ctx, AppSyncEvent{
+			Field: "createLocation",
+			Arguments: json.RawMessage(`{"input": {"accountId": "acc-12345", "locationType": "shop", "shop": {
+				"name": "Main Street Store", "contactId": "contact-1", "phone": "(555) 123-4567", "email": "shop@example.com",
+				"address": {"streetAddress": "123 Main St", "city": "Springfield", "postalCode": "12345", "country": "US"}
+			}}}`),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "+15551234567", result.(map[string]interface{})["shop"].(map[string]interface{})["phone"])
 		mockRepo.AssertExpectations(t)
 	})
 
@@ -96,7 +164,7 @@ func TestAppSyncHandlerCreateLocation(t *testing.T) {
 
 		result, err := handler.Handle(ctx, invalidEvent)
 		assert.Error(t, err)
-		assert.Equal(t, "", result)
+		assert.Nil(t, result)
 		assert.Contains(t, err.Error(), "failed to unmarshal location")
 	})
 
@@ -105,7 +173,7 @@ func TestAppSyncHandlerCreateLocation(t *testing.T) {
 
 		result, err := handler.Handle(ctx, event)
 		assert.Error(t, err)
-		assert.Equal(t, "", result)
+		assert.Nil(t, result)
 		assert.Contains(t, err.Error(), "failed to create location")
 		mockRepo.AssertExpectations(t)
 	})
@@ -136,7 +204,7 @@ func TestAppSyncHandlerGetLocation(t *testing.T) {
 	}
 
 	t.Run("Successful get", func(t *testing.T) {
-		mockRepo.On("Get", ctx, "acc-12345", "loc-001").Return(expectedLocation, nil).Once()
+		mockRepo.On("Get", mock.Anything, "acc-12345", "loc-001").Return(withID("loc-001", expectedLocation), nil).Once()
 
 		result, err := handler.Handle(ctx, event)
 		require.NoError(t, err)
@@ -146,11 +214,26 @@ func TestAppSyncHandlerGetLocation(t *testing.T) {
 		assert.Equal(t, "acc-12345", locationMap["accountId"])
 		assert.Equal(t, "loc-001", locationMap["locationId"])
 		assert.Equal(t, "AddressLocation", locationMap["__typename"])
+		assert.NotContains(t, locationMap, "links")
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Includes links when requested", func(t *testing.T) {
+		mockRepo.On("Get", mock.Anything, "acc-12345", "loc-001").Return(withID("loc-001", expectedLocation), nil).Once()
+
+		result, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "getLocation",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "locationId": "loc-001", "includeLinks": true}`),
+		})
+		require.NoError(t, err)
+
+		locationMap := result.(map[string]interface{})
+		assert.Equal(t, links.For(expectedLocation), locationMap["links"])
 		mockRepo.AssertExpectations(t)
 	})
 
 	t.Run("Location not found", func(t *testing.T) {
-		mockRepo.On("Get", ctx, "acc-12345", "loc-001").Return(nil, errors.New("location not found")).Once()
+		mockRepo.On("Get", mock.Anything, "acc-12345", "loc-001").Return(nil, errors.New("location not found")).Once()
 
 		result, err := handler.Handle(ctx, event)
 		assert.Error(t, err)
@@ -168,7 +251,7 @@ func TestAppSyncHandlerGetLocation(t *testing.T) {
 
 		// The handler will try to call Get with empty strings due to missing fields
 		// This is expected behavior - the arguments unmarshal to zero values
-		mockRepo.On("Get", ctx, "", "").Return(nil, errors.New("location not found")).Once()
+		mockRepo.On("Get", mock.Anything, "", "").Return(nil, errors.New("location not found")).Once()
 
 		result, err := handler.Handle(ctx, invalidEvent)
 		assert.Error(t, err)
@@ -178,6 +261,46 @@ func TestAppSyncHandlerGetLocation(t *testing.T) {
 	})
 }
 
+func TestAppSyncHandlerGetLocationByExternalID(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Resolves the external ID", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		handler := NewAppSyncHandler(mockRepo)
+
+		location := models.CoordinatesLocation{
+			LocationBase: models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeCoordinates, ExternalID: "ERP-1"},
+			Coordinates:  models.Coordinates{Latitude: 40.7128, Longitude: -74.006},
+		}
+		mockRepo.On("GetByExternalID", ctx, "acc-12345", "ERP-1").Return(withID("loc-001", location), nil).Once()
+
+		result, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "getLocationByExternalId",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "externalId": "ERP-1"}`),
+		})
+		require.NoError(t, err)
+
+		locationMap := result.(map[string]interface{})
+		assert.Equal(t, "loc-001", locationMap["locationId"])
+		assert.Equal(t, "ERP-1", locationMap["externalId"])
+		assert.Equal(t, "CoordinatesLocation", locationMap["__typename"])
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		handler := NewAppSyncHandler(mockRepo)
+
+		mockRepo.On("GetByExternalID", ctx, "acc-12345", "ERP-404").Return(nil, errors.New("location not found")).Once()
+
+		_, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "getLocationByExternalId",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "externalId": "ERP-404"}`),
+		})
+		assert.EqualError(t, err, "failed to get location: location not found")
+	})
+}
+
 func TestAppSyncHandlerUpdateLocation(t *testing.T) {
 	ctx := context.Background()
 	mockRepo := new(mockRepository)
@@ -209,9 +332,12 @@ func TestAppSyncHandlerUpdateLocation(t *testing.T) {
 		result, err := handler.Handle(ctx, event)
 		require.NoError(t, err)
 
-		success, ok := result.(bool)
-		require.True(t, ok)
-		assert.True(t, success)
+		assert.Equal(t, &UpdateResponse{
+			Success:          true,
+			Message:          "location updated",
+			LocationID:       "loc-001",
+			FilterableFields: filterable.Fields{AccountID: "acc-12345", LocationType: "address", Tags: []string{}, GeohashPrefixes: []string{}},
+		}, result)
 		mockRepo.AssertExpectations(t)
 	})
 
@@ -220,10 +346,128 @@ func TestAppSyncHandlerUpdateLocation(t *testing.T) {
 
 		result, err := handler.Handle(ctx, event)
 		assert.Error(t, err)
-		assert.Equal(t, false, result)
+		assert.Nil(t, result)
 		assert.Contains(t, err.Error(), "failed to update location")
 		mockRepo.AssertExpectations(t)
 	})
+
+	t.Run("Matching accountId", func(t *testing.T) {
+		mockRepo.On("Update", ctx, mock.Anything, "loc-001").Return(nil).Once()
+
+		result, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "updateLocation",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "locationId": "loc-001", "input": ` + updatedLocationJSON + `}`),
+		})
+		require.NoError(t, err)
+		assert.True(t, result.(*UpdateResponse).Success)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Refuses to move the location to another account", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		result, err := NewAppSyncHandler(mockRepo).Handle(ctx, AppSyncEvent{
+			Field:     "updateLocation",
+			Arguments: json.RawMessage(`{"accountId": "acc-99999", "locationId": "loc-001", "input": ` + updatedLocationJSON + `}`),
+		})
+		assert.EqualError(t, err, "input accountId must match accountId acc-99999; use adminTransferLocation to move a location between accounts")
+		assert.Nil(t, result)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+// mockTypeChanger is a mock implementation of repository.TypeChanger.
+type mockTypeChanger struct {
+	mock.Mock
+}
+
+func (m *mockTypeChanger) ChangeType(ctx context.Context, location models.Location, locationID string) (*repository.TypeChange, error) {
+	args := m.Called(ctx, location, locationID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.TypeChange), args.Error(1)
+}
+
+func TestAppSyncHandlerUpdateLocationTypeChange(t *testing.T) {
+	ctx := context.Background()
+	shopInput := `{
+		"accountId": "acc-12345",
+		"locationType": "shop",
+		"shop": {
+			"name": "Oak Avenue Store",
+			"contactId": "contact-1",
+			"address": {"streetAddress": "456 Oak Ave", "city": "Springfield", "postalCode": "12345", "country": "US"}
+		}
+	}`
+	typeErr := &repository.LocationTypeError{Current: models.LocationTypeAddress, Requested: models.LocationTypeShop}
+	isShop := mock.MatchedBy(func(loc models.Location) bool {
+		_, ok := loc.(models.ShopLocation)
+		return ok
+	})
+
+	t.Run("Refused without allowTypeChange", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		changer := new(mockTypeChanger)
+		mockRepo.On("Update", ctx, isShop, "loc-001").Return(typeErr).Once()
+
+		result, err := NewAppSyncHandler(mockRepo, WithTypeChanger(changer)).Handle(ctx, AppSyncEvent{
+			Field:     "updateLocation",
+			Arguments: json.RawMessage(`{"locationId": "loc-001", "input": ` + shopInput + `}`),
+		})
+		assert.EqualError(t, err, "failed to update location: location is of type address, not shop; set allowTypeChange to convert it")
+		assert.Nil(t, result)
+		changer.AssertNotCalled(t, "ChangeType", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Converted with allowTypeChange", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		changer := new(mockTypeChanger)
+		mockRepo.On("Update", ctx, isShop, "loc-001").Return(typeErr).Once()
+		changer.On("ChangeType", ctx, isShop, "loc-001").Return(&repository.TypeChange{
+			LocationID: "loc-001",
+			FromType:   models.LocationTypeAddress,
+			ToType:     models.LocationTypeShop,
+		}, nil).Once()
+
+		result, err := NewAppSyncHandler(mockRepo, WithTypeChanger(changer)).Handle(ctx, AppSyncEvent{
+			Field:     "updateLocation",
+			Arguments: json.RawMessage(`{"locationId": "loc-001", "allowTypeChange": true, "input": ` + shopInput + `}`),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, &UpdateResponse{
+			Success:          true,
+			Message:          "location converted from address to shop",
+			LocationID:       "loc-001",
+			FilterableFields: filterable.Fields{AccountID: "acc-12345", LocationType: "shop", Tags: []string{}, GeohashPrefixes: []string{}},
+		}, result)
+		mockRepo.AssertExpectations(t)
+		changer.AssertExpectations(t)
+	})
+
+	t.Run("Transition rules are enforced", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		changer := new(mockTypeChanger)
+		mockRepo.On("Update", ctx, isShop, "loc-001").Return(typeErr).Once()
+		changer.On("ChangeType", ctx, isShop, "loc-001").Return(nil,
+			errors.New("validation failed: locationType cannot change from coordinates to shop")).Once()
+
+		_, err := NewAppSyncHandler(mockRepo, WithTypeChanger(changer)).Handle(ctx, AppSyncEvent{
+			Field:     "updateLocation",
+			Arguments: json.RawMessage(`{"locationId": "loc-001", "allowTypeChange": true, "input": ` + shopInput + `}`),
+		})
+		assert.EqualError(t, err, "failed to update location: validation failed: locationType cannot change from coordinates to shop")
+	})
+
+	t.Run("Type changes not configured", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		mockRepo.On("Update", ctx, isShop, "loc-001").Return(typeErr).Once()
+
+		_, err := NewAppSyncHandler(mockRepo).Handle(ctx, AppSyncEvent{
+			Field:     "updateLocation",
+			Arguments: json.RawMessage(`{"locationId": "loc-001", "allowTypeChange": true, "input": ` + shopInput + `}`),
+		})
+		assert.EqualError(t, err, "failed to update location: location type changes are not configured")
+	})
 }
 
 func TestAppSyncHandlerDeleteLocation(t *testing.T) {
@@ -243,9 +487,12 @@ func TestAppSyncHandlerDeleteLocation(t *testing.T) {
 		result, err := handler.Handle(ctx, event)
 		require.NoError(t, err)
 
-		success, ok := result.(bool)
-		require.True(t, ok)
-		assert.True(t, success)
+		assert.Equal(t, &DeleteResponse{
+			Success:          true,
+			Message:          "location deleted",
+			LocationID:       "loc-001",
+			FilterableFields: filterable.ForAccount("acc-12345"),
+		}, result)
 		mockRepo.AssertExpectations(t)
 	})
 
@@ -254,12 +501,231 @@ func TestAppSyncHandlerDeleteLocation(t *testing.T) {
 
 		result, err := handler.Handle(ctx, event)
 		assert.Error(t, err)
-		assert.Equal(t, false, result)
+		assert.Nil(t, result)
 		assert.Contains(t, err.Error(), "failed to delete location")
 		mockRepo.AssertExpectations(t)
 	})
 }
 
+// mockCascadeDeleter is a mock implementation of repository.CascadeDeleter.
+type mockCascadeDeleter struct {
+	mock.Mock
+}
+
+func (m *mockCascadeDeleter) DeleteCascade(ctx context.Context, accountID, locationID string) ([]string, error) {
+	args := m.Called(ctx, accountID, locationID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func TestAppSyncHandlerDeleteLocationCascade(t *testing.T) {
+	ctx := context.Background()
+	conflict := &repository.ReferenceConflictError{LocationID: "loc-001", ReferencedBy: []string{"loc-002"}}
+
+	t.Run("Referenced locations are not deleted without cascade", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		deleter := new(mockCascadeDeleter)
+		mockRepo.On("Delete", ctx, "acc-12345", "loc-001").Return(conflict).Once()
+
+		result, err := NewAppSyncHandler(mockRepo, WithCascadeDeleter(deleter)).Handle(ctx, AppSyncEvent{
+			Field:     "deleteLocation",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "locationId": "loc-001"}`),
+		})
+		assert.EqualError(t, err, "failed to delete location: location loc-001 is referenced by merged locations loc-002; set cascade to delete them too")
+		assert.Nil(t, result)
+		var typed *repository.ReferenceConflictError
+		assert.ErrorAs(t, err, &typed)
+		deleter.AssertNotCalled(t, "DeleteCascade", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Cascade deletes merged locations", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		deleter := new(mockCascadeDeleter)
+		deleter.On("DeleteCascade", ctx, "acc-12345", "loc-001").Return([]string{"loc-002"}, nil).Once()
+
+		result, err := NewAppSyncHandler(mockRepo, WithCascadeDeleter(deleter)).Handle(ctx, AppSyncEvent{
+			Field:     "deleteLocation",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "locationId": "loc-001", "cascade": true}`),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, &DeleteResponse{
+			Success:          true,
+			Message:          "location and 1 merged locations deleted",
+			LocationID:       "loc-001",
+			CascadeDeleted:   []string{"loc-002"},
+			FilterableFields: filterable.ForAccount("acc-12345"),
+		}, result)
+		mockRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+		deleter.AssertExpectations(t)
+	})
+
+	t.Run("Cascade not configured", func(t *testing.T) {
+		_, err := NewAppSyncHandler(new(mockRepository)).Handle(ctx, AppSyncEvent{
+			Field:     "deleteLocation",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "locationId": "loc-001", "cascade": true}`),
+		})
+		assert.EqualError(t, err, "failed to delete location: cascading deletes are not configured")
+	})
+}
+
+func TestAppSyncHandlerEraseLocationData(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(mockRepository)
+	handler := NewAppSyncHandler(mockRepo)
+
+	event := AppSyncEvent{
+		Field:     "eraseLocationData",
+		Arguments: json.RawMessage(`{"accountId": "acc-12345", "locationId": "loc-001"}`),
+	}
+
+	t.Run("Successful erasure", func(t *testing.T) {
+		cert := &repository.ErasureCertificate{
+			CertificateID: "cert-001",
+			AccountID:     "acc-12345",
+			LocationID:    "loc-001",
+			RecordErased:  true,
+		}
+		mockRepo.On("Erase", ctx, "acc-12345", "loc-001").Return(cert, nil).Once()
+
+		result, err := handler.Handle(ctx, event)
+		require.NoError(t, err)
+		assert.Equal(t, cert, result)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Erasure failure", func(t *testing.T) {
+		mockRepo.On("Erase", ctx, "acc-12345", "loc-001").Return(nil, errors.New("throttled")).Once()
+
+		_, err := handler.Handle(ctx, event)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to erase location data")
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Missing identifiers", func(t *testing.T) {
+		_, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "eraseLocationData",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345"}`),
+		})
+		assert.EqualError(t, err, "accountId and locationId are required")
+	})
+}
+
+func TestAppSyncHandlerMergeLocations(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(mockRepository)
+	handler := NewAppSyncHandler(mockRepo)
+
+	event := AppSyncEvent{
+		Field:     "mergeLocations",
+		Arguments: json.RawMessage(`{"accountId": "acc-12345", "survivorId": "loc-001", "duplicateIds": ["loc-002", "loc-003"]}`),
+	}
+
+	t.Run("Successful merge", func(t *testing.T) {
+		merge := &repository.MergeResult{
+			MergeID:      "merge-001",
+			AccountID:    "acc-12345",
+			SurvivorID:   "loc-001",
+			DuplicateIDs: []string{"loc-002", "loc-003"},
+		}
+		mockRepo.On("Merge", ctx, "acc-12345", "loc-001", []string{"loc-002", "loc-003"}).Return(merge, nil).Once()
+
+		result, err := handler.Handle(ctx, event)
+		require.NoError(t, err)
+		assert.Equal(t, merge, result)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Merge failure", func(t *testing.T) {
+		mockRepo.On("Merge", ctx, "acc-12345", "loc-001", []string{"loc-002", "loc-003"}).Return(nil, errors.New("merge conflict")).Once()
+
+		_, err := handler.Handle(ctx, event)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to merge locations")
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Missing duplicates", func(t *testing.T) {
+		_, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "mergeLocations",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "survivorId": "loc-001"}`),
+		})
+		assert.EqualError(t, err, "accountId, survivorId, and duplicateIds are required")
+	})
+}
+
+func TestAppSyncHandlerUpsertLocationByExternalID(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Upserts by external ID", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		handler := NewAppSyncHandler(mockRepo)
+
+		mockRepo.On("Upsert", ctx, mock.MatchedBy(func(l models.Location) bool {
+			loc, ok := l.(models.AddressLocation)
+			return ok && loc.ExternalID == "ERP-1" && loc.Address.Verification == nil &&
+				loc.Addresses[models.AddressKindBilling].StreetAddress == "1 Finance Way" && loc.Addresses[models.AddressKindBilling].Verification == nil
+		})).Return(&repository.UpsertResult{LocationID: "loc-001", Created: true}, nil).Once()
+
+		result, err := handler.Handle(ctx, AppSyncEvent{
+			Field: "upsertLocationByExternalId",
+			Arguments: json.RawMessage(`{"input": {
+				"accountId": "acc-12345",
+				"locationType": "address",
+				"externalId": "ERP-1",
+				"address": {
+					"streetAddress": "123 Main St",
+					"city": "Springfield",
+					"postalCode": "12345",
+					"country": "US",
+					"verification": {"status": "verified", "provider": "forged"}
+				},
+				"addresses": {"billing": {
+					"streetAddress": "1 Finance Way",
+					"city": "Springfield",
+					"postalCode": "12345",
+					"country": "US",
+					"verification": {"status": "verified", "provider": "forged"}
+				}}
+			}}`),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, &repository.UpsertResult{
+			LocationID:       "loc-001",
+			Created:          true,
+			FilterableFields: &filterable.Fields{AccountID: "acc-12345", LocationType: "address", Tags: []string{}, GeohashPrefixes: []string{}},
+		}, result)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Requires an external ID", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		handler := NewAppSyncHandler(mockRepo)
+
+		_, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "upsertLocationByExternalId",
+			Arguments: json.RawMessage(`{"input": {"accountId": "acc-12345", "locationType": "coordinates", "coordinates": {"latitude": 1, "longitude": 2}}}`),
+		})
+		assert.EqualError(t, err, "externalId is required")
+		mockRepo.AssertNotCalled(t, "Upsert", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Conflict", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		handler := NewAppSyncHandler(mockRepo)
+
+		mockRepo.On("Upsert", ctx, mock.Anything).Return(nil, &repository.ExternalIDConflictError{ExternalID: "ERP-1", LocationID: "loc-002"}).Once()
+
+		_, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "upsertLocationByExternalId",
+			Arguments: json.RawMessage(`{"input": {"accountId": "acc-12345", "locationType": "coordinates", "externalId": "ERP-1", "coordinates": {"latitude": 1, "longitude": 2}}}`),
+		})
+		assert.EqualError(t, err, `failed to upsert location: externalId "ERP-1" is already used by location loc-002`)
+	})
+}
+
 func TestAppSyncHandlerListLocations(t *testing.T) {
 	ctx := context.Background()
 	mockRepo := new(mockRepository)
@@ -296,11 +762,15 @@ func TestAppSyncHandlerListLocations(t *testing.T) {
 		},
 	}
 
+	expectedItems := []repository.LocationEnvelope{
+		{LocationID: "loc-123", Location: expectedLocations[0]},
+		{LocationID: "loc-456", Location: expectedLocations[1]},
+	}
+
 	t.Run("Successful list", func(t *testing.T) {
 		expectedResult := &repository.ListResult{
-			Locations:   expectedLocations,
-			LocationIDs: []string{"loc-123", "loc-456"},
-			NextCursor:  nil,
+			Items:      expectedItems,
+			NextCursor: nil,
 		}
 		mockRepo.On("List", ctx, "acc-12345", mock.AnythingOfType("*repository.ListOptions")).Return(expectedResult, nil).Once()
 
@@ -314,11 +784,151 @@ func TestAppSyncHandlerListLocations(t *testing.T) {
 		mockRepo.AssertExpectations(t)
 	})
 
+	t.Run("Stale read is surfaced", func(t *testing.T) {
+		expectedResult := &repository.ListResult{
+			Items:     expectedItems,
+			StaleRead: true,
+		}
+		mockRepo.On("List", ctx, "acc-12345", mock.AnythingOfType("*repository.ListOptions")).Return(expectedResult, nil).Once()
+
+		result, err := handler.Handle(ctx, event)
+		require.NoError(t, err)
+
+		response, ok := result.(*ListLocationsResponse)
+		require.True(t, ok)
+		assert.True(t, response.StaleRead)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Index fallback is surfaced", func(t *testing.T) {
+		expectedResult := &repository.ListResult{
+			Items:         expectedItems,
+			IndexFallback: true,
+		}
+		mockRepo.On("List", ctx, "acc-12345", mock.AnythingOfType("*repository.ListOptions")).Return(expectedResult, nil).Once()
+
+		result, err := handler.Handle(ctx, event)
+		require.NoError(t, err)
+
+		response, ok := result.(*ListLocationsResponse)
+		require.True(t, ok)
+		assert.True(t, response.IndexFallback)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Filters by geocode confidence", func(t *testing.T) {
+		mockRepo.On("List", ctx, "acc-12345", mock.MatchedBy(func(options *repository.ListOptions) bool {
+			return options.MinGeocodeConfidence != nil && *options.MinGeocodeConfidence == 0.8
+		})).Return(&repository.ListResult{Items: expectedItems}, nil).Once()
+
+		_, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "listLocations",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "minGeocodeConfidence": 0.8}`),
+		})
+		require.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+
+		_, err = handler.Handle(ctx, AppSyncEvent{
+			Field:     "listLocations",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "minGeocodeConfidence": 1.5}`),
+		})
+		assert.EqualError(t, err, "minGeocodeConfidence must be between 0 and 1")
+	})
+
+	t.Run("Filters by coordinate accuracy", func(t *testing.T) {
+		mockRepo.On("List", ctx, "acc-12345", mock.MatchedBy(func(options *repository.ListOptions) bool {
+			return options.MaxAccuracyMeters != nil && *options.MaxAccuracyMeters == 10
+		})).Return(&repository.ListResult{Items: expectedItems}, nil).Once()
+
+		_, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "listLocations",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "maxAccuracyMeters": 10}`),
+		})
+		require.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+
+		_, err = handler.Handle(ctx, AppSyncEvent{
+			Field:     "listLocations",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "maxAccuracyMeters": 0}`),
+		})
+		assert.EqualError(t, err, "maxAccuracyMeters must be greater than 0")
+	})
+
+	t.Run("Includes drafts when asked", func(t *testing.T) {
+		mockRepo.On("List", ctx, "acc-12345", mock.MatchedBy(func(options *repository.ListOptions) bool {
+			return options.IncludeDrafts
+		})).Return(&repository.ListResult{Items: expectedItems}, nil).Once()
+
+		_, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "listLocations",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "includeDrafts": true}`),
+		})
+		require.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+	t.Run("Filters events by the day they run", func(t *testing.T) {
+		mockRepo.On("List", ctx, "acc-12345", mock.MatchedBy(func(options *repository.ListOptions) bool {
+			return options.ActiveOn != nil && options.ActiveOn.Equal(time.Date(2024, time.June, 4, 0, 0, 0, 0, time.UTC))
+		})).Return(&repository.ListResult{Items: []repository.LocationEnvelope{{LocationID: "loc-009", Location: models.EventLocation{
+			LocationBase: models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeEvent},
+			Coordinates:  &models.Coordinates{Latitude: 41.8781, Longitude: -87.6298},
+			StartsAt:     time.Date(2024, time.June, 3, 15, 0, 0, 0, time.UTC),
+			EndsAt:       time.Date(2024, time.June, 5, 23, 0, 0, 0, time.UTC),
+		}}}}, nil).Once()
+
+		result, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "listLocations",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "activeOn": "2024-06-04"}`),
+		})
+		require.NoError(t, err)
+		locations := result.(*ListLocationsResponse).Locations
+		require.Len(t, locations, 1)
+		assert.Equal(t, "EventLocation", locations[0]["__typename"])
+		mockRepo.AssertExpectations(t)
+
+		_, err = handler.Handle(ctx, AppSyncEvent{
+			Field:     "listLocations",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "activeOn": "June 4"}`),
+		})
+		assert.EqualError(t, err, "activeOn must be a date such as 2024-06-04")
+	})
+
+	t.Run("Includes inactive locations when asked", func(t *testing.T) {
+		mockRepo.On("List", ctx, "acc-12345", mock.MatchedBy(func(options *repository.ListOptions) bool {
+			return options.IncludeInactive
+		})).Return(&repository.ListResult{Items: expectedItems}, nil).Once()
+
+		_, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "listLocations",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "includeInactive": true}`),
+		})
+		require.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Includes links when requested", func(t *testing.T) {
+		expectedResult := &repository.ListResult{
+			Items: expectedItems,
+		}
+		mockRepo.On("List", ctx, "acc-12345", mock.AnythingOfType("*repository.ListOptions")).Return(expectedResult, nil).Once()
+
+		result, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "listLocations",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "includeLinks": true}`),
+		})
+		require.NoError(t, err)
+
+		response := result.(*ListLocationsResponse)
+		require.Len(t, response.Locations, 2)
+		assert.Equal(t, links.For(expectedLocations[0]), response.Locations[0]["links"])
+		assert.Equal(t, "geo:40.7128,-74.006", response.Locations[1]["links"].(*links.Links).GeoURI)
+		mockRepo.AssertExpectations(t)
+	})
+
 	t.Run("Empty list", func(t *testing.T) {
 		expectedResult := &repository.ListResult{
-			Locations:   []models.Location{},
-			LocationIDs: []string{},
-			NextCursor:  nil,
+			Items:      []repository.LocationEnvelope{},
+			NextCursor: nil,
 		}
 		mockRepo.On("List", ctx, "acc-12345", mock.AnythingOfType("*repository.ListOptions")).Return(expectedResult, nil).Once()
 
@@ -341,6 +951,27 @@ func TestAppSyncHandlerListLocations(t *testing.T) {
 		assert.Contains(t, err.Error(), "failed to list locations")
 		mockRepo.AssertExpectations(t)
 	})
+
+	t.Run("Applies configured limits", func(t *testing.T) {
+		limited := NewAppSyncHandler(mockRepo, WithListLimits(config.ListLimits{Default: 5, Max: 50}))
+		matchLimit := func(limit int32) interface{} {
+			return mock.MatchedBy(func(options *repository.ListOptions) bool {
+				return options.Limit != nil && *options.Limit == limit
+			})
+		}
+		emptyResult := &repository.ListResult{Items: []repository.LocationEnvelope{}}
+		mockRepo.On("List", ctx, "acc-12345", matchLimit(5)).Return(emptyResult, nil).Once()
+		mockRepo.On("List", ctx, "acc-12345", matchLimit(50)).Return(emptyResult, nil).Once()
+
+		_, err := limited.Handle(ctx, event)
+		require.NoError(t, err)
+		_, err = limited.Handle(ctx, AppSyncEvent{Field: "listLocations", Arguments: json.RawMessage(`{"accountId": "acc-12345", "limit": 50}`)})
+		require.NoError(t, err)
+
+		_, err = limited.Handle(ctx, AppSyncEvent{Field: "listLocations", Arguments: json.RawMessage(`{"accountId": "acc-12345", "limit": 51}`)})
+		assert.EqualError(t, err, "limit must be between 1 and 50")
+		mockRepo.AssertExpectations(t)
+	})
 }
 
 func TestAppSyncHandlerUnknownField(t *testing.T) {
@@ -358,3 +989,82 @@ func TestAppSyncHandlerUnknownField(t *testing.T) {
 	assert.Nil(t, result)
 	assert.Contains(t, err.Error(), "unknown field: unknownOperation")
 }
+
+func TestAppSyncHandlerETags(t *testing.T) {
+	ctx := context.Background()
+	location := models.CoordinatesLocation{
+		LocationBase: models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeCoordinates},
+		Coordinates:  models.Coordinates{Latitude: 40.7128, Longitude: -74.006},
+	}
+
+	t.Run("getLocation returns the etag", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		handler := NewAppSyncHandler(mockRepo)
+		mockRepo.On("Get", mock.Anything, "acc-12345", "loc-001").Return(&repository.LocationEnvelope{LocationID: "loc-001", Location: location, ETag: "etag-1"}, nil).Once()
+
+		result, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "getLocation",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "locationId": "loc-001"}`),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "etag-1", result.(map[string]interface{})["etag"])
+	})
+
+	t.Run("ifMatch makes the update conditional", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		handler := NewAppSyncHandler(mockRepo)
+		mockRepo.On("Update", mock.Anything, mock.Anything, "loc-001").Run(func(args mock.Arguments) {
+			assert.Equal(t, repository.WithIfMatch(ctx, "etag-1"), args.Get(0))
+		}).Return(&repository.PreconditionFailedError{ETag: "etag-2"}).Once()
+
+		_, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "updateLocation",
+			Arguments: json.RawMessage(`{"locationId": "loc-001", "ifMatch": "etag-1", "input": {"accountId": "acc-12345", "locationType": "coordinates", "coordinates": {"latitude": 40.7128, "longitude": -74.006}}}`),
+		})
+		var precondition *repository.PreconditionFailedError
+		require.True(t, errors.As(err, &precondition))
+		assert.Equal(t, "etag-2", precondition.ETag)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestAppSyncHandlerGetLocationIfNoneMatch(t *testing.T) {
+	ctx := context.Background()
+	envelope := &repository.LocationEnvelope{
+		LocationID: "loc-001",
+		Location: models.CoordinatesLocation{
+			LocationBase: models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeCoordinates},
+			Coordinates:  models.Coordinates{Latitude: 40.7128, Longitude: -74.006},
+		},
+		ETag: "etag-1",
+	}
+
+	tests := []struct {
+		name         string
+		ifNoneMatch  string
+		wantTypename string
+	}{
+		{name: "Matching etag returns NotModified", ifNoneMatch: "etag-1", wantTypename: "NotModified"},
+		{name: "Stale etag returns the location", ifNoneMatch: "etag-0", wantTypename: "CoordinatesLocation"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(mockRepository)
+			handler := NewAppSyncHandler(mockRepo)
+			mockRepo.On("Get", mock.Anything, "acc-12345", "loc-001").Return(envelope, nil).Once()
+
+			result, err := handler.Handle(ctx, AppSyncEvent{
+				Field:     "getLocation",
+				Arguments: json.RawMessage(`{"accountId": "acc-12345", "locationId": "loc-001", "ifNoneMatch": "` + tt.ifNoneMatch + `"}`),
+			})
+			require.NoError(t, err)
+			location := result.(map[string]interface{})
+			assert.Equal(t, tt.wantTypename, location["__typename"])
+			assert.Equal(t, "etag-1", location["etag"])
+			if tt.wantTypename == "NotModified" {
+				assert.Equal(t, map[string]interface{}{"__typename": "NotModified", "notModified": true, "locationId": "loc-001", "etag": "etag-1"}, location)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/steverhoton/location-lambda/internal/assets"
+	"github.com/steverhoton/location-lambda/internal/models"
+)
+
+// ShopAssets are a shop's logo and photos resolved to URLs.
+type ShopAssets struct {
+	Logo   *assets.Asset  `json:"logo,omitempty"`
+	Photos []assets.Asset `json:"photos"`
+}
+
+// WithAssetResolver enables includeAssets, resolving shop logo and photo keys,
+// and floor plan keys, to URLs.
+func WithAssetResolver(resolver assets.Resolver) Option {
+	return func(h *AppSyncHandler) {
+		h.assets = resolver
+	}
+}
+
+// requireAssets rejects includeAssets when no asset resolver is configured,
+// before any location is read.
+func (h *AppSyncHandler) requireAssets(includeAssets bool) error {
+	if includeAssets && h.assets == nil {
+		return fmt.Errorf("asset URLs are not configured")
+	}
+	return nil
+}
+
+// addAssets sets result's assets to the shop's resolved logo and photos, and
+// result's floorPlan to the resolved floor plan of a coordinates location
+// placed on one. Other locations are left as they are.
+func (h *AppSyncHandler) addAssets(ctx context.Context, location models.Location, result map[string]interface{}) error {
+	if indoor, ok := location.(models.CoordinatesLocation); ok && indoor.IndoorCoordinates != nil {
+		floorPlan, err := h.assets.Resolve(ctx, indoor.IndoorCoordinates.FloorPlanKey)
+		if err != nil {
+			return fmt.Errorf("failed to resolve floor plan: %w", err)
+		}
+		result["floorPlan"] = floorPlan
+		return nil
+	}
+	shop, ok := location.(models.ShopLocation)
+	if !ok {
+		return nil
+	}
+
+	resolved := ShopAssets{Photos: make([]assets.Asset, 0, len(shop.Shop.PhotoKeys))}
+	if shop.Shop.LogoKey != "" {
+		logo, err := h.assets.Resolve(ctx, shop.Shop.LogoKey)
+		if err != nil {
+			return fmt.Errorf("failed to resolve logo: %w", err)
+		}
+		resolved.Logo = logo
+	}
+	for _, key := range shop.Shop.PhotoKeys {
+		photo, err := h.assets.Resolve(ctx, key)
+		if err != nil {
+			return fmt.Errorf("failed to resolve photo: %w", err)
+		}
+		resolved.Photos = append(resolved.Photos, *photo)
+	}
+	result["assets"] = resolved
+	return nil
+}
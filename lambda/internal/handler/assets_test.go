@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/steverhoton/location-lambda/internal/assets"
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockAssetResolver is a mock implementation of the assets.Resolver interface.
+type mockAssetResolver struct {
+	mock.Mock
+}
+
+func (m *mockAssetResolver) Resolve(ctx context.Context, key string) (*assets.Asset, error) {
+	args := m.Called(ctx, key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*assets.Asset), args.Error(1)
+}
+
+func TestAppSyncHandlerIncludeAssets(t *testing.T) {
+	ctx := context.Background()
+	shop := models.ShopLocation{
+		LocationBase: models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeShop},
+		Shop: models.Shop{
+			Name:       "Main Street Store",
+			ContactID:  "contact-1",
+			Address:    models.Address{StreetAddress: "123 Main St", City: "Springfield", PostalCode: "62704", Country: "US"},
+			LogoKey:    "acc-12345/logo.png",
+			PhotoKeys:  []string{"acc-12345/front.jpg"},
+			BrandColor: "#1A2B3C",
+		},
+	}
+	logo := &assets.Asset{Key: "acc-12345/logo.png", URL: "https://cdn.example.com/acc-12345/logo.png"}
+	photo := &assets.Asset{Key: "acc-12345/front.jpg", URL: "https://cdn.example.com/acc-12345/front.jpg"}
+
+	t.Run("Get resolves the logo and photos", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		resolver := new(mockAssetResolver)
+		handler := NewAppSyncHandler(mockRepo, WithAssetResolver(resolver))
+
+		mockRepo.On("Get", mock.Anything, "acc-12345", "loc-001").Return(&repository.LocationEnvelope{LocationID: "loc-001", Location: shop}, nil).Once()
+		resolver.On("Resolve", mock.Anything, "acc-12345/logo.png").Return(logo, nil).Once()
+		resolver.On("Resolve", mock.Anything, "acc-12345/front.jpg").Return(photo, nil).Once()
+
+		result, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "getLocation",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "locationId": "loc-001", "includeAssets": true}`),
+		})
+		require.NoError(t, err)
+		location := result.(map[string]interface{})
+		assert.Equal(t, ShopAssets{Logo: logo, Photos: []assets.Asset{*photo}}, location["assets"])
+		resolver.AssertExpectations(t)
+	})
+
+	t.Run("Get resolves a floor plan", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		resolver := new(mockAssetResolver)
+		handler := NewAppSyncHandler(mockRepo, WithAssetResolver(resolver))
+
+		dock := models.CoordinatesLocation{
+			LocationBase:      models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeCoordinates},
+			Coordinates:       models.Coordinates{Latitude: 39.7817, Longitude: -89.6501},
+			Building:          "Warehouse 3",
+			Floor:             "1",
+			IndoorCoordinates: &models.IndoorCoordinates{X: 120, Y: 45.5, FloorPlanKey: "acc-12345/warehouse-3.png"},
+		}
+		floorPlan := &assets.Asset{Key: "acc-12345/warehouse-3.png", URL: "https://cdn.example.com/acc-12345/warehouse-3.png"}
+		mockRepo.On("Get", mock.Anything, "acc-12345", "loc-002").Return(&repository.LocationEnvelope{LocationID: "loc-002", Location: dock}, nil).Once()
+		resolver.On("Resolve", mock.Anything, "acc-12345/warehouse-3.png").Return(floorPlan, nil).Once()
+
+		result, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "getLocation",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "locationId": "loc-002", "includeAssets": true}`),
+		})
+		require.NoError(t, err)
+		location := result.(map[string]interface{})
+		assert.Equal(t, floorPlan, location["floorPlan"])
+		assert.NotContains(t, location, "assets")
+		resolver.AssertExpectations(t)
+	})
+
+	t.Run("Assets are omitted unless requested", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		resolver := new(mockAssetResolver)
+		handler := NewAppSyncHandler(mockRepo, WithAssetResolver(resolver))
+
+		mockRepo.On("Get", mock.Anything, "acc-12345", "loc-001").Return(&repository.LocationEnvelope{LocationID: "loc-001", Location: shop}, nil).Once()
+
+		result, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "getLocation",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "locationId": "loc-001"}`),
+		})
+		require.NoError(t, err)
+		assert.NotContains(t, result.(map[string]interface{}), "assets")
+		resolver.AssertNotCalled(t, "Resolve", mock.Anything, mock.Anything)
+	})
+
+	t.Run("List resolves shop assets only", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		resolver := new(mockAssetResolver)
+		handler := NewAppSyncHandler(mockRepo, WithAssetResolver(resolver))
+
+		address := models.AddressLocation{
+			LocationBase: models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeAddress},
+			Address:      models.Address{StreetAddress: "1 Elm St", City: "Springfield", PostalCode: "62704", Country: "US"},
+		}
+		mockRepo.On("List", mock.Anything, "acc-12345", mock.AnythingOfType("*repository.ListOptions")).Return(&repository.ListResult{
+			Items: []repository.LocationEnvelope{{LocationID: "loc-001", Location: shop}, {LocationID: "loc-002", Location: address}},
+		}, nil).Once()
+		resolver.On("Resolve", mock.Anything, "acc-12345/logo.png").Return(logo, nil).Once()
+		resolver.On("Resolve", mock.Anything, "acc-12345/front.jpg").Return(photo, nil).Once()
+
+		result, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "listLocations",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "includeAssets": true}`),
+		})
+		require.NoError(t, err)
+		response := result.(*ListLocationsResponse)
+		require.Len(t, response.Locations, 2)
+		assert.Contains(t, response.Locations[0], "assets")
+		assert.NotContains(t, response.Locations[1], "assets")
+	})
+
+	t.Run("Resolver errors are returned", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		resolver := new(mockAssetResolver)
+		handler := NewAppSyncHandler(mockRepo, WithAssetResolver(resolver))
+
+		mockRepo.On("Get", mock.Anything, "acc-12345", "loc-001").Return(&repository.LocationEnvelope{LocationID: "loc-001", Location: shop}, nil).Once()
+		resolver.On("Resolve", mock.Anything, "acc-12345/logo.png").Return(nil, errors.New("no credentials")).Once()
+
+		_, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "getLocation",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "locationId": "loc-001", "includeAssets": true}`),
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to resolve logo: no credentials")
+	})
+
+	t.Run("Not configured", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		handler := NewAppSyncHandler(mockRepo)
+
+		_, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "getLocation",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "locationId": "loc-001", "includeAssets": true}`),
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "asset URLs are not configured")
+		mockRepo.AssertNotCalled(t, "Get", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/steverhoton/location-lambda/internal/repository"
+)
+
+// AssignNearestArguments represents arguments for assigning points to their nearest shops.
+type AssignNearestArguments struct {
+	AccountID string                       `json:"accountId"`
+	Points    []repository.AssignmentPoint `json:"points"`
+	// Category limits the shops to those listed under it
+	Category string `json:"category,omitempty"`
+	// MaxDistanceMeters leaves points with no shop that close unassigned
+	MaxDistanceMeters *float64 `json:"maxDistanceMeters,omitempty"`
+}
+
+// WithNearestShopAssigner enables assignNearest.
+func WithNearestShopAssigner(assigner repository.NearestShopAssigner) Option {
+	return func(h *AppSyncHandler) {
+		h.assigner = assigner
+	}
+}
+
+// handleAssignNearest returns the nearest shop to each of a batch of points,
+// such as orders to route, in the order of the points.
+func (h *AppSyncHandler) handleAssignNearest(ctx context.Context, arguments json.RawMessage) ([]repository.Assignment, error) {
+	var args AssignNearestArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+
+	if args.AccountID == "" {
+		return nil, fmt.Errorf("accountId is required")
+	}
+	if h.assigner == nil {
+		return nil, fmt.Errorf("nearest shop assignment is not configured")
+	}
+
+	assignments, err := h.assigner.AssignNearestShops(ctx, args.AccountID, args.Points, args.Category, args.MaxDistanceMeters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to assign points: %w", err)
+	}
+	return assignments, nil
+}
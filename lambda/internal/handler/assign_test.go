@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/steverhoton/location-lambda/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockNearestShopAssigner is a mock implementation of the repository.NearestShopAssigner interface.
+type mockNearestShopAssigner struct {
+	mock.Mock
+}
+
+func (m *mockNearestShopAssigner) AssignNearestShops(ctx context.Context, accountID string, points []repository.AssignmentPoint, category string, maxDistanceMeters *float64) ([]repository.Assignment, error) {
+	args := m.Called(ctx, accountID, points, category, maxDistanceMeters)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repository.Assignment), args.Error(1)
+}
+
+func TestAppSyncHandlerAssignNearest(t *testing.T) {
+	ctx := context.Background()
+	event := AppSyncEvent{
+		Field: "assignNearest",
+		Arguments: json.RawMessage(`{
+			"accountId": "acc-12345",
+			"points": [{"id": "order-1", "lat": 39.80, "lon": -89.64}, {"id": "order-2", "lat": 41.88, "lon": -87.63}],
+			"category": "grocery",
+			"maxDistanceMeters": 50000
+		}`),
+	}
+	points := []repository.AssignmentPoint{{ID: "order-1", Lat: 39.80, Lon: -89.64}, {ID: "order-2", Lat: 41.88, Lon: -87.63}}
+	maxDistance := 50000.0
+
+	t.Run("Returns an assignment per point", func(t *testing.T) {
+		assigner := new(mockNearestShopAssigner)
+		handler := NewAppSyncHandler(new(mockRepository), WithNearestShopAssigner(assigner))
+
+		shopID, distance := "shop-springfield", 2204.5
+		assignments := []repository.Assignment{{ID: "order-1", LocationID: &shopID, DistanceMeters: &distance}, {ID: "order-2"}}
+		assigner.On("AssignNearestShops", mock.Anything, "acc-12345", points, "grocery", &maxDistance).Return(assignments, nil).Once()
+
+		result, err := handler.Handle(ctx, event)
+		require.NoError(t, err)
+		assert.Equal(t, assignments, result)
+
+		encoded, err := json.Marshal(result)
+		require.NoError(t, err)
+		assert.JSONEq(t, `[
+			{"id": "order-1", "locationId": "shop-springfield", "distanceMeters": 2204.5},
+			{"id": "order-2", "locationId": null, "distanceMeters": null}
+		]`, string(encoded))
+	})
+
+	t.Run("Repository failure", func(t *testing.T) {
+		assigner := new(mockNearestShopAssigner)
+		handler := NewAppSyncHandler(new(mockRepository), WithNearestShopAssigner(assigner))
+
+		assigner.On("AssignNearestShops", mock.Anything, "acc-12345", points, "grocery", &maxDistance).Return(nil, errors.New("validation failed: points[1]: duplicate id \"a\"")).Once()
+
+		_, err := handler.Handle(ctx, event)
+		assert.EqualError(t, err, `failed to assign points: validation failed: points[1]: duplicate id "a"`)
+	})
+
+	t.Run("Not configured", func(t *testing.T) {
+		_, err := NewAppSyncHandler(new(mockRepository)).Handle(ctx, event)
+		assert.EqualError(t, err, "nearest shop assignment is not configured")
+	})
+}
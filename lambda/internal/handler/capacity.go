@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/steverhoton/location-lambda/internal/repository"
+)
+
+// DefaultCapacityNamespace is the CloudWatch namespace of capacity metrics
+// when none is configured.
+const DefaultCapacityNamespace = "LocationService"
+
+// capacityMetrics writes each operation's consumed capacity to out in
+// CloudWatch embedded metric format.
+type capacityMetrics struct {
+	out       io.Writer
+	namespace string
+}
+
+// WithCapacityMetrics reports the DynamoDB capacity each operation consumes
+// as a CloudWatch embedded metric format line on out, normally stdout, with
+// the operation as the metrics' dimension and the account as a property for
+// per-tenant cost queries in Logs Insights. The repository's client must come
+// from repository.NewCapacityClient for there to be anything to report.
+func WithCapacityMetrics(out io.Writer, namespace string) Option {
+	return func(h *AppSyncHandler) {
+		h.capacity = &capacityMetrics{out: out, namespace: namespace}
+	}
+}
+
+// trackCapacity returns a context that totals the capacity consumed by the
+// event's operation, and a function reporting it once the operation is done.
+func (h *AppSyncHandler) trackCapacity(ctx context.Context, event AppSyncEvent) (context.Context, func()) {
+	if h.capacity == nil {
+		return ctx, func() {}
+	}
+	ctx, usage := repository.WithCapacityUsage(ctx)
+	return ctx, func() {
+		if usage.Calls() == 0 {
+			return
+		}
+		// Operations without an account, such as healthCheck, are still counted per operation
+		accountID, _ := mutatedAccountID(event.Arguments)
+		if err := h.capacity.write(h.clock.Now(), event.Field, accountID, usage); err != nil {
+			log.Printf("WARN: Failed to report consumed capacity of %s: %v", event.Field, err)
+		}
+	}
+}
+
+// write emits one operation's consumed capacity.
+func (m *capacityMetrics) write(now time.Time, operation, accountID string, usage *repository.CapacityUsage) error {
+	line, err := json.Marshal(map[string]interface{}{
+		"_aws": map[string]interface{}{
+			"Timestamp": now.UnixMilli(),
+			"CloudWatchMetrics": []map[string]interface{}{{
+				"Namespace":  m.namespace,
+				"Dimensions": [][]string{{"Operation"}},
+				"Metrics": []map[string]string{
+					{"Name": "ReadCapacityUnits", "Unit": "Count"},
+					{"Name": "WriteCapacityUnits", "Unit": "Count"},
+				},
+			}},
+		},
+		"Operation":          operation,
+		"accountId":          accountID,
+		"ReadCapacityUnits":  usage.ReadCapacityUnits(),
+		"WriteCapacityUnits": usage.WriteCapacityUnits(),
+		"dynamoDBCalls":      usage.Calls(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal capacity metrics: %w", err)
+	}
+	_, err = fmt.Fprintln(m.out, string(line))
+	return err
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/steverhoton/location-lambda/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// capacityStubClient answers GetItem with a stored location and the capacity
+// it consumed; its other operations are not used.
+type capacityStubClient struct {
+	repository.DynamoDBClient
+}
+
+func (c *capacityStubClient) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	return &dynamodb.GetItemOutput{
+		Item: map[string]types.AttributeValue{
+			"PK":           &types.AttributeValueMemberS{Value: "acc-12345"},
+			"SK":           &types.AttributeValueMemberS{Value: "loc-001"},
+			"locationType": &types.AttributeValueMemberS{Value: "coordinates"},
+			"coordinates": &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+				"latitude":  &types.AttributeValueMemberN{Value: "40.7128"},
+				"longitude": &types.AttributeValueMemberN{Value: "-74.006"},
+			}},
+		},
+		ConsumedCapacity: &types.ConsumedCapacity{CapacityUnits: aws.Float64(0.5)},
+	}, nil
+}
+
+func TestCapacityMetrics(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewDynamoDBRepository(repository.NewCapacityClient(&capacityStubClient{}), "test-table")
+
+	t.Run("Reports an operation's capacity", func(t *testing.T) {
+		var out bytes.Buffer
+		h := NewAppSyncHandler(repo, WithCapacityMetrics(&out, "Locations"))
+
+		_, err := h.Handle(ctx, AppSyncEvent{
+			Field:     "getLocation",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "locationId": "loc-001"}`),
+		})
+		require.NoError(t, err)
+
+		var line map[string]interface{}
+		require.NoError(t, json.Unmarshal(out.Bytes(), &line))
+		assert.Equal(t, "getLocation", line["Operation"])
+		assert.Equal(t, "acc-12345", line["accountId"])
+		assert.Equal(t, 0.5, line["ReadCapacityUnits"])
+		assert.Equal(t, 0.0, line["WriteCapacityUnits"])
+		metrics := line["_aws"].(map[string]interface{})["CloudWatchMetrics"].([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, "Locations", metrics["Namespace"])
+		assert.Equal(t, []interface{}{[]interface{}{"Operation"}}, metrics["Dimensions"])
+	})
+
+	t.Run("Reports nothing for operations without DynamoDB calls", func(t *testing.T) {
+		var out bytes.Buffer
+		h := NewAppSyncHandler(repo, WithCapacityMetrics(&out, "Locations"))
+
+		_, err := h.Handle(ctx, AppSyncEvent{Field: "parseAddress", Arguments: json.RawMessage(`{"text": "1 Main St, Springfield"}`)})
+		require.NoError(t, err)
+		assert.Empty(t, out.String())
+	})
+}
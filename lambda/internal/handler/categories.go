@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+)
+
+// validateCategories checks a shop's categories against its account's
+// taxonomy. Without account settings, the default NAICS taxonomy applies.
+func (h *AppSyncHandler) validateCategories(ctx context.Context, location models.Location) error {
+	shop, ok := location.(models.ShopLocation)
+	if !ok || len(shop.Shop.Categories) == 0 {
+		return nil
+	}
+
+	settings := models.DefaultAccountSettings(shop.AccountID)
+	if h.settings != nil {
+		stored, err := h.settings.GetAccountSettings(ctx, shop.AccountID)
+		if err != nil {
+			return fmt.Errorf("failed to get account settings: %w", err)
+		}
+		settings = *stored
+	}
+
+	if err := settings.ValidateCategories(shop.Shop.Categories); err != nil {
+		return fmt.Errorf("validation failed: %w", err)
+	}
+	return nil
+}
+
+// handleListLocationsByCategory lists an account's shops in a category, in
+// the same shape and with the same paging as listLocations.
+func (h *AppSyncHandler) handleListLocationsByCategory(ctx context.Context, event AppSyncEvent) (*ListLocationsResponse, error) {
+	var args ListLocationsArguments
+	if err := json.Unmarshal(event.Arguments, &args); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+	if args.Category == "" {
+		return nil, fmt.Errorf("category is required")
+	}
+	return h.handleListLocations(ctx, event)
+}
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppSyncHandlerValidateCategories(t *testing.T) {
+	ctx := context.Background()
+	shop := func(categories ...string) models.ShopLocation {
+		return models.ShopLocation{
+			LocationBase: models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeShop},
+			Shop:         models.Shop{Name: "Main Street Store", Categories: categories},
+		}
+	}
+
+	t.Run("Defaults to NAICS without a settings store", func(t *testing.T) {
+		handler := NewAppSyncHandler(new(mockRepository))
+
+		assert.NoError(t, handler.validateCategories(ctx, shop("722515", "44")))
+		err := handler.validateCategories(ctx, shop("coffee"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "validation failed")
+		assert.Contains(t, err.Error(), `category "coffee" must be a NAICS code`)
+	})
+
+	t.Run("Checks the account's custom taxonomy", func(t *testing.T) {
+		store := new(mockSettingsStore)
+		handler := NewAppSyncHandler(new(mockRepository), WithSettingsStore(store))
+
+		settings := models.DefaultAccountSettings("acc-12345")
+		settings.CategoryTaxonomy = models.CategoryTaxonomyCustom
+		settings.Categories = []string{"coffee", "bakery"}
+		store.On("GetAccountSettings", ctx, "acc-12345").Return(&settings, nil).Twice()
+
+		assert.NoError(t, handler.validateCategories(ctx, shop("bakery")))
+		err := handler.validateCategories(ctx, shop("coffee", "florist"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `category "florist" is not in the account's categories`)
+		store.AssertExpectations(t)
+	})
+
+	t.Run("Skips shops without categories", func(t *testing.T) {
+		store := new(mockSettingsStore)
+		handler := NewAppSyncHandler(new(mockRepository), WithSettingsStore(store))
+
+		assert.NoError(t, handler.validateCategories(ctx, shop()))
+		store.AssertNotCalled(t, "GetAccountSettings", mock.Anything, mock.Anything)
+	})
+}
+
+func TestAppSyncHandlerListLocationsByCategory(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Filters the list by category", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		handler := NewAppSyncHandler(mockRepo)
+
+		mockRepo.On("List", ctx, "acc-12345", mock.MatchedBy(func(options *repository.ListOptions) bool {
+			return options.Category == "722515" && options.Limit != nil && *options.Limit == 10
+		})).Return(&repository.ListResult{Items: []repository.LocationEnvelope{}}, nil).Once()
+
+		result, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "listLocationsByCategory",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "category": "722515", "limit": 10}`),
+		})
+		require.NoError(t, err)
+		assert.IsType(t, &ListLocationsResponse{}, result)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Requires a category", func(t *testing.T) {
+		handler := NewAppSyncHandler(new(mockRepository))
+
+		_, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "listLocationsByCategory",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345"}`),
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "category is required")
+	})
+}
@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// payloadEncodingHeader is the request header through which clients accept
+// compressed list pages. Accept-Encoding cannot be used: browsers always send
+// it and undo only the transport compression it negotiates.
+const payloadEncodingHeader = "x-accept-payload-encoding"
+
+// EncodingGzip marks a list page whose locations are gzip-compressed.
+const EncodingGzip = "gzip"
+
+// WithResponseCompression gzip-compresses list pages whose locations encode to
+// more than thresholdBytes of JSON, for clients that accept it. This keeps
+// large pages within the AppSync response size limit.
+func WithResponseCompression(thresholdBytes int) Option {
+	return func(h *AppSyncHandler) {
+		h.compressionThreshold = thresholdBytes
+	}
+}
+
+// acceptsGzip reports whether the request accepts gzip-compressed payloads.
+func acceptsGzip(request AppSyncRequest) bool {
+	for name, value := range request.Headers {
+		if !strings.EqualFold(name, payloadEncodingHeader) {
+			continue
+		}
+		for _, encoding := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(encoding), EncodingGzip) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// compressLocations replaces the locations of a large page with their
+// base64-encoded gzip JSON when compression is enabled and accepted.
+func (h *AppSyncHandler) compressLocations(request AppSyncRequest, response *ListLocationsResponse) error {
+	if h.compressionThreshold <= 0 || !acceptsGzip(request) {
+		return nil
+	}
+
+	body, err := json.Marshal(response.Locations)
+	if err != nil {
+		return fmt.Errorf("failed to marshal locations: %w", err)
+	}
+	if len(body) <= h.compressionThreshold {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(body); err != nil {
+		return fmt.Errorf("failed to compress locations: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to compress locations: %w", err)
+	}
+
+	response.Locations = []map[string]interface{}{}
+	response.Encoding = EncodingGzip
+	response.CompressedLocations = base64.StdEncoding.EncodeToString(buf.Bytes())
+	return nil
+}
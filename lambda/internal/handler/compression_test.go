@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// decompressLocations decodes the compressed locations of a list page.
+func decompressLocations(t *testing.T, response *ListLocationsResponse) []map[string]interface{} {
+	t.Helper()
+	compressed, err := base64.StdEncoding.DecodeString(response.CompressedLocations)
+	require.NoError(t, err)
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	require.NoError(t, err)
+	body, err := io.ReadAll(zr)
+	require.NoError(t, err)
+
+	var locations []map[string]interface{}
+	require.NoError(t, json.Unmarshal(body, &locations))
+	return locations
+}
+
+func TestAppSyncHandlerListLocationsCompression(t *testing.T) {
+	ctx := context.Background()
+	page := &repository.ListResult{
+		Items: []repository.LocationEnvelope{{
+			LocationID: "loc-001",
+			Location: models.CoordinatesLocation{
+				LocationBase: models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeCoordinates},
+				Coordinates:  models.Coordinates{Latitude: 40.7128, Longitude: -74.006},
+			},
+		}},
+	}
+	listEvent := func(headers map[string]string) AppSyncEvent {
+		return AppSyncEvent{
+			Field:     "listLocations",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345"}`),
+			Request:   AppSyncRequest{Headers: headers},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		threshold  int
+		headers    map[string]string
+		compressed bool
+	}{
+		{name: "Compresses large pages for clients that accept gzip", threshold: 10, headers: map[string]string{"x-accept-payload-encoding": "gzip"}, compressed: true},
+		{name: "Matches the header and encoding case-insensitively", threshold: 10, headers: map[string]string{"X-Accept-Payload-Encoding": "br, GZIP"}, compressed: true},
+		{name: "Leaves pages under the threshold", threshold: 100000, headers: map[string]string{"x-accept-payload-encoding": "gzip"}},
+		{name: "Ignores transport Accept-Encoding", threshold: 10, headers: map[string]string{"accept-encoding": "gzip"}},
+		{name: "Disabled by a zero threshold", threshold: 0, headers: map[string]string{"x-accept-payload-encoding": "gzip"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(mockRepository)
+			handler := NewAppSyncHandler(mockRepo, WithResponseCompression(tt.threshold))
+			mockRepo.On("List", ctx, "acc-12345", mock.Anything).Return(page, nil).Once()
+
+			result, err := handler.Handle(ctx, listEvent(tt.headers))
+			require.NoError(t, err)
+			response := result.(*ListLocationsResponse)
+
+			if !tt.compressed {
+				assert.Empty(t, response.Encoding)
+				assert.Len(t, response.Locations, 1)
+				return
+			}
+			assert.Equal(t, EncodingGzip, response.Encoding)
+			assert.Empty(t, response.Locations)
+			locations := decompressLocations(t, response)
+			require.Len(t, locations, 1)
+			assert.Equal(t, "loc-001", locations[0]["locationId"])
+			assert.Equal(t, "CoordinatesLocation", locations[0]["__typename"])
+		})
+	}
+}
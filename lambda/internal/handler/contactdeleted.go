@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/notify"
+	"github.com/steverhoton/location-lambda/internal/repository"
+)
+
+// ContactDeletedArguments represents a ContactDeleted event from the contacts service.
+type ContactDeletedArguments struct {
+	AccountID string `json:"accountId"`
+	ContactID string `json:"contactId"`
+}
+
+// ContactDeletedReport lists the shops a deleted contact was removed from.
+type ContactDeletedReport struct {
+	AccountID string `json:"accountId"`
+	ContactID string `json:"contactId"`
+	// Flagged shops had the contact as their primary contact and now carry contactDeleted
+	Flagged []string `json:"flagged"`
+	// Notified is set when the account's webhook was told about the shops
+	Notified bool `json:"notified"`
+}
+
+// WithNotifier lets contactDeleted tell account administrators about the
+// shops it changed, through the webhookUrl in their account settings.
+func WithNotifier(notifier notify.Notifier) Option {
+	return func(h *AppSyncHandler) {
+		h.notifier = notifier
+	}
+}
+
+// handleContactDeleted flags the shops whose primary contact the contacts
+// service deleted, found with the contact index. Each keeps its contactId,
+// which a shop needs, with contactDeleted set for an administrator to replace
+// it, and the contact is dropped from any of its roles. Shops listing the
+// contact only in a role are not indexed, so are not found. It is invoked
+// by an EventBridge rule rather than through the API, so it is not in the
+// GraphQL schema. Shops already flagged are not written again, so a retried
+// event only repeats the notification.
+func (h *AppSyncHandler) handleContactDeleted(ctx context.Context, arguments json.RawMessage) (*ContactDeletedReport, error) {
+	var args ContactDeletedArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+
+	if args.AccountID == "" || args.ContactID == "" {
+		return nil, fmt.Errorf("accountId and contactId are required")
+	}
+	if h.contacts == nil {
+		return nil, fmt.Errorf("contact search is not configured")
+	}
+
+	findCtx, readInfo := repository.WithReadInfo(ctx)
+	envelopes, err := h.contacts.FindByContactID(findCtx, args.AccountID, args.ContactID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find locations: %w", err)
+	}
+	// A bounded scan standing in for the contact index can miss shops, so
+	// fail for the event to be retried rather than leave them unflagged
+	if readInfo.IndexFallback {
+		return nil, fmt.Errorf("failed to find locations: contact index is unavailable")
+	}
+
+	report := &ContactDeletedReport{AccountID: args.AccountID, ContactID: args.ContactID, Flagged: []string{}}
+	for _, envelope := range envelopes {
+		shop, ok := envelope.Location.(models.ShopLocation)
+		// The index is eventually consistent, so it can still list a shop
+		// whose contact was just replaced
+		if !ok || shop.Shop.ContactID != args.ContactID {
+			continue
+		}
+		unlisted := shop.Shop.RemoveContact(args.ContactID, "")
+		if !shop.Shop.ContactDeleted || unlisted {
+			shop.Shop.ContactDeleted = true
+			if err := h.repo.Update(ctx, shop, envelope.LocationID); err != nil {
+				return nil, fmt.Errorf("failed to update location %s: %w", envelope.LocationID, err)
+			}
+		}
+		report.Flagged = append(report.Flagged, envelope.LocationID)
+	}
+	if len(report.Flagged) == 0 {
+		return report, nil
+	}
+
+	notified, err := h.notifyAccount(ctx, notify.Notice{
+		Type:        notify.NoticeContactDeleted,
+		AccountID:   args.AccountID,
+		ContactID:   args.ContactID,
+		LocationIDs: report.Flagged,
+		Message:     fmt.Sprintf("contact %s was deleted; %d shops need a new primary contact", args.ContactID, len(report.Flagged)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	report.Notified = notified
+	return report, nil
+}
+
+// notifyAccount sends notice to the account's webhook and reports whether
+// there was one to send it to.
+func (h *AppSyncHandler) notifyAccount(ctx context.Context, notice notify.Notice) (bool, error) {
+	if h.notifier == nil || h.settings == nil {
+		log.Printf("WARN: Not notifying account %s of %s: notifications are not configured", notice.AccountID, notice.Type)
+		return false, nil
+	}
+	settings, err := h.settings.GetAccountSettings(ctx, notice.AccountID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get account settings: %w", err)
+	}
+	if settings.WebhookURL == "" {
+		return false, nil
+	}
+
+	notice.SentAt = h.clock.Now().UTC()
+	if err := h.notifier.Notify(ctx, settings.WebhookURL, notice); err != nil {
+		return false, fmt.Errorf("failed to notify account %s: %w", notice.AccountID, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/notify"
+	"github.com/steverhoton/location-lambda/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockNotifier is a mock implementation of the notify.Notifier interface.
+type mockNotifier struct {
+	mock.Mock
+}
+
+func (m *mockNotifier) Notify(ctx context.Context, webhookURL string, notice notify.Notice) error {
+	args := m.Called(ctx, webhookURL, notice)
+	return args.Error(0)
+}
+
+func TestAppSyncHandlerContactDeleted(t *testing.T) {
+	ctx := context.Background()
+	event := AppSyncEvent{Field: "contactDeleted", Arguments: json.RawMessage(`{"accountId": "acc-12345", "contactId": "contact-1"}`)}
+	shop := func(modify func(*models.Shop)) models.ShopLocation {
+		location := models.ShopLocation{
+			LocationBase: models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeShop},
+			Shop: models.Shop{
+				Name:      "Corner Store",
+				ContactID: "contact-1",
+				Address:   models.Address{StreetAddress: "1 Main St", City: "Springfield", StateProvince: "IL", PostalCode: "62701", Country: "US"},
+			},
+		}
+		modify(&location.Shop)
+		return location
+	}
+	settings := &models.AccountSettings{AccountID: "acc-12345", WebhookURL: "https://hooks.example.com/locations"}
+
+	t.Run("Flags shops and notifies the account", func(t *testing.T) {
+		repo, finder, store, notifier := new(mockRepository), new(mockContactFinder), new(mockSettingsStore), new(mockNotifier)
+		handler := NewAppSyncHandler(repo, WithContactFinder(finder), WithSettingsStore(store), WithNotifier(notifier))
+
+		manager := models.ShopContact{ContactID: "contact-1", Role: models.ShopContactRoleManager}
+		billing := models.ShopContact{ContactID: "contact-2", Role: models.ShopContactRoleBilling}
+		finder.On("FindByContactID", mock.Anything, "acc-12345", "contact-1").Return([]repository.LocationEnvelope{
+			{LocationID: "loc-001", Location: shop(func(s *models.Shop) { s.Contacts = []models.ShopContact{manager, billing} })},
+			{LocationID: "loc-002", Location: shop(func(s *models.Shop) { s.ContactID = "contact-3" })},
+		}, nil).Once()
+		repo.On("Update", ctx, shop(func(s *models.Shop) {
+			s.ContactDeleted = true
+			s.Contacts = []models.ShopContact{billing}
+		}), "loc-001").Return(nil).Once()
+		store.On("GetAccountSettings", ctx, "acc-12345").Return(settings, nil).Once()
+		notifier.On("Notify", ctx, settings.WebhookURL, mock.MatchedBy(func(notice notify.Notice) bool {
+			return notice.Type == notify.NoticeContactDeleted && notice.ContactID == "contact-1" &&
+				assert.ObjectsAreEqual([]string{"loc-001"}, notice.LocationIDs) && !notice.SentAt.IsZero()
+		})).Return(nil).Once()
+
+		result, err := handler.Handle(ctx, event)
+		require.NoError(t, err)
+		assert.Equal(t, &ContactDeletedReport{AccountID: "acc-12345", ContactID: "contact-1", Flagged: []string{"loc-001"}, Notified: true}, result)
+		repo.AssertExpectations(t)
+		notifier.AssertExpectations(t)
+	})
+
+	t.Run("Retried event does not write again", func(t *testing.T) {
+		repo, finder := new(mockRepository), new(mockContactFinder)
+		handler := NewAppSyncHandler(repo, WithContactFinder(finder))
+
+		finder.On("FindByContactID", mock.Anything, "acc-12345", "contact-1").Return([]repository.LocationEnvelope{
+			{LocationID: "loc-001", Location: shop(func(s *models.Shop) { s.ContactDeleted = true })},
+		}, nil).Once()
+
+		result, err := handler.Handle(ctx, event)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"loc-001"}, result.(*ContactDeletedReport).Flagged)
+		assert.False(t, result.(*ContactDeletedReport).Notified)
+		repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Account without a webhook", func(t *testing.T) {
+		repo, finder, store, notifier := new(mockRepository), new(mockContactFinder), new(mockSettingsStore), new(mockNotifier)
+		handler := NewAppSyncHandler(repo, WithContactFinder(finder), WithSettingsStore(store), WithNotifier(notifier))
+
+		finder.On("FindByContactID", mock.Anything, "acc-12345", "contact-1").Return([]repository.LocationEnvelope{
+			{LocationID: "loc-001", Location: shop(func(*models.Shop) {})},
+		}, nil).Once()
+		repo.On("Update", ctx, mock.Anything, "loc-001").Return(nil).Once()
+		store.On("GetAccountSettings", ctx, "acc-12345").Return(&models.AccountSettings{AccountID: "acc-12345"}, nil).Once()
+
+		result, err := handler.Handle(ctx, event)
+		require.NoError(t, err)
+		assert.False(t, result.(*ContactDeletedReport).Notified)
+		notifier.AssertNotCalled(t, "Notify", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Failed notification fails the event", func(t *testing.T) {
+		repo, finder, store, notifier := new(mockRepository), new(mockContactFinder), new(mockSettingsStore), new(mockNotifier)
+		handler := NewAppSyncHandler(repo, WithContactFinder(finder), WithSettingsStore(store), WithNotifier(notifier))
+
+		finder.On("FindByContactID", mock.Anything, "acc-12345", "contact-1").Return([]repository.LocationEnvelope{
+			{LocationID: "loc-001", Location: shop(func(*models.Shop) {})},
+		}, nil).Once()
+		repo.On("Update", ctx, mock.Anything, "loc-001").Return(nil).Once()
+		store.On("GetAccountSettings", ctx, "acc-12345").Return(settings, nil).Once()
+		notifier.On("Notify", ctx, settings.WebhookURL, mock.Anything).Return(errors.New("webhook failed with status 503: try later")).Once()
+
+		_, err := handler.Handle(ctx, event)
+		assert.EqualError(t, err, "failed to notify account acc-12345: webhook failed with status 503: try later")
+	})
+
+	t.Run("Validation and configuration errors", func(t *testing.T) {
+		_, err := NewAppSyncHandler(new(mockRepository), WithContactFinder(new(mockContactFinder))).Handle(ctx, AppSyncEvent{Field: "contactDeleted", Arguments: json.RawMessage(`{"accountId": "acc-12345"}`)})
+		assert.EqualError(t, err, "accountId and contactId are required")
+
+		_, err = NewAppSyncHandler(new(mockRepository)).Handle(ctx, event)
+		assert.EqualError(t, err, "contact search is not configured")
+	})
+}
+
+func TestWithoutProviderDataClearsContactDeleted(t *testing.T) {
+	location := withoutProviderData(models.ShopLocation{Shop: models.Shop{ContactID: "contact-1", ContactDeleted: true}})
+	assert.False(t, location.(models.ShopLocation).Shop.ContactDeleted)
+}
@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/repository"
+)
+
+// AddShopContactArguments represents arguments for adding a contact to a shop.
+type AddShopContactArguments struct {
+	AccountID  string             `json:"accountId"`
+	LocationID string             `json:"locationId"`
+	Contact    models.ShopContact `json:"contact"`
+}
+
+// RemoveShopContactArguments represents arguments for removing a contact from
+// a shop. Without a role, the contact is removed from every role.
+type RemoveShopContactArguments struct {
+	AccountID  string                 `json:"accountId"`
+	LocationID string                 `json:"locationId"`
+	ContactID  string                 `json:"contactId"`
+	Role       models.ShopContactRole `json:"role,omitempty"`
+}
+
+// ListLocationsByContactIDArguments represents arguments for listing the shops attached to a contact.
+type ListLocationsByContactIDArguments struct {
+	AccountID    string `json:"accountId"`
+	ContactID    string `json:"contactId"`
+	IncludeLinks bool   `json:"includeLinks,omitempty"`
+}
+
+// WithContactFinder enables listLocationsByContactId.
+func WithContactFinder(finder repository.ContactFinder) Option {
+	return func(h *AppSyncHandler) {
+		h.contacts = finder
+	}
+}
+
+// handleListLocationsByContactID returns the shops whose primary contact is
+// the given contact, in the same shape as getLocation.
+func (h *AppSyncHandler) handleListLocationsByContactID(ctx context.Context, arguments json.RawMessage) ([]map[string]interface{}, error) {
+	var args ListLocationsByContactIDArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+
+	if args.AccountID == "" || args.ContactID == "" {
+		return nil, fmt.Errorf("accountId and contactId are required")
+	}
+	if h.contacts == nil {
+		return nil, fmt.Errorf("contact search is not configured")
+	}
+
+	ctx, readInfo := repository.WithReadInfo(ctx)
+	envelopes, err := h.contacts.FindByContactID(ctx, args.AccountID, args.ContactID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find locations: %w", err)
+	}
+
+	locations := make([]map[string]interface{}, 0, len(envelopes))
+	for _, envelope := range envelopes {
+		location, err := toLocationMap(envelope, args.IncludeLinks)
+		if err != nil {
+			return nil, err
+		}
+		if readInfo.IndexFallback {
+			location["indexFallback"] = true
+		}
+		locations = append(locations, location)
+	}
+	return locations, nil
+}
+
+// handleAddShopContact lists a contact on a stored shop and returns the shop's contacts.
+func (h *AppSyncHandler) handleAddShopContact(ctx context.Context, arguments json.RawMessage) ([]models.ShopContact, error) {
+	var args AddShopContactArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+
+	return h.updateShopContacts(ctx, args.AccountID, args.LocationID, func(shop *models.Shop) error {
+		return shop.AddContact(args.Contact)
+	})
+}
+
+// handleRemoveShopContact removes a contact from a stored shop and returns the shop's contacts.
+func (h *AppSyncHandler) handleRemoveShopContact(ctx context.Context, arguments json.RawMessage) ([]models.ShopContact, error) {
+	var args RemoveShopContactArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+	if args.ContactID == "" {
+		return nil, fmt.Errorf("contactId is required")
+	}
+
+	return h.updateShopContacts(ctx, args.AccountID, args.LocationID, func(shop *models.Shop) error {
+		if !shop.RemoveContact(args.ContactID, args.Role) {
+			if args.Role != "" {
+				return fmt.Errorf("contact %s is not listed as %s", args.ContactID, args.Role)
+			}
+			return fmt.Errorf("contact %s is not listed", args.ContactID)
+		}
+		return nil
+	})
+}
+
+// updateShopContacts applies change to a stored shop's contacts and saves it,
+// so contacts can be edited without resending the whole location.
+func (h *AppSyncHandler) updateShopContacts(ctx context.Context, accountID, locationID string, change func(*models.Shop) error) ([]models.ShopContact, error) {
+	if accountID == "" || locationID == "" {
+		return nil, fmt.Errorf("accountId and locationId are required")
+	}
+
+	envelope, err := h.repo.Get(ctx, accountID, locationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get location: %w", err)
+	}
+	shop, ok := envelope.Location.(models.ShopLocation)
+	if !ok {
+		return nil, fmt.Errorf("only shop locations have contacts, got %s", envelope.Location.GetLocationType())
+	}
+
+	if err := change(&shop.Shop); err != nil {
+		return nil, err
+	}
+	if err := h.repo.Update(ctx, shop, locationID); err != nil {
+		return nil, fmt.Errorf("failed to update location: %w", err)
+	}
+
+	if shop.Shop.Contacts == nil {
+		return []models.ShopContact{}, nil
+	}
+	return shop.Shop.Contacts, nil
+}
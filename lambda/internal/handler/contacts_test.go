@@ -0,0 +1,200 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppSyncHandlerShopContacts(t *testing.T) {
+	ctx := context.Background()
+	manager := models.ShopContact{ContactID: "contact-2", Role: models.ShopContactRoleManager}
+	billing := models.ShopContact{ContactID: "contact-3", Role: models.ShopContactRoleBilling}
+	shop := models.ShopLocation{
+		LocationBase: models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeShop},
+		Shop: models.Shop{
+			Name:      "Main Street Store",
+			ContactID: "contact-1",
+			Address:   models.Address{StreetAddress: "123 Main St", City: "Springfield", PostalCode: "62704", Country: "US"},
+			Contacts:  []models.ShopContact{manager},
+		},
+	}
+	withContacts := func(contacts ...models.ShopContact) interface{} {
+		return mock.MatchedBy(func(l models.Location) bool {
+			loc, ok := l.(models.ShopLocation)
+			return ok && assert.ObjectsAreEqual(contacts, loc.Shop.Contacts)
+		})
+	}
+
+	tests := []struct {
+		name      string
+		field     string
+		arguments string
+		update    interface{}
+		want      []models.ShopContact
+		errMsg    string
+	}{
+		{
+			name:      "Adds a contact",
+			field:     "addShopContact",
+			arguments: `{"accountId": "acc-12345", "locationId": "loc-001", "contact": {"contactId": "contact-3", "role": "billing"}}`,
+			update:    withContacts(manager, billing),
+			want:      []models.ShopContact{manager, billing},
+		},
+		{
+			name:      "Rejects a contact already in the role",
+			field:     "addShopContact",
+			arguments: `{"accountId": "acc-12345", "locationId": "loc-001", "contact": {"contactId": "contact-2", "role": "manager"}}`,
+			errMsg:    "contacts[1]: contact contact-2 is already listed as manager",
+		},
+		{
+			name:      "Rejects an unknown role",
+			field:     "addShopContact",
+			arguments: `{"accountId": "acc-12345", "locationId": "loc-001", "contact": {"contactId": "contact-3", "role": "owner"}}`,
+			errMsg:    `contacts[1]: role must be one of manager, billing, or emergency, got "owner"`,
+		},
+		{
+			name:      "Removes a contact",
+			field:     "removeShopContact",
+			arguments: `{"accountId": "acc-12345", "locationId": "loc-001", "contactId": "contact-2"}`,
+			update:    withContacts(),
+			want:      []models.ShopContact{},
+		},
+		{
+			name:      "Rejects removing a role the contact does not hold",
+			field:     "removeShopContact",
+			arguments: `{"accountId": "acc-12345", "locationId": "loc-001", "contactId": "contact-2", "role": "billing"}`,
+			errMsg:    "contact contact-2 is not listed as billing",
+		},
+		{
+			name:      "Rejects removing an unknown contact",
+			field:     "removeShopContact",
+			arguments: `{"accountId": "acc-12345", "locationId": "loc-001", "contactId": "contact-9"}`,
+			errMsg:    "contact contact-9 is not listed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(mockRepository)
+			handler := NewAppSyncHandler(mockRepo)
+
+			mockRepo.On("Get", ctx, "acc-12345", "loc-001").Return(withID("loc-001", shop), nil).Once()
+			if tt.update != nil {
+				mockRepo.On("Update", ctx, tt.update, "loc-001").Return(nil).Once()
+			}
+
+			result, err := handler.Handle(ctx, AppSyncEvent{Field: tt.field, Arguments: json.RawMessage(tt.arguments)})
+			if tt.errMsg != "" {
+				assert.EqualError(t, err, tt.errMsg)
+				mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, result)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+
+	t.Run("Only shops have contacts", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		handler := NewAppSyncHandler(mockRepo)
+
+		mockRepo.On("Get", ctx, "acc-12345", "loc-001").Return(withID("loc-001", models.AddressLocation{
+			LocationBase: models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeAddress},
+		}), nil).Once()
+
+		_, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "addShopContact",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "locationId": "loc-001", "contact": {"contactId": "contact-3", "role": "billing"}}`),
+		})
+		assert.EqualError(t, err, "only shop locations have contacts, got address")
+	})
+
+	t.Run("Requires the location", func(t *testing.T) {
+		handler := NewAppSyncHandler(new(mockRepository))
+
+		_, err := handler.Handle(ctx, AppSyncEvent{Field: "removeShopContact", Arguments: json.RawMessage(`{"contactId": "contact-2"}`)})
+		assert.EqualError(t, err, "accountId and locationId are required")
+	})
+}
+
+// mockContactFinder is a mock implementation of the repository.ContactFinder interface.
+type mockContactFinder struct {
+	mock.Mock
+}
+
+func (m *mockContactFinder) FindByContactID(ctx context.Context, accountID, contactID string) ([]repository.LocationEnvelope, error) {
+	args := m.Called(ctx, accountID, contactID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repository.LocationEnvelope), args.Error(1)
+}
+
+func TestAppSyncHandlerListLocationsByContactID(t *testing.T) {
+	ctx := context.Background()
+	shop := models.ShopLocation{
+		LocationBase: models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeShop},
+		Shop: models.Shop{
+			Name:      "Main Street Store",
+			ContactID: "contact-1",
+			Address:   models.Address{StreetAddress: "123 Main St", City: "Springfield", PostalCode: "62704", Country: "US"},
+		},
+	}
+	event := AppSyncEvent{
+		Field:     "listLocationsByContactId",
+		Arguments: json.RawMessage(`{"accountId": "acc-12345", "contactId": "contact-1"}`),
+	}
+
+	t.Run("Returns the contact's shops", func(t *testing.T) {
+		finder := new(mockContactFinder)
+		handler := NewAppSyncHandler(new(mockRepository), WithContactFinder(finder))
+
+		finder.On("FindByContactID", mock.Anything, "acc-12345", "contact-1").Return([]repository.LocationEnvelope{
+			{LocationID: "loc-001", Location: shop},
+		}, nil).Once()
+
+		result, err := handler.Handle(ctx, event)
+		require.NoError(t, err)
+		locations := result.([]map[string]interface{})
+		require.Len(t, locations, 1)
+		assert.Equal(t, "loc-001", locations[0]["locationId"])
+		assert.Equal(t, "ShopLocation", locations[0]["__typename"])
+		finder.AssertExpectations(t)
+	})
+
+	t.Run("Returns an empty list for an unattached contact", func(t *testing.T) {
+		finder := new(mockContactFinder)
+		handler := NewAppSyncHandler(new(mockRepository), WithContactFinder(finder))
+
+		finder.On("FindByContactID", mock.Anything, "acc-12345", "contact-1").Return([]repository.LocationEnvelope{}, nil).Once()
+
+		result, err := handler.Handle(ctx, event)
+		require.NoError(t, err)
+		assert.Equal(t, []map[string]interface{}{}, result)
+	})
+
+	t.Run("Requires a configured finder", func(t *testing.T) {
+		handler := NewAppSyncHandler(new(mockRepository))
+
+		_, err := handler.Handle(ctx, event)
+		assert.EqualError(t, err, "contact search is not configured")
+	})
+
+	t.Run("Requires a contact", func(t *testing.T) {
+		handler := NewAppSyncHandler(new(mockRepository), WithContactFinder(new(mockContactFinder)))
+
+		_, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "listLocationsByContactId",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345"}`),
+		})
+		assert.EqualError(t, err, "accountId and contactId are required")
+	})
+}
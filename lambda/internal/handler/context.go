@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/weather"
+)
+
+// GetLocationContextArguments represents arguments for looking up a location's context.
+type GetLocationContextArguments struct {
+	AccountID  string `json:"accountId"`
+	LocationID string `json:"locationId"`
+}
+
+// LocationContextResponse is the current weather and daylight at a stored location.
+type LocationContextResponse struct {
+	LocationID  string              `json:"locationId"`
+	Coordinates models.Coordinates  `json:"coordinates"`
+	Weather     *weather.Conditions `json:"weather"`
+}
+
+// WithWeatherProvider enables location context lookups.
+func WithWeatherProvider(provider weather.Provider) Option {
+	return func(h *AppSyncHandler) {
+		h.weather = provider
+	}
+}
+
+func (h *AppSyncHandler) handleGetLocationContext(ctx context.Context, arguments json.RawMessage) (*LocationContextResponse, error) {
+	var args GetLocationContextArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+
+	if args.AccountID == "" || args.LocationID == "" {
+		return nil, fmt.Errorf("accountId and locationId are required")
+	}
+	if h.weather == nil {
+		return nil, fmt.Errorf("location context is not configured")
+	}
+
+	envelope, err := h.repo.Get(ctx, args.AccountID, args.LocationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get location: %w", err)
+	}
+	loc, ok := envelope.Location.(models.CoordinatesLocation)
+	if !ok {
+		return nil, fmt.Errorf("location has no stored coordinates")
+	}
+
+	conditions, err := h.weather.Current(ctx, loc.Coordinates)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up weather: %w", err)
+	}
+
+	return &LocationContextResponse{
+		LocationID:  args.LocationID,
+		Coordinates: loc.Coordinates,
+		Weather:     conditions,
+	}, nil
+}
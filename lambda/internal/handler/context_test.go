@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/weather"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockWeatherProvider is a mock implementation of the weather.Provider interface.
+type mockWeatherProvider struct {
+	mock.Mock
+}
+
+func (m *mockWeatherProvider) Current(ctx context.Context, coords models.Coordinates) (*weather.Conditions, error) {
+	args := m.Called(ctx, coords)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*weather.Conditions), args.Error(1)
+}
+
+func TestAppSyncHandlerGetLocationContext(t *testing.T) {
+	ctx := context.Background()
+	coords := models.Coordinates{Latitude: 40.7128, Longitude: -74.006}
+	event := AppSyncEvent{
+		Field:     "getLocationContext",
+		Arguments: json.RawMessage(`{"accountId": "acc-12345", "locationId": "loc-001"}`),
+	}
+
+	t.Run("Returns weather for stored coordinates", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		provider := new(mockWeatherProvider)
+		handler := NewAppSyncHandler(mockRepo, WithWeatherProvider(provider))
+
+		conditions := &weather.Conditions{TemperatureC: 24.3, Description: "partly cloudy"}
+		mockRepo.On("Get", ctx, "acc-12345", "loc-001").Return(withID("loc-001", coordinatesAt(coords.Latitude, coords.Longitude)), nil).Once()
+		provider.On("Current", ctx, coords).Return(conditions, nil).Once()
+
+		result, err := handler.Handle(ctx, event)
+		require.NoError(t, err)
+		assert.Equal(t, &LocationContextResponse{LocationID: "loc-001", Coordinates: coords, Weather: conditions}, result)
+	})
+
+	t.Run("Address locations have no coordinates", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		handler := NewAppSyncHandler(mockRepo, WithWeatherProvider(new(mockWeatherProvider)))
+
+		mockRepo.On("Get", ctx, "acc-12345", "loc-001").Return(withID("loc-001", models.AddressLocation{
+			LocationBase: models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeAddress},
+		}), nil).Once()
+
+		_, err := handler.Handle(ctx, event)
+		assert.EqualError(t, err, "location has no stored coordinates")
+	})
+
+	t.Run("Provider failure", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		provider := new(mockWeatherProvider)
+		handler := NewAppSyncHandler(mockRepo, WithWeatherProvider(provider))
+
+		mockRepo.On("Get", ctx, "acc-12345", "loc-001").Return(withID("loc-001", coordinatesAt(coords.Latitude, coords.Longitude)), nil).Once()
+		provider.On("Current", ctx, coords).Return(nil, errors.New("unavailable")).Once()
+
+		_, err := handler.Handle(ctx, event)
+		assert.EqualError(t, err, "failed to look up weather: unavailable")
+	})
+
+	t.Run("Not configured", func(t *testing.T) {
+		_, err := NewAppSyncHandler(new(mockRepository)).Handle(ctx, event)
+		assert.EqualError(t, err, "location context is not configured")
+	})
+}
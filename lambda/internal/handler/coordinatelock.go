@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+)
+
+// UnlockCoordinatesArguments represents arguments for unlocking an address location's coordinates.
+type UnlockCoordinatesArguments struct {
+	AccountID  string `json:"accountId"`
+	LocationID string `json:"locationId"`
+}
+
+// handleUnlockCoordinates clears an address location's coordinatesLocked,
+// keeping its coordinates, so geocoding may replace them again.
+func (h *AppSyncHandler) handleUnlockCoordinates(ctx context.Context, arguments json.RawMessage) (*UpdateResponse, error) {
+	var args UnlockCoordinatesArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+
+	if args.AccountID == "" || args.LocationID == "" {
+		return nil, fmt.Errorf("accountId and locationId are required")
+	}
+
+	envelope, err := h.repo.Get(ctx, args.AccountID, args.LocationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get location: %w", err)
+	}
+	location, ok := envelope.Location.(models.AddressLocation)
+	if !ok {
+		return nil, fmt.Errorf("only address locations have coordinate locks, got %s", envelope.Location.GetLocationType())
+	}
+	if !location.CoordinatesLocked {
+		return &UpdateResponse{Success: true, Message: "coordinates were not locked", LocationID: args.LocationID}, nil
+	}
+
+	location.CoordinatesLocked = false
+	if err := h.repo.Update(ctx, location, args.LocationID); err != nil {
+		return nil, fmt.Errorf("failed to update location: %w", err)
+	}
+
+	return &UpdateResponse{Success: true, Message: "coordinates unlocked", LocationID: args.LocationID}, nil
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppSyncHandlerUnlockCoordinates(t *testing.T) {
+	ctx := context.Background()
+	locked := models.AddressLocation{
+		LocationBase:      models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeAddress},
+		Address:           models.Address{StreetAddress: "123 Main St", City: "Springfield", PostalCode: "62704", Country: "US"},
+		Coordinates:       &models.Coordinates{Latitude: 39.7801, Longitude: -89.6489},
+		CoordinatesLocked: true,
+	}
+	event := AppSyncEvent{
+		Field:     "unlockCoordinates",
+		Arguments: json.RawMessage(`{"accountId": "acc-12345", "locationId": "loc-001"}`),
+	}
+
+	t.Run("Clears the lock and keeps the coordinates", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		handler := NewAppSyncHandler(mockRepo)
+
+		mockRepo.On("Get", ctx, "acc-12345", "loc-001").Return(withID("loc-001", locked), nil).Once()
+		mockRepo.On("Update", ctx, mock.MatchedBy(func(l models.Location) bool {
+			loc, ok := l.(models.AddressLocation)
+			return ok && !loc.CoordinatesLocked && loc.Coordinates != nil && loc.Coordinates.Latitude == 39.7801
+		}), "loc-001").Return(nil).Once()
+
+		result, err := handler.Handle(ctx, event)
+		require.NoError(t, err)
+		response := result.(*UpdateResponse)
+		assert.True(t, response.Success)
+		assert.Equal(t, "loc-001", response.LocationID)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Leaves unlocked coordinates alone", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		handler := NewAppSyncHandler(mockRepo)
+
+		unlocked := locked
+		unlocked.CoordinatesLocked = false
+		mockRepo.On("Get", ctx, "acc-12345", "loc-001").Return(withID("loc-001", unlocked), nil).Once()
+
+		result, err := handler.Handle(ctx, event)
+		require.NoError(t, err)
+		assert.True(t, result.(*UpdateResponse).Success)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Rejects other location types", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		handler := NewAppSyncHandler(mockRepo)
+
+		coordinates := models.CoordinatesLocation{
+			LocationBase: models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeCoordinates},
+			Coordinates:  models.Coordinates{Latitude: 39.7801, Longitude: -89.6489},
+		}
+		mockRepo.On("Get", ctx, "acc-12345", "loc-001").Return(withID("loc-001", coordinates), nil).Once()
+
+		_, err := handler.Handle(ctx, event)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "only address locations have coordinate locks")
+	})
+
+	t.Run("Requires accountId and locationId", func(t *testing.T) {
+		handler := NewAppSyncHandler(new(mockRepository))
+
+		_, err := handler.Handle(ctx, AppSyncEvent{Field: "unlockCoordinates", Arguments: json.RawMessage(`{"accountId": "acc-12345"}`)})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "accountId and locationId are required")
+	})
+}
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/steverhoton/location-lambda/internal/geo"
+	"github.com/steverhoton/location-lambda/internal/models"
+)
+
+// withConvertedCoordinates converts a location's coordinates to WGS84
+// latitude and longitude. Coordinates entered as a UTM or MGRS grid reference
+// keep the reference, which wins over any latitude and longitude sent with
+// it; those entered in another datum, such as NAD83, are shifted to WGS84.
+func withConvertedCoordinates(location models.Location) (models.Location, error) {
+	var err error
+	switch loc := location.(type) {
+	case models.AddressLocation:
+		if loc.Coordinates, err = convertCoordinates(loc.Coordinates); err != nil {
+			return nil, err
+		}
+		return loc, nil
+	case models.CoordinatesLocation:
+		coordinates, err := convertCoordinates(&loc.Coordinates)
+		if err != nil {
+			return nil, err
+		}
+		loc.Coordinates = *coordinates
+		return loc, nil
+	case models.ShopLocation:
+		if loc.Shop.Coordinates, err = convertCoordinates(loc.Shop.Coordinates); err != nil {
+			return nil, err
+		}
+		return loc, nil
+	case models.EventLocation:
+		if loc.Coordinates, err = convertCoordinates(loc.Coordinates); err != nil {
+			return nil, err
+		}
+		return loc, nil
+	}
+	return location, nil
+}
+
+// convertCoordinates returns a copy of coordinates with WGS84 latitude and
+// longitude, read from its grid reference if it has one. A grid reference is
+// taken to be in the coordinates' CRS, which it keeps, so converting stored
+// coordinates again gives the same result.
+func convertCoordinates(coordinates *models.Coordinates) (*models.Coordinates, error) {
+	if coordinates == nil {
+		return nil, nil
+	}
+	if coordinates.UTM != "" && coordinates.MGRS != "" {
+		// Left for validation to reject
+		return coordinates, nil
+	}
+
+	result := *coordinates
+	if reference := result.UTM + result.MGRS; reference != "" {
+		parse := geo.ParseUTM
+		if result.MGRS != "" {
+			parse = geo.ParseMGRS
+		}
+		converted, err := parse(reference)
+		if err != nil {
+			return nil, fmt.Errorf("invalid coordinates: %w", err)
+		}
+		result.Latitude = converted.Latitude
+		result.Longitude = converted.Longitude
+	}
+
+	converted, err := geo.ToWGS84(result)
+	if err != nil {
+		return nil, fmt.Errorf("invalid coordinates: %w", err)
+	}
+	if result.UTM != "" || result.MGRS != "" {
+		converted.CRS = result.CRS
+	}
+	return &converted, nil
+}
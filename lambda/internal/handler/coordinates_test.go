@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppSyncHandlerConvertedCoordinates(t *testing.T) {
+	ctx := context.Background()
+	near := func(c models.Coordinates, latitude, longitude float64) bool {
+		return math.Abs(c.Latitude-latitude) < 1e-5 && math.Abs(c.Longitude-longitude) < 1e-5
+	}
+
+	t.Run("Converts MGRS on create", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		handler := NewAppSyncHandler(mockRepo)
+
+		mockRepo.On("Create", ctx, mock.MatchedBy(func(l models.Location) bool {
+			loc, ok := l.(models.CoordinatesLocation)
+			return ok && loc.Coordinates.MGRS == "18TWL8562811322" && near(loc.Coordinates, 40.7484, -73.985699)
+		})).Return("loc-001", nil).Once()
+
+		result, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "createLocation",
+			Arguments: json.RawMessage(`{"input": {"accountId": "acc-12345", "locationType": "coordinates", "coordinates": {"mgrs": "18TWL8562811322"}}}`),
+		})
+		require.NoError(t, err)
+		coordinates := result.(map[string]interface{})["coordinates"].(map[string]interface{})
+		assert.Equal(t, "18TWL8562811322", coordinates["mgrs"])
+		assert.InDelta(t, 40.7484, coordinates["latitude"], 1e-5)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("UTM replaces latitude and longitude on update", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		handler := NewAppSyncHandler(mockRepo)
+
+		mockRepo.On("Update", ctx, mock.MatchedBy(func(l models.Location) bool {
+			loc, ok := l.(models.AddressLocation)
+			return ok && loc.Coordinates.UTM == "18T 585628 4511322" && near(*loc.Coordinates, 40.748396, -73.985705)
+		}), "loc-001").Return(nil).Once()
+
+		_, err := handler.Handle(ctx, AppSyncEvent{
+			Field: "updateLocation",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "locationId": "loc-001", "input": {
+				"accountId": "acc-12345",
+				"locationType": "address",
+				"address": {"streetAddress": "350 5th Ave", "city": "New York", "postalCode": "10118", "country": "US"},
+				"coordinates": {"latitude": 1, "longitude": 2, "utm": "18T 585628 4511322"}
+			}}`),
+		})
+		require.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Rejects malformed references", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		handler := NewAppSyncHandler(mockRepo)
+
+		_, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "createLocation",
+			Arguments: json.RawMessage(`{"input": {"accountId": "acc-12345", "locationType": "coordinates", "coordinates": {"mgrs": "18TWL856"}}}`),
+		})
+		assert.EqualError(t, err, "invalid coordinates: mgrs must have an even number of digits, at most 10, got 3")
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Shifts NAD83 to WGS84", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		handler := NewAppSyncHandler(mockRepo)
+
+		mockRepo.On("Create", ctx, mock.MatchedBy(func(l models.Location) bool {
+			loc, ok := l.(models.CoordinatesLocation)
+			return ok && loc.Coordinates.CRS == models.CRSWGS84 &&
+				!near(loc.Coordinates, 39.7392, -104.9903) && near(loc.Coordinates, 39.739205, -104.990321)
+		})).Return("loc-001", nil).Once()
+
+		_, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "createLocation",
+			Arguments: json.RawMessage(`{"input": {"accountId": "acc-12345", "locationType": "coordinates", "coordinates": {"latitude": 39.7392, "longitude": -104.9903, "crs": "NAD83"}}}`),
+		})
+		require.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Grid references keep their datum", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		handler := NewAppSyncHandler(mockRepo)
+
+		var stored models.Coordinates
+		mockRepo.On("Create", ctx, mock.MatchedBy(func(l models.Location) bool {
+			loc, ok := l.(models.CoordinatesLocation)
+			stored = loc.Coordinates
+			return ok && loc.Coordinates.CRS == models.CRSNAD83 && !near(loc.Coordinates, 40.7484, -73.985699)
+		})).Return("loc-001", nil).Once()
+
+		_, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "createLocation",
+			Arguments: json.RawMessage(`{"input": {"accountId": "acc-12345", "locationType": "coordinates", "coordinates": {"mgrs": "18TWL8562811322", "crs": "NAD83"}}}`),
+		})
+		require.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+
+		// Converting the stored coordinates again doesn't move them
+		again, err := convertCoordinates(&stored)
+		require.NoError(t, err)
+		assert.Equal(t, stored, *again)
+	})
+
+	t.Run("Rejects unsupported datums", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		handler := NewAppSyncHandler(mockRepo)
+
+		_, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "createLocation",
+			Arguments: json.RawMessage(`{"input": {"accountId": "acc-12345", "locationType": "coordinates", "coordinates": {"latitude": 39.7392, "longitude": -104.9903, "crs": "NAD27"}}}`),
+		})
+		assert.EqualError(t, err, `invalid coordinates: crs must be WGS84 or NAD83, got "NAD27"`)
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/repository"
+)
+
+// GetCustomFieldDefinitionsArguments represents arguments for reading an account's custom fields.
+type GetCustomFieldDefinitionsArguments struct {
+	AccountID string `json:"accountId"`
+}
+
+// PutCustomFieldDefinitionsArguments represents arguments for replacing an account's custom fields.
+type PutCustomFieldDefinitionsArguments struct {
+	AccountID string                         `json:"accountId"`
+	Fields    []models.CustomFieldDefinition `json:"fields"`
+}
+
+// WithCustomFieldStore enables account-defined custom fields.
+func WithCustomFieldStore(store repository.CustomFieldStore) Option {
+	return func(h *AppSyncHandler) {
+		h.customFields = store
+	}
+}
+
+// customFieldStore returns the configured custom field store or an error when custom fields are disabled.
+func (h *AppSyncHandler) customFieldStore() (repository.CustomFieldStore, error) {
+	if h.customFields == nil {
+		return nil, fmt.Errorf("custom fields are not configured")
+	}
+	return h.customFields, nil
+}
+
+func (h *AppSyncHandler) handleGetCustomFieldDefinitions(ctx context.Context, arguments json.RawMessage) ([]models.CustomFieldDefinition, error) {
+	var args GetCustomFieldDefinitionsArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+
+	store, err := h.customFieldStore()
+	if err != nil {
+		return nil, err
+	}
+
+	definitions, err := store.GetCustomFieldDefinitions(ctx, args.AccountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get custom field definitions: %w", err)
+	}
+
+	return definitions, nil
+}
+
+func (h *AppSyncHandler) handlePutCustomFieldDefinitions(ctx context.Context, arguments json.RawMessage) ([]models.CustomFieldDefinition, error) {
+	var args PutCustomFieldDefinitionsArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+
+	store, err := h.customFieldStore()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := store.PutCustomFieldDefinitions(ctx, args.AccountID, args.Fields); err != nil {
+		return nil, fmt.Errorf("failed to put custom field definitions: %w", err)
+	}
+
+	return args.Fields, nil
+}
+
+// validateCustomFields checks a location's custom field values against its
+// account's definitions. Without a store, locations cannot carry custom fields.
+func (h *AppSyncHandler) validateCustomFields(ctx context.Context, location models.Location) error {
+	values := location.GetCustomFields()
+	if h.customFields == nil {
+		if len(values) > 0 {
+			return fmt.Errorf("custom fields are not configured")
+		}
+		return nil
+	}
+
+	definitions, err := h.customFields.GetCustomFieldDefinitions(ctx, location.GetAccountID())
+	if err != nil {
+		return fmt.Errorf("failed to get custom field definitions: %w", err)
+	}
+
+	return models.ValidateCustomFields(definitions, values)
+}
@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockCustomFieldStore is a mock implementation of the repository.CustomFieldStore interface.
+type mockCustomFieldStore struct {
+	mock.Mock
+}
+
+func (m *mockCustomFieldStore) GetCustomFieldDefinitions(ctx context.Context, accountID string) ([]models.CustomFieldDefinition, error) {
+	args := m.Called(ctx, accountID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.CustomFieldDefinition), args.Error(1)
+}
+
+func (m *mockCustomFieldStore) PutCustomFieldDefinitions(ctx context.Context, accountID string, definitions []models.CustomFieldDefinition) error {
+	args := m.Called(ctx, accountID, definitions)
+	return args.Error(0)
+}
+
+func TestAppSyncHandlerCustomFieldDefinitions(t *testing.T) {
+	ctx := context.Background()
+	definitions := []models.CustomFieldDefinition{
+		{Name: "storeNumber", Type: models.CustomFieldTypeInteger, Required: true},
+	}
+
+	t.Run("Put definitions", func(t *testing.T) {
+		store := new(mockCustomFieldStore)
+		handler := NewAppSyncHandler(new(mockRepository), WithCustomFieldStore(store))
+
+		store.On("PutCustomFieldDefinitions", ctx, "acc-12345", definitions).Return(nil).Once()
+
+		result, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "putCustomFieldDefinitions",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "fields": [{"name": "storeNumber", "type": "integer", "required": true}]}`),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, definitions, result)
+		store.AssertExpectations(t)
+	})
+
+	t.Run("Get definitions", func(t *testing.T) {
+		store := new(mockCustomFieldStore)
+		handler := NewAppSyncHandler(new(mockRepository), WithCustomFieldStore(store))
+
+		store.On("GetCustomFieldDefinitions", ctx, "acc-12345").Return(definitions, nil).Once()
+
+		result, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "getCustomFieldDefinitions",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345"}`),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, definitions, result)
+	})
+
+	t.Run("Not configured", func(t *testing.T) {
+		handler := NewAppSyncHandler(new(mockRepository))
+
+		_, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "getCustomFieldDefinitions",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345"}`),
+		})
+		assert.EqualError(t, err, "custom fields are not configured")
+	})
+}
+
+func TestAppSyncHandlerCreateLocationWithCustomFields(t *testing.T) {
+	ctx := context.Background()
+	definitions := []models.CustomFieldDefinition{
+		{Name: "storeNumber", Type: models.CustomFieldTypeInteger, Required: true},
+		{Name: "region", Type: models.CustomFieldTypeString},
+	}
+	input := func(customFields string) json.RawMessage {
+		return json.RawMessage(`{"input": {
+			"accountId": "acc-12345",
+			"locationType": "coordinates",
+			"coordinates": {"latitude": 40.7128, "longitude": -74.0060},
+			"customFields": ` + customFields + `
+		}}`)
+	}
+
+	tests := []struct {
+		name          string
+		customFields  string
+		configured    bool
+		expectedError string
+	}{
+		{
+			name:         "Valid custom fields are stored",
+			customFields: `{"storeNumber": 42, "region": "east"}`,
+			configured:   true,
+		},
+		{
+			name:          "Missing required field",
+			customFields:  `{"region": "east"}`,
+			configured:    true,
+			expectedError: "invalid customFields: storeNumber is required",
+		},
+		{
+			name:          "Wrong type",
+			customFields:  `{"storeNumber": "42"}`,
+			configured:    true,
+			expectedError: "invalid customFields: storeNumber must be of type integer",
+		},
+		{
+			name:          "Custom fields without a store",
+			customFields:  `{"storeNumber": 42}`,
+			expectedError: "custom fields are not configured",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(mockRepository)
+			store := new(mockCustomFieldStore)
+			var opts []Option
+			if tt.configured {
+				opts = append(opts, WithCustomFieldStore(store))
+				store.On("GetCustomFieldDefinitions", ctx, "acc-12345").Return(definitions, nil).Once()
+			}
+			handler := NewAppSyncHandler(mockRepo, opts...)
+
+			if tt.expectedError == "" {
+				mockRepo.On("Create", ctx, mock.MatchedBy(func(l models.Location) bool {
+					return l.GetCustomFields()["storeNumber"] == float64(42)
+				})).Return("loc-001", nil).Once()
+			}
+
+			result, err := handler.Handle(ctx, AppSyncEvent{Field: "createLocation", Arguments: input(tt.customFields)})
+
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "loc-001", result.(map[string]interface{})["locationId"])
+			mockRepo.AssertExpectations(t)
+			store.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/repository"
+)
+
+// QuoteDeliveryForPointArguments represents arguments for quoting delivery to a point.
+type QuoteDeliveryForPointArguments struct {
+	AccountID string  `json:"accountId"`
+	Lat       float64 `json:"lat"`
+	Lon       float64 `json:"lon"`
+}
+
+// DeliveryQuote is the fee data of the delivery zone chosen for a point.
+type DeliveryQuote struct {
+	LocationID  string `json:"locationId"`
+	ShopName    string `json:"shopName"`
+	ZoneName    string `json:"zoneName"`
+	DeliveryFee string `json:"deliveryFee"`
+	MinOrder    string `json:"minOrder,omitempty"`
+	EtaMinutes  int    `json:"etaMinutes,omitempty"`
+}
+
+// WithDeliveryZoneFinder enables quoteDeliveryForPoint.
+func WithDeliveryZoneFinder(finder repository.DeliveryZoneFinder) Option {
+	return func(h *AppSyncHandler) {
+		h.deliveryZones = finder
+	}
+}
+
+// cheaperDelivery reports whether zone a beats zone b for the customer: a
+// lower fee, then a shorter known ETA.
+func cheaperDelivery(a, b models.DeliveryZone) bool {
+	if c := models.CompareDecimals(a.DeliveryFee, b.DeliveryFee); c != 0 {
+		return c < 0
+	}
+	return a.EtaMinutes > 0 && (b.EtaMinutes == 0 || a.EtaMinutes < b.EtaMinutes)
+}
+
+// handleQuoteDeliveryForPoint returns the delivery zone containing a point
+// with its fee data, or nil when no zone covers it. Where zones overlap, the
+// cheapest wins.
+func (h *AppSyncHandler) handleQuoteDeliveryForPoint(ctx context.Context, arguments json.RawMessage) (*DeliveryQuote, error) {
+	var args QuoteDeliveryForPointArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+
+	if args.AccountID == "" {
+		return nil, fmt.Errorf("accountId is required")
+	}
+	if h.deliveryZones == nil {
+		return nil, fmt.Errorf("delivery quotes are not configured")
+	}
+
+	point := models.Coordinates{Latitude: args.Lat, Longitude: args.Lon}
+	matches, err := h.deliveryZones.FindDeliveryZones(ctx, args.AccountID, point)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find delivery zones: %w", err)
+	}
+	if len(matches) == 0 {
+		return nil, nil
+	}
+
+	best := matches[0]
+	for _, match := range matches[1:] {
+		if cheaperDelivery(match.Zone, best.Zone) {
+			best = match
+		}
+	}
+	return &DeliveryQuote{
+		LocationID:  best.LocationID,
+		ShopName:    best.ShopName,
+		ZoneName:    best.Zone.Name,
+		DeliveryFee: best.Zone.DeliveryFee,
+		MinOrder:    best.Zone.MinOrder,
+		EtaMinutes:  best.Zone.EtaMinutes,
+	}, nil
+}
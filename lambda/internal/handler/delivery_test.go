@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockDeliveryZoneFinder is a mock implementation of the repository.DeliveryZoneFinder interface.
+type mockDeliveryZoneFinder struct {
+	mock.Mock
+}
+
+func (m *mockDeliveryZoneFinder) FindDeliveryZones(ctx context.Context, accountID string, point models.Coordinates) ([]repository.DeliveryZoneMatch, error) {
+	args := m.Called(ctx, accountID, point)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repository.DeliveryZoneMatch), args.Error(1)
+}
+
+func TestAppSyncHandlerQuoteDeliveryForPoint(t *testing.T) {
+	ctx := context.Background()
+	point := models.Coordinates{Latitude: 39.7817, Longitude: -89.6501}
+	event := AppSyncEvent{
+		Field:     "quoteDeliveryForPoint",
+		Arguments: json.RawMessage(`{"accountId": "acc-12345", "lat": 39.7817, "lon": -89.6501}`),
+	}
+	match := func(locationID, zone, fee string, eta int) repository.DeliveryZoneMatch {
+		return repository.DeliveryZoneMatch{
+			LocationID: locationID,
+			ShopName:   "Store " + locationID,
+			Zone:       models.DeliveryZone{Name: zone, DeliveryFee: fee, MinOrder: "15.00", EtaMinutes: eta},
+		}
+	}
+
+	t.Run("Quotes the cheapest containing zone", func(t *testing.T) {
+		finder := new(mockDeliveryZoneFinder)
+		handler := NewAppSyncHandler(new(mockRepository), WithDeliveryZoneFinder(finder))
+
+		finder.On("FindDeliveryZones", ctx, "acc-12345", point).Return([]repository.DeliveryZoneMatch{
+			match("loc-001", "Citywide", "10", 60),
+			match("loc-002", "Downtown", "4.99", 45),
+			match("loc-003", "Center", "4.990", 30),
+		}, nil).Once()
+
+		result, err := handler.Handle(ctx, event)
+		require.NoError(t, err)
+		assert.Equal(t, &DeliveryQuote{
+			LocationID:  "loc-003",
+			ShopName:    "Store loc-003",
+			ZoneName:    "Center",
+			DeliveryFee: "4.990",
+			MinOrder:    "15.00",
+			EtaMinutes:  30,
+		}, result)
+	})
+
+	t.Run("Returns nil outside every zone", func(t *testing.T) {
+		finder := new(mockDeliveryZoneFinder)
+		handler := NewAppSyncHandler(new(mockRepository), WithDeliveryZoneFinder(finder))
+
+		finder.On("FindDeliveryZones", ctx, "acc-12345", point).Return([]repository.DeliveryZoneMatch{}, nil).Once()
+
+		result, err := handler.Handle(ctx, event)
+		require.NoError(t, err)
+		assert.Nil(t, result)
+	})
+
+	t.Run("Finder errors are returned", func(t *testing.T) {
+		finder := new(mockDeliveryZoneFinder)
+		handler := NewAppSyncHandler(new(mockRepository), WithDeliveryZoneFinder(finder))
+
+		finder.On("FindDeliveryZones", ctx, "acc-12345", point).Return(nil, errors.New("throttled")).Once()
+
+		_, err := handler.Handle(ctx, event)
+		assert.EqualError(t, err, "failed to find delivery zones: throttled")
+	})
+
+	t.Run("Not configured", func(t *testing.T) {
+		handler := NewAppSyncHandler(new(mockRepository))
+
+		_, err := handler.Handle(ctx, event)
+		assert.EqualError(t, err, "delivery quotes are not configured")
+	})
+}
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/steverhoton/location-lambda/internal/repository"
+)
+
+// PublishLocationArguments represents arguments for publishing a draft location.
+type PublishLocationArguments struct {
+	AccountID  string `json:"accountId"`
+	LocationID string `json:"locationId"`
+}
+
+// WithDraftPublisher enables publishLocation.
+func WithDraftPublisher(publisher repository.DraftPublisher) Option {
+	return func(h *AppSyncHandler) {
+		h.drafts = publisher
+	}
+}
+
+// handlePublishLocation makes a draft location visible to lists and
+// searches. The draft is checked against the account's current custom fields
+// and categories first, as they may have changed while it was staged.
+func (h *AppSyncHandler) handlePublishLocation(ctx context.Context, arguments json.RawMessage) (*UpdateResponse, error) {
+	var args PublishLocationArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+
+	if args.AccountID == "" || args.LocationID == "" {
+		return nil, fmt.Errorf("accountId and locationId are required")
+	}
+	if h.drafts == nil {
+		return nil, fmt.Errorf("draft locations are not configured")
+	}
+
+	envelope, err := h.repo.Get(ctx, args.AccountID, args.LocationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get location: %w", err)
+	}
+	if !envelope.Location.IsDraft() {
+		return nil, fmt.Errorf("location is already published")
+	}
+	if err := h.validateCustomFields(ctx, envelope.Location); err != nil {
+		return nil, err
+	}
+	if err := h.validateCategories(ctx, envelope.Location); err != nil {
+		return nil, err
+	}
+
+	if err := h.drafts.Publish(ctx, args.AccountID, args.LocationID); err != nil {
+		return nil, fmt.Errorf("failed to publish location: %w", err)
+	}
+
+	return &UpdateResponse{Success: true, Message: "location published", LocationID: args.LocationID}, nil
+}
@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type mockDraftPublisher struct {
+	mock.Mock
+}
+
+func (m *mockDraftPublisher) Publish(ctx context.Context, accountID, locationID string) error {
+	args := m.Called(ctx, accountID, locationID)
+	return args.Error(0)
+}
+
+func TestAppSyncHandlerPublishLocation(t *testing.T) {
+	ctx := context.Background()
+	draft := models.ShopLocation{
+		LocationBase: models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeShop, Draft: true},
+		Shop:         models.Shop{Name: "Main Street Store", Categories: []string{"722515"}},
+	}
+	event := AppSyncEvent{
+		Field:     "publishLocation",
+		Arguments: json.RawMessage(`{"accountId": "acc-12345", "locationId": "loc-001"}`),
+	}
+
+	t.Run("Publishes a draft", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		publisher := new(mockDraftPublisher)
+		handler := NewAppSyncHandler(mockRepo, WithDraftPublisher(publisher))
+
+		mockRepo.On("Get", ctx, "acc-12345", "loc-001").Return(withID("loc-001", draft), nil).Once()
+		publisher.On("Publish", ctx, "acc-12345", "loc-001").Return(nil).Once()
+
+		result, err := handler.Handle(ctx, event)
+		require.NoError(t, err)
+		response := result.(*UpdateResponse)
+		assert.True(t, response.Success)
+		assert.Equal(t, "loc-001", response.LocationID)
+		publisher.AssertExpectations(t)
+	})
+
+	t.Run("Rejects a published location", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		publisher := new(mockDraftPublisher)
+		handler := NewAppSyncHandler(mockRepo, WithDraftPublisher(publisher))
+
+		published := draft
+		published.Draft = false
+		mockRepo.On("Get", ctx, "acc-12345", "loc-001").Return(withID("loc-001", published), nil).Once()
+
+		_, err := handler.Handle(ctx, event)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "location is already published")
+		publisher.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Checks the account's current categories", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		publisher := new(mockDraftPublisher)
+		store := new(mockSettingsStore)
+		handler := NewAppSyncHandler(mockRepo, WithDraftPublisher(publisher), WithSettingsStore(store))
+
+		settings := models.DefaultAccountSettings("acc-12345")
+		settings.CategoryTaxonomy = models.CategoryTaxonomyCustom
+		settings.Categories = []string{"coffee"}
+		store.On("GetAccountSettings", ctx, "acc-12345").Return(&settings, nil).Once()
+		mockRepo.On("Get", ctx, "acc-12345", "loc-001").Return(withID("loc-001", draft), nil).Once()
+
+		_, err := handler.Handle(ctx, event)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "validation failed")
+		publisher.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Requires a draft publisher", func(t *testing.T) {
+		handler := NewAppSyncHandler(new(mockRepository))
+
+		_, err := handler.Handle(ctx, event)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "draft locations are not configured")
+	})
+
+	t.Run("Requires accountId and locationId", func(t *testing.T) {
+		handler := NewAppSyncHandler(new(mockRepository), WithDraftPublisher(new(mockDraftPublisher)))
+
+		_, err := handler.Handle(ctx, AppSyncEvent{Field: "publishLocation", Arguments: json.RawMessage(`{"accountId": "acc-12345"}`)})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "accountId and locationId are required")
+	})
+}
@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/steverhoton/location-lambda/internal/dedupe"
+	"github.com/steverhoton/location-lambda/internal/repository"
+)
+
+const (
+	// defaultDuplicateThreshold is the minimum candidate score when none is given.
+	defaultDuplicateThreshold = 0.8
+	// defaultDuplicatePageSize and maxDuplicatePageSize bound candidate pages.
+	defaultDuplicatePageSize = 20
+	maxDuplicatePageSize     = 100
+	// maxDuplicateScanLocations caps how many locations one report compares
+	// pairwise, keeping the request within the Lambda timeout.
+	maxDuplicateScanLocations = 5000
+	// duplicateScanPageSize is the page size used to read an account's locations.
+	duplicateScanPageSize = 100
+)
+
+// FindDuplicateCandidatesArguments represents arguments for the duplicate candidates report.
+type FindDuplicateCandidatesArguments struct {
+	AccountID string   `json:"accountId"`
+	Threshold *float64 `json:"threshold,omitempty"` // Minimum score, 0-1; defaults to 0.8
+	Limit     *int32   `json:"limit,omitempty"`
+	Cursor    *string  `json:"cursor,omitempty"`
+}
+
+// DuplicateCandidatesResponse is a page of scored duplicate candidate pairs.
+type DuplicateCandidatesResponse struct {
+	Candidates       []dedupe.Candidate `json:"candidates"`
+	NextCursor       *string            `json:"nextCursor,omitempty"`
+	ScannedLocations int                `json:"scannedLocations"`
+}
+
+// duplicateCursor is the position of the next page within the ranked candidate list.
+type duplicateCursor struct {
+	Offset int `json:"offset"`
+}
+
+func (h *AppSyncHandler) handleFindDuplicateCandidates(ctx context.Context, arguments json.RawMessage) (*DuplicateCandidatesResponse, error) {
+	var args FindDuplicateCandidatesArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+
+	if args.AccountID == "" {
+		return nil, fmt.Errorf("accountId is required")
+	}
+	threshold := defaultDuplicateThreshold
+	if args.Threshold != nil {
+		threshold = *args.Threshold
+	}
+	if threshold <= 0 || threshold > 1 {
+		return nil, fmt.Errorf("threshold must be greater than 0 and at most 1")
+	}
+	limit := defaultDuplicatePageSize
+	if args.Limit != nil {
+		limit = int(*args.Limit)
+	}
+	if limit <= 0 || limit > maxDuplicatePageSize {
+		return nil, fmt.Errorf("limit must be between 1 and %d", maxDuplicatePageSize)
+	}
+	offset, err := decodeDuplicateCursor(args.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := h.scanAccount(ctx, args.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Candidates are recomputed on every page; ranking is deterministic so
+	// offsets stay stable while the account is unchanged.
+	candidates := dedupe.FindCandidates(entries, threshold)
+	response := &DuplicateCandidatesResponse{
+		Candidates:       []dedupe.Candidate{},
+		ScannedLocations: len(entries),
+	}
+	if offset < len(candidates) {
+		end := offset + limit
+		if end > len(candidates) {
+			end = len(candidates)
+		}
+		response.Candidates = candidates[offset:end]
+		if end < len(candidates) {
+			response.NextCursor = encodeDuplicateCursor(end)
+		}
+	}
+
+	return response, nil
+}
+
+// scanAccount reads every location in an account, up to maxDuplicateScanLocations.
+func (h *AppSyncHandler) scanAccount(ctx context.Context, accountID string) ([]dedupe.Entry, error) {
+	var entries []dedupe.Entry
+	// Drafts and inactive locations are scanned too, so staged and seasonal locations are checked
+	options := &repository.ListOptions{Limit: aws.Int32(duplicateScanPageSize), IncludeDrafts: true, IncludeInactive: true}
+	for {
+		result, err := h.repo.List(ctx, accountID, options)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list locations: %w", err)
+		}
+		for _, item := range result.Items {
+			entries = append(entries, dedupe.Entry{LocationID: item.LocationID, Location: item.Location})
+		}
+		if len(entries) > maxDuplicateScanLocations {
+			return nil, fmt.Errorf("account has more than %d locations; too many to compare in one request", maxDuplicateScanLocations)
+		}
+		if result.NextCursor == nil {
+			return entries, nil
+		}
+		options.Cursor = result.NextCursor
+	}
+}
+
+// encodeDuplicateCursor encodes a candidate offset as an opaque cursor.
+func encodeDuplicateCursor(offset int) *string {
+	data, _ := json.Marshal(duplicateCursor{Offset: offset})
+	cursor := base64.URLEncoding.EncodeToString(data)
+	return &cursor
+}
+
+// decodeDuplicateCursor returns the candidate offset encoded in cursor, or 0 for the first page.
+func decodeDuplicateCursor(cursor *string) (int, error) {
+	if cursor == nil || *cursor == "" {
+		return 0, nil
+	}
+	data, err := base64.URLEncoding.DecodeString(*cursor)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cursor: %w", err)
+	}
+	var c duplicateCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.Offset < 0 {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+	return c.Offset, nil
+}
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func coordinatesAt(lat, lon float64) models.Location {
+	return models.CoordinatesLocation{
+		LocationBase: models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeCoordinates},
+		Coordinates:  models.Coordinates{Latitude: lat, Longitude: lon},
+	}
+}
+
+func TestAppSyncHandlerFindDuplicateCandidates(t *testing.T) {
+	ctx := context.Background()
+
+	// Two pages of locations containing two duplicate pairs
+	firstPage := &repository.ListResult{
+		Items: []repository.LocationEnvelope{
+			{LocationID: "loc-001", Location: coordinatesAt(40.7128, -74.0060)},
+			{LocationID: "loc-002", Location: coordinatesAt(51.5072, -0.1276)},
+		},
+		NextCursor: aws.String("page-2"),
+	}
+	secondPage := &repository.ListResult{
+		Items: []repository.LocationEnvelope{
+			{LocationID: "loc-003", Location: coordinatesAt(40.7128, -74.0060)},
+			{LocationID: "loc-004", Location: coordinatesAt(51.5072, -0.1276)},
+		},
+	}
+	expectScan := func(mockRepo *mockRepository) {
+		mockRepo.On("List", ctx, "acc-12345", mock.MatchedBy(func(o *repository.ListOptions) bool {
+			return o.Cursor == nil
+		})).Return(firstPage, nil).Once()
+		mockRepo.On("List", ctx, "acc-12345", mock.MatchedBy(func(o *repository.ListOptions) bool {
+			return o.Cursor != nil && *o.Cursor == "page-2"
+		})).Return(secondPage, nil).Once()
+	}
+
+	t.Run("Pages through ranked candidates", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		handler := NewAppSyncHandler(mockRepo)
+		expectScan(mockRepo)
+
+		result, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "findDuplicateCandidates",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "limit": 1}`),
+		})
+		require.NoError(t, err)
+		page := result.(*DuplicateCandidatesResponse)
+		assert.Equal(t, 4, page.ScannedLocations)
+		require.Len(t, page.Candidates, 1)
+		assert.Equal(t, []string{"loc-001", "loc-003"}, page.Candidates[0].LocationIDs)
+		require.NotNil(t, page.NextCursor)
+
+		expectScan(mockRepo)
+		args, _ := json.Marshal(map[string]interface{}{"accountId": "acc-12345", "limit": 1, "cursor": *page.NextCursor})
+		result, err = handler.Handle(ctx, AppSyncEvent{Field: "findDuplicateCandidates", Arguments: args})
+		require.NoError(t, err)
+		page = result.(*DuplicateCandidatesResponse)
+		require.Len(t, page.Candidates, 1)
+		assert.Equal(t, []string{"loc-002", "loc-004"}, page.Candidates[0].LocationIDs)
+		assert.Nil(t, page.NextCursor)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Invalid arguments", func(t *testing.T) {
+		handler := NewAppSyncHandler(new(mockRepository))
+		tests := []struct {
+			name      string
+			arguments string
+			wantErr   string
+		}{
+			{"missing account", `{}`, "accountId is required"},
+			{"threshold out of range", `{"accountId": "acc-12345", "threshold": 1.5}`, "threshold must be greater than 0 and at most 1"},
+			{"limit too large", `{"accountId": "acc-12345", "limit": 500}`, "limit must be between 1 and 100"},
+			{"malformed cursor", `{"accountId": "acc-12345", "cursor": "%%%"}`, "invalid cursor"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := handler.Handle(ctx, AppSyncEvent{Field: "findDuplicateCandidates", Arguments: json.RawMessage(tt.arguments)})
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+			})
+		}
+	})
+}
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/steverhoton/location-lambda/internal/elevation"
+	"github.com/steverhoton/location-lambda/internal/models"
+)
+
+// WithElevationProvider fills in the altitude of coordinates written without one.
+func WithElevationProvider(provider elevation.Provider) Option {
+	return func(h *AppSyncHandler) {
+		h.elevation = provider
+	}
+}
+
+// withElevation sets the altitude of a location's coordinates to the terrain
+// elevation there when it is absent and an elevation provider is configured.
+// Coordinates given an altitude, such as a drone's or a tower top's, keep it.
+func (h *AppSyncHandler) withElevation(ctx context.Context, location models.Location) (models.Location, error) {
+	if h.elevation == nil {
+		return location, nil
+	}
+	var err error
+	switch loc := location.(type) {
+	case models.AddressLocation:
+		if loc.Coordinates, err = h.elevate(ctx, loc.Coordinates); err != nil {
+			return nil, err
+		}
+		return loc, nil
+	case models.CoordinatesLocation:
+		coordinates, err := h.elevate(ctx, &loc.Coordinates)
+		if err != nil {
+			return nil, err
+		}
+		loc.Coordinates = *coordinates
+		return loc, nil
+	case models.ShopLocation:
+		if loc.Shop.Coordinates, err = h.elevate(ctx, loc.Shop.Coordinates); err != nil {
+			return nil, err
+		}
+		return loc, nil
+	case models.EventLocation:
+		if loc.Coordinates, err = h.elevate(ctx, loc.Coordinates); err != nil {
+			return nil, err
+		}
+		return loc, nil
+	}
+	return location, nil
+}
+
+// elevate returns a copy of coordinates with the terrain elevation as its
+// altitude, if it has none. Invalid coordinates are left for validation.
+func (h *AppSyncHandler) elevate(ctx context.Context, coordinates *models.Coordinates) (*models.Coordinates, error) {
+	if coordinates == nil || coordinates.Altitude != nil || coordinates.Validate() != nil {
+		return coordinates, nil
+	}
+	meters, err := h.elevation.Elevation(ctx, *coordinates)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up elevation: %w", err)
+	}
+	result := *coordinates
+	result.Altitude = &meters
+	return &result, nil
+}
@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockElevationProvider is a mock implementation of the elevation.Provider interface.
+type mockElevationProvider struct {
+	mock.Mock
+}
+
+func (m *mockElevationProvider) Elevation(ctx context.Context, coords models.Coordinates) (float64, error) {
+	args := m.Called(ctx, coords)
+	return args.Get(0).(float64), args.Error(1)
+}
+
+func TestAppSyncHandlerElevation(t *testing.T) {
+	ctx := context.Background()
+	denver := models.Coordinates{Latitude: 39.7392, Longitude: -104.9903}
+	create := func(coordinates string) AppSyncEvent {
+		return AppSyncEvent{
+			Field:     "createLocation",
+			Arguments: json.RawMessage(`{"input": {"accountId": "acc-12345", "locationType": "coordinates", "coordinates": ` + coordinates + `}}`),
+		}
+	}
+
+	t.Run("Fills in a missing altitude", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		provider := new(mockElevationProvider)
+		handler := NewAppSyncHandler(mockRepo, WithElevationProvider(provider))
+
+		provider.On("Elevation", ctx, denver).Return(1596.0, nil).Once()
+		mockRepo.On("Create", ctx, mock.MatchedBy(func(l models.Location) bool {
+			altitude := l.(models.CoordinatesLocation).Coordinates.Altitude
+			return altitude != nil && *altitude == 1596
+		})).Return("loc-001", nil).Once()
+
+		_, err := handler.Handle(ctx, create(`{"latitude": 39.7392, "longitude": -104.9903}`))
+		require.NoError(t, err)
+		provider.AssertExpectations(t)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Keeps a given altitude", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		provider := new(mockElevationProvider)
+		handler := NewAppSyncHandler(mockRepo, WithElevationProvider(provider))
+
+		mockRepo.On("Create", ctx, mock.MatchedBy(func(l models.Location) bool {
+			altitude := l.(models.CoordinatesLocation).Coordinates.Altitude
+			return altitude != nil && *altitude == 1720
+		})).Return("loc-001", nil).Once()
+
+		_, err := handler.Handle(ctx, create(`{"latitude": 39.7392, "longitude": -104.9903, "altitude": 1720}`))
+		require.NoError(t, err)
+		provider.AssertNotCalled(t, "Elevation", mock.Anything, mock.Anything)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Fills in a shop's altitude on update", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		provider := new(mockElevationProvider)
+		handler := NewAppSyncHandler(mockRepo, WithElevationProvider(provider))
+
+		provider.On("Elevation", ctx, denver).Return(1596.0, nil).Once()
+		mockRepo.On("Update", ctx, mock.MatchedBy(func(l models.Location) bool {
+			altitude := l.(models.ShopLocation).Shop.Coordinates.Altitude
+			return altitude != nil && *altitude == 1596
+		}), "loc-001").Return(nil).Once()
+
+		_, err := handler.Handle(ctx, AppSyncEvent{
+			Field: "updateLocation",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "locationId": "loc-001", "input": {
+				"accountId": "acc-12345",
+				"locationType": "shop",
+				"shop": {
+					"name": "Union Station Books",
+					"contactId": "contact-1",
+					"address": {"streetAddress": "1701 Wynkoop St", "city": "Denver", "postalCode": "80202", "country": "US"},
+					"coordinates": {"latitude": 39.7392, "longitude": -104.9903}
+				}
+			}}`),
+		})
+		require.NoError(t, err)
+		provider.AssertExpectations(t)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Leaves invalid coordinates for validation", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		provider := new(mockElevationProvider)
+		handler := NewAppSyncHandler(mockRepo, WithElevationProvider(provider))
+
+		mockRepo.On("Create", ctx, mock.Anything).Return("", errors.New("validation failed: latitude must be between -90 and 90, got 91.000000")).Once()
+
+		_, err := handler.Handle(ctx, create(`{"latitude": 91, "longitude": -104.9903}`))
+		assert.Error(t, err)
+		provider.AssertNotCalled(t, "Elevation", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Surfaces provider errors", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		provider := new(mockElevationProvider)
+		handler := NewAppSyncHandler(mockRepo, WithElevationProvider(provider))
+
+		provider.On("Elevation", ctx, denver).Return(0.0, errors.New("service unavailable")).Once()
+
+		_, err := handler.Handle(ctx, create(`{"latitude": 39.7392, "longitude": -104.9903}`))
+		assert.EqualError(t, err, "failed to look up elevation: service unavailable")
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+}
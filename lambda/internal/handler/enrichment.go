@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/places"
+)
+
+// EnrichLocationArguments represents arguments for enriching a shop location.
+type EnrichLocationArguments struct {
+	AccountID  string `json:"accountId"`
+	LocationID string `json:"locationId"`
+}
+
+// WithPlacesProvider enables shop enrichment from an external places provider.
+func WithPlacesProvider(provider places.Provider) Option {
+	return func(h *AppSyncHandler) {
+		h.places = provider
+	}
+}
+
+func (h *AppSyncHandler) handleEnrichLocation(ctx context.Context, arguments json.RawMessage) (*models.Enrichment, error) {
+	var args EnrichLocationArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+
+	if args.AccountID == "" || args.LocationID == "" {
+		return nil, fmt.Errorf("accountId and locationId are required")
+	}
+	if h.places == nil {
+		return nil, fmt.Errorf("location enrichment is not configured")
+	}
+
+	envelope, err := h.repo.Get(ctx, args.AccountID, args.LocationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get location: %w", err)
+	}
+	location := envelope.Location
+	shop, ok := location.(models.ShopLocation)
+	if !ok {
+		return nil, fmt.Errorf("only shop locations can be enriched, got %s", location.GetLocationType())
+	}
+
+	place, err := h.places.FindPlace(ctx, places.Query{Name: shop.Shop.Name, Address: shop.Shop.Address})
+	if errors.Is(err, places.ErrNoMatch) {
+		return nil, fmt.Errorf("no place found matching %q", shop.Shop.Name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up place: %w", err)
+	}
+
+	enrichment := &models.Enrichment{
+		Category:    place.Category,
+		Phone:       place.Phone,
+		Website:     place.Website,
+		Provider:    h.places.Name(),
+		PlaceID:     place.PlaceID,
+		MatchedName: place.Name,
+		EnrichedAt:  h.clock.Now().UTC(),
+	}
+	shop.Shop.Enrichment = enrichment
+
+	if err := h.repo.Update(ctx, shop, args.LocationID); err != nil {
+		return nil, fmt.Errorf("failed to update location: %w", err)
+	}
+
+	return enrichment, nil
+}
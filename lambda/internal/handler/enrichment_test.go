@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/places"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockPlacesProvider is a mock implementation of the places.Provider interface.
+type mockPlacesProvider struct {
+	mock.Mock
+}
+
+func (m *mockPlacesProvider) Name() string {
+	return "mock_places"
+}
+
+func (m *mockPlacesProvider) FindPlace(ctx context.Context, query places.Query) (*places.Place, error) {
+	args := m.Called(ctx, query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*places.Place), args.Error(1)
+}
+
+func TestAppSyncHandlerEnrichLocation(t *testing.T) {
+	ctx := context.Background()
+	shop := models.ShopLocation{
+		LocationBase: models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeShop},
+		Shop: models.Shop{
+			Name:      "Blue Bottle Coffee",
+			ContactID: "contact-1",
+			Address:   models.Address{StreetAddress: "66 Mint St", City: "San Francisco", PostalCode: "94103", Country: "US"},
+		},
+	}
+	event := AppSyncEvent{
+		Field:     "enrichLocation",
+		Arguments: json.RawMessage(`{"accountId": "acc-12345", "locationId": "loc-001"}`),
+	}
+
+	t.Run("Stores enrichment with provenance", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		provider := new(mockPlacesProvider)
+		handler := NewAppSyncHandler(mockRepo, WithPlacesProvider(provider))
+
+		mockRepo.On("Get", ctx, "acc-12345", "loc-001").Return(withID("loc-001", shop), nil).Once()
+		provider.On("FindPlace", ctx, places.Query{Name: shop.Shop.Name, Address: shop.Shop.Address}).Return(&places.Place{
+			PlaceID:  "place-1",
+			Name:     "Blue Bottle Coffee",
+			Category: "coffee_shop",
+			Phone:    "+15106533394",
+			Website:  "https://bluebottlecoffee.com",
+		}, nil).Once()
+		mockRepo.On("Update", ctx, mock.MatchedBy(func(l models.Location) bool {
+			loc, ok := l.(models.ShopLocation)
+			return ok && loc.Shop.Enrichment != nil && loc.Shop.Enrichment.Category == "coffee_shop"
+		}), "loc-001").Return(nil).Once()
+
+		result, err := handler.Handle(ctx, event)
+		require.NoError(t, err)
+		enrichment := result.(*models.Enrichment)
+		assert.Equal(t, "mock_places", enrichment.Provider)
+		assert.Equal(t, "place-1", enrichment.PlaceID)
+		assert.Equal(t, "+15106533394", enrichment.Phone)
+		assert.False(t, enrichment.EnrichedAt.IsZero())
+		mockRepo.AssertExpectations(t)
+		provider.AssertExpectations(t)
+	})
+
+	t.Run("No matching place", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		provider := new(mockPlacesProvider)
+		handler := NewAppSyncHandler(mockRepo, WithPlacesProvider(provider))
+
+		mockRepo.On("Get", ctx, "acc-12345", "loc-001").Return(withID("loc-001", shop), nil).Once()
+		provider.On("FindPlace", ctx, mock.Anything).Return(nil, places.ErrNoMatch).Once()
+
+		_, err := handler.Handle(ctx, event)
+		assert.EqualError(t, err, `no place found matching "Blue Bottle Coffee"`)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Only shops are enriched", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		handler := NewAppSyncHandler(mockRepo, WithPlacesProvider(new(mockPlacesProvider)))
+
+		mockRepo.On("Get", ctx, "acc-12345", "loc-001").Return(withID("loc-001", models.AddressLocation{
+			LocationBase: models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeAddress},
+		}), nil).Once()
+
+		_, err := handler.Handle(ctx, event)
+		assert.EqualError(t, err, "only shop locations can be enriched, got address")
+	})
+
+	t.Run("Not configured", func(t *testing.T) {
+		handler := NewAppSyncHandler(new(mockRepository))
+
+		_, err := handler.Handle(ctx, event)
+		assert.EqualError(t, err, "location enrichment is not configured")
+	})
+}
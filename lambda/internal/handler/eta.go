@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/routing"
+)
+
+// EtaToLocationArguments represents arguments for estimating travel to a stored location.
+type EtaToLocationArguments struct {
+	AccountID  string  `json:"accountId"`
+	FromLat    float64 `json:"fromLat"`
+	FromLon    float64 `json:"fromLon"`
+	LocationID string  `json:"locationId"`
+	// Mode is a routing travel mode, car when empty
+	Mode string `json:"mode,omitempty"`
+}
+
+// EtaResponse is the estimated travel to a stored location.
+type EtaResponse struct {
+	LocationID      string             `json:"locationId"`
+	Mode            string             `json:"mode"`
+	Destination     models.Coordinates `json:"destination"`
+	DurationSeconds float64            `json:"durationSeconds"`
+	DistanceMeters  float64            `json:"distanceMeters"`
+	// ArrivesAt is when the trip would end leaving now
+	ArrivesAt time.Time `json:"arrivesAt"`
+}
+
+// WithRoutingProvider enables ETAs to stored locations.
+func WithRoutingProvider(provider routing.Provider) Option {
+	return func(h *AppSyncHandler) {
+		h.router = provider
+	}
+}
+
+func (h *AppSyncHandler) handleEtaToLocation(ctx context.Context, arguments json.RawMessage) (*EtaResponse, error) {
+	var args EtaToLocationArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+
+	if args.AccountID == "" || args.LocationID == "" {
+		return nil, fmt.Errorf("accountId and locationId are required")
+	}
+	from := models.Coordinates{Latitude: args.FromLat, Longitude: args.FromLon}
+	if err := from.Validate(); err != nil {
+		return nil, err
+	}
+	if args.Mode == "" {
+		args.Mode = routing.ModeCar
+	}
+	if err := routing.ValidateMode(args.Mode); err != nil {
+		return nil, err
+	}
+	if h.router == nil {
+		return nil, fmt.Errorf("ETAs are not configured")
+	}
+
+	envelope, err := h.repo.Get(ctx, args.AccountID, args.LocationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get location: %w", err)
+	}
+	destination, ok := destinationOf(envelope.Location)
+	if !ok {
+		return nil, fmt.Errorf("location has no stored coordinates")
+	}
+
+	route, err := h.router.Route(ctx, from, destination, args.Mode)
+	if errors.Is(err, routing.ErrNoRoute) {
+		return nil, fmt.Errorf("no %s route to location %s", args.Mode, args.LocationID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate route: %w", err)
+	}
+
+	return &EtaResponse{
+		LocationID:      args.LocationID,
+		Mode:            args.Mode,
+		Destination:     destination,
+		DurationSeconds: route.DurationSeconds,
+		DistanceMeters:  route.DistanceMeters,
+		ArrivesAt:       h.clock.Now().UTC().Add(time.Duration(route.DurationSeconds * float64(time.Second))).Truncate(time.Second),
+	}, nil
+}
+
+// destinationOf returns where to route to for a location: its coordinates,
+// hand-entered or geocoded, or a shop's map pin.
+func destinationOf(location models.Location) (models.Coordinates, bool) {
+	var coordinates *models.Coordinates
+	switch loc := location.(type) {
+	case models.AddressLocation:
+		coordinates = loc.Coordinates
+	case models.CoordinatesLocation:
+		coordinates = &loc.Coordinates
+	case models.ShopLocation:
+		coordinates = loc.Shop.Coordinates
+	case models.EventLocation:
+		coordinates = loc.Coordinates
+	}
+	if coordinates == nil {
+		return models.Coordinates{}, false
+	}
+	return *coordinates, true
+}
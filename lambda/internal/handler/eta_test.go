@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/routing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockRoutingProvider is a mock implementation of the routing.Provider interface.
+type mockRoutingProvider struct {
+	mock.Mock
+}
+
+func (m *mockRoutingProvider) Route(ctx context.Context, from, to models.Coordinates, mode string) (*routing.Route, error) {
+	args := m.Called(ctx, from, to, mode)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*routing.Route), args.Error(1)
+}
+
+func TestAppSyncHandlerEtaToLocation(t *testing.T) {
+	ctx := context.Background()
+	from := models.Coordinates{Latitude: 39.6801, Longitude: -105.0202}
+	shopPin := models.Coordinates{Latitude: 39.7392, Longitude: -104.9903}
+	shop := models.ShopLocation{
+		LocationBase: models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeShop},
+		Shop:         models.Shop{Name: "Downtown", Coordinates: &shopPin},
+	}
+	event := func(arguments string) AppSyncEvent {
+		return AppSyncEvent{Field: "etaToLocation", Arguments: json.RawMessage(arguments)}
+	}
+
+	t.Run("Routes to a shop's pin", func(t *testing.T) {
+		mockRepo, router := new(mockRepository), new(mockRoutingProvider)
+		handler := NewAppSyncHandler(mockRepo, WithRoutingProvider(router))
+
+		mockRepo.On("Get", ctx, "acc-12345", "shop-001").Return(withID("shop-001", shop), nil).Once()
+		router.On("Route", ctx, from, shopPin, routing.ModeTruck).Return(&routing.Route{DurationSeconds: 731, DistanceMeters: 8412}, nil).Once()
+
+		result, err := handler.Handle(ctx, event(`{"accountId": "acc-12345", "fromLat": 39.6801, "fromLon": -105.0202, "locationId": "shop-001", "mode": "truck"}`))
+		require.NoError(t, err)
+		eta := result.(*EtaResponse)
+		assert.Equal(t, "shop-001", eta.LocationID)
+		assert.Equal(t, routing.ModeTruck, eta.Mode)
+		assert.Equal(t, shopPin, eta.Destination)
+		assert.Equal(t, 731.0, eta.DurationSeconds)
+		assert.Equal(t, 8412.0, eta.DistanceMeters)
+		assert.WithinDuration(t, time.Now().Add(731*time.Second), eta.ArrivesAt, 5*time.Second)
+	})
+
+	t.Run("Drives by default", func(t *testing.T) {
+		mockRepo, router := new(mockRepository), new(mockRoutingProvider)
+		handler := NewAppSyncHandler(mockRepo, WithRoutingProvider(router))
+
+		mockRepo.On("Get", ctx, "acc-12345", "loc-001").Return(withID("loc-001", coordinatesAt(39.7392, -104.9903)), nil).Once()
+		router.On("Route", ctx, from, mock.Anything, routing.ModeCar).Return(&routing.Route{DurationSeconds: 600, DistanceMeters: 8000}, nil).Once()
+
+		_, err := handler.Handle(ctx, event(`{"accountId": "acc-12345", "fromLat": 39.6801, "fromLon": -105.0202, "locationId": "loc-001"}`))
+		require.NoError(t, err)
+		router.AssertExpectations(t)
+	})
+
+	t.Run("No route", func(t *testing.T) {
+		mockRepo, router := new(mockRepository), new(mockRoutingProvider)
+		handler := NewAppSyncHandler(mockRepo, WithRoutingProvider(router))
+
+		mockRepo.On("Get", ctx, "acc-12345", "shop-001").Return(withID("shop-001", shop), nil).Once()
+		router.On("Route", ctx, from, shopPin, routing.ModePedestrian).Return(nil, routing.ErrNoRoute).Once()
+
+		_, err := handler.Handle(ctx, event(`{"accountId": "acc-12345", "fromLat": 39.6801, "fromLon": -105.0202, "locationId": "shop-001", "mode": "pedestrian"}`))
+		assert.EqualError(t, err, "no pedestrian route to location shop-001")
+	})
+
+	t.Run("Shop without a pin", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		handler := NewAppSyncHandler(mockRepo, WithRoutingProvider(new(mockRoutingProvider)))
+
+		unpinned := shop
+		unpinned.Shop.Coordinates = nil
+		mockRepo.On("Get", ctx, "acc-12345", "shop-001").Return(withID("shop-001", unpinned), nil).Once()
+
+		_, err := handler.Handle(ctx, event(`{"accountId": "acc-12345", "fromLat": 39.6801, "fromLon": -105.0202, "locationId": "shop-001"}`))
+		assert.EqualError(t, err, "location has no stored coordinates")
+	})
+
+	tests := []struct {
+		name      string
+		arguments string
+		errMsg    string
+	}{
+		{name: "Missing location", arguments: `{"accountId": "acc-12345", "fromLat": 39.68, "fromLon": -105.02}`, errMsg: "accountId and locationId are required"},
+		{name: "Bad origin", arguments: `{"accountId": "acc-12345", "fromLat": 95, "fromLon": -105.02, "locationId": "loc-001"}`, errMsg: "latitude must be between -90 and 90, got 95.000000"},
+		{name: "Bad mode", arguments: `{"accountId": "acc-12345", "fromLat": 39.68, "fromLon": -105.02, "locationId": "loc-001", "mode": "bicycle"}`, errMsg: "mode must be one of car, truck, scooter, or pedestrian"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewAppSyncHandler(new(mockRepository), WithRoutingProvider(new(mockRoutingProvider))).Handle(ctx, event(tt.arguments))
+			assert.EqualError(t, err, tt.errMsg)
+		})
+	}
+
+	t.Run("Not configured", func(t *testing.T) {
+		_, err := NewAppSyncHandler(new(mockRepository)).Handle(ctx, event(`{"accountId": "acc-12345", "fromLat": 39.68, "fromLon": -105.02, "locationId": "loc-001"}`))
+		assert.EqualError(t, err, "ETAs are not configured")
+	})
+}
@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"reflect"
+
+	"github.com/steverhoton/location-lambda/internal/filterable"
+	"github.com/steverhoton/location-lambda/internal/geofence"
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/repository"
+)
+
+// GetGeofencesArguments represents arguments for reading an account's geofences.
+type GetGeofencesArguments struct {
+	AccountID string `json:"accountId"`
+}
+
+// PutGeofencesArguments represents arguments for replacing an account's geofences.
+type PutGeofencesArguments struct {
+	AccountID string            `json:"accountId"`
+	Geofences []models.Geofence `json:"geofences"`
+}
+
+// WithGeofenceStore enables the geofence operations.
+func WithGeofenceStore(store repository.GeofenceStore) Option {
+	return func(h *AppSyncHandler) {
+		h.geofences = store
+	}
+}
+
+// WithGeofencePublisher has position updates of coordinates locations
+// evaluated against their account's geofences, publishing the crossings.
+func WithGeofencePublisher(publisher geofence.Publisher) Option {
+	return func(h *AppSyncHandler) {
+		h.geofencePublisher = publisher
+	}
+}
+
+// geofenceStore returns the configured geofence store or an error when geofences are disabled.
+func (h *AppSyncHandler) geofenceStore() (repository.GeofenceStore, error) {
+	if h.geofences == nil {
+		return nil, fmt.Errorf("geofences are not configured")
+	}
+	return h.geofences, nil
+}
+
+func (h *AppSyncHandler) handleGetGeofences(ctx context.Context, arguments json.RawMessage) ([]models.Geofence, error) {
+	var args GetGeofencesArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+
+	store, err := h.geofenceStore()
+	if err != nil {
+		return nil, err
+	}
+
+	geofences, err := store.GetGeofences(ctx, args.AccountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get geofences: %w", err)
+	}
+
+	return geofences, nil
+}
+
+func (h *AppSyncHandler) handlePutGeofences(ctx context.Context, arguments json.RawMessage) ([]models.Geofence, error) {
+	var args PutGeofencesArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+
+	store, err := h.geofenceStore()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := store.PutGeofences(ctx, args.AccountID, args.Geofences); err != nil {
+		return nil, fmt.Errorf("failed to put geofences: %w", err)
+	}
+
+	return args.Geofences, nil
+}
+
+// evaluateGeofences publishes the geofence crossings of an updated
+// coordinates location. Positions flagged by the account's position checks
+// are not evaluated. The location is already saved, so failures are logged
+// rather than failing the update; the presence is saved only after the
+// crossings are published, so the next update publishes them again instead.
+func (h *AppSyncHandler) evaluateGeofences(ctx context.Context, location models.Location, locationID string) {
+	moved, ok := location.(models.CoordinatesLocation)
+	if !ok || moved.PositionAnomaly != nil || h.geofences == nil || h.geofencePublisher == nil {
+		return
+	}
+
+	geofences, err := h.geofences.GetGeofences(ctx, moved.AccountID)
+	if err != nil {
+		log.Printf("WARN: Failed to evaluate geofences for location %s: %v", locationID, err)
+		return
+	}
+	presence, err := h.geofences.GetGeofencePresence(ctx, moved.AccountID, locationID)
+	if err != nil {
+		log.Printf("WARN: Failed to evaluate geofences for location %s: %v", locationID, err)
+		return
+	}
+	if len(geofences) == 0 && len(presence) == 0 {
+		return
+	}
+
+	at := h.clock.Now().UTC()
+	if moved.Coordinates.ObservedAt != nil {
+		at = *moved.Coordinates.ObservedAt
+	}
+	transitions, next := geofence.Evaluate(geofences, presence, moved.AccountID, locationID, moved.Coordinates, at)
+	fields := filterable.For(moved)
+	for i := range transitions {
+		transitions[i].FilterableFields = &fields
+	}
+	if len(transitions) > 0 {
+		if err := h.geofencePublisher.Publish(ctx, transitions); err != nil {
+			log.Printf("WARN: Failed to publish geofence events for location %s: %v", locationID, err)
+			return
+		}
+	}
+	if len(next) == len(presence) && (len(next) == 0 || reflect.DeepEqual(next, presence)) {
+		return
+	}
+	if err := h.geofences.PutGeofencePresence(ctx, moved.AccountID, locationID, next); err != nil {
+		log.Printf("WARN: Failed to save geofence presence of location %s: %v", locationID, err)
+	}
+}
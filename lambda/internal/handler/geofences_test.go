@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/steverhoton/location-lambda/internal/geofence"
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockGeofenceStore is a mock implementation of the repository.GeofenceStore interface.
+type mockGeofenceStore struct {
+	mock.Mock
+}
+
+func (m *mockGeofenceStore) GetGeofences(ctx context.Context, accountID string) ([]models.Geofence, error) {
+	args := m.Called(ctx, accountID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Geofence), args.Error(1)
+}
+
+func (m *mockGeofenceStore) PutGeofences(ctx context.Context, accountID string, geofences []models.Geofence) error {
+	args := m.Called(ctx, accountID, geofences)
+	return args.Error(0)
+}
+
+func (m *mockGeofenceStore) GetGeofencePresence(ctx context.Context, accountID, locationID string) (map[string]models.GeofencePresence, error) {
+	args := m.Called(ctx, accountID, locationID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]models.GeofencePresence), args.Error(1)
+}
+
+func (m *mockGeofenceStore) PutGeofencePresence(ctx context.Context, accountID, locationID string, presence map[string]models.GeofencePresence) error {
+	args := m.Called(ctx, accountID, locationID, presence)
+	return args.Error(0)
+}
+
+// mockGeofencePublisher is a mock implementation of the geofence.Publisher interface.
+type mockGeofencePublisher struct {
+	mock.Mock
+}
+
+func (m *mockGeofencePublisher) Publish(ctx context.Context, transitions []geofence.Transition) error {
+	args := m.Called(ctx, transitions)
+	return args.Error(0)
+}
+
+func TestAppSyncHandlerGeofences(t *testing.T) {
+	ctx := context.Background()
+	depot := models.Geofence{
+		GeofenceID:   "depot",
+		Name:         "Denver depot",
+		Center:       &models.Coordinates{Latitude: 39.7392, Longitude: -104.9903},
+		RadiusMeters: 500,
+	}
+	moveTruck := AppSyncEvent{Field: "updateLocation", Arguments: json.RawMessage(`{
+		"accountId": "acc-12345",
+		"locationId": "loc-001",
+		"input": {
+			"accountId": "acc-12345",
+			"locationType": "coordinates",
+			"coordinates": {"latitude": 39.7395, "longitude": -104.9900, "observedAt": "2024-06-01T12:00:00Z"}
+		}
+	}`)}
+
+	t.Run("Put and get geofences", func(t *testing.T) {
+		store := new(mockGeofenceStore)
+		handler := NewAppSyncHandler(new(mockRepository), WithGeofenceStore(store))
+
+		store.On("PutGeofences", ctx, "acc-12345", []models.Geofence{depot}).Return(nil).Once()
+		store.On("GetGeofences", ctx, "acc-12345").Return([]models.Geofence{depot}, nil).Once()
+
+		result, err := handler.Handle(ctx, AppSyncEvent{
+			Field: "putGeofences",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "geofences": [
+				{"geofenceId": "depot", "name": "Denver depot", "center": {"latitude": 39.7392, "longitude": -104.9903}, "radiusMeters": 500}
+			]}`),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []models.Geofence{depot}, result)
+
+		result, err = handler.Handle(ctx, AppSyncEvent{Field: "getGeofences", Arguments: json.RawMessage(`{"accountId": "acc-12345"}`)})
+		require.NoError(t, err)
+		assert.Equal(t, []models.Geofence{depot}, result)
+		store.AssertExpectations(t)
+	})
+
+	t.Run("Not configured", func(t *testing.T) {
+		handler := NewAppSyncHandler(new(mockRepository))
+
+		_, err := handler.Handle(ctx, AppSyncEvent{Field: "getGeofences", Arguments: json.RawMessage(`{"accountId": "acc-12345"}`)})
+		assert.EqualError(t, err, "geofences are not configured")
+	})
+
+	t.Run("Publishes an entry and saves the presence", func(t *testing.T) {
+		repo, store, publisher := new(mockRepository), new(mockGeofenceStore), new(mockGeofencePublisher)
+		handler := NewAppSyncHandler(repo, WithGeofenceStore(store), WithGeofencePublisher(publisher))
+
+		repo.On("Update", mock.Anything, mock.Anything, "loc-001").Return(nil).Once()
+		store.On("GetGeofences", mock.Anything, "acc-12345").Return([]models.Geofence{depot}, nil).Once()
+		store.On("GetGeofencePresence", mock.Anything, "acc-12345", "loc-001").Return(map[string]models.GeofencePresence{}, nil).Once()
+		publisher.On("Publish", mock.Anything, mock.MatchedBy(func(transitions []geofence.Transition) bool {
+			return len(transitions) == 1 && transitions[0].Type == geofence.Enter && transitions[0].GeofenceID == "depot" &&
+				transitions[0].LocationID == "loc-001" && transitions[0].At.Equal(time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)) &&
+				transitions[0].FilterableFields != nil && transitions[0].FilterableFields.Geohash == "9xj64f"
+		})).Return(nil).Once()
+		store.On("PutGeofencePresence", mock.Anything, "acc-12345", "loc-001", map[string]models.GeofencePresence{"depot": {Inside: true}}).Return(nil).Once()
+
+		result, err := handler.Handle(ctx, moveTruck)
+		require.NoError(t, err)
+		assert.True(t, result.(*UpdateResponse).Success)
+		store.AssertExpectations(t)
+		publisher.AssertExpectations(t)
+	})
+
+	t.Run("A failed publish keeps the presence for the next update", func(t *testing.T) {
+		repo, store, publisher := new(mockRepository), new(mockGeofenceStore), new(mockGeofencePublisher)
+		handler := NewAppSyncHandler(repo, WithGeofenceStore(store), WithGeofencePublisher(publisher))
+
+		repo.On("Update", mock.Anything, mock.Anything, "loc-001").Return(nil).Once()
+		store.On("GetGeofences", mock.Anything, "acc-12345").Return([]models.Geofence{depot}, nil).Once()
+		store.On("GetGeofencePresence", mock.Anything, "acc-12345", "loc-001").Return(map[string]models.GeofencePresence{}, nil).Once()
+		publisher.On("Publish", mock.Anything, mock.Anything).Return(errors.New("throttled")).Once()
+
+		result, err := handler.Handle(ctx, moveTruck)
+		require.NoError(t, err)
+		assert.True(t, result.(*UpdateResponse).Success)
+		store.AssertNotCalled(t, "PutGeofencePresence", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Unchanged presence is not written", func(t *testing.T) {
+		repo, store, publisher := new(mockRepository), new(mockGeofenceStore), new(mockGeofencePublisher)
+		handler := NewAppSyncHandler(repo, WithGeofenceStore(store), WithGeofencePublisher(publisher))
+
+		repo.On("Update", mock.Anything, mock.Anything, "loc-001").Return(nil).Once()
+		store.On("GetGeofences", mock.Anything, "acc-12345").Return([]models.Geofence{depot}, nil).Once()
+		store.On("GetGeofencePresence", mock.Anything, "acc-12345", "loc-001").Return(map[string]models.GeofencePresence{"depot": {Inside: true}}, nil).Once()
+
+		_, err := handler.Handle(ctx, moveTruck)
+		require.NoError(t, err)
+		publisher.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+		store.AssertNotCalled(t, "PutGeofencePresence", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/steverhoton/location-lambda/internal/geocode"
+	"github.com/steverhoton/location-lambda/internal/repository"
+)
+
+// RefreshStaleGeocodesArguments represents arguments for the scheduled geocode refresh.
+type RefreshStaleGeocodesArguments struct {
+	AccountIDs []string `json:"accountIds"`
+	// MaxAge is the Go duration after which a geocode is stale, e.g. "2160h";
+	// "0s" refreshes only changed addresses
+	MaxAge string `json:"maxAge,omitempty"`
+	// Rate caps geocode requests per second; 0 leaves them unlimited
+	Rate   float64 `json:"rate,omitempty"`
+	DryRun bool    `json:"dryRun,omitempty"`
+}
+
+// GeocodeRefreshReport is the result of refreshing one account's geocodes.
+type GeocodeRefreshReport struct {
+	AccountID string `json:"accountId"`
+	*geocode.RefreshResult
+}
+
+// WithGeocodeWriter enables refreshStaleGeocodes, which also needs WithGeocoder.
+func WithGeocodeWriter(writer repository.GeocodeWriter) Option {
+	return func(h *AppSyncHandler) {
+		h.geocodes = writer
+	}
+}
+
+// handleRefreshStaleGeocodes geocodes each account's stale address locations
+// again. It is invoked on a schedule rather than through the API, so it is
+// not in the GraphQL schema. Every account is refreshed even when one fails;
+// the failures are then returned together so the invocation is retried.
+func (h *AppSyncHandler) handleRefreshStaleGeocodes(ctx context.Context, arguments json.RawMessage) ([]GeocodeRefreshReport, error) {
+	var args RefreshStaleGeocodesArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+
+	if len(args.AccountIDs) == 0 {
+		return nil, fmt.Errorf("accountIds is required")
+	}
+	maxAge := geocode.DefaultRefreshMaxAge
+	if args.MaxAge != "" {
+		var err error
+		if maxAge, err = time.ParseDuration(args.MaxAge); err != nil || maxAge < 0 {
+			return nil, fmt.Errorf("invalid maxAge %q: must be a non-negative duration", args.MaxAge)
+		}
+	}
+	if args.Rate < 0 {
+		return nil, fmt.Errorf("rate must not be negative")
+	}
+	if h.geocoder == nil || h.geocodes == nil {
+		return nil, fmt.Errorf("geocoding is not configured")
+	}
+
+	refresher := geocode.NewRefresher(h.geocoder, struct {
+		repository.Repository
+		repository.GeocodeWriter
+	}{h.repo, h.geocodes})
+	options := geocode.RefreshOptions{MaxAge: maxAge, Rate: args.Rate, DryRun: args.DryRun}
+
+	reports := make([]GeocodeRefreshReport, 0, len(args.AccountIDs))
+	var failures []error
+	for _, accountID := range args.AccountIDs {
+		result, err := refresher.Run(ctx, accountID, options)
+		if err != nil {
+			failures = append(failures, fmt.Errorf("failed to refresh geocodes of account %s after %d refreshed: %w", accountID, len(result.Refreshed), err))
+			continue
+		}
+		reports = append(reports, GeocodeRefreshReport{AccountID: accountID, RefreshResult: result})
+	}
+	if len(failures) > 0 {
+		return nil, errors.Join(failures...)
+	}
+	return reports, nil
+}
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/steverhoton/location-lambda/internal/geocode"
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockGeocoder is a mock implementation of the geocode.Geocoder interface.
+type mockGeocoder struct {
+	mock.Mock
+}
+
+func (m *mockGeocoder) Name() string {
+	return "mock_geocoder"
+}
+
+func (m *mockGeocoder) Geocode(ctx context.Context, query string) (*geocode.Result, error) {
+	args := m.Called(ctx, query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*geocode.Result), args.Error(1)
+}
+
+// mockGeocodeWriter is a mock implementation of the repository.GeocodeWriter interface.
+type mockGeocodeWriter struct {
+	mock.Mock
+}
+
+func (m *mockGeocodeWriter) SetGeocode(ctx context.Context, accountID, locationID string, address models.Address, coordinates models.Coordinates, info models.GeocodeInfo) (bool, error) {
+	args := m.Called(ctx, accountID, locationID, address, coordinates, info)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockGeocodeWriter) RefreshGeocode(ctx context.Context, accountID, locationID string, previous models.GeocodeInfo, address models.Address, coordinates models.Coordinates, info models.GeocodeInfo) (bool, error) {
+	args := m.Called(ctx, accountID, locationID, previous, address, coordinates, info)
+	return args.Bool(0), args.Error(1)
+}
+
+func TestAppSyncHandlerRefreshStaleGeocodes(t *testing.T) {
+	ctx := context.Background()
+	address := models.Address{StreetAddress: "123 Main St", City: "Springfield", StateProvince: "IL", PostalCode: "62704", Country: "US"}
+	previous := models.GeocodeInfo{Provider: "mock_geocoder", Confidence: 0.6, GeocodedAt: time.Now().Add(-365 * 24 * time.Hour).UTC(), AddressHash: models.AddressHash(address)}
+	stale := models.AddressLocation{
+		LocationBase: models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeAddress},
+		Address:      address,
+		Coordinates:  &models.Coordinates{Latitude: 39.79, Longitude: -89.64},
+		Geocode:      &previous,
+	}
+	page := &repository.ListResult{Items: []repository.LocationEnvelope{{LocationID: "loc-001", Location: stale}}}
+
+	t.Run("Refreshes each account's stale geocodes", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		geocoder := new(mockGeocoder)
+		writer := new(mockGeocodeWriter)
+		handler := NewAppSyncHandler(mockRepo, WithGeocoder(geocoder), WithGeocodeWriter(writer))
+
+		mockRepo.On("List", ctx, "acc-12345", mock.MatchedBy(func(options *repository.ListOptions) bool { return options.Geocoded })).Return(page, nil).Once()
+		mockRepo.On("List", ctx, "acc-67890", mock.Anything).Return(&repository.ListResult{}, nil).Once()
+		coordinates := models.Coordinates{Latitude: 39.7817, Longitude: -89.6501}
+		geocoder.On("Geocode", ctx, "123 Main St, Springfield, IL 62704, US").Return(&geocode.Result{Coordinates: coordinates, Relevance: 0.98}, nil).Once()
+		writer.On("RefreshGeocode", ctx, "acc-12345", "loc-001", previous, address, coordinates, mock.MatchedBy(func(info models.GeocodeInfo) bool {
+			return info.Confidence == 0.98 && info.GeocodedAt.After(previous.GeocodedAt)
+		})).Return(true, nil).Once()
+
+		result, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "refreshStaleGeocodes",
+			Arguments: json.RawMessage(`{"accountIds": ["acc-12345", "acc-67890"], "maxAge": "2160h"}`),
+		})
+		require.NoError(t, err)
+		reports := result.([]GeocodeRefreshReport)
+		require.Len(t, reports, 2)
+		assert.Equal(t, []string{"loc-001"}, reports[0].Refreshed)
+		assert.Empty(t, reports[1].Refreshed)
+		mockRepo.AssertExpectations(t)
+		writer.AssertExpectations(t)
+	})
+
+	t.Run("Refreshes the other accounts when one fails", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		handler := NewAppSyncHandler(mockRepo, WithGeocoder(new(mockGeocoder)), WithGeocodeWriter(new(mockGeocodeWriter)))
+
+		mockRepo.On("List", ctx, "acc-12345", mock.Anything).Return(nil, errors.New("throttled")).Once()
+		mockRepo.On("List", ctx, "acc-67890", mock.Anything).Return(&repository.ListResult{}, nil).Once()
+
+		_, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "refreshStaleGeocodes",
+			Arguments: json.RawMessage(`{"accountIds": ["acc-12345", "acc-67890"]}`),
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to refresh geocodes of account acc-12345 after 0 refreshed: throttled")
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Validates arguments", func(t *testing.T) {
+		handler := NewAppSyncHandler(new(mockRepository), WithGeocoder(new(mockGeocoder)), WithGeocodeWriter(new(mockGeocodeWriter)))
+		for arguments, message := range map[string]string{
+			`{}`: "accountIds is required",
+			`{"accountIds": ["acc-12345"], "maxAge": "90 days"}`: `invalid maxAge "90 days": must be a non-negative duration`,
+			`{"accountIds": ["acc-12345"], "rate": -1}`:          "rate must not be negative",
+		} {
+			_, err := handler.Handle(ctx, AppSyncEvent{Field: "refreshStaleGeocodes", Arguments: json.RawMessage(arguments)})
+			assert.EqualError(t, err, message)
+		}
+	})
+
+	t.Run("Requires geocoding", func(t *testing.T) {
+		handler := NewAppSyncHandler(new(mockRepository))
+
+		_, err := handler.Handle(ctx, AppSyncEvent{Field: "refreshStaleGeocodes", Arguments: json.RawMessage(`{"accountIds": ["acc-12345"]}`)})
+		assert.EqualError(t, err, "geocoding is not configured")
+	})
+}
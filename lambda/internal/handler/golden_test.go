@@ -0,0 +1,243 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// updateGolden rewrites the golden files from the current responses:
+//
+//	go test ./internal/handler -run TestGoldenResponses -update
+var updateGolden = flag.Bool("update", false, "rewrite golden response files")
+
+// goldenTime is the fixed timestamp used in golden responses.
+var goldenTime = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+// goldenLocations returns one location of each type.
+func goldenLocations() map[string]models.Location {
+	altitude := 10.5
+	return map[string]models.Location{
+		"address": models.AddressLocation{
+			LocationBase: models.LocationBase{
+				AccountID:          "acc-12345",
+				LocationType:       models.LocationTypeAddress,
+				ExtendedAttributes: map[string]interface{}{"floor": "3"},
+				ExternalID:         "ERP-1",
+			},
+			Address: models.Address{
+				StreetAddress:  "123 Main St",
+				StreetAddress2: "Suite 100",
+				City:           "Springfield",
+				StateProvince:  "IL",
+				PostalCode:     "62704",
+				Country:        "US",
+			},
+		},
+		"coordinates": models.CoordinatesLocation{
+			LocationBase: models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeCoordinates},
+			Coordinates:  models.Coordinates{Latitude: 40.7128, Longitude: -74.006, Altitude: &altitude},
+		},
+		"shop": models.ShopLocation{
+			LocationBase: models.LocationBase{
+				AccountID:    "acc-12345",
+				LocationType: models.LocationTypeShop,
+				CustomFields: map[string]interface{}{"storeNumber": "042"},
+			},
+			Shop: models.Shop{
+				Name:      "Downtown Store",
+				ContactID: "contact-1",
+				Address: models.Address{
+					StreetAddress: "456 Oak Ave",
+					City:          "Springfield",
+					StateProvince: "IL",
+					PostalCode:    "62701",
+					Country:       "US",
+				},
+			},
+		},
+	}
+}
+
+// assertGolden compares the JSON encoding of got with testdata/golden/name.json.
+func assertGolden(t *testing.T, name string, got interface{}) {
+	t.Helper()
+
+	actual, err := json.MarshalIndent(got, "", "  ")
+	require.NoError(t, err)
+	actual = append(actual, '\n')
+
+	path := filepath.Join("testdata", "golden", name+".json")
+	if *updateGolden {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, actual, 0o644))
+		return
+	}
+
+	expected, err := os.ReadFile(path)
+	require.NoError(t, err, "missing golden file; run with -update to create it")
+	assert.Equal(t, string(expected), string(actual), "response shape of %s changed; run with -update if intended", name)
+}
+
+func TestGoldenResponses(t *testing.T) {
+	ctx := context.Background()
+	locations := goldenLocations()
+
+	for _, locationType := range []string{"address", "coordinates", "shop"} {
+		location := locations[locationType]
+
+		t.Run("getLocation "+locationType, func(t *testing.T) {
+			mockRepo := new(mockRepository)
+			mockRepo.On("Get", mock.Anything, "acc-12345", "loc-001").Return(withID("loc-001", location), nil).Once()
+
+			result, err := NewAppSyncHandler(mockRepo).Handle(ctx, AppSyncEvent{
+				Field:     "getLocation",
+				Arguments: json.RawMessage(`{"accountId": "acc-12345", "locationId": "loc-001"}`),
+			})
+			require.NoError(t, err)
+			assertGolden(t, "getLocation_"+locationType, result)
+		})
+
+		t.Run("getLocation with links "+locationType, func(t *testing.T) {
+			mockRepo := new(mockRepository)
+			mockRepo.On("Get", mock.Anything, "acc-12345", "loc-001").Return(withID("loc-001", location), nil).Once()
+
+			result, err := NewAppSyncHandler(mockRepo).Handle(ctx, AppSyncEvent{
+				Field:     "getLocation",
+				Arguments: json.RawMessage(`{"accountId": "acc-12345", "locationId": "loc-001", "includeLinks": true}`),
+			})
+			require.NoError(t, err)
+			assertGolden(t, "getLocation_links_"+locationType, result)
+		})
+	}
+
+	t.Run("getLocationByExternalId", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		mockRepo.On("GetByExternalID", mock.Anything, "acc-12345", "ERP-1").Return(withID("loc-001", locations["address"]), nil).Once()
+
+		result, err := NewAppSyncHandler(mockRepo).Handle(ctx, AppSyncEvent{
+			Field:     "getLocationByExternalId",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "externalId": "ERP-1"}`),
+		})
+		require.NoError(t, err)
+		assertGolden(t, "getLocationByExternalId", result)
+	})
+
+	t.Run("listLocations", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		mockRepo.On("List", mock.Anything, "acc-12345", mock.Anything).Return(&repository.ListResult{
+			Items: []repository.LocationEnvelope{
+				{LocationID: "loc-001", Location: locations["address"]},
+				{LocationID: "loc-002", Location: locations["coordinates"]},
+				{LocationID: "loc-003", Location: locations["shop"]},
+			},
+			NextCursor: aws.String("eyJsYXN0In0="),
+		}, nil).Once()
+
+		result, err := NewAppSyncHandler(mockRepo).Handle(ctx, AppSyncEvent{
+			Field:     "listLocations",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "limit": 3}`),
+		})
+		require.NoError(t, err)
+		assertGolden(t, "listLocations", result)
+	})
+
+	t.Run("createLocation", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		mockRepo.On("Create", mock.Anything, mock.Anything).Return("loc-001", nil).Once()
+
+		result, err := NewAppSyncHandler(mockRepo).Handle(ctx, AppSyncEvent{
+			Field: "createLocation",
+			Arguments: json.RawMessage(`{"input": {"accountId": "acc-12345", "locationType": "coordinates",
+				"coordinates": {"latitude": 40.7128, "longitude": -74.006}}}`),
+		})
+		require.NoError(t, err)
+		assertGolden(t, "createLocation", result)
+	})
+
+	t.Run("updateLocation", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		mockRepo.On("Update", mock.Anything, mock.Anything, "loc-001").Return(nil).Once()
+
+		result, err := NewAppSyncHandler(mockRepo).Handle(ctx, AppSyncEvent{
+			Field: "updateLocation",
+			Arguments: json.RawMessage(`{"locationId": "loc-001", "input": {"accountId": "acc-12345", "locationType": "coordinates",
+				"coordinates": {"latitude": 40.7128, "longitude": -74.006}}}`),
+		})
+		require.NoError(t, err)
+		assertGolden(t, "updateLocation", result)
+	})
+
+	t.Run("deleteLocation", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		mockRepo.On("Delete", mock.Anything, "acc-12345", "loc-001").Return(nil).Once()
+
+		result, err := NewAppSyncHandler(mockRepo).Handle(ctx, AppSyncEvent{
+			Field:     "deleteLocation",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "locationId": "loc-001"}`),
+		})
+		require.NoError(t, err)
+		assertGolden(t, "deleteLocation", result)
+	})
+
+	t.Run("upsertLocationByExternalId", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		mockRepo.On("Upsert", mock.Anything, mock.Anything).Return(&repository.UpsertResult{LocationID: "loc-001", Created: true}, nil).Once()
+
+		result, err := NewAppSyncHandler(mockRepo).Handle(ctx, AppSyncEvent{
+			Field: "upsertLocationByExternalId",
+			Arguments: json.RawMessage(`{"input": {"accountId": "acc-12345", "locationType": "coordinates", "externalId": "ERP-1",
+				"coordinates": {"latitude": 40.7128, "longitude": -74.006}}}`),
+		})
+		require.NoError(t, err)
+		assertGolden(t, "upsertLocationByExternalId", result)
+	})
+
+	t.Run("mergeLocations", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		mockRepo.On("Merge", mock.Anything, "acc-12345", "loc-001", []string{"loc-002"}).Return(&repository.MergeResult{
+			MergeID:         "merge-1",
+			AccountID:       "acc-12345",
+			SurvivorID:      "loc-001",
+			DuplicateIDs:    []string{"loc-002"},
+			MergedAt:        goldenTime,
+			AddedAttributes: []string{"floor"},
+		}, nil).Once()
+
+		result, err := NewAppSyncHandler(mockRepo).Handle(ctx, AppSyncEvent{
+			Field:     "mergeLocations",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "survivorId": "loc-001", "duplicateIds": ["loc-002"]}`),
+		})
+		require.NoError(t, err)
+		assertGolden(t, "mergeLocations", result)
+	})
+
+	t.Run("eraseLocationData", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		mockRepo.On("Erase", mock.Anything, "acc-12345", "loc-001").Return(&repository.ErasureCertificate{
+			CertificateID: "cert-1",
+			AccountID:     "acc-12345",
+			LocationID:    "loc-001",
+			ErasedAt:      goldenTime,
+			RecordErased:  true,
+		}, nil).Once()
+
+		result, err := NewAppSyncHandler(mockRepo).Handle(ctx, AppSyncEvent{
+			Field:     "eraseLocationData",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "locationId": "loc-001"}`),
+		})
+		require.NoError(t, err)
+		assertGolden(t, "eraseLocationData", result)
+	})
+}
@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/steverhoton/location-lambda/internal/repository"
+)
+
+// Health statuses, from best to worst.
+const (
+	HealthStatusOK       = "ok"
+	HealthStatusDegraded = "degraded"
+	HealthStatusDown     = "down"
+)
+
+// healthCheckTimeout bounds the dependency probes of one health check.
+const healthCheckTimeout = 2 * time.Second
+
+// slowDependencyThreshold marks a dependency degraded when it answers more slowly.
+const slowDependencyThreshold = 500 * time.Millisecond
+
+// HealthStatus is the response of healthCheck. Status is the worst status of its checks.
+type HealthStatus struct {
+	Status    string        `json:"status"`
+	Checks    []HealthCheck `json:"checks"`
+	Features  []string      `json:"features"` // Optional features that are configured
+	CheckedAt time.Time     `json:"checkedAt"`
+}
+
+// HealthCheck is the result of checking one dependency or aspect of configuration.
+type HealthCheck struct {
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+	LatencyMs *int64 `json:"latencyMs,omitempty"`
+	Message   string `json:"message,omitempty"`
+}
+
+// WithHealthChecker enables table probes in healthCheck.
+func WithHealthChecker(checker repository.HealthChecker) Option {
+	return func(h *AppSyncHandler) {
+		h.health = checker
+	}
+}
+
+// WithConfigIssues reports configuration problems found at startup through
+// healthCheck, such as an admin group without the index its lookups need.
+func WithConfigIssues(issues []string) Option {
+	return func(h *AppSyncHandler) {
+		h.configIssues = issues
+	}
+}
+
+func (h *AppSyncHandler) handleHealthCheck(ctx context.Context) (*HealthStatus, error) {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	checks := []HealthCheck{h.configurationCheck()}
+	if h.health == nil {
+		checks = append(checks, HealthCheck{
+			Name:    "dynamodb",
+			Status:  HealthStatusDegraded,
+			Message: "the repository does not support health checks",
+		})
+	} else {
+		for _, table := range h.health.CheckHealth(ctx) {
+			checks = append(checks, tableCheck(table))
+		}
+	}
+
+	status := HealthStatusOK
+	for _, check := range checks {
+		status = worseStatus(status, check.Status)
+	}
+
+	return &HealthStatus{
+		Status:    status,
+		Checks:    checks,
+		Features:  h.features(),
+		CheckedAt: h.clock.Now().UTC(),
+	}, nil
+}
+
+// configurationCheck reports the configuration issues found at startup.
+func (h *AppSyncHandler) configurationCheck() HealthCheck {
+	if len(h.configIssues) == 0 {
+		return HealthCheck{Name: "configuration", Status: HealthStatusOK}
+	}
+	return HealthCheck{Name: "configuration", Status: HealthStatusDegraded, Message: strings.Join(h.configIssues, "; ")}
+}
+
+// tableCheck converts a table probe into a health check.
+func tableCheck(table repository.TableHealth) HealthCheck {
+	latency := table.Latency.Milliseconds()
+	check := HealthCheck{Name: "dynamodb:" + table.Name, Status: HealthStatusOK, LatencyMs: &latency}
+	switch {
+	case table.Err != nil:
+		check.Status = HealthStatusDown
+		check.Message = table.Err.Error()
+	case table.Latency > slowDependencyThreshold:
+		check.Status = HealthStatusDegraded
+		check.Message = "slow response"
+	}
+	return check
+}
+
+// worseStatus returns the worse of two health statuses.
+func worseStatus(a, b string) string {
+	rank := map[string]int{HealthStatusOK: 0, HealthStatusDegraded: 1, HealthStatusDown: 2}
+	if rank[b] > rank[a] {
+		return b
+	}
+	return a
+}
+
+// features lists the optional features the handler was configured with.
+func (h *AppSyncHandler) features() []string {
+	features := []string{}
+	for _, feature := range []struct {
+		name    string
+		enabled bool
+	}{
+		{"templates", h.templates != nil},
+		{"customFields", h.customFields != nil},
+		{"geofenceEvents", h.geofences != nil && h.geofencePublisher != nil},
+		{"positionHistory", h.positions != nil},
+		{"accountSettings", h.settings != nil},
+		{"accountHierarchy", h.hierarchy != nil},
+		{"admin", h.admin != nil && h.adminGroup != ""},
+		{"permissions", h.permissions != nil},
+		{"requestSigning", h.signingSecrets != nil},
+		{"replayProtection", h.replayGuard != nil && h.settings != nil},
+		{"usageMetering", h.usage != nil},
+		{"geocoding", h.geocoder != nil},
+		{"addressVerification", h.verifier != nil},
+		{"enrichment", h.places != nil},
+		{"locationContext", h.weather != nil},
+		{"elevation", h.elevation != nil},
+		{"staticMaps", h.staticMap != nil},
+		{"eta", h.router != nil},
+		{"responseCompression", h.compressionThreshold > 0},
+	} {
+		if feature.enabled {
+			features = append(features, feature.name)
+		}
+	}
+	return features
+}
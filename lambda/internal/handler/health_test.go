@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/steverhoton/location-lambda/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockHealthChecker is a mock implementation of the repository.HealthChecker interface.
+type mockHealthChecker struct {
+	mock.Mock
+}
+
+func (m *mockHealthChecker) CheckHealth(ctx context.Context) []repository.TableHealth {
+	args := m.Called(ctx)
+	return args.Get(0).([]repository.TableHealth)
+}
+
+func TestAppSyncHandlerHealthCheck(t *testing.T) {
+	ctx := context.Background()
+	event := AppSyncEvent{Field: "healthCheck"}
+
+	tests := []struct {
+		name         string
+		tables       []repository.TableHealth
+		configIssues []string
+		status       string
+		tableStatus  string
+	}{
+		{
+			name:        "Healthy",
+			tables:      []repository.TableHealth{{Name: "locations", Latency: 12 * time.Millisecond}},
+			status:      HealthStatusOK,
+			tableStatus: HealthStatusOK,
+		},
+		{
+			name:        "Slow table",
+			tables:      []repository.TableHealth{{Name: "locations", Latency: time.Second}},
+			status:      HealthStatusDegraded,
+			tableStatus: HealthStatusDegraded,
+		},
+		{
+			name:        "Unreachable table",
+			tables:      []repository.TableHealth{{Name: "locations", Err: errors.New("failed to read table: timeout")}},
+			status:      HealthStatusDown,
+			tableStatus: HealthStatusDown,
+		},
+		{
+			name:         "Configuration issues",
+			tables:       []repository.TableHealth{{Name: "locations", Latency: 12 * time.Millisecond}},
+			configIssues: []string{"ADMIN_GROUP is set without DYNAMODB_LOCATION_ID_INDEX_NAME"},
+			status:       HealthStatusDegraded,
+			tableStatus:  HealthStatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := new(mockHealthChecker)
+			checker.On("CheckHealth", mock.Anything).Return(tt.tables).Once()
+			handler := NewAppSyncHandler(new(mockRepository), WithHealthChecker(checker), WithConfigIssues(tt.configIssues))
+
+			result, err := handler.Handle(ctx, event)
+			require.NoError(t, err)
+			health := result.(*HealthStatus)
+			assert.Equal(t, tt.status, health.Status)
+			require.Len(t, health.Checks, 2)
+			assert.Equal(t, "configuration", health.Checks[0].Name)
+			assert.Equal(t, "dynamodb:locations", health.Checks[1].Name)
+			assert.Equal(t, tt.tableStatus, health.Checks[1].Status)
+			assert.NotNil(t, health.Checks[1].LatencyMs)
+			assert.False(t, health.CheckedAt.IsZero())
+		})
+	}
+
+	t.Run("Lists configured features", func(t *testing.T) {
+		checker := new(mockHealthChecker)
+		checker.On("CheckHealth", mock.Anything).Return([]repository.TableHealth{{Name: "locations"}}).Once()
+		handler := NewAppSyncHandler(new(mockRepository), WithHealthChecker(checker), WithResponseCompression(1024), WithWeatherProvider(new(mockWeatherProvider)))
+
+		result, err := handler.Handle(ctx, event)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"locationContext", "responseCompression"}, result.(*HealthStatus).Features)
+	})
+
+	t.Run("Repository without health checks", func(t *testing.T) {
+		result, err := NewAppSyncHandler(new(mockRepository)).Handle(ctx, event)
+		require.NoError(t, err)
+		health := result.(*HealthStatus)
+		assert.Equal(t, HealthStatusDegraded, health.Status)
+		assert.Equal(t, "the repository does not support health checks", health.Checks[1].Message)
+	})
+}
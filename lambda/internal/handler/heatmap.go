@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/steverhoton/location-lambda/internal/geo"
+	"github.com/steverhoton/location-lambda/internal/repository"
+)
+
+// defaultHeatmapResolution is the geohash length locationHeatmap counts by
+// when resolution is omitted, cells about 5 km across.
+const defaultHeatmapResolution = 5
+
+// LocationHeatmapArguments represents arguments for counting an account's locations by geohash cell.
+type LocationHeatmapArguments struct {
+	AccountID string           `json:"accountId"`
+	BBox      *geo.BoundingBox `json:"bbox"`
+	// Resolution is the geohash length of the cells, from 1 to 8
+	Resolution *int `json:"resolution,omitempty"`
+}
+
+// WithHeatmapReader enables locationHeatmap.
+func WithHeatmapReader(reader repository.HeatmapReader) Option {
+	return func(h *AppSyncHandler) {
+		h.heatmaps = reader
+	}
+}
+
+// handleLocationHeatmap returns the number of an account's locations in each
+// geohash cell of a bounding box, for density maps.
+func (h *AppSyncHandler) handleLocationHeatmap(ctx context.Context, arguments json.RawMessage) (*repository.Heatmap, error) {
+	var args LocationHeatmapArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+
+	if args.AccountID == "" || args.BBox == nil {
+		return nil, fmt.Errorf("accountId and bbox are required")
+	}
+	if h.heatmaps == nil {
+		return nil, fmt.Errorf("location heatmaps are not configured")
+	}
+	resolution := defaultHeatmapResolution
+	if args.Resolution != nil {
+		resolution = *args.Resolution
+	}
+
+	heatmap, err := h.heatmaps.LocationHeatmap(ctx, args.AccountID, *args.BBox, resolution)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build heatmap: %w", err)
+	}
+	return heatmap, nil
+}
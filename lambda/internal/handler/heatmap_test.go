@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/steverhoton/location-lambda/internal/geo"
+	"github.com/steverhoton/location-lambda/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockHeatmapReader is a mock implementation of the repository.HeatmapReader interface.
+type mockHeatmapReader struct {
+	mock.Mock
+}
+
+func (m *mockHeatmapReader) LocationHeatmap(ctx context.Context, accountID string, box geo.BoundingBox, resolution int) (*repository.Heatmap, error) {
+	args := m.Called(ctx, accountID, box, resolution)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.Heatmap), args.Error(1)
+}
+
+func TestAppSyncHandlerLocationHeatmap(t *testing.T) {
+	ctx := context.Background()
+	box := geo.BoundingBox{MinLatitude: 39.7, MinLongitude: -89.8, MaxLatitude: 39.9, MaxLongitude: -89.5}
+	event := func(arguments string) AppSyncEvent {
+		return AppSyncEvent{Field: "locationHeatmap", Arguments: json.RawMessage(arguments)}
+	}
+	heatmap := &repository.Heatmap{
+		Resolution: 6,
+		Total:      2,
+		Cells:      []repository.HeatmapCell{{Geohash: "dp04rf", Latitude: 39.78, Longitude: -89.65, Count: 2}},
+	}
+
+	t.Run("Returns the cells", func(t *testing.T) {
+		reader := new(mockHeatmapReader)
+		handler := NewAppSyncHandler(new(mockRepository), WithHeatmapReader(reader))
+
+		reader.On("LocationHeatmap", mock.Anything, "acc-12345", box, 6).Return(heatmap, nil).Once()
+
+		result, err := handler.Handle(ctx, event(`{"accountId": "acc-12345", "resolution": 6,
+			"bbox": {"minLatitude": 39.7, "minLongitude": -89.8, "maxLatitude": 39.9, "maxLongitude": -89.5}}`))
+		require.NoError(t, err)
+		assert.Equal(t, heatmap, result)
+		reader.AssertExpectations(t)
+	})
+
+	t.Run("Defaults the resolution", func(t *testing.T) {
+		reader := new(mockHeatmapReader)
+		handler := NewAppSyncHandler(new(mockRepository), WithHeatmapReader(reader))
+
+		reader.On("LocationHeatmap", mock.Anything, "acc-12345", box, defaultHeatmapResolution).Return(heatmap, nil).Once()
+
+		_, err := handler.Handle(ctx, event(`{"accountId": "acc-12345",
+			"bbox": {"minLatitude": 39.7, "minLongitude": -89.8, "maxLatitude": 39.9, "maxLongitude": -89.5}}`))
+		require.NoError(t, err)
+		reader.AssertExpectations(t)
+	})
+
+	t.Run("Requires a bounding box", func(t *testing.T) {
+		handler := NewAppSyncHandler(new(mockRepository), WithHeatmapReader(new(mockHeatmapReader)))
+
+		_, err := handler.Handle(ctx, event(`{"accountId": "acc-12345"}`))
+		assert.EqualError(t, err, "accountId and bbox are required")
+	})
+
+	t.Run("Repository error", func(t *testing.T) {
+		reader := new(mockHeatmapReader)
+		handler := NewAppSyncHandler(new(mockRepository), WithHeatmapReader(reader))
+
+		reader.On("LocationHeatmap", mock.Anything, "acc-12345", box, 9).Return(nil, errors.New("validation failed: resolution must be between 1 and 8")).Once()
+
+		_, err := handler.Handle(ctx, event(`{"accountId": "acc-12345", "resolution": 9,
+			"bbox": {"minLatitude": 39.7, "minLongitude": -89.8, "maxLatitude": 39.9, "maxLongitude": -89.5}}`))
+		assert.EqualError(t, err, "failed to build heatmap: validation failed: resolution must be between 1 and 8")
+	})
+
+	t.Run("Not configured", func(t *testing.T) {
+		_, err := NewAppSyncHandler(new(mockRepository)).Handle(ctx, event(`{"accountId": "acc-12345", "bbox": {}}`))
+		assert.EqualError(t, err, "location heatmaps are not configured")
+	})
+}
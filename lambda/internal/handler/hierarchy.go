@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/steverhoton/location-lambda/internal/repository"
+)
+
+// WithAccountHierarchy enables includeSubAccounts on list queries.
+func WithAccountHierarchy(hierarchy repository.AccountHierarchy) Option {
+	return func(h *AppSyncHandler) {
+		h.hierarchy = hierarchy
+	}
+}
+
+// subAccountCursor is the position of a list spanning sub-accounts: the
+// account being listed and the repository cursor within it.
+type subAccountCursor struct {
+	AccountID string  `json:"accountId"`
+	Cursor    *string `json:"cursor,omitempty"`
+}
+
+// listWithSubAccounts lists an account's locations followed by those of each
+// of its sub-accounts in turn, filling each page from as many accounts as it
+// takes. Sub-accounts are resolved again for every page, so one added or
+// moved mid-listing may be missed or, if listed already, skipped.
+func (h *AppSyncHandler) listWithSubAccounts(ctx context.Context, accountID string, options *repository.ListOptions) (*repository.ListResult, error) {
+	if h.hierarchy == nil {
+		return nil, fmt.Errorf("account hierarchy is not configured")
+	}
+	subAccounts, err := h.hierarchy.SubAccounts(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	accounts := append([]string{accountID}, subAccounts...)
+
+	start, position := 0, subAccountCursor{AccountID: accountID}
+	if options.Cursor != nil && *options.Cursor != "" {
+		data, err := base64.StdEncoding.DecodeString(*options.Cursor)
+		if err != nil || json.Unmarshal(data, &position) != nil {
+			return nil, fmt.Errorf("invalid cursor")
+		}
+		start = -1
+		for i, account := range accounts {
+			if account == position.AccountID {
+				start = i
+			}
+		}
+		if start < 0 {
+			return nil, fmt.Errorf("cursor account %s is no longer a sub-account of %s", position.AccountID, accountID)
+		}
+	}
+
+	result := &repository.ListResult{Items: []repository.LocationEnvelope{}}
+	remaining := *options.Limit
+	cursor := position.Cursor
+	for i := start; i < len(accounts); i++ {
+		pageOptions := *options
+		pageOptions.Limit, pageOptions.Cursor = &remaining, cursor
+		page, err := h.repo.List(ctx, accounts[i], &pageOptions)
+		if err != nil {
+			return nil, err
+		}
+		result.Items = append(result.Items, page.Items...)
+		result.StaleRead = result.StaleRead || page.StaleRead
+		result.IndexFallback = result.IndexFallback || page.IndexFallback
+		remaining -= int32(len(page.Items))
+
+		next := subAccountCursor{AccountID: accounts[i], Cursor: page.NextCursor}
+		if page.NextCursor == nil {
+			if i+1 == len(accounts) {
+				break
+			}
+			next = subAccountCursor{AccountID: accounts[i+1]}
+		}
+		if page.NextCursor != nil || remaining <= 0 {
+			data, err := json.Marshal(next)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode cursor: %w", err)
+			}
+			encoded := base64.StdEncoding.EncodeToString(data)
+			result.NextCursor = &encoded
+			break
+		}
+		cursor = nil
+	}
+	return result, nil
+}
+
+// checkParentAccount rejects making parentID the parent of accountID when
+// accountID is already among parentID's ancestors, which would make the
+// hierarchy a cycle. An account named as its own parent is left for
+// AccountSettings.Validate to reject.
+func (h *AppSyncHandler) checkParentAccount(ctx context.Context, store repository.SettingsStore, accountID, parentID string) error {
+	if parentID == accountID {
+		return nil
+	}
+	seen := map[string]bool{}
+	for ancestor := parentID; ancestor != "" && !seen[ancestor]; {
+		if ancestor == accountID {
+			return fmt.Errorf("parentAccountId %s is a sub-account of %s", parentID, accountID)
+		}
+		seen[ancestor] = true
+		settings, err := store.GetAccountSettings(ctx, ancestor)
+		if err != nil {
+			return fmt.Errorf("failed to get account settings: %w", err)
+		}
+		ancestor = settings.ParentAccountID
+	}
+	return nil
+}
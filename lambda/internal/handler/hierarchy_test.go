@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockAccountHierarchy is a mock implementation of the repository.AccountHierarchy interface.
+type mockAccountHierarchy struct {
+	mock.Mock
+}
+
+func (m *mockAccountHierarchy) ChildAccounts(ctx context.Context, accountID string) ([]string, error) {
+	args := m.Called(ctx, accountID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *mockAccountHierarchy) SubAccounts(ctx context.Context, accountID string) ([]string, error) {
+	args := m.Called(ctx, accountID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func TestAppSyncHandlerListIncludeSubAccounts(t *testing.T) {
+	ctx := context.Background()
+	location := func(accountID string) repository.LocationEnvelope {
+		return repository.LocationEnvelope{LocationID: "loc-" + accountID, Location: models.CoordinatesLocation{
+			LocationBase: models.LocationBase{AccountID: accountID, LocationType: models.LocationTypeCoordinates},
+			Coordinates:  models.Coordinates{Latitude: 39.7392, Longitude: -104.9903},
+		}}
+	}
+	listed := func(accountID string, limit int32, cursor *string) interface{} {
+		return mock.MatchedBy(func(options *repository.ListOptions) bool {
+			return *options.Limit == limit && assert.ObjectsAreEqual(cursor, options.Cursor)
+		})
+	}
+	list := func(handler *AppSyncHandler, arguments string) *ListLocationsResponse {
+		t.Helper()
+		result, err := handler.Handle(ctx, AppSyncEvent{Field: "listLocations", Arguments: json.RawMessage(arguments)})
+		require.NoError(t, err)
+		return result.(*ListLocationsResponse)
+	}
+	accountsOf := func(response *ListLocationsResponse) []string {
+		var accounts []string
+		for _, location := range response.Locations {
+			accounts = append(accounts, location["accountId"].(string))
+		}
+		return accounts
+	}
+
+	t.Run("Fills pages across accounts", func(t *testing.T) {
+		repo, hierarchy := new(mockRepository), new(mockAccountHierarchy)
+		handler := NewAppSyncHandler(repo, WithAccountHierarchy(hierarchy))
+		hierarchy.On("SubAccounts", ctx, "acc-hq").Return([]string{"acc-east", "acc-west"}, nil)
+
+		eastCursor := "east-page-2"
+		repo.On("List", ctx, "acc-hq", listed("acc-hq", 2, nil)).Return(&repository.ListResult{
+			Items: []repository.LocationEnvelope{location("acc-hq")},
+		}, nil).Once()
+		repo.On("List", ctx, "acc-east", listed("acc-east", 1, nil)).Return(&repository.ListResult{
+			Items:      []repository.LocationEnvelope{location("acc-east")},
+			NextCursor: &eastCursor,
+		}, nil).Once()
+
+		first := list(handler, `{"accountId": "acc-hq", "limit": 2, "includeSubAccounts": true}`)
+		assert.Equal(t, []string{"acc-hq", "acc-east"}, accountsOf(first))
+		require.NotNil(t, first.NextCursor)
+
+		repo.On("List", ctx, "acc-east", listed("acc-east", 2, &eastCursor)).Return(&repository.ListResult{
+			Items: []repository.LocationEnvelope{location("acc-east")},
+		}, nil).Once()
+		repo.On("List", ctx, "acc-west", listed("acc-west", 1, nil)).Return(&repository.ListResult{
+			Items: []repository.LocationEnvelope{location("acc-west")},
+		}, nil).Once()
+
+		second := list(handler, `{"accountId": "acc-hq", "limit": 2, "includeSubAccounts": true, "cursor": "`+*first.NextCursor+`"}`)
+		assert.Equal(t, []string{"acc-east", "acc-west"}, accountsOf(second))
+		assert.Nil(t, second.NextCursor)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Full page ends at an account boundary", func(t *testing.T) {
+		repo, hierarchy := new(mockRepository), new(mockAccountHierarchy)
+		handler := NewAppSyncHandler(repo, WithAccountHierarchy(hierarchy))
+		hierarchy.On("SubAccounts", ctx, "acc-hq").Return([]string{"acc-east"}, nil)
+
+		repo.On("List", ctx, "acc-hq", listed("acc-hq", 1, nil)).Return(&repository.ListResult{
+			Items: []repository.LocationEnvelope{location("acc-hq")},
+		}, nil).Once()
+		first := list(handler, `{"accountId": "acc-hq", "limit": 1, "includeSubAccounts": true}`)
+		require.NotNil(t, first.NextCursor)
+
+		repo.On("List", ctx, "acc-east", listed("acc-east", 1, nil)).Return(&repository.ListResult{
+			Items: []repository.LocationEnvelope{location("acc-east")},
+		}, nil).Once()
+		second := list(handler, `{"accountId": "acc-hq", "limit": 1, "includeSubAccounts": true, "cursor": "`+*first.NextCursor+`"}`)
+		assert.Equal(t, []string{"acc-east"}, accountsOf(second))
+		assert.Nil(t, second.NextCursor)
+	})
+
+	t.Run("Cursor for an account no longer below", func(t *testing.T) {
+		repo, hierarchy := new(mockRepository), new(mockAccountHierarchy)
+		handler := NewAppSyncHandler(repo, WithAccountHierarchy(hierarchy))
+		hierarchy.On("SubAccounts", ctx, "acc-hq").Return([]string{}, nil)
+
+		// {"accountId":"acc-east"}
+		_, err := handler.Handle(ctx, AppSyncEvent{Field: "listLocations", Arguments: json.RawMessage(`{"accountId": "acc-hq", "includeSubAccounts": true, "cursor": "eyJhY2NvdW50SWQiOiJhY2MtZWFzdCJ9"}`)})
+		assert.EqualError(t, err, "failed to list locations: cursor account acc-east is no longer a sub-account of acc-hq")
+	})
+
+	t.Run("Not configured", func(t *testing.T) {
+		_, err := NewAppSyncHandler(new(mockRepository)).Handle(ctx, AppSyncEvent{Field: "listLocations", Arguments: json.RawMessage(`{"accountId": "acc-hq", "includeSubAccounts": true}`)})
+		assert.EqualError(t, err, "failed to list locations: account hierarchy is not configured")
+	})
+}
+
+func TestAppSyncHandlerUpdateParentAccount(t *testing.T) {
+	ctx := context.Background()
+	update := func(store *mockSettingsStore, accountID, parentID string) (interface{}, error) {
+		handler := NewAppSyncHandler(new(mockRepository), WithSettingsStore(store))
+		return handler.Handle(ctx, AppSyncEvent{
+			Field:     "updateAccountSettings",
+			Arguments: json.RawMessage(`{"accountId": "` + accountID + `", "input": {"parentAccountId": "` + parentID + `"}}`),
+		})
+	}
+	settings := func(accountID, parentID string) *models.AccountSettings {
+		s := models.DefaultAccountSettings(accountID)
+		s.ParentAccountID = parentID
+		return &s
+	}
+
+	t.Run("Sets the parent", func(t *testing.T) {
+		store := new(mockSettingsStore)
+		store.On("GetAccountSettings", ctx, "acc-boston").Return(settings("acc-boston", ""), nil).Once()
+		store.On("GetAccountSettings", ctx, "acc-east").Return(settings("acc-east", "acc-hq"), nil).Once()
+		store.On("GetAccountSettings", ctx, "acc-hq").Return(settings("acc-hq", ""), nil).Once()
+		store.On("PutAccountSettings", ctx, *settings("acc-boston", "acc-east")).Return(settings("acc-boston", "acc-east"), nil).Once()
+
+		result, err := update(store, "acc-boston", "acc-east")
+		require.NoError(t, err)
+		assert.Equal(t, "acc-east", result.(*models.AccountSettings).ParentAccountID)
+		store.AssertExpectations(t)
+	})
+
+	t.Run("Rejects a cycle", func(t *testing.T) {
+		store := new(mockSettingsStore)
+		store.On("GetAccountSettings", ctx, "acc-hq").Return(settings("acc-hq", ""), nil).Once()
+		store.On("GetAccountSettings", ctx, "acc-boston").Return(settings("acc-boston", "acc-east"), nil).Once()
+		store.On("GetAccountSettings", ctx, "acc-east").Return(settings("acc-east", "acc-hq"), nil).Once()
+
+		_, err := update(store, "acc-hq", "acc-boston")
+		assert.EqualError(t, err, "parentAccountId acc-boston is a sub-account of acc-hq")
+		store.AssertNotCalled(t, "PutAccountSettings", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Clears the parent", func(t *testing.T) {
+		store := new(mockSettingsStore)
+		store.On("GetAccountSettings", ctx, "acc-boston").Return(settings("acc-boston", "acc-east"), nil).Once()
+		store.On("PutAccountSettings", ctx, *settings("acc-boston", "")).Return(settings("acc-boston", ""), nil).Once()
+
+		_, err := update(store, "acc-boston", "")
+		require.NoError(t, err)
+		store.AssertExpectations(t)
+	})
+}
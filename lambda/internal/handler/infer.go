@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/steverhoton/location-lambda/internal/inference"
+)
+
+// InferLocationArguments represents arguments for inferring a location from free-form input.
+type InferLocationArguments struct {
+	AccountID string `json:"accountId"`
+	// Input is a free-form string or a JSON object with legacy field names.
+	Input json.RawMessage `json:"input"`
+}
+
+func (h *AppSyncHandler) handleInferLocation(ctx context.Context, arguments json.RawMessage) (*inference.Result, error) {
+	var args InferLocationArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+
+	// Accept both a plain string and an object blob
+	input := string(args.Input)
+	var text string
+	if err := json.Unmarshal(args.Input, &text); err == nil {
+		input = text
+	}
+
+	result, err := inference.NewInferrer(h.geocoder).Infer(ctx, args.AccountID, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to infer location: %w", err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/steverhoton/location-lambda/internal/inference"
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppSyncHandlerInferLocation(t *testing.T) {
+	ctx := context.Background()
+	handler := NewAppSyncHandler(new(mockRepository))
+
+	tests := []struct {
+		name         string
+		arguments    string
+		expectedType models.LocationType
+	}{
+		{
+			name:         "String input",
+			arguments:    `{"accountId": "acc-12345", "input": "39.7817, -89.6501"}`,
+			expectedType: models.LocationTypeCoordinates,
+		},
+		{
+			name:         "Object input",
+			arguments:    `{"accountId": "acc-12345", "input": {"street": "123 Main St", "city": "Springfield", "state": "IL", "zip": "62704"}}`,
+			expectedType: models.LocationTypeAddress,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := handler.Handle(ctx, AppSyncEvent{
+				Field:     "inferLocation",
+				Arguments: json.RawMessage(tt.arguments),
+			})
+			require.NoError(t, err)
+
+			inferred, ok := result.(*inference.Result)
+			require.True(t, ok)
+			assert.Equal(t, tt.expectedType, inferred.LocationType)
+			assert.Equal(t, "acc-12345", inferred.Payload["accountId"])
+		})
+	}
+
+	t.Run("Uninferrable input", func(t *testing.T) {
+		_, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "inferLocation",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "input": "somewhere"}`),
+		})
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to infer location")
+	})
+}
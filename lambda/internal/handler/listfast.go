@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/steverhoton/location-lambda/internal/repository"
+)
+
+// DefaultFastListBudget bounds listLocationsFast when no budget is configured.
+const DefaultFastListBudget = 300 * time.Millisecond
+
+// fastListChunk is the number of locations fetched per query by listLocationsFast.
+// Small chunks let the page stop close to the budget.
+const fastListChunk int32 = 10
+
+// ListLocationsFastArguments represents arguments for a time-budgeted list.
+type ListLocationsFastArguments struct {
+	AccountID string  `json:"accountId"`
+	Limit     *int32  `json:"limit,omitempty"`
+	Cursor    *string `json:"cursor,omitempty"`
+	// BudgetMs lowers the configured time budget for this call
+	BudgetMs     *int `json:"budgetMs,omitempty"`
+	IncludeLinks bool `json:"includeLinks,omitempty"`
+}
+
+// WithFastListBudget sets the time budget of listLocationsFast.
+func WithFastListBudget(budget time.Duration) Option {
+	return func(h *AppSyncHandler) {
+		h.fastListBudget = budget
+	}
+}
+
+// handleListLocationsFast lists locations in small chunks until the page is
+// full or the time budget is spent, returning what it gathered and a cursor
+// to continue from. The first chunk is always awaited so every call makes
+// progress; a later chunk still running at the deadline is abandoned.
+func (h *AppSyncHandler) handleListLocationsFast(ctx context.Context, event AppSyncEvent) (*ListLocationsResponse, error) {
+	var args ListLocationsFastArguments
+	if err := json.Unmarshal(event.Arguments, &args); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+
+	limit, err := h.listLimits.Resolve(args.Limit)
+	if err != nil {
+		return nil, err
+	}
+	budget := h.fastListBudget
+	if budget <= 0 {
+		budget = DefaultFastListBudget
+	}
+	if args.BudgetMs != nil {
+		if *args.BudgetMs <= 0 {
+			return nil, fmt.Errorf("budgetMs must be positive")
+		}
+		if requested := time.Duration(*args.BudgetMs) * time.Millisecond; requested < budget {
+			budget = requested
+		}
+	}
+	deadline := time.Now().Add(budget)
+
+	response := &ListLocationsResponse{Locations: []map[string]interface{}{}}
+	cursor := args.Cursor
+	for first, remaining := true, limit; remaining > 0; first = false {
+		if !first && !time.Now().Before(deadline) {
+			response.Partial = true
+			break
+		}
+
+		result, err := h.listChunk(ctx, deadline, !first, args.AccountID, min(remaining, fastListChunk), cursor)
+		if errors.Is(err, errBudgetSpent) {
+			response.Partial = true
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list locations: %w", err)
+		}
+
+		for _, item := range result.Items {
+			locationMap, err := toLocationMap(item, args.IncludeLinks)
+			if err != nil {
+				return nil, err
+			}
+			response.Locations = append(response.Locations, locationMap)
+		}
+		response.StaleRead = response.StaleRead || result.StaleRead
+		response.IndexFallback = response.IndexFallback || result.IndexFallback
+		remaining -= int32(len(result.Items))
+		cursor = result.NextCursor
+		if cursor == nil {
+			break
+		}
+	}
+	response.NextCursor = cursor
+
+	if err := h.compressLocations(event.Request, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// errBudgetSpent reports a chunk abandoned at the listLocationsFast deadline.
+var errBudgetSpent = errors.New("time budget spent")
+
+// listChunk fetches one chunk of locations, abandoning it at deadline when bounded.
+func (h *AppSyncHandler) listChunk(ctx context.Context, deadline time.Time, bounded bool, accountID string, limit int32, cursor *string) (*repository.ListResult, error) {
+	chunkCtx := ctx
+	if bounded {
+		var cancel context.CancelFunc
+		chunkCtx, cancel = context.WithDeadline(ctx, deadline)
+		defer cancel()
+	}
+
+	result, err := h.repo.List(chunkCtx, accountID, &repository.ListOptions{Limit: aws.Int32(limit), Cursor: cursor})
+	if err != nil && chunkCtx.Err() != nil && ctx.Err() == nil {
+		return nil, errBudgetSpent
+	}
+	return result, err
+}
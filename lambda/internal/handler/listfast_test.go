@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// chunk returns a list result of n coordinates locations starting at ID offset.
+func chunk(offset, n int, nextCursor *string) *repository.ListResult {
+	result := &repository.ListResult{NextCursor: nextCursor}
+	for i := offset; i < offset+n; i++ {
+		result.Items = append(result.Items, repository.LocationEnvelope{
+			LocationID: fmt.Sprintf("loc-%03d", i),
+			Location: models.CoordinatesLocation{
+				LocationBase: models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeCoordinates},
+				Coordinates:  models.Coordinates{Latitude: 40.7128, Longitude: -74.006},
+			},
+		})
+	}
+	return result
+}
+
+// matchChunk matches list options by limit and cursor.
+func matchChunk(limit int32, cursor *string) interface{} {
+	return mock.MatchedBy(func(options *repository.ListOptions) bool {
+		return *options.Limit == limit && aws.ToString(options.Cursor) == aws.ToString(cursor)
+	})
+}
+
+func TestAppSyncHandlerListLocationsFast(t *testing.T) {
+	ctx := context.Background()
+	fastEvent := func(arguments string) AppSyncEvent {
+		return AppSyncEvent{Field: "listLocationsFast", Arguments: json.RawMessage(arguments)}
+	}
+
+	t.Run("Fills the page across chunks", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		handler := NewAppSyncHandler(mockRepo)
+		mockRepo.On("List", mock.Anything, "acc-12345", matchChunk(10, nil)).Return(chunk(0, 10, aws.String("c1")), nil).Once()
+		mockRepo.On("List", mock.Anything, "acc-12345", matchChunk(5, aws.String("c1"))).Return(chunk(10, 5, aws.String("c2")), nil).Once()
+
+		result, err := handler.Handle(ctx, fastEvent(`{"accountId": "acc-12345", "limit": 15}`))
+		require.NoError(t, err)
+		response := result.(*ListLocationsResponse)
+		assert.Len(t, response.Locations, 15)
+		assert.Equal(t, "c2", aws.ToString(response.NextCursor))
+		assert.False(t, response.Partial)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Stops at the last page", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		handler := NewAppSyncHandler(mockRepo)
+		mockRepo.On("List", mock.Anything, "acc-12345", matchChunk(10, aws.String("c1"))).Return(chunk(0, 3, nil), nil).Once()
+
+		result, err := handler.Handle(ctx, fastEvent(`{"accountId": "acc-12345", "cursor": "c1"}`))
+		require.NoError(t, err)
+		response := result.(*ListLocationsResponse)
+		assert.Len(t, response.Locations, 3)
+		assert.Nil(t, response.NextCursor)
+		assert.False(t, response.Partial)
+	})
+
+	t.Run("Returns what it gathered when the budget is spent", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		handler := NewAppSyncHandler(mockRepo)
+		mockRepo.On("List", mock.Anything, "acc-12345", matchChunk(10, nil)).Return(chunk(0, 2, aws.String("c1")), nil).Once()
+		// The second chunk hangs until the budget cancels it
+		mockRepo.On("List", mock.Anything, "acc-12345", matchChunk(10, aws.String("c1"))).
+			Run(func(args mock.Arguments) { <-args.Get(0).(context.Context).Done() }).
+			Return(nil, context.DeadlineExceeded).Once()
+
+		result, err := handler.Handle(ctx, fastEvent(`{"accountId": "acc-12345", "limit": 20, "budgetMs": 20}`))
+		require.NoError(t, err)
+		response := result.(*ListLocationsResponse)
+		assert.Len(t, response.Locations, 2)
+		assert.Equal(t, "c1", aws.ToString(response.NextCursor))
+		assert.True(t, response.Partial)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Reports repository errors", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		handler := NewAppSyncHandler(mockRepo)
+		mockRepo.On("List", mock.Anything, "acc-12345", mock.Anything).Return(nil, fmt.Errorf("throttled")).Once()
+
+		_, err := handler.Handle(ctx, fastEvent(`{"accountId": "acc-12345"}`))
+		assert.EqualError(t, err, "failed to list locations: throttled")
+	})
+
+	t.Run("Rejects a non-positive limit or budget", func(t *testing.T) {
+		handler := NewAppSyncHandler(new(mockRepository))
+
+		_, err := handler.Handle(ctx, fastEvent(`{"accountId": "acc-12345", "limit": 0}`))
+		assert.EqualError(t, err, "limit must be between 1 and 100")
+		_, err = handler.Handle(ctx, fastEvent(`{"accountId": "acc-12345", "budgetMs": 0}`))
+		assert.EqualError(t, err, "budgetMs must be positive")
+	})
+}
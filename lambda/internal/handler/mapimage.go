@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/staticmap"
+)
+
+// Default static map thumbnail size and zoom.
+const (
+	defaultMapImageWidth  = 300
+	defaultMapImageHeight = 200
+	defaultMapImageZoom   = 15
+)
+
+// GetLocationMapImageURLArguments represents arguments for building a map thumbnail URL.
+type GetLocationMapImageURLArguments struct {
+	AccountID  string   `json:"accountId"`
+	LocationID string   `json:"locationId"`
+	Width      *int     `json:"width,omitempty"`
+	Height     *int     `json:"height,omitempty"`
+	Zoom       *float64 `json:"zoom,omitempty"`
+}
+
+// WithStaticMapProvider enables map thumbnail URLs.
+func WithStaticMapProvider(provider staticmap.Provider) Option {
+	return func(h *AppSyncHandler) {
+		h.staticMap = provider
+	}
+}
+
+func (h *AppSyncHandler) handleGetLocationMapImageURL(ctx context.Context, arguments json.RawMessage) (*staticmap.Image, error) {
+	var args GetLocationMapImageURLArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+
+	if args.AccountID == "" || args.LocationID == "" {
+		return nil, fmt.Errorf("accountId and locationId are required")
+	}
+	if h.staticMap == nil {
+		return nil, fmt.Errorf("map images are not configured")
+	}
+
+	req := staticmap.Request{Width: defaultMapImageWidth, Height: defaultMapImageHeight, Zoom: defaultMapImageZoom}
+	if args.Width != nil {
+		req.Width = *args.Width
+	}
+	if args.Height != nil {
+		req.Height = *args.Height
+	}
+	if args.Zoom != nil {
+		req.Zoom = *args.Zoom
+	}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	envelope, err := h.repo.Get(ctx, args.AccountID, args.LocationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get location: %w", err)
+	}
+	loc, ok := envelope.Location.(models.CoordinatesLocation)
+	if !ok {
+		return nil, fmt.Errorf("location has no stored coordinates")
+	}
+	req.Center = loc.Coordinates
+
+	image, err := h.staticMap.URL(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build map image URL: %w", err)
+	}
+	return image, nil
+}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/staticmap"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockStaticMapProvider is a mock implementation of the staticmap.Provider interface.
+type mockStaticMapProvider struct {
+	mock.Mock
+}
+
+func (m *mockStaticMapProvider) URL(ctx context.Context, req staticmap.Request) (*staticmap.Image, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*staticmap.Image), args.Error(1)
+}
+
+func TestAppSyncHandlerGetLocationMapImageURL(t *testing.T) {
+	ctx := context.Background()
+	center := models.Coordinates{Latitude: 40.7128, Longitude: -74.006}
+	image := &staticmap.Image{URL: "https://maps.example.com/map.png?sig=abc", ExpiresAt: time.Date(2024, 6, 1, 13, 0, 0, 0, time.UTC)}
+
+	t.Run("Defaults size and zoom", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		provider := new(mockStaticMapProvider)
+		handler := NewAppSyncHandler(mockRepo, WithStaticMapProvider(provider))
+
+		mockRepo.On("Get", ctx, "acc-12345", "loc-001").Return(withID("loc-001", coordinatesAt(center.Latitude, center.Longitude)), nil).Once()
+		provider.On("URL", ctx, staticmap.Request{Center: center, Width: 300, Height: 200, Zoom: 15}).Return(image, nil).Once()
+
+		result, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "getLocationMapImageURL",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "locationId": "loc-001"}`),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, image, result)
+		provider.AssertExpectations(t)
+	})
+
+	t.Run("Explicit size and zoom", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		provider := new(mockStaticMapProvider)
+		handler := NewAppSyncHandler(mockRepo, WithStaticMapProvider(provider))
+
+		mockRepo.On("Get", ctx, "acc-12345", "loc-001").Return(withID("loc-001", coordinatesAt(center.Latitude, center.Longitude)), nil).Once()
+		provider.On("URL", ctx, staticmap.Request{Center: center, Width: 128, Height: 128, Zoom: 12.5}).Return(image, nil).Once()
+
+		_, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "getLocationMapImageURL",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "locationId": "loc-001", "width": 128, "height": 128, "zoom": 12.5}`),
+		})
+		require.NoError(t, err)
+		provider.AssertExpectations(t)
+	})
+
+	t.Run("Invalid size is rejected before reading the location", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		handler := NewAppSyncHandler(mockRepo, WithStaticMapProvider(new(mockStaticMapProvider)))
+
+		_, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "getLocationMapImageURL",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "locationId": "loc-001", "width": 5000}`),
+		})
+		assert.EqualError(t, err, "width and height must be between 64 and 1400")
+		mockRepo.AssertNotCalled(t, "Get", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Address locations have no coordinates", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		handler := NewAppSyncHandler(mockRepo, WithStaticMapProvider(new(mockStaticMapProvider)))
+
+		mockRepo.On("Get", ctx, "acc-12345", "loc-001").Return(withID("loc-001", models.AddressLocation{
+			LocationBase: models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeAddress},
+		}), nil).Once()
+
+		_, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "getLocationMapImageURL",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "locationId": "loc-001"}`),
+		})
+		assert.EqualError(t, err, "location has no stored coordinates")
+	})
+
+	t.Run("Not configured", func(t *testing.T) {
+		_, err := NewAppSyncHandler(new(mockRepository)).Handle(ctx, AppSyncEvent{
+			Field:     "getLocationMapImageURL",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "locationId": "loc-001"}`),
+		})
+		assert.EqualError(t, err, "map images are not configured")
+	})
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/repository"
+)
+
+// defaultNearestK is how many shops nearestLocationsByCategory returns when k is omitted.
+const defaultNearestK = 10
+
+// NearestLocationsByCategoryArguments represents arguments for finding the nearest shops in a category.
+type NearestLocationsByCategoryArguments struct {
+	AccountID    string  `json:"accountId"`
+	Lat          float64 `json:"lat"`
+	Lon          float64 `json:"lon"`
+	Category     string  `json:"category"`
+	K            *int    `json:"k,omitempty"`
+	IncludeLinks bool    `json:"includeLinks,omitempty"`
+	// OpenAt limits the shops to those open at this instant (RFC 3339)
+	OpenAt *time.Time `json:"openAt,omitempty"`
+	// MaxAccuracyMeters leaves out shops pinned less accurately
+	MaxAccuracyMeters *float64 `json:"maxAccuracyMeters,omitempty"`
+}
+
+// WithNearestShopFinder enables nearestLocationsByCategory.
+func WithNearestShopFinder(finder repository.NearestShopFinder) Option {
+	return func(h *AppSyncHandler) {
+		h.nearest = finder
+	}
+}
+
+// handleNearestLocationsByCategory returns the k shops in a category nearest
+// a point, nearest first, each as getLocation returns it with its
+// distanceMeters.
+func (h *AppSyncHandler) handleNearestLocationsByCategory(ctx context.Context, arguments json.RawMessage) ([]map[string]interface{}, error) {
+	var args NearestLocationsByCategoryArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+
+	if args.AccountID == "" || args.Category == "" {
+		return nil, fmt.Errorf("accountId and category are required")
+	}
+	if h.nearest == nil {
+		return nil, fmt.Errorf("nearest shop search is not configured")
+	}
+	k := defaultNearestK
+	if args.K != nil {
+		k = *args.K
+	}
+
+	center := models.Coordinates{Latitude: args.Lat, Longitude: args.Lon}
+	shops, err := h.nearest.FindNearestShops(ctx, args.AccountID, center, args.Category, k, args.OpenAt, args.MaxAccuracyMeters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find shops: %w", err)
+	}
+
+	locations := make([]map[string]interface{}, 0, len(shops))
+	for _, shop := range shops {
+		location, err := toLocationMap(shop.LocationEnvelope, args.IncludeLinks)
+		if err != nil {
+			return nil, err
+		}
+		location["distanceMeters"] = shop.DistanceMeters
+		locations = append(locations, location)
+	}
+	return locations, nil
+}
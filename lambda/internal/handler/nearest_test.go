@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockNearestShopFinder is a mock implementation of the repository.NearestShopFinder interface.
+type mockNearestShopFinder struct {
+	mock.Mock
+}
+
+func (m *mockNearestShopFinder) FindNearestShops(ctx context.Context, accountID string, center models.Coordinates, category string, k int, openAt *time.Time, maxAccuracyMeters *float64) ([]repository.NearbyShop, error) {
+	args := m.Called(ctx, accountID, center, category, k, openAt, maxAccuracyMeters)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repository.NearbyShop), args.Error(1)
+}
+
+func TestAppSyncHandlerNearestLocationsByCategory(t *testing.T) {
+	ctx := context.Background()
+	center := models.Coordinates{Latitude: 39.7817, Longitude: -89.6501}
+	shop := models.ShopLocation{
+		LocationBase: models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeShop},
+		Shop: models.Shop{
+			Name:        "Main Street Pharmacy",
+			ContactID:   "contact-1",
+			Address:     models.Address{StreetAddress: "123 Main St", City: "Springfield", PostalCode: "62704", Country: "US"},
+			Coordinates: &models.Coordinates{Latitude: 39.7917, Longitude: -89.6501},
+			Categories:  []string{"446110"},
+		},
+	}
+
+	t.Run("Returns shops with their distance", func(t *testing.T) {
+		finder := new(mockNearestShopFinder)
+		handler := NewAppSyncHandler(new(mockRepository), WithNearestShopFinder(finder))
+
+		finder.On("FindNearestShops", ctx, "acc-12345", center, "446110", 3, (*time.Time)(nil), (*float64)(nil)).Return([]repository.NearbyShop{
+			{LocationEnvelope: repository.LocationEnvelope{LocationID: "loc-001", Location: shop}, DistanceMeters: 1112},
+		}, nil).Once()
+
+		result, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "nearestLocationsByCategory",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "lat": 39.7817, "lon": -89.6501, "category": "446110", "k": 3}`),
+		})
+		require.NoError(t, err)
+		locations := result.([]map[string]interface{})
+		require.Len(t, locations, 1)
+		assert.Equal(t, "loc-001", locations[0]["locationId"])
+		assert.Equal(t, "ShopLocation", locations[0]["__typename"])
+		assert.Equal(t, 1112.0, locations[0]["distanceMeters"])
+		finder.AssertExpectations(t)
+	})
+
+	t.Run("Defaults k", func(t *testing.T) {
+		finder := new(mockNearestShopFinder)
+		handler := NewAppSyncHandler(new(mockRepository), WithNearestShopFinder(finder))
+
+		finder.On("FindNearestShops", ctx, "acc-12345", center, "446110", defaultNearestK, (*time.Time)(nil), (*float64)(nil)).Return([]repository.NearbyShop{}, nil).Once()
+
+		_, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "nearestLocationsByCategory",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "lat": 39.7817, "lon": -89.6501, "category": "446110"}`),
+		})
+		require.NoError(t, err)
+		finder.AssertExpectations(t)
+	})
+
+	t.Run("Passes openAt through", func(t *testing.T) {
+		finder := new(mockNearestShopFinder)
+		handler := NewAppSyncHandler(new(mockRepository), WithNearestShopFinder(finder))
+
+		openAt := mock.MatchedBy(func(at *time.Time) bool {
+			return at != nil && at.Equal(time.Date(2024, time.June, 4, 15, 30, 0, 0, time.UTC))
+		})
+		finder.On("FindNearestShops", ctx, "acc-12345", center, "446110", defaultNearestK, openAt, (*float64)(nil)).Return([]repository.NearbyShop{}, nil).Once()
+
+		_, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "nearestLocationsByCategory",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "lat": 39.7817, "lon": -89.6501, "category": "446110", "openAt": "2024-06-04T10:30:00-05:00"}`),
+		})
+		require.NoError(t, err)
+		finder.AssertExpectations(t)
+	})
+
+	t.Run("Passes maxAccuracyMeters through", func(t *testing.T) {
+		finder := new(mockNearestShopFinder)
+		handler := NewAppSyncHandler(new(mockRepository), WithNearestShopFinder(finder))
+
+		finder.On("FindNearestShops", ctx, "acc-12345", center, "446110", defaultNearestK, (*time.Time)(nil), mock.MatchedBy(func(m *float64) bool {
+			return m != nil && *m == 15
+		})).Return([]repository.NearbyShop{}, nil).Once()
+
+		_, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "nearestLocationsByCategory",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "lat": 39.7817, "lon": -89.6501, "category": "446110", "maxAccuracyMeters": 15}`),
+		})
+		require.NoError(t, err)
+		finder.AssertExpectations(t)
+	})
+
+	t.Run("Requires a category", func(t *testing.T) {
+		handler := NewAppSyncHandler(new(mockRepository), WithNearestShopFinder(new(mockNearestShopFinder)))
+
+		_, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "nearestLocationsByCategory",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "lat": 39.7817, "lon": -89.6501}`),
+		})
+		assert.EqualError(t, err, "accountId and category are required")
+	})
+}
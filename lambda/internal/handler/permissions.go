@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/permissions"
+)
+
+// readFields are the operations that change nothing; every other field is a write.
+var readFields = map[string]bool{
+	"getLocation":                true,
+	"getLocationByExternalId":    true,
+	"listLocations":              true,
+	"listLocationsByCategory":    true,
+	"listLocationsFast":          true,
+	"occurrencesBetween":         true,
+	"getLocationTemplate":        true,
+	"listLocationTemplates":      true,
+	"getCustomFieldDefinitions":  true,
+	"getGeofences":               true,
+	"listDetectedStops":          true,
+	"getAccountSettings":         true,
+	"getUsage":                   true,
+	"adminGetLocationById":       true,
+	"adminListAccountLocations":  true,
+	"adminListAllLocations":      true,
+	"listProposals":              true,
+	"inferLocation":              true,
+	"parseAddress":               true,
+	"findDuplicateCandidates":    true,
+	"findShopsByWebsite":         true,
+	"listLocationsByContactId":   true,
+	"syncLocations":              true,
+	"locationStats":              true,
+	"locationHeatmap":            true,
+	"publicNearbyShops":          true,
+	"publicShop":                 true,
+	"nearestLocationsByCategory": true,
+	"assignNearest":              true,
+	"quoteDeliveryForPoint":      true,
+	"getLocationContext":         true,
+	"etaToLocation":              true,
+	"getLocationMapImageURL":     true,
+	"healthCheck":                true,
+}
+
+// WithPermissions checks every signed-in caller's roles against policy
+// before handling a field.
+func WithPermissions(policy *permissions.Policy) Option {
+	return func(h *AppSyncHandler) {
+		h.permissions = policy
+	}
+}
+
+// InternalInvoker is the invoker the function's EventBridge rules mark their
+// events with.
+const InternalInvoker = "eventbridge"
+
+// isInternalCaller reports whether the event came from one of the function's
+// EventBridge rules. Such events must say so with InternalInvoker and carry no
+// caller identity; an event that merely lacks an identity, such as one from a
+// Lambda authorizer returning no resolverContext, is not trusted.
+func isInternalCaller(event AppSyncEvent) bool {
+	return event.Invoker == InternalInvoker && !hasIdentity(event)
+}
+
+// hasIdentity reports whether AppSync identified the caller, by a signed-in
+// identity, a Lambda authorizer's resolverContext, or an API key.
+func hasIdentity(event AppSyncEvent) bool {
+	return event.Request.Headers[apiKeyHeader] != "" ||
+		len(event.Identity.Claims) > 0 || event.Identity.UserArn != "" || event.Identity.Username != "" ||
+		len(event.Identity.ResolverContext) > 0
+}
+
+// authorize checks that the caller's roles allow the event's field and, for
+// writes by roles limited to some location types, that every location the
+// write changes is of one of them.
+func (h *AppSyncHandler) authorize(ctx context.Context, event AppSyncEvent) error {
+	if h.permissions == nil || isInternalCaller(event) {
+		return nil
+	}
+	if !hasIdentity(event) {
+		return fmt.Errorf("permission denied: %s requires an identified caller", event.Field)
+	}
+
+	action := permissions.ActionWrite
+	if readFields[event.Field] {
+		action = permissions.ActionRead
+	}
+	grant, ok := h.permissions.Authorize(claimValues(event.Identity, h.permissions.Claim()), event.Field, action)
+	if !ok {
+		return fmt.Errorf("permission denied: %s is not allowed for the caller's roles", event.Field)
+	}
+	if grant.LocationTypes == nil {
+		return nil
+	}
+
+	written, err := h.writtenLocationTypes(ctx, event)
+	if err != nil {
+		return err
+	}
+	if len(written) == 0 {
+		return fmt.Errorf("permission denied: the caller's roles may only write %s locations, and %s does not write a single location", strings.Join(grant.Types(), ", "), event.Field)
+	}
+	for _, locationType := range written {
+		if !grant.AllowsType(locationType) {
+			return fmt.Errorf("permission denied: the caller's roles may only write %s locations, not %s", strings.Join(grant.Types(), ", "), locationType)
+		}
+	}
+	return nil
+}
+
+// writtenLocationTypes returns the types of the locations a write changes:
+// the input location's type, and the stored location's when the write names a
+// locationId. Writes to anything else, such as account settings or several
+// merged locations, return none.
+func (h *AppSyncHandler) writtenLocationTypes(ctx context.Context, event AppSyncEvent) ([]models.LocationType, error) {
+	var args struct {
+		AccountID  string `json:"accountId"`
+		LocationID string `json:"locationId"`
+		Input      struct {
+			LocationType models.LocationType `json:"locationType"`
+		} `json:"input"`
+	}
+	if err := json.Unmarshal(event.Arguments, &args); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+
+	var types []models.LocationType
+	if args.Input.LocationType != "" {
+		types = append(types, args.Input.LocationType)
+	}
+	if args.AccountID != "" && args.LocationID != "" {
+		envelope, err := h.repo.Get(ctx, args.AccountID, args.LocationID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get location: %w", err)
+		}
+		types = append(types, envelope.Location.GetLocationType())
+	}
+	return types, nil
+}
@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/permissions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppSyncHandlerPermissions(t *testing.T) {
+	ctx := context.Background()
+	policy, err := permissions.Parse(`{
+		"defaultRole": "viewer",
+		"roles": {
+			"viewer": {"operations": ["read"]},
+			"editor": {"operations": ["*"]},
+			"shop-editor": {"operations": ["read", "write"], "locationTypes": ["shop"]}
+		}
+	}`)
+	require.NoError(t, err)
+	identity := func(groups ...interface{}) AppSyncIdentity {
+		return AppSyncIdentity{Username: "user-1", Claims: map[string]interface{}{"cognito:groups": groups}}
+	}
+	shop := models.ShopLocation{
+		LocationBase: models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeShop},
+		Shop:         models.Shop{Name: "Corner Shop"},
+	}
+	coordinates := models.CoordinatesLocation{
+		LocationBase: models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeCoordinates},
+		Coordinates:  models.Coordinates{Latitude: 40.7128, Longitude: -74.006},
+	}
+	deleteEvent := func(identity AppSyncIdentity) AppSyncEvent {
+		return AppSyncEvent{
+			Field:     "deleteLocation",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "locationId": "loc-001"}`),
+			Identity:  identity,
+		}
+	}
+
+	t.Run("Viewers can read", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		handler := NewAppSyncHandler(mockRepo, WithPermissions(policy))
+		mockRepo.On("Get", mock.Anything, "acc-12345", "loc-001").Return(withID("loc-001", shop), nil).Once()
+
+		_, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "getLocation",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "locationId": "loc-001"}`),
+			Identity:  identity("viewer"),
+		})
+		require.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Viewers cannot write", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		handler := NewAppSyncHandler(mockRepo, WithPermissions(policy))
+
+		_, err := handler.Handle(ctx, deleteEvent(identity("viewer")))
+		assert.EqualError(t, err, "permission denied: deleteLocation is not allowed for the caller's roles")
+		mockRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Callers without a policy role get the default", func(t *testing.T) {
+		handler := NewAppSyncHandler(new(mockRepository), WithPermissions(policy))
+
+		_, err := handler.Handle(ctx, deleteEvent(identity("staff")))
+		assert.EqualError(t, err, "permission denied: deleteLocation is not allowed for the caller's roles")
+	})
+
+	t.Run("Editors can write", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		handler := NewAppSyncHandler(mockRepo, WithPermissions(policy))
+		mockRepo.On("Delete", ctx, "acc-12345", "loc-001").Return(nil).Once()
+
+		_, err := handler.Handle(ctx, deleteEvent(identity("viewer", "editor")))
+		require.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Type-limited roles can write their stored types", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		handler := NewAppSyncHandler(mockRepo, WithPermissions(policy))
+		mockRepo.On("Get", mock.Anything, "acc-12345", "loc-001").Return(withID("loc-001", shop), nil).Once()
+		mockRepo.On("Delete", ctx, "acc-12345", "loc-001").Return(nil).Once()
+
+		_, err := handler.Handle(ctx, deleteEvent(identity("shop-editor")))
+		require.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Type-limited roles cannot write other types", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		handler := NewAppSyncHandler(mockRepo, WithPermissions(policy))
+		mockRepo.On("Get", mock.Anything, "acc-12345", "loc-001").Return(withID("loc-001", coordinates), nil).Once()
+
+		_, err := handler.Handle(ctx, deleteEvent(identity("shop-editor")))
+		assert.EqualError(t, err, "permission denied: the caller's roles may only write shop locations, not coordinates")
+		mockRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Type-limited roles cannot create other types", func(t *testing.T) {
+		handler := NewAppSyncHandler(new(mockRepository), WithPermissions(policy))
+
+		_, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "createCoordinatesLocation",
+			Arguments: json.RawMessage(`{"input": {"accountId": "acc-12345", "locationType": "coordinates", "coordinates": {"latitude": 40.7128, "longitude": -74.006}}}`),
+			Identity:  identity("shop-editor"),
+		})
+		assert.EqualError(t, err, "permission denied: the caller's roles may only write shop locations, not coordinates")
+	})
+
+	t.Run("Type-limited roles cannot write account-wide data", func(t *testing.T) {
+		handler := NewAppSyncHandler(new(mockRepository), WithPermissions(policy))
+
+		_, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "updateAccountSettings",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "input": {}}`),
+			Identity:  identity("shop-editor"),
+		})
+		assert.EqualError(t, err, "permission denied: the caller's roles may only write shop locations, and updateAccountSettings does not write a single location")
+	})
+
+	t.Run("Internal invocations are not checked", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		handler := NewAppSyncHandler(mockRepo, WithPermissions(policy))
+		mockRepo.On("Delete", ctx, "acc-12345", "loc-001").Return(nil).Once()
+
+		event := deleteEvent(AppSyncIdentity{})
+		event.Invoker = InternalInvoker
+		_, err := handler.Handle(ctx, event)
+		require.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Unidentified callers are denied", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		handler := NewAppSyncHandler(mockRepo, WithPermissions(policy))
+
+		// A Lambda authorizer returning no resolverContext leaves the identity empty
+		_, err := handler.Handle(ctx, deleteEvent(AppSyncIdentity{}))
+		assert.EqualError(t, err, "permission denied: deleteLocation requires an identified caller")
+		mockRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("The internal marker does not excuse an identified caller", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		handler := NewAppSyncHandler(mockRepo, WithPermissions(policy))
+
+		event := deleteEvent(identity("viewer"))
+		event.Invoker = InternalInvoker
+		_, err := handler.Handle(ctx, event)
+		assert.EqualError(t, err, "permission denied: deleteLocation is not allowed for the caller's roles")
+	})
+}
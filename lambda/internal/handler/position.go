@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/steverhoton/location-lambda/internal/geo"
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/notify"
+)
+
+// checkPosition applies the account's position checks to an update of a
+// coordinates location, comparing it with the stored position, or with the
+// last plausible one while the stored position is flagged. An implausible
+// update is reported to the account's webhook, then either rejected or
+// returned with its positionAnomaly set; a plausible one clears it. Other
+// locations, and accounts without position checks, are returned unchanged.
+func (h *AppSyncHandler) checkPosition(ctx context.Context, location models.Location, locationID string) (models.Location, error) {
+	update, ok := location.(models.CoordinatesLocation)
+	if !ok || h.settings == nil {
+		return location, nil
+	}
+	settings, err := h.settings.GetAccountSettings(ctx, update.AccountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account settings: %w", err)
+	}
+	if settings.PositionChecks == nil {
+		return location, nil
+	}
+
+	envelope, err := h.repo.Get(ctx, update.AccountID, locationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check position: %w", err)
+	}
+	stored, ok := envelope.Location.(models.CoordinatesLocation)
+	if !ok {
+		return location, nil
+	}
+	previous := stored.Coordinates
+	if stored.PositionAnomaly != nil {
+		previous = stored.PositionAnomaly.Previous
+	}
+
+	anomaly := positionAnomaly(*settings.PositionChecks, previous, update.Coordinates, h.clock.Now().UTC())
+	if anomaly == nil {
+		return update, nil
+	}
+
+	rejected := settings.PositionChecks.Action == models.PositionCheckReject
+	message := fmt.Sprintf("location %s %s", locationID, describeAnomaly(*anomaly))
+	if rejected {
+		message += "; the update was rejected"
+	}
+	// The update shouldn't fail, or be let through, because a webhook is down
+	if _, err := h.notifyAccount(ctx, notify.Notice{
+		Type:        notify.NoticePositionAnomaly,
+		AccountID:   update.AccountID,
+		LocationIDs: []string{locationID},
+		Message:     message,
+	}); err != nil {
+		log.Printf("WARN: Failed to report position anomaly of location %s: %v", locationID, err)
+	}
+
+	if rejected {
+		return nil, fmt.Errorf("position update rejected: %s", describeAnomaly(*anomaly))
+	}
+	update.PositionAnomaly = anomaly
+	return update, nil
+}
+
+// positionAnomaly returns how moving from previous to next breaks checks, or
+// nil when it doesn't. The speed check needs both fixes' observedAt.
+func positionAnomaly(checks models.PositionChecks, previous, next models.Coordinates, now time.Time) *models.PositionAnomaly {
+	distance := geo.Distance(previous, next)
+	if distance == 0 {
+		return nil
+	}
+	anomaly := &models.PositionAnomaly{DistanceMeters: distance, Previous: previous, DetectedAt: now}
+
+	if previous.ObservedAt != nil && next.ObservedAt != nil {
+		elapsed := next.ObservedAt.Sub(*previous.ObservedAt).Seconds()
+		if elapsed <= 0 {
+			anomaly.Reason = models.PositionAnomalyOutOfOrder
+			return anomaly
+		}
+		speed := distance / elapsed * 3.6
+		anomaly.ElapsedSeconds = &elapsed
+		anomaly.SpeedKmh = &speed
+		if checks.MaxSpeedKmh > 0 && speed > checks.MaxSpeedKmh {
+			anomaly.Reason = models.PositionAnomalySpeed
+			return anomaly
+		}
+	}
+	if checks.MaxJumpMeters > 0 && distance > checks.MaxJumpMeters {
+		anomaly.Reason = models.PositionAnomalyJump
+		return anomaly
+	}
+	return nil
+}
+
+// describeAnomaly explains an anomaly for notices and errors.
+func describeAnomaly(anomaly models.PositionAnomaly) string {
+	switch anomaly.Reason {
+	case models.PositionAnomalySpeed:
+		return fmt.Sprintf("moved %.0fm in %.0fs, %.0fkm/h", anomaly.DistanceMeters, *anomaly.ElapsedSeconds, *anomaly.SpeedKmh)
+	case models.PositionAnomalyOutOfOrder:
+		return fmt.Sprintf("moved %.0fm with a fix observed no later than the last one", anomaly.DistanceMeters)
+	default:
+		return fmt.Sprintf("jumped %.0fm", anomaly.DistanceMeters)
+	}
+}
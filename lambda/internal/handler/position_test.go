@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/notify"
+	"github.com/steverhoton/location-lambda/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppSyncHandlerPositionChecks(t *testing.T) {
+	ctx := context.Background()
+	observed := func(minutes int) *time.Time {
+		at := time.Date(2024, 6, 1, 12, minutes, 0, 0, time.UTC)
+		return &at
+	}
+	denver := models.Coordinates{Latitude: 39.7392, Longitude: -104.9903, ObservedAt: observed(0)}
+	truck := func(coordinates models.Coordinates, anomaly *models.PositionAnomaly) models.CoordinatesLocation {
+		return models.CoordinatesLocation{
+			LocationBase:    models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeCoordinates},
+			Coordinates:     coordinates,
+			PositionAnomaly: anomaly,
+		}
+	}
+	update := func(latitude, longitude float64, minutes int) AppSyncEvent {
+		return AppSyncEvent{Field: "updateLocation", Arguments: json.RawMessage(fmt.Sprintf(`{
+			"accountId": "acc-12345",
+			"locationId": "loc-001",
+			"input": {
+				"accountId": "acc-12345",
+				"locationType": "coordinates",
+				"coordinates": {"latitude": %g, "longitude": %g, "observedAt": %q}
+			}
+		}`, latitude, longitude, observed(minutes).Format(time.RFC3339)))}
+	}
+	settings := func(checks models.PositionChecks) *models.AccountSettings {
+		s := models.DefaultAccountSettings("acc-12345")
+		s.WebhookURL = "https://hooks.example.com/locations"
+		s.PositionChecks = &checks
+		return &s
+	}
+
+	t.Run("Flags an impossible speed and notifies the account", func(t *testing.T) {
+		repo, store, notifier := new(mockRepository), new(mockSettingsStore), new(mockNotifier)
+		handler := NewAppSyncHandler(repo, WithSettingsStore(store), WithNotifier(notifier))
+
+		store.On("GetAccountSettings", mock.Anything, "acc-12345").Return(settings(models.PositionChecks{MaxSpeedKmh: 160, Action: models.PositionCheckFlag}), nil)
+		repo.On("Get", mock.Anything, "acc-12345", "loc-001").Return(&repository.LocationEnvelope{LocationID: "loc-001", Location: truck(denver, nil)}, nil).Once()
+		repo.On("Update", mock.Anything, mock.MatchedBy(func(location models.Location) bool {
+			anomaly := location.(models.CoordinatesLocation).PositionAnomaly
+			return anomaly != nil && anomaly.Reason == models.PositionAnomalySpeed && anomaly.Previous == denver
+		}), "loc-001").Return(nil).Once()
+		notifier.On("Notify", mock.Anything, "https://hooks.example.com/locations", mock.MatchedBy(func(notice notify.Notice) bool {
+			return notice.Type == notify.NoticePositionAnomaly && assert.ObjectsAreEqual([]string{"loc-001"}, notice.LocationIDs)
+		})).Return(nil).Once()
+
+		// Boulder is about 39km from Denver, five minutes later
+		result, err := handler.Handle(ctx, update(40.0150, -105.2705, 5))
+		require.NoError(t, err)
+		response := result.(*UpdateResponse)
+		assert.Equal(t, "location updated; position flagged as anomalous", response.Message)
+		require.NotNil(t, response.PositionAnomaly)
+		assert.InDelta(t, 470, *response.PositionAnomaly.SpeedKmh, 10)
+		assert.Equal(t, 300.0, *response.PositionAnomaly.ElapsedSeconds)
+		repo.AssertExpectations(t)
+		notifier.AssertExpectations(t)
+	})
+
+	t.Run("Rejects a jump and keeps the stored position", func(t *testing.T) {
+		repo, store := new(mockRepository), new(mockSettingsStore)
+		handler := NewAppSyncHandler(repo, WithSettingsStore(store))
+
+		store.On("GetAccountSettings", mock.Anything, "acc-12345").Return(settings(models.PositionChecks{MaxJumpMeters: 5000, Action: models.PositionCheckReject}), nil)
+		repo.On("Get", mock.Anything, "acc-12345", "loc-001").Return(&repository.LocationEnvelope{LocationID: "loc-001", Location: truck(denver, nil)}, nil).Once()
+
+		// A day later, too long for the speed to be implausible
+		_, err := handler.Handle(ctx, update(40.0150, -105.2705, 60*24))
+		require.Error(t, err)
+		assert.Regexp(t, `^position update rejected: jumped 3\d{4}m$`, err.Error())
+		repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Plausible update clears a flagged position", func(t *testing.T) {
+		repo, store := new(mockRepository), new(mockSettingsStore)
+		handler := NewAppSyncHandler(repo, WithSettingsStore(store))
+
+		boulder := models.Coordinates{Latitude: 40.0150, Longitude: -105.2705, ObservedAt: observed(5)}
+		flagged := truck(boulder, &models.PositionAnomaly{Reason: models.PositionAnomalySpeed, Previous: denver})
+		store.On("GetAccountSettings", mock.Anything, "acc-12345").Return(settings(models.PositionChecks{MaxSpeedKmh: 160, Action: models.PositionCheckFlag}), nil)
+		repo.On("Get", mock.Anything, "acc-12345", "loc-001").Return(&repository.LocationEnvelope{LocationID: "loc-001", Location: flagged}, nil).Once()
+		// Checked against Denver, the last plausible position, not Boulder
+		repo.On("Update", mock.Anything, mock.MatchedBy(func(location models.Location) bool {
+			return location.(models.CoordinatesLocation).PositionAnomaly == nil
+		}), "loc-001").Return(nil).Once()
+
+		result, err := handler.Handle(ctx, update(39.7400, -104.9900, 6))
+		require.NoError(t, err)
+		assert.Nil(t, result.(*UpdateResponse).PositionAnomaly)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Ignores a client-sent anomaly when checks are off", func(t *testing.T) {
+		repo, store := new(mockRepository), new(mockSettingsStore)
+		handler := NewAppSyncHandler(repo, WithSettingsStore(store))
+
+		store.On("GetAccountSettings", mock.Anything, "acc-12345").Return(&models.AccountSettings{AccountID: "acc-12345"}, nil)
+		repo.On("Update", mock.Anything, mock.MatchedBy(func(location models.Location) bool {
+			return location.(models.CoordinatesLocation).PositionAnomaly == nil
+		}), "loc-001").Return(nil).Once()
+
+		_, err := handler.Handle(ctx, AppSyncEvent{Field: "updateLocation", Arguments: json.RawMessage(`{
+			"accountId": "acc-12345",
+			"locationId": "loc-001",
+			"input": {
+				"accountId": "acc-12345",
+				"locationType": "coordinates",
+				"coordinates": {"latitude": 39.7392, "longitude": -104.9903},
+				"positionAnomaly": {"reason": "jump", "distanceMeters": 1}
+			}
+		}`)})
+		require.NoError(t, err)
+		repo.AssertExpectations(t)
+		repo.AssertNotCalled(t, "Get", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestPositionAnomaly(t *testing.T) {
+	now := time.Date(2024, 6, 1, 13, 0, 0, 0, time.UTC)
+	at := func(minutes int) *time.Time {
+		observed := time.Date(2024, 6, 1, 12, minutes, 0, 0, time.UTC)
+		return &observed
+	}
+	denver := models.Coordinates{Latitude: 39.7392, Longitude: -104.9903, ObservedAt: at(10)}
+	checks := models.PositionChecks{MaxSpeedKmh: 160, MaxJumpMeters: 50000, Action: models.PositionCheckFlag}
+
+	tests := []struct {
+		name   string
+		next   models.Coordinates
+		reason string
+	}{
+		{name: "Unmoved", next: models.Coordinates{Latitude: 39.7392, Longitude: -104.9903, ObservedAt: at(5)}},
+		{name: "Driving speed", next: models.Coordinates{Latitude: 39.7492, Longitude: -104.9903, ObservedAt: at(11)}},
+		{name: "Too fast", next: models.Coordinates{Latitude: 39.7792, Longitude: -104.9903, ObservedAt: at(11)}, reason: models.PositionAnomalySpeed},
+		{name: "Older fix", next: models.Coordinates{Latitude: 39.7492, Longitude: -104.9903, ObservedAt: at(9)}, reason: models.PositionAnomalyOutOfOrder},
+		{name: "Jump without observedAt", next: models.Coordinates{Latitude: 40.5, Longitude: -104.9903}, reason: models.PositionAnomalyJump},
+		{name: "Short move without observedAt", next: models.Coordinates{Latitude: 39.9, Longitude: -104.9903}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			anomaly := positionAnomaly(checks, denver, tt.next, now)
+			if tt.reason == "" {
+				assert.Nil(t, anomaly)
+				return
+			}
+			require.NotNil(t, anomaly)
+			assert.Equal(t, tt.reason, anomaly.Reason)
+			assert.Equal(t, denver, anomaly.Previous)
+			assert.Equal(t, now, anomaly.DetectedAt)
+		})
+	}
+}
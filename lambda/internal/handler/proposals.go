@@ -0,0 +1,199 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/steverhoton/location-lambda/internal/repository"
+)
+
+// ProposeLocationUpdateArguments represents arguments for proposing a change to a location.
+type ProposeLocationUpdateArguments struct {
+	AccountID  string          `json:"accountId"`
+	LocationID string          `json:"locationId"`
+	Input      json.RawMessage `json:"input"`
+}
+
+// ListProposalsArguments represents arguments for listing an account's change proposals.
+type ListProposalsArguments struct {
+	AccountID string `json:"accountId"`
+	// Status limits the list to proposals in that status
+	Status repository.ProposalStatus `json:"status,omitempty"`
+	Limit  *int32                    `json:"limit,omitempty"`
+	Cursor *string                   `json:"cursor,omitempty"`
+}
+
+// ReviewProposalArguments represents arguments for approving or rejecting a change proposal.
+type ReviewProposalArguments struct {
+	AccountID  string `json:"accountId"`
+	ProposalID string `json:"proposalId"`
+	Approve    bool   `json:"approve"`
+	Comment    string `json:"comment,omitempty"`
+}
+
+// WithProposalStore enables the change proposal operations.
+func WithProposalStore(store repository.ProposalStore) Option {
+	return func(h *AppSyncHandler) {
+		h.proposals = store
+	}
+}
+
+// proposalStore returns the configured proposal store or an error when proposals are disabled.
+func (h *AppSyncHandler) proposalStore() (repository.ProposalStore, error) {
+	if h.proposals == nil {
+		return nil, fmt.Errorf("change proposals are not configured")
+	}
+	return h.proposals, nil
+}
+
+// handleProposeLocationUpdate stores a proposed replacement of a location
+// for an admin to review. The input is checked as updateLocation would check
+// it, but the location is left unchanged.
+func (h *AppSyncHandler) handleProposeLocationUpdate(ctx context.Context, event AppSyncEvent) (*repository.LocationProposal, error) {
+	var args ProposeLocationUpdateArguments
+	if err := json.Unmarshal(event.Arguments, &args); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+
+	if args.AccountID == "" || args.LocationID == "" {
+		return nil, fmt.Errorf("accountId and locationId are required")
+	}
+	store, err := h.proposalStore()
+	if err != nil {
+		return nil, err
+	}
+
+	// Proposals are reviewed as submitted, so nothing is dropped from them
+	location, _, err := h.updateInput(ctx, args.Input, args.AccountID, &strictValidation)
+	if err != nil {
+		return nil, err
+	}
+	if err := location.Validate(); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	current, err := h.repo.Get(ctx, args.AccountID, args.LocationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get location: %w", err)
+	}
+	if current.Location.GetLocationType() != location.GetLocationType() {
+		return nil, fmt.Errorf("proposals cannot change a location's type from %s to %s", current.Location.GetLocationType(), location.GetLocationType())
+	}
+
+	var input map[string]interface{}
+	if err := json.Unmarshal(args.Input, &input); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal input: %w", err)
+	}
+	proposalID, err := store.CreateProposal(ctx, repository.LocationProposal{
+		AccountID:  args.AccountID,
+		LocationID: args.LocationID,
+		Input:      input,
+		BaseETag:   current.ETag,
+		ProposedBy: event.Identity.Username,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create proposal: %w", err)
+	}
+
+	proposal, err := store.GetProposal(ctx, args.AccountID, proposalID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get proposal: %w", err)
+	}
+	return proposal, nil
+}
+
+// handleListProposals lists an account's change proposals for admins.
+func (h *AppSyncHandler) handleListProposals(ctx context.Context, event AppSyncEvent) (*repository.ProposalListResult, error) {
+	if err := h.requireAdmin(event.Identity); err != nil {
+		return nil, err
+	}
+
+	var args ListProposalsArguments
+	if err := json.Unmarshal(event.Arguments, &args); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+
+	store, err := h.proposalStore()
+	if err != nil {
+		return nil, err
+	}
+	switch args.Status {
+	case "", repository.ProposalPending, repository.ProposalApproved, repository.ProposalRejected, repository.ProposalConflicting:
+	default:
+		return nil, fmt.Errorf("status must be %s, %s, %s, or %s", repository.ProposalPending, repository.ProposalApproved, repository.ProposalRejected, repository.ProposalConflicting)
+	}
+
+	result, err := store.ListProposals(ctx, args.AccountID, args.Status, &repository.ListOptions{
+		Limit:  args.Limit,
+		Cursor: args.Cursor,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list proposals: %w", err)
+	}
+
+	return result, nil
+}
+
+// handleReviewProposal approves or rejects a pending proposal for admins.
+// Approval applies the proposed location as updateLocation would, with the
+// account's current custom fields and categories, before the review is
+// recorded, so a change that no longer applies leaves the proposal pending.
+// A proposal whose location has changed since it was made is not applied,
+// so it cannot undo those changes, but recorded as conflicting instead.
+func (h *AppSyncHandler) handleReviewProposal(ctx context.Context, event AppSyncEvent) (*repository.LocationProposal, error) {
+	if err := h.requireAdmin(event.Identity); err != nil {
+		return nil, err
+	}
+
+	var args ReviewProposalArguments
+	if err := json.Unmarshal(event.Arguments, &args); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+
+	if args.AccountID == "" || args.ProposalID == "" {
+		return nil, fmt.Errorf("accountId and proposalId are required")
+	}
+	store, err := h.proposalStore()
+	if err != nil {
+		return nil, err
+	}
+
+	status := repository.ProposalRejected
+	if args.Approve {
+		status = repository.ProposalApproved
+
+		proposal, err := store.GetProposal(ctx, args.AccountID, args.ProposalID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get proposal: %w", err)
+		}
+		if proposal.Status != repository.ProposalPending {
+			return nil, fmt.Errorf("proposal is already %s", proposal.Status)
+		}
+		input, err := json.Marshal(proposal.Input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal proposal input: %w", err)
+		}
+		location, _, err := h.updateInput(ctx, input, proposal.AccountID, &strictValidation)
+		if err != nil {
+			return nil, err
+		}
+		applyCtx := ctx
+		if proposal.BaseETag != "" {
+			applyCtx = repository.WithIfMatch(ctx, proposal.BaseETag)
+		}
+		err = h.repo.Update(applyCtx, location, proposal.LocationID)
+		var precondition *repository.PreconditionFailedError
+		if errors.As(err, &precondition) {
+			status = repository.ProposalConflicting
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to apply proposal: %w", err)
+		}
+	}
+
+	reviewed, err := store.ReviewProposal(ctx, args.AccountID, args.ProposalID, status, event.Identity.Username, args.Comment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to review proposal: %w", err)
+	}
+	return reviewed, nil
+}
@@ -0,0 +1,248 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockProposalStore is a mock implementation of the repository.ProposalStore interface.
+type mockProposalStore struct {
+	mock.Mock
+}
+
+func (m *mockProposalStore) CreateProposal(ctx context.Context, proposal repository.LocationProposal) (string, error) {
+	args := m.Called(ctx, proposal)
+	return args.String(0), args.Error(1)
+}
+
+func (m *mockProposalStore) GetProposal(ctx context.Context, accountID, proposalID string) (*repository.LocationProposal, error) {
+	args := m.Called(ctx, accountID, proposalID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.LocationProposal), args.Error(1)
+}
+
+func (m *mockProposalStore) ListProposals(ctx context.Context, accountID string, status repository.ProposalStatus, options *repository.ListOptions) (*repository.ProposalListResult, error) {
+	args := m.Called(ctx, accountID, status, options)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.ProposalListResult), args.Error(1)
+}
+
+func (m *mockProposalStore) ReviewProposal(ctx context.Context, accountID, proposalID string, status repository.ProposalStatus, reviewedBy, comment string) (*repository.LocationProposal, error) {
+	args := m.Called(ctx, accountID, proposalID, status, reviewedBy, comment)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.LocationProposal), args.Error(1)
+}
+
+func TestAppSyncHandlerProposeLocationUpdate(t *testing.T) {
+	ctx := context.Background()
+	current := models.CoordinatesLocation{
+		LocationBase: models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeCoordinates},
+		Coordinates:  models.Coordinates{Latitude: 40.7128, Longitude: -74.0060},
+	}
+	identity := AppSyncIdentity{Username: "field-tech"}
+
+	t.Run("Stores the proposal without changing the location", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		store := new(mockProposalStore)
+		handler := NewAppSyncHandler(mockRepo, WithProposalStore(store))
+
+		mockRepo.On("Get", ctx, "acc-12345", "loc-001").Return(&repository.LocationEnvelope{LocationID: "loc-001", Location: current, ETag: "etag-1"}, nil).Once()
+		store.On("CreateProposal", ctx, mock.MatchedBy(func(p repository.LocationProposal) bool {
+			return p.AccountID == "acc-12345" && p.LocationID == "loc-001" && p.ProposedBy == "field-tech" && p.BaseETag == "etag-1" &&
+				p.Input["coordinates"].(map[string]interface{})["latitude"] == 40.7306
+		})).Return("prop-001", nil).Once()
+		proposal := &repository.LocationProposal{ProposalID: "prop-001", Status: repository.ProposalPending}
+		store.On("GetProposal", ctx, "acc-12345", "prop-001").Return(proposal, nil).Once()
+
+		result, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "proposeLocationUpdate",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "locationId": "loc-001", "input": {"accountId": "acc-12345", "locationType": "coordinates", "coordinates": {"latitude": 40.7306, "longitude": -73.9352}}}`),
+			Identity:  identity,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, proposal, result)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+		store.AssertExpectations(t)
+	})
+
+	t.Run("Rejects invalid input", func(t *testing.T) {
+		store := new(mockProposalStore)
+		handler := NewAppSyncHandler(new(mockRepository), WithProposalStore(store))
+
+		_, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "proposeLocationUpdate",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "locationId": "loc-001", "input": {"accountId": "acc-12345", "locationType": "coordinates", "coordinates": {"latitude": 140, "longitude": -73.9352}}}`),
+			Identity:  identity,
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "validation failed")
+		store.AssertNotCalled(t, "CreateProposal", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Rejects type changes", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		handler := NewAppSyncHandler(mockRepo, WithProposalStore(new(mockProposalStore)))
+
+		mockRepo.On("Get", ctx, "acc-12345", "loc-001").Return(withID("loc-001", current), nil).Once()
+
+		_, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "proposeLocationUpdate",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "locationId": "loc-001", "input": {"accountId": "acc-12345", "locationType": "address", "address": {"streetAddress": "123 Main St", "city": "Springfield", "postalCode": "62704", "country": "US"}}}`),
+			Identity:  identity,
+		})
+		assert.EqualError(t, err, "proposals cannot change a location's type from coordinates to address")
+	})
+}
+
+func TestAppSyncHandlerReviewProposal(t *testing.T) {
+	ctx := context.Background()
+	admin := AppSyncIdentity{Username: "governance-lead", Claims: map[string]interface{}{"cognito:groups": []interface{}{"location-admins"}}}
+	pending := &repository.LocationProposal{
+		ProposalID: "prop-001",
+		AccountID:  "acc-12345",
+		LocationID: "loc-001",
+		BaseETag:   "etag-1",
+		Status:     repository.ProposalPending,
+		Input: map[string]interface{}{
+			"accountId":    "acc-12345",
+			"locationType": "coordinates",
+			"coordinates":  map[string]interface{}{"latitude": 40.7306, "longitude": -73.9352},
+		},
+	}
+	newHandler := func(mockRepo *mockRepository, store *mockProposalStore) *AppSyncHandler {
+		return NewAppSyncHandler(mockRepo, WithProposalStore(store), WithAdminStore(new(mockAdminStore), "location-admins"))
+	}
+
+	t.Run("Approval applies the change", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		store := new(mockProposalStore)
+		handler := newHandler(mockRepo, store)
+
+		store.On("GetProposal", ctx, "acc-12345", "prop-001").Return(pending, nil).Once()
+		mockRepo.On("Update", repository.WithIfMatch(ctx, "etag-1"), mock.MatchedBy(func(l models.Location) bool {
+			loc, ok := l.(models.CoordinatesLocation)
+			return ok && loc.Coordinates.Latitude == 40.7306
+		}), "loc-001").Return(nil).Once()
+		approved := &repository.LocationProposal{ProposalID: "prop-001", Status: repository.ProposalApproved}
+		store.On("ReviewProposal", ctx, "acc-12345", "prop-001", repository.ProposalApproved, "governance-lead", "").Return(approved, nil).Once()
+
+		result, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "reviewProposal",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "proposalId": "prop-001", "approve": true}`),
+			Identity:  admin,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, approved, result)
+		mockRepo.AssertExpectations(t)
+		store.AssertExpectations(t)
+	})
+
+	t.Run("A change that no longer applies leaves the proposal pending", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		store := new(mockProposalStore)
+		handler := newHandler(mockRepo, store)
+
+		store.On("GetProposal", ctx, "acc-12345", "prop-001").Return(pending, nil).Once()
+		mockRepo.On("Update", mock.Anything, mock.Anything, "loc-001").Return(errors.New("location not found or access denied")).Once()
+
+		_, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "reviewProposal",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "proposalId": "prop-001", "approve": true}`),
+			Identity:  admin,
+		})
+		assert.EqualError(t, err, "failed to apply proposal: location not found or access denied")
+		store.AssertNotCalled(t, "ReviewProposal", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("A location changed since the proposal marks it conflicting", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		store := new(mockProposalStore)
+		handler := newHandler(mockRepo, store)
+
+		store.On("GetProposal", ctx, "acc-12345", "prop-001").Return(pending, nil).Once()
+		mockRepo.On("Update", repository.WithIfMatch(ctx, "etag-1"), mock.Anything, "loc-001").Return(&repository.PreconditionFailedError{ETag: "etag-2"}).Once()
+		conflicting := &repository.LocationProposal{ProposalID: "prop-001", Status: repository.ProposalConflicting}
+		store.On("ReviewProposal", ctx, "acc-12345", "prop-001", repository.ProposalConflicting, "governance-lead", "").Return(conflicting, nil).Once()
+
+		result, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "reviewProposal",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "proposalId": "prop-001", "approve": true}`),
+			Identity:  admin,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, conflicting, result)
+		mockRepo.AssertExpectations(t)
+		store.AssertExpectations(t)
+	})
+
+	t.Run("Rejection leaves the location alone", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		store := new(mockProposalStore)
+		handler := newHandler(mockRepo, store)
+
+		rejected := &repository.LocationProposal{ProposalID: "prop-001", Status: repository.ProposalRejected, Comment: "wrong site"}
+		store.On("ReviewProposal", ctx, "acc-12345", "prop-001", repository.ProposalRejected, "governance-lead", "wrong site").Return(rejected, nil).Once()
+
+		result, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "reviewProposal",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "proposalId": "prop-001", "approve": false, "comment": "wrong site"}`),
+			Identity:  admin,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, rejected, result)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Requires an admin", func(t *testing.T) {
+		store := new(mockProposalStore)
+		handler := newHandler(new(mockRepository), store)
+
+		for _, field := range []string{"reviewProposal", "listProposals"} {
+			_, err := handler.Handle(ctx, AppSyncEvent{
+				Field:     field,
+				Arguments: json.RawMessage(`{"accountId": "acc-12345", "proposalId": "prop-001", "approve": true}`),
+				Identity:  AppSyncIdentity{Username: "field-tech"},
+			})
+			assert.EqualError(t, err, "admin access required", field)
+		}
+		store.AssertNotCalled(t, "GetProposal", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestAppSyncHandlerListProposals(t *testing.T) {
+	ctx := context.Background()
+	admin := AppSyncIdentity{Claims: map[string]interface{}{"cognito:groups": []interface{}{"location-admins"}}}
+	store := new(mockProposalStore)
+	handler := NewAppSyncHandler(new(mockRepository), WithProposalStore(store), WithAdminStore(new(mockAdminStore), "location-admins"))
+
+	store.On("ListProposals", ctx, "acc-12345", repository.ProposalPending, mock.Anything).Return(&repository.ProposalListResult{Proposals: []repository.LocationProposal{{ProposalID: "prop-001"}}}, nil).Once()
+
+	result, err := handler.Handle(ctx, AppSyncEvent{
+		Field:     "listProposals",
+		Arguments: json.RawMessage(`{"accountId": "acc-12345", "status": "pending"}`),
+		Identity:  admin,
+	})
+	require.NoError(t, err)
+	assert.Len(t, result.(*repository.ProposalListResult).Proposals, 1)
+
+	_, err = handler.Handle(ctx, AppSyncEvent{
+		Field:     "listProposals",
+		Arguments: json.RawMessage(`{"accountId": "acc-12345", "status": "merged"}`),
+		Identity:  admin,
+	})
+	assert.EqualError(t, err, "status must be pending, approved, rejected, or conflicting")
+}
@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+)
+
+// maxOccurrenceRange is the longest span occurrencesBetween expands.
+const maxOccurrenceRange = 366 * 24 * time.Hour
+
+// OccurrencesBetweenArguments represents arguments for expanding a location's schedule.
+type OccurrencesBetweenArguments struct {
+	AccountID  string `json:"accountId"`
+	LocationID string `json:"locationId"`
+	// From and To are RFC 3339 timestamps bounding the occurrences returned
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// handleOccurrencesBetween lists when an event or a shop with a recurrence
+// is on between from and to, including occurrences already under way at from.
+func (h *AppSyncHandler) handleOccurrencesBetween(ctx context.Context, arguments json.RawMessage) ([]models.Occurrence, error) {
+	var args OccurrencesBetweenArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+
+	if args.AccountID == "" || args.LocationID == "" {
+		return nil, fmt.Errorf("accountId and locationId are required")
+	}
+	from, err := time.Parse(time.RFC3339, args.From)
+	if err != nil {
+		return nil, fmt.Errorf("from must be an RFC 3339 timestamp")
+	}
+	to, err := time.Parse(time.RFC3339, args.To)
+	if err != nil {
+		return nil, fmt.Errorf("to must be an RFC 3339 timestamp")
+	}
+	if !to.After(from) {
+		return nil, fmt.Errorf("to must be after from")
+	}
+	if to.Sub(from) > maxOccurrenceRange {
+		return nil, fmt.Errorf("from and to can be at most 366 days apart")
+	}
+
+	envelope, err := h.repo.Get(ctx, args.AccountID, args.LocationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get location: %w", err)
+	}
+	occurrences, err := models.OccurrencesBetween(envelope.Location, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if occurrences == nil {
+		occurrences = []models.Occurrence{}
+	}
+	return occurrences, nil
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppSyncHandlerOccurrencesBetween(t *testing.T) {
+	ctx := context.Background()
+	market := models.ShopLocation{
+		LocationBase: models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeShop},
+		Shop: models.Shop{
+			Name:       "Taco Truck",
+			ContactID:  "contact-001",
+			Address:    models.Address{StreetAddress: "100 Market Sq", City: "Springfield", PostalCode: "62701", Country: "US"},
+			Recurrence: &models.Recurrence{Rule: "FREQ=WEEKLY;BYDAY=SA", TimeZone: "UTC", StartDate: "2024-06-01", StartTime: "08:00", EndTime: "13:00"},
+		},
+	}
+	occurrencesBetween := func(arguments string) AppSyncEvent {
+		return AppSyncEvent{Field: "occurrencesBetween", Arguments: json.RawMessage(arguments)}
+	}
+
+	t.Run("Expands a shop's recurrence", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		handler := NewAppSyncHandler(mockRepo)
+
+		mockRepo.On("Get", ctx, "acc-12345", "loc-001").Return(withID("loc-001", market), nil).Once()
+
+		result, err := handler.Handle(ctx, occurrencesBetween(`{"accountId": "acc-12345", "locationId": "loc-001", "from": "2024-06-01T10:00:00Z", "to": "2024-06-15T00:00:00Z"}`))
+		require.NoError(t, err)
+		assert.Equal(t, []models.Occurrence{
+			{StartsAt: time.Date(2024, time.June, 1, 8, 0, 0, 0, time.UTC), EndsAt: time.Date(2024, time.June, 1, 13, 0, 0, 0, time.UTC)},
+			{StartsAt: time.Date(2024, time.June, 8, 8, 0, 0, 0, time.UTC), EndsAt: time.Date(2024, time.June, 8, 13, 0, 0, 0, time.UTC)},
+		}, result)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Returns an empty list when nothing occurs", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		handler := NewAppSyncHandler(mockRepo)
+
+		mockRepo.On("Get", ctx, "acc-12345", "loc-001").Return(withID("loc-001", market), nil).Once()
+
+		result, err := handler.Handle(ctx, occurrencesBetween(`{"accountId": "acc-12345", "locationId": "loc-001", "from": "2024-06-03T00:00:00Z", "to": "2024-06-07T00:00:00Z"}`))
+		require.NoError(t, err)
+		assert.Equal(t, []models.Occurrence{}, result)
+	})
+
+	t.Run("Rejects shops without a recurrence", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		handler := NewAppSyncHandler(mockRepo)
+
+		shop := market
+		shop.Shop.Recurrence = nil
+		mockRepo.On("Get", ctx, "acc-12345", "loc-001").Return(withID("loc-001", shop), nil).Once()
+
+		_, err := handler.Handle(ctx, occurrencesBetween(`{"accountId": "acc-12345", "locationId": "loc-001", "from": "2024-06-01T00:00:00Z", "to": "2024-06-15T00:00:00Z"}`))
+		assert.EqualError(t, err, "shop has no recurrence")
+	})
+
+	t.Run("Validates the range", func(t *testing.T) {
+		tests := []struct {
+			name      string
+			arguments string
+			errMsg    string
+		}{
+			{name: "Missing locationId", arguments: `{"accountId": "acc-12345", "from": "2024-06-01T00:00:00Z", "to": "2024-06-15T00:00:00Z"}`, errMsg: "accountId and locationId are required"},
+			{name: "Malformed from", arguments: `{"accountId": "acc-12345", "locationId": "loc-001", "from": "2024-06-01", "to": "2024-06-15T00:00:00Z"}`, errMsg: "from must be an RFC 3339 timestamp"},
+			{name: "Malformed to", arguments: `{"accountId": "acc-12345", "locationId": "loc-001", "from": "2024-06-01T00:00:00Z"}`, errMsg: "to must be an RFC 3339 timestamp"},
+			{name: "Backwards", arguments: `{"accountId": "acc-12345", "locationId": "loc-001", "from": "2024-06-15T00:00:00Z", "to": "2024-06-01T00:00:00Z"}`, errMsg: "to must be after from"},
+			{name: "Too long", arguments: `{"accountId": "acc-12345", "locationId": "loc-001", "from": "2024-01-01T00:00:00Z", "to": "2025-01-02T00:00:01Z"}`, errMsg: "from and to can be at most 366 days apart"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				mockRepo := new(mockRepository)
+				handler := NewAppSyncHandler(mockRepo)
+
+				_, err := handler.Handle(ctx, occurrencesBetween(tt.arguments))
+				assert.EqualError(t, err, tt.errMsg)
+				mockRepo.AssertNotCalled(t, "Get", mock.Anything, mock.Anything, mock.Anything)
+			})
+		}
+	})
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/steverhoton/location-lambda/internal/repository"
+)
+
+// Request headers identifying a mutation for replay protection. A client's
+// own nonce is preferred to a request ID added by a proxy.
+const (
+	nonceHeader     = "x-nonce"
+	requestIDHeader = "x-request-id"
+)
+
+// DefaultReplayWindow is how long a request ID is remembered when no other
+// window is configured.
+const DefaultReplayWindow = 10 * time.Minute
+
+// WithReplayGuard rejects mutations that repeat a request ID seen in the last
+// window, for accounts whose settings enable replayProtection. It needs the
+// settings store.
+func WithReplayGuard(guard repository.ReplayGuard, window time.Duration) Option {
+	return func(h *AppSyncHandler) {
+		h.replayGuard = guard
+		h.replayWindow = window
+	}
+}
+
+// recordRequest records the request ID of a mutation on an account with
+// replay protection, returning the ID, or an empty string when the mutation
+// is not protected.
+func (h *AppSyncHandler) recordRequest(ctx context.Context, event AppSyncEvent) (accountID, requestID string, err error) {
+	if h.replayGuard == nil || h.settings == nil || readFields[event.Field] || isInternalCaller(event) {
+		return "", "", nil
+	}
+
+	accountID, err = mutatedAccountID(event.Arguments)
+	if err != nil || accountID == "" {
+		return "", "", err
+	}
+	settings, err := h.settings.GetAccountSettings(ctx, accountID)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get account settings: %w", err)
+	}
+	if !settings.ReplayProtection {
+		return "", "", nil
+	}
+
+	requestID = event.Request.Headers[nonceHeader]
+	if requestID == "" {
+		requestID = event.Request.Headers[requestIDHeader]
+	}
+	if requestID == "" {
+		return "", "", fmt.Errorf("account %s requires an %s or %s header on mutations", accountID, nonceHeader, requestIDHeader)
+	}
+
+	window := h.replayWindow
+	if window <= 0 {
+		window = DefaultReplayWindow
+	}
+	if err := h.replayGuard.RecordRequest(ctx, accountID, requestID, window); err != nil {
+		return "", "", err
+	}
+	return accountID, requestID, nil
+}
+
+// releaseRequest forgets the request ID of a mutation that failed, so the
+// client can retry it.
+func (h *AppSyncHandler) releaseRequest(ctx context.Context, accountID, requestID string) {
+	if err := h.replayGuard.ReleaseRequest(ctx, accountID, requestID); err != nil {
+		log.Printf("WARN: Failed to release request %s of account %s: %v", requestID, accountID, err)
+	}
+}
@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockReplayGuard is a mock implementation of repository.ReplayGuard.
+type mockReplayGuard struct {
+	mock.Mock
+}
+
+func (m *mockReplayGuard) RecordRequest(ctx context.Context, accountID, requestID string, window time.Duration) error {
+	args := m.Called(ctx, accountID, requestID, window)
+	return args.Error(0)
+}
+
+func (m *mockReplayGuard) ReleaseRequest(ctx context.Context, accountID, requestID string) error {
+	args := m.Called(ctx, accountID, requestID)
+	return args.Error(0)
+}
+
+func TestAppSyncHandlerReplayProtection(t *testing.T) {
+	ctx := context.Background()
+	identity := AppSyncIdentity{Username: "user-1"}
+	arguments := json.RawMessage(`{"accountId": "acc-12345", "locationId": "loc-001"}`)
+	protected := models.DefaultAccountSettings("acc-12345")
+	protected.ReplayProtection = true
+	deleteEvent := func(headers map[string]string) AppSyncEvent {
+		return AppSyncEvent{Field: "deleteLocation", Arguments: arguments, Identity: identity, Request: AppSyncRequest{Headers: headers}}
+	}
+	setup := func(settings models.AccountSettings) (*AppSyncHandler, *mockRepository, *mockReplayGuard) {
+		mockRepo := new(mockRepository)
+		settingsStore := new(mockSettingsStore)
+		guard := new(mockReplayGuard)
+		settingsStore.On("GetAccountSettings", ctx, "acc-12345").Return(&settings, nil)
+		return NewAppSyncHandler(mockRepo, WithSettingsStore(settingsStore), WithReplayGuard(guard, time.Minute)), mockRepo, guard
+	}
+
+	t.Run("Records the nonce of a protected account's mutation", func(t *testing.T) {
+		handler, mockRepo, guard := setup(protected)
+		guard.On("RecordRequest", ctx, "acc-12345", "nonce-1", time.Minute).Return(nil).Once()
+		mockRepo.On("Delete", ctx, "acc-12345", "loc-001").Return(nil).Once()
+
+		_, err := handler.Handle(ctx, deleteEvent(map[string]string{"x-nonce": "nonce-1", "x-request-id": "req-1"}))
+		require.NoError(t, err)
+		guard.AssertExpectations(t)
+		guard.AssertNotCalled(t, "ReleaseRequest", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Falls back to the request ID", func(t *testing.T) {
+		handler, mockRepo, guard := setup(protected)
+		guard.On("RecordRequest", ctx, "acc-12345", "req-1", time.Minute).Return(nil).Once()
+		mockRepo.On("Delete", ctx, "acc-12345", "loc-001").Return(nil).Once()
+
+		_, err := handler.Handle(ctx, deleteEvent(map[string]string{"x-request-id": "req-1"}))
+		require.NoError(t, err)
+		guard.AssertExpectations(t)
+	})
+
+	t.Run("Rejects a replay", func(t *testing.T) {
+		handler, mockRepo, guard := setup(protected)
+		guard.On("RecordRequest", ctx, "acc-12345", "nonce-1", time.Minute).Return(&repository.ReplayError{RequestID: "nonce-1"}).Once()
+
+		_, err := handler.Handle(ctx, deleteEvent(map[string]string{"x-nonce": "nonce-1"}))
+		assert.EqualError(t, err, "request nonce-1 was already received; send each mutation with a new request ID")
+		mockRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+		guard.AssertNotCalled(t, "ReleaseRequest", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Requires a request ID", func(t *testing.T) {
+		handler, mockRepo, _ := setup(protected)
+
+		_, err := handler.Handle(ctx, deleteEvent(nil))
+		assert.EqualError(t, err, "account acc-12345 requires an x-nonce or x-request-id header on mutations")
+		mockRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Releases the request ID of a failed mutation", func(t *testing.T) {
+		handler, mockRepo, guard := setup(protected)
+		guard.On("RecordRequest", ctx, "acc-12345", "nonce-1", time.Minute).Return(nil).Once()
+		guard.On("ReleaseRequest", ctx, "acc-12345", "nonce-1").Return(nil).Once()
+		mockRepo.On("Delete", ctx, "acc-12345", "loc-001").Return(errors.New("throttled")).Once()
+
+		_, err := handler.Handle(ctx, deleteEvent(map[string]string{"x-nonce": "nonce-1"}))
+		assert.ErrorContains(t, err, "failed to delete location")
+		guard.AssertExpectations(t)
+	})
+
+	t.Run("Accounts without replay protection are not recorded", func(t *testing.T) {
+		handler, mockRepo, guard := setup(models.DefaultAccountSettings("acc-12345"))
+		mockRepo.On("Delete", ctx, "acc-12345", "loc-001").Return(nil).Once()
+
+		_, err := handler.Handle(ctx, deleteEvent(nil))
+		require.NoError(t, err)
+		guard.AssertNotCalled(t, "RecordRequest", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
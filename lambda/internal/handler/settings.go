@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/repository"
+)
+
+// GetAccountSettingsArguments represents arguments for reading an account's settings.
+type GetAccountSettingsArguments struct {
+	AccountID string `json:"accountId"`
+}
+
+// AccountSettingsInput holds the settings to change. Omitted fields keep their
+// current value; an empty string clears defaultCountry, webhookUrl, or
+// parentAccountId.
+type AccountSettingsInput struct {
+	DefaultCountry       *string                      `json:"defaultCountry,omitempty"`
+	DefaultUnits         *models.Units                `json:"defaultUnits,omitempty"`
+	ValidationStrictness *models.ValidationStrictness `json:"validationStrictness,omitempty"`
+	WebhookURL           *string                      `json:"webhookUrl,omitempty"`
+	Quotas               *models.AccountQuotas        `json:"quotas,omitempty"`
+	CategoryTaxonomy     *models.CategoryTaxonomy     `json:"categoryTaxonomy,omitempty"`
+	Categories           *[]string                    `json:"categories,omitempty"`
+	PublicStoreLocator   *bool                        `json:"publicStoreLocator,omitempty"`
+	ReplayProtection     *bool                        `json:"replayProtection,omitempty"`
+	// PositionChecks replaces the account's position checks; an empty object turns them off
+	PositionChecks *models.PositionChecks `json:"positionChecks,omitempty"`
+	// FieldVisibility replaces all of the account's visibility overrides
+	FieldVisibility *map[string]models.Visibility `json:"fieldVisibility,omitempty"`
+	ParentAccountID *string                       `json:"parentAccountId,omitempty"`
+}
+
+// UpdateAccountSettingsArguments represents arguments for updating an account's settings.
+type UpdateAccountSettingsArguments struct {
+	AccountID string               `json:"accountId"`
+	Input     AccountSettingsInput `json:"input"`
+}
+
+// WithSettingsStore enables the account settings operations.
+func WithSettingsStore(store repository.SettingsStore) Option {
+	return func(h *AppSyncHandler) {
+		h.settings = store
+	}
+}
+
+// settingsStore returns the configured settings store or an error when settings are disabled.
+func (h *AppSyncHandler) settingsStore() (repository.SettingsStore, error) {
+	if h.settings == nil {
+		return nil, fmt.Errorf("account settings are not configured")
+	}
+	return h.settings, nil
+}
+
+func (h *AppSyncHandler) handleGetAccountSettings(ctx context.Context, arguments json.RawMessage) (*models.AccountSettings, error) {
+	var args GetAccountSettingsArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+
+	store, err := h.settingsStore()
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := store.GetAccountSettings(ctx, args.AccountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account settings: %w", err)
+	}
+
+	return settings, nil
+}
+
+func (h *AppSyncHandler) handleUpdateAccountSettings(ctx context.Context, arguments json.RawMessage) (*models.AccountSettings, error) {
+	var args UpdateAccountSettingsArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+
+	store, err := h.settingsStore()
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := store.GetAccountSettings(ctx, args.AccountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account settings: %w", err)
+	}
+
+	input := args.Input
+	if input.DefaultCountry != nil {
+		settings.DefaultCountry = *input.DefaultCountry
+	}
+	if input.DefaultUnits != nil {
+		settings.DefaultUnits = *input.DefaultUnits
+	}
+	if input.ValidationStrictness != nil {
+		settings.ValidationStrictness = *input.ValidationStrictness
+	}
+	if input.WebhookURL != nil {
+		settings.WebhookURL = *input.WebhookURL
+	}
+	if input.Quotas != nil {
+		settings.Quotas = *input.Quotas
+	}
+	if input.CategoryTaxonomy != nil {
+		settings.CategoryTaxonomy = *input.CategoryTaxonomy
+	}
+	if input.Categories != nil {
+		settings.Categories = *input.Categories
+	}
+	if input.PublicStoreLocator != nil {
+		settings.PublicStoreLocator = *input.PublicStoreLocator
+	}
+	if input.ReplayProtection != nil {
+		settings.ReplayProtection = *input.ReplayProtection
+	}
+	if input.PositionChecks != nil {
+		settings.PositionChecks = input.PositionChecks
+		if *input.PositionChecks == (models.PositionChecks{}) {
+			settings.PositionChecks = nil
+		}
+	}
+	if input.FieldVisibility != nil {
+		settings.FieldVisibility = *input.FieldVisibility
+	}
+	if input.ParentAccountID != nil && *input.ParentAccountID != settings.ParentAccountID {
+		if err := h.checkParentAccount(ctx, store, args.AccountID, *input.ParentAccountID); err != nil {
+			return nil, err
+		}
+		settings.ParentAccountID = *input.ParentAccountID
+	}
+
+	updated, err := store.PutAccountSettings(ctx, *settings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update account settings: %w", err)
+	}
+
+	return updated, nil
+}
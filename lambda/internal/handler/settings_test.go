@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockSettingsStore is a mock implementation of the repository.SettingsStore interface.
+type mockSettingsStore struct {
+	mock.Mock
+}
+
+func (m *mockSettingsStore) GetAccountSettings(ctx context.Context, accountID string) (*models.AccountSettings, error) {
+	args := m.Called(ctx, accountID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.AccountSettings), args.Error(1)
+}
+
+func (m *mockSettingsStore) PutAccountSettings(ctx context.Context, settings models.AccountSettings) (*models.AccountSettings, error) {
+	args := m.Called(ctx, settings)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.AccountSettings), args.Error(1)
+}
+
+func TestAppSyncHandlerAccountSettings(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Get settings", func(t *testing.T) {
+		store := new(mockSettingsStore)
+		handler := NewAppSyncHandler(new(mockRepository), WithSettingsStore(store))
+
+		settings := models.DefaultAccountSettings("acc-12345")
+		store.On("GetAccountSettings", ctx, "acc-12345").Return(&settings, nil).Once()
+
+		result, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "getAccountSettings",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345"}`),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, &settings, result)
+	})
+
+	t.Run("Update changes only supplied fields", func(t *testing.T) {
+		store := new(mockSettingsStore)
+		handler := NewAppSyncHandler(new(mockRepository), WithSettingsStore(store))
+
+		current := models.DefaultAccountSettings("acc-12345")
+		current.DefaultCountry = "US"
+		current.WebhookURL = "https://hooks.example.com/old"
+		store.On("GetAccountSettings", ctx, "acc-12345").Return(&current, nil).Once()
+
+		expected := current
+		expected.DefaultUnits = models.UnitsImperial
+		expected.WebhookURL = ""
+		store.On("PutAccountSettings", ctx, expected).Return(&expected, nil).Once()
+
+		result, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "updateAccountSettings",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "input": {"defaultUnits": "imperial", "webhookUrl": ""}}`),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, &expected, result)
+		store.AssertExpectations(t)
+	})
+
+	t.Run("Not configured", func(t *testing.T) {
+		handler := NewAppSyncHandler(new(mockRepository))
+
+		_, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "updateAccountSettings",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "input": {}}`),
+		})
+		assert.EqualError(t, err, "account settings are not configured")
+	})
+}
@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/steverhoton/location-lambda/internal/repository"
+	"github.com/steverhoton/location-lambda/internal/signing"
+)
+
+// DefaultSignatureMaxAge is how far a signature's timestamp may be from now
+// when no other limit is configured.
+const DefaultSignatureMaxAge = 5 * time.Minute
+
+// SigningSecretArguments represents arguments for rotating or removing an
+// account's signing secret.
+type SigningSecretArguments struct {
+	AccountID string `json:"accountId"`
+}
+
+// WithRequestSigning makes accounts with a signing secret reject mutations
+// that are unsigned, wrongly signed, or signed more than maxAge from now.
+func WithRequestSigning(store repository.SigningSecretStore, maxAge time.Duration) Option {
+	return func(h *AppSyncHandler) {
+		h.signingSecrets = store
+		h.signatureMaxAge = maxAge
+	}
+}
+
+// signingSecretStore returns the configured signing secret store or an error
+// when request signing is disabled.
+func (h *AppSyncHandler) signingSecretStore() (repository.SigningSecretStore, error) {
+	if h.signingSecrets == nil {
+		return nil, fmt.Errorf("request signing is not configured")
+	}
+	return h.signingSecrets, nil
+}
+
+// verifySignature checks the signature of a mutation on an account that has a
+// signing secret. Reads, and mutations that name no account, are not signed.
+func (h *AppSyncHandler) verifySignature(ctx context.Context, event AppSyncEvent) error {
+	if h.signingSecrets == nil || readFields[event.Field] || isInternalCaller(event) {
+		return nil
+	}
+
+	accountID, err := mutatedAccountID(event.Arguments)
+	if err != nil || accountID == "" {
+		return err
+	}
+	secret, err := h.signingSecrets.GetSigningSecret(ctx, accountID)
+	if err != nil {
+		return fmt.Errorf("failed to get signing secret: %w", err)
+	}
+	if secret == nil {
+		return nil
+	}
+
+	headers := event.Request.Headers
+	maxAge := h.signatureMaxAge
+	if maxAge <= 0 {
+		maxAge = DefaultSignatureMaxAge
+	}
+	if err := signing.Verify(secret.Secret, headers[signing.SignatureHeader], headers[signing.TimestampHeader], event.Field, event.Arguments, h.clock.Now(), maxAge); err != nil {
+		return fmt.Errorf("account %s requires signed mutations: %w", accountID, err)
+	}
+	return nil
+}
+
+// mutatedAccountID returns the account a mutation names, either directly or
+// in its input.
+func mutatedAccountID(arguments json.RawMessage) (string, error) {
+	var args struct {
+		AccountID string `json:"accountId"`
+		Input     struct {
+			AccountID string `json:"accountId"`
+		} `json:"input"`
+	}
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return "", fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+	if args.AccountID != "" {
+		return args.AccountID, nil
+	}
+	return args.Input.AccountID, nil
+}
+
+// handleRotateSigningSecret replaces an account's signing secret with a new
+// random one, which is returned only this once. The first rotation opts the
+// account into signing; later ones must be signed with the current secret.
+func (h *AppSyncHandler) handleRotateSigningSecret(ctx context.Context, arguments json.RawMessage) (*repository.SigningSecret, error) {
+	var args SigningSecretArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+
+	store, err := h.signingSecretStore()
+	if err != nil {
+		return nil, err
+	}
+	if args.AccountID == "" {
+		return nil, fmt.Errorf("validation failed: accountId is required")
+	}
+
+	value, err := signing.NewSecret()
+	if err != nil {
+		return nil, err
+	}
+	secret := repository.SigningSecret{AccountID: args.AccountID, Secret: value, CreatedAt: h.clock.Now().UTC()}
+	if err := store.PutSigningSecret(ctx, secret); err != nil {
+		return nil, fmt.Errorf("failed to put signing secret: %w", err)
+	}
+	return &secret, nil
+}
+
+// handleRemoveSigningSecret stops requiring signed mutations for an account.
+func (h *AppSyncHandler) handleRemoveSigningSecret(ctx context.Context, arguments json.RawMessage) (bool, error) {
+	var args SigningSecretArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return false, fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+
+	store, err := h.signingSecretStore()
+	if err != nil {
+		return false, err
+	}
+	if err := store.DeleteSigningSecret(ctx, args.AccountID); err != nil {
+		return false, fmt.Errorf("failed to delete signing secret: %w", err)
+	}
+	return true, nil
+}
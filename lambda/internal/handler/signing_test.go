@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/repository"
+	"github.com/steverhoton/location-lambda/internal/repository/repositorytest"
+	"github.com/steverhoton/location-lambda/internal/signing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockSigningSecretStore is a mock implementation of repository.SigningSecretStore.
+type mockSigningSecretStore struct {
+	mock.Mock
+}
+
+func (m *mockSigningSecretStore) GetSigningSecret(ctx context.Context, accountID string) (*repository.SigningSecret, error) {
+	args := m.Called(ctx, accountID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.SigningSecret), args.Error(1)
+}
+
+func (m *mockSigningSecretStore) PutSigningSecret(ctx context.Context, secret repository.SigningSecret) error {
+	args := m.Called(ctx, secret)
+	return args.Error(0)
+}
+
+func (m *mockSigningSecretStore) DeleteSigningSecret(ctx context.Context, accountID string) error {
+	args := m.Called(ctx, accountID)
+	return args.Error(0)
+}
+
+func TestAppSyncHandlerRequestSigning(t *testing.T) {
+	ctx := context.Background()
+	identity := AppSyncIdentity{Username: "user-1"}
+	arguments := json.RawMessage(`{"accountId": "acc-12345", "locationId": "loc-001"}`)
+	secret := &repository.SigningSecret{AccountID: "acc-12345", Secret: "s3cret"}
+	signed := func(at time.Time) AppSyncRequest {
+		timestamp := strconv.FormatInt(at.Unix(), 10)
+		signature, err := signing.Sign("s3cret", timestamp, "deleteLocation", arguments)
+		require.NoError(t, err)
+		return AppSyncRequest{Headers: map[string]string{signing.SignatureHeader: signature, signing.TimestampHeader: timestamp}}
+	}
+
+	tests := []struct {
+		name          string
+		secret        *repository.SigningSecret
+		identity      *AppSyncIdentity
+		request       AppSyncRequest
+		expectedError string
+	}{
+		{name: "Signed mutation", secret: secret, request: signed(time.Now())},
+		{name: "Account without a secret", request: AppSyncRequest{}},
+		{
+			name:          "Unsigned mutation",
+			secret:        secret,
+			expectedError: "account acc-12345 requires signed mutations: mutation must be signed with the x-signature and x-signature-timestamp headers",
+		},
+		{
+			name:          "Unsigned mutation without an identity",
+			secret:        secret,
+			identity:      &AppSyncIdentity{},
+			expectedError: "account acc-12345 requires signed mutations: mutation must be signed with the x-signature and x-signature-timestamp headers",
+		},
+		{
+			name:          "Stale signature",
+			secret:        secret,
+			request:       signed(time.Now().Add(-time.Hour)),
+			expectedError: "account acc-12345 requires signed mutations: signature is stale: x-signature-timestamp must be within 5m0s of the current time",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(mockRepository)
+			store := new(mockSigningSecretStore)
+			handler := NewAppSyncHandler(mockRepo, WithRequestSigning(store, DefaultSignatureMaxAge))
+
+			store.On("GetSigningSecret", ctx, "acc-12345").Return(tt.secret, nil).Once()
+			if tt.expectedError == "" {
+				mockRepo.On("Delete", ctx, "acc-12345", "loc-001").Return(nil).Once()
+			}
+
+			event := AppSyncEvent{Field: "deleteLocation", Arguments: arguments, Identity: identity, Request: tt.request}
+			if tt.identity != nil {
+				event.Identity = *tt.identity
+			}
+			_, err := handler.Handle(ctx, event)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				mockRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+
+	t.Run("Reads are not signed", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		store := new(mockSigningSecretStore)
+		handler := NewAppSyncHandler(mockRepo, WithRequestSigning(store, DefaultSignatureMaxAge))
+		mockRepo.On("Get", mock.Anything, "acc-12345", "loc-001").Return(withID("loc-001", models.CoordinatesLocation{
+			LocationBase: models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeCoordinates},
+			Coordinates:  models.Coordinates{Latitude: 40.7128, Longitude: -74.006},
+		}), nil).Once()
+
+		_, err := handler.Handle(ctx, AppSyncEvent{Field: "getLocation", Arguments: arguments, Identity: identity})
+		require.NoError(t, err)
+		store.AssertNotCalled(t, "GetSigningSecret", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Rotate secret", func(t *testing.T) {
+		store := new(mockSigningSecretStore)
+		handler := NewAppSyncHandler(new(mockRepository), WithRequestSigning(store, DefaultSignatureMaxAge))
+		store.On("GetSigningSecret", ctx, "acc-12345").Return(nil, nil).Once()
+		store.On("PutSigningSecret", ctx, mock.MatchedBy(func(s repository.SigningSecret) bool {
+			return s.AccountID == "acc-12345" && len(s.Secret) == 64
+		})).Return(nil).Once()
+
+		result, err := handler.Handle(ctx, AppSyncEvent{Field: "rotateSigningSecret", Arguments: json.RawMessage(`{"accountId": "acc-12345"}`), Identity: identity})
+		require.NoError(t, err)
+		assert.Len(t, result.(*repository.SigningSecret).Secret, 64)
+		store.AssertExpectations(t)
+	})
+
+	t.Run("Signatures age on the handler's clock", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		store := new(mockSigningSecretStore)
+		clock := repositorytest.NewClock(time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC))
+		handler := NewAppSyncHandler(mockRepo, WithRequestSigning(store, DefaultSignatureMaxAge), WithClock(clock))
+		request := signed(clock.Now().Add(-4 * time.Minute))
+
+		store.On("GetSigningSecret", ctx, "acc-12345").Return(secret, nil).Twice()
+		mockRepo.On("Delete", ctx, "acc-12345", "loc-001").Return(nil).Once()
+
+		_, err := handler.Handle(ctx, AppSyncEvent{Field: "deleteLocation", Arguments: arguments, Identity: identity, Request: request})
+		require.NoError(t, err)
+
+		clock.Advance(2 * time.Minute)
+		_, err = handler.Handle(ctx, AppSyncEvent{Field: "deleteLocation", Arguments: arguments, Identity: identity, Request: request})
+		assert.ErrorContains(t, err, "signature is stale")
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Not configured", func(t *testing.T) {
+		handler := NewAppSyncHandler(new(mockRepository))
+		_, err := handler.Handle(ctx, AppSyncEvent{Field: "removeSigningSecret", Arguments: json.RawMessage(`{"accountId": "acc-12345"}`), Identity: identity})
+		assert.EqualError(t, err, "request signing is not configured")
+	})
+}
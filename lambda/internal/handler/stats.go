@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/steverhoton/location-lambda/internal/repository"
+)
+
+// LocationStatsArguments represents arguments for summarizing an account's locations.
+type LocationStatsArguments struct {
+	AccountID string `json:"accountId"`
+}
+
+// WithStatsReader enables locationStats.
+func WithStatsReader(reader repository.StatsReader) Option {
+	return func(h *AppSyncHandler) {
+		h.stats = reader
+	}
+}
+
+// handleLocationStats returns an account's location counts for dashboards.
+func (h *AppSyncHandler) handleLocationStats(ctx context.Context, arguments json.RawMessage) (*repository.LocationStats, error) {
+	var args LocationStatsArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+
+	if args.AccountID == "" {
+		return nil, fmt.Errorf("accountId is required")
+	}
+	if h.stats == nil {
+		return nil, fmt.Errorf("location stats are not configured")
+	}
+
+	stats, err := h.stats.LocationStats(ctx, args.AccountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get location stats: %w", err)
+	}
+	return stats, nil
+}
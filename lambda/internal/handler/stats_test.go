@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/steverhoton/location-lambda/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockStatsReader is a mock implementation of the repository.StatsReader interface.
+type mockStatsReader struct {
+	mock.Mock
+}
+
+func (m *mockStatsReader) LocationStats(ctx context.Context, accountID string) (*repository.LocationStats, error) {
+	args := m.Called(ctx, accountID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.LocationStats), args.Error(1)
+}
+
+func TestAppSyncHandlerLocationStats(t *testing.T) {
+	ctx := context.Background()
+	event := AppSyncEvent{
+		Field:     "locationStats",
+		Arguments: json.RawMessage(`{"accountId": "acc-12345"}`),
+	}
+
+	t.Run("Returns the account's stats", func(t *testing.T) {
+		reader := new(mockStatsReader)
+		handler := NewAppSyncHandler(new(mockRepository), WithStatsReader(reader))
+
+		stats := &repository.LocationStats{
+			AccountID: "acc-12345",
+			Total:     2,
+			ByType:    []repository.StatCount{{Value: "shop", Count: 2}},
+			ByStatus:  []repository.StatCount{{Value: repository.StatusActive, Count: 2}},
+		}
+		reader.On("LocationStats", mock.Anything, "acc-12345").Return(stats, nil).Once()
+
+		result, err := handler.Handle(ctx, event)
+		require.NoError(t, err)
+		assert.Equal(t, stats, result)
+		reader.AssertExpectations(t)
+	})
+
+	t.Run("Requires an account", func(t *testing.T) {
+		handler := NewAppSyncHandler(new(mockRepository), WithStatsReader(new(mockStatsReader)))
+
+		_, err := handler.Handle(ctx, AppSyncEvent{Field: "locationStats", Arguments: json.RawMessage(`{}`)})
+		assert.EqualError(t, err, "accountId is required")
+	})
+
+	t.Run("Repository error", func(t *testing.T) {
+		reader := new(mockStatsReader)
+		handler := NewAppSyncHandler(new(mockRepository), WithStatsReader(reader))
+
+		reader.On("LocationStats", mock.Anything, "acc-12345").Return(nil, errors.New("throttled")).Once()
+
+		_, err := handler.Handle(ctx, event)
+		assert.EqualError(t, err, "failed to get location stats: throttled")
+	})
+
+	t.Run("Not configured", func(t *testing.T) {
+		_, err := NewAppSyncHandler(new(mockRepository)).Handle(ctx, event)
+		assert.EqualError(t, err, "location stats are not configured")
+	})
+}
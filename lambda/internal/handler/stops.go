@@ -0,0 +1,192 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/repository"
+	"github.com/steverhoton/location-lambda/internal/stops"
+)
+
+// DefaultStopLookback is how far back detectStops reads positions by default.
+const DefaultStopLookback = 48 * time.Hour
+
+// DetectStopsArguments represents arguments for the scheduled stop detection.
+type DetectStopsArguments struct {
+	AccountIDs []string `json:"accountIds"`
+	// Lookback is the Go duration of position history read, e.g. "48h"; it
+	// should exceed the schedule's interval plus the longest expected stop
+	Lookback     string  `json:"lookback,omitempty"`
+	RadiusMeters float64 `json:"radiusMeters,omitempty"`
+	// MinDwell is the Go duration a location must stay put for a stop, e.g. "5m"
+	MinDwell string `json:"minDwell,omitempty"`
+}
+
+// StopDetectionReport is the result of detecting one account's stops.
+type StopDetectionReport struct {
+	AccountID string `json:"accountId"`
+	Pings     int    `json:"pings"`
+	Locations int    `json:"locations"`
+	Stops     int    `json:"stops"`
+}
+
+// ListDetectedStopsArguments represents arguments for listing detected stops.
+type ListDetectedStopsArguments struct {
+	AccountID  string  `json:"accountId"`
+	LocationID string  `json:"locationId,omitempty"`
+	Limit      *int32  `json:"limit,omitempty"`
+	Cursor     *string `json:"cursor,omitempty"`
+}
+
+// WithPositionHistory records each position update of a coordinates location
+// for retention, enabling detectStops and listDetectedStops.
+func WithPositionHistory(history repository.PositionHistory, retention time.Duration) Option {
+	return func(h *AppSyncHandler) {
+		h.positions = history
+		h.positionRetention = retention
+	}
+}
+
+// positionHistory returns the configured position history or an error when it is disabled.
+func (h *AppSyncHandler) positionHistory() (repository.PositionHistory, error) {
+	if h.positions == nil {
+		return nil, fmt.Errorf("position history is not configured")
+	}
+	return h.positions, nil
+}
+
+// recordPosition adds an updated coordinates location's position to its
+// history, unless the position was flagged as anomalous. The location is
+// already saved, so a failure only loses the ping and is logged.
+func (h *AppSyncHandler) recordPosition(ctx context.Context, location models.Location, locationID string) {
+	moved, ok := location.(models.CoordinatesLocation)
+	if !ok || moved.PositionAnomaly != nil || h.positions == nil {
+		return
+	}
+
+	at := h.clock.Now().UTC()
+	if moved.Coordinates.ObservedAt != nil {
+		at = *moved.Coordinates.ObservedAt
+	}
+	ping := models.PositionPing{LocationID: locationID, Coordinates: moved.Coordinates, At: at}
+	if err := h.positions.RecordPosition(ctx, moved.AccountID, ping, h.positionRetention); err != nil {
+		log.Printf("WARN: Failed to record position of location %s: %v", locationID, err)
+	}
+}
+
+// handleDetectStops segments the recent position history of each account's
+// locations into trips and stops, storing the stops. Like the geocode
+// refresh it runs on a schedule and is not in the GraphQL schema. Stops are
+// keyed by location and arrival, so overlapping runs rewrite rather than
+// duplicate them.
+func (h *AppSyncHandler) handleDetectStops(ctx context.Context, arguments json.RawMessage) ([]StopDetectionReport, error) {
+	var args DetectStopsArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+
+	if len(args.AccountIDs) == 0 {
+		return nil, fmt.Errorf("accountIds is required")
+	}
+	lookback := DefaultStopLookback
+	if args.Lookback != "" {
+		var err error
+		if lookback, err = time.ParseDuration(args.Lookback); err != nil || lookback <= 0 {
+			return nil, fmt.Errorf("invalid lookback %q: must be a positive duration", args.Lookback)
+		}
+	}
+	options := stops.Options{RadiusMeters: args.RadiusMeters}
+	if args.RadiusMeters < 0 {
+		return nil, fmt.Errorf("radiusMeters must not be negative")
+	}
+	if args.MinDwell != "" {
+		var err error
+		if options.MinDwell, err = time.ParseDuration(args.MinDwell); err != nil || options.MinDwell <= 0 {
+			return nil, fmt.Errorf("invalid minDwell %q: must be a positive duration", args.MinDwell)
+		}
+	}
+	history, err := h.positionHistory()
+	if err != nil {
+		return nil, err
+	}
+
+	now := h.clock.Now().UTC()
+	reports := make([]StopDetectionReport, 0, len(args.AccountIDs))
+	var failures []error
+	for _, accountID := range args.AccountIDs {
+		report, err := detectAccountStops(ctx, history, accountID, now.Add(-lookback), options, now)
+		if err != nil {
+			failures = append(failures, fmt.Errorf("failed to detect stops of account %s: %w", accountID, err))
+			continue
+		}
+		reports = append(reports, report)
+	}
+	if len(failures) > 0 {
+		return nil, errors.Join(failures...)
+	}
+	return reports, nil
+}
+
+// detectAccountStops detects and stores the stops in an account's pings since a time.
+func detectAccountStops(ctx context.Context, history repository.PositionHistory, accountID string, since time.Time, options stops.Options, now time.Time) (StopDetectionReport, error) {
+	report := StopDetectionReport{AccountID: accountID}
+	pings, err := history.ListPositions(ctx, accountID, since)
+	if err != nil {
+		return report, err
+	}
+	report.Pings = len(pings)
+
+	// Pings come in time order, which grouping by location keeps
+	byLocation := map[string][]models.PositionPing{}
+	for _, ping := range pings {
+		byLocation[ping.LocationID] = append(byLocation[ping.LocationID], ping)
+	}
+	locationIDs := make([]string, 0, len(byLocation))
+	for locationID := range byLocation {
+		locationIDs = append(locationIDs, locationID)
+	}
+	sort.Strings(locationIDs)
+	report.Locations = len(locationIDs)
+
+	for _, locationID := range locationIDs {
+		for _, stop := range stops.Detect(accountID, byLocation[locationID], options, now) {
+			if err := history.PutDetectedStop(ctx, stop); err != nil {
+				return report, err
+			}
+			report.Stops++
+		}
+	}
+	return report, nil
+}
+
+func (h *AppSyncHandler) handleListDetectedStops(ctx context.Context, arguments json.RawMessage) (*repository.DetectedStopListResult, error) {
+	var args ListDetectedStopsArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+
+	history, err := h.positionHistory()
+	if err != nil {
+		return nil, err
+	}
+	limit, err := h.listLimits.Resolve(args.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := history.ListDetectedStops(ctx, args.AccountID, args.LocationID, &repository.ListOptions{
+		Limit:  &limit,
+		Cursor: args.Cursor,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list detected stops: %w", err)
+	}
+
+	return result, nil
+}
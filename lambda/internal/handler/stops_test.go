@@ -0,0 +1,185 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockPositionHistory is a mock implementation of the repository.PositionHistory interface.
+type mockPositionHistory struct {
+	mock.Mock
+}
+
+func (m *mockPositionHistory) RecordPosition(ctx context.Context, accountID string, ping models.PositionPing, retention time.Duration) error {
+	args := m.Called(ctx, accountID, ping, retention)
+	return args.Error(0)
+}
+
+func (m *mockPositionHistory) ListPositions(ctx context.Context, accountID string, since time.Time) ([]models.PositionPing, error) {
+	args := m.Called(ctx, accountID, since)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.PositionPing), args.Error(1)
+}
+
+func (m *mockPositionHistory) PutDetectedStop(ctx context.Context, stop models.DetectedStop) error {
+	args := m.Called(ctx, stop)
+	return args.Error(0)
+}
+
+func (m *mockPositionHistory) ListDetectedStops(ctx context.Context, accountID, locationID string, options *repository.ListOptions) (*repository.DetectedStopListResult, error) {
+	args := m.Called(ctx, accountID, locationID, options)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.DetectedStopListResult), args.Error(1)
+}
+
+func TestAppSyncHandlerPositionHistory(t *testing.T) {
+	ctx := context.Background()
+	observedAt := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Records a position update", func(t *testing.T) {
+		repo, history := new(mockRepository), new(mockPositionHistory)
+		handler := NewAppSyncHandler(repo, WithPositionHistory(history, 72*time.Hour))
+
+		repo.On("Update", mock.Anything, mock.Anything, "loc-001").Return(nil).Once()
+		history.On("RecordPosition", mock.Anything, "acc-12345", models.PositionPing{
+			LocationID:  "loc-001",
+			Coordinates: models.Coordinates{Latitude: 39.7395, Longitude: -104.99, ObservedAt: &observedAt},
+			At:          observedAt,
+		}, 72*time.Hour).Return(nil).Once()
+
+		result, err := handler.Handle(ctx, AppSyncEvent{Field: "updateLocation", Arguments: json.RawMessage(`{
+			"accountId": "acc-12345",
+			"locationId": "loc-001",
+			"input": {
+				"accountId": "acc-12345",
+				"locationType": "coordinates",
+				"coordinates": {"latitude": 39.7395, "longitude": -104.99, "observedAt": "2024-06-01T12:00:00Z"}
+			}
+		}`)})
+		require.NoError(t, err)
+		assert.True(t, result.(*UpdateResponse).Success)
+		history.AssertExpectations(t)
+	})
+
+	t.Run("A failed recording does not fail the update", func(t *testing.T) {
+		repo, history := new(mockRepository), new(mockPositionHistory)
+		handler := NewAppSyncHandler(repo, WithPositionHistory(history, 72*time.Hour))
+
+		repo.On("Update", mock.Anything, mock.Anything, "loc-001").Return(nil).Once()
+		history.On("RecordPosition", mock.Anything, "acc-12345", mock.Anything, 72*time.Hour).Return(errors.New("throttled")).Once()
+
+		result, err := handler.Handle(ctx, AppSyncEvent{Field: "updateLocation", Arguments: json.RawMessage(`{
+			"accountId": "acc-12345",
+			"locationId": "loc-001",
+			"input": {"accountId": "acc-12345", "locationType": "coordinates", "coordinates": {"latitude": 39.7395, "longitude": -104.99}}
+		}`)})
+		require.NoError(t, err)
+		assert.True(t, result.(*UpdateResponse).Success)
+	})
+}
+
+func TestAppSyncHandlerDetectStops(t *testing.T) {
+	ctx := context.Background()
+	start := time.Now().UTC().Add(-6 * time.Hour)
+	ping := func(locationID string, latitude float64, minutes int) models.PositionPing {
+		return models.PositionPing{
+			LocationID:  locationID,
+			Coordinates: models.Coordinates{Latitude: latitude, Longitude: -104.99},
+			At:          start.Add(time.Duration(minutes) * time.Minute),
+		}
+	}
+
+	t.Run("Stores the stops of each location", func(t *testing.T) {
+		history := new(mockPositionHistory)
+		handler := NewAppSyncHandler(new(mockRepository), WithPositionHistory(history, 72*time.Hour))
+
+		history.On("ListPositions", mock.Anything, "acc-12345", mock.MatchedBy(func(since time.Time) bool {
+			return time.Since(since) > 47*time.Hour && time.Since(since) < 49*time.Hour
+		})).Return([]models.PositionPing{
+			ping("truck-2", 39.70, 0),
+			ping("truck-1", 39.74, 0),
+			ping("truck-1", 39.75, 2),
+			ping("truck-2", 39.70, 3),
+			ping("truck-1", 39.75, 30),
+			ping("truck-1", 39.76, 32),
+		}, nil).Once()
+		history.On("PutDetectedStop", mock.Anything, mock.MatchedBy(func(stop models.DetectedStop) bool {
+			return stop.AccountID == "acc-12345" && stop.LocationID == "truck-1" && stop.DwellSeconds == 1680
+		})).Return(nil).Once()
+
+		result, err := handler.Handle(ctx, AppSyncEvent{Field: "detectStops", Arguments: json.RawMessage(`{"accountIds": ["acc-12345"]}`)})
+		require.NoError(t, err)
+		assert.Equal(t, []StopDetectionReport{{AccountID: "acc-12345", Pings: 6, Locations: 2, Stops: 1}}, result)
+		history.AssertExpectations(t)
+	})
+
+	t.Run("Detects the other accounts when one fails", func(t *testing.T) {
+		history := new(mockPositionHistory)
+		handler := NewAppSyncHandler(new(mockRepository), WithPositionHistory(history, 72*time.Hour))
+
+		history.On("ListPositions", mock.Anything, "acc-1", mock.Anything).Return(nil, errors.New("throttled")).Once()
+		history.On("ListPositions", mock.Anything, "acc-2", mock.Anything).Return([]models.PositionPing{}, nil).Once()
+
+		_, err := handler.Handle(ctx, AppSyncEvent{Field: "detectStops", Arguments: json.RawMessage(`{"accountIds": ["acc-1", "acc-2"]}`)})
+		assert.EqualError(t, err, "failed to detect stops of account acc-1: throttled")
+		history.AssertExpectations(t)
+	})
+
+	tests := []struct {
+		name      string
+		arguments string
+		errMsg    string
+	}{
+		{name: "No accounts", arguments: `{}`, errMsg: "accountIds is required"},
+		{name: "Bad lookback", arguments: `{"accountIds": ["acc-1"], "lookback": "2d"}`, errMsg: `invalid lookback "2d": must be a positive duration`},
+		{name: "Negative radius", arguments: `{"accountIds": ["acc-1"], "radiusMeters": -5}`, errMsg: "radiusMeters must not be negative"},
+		{name: "Bad minDwell", arguments: `{"accountIds": ["acc-1"], "minDwell": "0s"}`, errMsg: `invalid minDwell "0s": must be a positive duration`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewAppSyncHandler(new(mockRepository), WithPositionHistory(new(mockPositionHistory), time.Hour))
+
+			_, err := handler.Handle(ctx, AppSyncEvent{Field: "detectStops", Arguments: json.RawMessage(tt.arguments)})
+			assert.EqualError(t, err, tt.errMsg)
+		})
+	}
+
+	t.Run("Not configured", func(t *testing.T) {
+		handler := NewAppSyncHandler(new(mockRepository))
+
+		_, err := handler.Handle(ctx, AppSyncEvent{Field: "detectStops", Arguments: json.RawMessage(`{"accountIds": ["acc-1"]}`)})
+		assert.EqualError(t, err, "position history is not configured")
+	})
+}
+
+func TestAppSyncHandlerListDetectedStops(t *testing.T) {
+	ctx := context.Background()
+	history := new(mockPositionHistory)
+	handler := NewAppSyncHandler(new(mockRepository), WithPositionHistory(history, time.Hour))
+	page := &repository.DetectedStopListResult{Stops: []models.DetectedStop{{AccountID: "acc-12345", LocationID: "loc-001"}}}
+
+	history.On("ListDetectedStops", mock.Anything, "acc-12345", "loc-001", mock.MatchedBy(func(options *repository.ListOptions) bool {
+		return *options.Limit == 10
+	})).Return(page, nil).Once()
+
+	result, err := handler.Handle(ctx, AppSyncEvent{
+		Field:     "listDetectedStops",
+		Arguments: json.RawMessage(`{"accountId": "acc-12345", "locationId": "loc-001", "limit": 10}`),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, page, result)
+	history.AssertExpectations(t)
+}
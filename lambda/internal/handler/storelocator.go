@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/repository"
+)
+
+// PublicNearbyShopsArguments represents arguments for the public store locator query.
+type PublicNearbyShopsArguments struct {
+	AccountID string  `json:"accountId"`
+	Lat       float64 `json:"lat"`
+	Lon       float64 `json:"lon"`
+	Radius    float64 `json:"radius"` // Meters
+	// OpenAt limits the shops to those open at this instant (RFC 3339)
+	OpenAt *time.Time `json:"openAt,omitempty"`
+	// MaxAccuracyMeters leaves out shops pinned less accurately
+	MaxAccuracyMeters *float64 `json:"maxAccuracyMeters,omitempty"`
+}
+
+// PublicShopArguments represents arguments for reading one shop's public fields.
+type PublicShopArguments struct {
+	AccountID  string `json:"accountId"`
+	LocationID string `json:"locationId"`
+}
+
+// WithNearbyShopFinder enables publicNearbyShops.
+func WithNearbyShopFinder(finder repository.NearbyShopFinder) Option {
+	return func(h *AppSyncHandler) {
+		h.nearby = finder
+	}
+}
+
+// publicSettings returns the settings of an account that enabled its public
+// store locator. Anyone holding the API key can name any account, so the
+// other accounts are refused.
+func (h *AppSyncHandler) publicSettings(ctx context.Context, accountID string) (*models.AccountSettings, error) {
+	if accountID == "" {
+		return nil, fmt.Errorf("accountId is required")
+	}
+	// Without a settings store no account can have enabled the locator
+	if h.settings == nil {
+		return nil, fmt.Errorf("the store locator is not enabled for this account")
+	}
+	settings, err := h.settings.GetAccountSettings(ctx, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account settings: %w", err)
+	}
+	if !settings.PublicStoreLocator {
+		return nil, fmt.Errorf("the store locator is not enabled for this account")
+	}
+	return settings, nil
+}
+
+// publicShopView returns the shop fields the account made public, keyed by
+// their JSON names, along with the shop's locationId.
+func publicShopView(settings models.AccountSettings, locationID string, shop models.Shop) (map[string]interface{}, error) {
+	shopBytes, err := json.Marshal(shop)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal shop: %w", err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(shopBytes, &fields); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal shop to map: %w", err)
+	}
+
+	view := map[string]interface{}{"locationId": locationID}
+	for field, value := range fields {
+		if settings.ShopFieldVisibility(field) == models.VisibilityPublic {
+			view[field] = value
+		}
+	}
+	return view, nil
+}
+
+// handlePublicNearbyShops returns an account's shops near a point for store
+// locators embedded on customer websites, each with its public fields and
+// distanceMeters.
+func (h *AppSyncHandler) handlePublicNearbyShops(ctx context.Context, arguments json.RawMessage) ([]map[string]interface{}, error) {
+	var args PublicNearbyShopsArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+
+	if args.AccountID == "" {
+		return nil, fmt.Errorf("accountId is required")
+	}
+	if h.nearby == nil {
+		return nil, fmt.Errorf("nearby shop search is not configured")
+	}
+	settings, err := h.publicSettings(ctx, args.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	center := models.Coordinates{Latitude: args.Lat, Longitude: args.Lon}
+	nearby, err := h.nearby.FindShopsNear(ctx, args.AccountID, center, args.Radius, args.OpenAt, args.MaxAccuracyMeters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find shops: %w", err)
+	}
+
+	shops := make([]map[string]interface{}, 0, len(nearby))
+	for _, found := range nearby {
+		location, ok := found.Location.(models.ShopLocation)
+		if !ok {
+			return nil, fmt.Errorf("location %s is not a shop", found.LocationID)
+		}
+		view, err := publicShopView(*settings, found.LocationID, location.Shop)
+		if err != nil {
+			return nil, err
+		}
+		view["distanceMeters"] = found.DistanceMeters
+		shops = append(shops, view)
+	}
+	return shops, nil
+}
+
+// handlePublicShop returns one shop's public fields. Locations that are not
+// shops, unpublished drafts, and shops outside their active window are
+// reported as missing, like shops of other accounts.
+func (h *AppSyncHandler) handlePublicShop(ctx context.Context, arguments json.RawMessage) (map[string]interface{}, error) {
+	var args PublicShopArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+
+	settings, err := h.publicSettings(ctx, args.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	if args.LocationID == "" {
+		return nil, fmt.Errorf("locationId is required")
+	}
+
+	envelope, err := h.repo.Get(ctx, args.AccountID, args.LocationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get location: %w", err)
+	}
+	location, ok := envelope.Location.(models.ShopLocation)
+	if !ok || location.Draft || !location.IsActiveAt(h.clock.Now()) {
+		return nil, fmt.Errorf("failed to get location: location not found or access denied")
+	}
+	return publicShopView(*settings, envelope.LocationID, location.Shop)
+}
@@ -0,0 +1,235 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockNearbyShopFinder is a mock implementation of the repository.NearbyShopFinder interface.
+type mockNearbyShopFinder struct {
+	mock.Mock
+}
+
+func (m *mockNearbyShopFinder) FindShopsNear(ctx context.Context, accountID string, center models.Coordinates, radiusMeters float64, openAt *time.Time, maxAccuracyMeters *float64) ([]repository.NearbyShop, error) {
+	args := m.Called(ctx, accountID, center, radiusMeters, openAt, maxAccuracyMeters)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repository.NearbyShop), args.Error(1)
+}
+
+func TestAppSyncHandlerPublicNearbyShops(t *testing.T) {
+	ctx := context.Background()
+	center := models.Coordinates{Latitude: 39.7817, Longitude: -89.6501}
+	shop := models.ShopLocation{
+		LocationBase: models.LocationBase{
+			AccountID:          "acc-12345",
+			LocationType:       models.LocationTypeShop,
+			ExternalID:         "erp-42",
+			ExtendedAttributes: map[string]interface{}{"internalNote": "back door code 1234"},
+		},
+		Shop: models.Shop{
+			Name:        "Main Street Store",
+			ContactID:   "contact-1",
+			Address:     models.Address{StreetAddress: "123 Main St", City: "Springfield", PostalCode: "62704", Country: "US"},
+			Coordinates: &models.Coordinates{Latitude: 39.7917, Longitude: -89.6501},
+			Phone:       "+12175550100",
+			Contacts:    []models.ShopContact{{ContactID: "contact-2", Role: models.ShopContactRoleManager}},
+			BrandColor:  "#1A2B3C",
+		},
+	}
+	event := AppSyncEvent{
+		Field:     "publicNearbyShops",
+		Arguments: json.RawMessage(`{"accountId": "acc-12345", "lat": 39.7817, "lon": -89.6501, "radius": 5000}`),
+	}
+	enabled := func() *models.AccountSettings {
+		settings := models.DefaultAccountSettings("acc-12345")
+		settings.PublicStoreLocator = true
+		return &settings
+	}
+
+	t.Run("Returns only public shop fields", func(t *testing.T) {
+		store := new(mockSettingsStore)
+		finder := new(mockNearbyShopFinder)
+		handler := NewAppSyncHandler(new(mockRepository), WithSettingsStore(store), WithNearbyShopFinder(finder))
+
+		store.On("GetAccountSettings", ctx, "acc-12345").Return(enabled(), nil).Once()
+		finder.On("FindShopsNear", ctx, "acc-12345", center, 5000.0, (*time.Time)(nil), (*float64)(nil)).Return([]repository.NearbyShop{
+			{LocationEnvelope: repository.LocationEnvelope{LocationID: "loc-001", Location: shop}, DistanceMeters: 1112},
+		}, nil).Once()
+
+		result, err := handler.Handle(ctx, event)
+		require.NoError(t, err)
+		shops := result.([]map[string]interface{})
+		require.Len(t, shops, 1)
+		assert.Equal(t, "loc-001", shops[0]["locationId"])
+		assert.Equal(t, "Main Street Store", shops[0]["name"])
+		assert.Equal(t, "+12175550100", shops[0]["phone"])
+		assert.Equal(t, 1112.0, shops[0]["distanceMeters"])
+
+		body, err := json.Marshal(shops[0])
+		require.NoError(t, err)
+		for _, private := range []string{"contactId", "contacts", "extendedAttributes", "externalId", "back door"} {
+			assert.NotContains(t, string(body), private)
+		}
+		finder.AssertExpectations(t)
+	})
+
+	t.Run("Accounts must enable the locator", func(t *testing.T) {
+		store := new(mockSettingsStore)
+		finder := new(mockNearbyShopFinder)
+		handler := NewAppSyncHandler(new(mockRepository), WithSettingsStore(store), WithNearbyShopFinder(finder))
+
+		settings := models.DefaultAccountSettings("acc-12345")
+		store.On("GetAccountSettings", ctx, "acc-12345").Return(&settings, nil).Once()
+
+		_, err := handler.Handle(ctx, event)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "the store locator is not enabled for this account")
+		finder.AssertNotCalled(t, "FindShopsNear", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Disabled without a settings store", func(t *testing.T) {
+		handler := NewAppSyncHandler(new(mockRepository), WithNearbyShopFinder(new(mockNearbyShopFinder)))
+
+		_, err := handler.Handle(ctx, event)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "the store locator is not enabled for this account")
+	})
+
+	t.Run("Not configured", func(t *testing.T) {
+		handler := NewAppSyncHandler(new(mockRepository))
+
+		_, err := handler.Handle(ctx, event)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "nearby shop search is not configured")
+	})
+}
+
+func TestAppSyncHandlerFieldVisibility(t *testing.T) {
+	ctx := context.Background()
+	shop := models.ShopLocation{
+		LocationBase: models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeShop},
+		Shop: models.Shop{
+			Name:        "Main Street Store",
+			ContactID:   "contact-1",
+			Address:     models.Address{StreetAddress: "123 Main St", City: "Springfield", PostalCode: "62704", Country: "US"},
+			Coordinates: &models.Coordinates{Latitude: 39.7917, Longitude: -89.6501},
+			Phone:       "+12175550100",
+			LogoKey:     "acc-12345/logo.png",
+		},
+	}
+	apiKeyEvent := func(field, arguments string) AppSyncEvent {
+		return AppSyncEvent{
+			Field:     field,
+			Arguments: json.RawMessage(arguments),
+			Request:   AppSyncRequest{Headers: map[string]string{"x-api-key": "da2-example"}},
+		}
+	}
+
+	t.Run("Account overrides apply to public shops", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		store := new(mockSettingsStore)
+		handler := NewAppSyncHandler(mockRepo, WithSettingsStore(store))
+
+		settings := models.DefaultAccountSettings("acc-12345")
+		settings.PublicStoreLocator = true
+		settings.FieldVisibility = map[string]models.Visibility{"phone": models.VisibilityInternal, "logoKey": models.VisibilityPublic}
+		store.On("GetAccountSettings", ctx, "acc-12345").Return(&settings, nil).Once()
+		mockRepo.On("Get", ctx, "acc-12345", "loc-001").Return(&repository.LocationEnvelope{LocationID: "loc-001", Location: shop}, nil).Once()
+
+		result, err := handler.Handle(ctx, apiKeyEvent("publicShop", `{"accountId": "acc-12345", "locationId": "loc-001"}`))
+		require.NoError(t, err)
+		view := result.(map[string]interface{})
+		assert.Equal(t, "Main Street Store", view["name"])
+		assert.Equal(t, "acc-12345/logo.png", view["logoKey"])
+		assert.Contains(t, view, "address")
+		assert.Contains(t, view, "coordinates")
+		assert.NotContains(t, view, "phone")
+		assert.NotContains(t, view, "contactId")
+	})
+
+	t.Run("Non-shop locations are not found", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		store := new(mockSettingsStore)
+		handler := NewAppSyncHandler(mockRepo, WithSettingsStore(store))
+
+		settings := models.DefaultAccountSettings("acc-12345")
+		settings.PublicStoreLocator = true
+		store.On("GetAccountSettings", ctx, "acc-12345").Return(&settings, nil).Once()
+		mockRepo.On("Get", ctx, "acc-12345", "loc-002").Return(&repository.LocationEnvelope{LocationID: "loc-002", Location: models.AddressLocation{
+			LocationBase: models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeAddress},
+		}}, nil).Once()
+
+		_, err := handler.Handle(ctx, apiKeyEvent("publicShop", `{"accountId": "acc-12345", "locationId": "loc-002"}`))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "location not found or access denied")
+	})
+
+	t.Run("Drafts are not found", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		store := new(mockSettingsStore)
+		handler := NewAppSyncHandler(mockRepo, WithSettingsStore(store))
+
+		settings := models.DefaultAccountSettings("acc-12345")
+		settings.PublicStoreLocator = true
+		draft := shop
+		draft.Draft = true
+		store.On("GetAccountSettings", ctx, "acc-12345").Return(&settings, nil).Once()
+		mockRepo.On("Get", ctx, "acc-12345", "loc-003").Return(&repository.LocationEnvelope{LocationID: "loc-003", Location: draft}, nil).Once()
+
+		_, err := handler.Handle(ctx, apiKeyEvent("publicShop", `{"accountId": "acc-12345", "locationId": "loc-003"}`))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "location not found or access denied")
+	})
+
+	t.Run("Shops outside their active window are not found", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		store := new(mockSettingsStore)
+		handler := NewAppSyncHandler(mockRepo, WithSettingsStore(store))
+
+		settings := models.DefaultAccountSettings("acc-12345")
+		settings.PublicStoreLocator = true
+		ended := shop
+		until := time.Now().Add(-time.Hour)
+		ended.ActiveUntil = &until
+		store.On("GetAccountSettings", ctx, "acc-12345").Return(&settings, nil).Once()
+		mockRepo.On("Get", ctx, "acc-12345", "loc-004").Return(&repository.LocationEnvelope{LocationID: "loc-004", Location: ended}, nil).Once()
+
+		_, err := handler.Handle(ctx, apiKeyEvent("publicShop", `{"accountId": "acc-12345", "locationId": "loc-004"}`))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "location not found or access denied")
+	})
+
+	t.Run("API key callers cannot reach internal fields", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		handler := NewAppSyncHandler(mockRepo)
+
+		_, err := handler.Handle(ctx, apiKeyEvent("getLocation", `{"accountId": "acc-12345", "locationId": "loc-001"}`))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "getLocation is not available with API key authorization")
+		mockRepo.AssertNotCalled(t, "Get", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Signed-in callers keep full access", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		handler := NewAppSyncHandler(mockRepo)
+
+		mockRepo.On("Get", mock.Anything, "acc-12345", "loc-001").Return(&repository.LocationEnvelope{LocationID: "loc-001", Location: shop}, nil).Once()
+
+		event := apiKeyEvent("getLocation", `{"accountId": "acc-12345", "locationId": "loc-001"}`)
+		event.Identity = AppSyncIdentity{Username: "user-1", Claims: map[string]interface{}{"sub": "user-1"}}
+		result, err := handler.Handle(ctx, event)
+		require.NoError(t, err)
+		shopFields := result.(map[string]interface{})["shop"].(map[string]interface{})
+		assert.Equal(t, "contact-1", shopFields["contactId"])
+	})
+}
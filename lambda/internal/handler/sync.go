@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/steverhoton/location-lambda/internal/repository"
+)
+
+// SyncLocationsArguments represents arguments for syncing an account's locations.
+type SyncLocationsArguments struct {
+	AccountID string `json:"accountId"`
+	// SinceToken is the nextToken of the client's last sync, or empty to sync from scratch
+	SinceToken   string `json:"sinceToken,omitempty"`
+	Limit        *int32 `json:"limit,omitempty"`
+	IncludeLinks bool   `json:"includeLinks,omitempty"`
+}
+
+// LocationChange is one change in a syncLocations response.
+type LocationChange struct {
+	Operation  repository.SyncOperation `json:"operation"`
+	LocationID string                   `json:"locationId"`
+	ChangedAt  time.Time                `json:"changedAt"`
+	// Location is in the same shape as getLocation, omitted for deletions
+	Location map[string]interface{} `json:"location,omitempty"`
+}
+
+// SyncLocationsResponse represents the response for syncLocations.
+type SyncLocationsResponse struct {
+	Changes   []LocationChange `json:"changes"`
+	NextToken string           `json:"nextToken,omitempty"`
+	HasMore   bool             `json:"hasMore"`
+}
+
+// WithLocationSyncer enables syncLocations.
+func WithLocationSyncer(syncer repository.LocationSyncer) Option {
+	return func(h *AppSyncHandler) {
+		h.syncer = syncer
+	}
+}
+
+// handleSyncLocations returns the changes to an account's locations since a
+// client's last sync, so offline caches can catch up without relisting.
+func (h *AppSyncHandler) handleSyncLocations(ctx context.Context, arguments json.RawMessage) (*SyncLocationsResponse, error) {
+	var args SyncLocationsArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+
+	if args.AccountID == "" {
+		return nil, fmt.Errorf("accountId is required")
+	}
+	if h.syncer == nil {
+		return nil, fmt.Errorf("location sync is not configured")
+	}
+	limit, err := h.listLimits.Resolve(args.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := h.syncer.SyncLocations(ctx, args.AccountID, args.SinceToken, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sync locations: %w", err)
+	}
+
+	response := &SyncLocationsResponse{
+		Changes:   make([]LocationChange, 0, len(result.Changes)),
+		NextToken: result.NextToken,
+		HasMore:   result.HasMore,
+	}
+	for _, change := range result.Changes {
+		converted := LocationChange{Operation: change.Operation, LocationID: change.LocationID, ChangedAt: change.ChangedAt}
+		if change.Location != nil {
+			if converted.Location, err = toLocationMap(*change.Location, args.IncludeLinks); err != nil {
+				return nil, err
+			}
+		}
+		response.Changes = append(response.Changes, converted)
+	}
+	return response, nil
+}
@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type mockLocationSyncer struct {
+	mock.Mock
+}
+
+func (m *mockLocationSyncer) SyncLocations(ctx context.Context, accountID, sinceToken string, limit int32) (*repository.SyncResult, error) {
+	args := m.Called(ctx, accountID, sinceToken, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.SyncResult), args.Error(1)
+}
+
+func TestAppSyncHandlerSyncLocations(t *testing.T) {
+	ctx := context.Background()
+	location := models.CoordinatesLocation{
+		LocationBase: models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeCoordinates},
+		Coordinates:  models.Coordinates{Latitude: 39.7, Longitude: -104.9},
+	}
+	changedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Returns changes in the getLocation shape", func(t *testing.T) {
+		syncer := new(mockLocationSyncer)
+		handler := NewAppSyncHandler(new(mockRepository), WithLocationSyncer(syncer))
+
+		syncer.On("SyncLocations", ctx, "acc-12345", "token-1", int32(50)).Return(&repository.SyncResult{
+			Changes: []repository.SyncChange{
+				{Operation: repository.SyncUpdated, LocationID: "loc-001", ChangedAt: changedAt,
+					Location: &repository.LocationEnvelope{LocationID: "loc-001", Location: location}},
+				{Operation: repository.SyncDeleted, LocationID: "loc-002", ChangedAt: changedAt},
+			},
+			NextToken: "token-2",
+			HasMore:   true,
+		}, nil).Once()
+
+		result, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "syncLocations",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "sinceToken": "token-1", "limit": 50}`),
+		})
+		require.NoError(t, err)
+		response := result.(*SyncLocationsResponse)
+		require.Len(t, response.Changes, 2)
+		assert.Equal(t, repository.SyncUpdated, response.Changes[0].Operation)
+		assert.Equal(t, "CoordinatesLocation", response.Changes[0].Location["__typename"])
+		assert.Equal(t, "loc-001", response.Changes[0].Location["locationId"])
+		assert.Equal(t, LocationChange{Operation: repository.SyncDeleted, LocationID: "loc-002", ChangedAt: changedAt}, response.Changes[1])
+		assert.Equal(t, "token-2", response.NextToken)
+		assert.True(t, response.HasMore)
+		syncer.AssertExpectations(t)
+	})
+
+	t.Run("Syncs from scratch with the default page size", func(t *testing.T) {
+		syncer := new(mockLocationSyncer)
+		handler := NewAppSyncHandler(new(mockRepository), WithLocationSyncer(syncer))
+
+		syncer.On("SyncLocations", ctx, "acc-12345", "", int32(20)).Return(&repository.SyncResult{Changes: []repository.SyncChange{}}, nil).Once()
+
+		result, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "syncLocations",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345"}`),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, &SyncLocationsResponse{Changes: []LocationChange{}}, result)
+		syncer.AssertExpectations(t)
+	})
+
+	t.Run("Requires a configured syncer", func(t *testing.T) {
+		handler := NewAppSyncHandler(new(mockRepository))
+
+		_, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "syncLocations",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345"}`),
+		})
+		assert.EqualError(t, err, "location sync is not configured")
+	})
+
+	t.Run("Requires an account", func(t *testing.T) {
+		handler := NewAppSyncHandler(new(mockRepository), WithLocationSyncer(new(mockLocationSyncer)))
+
+		_, err := handler.Handle(ctx, AppSyncEvent{Field: "syncLocations", Arguments: json.RawMessage(`{}`)})
+		assert.EqualError(t, err, "accountId is required")
+	})
+}
@@ -0,0 +1,196 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/repository"
+)
+
+// CreateLocationTemplateArguments represents arguments for creating a location template.
+type CreateLocationTemplateArguments struct {
+	AccountID string                 `json:"accountId"`
+	Name      string                 `json:"name"`
+	Payload   map[string]interface{} `json:"payload"`
+}
+
+// LocationTemplateArguments represents arguments identifying a location template.
+type LocationTemplateArguments struct {
+	AccountID  string `json:"accountId"`
+	TemplateID string `json:"templateId"`
+}
+
+// ListLocationTemplatesArguments represents arguments for listing location templates.
+type ListLocationTemplatesArguments struct {
+	AccountID string  `json:"accountId"`
+	Limit     *int32  `json:"limit,omitempty"`
+	Cursor    *string `json:"cursor,omitempty"`
+}
+
+// CreateLocationFromTemplateArguments represents arguments for creating a location from a template.
+type CreateLocationFromTemplateArguments struct {
+	AccountID  string                 `json:"accountId"`
+	TemplateID string                 `json:"templateId"`
+	Overrides  map[string]interface{} `json:"overrides,omitempty"`
+}
+
+// templateStore returns the configured template store or an error when templates are disabled.
+func (h *AppSyncHandler) templateStore() (repository.TemplateStore, error) {
+	if h.templates == nil {
+		return nil, fmt.Errorf("location templates are not configured")
+	}
+	return h.templates, nil
+}
+
+func (h *AppSyncHandler) handleCreateLocationTemplate(ctx context.Context, arguments json.RawMessage) (string, error) {
+	var args CreateLocationTemplateArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return "", fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+
+	store, err := h.templateStore()
+	if err != nil {
+		return "", err
+	}
+
+	templateID, err := store.CreateTemplate(ctx, repository.LocationTemplate{
+		AccountID: args.AccountID,
+		Name:      args.Name,
+		Payload:   args.Payload,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create location template: %w", err)
+	}
+
+	return templateID, nil
+}
+
+func (h *AppSyncHandler) handleGetLocationTemplate(ctx context.Context, arguments json.RawMessage) (*repository.LocationTemplate, error) {
+	var args LocationTemplateArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+
+	store, err := h.templateStore()
+	if err != nil {
+		return nil, err
+	}
+
+	template, err := store.GetTemplate(ctx, args.AccountID, args.TemplateID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get location template: %w", err)
+	}
+
+	return template, nil
+}
+
+func (h *AppSyncHandler) handleListLocationTemplates(ctx context.Context, arguments json.RawMessage) (*repository.TemplateListResult, error) {
+	var args ListLocationTemplatesArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+
+	store, err := h.templateStore()
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := store.ListTemplates(ctx, args.AccountID, &repository.ListOptions{
+		Limit:  args.Limit,
+		Cursor: args.Cursor,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list location templates: %w", err)
+	}
+
+	return result, nil
+}
+
+func (h *AppSyncHandler) handleDeleteLocationTemplate(ctx context.Context, arguments json.RawMessage) (bool, error) {
+	var args LocationTemplateArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return false, fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+
+	store, err := h.templateStore()
+	if err != nil {
+		return false, err
+	}
+
+	if err := store.DeleteTemplate(ctx, args.AccountID, args.TemplateID); err != nil {
+		return false, fmt.Errorf("failed to delete location template: %w", err)
+	}
+
+	return true, nil
+}
+
+func (h *AppSyncHandler) handleCreateLocationFromTemplate(ctx context.Context, arguments json.RawMessage) (string, error) {
+	var args CreateLocationFromTemplateArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return "", fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+
+	store, err := h.templateStore()
+	if err != nil {
+		return "", err
+	}
+
+	template, err := store.GetTemplate(ctx, args.AccountID, args.TemplateID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get location template: %w", err)
+	}
+
+	// Overrides win over template values; the account always comes from the request
+	payload := mergePayload(template.Payload, args.Overrides)
+	payload["accountId"] = args.AccountID
+
+	input, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal location: %w", err)
+	}
+
+	location, err := models.UnmarshalLocation(input)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal location: %w", err)
+	}
+	if location, err = withConvertedCoordinates(location); err != nil {
+		return "", err
+	}
+	if location, err = h.withElevation(ctx, location); err != nil {
+		return "", err
+	}
+	if err := h.validateCustomFields(ctx, location); err != nil {
+		return "", err
+	}
+	if err := h.validateCategories(ctx, location); err != nil {
+		return "", err
+	}
+
+	created, err := h.repo.Create(ctx, location)
+	if err != nil {
+		return "", fmt.Errorf("failed to create location: %w", err)
+	}
+
+	return created.LocationID, nil
+}
+
+// mergePayload deep-merges overrides onto base without modifying either.
+// Nested objects are merged key by key; any other override value replaces the base value.
+func mergePayload(base, overrides map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(base)+len(overrides))
+	for key, value := range base {
+		merged[key] = value
+	}
+	for key, value := range overrides {
+		baseMap, baseIsMap := merged[key].(map[string]interface{})
+		overrideMap, overrideIsMap := value.(map[string]interface{})
+		if baseIsMap && overrideIsMap {
+			merged[key] = mergePayload(baseMap, overrideMap)
+			continue
+		}
+		merged[key] = value
+	}
+	return merged
+}
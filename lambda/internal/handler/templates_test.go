@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockTemplateStore is a mock implementation of the repository.TemplateStore interface.
+type mockTemplateStore struct {
+	mock.Mock
+}
+
+func (m *mockTemplateStore) CreateTemplate(ctx context.Context, template repository.LocationTemplate) (string, error) {
+	args := m.Called(ctx, template)
+	return args.String(0), args.Error(1)
+}
+
+func (m *mockTemplateStore) GetTemplate(ctx context.Context, accountID, templateID string) (*repository.LocationTemplate, error) {
+	args := m.Called(ctx, accountID, templateID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.LocationTemplate), args.Error(1)
+}
+
+func (m *mockTemplateStore) ListTemplates(ctx context.Context, accountID string, options *repository.ListOptions) (*repository.TemplateListResult, error) {
+	args := m.Called(ctx, accountID, options)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.TemplateListResult), args.Error(1)
+}
+
+func (m *mockTemplateStore) DeleteTemplate(ctx context.Context, accountID, templateID string) error {
+	args := m.Called(ctx, accountID, templateID)
+	return args.Error(0)
+}
+
+func TestAppSyncHandlerCreateLocationFromTemplate(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(mockRepository)
+	mockStore := new(mockTemplateStore)
+	handler := NewAppSyncHandler(mockRepo, WithTemplateStore(mockStore))
+
+	template := &repository.LocationTemplate{
+		TemplateID: "tpl-001",
+		AccountID:  "acc-12345",
+		Name:       "Standard shop",
+		Payload: map[string]interface{}{
+			"locationType": "shop",
+			"shop": map[string]interface{}{
+				"name": "Main Street Shop",
+				"address": map[string]interface{}{
+					"streetAddress": "123 Main St",
+					"city":          "Springfield",
+					"postalCode":    "12345",
+					"country":       "US",
+				},
+			},
+		},
+	}
+
+	event := AppSyncEvent{
+		Field: "createLocationFromTemplate",
+		Arguments: json.RawMessage(`{
+			"accountId": "acc-12345",
+			"templateId": "tpl-001",
+			"overrides": {"shop": {"name": "Elm Street Shop"}}
+		}`),
+	}
+
+	t.Run("Overrides are merged onto the template", func(t *testing.T) {
+		mockStore.On("GetTemplate", ctx, "acc-12345", "tpl-001").Return(template, nil).Once()
+		mockRepo.On("Create", ctx, mock.MatchedBy(func(loc models.Location) bool {
+			shop, ok := loc.(models.ShopLocation)
+			return ok &&
+				shop.AccountID == "acc-12345" &&
+				shop.Shop.Name == "Elm Street Shop" &&
+				shop.Shop.Address.City == "Springfield"
+		})).Return("loc-001", nil).Once()
+
+		result, err := handler.Handle(ctx, event)
+		require.NoError(t, err)
+		assert.Equal(t, "loc-001", result)
+		assert.Equal(t, "Main Street Shop", template.Payload["shop"].(map[string]interface{})["name"], "template must not be modified")
+		mockStore.AssertExpectations(t)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Template not found", func(t *testing.T) {
+		mockStore.On("GetTemplate", ctx, "acc-12345", "tpl-001").Return(nil, errors.New("template not found")).Once()
+
+		_, err := handler.Handle(ctx, event)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to get location template")
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("Templates not configured", func(t *testing.T) {
+		_, err := NewAppSyncHandler(mockRepo).Handle(ctx, event)
+		assert.EqualError(t, err, "location templates are not configured")
+	})
+}
+
+func TestAppSyncHandlerCreateLocationTemplate(t *testing.T) {
+	ctx := context.Background()
+	mockStore := new(mockTemplateStore)
+	handler := NewAppSyncHandler(new(mockRepository), WithTemplateStore(mockStore))
+
+	mockStore.On("CreateTemplate", ctx, mock.MatchedBy(func(tpl repository.LocationTemplate) bool {
+		return tpl.AccountID == "acc-12345" && tpl.Name == "Depot" && tpl.Payload["locationType"] == "coordinates"
+	})).Return("tpl-002", nil).Once()
+
+	result, err := handler.Handle(ctx, AppSyncEvent{
+		Field:     "createLocationTemplate",
+		Arguments: json.RawMessage(`{"accountId": "acc-12345", "name": "Depot", "payload": {"locationType": "coordinates"}}`),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "tpl-002", result)
+	mockStore.AssertExpectations(t)
+}
+
+func TestMergePayload(t *testing.T) {
+	base := map[string]interface{}{
+		"locationType": "address",
+		"address":      map[string]interface{}{"city": "Springfield", "country": "US"},
+		"tags":         []interface{}{"a"},
+	}
+	overrides := map[string]interface{}{
+		"address": map[string]interface{}{"city": "Shelbyville"},
+		"tags":    []interface{}{"b"},
+	}
+
+	merged := mergePayload(base, overrides)
+
+	assert.Equal(t, map[string]interface{}{
+		"locationType": "address",
+		"address":      map[string]interface{}{"city": "Shelbyville", "country": "US"},
+		"tags":         []interface{}{"b"},
+	}, merged)
+	assert.Equal(t, "Springfield", base["address"].(map[string]interface{})["city"])
+}
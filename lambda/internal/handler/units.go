@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+)
+
+// AddLocationUnitArguments represents arguments for adding a unit to a building.
+type AddLocationUnitArguments struct {
+	AccountID  string      `json:"accountId"`
+	LocationID string      `json:"locationId"`
+	Unit       models.Unit `json:"unit"`
+}
+
+// UpdateLocationUnitArguments represents arguments for replacing one of a
+// building's units. Unit may carry a new number.
+type UpdateLocationUnitArguments struct {
+	AccountID  string      `json:"accountId"`
+	LocationID string      `json:"locationId"`
+	Number     string      `json:"number"`
+	Unit       models.Unit `json:"unit"`
+}
+
+// RemoveLocationUnitArguments represents arguments for removing a unit from a building.
+type RemoveLocationUnitArguments struct {
+	AccountID  string `json:"accountId"`
+	LocationID string `json:"locationId"`
+	Number     string `json:"number"`
+}
+
+// handleAddLocationUnit lists a unit in a stored building and returns its units.
+func (h *AppSyncHandler) handleAddLocationUnit(ctx context.Context, arguments json.RawMessage) ([]models.Unit, error) {
+	var args AddLocationUnitArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+
+	return h.updateLocationUnits(ctx, args.AccountID, args.LocationID, func(location *models.AddressLocation) error {
+		return location.AddUnit(args.Unit)
+	})
+}
+
+// handleUpdateLocationUnit replaces a unit of a stored building and returns its units.
+func (h *AppSyncHandler) handleUpdateLocationUnit(ctx context.Context, arguments json.RawMessage) ([]models.Unit, error) {
+	var args UpdateLocationUnitArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+	if args.Number == "" {
+		return nil, fmt.Errorf("number is required")
+	}
+
+	return h.updateLocationUnits(ctx, args.AccountID, args.LocationID, func(location *models.AddressLocation) error {
+		return location.UpdateUnit(args.Number, args.Unit)
+	})
+}
+
+// handleRemoveLocationUnit removes a unit from a stored building and returns its units.
+func (h *AppSyncHandler) handleRemoveLocationUnit(ctx context.Context, arguments json.RawMessage) ([]models.Unit, error) {
+	var args RemoveLocationUnitArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+	if args.Number == "" {
+		return nil, fmt.Errorf("number is required")
+	}
+
+	return h.updateLocationUnits(ctx, args.AccountID, args.LocationID, func(location *models.AddressLocation) error {
+		if !location.RemoveUnit(args.Number) {
+			return fmt.Errorf("unit %s is not listed", args.Number)
+		}
+		return nil
+	})
+}
+
+// updateLocationUnits applies change to a stored building's units and saves
+// it, so one unit can be edited without resending the whole location.
+func (h *AppSyncHandler) updateLocationUnits(ctx context.Context, accountID, locationID string, change func(*models.AddressLocation) error) ([]models.Unit, error) {
+	if accountID == "" || locationID == "" {
+		return nil, fmt.Errorf("accountId and locationId are required")
+	}
+
+	envelope, err := h.repo.Get(ctx, accountID, locationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get location: %w", err)
+	}
+	location, ok := envelope.Location.(models.AddressLocation)
+	if !ok {
+		return nil, fmt.Errorf("only address locations have units, got %s", envelope.Location.GetLocationType())
+	}
+
+	if err := change(&location); err != nil {
+		return nil, err
+	}
+	if err := h.repo.Update(ctx, location, locationID); err != nil {
+		return nil, fmt.Errorf("failed to update location: %w", err)
+	}
+
+	if location.Units == nil {
+		return []models.Unit{}, nil
+	}
+	return location.Units, nil
+}
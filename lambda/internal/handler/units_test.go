@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppSyncHandlerLocationUnits(t *testing.T) {
+	ctx := context.Background()
+	lobby := models.Unit{Number: "G1", Floor: "G", Occupant: "Front Desk"}
+	suite := models.Unit{Number: "210", Floor: "2", Occupant: "Acme Corp"}
+	building := models.AddressLocation{
+		LocationBase: models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeAddress},
+		Address:      models.Address{StreetAddress: "500 Commerce Blvd", City: "Springfield", PostalCode: "62704", Country: "US"},
+		Units:        []models.Unit{lobby},
+	}
+	withUnits := func(units ...models.Unit) interface{} {
+		return mock.MatchedBy(func(l models.Location) bool {
+			loc, ok := l.(models.AddressLocation)
+			return ok && assert.ObjectsAreEqual(units, loc.Units)
+		})
+	}
+
+	tests := []struct {
+		name      string
+		field     string
+		arguments string
+		update    interface{}
+		want      []models.Unit
+		errMsg    string
+	}{
+		{
+			name:      "Adds a unit",
+			field:     "addLocationUnit",
+			arguments: `{"accountId": "acc-12345", "locationId": "loc-001", "unit": {"number": "210", "floor": "2", "occupant": "Acme Corp"}}`,
+			update:    withUnits(lobby, suite),
+			want:      []models.Unit{lobby, suite},
+		},
+		{
+			name:      "Rejects a unit number already listed",
+			field:     "addLocationUnit",
+			arguments: `{"accountId": "acc-12345", "locationId": "loc-001", "unit": {"number": "G1"}}`,
+			errMsg:    "units[1]: unit G1 is already listed",
+		},
+		{
+			name:      "Updates a unit",
+			field:     "updateLocationUnit",
+			arguments: `{"accountId": "acc-12345", "locationId": "loc-001", "number": "G1", "unit": {"number": "G1", "floor": "G"}}`,
+			update:    withUnits(models.Unit{Number: "G1", Floor: "G"}),
+			want:      []models.Unit{{Number: "G1", Floor: "G"}},
+		},
+		{
+			name:      "Rejects updating an unknown unit",
+			field:     "updateLocationUnit",
+			arguments: `{"accountId": "acc-12345", "locationId": "loc-001", "number": "210", "unit": {"number": "210"}}`,
+			errMsg:    "unit 210 is not listed",
+		},
+		{
+			name:      "Removes a unit",
+			field:     "removeLocationUnit",
+			arguments: `{"accountId": "acc-12345", "locationId": "loc-001", "number": "G1"}`,
+			update:    withUnits(),
+			want:      []models.Unit{},
+		},
+		{
+			name:      "Rejects removing an unknown unit",
+			field:     "removeLocationUnit",
+			arguments: `{"accountId": "acc-12345", "locationId": "loc-001", "number": "210"}`,
+			errMsg:    "unit 210 is not listed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(mockRepository)
+			handler := NewAppSyncHandler(mockRepo)
+
+			mockRepo.On("Get", ctx, "acc-12345", "loc-001").Return(withID("loc-001", building), nil).Once()
+			if tt.update != nil {
+				mockRepo.On("Update", ctx, tt.update, "loc-001").Return(nil).Once()
+			}
+
+			result, err := handler.Handle(ctx, AppSyncEvent{Field: tt.field, Arguments: json.RawMessage(tt.arguments)})
+			if tt.errMsg != "" {
+				assert.EqualError(t, err, tt.errMsg)
+				mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, result)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+
+	t.Run("Only address locations have units", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		handler := NewAppSyncHandler(mockRepo)
+
+		mockRepo.On("Get", ctx, "acc-12345", "loc-001").Return(withID("loc-001", models.CoordinatesLocation{
+			LocationBase: models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeCoordinates},
+		}), nil).Once()
+
+		_, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "addLocationUnit",
+			Arguments: json.RawMessage(`{"accountId": "acc-12345", "locationId": "loc-001", "unit": {"number": "210"}}`),
+		})
+		assert.EqualError(t, err, "only address locations have units, got coordinates")
+	})
+
+	t.Run("Requires the unit number", func(t *testing.T) {
+		handler := NewAppSyncHandler(new(mockRepository))
+
+		_, err := handler.Handle(ctx, AppSyncEvent{Field: "removeLocationUnit", Arguments: json.RawMessage(`{"accountId": "acc-12345", "locationId": "loc-001"}`)})
+		assert.EqualError(t, err, "number is required")
+	})
+
+	t.Run("Requires the location", func(t *testing.T) {
+		handler := NewAppSyncHandler(new(mockRepository))
+
+		_, err := handler.Handle(ctx, AppSyncEvent{Field: "addLocationUnit", Arguments: json.RawMessage(`{"unit": {"number": "210"}}`)})
+		assert.EqualError(t, err, "accountId and locationId are required")
+	})
+}
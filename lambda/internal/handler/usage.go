@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/steverhoton/location-lambda/internal/repository"
+)
+
+// GetUsageArguments represents arguments for reading an account's usage.
+type GetUsageArguments struct {
+	AccountID string `json:"accountId"`
+	Period    string `json:"period"` // A UTC day, 2024-06-01, or a month, 2024-06
+}
+
+// WithUsageMeter counts every successful operation naming an account, as
+// accountId or input.accountId, and enables getUsage for the admin group.
+func WithUsageMeter(meter repository.UsageMeter) Option {
+	return func(h *AppSyncHandler) {
+		h.usage = meter
+	}
+}
+
+// meterUsage counts a successful operation against its account. Metering is
+// best effort: a failure to count is logged rather than failing the
+// operation, which has already taken effect.
+func (h *AppSyncHandler) meterUsage(ctx context.Context, event AppSyncEvent) {
+	if h.usage == nil {
+		return
+	}
+	accountID, err := mutatedAccountID(event.Arguments)
+	if err != nil || accountID == "" {
+		return
+	}
+	if err := h.usage.RecordUsage(ctx, accountID, event.Field, h.clock.Now()); err != nil {
+		log.Printf("WARN: Failed to meter %s for account %s: %v", event.Field, accountID, err)
+	}
+}
+
+// handleGetUsage returns an account's operation counts over a day or month,
+// for the platform team's billing and plan enforcement.
+func (h *AppSyncHandler) handleGetUsage(ctx context.Context, event AppSyncEvent) (*repository.Usage, error) {
+	if h.usage == nil {
+		return nil, fmt.Errorf("usage metering is not configured")
+	}
+	if err := h.requireAdmin(event.Identity); err != nil {
+		return nil, err
+	}
+
+	var args GetUsageArguments
+	if err := json.Unmarshal(event.Arguments, &args); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+	if args.AccountID == "" {
+		return nil, fmt.Errorf("validation failed: accountId is required")
+	}
+	if err := repository.ValidateUsagePeriod(args.Period); err != nil {
+		return nil, err
+	}
+
+	usage, err := h.usage.GetUsage(ctx, args.AccountID, args.Period)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get usage: %w", err)
+	}
+	return usage, nil
+}
@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockUsageMeter is a mock implementation of the repository.UsageMeter interface.
+type mockUsageMeter struct {
+	mock.Mock
+}
+
+func (m *mockUsageMeter) RecordUsage(ctx context.Context, accountID, operation string, at time.Time) error {
+	args := m.Called(ctx, accountID, operation, at)
+	return args.Error(0)
+}
+
+func (m *mockUsageMeter) GetUsage(ctx context.Context, accountID, period string) (*repository.Usage, error) {
+	args := m.Called(ctx, accountID, period)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.Usage), args.Error(1)
+}
+
+func TestAppSyncHandlerMetersUsage(t *testing.T) {
+	ctx := context.Background()
+	location := models.CoordinatesLocation{
+		LocationBase: models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeCoordinates},
+		Coordinates:  models.Coordinates{Latitude: 40.7128, Longitude: -74.006},
+	}
+	event := AppSyncEvent{
+		Field:     "getLocation",
+		Arguments: json.RawMessage(`{"accountId": "acc-12345", "locationId": "loc-001"}`),
+	}
+
+	t.Run("Counts a successful operation", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		meter := new(mockUsageMeter)
+		h := NewAppSyncHandler(mockRepo, WithUsageMeter(meter))
+		mockRepo.On("Get", mock.Anything, "acc-12345", "loc-001").Return(withID("loc-001", location), nil).Once()
+		meter.On("RecordUsage", mock.Anything, "acc-12345", "getLocation", mock.AnythingOfType("time.Time")).Return(nil).Once()
+
+		_, err := h.Handle(ctx, event)
+		require.NoError(t, err)
+		meter.AssertExpectations(t)
+	})
+
+	t.Run("Skips failed operations", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		meter := new(mockUsageMeter)
+		h := NewAppSyncHandler(mockRepo, WithUsageMeter(meter))
+		mockRepo.On("Get", mock.Anything, "acc-12345", "loc-001").Return(nil, errors.New("location not found")).Once()
+
+		_, err := h.Handle(ctx, event)
+		assert.Error(t, err)
+		meter.AssertNotCalled(t, "RecordUsage", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Succeeds when the count can't be written", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		meter := new(mockUsageMeter)
+		h := NewAppSyncHandler(mockRepo, WithUsageMeter(meter))
+		mockRepo.On("Get", mock.Anything, "acc-12345", "loc-001").Return(withID("loc-001", location), nil).Once()
+		meter.On("RecordUsage", mock.Anything, "acc-12345", "getLocation", mock.Anything).Return(errors.New("throttled")).Once()
+
+		_, err := h.Handle(ctx, event)
+		assert.NoError(t, err)
+	})
+}
+
+func TestAppSyncHandlerGetUsage(t *testing.T) {
+	ctx := context.Background()
+	admin := AppSyncIdentity{Claims: map[string]interface{}{"cognito:groups": []interface{}{"location-admins"}}}
+	usage := &repository.Usage{
+		AccountID:  "acc-12345",
+		Period:     "2024-06",
+		Total:      3,
+		Operations: []repository.OperationUsage{{Operation: "getLocation", Count: 3}},
+		Days:       []repository.DailyUsage{{Date: "2024-06-01", Total: 3}},
+	}
+
+	tests := []struct {
+		name          string
+		identity      AppSyncIdentity
+		arguments     string
+		expectedError string
+	}{
+		{name: "Admin reads a month", identity: admin, arguments: `{"accountId": "acc-12345", "period": "2024-06"}`},
+		{name: "Tenant user", arguments: `{"accountId": "acc-12345", "period": "2024-06"}`, expectedError: "admin access required"},
+		{name: "Missing account", identity: admin, arguments: `{"period": "2024-06"}`, expectedError: "validation failed: accountId is required"},
+		{name: "Invalid period", identity: admin, arguments: `{"accountId": "acc-12345", "period": "June"}`, expectedError: `validation failed: period must be a day (YYYY-MM-DD) or a month (YYYY-MM), got "June"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meter := new(mockUsageMeter)
+			h := NewAppSyncHandler(new(mockRepository), WithAdminStore(new(mockAdminStore), "location-admins"), WithUsageMeter(meter))
+			meter.On("GetUsage", mock.Anything, "acc-12345", "2024-06").Return(usage, nil).Maybe()
+			meter.On("RecordUsage", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+
+			result, err := h.Handle(ctx, AppSyncEvent{Field: "getUsage", Identity: tt.identity, Arguments: json.RawMessage(tt.arguments)})
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, usage, result)
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+)
+
+// strictValidation is the validation mode of operations that always validate strictly.
+var strictValidation = models.ValidationStrict
+
+// relaxLocation applies an operation's validation mode, or the account's
+// validationStrictness when the operation gives none. In lenient mode the
+// optional fields failing validation are dropped and returned as warnings;
+// in strict mode the location is returned as is, for the repository to
+// reject if invalid.
+func (h *AppSyncHandler) relaxLocation(ctx context.Context, location models.Location, mode *models.ValidationStrictness) (models.Location, models.ValidationErrors, error) {
+	strictness, err := h.validationStrictness(ctx, location.GetAccountID(), mode)
+	if err != nil {
+		return nil, nil, err
+	}
+	if strictness != models.ValidationLenient {
+		return location, nil, nil
+	}
+	relaxed, dropped := models.Relax(location)
+	return relaxed, dropped, nil
+}
+
+// validationStrictness resolves the validation mode of an operation on accountID.
+func (h *AppSyncHandler) validationStrictness(ctx context.Context, accountID string, mode *models.ValidationStrictness) (models.ValidationStrictness, error) {
+	if mode != nil {
+		switch *mode {
+		case models.ValidationStrict, models.ValidationLenient:
+			return *mode, nil
+		}
+		return "", fmt.Errorf("validationMode must be %s or %s", models.ValidationStrict, models.ValidationLenient)
+	}
+	if h.settings == nil || accountID == "" {
+		return models.ValidationStrict, nil
+	}
+	settings, err := h.settings.GetAccountSettings(ctx, accountID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get account settings: %w", err)
+	}
+	return settings.ValidationStrictness, nil
+}
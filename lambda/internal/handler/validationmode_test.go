@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppSyncHandlerValidationMode(t *testing.T) {
+	ctx := context.Background()
+	shopJSON := `{"accountId": "acc-12345", "locationType": "shop", "shop": {
+		"name": "Main Street Store", "contactId": "contact-1", "email": "not-an-email",
+		"address": {"streetAddress": "123 Main St", "city": "Springfield", "postalCode": "12345", "country": "US"}
+	}}`
+	emailDropped := mock.MatchedBy(func(loc models.Location) bool {
+		shop, ok := loc.(models.ShopLocation)
+		return ok && shop.Shop.Email == ""
+	})
+
+	t.Run("Lenient create drops invalid optional fields", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		handler := NewAppSyncHandler(mockRepo)
+		mockRepo.On("Create", ctx, emailDropped).Return("loc-shop", nil).Once()
+
+		result, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "createLocation",
+			Arguments: json.RawMessage(`{"validationMode": "lenient", "input": ` + shopJSON + `}`),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, models.ValidationErrors{
+			{Field: "shop.email", Message: `email must be an address such as shop@example.com, got "not-an-email"`},
+		}, result.(map[string]interface{})["validationWarnings"])
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Strict create leaves the location for the repository to reject", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		handler := NewAppSyncHandler(mockRepo)
+		mockRepo.On("Create", ctx, mock.MatchedBy(func(loc models.Location) bool {
+			return loc.(models.ShopLocation).Shop.Email == "not-an-email"
+		})).Return("", assert.AnError).Once()
+
+		_, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "createLocation",
+			Arguments: json.RawMessage(`{"validationMode": "strict", "input": ` + shopJSON + `}`),
+		})
+		require.Error(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Defaults to the account's validation strictness", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		store := new(mockSettingsStore)
+		handler := NewAppSyncHandler(mockRepo, WithSettingsStore(store))
+
+		settings := models.DefaultAccountSettings("acc-12345")
+		settings.ValidationStrictness = models.ValidationLenient
+		store.On("GetAccountSettings", ctx, "acc-12345").Return(&settings, nil).Once()
+		mockRepo.On("Upsert", ctx, emailDropped).Return(&repository.UpsertResult{LocationID: "loc-shop", Created: true}, nil).Once()
+
+		input := `{"accountId": "acc-12345", "locationType": "shop", "externalId": "store-1", "shop": {
+			"name": "Main Street Store", "contactId": "contact-1", "email": "not-an-email",
+			"address": {"streetAddress": "123 Main St", "city": "Springfield", "postalCode": "12345", "country": "US"}
+		}}`
+		result, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "upsertLocationByExternalId",
+			Arguments: json.RawMessage(`{"input": ` + input + `}`),
+		})
+		require.NoError(t, err)
+		upserted := result.(*repository.UpsertResult)
+		require.Len(t, upserted.ValidationWarnings, 1)
+		assert.Equal(t, "shop.email", upserted.ValidationWarnings[0].Field)
+		store.AssertExpectations(t)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Lenient upsert keeps contact details it can normalize", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		handler := NewAppSyncHandler(mockRepo)
+		mockRepo.On("Upsert", ctx, mock.MatchedBy(func(loc models.Location) bool {
+			shop, ok := loc.(models.ShopLocation)
+			return ok && shop.Shop.Phone == "+12025550143" && shop.Shop.WebsiteURL == "https://example.com/"
+		})).Return(&repository.UpsertResult{LocationID: "loc-shop", Created: true}, nil).Once()
+
+		input := `{"accountId": "acc-12345", "locationType": "shop", "externalId": "store-1", "shop": {
+			"name": "Main Street Store", "contactId": "contact-1", "phone": "(202) 555-0143", "websiteUrl": "HTTPS://Example.com/?utm_source=flyer",
+			"address": {"streetAddress": "123 Main St", "city": "Springfield", "postalCode": "12345", "country": "US"}
+		}}`
+		result, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "upsertLocationByExternalId",
+			Arguments: json.RawMessage(`{"validationMode": "lenient", "input": ` + input + `}`),
+		})
+		require.NoError(t, err)
+		assert.Empty(t, result.(*repository.UpsertResult).ValidationWarnings)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Lenient update reports the dropped fields", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		handler := NewAppSyncHandler(mockRepo)
+		mockRepo.On("Update", ctx, emailDropped, "loc-shop").Return(nil).Once()
+
+		result, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "updateLocation",
+			Arguments: json.RawMessage(`{"locationId": "loc-shop", "validationMode": "lenient", "input": ` + shopJSON + `}`),
+		})
+		require.NoError(t, err)
+		response := result.(*UpdateResponse)
+		assert.True(t, response.Success)
+		require.Len(t, response.ValidationWarnings, 1)
+		assert.Equal(t, "shop.email", response.ValidationWarnings[0].Field)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Rejects an unknown validation mode", func(t *testing.T) {
+		handler := NewAppSyncHandler(new(mockRepository))
+
+		_, err := handler.Handle(ctx, AppSyncEvent{
+			Field:     "createLocation",
+			Arguments: json.RawMessage(`{"validationMode": "loose", "input": ` + shopJSON + `}`),
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "validationMode must be strict or lenient")
+	})
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/verify"
+)
+
+// VerifyAddressArguments represents arguments for verifying a stored location's address.
+type VerifyAddressArguments struct {
+	AccountID  string `json:"accountId"`
+	LocationID string `json:"locationId"`
+}
+
+// WithVerifier enables address verification with a third-party provider.
+func WithVerifier(verifier verify.Verifier) Option {
+	return func(h *AppSyncHandler) {
+		h.verifier = verifier
+	}
+}
+
+func (h *AppSyncHandler) handleVerifyAddress(ctx context.Context, arguments json.RawMessage) (*models.AddressVerification, error) {
+	var args VerifyAddressArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+
+	if args.AccountID == "" || args.LocationID == "" {
+		return nil, fmt.Errorf("accountId and locationId are required")
+	}
+
+	envelope, err := h.repo.Get(ctx, args.AccountID, args.LocationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get location: %w", err)
+	}
+
+	verified, verification, err := h.verifyLocation(ctx, envelope.Location)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.repo.Update(ctx, verified, args.LocationID); err != nil {
+		return nil, fmt.Errorf("failed to update location: %w", err)
+	}
+
+	return verification, nil
+}
+
+// verifyLocation verifies the address of an address or shop location and
+// returns the location with the verification attached.
+func (h *AppSyncHandler) verifyLocation(ctx context.Context, location models.Location) (models.Location, *models.AddressVerification, error) {
+	if h.verifier == nil {
+		return nil, nil, fmt.Errorf("address verification is not configured")
+	}
+
+	switch loc := location.(type) {
+	case models.AddressLocation:
+		verification, err := h.verifyAddress(ctx, loc.Address)
+		if err != nil {
+			return nil, nil, err
+		}
+		loc.Address.Verification = verification
+		return loc, verification, nil
+	case models.ShopLocation:
+		verification, err := h.verifyAddress(ctx, loc.Shop.Address)
+		if err != nil {
+			return nil, nil, err
+		}
+		loc.Shop.Address.Verification = verification
+		return loc, verification, nil
+	default:
+		return nil, nil, fmt.Errorf("%s locations have no address to verify", location.GetLocationType())
+	}
+}
+
+// verifyAddress verifies a single address with the configured provider.
+func (h *AppSyncHandler) verifyAddress(ctx context.Context, address models.Address) (*models.AddressVerification, error) {
+	verification, err := h.verifier.Verify(ctx, address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify address: %w", err)
+	}
+	return verification, nil
+}
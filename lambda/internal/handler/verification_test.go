@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockVerifier is a mock implementation of the verify.Verifier interface.
+type mockVerifier struct {
+	mock.Mock
+}
+
+func (m *mockVerifier) Verify(ctx context.Context, address models.Address) (*models.AddressVerification, error) {
+	args := m.Called(ctx, address)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.AddressVerification), args.Error(1)
+}
+
+func TestAppSyncHandlerVerifyAddress(t *testing.T) {
+	ctx := context.Background()
+	address := models.Address{StreetAddress: "123 Main St", City: "Springfield", PostalCode: "62704", Country: "US"}
+	stored := models.AddressLocation{
+		LocationBase: models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeAddress},
+		Address:      address,
+	}
+	verification := &models.AddressVerification{
+		Status:              models.VerificationStatusCorrected,
+		Provider:            "lob",
+		StandardizedAddress: &models.Address{StreetAddress: "123 MAIN ST", City: "SPRINGFIELD", PostalCode: "62704-1234", Country: "US"},
+	}
+	event := AppSyncEvent{
+		Field:     "verifyAddress",
+		Arguments: json.RawMessage(`{"accountId": "acc-12345", "locationId": "loc-001"}`),
+	}
+
+	t.Run("Stores verification on the record", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		verifier := new(mockVerifier)
+		handler := NewAppSyncHandler(mockRepo, WithVerifier(verifier))
+
+		mockRepo.On("Get", ctx, "acc-12345", "loc-001").Return(withID("loc-001", stored), nil).Once()
+		verifier.On("Verify", ctx, address).Return(verification, nil).Once()
+		mockRepo.On("Update", ctx, mock.MatchedBy(func(l models.Location) bool {
+			loc, ok := l.(models.AddressLocation)
+			return ok && loc.Address.Verification == verification
+		}), "loc-001").Return(nil).Once()
+
+		result, err := handler.Handle(ctx, event)
+		require.NoError(t, err)
+		assert.Equal(t, verification, result)
+		mockRepo.AssertExpectations(t)
+		verifier.AssertExpectations(t)
+	})
+
+	t.Run("Coordinates locations have no address", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		handler := NewAppSyncHandler(mockRepo, WithVerifier(new(mockVerifier)))
+
+		mockRepo.On("Get", ctx, "acc-12345", "loc-001").Return(withID("loc-001", models.CoordinatesLocation{
+			LocationBase: models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeCoordinates},
+		}), nil).Once()
+
+		_, err := handler.Handle(ctx, event)
+		assert.EqualError(t, err, "coordinates locations have no address to verify")
+	})
+
+	t.Run("Provider failure leaves the record unchanged", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		verifier := new(mockVerifier)
+		handler := NewAppSyncHandler(mockRepo, WithVerifier(verifier))
+
+		mockRepo.On("Get", ctx, "acc-12345", "loc-001").Return(withID("loc-001", stored), nil).Once()
+		verifier.On("Verify", ctx, address).Return(nil, errors.New("timeout")).Once()
+
+		_, err := handler.Handle(ctx, event)
+		assert.EqualError(t, err, "failed to verify address: timeout")
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Not configured", func(t *testing.T) {
+		mockRepo := new(mockRepository)
+		handler := NewAppSyncHandler(mockRepo)
+
+		mockRepo.On("Get", ctx, "acc-12345", "loc-001").Return(withID("loc-001", stored), nil).Once()
+
+		_, err := handler.Handle(ctx, event)
+		assert.EqualError(t, err, "address verification is not configured")
+	})
+}
+
+func TestAppSyncHandlerCreateLocationWithVerification(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(mockRepository)
+	verifier := new(mockVerifier)
+	handler := NewAppSyncHandler(mockRepo, WithVerifier(verifier))
+
+	verification := &models.AddressVerification{Status: models.VerificationStatusVerified, Provider: "usps"}
+	verifier.On("Verify", ctx, mock.MatchedBy(func(a models.Address) bool {
+		// Client-supplied verification is dropped before verifying
+		return a.Verification == nil && a.StreetAddress == "123 Main St"
+	})).Return(verification, nil).Once()
+	mockRepo.On("Create", ctx, mock.MatchedBy(func(l models.Location) bool {
+		loc, ok := l.(models.AddressLocation)
+		return ok && loc.Address.Verification == verification
+	})).Return("loc-001", nil).Once()
+
+	result, err := handler.Handle(ctx, AppSyncEvent{
+		Field: "createLocation",
+		Arguments: json.RawMessage(`{
+			"verifyAddress": true,
+			"input": {
+				"accountId": "acc-12345",
+				"locationType": "address",
+				"address": {
+					"streetAddress": "123 Main St",
+					"city": "Springfield",
+					"postalCode": "62704",
+					"country": "US",
+					"verification": {"status": "verified", "provider": "forged"}
+				}
+			}
+		}`),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "loc-001", result.(map[string]interface{})["locationId"])
+	mockRepo.AssertExpectations(t)
+	verifier.AssertExpectations(t)
+}
@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+)
+
+// apiKeyHeader is the header AppSync forwards on requests authorized with an API key.
+const apiKeyHeader = "x-api-key"
+
+// isAPIKeyCaller reports whether AppSync authorized the request with an API
+// key rather than a user sign-in. Such requests carry the key's header and
+// no identity.
+func isAPIKeyCaller(event AppSyncEvent) bool {
+	return event.Request.Headers[apiKeyHeader] != "" &&
+		len(event.Identity.Claims) == 0 && event.Identity.UserArn == "" && event.Identity.Username == ""
+}
+
+// handleAPIKeyRequest serves API key callers. They reach only the public
+// store locator fields, which return only the fields each account made
+// public, whatever else the schema lets the key call.
+func (h *AppSyncHandler) handleAPIKeyRequest(ctx context.Context, event AppSyncEvent) (interface{}, error) {
+	switch event.Field {
+	case "publicNearbyShops":
+		return h.handlePublicNearbyShops(ctx, event.Arguments)
+	case "publicShop":
+		return h.handlePublicShop(ctx, event.Arguments)
+	default:
+		return nil, fmt.Errorf("%s is not available with API key authorization", event.Field)
+	}
+}
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/steverhoton/location-lambda/internal/repository"
+)
+
+// FindShopsByWebsiteArguments represents arguments for finding shops by website.
+type FindShopsByWebsiteArguments struct {
+	AccountID     string `json:"accountId"`
+	Website       string `json:"website"`
+	IncludeLinks  bool   `json:"includeLinks,omitempty"`
+	IncludeAssets bool   `json:"includeAssets,omitempty"`
+}
+
+// WithWebsiteFinder enables findShopsByWebsite.
+func WithWebsiteFinder(finder repository.WebsiteFinder) Option {
+	return func(h *AppSyncHandler) {
+		h.websites = finder
+	}
+}
+
+// handleFindShopsByWebsite returns an account's shops on the same website
+// host, in the same shape as getLocation.
+func (h *AppSyncHandler) handleFindShopsByWebsite(ctx context.Context, arguments json.RawMessage) ([]map[string]interface{}, error) {
+	var args FindShopsByWebsiteArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+
+	if args.AccountID == "" || args.Website == "" {
+		return nil, fmt.Errorf("accountId and website are required")
+	}
+	if h.websites == nil {
+		return nil, fmt.Errorf("website search is not configured")
+	}
+	if err := h.requireAssets(args.IncludeAssets); err != nil {
+		return nil, err
+	}
+
+	ctx, readInfo := repository.WithReadInfo(ctx)
+	envelopes, err := h.websites.FindByWebsite(ctx, args.AccountID, args.Website)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find shops: %w", err)
+	}
+
+	locations := make([]map[string]interface{}, 0, len(envelopes))
+	for _, envelope := range envelopes {
+		location, err := toLocationMap(envelope, args.IncludeLinks)
+		if err != nil {
+			return nil, err
+		}
+		if args.IncludeAssets {
+			if err := h.addAssets(ctx, envelope.Location, location); err != nil {
+				return nil, err
+			}
+		}
+		// Flag each shop when a scan stood in for the website index, as it may have missed some
+		if readInfo.IndexFallback {
+			location["indexFallback"] = true
+		}
+		locations = append(locations, location)
+	}
+	return locations, nil
+}
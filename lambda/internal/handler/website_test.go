@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockWebsiteFinder is a mock implementation of the repository.WebsiteFinder interface.
+type mockWebsiteFinder struct {
+	mock.Mock
+}
+
+func (m *mockWebsiteFinder) FindByWebsite(ctx context.Context, accountID, website string) ([]repository.LocationEnvelope, error) {
+	args := m.Called(ctx, accountID, website)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repository.LocationEnvelope), args.Error(1)
+}
+
+func TestAppSyncHandlerFindShopsByWebsite(t *testing.T) {
+	ctx := context.Background()
+	shop := models.ShopLocation{
+		LocationBase: models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeShop},
+		Shop: models.Shop{
+			Name:        "Main Street Store",
+			ContactID:   "contact-1",
+			Address:     models.Address{StreetAddress: "123 Main St", City: "Springfield", PostalCode: "62704", Country: "US"},
+			WebsiteURL:  "https://example.com/",
+			SocialLinks: map[string]string{"instagram": "https://instagram.com/mainstreet"},
+		},
+	}
+	event := AppSyncEvent{
+		Field:     "findShopsByWebsite",
+		Arguments: json.RawMessage(`{"accountId": "acc-12345", "website": "https://www.example.com/about"}`),
+	}
+
+	t.Run("Returns matching shops with their links", func(t *testing.T) {
+		finder := new(mockWebsiteFinder)
+		handler := NewAppSyncHandler(new(mockRepository), WithWebsiteFinder(finder))
+
+		finder.On("FindByWebsite", mock.Anything, "acc-12345", "https://www.example.com/about").Return([]repository.LocationEnvelope{
+			{LocationID: "loc-001", Location: shop},
+		}, nil).Once()
+
+		result, err := handler.Handle(ctx, event)
+		require.NoError(t, err)
+		locations := result.([]map[string]interface{})
+		require.Len(t, locations, 1)
+		assert.Equal(t, "loc-001", locations[0]["locationId"])
+		assert.Equal(t, "ShopLocation", locations[0]["__typename"])
+		shopFields := locations[0]["shop"].(map[string]interface{})
+		assert.Equal(t, "https://example.com/", shopFields["websiteUrl"])
+		assert.Equal(t, map[string]interface{}{"instagram": "https://instagram.com/mainstreet"}, shopFields["socialLinks"])
+		finder.AssertExpectations(t)
+	})
+
+	t.Run("Repository error", func(t *testing.T) {
+		finder := new(mockWebsiteFinder)
+		handler := NewAppSyncHandler(new(mockRepository), WithWebsiteFinder(finder))
+
+		finder.On("FindByWebsite", mock.Anything, "acc-12345", mock.Anything).Return(nil, errors.New("throttled")).Once()
+
+		_, err := handler.Handle(ctx, event)
+		assert.EqualError(t, err, "failed to find shops: throttled")
+	})
+
+	t.Run("Not configured", func(t *testing.T) {
+		_, err := NewAppSyncHandler(new(mockRepository)).Handle(ctx, event)
+		assert.EqualError(t, err, "website search is not configured")
+	})
+}
@@ -0,0 +1,223 @@
+// Package inference converts free-form legacy location data into typed location payloads.
+package inference
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/steverhoton/location-lambda/internal/addressparser"
+	"github.com/steverhoton/location-lambda/internal/geocode"
+	"github.com/steverhoton/location-lambda/internal/models"
+)
+
+// Result is an inferred location ready to pass to createLocation.
+type Result struct {
+	LocationType models.LocationType    `json:"locationType"`
+	Payload      map[string]interface{} `json:"payload"`
+	Confidence   float64                `json:"confidence"` // 0-1 heuristic confidence in the inferred type and fields
+	Warnings     []string               `json:"warnings,omitempty"`
+}
+
+// Inferrer infers typed locations from unstructured input.
+type Inferrer struct {
+	geocoder geocode.Geocoder
+}
+
+// NewInferrer creates an inferrer. The geocoder is optional; without one,
+// incomplete addresses cannot be resolved.
+func NewInferrer(geocoder geocode.Geocoder) *Inferrer {
+	return &Inferrer{geocoder: geocoder}
+}
+
+// coordinatePairPattern requires decimals so street numbers and postal codes are not mistaken for coordinates.
+var coordinatePairPattern = regexp.MustCompile(`\(?\s*(-?\d{1,3}\.\d+)\s*[,;\s]\s*(-?\d{1,3}\.\d+)\s*\)?`)
+
+// Infer parses input, which may be an address string, a latitude/longitude
+// pair, a mix of both, or a JSON object with common field names, into the
+// best-fit typed location for accountID.
+func (i *Inferrer) Infer(ctx context.Context, accountID, input string) (*Result, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return nil, errors.New("input is required")
+	}
+
+	text, coords, warnings := extract(input)
+	parsed := addressparser.Parse(text, "")
+	address, missing := parsed.Address, parsed.Missing
+
+	switch {
+	case coords != nil && text == "":
+		return newResult(accountID, models.CoordinatesLocation{
+			Coordinates: *coords,
+		}, 0.95-0.15*float64(len(warnings)), warnings)
+	case len(missing) == 0:
+		confidence := 0.85
+		var ext map[string]interface{}
+		if coords != nil {
+			// Keep the source coordinates rather than silently dropping them
+			ext = map[string]interface{}{"coordinates": map[string]interface{}{
+				"latitude":  coords.Latitude,
+				"longitude": coords.Longitude,
+			}}
+			confidence = 0.9
+		}
+		return newResult(accountID, models.AddressLocation{
+			LocationBase: models.LocationBase{ExtendedAttributes: ext},
+			Address:      address,
+		}, confidence*parsed.Confidence, warnings)
+	case coords != nil:
+		warnings = append(warnings, fmt.Sprintf("address is incomplete (missing %s); using coordinates", strings.Join(missing, ", ")))
+		return newResult(accountID, models.CoordinatesLocation{
+			LocationBase: models.LocationBase{ExtendedAttributes: map[string]interface{}{"sourceText": text}},
+			Coordinates:  *coords,
+		}, 0.75, warnings)
+	case i.geocoder != nil:
+		match, err := i.geocoder.Geocode(ctx, text)
+		if err != nil {
+			return nil, fmt.Errorf("failed to geocode input: %w", err)
+		}
+		warnings = append(warnings, fmt.Sprintf("address is incomplete (missing %s); coordinates were geocoded", strings.Join(missing, ", ")))
+		return newResult(accountID, models.CoordinatesLocation{
+			LocationBase: models.LocationBase{ExtendedAttributes: map[string]interface{}{
+				"sourceText":    text,
+				"geocodedLabel": match.Label,
+			}},
+			Coordinates: match.Coordinates,
+		}, 0.8*match.Relevance, warnings)
+	default:
+		return nil, fmt.Errorf("could not infer location: address is missing %s", strings.Join(missing, ", "))
+	}
+}
+
+// newResult validates an inferred location and converts it to a create payload.
+func newResult(accountID string, location models.Location, confidence float64, warnings []string) (*Result, error) {
+	switch loc := location.(type) {
+	case models.AddressLocation:
+		loc.AccountID = accountID
+		loc.LocationType = models.LocationTypeAddress
+		location = loc
+	case models.CoordinatesLocation:
+		loc.AccountID = accountID
+		loc.LocationType = models.LocationTypeCoordinates
+		location = loc
+	}
+
+	if err := location.Validate(); err != nil {
+		return nil, fmt.Errorf("inferred location is invalid: %w", err)
+	}
+
+	data, err := json.Marshal(location)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal location: %w", err)
+	}
+	var payload map[string]interface{}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal location: %w", err)
+	}
+
+	if confidence < 0 {
+		confidence = 0
+	}
+	return &Result{
+		LocationType: location.GetLocationType(),
+		Payload:      payload,
+		Confidence:   confidence,
+		Warnings:     warnings,
+	}, nil
+}
+
+// extract separates a coordinate pair from the remaining address text. JSON
+// objects are flattened to text using common legacy field names.
+func extract(input string) (string, *models.Coordinates, []string) {
+	if strings.HasPrefix(input, "{") {
+		var obj map[string]interface{}
+		if err := json.Unmarshal([]byte(input), &obj); err == nil {
+			return extractObject(obj)
+		}
+	}
+
+	match := coordinatePairPattern.FindStringSubmatchIndex(input)
+	if match == nil {
+		return input, nil, nil
+	}
+	lat, _ := strconv.ParseFloat(input[match[2]:match[3]], 64)
+	lon, _ := strconv.ParseFloat(input[match[4]:match[5]], 64)
+	coords, warnings := orderCoordinates(lat, lon)
+	if coords == nil {
+		return input, nil, warnings
+	}
+
+	text := strings.TrimSpace(input[:match[0]] + " " + input[match[1]:])
+	text = strings.Trim(text, " ,;")
+	return text, coords, warnings
+}
+
+// extractObject reads coordinates and address text from a JSON object.
+func extractObject(obj map[string]interface{}) (string, *models.Coordinates, []string) {
+	lat, hasLat := numberField(obj, "latitude", "lat")
+	lon, hasLon := numberField(obj, "longitude", "lon", "lng", "long")
+
+	var parts []string
+	for _, keys := range [][]string{
+		{"address", "street", "streetAddress", "address1"},
+		{"streetAddress2", "address2", "unit"},
+		{"city", "town"},
+		{"state", "stateProvince", "province", "region"},
+		{"postalCode", "zip", "zipCode", "postcode"},
+		{"country", "countryCode"},
+	} {
+		if value := stringField(obj, keys...); value != "" {
+			parts = append(parts, value)
+		}
+	}
+	text := strings.Join(parts, ", ")
+
+	if !hasLat || !hasLon {
+		return text, nil, nil
+	}
+	coords, warnings := orderCoordinates(lat, lon)
+	return text, coords, warnings
+}
+
+// orderCoordinates validates a pair, swapping it when it is clearly lon/lat.
+func orderCoordinates(lat, lon float64) (*models.Coordinates, []string) {
+	coords := models.Coordinates{Latitude: lat, Longitude: lon}
+	if coords.Validate() == nil {
+		return &coords, nil
+	}
+	swapped := models.Coordinates{Latitude: lon, Longitude: lat}
+	if swapped.Validate() == nil {
+		return &swapped, []string{"coordinates appeared to be longitude/latitude and were swapped"}
+	}
+	return nil, []string{"coordinate pair is out of range and was ignored"}
+}
+
+// numberField returns the first numeric field present under any of keys.
+func numberField(obj map[string]interface{}, keys ...string) (float64, bool) {
+	for _, key := range keys {
+		switch v := obj[key].(type) {
+		case float64:
+			return v, true
+		case string:
+			if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+				return f, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// stringField returns the first non-empty string field present under any of keys.
+func stringField(obj map[string]interface{}, keys ...string) string {
+	for _, key := range keys {
+		if v, ok := obj[key].(string); ok && strings.TrimSpace(v) != "" {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
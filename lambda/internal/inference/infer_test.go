@@ -0,0 +1,143 @@
+package inference
+
+import (
+	"context"
+	"testing"
+
+	"github.com/steverhoton/location-lambda/internal/geocode"
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubGeocoder returns a fixed result for any query.
+type stubGeocoder struct {
+	result *geocode.Result
+	err    error
+	query  string
+}
+
+func (s *stubGeocoder) Name() string {
+	return "stub"
+}
+
+func (s *stubGeocoder) Geocode(ctx context.Context, query string) (*geocode.Result, error) {
+	s.query = query
+	return s.result, s.err
+}
+
+func TestInfer(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		expectedType  models.LocationType
+		expectedError string
+		check         func(t *testing.T, result *Result)
+	}{
+		{
+			name:         "Coordinate pair",
+			input:        "39.7817, -89.6501",
+			expectedType: models.LocationTypeCoordinates,
+			check: func(t *testing.T, result *Result) {
+				coords := result.Payload["coordinates"].(map[string]interface{})
+				assert.Equal(t, 39.7817, coords["latitude"])
+				assert.Equal(t, -89.6501, coords["longitude"])
+				assert.Empty(t, result.Warnings)
+			},
+		},
+		{
+			name:         "Swapped coordinate pair",
+			input:        "-122.0312 37.3318",
+			expectedType: models.LocationTypeCoordinates,
+			check: func(t *testing.T, result *Result) {
+				coords := result.Payload["coordinates"].(map[string]interface{})
+				assert.Equal(t, 37.3318, coords["latitude"])
+				assert.Len(t, result.Warnings, 1)
+			},
+		},
+		{
+			name:         "US address",
+			input:        "123 Main St, Apt 4, Springfield, IL 62704",
+			expectedType: models.LocationTypeAddress,
+			check: func(t *testing.T, result *Result) {
+				address := result.Payload["address"].(map[string]interface{})
+				assert.Equal(t, "123 Main St", address["streetAddress"])
+				assert.Equal(t, "Apt 4", address["streetAddress2"])
+				assert.Equal(t, "Springfield", address["city"])
+				assert.Equal(t, "IL", address["stateProvince"])
+				assert.Equal(t, "62704", address["postalCode"])
+				assert.Equal(t, "US", address["country"])
+			},
+		},
+		{
+			name:         "Address with coordinates",
+			input:        "10 Downing St, London, SW1A 2AA, GB (51.5034, -0.1276)",
+			expectedType: models.LocationTypeAddress,
+			check: func(t *testing.T, result *Result) {
+				assert.Equal(t, "SW1A 2AA", result.Payload["address"].(map[string]interface{})["postalCode"])
+				assert.Contains(t, result.Payload["extendedAttributes"], "coordinates")
+			},
+		},
+		{
+			name:         "Incomplete address with coordinates",
+			input:        "Warehouse 7; 40.7128, -74.0060",
+			expectedType: models.LocationTypeCoordinates,
+			check: func(t *testing.T, result *Result) {
+				assert.Equal(t, "Warehouse 7", result.Payload["extendedAttributes"].(map[string]interface{})["sourceText"])
+				assert.Less(t, result.Confidence, 0.8)
+			},
+		},
+		{
+			name:         "JSON object",
+			input:        `{"street": "1 Infinite Loop", "city": "Cupertino", "state": "CA", "zip": "95014", "lat": "37.3318", "lng": -122.0312}`,
+			expectedType: models.LocationTypeAddress,
+			check: func(t *testing.T, result *Result) {
+				address := result.Payload["address"].(map[string]interface{})
+				assert.Equal(t, "Cupertino", address["city"])
+				assert.Equal(t, "US", address["country"])
+			},
+		},
+		{
+			name:          "Incomplete address without geocoder",
+			input:         "Springfield",
+			expectedError: "could not infer location: address is missing streetAddress, postalCode, country",
+		},
+		{
+			name:          "Empty input",
+			input:         "  ",
+			expectedError: "input is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewInferrer(nil).Infer(context.Background(), "acc-12345", tt.input)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedType, result.LocationType)
+			assert.Equal(t, "acc-12345", result.Payload["accountId"])
+			assert.Equal(t, string(tt.expectedType), result.Payload["locationType"])
+			tt.check(t, result)
+		})
+	}
+}
+
+func TestInferWithGeocoder(t *testing.T) {
+	geocoder := &stubGeocoder{result: &geocode.Result{
+		Coordinates: models.Coordinates{Latitude: 39.7817, Longitude: -89.6501},
+		Label:       "Springfield, IL, USA",
+		Relevance:   1,
+	}}
+
+	result, err := NewInferrer(geocoder).Infer(context.Background(), "acc-12345", "Springfield")
+	require.NoError(t, err)
+
+	assert.Equal(t, "Springfield", geocoder.query)
+	assert.Equal(t, models.LocationTypeCoordinates, result.LocationType)
+	assert.Equal(t, "Springfield, IL, USA", result.Payload["extendedAttributes"].(map[string]interface{})["geocodedLabel"])
+	assert.InDelta(t, 0.8, result.Confidence, 0.001)
+	assert.Len(t, result.Warnings, 1)
+}
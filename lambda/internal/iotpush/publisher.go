@@ -0,0 +1,166 @@
+// Package iotpush republishes location changes from the table's DynamoDB
+// stream to per-account AWS IoT Core MQTT topics, for devices such as kiosks
+// that can't hold a GraphQL subscription.
+package iotpush
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/steverhoton/location-lambda/internal/changeexport"
+	"github.com/steverhoton/location-lambda/internal/models"
+)
+
+const (
+	// DefaultTopicPrefix is the prefix of account topics when none is configured.
+	DefaultTopicPrefix = "locations/"
+	// maxPayloadBytes is the largest message IoT Core accepts.
+	maxPayloadBytes = 128 * 1024
+)
+
+// HTTPClient is the subset of http.Client used by Publisher.
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Message is a change as published to its account's topic.
+type Message struct {
+	// EventID is the stream record's ID; a retried batch publishes its
+	// changes again, so devices can use it to drop repeats
+	EventID           string    `json:"eventId"`
+	EventName         string    `json:"eventName"` // INSERT, MODIFY, or REMOVE
+	AccountID         string    `json:"accountId"`
+	LocationID        string    `json:"locationId"`
+	ChangedAt         time.Time `json:"changedAt"`
+	ChangedAttributes []string  `json:"changedAttributes,omitempty"`
+	// Location is the location after the change, left out for REMOVE
+	Location models.Location `json:"location,omitempty"`
+	// LocationOmitted reports that Location was left out to fit IoT Core's
+	// message size limit, so the device must fetch it with getLocation
+	LocationOmitted bool `json:"locationOmitted,omitempty"`
+}
+
+// Publisher publishes changes through the IoT Core data plane's HTTPS
+// publish API, at QoS 1.
+type Publisher struct {
+	httpClient  HTTPClient
+	signer      *v4.Signer
+	credentials aws.CredentialsProvider
+	region      string
+	endpoint    string
+	topicPrefix string
+}
+
+// NewPublisher creates a publisher to the account's IoT data endpoint, such
+// as abc123-ats.iot.us-east-1.amazonaws.com, whose topics start with
+// topicPrefix.
+func NewPublisher(cfg aws.Config, endpoint, topicPrefix string) *Publisher {
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+	return &Publisher{
+		httpClient:  http.DefaultClient,
+		signer:      v4.NewSigner(),
+		credentials: cfg.Credentials,
+		region:      cfg.Region,
+		endpoint:    strings.TrimSuffix(endpoint, "/"),
+		topicPrefix: topicPrefix,
+	}
+}
+
+// Topic returns the topic an account's changes are published to.
+func (p *Publisher) Topic(accountID string) string {
+	return p.topicPrefix + accountID + "/changes"
+}
+
+// Publish publishes each change to its account's topic in stream order,
+// returning how many were published. It stops at the first failure, so the
+// batch can be retried.
+func (p *Publisher) Publish(ctx context.Context, changes []changeexport.Change) (int, error) {
+	for i, change := range changes {
+		payload, err := encode(change)
+		if err != nil {
+			return i, err
+		}
+		if err := p.publish(ctx, p.Topic(change.AccountID), payload); err != nil {
+			return i, fmt.Errorf("failed to publish change %s: %w", change.EventID, err)
+		}
+	}
+	return len(changes), nil
+}
+
+// encode builds a change's message, leaving the location out when the
+// message would be too large with it.
+func encode(change changeexport.Change) ([]byte, error) {
+	message := Message{
+		EventID:           change.EventID,
+		EventName:         change.EventName,
+		AccountID:         change.AccountID,
+		LocationID:        change.LocationID,
+		ChangedAt:         change.ChangedAt,
+		ChangedAttributes: change.ChangedAttributes,
+	}
+	if change.NewImage != nil {
+		envelope, err := change.Location()
+		if err != nil {
+			return nil, err
+		}
+		message.Location = envelope.Location
+	}
+
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal change %s: %w", change.EventID, err)
+	}
+	if len(payload) <= maxPayloadBytes {
+		return payload, nil
+	}
+	message.Location = nil
+	message.LocationOmitted = true
+	if payload, err = json.Marshal(message); err != nil {
+		return nil, fmt.Errorf("failed to marshal change %s: %w", change.EventID, err)
+	}
+	return payload, nil
+}
+
+// publish sends a SigV4-signed publish request for one message.
+func (p *Publisher) publish(ctx context.Context, topic string, payload []byte) error {
+	endpoint := fmt.Sprintf("%s/topics/%s?qos=1", p.endpoint, url.PathEscape(topic))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to build publish request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	creds, err := p.credentials.Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve credentials: %w", err)
+	}
+	hash := sha256.Sum256(payload)
+	if err := p.signer.SignHTTP(ctx, creds, req, hex.EncodeToString(hash[:]), "iotdata", p.region, time.Now()); err != nil {
+		return fmt.Errorf("failed to sign publish request: %w", err)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("publish to %s failed with status %d: %s", topic, resp.StatusCode, body)
+	}
+	return nil
+}
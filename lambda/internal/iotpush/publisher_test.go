@@ -0,0 +1,131 @@
+package iotpush
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/steverhoton/location-lambda/internal/changeexport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// streamEvent is a DynamoDB stream batch as Lambda delivers it.
+const streamEvent = `{"Records": [
+	{
+		"eventID": "evt-1", "eventName": "INSERT", "eventSource": "aws:dynamodb",
+		"dynamodb": {
+			"ApproximateCreationDateTime": 1717243200,
+			"Keys": {"PK": {"S": "acc-12345"}, "SK": {"S": "loc-001"}},
+			"NewImage": {
+				"PK": {"S": "acc-12345"}, "SK": {"S": "loc-001"}, "accountId": {"S": "acc-12345"}, "locationType": {"S": "coordinates"},
+				"coordinates": {"M": {"latitude": {"N": "40.7128"}, "longitude": {"N": "-74.006"}}}
+			},
+			"SequenceNumber": "100"
+		}
+	},
+	{
+		"eventID": "evt-2", "eventName": "REMOVE", "eventSource": "aws:dynamodb",
+		"dynamodb": {
+			"ApproximateCreationDateTime": 1717243260,
+			"Keys": {"PK": {"S": "acc-67890"}, "SK": {"S": "loc-002"}},
+			"OldImage": {"PK": {"S": "acc-67890"}, "SK": {"S": "loc-002"}, "locationType": {"S": "shop"}},
+			"SequenceNumber": "101"
+		}
+	}
+]}`
+
+func newTestPublisher(t *testing.T, handler http.HandlerFunc) *Publisher {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return NewPublisher(aws.Config{
+		Region:      "us-west-2",
+		Credentials: credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""),
+	}, server.URL, DefaultTopicPrefix)
+}
+
+func testChanges(t *testing.T) []changeexport.Change {
+	t.Helper()
+	var event events.DynamoDBEvent
+	require.NoError(t, json.Unmarshal([]byte(streamEvent), &event))
+	changes, err := changeexport.Changes(event)
+	require.NoError(t, err)
+	return changes
+}
+
+func TestNewPublisher(t *testing.T) {
+	p := NewPublisher(aws.Config{Region: "us-east-1"}, "abc123-ats.iot.us-east-1.amazonaws.com", "fleet/")
+	assert.Equal(t, "https://abc123-ats.iot.us-east-1.amazonaws.com", p.endpoint)
+	assert.Equal(t, "fleet/acc-12345/changes", p.Topic("acc-12345"))
+}
+
+func TestPublisherPublish(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Publishes each change to its account's topic", func(t *testing.T) {
+		var topics []string
+		var messages []map[string]interface{}
+		p := newTestPublisher(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, http.MethodPost, r.Method)
+			assert.True(t, strings.Contains(r.Header.Get("Authorization"), "/iotdata/aws4_request"))
+			assert.Equal(t, "1", r.URL.Query().Get("qos"))
+			topics = append(topics, strings.TrimPrefix(r.URL.Path, "/topics/"))
+			var message map[string]interface{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&message))
+			messages = append(messages, message)
+		})
+
+		published, err := p.Publish(ctx, testChanges(t))
+		require.NoError(t, err)
+		assert.Equal(t, 2, published)
+		assert.Equal(t, []string{"locations/acc-12345/changes", "locations/acc-67890/changes"}, topics)
+
+		assert.Equal(t, "evt-1", messages[0]["eventId"])
+		assert.Equal(t, "INSERT", messages[0]["eventName"])
+		assert.Equal(t, "loc-001", messages[0]["locationId"])
+		location := messages[0]["location"].(map[string]interface{})
+		assert.Equal(t, "coordinates", location["locationType"])
+		assert.Equal(t, map[string]interface{}{"latitude": 40.7128, "longitude": -74.006}, location["coordinates"])
+
+		assert.Equal(t, "REMOVE", messages[1]["eventName"])
+		assert.NotContains(t, messages[1], "location")
+		assert.NotContains(t, messages[1], "locationOmitted")
+	})
+
+	t.Run("Stops at the first failure", func(t *testing.T) {
+		p := newTestPublisher(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = fmt.Fprint(w, `{"message":"not authorized"}`)
+		})
+
+		published, err := p.Publish(ctx, testChanges(t))
+		assert.Equal(t, 0, published)
+		assert.EqualError(t, err, `failed to publish change evt-1: publish to locations/acc-12345/changes failed with status 403: {"message":"not authorized"}`)
+	})
+}
+
+func TestEncodeOmitsOversizedLocations(t *testing.T) {
+	var event events.DynamoDBEvent
+	require.NoError(t, json.Unmarshal([]byte(streamEvent), &event))
+	event.Records[0].Change.NewImage["externalId"] = events.NewStringAttribute(strings.Repeat("x", maxPayloadBytes))
+	changes, err := changeexport.Changes(event)
+	require.NoError(t, err)
+
+	payload, err := encode(changes[0])
+	require.NoError(t, err)
+	assert.LessOrEqual(t, len(payload), maxPayloadBytes)
+	var message map[string]interface{}
+	require.NoError(t, json.Unmarshal(payload, &message))
+	assert.Equal(t, true, message["locationOmitted"])
+	assert.NotContains(t, message, "location")
+	assert.Equal(t, "loc-001", message["locationId"])
+}
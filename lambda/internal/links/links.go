@@ -0,0 +1,91 @@
+// Package links builds map deep links for locations, so clients don't each
+// re-implement the URL formats.
+package links
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+)
+
+// Links are URLs that open a location in a maps app.
+type Links struct {
+	GeoURI     string `json:"geoUri"`     // RFC 5870 geo: URI, opened by the device's default maps app
+	GoogleMaps string `json:"googleMaps"` // Google Maps search URL
+	AppleMaps  string `json:"appleMaps"`  // Apple Maps URL
+}
+
+// For returns links to location. Coordinates locations, and events with
+// coordinates, link to their exact point; address and shop locations, and
+// other events, link to a search for their address.
+// It returns nil for unknown location types.
+func For(location models.Location) *Links {
+	switch loc := location.(type) {
+	case models.CoordinatesLocation:
+		return forCoordinates(loc.Coordinates)
+	case models.AddressLocation:
+		return forQuery(addressText(loc.Address), "")
+	case models.ShopLocation:
+		return forQuery(addressText(loc.Shop.Address), loc.Shop.Name)
+	case models.EventLocation:
+		if loc.Coordinates != nil {
+			return forCoordinates(*loc.Coordinates)
+		}
+		if loc.Address != nil {
+			return forQuery(addressText(*loc.Address), "")
+		}
+	}
+	return nil
+}
+
+// forCoordinates links to a point.
+func forCoordinates(c models.Coordinates) *Links {
+	point := formatFloat(c.Latitude) + "," + formatFloat(c.Longitude)
+	return &Links{
+		GeoURI:     "geo:" + point,
+		GoogleMaps: "https://www.google.com/maps/search/?" + url.Values{"api": {"1"}, "query": {point}}.Encode(),
+		AppleMaps:  "https://maps.apple.com/?" + url.Values{"ll": {point}, "q": {point}}.Encode(),
+	}
+}
+
+// forQuery links to a search for address, labelled with name when set.
+func forQuery(address, name string) *Links {
+	text := address
+	if name != "" {
+		text = name + ", " + address
+	}
+
+	apple := url.Values{"address": {address}}
+	if name != "" {
+		apple.Set("q", name)
+	}
+	return &Links{
+		GeoURI:     "geo:0,0?q=" + url.QueryEscape(text),
+		GoogleMaps: "https://www.google.com/maps/search/?" + url.Values{"api": {"1"}, "query": {text}}.Encode(),
+		AppleMaps:  "https://maps.apple.com/?" + apple.Encode(),
+	}
+}
+
+// addressText formats an address as a single line.
+func addressText(a models.Address) string {
+	parts := make([]string, 0, 5)
+	for _, part := range []string{
+		a.StreetAddress,
+		a.StreetAddress2,
+		a.City,
+		strings.TrimSpace(a.StateProvince + " " + a.PostalCode),
+		a.Country,
+	} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatFloat formats f without trailing zeros.
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
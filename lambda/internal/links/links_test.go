@@ -0,0 +1,104 @@
+package links
+
+import (
+	"testing"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFor(t *testing.T) {
+	address := models.Address{
+		StreetAddress: "1 Infinite Loop",
+		City:          "Cupertino",
+		StateProvince: "CA",
+		PostalCode:    "95014",
+		Country:       "US",
+	}
+
+	tests := []struct {
+		name     string
+		location models.Location
+		want     *Links
+	}{
+		{
+			name: "Coordinates",
+			location: models.CoordinatesLocation{
+				LocationBase: models.LocationBase{LocationType: models.LocationTypeCoordinates},
+				Coordinates:  models.Coordinates{Latitude: 40.7128, Longitude: -74.006},
+			},
+			want: &Links{
+				GeoURI:     "geo:40.7128,-74.006",
+				GoogleMaps: "https://www.google.com/maps/search/?api=1&query=40.7128%2C-74.006",
+				AppleMaps:  "https://maps.apple.com/?ll=40.7128%2C-74.006&q=40.7128%2C-74.006",
+			},
+		},
+		{
+			name: "Address",
+			location: models.AddressLocation{
+				LocationBase: models.LocationBase{LocationType: models.LocationTypeAddress},
+				Address:      address,
+			},
+			want: &Links{
+				GeoURI:     "geo:0,0?q=1+Infinite+Loop%2C+Cupertino%2C+CA+95014%2C+US",
+				GoogleMaps: "https://www.google.com/maps/search/?api=1&query=1+Infinite+Loop%2C+Cupertino%2C+CA+95014%2C+US",
+				AppleMaps:  "https://maps.apple.com/?address=1+Infinite+Loop%2C+Cupertino%2C+CA+95014%2C+US",
+			},
+		},
+		{
+			name: "Shop",
+			location: models.ShopLocation{
+				LocationBase: models.LocationBase{LocationType: models.LocationTypeShop},
+				Shop:         models.Shop{Name: "Apple Park", ContactID: "contact-1", Address: address},
+			},
+			want: &Links{
+				GeoURI:     "geo:0,0?q=Apple+Park%2C+1+Infinite+Loop%2C+Cupertino%2C+CA+95014%2C+US",
+				GoogleMaps: "https://www.google.com/maps/search/?api=1&query=Apple+Park%2C+1+Infinite+Loop%2C+Cupertino%2C+CA+95014%2C+US",
+				AppleMaps:  "https://maps.apple.com/?address=1+Infinite+Loop%2C+Cupertino%2C+CA+95014%2C+US&q=Apple+Park",
+			},
+		},
+		{
+			name: "Event with coordinates",
+			location: models.EventLocation{
+				LocationBase: models.LocationBase{LocationType: models.LocationTypeEvent},
+				Address:      &address,
+				Coordinates:  &models.Coordinates{Latitude: 40.7128, Longitude: -74.006},
+			},
+			want: &Links{
+				GeoURI:     "geo:40.7128,-74.006",
+				GoogleMaps: "https://www.google.com/maps/search/?api=1&query=40.7128%2C-74.006",
+				AppleMaps:  "https://maps.apple.com/?ll=40.7128%2C-74.006&q=40.7128%2C-74.006",
+			},
+		},
+		{
+			name: "Event at an address",
+			location: models.EventLocation{
+				LocationBase: models.LocationBase{LocationType: models.LocationTypeEvent},
+				Address:      &address,
+			},
+			want: &Links{
+				GeoURI:     "geo:0,0?q=1+Infinite+Loop%2C+Cupertino%2C+CA+95014%2C+US",
+				GoogleMaps: "https://www.google.com/maps/search/?api=1&query=1+Infinite+Loop%2C+Cupertino%2C+CA+95014%2C+US",
+				AppleMaps:  "https://maps.apple.com/?address=1+Infinite+Loop%2C+Cupertino%2C+CA+95014%2C+US",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, For(tt.location))
+		})
+	}
+}
+
+func TestAddressText(t *testing.T) {
+	t.Run("Skips empty parts", func(t *testing.T) {
+		got := addressText(models.Address{StreetAddress: "10 Downing St", City: "London", PostalCode: "SW1A 2AA", Country: "GB"})
+		assert.Equal(t, "10 Downing St, London, SW1A 2AA, GB", got)
+	})
+
+	t.Run("Includes the second street line", func(t *testing.T) {
+		got := addressText(models.Address{StreetAddress: "123 Main St", StreetAddress2: "Suite 4", City: "Springfield", StateProvince: "IL", PostalCode: "62704", Country: "US"})
+		assert.Equal(t, "123 Main St, Suite 4, Springfield, IL 62704, US", got)
+	})
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+)
+
+// AddressKind is the role of one of a location's typed addresses.
+type AddressKind string
+
+const (
+	// AddressKindPhysical is where the location is.
+	AddressKindPhysical AddressKind = "physical"
+	// AddressKindBilling is where invoices are sent.
+	AddressKindBilling AddressKind = "billing"
+	// AddressKindShipping is where goods are delivered.
+	AddressKindShipping AddressKind = "shipping"
+)
+
+// isAddressKind reports whether kind is a known AddressKind.
+func isAddressKind(kind AddressKind) bool {
+	switch kind {
+	case AddressKindPhysical, AddressKindBilling, AddressKindShipping:
+		return true
+	}
+	return false
+}
+
+// validateAddresses validates the typed addresses. hasPrimary is set for
+// locations with an address of their own, which is their physical address,
+// so addresses may not repeat it.
+func (l LocationBase) validateAddresses(hasPrimary bool) error {
+	kinds := make([]string, 0, len(l.Addresses))
+	for kind := range l.Addresses {
+		kinds = append(kinds, string(kind))
+	}
+	// Sorted so the same input always reports its errors in the same order
+	sort.Strings(kinds)
+
+	var f fieldErrors
+	for _, kind := range kinds {
+		switch {
+		case !isAddressKind(AddressKind(kind)):
+			f.addMessage("addresses", fmt.Sprintf("addresses: %q is not an address kind; use %s, %s, or %s", kind, AddressKindPhysical, AddressKindBilling, AddressKindShipping))
+		case hasPrimary && AddressKind(kind) == AddressKindPhysical:
+			f.addMessage("addresses", "addresses: the location's address is its physical address; set address instead of addresses.physical")
+		default:
+			f.add("addresses."+kind, l.Addresses[AddressKind(kind)].Validate())
+		}
+	}
+	return f.err()
+}
@@ -0,0 +1,106 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocationAddressesValidation(t *testing.T) {
+	billing := Address{StreetAddress: "1 Finance Way", City: "Springfield", PostalCode: "62701", Country: "US"}
+	physical := Address{StreetAddress: "123 Main St", City: "Springfield", PostalCode: "62704", Country: "US"}
+
+	tests := []struct {
+		name     string
+		location Location
+		errMsg   string
+	}{
+		{
+			name: "Billing and shipping beside an address",
+			location: AddressLocation{
+				LocationBase: LocationBase{AccountID: "acc-12345", LocationType: LocationTypeAddress, Addresses: map[AddressKind]Address{
+					AddressKindBilling:  billing,
+					AddressKindShipping: billing,
+				}},
+				Address: physical,
+			},
+		},
+		{
+			name: "Physical address of a coordinates location",
+			location: CoordinatesLocation{
+				LocationBase: LocationBase{AccountID: "acc-12345", LocationType: LocationTypeCoordinates, Addresses: map[AddressKind]Address{
+					AddressKindPhysical: physical,
+				}},
+				Coordinates: Coordinates{Latitude: 39.7817, Longitude: -89.6501},
+			},
+		},
+		{
+			name: "Physical address repeating an address location's own",
+			location: AddressLocation{
+				LocationBase: LocationBase{AccountID: "acc-12345", LocationType: LocationTypeAddress, Addresses: map[AddressKind]Address{
+					AddressKindPhysical: physical,
+				}},
+				Address: physical,
+			},
+			errMsg: "addresses: the location's address is its physical address; set address instead of addresses.physical",
+		},
+		{
+			name: "Physical address repeating an event's own",
+			location: EventLocation{
+				LocationBase: LocationBase{AccountID: "acc-12345", LocationType: LocationTypeEvent, Addresses: map[AddressKind]Address{
+					AddressKindPhysical: physical,
+				}},
+				Address:  &physical,
+				StartsAt: time.Date(2024, time.June, 3, 15, 0, 0, 0, time.UTC),
+				EndsAt:   time.Date(2024, time.June, 3, 23, 0, 0, 0, time.UTC),
+			},
+			errMsg: "set address instead of addresses.physical",
+		},
+		{
+			name: "Unknown kind",
+			location: CoordinatesLocation{
+				LocationBase: LocationBase{AccountID: "acc-12345", LocationType: LocationTypeCoordinates, Addresses: map[AddressKind]Address{
+					"home": physical,
+				}},
+				Coordinates: Coordinates{Latitude: 39.7817, Longitude: -89.6501},
+			},
+			errMsg: `addresses: "home" is not an address kind; use physical, billing, or shipping`,
+		},
+		{
+			name: "Invalid typed address",
+			location: ShopLocation{
+				LocationBase: LocationBase{AccountID: "acc-12345", LocationType: LocationTypeShop, Addresses: map[AddressKind]Address{
+					AddressKindBilling: {City: "Springfield"},
+				}},
+				Shop: Shop{Name: "Corner Store", ContactID: "contact-001", Address: physical},
+			},
+			errMsg: "addresses.billing: streetAddress is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.location.Validate()
+			if tt.errMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestLocationAddressesJSON(t *testing.T) {
+	location, err := UnmarshalLocation([]byte(`{
+		"accountId": "acc-12345",
+		"locationType": "address",
+		"address": {"streetAddress": "123 Main St", "city": "Springfield", "postalCode": "62704", "country": "US"},
+		"addresses": {"billing": {"streetAddress": "1 Finance Way", "city": "Springfield", "postalCode": "62701", "country": "US"}}
+	}`))
+	require.NoError(t, err)
+	require.NoError(t, location.Validate())
+	assert.Equal(t, "1 Finance Way", location.GetAddresses()[AddressKindBilling].StreetAddress)
+}
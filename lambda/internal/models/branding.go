@@ -0,0 +1,60 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// MaxShopPhotos is the most photos a shop can reference.
+	MaxShopPhotos = 10
+	// maxAssetKeyLength is the longest S3 object key.
+	maxAssetKeyLength = 1024
+)
+
+// brandColorPattern matches a six digit hex color such as #1A2B3C.
+var brandColorPattern = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+// ValidateAssetKey checks that key can name an object in the asset bucket. Keys
+// are relative, so full URLs, leading slashes, and ".." segments are rejected.
+func ValidateAssetKey(key string) error {
+	switch {
+	case strings.TrimSpace(key) == "":
+		return fmt.Errorf("asset key must not be blank")
+	case len(key) > maxAssetKeyLength:
+		return fmt.Errorf("asset key must be at most %d bytes", maxAssetKeyLength)
+	case strings.HasPrefix(key, "/") || strings.Contains(key, "://"):
+		return fmt.Errorf("asset key %q must be an object key, not a path or URL", key)
+	case strings.IndexFunc(key, func(r rune) bool { return r < 0x20 || r == 0x7f }) >= 0:
+		return fmt.Errorf("asset key %q must not contain control characters", key)
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == ".." {
+			return fmt.Errorf("asset key %q must not contain .. segments", key)
+		}
+	}
+	return nil
+}
+
+// validateBranding records the problems with the shop's logo, photos, and
+// brand color.
+func (s Shop) validateBranding(f *fieldErrors) {
+	if s.LogoKey != "" {
+		if err := ValidateAssetKey(s.LogoKey); err != nil {
+			f.add("logoKey", fmt.Errorf("logoKey: %w", err))
+		}
+	}
+	if len(s.PhotoKeys) > MaxShopPhotos {
+		f.addMessage("photoKeys", fmt.Sprintf("photoKeys can have at most %d entries", MaxShopPhotos))
+	} else {
+		for i, key := range s.PhotoKeys {
+			if err := ValidateAssetKey(key); err != nil {
+				f.add(fmt.Sprintf("photoKeys[%d]", i), fmt.Errorf("photoKeys[%d]: %w", i, err))
+			}
+		}
+	}
+	if s.BrandColor != "" && !brandColorPattern.MatchString(s.BrandColor) {
+		f.addMessage("brandColor", fmt.Sprintf("brandColor must be a hex color such as #1A2B3C, got %q", s.BrandColor))
+	}
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShopBrandingValidation(t *testing.T) {
+	tests := []struct {
+		name       string
+		logoKey    string
+		photoKeys  []string
+		brandColor string
+		errMsg     string
+	}{
+		{name: "None set"},
+		{name: "Logo, photos, and color", logoKey: "acc-1/logo.png", photoKeys: []string{"acc-1/front.jpg", "acc-1/inside.jpg"}, brandColor: "#1a2B3c"},
+		{name: "Logo URL", logoKey: "https://cdn.example.com/logo.png", errMsg: `logoKey: asset key "https://cdn.example.com/logo.png" must be an object key, not a path or URL`},
+		{name: "Logo with leading slash", logoKey: "/logo.png", errMsg: `logoKey: asset key "/logo.png" must be an object key, not a path or URL`},
+		{name: "Logo too long", logoKey: strings.Repeat("a", 1025), errMsg: "logoKey: asset key must be at most 1024 bytes"},
+		{name: "Photo escaping its prefix", photoKeys: []string{"acc-1/../acc-2/photo.jpg"}, errMsg: `photoKeys[0]: asset key "acc-1/../acc-2/photo.jpg" must not contain .. segments`},
+		{name: "Blank photo", photoKeys: []string{"acc-1/front.jpg", " "}, errMsg: "photoKeys[1]: asset key must not be blank"},
+		{name: "Photo with control character", photoKeys: []string{"acc-1/front\n.jpg"}, errMsg: `photoKeys[0]: asset key "acc-1/front\n.jpg" must not contain control characters`},
+		{name: "Too many photos", photoKeys: make([]string, MaxShopPhotos+1), errMsg: "photoKeys can have at most 10 entries"},
+		{name: "Short color", brandColor: "#abc", errMsg: `brandColor must be a hex color such as #1A2B3C, got "#abc"`},
+		{name: "Named color", brandColor: "red", errMsg: `brandColor must be a hex color such as #1A2B3C, got "red"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shop := Shop{
+				Name:       "Coffee Shop",
+				ContactID:  "contact-1",
+				Address:    Address{StreetAddress: "123 Main St", City: "Springfield", PostalCode: "12345", Country: "US"},
+				LogoKey:    tt.logoKey,
+				PhotoKeys:  tt.photoKeys,
+				BrandColor: tt.brandColor,
+			}
+			err := shop.Validate()
+			if tt.errMsg != "" {
+				assert.EqualError(t, err, tt.errMsg)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
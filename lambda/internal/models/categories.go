@@ -0,0 +1,121 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// MaxShopCategories is the most categories a shop can be listed under.
+	MaxShopCategories = 20
+	// MaxCustomCategories is the most categories a custom taxonomy can define.
+	MaxCustomCategories = 500
+	// maxCategoryLength is the longest category name.
+	maxCategoryLength = 100
+)
+
+// CategoryTaxonomy is the set of business categories an account's shops are classified by.
+type CategoryTaxonomy string
+
+const (
+	// CategoryTaxonomyNAICS accepts North American Industry Classification
+	// System codes of two to six digits, such as 722515 for snack and
+	// nonalcoholic beverage bars.
+	CategoryTaxonomyNAICS CategoryTaxonomy = "naics"
+	// CategoryTaxonomyCustom accepts only the categories listed in the
+	// account's settings.
+	CategoryTaxonomyCustom CategoryTaxonomy = "custom"
+)
+
+// naicsSectors are the two-digit sectors NAICS codes start with.
+var naicsSectors = map[string]bool{
+	"11": true, "21": true, "22": true, "23": true, "31": true, "32": true, "33": true,
+	"42": true, "44": true, "45": true, "48": true, "49": true, "51": true, "52": true,
+	"53": true, "54": true, "55": true, "56": true, "61": true, "62": true, "71": true,
+	"72": true, "81": true, "92": true,
+}
+
+// validateNAICSCode checks that code has the shape of a NAICS code in a known
+// sector. Codes within a sector are not checked against the published list.
+func validateNAICSCode(code string) error {
+	if len(code) < 2 || len(code) > 6 || strings.Trim(code, "0123456789") != "" {
+		return fmt.Errorf("category %q must be a NAICS code of 2 to 6 digits", code)
+	}
+	if !naicsSectors[code[:2]] {
+		return fmt.Errorf("category %q is not in a NAICS sector", code)
+	}
+	return nil
+}
+
+// validateCategoryList checks a list of category names for blanks, overlong
+// names, and duplicates.
+func validateCategoryList(field string, categories []string, max int) error {
+	if len(categories) > max {
+		return fmt.Errorf("%s can have at most %d entries", field, max)
+	}
+	seen := make(map[string]bool, len(categories))
+	for _, category := range categories {
+		if strings.TrimSpace(category) == "" {
+			return fmt.Errorf("%s must not contain blank entries", field)
+		}
+		if len(category) > maxCategoryLength {
+			return fmt.Errorf("%s entries must be at most %d characters", field, maxCategoryLength)
+		}
+		if seen[category] {
+			return fmt.Errorf("%s lists %q more than once", field, category)
+		}
+		seen[category] = true
+	}
+	return nil
+}
+
+// Taxonomy returns the account's category taxonomy, NAICS unless a custom
+// one was chosen.
+func (s AccountSettings) Taxonomy() CategoryTaxonomy {
+	if s.CategoryTaxonomy == "" {
+		return CategoryTaxonomyNAICS
+	}
+	return s.CategoryTaxonomy
+}
+
+// ValidateCategories checks shop categories against the account's taxonomy.
+func (s AccountSettings) ValidateCategories(categories []string) error {
+	switch s.Taxonomy() {
+	case CategoryTaxonomyNAICS:
+		for _, category := range categories {
+			if err := validateNAICSCode(category); err != nil {
+				return err
+			}
+		}
+	case CategoryTaxonomyCustom:
+		allowed := make(map[string]bool, len(s.Categories))
+		for _, category := range s.Categories {
+			allowed[category] = true
+		}
+		for _, category := range categories {
+			if !allowed[category] {
+				return fmt.Errorf("category %q is not in the account's categories", category)
+			}
+		}
+	}
+	return nil
+}
+
+// validateTaxonomy validates the taxonomy settings.
+func (s AccountSettings) validateTaxonomy() error {
+	switch s.Taxonomy() {
+	case CategoryTaxonomyNAICS:
+		if len(s.Categories) > 0 {
+			return errors.New("categories can only be set with the custom categoryTaxonomy")
+		}
+	case CategoryTaxonomyCustom:
+		if len(s.Categories) == 0 {
+			return errors.New("the custom categoryTaxonomy requires categories")
+		}
+		return validateCategoryList("categories", s.Categories, MaxCustomCategories)
+	default:
+		return fmt.Errorf("categoryTaxonomy must be %s or %s", CategoryTaxonomyNAICS, CategoryTaxonomyCustom)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package models
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShopCategoriesValidation(t *testing.T) {
+	tooMany := make([]string, MaxShopCategories+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("72%04d", i)
+	}
+
+	tests := []struct {
+		name       string
+		categories []string
+		errMsg     string
+	}{
+		{name: "None"},
+		{name: "Distinct categories", categories: []string{"722515", "445"}},
+		{name: "Blank category", categories: []string{"722515", " "}, errMsg: "categories must not contain blank entries"},
+		{name: "Duplicate category", categories: []string{"722515", "722515"}, errMsg: `categories lists "722515" more than once`},
+		{name: "Too many categories", categories: tooMany, errMsg: "categories can have at most 20 entries"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shop := Shop{
+				Name:       "Coffee Shop",
+				ContactID:  "contact-1",
+				Address:    Address{StreetAddress: "123 Main St", City: "Springfield", PostalCode: "12345", Country: "US"},
+				Categories: tt.categories,
+			}
+			err := shop.Validate()
+			if tt.errMsg != "" {
+				assert.EqualError(t, err, tt.errMsg)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestAccountSettingsValidateCategories(t *testing.T) {
+	naics := DefaultAccountSettings("acc-12345")
+	custom := DefaultAccountSettings("acc-12345")
+	custom.CategoryTaxonomy = CategoryTaxonomyCustom
+	custom.Categories = []string{"coffee", "bakery"}
+
+	tests := []struct {
+		name       string
+		settings   AccountSettings
+		categories []string
+		errMsg     string
+	}{
+		{name: "NAICS sector", settings: naics, categories: []string{"72"}},
+		{name: "NAICS national industry", settings: naics, categories: []string{"722515"}},
+		{name: "Unset taxonomy means NAICS", settings: AccountSettings{}, categories: []string{"445110"}},
+		{name: "NAICS code with letters", settings: naics, categories: []string{"72a515"}, errMsg: `category "72a515" must be a NAICS code of 2 to 6 digits`},
+		{name: "NAICS code too long", settings: naics, categories: []string{"7225151"}, errMsg: `category "7225151" must be a NAICS code of 2 to 6 digits`},
+		{name: "NAICS code outside any sector", settings: naics, categories: []string{"99"}, errMsg: `category "99" is not in a NAICS sector`},
+		{name: "Custom category", settings: custom, categories: []string{"bakery", "coffee"}},
+		{name: "Unknown custom category", settings: custom, categories: []string{"florist"}, errMsg: `category "florist" is not in the account's categories`},
+		{name: "NAICS code under a custom taxonomy", settings: custom, categories: []string{"722515"}, errMsg: `category "722515" is not in the account's categories`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.settings.ValidateCategories(tt.categories)
+			if tt.errMsg != "" {
+				assert.EqualError(t, err, tt.errMsg)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestAccountSettingsTaxonomyValidation(t *testing.T) {
+	tests := []struct {
+		name       string
+		taxonomy   CategoryTaxonomy
+		categories []string
+		errMsg     string
+	}{
+		{name: "Default NAICS", taxonomy: CategoryTaxonomyNAICS},
+		{name: "Unset", taxonomy: ""},
+		{name: "Custom with categories", taxonomy: CategoryTaxonomyCustom, categories: []string{"coffee"}},
+		{name: "Custom without categories", taxonomy: CategoryTaxonomyCustom, errMsg: "the custom categoryTaxonomy requires categories"},
+		{name: "Custom with duplicates", taxonomy: CategoryTaxonomyCustom, categories: []string{"coffee", "coffee"}, errMsg: `categories lists "coffee" more than once`},
+		{name: "Categories with NAICS", taxonomy: CategoryTaxonomyNAICS, categories: []string{"coffee"}, errMsg: "categories can only be set with the custom categoryTaxonomy"},
+		{name: "Unknown taxonomy", taxonomy: "sic", errMsg: "categoryTaxonomy must be naics or custom"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := DefaultAccountSettings("acc-12345")
+			settings.CategoryTaxonomy = tt.taxonomy
+			settings.Categories = tt.categories
+			err := settings.Validate()
+			if tt.errMsg != "" {
+				assert.EqualError(t, err, tt.errMsg)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package models
+
+import (
+	"fmt"
+)
+
+// MaxShopContacts is the most contacts a shop can list.
+const MaxShopContacts = 50
+
+// ShopContactRole is the responsibility a contact holds for a shop.
+type ShopContactRole string
+
+const (
+	// ShopContactRoleManager runs the shop day to day.
+	ShopContactRoleManager ShopContactRole = "manager"
+	// ShopContactRoleBilling receives the shop's invoices.
+	ShopContactRoleBilling ShopContactRole = "billing"
+	// ShopContactRoleEmergency is called outside opening hours.
+	ShopContactRoleEmergency ShopContactRole = "emergency"
+)
+
+// IsValid reports whether r is a known role.
+func (r ShopContactRole) IsValid() bool {
+	switch r {
+	case ShopContactRoleManager, ShopContactRoleBilling, ShopContactRoleEmergency:
+		return true
+	}
+	return false
+}
+
+// ShopContact is a contact holding a role for a shop. A contact can hold
+// several roles, listed once per role.
+type ShopContact struct {
+	ContactID string          `json:"contactId" dynamodbav:"contactId"`
+	Role      ShopContactRole `json:"role" dynamodbav:"role"`
+}
+
+// Validate validates the contact.
+func (c ShopContact) Validate() error {
+	var f fieldErrors
+	if c.ContactID == "" {
+		f.addMessage("contactId", "contactId is required")
+	}
+	if !c.Role.IsValid() {
+		f.addMessage("role", fmt.Sprintf("role must be one of manager, billing, or emergency, got %q", c.Role))
+	}
+	return f.err()
+}
+
+// validateContacts validates each contact and rejects a contact listed twice
+// in the same role. Problems are reported under contacts[i].
+func validateContacts(contacts []ShopContact) error {
+	if len(contacts) > MaxShopContacts {
+		return ValidationErrors{{Field: "contacts", Message: fmt.Sprintf("a shop can have at most %d contacts", MaxShopContacts)}}
+	}
+	var f fieldErrors
+	seen := make(map[ShopContact]bool, len(contacts))
+	for i, contact := range contacts {
+		path := fmt.Sprintf("contacts[%d]", i)
+		if err := contact.Validate(); err != nil {
+			f.add(path, err)
+			continue
+		}
+		if seen[contact] {
+			f.addMessage(path+".contactId", fmt.Sprintf("contact %s is already listed as %s", contact.ContactID, contact.Role))
+		}
+		seen[contact] = true
+	}
+	return f.err()
+}
+
+// AddContact lists contact on the shop.
+func (s *Shop) AddContact(contact ShopContact) error {
+	contacts := append(append([]ShopContact(nil), s.Contacts...), contact)
+	if err := validateContacts(contacts); err != nil {
+		return err
+	}
+	s.Contacts = contacts
+	return nil
+}
+
+// RemoveContact removes a contact from the shop, from every role when role is
+// empty, and reports whether anything was removed.
+func (s *Shop) RemoveContact(contactID string, role ShopContactRole) bool {
+	var kept []ShopContact
+	for _, contact := range s.Contacts {
+		if contact.ContactID == contactID && (role == "" || contact.Role == role) {
+			continue
+		}
+		kept = append(kept, contact)
+	}
+	if len(kept) == len(s.Contacts) {
+		return false
+	}
+	s.Contacts = kept
+	return true
+}
@@ -0,0 +1,107 @@
+package models
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShopContactsValidation(t *testing.T) {
+	tooMany := make([]ShopContact, MaxShopContacts+1)
+	for i := range tooMany {
+		tooMany[i] = ShopContact{ContactID: fmt.Sprintf("contact-%d", i), Role: ShopContactRoleManager}
+	}
+
+	tests := []struct {
+		name     string
+		contacts []ShopContact
+		errMsg   string
+	}{
+		{name: "No contacts"},
+		{
+			name: "One contact in several roles",
+			contacts: []ShopContact{
+				{ContactID: "contact-2", Role: ShopContactRoleManager},
+				{ContactID: "contact-2", Role: ShopContactRoleEmergency},
+				{ContactID: "contact-3", Role: ShopContactRoleBilling},
+			},
+		},
+		{
+			name:     "Missing contact ID",
+			contacts: []ShopContact{{Role: ShopContactRoleBilling}},
+			errMsg:   "contacts[0]: contactId is required",
+		},
+		{
+			name:     "Unknown role",
+			contacts: []ShopContact{{ContactID: "contact-2", Role: "owner"}},
+			errMsg:   `contacts[0]: role must be one of manager, billing, or emergency, got "owner"`,
+		},
+		{
+			name: "Duplicate contact and role",
+			contacts: []ShopContact{
+				{ContactID: "contact-2", Role: ShopContactRoleBilling},
+				{ContactID: "contact-2", Role: ShopContactRoleBilling},
+			},
+			errMsg: "contacts[1]: contact contact-2 is already listed as billing",
+		},
+		{name: "Too many contacts", contacts: tooMany, errMsg: "a shop can have at most 50 contacts"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shop := Shop{
+				Name:      "Coffee Shop",
+				ContactID: "contact-1",
+				Address:   Address{StreetAddress: "123 Main St", City: "Springfield", PostalCode: "12345", Country: "US"},
+				Contacts:  tt.contacts,
+			}
+			err := shop.Validate()
+			if tt.errMsg != "" {
+				assert.EqualError(t, err, tt.errMsg)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestShopAddRemoveContact(t *testing.T) {
+	manager := ShopContact{ContactID: "contact-2", Role: ShopContactRoleManager}
+	emergency := ShopContact{ContactID: "contact-2", Role: ShopContactRoleEmergency}
+	billing := ShopContact{ContactID: "contact-3", Role: ShopContactRoleBilling}
+
+	t.Run("Adds contacts", func(t *testing.T) {
+		var shop Shop
+		require.NoError(t, shop.AddContact(manager))
+		require.NoError(t, shop.AddContact(billing))
+		assert.Equal(t, []ShopContact{manager, billing}, shop.Contacts)
+	})
+
+	t.Run("Rejects invalid and duplicate contacts unchanged", func(t *testing.T) {
+		shop := Shop{Contacts: []ShopContact{manager}}
+		assert.EqualError(t, shop.AddContact(manager), "contacts[1]: contact contact-2 is already listed as manager")
+		assert.Error(t, shop.AddContact(ShopContact{ContactID: "contact-3"}))
+		assert.Equal(t, []ShopContact{manager}, shop.Contacts)
+	})
+
+	t.Run("Removes one role", func(t *testing.T) {
+		shop := Shop{Contacts: []ShopContact{manager, emergency, billing}}
+		assert.True(t, shop.RemoveContact("contact-2", ShopContactRoleEmergency))
+		assert.Equal(t, []ShopContact{manager, billing}, shop.Contacts)
+	})
+
+	t.Run("Removes every role", func(t *testing.T) {
+		shop := Shop{Contacts: []ShopContact{manager, emergency, billing}}
+		assert.True(t, shop.RemoveContact("contact-2", ""))
+		assert.Equal(t, []ShopContact{billing}, shop.Contacts)
+	})
+
+	t.Run("Reports unknown contacts", func(t *testing.T) {
+		shop := Shop{Contacts: []ShopContact{manager}}
+		assert.False(t, shop.RemoveContact("contact-3", ""))
+		assert.False(t, shop.RemoveContact("contact-2", ShopContactRoleBilling))
+		assert.Equal(t, []ShopContact{manager}, shop.Contacts)
+	})
+}
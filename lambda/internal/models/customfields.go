@@ -0,0 +1,137 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CustomFieldType is the value type of an account-defined custom field.
+type CustomFieldType string
+
+const (
+	// CustomFieldTypeString holds any string.
+	CustomFieldTypeString CustomFieldType = "string"
+	// CustomFieldTypeNumber holds any number.
+	CustomFieldTypeNumber CustomFieldType = "number"
+	// CustomFieldTypeInteger holds a whole number.
+	CustomFieldTypeInteger CustomFieldType = "integer"
+	// CustomFieldTypeBoolean holds true or false.
+	CustomFieldTypeBoolean CustomFieldType = "boolean"
+	// CustomFieldTypeDate holds a calendar date formatted as YYYY-MM-DD.
+	CustomFieldTypeDate CustomFieldType = "date"
+)
+
+// MaxCustomFields is the most custom fields an account can define.
+const MaxCustomFields = 100
+
+// customFieldNamePattern restricts field names to identifiers usable as GraphQL keys.
+var customFieldNamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]{0,63}$`)
+
+// CustomFieldDefinition declares one custom field of an account's locations.
+type CustomFieldDefinition struct {
+	Name     string          `json:"name" dynamodbav:"name"`
+	Type     CustomFieldType `json:"type" dynamodbav:"type"`
+	Required bool            `json:"required" dynamodbav:"required"`
+}
+
+// ValidateCustomFieldDefinitions checks that definitions have valid, unique names and known types.
+func ValidateCustomFieldDefinitions(definitions []CustomFieldDefinition) error {
+	if len(definitions) > MaxCustomFields {
+		return fmt.Errorf("at most %d custom fields can be defined", MaxCustomFields)
+	}
+	seen := make(map[string]bool, len(definitions))
+	for _, def := range definitions {
+		if !customFieldNamePattern.MatchString(def.Name) {
+			return fmt.Errorf("invalid custom field name %q: must start with a letter and contain at most 64 letters, digits, or underscores", def.Name)
+		}
+		if seen[def.Name] {
+			return fmt.Errorf("custom field %s is defined more than once", def.Name)
+		}
+		seen[def.Name] = true
+
+		switch def.Type {
+		case CustomFieldTypeString, CustomFieldTypeNumber, CustomFieldTypeInteger, CustomFieldTypeBoolean, CustomFieldTypeDate:
+		default:
+			return fmt.Errorf("custom field %s has unknown type %q", def.Name, def.Type)
+		}
+	}
+	return nil
+}
+
+// ValidateCustomFields checks values against an account's definitions: every
+// value must be defined and of its field's type, and required fields must be set.
+// Values are as decoded from JSON, so numbers are float64.
+func ValidateCustomFields(definitions []CustomFieldDefinition, values map[string]interface{}) error {
+	byName := make(map[string]CustomFieldDefinition, len(definitions))
+	for _, def := range definitions {
+		byName[def.Name] = def
+	}
+
+	var problems []string
+	for _, name := range sortedKeys(values) {
+		def, ok := byName[name]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s is not a defined custom field", name))
+			continue
+		}
+		if err := checkCustomFieldValue(def.Type, values[name]); err != nil {
+			problems = append(problems, fmt.Sprintf("%s %v", name, err))
+		}
+	}
+	for _, def := range definitions {
+		if _, ok := values[def.Name]; def.Required && !ok {
+			problems = append(problems, fmt.Sprintf("%s is required", def.Name))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid customFields: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// checkCustomFieldValue checks that value has the given type. Null is never valid;
+// an unset optional field is omitted instead.
+func checkCustomFieldValue(fieldType CustomFieldType, value interface{}) error {
+	switch fieldType {
+	case CustomFieldTypeString:
+		if _, ok := value.(string); ok {
+			return nil
+		}
+	case CustomFieldTypeNumber:
+		if _, ok := value.(float64); ok {
+			return nil
+		}
+	case CustomFieldTypeInteger:
+		if f, ok := value.(float64); ok && f == math.Trunc(f) && math.Abs(f) <= 1<<53 {
+			return nil
+		}
+	case CustomFieldTypeBoolean:
+		if _, ok := value.(bool); ok {
+			return nil
+		}
+	case CustomFieldTypeDate:
+		if s, ok := value.(string); ok {
+			if _, err := time.Parse(time.DateOnly, s); err == nil {
+				return nil
+			}
+			return errors.New("must be a date formatted as YYYY-MM-DD")
+		}
+	}
+	return fmt.Errorf("must be of type %s", fieldType)
+}
+
+// sortedKeys returns the keys of m in order, so validation messages are stable.
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
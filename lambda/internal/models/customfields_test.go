@@ -0,0 +1,133 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateCustomFieldDefinitions(t *testing.T) {
+	tests := []struct {
+		name        string
+		definitions []CustomFieldDefinition
+		errMsg      string
+	}{
+		{
+			name: "Valid definitions",
+			definitions: []CustomFieldDefinition{
+				{Name: "storeNumber", Type: CustomFieldTypeInteger, Required: true},
+				{Name: "region_code", Type: CustomFieldTypeString},
+				{Name: "openedOn", Type: CustomFieldTypeDate},
+			},
+		},
+		{
+			name: "No definitions",
+		},
+		{
+			name:        "Name must start with a letter",
+			definitions: []CustomFieldDefinition{{Name: "1st", Type: CustomFieldTypeString}},
+			errMsg:      `invalid custom field name "1st": must start with a letter and contain at most 64 letters, digits, or underscores`,
+		},
+		{
+			name:        "Name too long",
+			definitions: []CustomFieldDefinition{{Name: "a" + strings.Repeat("b", 64), Type: CustomFieldTypeString}},
+			errMsg:      `invalid custom field name "a` + strings.Repeat("b", 64) + `": must start with a letter and contain at most 64 letters, digits, or underscores`,
+		},
+		{
+			name: "Duplicate name",
+			definitions: []CustomFieldDefinition{
+				{Name: "tier", Type: CustomFieldTypeString},
+				{Name: "tier", Type: CustomFieldTypeInteger},
+			},
+			errMsg: "custom field tier is defined more than once",
+		},
+		{
+			name:        "Unknown type",
+			definitions: []CustomFieldDefinition{{Name: "tier", Type: "enum"}},
+			errMsg:      `custom field tier has unknown type "enum"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateCustomFieldDefinitions(tt.definitions)
+			if tt.errMsg != "" {
+				assert.EqualError(t, err, tt.errMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateCustomFields(t *testing.T) {
+	definitions := []CustomFieldDefinition{
+		{Name: "storeNumber", Type: CustomFieldTypeInteger, Required: true},
+		{Name: "squareFeet", Type: CustomFieldTypeNumber},
+		{Name: "region", Type: CustomFieldTypeString},
+		{Name: "drive_thru", Type: CustomFieldTypeBoolean},
+		{Name: "openedOn", Type: CustomFieldTypeDate},
+	}
+
+	tests := []struct {
+		name   string
+		values string
+		errMsg string
+	}{
+		{
+			name:   "All fields valid",
+			values: `{"storeNumber": 42, "squareFeet": 1250.5, "region": "west", "drive_thru": true, "openedOn": "2021-03-15"}`,
+		},
+		{
+			name:   "Only required fields",
+			values: `{"storeNumber": 7}`,
+		},
+		{
+			name:   "Missing required field",
+			values: `{"region": "west"}`,
+			errMsg: "invalid customFields: storeNumber is required",
+		},
+		{
+			name:   "Undefined field",
+			values: `{"storeNumber": 7, "color": "red"}`,
+			errMsg: "invalid customFields: color is not a defined custom field",
+		},
+		{
+			name:   "Integer with fraction",
+			values: `{"storeNumber": 7.5}`,
+			errMsg: "invalid customFields: storeNumber must be of type integer",
+		},
+		{
+			name:   "Bad date",
+			values: `{"storeNumber": 7, "openedOn": "03/15/2021"}`,
+			errMsg: "invalid customFields: openedOn must be a date formatted as YYYY-MM-DD",
+		},
+		{
+			name:   "Null value",
+			values: `{"storeNumber": 7, "region": null}`,
+			errMsg: "invalid customFields: region must be of type string",
+		},
+		{
+			name:   "Problems are reported together",
+			values: `{"drive_thru": "yes", "squareFeet": "big"}`,
+			errMsg: "invalid customFields: drive_thru must be of type boolean; squareFeet must be of type number; storeNumber is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var values map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(tt.values), &values))
+
+			err := ValidateCustomFields(definitions, values)
+			if tt.errMsg != "" {
+				assert.EqualError(t, err, tt.errMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+const (
+	// MaxDeliveryZones is the most delivery zones a shop can define.
+	MaxDeliveryZones = 20
+	// MaxZoneVertices is the most points a delivery zone's boundary can have.
+	MaxZoneVertices = 500
+)
+
+// decimalPattern matches a non-negative decimal amount such as 4.99, with at
+// most four decimal places.
+var decimalPattern = regexp.MustCompile(`^\d{1,10}(\.\d{1,4})?$`)
+
+// DeliveryZone is an area a shop delivers to and what delivery there costs.
+// Amounts are decimal strings in the account's currency, so they round-trip
+// without floating-point error.
+type DeliveryZone struct {
+	Name string `json:"name" dynamodbav:"name"`
+	// Boundary is the zone's polygon, at least three points; the closing
+	// point may be omitted
+	Boundary    []Coordinates `json:"boundary" dynamodbav:"boundary"`
+	DeliveryFee string        `json:"deliveryFee" dynamodbav:"deliveryFee"`
+	MinOrder    string        `json:"minOrder,omitempty" dynamodbav:"minOrder,omitempty"`
+	EtaMinutes  int           `json:"etaMinutes,omitempty" dynamodbav:"etaMinutes,omitempty"`
+}
+
+// ValidateDecimal checks that amount is a non-negative decimal such as 4.99.
+func ValidateDecimal(field, amount string) error {
+	if !decimalPattern.MatchString(amount) {
+		return fmt.Errorf("%s must be a non-negative decimal such as 4.99, got %q", field, amount)
+	}
+	return nil
+}
+
+// CompareDecimals compares two amounts that passed ValidateDecimal, returning
+// -1, 0, or +1 as a is less than, equal to, or greater than b.
+func CompareDecimals(a, b string) int {
+	x, _ := new(big.Rat).SetString(a)
+	y, _ := new(big.Rat).SetString(b)
+	return x.Cmp(y)
+}
+
+// Validate validates the delivery zone.
+func (z DeliveryZone) Validate() error {
+	var f fieldErrors
+	if strings.TrimSpace(z.Name) == "" {
+		f.addMessage("name", "name is required")
+	}
+	switch {
+	case len(z.Boundary) < 3:
+		f.addMessage("boundary", "boundary must have at least 3 points")
+	case len(z.Boundary) > MaxZoneVertices:
+		f.addMessage("boundary", fmt.Sprintf("boundary can have at most %d points", MaxZoneVertices))
+	default:
+		valid := true
+		minLon, maxLon := math.Inf(1), math.Inf(-1)
+		for i, point := range z.Boundary {
+			if err := point.Validate(); err != nil {
+				f.add(fmt.Sprintf("boundary[%d]", i), err)
+				valid = false
+			}
+			minLon = math.Min(minLon, point.Longitude)
+			maxLon = math.Max(maxLon, point.Longitude)
+		}
+		// Edges are straight in latitude and longitude, so a zone spanning more
+		// than half the globe would be read as wrapping the other way round
+		if valid && maxLon-minLon > 180 {
+			f.addMessage("boundary", "boundary must not span more than 180 degrees of longitude")
+		}
+	}
+	f.add("deliveryFee", ValidateDecimal("deliveryFee", z.DeliveryFee))
+	if z.MinOrder != "" {
+		f.add("minOrder", ValidateDecimal("minOrder", z.MinOrder))
+	}
+	if z.EtaMinutes < 0 {
+		f.addMessage("etaMinutes", "etaMinutes must not be negative")
+	}
+	return f.err()
+}
+
+// validateDeliveryZones validates a shop's delivery zones and checks their
+// names are unique. Problems are reported under deliveryZones[i].
+func validateDeliveryZones(zones []DeliveryZone) error {
+	if len(zones) > MaxDeliveryZones {
+		return ValidationErrors{{Field: "deliveryZones", Message: fmt.Sprintf("deliveryZones can have at most %d entries", MaxDeliveryZones)}}
+	}
+	var f fieldErrors
+	seen := make(map[string]bool, len(zones))
+	for i, zone := range zones {
+		if err := zone.Validate(); err != nil {
+			f.add(fmt.Sprintf("deliveryZones[%d]", i), err)
+			continue
+		}
+		if seen[zone.Name] {
+			f.addMessage("deliveryZones", fmt.Sprintf("deliveryZones lists %q more than once", zone.Name))
+		}
+		seen[zone.Name] = true
+	}
+	return f.err()
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeliveryZoneValidation(t *testing.T) {
+	square := []Coordinates{
+		{Latitude: 39.77, Longitude: -89.67},
+		{Latitude: 39.77, Longitude: -89.63},
+		{Latitude: 39.80, Longitude: -89.63},
+		{Latitude: 39.80, Longitude: -89.67},
+	}
+	zone := func(modify func(*DeliveryZone)) []DeliveryZone {
+		z := DeliveryZone{Name: "Downtown", Boundary: square, DeliveryFee: "4.99", MinOrder: "15", EtaMinutes: 30}
+		modify(&z)
+		return []DeliveryZone{z}
+	}
+
+	tests := []struct {
+		name   string
+		zones  []DeliveryZone
+		errMsg string
+	}{
+		{name: "No zones"},
+		{name: "Valid zone", zones: zone(func(z *DeliveryZone) {})},
+		{name: "Free delivery without minimum", zones: zone(func(z *DeliveryZone) { z.DeliveryFee = "0"; z.MinOrder = "" })},
+		{name: "Missing name", zones: zone(func(z *DeliveryZone) { z.Name = " " }), errMsg: "deliveryZones[0]: name is required"},
+		{name: "Two points", zones: zone(func(z *DeliveryZone) { z.Boundary = square[:2] }), errMsg: "deliveryZones[0]: boundary must have at least 3 points"},
+		{name: "Invalid point", zones: zone(func(z *DeliveryZone) {
+			z.Boundary = []Coordinates{square[0], square[1], {Latitude: 95, Longitude: 0}}
+		}), errMsg: "deliveryZones[0].boundary[2]: latitude must be between -90 and 90, got 95.000000"},
+		{name: "Spans the antimeridian", zones: zone(func(z *DeliveryZone) {
+			z.Boundary = []Coordinates{{Latitude: 0, Longitude: 179}, {Latitude: 1, Longitude: -179}, {Latitude: 1, Longitude: 179}}
+		}), errMsg: "deliveryZones[0]: boundary must not span more than 180 degrees of longitude"},
+		{name: "Missing fee", zones: zone(func(z *DeliveryZone) { z.DeliveryFee = "" }), errMsg: `deliveryZones[0]: deliveryFee must be a non-negative decimal such as 4.99, got ""`},
+		{name: "Negative fee", zones: zone(func(z *DeliveryZone) { z.DeliveryFee = "-1.00" }), errMsg: `deliveryZones[0]: deliveryFee must be a non-negative decimal such as 4.99, got "-1.00"`},
+		{name: "Fee with currency symbol", zones: zone(func(z *DeliveryZone) { z.DeliveryFee = "$4.99" }), errMsg: `deliveryZones[0]: deliveryFee must be a non-negative decimal such as 4.99, got "$4.99"`},
+		{name: "Minimum with exponent", zones: zone(func(z *DeliveryZone) { z.MinOrder = "1e3" }), errMsg: `deliveryZones[0]: minOrder must be a non-negative decimal such as 4.99, got "1e3"`},
+		{name: "Negative ETA", zones: zone(func(z *DeliveryZone) { z.EtaMinutes = -5 }), errMsg: "deliveryZones[0]: etaMinutes must not be negative"},
+		{name: "Duplicate names", zones: append(zone(func(z *DeliveryZone) {}), zone(func(z *DeliveryZone) {})...), errMsg: `deliveryZones lists "Downtown" more than once`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shop := Shop{
+				Name:          "Coffee Shop",
+				ContactID:     "contact-1",
+				Address:       Address{StreetAddress: "123 Main St", City: "Springfield", PostalCode: "12345", Country: "US"},
+				DeliveryZones: tt.zones,
+			}
+			err := shop.Validate()
+			if tt.errMsg != "" {
+				assert.EqualError(t, err, tt.errMsg)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestCompareDecimals(t *testing.T) {
+	assert.Equal(t, -1, CompareDecimals("4.99", "5"))
+	assert.Equal(t, 0, CompareDecimals("5.00", "5"))
+	assert.Equal(t, 1, CompareDecimals("10", "9.9999"))
+}
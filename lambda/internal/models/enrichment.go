@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// Enrichment holds business details looked up from an external places provider.
+type Enrichment struct {
+	Category string `json:"category,omitempty" dynamodbav:"category,omitempty"`
+	Phone    string `json:"phone,omitempty" dynamodbav:"phone,omitempty"`
+	Website  string `json:"website,omitempty" dynamodbav:"website,omitempty"`
+	// Provider, PlaceID, and MatchedName record where the details came from
+	Provider    string    `json:"provider" dynamodbav:"provider"`
+	PlaceID     string    `json:"placeId,omitempty" dynamodbav:"placeId,omitempty"`
+	MatchedName string    `json:"matchedName,omitempty" dynamodbav:"matchedName,omitempty"`
+	EnrichedAt  time.Time `json:"enrichedAt" dynamodbav:"enrichedAt"`
+}
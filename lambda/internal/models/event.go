@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// EventLocation represents a temporary location, such as a conference or a
+// festival, at an address, coordinates, or both, from StartsAt until EndsAt.
+type EventLocation struct {
+	LocationBase
+	Address     *Address     `json:"address,omitempty" dynamodbav:"address,omitempty"`
+	Coordinates *Coordinates `json:"coordinates,omitempty" dynamodbav:"coordinates,omitempty"`
+	StartsAt    time.Time    `json:"startsAt" dynamodbav:"startsAt"`
+	EndsAt      time.Time    `json:"endsAt" dynamodbav:"endsAt"`
+	// Recurrence repeats the event, such as a weekly market, within
+	// StartsAt and EndsAt
+	Recurrence *Recurrence `json:"recurrence,omitempty" dynamodbav:"recurrence,omitempty"`
+}
+
+// RunsOn reports whether the event runs at any time during the UTC day of date.
+func (l EventLocation) RunsOn(date time.Time) bool {
+	dayStart := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	return l.StartsAt.Before(dayStart.AddDate(0, 0, 1)) && l.EndsAt.After(dayStart)
+}
+
+// Validate validates the event location, returning ValidationErrors listing
+// every problem.
+func (l EventLocation) Validate() error {
+	var f fieldErrors
+	l.validateBase(&f, LocationTypeEvent, l.Address != nil)
+	if l.Address == nil && l.Coordinates == nil {
+		f.addMessage("", "event requires an address or coordinates")
+	}
+	if l.Address != nil {
+		f.add("address", l.Address.Validate())
+	}
+	if l.Coordinates != nil {
+		f.add("coordinates", l.Coordinates.Validate())
+	}
+	switch {
+	case l.StartsAt.IsZero() || l.EndsAt.IsZero():
+		f.addMessage("", "startsAt and endsAt are required")
+	case !l.EndsAt.After(l.StartsAt):
+		f.addMessage("endsAt", "endsAt must be after startsAt")
+	}
+	if l.Recurrence != nil {
+		f.add("recurrence", l.Recurrence.Validate())
+	}
+	return f.err()
+}
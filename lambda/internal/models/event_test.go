@@ -0,0 +1,106 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventLocationValidation(t *testing.T) {
+	startsAt := time.Date(2024, time.June, 3, 15, 0, 0, 0, time.UTC)
+	event := func(modify func(*EventLocation)) EventLocation {
+		location := EventLocation{
+			LocationBase: LocationBase{AccountID: "acc-12345", LocationType: LocationTypeEvent},
+			Coordinates:  &Coordinates{Latitude: 41.8781, Longitude: -87.6298},
+			StartsAt:     startsAt,
+			EndsAt:       startsAt.Add(8 * time.Hour),
+		}
+		modify(&location)
+		return location
+	}
+
+	tests := []struct {
+		name     string
+		location EventLocation
+		errMsg   string
+	}{
+		{
+			name:     "Event at coordinates",
+			location: event(func(*EventLocation) {}),
+		},
+		{
+			name: "Event at an address",
+			location: event(func(l *EventLocation) {
+				l.Coordinates = nil
+				l.Address = &Address{StreetAddress: "2301 S Lake Shore Dr", City: "Chicago", PostalCode: "60616", Country: "US"}
+			}),
+		},
+		{
+			name:     "Event without a place",
+			location: event(func(l *EventLocation) { l.Coordinates = nil }),
+			errMsg:   "event requires an address or coordinates",
+		},
+		{
+			name:     "Invalid address",
+			location: event(func(l *EventLocation) { l.Address = &Address{City: "Chicago"} }),
+			errMsg:   "streetAddress is required",
+		},
+		{
+			name:     "Missing end",
+			location: event(func(l *EventLocation) { l.EndsAt = time.Time{} }),
+			errMsg:   "startsAt and endsAt are required",
+		},
+		{
+			name:     "End before start",
+			location: event(func(l *EventLocation) { l.EndsAt = startsAt.Add(-time.Hour) }),
+			errMsg:   "endsAt must be after startsAt",
+		},
+		{
+			name:     "Wrong location type",
+			location: event(func(l *EventLocation) { l.LocationType = LocationTypeCoordinates }),
+			errMsg:   "invalid locationType for EventLocation",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.location.Validate()
+			if tt.errMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestEventLocationRunsOn(t *testing.T) {
+	event := EventLocation{
+		StartsAt: time.Date(2024, time.June, 3, 20, 0, 0, 0, time.UTC),
+		EndsAt:   time.Date(2024, time.June, 5, 0, 0, 0, 0, time.UTC),
+	}
+
+	assert.False(t, event.RunsOn(time.Date(2024, time.June, 2, 0, 0, 0, 0, time.UTC)))
+	assert.True(t, event.RunsOn(time.Date(2024, time.June, 3, 0, 0, 0, 0, time.UTC)))
+	assert.True(t, event.RunsOn(time.Date(2024, time.June, 4, 12, 0, 0, 0, time.UTC)))
+	assert.False(t, event.RunsOn(time.Date(2024, time.June, 5, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestUnmarshalEventLocation(t *testing.T) {
+	location, err := UnmarshalLocation([]byte(`{
+		"accountId": "acc-12345",
+		"locationType": "event",
+		"coordinates": {"latitude": 41.8781, "longitude": -87.6298},
+		"startsAt": "2024-06-03T15:00:00Z",
+		"endsAt": "2024-06-03T23:00:00Z"
+	}`))
+	require.NoError(t, err)
+	event, ok := location.(EventLocation)
+	require.True(t, ok)
+	assert.Equal(t, 41.8781, event.Coordinates.Latitude)
+	assert.Equal(t, 8*time.Hour, event.EndsAt.Sub(event.StartsAt))
+	assert.NoError(t, event.Validate())
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// GeocodeInfo records how an address location's coordinates were derived.
+// Coordinates without it were entered by hand.
+type GeocodeInfo struct {
+	Provider string `json:"provider" dynamodbav:"provider"`
+	// Label is the provider's formatted address of the match
+	Label string `json:"label,omitempty" dynamodbav:"label,omitempty"`
+	// Confidence is the provider's 0-1 score of the match; rooftop matches
+	// score near 1, street or postal code matches lower
+	Confidence float64   `json:"confidence" dynamodbav:"confidence"`
+	GeocodedAt time.Time `json:"geocodedAt" dynamodbav:"geocodedAt"`
+	// AddressHash is AddressHash of the address that was geocoded, so a
+	// later change to the address can be told apart from the match
+	AddressHash string `json:"addressHash,omitempty" dynamodbav:"addressHash,omitempty"`
+}
+
+// Validate validates the geocode provenance.
+func (g GeocodeInfo) Validate() error {
+	if g.Provider == "" {
+		return errors.New("geocode: provider is required")
+	}
+	if g.Confidence < 0 || g.Confidence > 1 {
+		return fmt.Errorf("geocode: confidence must be between 0 and 1, got %g", g.Confidence)
+	}
+	if g.GeocodedAt.IsZero() {
+		return errors.New("geocode: geocodedAt is required")
+	}
+	return nil
+}
+
+// AddressHash returns a digest of an address's fields, ignoring case,
+// surrounding and repeated whitespace, and verification, so it changes only
+// when the address itself does.
+func AddressHash(a Address) string {
+	fields := []string{a.StreetAddress, a.StreetAddress2, a.City, a.StateProvince, a.PostalCode, a.Country}
+	for i, field := range fields {
+		fields[i] = strings.ToLower(strings.Join(strings.Fields(field), " "))
+	}
+	sum := sha256.Sum256([]byte(strings.Join(fields, "\n")))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddressHash(t *testing.T) {
+	address := Address{StreetAddress: "123 Main St", City: "Springfield", StateProvince: "IL", PostalCode: "62704", Country: "US"}
+
+	t.Run("Ignores case, spacing, and verification", func(t *testing.T) {
+		reformatted := Address{StreetAddress: " 123  MAIN st", City: "springfield", StateProvince: "IL", PostalCode: "62704 ", Country: "us"}
+		reformatted.Verification = &AddressVerification{}
+		assert.Equal(t, AddressHash(address), AddressHash(reformatted))
+	})
+
+	t.Run("Changes with the address", func(t *testing.T) {
+		for _, changed := range []func(*Address){
+			func(a *Address) { a.StreetAddress = "125 Main St" },
+			func(a *Address) { a.StreetAddress2 = "Suite 4" },
+			func(a *Address) { a.PostalCode = "62701" },
+		} {
+			moved := address
+			changed(&moved)
+			assert.NotEqual(t, AddressHash(address), AddressHash(moved))
+		}
+	})
+
+	t.Run("Keeps fields apart", func(t *testing.T) {
+		split := Address{StreetAddress: "123 Main St", StreetAddress2: "Springfield"}
+		joined := Address{StreetAddress: "123 Main St", City: "Springfield"}
+		assert.NotEqual(t, AddressHash(split), AddressHash(joined))
+	})
+}
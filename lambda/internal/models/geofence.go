@@ -0,0 +1,109 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	// MaxGeofences is the most geofences an account can define.
+	MaxGeofences = 100
+	// MaxGeofenceDebounce is the longest a geofence can wait to confirm a transition.
+	MaxGeofenceDebounce = 3600
+)
+
+// Geofence is an area whose boundary crossings by an account's coordinates
+// locations, such as vehicles, are published as enter and exit events. It is
+// either a polygon Boundary or a circle of RadiusMeters around Center.
+type Geofence struct {
+	GeofenceID string `json:"geofenceId" dynamodbav:"geofenceId"`
+	Name       string `json:"name" dynamodbav:"name"`
+	// Boundary is the polygon, at least three points; the closing point may be omitted
+	Boundary     []Coordinates `json:"boundary,omitempty" dynamodbav:"boundary,omitempty"`
+	Center       *Coordinates  `json:"center,omitempty" dynamodbav:"center,omitempty"`
+	RadiusMeters float64       `json:"radiusMeters,omitempty" dynamodbav:"radiusMeters,omitempty"`
+	// DebounceSeconds is how long a location must keep reporting the other
+	// side of the boundary before the crossing is published, so GPS jitter
+	// along the edge doesn't publish a burst of enters and exits
+	DebounceSeconds int `json:"debounceSeconds,omitempty" dynamodbav:"debounceSeconds,omitempty"`
+}
+
+// Validate validates the geofence.
+func (g Geofence) Validate() error {
+	if strings.TrimSpace(g.GeofenceID) == "" {
+		return fmt.Errorf("geofenceId is required")
+	}
+	if len(g.GeofenceID) > 64 {
+		return fmt.Errorf("geofenceId must be at most 64 characters")
+	}
+	if strings.TrimSpace(g.Name) == "" {
+		return fmt.Errorf("name is required")
+	}
+	if g.DebounceSeconds < 0 || g.DebounceSeconds > MaxGeofenceDebounce {
+		return fmt.Errorf("debounceSeconds must be between 0 and %d", MaxGeofenceDebounce)
+	}
+
+	switch {
+	case len(g.Boundary) > 0 && g.Center != nil:
+		return fmt.Errorf("set either boundary or center and radiusMeters, not both")
+	case g.Center != nil:
+		if err := g.Center.Validate(); err != nil {
+			return fmt.Errorf("center: %w", err)
+		}
+		if g.RadiusMeters <= 0 {
+			return fmt.Errorf("radiusMeters must be positive")
+		}
+		return nil
+	case len(g.Boundary) == 0:
+		return fmt.Errorf("boundary or center is required")
+	}
+
+	if g.RadiusMeters != 0 {
+		return fmt.Errorf("radiusMeters is only allowed with center")
+	}
+	if len(g.Boundary) < 3 {
+		return fmt.Errorf("boundary must have at least 3 points")
+	}
+	if len(g.Boundary) > MaxZoneVertices {
+		return fmt.Errorf("boundary can have at most %d points", MaxZoneVertices)
+	}
+	minLon, maxLon := math.Inf(1), math.Inf(-1)
+	for i, point := range g.Boundary {
+		if err := point.Validate(); err != nil {
+			return fmt.Errorf("boundary[%d]: %w", i, err)
+		}
+		minLon = math.Min(minLon, point.Longitude)
+		maxLon = math.Max(maxLon, point.Longitude)
+	}
+	if maxLon-minLon > 180 {
+		return fmt.Errorf("boundary must not span more than 180 degrees of longitude")
+	}
+	return nil
+}
+
+// ValidateGeofences checks an account's geofences, which must have distinct IDs.
+func ValidateGeofences(geofences []Geofence) error {
+	if len(geofences) > MaxGeofences {
+		return fmt.Errorf("an account can have at most %d geofences", MaxGeofences)
+	}
+	seen := make(map[string]bool, len(geofences))
+	for i, geofence := range geofences {
+		if err := geofence.Validate(); err != nil {
+			return fmt.Errorf("geofences[%d]: %w", i, err)
+		}
+		if seen[geofence.GeofenceID] {
+			return fmt.Errorf("geofences[%d]: duplicate geofenceId %q", i, geofence.GeofenceID)
+		}
+		seen[geofence.GeofenceID] = true
+	}
+	return nil
+}
+
+// GeofencePresence is which side of a geofence a location was last confirmed
+// on, and when it started reporting the other side, while that is debounced.
+type GeofencePresence struct {
+	Inside       bool       `json:"inside" dynamodbav:"inside"`
+	PendingSince *time.Time `json:"pendingSince,omitempty" dynamodbav:"pendingSince,omitempty"`
+}
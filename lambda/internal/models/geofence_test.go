@@ -0,0 +1,68 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGeofenceValidation(t *testing.T) {
+	square := []Coordinates{
+		{Latitude: 39.73, Longitude: -105.00},
+		{Latitude: 39.73, Longitude: -104.98},
+		{Latitude: 39.75, Longitude: -104.98},
+		{Latitude: 39.75, Longitude: -105.00},
+	}
+	center := &Coordinates{Latitude: 39.74, Longitude: -104.99}
+
+	tests := []struct {
+		name     string
+		geofence Geofence
+		errMsg   string
+	}{
+		{name: "Polygon", geofence: Geofence{GeofenceID: "depot", Name: "Depot", Boundary: square, DebounceSeconds: 30}},
+		{name: "Circle", geofence: Geofence{GeofenceID: "yard", Name: "Yard", Center: center, RadiusMeters: 250}},
+		{name: "Missing ID", geofence: Geofence{Name: "Depot", Boundary: square}, errMsg: "geofenceId is required"},
+		{name: "Missing name", geofence: Geofence{GeofenceID: "depot", Boundary: square}, errMsg: "name is required"},
+		{name: "No area", geofence: Geofence{GeofenceID: "depot", Name: "Depot"}, errMsg: "boundary or center is required"},
+		{
+			name:     "Both shapes",
+			geofence: Geofence{GeofenceID: "depot", Name: "Depot", Boundary: square, Center: center, RadiusMeters: 250},
+			errMsg:   "set either boundary or center and radiusMeters, not both",
+		},
+		{name: "Circle without radius", geofence: Geofence{GeofenceID: "yard", Name: "Yard", Center: center}, errMsg: "radiusMeters must be positive"},
+		{
+			name:     "Radius without center",
+			geofence: Geofence{GeofenceID: "depot", Name: "Depot", Boundary: square, RadiusMeters: 250},
+			errMsg:   "radiusMeters is only allowed with center",
+		},
+		{name: "Two points", geofence: Geofence{GeofenceID: "depot", Name: "Depot", Boundary: square[:2]}, errMsg: "boundary must have at least 3 points"},
+		{
+			name:     "Debounce too long",
+			geofence: Geofence{GeofenceID: "depot", Name: "Depot", Boundary: square, DebounceSeconds: 7200},
+			errMsg:   "debounceSeconds must be between 0 and 3600",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.geofence.Validate()
+			if tt.errMsg != "" {
+				assert.EqualError(t, err, tt.errMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateGeofences(t *testing.T) {
+	yard := Geofence{GeofenceID: "yard", Name: "Yard", Center: &Coordinates{Latitude: 39.74, Longitude: -104.99}, RadiusMeters: 250}
+
+	assert.NoError(t, ValidateGeofences(nil))
+	assert.EqualError(t, ValidateGeofences([]Geofence{yard, yard}), `geofences[1]: duplicate geofenceId "yard"`)
+	assert.EqualError(t, ValidateGeofences([]Geofence{yard, {GeofenceID: "depot"}}), "geofences[1]: name is required")
+
+	many := make([]Geofence, MaxGeofences+1)
+	assert.EqualError(t, ValidateGeofences(many), "an account can have at most 100 geofences")
+}
@@ -0,0 +1,124 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// maxPeriodsPerDay is the most open periods a day of OperatingHours can have.
+const maxPeriodsPerDay = 6
+
+// weekdays are the keys of OperatingHours.Weekly, indexed by time.Weekday.
+var weekdays = [7]string{"sunday", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday"}
+
+// OpenPeriod is a stretch of a day a shop is open, as 24-hour local "HH:MM"
+// times. A Close at or before Open runs past midnight into the next day, and
+// a Close of "24:00" ends at midnight.
+type OpenPeriod struct {
+	Open  string `json:"open" dynamodbav:"open"`
+	Close string `json:"close" dynamodbav:"close"`
+}
+
+// OperatingHours is a shop's weekly opening schedule in its own time zone.
+// Days missing from Weekly are closed.
+type OperatingHours struct {
+	// TimeZone is an IANA time zone, such as America/Chicago
+	TimeZone string `json:"timeZone" dynamodbav:"timeZone"`
+	// Weekly maps a lowercase weekday, such as monday, to its open periods
+	Weekly map[string][]OpenPeriod `json:"weekly" dynamodbav:"weekly"`
+}
+
+// parseClock parses an "HH:MM" time to minutes after midnight, allowing
+// "24:00" when end is set.
+func parseClock(clock string, end bool) (int, error) {
+	var hours, minutes int
+	if len(clock) != 5 || clock[2] != ':' {
+		return 0, fmt.Errorf("time %q must be HH:MM", clock)
+	}
+	if _, err := fmt.Sscanf(clock, "%02d:%02d", &hours, &minutes); err != nil || minutes > 59 {
+		return 0, fmt.Errorf("time %q must be HH:MM", clock)
+	}
+	if hours > 23 && !(end && hours == 24 && minutes == 0) {
+		return 0, fmt.Errorf("time %q must be HH:MM", clock)
+	}
+	return hours*60 + minutes, nil
+}
+
+// Validate validates the operating hours.
+func (h OperatingHours) Validate() error {
+	if h.TimeZone == "" {
+		return fmt.Errorf("hours: timeZone is required")
+	}
+	if _, err := time.LoadLocation(h.TimeZone); err != nil || h.TimeZone == "Local" {
+		return fmt.Errorf("hours: timeZone %q is not an IANA time zone", h.TimeZone)
+	}
+	for day, periods := range h.Weekly {
+		if !isWeekday(day) {
+			return fmt.Errorf("hours: %q is not a weekday; use one of %s", day, strings.Join(weekdays[:], ", "))
+		}
+		if len(periods) > maxPeriodsPerDay {
+			return fmt.Errorf("hours: %s can have at most %d periods", day, maxPeriodsPerDay)
+		}
+		for i, period := range periods {
+			open, err := parseClock(period.Open, false)
+			if err != nil {
+				return fmt.Errorf("hours: %s[%d]: %w", day, i, err)
+			}
+			close, err := parseClock(period.Close, true)
+			if err != nil {
+				return fmt.Errorf("hours: %s[%d]: %w", day, i, err)
+			}
+			if open == close {
+				return fmt.Errorf("hours: %s[%d]: open and close must differ", day, i)
+			}
+		}
+	}
+	return nil
+}
+
+// isWeekday reports whether day is a key of OperatingHours.Weekly.
+func isWeekday(day string) bool {
+	for _, weekday := range weekdays {
+		if day == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// OpenAt reports whether the shop is open at t, in the shop's time zone.
+// Hours that fail validation are never open.
+func (h OperatingHours) OpenAt(t time.Time) bool {
+	location, err := time.LoadLocation(h.TimeZone)
+	if err != nil {
+		return false
+	}
+	local := t.In(location)
+	now := local.Hour()*60 + local.Minute()
+	today := weekdays[local.Weekday()]
+	yesterday := weekdays[(local.Weekday()+6)%7]
+
+	for _, period := range h.Weekly[today] {
+		open, openErr := parseClock(period.Open, false)
+		close, closeErr := parseClock(period.Close, true)
+		if openErr != nil || closeErr != nil {
+			continue
+		}
+		if now >= open && (close <= open || now < close) {
+			return true
+		}
+	}
+	// Yesterday's periods running past midnight
+	for _, period := range h.Weekly[yesterday] {
+		open, openErr := parseClock(period.Open, false)
+		close, closeErr := parseClock(period.Close, true)
+		if openErr != nil || closeErr != nil {
+			continue
+		}
+		if close <= open && now < close {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOperatingHoursValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		hours  OperatingHours
+		errMsg string
+	}{
+		{name: "Valid", hours: OperatingHours{TimeZone: "America/Chicago", Weekly: map[string][]OpenPeriod{"monday": {{Open: "09:00", Close: "17:00"}}, "friday": {{Open: "18:00", Close: "02:00"}}}}},
+		{name: "Closes at midnight", hours: OperatingHours{TimeZone: "UTC", Weekly: map[string][]OpenPeriod{"sunday": {{Open: "12:00", Close: "24:00"}}}}},
+		{name: "Missing time zone", hours: OperatingHours{}, errMsg: "hours: timeZone is required"},
+		{name: "Unknown time zone", hours: OperatingHours{TimeZone: "Mars/Olympus"}, errMsg: `hours: timeZone "Mars/Olympus" is not an IANA time zone`},
+		{name: "Unknown day", hours: OperatingHours{TimeZone: "UTC", Weekly: map[string][]OpenPeriod{"Mon": {{Open: "09:00", Close: "17:00"}}}}, errMsg: `hours: "Mon" is not a weekday; use one of sunday, monday, tuesday, wednesday, thursday, friday, saturday`},
+		{name: "Malformed time", hours: OperatingHours{TimeZone: "UTC", Weekly: map[string][]OpenPeriod{"monday": {{Open: "9am", Close: "17:00"}}}}, errMsg: `hours: monday[0]: time "9am" must be HH:MM`},
+		{name: "Opens at 24:00", hours: OperatingHours{TimeZone: "UTC", Weekly: map[string][]OpenPeriod{"monday": {{Open: "24:00", Close: "02:00"}}}}, errMsg: `hours: monday[0]: time "24:00" must be HH:MM`},
+		{name: "Empty period", hours: OperatingHours{TimeZone: "UTC", Weekly: map[string][]OpenPeriod{"monday": {{Open: "09:00", Close: "09:00"}}}}, errMsg: "hours: monday[0]: open and close must differ"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.hours.Validate()
+			if tt.errMsg == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestOperatingHoursOpenAt(t *testing.T) {
+	hours := OperatingHours{TimeZone: "America/Chicago", Weekly: map[string][]OpenPeriod{
+		"monday": {{Open: "09:00", Close: "12:00"}, {Open: "13:00", Close: "17:00"}},
+		"friday": {{Open: "18:00", Close: "02:00"}},
+		"sunday": {{Open: "10:00", Close: "24:00"}},
+	}}
+	chicago, err := time.LoadLocation("America/Chicago")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name string
+		at   time.Time
+		open bool
+	}{
+		{name: "Morning period", at: time.Date(2024, time.June, 3, 9, 0, 0, 0, chicago), open: true},
+		{name: "Lunch break", at: time.Date(2024, time.June, 3, 12, 30, 0, 0, chicago), open: false},
+		{name: "At closing time", at: time.Date(2024, time.June, 3, 17, 0, 0, 0, chicago), open: false},
+		{name: "Closed day", at: time.Date(2024, time.June, 4, 10, 0, 0, 0, chicago), open: false},
+		{name: "Overnight before midnight", at: time.Date(2024, time.June, 7, 23, 0, 0, 0, chicago), open: true},
+		{name: "Overnight into Saturday", at: time.Date(2024, time.June, 8, 1, 30, 0, 0, chicago), open: true},
+		{name: "After overnight close", at: time.Date(2024, time.June, 8, 2, 0, 0, 0, chicago), open: false},
+		{name: "Until midnight", at: time.Date(2024, time.June, 2, 23, 59, 0, 0, chicago), open: true},
+		{name: "Converted from UTC", at: time.Date(2024, time.June, 3, 15, 0, 0, 0, time.UTC), open: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.open, hours.OpenAt(tt.at))
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// maxIndoorLabelLength is the longest building or floor name accepted.
+const maxIndoorLabelLength = 128
+
+// IndoorCoordinates place a location on a floor plan image, measured from the
+// plan's top-left corner in the plan's own units, such as pixels or meters.
+type IndoorCoordinates struct {
+	X float64 `json:"x" dynamodbav:"x"`
+	Y float64 `json:"y" dynamodbav:"y"`
+	// FloorPlanKey is the asset bucket key of the floor plan X and Y refer to
+	FloorPlanKey string `json:"floorPlanKey" dynamodbav:"floorPlanKey"`
+}
+
+// Validate validates the indoor coordinates.
+func (c IndoorCoordinates) Validate() error {
+	if c.X < 0 || c.Y < 0 {
+		return fmt.Errorf("indoorCoordinates: x and y must be non-negative, got %f, %f", c.X, c.Y)
+	}
+	if c.FloorPlanKey == "" {
+		return errors.New("indoorCoordinates: floorPlanKey is required")
+	}
+	if err := ValidateAssetKey(c.FloorPlanKey); err != nil {
+		return fmt.Errorf("indoorCoordinates: floorPlanKey: %w", err)
+	}
+	return nil
+}
+
+// validateIndoorPosition records the problems with a coordinates location's
+// building, floor, and indoor coordinates.
+func (l CoordinatesLocation) validateIndoorPosition(f *fieldErrors) {
+	for _, label := range []struct{ name, value string }{{"building", l.Building}, {"floor", l.Floor}} {
+		if strings.TrimSpace(label.value) != label.value {
+			f.addMessage(label.name, fmt.Sprintf("%s must not have leading or trailing whitespace", label.name))
+		} else if len(label.value) > maxIndoorLabelLength {
+			f.addMessage(label.name, fmt.Sprintf("%s must be at most %d characters", label.name, maxIndoorLabelLength))
+		}
+	}
+	if l.IndoorCoordinates != nil {
+		f.add("indoorCoordinates", l.IndoorCoordinates.Validate())
+	}
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoordinatesLocationIndoorValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		building string
+		floor    string
+		indoor   *IndoorCoordinates
+		errMsg   string
+	}{
+		{name: "Outdoors"},
+		{
+			name:     "On a floor plan",
+			building: "Warehouse 3",
+			floor:    "Mezzanine",
+			indoor:   &IndoorCoordinates{X: 120, Y: 45.5, FloorPlanKey: "acc-12345/warehouse-3.png"},
+		},
+		{name: "Floor without a plan", floor: "B1"},
+		{name: "Padded building", building: "Warehouse 3 ", errMsg: "building must not have leading or trailing whitespace"},
+		{name: "Long floor", floor: strings.Repeat("1", 129), errMsg: "floor must be at most 128 characters"},
+		{
+			name:   "Negative position",
+			indoor: &IndoorCoordinates{X: -1, Y: 10, FloorPlanKey: "acc-12345/warehouse-3.png"},
+			errMsg: "indoorCoordinates: x and y must be non-negative",
+		},
+		{
+			name:   "Missing floor plan",
+			indoor: &IndoorCoordinates{X: 1, Y: 10},
+			errMsg: "indoorCoordinates: floorPlanKey is required",
+		},
+		{
+			name:   "Floor plan URL",
+			indoor: &IndoorCoordinates{X: 1, Y: 10, FloorPlanKey: "https://example.com/plan.png"},
+			errMsg: "indoorCoordinates: floorPlanKey: asset key \"https://example.com/plan.png\" must be an object key, not a path or URL",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			location := CoordinatesLocation{
+				LocationBase:      LocationBase{AccountID: "acc-12345", LocationType: LocationTypeCoordinates},
+				Coordinates:       Coordinates{Latitude: 39.7817, Longitude: -89.6501},
+				Building:          tt.building,
+				Floor:             tt.floor,
+				IndoorCoordinates: tt.indoor,
+			}
+			err := location.Validate()
+			if tt.errMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMsg)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+)
+
+// lenientRule lets lenient validation drop an optional field, rather than
+// reject the location, when the field fails validation.
+type lenientRule struct {
+	// locationType is the type of location with the field, or empty for all
+	locationType LocationType
+	// field is the path of the optional field
+	field string
+	// perEntry drops only the failing entry of a list or map field
+	perEntry bool
+	// drop removes target, the field or one of its entries, from the location
+	drop func(location Location, target string) Location
+}
+
+// lenientRules are the optional fields lenient validation may drop. Fields
+// identifying or placing the location, such as accountId, an address
+// location's address, or a coordinates location's coordinates, stay strict.
+var lenientRules = []lenientRule{
+	baseRule("addresses", true, func(l *LocationBase, target string) {
+		l.Addresses = withoutKey(l.Addresses, target, "addresses")
+	}),
+	addressRule("coordinates", false, func(l *AddressLocation, target string) {
+		// Geocode and coordinatesLocked need the coordinates they describe
+		l.Coordinates, l.Geocode, l.CoordinatesLocked = nil, nil, false
+	}),
+	addressRule("coordinatesLocked", false, func(l *AddressLocation, target string) { l.CoordinatesLocked = false }),
+	addressRule("geocode", false, func(l *AddressLocation, target string) { l.Geocode = nil }),
+	addressRule("units", true, func(l *AddressLocation, target string) { l.Units = withoutEntry(l.Units, target, "units") }),
+	coordinatesRule("building", func(l *CoordinatesLocation) { l.Building = "" }),
+	coordinatesRule("floor", func(l *CoordinatesLocation) { l.Floor = "" }),
+	coordinatesRule("indoorCoordinates", func(l *CoordinatesLocation) { l.IndoorCoordinates = nil }),
+	eventRule("recurrence", func(l *EventLocation) { l.Recurrence = nil }),
+	shopRule("coordinates", false, func(s *Shop, target string) { s.Coordinates = nil }),
+	shopRule("phone", false, func(s *Shop, target string) { s.Phone = "" }),
+	shopRule("email", false, func(s *Shop, target string) { s.Email = "" }),
+	shopRule("websiteUrl", false, func(s *Shop, target string) { s.WebsiteURL = "" }),
+	shopRule("socialLinks", true, func(s *Shop, target string) {
+		s.SocialLinks = withoutKey(s.SocialLinks, target, "socialLinks")
+	}),
+	shopRule("categories", false, func(s *Shop, target string) { s.Categories = nil }),
+	shopRule("logoKey", false, func(s *Shop, target string) { s.LogoKey = "" }),
+	shopRule("photoKeys", true, func(s *Shop, target string) { s.PhotoKeys = withoutEntry(s.PhotoKeys, target, "photoKeys") }),
+	shopRule("brandColor", false, func(s *Shop, target string) { s.BrandColor = "" }),
+	shopRule("hours", false, func(s *Shop, target string) { s.Hours = nil }),
+	shopRule("recurrence", false, func(s *Shop, target string) { s.Recurrence = nil }),
+	shopRule("deliveryZones", true, func(s *Shop, target string) {
+		s.DeliveryZones = withoutEntry(s.DeliveryZones, target, "deliveryZones")
+	}),
+	shopRule("contacts", true, func(s *Shop, target string) { s.Contacts = withoutEntry(s.Contacts, target, "contacts") }),
+}
+
+// Relax prepares a location for lenient validation: each optional field, or
+// entry of an optional list or map, that fails validation is dropped. It
+// returns the location without them and the problems that dropped them,
+// which callers report as warnings. Problems with other fields are left for
+// Validate to reject.
+func Relax(location Location) (Location, ValidationErrors) {
+	errs, _ := location.Validate().(ValidationErrors)
+
+	var dropped ValidationErrors
+	var targets []string
+	rules := make(map[string]lenientRule)
+	for _, fieldErr := range errs {
+		rule, ok := lenientRuleFor(location, fieldErr.Field)
+		if !ok {
+			continue
+		}
+		dropped = append(dropped, fieldErr)
+		target := rule.target(fieldErr.Field)
+		if _, seen := rules[target]; !seen {
+			targets = append(targets, target)
+			rules[target] = rule
+		}
+	}
+
+	// Problems are found in index order, so dropping the last first keeps
+	// the indexes of the rest
+	for i := len(targets) - 1; i >= 0; i-- {
+		location = rules[targets[i]].drop(location, targets[i])
+	}
+	return location, dropped
+}
+
+// lenientRuleFor returns the rule covering field of location, if any.
+func lenientRuleFor(location Location, field string) (lenientRule, bool) {
+	for _, rule := range lenientRules {
+		if (rule.locationType == "" || rule.locationType == location.GetLocationType()) && fieldPathWithin(field, rule.field) {
+			return rule, true
+		}
+	}
+	return lenientRule{}, false
+}
+
+// target returns what the rule drops for a problem with field: the entry
+// field is within for per-entry rules, otherwise the whole field.
+func (r lenientRule) target(field string) string {
+	rest := field[len(r.field):]
+	if !r.perEntry || rest == "" {
+		return r.field
+	}
+	if end := strings.IndexAny(rest[1:], ".["); end >= 0 {
+		rest = rest[:end+1]
+	}
+	return r.field + rest
+}
+
+// withoutEntry returns entries without the one target, such as units[2],
+// selects in the list at field; a target of the whole list drops them all.
+func withoutEntry[T any](entries []T, target, field string) []T {
+	index, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(target, field+"["), "]"))
+	if err != nil || index < 0 || index >= len(entries) {
+		return nil
+	}
+	return append(append([]T(nil), entries[:index]...), entries[index+1:]...)
+}
+
+// withoutKey returns entries without the one target, such as
+// socialLinks.myspace, selects in the map at field; a target of the whole
+// map drops them all.
+func withoutKey[K ~string, V any](entries map[K]V, target, field string) map[K]V {
+	key := strings.TrimPrefix(target, field+".")
+	if key == target {
+		return nil
+	}
+	kept := make(map[K]V, len(entries))
+	for k, v := range entries {
+		if string(k) != key {
+			kept[k] = v
+		}
+	}
+	return kept
+}
+
+// baseRule covers a field every location type shares.
+func baseRule(field string, perEntry bool, drop func(l *LocationBase, target string)) lenientRule {
+	return lenientRule{field: field, perEntry: perEntry, drop: func(location Location, target string) Location {
+		switch l := location.(type) {
+		case AddressLocation:
+			drop(&l.LocationBase, target)
+			return l
+		case CoordinatesLocation:
+			drop(&l.LocationBase, target)
+			return l
+		case ShopLocation:
+			drop(&l.LocationBase, target)
+			return l
+		case EventLocation:
+			drop(&l.LocationBase, target)
+			return l
+		}
+		return location
+	}}
+}
+
+// addressRule covers a field of address locations.
+func addressRule(field string, perEntry bool, drop func(l *AddressLocation, target string)) lenientRule {
+	return lenientRule{locationType: LocationTypeAddress, field: field, perEntry: perEntry, drop: func(location Location, target string) Location {
+		l, ok := location.(AddressLocation)
+		if !ok {
+			return location
+		}
+		drop(&l, target)
+		return l
+	}}
+}
+
+// coordinatesRule covers a field of coordinates locations.
+func coordinatesRule(field string, drop func(l *CoordinatesLocation)) lenientRule {
+	return lenientRule{locationType: LocationTypeCoordinates, field: field, drop: func(location Location, target string) Location {
+		l, ok := location.(CoordinatesLocation)
+		if !ok {
+			return location
+		}
+		drop(&l)
+		return l
+	}}
+}
+
+// eventRule covers a field of event locations.
+func eventRule(field string, drop func(l *EventLocation)) lenientRule {
+	return lenientRule{locationType: LocationTypeEvent, field: field, drop: func(location Location, target string) Location {
+		l, ok := location.(EventLocation)
+		if !ok {
+			return location
+		}
+		drop(&l)
+		return l
+	}}
+}
+
+// shopRule covers a field of a shop location's shop.
+func shopRule(field string, perEntry bool, drop func(s *Shop, target string)) lenientRule {
+	return lenientRule{locationType: LocationTypeShop, field: "shop." + field, perEntry: perEntry, drop: func(location Location, target string) Location {
+		l, ok := location.(ShopLocation)
+		if !ok {
+			return location
+		}
+		drop(&l.Shop, strings.TrimPrefix(target, "shop."))
+		return l
+	}}
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelax(t *testing.T) {
+	address := Address{StreetAddress: "123 Main St", City: "Springfield", PostalCode: "12345", Country: "US"}
+
+	t.Run("Drops invalid optional fields of a shop", func(t *testing.T) {
+		location := ShopLocation{
+			LocationBase: LocationBase{AccountID: "acc-12345", LocationType: LocationTypeShop},
+			Shop: Shop{
+				Name:        "Coffee Shop",
+				ContactID:   "contact-1",
+				Address:     address,
+				Email:       "not-an-email",
+				BrandColor:  "blue",
+				SocialLinks: map[string]string{"myspace": "https://myspace.com/coffee", "instagram": "https://instagram.com/coffee"},
+				Contacts: []ShopContact{
+					{ContactID: "contact-2", Role: ShopContactRoleManager},
+					{Role: "owner"},
+					{ContactID: "contact-3", Role: ShopContactRoleBilling},
+				},
+			},
+		}
+
+		relaxed, dropped := Relax(location)
+		require.NoError(t, relaxed.Validate())
+		assert.Equal(t, []string{"shop.email", "shop.socialLinks.myspace", "shop.brandColor", "shop.contacts[1].contactId", "shop.contacts[1].role"}, fieldPaths(dropped))
+
+		shop := relaxed.(ShopLocation).Shop
+		assert.Empty(t, shop.Email)
+		assert.Empty(t, shop.BrandColor)
+		assert.Equal(t, map[string]string{"instagram": "https://instagram.com/coffee"}, shop.SocialLinks)
+		assert.Equal(t, []ShopContact{
+			{ContactID: "contact-2", Role: ShopContactRoleManager},
+			{ContactID: "contact-3", Role: ShopContactRoleBilling},
+		}, shop.Contacts)
+		assert.Equal(t, "not-an-email", location.Shop.Email, "the original location is left as is")
+	})
+
+	t.Run("Drops an address location's coordinates with their geocode", func(t *testing.T) {
+		location := AddressLocation{
+			LocationBase: LocationBase{AccountID: "acc-12345", LocationType: LocationTypeAddress},
+			Address:      address,
+			Coordinates:  &Coordinates{Latitude: 95, Longitude: -104.99},
+			Units:        []Unit{{Number: "1"}, {Number: "1"}},
+		}
+
+		relaxed, dropped := Relax(location)
+		require.NoError(t, relaxed.Validate())
+		assert.Equal(t, []string{"coordinates.latitude", "units[1].number"}, fieldPaths(dropped))
+		assert.Nil(t, relaxed.(AddressLocation).Coordinates)
+		assert.Equal(t, []Unit{{Number: "1"}}, relaxed.(AddressLocation).Units)
+	})
+
+	t.Run("Leaves required fields for validation to reject", func(t *testing.T) {
+		location := CoordinatesLocation{
+			LocationBase: LocationBase{LocationType: LocationTypeCoordinates},
+			Coordinates:  Coordinates{Latitude: 95, Longitude: -104.99},
+		}
+
+		relaxed, dropped := Relax(location)
+		assert.Empty(t, dropped)
+		assert.Equal(t, location, relaxed)
+		assert.Error(t, relaxed.Validate())
+	})
+}
@@ -0,0 +1,145 @@
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// MaxURLLength is the longest website or social link a shop can store.
+const MaxURLLength = 2048
+
+// trackingParams are query parameters added by ad and analytics tools, which
+// NormalizeURL removes. Parameters starting with utm_ are removed as well.
+var trackingParams = map[string]bool{
+	"fbclid": true, "gclid": true, "dclid": true, "gbraid": true, "wbraid": true,
+	"msclkid": true, "mc_cid": true, "mc_eid": true, "igshid": true, "_ga": true, "_gl": true,
+}
+
+// socialNetworks maps the networks a shop can link to onto the domains their
+// profile URLs are on.
+var socialNetworks = map[string][]string{
+	"facebook":  {"facebook.com", "fb.com"},
+	"instagram": {"instagram.com"},
+	"linkedin":  {"linkedin.com"},
+	"pinterest": {"pinterest.com"},
+	"tiktok":    {"tiktok.com"},
+	"x":         {"x.com", "twitter.com"},
+	"yelp":      {"yelp.com"},
+	"youtube":   {"youtube.com", "youtu.be"},
+}
+
+// NormalizeURL checks that raw is an absolute http or https URL with a host,
+// and returns it with the scheme and host lowercased and tracking parameters
+// removed.
+func NormalizeURL(raw string) (string, error) {
+	if len(raw) > MaxURLLength {
+		return "", fmt.Errorf("must be at most %d characters", MaxURLLength)
+	}
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return "", fmt.Errorf("must be a valid URL, got %q", raw)
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("must be an http or https URL, got %q", raw)
+	}
+	if u.Hostname() == "" || u.User != nil {
+		return "", fmt.Errorf("must have a host and no credentials, got %q", raw)
+	}
+	u.Host = strings.ToLower(u.Host)
+
+	if u.RawQuery != "" {
+		query := u.Query()
+		for key := range query {
+			if trackingParams[strings.ToLower(key)] || strings.HasPrefix(strings.ToLower(key), "utm_") {
+				query.Del(key)
+			}
+		}
+		u.RawQuery = query.Encode()
+	}
+	return u.String(), nil
+}
+
+// WebsiteHost returns the host of a website URL without a leading "www.",
+// which is how shops are found by website. It returns an empty string for
+// URLs NormalizeURL rejects.
+func WebsiteHost(raw string) string {
+	normalized, err := NormalizeURL(raw)
+	if err != nil {
+		return ""
+	}
+	u, _ := url.Parse(normalized)
+	return strings.TrimPrefix(u.Hostname(), "www.")
+}
+
+// validateSocialLinks checks that every link is to a known network's domain.
+func validateSocialLinks(links map[string]string) error {
+	networks := make([]string, 0, len(links))
+	for network := range links {
+		networks = append(networks, network)
+	}
+	sort.Strings(networks)
+
+	var f fieldErrors
+	for _, network := range networks {
+		path := "socialLinks." + network
+		domains, ok := socialNetworks[network]
+		if !ok {
+			f.addMessage(path, fmt.Sprintf("%s is not a known network; use one of %s", network, strings.Join(SocialNetworks(), ", ")))
+			continue
+		}
+		normalized, err := NormalizeURL(links[network])
+		if err != nil {
+			f.addMessage(path, fmt.Sprintf("%s %s", network, err))
+			continue
+		}
+		u, _ := url.Parse(normalized)
+		if !onDomain(u.Hostname(), domains) {
+			f.addMessage(path, fmt.Sprintf("%s must be a link to %s, got %q", network, strings.Join(domains, " or "), links[network]))
+		}
+	}
+	return f.err()
+}
+
+// onDomain reports whether host is one of domains or a subdomain of one.
+func onDomain(host string, domains []string) bool {
+	for _, domain := range domains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// SocialNetworks returns the networks a shop's socialLinks can name, sorted.
+func SocialNetworks() []string {
+	networks := make([]string, 0, len(socialNetworks))
+	for network := range socialNetworks {
+		networks = append(networks, network)
+	}
+	sort.Strings(networks)
+	return networks
+}
+
+// NormalizeLinks normalizes the shop's website and social links in place,
+// leaving any that NormalizeURL rejects for Validate to report.
+func (s *Shop) NormalizeLinks() {
+	if s.WebsiteURL != "" {
+		if normalized, err := NormalizeURL(s.WebsiteURL); err == nil {
+			s.WebsiteURL = normalized
+		}
+	}
+	if len(s.SocialLinks) == 0 {
+		return
+	}
+	links := make(map[string]string, len(s.SocialLinks))
+	for network, link := range s.SocialLinks {
+		if normalized, err := NormalizeURL(link); err == nil {
+			link = normalized
+		}
+		links[network] = link
+	}
+	s.SocialLinks = links
+}
@@ -2,9 +2,14 @@
 package models
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // LocationType represents the type of location.
@@ -17,6 +22,8 @@ const (
 	LocationTypeCoordinates LocationType = "coordinates"
 	// LocationTypeShop represents a shop location with business details.
 	LocationTypeShop LocationType = "shop"
+	// LocationTypeEvent represents a temporary location with a start and end time.
+	LocationTypeEvent LocationType = "event"
 )
 
 // Location is the base interface for all location types.
@@ -24,14 +31,38 @@ type Location interface {
 	GetAccountID() string
 	GetLocationType() LocationType
 	GetExtendedAttributes() map[string]interface{}
+	GetExternalID() string
+	GetCustomFields() map[string]interface{}
+	IsDraft() bool
+	GetActiveFrom() *time.Time
+	GetActiveUntil() *time.Time
+	IsActiveAt(now time.Time) bool
+	GetAddresses() map[AddressKind]Address
 	Validate() error
 }
 
+// MaxExternalIDLength is the longest externalId accepted.
+const MaxExternalIDLength = 256
+
 // LocationBase contains common fields for all location types.
 type LocationBase struct {
 	AccountID          string                 `json:"accountId" dynamodbav:"accountId"`
 	LocationType       LocationType           `json:"locationType" dynamodbav:"locationType"`
 	ExtendedAttributes map[string]interface{} `json:"extendedAttributes,omitempty" dynamodbav:"extendedAttributes,omitempty"`
+	// ExternalID is the caller's own identifier for the location, unique within the account
+	ExternalID string `json:"externalId,omitempty" dynamodbav:"externalId,omitempty"`
+	// CustomFields holds values of the account's declared custom fields, unlike
+	// free-form ExtendedAttributes
+	CustomFields map[string]interface{} `json:"customFields,omitempty" dynamodbav:"customFields,omitempty"`
+	// Draft keeps a staged location out of lists and searches until it is published
+	Draft bool `json:"draft,omitempty" dynamodbav:"draft,omitempty"`
+	// ActiveFrom and ActiveUntil bound when a seasonal location is listed;
+	// either may be left open
+	ActiveFrom  *time.Time `json:"activeFrom,omitempty" dynamodbav:"activeFrom,omitempty"`
+	ActiveUntil *time.Time `json:"activeUntil,omitempty" dynamodbav:"activeUntil,omitempty"`
+	// Addresses holds further addresses by kind, such as a billing address
+	// alongside the physical one
+	Addresses map[AddressKind]Address `json:"addresses,omitempty" dynamodbav:"addresses,omitempty"`
 }
 
 // GetAccountID returns the account ID.
@@ -49,6 +80,87 @@ func (l LocationBase) GetExtendedAttributes() map[string]interface{} {
 	return l.ExtendedAttributes
 }
 
+// GetExternalID returns the external ID.
+func (l LocationBase) GetExternalID() string {
+	return l.ExternalID
+}
+
+// GetCustomFields returns the custom field values.
+func (l LocationBase) GetCustomFields() map[string]interface{} {
+	return l.CustomFields
+}
+
+// IsDraft reports whether the location is an unpublished draft.
+func (l LocationBase) IsDraft() bool {
+	return l.Draft
+}
+
+// GetActiveFrom returns the start of the active window, if any.
+func (l LocationBase) GetActiveFrom() *time.Time {
+	return l.ActiveFrom
+}
+
+// GetActiveUntil returns the end of the active window, if any.
+func (l LocationBase) GetActiveUntil() *time.Time {
+	return l.ActiveUntil
+}
+
+// IsActiveAt reports whether now falls within the location's active window,
+// which includes ActiveFrom and excludes ActiveUntil.
+func (l LocationBase) IsActiveAt(now time.Time) bool {
+	if l.ActiveFrom != nil && now.Before(*l.ActiveFrom) {
+		return false
+	}
+	return l.ActiveUntil == nil || now.Before(*l.ActiveUntil)
+}
+
+// GetAddresses returns the typed addresses.
+func (l LocationBase) GetAddresses() map[AddressKind]Address {
+	return l.Addresses
+}
+
+// validateBase records the problems with the fields every location type
+// shares. hasPrimary is passed through to validateAddresses.
+func (l LocationBase) validateBase(f *fieldErrors, want LocationType, hasPrimary bool) {
+	if l.AccountID == "" {
+		f.addMessage("accountId", "accountId is required")
+	}
+	if l.LocationType != want {
+		f.addMessage("locationType", fmt.Sprintf("invalid locationType for %s: %s", locationTypeNames[want], l.LocationType))
+	}
+	f.add("externalId", l.validateExternalID())
+	f.add("activeUntil", l.validateSchedule())
+	f.add("", l.validateAddresses(hasPrimary))
+}
+
+// locationTypeNames are the Go types of the location types, as named in
+// locationType errors.
+var locationTypeNames = map[LocationType]string{
+	LocationTypeAddress:     "AddressLocation",
+	LocationTypeCoordinates: "CoordinatesLocation",
+	LocationTypeShop:        "ShopLocation",
+	LocationTypeEvent:       "EventLocation",
+}
+
+// validateSchedule validates the optional active window.
+func (l LocationBase) validateSchedule() error {
+	if l.ActiveFrom != nil && l.ActiveUntil != nil && !l.ActiveUntil.After(*l.ActiveFrom) {
+		return errors.New("activeUntil must be after activeFrom")
+	}
+	return nil
+}
+
+// validateExternalID validates the optional external ID.
+func (l LocationBase) validateExternalID() error {
+	if len(l.ExternalID) > MaxExternalIDLength {
+		return fmt.Errorf("externalId must be at most %d characters", MaxExternalIDLength)
+	}
+	if l.ExternalID != "" && strings.TrimSpace(l.ExternalID) != l.ExternalID {
+		return errors.New("externalId must not have leading or trailing whitespace")
+	}
+	return nil
+}
+
 // Address represents a mailing address.
 type Address struct {
 	StreetAddress  string `json:"streetAddress" dynamodbav:"streetAddress"`
@@ -57,43 +169,66 @@ type Address struct {
 	StateProvince  string `json:"stateProvince,omitempty" dynamodbav:"stateProvince,omitempty"`
 	PostalCode     string `json:"postalCode" dynamodbav:"postalCode"`
 	Country        string `json:"country" dynamodbav:"country"`
+	// Verification is the latest provider verification of this address, if any
+	Verification *AddressVerification `json:"verification,omitempty" dynamodbav:"verification,omitempty"`
 }
 
-// Validate validates the address fields.
+// Validate validates the address fields, returning ValidationErrors listing
+// every problem.
 func (a Address) Validate() error {
+	var f fieldErrors
 	if a.StreetAddress == "" {
-		return errors.New("streetAddress is required")
+		f.addMessage("streetAddress", "streetAddress is required")
 	}
 	if a.City == "" {
-		return errors.New("city is required")
+		f.addMessage("city", "city is required")
 	}
 	if a.PostalCode == "" {
-		return errors.New("postalCode is required")
+		f.addMessage("postalCode", "postalCode is required")
 	}
 	if a.Country == "" {
-		return errors.New("country is required")
+		f.addMessage("country", "country is required")
+	} else if len(a.Country) != 2 {
+		f.addMessage("country", "country must be a 2-character ISO 3166-1 alpha-2 code")
 	}
-	if len(a.Country) != 2 {
-		return errors.New("country must be a 2-character ISO 3166-1 alpha-2 code")
-	}
-	return nil
+	return f.err()
 }
 
 // AddressLocation represents a location specified by mailing address.
 type AddressLocation struct {
 	LocationBase
 	Address Address `json:"address" dynamodbav:"address"`
+	// Coordinates pin the address on a map, once geocoded or entered by hand
+	Coordinates *Coordinates `json:"coordinates,omitempty" dynamodbav:"coordinates,omitempty"`
+	// Geocode records where geocoded coordinates came from
+	Geocode *GeocodeInfo `json:"geocode,omitempty" dynamodbav:"geocode,omitempty"`
+	// CoordinatesLocked keeps geocoding from replacing hand-pinned coordinates
+	CoordinatesLocked bool `json:"coordinatesLocked,omitempty" dynamodbav:"coordinatesLocked,omitempty"`
+	// Units are the suites or apartments within the building at the address
+	Units []Unit `json:"units,omitempty" dynamodbav:"units,omitempty"`
 }
 
-// Validate validates the address location.
+// Validate validates the address location, returning ValidationErrors
+// listing every problem.
 func (l AddressLocation) Validate() error {
-	if l.AccountID == "" {
-		return errors.New("accountId is required")
+	var f fieldErrors
+	l.validateBase(&f, LocationTypeAddress, true)
+	if l.Coordinates != nil {
+		f.add("coordinates", l.Coordinates.Validate())
 	}
-	if l.LocationType != LocationTypeAddress {
-		return fmt.Errorf("invalid locationType for AddressLocation: %s", l.LocationType)
+	if l.CoordinatesLocked && l.Coordinates == nil {
+		f.addMessage("coordinatesLocked", "coordinatesLocked requires coordinates")
 	}
-	return l.Address.Validate()
+	if l.Geocode != nil {
+		if l.Coordinates == nil {
+			f.addMessage("geocode", "geocode requires coordinates")
+		} else {
+			f.add("geocode", l.Geocode.Validate())
+		}
+	}
+	f.add("", validateUnits(l.Units))
+	f.add("address", l.Address.Validate())
+	return f.err()
 }
 
 // Coordinates represents GPS coordinates.
@@ -102,37 +237,71 @@ type Coordinates struct {
 	Longitude float64  `json:"longitude" dynamodbav:"longitude"`
 	Altitude  *float64 `json:"altitude,omitempty" dynamodbav:"altitude,omitempty"`
 	Accuracy  *float64 `json:"accuracy,omitempty" dynamodbav:"accuracy,omitempty"`
+	// UTM or MGRS is the grid reference the coordinates were entered as,
+	// such as 18T 585628 4511322, kept alongside the converted latitude and
+	// longitude
+	UTM  string `json:"utm,omitempty" dynamodbav:"utm,omitempty"`
+	MGRS string `json:"mgrs,omitempty" dynamodbav:"mgrs,omitempty"`
+	// CRS is the datum the coordinates were entered in. Latitude and
+	// longitude are stored as WGS84, so CRS is WGS84 once they have been
+	// converted, unless it gives the datum of a grid reference
+	CRS string `json:"crs,omitempty" dynamodbav:"crs,omitempty"`
+	// ObservedAt is when the position was fixed, such as a vehicle's GPS
+	// timestamp, which position checks measure speed by
+	ObservedAt *time.Time `json:"observedAt,omitempty" dynamodbav:"observedAt,omitempty"`
 }
 
-// Validate validates the coordinates.
+// Coordinate reference systems coordinates can be entered in.
+const (
+	CRSWGS84 = "WGS84"
+	CRSNAD83 = "NAD83"
+)
+
+// Validate validates the coordinates, returning ValidationErrors listing
+// every problem.
 func (c Coordinates) Validate() error {
+	var f fieldErrors
 	if c.Latitude < -90 || c.Latitude > 90 {
-		return fmt.Errorf("latitude must be between -90 and 90, got %f", c.Latitude)
+		f.addMessage("latitude", fmt.Sprintf("latitude must be between -90 and 90, got %f", c.Latitude))
 	}
 	if c.Longitude < -180 || c.Longitude > 180 {
-		return fmt.Errorf("longitude must be between -180 and 180, got %f", c.Longitude)
+		f.addMessage("longitude", fmt.Sprintf("longitude must be between -180 and 180, got %f", c.Longitude))
 	}
 	if c.Accuracy != nil && *c.Accuracy < 0 {
-		return fmt.Errorf("accuracy must be non-negative, got %f", *c.Accuracy)
+		f.addMessage("accuracy", fmt.Sprintf("accuracy must be non-negative, got %f", *c.Accuracy))
 	}
-	return nil
+	if c.UTM != "" && c.MGRS != "" {
+		f.addMessage("", "coordinates can have utm or mgrs, not both")
+	}
+	if c.CRS != "" && c.CRS != CRSWGS84 && c.CRS != CRSNAD83 {
+		f.addMessage("crs", fmt.Sprintf("crs must be %s or %s, got %q", CRSWGS84, CRSNAD83, c.CRS))
+	}
+	return f.err()
 }
 
 // CoordinatesLocation represents a location specified by GPS coordinates.
 type CoordinatesLocation struct {
 	LocationBase
 	Coordinates Coordinates `json:"coordinates" dynamodbav:"coordinates"`
+	// Building and Floor place the point within a campus or warehouse, such
+	// as Warehouse 3 and Mezzanine
+	Building string `json:"building,omitempty" dynamodbav:"building,omitempty"`
+	Floor    string `json:"floor,omitempty" dynamodbav:"floor,omitempty"`
+	// IndoorCoordinates place the point on a floor plan
+	IndoorCoordinates *IndoorCoordinates `json:"indoorCoordinates,omitempty" dynamodbav:"indoorCoordinates,omitempty"`
+	// PositionAnomaly is set by updates the account's position checks
+	// flagged, and cleared by the next plausible one
+	PositionAnomaly *PositionAnomaly `json:"positionAnomaly,omitempty" dynamodbav:"positionAnomaly,omitempty"`
 }
 
-// Validate validates the coordinates location.
+// Validate validates the coordinates location, returning ValidationErrors
+// listing every problem.
 func (l CoordinatesLocation) Validate() error {
-	if l.AccountID == "" {
-		return errors.New("accountId is required")
-	}
-	if l.LocationType != LocationTypeCoordinates {
-		return fmt.Errorf("invalid locationType for CoordinatesLocation: %s", l.LocationType)
-	}
-	return l.Coordinates.Validate()
+	var f fieldErrors
+	l.validateBase(&f, LocationTypeCoordinates, false)
+	l.validateIndoorPosition(&f)
+	f.add("coordinates", l.Coordinates.Validate())
+	return f.err()
 }
 
 // Shop represents a shop or business location with address information.
@@ -140,70 +309,284 @@ type Shop struct {
 	Name      string  `json:"name" dynamodbav:"name"`
 	ContactID string  `json:"contactId" dynamodbav:"contactId"`
 	Address   Address `json:"address" dynamodbav:"address"`
+	// Coordinates pin the shop on a map, such as a store locator's
+	Coordinates *Coordinates `json:"coordinates,omitempty" dynamodbav:"coordinates,omitempty"`
+	// Phone is in E.164 format, such as +15551234567
+	Phone      string `json:"phone,omitempty" dynamodbav:"phone,omitempty"`
+	Email      string `json:"email,omitempty" dynamodbav:"email,omitempty"`
+	WebsiteURL string `json:"websiteUrl,omitempty" dynamodbav:"websiteUrl,omitempty"`
+	// SocialLinks maps a network, such as instagram, to the shop's profile URL there
+	SocialLinks map[string]string `json:"socialLinks,omitempty" dynamodbav:"socialLinks,omitempty"`
+	// Categories classify the shop's business in its account's taxonomy
+	Categories []string `json:"categories,omitempty" dynamodbav:"categories,omitempty"`
+	// ContactDeleted is set when the contacts service deletes the primary
+	// contact, until the shop is next saved
+	ContactDeleted bool `json:"contactDeleted,omitempty" dynamodbav:"contactDeleted,omitempty"`
+	// Contacts lists further contacts by role, alongside the primary ContactID
+	Contacts []ShopContact `json:"contacts,omitempty" dynamodbav:"contacts,omitempty"`
+	// LogoKey and PhotoKeys are object keys in the asset bucket, resolved to
+	// URLs at read time
+	LogoKey   string   `json:"logoKey,omitempty" dynamodbav:"logoKey,omitempty"`
+	PhotoKeys []string `json:"photoKeys,omitempty" dynamodbav:"photoKeys,omitempty"`
+	// BrandColor is a hex color such as #1A2B3C
+	BrandColor string `json:"brandColor,omitempty" dynamodbav:"brandColor,omitempty"`
+	// Hours is the shop's weekly opening schedule, if known
+	Hours *OperatingHours `json:"hours,omitempty" dynamodbav:"hours,omitempty"`
+	// Recurrence is when a mobile vendor trades at this site, such as Saturdays
+	// from 8am to 1pm
+	Recurrence *Recurrence `json:"recurrence,omitempty" dynamodbav:"recurrence,omitempty"`
+	// DeliveryZones are the areas the shop delivers to, with their fees
+	DeliveryZones []DeliveryZone `json:"deliveryZones,omitempty" dynamodbav:"deliveryZones,omitempty"`
+	// Enrichment is the latest places provider lookup for this shop, if any
+	Enrichment *Enrichment `json:"enrichment,omitempty" dynamodbav:"enrichment,omitempty"`
+}
+
+// UnmarshalJSON accepts both the nested shape, with the shop's address under
+// "address", and the legacy flat shape, with the address fields directly on
+// the shop. The nested shape wins when both are present; marshaling always
+// produces it.
+func (s *Shop) UnmarshalJSON(data []byte) error {
+	type shop Shop // drops this method so the nested shape decodes normally
+	var nested shop
+	if err := json.Unmarshal(data, &nested); err != nil {
+		return err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	if _, ok := fields["address"]; !ok {
+		if err := json.Unmarshal(data, &nested.Address); err != nil {
+			return err
+		}
+	}
+
+	*s = Shop(nested)
+	return nil
 }
 
-// Validate validates the shop fields.
+// Validate validates the shop fields, returning ValidationErrors listing
+// every problem.
 func (s Shop) Validate() error {
+	var f fieldErrors
 	if s.Name == "" {
-		return errors.New("name is required")
+		f.addMessage("name", "name is required")
 	}
 	if s.ContactID == "" {
-		return errors.New("contactId is required")
+		f.addMessage("contactId", "contactId is required")
 	}
-	if err := s.Address.Validate(); err != nil {
-		return err
+	f.add("address", s.Address.Validate())
+	if s.Coordinates != nil {
+		f.add("coordinates", s.Coordinates.Validate())
 	}
-	return nil
+	if s.Phone != "" {
+		f.add("phone", ValidatePhone(s.Phone))
+	}
+	if s.Email != "" {
+		f.add("email", ValidateEmail(s.Email))
+	}
+	if s.WebsiteURL != "" {
+		if _, err := NormalizeURL(s.WebsiteURL); err != nil {
+			f.add("websiteUrl", fmt.Errorf("websiteUrl: %w", err))
+		}
+	}
+	f.add("", validateSocialLinks(s.SocialLinks))
+	f.add("categories", validateCategoryList("categories", s.Categories, MaxShopCategories))
+	s.validateBranding(&f)
+	if s.Hours != nil {
+		f.add("hours", s.Hours.Validate())
+	}
+	if s.Recurrence != nil {
+		f.add("recurrence", s.Recurrence.Validate())
+	}
+	f.add("", validateDeliveryZones(s.DeliveryZones))
+	f.add("", validateContacts(s.Contacts))
+	return f.err()
 }
+
 // ShopLocation represents a shop location with business details.
 type ShopLocation struct {
 	LocationBase
 	Shop Shop `json:"shop" dynamodbav:"shop"`
 }
 
-// Validate validates the shop location.
+// Validate validates the shop location, returning ValidationErrors listing
+// every problem.
 func (l ShopLocation) Validate() error {
-	if l.AccountID == "" {
-		return errors.New("accountId is required")
-	}
-	if l.LocationType != LocationTypeShop {
-		return fmt.Errorf("invalid locationType for ShopLocation: %s", l.LocationType)
-	}
-	return l.Shop.Validate()
+	var f fieldErrors
+	l.validateBase(&f, LocationTypeShop, true)
+	f.add("shop", l.Shop.Validate())
+	return f.err()
 }
 
-// UnmarshalLocation unmarshals a JSON byte slice into the appropriate Location type.
+// UnmarshalLocation unmarshals a JSON byte slice into the appropriate Location
+// type. Fields of the wrong JSON type are returned as ValidationErrors, all of
+// them rather than only the first, together with the problems Validate finds
+// in the rest of the location.
 func UnmarshalLocation(data []byte) (Location, error) {
 	var base struct {
 		LocationType LocationType `json:"locationType"`
 	}
 
 	if err := json.Unmarshal(data, &base); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field != "" {
+			return nil, ValidationErrors{typeFieldError(typeErr, typeErr.Field)}
+		}
 		return nil, fmt.Errorf("failed to unmarshal location type: %w", err)
 	}
 
 	switch base.LocationType {
 	case LocationTypeAddress:
-		var loc AddressLocation
-		if err := json.Unmarshal(data, &loc); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal address location: %w", err)
-		}
-		return loc, nil
+		return unmarshalLocationAs[AddressLocation](data, "address")
 	case LocationTypeCoordinates:
-		var loc CoordinatesLocation
-		if err := json.Unmarshal(data, &loc); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal coordinates location: %w", err)
-		}
-		return loc, nil
+		return unmarshalLocationAs[CoordinatesLocation](data, "coordinates")
 	case LocationTypeShop:
-		var loc ShopLocation
-		if err := json.Unmarshal(data, &loc); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal shop location: %w", err)
+		return unmarshalLocationAs[ShopLocation](data, "shop")
+	case LocationTypeEvent:
+		return unmarshalLocationAs[EventLocation](data, "event")
+	default:
+		return nil, ValidationErrors{{Field: "locationType", Message: fmt.Sprintf("unknown location type: %s", base.LocationType)}}
+	}
+}
+
+// unmarshalLocationAs unmarshals data as a T. encoding/json reports only the
+// first field of the wrong type, so each one found is nulled out of data and
+// the rest decoded again, until none are left.
+func unmarshalLocationAs[T Location](data []byte, name string) (Location, error) {
+	var typeErrs ValidationErrors
+	for {
+		var loc T
+		err := json.Unmarshal(data, &loc)
+		var typeErr *json.UnmarshalTypeError
+		if !errors.As(err, &typeErr) || typeErr.Field == "" {
+			if err != nil {
+				return nil, fmt.Errorf("failed to unmarshal %s location: %w", name, err)
+			}
+			if len(typeErrs) == 0 {
+				return loc, nil
+			}
+			return nil, append(typeErrs, validationErrorsExcept(loc.Validate(), typeErrs)...)
+		}
+
+		path := typeErr.Field
+		next, err := withoutField(data, path)
+		if err != nil && name == "shop" {
+			// Shop's own UnmarshalJSON reports paths within the shop
+			path = "shop." + path
+			next, err = withoutField(data, path)
+		}
+		typeErrs = append(typeErrs, typeFieldError(typeErr, path))
+		if err != nil {
+			return nil, typeErrs
 		}
-		return loc, nil
+		data = next
+	}
+}
+
+// typeFieldError describes a field of the wrong JSON type at path, as
+// encoding/json gives it. An element of a list of strings or numbers is
+// named with its index, as categories[1], since it has no field of its own.
+func typeFieldError(typeErr *json.UnmarshalTypeError, path string) FieldError {
+	var field, name string
+	for _, segment := range strings.Split(path, ".") {
+		if _, err := strconv.Atoi(segment); err == nil {
+			field += "[" + segment + "]"
+			name += "[" + segment + "]"
+			continue
+		}
+		field, name = joinFieldPath(field, segment), segment
+	}
+	return FieldError{Field: field, Message: fmt.Sprintf("%s must be %s, got %s", name, jsonTypeName(typeErr.Type), typeErr.Value)}
+}
+
+// jsonTypeName names the JSON type that decodes into t.
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return jsonTypeName(t.Elem())
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
 	default:
-		return nil, fmt.Errorf("unknown location type: %s", base.LocationType)
+		return "an object"
+	}
+}
+
+// withoutField returns data with the value at path, as encoding/json gives
+// it, replaced by null, which decodes into any field by leaving it unset.
+func withoutField(data []byte, path string) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var root interface{}
+	if err := decoder.Decode(&root); err != nil {
+		return nil, err
+	}
+
+	value := root
+	segments := strings.Split(path, ".")
+	for i, segment := range segments {
+		last := i == len(segments)-1
+		switch node := value.(type) {
+		case map[string]interface{}:
+			if _, ok := node[segment]; !ok {
+				return nil, fmt.Errorf("field %s not found", path)
+			}
+			if last {
+				node[segment] = nil
+			}
+			value = node[segment]
+		case []interface{}:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, fmt.Errorf("field %s not found", path)
+			}
+			if last {
+				node[index] = nil
+			}
+			value = node[index]
+		default:
+			return nil, fmt.Errorf("field %s not found", path)
+		}
+	}
+	return json.Marshal(root)
+}
+
+// validationErrorsExcept returns the problems in err other than those with
+// the fields in skip, within them, or holding them, which would only repeat
+// that a field of the wrong type was left unset.
+func validationErrorsExcept(err error, skip ValidationErrors) ValidationErrors {
+	errs, _ := err.(ValidationErrors)
+	var kept ValidationErrors
+	for _, fieldErr := range errs {
+		skipped := false
+		for _, s := range skip {
+			if fieldPathWithin(fieldErr.Field, s.Field) || fieldPathWithin(s.Field, fieldErr.Field) {
+				skipped = true
+				break
+			}
+		}
+		if !skipped {
+			kept = append(kept, fieldErr)
+		}
+	}
+	return kept
+}
+
+// fieldPathWithin reports whether path is parent or a field within it.
+func fieldPathWithin(path, parent string) bool {
+	if parent == "" || !strings.HasPrefix(path, parent) {
+		return false
 	}
+	return len(path) == len(parent) || path[len(parent)] == '.' || path[len(parent)] == '['
 }
 
 // LocationWrapper is used for unmarshaling locations from DynamoDB.
@@ -3,6 +3,7 @@ package models
 import (
 	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -174,6 +175,43 @@ func TestCoordinatesValidation(t *testing.T) {
 			wantErr: true,
 			errMsg:  "accuracy must be non-negative",
 		},
+		{
+			name: "Grid reference kept beside its conversion",
+			coordinates: Coordinates{
+				Latitude:  40.7484,
+				Longitude: -73.9857,
+				MGRS:      "18TWL8562811322",
+			},
+		},
+		{
+			name: "Both grid references",
+			coordinates: Coordinates{
+				Latitude:  40.7484,
+				Longitude: -73.9857,
+				UTM:       "18T 585628 4511322",
+				MGRS:      "18TWL8562811322",
+			},
+			wantErr: true,
+			errMsg:  "coordinates can have utm or mgrs, not both",
+		},
+		{
+			name: "NAD83 datum",
+			coordinates: Coordinates{
+				Latitude:  39.7392,
+				Longitude: -104.9903,
+				CRS:       CRSNAD83,
+			},
+		},
+		{
+			name: "Unsupported datum",
+			coordinates: Coordinates{
+				Latitude:  39.7392,
+				Longitude: -104.9903,
+				CRS:       "NAD27",
+			},
+			wantErr: true,
+			errMsg:  `crs must be WGS84 or NAD83, got "NAD27"`,
+		},
 	}
 
 	for _, tt := range tests {
@@ -248,6 +286,60 @@ func TestAddressLocationValidation(t *testing.T) {
 			wantErr: true,
 			errMsg:  "invalid locationType for AddressLocation",
 		},
+		{
+			name: "Geocoded coordinates",
+			location: AddressLocation{
+				LocationBase: LocationBase{AccountID: "acc-12345", LocationType: LocationTypeAddress},
+				Address:      Address{StreetAddress: "123 Main St", City: "Springfield", PostalCode: "12345", Country: "US"},
+				Coordinates:  &Coordinates{Latitude: 39.7817, Longitude: -89.6501},
+				Geocode:      &GeocodeInfo{Provider: "amazon_location", GeocodedAt: time.Date(2024, time.June, 4, 12, 0, 0, 0, time.UTC)},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Geocode without coordinates",
+			location: AddressLocation{
+				LocationBase: LocationBase{AccountID: "acc-12345", LocationType: LocationTypeAddress},
+				Address:      Address{StreetAddress: "123 Main St", City: "Springfield", PostalCode: "12345", Country: "US"},
+				Geocode:      &GeocodeInfo{Provider: "amazon_location", GeocodedAt: time.Date(2024, time.June, 4, 12, 0, 0, 0, time.UTC)},
+			},
+			wantErr: true,
+			errMsg:  "geocode requires coordinates",
+		},
+		{
+			name: "Locked coordinates without coordinates",
+			location: AddressLocation{
+				LocationBase:      LocationBase{AccountID: "acc-12345", LocationType: LocationTypeAddress},
+				Address:           Address{StreetAddress: "123 Main St", City: "Springfield", PostalCode: "12345", Country: "US"},
+				CoordinatesLocked: true,
+			},
+			wantErr: true,
+			errMsg:  "coordinatesLocked requires coordinates",
+		},
+		{
+			name: "Active window ending before it starts",
+			location: AddressLocation{
+				LocationBase: LocationBase{
+					AccountID:    "acc-12345",
+					LocationType: LocationTypeAddress,
+					ActiveFrom:   timePtr(time.Date(2024, time.December, 24, 0, 0, 0, 0, time.UTC)),
+					ActiveUntil:  timePtr(time.Date(2024, time.November, 29, 0, 0, 0, 0, time.UTC)),
+				},
+				Address: Address{StreetAddress: "123 Main St", City: "Springfield", PostalCode: "12345", Country: "US"},
+			},
+			wantErr: true,
+			errMsg:  "activeUntil must be after activeFrom",
+		},
+		{
+			name: "Invalid coordinates",
+			location: AddressLocation{
+				LocationBase: LocationBase{AccountID: "acc-12345", LocationType: LocationTypeAddress},
+				Address:      Address{StreetAddress: "123 Main St", City: "Springfield", PostalCode: "12345", Country: "US"},
+				Coordinates:  &Coordinates{Latitude: 91},
+			},
+			wantErr: true,
+			errMsg:  "latitude must be between -90 and 90",
+		},
 	}
 
 	for _, tt := range tests {
@@ -263,6 +355,24 @@ func TestAddressLocationValidation(t *testing.T) {
 	}
 }
 
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+func TestLocationBaseIsActiveAt(t *testing.T) {
+	from := time.Date(2024, time.November, 29, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2024, time.December, 25, 0, 0, 0, 0, time.UTC)
+	seasonal := LocationBase{ActiveFrom: &from, ActiveUntil: &until}
+
+	assert.False(t, seasonal.IsActiveAt(from.Add(-time.Second)))
+	assert.True(t, seasonal.IsActiveAt(from))
+	assert.True(t, seasonal.IsActiveAt(until.Add(-time.Second)))
+	assert.False(t, seasonal.IsActiveAt(until))
+	assert.True(t, LocationBase{}.IsActiveAt(from))
+	assert.True(t, LocationBase{ActiveFrom: &from}.IsActiveAt(until.AddDate(1, 0, 0)))
+	assert.False(t, LocationBase{ActiveUntil: &until}.IsActiveAt(until.AddDate(1, 0, 0)))
+}
+
 func TestCoordinatesLocationValidation(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -737,6 +847,53 @@ func TestLocationWrapperUnmarshalJSON(t *testing.T) {
 	assert.Equal(t, LocationTypeAddress, wrapper.Location.GetLocationType())
 }
 
+func TestShopUnmarshalJSON(t *testing.T) {
+	address := Address{StreetAddress: "123 Main St", City: "Springfield", StateProvince: "IL", PostalCode: "12345", Country: "US"}
+
+	tests := []struct {
+		name string
+		json string
+		want Shop
+	}{
+		{
+			name: "Nested address",
+			json: `{"name": "Coffee Shop", "contactId": "contact-1", "address": {"streetAddress": "123 Main St", "city": "Springfield", "stateProvince": "IL", "postalCode": "12345", "country": "US"}}`,
+			want: Shop{Name: "Coffee Shop", ContactID: "contact-1", Address: address},
+		},
+		{
+			name: "Legacy flat address",
+			json: `{"name": "Coffee Shop", "contactId": "contact-1", "streetAddress": "123 Main St", "city": "Springfield", "stateProvince": "IL", "postalCode": "12345", "country": "US"}`,
+			want: Shop{Name: "Coffee Shop", ContactID: "contact-1", Address: address},
+		},
+		{
+			name: "Nested address wins over flat fields",
+			json: `{"name": "Coffee Shop", "contactId": "contact-1", "city": "Shelbyville", "address": {"streetAddress": "123 Main St", "city": "Springfield", "stateProvince": "IL", "postalCode": "12345", "country": "US"}}`,
+			want: Shop{Name: "Coffee Shop", ContactID: "contact-1", Address: address},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var shop Shop
+			require.NoError(t, json.Unmarshal([]byte(tt.json), &shop))
+			assert.Equal(t, tt.want, shop)
+
+			// Both shapes marshal back nested
+			data, err := json.Marshal(shop)
+			require.NoError(t, err)
+			var fields map[string]interface{}
+			require.NoError(t, json.Unmarshal(data, &fields))
+			assert.Contains(t, fields, "address")
+			assert.NotContains(t, fields, "streetAddress")
+		})
+	}
+
+	t.Run("Rejects invalid JSON", func(t *testing.T) {
+		var shop Shop
+		assert.Error(t, json.Unmarshal([]byte(`{"name": 1}`), &shop))
+	})
+}
+
 func floatPtr(f float64) *float64 {
 	return &f
 }
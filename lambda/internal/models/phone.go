@@ -0,0 +1,114 @@
+package models
+
+import (
+	"fmt"
+	"net/mail"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// e164Pattern matches an E.164 number: a plus sign, a country calling code
+// not starting with zero, and at most 15 digits in all.
+var e164Pattern = regexp.MustCompile(`^\+[1-9][0-9]{1,14}$`)
+
+// callingCodes maps ISO 3166-1 alpha-2 countries to their calling codes, for
+// the countries whose national numbers NormalizePhone and FormatPhone handle.
+var callingCodes = map[string]string{
+	"US": "1", "CA": "1", "PR": "1",
+	"GB": "44", "IE": "353", "FR": "33", "DE": "49", "ES": "34", "IT": "39", "NL": "31",
+	"AU": "61", "NZ": "64", "MX": "52", "BR": "55", "IN": "91", "JP": "81",
+}
+
+// ValidatePhone checks that phone is an E.164 number such as +15551234567.
+func ValidatePhone(phone string) error {
+	if !e164Pattern.MatchString(phone) {
+		return fmt.Errorf("phone must be an E.164 number such as +15551234567, got %q", phone)
+	}
+	return nil
+}
+
+// ValidateEmail checks that email is a bare address such as shop@example.com.
+func ValidateEmail(email string) error {
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email || addr.Name != "" {
+		return fmt.Errorf("email must be an address such as shop@example.com, got %q", email)
+	}
+	return nil
+}
+
+// NormalizePhone converts a phone number as entered into E.164. Numbers
+// starting with + or the 00 international prefix are taken as international;
+// others are read as national numbers of country, dropping a leading trunk
+// prefix. Spaces, dots, dashes, and parentheses are ignored.
+func NormalizePhone(raw, country string) (string, error) {
+	digits := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '.', '-', '(', ')':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(raw))
+
+	var phone string
+	switch {
+	case strings.HasPrefix(digits, "+"):
+		phone = digits
+	case strings.HasPrefix(digits, "00"):
+		phone = "+" + digits[2:]
+	default:
+		code, ok := callingCodes[strings.ToUpper(country)]
+		if !ok {
+			return "", fmt.Errorf("cannot read national phone numbers for country %q; enter it as +<country code><number>", country)
+		}
+		if code == "1" {
+			digits = strings.TrimPrefix(digits, "1")
+		} else {
+			digits = strings.TrimPrefix(digits, "0")
+		}
+		phone = "+" + code + digits
+	}
+
+	if err := ValidatePhone(phone); err != nil {
+		return "", err
+	}
+	return phone, nil
+}
+
+// FormatPhone renders an E.164 number for display to someone in country: in
+// national format when it is a North American number shown in North America,
+// otherwise with its calling code separated, as in +44 2079460958.
+func FormatPhone(phone, country string) string {
+	if ValidatePhone(phone) != nil {
+		return phone
+	}
+	if strings.HasPrefix(phone, "+1") && len(phone) == 12 && callingCodes[strings.ToUpper(country)] == "1" {
+		return fmt.Sprintf("(%s) %s-%s", phone[2:5], phone[5:8], phone[8:])
+	}
+	for _, code := range sortedCallingCodes {
+		if strings.HasPrefix(phone[1:], code) {
+			return "+" + code + " " + phone[1+len(code):]
+		}
+	}
+	return phone
+}
+
+// sortedCallingCodes lists the distinct calling codes, longest first, so a
+// number's code is matched before any shorter code it starts with.
+var sortedCallingCodes = func() []string {
+	seen := map[string]bool{}
+	var codes []string
+	for _, code := range callingCodes {
+		if !seen[code] {
+			seen[code] = true
+			codes = append(codes, code)
+		}
+	}
+	sort.Slice(codes, func(i, j int) bool {
+		if len(codes[i]) != len(codes[j]) {
+			return len(codes[i]) > len(codes[j])
+		}
+		return codes[i] < codes[j]
+	})
+	return codes
+}()
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShopPhoneAndEmailValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		phone  string
+		email  string
+		errMsg string
+	}{
+		{name: "Neither set"},
+		{name: "Valid phone and email", phone: "+15551234567", email: "shop@example.com"},
+		{name: "Phone without plus", phone: "15551234567", errMsg: `phone must be an E.164 number such as +15551234567, got "15551234567"`},
+		{name: "Phone with formatting", phone: "+1 555 123 4567", errMsg: `phone must be an E.164 number such as +15551234567, got "+1 555 123 4567"`},
+		{name: "Phone too long", phone: "+1234567890123456", errMsg: `phone must be an E.164 number such as +15551234567, got "+1234567890123456"`},
+		{name: "Email without domain", email: "shop@", errMsg: `email must be an address such as shop@example.com, got "shop@"`},
+		{name: "Email with display name", email: "Shop <shop@example.com>", errMsg: `email must be an address such as shop@example.com, got "Shop <shop@example.com>"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shop := Shop{
+				Name:      "Coffee Shop",
+				ContactID: "contact-1",
+				Address:   Address{StreetAddress: "123 Main St", City: "Springfield", PostalCode: "12345", Country: "US"},
+				Phone:     tt.phone,
+				Email:     tt.email,
+			}
+			err := shop.Validate()
+			if tt.errMsg != "" {
+				assert.EqualError(t, err, tt.errMsg)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestNormalizePhone(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		country string
+		want    string
+		errMsg  string
+	}{
+		{name: "US national", raw: "(555) 123-4567", country: "US", want: "+15551234567"},
+		{name: "US national with trunk 1", raw: "1-555-123-4567", country: "us", want: "+15551234567"},
+		{name: "UK national", raw: "020 7946 0958", country: "GB", want: "+442079460958"},
+		{name: "Already international", raw: "+44 20 7946 0958", country: "US", want: "+442079460958"},
+		{name: "00 prefix", raw: "0049 30 901820", country: "US", want: "+4930901820"},
+		{name: "Unknown country", raw: "555 1234", country: "ZZ", errMsg: `cannot read national phone numbers for country "ZZ"; enter it as +<country code><number>`},
+		{name: "Not a number", raw: "call us", country: "US", errMsg: `phone must be an E.164 number such as +15551234567, got "+1callus"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			phone, err := NormalizePhone(tt.raw, tt.country)
+			if tt.errMsg != "" {
+				assert.EqualError(t, err, tt.errMsg)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, phone)
+		})
+	}
+}
+
+func TestFormatPhone(t *testing.T) {
+	tests := []struct {
+		name    string
+		phone   string
+		country string
+		want    string
+	}{
+		{name: "North American number in the US", phone: "+15551234567", country: "US", want: "(555) 123-4567"},
+		{name: "North American number in Canada", phone: "+15551234567", country: "CA", want: "(555) 123-4567"},
+		{name: "North American number abroad", phone: "+15551234567", country: "GB", want: "+1 5551234567"},
+		{name: "UK number", phone: "+442079460958", country: "GB", want: "+44 2079460958"},
+		{name: "Irish number matches its three-digit code", phone: "+35312345678", country: "IE", want: "+353 12345678"},
+		{name: "Unknown calling code", phone: "+8613912345678", country: "CN", want: "+8613912345678"},
+		{name: "Not E.164", phone: "555-1234", country: "US", want: "555-1234"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, FormatPhone(tt.phone, tt.country))
+		})
+	}
+}
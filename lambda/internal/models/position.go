@@ -0,0 +1,70 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// PositionCheckAction is what happens to a position update that fails the
+// account's position checks.
+type PositionCheckAction string
+
+const (
+	// PositionCheckFlag stores the update with a positionAnomaly.
+	PositionCheckFlag PositionCheckAction = "flag"
+	// PositionCheckReject refuses the update, keeping the stored position.
+	PositionCheckReject PositionCheckAction = "reject"
+)
+
+// Reasons a position update is anomalous.
+const (
+	// PositionAnomalySpeed updates imply travelling faster than maxSpeedKmh.
+	PositionAnomalySpeed = "speed"
+	// PositionAnomalyJump updates move further than maxJumpMeters at once.
+	PositionAnomalyJump = "jump"
+	// PositionAnomalyOutOfOrder updates move the location with a fix observed
+	// no later than the stored one's.
+	PositionAnomalyOutOfOrder = "outOfOrder"
+)
+
+// PositionChecks are an account's plausibility limits on moving coordinates
+// locations, such as vehicles, so a GPS glitch doesn't reach downstream ETAs.
+// A zero limit isn't checked.
+type PositionChecks struct {
+	// MaxSpeedKmh caps the speed between the observedAt of consecutive fixes
+	MaxSpeedKmh float64 `json:"maxSpeedKmh,omitempty" dynamodbav:"maxSpeedKmh,omitempty"`
+	// MaxJumpMeters caps the distance between consecutive fixes, however far apart in time
+	MaxJumpMeters float64             `json:"maxJumpMeters,omitempty" dynamodbav:"maxJumpMeters,omitempty"`
+	Action        PositionCheckAction `json:"action" dynamodbav:"action"`
+}
+
+// Validate validates the position checks.
+func (c PositionChecks) Validate() error {
+	if c.MaxSpeedKmh < 0 || c.MaxJumpMeters < 0 {
+		return errors.New("positionChecks: limits must not be negative")
+	}
+	if c.MaxSpeedKmh == 0 && c.MaxJumpMeters == 0 {
+		return errors.New("positionChecks: set maxSpeedKmh, maxJumpMeters, or both")
+	}
+	switch c.Action {
+	case PositionCheckFlag, PositionCheckReject:
+	default:
+		return fmt.Errorf("positionChecks: action must be %s or %s", PositionCheckFlag, PositionCheckReject)
+	}
+	return nil
+}
+
+// PositionAnomaly describes a position update that failed its account's
+// position checks.
+type PositionAnomaly struct {
+	Reason         string  `json:"reason" dynamodbav:"reason"`
+	DistanceMeters float64 `json:"distanceMeters" dynamodbav:"distanceMeters"`
+	// ElapsedSeconds and SpeedKmh are set when both fixes have an observedAt
+	ElapsedSeconds *float64 `json:"elapsedSeconds,omitempty" dynamodbav:"elapsedSeconds,omitempty"`
+	SpeedKmh       *float64 `json:"speedKmh,omitempty" dynamodbav:"speedKmh,omitempty"`
+	// Previous is the last plausible position, which later updates are
+	// checked against until one is plausible again
+	Previous   Coordinates `json:"previous" dynamodbav:"previous"`
+	DetectedAt time.Time   `json:"detectedAt" dynamodbav:"detectedAt"`
+}
@@ -0,0 +1,324 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// maxRecurrenceCount is the highest COUNT a recurrence rule can have.
+	maxRecurrenceCount = 1000
+	// maxRecurrenceInterval is the highest INTERVAL a recurrence rule can have.
+	maxRecurrenceInterval = 99
+)
+
+// ruleWeekdays are the two-letter RRULE weekday codes, indexed by time.Weekday.
+var ruleWeekdays = [7]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// Recurrence is a repeating schedule, such as a farmers market held on
+// Saturdays from 8am to 1pm, in a subset of RFC 5545 RRULE terms: FREQ of
+// DAILY, WEEKLY, or MONTHLY, with INTERVAL, BYDAY, and COUNT or UNTIL.
+type Recurrence struct {
+	// Rule is an RRULE such as FREQ=WEEKLY;BYDAY=SA
+	Rule string `json:"rule" dynamodbav:"rule"`
+	// TimeZone is the IANA time zone the dates and times are local to
+	TimeZone string `json:"timeZone" dynamodbav:"timeZone"`
+	// StartDate is the local "YYYY-MM-DD" date the rule starts from
+	StartDate string `json:"startDate" dynamodbav:"startDate"`
+	// StartTime and EndTime are each occurrence's local "HH:MM" times; an
+	// EndTime at or before StartTime runs past midnight
+	StartTime string `json:"startTime" dynamodbav:"startTime"`
+	EndTime   string `json:"endTime" dynamodbav:"endTime"`
+}
+
+// Occurrence is one instance of a recurring schedule.
+type Occurrence struct {
+	StartsAt time.Time `json:"startsAt"`
+	EndsAt   time.Time `json:"endsAt"`
+}
+
+// ruleDay is a BYDAY entry. A nonzero ordinal picks the nth such weekday of
+// the month, counting from the end when negative.
+type ruleDay struct {
+	weekday time.Weekday
+	ordinal int
+}
+
+// recurrenceRule is a parsed Recurrence.Rule.
+type recurrenceRule struct {
+	freq     string
+	interval int
+	byDay    []ruleDay
+	count    int
+	// until is the last local date occurrences can start on, zero when unbounded
+	until time.Time
+}
+
+// parseRule parses an RRULE, with or without its "RRULE:" prefix.
+func parseRule(rule string) (*recurrenceRule, error) {
+	parsed := &recurrenceRule{interval: 1}
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(strings.TrimPrefix(rule, "RRULE:"), ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("%q is not a NAME=VALUE rule part", part)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("%s is given more than once", key)
+		}
+		seen[key] = true
+
+		switch key {
+		case "FREQ":
+			if value != "DAILY" && value != "WEEKLY" && value != "MONTHLY" {
+				return nil, fmt.Errorf("FREQ must be DAILY, WEEKLY, or MONTHLY")
+			}
+			parsed.freq = value
+		case "INTERVAL":
+			interval, err := strconv.Atoi(value)
+			if err != nil || interval < 1 || interval > maxRecurrenceInterval {
+				return nil, fmt.Errorf("INTERVAL must be from 1 to %d", maxRecurrenceInterval)
+			}
+			parsed.interval = interval
+		case "COUNT":
+			count, err := strconv.Atoi(value)
+			if err != nil || count < 1 || count > maxRecurrenceCount {
+				return nil, fmt.Errorf("COUNT must be from 1 to %d", maxRecurrenceCount)
+			}
+			parsed.count = count
+		case "UNTIL":
+			until, err := time.Parse("20060102", value)
+			if err != nil {
+				return nil, fmt.Errorf("UNTIL must be a date such as 20241031")
+			}
+			parsed.until = until
+		case "BYDAY":
+			for _, day := range strings.Split(value, ",") {
+				byDay, err := parseRuleDay(day)
+				if err != nil {
+					return nil, err
+				}
+				parsed.byDay = append(parsed.byDay, byDay)
+			}
+		default:
+			return nil, fmt.Errorf("%s is not supported; use FREQ, INTERVAL, BYDAY, COUNT, or UNTIL", key)
+		}
+	}
+
+	if parsed.freq == "" {
+		return nil, errors.New("FREQ is required")
+	}
+	if parsed.count > 0 && !parsed.until.IsZero() {
+		return nil, errors.New("COUNT and UNTIL cannot both be given")
+	}
+	for _, day := range parsed.byDay {
+		if day.ordinal != 0 && parsed.freq != "MONTHLY" {
+			return nil, errors.New("BYDAY ordinals such as 1SA need FREQ=MONTHLY")
+		}
+	}
+	return parsed, nil
+}
+
+// parseRuleDay parses a BYDAY entry such as SA, 2SA, or -1SU.
+func parseRuleDay(day string) (ruleDay, error) {
+	if len(day) >= 2 {
+		code := day[len(day)-2:]
+		for weekday, ruleWeekday := range ruleWeekdays {
+			if code != ruleWeekday {
+				continue
+			}
+			if len(day) == 2 {
+				return ruleDay{weekday: time.Weekday(weekday)}, nil
+			}
+			ordinal, err := strconv.Atoi(day[:len(day)-2])
+			if err == nil && ordinal != 0 && ordinal >= -5 && ordinal <= 5 {
+				return ruleDay{weekday: time.Weekday(weekday), ordinal: ordinal}, nil
+			}
+		}
+	}
+	return ruleDay{}, fmt.Errorf("BYDAY %q must be a weekday such as SA, optionally after an ordinal from -5 to 5", day)
+}
+
+// Validate validates the recurrence.
+func (r Recurrence) Validate() error {
+	if r.Rule == "" {
+		return errors.New("recurrence: rule is required")
+	}
+	rule, err := parseRule(r.Rule)
+	if err != nil {
+		return fmt.Errorf("recurrence: %w", err)
+	}
+	if r.TimeZone == "" {
+		return errors.New("recurrence: timeZone is required")
+	}
+	if _, err := time.LoadLocation(r.TimeZone); err != nil || r.TimeZone == "Local" {
+		return fmt.Errorf("recurrence: timeZone %q is not an IANA time zone", r.TimeZone)
+	}
+	startDate, err := time.Parse(time.DateOnly, r.StartDate)
+	if err != nil {
+		return errors.New("recurrence: startDate must be a date such as 2024-06-01")
+	}
+	if !rule.until.IsZero() && rule.until.Before(startDate) {
+		return errors.New("recurrence: UNTIL must not be before startDate")
+	}
+	start, err := parseClock(r.StartTime, false)
+	if err != nil {
+		return fmt.Errorf("recurrence: startTime: %w", err)
+	}
+	end, err := parseClock(r.EndTime, true)
+	if err != nil {
+		return fmt.Errorf("recurrence: endTime: %w", err)
+	}
+	if start == end {
+		return errors.New("recurrence: startTime and endTime must differ")
+	}
+	return nil
+}
+
+// Between returns the occurrences that overlap from until to, in order.
+// A recurrence that fails validation has none.
+func (r Recurrence) Between(from, to time.Time) []Occurrence {
+	rule, err := parseRule(r.Rule)
+	if err != nil {
+		return nil
+	}
+	location, err := time.LoadLocation(r.TimeZone)
+	if err != nil {
+		return nil
+	}
+	startDate, err := time.Parse(time.DateOnly, r.StartDate)
+	if err != nil {
+		return nil
+	}
+	start, startErr := parseClock(r.StartTime, false)
+	end, endErr := parseClock(r.EndTime, true)
+	if startErr != nil || endErr != nil {
+		return nil
+	}
+	if end <= start {
+		end += 24 * 60
+	}
+
+	// Dates are walked as UTC midnights so DST changes don't skew day counts
+	last := civilDate(to.In(location))
+	if !rule.until.IsZero() && rule.until.Before(last) {
+		last = rule.until
+	}
+	day := startDate
+	if rule.count == 0 {
+		// COUNT numbers occurrences from startDate; without it the walk can
+		// begin the day before from, whose occurrence may run past midnight
+		if earliest := civilDate(from.In(location)).AddDate(0, 0, -1); earliest.After(day) {
+			day = earliest
+		}
+	}
+
+	var occurrences []Occurrence
+	for matched := 0; !day.After(last); day = day.AddDate(0, 0, 1) {
+		if !rule.matches(day, startDate) {
+			continue
+		}
+		matched++
+		occurrence := Occurrence{
+			StartsAt: time.Date(day.Year(), day.Month(), day.Day(), 0, start, 0, 0, location),
+			EndsAt:   time.Date(day.Year(), day.Month(), day.Day(), 0, end, 0, 0, location),
+		}
+		if occurrence.StartsAt.Before(to) && occurrence.EndsAt.After(from) {
+			occurrences = append(occurrences, occurrence)
+		}
+		if rule.count > 0 && matched == rule.count {
+			break
+		}
+	}
+	return occurrences
+}
+
+// civilDate returns the UTC midnight of t's date in t's own location.
+func civilDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// matches reports whether the rule has an occurrence on day, both day and
+// startDate being UTC midnights.
+func (r *recurrenceRule) matches(day, startDate time.Time) bool {
+	if day.Before(startDate) {
+		return false
+	}
+	days := int(day.Sub(startDate).Hours() / 24)
+
+	switch r.freq {
+	case "DAILY":
+		return days%r.interval == 0 && (len(r.byDay) == 0 || r.onDay(day))
+	case "WEEKLY":
+		// Weeks start on Monday, the RRULE default
+		weeks := (days + (int(startDate.Weekday())+6)%7) / 7
+		if weeks%r.interval != 0 {
+			return false
+		}
+		if len(r.byDay) == 0 {
+			return day.Weekday() == startDate.Weekday()
+		}
+		return r.onDay(day)
+	default: // MONTHLY
+		months := (day.Year()-startDate.Year())*12 + int(day.Month()-startDate.Month())
+		if months%r.interval != 0 {
+			return false
+		}
+		if len(r.byDay) == 0 {
+			return day.Day() == startDate.Day()
+		}
+		return r.onDay(day)
+	}
+}
+
+// onDay reports whether day matches one of the rule's BYDAY entries.
+func (r *recurrenceRule) onDay(day time.Time) bool {
+	daysInMonth := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	for _, byDay := range r.byDay {
+		if byDay.weekday != day.Weekday() {
+			continue
+		}
+		switch {
+		case byDay.ordinal == 0:
+			return true
+		case byDay.ordinal > 0 && (day.Day()-1)/7 == byDay.ordinal-1:
+			return true
+		case byDay.ordinal < 0 && (daysInMonth-day.Day())/7 == -byDay.ordinal-1:
+			return true
+		}
+	}
+	return false
+}
+
+// OccurrencesBetween returns the occurrences of an event or shop location
+// that overlap from until to. A shop occurs only on its recurrence. An event
+// without a recurrence occurs once, from StartsAt until EndsAt; with one, its
+// occurrences are those of the recurrence that start within that span.
+func OccurrencesBetween(location Location, from, to time.Time) ([]Occurrence, error) {
+	switch loc := location.(type) {
+	case ShopLocation:
+		if loc.Shop.Recurrence == nil {
+			return nil, errors.New("shop has no recurrence")
+		}
+		return loc.Shop.Recurrence.Between(from, to), nil
+	case EventLocation:
+		if loc.Recurrence == nil {
+			if loc.StartsAt.Before(to) && loc.EndsAt.After(from) {
+				return []Occurrence{{StartsAt: loc.StartsAt, EndsAt: loc.EndsAt}}, nil
+			}
+			return nil, nil
+		}
+		var occurrences []Occurrence
+		for _, occurrence := range loc.Recurrence.Between(from, to) {
+			if !occurrence.StartsAt.Before(loc.StartsAt) && occurrence.StartsAt.Before(loc.EndsAt) {
+				occurrences = append(occurrences, occurrence)
+			}
+		}
+		return occurrences, nil
+	default:
+		return nil, fmt.Errorf("only event and shop locations have occurrences, not %s", location.GetLocationType())
+	}
+}
@@ -0,0 +1,177 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecurrenceValidate(t *testing.T) {
+	market := func(modify func(*Recurrence)) Recurrence {
+		recurrence := Recurrence{Rule: "FREQ=WEEKLY;BYDAY=SA", TimeZone: "America/Chicago", StartDate: "2024-06-01", StartTime: "08:00", EndTime: "13:00"}
+		modify(&recurrence)
+		return recurrence
+	}
+
+	tests := []struct {
+		name       string
+		recurrence Recurrence
+		errMsg     string
+	}{
+		{name: "Weekly market", recurrence: market(func(*Recurrence) {})},
+		{name: "Prefixed rule", recurrence: market(func(r *Recurrence) { r.Rule = "RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=SA,SU;UNTIL=20241031" })},
+		{name: "Monthly on the last Sunday", recurrence: market(func(r *Recurrence) { r.Rule = "FREQ=MONTHLY;BYDAY=-1SU;COUNT=6" })},
+		{name: "Runs past midnight", recurrence: market(func(r *Recurrence) { r.StartTime, r.EndTime = "20:00", "02:00" })},
+		{name: "Missing rule", recurrence: market(func(r *Recurrence) { r.Rule = "" }), errMsg: "recurrence: rule is required"},
+		{name: "Missing FREQ", recurrence: market(func(r *Recurrence) { r.Rule = "BYDAY=SA" }), errMsg: "recurrence: FREQ is required"},
+		{name: "Yearly", recurrence: market(func(r *Recurrence) { r.Rule = "FREQ=YEARLY" }), errMsg: "recurrence: FREQ must be DAILY, WEEKLY, or MONTHLY"},
+		{name: "Unsupported part", recurrence: market(func(r *Recurrence) { r.Rule = "FREQ=WEEKLY;BYHOUR=8" }), errMsg: "recurrence: BYHOUR is not supported"},
+		{name: "Repeated part", recurrence: market(func(r *Recurrence) { r.Rule = "FREQ=WEEKLY;FREQ=DAILY" }), errMsg: "recurrence: FREQ is given more than once"},
+		{name: "Malformed part", recurrence: market(func(r *Recurrence) { r.Rule = "FREQ=WEEKLY;BYDAY" }), errMsg: `recurrence: "BYDAY" is not a NAME=VALUE rule part`},
+		{name: "Zero interval", recurrence: market(func(r *Recurrence) { r.Rule = "FREQ=WEEKLY;INTERVAL=0" }), errMsg: "recurrence: INTERVAL must be from 1 to 99"},
+		{name: "Unknown weekday", recurrence: market(func(r *Recurrence) { r.Rule = "FREQ=WEEKLY;BYDAY=SAT" }), errMsg: `recurrence: BYDAY "SAT" must be a weekday`},
+		{name: "Weekly ordinal", recurrence: market(func(r *Recurrence) { r.Rule = "FREQ=WEEKLY;BYDAY=1SA" }), errMsg: "recurrence: BYDAY ordinals such as 1SA need FREQ=MONTHLY"},
+		{name: "COUNT and UNTIL", recurrence: market(func(r *Recurrence) { r.Rule = "FREQ=DAILY;COUNT=3;UNTIL=20240901" }), errMsg: "recurrence: COUNT and UNTIL cannot both be given"},
+		{name: "UNTIL before start", recurrence: market(func(r *Recurrence) { r.Rule = "FREQ=DAILY;UNTIL=20240501" }), errMsg: "recurrence: UNTIL must not be before startDate"},
+		{name: "Unknown time zone", recurrence: market(func(r *Recurrence) { r.TimeZone = "Mars/Olympus" }), errMsg: `recurrence: timeZone "Mars/Olympus" is not an IANA time zone`},
+		{name: "Malformed start date", recurrence: market(func(r *Recurrence) { r.StartDate = "06/01/2024" }), errMsg: "recurrence: startDate must be a date such as 2024-06-01"},
+		{name: "Malformed time", recurrence: market(func(r *Recurrence) { r.StartTime = "8am" }), errMsg: `recurrence: startTime: time "8am" must be HH:MM`},
+		{name: "Empty occurrence", recurrence: market(func(r *Recurrence) { r.EndTime = "08:00" }), errMsg: "recurrence: startTime and endTime must differ"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.recurrence.Validate()
+			if tt.errMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRecurrenceBetween(t *testing.T) {
+	chicago, err := time.LoadLocation("America/Chicago")
+	require.NoError(t, err)
+	at := func(month time.Month, day, hour int) time.Time {
+		return time.Date(2024, month, day, hour, 0, 0, 0, chicago)
+	}
+	starts := func(occurrences []Occurrence) []time.Time {
+		var times []time.Time
+		for _, occurrence := range occurrences {
+			times = append(times, occurrence.StartsAt)
+		}
+		return times
+	}
+	recurrence := func(rule string) Recurrence {
+		return Recurrence{Rule: rule, TimeZone: "America/Chicago", StartDate: "2024-06-01", StartTime: "08:00", EndTime: "13:00"}
+	}
+
+	t.Run("Weekly on Saturdays", func(t *testing.T) {
+		occurrences := recurrence("FREQ=WEEKLY;BYDAY=SA").Between(at(time.June, 10, 0), at(time.June, 30, 0))
+		assert.Equal(t, []time.Time{at(time.June, 15, 8), at(time.June, 22, 8), at(time.June, 29, 8)}, starts(occurrences))
+		assert.Equal(t, at(time.June, 15, 13), occurrences[0].EndsAt)
+	})
+
+	t.Run("Every other week", func(t *testing.T) {
+		occurrences := recurrence("FREQ=WEEKLY;INTERVAL=2;BYDAY=SA,SU").Between(at(time.June, 1, 0), at(time.June, 17, 0))
+		assert.Equal(t, []time.Time{at(time.June, 1, 8), at(time.June, 2, 8), at(time.June, 15, 8), at(time.June, 16, 8)}, starts(occurrences))
+	})
+
+	t.Run("Includes an occurrence already under way", func(t *testing.T) {
+		occurrences := recurrence("FREQ=WEEKLY;BYDAY=SA").Between(at(time.June, 8, 12), at(time.June, 9, 0))
+		assert.Equal(t, []time.Time{at(time.June, 8, 8)}, starts(occurrences))
+	})
+
+	t.Run("Runs past midnight", func(t *testing.T) {
+		late := recurrence("FREQ=DAILY")
+		late.StartTime, late.EndTime = "20:00", "02:00"
+		occurrences := late.Between(at(time.June, 5, 1), at(time.June, 5, 2))
+		require.Len(t, occurrences, 1)
+		assert.Equal(t, at(time.June, 4, 20), occurrences[0].StartsAt)
+		assert.Equal(t, at(time.June, 5, 2), occurrences[0].EndsAt)
+	})
+
+	t.Run("Monthly on the last Sunday", func(t *testing.T) {
+		occurrences := recurrence("FREQ=MONTHLY;BYDAY=-1SU").Between(at(time.June, 1, 0), at(time.September, 1, 0))
+		assert.Equal(t, []time.Time{at(time.June, 30, 8), at(time.July, 28, 8), at(time.August, 25, 8)}, starts(occurrences))
+	})
+
+	t.Run("Monthly on the start date's day", func(t *testing.T) {
+		monthly := recurrence("FREQ=MONTHLY")
+		monthly.StartDate = "2024-01-31"
+		occurrences := monthly.Between(at(time.January, 1, 0), at(time.May, 1, 0))
+		assert.Equal(t, []time.Time{at(time.January, 31, 8), at(time.March, 31, 8)}, starts(occurrences))
+	})
+
+	t.Run("Stops after COUNT occurrences", func(t *testing.T) {
+		occurrences := recurrence("FREQ=WEEKLY;BYDAY=SA;COUNT=2").Between(at(time.June, 1, 0), at(time.July, 1, 0))
+		assert.Equal(t, []time.Time{at(time.June, 1, 8), at(time.June, 8, 8)}, starts(occurrences))
+	})
+
+	t.Run("Stops after UNTIL", func(t *testing.T) {
+		occurrences := recurrence("FREQ=WEEKLY;BYDAY=SA;UNTIL=20240615").Between(at(time.June, 10, 0), at(time.July, 1, 0))
+		assert.Equal(t, []time.Time{at(time.June, 15, 8)}, starts(occurrences))
+	})
+
+	t.Run("Keeps local times across DST", func(t *testing.T) {
+		occurrences := recurrence("FREQ=WEEKLY;BYDAY=SA").Between(at(time.October, 26, 0), at(time.November, 3, 0))
+		assert.Equal(t, []time.Time{at(time.October, 26, 8), at(time.November, 2, 8)}, starts(occurrences))
+	})
+
+	t.Run("Invalid recurrences have none", func(t *testing.T) {
+		assert.Empty(t, recurrence("FREQ=YEARLY").Between(at(time.June, 1, 0), at(time.July, 1, 0)))
+	})
+}
+
+func TestOccurrencesBetween(t *testing.T) {
+	from := time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, time.July, 1, 0, 0, 0, 0, time.UTC)
+	market := &Recurrence{Rule: "FREQ=WEEKLY;BYDAY=SA", TimeZone: "UTC", StartDate: "2024-05-04", StartTime: "08:00", EndTime: "13:00"}
+	event := EventLocation{
+		LocationBase: LocationBase{AccountID: "acc-12345", LocationType: LocationTypeEvent},
+		StartsAt:     time.Date(2024, time.June, 10, 0, 0, 0, 0, time.UTC),
+		EndsAt:       time.Date(2024, time.June, 20, 0, 0, 0, 0, time.UTC),
+	}
+
+	t.Run("Shop recurrence", func(t *testing.T) {
+		occurrences, err := OccurrencesBetween(ShopLocation{Shop: Shop{Recurrence: market}}, from, to)
+		require.NoError(t, err)
+		assert.Len(t, occurrences, 5)
+	})
+
+	t.Run("Shop without a recurrence", func(t *testing.T) {
+		_, err := OccurrencesBetween(ShopLocation{}, from, to)
+		assert.EqualError(t, err, "shop has no recurrence")
+	})
+
+	t.Run("One-off event", func(t *testing.T) {
+		occurrences, err := OccurrencesBetween(event, from, to)
+		require.NoError(t, err)
+		assert.Equal(t, []Occurrence{{StartsAt: event.StartsAt, EndsAt: event.EndsAt}}, occurrences)
+
+		occurrences, err = OccurrencesBetween(event, to, to.AddDate(0, 1, 0))
+		require.NoError(t, err)
+		assert.Empty(t, occurrences)
+	})
+
+	t.Run("Recurring event within its span", func(t *testing.T) {
+		recurring := event
+		recurring.Recurrence = market
+		occurrences, err := OccurrencesBetween(recurring, from, to)
+		require.NoError(t, err)
+		assert.Equal(t, []Occurrence{{
+			StartsAt: time.Date(2024, time.June, 15, 8, 0, 0, 0, time.UTC),
+			EndsAt:   time.Date(2024, time.June, 15, 13, 0, 0, 0, time.UTC),
+		}}, occurrences)
+	})
+
+	t.Run("Other location types", func(t *testing.T) {
+		_, err := OccurrencesBetween(CoordinatesLocation{LocationBase: LocationBase{LocationType: LocationTypeCoordinates}}, from, to)
+		assert.EqualError(t, err, "only event and shop locations have occurrences, not coordinates")
+	})
+}
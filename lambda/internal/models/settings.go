@@ -0,0 +1,111 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// Units is the measurement system used when presenting distances and sizes.
+type Units string
+
+const (
+	// UnitsMetric presents meters and kilometers.
+	UnitsMetric Units = "metric"
+	// UnitsImperial presents feet and miles.
+	UnitsImperial Units = "imperial"
+)
+
+// ValidationStrictness controls how strictly an account's location input is validated.
+type ValidationStrictness string
+
+const (
+	// ValidationStrict rejects any input that fails validation.
+	ValidationStrict ValidationStrictness = "strict"
+	// ValidationLenient accepts input with recoverable problems.
+	ValidationLenient ValidationStrictness = "lenient"
+)
+
+// AccountQuotas caps an account's usage. A zero value means no limit.
+type AccountQuotas struct {
+	MaxLocations int `json:"maxLocations,omitempty" dynamodbav:"maxLocations,omitempty"`
+	MaxTemplates int `json:"maxTemplates,omitempty" dynamodbav:"maxTemplates,omitempty"`
+}
+
+// AccountSettings is the managed document of an account's per-account behaviors.
+type AccountSettings struct {
+	AccountID            string               `json:"accountId" dynamodbav:"accountId"`
+	DefaultCountry       string               `json:"defaultCountry,omitempty" dynamodbav:"defaultCountry,omitempty"`
+	DefaultUnits         Units                `json:"defaultUnits" dynamodbav:"defaultUnits"`
+	ValidationStrictness ValidationStrictness `json:"validationStrictness" dynamodbav:"validationStrictness"`
+	WebhookURL           string               `json:"webhookUrl,omitempty" dynamodbav:"webhookUrl,omitempty"`
+	Quotas               AccountQuotas        `json:"quotas" dynamodbav:"quotas"`
+	// ParentAccountID makes this account a sub-account, such as a franchisee
+	// of a franchise headquarters, whose locations the parent can list
+	ParentAccountID string `json:"parentAccountId,omitempty" dynamodbav:"parentAccountId,omitempty"`
+	// CategoryTaxonomy classifies the account's shops; Categories lists the custom taxonomy's entries
+	CategoryTaxonomy CategoryTaxonomy `json:"categoryTaxonomy,omitempty" dynamodbav:"categoryTaxonomy,omitempty"`
+	Categories       []string         `json:"categories,omitempty" dynamodbav:"categories,omitempty"`
+	// PublicStoreLocator lets anyone with the public API key find the account's shops
+	PublicStoreLocator bool `json:"publicStoreLocator,omitempty" dynamodbav:"publicStoreLocator,omitempty"`
+	// ReplayProtection rejects mutations without a request ID, or repeating
+	// one, when the Lambda has replay protection enabled
+	ReplayProtection bool `json:"replayProtection,omitempty" dynamodbav:"replayProtection,omitempty"`
+	// FieldVisibility overrides which shop fields API key callers see
+	FieldVisibility map[string]Visibility `json:"fieldVisibility,omitempty" dynamodbav:"fieldVisibility,omitempty"`
+	// PositionChecks flag or reject implausible moves of coordinates locations
+	PositionChecks *PositionChecks `json:"positionChecks,omitempty" dynamodbav:"positionChecks,omitempty"`
+	UpdatedAt      *time.Time      `json:"updatedAt,omitempty" dynamodbav:"updatedAt,omitempty"`
+}
+
+// DefaultAccountSettings returns the settings of an account that has never saved any.
+func DefaultAccountSettings(accountID string) AccountSettings {
+	return AccountSettings{
+		AccountID:            accountID,
+		DefaultUnits:         UnitsMetric,
+		ValidationStrictness: ValidationStrict,
+		CategoryTaxonomy:     CategoryTaxonomyNAICS,
+	}
+}
+
+// Validate validates the account settings.
+func (s AccountSettings) Validate() error {
+	if s.AccountID == "" {
+		return errors.New("accountId is required")
+	}
+	if s.DefaultCountry != "" && len(s.DefaultCountry) != 2 {
+		return errors.New("defaultCountry must be a 2-character ISO 3166-1 alpha-2 code")
+	}
+	switch s.DefaultUnits {
+	case UnitsMetric, UnitsImperial:
+	default:
+		return fmt.Errorf("defaultUnits must be %s or %s", UnitsMetric, UnitsImperial)
+	}
+	switch s.ValidationStrictness {
+	case ValidationStrict, ValidationLenient:
+	default:
+		return fmt.Errorf("validationStrictness must be %s or %s", ValidationStrict, ValidationLenient)
+	}
+	if s.WebhookURL != "" {
+		u, err := url.Parse(s.WebhookURL)
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			return errors.New("webhookUrl must be an absolute https URL")
+		}
+	}
+	if s.ParentAccountID == s.AccountID {
+		return errors.New("parentAccountId must not be the account itself")
+	}
+	if s.Quotas.MaxLocations < 0 || s.Quotas.MaxTemplates < 0 {
+		return errors.New("quotas must not be negative")
+	}
+	if err := s.validateFieldVisibility(); err != nil {
+		return err
+	}
+	if s.PositionChecks != nil {
+		if err := s.PositionChecks.Validate(); err != nil {
+			return err
+		}
+	}
+	return s.validateTaxonomy()
+}
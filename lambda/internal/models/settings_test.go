@@ -0,0 +1,103 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccountSettingsValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*AccountSettings)
+		errMsg string
+	}{
+		{
+			name:   "Defaults",
+			modify: func(s *AccountSettings) {},
+		},
+		{
+			name: "All fields set",
+			modify: func(s *AccountSettings) {
+				s.DefaultCountry = "CA"
+				s.DefaultUnits = UnitsImperial
+				s.ValidationStrictness = ValidationLenient
+				s.WebhookURL = "https://hooks.example.com/locations"
+				s.Quotas = AccountQuotas{MaxLocations: 10000, MaxTemplates: 50}
+				s.ParentAccountID = "acc-hq"
+			},
+		},
+		{
+			name:   "Missing account",
+			modify: func(s *AccountSettings) { s.AccountID = "" },
+			errMsg: "accountId is required",
+		},
+		{
+			name:   "Invalid country",
+			modify: func(s *AccountSettings) { s.DefaultCountry = "USA" },
+			errMsg: "defaultCountry must be a 2-character ISO 3166-1 alpha-2 code",
+		},
+		{
+			name:   "Unknown units",
+			modify: func(s *AccountSettings) { s.DefaultUnits = "nautical" },
+			errMsg: "defaultUnits must be metric or imperial",
+		},
+		{
+			name:   "Unknown strictness",
+			modify: func(s *AccountSettings) { s.ValidationStrictness = "" },
+			errMsg: "validationStrictness must be strict or lenient",
+		},
+		{
+			name:   "Plain HTTP webhook",
+			modify: func(s *AccountSettings) { s.WebhookURL = "http://hooks.example.com" },
+			errMsg: "webhookUrl must be an absolute https URL",
+		},
+		{
+			name:   "Own parent",
+			modify: func(s *AccountSettings) { s.ParentAccountID = "acc-12345" },
+			errMsg: "parentAccountId must not be the account itself",
+		},
+		{
+			name:   "Negative quota",
+			modify: func(s *AccountSettings) { s.Quotas.MaxLocations = -1 },
+			errMsg: "quotas must not be negative",
+		},
+		{
+			name: "Position checks",
+			modify: func(s *AccountSettings) {
+				s.PositionChecks = &PositionChecks{MaxSpeedKmh: 160, Action: PositionCheckFlag}
+			},
+		},
+		{
+			name:   "Position checks without limits",
+			modify: func(s *AccountSettings) { s.PositionChecks = &PositionChecks{Action: PositionCheckReject} },
+			errMsg: "positionChecks: set maxSpeedKmh, maxJumpMeters, or both",
+		},
+		{
+			name: "Negative position check",
+			modify: func(s *AccountSettings) {
+				s.PositionChecks = &PositionChecks{MaxJumpMeters: -1, Action: PositionCheckReject}
+			},
+			errMsg: "positionChecks: limits must not be negative",
+		},
+		{
+			name:   "Unknown position check action",
+			modify: func(s *AccountSettings) { s.PositionChecks = &PositionChecks{MaxJumpMeters: 5000, Action: "drop"} },
+			errMsg: "positionChecks: action must be flag or reject",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := DefaultAccountSettings("acc-12345")
+			tt.modify(&settings)
+
+			err := settings.Validate()
+			if tt.errMsg != "" {
+				assert.EqualError(t, err, tt.errMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// PositionPing is one recorded position of a coordinates location.
+type PositionPing struct {
+	LocationID  string      `json:"locationId" dynamodbav:"locationId"`
+	Coordinates Coordinates `json:"coordinates" dynamodbav:"coordinates"`
+	At          time.Time   `json:"at" dynamodbav:"at"`
+}
+
+// DetectedStop is a place a coordinates location stayed at, derived from its
+// position history.
+type DetectedStop struct {
+	AccountID  string `json:"accountId" dynamodbav:"accountId"`
+	LocationID string `json:"locationId" dynamodbav:"locationId"`
+	// Center is the mean of the positions recorded during the stop
+	Center       Coordinates `json:"center" dynamodbav:"center"`
+	ArrivedAt    time.Time   `json:"arrivedAt" dynamodbav:"arrivedAt"`
+	DepartedAt   time.Time   `json:"departedAt" dynamodbav:"departedAt"`
+	DwellSeconds float64     `json:"dwellSeconds" dynamodbav:"dwellSeconds"`
+	Pings        int         `json:"pings" dynamodbav:"pings"`
+	// TripDistanceMeters is the distance travelled along the recorded
+	// positions since the previous stop, when that stop is known
+	TripDistanceMeters *float64  `json:"tripDistanceMeters,omitempty" dynamodbav:"tripDistanceMeters,omitempty"`
+	DetectedAt         time.Time `json:"detectedAt" dynamodbav:"detectedAt"`
+}
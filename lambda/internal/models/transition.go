@@ -0,0 +1,34 @@
+package models
+
+import (
+	"fmt"
+)
+
+// typeTransitions lists the location types each type may be converted into
+// in place. Address and shop locations share an address, so either can become
+// the other; coordinates locations have none and must be recreated instead.
+var typeTransitions = map[LocationType][]LocationType{
+	LocationTypeAddress: {LocationTypeShop},
+	LocationTypeShop:    {LocationTypeAddress},
+}
+
+// ValidateTypeTransition checks that current, a stored location, may be
+// replaced by next, a location of another type in the same account.
+func ValidateTypeTransition(current, next Location) error {
+	from, to := current.GetLocationType(), next.GetLocationType()
+	if from == to {
+		return fmt.Errorf("location is already of type %s", from)
+	}
+	if current.GetAccountID() != next.GetAccountID() {
+		return fmt.Errorf("accountId cannot change with locationType")
+	}
+	if current.GetExternalID() != next.GetExternalID() {
+		return fmt.Errorf("externalId cannot change with locationType")
+	}
+	for _, allowed := range typeTransitions[from] {
+		if allowed == to {
+			return next.Validate()
+		}
+	}
+	return fmt.Errorf("locationType cannot change from %s to %s", from, to)
+}
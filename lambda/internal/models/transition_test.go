@@ -0,0 +1,57 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateTypeTransition(t *testing.T) {
+	address := Address{StreetAddress: "123 Main St", City: "Springfield", PostalCode: "62704", Country: "US"}
+	addressLocation := AddressLocation{
+		LocationBase: LocationBase{AccountID: "acc-1", LocationType: LocationTypeAddress, ExternalID: "ERP-1"},
+		Address:      address,
+	}
+	shopLocation := ShopLocation{
+		LocationBase: LocationBase{AccountID: "acc-1", LocationType: LocationTypeShop, ExternalID: "ERP-1"},
+		Shop:         Shop{Name: "Main Street Store", ContactID: "contact-1", Address: address},
+	}
+	coordinatesLocation := CoordinatesLocation{
+		LocationBase: LocationBase{AccountID: "acc-1", LocationType: LocationTypeCoordinates, ExternalID: "ERP-1"},
+		Coordinates:  Coordinates{Latitude: 39.78, Longitude: -89.65},
+	}
+
+	otherAccount := shopLocation
+	otherAccount.AccountID = "acc-2"
+	otherExternalID := shopLocation
+	otherExternalID.ExternalID = "ERP-2"
+	unnamedShop := shopLocation
+	unnamedShop.Shop.Name = ""
+
+	tests := []struct {
+		name    string
+		current Location
+		next    Location
+		errMsg  string
+	}{
+		{name: "Address to shop", current: addressLocation, next: shopLocation},
+		{name: "Shop to address", current: shopLocation, next: addressLocation},
+		{name: "Same type", current: addressLocation, next: addressLocation, errMsg: "location is already of type address"},
+		{name: "Address to coordinates", current: addressLocation, next: coordinatesLocation, errMsg: "locationType cannot change from address to coordinates"},
+		{name: "Coordinates to shop", current: coordinatesLocation, next: shopLocation, errMsg: "locationType cannot change from coordinates to shop"},
+		{name: "Account changes", current: addressLocation, next: otherAccount, errMsg: "accountId cannot change with locationType"},
+		{name: "External ID changes", current: addressLocation, next: otherExternalID, errMsg: "externalId cannot change with locationType"},
+		{name: "Invalid new location", current: addressLocation, next: unnamedShop, errMsg: "shop: name is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateTypeTransition(tt.current, tt.next)
+			if tt.errMsg != "" {
+				assert.EqualError(t, err, tt.errMsg)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// MaxLocationUnits is the most units a building can list.
+	MaxLocationUnits = 500
+	// maxUnitFieldLength is the longest unit number, floor, or occupant accepted.
+	maxUnitFieldLength = 128
+)
+
+// Unit is a suite, apartment, or other space within a building.
+type Unit struct {
+	// Number identifies the unit within its building, such as 4B or Suite 210
+	Number string `json:"number" dynamodbav:"number"`
+	// Floor is free text so buildings can use G, B1, or Mezzanine
+	Floor    string `json:"floor,omitempty" dynamodbav:"floor,omitempty"`
+	Occupant string `json:"occupant,omitempty" dynamodbav:"occupant,omitempty"`
+}
+
+// Validate validates the unit.
+func (u Unit) Validate() error {
+	var f fieldErrors
+	if u.Number == "" {
+		f.addMessage("number", "number is required")
+	} else if strings.TrimSpace(u.Number) != u.Number {
+		f.addMessage("number", "number must not have leading or trailing whitespace")
+	}
+	for _, field := range []struct{ name, value string }{{"number", u.Number}, {"floor", u.Floor}, {"occupant", u.Occupant}} {
+		if len(field.value) > maxUnitFieldLength {
+			f.addMessage(field.name, fmt.Sprintf("%s must be at most %d characters", field.name, maxUnitFieldLength))
+		}
+	}
+	return f.err()
+}
+
+// validateUnits validates each unit and rejects a unit number listed twice.
+// Problems are reported under units[i].
+func validateUnits(units []Unit) error {
+	if len(units) > MaxLocationUnits {
+		return ValidationErrors{{Field: "units", Message: fmt.Sprintf("a location can have at most %d units", MaxLocationUnits)}}
+	}
+	var f fieldErrors
+	seen := make(map[string]bool, len(units))
+	for i, unit := range units {
+		path := fmt.Sprintf("units[%d]", i)
+		if err := unit.Validate(); err != nil {
+			f.add(path, err)
+			continue
+		}
+		if seen[unit.Number] {
+			f.addMessage(path+".number", fmt.Sprintf("unit %s is already listed", unit.Number))
+		}
+		seen[unit.Number] = true
+	}
+	return f.err()
+}
+
+// AddUnit lists unit in the building.
+func (l *AddressLocation) AddUnit(unit Unit) error {
+	units := append(append([]Unit(nil), l.Units...), unit)
+	if err := validateUnits(units); err != nil {
+		return err
+	}
+	l.Units = units
+	return nil
+}
+
+// UpdateUnit replaces the unit numbered number, which may renumber it.
+func (l *AddressLocation) UpdateUnit(number string, unit Unit) error {
+	units := append([]Unit(nil), l.Units...)
+	for i := range units {
+		if units[i].Number == number {
+			units[i] = unit
+			if err := validateUnits(units); err != nil {
+				return err
+			}
+			l.Units = units
+			return nil
+		}
+	}
+	return fmt.Errorf("unit %s is not listed", number)
+}
+
+// RemoveUnit removes the unit numbered number and reports whether it was listed.
+func (l *AddressLocation) RemoveUnit(number string) bool {
+	for i, unit := range l.Units {
+		if unit.Number == number {
+			l.Units = append(append([]Unit(nil), l.Units[:i]...), l.Units[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
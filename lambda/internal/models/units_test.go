@@ -0,0 +1,98 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocationUnitsValidation(t *testing.T) {
+	tooMany := make([]Unit, MaxLocationUnits+1)
+	for i := range tooMany {
+		tooMany[i] = Unit{Number: fmt.Sprintf("%d", i)}
+	}
+
+	tests := []struct {
+		name   string
+		units  []Unit
+		errMsg string
+	}{
+		{name: "No units"},
+		{
+			name:  "Suites on several floors",
+			units: []Unit{{Number: "Suite 210", Floor: "2", Occupant: "Acme Corp"}, {Number: "G1", Floor: "G"}},
+		},
+		{name: "Missing number", units: []Unit{{Floor: "2"}}, errMsg: "units[0]: number is required"},
+		{name: "Padded number", units: []Unit{{Number: " 4B"}}, errMsg: "units[0]: number must not have leading or trailing whitespace"},
+		{name: "Long occupant", units: []Unit{{Number: "4B", Occupant: strings.Repeat("a", 129)}}, errMsg: "units[0]: occupant must be at most 128 characters"},
+		{name: "Duplicate number", units: []Unit{{Number: "4B"}, {Number: "4B", Floor: "4"}}, errMsg: "units[1]: unit 4B is already listed"},
+		{name: "Too many units", units: tooMany, errMsg: "a location can have at most 500 units"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			location := AddressLocation{
+				LocationBase: LocationBase{AccountID: "acc-12345", LocationType: LocationTypeAddress},
+				Address:      Address{StreetAddress: "123 Main St", City: "Springfield", PostalCode: "12345", Country: "US"},
+				Units:        tt.units,
+			}
+			err := location.Validate()
+			if tt.errMsg != "" {
+				assert.EqualError(t, err, tt.errMsg)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestAddressLocationUnits(t *testing.T) {
+	lobby := Unit{Number: "G1", Floor: "G"}
+	suite := Unit{Number: "210", Floor: "2", Occupant: "Acme Corp"}
+
+	t.Run("Adds units", func(t *testing.T) {
+		var location AddressLocation
+		require.NoError(t, location.AddUnit(lobby))
+		require.NoError(t, location.AddUnit(suite))
+		assert.Equal(t, []Unit{lobby, suite}, location.Units)
+	})
+
+	t.Run("Rejects invalid and duplicate units unchanged", func(t *testing.T) {
+		location := AddressLocation{Units: []Unit{lobby}}
+		assert.EqualError(t, location.AddUnit(lobby), "units[1]: unit G1 is already listed")
+		assert.Error(t, location.AddUnit(Unit{Floor: "3"}))
+		assert.Equal(t, []Unit{lobby}, location.Units)
+	})
+
+	t.Run("Updates a unit in place", func(t *testing.T) {
+		location := AddressLocation{Units: []Unit{lobby, suite}}
+		vacant := Unit{Number: "210", Floor: "2"}
+		require.NoError(t, location.UpdateUnit("210", vacant))
+		assert.Equal(t, []Unit{lobby, vacant}, location.Units)
+	})
+
+	t.Run("Renumbers a unit unless the number is taken", func(t *testing.T) {
+		location := AddressLocation{Units: []Unit{lobby, suite}}
+		assert.EqualError(t, location.UpdateUnit("210", Unit{Number: "G1"}), "units[1]: unit G1 is already listed")
+		assert.Equal(t, []Unit{lobby, suite}, location.Units)
+
+		require.NoError(t, location.UpdateUnit("210", Unit{Number: "210A", Floor: "2"}))
+		assert.Equal(t, []Unit{lobby, {Number: "210A", Floor: "2"}}, location.Units)
+	})
+
+	t.Run("Reports unknown units", func(t *testing.T) {
+		location := AddressLocation{Units: []Unit{lobby}}
+		assert.EqualError(t, location.UpdateUnit("210", suite), "unit 210 is not listed")
+		assert.False(t, location.RemoveUnit("210"))
+		assert.Equal(t, []Unit{lobby}, location.Units)
+	})
+
+	t.Run("Removes a unit", func(t *testing.T) {
+		location := AddressLocation{Units: []Unit{lobby, suite}}
+		assert.True(t, location.RemoveUnit("G1"))
+		assert.Equal(t, []Unit{suite}, location.Units)
+	})
+}
@@ -0,0 +1,78 @@
+package models
+
+import "strings"
+
+// FieldError is one problem found by validation.
+type FieldError struct {
+	// Field is the path of the invalid field, such as shop.address.city, or
+	// empty for a problem with the object as a whole
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error returns the message, prefixed with the path of the object holding a
+// nested field, as in "shop.address: city is required".
+func (e FieldError) Error() string {
+	if i := strings.LastIndex(e.Field, "."); i >= 0 {
+		return e.Field[:i] + ": " + e.Message
+	}
+	return e.Message
+}
+
+// ValidationErrors is every problem found validating an object, in the order
+// its fields are checked, so a form can flag all of them at once.
+type ValidationErrors []FieldError
+
+// Error joins the problems with semicolons.
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, fieldErr := range e {
+		messages[i] = fieldErr.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// fieldErrors accumulates the problems of one object.
+type fieldErrors struct {
+	errs ValidationErrors
+}
+
+// add records err against field. The problems of a nested object's
+// ValidationErrors are recorded individually, their paths under field.
+func (f *fieldErrors) add(field string, err error) {
+	if err == nil {
+		return
+	}
+	nested, ok := err.(ValidationErrors)
+	if !ok {
+		f.errs = append(f.errs, FieldError{Field: field, Message: err.Error()})
+		return
+	}
+	for _, fieldErr := range nested {
+		f.errs = append(f.errs, FieldError{Field: joinFieldPath(field, fieldErr.Field), Message: fieldErr.Message})
+	}
+}
+
+// addMessage records a problem with field.
+func (f *fieldErrors) addMessage(field, message string) {
+	f.errs = append(f.errs, FieldError{Field: field, Message: message})
+}
+
+// err returns the problems recorded, or nil when there are none.
+func (f *fieldErrors) err() error {
+	if len(f.errs) == 0 {
+		return nil
+	}
+	return f.errs
+}
+
+// joinFieldPath appends a nested field's path to its parent's.
+func joinFieldPath(parent, field string) string {
+	switch {
+	case parent == "":
+		return field
+	case field == "":
+		return parent
+	}
+	return parent + "." + field
+}
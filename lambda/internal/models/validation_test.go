@@ -0,0 +1,170 @@
+package models
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidationErrors(t *testing.T) {
+	errs := ValidationErrors{
+		{Field: "accountId", Message: "accountId is required"},
+		{Field: "shop.address.city", Message: "city is required"},
+		{Message: "event requires an address or coordinates"},
+	}
+	assert.EqualError(t, errs, "accountId is required; shop.address: city is required; event requires an address or coordinates")
+}
+
+func TestValidateReturnsEveryProblem(t *testing.T) {
+	location := ShopLocation{
+		LocationBase: LocationBase{LocationType: LocationTypeShop, ExternalID: " store-1"},
+		Shop: Shop{
+			ContactID:   "contact-1",
+			Address:     Address{StreetAddress: "123 Main St", Country: "USA"},
+			Coordinates: &Coordinates{Latitude: 95, Longitude: -104.99},
+			BrandColor:  "blue",
+		},
+	}
+
+	err := location.Validate()
+	var errs ValidationErrors
+	require.True(t, errors.As(err, &errs))
+	assert.Equal(t, ValidationErrors{
+		{Field: "accountId", Message: "accountId is required"},
+		{Field: "externalId", Message: "externalId must not have leading or trailing whitespace"},
+		{Field: "shop.name", Message: "name is required"},
+		{Field: "shop.address.city", Message: "city is required"},
+		{Field: "shop.address.postalCode", Message: "postalCode is required"},
+		{Field: "shop.address.country", Message: "country must be a 2-character ISO 3166-1 alpha-2 code"},
+		{Field: "shop.coordinates.latitude", Message: "latitude must be between -90 and 90, got 95.000000"},
+		{Field: "shop.brandColor", Message: `brandColor must be a hex color such as #1A2B3C, got "blue"`},
+	}, errs)
+}
+
+func TestValidateReportsPathsWithinCollections(t *testing.T) {
+	location := ShopLocation{
+		LocationBase: LocationBase{AccountID: "acc-12345", LocationType: LocationTypeShop},
+		Shop: Shop{
+			Name:        "Coffee Shop",
+			ContactID:   "contact-1",
+			Address:     Address{StreetAddress: "123 Main St", City: "Springfield", PostalCode: "12345", Country: "US"},
+			SocialLinks: map[string]string{"myspace": "https://myspace.com/coffee"},
+			Contacts: []ShopContact{
+				{ContactID: "contact-2", Role: ShopContactRoleManager},
+				{Role: "owner"},
+				{ContactID: "contact-2", Role: ShopContactRoleManager},
+			},
+		},
+	}
+
+	err := location.Validate()
+	var errs ValidationErrors
+	require.True(t, errors.As(err, &errs))
+	assert.Equal(t, []string{"shop.socialLinks.myspace", "shop.contacts[1].contactId", "shop.contacts[1].role", "shop.contacts[2].contactId"}, fieldPaths(errs))
+	assert.EqualError(t, err, "shop.socialLinks: myspace is not a known network; use one of facebook, instagram, linkedin, pinterest, tiktok, x, yelp, youtube; "+
+		"shop.contacts[1]: contactId is required; "+
+		`shop.contacts[1]: role must be one of manager, billing, or emergency, got "owner"; `+
+		"shop.contacts[2]: contact contact-2 is already listed as manager")
+}
+
+// fieldPaths returns the field of each problem.
+func fieldPaths(errs ValidationErrors) []string {
+	paths := make([]string, len(errs))
+	for i, fieldErr := range errs {
+		paths[i] = fieldErr.Field
+	}
+	return paths
+}
+
+func TestUnmarshalLocationReturnsEveryProblem(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want ValidationErrors
+	}{
+		{
+			name: "Wrong types and invalid values",
+			json: `{
+				"accountId": 12345,
+				"locationType": "coordinates",
+				"coordinates": {"latitude": "39.7", "longitude": 200},
+				"building": true
+			}`,
+			want: ValidationErrors{
+				{Field: "accountId", Message: "accountId must be a string, got number"},
+				{Field: "building", Message: "building must be a string, got bool"},
+				{Field: "coordinates.latitude", Message: "latitude must be a number, got string"},
+				{Field: "coordinates.longitude", Message: "longitude must be between -180 and 180, got 200.000000"},
+			},
+		},
+		{
+			name: "Wrong type within an array",
+			json: `{
+				"accountId": "acc-12345",
+				"locationType": "address",
+				"address": {"streetAddress": "123 Main St", "city": "Springfield", "postalCode": 12345, "country": "US"},
+				"units": [{"number": 4}]
+			}`,
+			want: ValidationErrors{
+				{Field: "address.postalCode", Message: "postalCode must be a string, got number"},
+				{Field: "units[0].number", Message: "number must be a string, got number"},
+			},
+		},
+		{
+			name: "Wrong types within a list of strings",
+			json: `{
+				"accountId": "acc-12345",
+				"locationType": "shop",
+				"shop": {
+					"name": "Main Street Store",
+					"contactId": "contact-1",
+					"address": {"streetAddress": "123 Main St", "city": "Springfield", "postalCode": "12345", "country": "US"},
+					"categories": ["grocery", 7, true]
+				}
+			}`,
+			want: ValidationErrors{
+				{Field: "shop.categories[1]", Message: "categories[1] must be a string, got number"},
+				{Field: "shop.categories[2]", Message: "categories[2] must be a string, got bool"},
+			},
+		},
+		{
+			name: "Wrong types within a shop",
+			json: `{
+				"accountId": "acc-12345",
+				"locationType": "shop",
+				"shop": {
+					"name": 7,
+					"contactId": "contact-1",
+					"address": {"streetAddress": "123 Main St", "city": ["Springfield"], "postalCode": "12345", "country": "US"},
+					"email": "not-an-email"
+				}
+			}`,
+			want: ValidationErrors{
+				{Field: "shop.name", Message: "name must be a string, got number"},
+				{Field: "shop.address.city", Message: "city must be a string, got array"},
+				{Field: "shop.email", Message: `email must be an address such as shop@example.com, got "not-an-email"`},
+			},
+		},
+		{
+			name: "Unknown location type",
+			json: `{"accountId": "acc-12345", "locationType": "unknown"}`,
+			want: ValidationErrors{{Field: "locationType", Message: "unknown location type: unknown"}},
+		},
+		{
+			name: "Location type of the wrong type",
+			json: `{"accountId": "acc-12345", "locationType": 7}`,
+			want: ValidationErrors{{Field: "locationType", Message: "locationType must be a string, got number"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := UnmarshalLocation([]byte(tt.json))
+			var errs ValidationErrors
+			require.True(t, errors.As(err, &errs), "got %v", err)
+			assert.Equal(t, tt.want, errs)
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// VerificationStatus is the outcome of verifying an address with a provider.
+type VerificationStatus string
+
+const (
+	// VerificationStatusVerified means the address is deliverable as entered.
+	VerificationStatusVerified VerificationStatus = "verified"
+	// VerificationStatusCorrected means the address is deliverable once standardized.
+	VerificationStatusCorrected VerificationStatus = "corrected"
+	// VerificationStatusUndeliverable means the provider could not match the address.
+	VerificationStatusUndeliverable VerificationStatus = "undeliverable"
+	// VerificationStatusUnsupported means the provider does not cover the address's country.
+	VerificationStatusUnsupported VerificationStatus = "unsupported"
+)
+
+// AddressVerification records the result of verifying an address.
+type AddressVerification struct {
+	Status     VerificationStatus `json:"status" dynamodbav:"status"`
+	Provider   string             `json:"provider" dynamodbav:"provider"`
+	VerifiedAt time.Time          `json:"verifiedAt" dynamodbav:"verifiedAt"`
+	// StandardizedAddress is the provider's canonical form of a deliverable address
+	StandardizedAddress *Address `json:"standardizedAddress,omitempty" dynamodbav:"standardizedAddress,omitempty"`
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Visibility is who can see a field: anyone, or only the account's signed-in users.
+type Visibility string
+
+const (
+	// VisibilityPublic fields are returned to API key callers, such as store locators.
+	VisibilityPublic Visibility = "public"
+	// VisibilityInternal fields are returned only to signed-in users.
+	VisibilityInternal Visibility = "internal"
+)
+
+// shopFieldVisibility lists the shop fields an account can make public or
+// internal, with their defaults. A shop's name and coordinates are always
+// public, since a store locator can't show a shop without them; fields not
+// listed here, such as contactId, contacts, and enrichment, are always internal.
+var shopFieldVisibility = map[string]Visibility{
+	"address":     VisibilityPublic,
+	"phone":       VisibilityPublic,
+	"email":       VisibilityPublic,
+	"websiteUrl":  VisibilityPublic,
+	"socialLinks": VisibilityPublic,
+	"categories":  VisibilityPublic,
+	"brandColor":  VisibilityPublic,
+	"hours":       VisibilityPublic,
+	"logoKey":     VisibilityInternal,
+	"photoKeys":   VisibilityInternal,
+}
+
+// AlwaysPublicShopFields are the shop fields every public view includes.
+var AlwaysPublicShopFields = []string{"name", "coordinates"}
+
+// ConfigurableShopFields returns the shop fields whose visibility an account
+// can set, sorted.
+func ConfigurableShopFields() []string {
+	fields := make([]string, 0, len(shopFieldVisibility))
+	for field := range shopFieldVisibility {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// ShopFieldVisibility returns the visibility of a shop field in the account,
+// its override when set and otherwise the default.
+func (s AccountSettings) ShopFieldVisibility(field string) Visibility {
+	for _, always := range AlwaysPublicShopFields {
+		if field == always {
+			return VisibilityPublic
+		}
+	}
+	defaultVisibility, ok := shopFieldVisibility[field]
+	if !ok {
+		return VisibilityInternal
+	}
+	if visibility, ok := s.FieldVisibility[field]; ok {
+		return visibility
+	}
+	return defaultVisibility
+}
+
+// validateFieldVisibility checks the visibility overrides name configurable
+// fields and known visibilities.
+func (s AccountSettings) validateFieldVisibility() error {
+	for field, visibility := range s.FieldVisibility {
+		if _, ok := shopFieldVisibility[field]; !ok {
+			return fmt.Errorf("fieldVisibility: %q is not a configurable field; use one of %v", field, ConfigurableShopFields())
+		}
+		if visibility != VisibilityPublic && visibility != VisibilityInternal {
+			return fmt.Errorf("fieldVisibility: %s must be %s or %s", field, VisibilityPublic, VisibilityInternal)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccountSettingsShopFieldVisibility(t *testing.T) {
+	settings := DefaultAccountSettings("acc-12345")
+	settings.FieldVisibility = map[string]Visibility{"phone": VisibilityInternal, "logoKey": VisibilityPublic}
+
+	tests := []struct {
+		field string
+		want  Visibility
+	}{
+		{field: "name", want: VisibilityPublic},
+		{field: "coordinates", want: VisibilityPublic},
+		{field: "address", want: VisibilityPublic},
+		{field: "phone", want: VisibilityInternal},
+		{field: "logoKey", want: VisibilityPublic},
+		{field: "photoKeys", want: VisibilityInternal},
+		{field: "contactId", want: VisibilityInternal},
+		{field: "enrichment", want: VisibilityInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			assert.Equal(t, tt.want, settings.ShopFieldVisibility(tt.field))
+		})
+	}
+}
+
+func TestAccountSettingsFieldVisibilityValidation(t *testing.T) {
+	tests := []struct {
+		name       string
+		visibility map[string]Visibility
+		errMsg     string
+	}{
+		{name: "No overrides"},
+		{name: "Configurable fields", visibility: map[string]Visibility{"email": VisibilityInternal, "photoKeys": VisibilityPublic}},
+		{name: "Always internal field", visibility: map[string]Visibility{"contactId": VisibilityPublic}, errMsg: `fieldVisibility: "contactId" is not a configurable field; use one of [address brandColor categories email hours logoKey phone photoKeys socialLinks websiteUrl]`},
+		{name: "Always public field", visibility: map[string]Visibility{"name": VisibilityInternal}, errMsg: `fieldVisibility: "name" is not a configurable field; use one of [address brandColor categories email hours logoKey phone photoKeys socialLinks websiteUrl]`},
+		{name: "Unknown visibility", visibility: map[string]Visibility{"email": "private"}, errMsg: "fieldVisibility: email must be public or internal"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := DefaultAccountSettings("acc-12345")
+			settings.FieldVisibility = tt.visibility
+			err := settings.Validate()
+			if tt.errMsg != "" {
+				assert.EqualError(t, err, tt.errMsg)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,76 @@
+// Package notify tells account administrators about changes they need to act on.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// NoticeContactDeleted is sent when shops lose a contact deleted by the contacts service.
+const NoticeContactDeleted = "contactDeleted"
+
+// NoticePositionAnomaly is sent when a position update fails the account's position checks.
+const NoticePositionAnomaly = "positionAnomaly"
+
+// Notice is the JSON body posted to an account's webhook.
+type Notice struct {
+	Type      string `json:"type"`
+	AccountID string `json:"accountId"`
+	ContactID string `json:"contactId,omitempty"`
+	// LocationIDs are the locations the notice is about
+	LocationIDs []string  `json:"locationIds"`
+	Message     string    `json:"message"`
+	SentAt      time.Time `json:"sentAt"`
+}
+
+// Notifier delivers notices to an account's administrators.
+type Notifier interface {
+	Notify(ctx context.Context, webhookURL string, notice Notice) error
+}
+
+// HTTPClient is the subset of http.Client used by WebhookNotifier.
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// WebhookNotifier posts notices to the webhookUrl in an account's settings.
+type WebhookNotifier struct {
+	httpClient HTTPClient
+}
+
+// NewWebhookNotifier creates a webhook notifier that gives up on a webhook
+// after timeout.
+func NewWebhookNotifier(timeout time.Duration) *WebhookNotifier {
+	return &WebhookNotifier{httpClient: &http.Client{Timeout: timeout}}
+}
+
+// Notify posts notice to webhookURL. Any 2xx response counts as delivered.
+func (n *WebhookNotifier) Notify(ctx context.Context, webhookURL string, notice Notice) error {
+	body, err := json.Marshal(notice)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notice: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		reply, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook failed with status %d: %s", resp.StatusCode, reply)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookNotifier(t *testing.T) {
+	notice := Notice{
+		Type:        NoticeContactDeleted,
+		AccountID:   "acc-12345",
+		ContactID:   "contact-1",
+		LocationIDs: []string{"loc-1"},
+		Message:     "contact contact-1 was deleted",
+		SentAt:      time.Date(2024, time.June, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	t.Run("Posts the notice", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, http.MethodPost, r.Method)
+			assert.Equal(t, "/hooks/locations", r.URL.Path)
+			assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+			var got Notice
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+			assert.Equal(t, notice, got)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		err := NewWebhookNotifier(time.Second).Notify(context.Background(), server.URL+"/hooks/locations", notice)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Webhook error", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte("try later"))
+		}))
+		defer server.Close()
+
+		err := NewWebhookNotifier(time.Second).Notify(context.Background(), server.URL, notice)
+		assert.EqualError(t, err, "webhook failed with status 503: try later")
+	})
+}
@@ -0,0 +1,149 @@
+// Package permissions decides which operations a caller's roles allow.
+package permissions
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+)
+
+// DefaultRoleClaim is the identity claim read for roles when a policy names none.
+const DefaultRoleClaim = "cognito:groups"
+
+// Action is whether an operation only reads or also changes data.
+type Action string
+
+const (
+	// ActionRead covers the GraphQL queries.
+	ActionRead Action = "read"
+	// ActionWrite covers the GraphQL mutations.
+	ActionWrite Action = "write"
+)
+
+// Role is what callers holding it may do.
+type Role struct {
+	// Operations lists GraphQL field names, "read" for every query, "write"
+	// for every mutation, or "*" for everything
+	Operations []string `json:"operations"`
+	// LocationTypes limits the role's writes to locations of these types;
+	// empty allows any
+	LocationTypes []models.LocationType `json:"locationTypes,omitempty"`
+}
+
+// Policy maps roles, read from an identity claim, to what they allow. A
+// caller may hold several roles, and gets everything any of them allows.
+type Policy struct {
+	// RoleClaim is the claim listing the caller's roles; empty uses cognito:groups
+	RoleClaim string `json:"roleClaim,omitempty"`
+	// DefaultRole is assumed for callers holding none of the policy's roles;
+	// empty denies them everything
+	DefaultRole string          `json:"defaultRole,omitempty"`
+	Roles       map[string]Role `json:"roles"`
+}
+
+// Parse reads a policy from JSON and validates it.
+func Parse(data string) (*Policy, error) {
+	var policy Policy
+	if err := json.Unmarshal([]byte(data), &policy); err != nil {
+		return nil, fmt.Errorf("failed to parse permissions policy: %w", err)
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// Validate validates the policy.
+func (p Policy) Validate() error {
+	if len(p.Roles) == 0 {
+		return fmt.Errorf("permissions policy must define at least one role")
+	}
+	if _, ok := p.Roles[p.DefaultRole]; p.DefaultRole != "" && !ok {
+		return fmt.Errorf("defaultRole %s is not one of the policy's roles", p.DefaultRole)
+	}
+	for name, role := range p.Roles {
+		if len(role.Operations) == 0 {
+			return fmt.Errorf("role %s must allow at least one operation", name)
+		}
+		for _, locationType := range role.LocationTypes {
+			switch locationType {
+			case models.LocationTypeAddress, models.LocationTypeCoordinates, models.LocationTypeShop, models.LocationTypeEvent:
+			default:
+				return fmt.Errorf("role %s: unknown location type %q", name, locationType)
+			}
+		}
+	}
+	return nil
+}
+
+// Claim returns the identity claim listing the caller's roles.
+func (p Policy) Claim() string {
+	if p.RoleClaim == "" {
+		return DefaultRoleClaim
+	}
+	return p.RoleClaim
+}
+
+// Grant is what a caller's roles allow for one operation.
+type Grant struct {
+	// LocationTypes limits a write to locations of these types; nil allows any
+	LocationTypes map[models.LocationType]bool
+}
+
+// AllowsType reports whether the grant covers writing a location of locationType.
+func (g Grant) AllowsType(locationType models.LocationType) bool {
+	return g.LocationTypes == nil || g.LocationTypes[locationType]
+}
+
+// Types returns the location types a limited grant covers, sorted.
+func (g Grant) Types() []string {
+	types := make([]string, 0, len(g.LocationTypes))
+	for locationType := range g.LocationTypes {
+		types = append(types, string(locationType))
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Authorize returns what roles allow for operation, an action of kind
+// action, and false when none of them allow it. Location type limits only
+// apply to writes; a role without limits lifts those of the caller's others.
+func (p Policy) Authorize(roles []string, operation string, action Action) (Grant, bool) {
+	held := make([]Role, 0, len(roles))
+	for _, name := range roles {
+		if role, ok := p.Roles[name]; ok {
+			held = append(held, role)
+		}
+	}
+	if len(held) == 0 && p.DefaultRole != "" {
+		held = append(held, p.Roles[p.DefaultRole])
+	}
+
+	allowed := false
+	grant := Grant{LocationTypes: map[models.LocationType]bool{}}
+	for _, role := range held {
+		if !role.allows(operation, action) {
+			continue
+		}
+		allowed = true
+		if action == ActionRead || len(role.LocationTypes) == 0 {
+			return Grant{}, true
+		}
+		for _, locationType := range role.LocationTypes {
+			grant.LocationTypes[locationType] = true
+		}
+	}
+	return grant, allowed
+}
+
+// allows reports whether the role's operations cover operation.
+func (r Role) allows(operation string, action Action) bool {
+	for _, allowed := range r.Operations {
+		if allowed == "*" || allowed == operation || allowed == string(action) {
+			return true
+		}
+	}
+	return false
+}
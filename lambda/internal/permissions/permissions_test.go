@@ -0,0 +1,97 @@
+package permissions
+
+import (
+	"testing"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("Valid policy", func(t *testing.T) {
+		policy, err := Parse(`{
+			"defaultRole": "viewer",
+			"roles": {
+				"viewer": {"operations": ["read"]},
+				"shop-editor": {"operations": ["read", "write"], "locationTypes": ["shop"]}
+			}
+		}`)
+		require.NoError(t, err)
+		assert.Equal(t, DefaultRoleClaim, policy.Claim())
+		assert.Equal(t, []models.LocationType{models.LocationTypeShop}, policy.Roles["shop-editor"].LocationTypes)
+	})
+
+	tests := []struct {
+		name   string
+		policy string
+		errMsg string
+	}{
+		{name: "Malformed", policy: `{"roles": [`, errMsg: "failed to parse permissions policy"},
+		{name: "No roles", policy: `{}`, errMsg: "permissions policy must define at least one role"},
+		{name: "Unknown default role", policy: `{"defaultRole": "guest", "roles": {"viewer": {"operations": ["read"]}}}`, errMsg: "defaultRole guest is not one of the policy's roles"},
+		{name: "Role without operations", policy: `{"roles": {"viewer": {}}}`, errMsg: "role viewer must allow at least one operation"},
+		{name: "Unknown location type", policy: `{"roles": {"editor": {"operations": ["write"], "locationTypes": ["store"]}}}`, errMsg: `role editor: unknown location type "store"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.policy)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
+
+func TestPolicyAuthorize(t *testing.T) {
+	policy := Policy{
+		Roles: map[string]Role{
+			"viewer":      {Operations: []string{"read"}},
+			"shop-editor": {Operations: []string{"read", "write"}, LocationTypes: []models.LocationType{models.LocationTypeShop}},
+			"event-staff": {Operations: []string{"updateEventLocation"}, LocationTypes: []models.LocationType{models.LocationTypeEvent}},
+			"admin":       {Operations: []string{"*"}},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		roles     []string
+		operation string
+		action    Action
+		allowed   bool
+		types     []string
+	}{
+		{name: "Viewer lists", roles: []string{"viewer"}, operation: "listLocations", action: ActionRead, allowed: true, types: []string{}},
+		{name: "Viewer cannot delete", roles: []string{"viewer"}, operation: "deleteLocation", action: ActionWrite},
+		{name: "Limited writer", roles: []string{"shop-editor"}, operation: "deleteLocation", action: ActionWrite, allowed: true, types: []string{"shop"}},
+		{name: "Limits combine", roles: []string{"shop-editor", "event-staff"}, operation: "updateEventLocation", action: ActionWrite, allowed: true, types: []string{"event", "shop"}},
+		{name: "Named operation only", roles: []string{"event-staff"}, operation: "deleteLocation", action: ActionWrite},
+		{name: "Unlimited role lifts limits", roles: []string{"shop-editor", "admin"}, operation: "deleteLocation", action: ActionWrite, allowed: true, types: []string{}},
+		{name: "Unknown roles", roles: []string{"guest"}, operation: "listLocations", action: ActionRead},
+		{name: "No roles", operation: "listLocations", action: ActionRead},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			grant, allowed := policy.Authorize(tt.roles, tt.operation, tt.action)
+			assert.Equal(t, tt.allowed, allowed)
+			if tt.allowed {
+				assert.Equal(t, tt.types, grant.Types())
+			}
+		})
+	}
+
+	t.Run("Default role", func(t *testing.T) {
+		withDefault := policy
+		withDefault.DefaultRole = "viewer"
+		_, allowed := withDefault.Authorize(nil, "getLocation", ActionRead)
+		assert.True(t, allowed)
+		_, allowed = withDefault.Authorize([]string{"event-staff"}, "getLocation", ActionRead)
+		assert.False(t, allowed, "a held role replaces the default")
+	})
+}
+
+func TestGrantAllowsType(t *testing.T) {
+	assert.True(t, Grant{}.AllowsType(models.LocationTypeEvent))
+	limited := Grant{LocationTypes: map[models.LocationType]bool{models.LocationTypeShop: true}}
+	assert.True(t, limited.AllowsType(models.LocationTypeShop))
+	assert.False(t, limited.AllowsType(models.LocationTypeEvent))
+}
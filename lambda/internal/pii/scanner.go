@@ -0,0 +1,225 @@
+// Package pii detects and redacts apparent personal data in free-form attributes.
+package pii
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Kind identifies the type of personal data detected.
+type Kind string
+
+const (
+	// KindEmail is an email address.
+	KindEmail Kind = "email"
+	// KindPhone is a phone number.
+	KindPhone Kind = "phone"
+)
+
+// Policy determines how detected PII is handled on write.
+type Policy string
+
+const (
+	// PolicyOff disables scanning.
+	PolicyOff Policy = "off"
+	// PolicyReject fails the write when PII is detected.
+	PolicyReject Policy = "reject"
+	// PolicyMask replaces detected PII with a masked value.
+	PolicyMask Policy = "mask"
+	// PolicyTag stores the write unchanged and records where PII was found.
+	PolicyTag Policy = "tag"
+)
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	phonePattern = regexp.MustCompile(`\+?\(?\d[\d\s().\-]{7,}\d`)
+)
+
+// minPhoneDigits avoids flagging short numeric strings such as postal codes.
+const minPhoneDigits = 10
+
+// Finding describes one detected PII value.
+type Finding struct {
+	Path string `json:"path"`
+	Kind Kind   `json:"kind"`
+}
+
+// String formats the finding for error messages.
+func (f Finding) String() string {
+	return fmt.Sprintf("%s (%s)", f.Path, f.Kind)
+}
+
+// Config holds the default policy and per-account overrides.
+type Config struct {
+	Default  Policy
+	Accounts map[string]Policy
+}
+
+// PolicyFor returns the policy that applies to an account.
+func (c Config) PolicyFor(accountID string) Policy {
+	if policy, ok := c.Accounts[accountID]; ok {
+		return policy
+	}
+	if c.Default == "" {
+		return PolicyOff
+	}
+	return c.Default
+}
+
+// ParsePolicy validates a policy name.
+func ParsePolicy(value string) (Policy, error) {
+	switch policy := Policy(strings.ToLower(strings.TrimSpace(value))); policy {
+	case "":
+		return PolicyOff, nil
+	case PolicyOff, PolicyReject, PolicyMask, PolicyTag:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown PII policy: %s", value)
+	}
+}
+
+// ParseConfig builds a Config from a default policy and a comma-separated list
+// of account overrides in the form "accountId:policy".
+func ParseConfig(defaultPolicy, accountPolicies string) (Config, error) {
+	def, err := ParsePolicy(defaultPolicy)
+	if err != nil {
+		return Config{}, err
+	}
+
+	cfg := Config{Default: def, Accounts: map[string]Policy{}}
+	for _, entry := range strings.Split(accountPolicies, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		accountID, value, ok := strings.Cut(entry, ":")
+		if !ok || accountID == "" {
+			return Config{}, fmt.Errorf("invalid account PII policy: %s", entry)
+		}
+		policy, err := ParsePolicy(value)
+		if err != nil {
+			return Config{}, err
+		}
+		cfg.Accounts[accountID] = policy
+	}
+	return cfg, nil
+}
+
+// Scan returns the PII findings in attrs, ordered by path.
+func Scan(attrs map[string]interface{}) []Finding {
+	var findings []Finding
+	walk("", attrs, func(path, value string) string {
+		for _, kind := range detect(value) {
+			findings = append(findings, Finding{Path: path, Kind: kind})
+		}
+		return value
+	})
+	sort.Slice(findings, func(i, j int) bool {
+		if findings[i].Path == findings[j].Path {
+			return findings[i].Kind < findings[j].Kind
+		}
+		return findings[i].Path < findings[j].Path
+	})
+	return findings
+}
+
+// Mask returns a copy of attrs with detected PII replaced by masked values.
+func Mask(attrs map[string]interface{}) map[string]interface{} {
+	masked, _ := walk("", attrs, func(_, value string) string {
+		value = emailPattern.ReplaceAllStringFunc(value, maskEmail)
+		return phonePattern.ReplaceAllStringFunc(value, func(match string) string {
+			if countDigits(match) < minPhoneDigits {
+				return match
+			}
+			return maskPhone(match)
+		})
+	}).(map[string]interface{})
+	return masked
+}
+
+// detect returns the PII kinds present in a string value.
+func detect(value string) []Kind {
+	var kinds []Kind
+	if emailPattern.MatchString(value) {
+		kinds = append(kinds, KindEmail)
+	}
+	for _, match := range phonePattern.FindAllString(value, -1) {
+		if countDigits(match) >= minPhoneDigits {
+			kinds = append(kinds, KindPhone)
+			break
+		}
+	}
+	return kinds
+}
+
+// walk visits every string in a JSON-like value, replacing it with the result
+// of fn, and returns the rebuilt value.
+func walk(path string, value interface{}, fn func(path, value string) string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, elem := range v {
+			out[key] = walk(joinPath(path, key), elem, fn)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, elem := range v {
+			out[i] = walk(fmt.Sprintf("%s[%d]", path, i), elem, fn)
+		}
+		return out
+	case string:
+		return fn(path, v)
+	default:
+		return v
+	}
+}
+
+// joinPath appends a key to a dotted path.
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// maskEmail keeps the first character of the local part and the domain.
+func maskEmail(email string) string {
+	local, domain, _ := strings.Cut(email, "@")
+	if local == "" {
+		return "***@" + domain
+	}
+	return local[:1] + "***@" + domain
+}
+
+// maskPhone replaces all but the last four digits with asterisks.
+func maskPhone(phone string) string {
+	remaining := countDigits(phone)
+	var b strings.Builder
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			if remaining > 4 {
+				b.WriteRune('*')
+			} else {
+				b.WriteRune(r)
+			}
+			remaining--
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// countDigits returns the number of ASCII digits in s.
+func countDigits(s string) int {
+	n := 0
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,87 @@
+package pii
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		name     string
+		attrs    map[string]interface{}
+		expected []Finding
+	}{
+		{
+			name:     "No PII",
+			attrs:    map[string]interface{}{"color": "blue", "zip": "10001", "floors": 3},
+			expected: nil,
+		},
+		{
+			name:     "Top-level email",
+			attrs:    map[string]interface{}{"owner": "jane.doe@example.com"},
+			expected: []Finding{{Path: "owner", Kind: KindEmail}},
+		},
+		{
+			name: "Nested phone and email",
+			attrs: map[string]interface{}{
+				"contact": map[string]interface{}{
+					"phones": []interface{}{"+1 (555) 123-4567"},
+					"note":   "email ops@example.org",
+				},
+			},
+			expected: []Finding{
+				{Path: "contact.note", Kind: KindEmail},
+				{Path: "contact.phones[0]", Kind: KindPhone},
+			},
+		},
+		{
+			name:     "Short digit runs are not phones",
+			attrs:    map[string]interface{}{"dock": "12-34-56"},
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Scan(tt.attrs))
+		})
+	}
+}
+
+func TestMask(t *testing.T) {
+	attrs := map[string]interface{}{
+		"owner": "jane.doe@example.com",
+		"contact": map[string]interface{}{
+			"phone": "555-123-4567",
+		},
+		"dock": "12-34-56",
+	}
+
+	masked := Mask(attrs)
+
+	assert.Equal(t, "j***@example.com", masked["owner"])
+	assert.Equal(t, "***-***-4567", masked["contact"].(map[string]interface{})["phone"])
+	assert.Equal(t, "12-34-56", masked["dock"])
+	assert.Equal(t, "jane.doe@example.com", attrs["owner"], "input must not be modified")
+	assert.Empty(t, Scan(masked))
+}
+
+func TestParseConfig(t *testing.T) {
+	cfg, err := ParseConfig("tag", "acc-1:reject, acc-2:MASK")
+	require.NoError(t, err)
+	assert.Equal(t, PolicyTag, cfg.PolicyFor("acc-other"))
+	assert.Equal(t, PolicyReject, cfg.PolicyFor("acc-1"))
+	assert.Equal(t, PolicyMask, cfg.PolicyFor("acc-2"))
+
+	cfg, err = ParseConfig("", "")
+	require.NoError(t, err)
+	assert.Equal(t, PolicyOff, cfg.PolicyFor("acc-1"))
+
+	_, err = ParseConfig("redact", "")
+	assert.Error(t, err)
+
+	_, err = ParseConfig("off", "acc-1")
+	assert.Error(t, err)
+}
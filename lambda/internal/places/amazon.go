@@ -0,0 +1,151 @@
+package places
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+// AmazonPlacesProvider finds places with the Amazon Location Service Places
+// v2 API. Text search needs a bias position, so the address is geocoded
+// first and the business name is searched near it.
+type AmazonPlacesProvider struct {
+	httpClient  HTTPClient
+	signer      *v4.Signer
+	credentials aws.CredentialsProvider
+	region      string
+	endpoint    string
+}
+
+// NewAmazonPlacesProvider creates a provider in the configured region.
+func NewAmazonPlacesProvider(cfg aws.Config) *AmazonPlacesProvider {
+	return &AmazonPlacesProvider{
+		httpClient:  http.DefaultClient,
+		signer:      v4.NewSigner(),
+		credentials: cfg.Credentials,
+		region:      cfg.Region,
+		endpoint:    fmt.Sprintf("https://places.geo.%s.amazonaws.com", cfg.Region),
+	}
+}
+
+// amazonGeocodeRequest is the Geocode request body.
+type amazonGeocodeRequest struct {
+	QueryText  string `json:"QueryText"`
+	MaxResults int    `json:"MaxResults"`
+}
+
+// amazonGeocodeResponse is the subset of the Geocode response used here.
+type amazonGeocodeResponse struct {
+	ResultItems []struct {
+		Position []float64 `json:"Position"` // [longitude, latitude]
+	} `json:"ResultItems"`
+}
+
+// amazonSearchTextRequest is the SearchText request body.
+type amazonSearchTextRequest struct {
+	QueryText          string    `json:"QueryText"`
+	BiasPosition       []float64 `json:"BiasPosition"`
+	MaxResults         int       `json:"MaxResults"`
+	AdditionalFeatures []string  `json:"AdditionalFeatures"`
+}
+
+// amazonSearchTextResponse is the subset of the SearchText response used here.
+type amazonSearchTextResponse struct {
+	ResultItems []struct {
+		PlaceID    string `json:"PlaceId"`
+		Title      string `json:"Title"`
+		Categories []struct {
+			ID      string `json:"Id"`
+			Name    string `json:"Name"`
+			Primary bool   `json:"Primary"`
+		} `json:"Categories"`
+		Contacts struct {
+			Phones   []struct{ Value string } `json:"Phones"`
+			Websites []struct{ Value string } `json:"Websites"`
+		} `json:"Contacts"`
+	} `json:"ResultItems"`
+}
+
+// Name identifies the provider.
+func (p *AmazonPlacesProvider) Name() string {
+	return "amazon_location"
+}
+
+// FindPlace geocodes the query's address and returns the closest place matching its name.
+func (p *AmazonPlacesProvider) FindPlace(ctx context.Context, query Query) (*Place, error) {
+	var geocoded amazonGeocodeResponse
+	if err := p.call(ctx, "/v2/geocode", amazonGeocodeRequest{QueryText: query.addressText(), MaxResults: 1}, &geocoded); err != nil {
+		return nil, err
+	}
+	if len(geocoded.ResultItems) == 0 || len(geocoded.ResultItems[0].Position) != 2 {
+		return nil, ErrNoMatch
+	}
+
+	var found amazonSearchTextResponse
+	if err := p.call(ctx, "/v2/search-text", amazonSearchTextRequest{
+		QueryText:          query.Name,
+		BiasPosition:       geocoded.ResultItems[0].Position,
+		MaxResults:         1,
+		AdditionalFeatures: []string{"Contact"},
+	}, &found); err != nil {
+		return nil, err
+	}
+	if len(found.ResultItems) == 0 {
+		return nil, ErrNoMatch
+	}
+
+	best := found.ResultItems[0]
+	place := &Place{PlaceID: best.PlaceID, Name: best.Title}
+	for _, category := range best.Categories {
+		if category.Primary || place.Category == "" {
+			place.Category = category.ID
+		}
+	}
+	if len(best.Contacts.Phones) > 0 {
+		place.Phone = best.Contacts.Phones[0].Value
+	}
+	if len(best.Contacts.Websites) > 0 {
+		place.Website = best.Contacts.Websites[0].Value
+	}
+	return place, nil
+}
+
+// call sends a SigV4-signed POST to path and decodes the response into out.
+func (p *AmazonPlacesProvider) call(ctx context.Context, path string, in, out interface{}) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("failed to marshal places request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint+path, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build places request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	creds, err := p.credentials.Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve credentials: %w", err)
+	}
+	hash := sha256.Sum256(body)
+	if err := p.signer.SignHTTP(ctx, creds, req, hex.EncodeToString(hash[:]), "geo-places", p.region, time.Now()); err != nil {
+		return fmt.Errorf("failed to sign places request: %w", err)
+	}
+
+	respBody, err := doRequest(p.httpClient, req)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("failed to unmarshal places response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package places
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestAmazonProvider(t *testing.T, handler http.HandlerFunc) *AmazonPlacesProvider {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	p := NewAmazonPlacesProvider(aws.Config{
+		Region:      "us-west-2",
+		Credentials: credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""),
+	})
+	p.endpoint = server.URL
+	return p
+}
+
+func TestAmazonPlacesProvider(t *testing.T) {
+	t.Run("Searches near the geocoded address", func(t *testing.T) {
+		p := newTestAmazonProvider(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.True(t, strings.Contains(r.Header.Get("Authorization"), "/geo-places/aws4_request"))
+			switch r.URL.Path {
+			case "/v2/geocode":
+				var req amazonGeocodeRequest
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+				assert.Equal(t, "66 Mint St, San Francisco, CA 94103, US", req.QueryText)
+				_, _ = w.Write([]byte(`{"ResultItems":[{"Position":[-122.4059,37.7825]}]}`))
+			case "/v2/search-text":
+				var req amazonSearchTextRequest
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+				assert.Equal(t, "Blue Bottle Coffee", req.QueryText)
+				assert.Equal(t, []float64{-122.4059, 37.7825}, req.BiasPosition)
+				assert.Equal(t, []string{"Contact"}, req.AdditionalFeatures)
+				_, _ = w.Write([]byte(`{"ResultItems":[{"PlaceId":"AQAAA","Title":"Blue Bottle Coffee","Categories":[{"Id":"restaurant","Name":"Restaurant"},{"Id":"coffee_shop","Name":"Coffee Shop","Primary":true}],"Contacts":{"Phones":[{"Value":"+15106533394"}],"Websites":[{"Value":"https://bluebottlecoffee.com"}]}}]}`))
+			default:
+				t.Errorf("unexpected path %s", r.URL.Path)
+			}
+		})
+
+		place, err := p.FindPlace(context.Background(), testQuery)
+		require.NoError(t, err)
+		assert.Equal(t, &Place{
+			PlaceID:  "AQAAA",
+			Name:     "Blue Bottle Coffee",
+			Category: "coffee_shop",
+			Phone:    "+15106533394",
+			Website:  "https://bluebottlecoffee.com",
+		}, place)
+	})
+
+	t.Run("Address not found", func(t *testing.T) {
+		p := newTestAmazonProvider(t, func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"ResultItems":[]}`))
+		})
+
+		_, err := p.FindPlace(context.Background(), testQuery)
+		assert.ErrorIs(t, err, ErrNoMatch)
+	})
+}
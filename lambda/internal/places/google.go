@@ -0,0 +1,100 @@
+package places
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// googleFieldMask limits Text Search responses to the fields used for enrichment.
+const googleFieldMask = "places.id,places.displayName,places.primaryType,places.types,places.internationalPhoneNumber,places.websiteUri"
+
+// GooglePlacesProvider finds places with the Google Places API (New) Text Search.
+type GooglePlacesProvider struct {
+	httpClient HTTPClient
+	apiKey     string
+	endpoint   string
+}
+
+// NewGooglePlacesProvider creates a provider using a Places API key.
+func NewGooglePlacesProvider(apiKey string) *GooglePlacesProvider {
+	return &GooglePlacesProvider{
+		httpClient: http.DefaultClient,
+		apiKey:     apiKey,
+		endpoint:   "https://places.googleapis.com",
+	}
+}
+
+// googleSearchRequest is the Text Search request body.
+type googleSearchRequest struct {
+	TextQuery  string `json:"textQuery"`
+	PageSize   int    `json:"pageSize"`
+	RegionCode string `json:"regionCode,omitempty"`
+}
+
+// googleSearchResponse is the subset of the Text Search response used here.
+type googleSearchResponse struct {
+	Places []struct {
+		ID          string `json:"id"`
+		DisplayName struct {
+			Text string `json:"text"`
+		} `json:"displayName"`
+		PrimaryType              string   `json:"primaryType"`
+		Types                    []string `json:"types"`
+		InternationalPhoneNumber string   `json:"internationalPhoneNumber"`
+		WebsiteURI               string   `json:"websiteUri"`
+	} `json:"places"`
+}
+
+// Name identifies the provider.
+func (p *GooglePlacesProvider) Name() string {
+	return "google_places"
+}
+
+// FindPlace returns the top Text Search result for the query.
+func (p *GooglePlacesProvider) FindPlace(ctx context.Context, query Query) (*Place, error) {
+	body, err := json.Marshal(googleSearchRequest{
+		TextQuery:  query.text(),
+		PageSize:   1,
+		RegionCode: query.Address.Country,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal places request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint+"/v1/places:searchText", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build places request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Goog-Api-Key", p.apiKey)
+	req.Header.Set("X-Goog-FieldMask", googleFieldMask)
+
+	respBody, err := doRequest(p.httpClient, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed googleSearchResponse
+	if err := json.Unmarshal(respBody, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal places response: %w", err)
+	}
+	if len(parsed.Places) == 0 {
+		return nil, ErrNoMatch
+	}
+
+	best := parsed.Places[0]
+	category := best.PrimaryType
+	if category == "" && len(best.Types) > 0 {
+		category = best.Types[0]
+	}
+	return &Place{
+		PlaceID:  best.ID,
+		Name:     best.DisplayName.Text,
+		Category: category,
+		Phone:    best.InternationalPhoneNumber,
+		Website:  best.WebsiteURI,
+	}, nil
+}
@@ -0,0 +1,81 @@
+package places
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testQuery is the shop sent to providers in tests.
+var testQuery = Query{
+	Name: "Blue Bottle Coffee",
+	Address: models.Address{
+		StreetAddress: "66 Mint St",
+		City:          "San Francisco",
+		StateProvince: "CA",
+		PostalCode:    "94103",
+		Country:       "US",
+	},
+}
+
+func newTestGoogleProvider(t *testing.T, handler http.HandlerFunc) *GooglePlacesProvider {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	p := NewGooglePlacesProvider("test-key")
+	p.endpoint = server.URL
+	return p
+}
+
+func TestGooglePlacesProvider(t *testing.T) {
+	t.Run("Best match", func(t *testing.T) {
+		p := newTestGoogleProvider(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/v1/places:searchText", r.URL.Path)
+			assert.Equal(t, "test-key", r.Header.Get("X-Goog-Api-Key"))
+			assert.Equal(t, googleFieldMask, r.Header.Get("X-Goog-FieldMask"))
+
+			var req googleSearchRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			assert.Equal(t, "Blue Bottle Coffee, 66 Mint St, San Francisco, CA 94103, US", req.TextQuery)
+			assert.Equal(t, "US", req.RegionCode)
+
+			_, _ = w.Write([]byte(`{"places":[{"id":"ChIJ123","displayName":{"text":"Blue Bottle Coffee"},"primaryType":"coffee_shop","types":["coffee_shop","cafe"],"internationalPhoneNumber":"+1 510-653-3394","websiteUri":"https://bluebottlecoffee.com/"}]}`))
+		})
+
+		place, err := p.FindPlace(context.Background(), testQuery)
+		require.NoError(t, err)
+		assert.Equal(t, &Place{
+			PlaceID:  "ChIJ123",
+			Name:     "Blue Bottle Coffee",
+			Category: "coffee_shop",
+			Phone:    "+1 510-653-3394",
+			Website:  "https://bluebottlecoffee.com/",
+		}, place)
+	})
+
+	t.Run("No match", func(t *testing.T) {
+		p := newTestGoogleProvider(t, func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{}`))
+		})
+
+		_, err := p.FindPlace(context.Background(), testQuery)
+		assert.ErrorIs(t, err, ErrNoMatch)
+	})
+
+	t.Run("Service error", func(t *testing.T) {
+		p := newTestGoogleProvider(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"error":{"status":"PERMISSION_DENIED"}}`))
+		})
+
+		_, err := p.FindPlace(context.Background(), testQuery)
+		assert.EqualError(t, err, `places request failed with status 403: {"error":{"status":"PERMISSION_DENIED"}}`)
+	})
+}
@@ -0,0 +1,94 @@
+// Package places looks up business details for shop locations from external
+// points-of-interest providers.
+package places
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+)
+
+// ErrNoMatch is returned when the provider finds no place for a query.
+var ErrNoMatch = errors.New("no matching place found")
+
+// HTTPClient is the subset of http.Client used by the places providers.
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Query identifies a business by name and address.
+type Query struct {
+	Name    string
+	Address models.Address
+}
+
+// Place is the provider's best match for a query.
+type Place struct {
+	PlaceID  string
+	Name     string
+	Category string // Primary category, in the provider's vocabulary
+	Phone    string
+	Website  string
+}
+
+// Provider finds the place that best matches a business name and address.
+type Provider interface {
+	// Name identifies the provider in enrichment provenance.
+	Name() string
+	FindPlace(ctx context.Context, query Query) (*Place, error)
+}
+
+// text formats a query as a single search string.
+func (q Query) text() string {
+	return strings.Join(nonEmpty(
+		q.Name,
+		q.Address.StreetAddress,
+		q.Address.City,
+		strings.TrimSpace(q.Address.StateProvince+" "+q.Address.PostalCode),
+		q.Address.Country,
+	), ", ")
+}
+
+// addressText formats the query's address as a single search string.
+func (q Query) addressText() string {
+	return strings.Join(nonEmpty(
+		q.Address.StreetAddress,
+		q.Address.City,
+		strings.TrimSpace(q.Address.StateProvince+" "+q.Address.PostalCode),
+		q.Address.Country,
+	), ", ")
+}
+
+// nonEmpty returns the non-empty values in order.
+func nonEmpty(values ...string) []string {
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if v != "" {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// doRequest sends req and returns the body of a 200 response.
+func doRequest(client HTTPClient, req *http.Request) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query places: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read places response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("places request failed with status %d: %s", resp.StatusCode, body)
+	}
+	return body, nil
+}
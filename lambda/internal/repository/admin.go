@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// AdminStore defines cross-account operations for internal tooling. They are
+// never exposed to tenants.
+type AdminStore interface {
+	FindByID(ctx context.Context, locationID string) (*LocationEnvelope, error)
+	Transfer(ctx context.Context, fromAccountID, locationID, toAccountID string) error
+}
+
+// WithLocationIDIndex enables FindByID with the GSI keyed on SK, which must
+// project locationType.
+func WithLocationIDIndex(indexName string) Option {
+	return func(r *DynamoDBRepository) {
+		r.locationIDIndex = indexName
+	}
+}
+
+// FindByID returns a location by its ID alone, whichever account owns it.
+// The index is eventually consistent; the record itself is read from the table.
+func (r *DynamoDBRepository) FindByID(ctx context.Context, locationID string) (*LocationEnvelope, error) {
+	if r.locationIDIndex == "" {
+		return nil, fmt.Errorf("location ID index is not configured")
+	}
+	if locationID == "" {
+		return nil, fmt.Errorf("locationId is required")
+	}
+
+	// Templates and other account items share the SK attribute; only locations have a type
+	result, err := r.client.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		IndexName:              aws.String(r.locationIDIndex),
+		KeyConditionExpression: aws.String("SK = :locationId"),
+		FilterExpression:       aws.String("attribute_exists(locationType)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":locationId": &types.AttributeValueMemberS{Value: r.locationSK(locationID)},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query location ID index: %w", err)
+	}
+	if len(result.Items) == 0 {
+		return nil, errLocationNotFound
+	}
+
+	pk, ok := result.Items[0]["PK"].(*types.AttributeValueMemberS)
+	if !ok {
+		return nil, fmt.Errorf("location ID index returned an item without PK")
+	}
+	accountID, _, _ := LocationKeys(pk.Value, r.locationSK(locationID))
+	return r.Get(ctx, accountID, locationID)
+}
+
+// Transfer moves a location to another account, keeping its ID. The new record,
+// the removal of the old one, and the move of its external ID claim are written
+// in one transaction.
+func (r *DynamoDBRepository) Transfer(ctx context.Context, fromAccountID, locationID, toAccountID string) error {
+	if fromAccountID == "" || locationID == "" || toAccountID == "" {
+		return fmt.Errorf("validation failed: accountId, locationId, and toAccountId are required")
+	}
+	if fromAccountID == toAccountID {
+		return fmt.Errorf("validation failed: location already belongs to account %s", toAccountID)
+	}
+
+	stored, err := r.getRecord(ctx, fromAccountID, locationID)
+	if errors.Is(err, errLocationNotFound) {
+		return fmt.Errorf("location not found or access denied")
+	}
+	if err != nil {
+		return err
+	}
+	oldRef := stored.ExtendedAttributesRef
+	if err := r.hydrateRecord(ctx, stored); err != nil {
+		return err
+	}
+	location, err := stored.toLocation()
+	if err != nil {
+		return fmt.Errorf("failed to convert record to location: %w", err)
+	}
+
+	// Re-prepare under the new account so its shard, index keys, encryption
+	// context, and overflow key follow the owner
+	record, err := toLocationRecord(location, locationID)
+	if err != nil {
+		return fmt.Errorf("failed to convert location to record: %w", err)
+	}
+	record.PK = toAccountID
+	item, err := r.prepareRecord(ctx, record)
+	if err != nil {
+		return err
+	}
+
+	staleOwner := ""
+	for attempt := 0; ; attempt++ {
+		items, err := r.transferItems(stored, record, item, staleOwner)
+		if err != nil {
+			r.deleteOverflow(ctx, record.ExtendedAttributesRef)
+			return err
+		}
+
+		_, err = r.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: items})
+		if err == nil {
+			break
+		}
+		if record.ExternalID == "" || !claimFailed(err, len(items)-1) || attempt > 0 {
+			r.deleteOverflow(ctx, record.ExtendedAttributesRef)
+			var tce *types.TransactionCanceledException
+			if errors.As(err, &tce) {
+				return fmt.Errorf("failed to transfer location: location exists in the target account or was modified concurrently")
+			}
+			return fmt.Errorf("failed to transfer location: %w", err)
+		}
+
+		staleOwner, err = r.resolveClaimConflict(ctx, record)
+		if err != nil {
+			r.deleteOverflow(ctx, record.ExtendedAttributesRef)
+			return err
+		}
+	}
+
+	r.deleteOverflow(ctx, oldRef)
+	if err := r.recordDeletion(ctx, stored.PK, stored.SK); err != nil {
+		log.Printf("WARN: %v", err)
+	}
+	return nil
+}
+
+// transferItems builds the transaction moving stored to record's account. The
+// new external ID claim, when there is one, is always the last item.
+func (r *DynamoDBRepository) transferItems(stored, record *locationRecord, item map[string]types.AttributeValue, staleOwner string) ([]types.TransactWriteItem, error) {
+	values := map[string]types.AttributeValue{}
+	items := []types.TransactWriteItem{
+		{Put: &types.Put{
+			TableName:           aws.String(r.tableName),
+			Item:                item,
+			ConditionExpression: aws.String("attribute_not_exists(PK) AND attribute_not_exists(SK)"),
+		}},
+		{Delete: &types.Delete{
+			TableName:                 aws.String(r.tableName),
+			Key:                       r.locationKey(stored.PK, stored.SK),
+			ConditionExpression:       aws.String("attribute_exists(PK) AND " + notMergedFilter + " AND " + sameExternalID(stored.ExternalID, values)),
+			ExpressionAttributeValues: valuesOrNil(values),
+		}},
+	}
+	if stored.ExternalID == "" {
+		return items, nil
+	}
+
+	items = append(items, types.TransactWriteItem{Delete: &types.Delete{
+		TableName:           aws.String(r.tableName),
+		Key:                 externalIDKey(stored.PK, stored.ExternalID),
+		ConditionExpression: aws.String("locationId = :locationId"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":locationId": &types.AttributeValueMemberS{Value: stored.SK},
+		},
+	}})
+	claim, err := r.claimPut(record, staleOwner)
+	if err != nil {
+		return nil, err
+	}
+	return append(items, claim), nil
+}
+
+// valuesOrNil returns nil for an empty map, which DynamoDB rejects as
+// ExpressionAttributeValues.
+func valuesOrNil(values map[string]types.AttributeValue) map[string]types.AttributeValue {
+	if len(values) == 0 {
+		return nil
+	}
+	return values
+}
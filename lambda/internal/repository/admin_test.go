@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDynamoDBRepositoryFindByID(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Resolves the owning account from the index", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table", WithLocationIDIndex("LocationIdIndex"))
+
+		mockClient.On("Query", ctx, mock.MatchedBy(func(input *dynamodb.QueryInput) bool {
+			return aws.ToString(input.IndexName) == "LocationIdIndex" &&
+				input.ExpressionAttributeValues[":locationId"].(*types.AttributeValueMemberS).Value == "loc-001"
+		})).Return(&dynamodb.QueryOutput{Items: []map[string]types.AttributeValue{
+			{
+				"PK": &types.AttributeValueMemberS{Value: "acc-other"},
+				"SK": &types.AttributeValueMemberS{Value: "loc-001"},
+			},
+		}}, nil).Once()
+		mockClient.On("GetItem", ctx, matchGet("loc-001")).Return(&dynamodb.GetItemOutput{Item: coordinatesItem("acc-other", "loc-001")}, nil).Once()
+
+		envelope, err := repo.FindByID(ctx, "loc-001")
+		require.NoError(t, err)
+		assert.Equal(t, "loc-001", envelope.LocationID)
+		assert.Equal(t, "acc-other", envelope.Location.GetAccountID())
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Unknown location", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table", WithLocationIDIndex("LocationIdIndex"))
+
+		mockClient.On("Query", ctx, mock.Anything).Return(&dynamodb.QueryOutput{}, nil).Once()
+
+		_, err := repo.FindByID(ctx, "loc-missing")
+		assert.ErrorIs(t, err, errLocationNotFound)
+	})
+
+	t.Run("Index not configured", func(t *testing.T) {
+		repo := NewDynamoDBRepository(new(mockDynamoDBClient), "test-table")
+
+		_, err := repo.FindByID(ctx, "loc-001")
+		assert.EqualError(t, err, "location ID index is not configured")
+	})
+}
+
+func TestDynamoDBRepositoryTransfer(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Moves the record to the new account", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		var transaction *dynamodb.TransactWriteItemsInput
+		mockClient.On("GetItem", ctx, matchGet("loc-001")).Return(&dynamodb.GetItemOutput{Item: coordinatesItem("acc-12345", "loc-001")}, nil).Once()
+		mockClient.On("TransactWriteItems", ctx, mock.Anything).Run(func(args mock.Arguments) {
+			transaction = args.Get(1).(*dynamodb.TransactWriteItemsInput)
+		}).Return(&dynamodb.TransactWriteItemsOutput{}, nil).Once()
+
+		require.NoError(t, repo.Transfer(ctx, "acc-12345", "loc-001", "acc-67890"))
+
+		require.Len(t, transaction.TransactItems, 2)
+		put := transaction.TransactItems[0].Put
+		assert.Equal(t, "acc-67890", put.Item["PK"].(*types.AttributeValueMemberS).Value)
+		assert.Equal(t, "loc-001", put.Item["SK"].(*types.AttributeValueMemberS).Value)
+		del := transaction.TransactItems[1].Delete
+		assert.Equal(t, "acc-12345", del.Key["PK"].(*types.AttributeValueMemberS).Value)
+		assert.Nil(t, del.ExpressionAttributeValues)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Moves the external ID claim", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		item := coordinatesItem("acc-12345", "loc-001")
+		item["externalId"] = &types.AttributeValueMemberS{Value: "ERP-1"}
+		var transaction *dynamodb.TransactWriteItemsInput
+		mockClient.On("GetItem", ctx, matchGet("loc-001")).Return(&dynamodb.GetItemOutput{Item: item}, nil).Once()
+		mockClient.On("TransactWriteItems", ctx, mock.Anything).Run(func(args mock.Arguments) {
+			transaction = args.Get(1).(*dynamodb.TransactWriteItemsInput)
+		}).Return(&dynamodb.TransactWriteItemsOutput{}, nil).Once()
+
+		require.NoError(t, repo.Transfer(ctx, "acc-12345", "loc-001", "acc-67890"))
+
+		require.Len(t, transaction.TransactItems, 4)
+		assert.Equal(t, "acc-67890#ERP-1", transaction.TransactItems[0].Put.Item["accountExternalId"].(*types.AttributeValueMemberS).Value)
+		assert.Equal(t, externalIDKey("acc-12345", "ERP-1"), transaction.TransactItems[2].Delete.Key)
+		claim := transaction.TransactItems[3].Put
+		assert.Equal(t, "EXTERNALID#acc-67890", claim.Item["PK"].(*types.AttributeValueMemberS).Value)
+	})
+
+	t.Run("External ID already used in the target account", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		item := coordinatesItem("acc-12345", "loc-001")
+		item["externalId"] = &types.AttributeValueMemberS{Value: "ERP-1"}
+		mockClient.On("GetItem", ctx, matchGet("loc-001")).Return(&dynamodb.GetItemOutput{Item: item}, nil).Once()
+		mockClient.On("TransactWriteItems", ctx, mock.Anything).Return(nil, claimCanceled(3)).Once()
+		mockClient.On("GetItem", ctx, matchGet("ERP-1")).Return(&dynamodb.GetItemOutput{Item: map[string]types.AttributeValue{
+			"PK":         &types.AttributeValueMemberS{Value: "EXTERNALID#acc-67890"},
+			"SK":         &types.AttributeValueMemberS{Value: "ERP-1"},
+			"locationId": &types.AttributeValueMemberS{Value: "loc-999"},
+		}}, nil).Once()
+		mockClient.On("GetItem", ctx, matchGet("loc-999")).Return(&dynamodb.GetItemOutput{Item: coordinatesItem("acc-67890", "loc-999")}, nil).Once()
+
+		err := repo.Transfer(ctx, "acc-12345", "loc-001", "acc-67890")
+		assert.EqualError(t, err, `externalId "ERP-1" is already used by location loc-999`)
+	})
+
+	t.Run("Same account", func(t *testing.T) {
+		repo := NewDynamoDBRepository(new(mockDynamoDBClient), "test-table")
+
+		err := repo.Transfer(ctx, "acc-12345", "loc-001", "acc-12345")
+		assert.EqualError(t, err, "validation failed: location already belongs to account acc-12345")
+	})
+
+	t.Run("Missing location", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		mockClient.On("GetItem", ctx, mock.Anything).Return(&dynamodb.GetItemOutput{}, nil).Once()
+
+		err := repo.Transfer(ctx, "acc-12345", "loc-001", "acc-67890")
+		assert.EqualError(t, err, "location not found or access denied")
+		mockClient.AssertNotCalled(t, "TransactWriteItems", mock.Anything, mock.Anything)
+	})
+}
+
+func TestRoutingRepositoryTransferAcrossRegions(t *testing.T) {
+	repo := NewRoutingRepository(
+		NewDynamoDBRepository(new(mockDynamoDBClient), "locations"),
+		map[string]Repository{"eu-west-1": NewDynamoDBRepository(new(mockDynamoDBClient), "locations-eu")},
+		map[string]string{"acc-eu": "eu-west-1"},
+	)
+
+	err := repo.Transfer(context.Background(), "acc-eu", "loc-001", "acc-us")
+	assert.EqualError(t, err, "cannot transfer locations between data residency regions")
+}
@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	// defaultScanSegments is how many parallel segments ScanLocations splits
+	// the table into when WithScanSegments is not set.
+	defaultScanSegments = 4
+	// maxScanSegments bounds the segments a cursor may name, so a forged
+	// cursor can't fan a page out into thousands of scans.
+	maxScanSegments = 64
+	// scanFilter keeps location items, dropping merged duplicates and the
+	// templates, claims, and counters sharing the table.
+	scanFilter = "attribute_exists(locationType) AND " + notMergedFilter
+)
+
+// LocationScanner pages through every account's locations for internal
+// tooling. It is never exposed to tenants.
+type LocationScanner interface {
+	// ScanLocations returns up to limit locations after cursor, in no
+	// particular order, and the cursor of the next page, which is nil once
+	// the whole table has been read.
+	ScanLocations(ctx context.Context, limit int32, cursor *string) (*ListResult, error)
+}
+
+// scanCursor holds each segment's position in a paged scan. The number of
+// segments is fixed by the first page, so a scan resumes the same way
+// whatever WithScanSegments says by then.
+type scanCursor struct {
+	Segments []scanSegment `json:"segments"`
+}
+
+// scanSegment is one segment's position: the key it stopped after, or Done
+// once it has been read to the end. A segment without either hasn't started.
+type scanSegment struct {
+	PK   string `json:"pk,omitempty"`
+	SK   string `json:"sk,omitempty"`
+	Done bool   `json:"done,omitempty"`
+}
+
+// WithScanSegments sets how many segments ScanLocations reads in parallel.
+func WithScanSegments(segments int) Option {
+	return func(r *DynamoDBRepository) {
+		r.scanSegments = segments
+	}
+}
+
+// ScanLocations reads the next page of a parallel segmented scan of the
+// table. The limit is shared among the unfinished segments, each reading
+// at most its share with eventually consistent reads, so a page costs about
+// as much as a listLocations page of the same size. The limit applies
+// before merged duplicates and other items are filtered out, so pages can
+// come back short, or empty, before the scan is done.
+func (r *DynamoDBRepository) ScanLocations(ctx context.Context, limit int32, cursor *string) (*ListResult, error) {
+	if limit <= 0 {
+		return nil, fmt.Errorf("validation failed: limit must be greater than 0")
+	}
+	position, err := r.decodeScanCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []int
+	for i, segment := range position.Segments {
+		if !segment.Done {
+			pending = append(pending, i)
+		}
+	}
+	found := make([][]map[string]types.AttributeValue, len(position.Segments))
+	errs := make([]error, len(position.Segments))
+	var wg sync.WaitGroup
+	for n, i := range pending {
+		// Spread the limit over the pending segments; those left without a
+		// share this page keep their position for the next
+		share := limit / int32(len(pending))
+		if int32(n) < limit%int32(len(pending)) {
+			share++
+		}
+		if share == 0 {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, share int32) {
+			defer wg.Done()
+			input := &dynamodb.ScanInput{
+				TableName:        aws.String(r.tableName),
+				FilterExpression: aws.String(scanFilter),
+				Segment:          aws.Int32(int32(i)),
+				TotalSegments:    aws.Int32(int32(len(position.Segments))),
+				Limit:            aws.Int32(share),
+			}
+			if segment := position.Segments[i]; segment.PK != "" {
+				input.ExclusiveStartKey = map[string]types.AttributeValue{
+					"PK": &types.AttributeValueMemberS{Value: segment.PK},
+					"SK": &types.AttributeValueMemberS{Value: segment.SK},
+				}
+			}
+			result, err := r.client.Scan(ctx, input)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			found[i] = result.Items
+			next := scanSegment{Done: true}
+			if lek := r.lastEvaluatedKeyToCursor(result.LastEvaluatedKey); lek != nil {
+				next = scanSegment{PK: lek.PK, SK: lek.SK}
+			}
+			position.Segments[i] = next
+		}(i, share)
+	}
+	wg.Wait()
+	// The caller's cursor is left valid, so a failed page can be retried
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("failed to scan locations: %w", err)
+	}
+
+	var items []map[string]types.AttributeValue
+	for _, segmentItems := range found {
+		items = append(items, segmentItems...)
+	}
+	envelopes, err := r.itemsToEnvelopes(ctx, items)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ListResult{Items: envelopes}
+	for _, segment := range position.Segments {
+		if !segment.Done {
+			result.NextCursor, err = encodeScanCursor(position)
+			if err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+	return result, nil
+}
+
+// decodeScanCursor returns the scan position of cursor, or the start of a
+// new scan when there is none.
+func (r *DynamoDBRepository) decodeScanCursor(cursor *string) (*scanCursor, error) {
+	if cursor == nil || *cursor == "" {
+		segments := r.scanSegments
+		if segments <= 0 {
+			segments = defaultScanSegments
+		}
+		return &scanCursor{Segments: make([]scanSegment, segments)}, nil
+	}
+
+	data, err := base64.StdEncoding.DecodeString(*cursor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode cursor: %w", err)
+	}
+	var position scanCursor
+	if err := json.Unmarshal(data, &position); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal cursor: %w", err)
+	}
+	if len(position.Segments) == 0 || len(position.Segments) > maxScanSegments {
+		return nil, fmt.Errorf("validation failed: cursor must hold between 1 and %d segments", maxScanSegments)
+	}
+	return &position, nil
+}
+
+// encodeScanCursor encodes a scan position as an opaque cursor.
+func encodeScanCursor(position *scanCursor) (*string, error) {
+	data, err := json.Marshal(position)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal cursor: %w", err)
+	}
+	encoded := base64.StdEncoding.EncodeToString(data)
+	return &encoded, nil
+}
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// matchSegment matches a scan of one of two segments, reading up to limit
+// items after startSK.
+func matchSegment(segment, limit int32, startSK string) interface{} {
+	return mock.MatchedBy(func(input *dynamodb.ScanInput) bool {
+		if aws.ToInt32(input.Segment) != segment || aws.ToInt32(input.TotalSegments) != 2 ||
+			aws.ToInt32(input.Limit) != limit || aws.ToString(input.FilterExpression) != scanFilter {
+			return false
+		}
+		if startSK == "" {
+			return input.ExclusiveStartKey == nil
+		}
+		sk, ok := input.ExclusiveStartKey["SK"].(*types.AttributeValueMemberS)
+		return ok && sk.Value == startSK
+	})
+}
+
+func TestDynamoDBRepositoryScanLocations(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Pages through every segment", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table", WithScanSegments(2))
+
+		mockClient.On("Scan", ctx, matchSegment(0, 2, "")).Return(&dynamodb.ScanOutput{
+			Items: []map[string]types.AttributeValue{coordinatesItem("acc-1", "loc-001")},
+			LastEvaluatedKey: map[string]types.AttributeValue{
+				"PK": &types.AttributeValueMemberS{Value: "acc-1"},
+				"SK": &types.AttributeValueMemberS{Value: "loc-001"},
+			},
+		}, nil).Once()
+		mockClient.On("Scan", ctx, matchSegment(1, 1, "")).Return(&dynamodb.ScanOutput{
+			Items: []map[string]types.AttributeValue{coordinatesItem("acc-2", "loc-002")},
+		}, nil).Once()
+
+		first, err := repo.ScanLocations(ctx, 3, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"loc-001", "loc-002"}, first.LocationIDs())
+		assert.Equal(t, "acc-2", first.Items[1].Location.GetAccountID())
+		require.NotNil(t, first.NextCursor)
+
+		// Only the unfinished segment is read, with the whole limit
+		mockClient.On("Scan", ctx, matchSegment(0, 3, "loc-001")).Return(&dynamodb.ScanOutput{
+			Items: []map[string]types.AttributeValue{coordinatesItem("acc-3", "loc-003")},
+		}, nil).Once()
+
+		second, err := repo.ScanLocations(ctx, 3, first.NextCursor)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"loc-003"}, second.LocationIDs())
+		assert.Nil(t, second.NextCursor)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Resumes with the cursor's segments", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table", WithScanSegments(8))
+		cursor, err := encodeScanCursor(&scanCursor{Segments: []scanSegment{{PK: "acc-1", SK: "loc-001"}, {Done: true}}})
+		require.NoError(t, err)
+
+		mockClient.On("Scan", ctx, matchSegment(0, 5, "loc-001")).Return(&dynamodb.ScanOutput{}, nil).Once()
+
+		result, err := repo.ScanLocations(ctx, 5, cursor)
+		require.NoError(t, err)
+		assert.Empty(t, result.Items)
+		assert.Nil(t, result.NextCursor)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Fails the page when a segment fails", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table", WithScanSegments(2))
+
+		mockClient.On("Scan", ctx, matchSegment(0, 1, "")).Return(&dynamodb.ScanOutput{}, nil).Once()
+		mockClient.On("Scan", ctx, matchSegment(1, 1, "")).Return(nil, errors.New("throttled")).Once()
+
+		_, err := repo.ScanLocations(ctx, 2, nil)
+		assert.EqualError(t, err, "failed to scan locations: throttled")
+	})
+
+	t.Run("Rejects a cursor with too many segments", func(t *testing.T) {
+		repo := NewDynamoDBRepository(new(mockDynamoDBClient), "test-table")
+		cursor, err := encodeScanCursor(&scanCursor{Segments: make([]scanSegment, maxScanSegments+1)})
+		require.NoError(t, err)
+
+		_, err = repo.ScanLocations(ctx, 10, cursor)
+		assert.ErrorContains(t, err, "cursor must hold between 1 and 64 segments")
+	})
+
+	t.Run("Rejects a malformed cursor", func(t *testing.T) {
+		repo := NewDynamoDBRepository(new(mockDynamoDBClient), "test-table")
+		cursor := base64.StdEncoding.EncodeToString([]byte("not json"))
+
+		_, err := repo.ScanLocations(ctx, 10, &cursor)
+		assert.ErrorContains(t, err, "failed to unmarshal cursor")
+	})
+}
+
+func TestRoutingRepositoryScanLocations(t *testing.T) {
+	ctx := context.Background()
+	homeClient := new(mockDynamoDBClient)
+	euClient := new(mockDynamoDBClient)
+	repo := NewRoutingRepository(
+		NewDynamoDBRepository(homeClient, "locations", WithScanSegments(1)),
+		map[string]Repository{"eu-west-1": NewDynamoDBRepository(euClient, "locations-eu", WithScanSegments(1))},
+		map[string]string{"acc-eu": "eu-west-1"},
+	)
+
+	homeClient.On("Scan", ctx, mock.Anything).Return(&dynamodb.ScanOutput{
+		Items: []map[string]types.AttributeValue{coordinatesItem("acc-us", "loc-001")},
+	}, nil).Once()
+	first, err := repo.ScanLocations(ctx, 10, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"loc-001"}, first.LocationIDs())
+	require.NotNil(t, first.NextCursor, "the regional table is still to be read")
+
+	euClient.On("Scan", ctx, mock.Anything).Return(&dynamodb.ScanOutput{
+		Items: []map[string]types.AttributeValue{coordinatesItem("acc-eu", "loc-002")},
+	}, nil).Once()
+	second, err := repo.ScanLocations(ctx, 10, first.NextCursor)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"loc-002"}, second.LocationIDs())
+	assert.Nil(t, second.NextCursor)
+	homeClient.AssertExpectations(t)
+	euClient.AssertExpectations(t)
+}
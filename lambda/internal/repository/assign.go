@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/steverhoton/location-lambda/internal/geo"
+	"github.com/steverhoton/location-lambda/internal/models"
+)
+
+const (
+	// MaxAssignmentPoints caps the points AssignNearestShops takes per batch.
+	MaxAssignmentPoints = 1000
+	// assignableShopFilter selects an account's live, published, active, pinned shops.
+	assignableShopFilter = notMergedFilter + " AND " + listedFilter + " AND locationType = :shop AND attribute_exists(shop.coordinates)"
+)
+
+// AssignmentPoint is an external point, such as an order's delivery
+// address, to assign to a shop.
+type AssignmentPoint struct {
+	ID  string  `json:"id"`
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// Assignment is the shop nearest a point. LocationID and DistanceMeters are
+// nil when no shop qualifies.
+type Assignment struct {
+	ID             string   `json:"id"`
+	LocationID     *string  `json:"locationId"`
+	DistanceMeters *float64 `json:"distanceMeters"`
+}
+
+// NearestShopAssigner assigns batches of points to their nearest shops.
+type NearestShopAssigner interface {
+	AssignNearestShops(ctx context.Context, accountID string, points []AssignmentPoint, category string, maxDistanceMeters *float64) ([]Assignment, error)
+}
+
+// AssignNearestShops returns the nearest shop to each point, in the order of
+// points, optionally only shops in category and within maxDistanceMeters.
+// The account's pinned shops are read once for the whole batch, without a
+// geo index, so a batch costs about what one nearestLocationsByCategory call
+// does however many points it holds.
+func (r *DynamoDBRepository) AssignNearestShops(ctx context.Context, accountID string, points []AssignmentPoint, category string, maxDistanceMeters *float64) ([]Assignment, error) {
+	if accountID == "" {
+		return nil, fmt.Errorf("validation failed: accountId is required")
+	}
+	if len(points) == 0 || len(points) > MaxAssignmentPoints {
+		return nil, fmt.Errorf("validation failed: points must hold between 1 and %d points", MaxAssignmentPoints)
+	}
+	seen := make(map[string]bool, len(points))
+	for i, point := range points {
+		if point.ID == "" {
+			return nil, fmt.Errorf("validation failed: points[%d]: id is required", i)
+		}
+		if seen[point.ID] {
+			return nil, fmt.Errorf("validation failed: points[%d]: duplicate id %q", i, point.ID)
+		}
+		seen[point.ID] = true
+		if err := (models.Coordinates{Latitude: point.Lat, Longitude: point.Lon}).Validate(); err != nil {
+			return nil, fmt.Errorf("validation failed: points[%d]: %w", i, err)
+		}
+	}
+	if maxDistanceMeters != nil && *maxDistanceMeters <= 0 {
+		return nil, fmt.Errorf("validation failed: maxDistanceMeters must be greater than 0")
+	}
+
+	filter := assignableShopFilter
+	values := map[string]types.AttributeValue{
+		":shop": &types.AttributeValueMemberS{Value: string(models.LocationTypeShop)},
+		":now":  r.nowValue(),
+	}
+	if category != "" {
+		filter += " AND contains(shop.categories, :category)"
+		values[":category"] = &types.AttributeValueMemberS{Value: category}
+	}
+	input := &dynamodb.QueryInput{
+		TableName:                 aws.String(r.tableName),
+		KeyConditionExpression:    aws.String(r.locationPartition(":pk", accountID, values)),
+		FilterExpression:          aws.String(filter),
+		ProjectionExpression:      aws.String("SK, shop.coordinates"),
+		ExpressionAttributeValues: values,
+	}
+
+	type pinnedShop struct {
+		locationID  string
+		coordinates models.Coordinates
+	}
+	var shops []pinnedShop
+	for {
+		result, err := r.client.Query(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query shops: %w", err)
+		}
+		for _, item := range result.Items {
+			var record locationRecord
+			if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal location: %w", err)
+			}
+			if record.Shop == nil || record.Shop.Coordinates == nil {
+				continue
+			}
+			shops = append(shops, pinnedShop{locationID: record.SK, coordinates: *record.Shop.Coordinates})
+		}
+		if result.LastEvaluatedKey == nil {
+			break
+		}
+		input.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+
+	assignments := make([]Assignment, 0, len(points))
+	for _, point := range points {
+		assignment := Assignment{ID: point.ID}
+		from := models.Coordinates{Latitude: point.Lat, Longitude: point.Lon}
+		nearest := math.Inf(1)
+		for _, shop := range shops {
+			distance := geo.Distance(from, shop.coordinates)
+			if distance < nearest && (maxDistanceMeters == nil || distance <= *maxDistanceMeters) {
+				locationID := shop.locationID
+				nearest = distance
+				assignment.LocationID = &locationID
+				assignment.DistanceMeters = &distance
+			}
+		}
+		assignments = append(assignments, assignment)
+	}
+	return assignments, nil
+}
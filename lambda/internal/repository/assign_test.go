@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDynamoDBRepositoryAssignNearestShops(t *testing.T) {
+	ctx := context.Background()
+	shopItem := func(t *testing.T, locationID string, coordinates models.Coordinates) map[string]types.AttributeValue {
+		item, err := attributevalue.MarshalMap(locationRecord{SK: locationID, Shop: &shopAttribute{Coordinates: &coordinates}})
+		require.NoError(t, err)
+		return item
+	}
+	// Shops in Springfield, IL and Peoria, IL
+	shops := []map[string]types.AttributeValue{
+		shopItem(t, "shop-springfield", models.Coordinates{Latitude: 39.7817, Longitude: -89.6501}),
+		shopItem(t, "shop-peoria", models.Coordinates{Latitude: 40.6936, Longitude: -89.5890}),
+	}
+	points := []AssignmentPoint{
+		{ID: "order-1", Lat: 39.80, Lon: -89.64},
+		{ID: "order-2", Lat: 40.70, Lon: -89.60},
+		{ID: "order-3", Lat: 41.88, Lon: -87.63},
+	}
+
+	t.Run("Assigns each point to its nearest shop in one read", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		mockClient.On("Query", ctx, mock.MatchedBy(func(input *dynamodb.QueryInput) bool {
+			return *input.FilterExpression == assignableShopFilter+" AND contains(shop.categories, :category)" &&
+				input.ExpressionAttributeValues[":category"].(*types.AttributeValueMemberS).Value == "grocery"
+		})).Return(&dynamodb.QueryOutput{Items: shops}, nil).Once()
+
+		assignments, err := repo.AssignNearestShops(ctx, "acc-12345", points, "grocery", nil)
+		require.NoError(t, err)
+		require.Len(t, assignments, 3)
+		assert.Equal(t, "order-1", assignments[0].ID)
+		assert.Equal(t, "shop-springfield", *assignments[0].LocationID)
+		assert.InDelta(t, 2200, *assignments[0].DistanceMeters, 100)
+		assert.Equal(t, "shop-peoria", *assignments[1].LocationID)
+		assert.Equal(t, "shop-peoria", *assignments[2].LocationID)
+		mockClient.AssertNumberOfCalls(t, "Query", 1)
+	})
+
+	t.Run("Leaves points beyond maxDistanceMeters unassigned", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		mockClient.On("Query", ctx, mock.MatchedBy(func(input *dynamodb.QueryInput) bool {
+			return *input.FilterExpression == assignableShopFilter
+		})).Return(&dynamodb.QueryOutput{Items: shops}, nil).Once()
+
+		maxDistance := 50000.0
+		assignments, err := repo.AssignNearestShops(ctx, "acc-12345", points, "", &maxDistance)
+		require.NoError(t, err)
+		assert.Equal(t, "shop-peoria", *assignments[1].LocationID)
+		assert.Equal(t, Assignment{ID: "order-3"}, assignments[2])
+	})
+
+	tests := []struct {
+		name   string
+		points []AssignmentPoint
+		errMsg string
+	}{
+		{name: "No points", errMsg: "validation failed: points must hold between 1 and 1000 points"},
+		{name: "Missing id", points: []AssignmentPoint{{Lat: 1, Lon: 1}}, errMsg: "validation failed: points[0]: id is required"},
+		{name: "Duplicate id", points: []AssignmentPoint{{ID: "a"}, {ID: "a"}}, errMsg: `validation failed: points[1]: duplicate id "a"`},
+		{name: "Bad latitude", points: []AssignmentPoint{{ID: "a", Lat: 91}}, errMsg: "validation failed: points[0]: latitude must be between -90 and 90, got 91.000000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := new(mockDynamoDBClient)
+			repo := NewDynamoDBRepository(mockClient, "test-table")
+
+			_, err := repo.AssignNearestShops(ctx, "acc-12345", tt.points, "", nil)
+			assert.EqualError(t, err, tt.errMsg)
+			mockClient.AssertNotCalled(t, "Query", mock.Anything, mock.Anything)
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// bootstrapWait bounds how long BootstrapTable waits for a new table to become active.
+const bootstrapWait = 5 * time.Minute
+
+// productionNames are the environment names, and table name segments, that
+// BootstrapTable refuses to provision.
+var productionNames = map[string]bool{"prod": true, "production": true, "prd": true}
+
+// TableAdminClient defines the DynamoDB control plane operations used to
+// provision a table.
+type TableAdminClient interface {
+	CreateTable(ctx context.Context, params *dynamodb.CreateTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.CreateTableOutput, error)
+	DescribeTable(ctx context.Context, params *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error)
+	DescribeTimeToLive(ctx context.Context, params *dynamodb.DescribeTimeToLiveInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTimeToLiveOutput, error)
+	UpdateTimeToLive(ctx context.Context, params *dynamodb.UpdateTimeToLiveInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateTimeToLiveOutput, error)
+}
+
+// TableSpec describes a locations table as the Terraform stack creates it.
+// Index names left empty are not created, as with their environment
+// variables left unset.
+type TableSpec struct {
+	TableName          string
+	AccountIndex       string
+	ExternalIDIndex    string
+	LocationIDIndex    string
+	WebsiteIndex       string
+	ContactIndex       string
+	ParentAccountIndex string
+	SyncIndex          string
+	// ShardIndex is created only when sharding is on, with ShardCount above one
+	ShardIndex string
+	ShardCount int
+	// Stream enables the table's stream with new and old images, for the
+	// change export and IoT Core republish
+	Stream bool
+}
+
+// BootstrapResult reports what BootstrapTable did.
+type BootstrapResult struct {
+	Created    bool // False when the table already existed and was left as it is
+	TTLEnabled bool // True when expiresAt TTL was turned on by this run
+}
+
+// CheckNonProduction fails for a production environment or a table whose
+// name has a production segment, such as location-prod-locations, so dev
+// tooling can't provision or reshape a production table.
+func CheckNonProduction(environment, tableName string) error {
+	if productionNames[strings.ToLower(environment)] {
+		return fmt.Errorf("refusing to bootstrap in the %s environment", environment)
+	}
+	for _, segment := range strings.FieldsFunc(strings.ToLower(tableName), func(r rune) bool {
+		return r == '-' || r == '_' || r == '.'
+	}) {
+		if productionNames[segment] {
+			return fmt.Errorf("refusing to bootstrap table %s, whose name marks it as production", tableName)
+		}
+	}
+	return nil
+}
+
+// BootstrapTable creates the table described by spec, waits for it to become
+// active, and turns on expiresAt TTL, for local and ephemeral environments
+// that run without the Terraform stack. An existing table is left as it is
+// apart from its TTL, so running it again is harmless; it never adds
+// indexes to an existing table.
+func BootstrapTable(ctx context.Context, client TableAdminClient, spec TableSpec) (*BootstrapResult, error) {
+	if spec.TableName == "" {
+		return nil, fmt.Errorf("table name is required")
+	}
+	if err := CheckNonProduction("", spec.TableName); err != nil {
+		return nil, err
+	}
+
+	result := &BootstrapResult{}
+	_, err := client.CreateTable(ctx, spec.createTableInput())
+	var inUse *types.ResourceInUseException
+	switch {
+	case err == nil:
+		result.Created = true
+	case errors.As(err, &inUse):
+	default:
+		return nil, fmt.Errorf("failed to create table %s: %w", spec.TableName, err)
+	}
+
+	waiter := dynamodb.NewTableExistsWaiter(client)
+	if err := waiter.Wait(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(spec.TableName)}, bootstrapWait); err != nil {
+		return nil, fmt.Errorf("failed waiting for table %s: %w", spec.TableName, err)
+	}
+
+	ttl, err := client.DescribeTimeToLive(ctx, &dynamodb.DescribeTimeToLiveInput{TableName: aws.String(spec.TableName)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe TTL of table %s: %w", spec.TableName, err)
+	}
+	if ttl.TimeToLiveDescription != nil {
+		switch ttl.TimeToLiveDescription.TimeToLiveStatus {
+		case types.TimeToLiveStatusEnabled, types.TimeToLiveStatusEnabling:
+			return result, nil
+		}
+	}
+	_, err = client.UpdateTimeToLive(ctx, &dynamodb.UpdateTimeToLiveInput{
+		TableName: aws.String(spec.TableName),
+		TimeToLiveSpecification: &types.TimeToLiveSpecification{
+			AttributeName: aws.String("expiresAt"),
+			Enabled:       aws.Bool(true),
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to enable TTL on table %s: %w", spec.TableName, err)
+	}
+	result.TTLEnabled = true
+	return result, nil
+}
+
+// createTableInput builds the table's definition, mirroring terraform/dynamodb.tf.
+func (s TableSpec) createTableInput() *dynamodb.CreateTableInput {
+	attributes := []string{"PK", "SK"}
+	defined := map[string]bool{"PK": true, "SK": true}
+	var indexes []types.GlobalSecondaryIndex
+	addIndex := func(name string, projection *types.Projection, keys ...string) {
+		if name == "" {
+			return
+		}
+		schema := []types.KeySchemaElement{{AttributeName: aws.String(keys[0]), KeyType: types.KeyTypeHash}}
+		if len(keys) > 1 {
+			schema = append(schema, types.KeySchemaElement{AttributeName: aws.String(keys[1]), KeyType: types.KeyTypeRange})
+		}
+		for _, key := range keys {
+			if !defined[key] {
+				defined[key] = true
+				attributes = append(attributes, key)
+			}
+		}
+		indexes = append(indexes, types.GlobalSecondaryIndex{IndexName: aws.String(name), KeySchema: schema, Projection: projection})
+	}
+	projectAll := &types.Projection{ProjectionType: types.ProjectionTypeAll}
+
+	addIndex(s.AccountIndex, projectAll, "accountId")
+	addIndex(s.ExternalIDIndex, projectAll, "accountExternalId")
+	addIndex(s.WebsiteIndex, projectAll, "accountWebsite")
+	addIndex(s.ContactIndex, projectAll, "accountContact")
+	addIndex(s.ParentAccountIndex, &types.Projection{ProjectionType: types.ProjectionTypeKeysOnly}, "parentAccountId")
+	addIndex(s.SyncIndex, projectAll, "syncAccount", "updatedAt")
+	addIndex(s.LocationIDIndex, &types.Projection{
+		ProjectionType:   types.ProjectionTypeInclude,
+		NonKeyAttributes: []string{"locationType"},
+	}, "SK")
+	if s.ShardCount > 1 {
+		addIndex(s.ShardIndex, projectAll, "accountShard", "SK")
+	}
+
+	definitions := make([]types.AttributeDefinition, 0, len(attributes))
+	for _, name := range attributes {
+		definitions = append(definitions, types.AttributeDefinition{AttributeName: aws.String(name), AttributeType: types.ScalarAttributeTypeS})
+	}
+	input := &dynamodb.CreateTableInput{
+		TableName:            aws.String(s.TableName),
+		BillingMode:          types.BillingModePayPerRequest,
+		AttributeDefinitions: definitions,
+		KeySchema: []types.KeySchemaElement{
+			{AttributeName: aws.String("PK"), KeyType: types.KeyTypeHash},
+			{AttributeName: aws.String("SK"), KeyType: types.KeyTypeRange},
+		},
+		GlobalSecondaryIndexes: indexes,
+	}
+	if s.Stream {
+		input.StreamSpecification = &types.StreamSpecification{
+			StreamEnabled:  aws.Bool(true),
+			StreamViewType: types.StreamViewTypeNewAndOldImages,
+		}
+	}
+	return input
+}
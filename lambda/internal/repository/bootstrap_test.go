@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockTableAdminClient is a mock implementation of the TableAdminClient interface.
+type mockTableAdminClient struct {
+	mock.Mock
+}
+
+func (m *mockTableAdminClient) CreateTable(ctx context.Context, params *dynamodb.CreateTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.CreateTableOutput, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dynamodb.CreateTableOutput), args.Error(1)
+}
+
+func (m *mockTableAdminClient) DescribeTable(ctx context.Context, params *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dynamodb.DescribeTableOutput), args.Error(1)
+}
+
+func (m *mockTableAdminClient) DescribeTimeToLive(ctx context.Context, params *dynamodb.DescribeTimeToLiveInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTimeToLiveOutput, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dynamodb.DescribeTimeToLiveOutput), args.Error(1)
+}
+
+func (m *mockTableAdminClient) UpdateTimeToLive(ctx context.Context, params *dynamodb.UpdateTimeToLiveInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateTimeToLiveOutput, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dynamodb.UpdateTimeToLiveOutput), args.Error(1)
+}
+
+// activeTable answers the table-exists waiter.
+func activeTable(client *mockTableAdminClient) {
+	client.On("DescribeTable", mock.Anything, mock.Anything).Return(&dynamodb.DescribeTableOutput{
+		Table: &types.TableDescription{TableStatus: types.TableStatusActive},
+	}, nil)
+}
+
+func TestBootstrapTable(t *testing.T) {
+	ctx := context.Background()
+	spec := TableSpec{
+		TableName:       "location-dev-locations",
+		AccountIndex:    "AccountIndex",
+		ExternalIDIndex: "ExternalIdIndex",
+		LocationIDIndex: "LocationIdIndex",
+		SyncIndex:       "SyncIndex",
+		ShardIndex:      "AccountShardIndex",
+		Stream:          true,
+	}
+
+	t.Run("Creates the table with its indexes and TTL", func(t *testing.T) {
+		client := new(mockTableAdminClient)
+		var created *dynamodb.CreateTableInput
+		client.On("CreateTable", ctx, mock.Anything).Run(func(args mock.Arguments) {
+			created = args.Get(1).(*dynamodb.CreateTableInput)
+		}).Return(&dynamodb.CreateTableOutput{}, nil).Once()
+		activeTable(client)
+		client.On("DescribeTimeToLive", ctx, mock.Anything).Return(&dynamodb.DescribeTimeToLiveOutput{
+			TimeToLiveDescription: &types.TimeToLiveDescription{TimeToLiveStatus: types.TimeToLiveStatusDisabled},
+		}, nil).Once()
+		client.On("UpdateTimeToLive", ctx, mock.MatchedBy(func(input *dynamodb.UpdateTimeToLiveInput) bool {
+			return aws.ToString(input.TimeToLiveSpecification.AttributeName) == "expiresAt" && aws.ToBool(input.TimeToLiveSpecification.Enabled)
+		})).Return(&dynamodb.UpdateTimeToLiveOutput{}, nil).Once()
+
+		result, err := BootstrapTable(ctx, client, spec)
+		require.NoError(t, err)
+		assert.Equal(t, &BootstrapResult{Created: true, TTLEnabled: true}, result)
+
+		require.NotNil(t, created)
+		assert.Equal(t, types.BillingModePayPerRequest, created.BillingMode)
+		var indexes []string
+		for _, index := range created.GlobalSecondaryIndexes {
+			indexes = append(indexes, aws.ToString(index.IndexName))
+		}
+		// The shard index needs sharding on
+		assert.Equal(t, []string{"AccountIndex", "ExternalIdIndex", "SyncIndex", "LocationIdIndex"}, indexes)
+		var attributes []string
+		for _, attribute := range created.AttributeDefinitions {
+			attributes = append(attributes, aws.ToString(attribute.AttributeName))
+		}
+		assert.Equal(t, []string{"PK", "SK", "accountId", "accountExternalId", "syncAccount", "updatedAt"}, attributes)
+		assert.Equal(t, types.StreamViewTypeNewAndOldImages, created.StreamSpecification.StreamViewType)
+		client.AssertExpectations(t)
+	})
+
+	t.Run("Leaves an existing table with TTL on alone", func(t *testing.T) {
+		client := new(mockTableAdminClient)
+		client.On("CreateTable", ctx, mock.Anything).Return(nil, &types.ResourceInUseException{Message: aws.String("Table already exists")}).Once()
+		activeTable(client)
+		client.On("DescribeTimeToLive", ctx, mock.Anything).Return(&dynamodb.DescribeTimeToLiveOutput{
+			TimeToLiveDescription: &types.TimeToLiveDescription{TimeToLiveStatus: types.TimeToLiveStatusEnabled},
+		}, nil).Once()
+
+		result, err := BootstrapTable(ctx, client, spec)
+		require.NoError(t, err)
+		assert.Equal(t, &BootstrapResult{}, result)
+		client.AssertNotCalled(t, "UpdateTimeToLive", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Fails when the table can't be created", func(t *testing.T) {
+		client := new(mockTableAdminClient)
+		client.On("CreateTable", ctx, mock.Anything).Return(nil, errors.New("access denied")).Once()
+
+		_, err := BootstrapTable(ctx, client, spec)
+		assert.EqualError(t, err, "failed to create table location-dev-locations: access denied")
+	})
+
+	t.Run("Refuses production tables", func(t *testing.T) {
+		client := new(mockTableAdminClient)
+
+		_, err := BootstrapTable(ctx, client, TableSpec{TableName: "location-prod-locations"})
+		assert.EqualError(t, err, "refusing to bootstrap table location-prod-locations, whose name marks it as production")
+		client.AssertNotCalled(t, "CreateTable", mock.Anything, mock.Anything)
+	})
+}
+
+func TestCheckNonProduction(t *testing.T) {
+	tests := []struct {
+		name        string
+		environment string
+		tableName   string
+		wantErr     string
+	}{
+		{name: "Dev table", environment: "dev", tableName: "location-dev-locations"},
+		{name: "Local table without an environment", tableName: "locations"},
+		{name: "Name merely containing prod", environment: "dev", tableName: "products-dev"},
+		{name: "Production environment", environment: "Production", tableName: "locations", wantErr: "refusing to bootstrap in the Production environment"},
+		{name: "Production table", environment: "dev", tableName: "location_PRD_locations", wantErr: "refusing to bootstrap table location_PRD_locations, whose name marks it as production"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckNonProduction(tt.environment, tt.tableName)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"context"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// CapacityUsage totals the DynamoDB capacity consumed by calls made with a
+// context, across every table they touched. Calls that fail, such as
+// conditional writes whose condition doesn't hold, still consume capacity
+// but return none to count, so the totals are a lower bound.
+type CapacityUsage struct {
+	mu                 sync.Mutex
+	calls              int
+	readCapacityUnits  float64
+	writeCapacityUnits float64
+}
+
+// Calls returns how many calls reported their consumed capacity.
+func (u *CapacityUsage) Calls() int {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.calls
+}
+
+// ReadCapacityUnits returns the read capacity units consumed.
+func (u *CapacityUsage) ReadCapacityUnits() float64 {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.readCapacityUnits
+}
+
+// WriteCapacityUnits returns the write capacity units consumed.
+func (u *CapacityUsage) WriteCapacityUnits() float64 {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.writeCapacityUnits
+}
+
+// add counts one call's consumed capacity, read or write by the call's kind
+// when DynamoDB reports only the total.
+func (u *CapacityUsage) add(write bool, consumed ...*types.ConsumedCapacity) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.calls++
+	for _, c := range consumed {
+		switch {
+		case c == nil:
+		case c.ReadCapacityUnits != nil || c.WriteCapacityUnits != nil:
+			u.readCapacityUnits += aws.ToFloat64(c.ReadCapacityUnits)
+			u.writeCapacityUnits += aws.ToFloat64(c.WriteCapacityUnits)
+		case write:
+			u.writeCapacityUnits += aws.ToFloat64(c.CapacityUnits)
+		default:
+			u.readCapacityUnits += aws.ToFloat64(c.CapacityUnits)
+		}
+	}
+}
+
+type capacityUsageKey struct{}
+
+// WithCapacityUsage returns a context that totals the capacity consumed by
+// calls made through a client from NewCapacityClient.
+func WithCapacityUsage(ctx context.Context) (context.Context, *CapacityUsage) {
+	usage := &CapacityUsage{}
+	return context.WithValue(ctx, capacityUsageKey{}, usage), usage
+}
+
+// recordCapacity adds a call's consumed capacity to the context's usage, if any.
+func recordCapacity(ctx context.Context, write bool, consumed ...*types.ConsumedCapacity) {
+	if usage, ok := ctx.Value(capacityUsageKey{}).(*CapacityUsage); ok {
+		usage.add(write, consumed...)
+	}
+}
+
+// capacityClient asks DynamoDB for the capacity each call consumes and
+// records it on the call's context.
+type capacityClient struct {
+	client DynamoDBClient
+}
+
+// NewCapacityClient wraps client so its calls return their consumed
+// capacity, which is totalled on contexts from WithCapacityUsage.
+func NewCapacityClient(client DynamoDBClient) DynamoDBClient {
+	return &capacityClient{client: client}
+}
+
+func (c *capacityClient) PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	input := *params
+	input.ReturnConsumedCapacity = types.ReturnConsumedCapacityTotal
+	output, err := c.client.PutItem(ctx, &input, optFns...)
+	if err == nil {
+		recordCapacity(ctx, true, output.ConsumedCapacity)
+	}
+	return output, err
+}
+
+func (c *capacityClient) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	input := *params
+	input.ReturnConsumedCapacity = types.ReturnConsumedCapacityTotal
+	output, err := c.client.GetItem(ctx, &input, optFns...)
+	if err == nil {
+		recordCapacity(ctx, false, output.ConsumedCapacity)
+	}
+	return output, err
+}
+
+func (c *capacityClient) DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	input := *params
+	input.ReturnConsumedCapacity = types.ReturnConsumedCapacityTotal
+	output, err := c.client.DeleteItem(ctx, &input, optFns...)
+	if err == nil {
+		recordCapacity(ctx, true, output.ConsumedCapacity)
+	}
+	return output, err
+}
+
+func (c *capacityClient) UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	input := *params
+	input.ReturnConsumedCapacity = types.ReturnConsumedCapacityTotal
+	output, err := c.client.UpdateItem(ctx, &input, optFns...)
+	if err == nil {
+		recordCapacity(ctx, true, output.ConsumedCapacity)
+	}
+	return output, err
+}
+
+func (c *capacityClient) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	input := *params
+	input.ReturnConsumedCapacity = types.ReturnConsumedCapacityTotal
+	output, err := c.client.Query(ctx, &input, optFns...)
+	if err == nil {
+		recordCapacity(ctx, false, output.ConsumedCapacity)
+	}
+	return output, err
+}
+
+func (c *capacityClient) Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	input := *params
+	input.ReturnConsumedCapacity = types.ReturnConsumedCapacityTotal
+	output, err := c.client.Scan(ctx, &input, optFns...)
+	if err == nil {
+		recordCapacity(ctx, false, output.ConsumedCapacity)
+	}
+	return output, err
+}
+
+func (c *capacityClient) TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	input := *params
+	input.ReturnConsumedCapacity = types.ReturnConsumedCapacityTotal
+	output, err := c.client.TransactWriteItems(ctx, &input, optFns...)
+	if err == nil {
+		consumed := make([]*types.ConsumedCapacity, 0, len(output.ConsumedCapacity))
+		for i := range output.ConsumedCapacity {
+			consumed = append(consumed, &output.ConsumedCapacity[i])
+		}
+		recordCapacity(ctx, true, consumed...)
+	}
+	return output, err
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCapacityClient(t *testing.T) {
+	requestsCapacity := func(mode types.ReturnConsumedCapacity) bool {
+		return mode == types.ReturnConsumedCapacityTotal
+	}
+
+	t.Run("Totals the capacity of a context's calls", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		client := NewCapacityClient(mockClient)
+		ctx, usage := WithCapacityUsage(context.Background())
+
+		mockClient.On("GetItem", ctx, mock.MatchedBy(func(input *dynamodb.GetItemInput) bool {
+			return requestsCapacity(input.ReturnConsumedCapacity)
+		})).Return(&dynamodb.GetItemOutput{ConsumedCapacity: &types.ConsumedCapacity{CapacityUnits: aws.Float64(0.5)}}, nil).Once()
+		mockClient.On("Query", ctx, mock.MatchedBy(func(input *dynamodb.QueryInput) bool {
+			return requestsCapacity(input.ReturnConsumedCapacity)
+		})).Return(&dynamodb.QueryOutput{ConsumedCapacity: &types.ConsumedCapacity{
+			CapacityUnits:     aws.Float64(2),
+			ReadCapacityUnits: aws.Float64(2),
+		}}, nil).Once()
+		mockClient.On("PutItem", ctx, mock.MatchedBy(func(input *dynamodb.PutItemInput) bool {
+			return requestsCapacity(input.ReturnConsumedCapacity)
+		})).Return(&dynamodb.PutItemOutput{ConsumedCapacity: &types.ConsumedCapacity{CapacityUnits: aws.Float64(1)}}, nil).Once()
+		mockClient.On("TransactWriteItems", ctx, mock.MatchedBy(func(input *dynamodb.TransactWriteItemsInput) bool {
+			return requestsCapacity(input.ReturnConsumedCapacity)
+		})).Return(&dynamodb.TransactWriteItemsOutput{ConsumedCapacity: []types.ConsumedCapacity{
+			{TableName: aws.String("locations"), CapacityUnits: aws.Float64(4)},
+			{TableName: aws.String("archive"), CapacityUnits: aws.Float64(2)},
+		}}, nil).Once()
+
+		_, err := client.GetItem(ctx, &dynamodb.GetItemInput{TableName: aws.String("locations")})
+		require.NoError(t, err)
+		_, err = client.Query(ctx, &dynamodb.QueryInput{TableName: aws.String("locations")})
+		require.NoError(t, err)
+		_, err = client.PutItem(ctx, &dynamodb.PutItemInput{TableName: aws.String("locations")})
+		require.NoError(t, err)
+		_, err = client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{})
+		require.NoError(t, err)
+
+		assert.Equal(t, 4, usage.Calls())
+		assert.Equal(t, 2.5, usage.ReadCapacityUnits())
+		assert.Equal(t, 7.0, usage.WriteCapacityUnits())
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Leaves the caller's input alone", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		mockClient.On("DeleteItem", mock.Anything, mock.Anything).Return(&dynamodb.DeleteItemOutput{}, nil).Once()
+
+		input := &dynamodb.DeleteItemInput{TableName: aws.String("locations")}
+		_, err := NewCapacityClient(mockClient).DeleteItem(context.Background(), input)
+		require.NoError(t, err)
+		assert.Empty(t, input.ReturnConsumedCapacity)
+	})
+
+	t.Run("Skips failed calls", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		ctx, usage := WithCapacityUsage(context.Background())
+		mockClient.On("PutItem", ctx, mock.Anything).Return(nil, errors.New("conditional check failed")).Once()
+
+		_, err := NewCapacityClient(mockClient).PutItem(ctx, &dynamodb.PutItemInput{})
+		assert.Error(t, err)
+		assert.Equal(t, 0, usage.Calls())
+	})
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/steverhoton/location-lambda/internal/models"
+)
+
+// listFilter is the filter a list query applies to an account's items.
+// DynamoDB cannot key an index on a list attribute, so a shop's categories
+// are matched by filtering the account's partition, or its shards, rather
+// than through a GSI; filtered pages may hold fewer items than the limit.
+type listFilter struct {
+	expression         string
+	category           string
+	missingCoordinates bool
+	minConfidence      *float64
+	geocoded           bool
+	active             bool
+	now                time.Time // The time active locations are active at
+	activeOn           *time.Time
+	maxAccuracy        *float64
+}
+
+// newListFilter returns the filter for a list call: live, published
+// locations within their active window, with drafts when
+// options.IncludeDrafts is set and inactive locations when
+// options.IncludeInactive is, limited
+// to shops in options.Category when it is set, to address locations
+// awaiting geocoding with options.MissingCoordinates, to confidently placed
+// address locations with options.MinGeocodeConfidence, to those whose
+// geocode may be refreshed with options.Geocoded, to events running on
+// options.ActiveOn, and to locations placed to within
+// options.MaxAccuracyMeters. Active windows are compared against now.
+func newListFilter(options *ListOptions, now time.Time) listFilter {
+	filter := listFilter{expression: notMergedFilter}
+	if options == nil || !options.IncludeDrafts {
+		filter.expression += " AND " + publishedFilter
+	}
+	if options == nil || !options.IncludeInactive {
+		filter.active = true
+		filter.now = now
+		filter.expression += " AND " + activeFilter
+	}
+	if options != nil && options.Category != "" {
+		filter.category = options.Category
+		filter.expression += " AND contains(shop.categories, :category)"
+	}
+	if options != nil && options.MissingCoordinates {
+		filter.missingCoordinates = true
+		filter.expression += " AND locationType = :addressType AND attribute_not_exists(coordinates)"
+	}
+	if options != nil && options.MinGeocodeConfidence != nil {
+		filter.minConfidence = options.MinGeocodeConfidence
+		filter.expression += " AND (locationType <> :addressType OR (attribute_exists(coordinates) AND (attribute_not_exists(geocode) OR geocode.confidence >= :minConfidence)))"
+	}
+	if options != nil && options.Geocoded {
+		filter.geocoded = true
+		filter.expression += " AND locationType = :addressType AND attribute_exists(geocode) AND attribute_not_exists(coordinatesLocked)"
+	}
+	if options != nil && options.ActiveOn != nil {
+		filter.activeOn = options.ActiveOn
+		filter.expression += " AND locationType = :eventType AND startsAt < :dayEnd AND endsAt > :dayStart"
+	}
+	if options != nil && options.MaxAccuracyMeters != nil {
+		// A missing accuracy compares false, so locations of unknown accuracy are left out
+		filter.maxAccuracy = options.MaxAccuracyMeters
+		filter.expression += " AND (coordinates.accuracy <= :maxAccuracy OR shop.coordinates.accuracy <= :maxAccuracy)"
+	}
+	return filter
+}
+
+// values adds the filter's expression values to those of the query.
+func (f listFilter) values(values map[string]types.AttributeValue) map[string]types.AttributeValue {
+	if f.category != "" {
+		values[":category"] = &types.AttributeValueMemberS{Value: f.category}
+	}
+	if f.missingCoordinates || f.minConfidence != nil || f.geocoded {
+		values[":addressType"] = &types.AttributeValueMemberS{Value: string(models.LocationTypeAddress)}
+	}
+	if f.active {
+		values[":now"] = &types.AttributeValueMemberS{Value: formatScheduleTime(&f.now)}
+	}
+	if f.minConfidence != nil {
+		values[":minConfidence"] = &types.AttributeValueMemberN{Value: strconv.FormatFloat(*f.minConfidence, 'f', -1, 64)}
+	}
+	if f.activeOn != nil {
+		dayStart := time.Date(f.activeOn.Year(), f.activeOn.Month(), f.activeOn.Day(), 0, 0, 0, 0, time.UTC)
+		dayEnd := dayStart.AddDate(0, 0, 1)
+		values[":eventType"] = &types.AttributeValueMemberS{Value: string(models.LocationTypeEvent)}
+		values[":dayStart"] = &types.AttributeValueMemberS{Value: formatScheduleTime(&dayStart)}
+		values[":dayEnd"] = &types.AttributeValueMemberS{Value: formatScheduleTime(&dayEnd)}
+	}
+	if f.maxAccuracy != nil {
+		values[":maxAccuracy"] = &types.AttributeValueMemberN{Value: formatFloat(*f.maxAccuracy)}
+	}
+	return values
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDynamoDBRepositoryListByCategory(t *testing.T) {
+	matchCategory := func(category string) interface{} {
+		return mock.MatchedBy(func(input *dynamodb.QueryInput) bool {
+			value, ok := input.ExpressionAttributeValues[":category"].(*types.AttributeValueMemberS)
+			return ok && value.Value == category &&
+				aws.ToString(input.FilterExpression) == notMergedFilter+" AND "+listedFilter+" AND contains(shop.categories, :category)"
+		})
+	}
+
+	t.Run("Filters the account partition", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		mockClient.On("Query", ctx, matchCategory("722515")).Return(&dynamodb.QueryOutput{}, nil).Once()
+
+		_, err := repo.List(ctx, "acc-12345", &ListOptions{Category: "722515"})
+		require.NoError(t, err)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Filters every shard", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table", WithSharding(ShardConfig{ShardCount: 2, IndexName: "AccountShardIndex"}))
+
+		mockClient.On("Query", ctx, matchCategory("722515")).Return(&dynamodb.QueryOutput{}, nil).Twice()
+
+		_, err := repo.List(ctx, "acc-12345", &ListOptions{Category: "722515"})
+		require.NoError(t, err)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Lists everything without a category", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		mockClient.On("Query", ctx, mock.MatchedBy(func(input *dynamodb.QueryInput) bool {
+			_, hasCategory := input.ExpressionAttributeValues[":category"]
+			return !hasCategory && aws.ToString(input.FilterExpression) == notMergedFilter+" AND "+listedFilter
+		})).Return(&dynamodb.QueryOutput{}, nil).Once()
+
+		result, err := repo.List(ctx, "acc-12345", nil)
+		require.NoError(t, err)
+		assert.Empty(t, result.Items)
+		mockClient.AssertExpectations(t)
+	})
+}
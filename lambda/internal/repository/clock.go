@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Clock tells the time items are stamped with. Tests inject a fixed clock to
+// make timestamps, TTLs, and sync tokens reproducible.
+type Clock interface {
+	Now() time.Time
+}
+
+// IDGenerator mints the IDs of new locations, proposals, templates, and the
+// other items the repository creates.
+type IDGenerator interface {
+	NewID() string
+}
+
+// SystemClock is the wall clock, which repositories and handlers use unless
+// given another.
+var SystemClock Clock = systemClock{}
+
+// UUIDv7Generator mints version 7 UUIDs, which sort by creation time and
+// carry it, so LocationStats can count locations by the month they were
+// created and proposals list oldest first.
+var UUIDv7Generator IDGenerator = uuidV7Generator{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+type uuidV7Generator struct{}
+
+func (uuidV7Generator) NewID() string { return uuid.Must(uuid.NewV7()).String() }
+
+// WithClock stamps items with the time from clock instead of the wall clock.
+func WithClock(clock Clock) Option {
+	return func(r *DynamoDBRepository) {
+		r.clock = clock
+	}
+}
+
+// WithIDGenerator mints the IDs of new items with ids instead of UUIDv7Generator.
+func WithIDGenerator(ids IDGenerator) Option {
+	return func(r *DynamoDBRepository) {
+		r.ids = ids
+	}
+}
+
+// now returns the current time from the repository's clock.
+func (r *DynamoDBRepository) now() time.Time {
+	return r.clock.Now()
+}
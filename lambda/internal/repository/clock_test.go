@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// fixedClock is a Clock stopped at one time.
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time { return time.Time(c) }
+
+// fixedID is an IDGenerator minting the same ID every time.
+type fixedID string
+
+func (id fixedID) NewID() string { return string(id) }
+
+func TestRepositoryClockAndIDs(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 6, 1, 12, 30, 0, 0, time.UTC)
+
+	t.Run("Create names and stamps the location", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table", WithClock(fixedClock(now)), WithIDGenerator(fixedID("loc-001")))
+
+		mockClient.On("PutItem", ctx, mock.MatchedBy(func(input *dynamodb.PutItemInput) bool {
+			sk, _ := input.Item["SK"].(*types.AttributeValueMemberS)
+			updatedAt, _ := input.Item["updatedAt"].(*types.AttributeValueMemberS)
+			return sk != nil && sk.Value == "loc-001" && updatedAt != nil && updatedAt.Value == syncTime(now)
+		})).Return(&dynamodb.PutItemOutput{}, nil).Once()
+
+		created, err := repo.Create(ctx, models.CoordinatesLocation{
+			LocationBase: models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeCoordinates},
+			Coordinates:  models.Coordinates{Latitude: 40.7128, Longitude: -74.006},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "loc-001", created.LocationID)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("List compares active windows with the clock", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table", WithClock(fixedClock(now)))
+
+		mockClient.On("Query", ctx, mock.MatchedBy(func(input *dynamodb.QueryInput) bool {
+			value, _ := input.ExpressionAttributeValues[":now"].(*types.AttributeValueMemberS)
+			return value != nil && value.Value == formatScheduleTime(&now)
+		})).Return(&dynamodb.QueryOutput{}, nil).Once()
+
+		_, err := repo.List(ctx, "acc-12345", nil)
+		require.NoError(t, err)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Defaults to time-ordered UUIDs", func(t *testing.T) {
+		first, second := UUIDv7Generator.NewID(), UUIDv7Generator.NewID()
+		assert.Len(t, first, 36)
+		assert.NotEqual(t, first, second)
+	})
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"strings"
+)
+
+// ReadConsistency configures strongly-consistent reads per operation.
+type ReadConsistency struct {
+	// Get enables strongly-consistent reads for Get.
+	Get bool
+	// List enables strongly-consistent reads for List.
+	List bool
+	// FallbackOnThrottle retries a throttled strongly-consistent read with
+	// eventual consistency instead of failing the request.
+	FallbackOnThrottle bool
+}
+
+// ParseConsistentReadOperations builds a ReadConsistency from a comma-separated
+// list of operation names (e.g. "get,list").
+func ParseConsistentReadOperations(operations string, fallbackOnThrottle bool) ReadConsistency {
+	rc := ReadConsistency{FallbackOnThrottle: fallbackOnThrottle}
+	for _, op := range strings.Split(operations, ",") {
+		switch strings.ToLower(strings.TrimSpace(op)) {
+		case "get":
+			rc.Get = true
+		case "list":
+			rc.List = true
+		}
+	}
+	return rc
+}
+
+// ReadInfo describes how reads made with a context were served.
+type ReadInfo struct {
+	// StaleRead is true when at least one read fell back to eventual consistency.
+	StaleRead bool
+	// IndexFallback is true when at least one read could not use its GSI and
+	// was served by other means, such as a bounded scan whose results may be
+	// incomplete.
+	IndexFallback bool
+}
+
+type readInfoKey struct{}
+
+// WithReadInfo returns a context that collects ReadInfo from repository reads.
+func WithReadInfo(ctx context.Context) (context.Context, *ReadInfo) {
+	info := &ReadInfo{}
+	return context.WithValue(ctx, readInfoKey{}, info), info
+}
+
+// markStaleRead records a stale read on the context's ReadInfo, if any.
+func markStaleRead(ctx context.Context) {
+	if info, ok := ctx.Value(readInfoKey{}).(*ReadInfo); ok {
+		info.StaleRead = true
+	}
+}
+
+// markIndexFallback records an index fallback on the context's ReadInfo, if any.
+func markIndexFallback(ctx context.Context) {
+	if info, ok := ctx.Value(readInfoKey{}).(*ReadInfo); ok {
+		info.IndexFallback = true
+	}
+}
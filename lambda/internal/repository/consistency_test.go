@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseConsistentReadOperations(t *testing.T) {
+	tests := []struct {
+		name       string
+		operations string
+		fallback   bool
+		expected   ReadConsistency
+	}{
+		{
+			name:       "Empty list",
+			operations: "",
+			expected:   ReadConsistency{},
+		},
+		{
+			name:       "Get and list with fallback",
+			operations: "get, LIST",
+			fallback:   true,
+			expected:   ReadConsistency{Get: true, List: true, FallbackOnThrottle: true},
+		},
+		{
+			name:       "Unknown operations are ignored",
+			operations: "get,delete",
+			expected:   ReadConsistency{Get: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ParseConsistentReadOperations(tt.operations, tt.fallback))
+		})
+	}
+}
+
+func TestReadInfo(t *testing.T) {
+	t.Run("Marks stale read on context", func(t *testing.T) {
+		ctx, info := WithReadInfo(context.Background())
+		markStaleRead(ctx)
+		assert.True(t, info.StaleRead)
+	})
+
+	t.Run("No-op without read info", func(t *testing.T) {
+		assert.NotPanics(t, func() {
+			markStaleRead(context.Background())
+		})
+	})
+}
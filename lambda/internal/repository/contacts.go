@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// maxContactMatches caps the shops FindByContactID returns.
+const maxContactMatches = 100
+
+// ContactFinder finds the shops a contact is attached to.
+type ContactFinder interface {
+	FindByContactID(ctx context.Context, accountID, contactID string) ([]LocationEnvelope, error)
+}
+
+// WithContactIndex enables FindByContactID with the sparse GSI keyed on accountContact.
+func WithContactIndex(indexName string) Option {
+	return func(r *DynamoDBRepository) {
+		r.contactIndex = indexName
+	}
+}
+
+// contactIndexKey returns the contact index key of a record, or an empty
+// string when it is not a shop, keeping the index sparse.
+func contactIndexKey(record *locationRecord) string {
+	if record.Shop == nil || record.Shop.ContactID == "" {
+		return ""
+	}
+	return accountIndexKey(record.PK, record.Shop.ContactID)
+}
+
+// FindByContactID returns the account's shops whose primary contact is
+// contactID, drafts and inactive shops included, so a contact is not deleted
+// while any shop still points at it. Contacts listed by role are not indexed.
+// At most 100 shops are returned. The index is eventually consistent; see
+// WithIndexFallback for when it can't be read.
+func (r *DynamoDBRepository) FindByContactID(ctx context.Context, accountID, contactID string) ([]LocationEnvelope, error) {
+	if r.contactIndex == "" {
+		return nil, fmt.Errorf("contact index is not configured")
+	}
+	if accountID == "" || contactID == "" {
+		return nil, fmt.Errorf("validation failed: accountId and contactId are required")
+	}
+
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		IndexName:              aws.String(r.contactIndex),
+		KeyConditionExpression: aws.String("accountContact = :key"),
+		FilterExpression:       aws.String(notMergedFilter),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":key": &types.AttributeValueMemberS{Value: accountIndexKey(accountID, contactID)},
+		},
+	}
+
+	locations := []LocationEnvelope{}
+	for {
+		result, err := r.client.Query(ctx, input)
+		if err != nil && r.indexUnavailable(err) {
+			found, err := r.scanFallback(ctx, "accountContact = :key AND "+aws.ToString(input.FilterExpression), input.ExpressionAttributeValues, maxContactMatches)
+			if err != nil {
+				return nil, err
+			}
+			return ownLocations(accountID, found), nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to query contact index: %w", err)
+		}
+		for _, item := range result.Items {
+			var record locationRecord
+			if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal location: %w", err)
+			}
+			if record.PK != accountID {
+				continue
+			}
+			if err := r.hydrateRecord(ctx, &record); err != nil {
+				return nil, err
+			}
+			envelope, err := record.toEnvelope()
+			if err != nil {
+				return nil, err
+			}
+			locations = append(locations, *envelope)
+			if len(locations) == maxContactMatches {
+				return locations, nil
+			}
+		}
+		if result.LastEvaluatedKey == nil {
+			return locations, nil
+		}
+		input.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+}
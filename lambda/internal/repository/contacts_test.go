@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContactIndexKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		location models.Location
+		want     string
+	}{
+		{name: "Shop", location: models.ShopLocation{
+			LocationBase: models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeShop},
+			Shop: models.Shop{
+				Name:      "Main Street Store",
+				ContactID: "contact-1",
+				Address:   models.Address{StreetAddress: "123 Main St", City: "Springfield", PostalCode: "62704", Country: "US"},
+				Contacts:  []models.ShopContact{{ContactID: "contact-2", Role: models.ShopContactRoleBilling}},
+			},
+		}, want: "acc-12345#contact-1"},
+		{name: "Account ID with a separator", location: models.ShopLocation{
+			LocationBase: models.LocationBase{AccountID: "acc#12345", LocationType: models.LocationTypeShop},
+			Shop: models.Shop{
+				Name:      "Main Street Store",
+				ContactID: "contact-1",
+				Address:   models.Address{StreetAddress: "123 Main St", City: "Springfield", PostalCode: "62704", Country: "US"},
+			},
+		}, want: "acc%2312345#contact-1"},
+		{name: "Address location", location: models.AddressLocation{
+			LocationBase: models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeAddress},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewDynamoDBRepository(new(mockDynamoDBClient), "test-table")
+			record, err := toLocationRecord(tt.location, "loc-001")
+			require.NoError(t, err)
+
+			item, err := repo.prepareRecord(context.Background(), record)
+			require.NoError(t, err)
+			if tt.want == "" {
+				assert.NotContains(t, item, "accountContact")
+				return
+			}
+			assert.Equal(t, tt.want, item["accountContact"].(*types.AttributeValueMemberS).Value)
+		})
+	}
+}
+
+func TestDynamoDBRepositoryFindByContactID(t *testing.T) {
+	shopItem := func(t *testing.T, locationID string) map[string]types.AttributeValue {
+		item, err := attributevalue.MarshalMap(locationRecord{
+			PK:           "acc-12345",
+			SK:           locationID,
+			LocationType: models.LocationTypeShop,
+			Shop: &shopAttribute{
+				Name:      "Main Street Store",
+				ContactID: "contact-1",
+				Address:   models.Address{StreetAddress: "123 Main St", City: "Springfield", PostalCode: "62704", Country: "US"},
+			},
+		})
+		require.NoError(t, err)
+		return item
+	}
+
+	t.Run("Queries the index by account and contact across pages", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table", WithContactIndex("contact-index"))
+
+		matchesQuery := func(first bool) interface{} {
+			return mock.MatchedBy(func(input *dynamodb.QueryInput) bool {
+				return aws.ToString(input.IndexName) == "contact-index" &&
+					input.ExpressionAttributeValues[":key"].(*types.AttributeValueMemberS).Value == "acc-12345#contact-1" &&
+					aws.ToString(input.FilterExpression) == notMergedFilter &&
+					(input.ExclusiveStartKey == nil) == first
+			})
+		}
+		lastKey := map[string]types.AttributeValue{"PK": &types.AttributeValueMemberS{Value: "acc-12345"}}
+		mockClient.On("Query", ctx, matchesQuery(true)).Return(&dynamodb.QueryOutput{
+			Items:            []map[string]types.AttributeValue{shopItem(t, "loc-001")},
+			LastEvaluatedKey: lastKey,
+		}, nil).Once()
+		mockClient.On("Query", ctx, matchesQuery(false)).Return(&dynamodb.QueryOutput{
+			Items: []map[string]types.AttributeValue{shopItem(t, "loc-002")},
+		}, nil).Once()
+
+		locations, err := repo.FindByContactID(ctx, "acc-12345", "contact-1")
+		require.NoError(t, err)
+		require.Len(t, locations, 2)
+		assert.Equal(t, "loc-001", locations[0].LocationID)
+		assert.Equal(t, "loc-002", locations[1].LocationID)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Skips other accounts' shops", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table", WithContactIndex("contact-index"))
+
+		// Written unescaped by account acc for contact 12345#contact-1
+		other := shopItem(t, "loc-666")
+		other["PK"] = &types.AttributeValueMemberS{Value: "acc"}
+		mockClient.On("Query", ctx, mock.Anything).Return(&dynamodb.QueryOutput{
+			Items: []map[string]types.AttributeValue{other},
+		}, nil).Once()
+
+		locations, err := repo.FindByContactID(ctx, "acc-12345", "contact-1")
+		require.NoError(t, err)
+		assert.Empty(t, locations)
+	})
+
+	t.Run("Requires a contact", func(t *testing.T) {
+		repo := NewDynamoDBRepository(new(mockDynamoDBClient), "test-table", WithContactIndex("contact-index"))
+
+		_, err := repo.FindByContactID(context.Background(), "acc-12345", "")
+		assert.EqualError(t, err, "validation failed: accountId and contactId are required")
+	})
+
+	t.Run("Requires the index", func(t *testing.T) {
+		repo := NewDynamoDBRepository(new(mockDynamoDBClient), "test-table")
+
+		_, err := repo.FindByContactID(context.Background(), "acc-12345", "contact-1")
+		assert.EqualError(t, err, "contact index is not configured")
+	})
+}
@@ -0,0 +1,20 @@
+//go:build integration
+
+package repository_test
+
+import (
+	"testing"
+
+	"github.com/steverhoton/location-lambda/internal/repository"
+	"github.com/steverhoton/location-lambda/internal/repository/repositorytest"
+)
+
+func TestIntegrationContract(t *testing.T) {
+	t.Run("Unsharded", func(t *testing.T) {
+		repositorytest.RunContractTests(t, repository.NewIntegrationRepository(t))
+	})
+	t.Run("Sharded", func(t *testing.T) {
+		repositorytest.RunContractTests(t, repository.NewIntegrationRepository(t,
+			repository.WithSharding(repository.ShardConfig{ShardCount: 4, IndexName: "AccountShardIndex"})))
+	})
+}
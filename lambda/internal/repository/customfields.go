@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/steverhoton/location-lambda/internal/models"
+)
+
+const (
+	// accountPKPrefix keeps per-account configuration out of the location partition.
+	accountPKPrefix = "ACCOUNT#"
+	// customFieldsSK is the sort key of an account's custom field definitions.
+	customFieldsSK = "customFields"
+)
+
+// CustomFieldStore defines storage operations for account-defined custom fields.
+type CustomFieldStore interface {
+	GetCustomFieldDefinitions(ctx context.Context, accountID string) ([]models.CustomFieldDefinition, error)
+	PutCustomFieldDefinitions(ctx context.Context, accountID string, definitions []models.CustomFieldDefinition) error
+}
+
+// customFieldsRecord is the DynamoDB item holding an account's custom field definitions.
+type customFieldsRecord struct {
+	PK        string                         `dynamodbav:"PK"` // ACCOUNT#accountId
+	SK        string                         `dynamodbav:"SK"` // customFields
+	AccountID string                         `dynamodbav:"accountId"`
+	Fields    []models.CustomFieldDefinition `dynamodbav:"fields"`
+	UpdatedAt time.Time                      `dynamodbav:"updatedAt"`
+}
+
+// accountConfigKey returns the primary key of an account configuration item.
+func accountConfigKey(accountID, sk string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberS{Value: accountPKPrefix + accountID},
+		"SK": &types.AttributeValueMemberS{Value: sk},
+	}
+}
+
+// GetCustomFieldDefinitions returns an account's custom field definitions, or
+// none when the account has not defined any.
+func (r *DynamoDBRepository) GetCustomFieldDefinitions(ctx context.Context, accountID string) ([]models.CustomFieldDefinition, error) {
+	result, err := r.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(r.tableName),
+		Key:       accountConfigKey(accountID, customFieldsSK),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get custom field definitions: %w", err)
+	}
+
+	if result.Item == nil {
+		return []models.CustomFieldDefinition{}, nil
+	}
+
+	var record customFieldsRecord
+	if err := attributevalue.UnmarshalMap(result.Item, &record); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal custom field definitions: %w", err)
+	}
+
+	return record.Fields, nil
+}
+
+// PutCustomFieldDefinitions replaces an account's custom field definitions.
+// Existing locations are not revalidated; they must satisfy the new definitions
+// on their next write.
+func (r *DynamoDBRepository) PutCustomFieldDefinitions(ctx context.Context, accountID string, definitions []models.CustomFieldDefinition) error {
+	if accountID == "" {
+		return fmt.Errorf("validation failed: accountId is required")
+	}
+	if err := models.ValidateCustomFieldDefinitions(definitions); err != nil {
+		return fmt.Errorf("validation failed: %w", err)
+	}
+	if definitions == nil {
+		definitions = []models.CustomFieldDefinition{}
+	}
+
+	item, err := attributevalue.MarshalMap(customFieldsRecord{
+		PK:        accountPKPrefix + accountID,
+		SK:        customFieldsSK,
+		AccountID: accountID,
+		Fields:    definitions,
+		UpdatedAt: r.now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal custom field definitions: %w", err)
+	}
+
+	_, err = r.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(r.tableName),
+		Item:      item,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put custom field definitions: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDynamoDBRepositoryPutCustomFieldDefinitions(t *testing.T) {
+	tests := []struct {
+		name          string
+		accountID     string
+		definitions   []models.CustomFieldDefinition
+		expectedError string
+	}{
+		{
+			name:      "Valid definitions",
+			accountID: "acc-12345",
+			definitions: []models.CustomFieldDefinition{
+				{Name: "storeNumber", Type: models.CustomFieldTypeInteger, Required: true},
+			},
+		},
+		{
+			name:      "Clearing definitions",
+			accountID: "acc-12345",
+		},
+		{
+			name:          "Missing account",
+			definitions:   []models.CustomFieldDefinition{{Name: "tier", Type: models.CustomFieldTypeString}},
+			expectedError: "validation failed: accountId is required",
+		},
+		{
+			name:          "Invalid definition",
+			accountID:     "acc-12345",
+			definitions:   []models.CustomFieldDefinition{{Name: "tier", Type: "enum"}},
+			expectedError: `validation failed: custom field tier has unknown type "enum"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			mockClient := new(mockDynamoDBClient)
+			repo := NewDynamoDBRepository(mockClient, "test-table")
+
+			var stored map[string]types.AttributeValue
+			if tt.expectedError == "" {
+				mockClient.On("PutItem", ctx, mock.Anything).Run(func(args mock.Arguments) {
+					stored = args.Get(1).(*dynamodb.PutItemInput).Item
+				}).Return(&dynamodb.PutItemOutput{}, nil).Once()
+			}
+
+			err := repo.PutCustomFieldDefinitions(ctx, tt.accountID, tt.definitions)
+
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				mockClient.AssertNotCalled(t, "PutItem", mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "ACCOUNT#acc-12345", stored["PK"].(*types.AttributeValueMemberS).Value)
+			assert.Equal(t, "customFields", stored["SK"].(*types.AttributeValueMemberS).Value)
+			assert.Len(t, stored["fields"].(*types.AttributeValueMemberL).Value, len(tt.definitions))
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDynamoDBRepositoryGetCustomFieldDefinitions(t *testing.T) {
+	ctx := context.Background()
+	definitions := []models.CustomFieldDefinition{
+		{Name: "storeNumber", Type: models.CustomFieldTypeInteger, Required: true},
+		{Name: "region", Type: models.CustomFieldTypeString},
+	}
+
+	t.Run("Stored definitions", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		item, err := attributevalue.MarshalMap(customFieldsRecord{
+			PK:        "ACCOUNT#acc-12345",
+			SK:        "customFields",
+			AccountID: "acc-12345",
+			Fields:    definitions,
+		})
+		require.NoError(t, err)
+		mockClient.On("GetItem", ctx, mock.MatchedBy(func(input *dynamodb.GetItemInput) bool {
+			return input.Key["PK"].(*types.AttributeValueMemberS).Value == "ACCOUNT#acc-12345" &&
+				input.Key["SK"].(*types.AttributeValueMemberS).Value == "customFields"
+		})).Return(&dynamodb.GetItemOutput{Item: item}, nil).Once()
+
+		result, err := repo.GetCustomFieldDefinitions(ctx, "acc-12345")
+		require.NoError(t, err)
+		assert.Equal(t, definitions, result)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("No definitions", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		mockClient.On("GetItem", ctx, mock.Anything).Return(&dynamodb.GetItemOutput{}, nil).Once()
+
+		result, err := repo.GetCustomFieldDefinitions(ctx, "acc-12345")
+		require.NoError(t, err)
+		assert.Empty(t, result)
+	})
+
+	t.Run("DynamoDB error", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		mockClient.On("GetItem", ctx, mock.Anything).Return(nil, errors.New("throttled")).Once()
+
+		_, err := repo.GetCustomFieldDefinitions(ctx, "acc-12345")
+		assert.EqualError(t, err, "failed to get custom field definitions: throttled")
+	})
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/steverhoton/location-lambda/internal/geo"
+	"github.com/steverhoton/location-lambda/internal/models"
+)
+
+// deliveryShopFilter selects an account's live, published, active shops with delivery zones.
+const deliveryShopFilter = notMergedFilter + " AND " + listedFilter + " AND locationType = :shop AND attribute_exists(shop.deliveryZones)"
+
+// DeliveryZoneFinder finds the delivery zones containing a point.
+type DeliveryZoneFinder interface {
+	FindDeliveryZones(ctx context.Context, accountID string, point models.Coordinates) ([]DeliveryZoneMatch, error)
+}
+
+// DeliveryZoneMatch is a shop's delivery zone containing the searched point.
+type DeliveryZoneMatch struct {
+	LocationID string
+	ShopName   string
+	Zone       models.DeliveryZone
+}
+
+// FindDeliveryZones returns every zone of the account's shops that contains
+// point, in the order the shops are stored. Like FindShopsNear, it reads the
+// account's shops with delivery zones rather than an index.
+func (r *DynamoDBRepository) FindDeliveryZones(ctx context.Context, accountID string, point models.Coordinates) ([]DeliveryZoneMatch, error) {
+	if accountID == "" {
+		return nil, fmt.Errorf("validation failed: accountId is required")
+	}
+	if err := point.Validate(); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	input := &dynamodb.QueryInput{
+		TableName:            aws.String(r.tableName),
+		FilterExpression:     aws.String(deliveryShopFilter),
+		ProjectionExpression: aws.String("SK, shop"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":shop": &types.AttributeValueMemberS{Value: string(models.LocationTypeShop)},
+			":now":  r.nowValue(),
+		},
+	}
+	input.KeyConditionExpression = aws.String(r.locationPartition(":pk", accountID, input.ExpressionAttributeValues))
+
+	matches := []DeliveryZoneMatch{}
+	for {
+		result, err := r.client.Query(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query shops: %w", err)
+		}
+		for _, item := range result.Items {
+			var record struct {
+				LocationID string         `dynamodbav:"SK"`
+				Shop       *shopAttribute `dynamodbav:"shop"`
+			}
+			if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal shop: %w", err)
+			}
+			if record.Shop == nil {
+				continue
+			}
+			for _, zone := range record.Shop.DeliveryZones {
+				if geo.PolygonContains(zone.Boundary, point) {
+					matches = append(matches, DeliveryZoneMatch{LocationID: locationIDOf(record.LocationID), ShopName: record.Shop.Name, Zone: zone})
+				}
+			}
+		}
+		if result.LastEvaluatedKey == nil {
+			return matches, nil
+		}
+		input.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+}
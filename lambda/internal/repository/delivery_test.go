@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDynamoDBRepositoryFindDeliveryZones(t *testing.T) {
+	downtown := models.DeliveryZone{
+		Name: "Downtown",
+		Boundary: []models.Coordinates{
+			{Latitude: 39.77, Longitude: -89.67},
+			{Latitude: 39.77, Longitude: -89.63},
+			{Latitude: 39.80, Longitude: -89.63},
+			{Latitude: 39.80, Longitude: -89.67},
+		},
+		DeliveryFee: "4.99",
+	}
+	airport := models.DeliveryZone{
+		Name: "Airport",
+		Boundary: []models.Coordinates{
+			{Latitude: 39.83, Longitude: -89.69},
+			{Latitude: 39.83, Longitude: -89.66},
+			{Latitude: 39.86, Longitude: -89.66},
+		},
+		DeliveryFee: "9.99",
+	}
+	item, err := attributevalue.MarshalMap(map[string]interface{}{
+		"SK": "loc-001",
+		"shop": shopAttribute{
+			Name:          "Main Street Store",
+			DeliveryZones: []models.DeliveryZone{airport, downtown},
+		},
+	})
+	require.NoError(t, err)
+
+	t.Run("Returns the zones containing the point", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		mockClient.On("Query", ctx, mock.MatchedBy(func(input *dynamodb.QueryInput) bool {
+			return input.ExpressionAttributeValues[":pk"].(*types.AttributeValueMemberS).Value == "acc-12345" &&
+				aws.ToString(input.FilterExpression) == deliveryShopFilter
+		})).Return(&dynamodb.QueryOutput{Items: []map[string]types.AttributeValue{item}}, nil).Once()
+
+		matches, err := repo.FindDeliveryZones(ctx, "acc-12345", models.Coordinates{Latitude: 39.7817, Longitude: -89.6501})
+		require.NoError(t, err)
+		require.Len(t, matches, 1)
+		assert.Equal(t, "loc-001", matches[0].LocationID)
+		assert.Equal(t, "Main Street Store", matches[0].ShopName)
+		assert.Equal(t, downtown, matches[0].Zone)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Validates the point", func(t *testing.T) {
+		repo := NewDynamoDBRepository(new(mockDynamoDBClient), "test-table")
+
+		_, err := repo.FindDeliveryZones(context.Background(), "acc-12345", models.Coordinates{Longitude: 200})
+		assert.EqualError(t, err, "validation failed: longitude must be between -180 and 180, got 200.000000")
+	})
+}
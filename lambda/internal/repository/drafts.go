@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/steverhoton/location-lambda/internal/models"
+)
+
+// publishedFilter excludes unpublished drafts from list and search queries.
+const publishedFilter = "attribute_not_exists(draft)"
+
+// locationPayload names an attribute every location of each type holds,
+// compared to detect concurrent edits.
+var locationPayload = map[models.LocationType]string{
+	models.LocationTypeAddress:     "address",
+	models.LocationTypeCoordinates: "coordinates",
+	models.LocationTypeShop:        "shop",
+	models.LocationTypeEvent:       "startsAt",
+}
+
+// DraftPublisher publishes draft locations.
+type DraftPublisher interface {
+	Publish(ctx context.Context, accountID, locationID string) error
+}
+
+// sameDraftState returns a condition that the stored record is a draft
+// exactly when draft is set.
+func sameDraftState(draft bool) string {
+	if draft {
+		return "attribute_exists(draft)"
+	}
+	return publishedFilter
+}
+
+// draftStateError explains why a write may not change a location's draft state.
+func draftStateError(draft bool) error {
+	if draft {
+		return fmt.Errorf("validation failed: location is a draft; publish it with publishLocation")
+	}
+	return fmt.Errorf("validation failed: a published location cannot become a draft")
+}
+
+// Publish makes a draft location visible to lists and searches. The stored
+// draft must still pass validation. Only the draft marker is removed, and the
+// write is conditional on the location's details being unchanged since they
+// were validated, so a concurrent edit makes Publish fail rather than be lost.
+func (r *DynamoDBRepository) Publish(ctx context.Context, accountID, locationID string) error {
+	result, err := r.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(r.tableName),
+		Key:            r.locationKey(accountID, locationID),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get location: %w", err)
+	}
+	if result.Item == nil {
+		return fmt.Errorf("location not found or access denied")
+	}
+
+	var record locationRecord
+	if err := attributevalue.UnmarshalMap(result.Item, &record); err != nil {
+		return fmt.Errorf("failed to unmarshal location: %w", err)
+	}
+	if record.MergedInto != "" {
+		return fmt.Errorf("location not found or access denied")
+	}
+	if !record.Draft {
+		return fmt.Errorf("location is already published")
+	}
+	payload, ok := locationPayload[record.LocationType]
+	if !ok || result.Item[payload] == nil {
+		return fmt.Errorf("failed to publish location: unknown location type: %s", record.LocationType)
+	}
+
+	if err := r.hydrateRecord(ctx, &record); err != nil {
+		return err
+	}
+	location, err := record.toLocation()
+	if err != nil {
+		return fmt.Errorf("failed to convert record to location: %w", err)
+	}
+	if err := location.Validate(); err != nil {
+		return fmt.Errorf("validation failed: %w", err)
+	}
+
+	// The raw item is written back, so encrypted and overflowed attributes stay as stored
+	record.Draft = false
+	if err := setContentHash(&record); err != nil {
+		return err
+	}
+	item := result.Item
+	delete(item, "draft")
+	item["contentHash"] = &types.AttributeValueMemberS{Value: record.ContentHash}
+	item["updatedAt"] = &types.AttributeValueMemberS{Value: syncTime(r.now())}
+	_, err = r.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(r.tableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_exists(draft) AND " + notMergedFilter + " AND #payload = :payload"),
+		ExpressionAttributeNames: map[string]string{
+			"#payload": payload,
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":payload": item[payload],
+		},
+	})
+	if err != nil {
+		var ccf *types.ConditionalCheckFailedException
+		if errors.As(err, &ccf) {
+			return fmt.Errorf("failed to publish location: location was modified concurrently")
+		}
+		return fmt.Errorf("failed to publish location: %w", err)
+	}
+	return nil
+}
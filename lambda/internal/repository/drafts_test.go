@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDynamoDBRepositoryListDrafts(t *testing.T) {
+	t.Run("Includes drafts when asked", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		mockClient.On("Query", ctx, mock.MatchedBy(func(input *dynamodb.QueryInput) bool {
+			return aws.ToString(input.FilterExpression) == notMergedFilter+" AND "+activeFilter
+		})).Return(&dynamodb.QueryOutput{}, nil).Once()
+
+		_, err := repo.List(ctx, "acc-12345", &ListOptions{IncludeDrafts: true})
+		require.NoError(t, err)
+		mockClient.AssertExpectations(t)
+	})
+}
+
+func TestDynamoDBRepositoryUpdateDraft(t *testing.T) {
+	coordinates := func(draft bool) models.CoordinatesLocation {
+		return models.CoordinatesLocation{
+			LocationBase: models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeCoordinates, Draft: draft},
+			Coordinates:  models.Coordinates{Latitude: 39.7817, Longitude: -89.6501},
+		}
+	}
+	storedItem := func(t *testing.T, draft bool) map[string]types.AttributeValue {
+		record, err := toLocationRecord(coordinates(draft), "loc-001")
+		require.NoError(t, err)
+		item, err := attributevalue.MarshalMap(record)
+		require.NoError(t, err)
+		return item
+	}
+
+	t.Run("Keeps a draft a draft", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		mockClient.On("PutItem", ctx, mock.MatchedBy(func(input *dynamodb.PutItemInput) bool {
+			_, draft := input.Item["draft"]
+			return draft && aws.ToString(input.ConditionExpression) ==
+				"attribute_exists(PK) AND attribute_exists(SK) AND PK = :accountId AND locationType = :locationType AND attribute_not_exists(mergedInto) AND attribute_not_exists(externalId) AND attribute_exists(draft)"
+		})).Return(&dynamodb.PutItemOutput{}, nil).Once()
+
+		require.NoError(t, repo.Update(ctx, coordinates(true), "loc-001"))
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Cannot publish a draft", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		mockClient.On("PutItem", ctx, mock.Anything).Return(nil, &types.ConditionalCheckFailedException{}).Once()
+		mockClient.On("GetItem", ctx, mock.Anything).Return(&dynamodb.GetItemOutput{Item: storedItem(t, true)}, nil).Once()
+
+		err := repo.Update(ctx, coordinates(false), "loc-001")
+		assert.EqualError(t, err, "validation failed: location is a draft; publish it with publishLocation")
+		mockClient.AssertNotCalled(t, "TransactWriteItems", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Cannot return a published location to draft", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		mockClient.On("PutItem", ctx, mock.Anything).Return(nil, &types.ConditionalCheckFailedException{}).Once()
+		mockClient.On("GetItem", ctx, mock.Anything).Return(&dynamodb.GetItemOutput{Item: storedItem(t, false)}, nil).Once()
+
+		err := repo.Update(ctx, coordinates(true), "loc-001")
+		assert.EqualError(t, err, "validation failed: a published location cannot become a draft")
+	})
+}
+
+func TestDynamoDBRepositoryPublish(t *testing.T) {
+	storedItem := func(t *testing.T, location models.Location) map[string]types.AttributeValue {
+		record, err := toLocationRecord(location, "loc-001")
+		require.NoError(t, err)
+		item, err := attributevalue.MarshalMap(record)
+		require.NoError(t, err)
+		return item
+	}
+	draft := models.ShopLocation{
+		LocationBase: models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeShop, Draft: true},
+		Shop: models.Shop{
+			Name:      "Corner Store",
+			ContactID: "contact-001",
+			Address:   models.Address{StreetAddress: "123 Main St", City: "Springfield", StateProvince: "IL", PostalCode: "62704", Country: "US"},
+		},
+	}
+
+	t.Run("Removes the draft marker from the stored item", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		mockClient.On("GetItem", ctx, mock.Anything).Return(&dynamodb.GetItemOutput{Item: storedItem(t, draft)}, nil).Once()
+		var put *dynamodb.PutItemInput
+		mockClient.On("PutItem", ctx, mock.Anything).Run(func(args mock.Arguments) {
+			put = args.Get(1).(*dynamodb.PutItemInput)
+		}).Return(&dynamodb.PutItemOutput{}, nil).Once()
+
+		require.NoError(t, repo.Publish(ctx, "acc-12345", "loc-001"))
+		assert.NotContains(t, put.Item, "draft")
+		assert.Equal(t, "attribute_exists(draft) AND "+notMergedFilter+" AND #payload = :payload", aws.ToString(put.ConditionExpression))
+		assert.Equal(t, "shop", put.ExpressionAttributeNames["#payload"])
+		assert.Equal(t, put.Item["shop"], put.ExpressionAttributeValues[":payload"])
+
+		var record locationRecord
+		require.NoError(t, attributevalue.UnmarshalMap(put.Item, &record))
+		assert.Equal(t, "Corner Store", record.Shop.Name)
+	})
+
+	t.Run("Rejects a published location", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		published := draft
+		published.Draft = false
+		mockClient.On("GetItem", ctx, mock.Anything).Return(&dynamodb.GetItemOutput{Item: storedItem(t, published)}, nil).Once()
+
+		err := repo.Publish(ctx, "acc-12345", "loc-001")
+		assert.EqualError(t, err, "location is already published")
+		mockClient.AssertNotCalled(t, "PutItem", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Rejects an invalid draft", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		invalid := draft
+		invalid.Shop.Name = ""
+		mockClient.On("GetItem", ctx, mock.Anything).Return(&dynamodb.GetItemOutput{Item: storedItem(t, invalid)}, nil).Once()
+
+		err := repo.Publish(ctx, "acc-12345", "loc-001")
+		assert.ErrorContains(t, err, "validation failed")
+		mockClient.AssertNotCalled(t, "PutItem", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Fails for unknown locations", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		mockClient.On("GetItem", ctx, mock.Anything).Return(&dynamodb.GetItemOutput{}, nil).Once()
+
+		err := repo.Publish(ctx, "acc-12345", "loc-001")
+		assert.EqualError(t, err, "location not found or access denied")
+	})
+
+	t.Run("Fails when the draft changed concurrently", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		mockClient.On("GetItem", ctx, mock.Anything).Return(&dynamodb.GetItemOutput{Item: storedItem(t, draft)}, nil).Once()
+		mockClient.On("PutItem", ctx, mock.Anything).Return(nil, &types.ConditionalCheckFailedException{}).Once()
+
+		err := repo.Publish(ctx, "acc-12345", "loc-001")
+		assert.EqualError(t, err, "failed to publish location: location was modified concurrently")
+	})
+}
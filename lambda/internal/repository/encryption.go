@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	kmstypes "github.com/aws/aws-sdk-go-v2/service/kms/types"
+)
+
+// encryptedValuePrefix marks an extended attribute value encrypted by the repository.
+const encryptedValuePrefix = "enc:v1:"
+
+// EncryptionConfig configures client-side envelope encryption of extended attributes.
+type EncryptionConfig struct {
+	// KeyID is the KMS key used to generate per-record data keys.
+	KeyID string
+	// Attributes lists the extendedAttributes keys to encrypt.
+	Attributes []string
+}
+
+// ParseEncryptedAttributes splits a comma-separated list of extendedAttributes keys.
+func ParseEncryptedAttributes(attributes string) []string {
+	var keys []string
+	for _, key := range strings.Split(attributes, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// encryptionEnabled reports whether client-side encryption is configured.
+func (r *DynamoDBRepository) encryptionEnabled() bool {
+	return r.kmsClient != nil && r.encryption.KeyID != "" && len(r.encryption.Attributes) > 0
+}
+
+// encryptionContext binds ciphertext to the record it belongs to.
+func encryptionContext(accountID, locationID string) map[string]string {
+	return map[string]string{
+		"accountId":  accountID,
+		"locationId": locationID,
+	}
+}
+
+// encryptAttributes encrypts the configured extended attributes of a record
+// with a fresh KMS data key, storing the wrapped key on the record.
+func (r *DynamoDBRepository) encryptAttributes(ctx context.Context, record *locationRecord) error {
+	if !r.encryptionEnabled() || !hasAnyKey(record.ExtendedAttributes, r.encryption.Attributes) {
+		return nil
+	}
+
+	dataKey, err := r.kmsClient.GenerateDataKey(ctx, &kms.GenerateDataKeyInput{
+		KeyId:             aws.String(r.encryption.KeyID),
+		KeySpec:           kmstypes.DataKeySpecAes256,
+		EncryptionContext: encryptionContext(record.PK, record.SK),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to generate data key: %w", err)
+	}
+
+	gcm, err := newGCM(dataKey.Plaintext)
+	if err != nil {
+		return err
+	}
+
+	// Copy the map so the caller's location is left untouched
+	attrs := make(map[string]interface{}, len(record.ExtendedAttributes))
+	for key, value := range record.ExtendedAttributes {
+		attrs[key] = value
+	}
+
+	for _, key := range r.encryption.Attributes {
+		value, ok := attrs[key]
+		if !ok {
+			continue
+		}
+		plaintext, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("failed to marshal attribute %s: %w", key, err)
+		}
+		nonce := make([]byte, gcm.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return fmt.Errorf("failed to generate nonce: %w", err)
+		}
+		sealed := gcm.Seal(nonce, nonce, plaintext, []byte(key))
+		attrs[key] = encryptedValuePrefix + base64.StdEncoding.EncodeToString(sealed)
+	}
+
+	record.ExtendedAttributes = attrs
+	record.EncryptedDataKey = dataKey.CiphertextBlob
+	return nil
+}
+
+// decryptAttributes reverses encryptAttributes for a record read from DynamoDB.
+func (r *DynamoDBRepository) decryptAttributes(ctx context.Context, record *locationRecord) error {
+	if len(record.EncryptedDataKey) == 0 {
+		return nil
+	}
+	if r.kmsClient == nil {
+		return fmt.Errorf("location has encrypted attributes but encryption is not configured")
+	}
+
+	dataKey, err := r.kmsClient.Decrypt(ctx, &kms.DecryptInput{
+		CiphertextBlob:    record.EncryptedDataKey,
+		EncryptionContext: encryptionContext(record.PK, record.SK),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to decrypt data key: %w", err)
+	}
+
+	gcm, err := newGCM(dataKey.Plaintext)
+	if err != nil {
+		return err
+	}
+
+	for key, value := range record.ExtendedAttributes {
+		encoded, ok := value.(string)
+		if !ok || !strings.HasPrefix(encoded, encryptedValuePrefix) {
+			continue
+		}
+		sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(encoded, encryptedValuePrefix))
+		if err != nil || len(sealed) < gcm.NonceSize() {
+			return fmt.Errorf("malformed encrypted attribute %s", key)
+		}
+		plaintext, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], []byte(key))
+		if err != nil {
+			return fmt.Errorf("failed to decrypt attribute %s: %w", key, err)
+		}
+		var decoded interface{}
+		if err := json.Unmarshal(plaintext, &decoded); err != nil {
+			return fmt.Errorf("failed to unmarshal attribute %s: %w", key, err)
+		}
+		record.ExtendedAttributes[key] = decoded
+	}
+
+	record.EncryptedDataKey = nil
+	return nil
+}
+
+// newGCM creates an AES-GCM cipher from a data key.
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+	return gcm, nil
+}
+
+// hasAnyKey reports whether m contains any of keys.
+func hasAnyKey(m map[string]interface{}, keys []string) bool {
+	for _, key := range keys {
+		if _, ok := m[key]; ok {
+			return true
+		}
+	}
+	return false
+}
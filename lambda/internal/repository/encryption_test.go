@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockKMSClient is a mock implementation of the KMS client.
+type mockKMSClient struct {
+	mock.Mock
+}
+
+func (m *mockKMSClient) GenerateDataKey(ctx context.Context, params *kms.GenerateDataKeyInput, optFns ...func(*kms.Options)) (*kms.GenerateDataKeyOutput, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*kms.GenerateDataKeyOutput), args.Error(1)
+}
+
+func (m *mockKMSClient) Decrypt(ctx context.Context, params *kms.DecryptInput, optFns ...func(*kms.Options)) (*kms.DecryptOutput, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*kms.DecryptOutput), args.Error(1)
+}
+
+func TestParseEncryptedAttributes(t *testing.T) {
+	assert.Equal(t, []string{"email", "phone"}, ParseEncryptedAttributes(" email, ,phone "))
+	assert.Empty(t, ParseEncryptedAttributes(""))
+}
+
+func TestDynamoDBRepositoryEncryption(t *testing.T) {
+	ctx := context.Background()
+	dataKey := []byte("0123456789abcdef0123456789abcdef")
+	wrappedKey := []byte("wrapped-data-key")
+
+	mockClient := new(mockDynamoDBClient)
+	mockKMS := new(mockKMSClient)
+	repo := NewDynamoDBRepository(mockClient, "test-table",
+		WithEncryption(mockKMS, EncryptionConfig{KeyID: "alias/locations", Attributes: []string{"email"}}))
+
+	attrs := map[string]interface{}{"email": "owner@example.com", "color": "blue"}
+	location := models.CoordinatesLocation{
+		LocationBase: models.LocationBase{
+			AccountID:          "acc-12345",
+			LocationType:       models.LocationTypeCoordinates,
+			ExtendedAttributes: attrs,
+		},
+		Coordinates: models.Coordinates{Latitude: 40.7128, Longitude: -74.0060},
+	}
+
+	var stored map[string]types.AttributeValue
+	mockKMS.On("GenerateDataKey", ctx, mock.MatchedBy(func(input *kms.GenerateDataKeyInput) bool {
+		return input.EncryptionContext["accountId"] == "acc-12345"
+	})).Return(&kms.GenerateDataKeyOutput{Plaintext: dataKey, CiphertextBlob: wrappedKey}, nil).Once()
+	mockClient.On("PutItem", ctx, mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(1).(*dynamodb.PutItemInput).Item
+	}).Return(&dynamodb.PutItemOutput{}, nil).Once()
+
+	created, err := repo.Create(ctx, location)
+	require.NoError(t, err)
+	locationID := created.LocationID
+	assert.Equal(t, "owner@example.com", created.Location.GetExtendedAttributes()["email"], "returned location must be plaintext")
+
+	// The stored email is ciphertext while other attributes stay readable
+	ext := stored["extendedAttributes"].(*types.AttributeValueMemberM).Value
+	email := ext["email"].(*types.AttributeValueMemberS).Value
+	assert.True(t, strings.HasPrefix(email, encryptedValuePrefix))
+	assert.NotContains(t, email, "owner@example.com")
+	assert.Equal(t, "blue", ext["color"].(*types.AttributeValueMemberS).Value)
+	assert.Equal(t, "owner@example.com", attrs["email"], "caller's attributes must not be modified")
+
+	mockClient.On("GetItem", ctx, mock.Anything).Return(&dynamodb.GetItemOutput{Item: stored}, nil).Once()
+	mockKMS.On("Decrypt", ctx, mock.MatchedBy(func(input *kms.DecryptInput) bool {
+		return string(input.CiphertextBlob) == string(wrappedKey) && input.EncryptionContext["locationId"] == locationID
+	})).Return(&kms.DecryptOutput{Plaintext: dataKey}, nil).Once()
+
+	fetched, err := repo.Get(ctx, "acc-12345", locationID)
+	require.NoError(t, err)
+	assert.Equal(t, "owner@example.com", fetched.Location.GetExtendedAttributes()["email"])
+	assert.Equal(t, "blue", fetched.Location.GetExtendedAttributes()["color"])
+	mockClient.AssertExpectations(t)
+	mockKMS.AssertExpectations(t)
+}
+
+func TestDynamoDBRepositoryEncryptionSkipsRecordsWithoutSensitiveAttributes(t *testing.T) {
+	ctx := context.Background()
+	mockClient := new(mockDynamoDBClient)
+	mockKMS := new(mockKMSClient)
+	repo := NewDynamoDBRepository(mockClient, "test-table",
+		WithEncryption(mockKMS, EncryptionConfig{KeyID: "alias/locations", Attributes: []string{"email"}}))
+
+	location := models.CoordinatesLocation{
+		LocationBase: models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeCoordinates},
+		Coordinates:  models.Coordinates{Latitude: 40.7128, Longitude: -74.0060},
+	}
+
+	mockClient.On("PutItem", ctx, mock.MatchedBy(func(input *dynamodb.PutItemInput) bool {
+		_, ok := input.Item["encryptedDataKey"]
+		return !ok
+	})).Return(&dynamodb.PutItemOutput{}, nil).Once()
+
+	_, err := repo.Create(ctx, location)
+	require.NoError(t, err)
+	mockKMS.AssertNotCalled(t, "GenerateDataKey", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,239 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// erasurePKPrefix keeps erasure certificates out of an account's location partition.
+const erasurePKPrefix = "ERASURE#"
+
+// ErasureCertificate records the permanent erasure of a location's data.
+type ErasureCertificate struct {
+	CertificateID  string    `json:"certificateId" dynamodbav:"certificateId"`
+	AccountID      string    `json:"accountId" dynamodbav:"accountId"`
+	LocationID     string    `json:"locationId" dynamodbav:"locationId"`
+	ErasedAt       time.Time `json:"erasedAt" dynamodbav:"erasedAt"`
+	RecordErased   bool      `json:"recordErased" dynamodbav:"recordErased"`     // False when no record existed
+	OverflowErased bool      `json:"overflowErased" dynamodbav:"overflowErased"` // True when an S3 overflow payload was removed
+	// PositionsErased, StopsErased, and ProposalsErased count the recorded
+	// positions, detected stops, and change proposals of the location that
+	// were removed
+	PositionsErased int `json:"positionsErased" dynamodbav:"positionsErased"`
+	StopsErased     int `json:"stopsErased" dynamodbav:"stopsErased"`
+	ProposalsErased int `json:"proposalsErased" dynamodbav:"proposalsErased"`
+	// True when the location's presence in its account's geofences was removed
+	GeofencePresenceErased bool `json:"geofencePresenceErased" dynamodbav:"geofencePresenceErased"`
+}
+
+// erasureRecord is the DynamoDB item holding an erasure certificate.
+type erasureRecord struct {
+	PK string `dynamodbav:"PK"` // ERASURE#accountId
+	SK string `dynamodbav:"SK"` // certificateId
+	ErasureCertificate
+}
+
+// Erase permanently removes a location and every stored copy of its data, then
+// writes an erasure certificate. It is safe to retry: erasing a location that no
+// longer exists still produces a certificate.
+func (r *DynamoDBRepository) Erase(ctx context.Context, accountID, locationID string) (*ErasureCertificate, error) {
+	cert := &ErasureCertificate{
+		CertificateID: r.ids.NewID(),
+		AccountID:     accountID,
+		LocationID:    locationID,
+	}
+
+	// Remove the overflow payloads first, found by their keys rather than the
+	// record, so a failure here leaves the record in place for a retry to find.
+	if r.overflowEnabled() {
+		if err := r.eraseOverflowVersions(ctx, accountID, locationID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Position history and detected stops hold the location's whereabouts;
+	// stops never expire. They too go before the record, for a retry to redo.
+	positions, err := r.eraseItems(ctx, "recorded positions", &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		KeyConditionExpression: aws.String("PK = :pk"),
+		FilterExpression:       aws.String("locationId = :locationId"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk":         &types.AttributeValueMemberS{Value: positionPKPrefix + accountID},
+			":locationId": &types.AttributeValueMemberS{Value: locationID},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	cert.PositionsErased = positions
+	stops, err := r.eraseItems(ctx, "detected stops", &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		KeyConditionExpression: aws.String("PK = :pk AND begins_with(SK, :location)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk":       &types.AttributeValueMemberS{Value: stopPKPrefix + accountID},
+			":location": &types.AttributeValueMemberS{Value: locationID + "#"},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	cert.StopsErased = stops
+	// Proposals hold whole proposed versions of the location, reviewed or not
+	proposals, err := r.eraseItems(ctx, "change proposals", &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		KeyConditionExpression: aws.String("PK = :pk"),
+		FilterExpression:       aws.String("locationId = :locationId"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk":         &types.AttributeValueMemberS{Value: proposalPKPrefix + accountID},
+			":locationId": &types.AttributeValueMemberS{Value: locationID},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	cert.ProposalsErased = proposals
+	// Geofence presence records which geofences the location was last inside
+	presence, err := r.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName:    aws.String(r.tableName),
+		Key:          geofencePresenceKey(accountID, locationID),
+		ReturnValues: types.ReturnValueAllOld,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to erase geofence presence: %w", err)
+	}
+	cert.GeofencePresenceErased = presence != nil && len(presence.Attributes) > 0
+
+	result, err := r.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName:    aws.String(r.tableName),
+		Key:          r.locationKey(accountID, locationID),
+		ReturnValues: types.ReturnValueAllOld,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to erase location: %w", err)
+	}
+
+	if result != nil && len(result.Attributes) > 0 {
+		cert.RecordErased = true
+		// The stored reference lies elsewhere when the key prefix has since changed
+		if ref := overflowRef(result.Attributes); ref != "" {
+			if !strings.HasPrefix(ref, r.overflow.overflowPrefix(accountID, locationID)) && ref != r.overflow.legacyOverflowKey(accountID, locationID) {
+				if err := r.eraseOverflow(ctx, ref); err != nil {
+					return nil, err
+				}
+			}
+			cert.OverflowErased = true
+		}
+		if externalID := externalIDOf(result.Attributes); externalID != "" {
+			if err := r.releaseExternalID(ctx, accountID, externalID, locationID); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Syncing clients hold copies of the location too, which they drop on
+	// seeing its tombstone
+	if err := r.recordDeletion(ctx, accountID, locationID); err != nil {
+		return nil, err
+	}
+
+	cert.ErasedAt = r.now().UTC()
+	item, err := attributevalue.MarshalMap(erasureRecord{
+		PK:                 erasurePKPrefix + accountID,
+		SK:                 cert.CertificateID,
+		ErasureCertificate: *cert,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal erasure certificate: %w", err)
+	}
+
+	_, err = r.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(r.tableName),
+		Item:      item,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to write erasure certificate: %w", err)
+	}
+
+	return cert, nil
+}
+
+// eraseItems deletes every item input finds, page by page, returning how
+// many it deleted. Positions are keyed by time before location, so finding a
+// location's means reading the account's whole retained history.
+func (r *DynamoDBRepository) eraseItems(ctx context.Context, what string, input *dynamodb.QueryInput) (int, error) {
+	input.ProjectionExpression = aws.String("PK, SK")
+	erased := 0
+	for {
+		result, err := r.client.Query(ctx, input)
+		if err != nil {
+			return erased, fmt.Errorf("failed to find %s to erase: %w", what, err)
+		}
+		for _, item := range result.Items {
+			_, err := r.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+				TableName: aws.String(r.tableName),
+				Key:       map[string]types.AttributeValue{"PK": item["PK"], "SK": item["SK"]},
+			})
+			if err != nil {
+				return erased, fmt.Errorf("failed to erase %s: %w", what, err)
+			}
+			erased++
+		}
+		if result.LastEvaluatedKey == nil {
+			return erased, nil
+		}
+		input.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+}
+
+// eraseOverflowVersions deletes every stored version of a location's overflow
+// payload, including the unversioned key older records used and versions a
+// failed cleanup left behind.
+func (r *DynamoDBRepository) eraseOverflowVersions(ctx context.Context, accountID, locationID string) error {
+	if err := r.eraseOverflow(ctx, r.overflow.legacyOverflowKey(accountID, locationID)); err != nil {
+		return err
+	}
+
+	input := &s3.ListObjectsV2Input{
+		Bucket: aws.String(r.overflow.Bucket),
+		Prefix: aws.String(r.overflow.overflowPrefix(accountID, locationID)),
+	}
+	for {
+		out, err := r.s3Client.ListObjectsV2(ctx, input)
+		if err != nil {
+			return fmt.Errorf("failed to list overflow payloads: %w", err)
+		}
+		for _, object := range out.Contents {
+			if err := r.eraseOverflow(ctx, aws.ToString(object.Key)); err != nil {
+				return err
+			}
+		}
+		if !aws.ToBool(out.IsTruncated) {
+			return nil
+		}
+		input.ContinuationToken = out.NextContinuationToken
+	}
+}
+
+// eraseOverflow deletes an overflow payload, returning any failure so erasure
+// is never reported complete while data remains.
+func (r *DynamoDBRepository) eraseOverflow(ctx context.Context, key string) error {
+	if !r.overflowEnabled() {
+		return fmt.Errorf("location has overflow payload but overflow storage is not configured")
+	}
+	_, err := r.s3Client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(r.overflow.Bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to erase overflow payload: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDynamoDBRepositoryErase(t *testing.T) {
+	t.Run("Erases record and overflow and writes certificate", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		mockS3 := new(mockS3Client)
+		repo := NewDynamoDBRepository(mockClient, "test-table",
+			WithOverflow(mockS3, OverflowConfig{Bucket: "overflow-bucket", KeyPrefix: "overflow/"}))
+
+		// The unversioned key of older records, then every listed version
+		mockS3.On("DeleteObject", ctx, mock.MatchedBy(func(input *s3.DeleteObjectInput) bool {
+			return aws.ToString(input.Key) == "overflow/acc-12345/loc-001.json"
+		})).Return(&s3.DeleteObjectOutput{}, nil).Once()
+		mockS3.On("ListObjectsV2", ctx, mock.MatchedBy(func(input *s3.ListObjectsV2Input) bool {
+			return aws.ToString(input.Prefix) == "overflow/acc-12345/loc-001/"
+		})).Return(&s3.ListObjectsV2Output{Contents: []s3types.Object{
+			{Key: aws.String("overflow/acc-12345/loc-001/v1.json")},
+			{Key: aws.String("overflow/acc-12345/loc-001/v2.json")},
+		}}, nil).Once()
+		for _, key := range []string{"overflow/acc-12345/loc-001/v1.json", "overflow/acc-12345/loc-001/v2.json"} {
+			key := key
+			mockS3.On("DeleteObject", ctx, mock.MatchedBy(func(input *s3.DeleteObjectInput) bool {
+				return aws.ToString(input.Key) == key
+			})).Return(&s3.DeleteObjectOutput{}, nil).Once()
+		}
+		// The location's pings are found among the account's, its stops by prefix
+		keyItem := func(pk, sk string) map[string]types.AttributeValue {
+			return map[string]types.AttributeValue{"PK": &types.AttributeValueMemberS{Value: pk}, "SK": &types.AttributeValueMemberS{Value: sk}}
+		}
+		mockClient.On("Query", ctx, mock.MatchedBy(func(input *dynamodb.QueryInput) bool {
+			return input.ExpressionAttributeValues[":pk"].(*types.AttributeValueMemberS).Value == "POSITION#acc-12345" &&
+				aws.ToString(input.FilterExpression) == "locationId = :locationId" &&
+				input.ExpressionAttributeValues[":locationId"].(*types.AttributeValueMemberS).Value == "loc-001"
+		})).Return(&dynamodb.QueryOutput{Items: []map[string]types.AttributeValue{
+			keyItem("POSITION#acc-12345", "2024-05-01T12:00:00Z#loc-001"),
+			keyItem("POSITION#acc-12345", "2024-05-01T12:05:00Z#loc-001"),
+		}}, nil).Once()
+		mockClient.On("Query", ctx, mock.MatchedBy(func(input *dynamodb.QueryInput) bool {
+			return input.ExpressionAttributeValues[":pk"].(*types.AttributeValueMemberS).Value == "STOP#acc-12345" &&
+				input.ExpressionAttributeValues[":location"].(*types.AttributeValueMemberS).Value == "loc-001#"
+		})).Return(&dynamodb.QueryOutput{Items: []map[string]types.AttributeValue{
+			keyItem("STOP#acc-12345", "loc-001#2024-05-01T12:00:00Z"),
+		}}, nil).Once()
+		mockClient.On("Query", ctx, mock.MatchedBy(func(input *dynamodb.QueryInput) bool {
+			return input.ExpressionAttributeValues[":pk"].(*types.AttributeValueMemberS).Value == "PROPOSAL#acc-12345" &&
+				aws.ToString(input.FilterExpression) == "locationId = :locationId" &&
+				input.ExpressionAttributeValues[":locationId"].(*types.AttributeValueMemberS).Value == "loc-001"
+		})).Return(&dynamodb.QueryOutput{Items: []map[string]types.AttributeValue{
+			keyItem("PROPOSAL#acc-12345", "prop-001"),
+		}}, nil).Once()
+		erasedKeys := []string{}
+		mockClient.On("DeleteItem", ctx, mock.MatchedBy(func(input *dynamodb.DeleteItemInput) bool {
+			return input.ReturnValues == ""
+		})).Run(func(args mock.Arguments) {
+			key := args.Get(1).(*dynamodb.DeleteItemInput).Key
+			erasedKeys = append(erasedKeys, key["PK"].(*types.AttributeValueMemberS).Value+" "+key["SK"].(*types.AttributeValueMemberS).Value)
+		}).Return(&dynamodb.DeleteItemOutput{}, nil).Times(4)
+		mockClient.On("DeleteItem", ctx, mock.MatchedBy(func(input *dynamodb.DeleteItemInput) bool {
+			return input.Key["PK"].(*types.AttributeValueMemberS).Value == "GEOFENCE_PRESENCE#acc-12345" &&
+				input.Key["SK"].(*types.AttributeValueMemberS).Value == "loc-001"
+		})).Return(&dynamodb.DeleteItemOutput{Attributes: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: "GEOFENCE_PRESENCE#acc-12345"},
+			"SK": &types.AttributeValueMemberS{Value: "loc-001"},
+		}}, nil).Once()
+		mockClient.On("DeleteItem", ctx, mock.MatchedBy(func(input *dynamodb.DeleteItemInput) bool {
+			return input.ConditionExpression == nil && input.ReturnValues == types.ReturnValueAllOld &&
+				input.Key["PK"].(*types.AttributeValueMemberS).Value == "acc-12345"
+		})).Return(&dynamodb.DeleteItemOutput{Attributes: map[string]types.AttributeValue{
+			"PK":                    &types.AttributeValueMemberS{Value: "acc-12345"},
+			"SK":                    &types.AttributeValueMemberS{Value: "loc-001"},
+			"extendedAttributesRef": &types.AttributeValueMemberS{Value: "overflow/acc-12345/loc-001/v2.json"},
+		}}, nil).Once()
+
+		var certItem map[string]types.AttributeValue
+		mockClient.On("PutItem", ctx, mock.Anything).Run(func(args mock.Arguments) {
+			certItem = args.Get(1).(*dynamodb.PutItemInput).Item
+		}).Return(&dynamodb.PutItemOutput{}, nil).Once()
+
+		cert, err := repo.Erase(ctx, "acc-12345", "loc-001")
+		require.NoError(t, err)
+
+		assert.True(t, cert.RecordErased)
+		assert.True(t, cert.OverflowErased)
+		assert.Equal(t, 2, cert.PositionsErased)
+		assert.Equal(t, 1, cert.StopsErased)
+		assert.Equal(t, 1, cert.ProposalsErased)
+		assert.True(t, cert.GeofencePresenceErased)
+		assert.Equal(t, []string{
+			"POSITION#acc-12345 2024-05-01T12:00:00Z#loc-001",
+			"POSITION#acc-12345 2024-05-01T12:05:00Z#loc-001",
+			"STOP#acc-12345 loc-001#2024-05-01T12:00:00Z",
+			"PROPOSAL#acc-12345 prop-001",
+		}, erasedKeys)
+		assert.NotEmpty(t, cert.CertificateID)
+		assert.False(t, cert.ErasedAt.IsZero())
+		assert.Equal(t, "ERASURE#acc-12345", certItem["PK"].(*types.AttributeValueMemberS).Value)
+		assert.Equal(t, cert.CertificateID, certItem["SK"].(*types.AttributeValueMemberS).Value)
+		assert.Equal(t, "loc-001", certItem["locationId"].(*types.AttributeValueMemberS).Value)
+		mockClient.AssertExpectations(t)
+		mockS3.AssertExpectations(t)
+	})
+
+	t.Run("Missing record still certified", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		mockClient.On("Query", ctx, mock.Anything).Return(&dynamodb.QueryOutput{}, nil).Times(3)
+		mockClient.On("DeleteItem", ctx, mock.Anything).Return(&dynamodb.DeleteItemOutput{}, nil).Times(2)
+		mockClient.On("PutItem", ctx, mock.Anything).Return(&dynamodb.PutItemOutput{}, nil).Once()
+
+		cert, err := repo.Erase(ctx, "acc-12345", "loc-001")
+		require.NoError(t, err)
+		assert.False(t, cert.RecordErased)
+		assert.False(t, cert.OverflowErased)
+		assert.False(t, cert.GeofencePresenceErased)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Overflow failure leaves record for retry", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		mockS3 := new(mockS3Client)
+		repo := NewDynamoDBRepository(mockClient, "test-table",
+			WithOverflow(mockS3, OverflowConfig{Bucket: "overflow-bucket"}))
+
+		mockS3.On("DeleteObject", ctx, mock.Anything).Return(nil, errors.New("access denied")).Once()
+
+		_, err := repo.Erase(ctx, "acc-12345", "loc-001")
+		assert.EqualError(t, err, "failed to erase overflow payload: access denied")
+		mockClient.AssertNotCalled(t, "Query", mock.Anything, mock.Anything)
+		mockClient.AssertNotCalled(t, "DeleteItem", mock.Anything, mock.Anything)
+		mockClient.AssertNotCalled(t, "PutItem", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Stop failure leaves record for retry", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		mockClient.On("Query", ctx, mock.MatchedBy(func(input *dynamodb.QueryInput) bool {
+			return input.FilterExpression != nil
+		})).Return(&dynamodb.QueryOutput{}, nil).Once()
+		mockClient.On("Query", ctx, mock.Anything).Return(nil, errors.New("throttled")).Once()
+
+		_, err := repo.Erase(ctx, "acc-12345", "loc-001")
+		assert.EqualError(t, err, "failed to find detected stops to erase: throttled")
+		mockClient.AssertNotCalled(t, "DeleteItem", mock.Anything, mock.Anything)
+		mockClient.AssertNotCalled(t, "PutItem", mock.Anything, mock.Anything)
+	})
+}
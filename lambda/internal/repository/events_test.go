@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDynamoDBRepositoryListActiveOn(t *testing.T) {
+	ctx := context.Background()
+	mockClient := new(mockDynamoDBClient)
+	repo := NewDynamoDBRepository(mockClient, "test-table")
+
+	var query *dynamodb.QueryInput
+	mockClient.On("Query", ctx, mock.Anything).Run(func(args mock.Arguments) {
+		query = args.Get(1).(*dynamodb.QueryInput)
+	}).Return(&dynamodb.QueryOutput{}, nil).Once()
+
+	activeOn := time.Date(2024, time.June, 4, 15, 30, 0, 0, time.UTC)
+	_, err := repo.List(ctx, "acc-12345", &ListOptions{ActiveOn: &activeOn})
+	require.NoError(t, err)
+	assert.Equal(t, notMergedFilter+" AND "+listedFilter+" AND locationType = :eventType AND startsAt < :dayEnd AND endsAt > :dayStart", aws.ToString(query.FilterExpression))
+	assert.Equal(t, &types.AttributeValueMemberS{Value: "event"}, query.ExpressionAttributeValues[":eventType"])
+	assert.Equal(t, &types.AttributeValueMemberS{Value: "2024-06-04T00:00:00Z"}, query.ExpressionAttributeValues[":dayStart"])
+	assert.Equal(t, &types.AttributeValueMemberS{Value: "2024-06-05T00:00:00Z"}, query.ExpressionAttributeValues[":dayEnd"])
+}
+
+func TestLocationRecordEvent(t *testing.T) {
+	event := models.EventLocation{
+		LocationBase: models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeEvent},
+		Address:      &models.Address{StreetAddress: "2301 S Lake Shore Dr", City: "Chicago", PostalCode: "60616", Country: "US"},
+		StartsAt:     time.Date(2024, time.June, 3, 15, 0, 0, 0, time.UTC),
+		EndsAt:       time.Date(2024, time.June, 5, 23, 0, 0, 0, time.UTC),
+		Recurrence:   &models.Recurrence{Rule: "FREQ=DAILY", TimeZone: "America/Chicago", StartDate: "2024-06-03", StartTime: "10:00", EndTime: "18:00"},
+	}
+
+	record, err := toLocationRecord(event, "loc-001")
+	require.NoError(t, err)
+	assert.Equal(t, "2024-06-03T15:00:00Z", record.StartsAt)
+	assert.Equal(t, "2024-06-05T23:00:00Z", record.EndsAt)
+	assert.Equal(t, event.Address, record.Address)
+
+	item, err := attributevalue.MarshalMap(record)
+	require.NoError(t, err)
+	var stored locationRecord
+	require.NoError(t, attributevalue.UnmarshalMap(item, &stored))
+	restored, err := stored.toLocation()
+	require.NoError(t, err)
+	assert.Equal(t, event, restored)
+
+	record.EndsAt = ""
+	_, err = record.toLocation()
+	assert.ErrorContains(t, err, "startsAt and endsAt are required")
+}
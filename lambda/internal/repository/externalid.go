@@ -0,0 +1,418 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/steverhoton/location-lambda/internal/filterable"
+	"github.com/steverhoton/location-lambda/internal/models"
+)
+
+// externalIDPKPrefix keeps external ID claims out of an account's location partition.
+const externalIDPKPrefix = "EXTERNALID#"
+
+// ExternalIDConflictError is returned when another location in the account
+// already holds an external ID.
+type ExternalIDConflictError struct {
+	ExternalID string
+	LocationID string // The location holding the external ID
+}
+
+func (e *ExternalIDConflictError) Error() string {
+	return fmt.Sprintf("externalId %q is already used by location %s", e.ExternalID, e.LocationID)
+}
+
+// UpsertResult reports the location written by an upsert and whether it was created.
+type UpsertResult struct {
+	LocationID string `json:"locationId"`
+	Created    bool   `json:"created"`
+	// ValidationWarnings lists the optional fields the handler's lenient
+	// validation dropped before the upsert
+	ValidationWarnings models.ValidationErrors `json:"validationWarnings,omitempty"`
+	// FilterableFields are the handler's subscription filter fields of the location
+	FilterableFields *filterable.Fields `json:"filterableFields,omitempty"`
+}
+
+// externalIDRecord is the DynamoDB item claiming an external ID for one location.
+// Writes that set or change a location's external ID write its claim in the same
+// transaction, so two locations in an account never share one.
+type externalIDRecord struct {
+	PK         string `dynamodbav:"PK"` // EXTERNALID#accountId
+	SK         string `dynamodbav:"SK"` // externalId
+	LocationID string `dynamodbav:"locationId"`
+}
+
+// externalIDKey returns the primary key of an external ID claim.
+func externalIDKey(accountID, externalID string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberS{Value: externalIDPKPrefix + accountID},
+		"SK": &types.AttributeValueMemberS{Value: externalID},
+	}
+}
+
+// externalIDIndexKey returns the external ID index key of a record, or an
+// empty string when it has no external ID, keeping the index sparse.
+func externalIDIndexKey(accountID, externalID string) string {
+	if externalID == "" {
+		return ""
+	}
+	return accountIndexKey(accountID, externalID)
+}
+
+// externalIDOf returns the external ID stored on a raw DynamoDB item, if any.
+func externalIDOf(item map[string]types.AttributeValue) string {
+	if v, ok := item["externalId"].(*types.AttributeValueMemberS); ok {
+		return v.Value
+	}
+	return ""
+}
+
+// sameExternalID returns a condition that the stored record has externalID,
+// adding its value to values.
+func sameExternalID(externalID string, values map[string]types.AttributeValue) string {
+	if externalID == "" {
+		return "attribute_not_exists(externalId)"
+	}
+	values[":externalId"] = &types.AttributeValueMemberS{Value: externalID}
+	return "externalId = :externalId"
+}
+
+// Upsert creates the location, or replaces the location already holding its
+// external ID. Integrations can repeat an upsert safely with their own identifiers.
+func (r *DynamoDBRepository) Upsert(ctx context.Context, location models.Location) (*UpsertResult, error) {
+	accountID, externalID := location.GetAccountID(), location.GetExternalID()
+	if externalID == "" {
+		return nil, fmt.Errorf("validation failed: externalId is required")
+	}
+	if err := location.Validate(); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	// A concurrent upsert can claim the external ID between the lookup and the
+	// create; the second attempt then updates the location it created.
+	for attempt := 0; ; attempt++ {
+		locationID, err := r.getExternalIDClaim(ctx, accountID, externalID)
+		if err != nil {
+			return nil, err
+		}
+		if locationID != "" {
+			_, err := r.getRecord(ctx, accountID, locationID)
+			if err == nil {
+				if err := r.Update(ctx, location, locationID); err != nil {
+					return nil, err
+				}
+				return &UpsertResult{LocationID: locationID}, nil
+			}
+			// A claim can outlive its location if a delete failed to release it;
+			// Create takes such claims over
+			if !errors.Is(err, errLocationNotFound) {
+				return nil, err
+			}
+		}
+
+		created, err := r.Create(ctx, location)
+		var conflict *ExternalIDConflictError
+		if errors.As(err, &conflict) && attempt == 0 {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &UpsertResult{LocationID: created.LocationID, Created: true}, nil
+	}
+}
+
+// GetByExternalID returns the ID and location holding externalID in an account.
+// With an external ID index it is a single GSI query, which is eventually
+// consistent; otherwise the external ID claim is read and then the location.
+func (r *DynamoDBRepository) GetByExternalID(ctx context.Context, accountID, externalID string) (*LocationEnvelope, error) {
+	if accountID == "" || externalID == "" {
+		return nil, fmt.Errorf("accountId and externalId are required")
+	}
+
+	var record *locationRecord
+	if r.externalIDIndex != "" {
+		var err error
+		if record, err = r.queryExternalIDIndex(ctx, accountID, externalID); err != nil {
+			return nil, err
+		}
+	} else {
+		locationID, err := r.getExternalIDClaim(ctx, accountID, externalID)
+		if err != nil {
+			return nil, err
+		}
+		if locationID == "" {
+			return nil, errLocationNotFound
+		}
+		if record, err = r.getRecord(ctx, accountID, locationID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := r.hydrateRecord(ctx, record); err != nil {
+		return nil, err
+	}
+	return record.toEnvelope()
+}
+
+// queryExternalIDIndex reads the record holding externalID from the external ID index.
+func (r *DynamoDBRepository) queryExternalIDIndex(ctx context.Context, accountID, externalID string) (*locationRecord, error) {
+	result, err := r.client.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		IndexName:              aws.String(r.externalIDIndex),
+		KeyConditionExpression: aws.String("accountExternalId = :key"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":key": &types.AttributeValueMemberS{Value: externalIDIndexKey(accountID, externalID)},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query external ID index: %w", err)
+	}
+
+	// Merged duplicates keep their external ID; report the survivor when only a tombstone matches
+	var merged *locationRecord
+	for _, item := range result.Items {
+		var record locationRecord
+		if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal location: %w", err)
+		}
+		// Keys written before account IDs were escaped can still collide
+		if record.PK != accountID {
+			continue
+		}
+		if record.MergedInto == "" {
+			return &record, nil
+		}
+		merged = &record
+	}
+	if merged != nil {
+		return nil, &MergedError{SurvivorID: merged.MergedInto}
+	}
+	return nil, errLocationNotFound
+}
+
+// getExternalIDClaim returns the ID of the location holding externalID, or "" when it is unclaimed.
+func (r *DynamoDBRepository) getExternalIDClaim(ctx context.Context, accountID, externalID string) (string, error) {
+	result, err := r.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(r.tableName),
+		Key:            externalIDKey(accountID, externalID),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to look up externalId: %w", err)
+	}
+	if result.Item == nil {
+		return "", nil
+	}
+
+	var claim externalIDRecord
+	if err := attributevalue.UnmarshalMap(result.Item, &claim); err != nil {
+		return "", fmt.Errorf("failed to unmarshal externalId claim: %w", err)
+	}
+	return claim.LocationID, nil
+}
+
+// claimPut returns a transaction item claiming the record's external ID. The
+// claim must be free, or held by staleOwner when that is set.
+func (r *DynamoDBRepository) claimPut(record *locationRecord, staleOwner string) (types.TransactWriteItem, error) {
+	item, err := attributevalue.MarshalMap(externalIDRecord{
+		PK:         externalIDPKPrefix + record.PK,
+		SK:         record.ExternalID,
+		LocationID: record.SK,
+	})
+	if err != nil {
+		return types.TransactWriteItem{}, fmt.Errorf("failed to marshal externalId claim: %w", err)
+	}
+
+	put := &types.Put{
+		TableName:           aws.String(r.tableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(PK)"),
+	}
+	if staleOwner != "" {
+		put.ConditionExpression = aws.String("locationId = :staleOwner")
+		put.ExpressionAttributeValues = map[string]types.AttributeValue{
+			":staleOwner": &types.AttributeValueMemberS{Value: staleOwner},
+		}
+	}
+	return types.TransactWriteItem{Put: put}, nil
+}
+
+// resolveClaimConflict is called when claiming the record's external ID failed.
+// It returns the claim's owner when the claim may be taken over, because the
+// owner no longer exists or is the record itself, and a conflict error otherwise.
+func (r *DynamoDBRepository) resolveClaimConflict(ctx context.Context, record *locationRecord) (string, error) {
+	owner, err := r.getExternalIDClaim(ctx, record.PK, record.ExternalID)
+	if err != nil {
+		return "", err
+	}
+	if owner == "" || owner == record.SK {
+		return owner, nil
+	}
+	if _, err := r.getRecord(ctx, record.PK, owner); !errors.Is(err, errLocationNotFound) {
+		return "", &ExternalIDConflictError{ExternalID: record.ExternalID, LocationID: owner}
+	}
+	return owner, nil
+}
+
+// claimFailed reports whether a transaction was canceled because the item at
+// index, the external ID claim, failed its condition.
+func claimFailed(err error, index int) bool {
+	var tce *types.TransactionCanceledException
+	if !errors.As(err, &tce) || len(tce.CancellationReasons) <= index {
+		return false
+	}
+	return aws.ToString(tce.CancellationReasons[index].Code) == "ConditionalCheckFailed"
+}
+
+// insertWithClaim writes a new location record together with its external ID claim.
+func (r *DynamoDBRepository) insertWithClaim(ctx context.Context, record *locationRecord, item map[string]types.AttributeValue) error {
+	staleOwner := ""
+	for attempt := 0; ; attempt++ {
+		claim, err := r.claimPut(record, staleOwner)
+		if err != nil {
+			return err
+		}
+
+		_, err = r.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+			TransactItems: []types.TransactWriteItem{
+				{Put: &types.Put{
+					TableName:           aws.String(r.tableName),
+					Item:                item,
+					ConditionExpression: aws.String("attribute_not_exists(PK) AND attribute_not_exists(SK)"),
+				}},
+				claim,
+			},
+		})
+		if err == nil {
+			return nil
+		}
+		if !claimFailed(err, 1) {
+			var tce *types.TransactionCanceledException
+			if errors.As(err, &tce) {
+				return fmt.Errorf("location already exists")
+			}
+			return fmt.Errorf("failed to create location: %w", err)
+		}
+		if attempt > 0 {
+			return fmt.Errorf("failed to create location: externalId %q was claimed concurrently", record.ExternalID)
+		}
+
+		staleOwner, err = r.resolveClaimConflict(ctx, record)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// updateExternalID finishes an update whose conditional put failed. Either the
+// location is missing, its type or draft state differs, or its external ID is changing, in
+// which case the record is written and the claim moved in one transaction.
+func (r *DynamoDBRepository) updateExternalID(ctx context.Context, record *locationRecord, item map[string]types.AttributeValue) error {
+	stored, err := r.getRecord(ctx, record.PK, record.SK)
+	if err != nil {
+		// The payload Update wrote is a new version, referenced by nothing stored
+		r.deleteOverflow(ctx, record.ExtendedAttributesRef)
+	}
+	var merged *MergedError
+	if errors.Is(err, errLocationNotFound) || errors.As(err, &merged) {
+		return fmt.Errorf("location not found or access denied")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update location: %w", err)
+	}
+	if stored.LocationType != record.LocationType {
+		r.deleteOverflow(ctx, newOverflowRef(stored.ExtendedAttributesRef, record.ExtendedAttributesRef))
+		return &LocationTypeError{Current: stored.LocationType, Requested: record.LocationType}
+	}
+	if stored.Draft != record.Draft {
+		r.deleteOverflow(ctx, newOverflowRef(stored.ExtendedAttributesRef, record.ExtendedAttributesRef))
+		return draftStateError(stored.Draft)
+	}
+	if etag, ok := ifMatchFrom(ctx); ok && stored.ContentHash != etag {
+		r.deleteOverflow(ctx, newOverflowRef(stored.ExtendedAttributesRef, record.ExtendedAttributesRef))
+		return &PreconditionFailedError{ETag: stored.ContentHash}
+	}
+	if stored.ExternalID == record.ExternalID {
+		r.deleteOverflow(ctx, newOverflowRef(stored.ExtendedAttributesRef, record.ExtendedAttributesRef))
+		return fmt.Errorf("failed to update location: location was modified concurrently")
+	}
+
+	staleOwner := ""
+	for attempt := 0; ; attempt++ {
+		values := map[string]types.AttributeValue{
+			":accountId":    &types.AttributeValueMemberS{Value: r.locationPK(record.PK)},
+			":locationType": &types.AttributeValueMemberS{Value: string(record.LocationType)},
+		}
+		items := []types.TransactWriteItem{
+			{Put: &types.Put{
+				TableName:                 aws.String(r.tableName),
+				Item:                      item,
+				ConditionExpression:       aws.String("attribute_exists(PK) AND PK = :accountId AND locationType = :locationType AND " + notMergedFilter + " AND " + sameExternalID(stored.ExternalID, values) + " AND " + sameDraftState(stored.Draft) + ifMatchCondition(ctx, values)),
+				ExpressionAttributeValues: values,
+			}},
+		}
+		if stored.ExternalID != "" {
+			items = append(items, types.TransactWriteItem{Delete: &types.Delete{
+				TableName:           aws.String(r.tableName),
+				Key:                 externalIDKey(record.PK, stored.ExternalID),
+				ConditionExpression: aws.String("locationId = :locationId"),
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":locationId": &types.AttributeValueMemberS{Value: record.SK},
+				},
+			}})
+		}
+		if record.ExternalID != "" {
+			claim, err := r.claimPut(record, staleOwner)
+			if err != nil {
+				r.deleteOverflow(ctx, newOverflowRef(stored.ExtendedAttributesRef, record.ExtendedAttributesRef))
+				return err
+			}
+			items = append(items, claim)
+		}
+
+		_, err := r.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: items})
+		if err == nil {
+			break
+		}
+		if record.ExternalID == "" || !claimFailed(err, len(items)-1) || attempt > 0 {
+			r.deleteOverflow(ctx, newOverflowRef(stored.ExtendedAttributesRef, record.ExtendedAttributesRef))
+			var tce *types.TransactionCanceledException
+			if errors.As(err, &tce) {
+				return fmt.Errorf("failed to update location: location was modified concurrently")
+			}
+			return fmt.Errorf("failed to update location: %w", err)
+		}
+
+		staleOwner, err = r.resolveClaimConflict(ctx, record)
+		if err != nil {
+			r.deleteOverflow(ctx, newOverflowRef(stored.ExtendedAttributesRef, record.ExtendedAttributesRef))
+			return err
+		}
+	}
+
+	r.replaceOverflow(ctx, stored.ExtendedAttributesRef, record.ExtendedAttributesRef)
+	return nil
+}
+
+// releaseExternalID deletes the claim on externalID if locationID still holds it.
+func (r *DynamoDBRepository) releaseExternalID(ctx context.Context, accountID, externalID, locationID string) error {
+	_, err := r.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName:           aws.String(r.tableName),
+		Key:                 externalIDKey(accountID, externalID),
+		ConditionExpression: aws.String("locationId = :locationId"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":locationId": &types.AttributeValueMemberS{Value: locationID},
+		},
+	})
+	var ccf *types.ConditionalCheckFailedException
+	if err != nil && !errors.As(err, &ccf) {
+		return fmt.Errorf("failed to release externalId %q: %w", externalID, err)
+	}
+	return nil
+}
@@ -0,0 +1,374 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// externalLocation is a coordinates location carrying an external ID.
+func externalLocation(externalID string) models.CoordinatesLocation {
+	return models.CoordinatesLocation{
+		LocationBase: models.LocationBase{
+			AccountID:    "acc-12345",
+			LocationType: models.LocationTypeCoordinates,
+			ExternalID:   externalID,
+		},
+		Coordinates: models.Coordinates{Latitude: 40.7128, Longitude: -74.006},
+	}
+}
+
+// claimItem is the claim item for externalID held by locationID.
+func claimItem(externalID, locationID string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"PK":         &types.AttributeValueMemberS{Value: "EXTERNALID#acc-12345"},
+		"SK":         &types.AttributeValueMemberS{Value: externalID},
+		"locationId": &types.AttributeValueMemberS{Value: locationID},
+	}
+}
+
+// claimCanceled is a transaction cancellation caused by the claim at index.
+func claimCanceled(index int) error {
+	reasons := make([]types.CancellationReason, index+1)
+	for i := range reasons {
+		reasons[i].Code = aws.String("None")
+	}
+	reasons[index].Code = aws.String("ConditionalCheckFailed")
+	return &types.TransactionCanceledException{Message: aws.String("transaction canceled"), CancellationReasons: reasons}
+}
+
+func TestDynamoDBRepositoryCreateWithExternalID(t *testing.T) {
+	t.Run("Claims the external ID in the same transaction", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		var transaction *dynamodb.TransactWriteItemsInput
+		mockClient.On("TransactWriteItems", ctx, mock.Anything).Run(func(args mock.Arguments) {
+			transaction = args.Get(1).(*dynamodb.TransactWriteItemsInput)
+		}).Return(&dynamodb.TransactWriteItemsOutput{}, nil).Once()
+
+		created, err := repo.Create(ctx, externalLocation("ERP-1"))
+		require.NoError(t, err)
+
+		require.Len(t, transaction.TransactItems, 2)
+		location := transaction.TransactItems[0].Put
+		assert.Equal(t, "ERP-1", location.Item["externalId"].(*types.AttributeValueMemberS).Value)
+		claim := transaction.TransactItems[1].Put
+		assert.Equal(t, claimItem("ERP-1", created.LocationID), claim.Item)
+		assert.Equal(t, "attribute_not_exists(PK)", aws.ToString(claim.ConditionExpression))
+		mockClient.AssertNotCalled(t, "PutItem", mock.Anything, mock.Anything)
+	})
+
+	t.Run("External ID held by another location", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		mockClient.On("TransactWriteItems", ctx, mock.Anything).Return(nil, claimCanceled(1)).Once()
+		mockClient.On("GetItem", ctx, matchGet("ERP-1")).Return(&dynamodb.GetItemOutput{Item: claimItem("ERP-1", "loc-999")}, nil).Once()
+		mockClient.On("GetItem", ctx, matchGet("loc-999")).Return(&dynamodb.GetItemOutput{Item: coordinatesItem("acc-12345", "loc-999")}, nil).Once()
+
+		_, err := repo.Create(ctx, externalLocation("ERP-1"))
+		var conflict *ExternalIDConflictError
+		require.True(t, errors.As(err, &conflict))
+		assert.Equal(t, "loc-999", conflict.LocationID)
+		assert.EqualError(t, err, `externalId "ERP-1" is already used by location loc-999`)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Takes over a claim whose location is gone", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		mockClient.On("TransactWriteItems", ctx, mock.Anything).Return(nil, claimCanceled(1)).Once()
+		mockClient.On("GetItem", ctx, matchGet("ERP-1")).Return(&dynamodb.GetItemOutput{Item: claimItem("ERP-1", "loc-999")}, nil).Once()
+		mockClient.On("GetItem", ctx, matchGet("loc-999")).Return(&dynamodb.GetItemOutput{}, nil).Once()
+		mockClient.On("TransactWriteItems", ctx, mock.MatchedBy(func(input *dynamodb.TransactWriteItemsInput) bool {
+			claim := input.TransactItems[1].Put
+			return aws.ToString(claim.ConditionExpression) == "locationId = :staleOwner" &&
+				claim.ExpressionAttributeValues[":staleOwner"].(*types.AttributeValueMemberS).Value == "loc-999"
+		})).Return(&dynamodb.TransactWriteItemsOutput{}, nil).Once()
+
+		_, err := repo.Create(ctx, externalLocation("ERP-1"))
+		require.NoError(t, err)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Rejects padded external IDs", func(t *testing.T) {
+		repo := NewDynamoDBRepository(new(mockDynamoDBClient), "test-table")
+
+		_, err := repo.Create(context.Background(), externalLocation(" ERP-1"))
+		assert.EqualError(t, err, "validation failed: externalId must not have leading or trailing whitespace")
+	})
+}
+
+func TestDynamoDBRepositoryUpdateExternalID(t *testing.T) {
+	t.Run("Moves the claim when the external ID changes", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		stored := coordinatesItem("acc-12345", "loc-001")
+		stored["externalId"] = &types.AttributeValueMemberS{Value: "ERP-OLD"}
+		mockClient.On("PutItem", ctx, mock.MatchedBy(func(input *dynamodb.PutItemInput) bool {
+			return input.ExpressionAttributeValues[":externalId"].(*types.AttributeValueMemberS).Value == "ERP-NEW"
+		})).Return(nil, &types.ConditionalCheckFailedException{Message: aws.String("The conditional request failed")}).Once()
+		mockClient.On("GetItem", ctx, matchGet("loc-001")).Return(&dynamodb.GetItemOutput{Item: stored}, nil).Once()
+
+		var transaction *dynamodb.TransactWriteItemsInput
+		mockClient.On("TransactWriteItems", ctx, mock.Anything).Run(func(args mock.Arguments) {
+			transaction = args.Get(1).(*dynamodb.TransactWriteItemsInput)
+		}).Return(&dynamodb.TransactWriteItemsOutput{}, nil).Once()
+
+		require.NoError(t, repo.Update(ctx, externalLocation("ERP-NEW"), "loc-001"))
+
+		require.Len(t, transaction.TransactItems, 3)
+		put := transaction.TransactItems[0].Put
+		assert.Contains(t, aws.ToString(put.ConditionExpression), "externalId = :externalId")
+		assert.Equal(t, "ERP-OLD", put.ExpressionAttributeValues[":externalId"].(*types.AttributeValueMemberS).Value)
+		release := transaction.TransactItems[1].Delete
+		assert.Equal(t, "ERP-OLD", release.Key["SK"].(*types.AttributeValueMemberS).Value)
+		assert.Equal(t, "locationId = :locationId", aws.ToString(release.ConditionExpression))
+		assert.Equal(t, claimItem("ERP-NEW", "loc-001"), transaction.TransactItems[2].Put.Item)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Removing the external ID releases the claim", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		stored := coordinatesItem("acc-12345", "loc-001")
+		stored["externalId"] = &types.AttributeValueMemberS{Value: "ERP-OLD"}
+		mockClient.On("PutItem", ctx, mock.Anything).
+			Return(nil, &types.ConditionalCheckFailedException{Message: aws.String("The conditional request failed")}).Once()
+		mockClient.On("GetItem", ctx, matchGet("loc-001")).Return(&dynamodb.GetItemOutput{Item: stored}, nil).Once()
+		mockClient.On("TransactWriteItems", ctx, mock.MatchedBy(func(input *dynamodb.TransactWriteItemsInput) bool {
+			return len(input.TransactItems) == 2 && input.TransactItems[1].Delete != nil
+		})).Return(&dynamodb.TransactWriteItemsOutput{}, nil).Once()
+
+		require.NoError(t, repo.Update(ctx, externalLocation(""), "loc-001"))
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("New external ID held by another location", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		mockClient.On("PutItem", ctx, mock.Anything).
+			Return(nil, &types.ConditionalCheckFailedException{Message: aws.String("The conditional request failed")}).Once()
+		mockClient.On("GetItem", ctx, matchGet("loc-001")).Return(&dynamodb.GetItemOutput{Item: coordinatesItem("acc-12345", "loc-001")}, nil).Once()
+		mockClient.On("TransactWriteItems", ctx, mock.Anything).Return(nil, claimCanceled(1)).Once()
+		mockClient.On("GetItem", ctx, matchGet("ERP-NEW")).Return(&dynamodb.GetItemOutput{Item: claimItem("ERP-NEW", "loc-002")}, nil).Once()
+		mockClient.On("GetItem", ctx, matchGet("loc-002")).Return(&dynamodb.GetItemOutput{Item: coordinatesItem("acc-12345", "loc-002")}, nil).Once()
+
+		err := repo.Update(ctx, externalLocation("ERP-NEW"), "loc-001")
+		assert.EqualError(t, err, `externalId "ERP-NEW" is already used by location loc-002`)
+		mockClient.AssertExpectations(t)
+	})
+}
+
+func TestDynamoDBRepositoryUpsert(t *testing.T) {
+	t.Run("Creates when the external ID is unclaimed", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		mockClient.On("GetItem", ctx, matchGet("ERP-1")).Return(&dynamodb.GetItemOutput{}, nil).Once()
+		mockClient.On("TransactWriteItems", ctx, mock.Anything).Return(&dynamodb.TransactWriteItemsOutput{}, nil).Once()
+
+		result, err := repo.Upsert(ctx, externalLocation("ERP-1"))
+		require.NoError(t, err)
+		assert.True(t, result.Created)
+		assert.NotEmpty(t, result.LocationID)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Updates the location holding the external ID", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		stored := coordinatesItem("acc-12345", "loc-001")
+		stored["externalId"] = &types.AttributeValueMemberS{Value: "ERP-1"}
+		mockClient.On("GetItem", ctx, matchGet("ERP-1")).Return(&dynamodb.GetItemOutput{Item: claimItem("ERP-1", "loc-001")}, nil).Once()
+		mockClient.On("GetItem", ctx, matchGet("loc-001")).Return(&dynamodb.GetItemOutput{Item: stored}, nil).Once()
+		mockClient.On("PutItem", ctx, mock.MatchedBy(func(input *dynamodb.PutItemInput) bool {
+			return input.Item["SK"].(*types.AttributeValueMemberS).Value == "loc-001"
+		})).Return(&dynamodb.PutItemOutput{}, nil).Once()
+
+		result, err := repo.Upsert(ctx, externalLocation("ERP-1"))
+		require.NoError(t, err)
+		assert.Equal(t, &UpsertResult{LocationID: "loc-001"}, result)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Concurrent create is retried as an update", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		stored := coordinatesItem("acc-12345", "loc-001")
+		stored["externalId"] = &types.AttributeValueMemberS{Value: "ERP-1"}
+		mockClient.On("GetItem", ctx, matchGet("ERP-1")).Return(&dynamodb.GetItemOutput{}, nil).Once()
+		mockClient.On("TransactWriteItems", ctx, mock.Anything).Return(nil, claimCanceled(1)).Once()
+		mockClient.On("GetItem", ctx, matchGet("ERP-1")).Return(&dynamodb.GetItemOutput{Item: claimItem("ERP-1", "loc-001")}, nil).Times(2)
+		mockClient.On("GetItem", ctx, matchGet("loc-001")).Return(&dynamodb.GetItemOutput{Item: stored}, nil).Times(2)
+		mockClient.On("PutItem", ctx, mock.Anything).Return(&dynamodb.PutItemOutput{}, nil).Once()
+
+		result, err := repo.Upsert(ctx, externalLocation("ERP-1"))
+		require.NoError(t, err)
+		assert.Equal(t, &UpsertResult{LocationID: "loc-001"}, result)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Merged location", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		tombstone := coordinatesItem("acc-12345", "loc-001")
+		tombstone["mergedInto"] = &types.AttributeValueMemberS{Value: "loc-009"}
+		mockClient.On("GetItem", ctx, matchGet("ERP-1")).Return(&dynamodb.GetItemOutput{Item: claimItem("ERP-1", "loc-001")}, nil).Once()
+		mockClient.On("GetItem", ctx, matchGet("loc-001")).Return(&dynamodb.GetItemOutput{Item: tombstone}, nil).Once()
+
+		_, err := repo.Upsert(ctx, externalLocation("ERP-1"))
+		assert.EqualError(t, err, "location has been merged into loc-009")
+		mockClient.AssertNotCalled(t, "PutItem", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Requires an external ID", func(t *testing.T) {
+		repo := NewDynamoDBRepository(new(mockDynamoDBClient), "test-table")
+
+		_, err := repo.Upsert(context.Background(), externalLocation(""))
+		assert.EqualError(t, err, "validation failed: externalId is required")
+	})
+}
+
+func TestDynamoDBRepositoryDeleteReleasesExternalID(t *testing.T) {
+	ctx := context.Background()
+	mockClient := new(mockDynamoDBClient)
+	repo := NewDynamoDBRepository(mockClient, "test-table")
+
+	stored := coordinatesItem("acc-12345", "loc-001")
+	stored["externalId"] = &types.AttributeValueMemberS{Value: "ERP-1"}
+	noMergeReferences(mockClient)
+	mockClient.On("DeleteItem", ctx, mock.MatchedBy(func(input *dynamodb.DeleteItemInput) bool {
+		return input.Key["SK"].(*types.AttributeValueMemberS).Value == "loc-001"
+	})).Return(&dynamodb.DeleteItemOutput{Attributes: stored}, nil).Once()
+	mockClient.On("DeleteItem", ctx, mock.MatchedBy(func(input *dynamodb.DeleteItemInput) bool {
+		return input.Key["PK"].(*types.AttributeValueMemberS).Value == "EXTERNALID#acc-12345" &&
+			input.Key["SK"].(*types.AttributeValueMemberS).Value == "ERP-1" &&
+			input.ExpressionAttributeValues[":locationId"].(*types.AttributeValueMemberS).Value == "loc-001"
+	})).Return(&dynamodb.DeleteItemOutput{}, nil).Once()
+
+	require.NoError(t, repo.Delete(ctx, "acc-12345", "loc-001"))
+	mockClient.AssertExpectations(t)
+}
+
+func TestDynamoDBRepositoryGetByExternalID(t *testing.T) {
+	indexed := coordinatesItem("acc-12345", "loc-001")
+	indexed["externalId"] = &types.AttributeValueMemberS{Value: "ERP-1"}
+	indexed["accountExternalId"] = &types.AttributeValueMemberS{Value: "acc-12345#ERP-1"}
+
+	t.Run("Queries the external ID index", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table", WithExternalIDIndex("ExternalIdIndex"))
+
+		mockClient.On("Query", ctx, mock.MatchedBy(func(input *dynamodb.QueryInput) bool {
+			return aws.ToString(input.IndexName) == "ExternalIdIndex" &&
+				input.ExpressionAttributeValues[":key"].(*types.AttributeValueMemberS).Value == "acc-12345#ERP-1"
+		})).Return(&dynamodb.QueryOutput{Items: []map[string]types.AttributeValue{indexed}}, nil).Once()
+
+		envelope, err := repo.GetByExternalID(ctx, "acc-12345", "ERP-1")
+		require.NoError(t, err)
+		assert.Equal(t, "loc-001", envelope.LocationID)
+		assert.Equal(t, "ERP-1", envelope.Location.GetExternalID())
+		mockClient.AssertNotCalled(t, "GetItem", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Merged tombstone reports the survivor", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table", WithExternalIDIndex("ExternalIdIndex"))
+
+		tombstone := coordinatesItem("acc-12345", "loc-001")
+		tombstone["mergedInto"] = &types.AttributeValueMemberS{Value: "loc-009"}
+		mockClient.On("Query", ctx, mock.Anything).Return(&dynamodb.QueryOutput{Items: []map[string]types.AttributeValue{tombstone}}, nil).Once()
+
+		_, err := repo.GetByExternalID(ctx, "acc-12345", "ERP-1")
+		assert.EqualError(t, err, "location has been merged into loc-009")
+	})
+
+	t.Run("Skips another account's location under a colliding key", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table", WithExternalIDIndex("ExternalIdIndex"))
+
+		// Written unescaped by account acc#12345 for ERP-1, before account IDs were escaped
+		other := coordinatesItem("acc#12345", "loc-666")
+		other["accountExternalId"] = &types.AttributeValueMemberS{Value: "acc#12345#ERP-1"}
+		mockClient.On("Query", ctx, mock.Anything).Return(&dynamodb.QueryOutput{Items: []map[string]types.AttributeValue{other}}, nil).Once()
+
+		_, err := repo.GetByExternalID(ctx, "acc", "12345#ERP-1")
+		assert.EqualError(t, err, "location not found")
+	})
+
+	t.Run("Not found in the index", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table", WithExternalIDIndex("ExternalIdIndex"))
+
+		mockClient.On("Query", ctx, mock.Anything).Return(&dynamodb.QueryOutput{}, nil).Once()
+
+		_, err := repo.GetByExternalID(ctx, "acc-12345", "ERP-404")
+		assert.EqualError(t, err, "location not found")
+	})
+
+	t.Run("Reads the claim without an index", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		mockClient.On("GetItem", ctx, matchGet("ERP-1")).Return(&dynamodb.GetItemOutput{Item: claimItem("ERP-1", "loc-001")}, nil).Once()
+		mockClient.On("GetItem", ctx, matchGet("loc-001")).Return(&dynamodb.GetItemOutput{Item: indexed}, nil).Once()
+
+		envelope, err := repo.GetByExternalID(ctx, "acc-12345", "ERP-1")
+		require.NoError(t, err)
+		assert.Equal(t, "loc-001", envelope.LocationID)
+		mockClient.AssertNotCalled(t, "Query", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Index key is written only with an external ID", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		mockClient.On("TransactWriteItems", ctx, mock.MatchedBy(func(input *dynamodb.TransactWriteItemsInput) bool {
+			key, ok := input.TransactItems[0].Put.Item["accountExternalId"].(*types.AttributeValueMemberS)
+			return ok && key.Value == "acc-12345#ERP-1"
+		})).Return(&dynamodb.TransactWriteItemsOutput{}, nil).Once()
+		mockClient.On("PutItem", ctx, mock.MatchedBy(func(input *dynamodb.PutItemInput) bool {
+			_, ok := input.Item["accountExternalId"]
+			return !ok
+		})).Return(&dynamodb.PutItemOutput{}, nil).Once()
+
+		_, err := repo.Create(ctx, externalLocation("ERP-1"))
+		require.NoError(t, err)
+		_, err = repo.Create(ctx, externalLocation(""))
+		require.NoError(t, err)
+		mockClient.AssertExpectations(t)
+	})
+}
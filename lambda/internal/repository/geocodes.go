@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/steverhoton/location-lambda/internal/models"
+)
+
+// ungeocodedCondition holds while an address location still has the address
+// that was geocoded, no coordinates, and no coordinate lock.
+const ungeocodedCondition = notMergedFilter + " AND locationType = :addressType AND attribute_not_exists(coordinates) AND attribute_not_exists(coordinatesLocked) AND address = :address"
+
+// regeocodeCondition holds while an address location still has the address
+// that was geocoded, the geocode being replaced, and no coordinate lock.
+const regeocodeCondition = notMergedFilter + " AND locationType = :addressType AND geocode.geocodedAt = :geocodedAt AND attribute_not_exists(coordinatesLocked) AND address = :address"
+
+// GeocodeWriter stores geocoded coordinates on address locations.
+type GeocodeWriter interface {
+	SetGeocode(ctx context.Context, accountID, locationID string, address models.Address, coordinates models.Coordinates, info models.GeocodeInfo) (bool, error)
+	RefreshGeocode(ctx context.Context, accountID, locationID string, previous models.GeocodeInfo, address models.Address, coordinates models.Coordinates, info models.GeocodeInfo) (bool, error)
+}
+
+// SetGeocode stores coordinates geocoded from address, with their provenance,
+// on an address location that has none. Only the coordinates and geocode
+// attributes change. It reports false, without writing, when the location
+// was given or locked coordinates, changed address, or went away since
+// address was read, so a geocode never replaces a hand-pinned location or
+// describes a stale address.
+func (r *DynamoDBRepository) SetGeocode(ctx context.Context, accountID, locationID string, address models.Address, coordinates models.Coordinates, info models.GeocodeInfo) (bool, error) {
+	return r.putGeocode(ctx, accountID, locationID, address, coordinates, info, ungeocodedCondition, func(record *locationRecord) bool {
+		return record.Coordinates == nil
+	})
+}
+
+// RefreshGeocode replaces the coordinates of an address location last
+// geocoded as previous, identified by its geocodedAt, with those geocoded
+// from address. Like SetGeocode it
+// reports false without writing when the location changed since it was read:
+// here, when it was geocoded again, its coordinates were entered by hand or
+// locked, or its address differs from address.
+func (r *DynamoDBRepository) RefreshGeocode(ctx context.Context, accountID, locationID string, previous models.GeocodeInfo, address models.Address, coordinates models.Coordinates, info models.GeocodeInfo) (bool, error) {
+	return r.putGeocode(ctx, accountID, locationID, address, coordinates, info, regeocodeCondition, func(record *locationRecord) bool {
+		return record.Geocode != nil && record.Geocode.GeocodedAt.Equal(previous.GeocodedAt)
+	})
+}
+
+// putGeocode writes coordinates and their geocode onto a live, unlocked
+// address location still at address, provided expected accepts its stored
+// record, and conditional on condition holding for the stored item.
+func (r *DynamoDBRepository) putGeocode(ctx context.Context, accountID, locationID string, address models.Address, coordinates models.Coordinates, info models.GeocodeInfo, condition string, expected func(*locationRecord) bool) (bool, error) {
+	if err := coordinates.Validate(); err != nil {
+		return false, fmt.Errorf("validation failed: %w", err)
+	}
+	if err := info.Validate(); err != nil {
+		return false, fmt.Errorf("validation failed: %w", err)
+	}
+
+	result, err := r.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(r.tableName),
+		Key:            r.locationKey(accountID, locationID),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to get location: %w", err)
+	}
+	if result.Item == nil {
+		return false, nil
+	}
+
+	var record locationRecord
+	if err := attributevalue.UnmarshalMap(result.Item, &record); err != nil {
+		return false, fmt.Errorf("failed to unmarshal location: %w", err)
+	}
+	if record.MergedInto != "" || record.LocationType != models.LocationTypeAddress || record.CoordinatesLocked ||
+		record.Address == nil || !reflect.DeepEqual(*record.Address, address) || !expected(&record) {
+		return false, nil
+	}
+
+	values := map[string]types.AttributeValue{
+		":addressType": &types.AttributeValueMemberS{Value: string(models.LocationTypeAddress)},
+		":address":     result.Item["address"],
+	}
+	if condition == regeocodeCondition {
+		geocode, _ := result.Item["geocode"].(*types.AttributeValueMemberM)
+		if geocode == nil || geocode.Value["geocodedAt"] == nil {
+			return false, nil
+		}
+		values[":geocodedAt"] = geocode.Value["geocodedAt"]
+	}
+
+	item := result.Item
+	if item["coordinates"], err = attributevalue.Marshal(coordinates); err != nil {
+		return false, fmt.Errorf("failed to marshal coordinates: %w", err)
+	}
+	if item["geocode"], err = attributevalue.Marshal(info); err != nil {
+		return false, fmt.Errorf("failed to marshal geocode: %w", err)
+	}
+	// The content hash covers the decrypted location, so it is recomputed
+	// from a hydrated copy while the item keeps its stored attributes
+	if err := r.hydrateRecord(ctx, &record); err != nil {
+		return false, err
+	}
+	record.Coordinates, record.Geocode = &coordinates, &info
+	if err := setContentHash(&record); err != nil {
+		return false, err
+	}
+	item["contentHash"] = &types.AttributeValueMemberS{Value: record.ContentHash}
+	item["updatedAt"] = &types.AttributeValueMemberS{Value: syncTime(r.now())}
+
+	_, err = r.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:                 aws.String(r.tableName),
+		Item:                      item,
+		ConditionExpression:       aws.String(condition),
+		ExpressionAttributeValues: values,
+	})
+	if err != nil {
+		var ccf *types.ConditionalCheckFailedException
+		if errors.As(err, &ccf) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to store geocode: %w", err)
+	}
+	return true, nil
+}
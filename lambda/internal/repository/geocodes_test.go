@@ -0,0 +1,251 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDynamoDBRepositorySetGeocode(t *testing.T) {
+	address := models.Address{StreetAddress: "123 Main St", City: "Springfield", StateProvince: "IL", PostalCode: "62704", Country: "US"}
+	coordinates := models.Coordinates{Latitude: 39.7817, Longitude: -89.6501}
+	info := models.GeocodeInfo{Provider: "amazon_location", Label: "123 Main St, Springfield, IL 62704, USA", Confidence: 0.97, GeocodedAt: time.Date(2024, time.June, 4, 12, 0, 0, 0, time.UTC)}
+	storedItem := func(t *testing.T, address models.Address) map[string]types.AttributeValue {
+		item, err := attributevalue.MarshalMap(locationRecord{
+			PK:                 "acc-12345",
+			SK:                 "loc-001",
+			LocationType:       models.LocationTypeAddress,
+			Address:            &address,
+			ExtendedAttributes: map[string]interface{}{"source": "legacy"},
+		})
+		require.NoError(t, err)
+		return item
+	}
+
+	t.Run("Adds coordinates and provenance to the stored item", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		mockClient.On("GetItem", ctx, mock.Anything).Return(&dynamodb.GetItemOutput{Item: storedItem(t, address)}, nil).Once()
+		var put *dynamodb.PutItemInput
+		mockClient.On("PutItem", ctx, mock.Anything).Run(func(args mock.Arguments) {
+			put = args.Get(1).(*dynamodb.PutItemInput)
+		}).Return(&dynamodb.PutItemOutput{}, nil).Once()
+
+		stored, err := repo.SetGeocode(ctx, "acc-12345", "loc-001", address, coordinates, info)
+		require.NoError(t, err)
+		assert.True(t, stored)
+		assert.Equal(t, ungeocodedCondition, aws.ToString(put.ConditionExpression))
+		assert.Equal(t, put.Item["address"], put.ExpressionAttributeValues[":address"])
+
+		var record locationRecord
+		require.NoError(t, attributevalue.UnmarshalMap(put.Item, &record))
+		assert.Equal(t, &coordinates, record.Coordinates)
+		assert.Equal(t, info, *record.Geocode)
+		assert.Equal(t, "legacy", record.ExtendedAttributes["source"])
+	})
+
+	t.Run("Skips a location whose address changed", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		moved := address
+		moved.StreetAddress = "456 Oak Ave"
+		mockClient.On("GetItem", ctx, mock.Anything).Return(&dynamodb.GetItemOutput{Item: storedItem(t, moved)}, nil).Once()
+
+		stored, err := repo.SetGeocode(ctx, "acc-12345", "loc-001", address, coordinates, info)
+		require.NoError(t, err)
+		assert.False(t, stored)
+		mockClient.AssertNotCalled(t, "PutItem", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Skips a location with locked coordinates", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		item, err := attributevalue.MarshalMap(locationRecord{
+			PK:                "acc-12345",
+			SK:                "loc-001",
+			LocationType:      models.LocationTypeAddress,
+			Address:           &address,
+			Coordinates:       &models.Coordinates{Latitude: 39.7801, Longitude: -89.6489},
+			CoordinatesLocked: true,
+		})
+		require.NoError(t, err)
+		mockClient.On("GetItem", ctx, mock.Anything).Return(&dynamodb.GetItemOutput{Item: item}, nil).Once()
+
+		stored, err := repo.SetGeocode(ctx, "acc-12345", "loc-001", address, coordinates, info)
+		require.NoError(t, err)
+		assert.False(t, stored)
+		mockClient.AssertNotCalled(t, "PutItem", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Skips a location changed before the write", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		mockClient.On("GetItem", ctx, mock.Anything).Return(&dynamodb.GetItemOutput{Item: storedItem(t, address)}, nil).Once()
+		mockClient.On("PutItem", ctx, mock.Anything).Return(nil, &types.ConditionalCheckFailedException{}).Once()
+
+		stored, err := repo.SetGeocode(ctx, "acc-12345", "loc-001", address, coordinates, info)
+		require.NoError(t, err)
+		assert.False(t, stored)
+	})
+
+	t.Run("Skips a deleted location", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		mockClient.On("GetItem", ctx, mock.Anything).Return(&dynamodb.GetItemOutput{}, nil).Once()
+
+		stored, err := repo.SetGeocode(ctx, "acc-12345", "loc-001", address, coordinates, info)
+		require.NoError(t, err)
+		assert.False(t, stored)
+	})
+
+	t.Run("Validates the geocode", func(t *testing.T) {
+		repo := NewDynamoDBRepository(new(mockDynamoDBClient), "test-table")
+
+		_, err := repo.SetGeocode(context.Background(), "acc-12345", "loc-001", address, coordinates, models.GeocodeInfo{GeocodedAt: info.GeocodedAt})
+		assert.EqualError(t, err, "validation failed: geocode: provider is required")
+		_, err = repo.SetGeocode(context.Background(), "acc-12345", "loc-001", address, coordinates, models.GeocodeInfo{Provider: "amazon_location", Confidence: 1.2, GeocodedAt: info.GeocodedAt})
+		assert.EqualError(t, err, "validation failed: geocode: confidence must be between 0 and 1, got 1.2")
+	})
+}
+
+func TestDynamoDBRepositoryRefreshGeocode(t *testing.T) {
+	address := models.Address{StreetAddress: "123 Main St", City: "Springfield", StateProvince: "IL", PostalCode: "62704", Country: "US"}
+	previous := models.GeocodeInfo{Provider: "amazon_location", Confidence: 0.6, GeocodedAt: time.Date(2024, time.January, 8, 9, 0, 0, 0, time.UTC)}
+	coordinates := models.Coordinates{Latitude: 39.7817, Longitude: -89.6501}
+	info := models.GeocodeInfo{Provider: "amazon_location", Confidence: 0.97, GeocodedAt: time.Date(2024, time.June, 4, 12, 0, 0, 0, time.UTC), AddressHash: models.AddressHash(address)}
+	storedItem := func(t *testing.T, geocode *models.GeocodeInfo, locked bool) map[string]types.AttributeValue {
+		item, err := attributevalue.MarshalMap(locationRecord{
+			PK:                "acc-12345",
+			SK:                "loc-001",
+			LocationType:      models.LocationTypeAddress,
+			Address:           &address,
+			Coordinates:       &models.Coordinates{Latitude: 39.79, Longitude: -89.64},
+			CoordinatesLocked: locked,
+			Geocode:           geocode,
+		})
+		require.NoError(t, err)
+		return item
+	}
+
+	t.Run("Replaces the coordinates of the geocode read", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		mockClient.On("GetItem", ctx, mock.Anything).Return(&dynamodb.GetItemOutput{Item: storedItem(t, &previous, false)}, nil).Once()
+		var put *dynamodb.PutItemInput
+		mockClient.On("PutItem", ctx, mock.Anything).Run(func(args mock.Arguments) {
+			put = args.Get(1).(*dynamodb.PutItemInput)
+		}).Return(&dynamodb.PutItemOutput{}, nil).Once()
+
+		stored, err := repo.RefreshGeocode(ctx, "acc-12345", "loc-001", previous, address, coordinates, info)
+		require.NoError(t, err)
+		assert.True(t, stored)
+		assert.Equal(t, regeocodeCondition, aws.ToString(put.ConditionExpression))
+		assert.Equal(t, &types.AttributeValueMemberS{Value: "2024-01-08T09:00:00Z"}, put.ExpressionAttributeValues[":geocodedAt"])
+
+		var record locationRecord
+		require.NoError(t, attributevalue.UnmarshalMap(put.Item, &record))
+		assert.Equal(t, &coordinates, record.Coordinates)
+		assert.Equal(t, info.AddressHash, record.Geocode.AddressHash)
+	})
+
+	t.Run("Skips a location geocoded again since it was read", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		newer := previous
+		newer.GeocodedAt = previous.GeocodedAt.Add(time.Hour)
+		mockClient.On("GetItem", ctx, mock.Anything).Return(&dynamodb.GetItemOutput{Item: storedItem(t, &newer, false)}, nil).Once()
+
+		stored, err := repo.RefreshGeocode(ctx, "acc-12345", "loc-001", previous, address, coordinates, info)
+		require.NoError(t, err)
+		assert.False(t, stored)
+		mockClient.AssertNotCalled(t, "PutItem", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Skips hand-entered and locked coordinates", func(t *testing.T) {
+		for name, item := range map[string]map[string]types.AttributeValue{
+			"hand-entered": storedItem(t, nil, false),
+			"locked":       storedItem(t, &previous, true),
+		} {
+			ctx := context.Background()
+			mockClient := new(mockDynamoDBClient)
+			repo := NewDynamoDBRepository(mockClient, "test-table")
+
+			mockClient.On("GetItem", ctx, mock.Anything).Return(&dynamodb.GetItemOutput{Item: item}, nil).Once()
+
+			stored, err := repo.RefreshGeocode(ctx, "acc-12345", "loc-001", previous, address, coordinates, info)
+			require.NoError(t, err, name)
+			assert.False(t, stored, name)
+			mockClient.AssertNotCalled(t, "PutItem", mock.Anything, mock.Anything)
+		}
+	})
+}
+
+func TestDynamoDBRepositoryListGeocoded(t *testing.T) {
+	ctx := context.Background()
+	mockClient := new(mockDynamoDBClient)
+	repo := NewDynamoDBRepository(mockClient, "test-table")
+
+	mockClient.On("Query", ctx, mock.MatchedBy(func(input *dynamodb.QueryInput) bool {
+		_, ok := input.ExpressionAttributeValues[":addressType"]
+		return ok && aws.ToString(input.FilterExpression) == notMergedFilter+" AND "+listedFilter+" AND locationType = :addressType AND attribute_exists(geocode) AND attribute_not_exists(coordinatesLocked)"
+	})).Return(&dynamodb.QueryOutput{}, nil).Once()
+
+	_, err := repo.List(ctx, "acc-12345", &ListOptions{Geocoded: true})
+	require.NoError(t, err)
+	mockClient.AssertExpectations(t)
+}
+
+func TestDynamoDBRepositoryListByGeocodeConfidence(t *testing.T) {
+	ctx := context.Background()
+	mockClient := new(mockDynamoDBClient)
+	repo := NewDynamoDBRepository(mockClient, "test-table")
+
+	mockClient.On("Query", ctx, mock.MatchedBy(func(input *dynamodb.QueryInput) bool {
+		value, ok := input.ExpressionAttributeValues[":minConfidence"].(*types.AttributeValueMemberN)
+		return ok && value.Value == "0.8" &&
+			aws.ToString(input.FilterExpression) == notMergedFilter+" AND "+listedFilter+" AND (locationType <> :addressType OR (attribute_exists(coordinates) AND (attribute_not_exists(geocode) OR geocode.confidence >= :minConfidence)))"
+	})).Return(&dynamodb.QueryOutput{}, nil).Once()
+
+	_, err := repo.List(ctx, "acc-12345", &ListOptions{MinGeocodeConfidence: aws.Float64(0.8)})
+	require.NoError(t, err)
+	mockClient.AssertExpectations(t)
+}
+
+func TestDynamoDBRepositoryListMissingCoordinates(t *testing.T) {
+	ctx := context.Background()
+	mockClient := new(mockDynamoDBClient)
+	repo := NewDynamoDBRepository(mockClient, "test-table")
+
+	mockClient.On("Query", ctx, mock.MatchedBy(func(input *dynamodb.QueryInput) bool {
+		value, ok := input.ExpressionAttributeValues[":addressType"].(*types.AttributeValueMemberS)
+		return ok && value.Value == string(models.LocationTypeAddress) &&
+			aws.ToString(input.FilterExpression) == notMergedFilter+" AND "+listedFilter+" AND locationType = :addressType AND attribute_not_exists(coordinates)"
+	})).Return(&dynamodb.QueryOutput{}, nil).Once()
+
+	_, err := repo.List(ctx, "acc-12345", &ListOptions{MissingCoordinates: true})
+	require.NoError(t, err)
+	mockClient.AssertExpectations(t)
+}
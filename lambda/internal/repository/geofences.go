@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/steverhoton/location-lambda/internal/models"
+)
+
+const (
+	// geofencesSK is the sort key of an account's geofences.
+	geofencesSK = "geofences"
+	// geofencePresencePKPrefix keeps locations' geofence presence out of the
+	// account's location partition.
+	geofencePresencePKPrefix = "GEOFENCE_PRESENCE#"
+)
+
+// GeofenceStore defines storage operations for an account's geofences and
+// which of them each location is in.
+type GeofenceStore interface {
+	GetGeofences(ctx context.Context, accountID string) ([]models.Geofence, error)
+	PutGeofences(ctx context.Context, accountID string, geofences []models.Geofence) error
+	GetGeofencePresence(ctx context.Context, accountID, locationID string) (map[string]models.GeofencePresence, error)
+	PutGeofencePresence(ctx context.Context, accountID, locationID string, presence map[string]models.GeofencePresence) error
+}
+
+// geofencesRecord is the DynamoDB item holding an account's geofences.
+type geofencesRecord struct {
+	PK        string            `dynamodbav:"PK"` // ACCOUNT#accountId
+	SK        string            `dynamodbav:"SK"` // geofences
+	AccountID string            `dynamodbav:"accountId"`
+	Geofences []models.Geofence `dynamodbav:"geofences"`
+	UpdatedAt time.Time         `dynamodbav:"updatedAt"`
+}
+
+// geofencePresenceRecord is the DynamoDB item holding a location's presence
+// in its account's geofences, by geofence ID.
+type geofencePresenceRecord struct {
+	PK       string                             `dynamodbav:"PK"` // GEOFENCE_PRESENCE#accountId
+	SK       string                             `dynamodbav:"SK"` // locationId
+	Presence map[string]models.GeofencePresence `dynamodbav:"presence"`
+}
+
+// geofencePresenceKey returns the primary key of a geofence presence item.
+func geofencePresenceKey(accountID, locationID string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberS{Value: geofencePresencePKPrefix + accountID},
+		"SK": &types.AttributeValueMemberS{Value: locationID},
+	}
+}
+
+// GetGeofences returns an account's geofences, or none when the account has
+// not defined any.
+func (r *DynamoDBRepository) GetGeofences(ctx context.Context, accountID string) ([]models.Geofence, error) {
+	result, err := r.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(r.tableName),
+		Key:       accountConfigKey(accountID, geofencesSK),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get geofences: %w", err)
+	}
+
+	if result.Item == nil {
+		return []models.Geofence{}, nil
+	}
+
+	var record geofencesRecord
+	if err := attributevalue.UnmarshalMap(result.Item, &record); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal geofences: %w", err)
+	}
+
+	return record.Geofences, nil
+}
+
+// PutGeofences replaces an account's geofences. Locations' presence in
+// removed geofences is dropped on their next position update.
+func (r *DynamoDBRepository) PutGeofences(ctx context.Context, accountID string, geofences []models.Geofence) error {
+	if accountID == "" {
+		return fmt.Errorf("validation failed: accountId is required")
+	}
+	if err := models.ValidateGeofences(geofences); err != nil {
+		return fmt.Errorf("validation failed: %w", err)
+	}
+	if geofences == nil {
+		geofences = []models.Geofence{}
+	}
+
+	item, err := attributevalue.MarshalMap(geofencesRecord{
+		PK:        accountPKPrefix + accountID,
+		SK:        geofencesSK,
+		AccountID: accountID,
+		Geofences: geofences,
+		UpdatedAt: r.now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal geofences: %w", err)
+	}
+
+	_, err = r.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(r.tableName),
+		Item:      item,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put geofences: %w", err)
+	}
+
+	return nil
+}
+
+// GetGeofencePresence returns the geofences a location was last in, or
+// pending a crossing of, by geofence ID.
+func (r *DynamoDBRepository) GetGeofencePresence(ctx context.Context, accountID, locationID string) (map[string]models.GeofencePresence, error) {
+	result, err := r.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(r.tableName),
+		Key:       geofencePresenceKey(accountID, locationID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get geofence presence: %w", err)
+	}
+
+	if result.Item == nil {
+		return map[string]models.GeofencePresence{}, nil
+	}
+
+	var record geofencePresenceRecord
+	if err := attributevalue.UnmarshalMap(result.Item, &record); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal geofence presence: %w", err)
+	}
+
+	return record.Presence, nil
+}
+
+// PutGeofencePresence replaces a location's geofence presence, deleting the
+// item when the location is in no geofence.
+func (r *DynamoDBRepository) PutGeofencePresence(ctx context.Context, accountID, locationID string, presence map[string]models.GeofencePresence) error {
+	if len(presence) == 0 {
+		_, err := r.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+			TableName: aws.String(r.tableName),
+			Key:       geofencePresenceKey(accountID, locationID),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to delete geofence presence: %w", err)
+		}
+		return nil
+	}
+
+	item, err := attributevalue.MarshalMap(geofencePresenceRecord{
+		PK:       geofencePresencePKPrefix + accountID,
+		SK:       locationID,
+		Presence: presence,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal geofence presence: %w", err)
+	}
+
+	_, err = r.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(r.tableName),
+		Item:      item,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put geofence presence: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDynamoDBRepositoryGeofences(t *testing.T) {
+	ctx := context.Background()
+	depot := models.Geofence{
+		GeofenceID:   "depot",
+		Name:         "Denver depot",
+		Center:       &models.Coordinates{Latitude: 39.74, Longitude: -104.99},
+		RadiusMeters: 500,
+	}
+
+	t.Run("Put and get geofences", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		var stored map[string]types.AttributeValue
+		mockClient.On("PutItem", ctx, mock.Anything).Run(func(args mock.Arguments) {
+			stored = args.Get(1).(*dynamodb.PutItemInput).Item
+		}).Return(&dynamodb.PutItemOutput{}, nil).Once()
+		require.NoError(t, repo.PutGeofences(ctx, "acc-12345", []models.Geofence{depot}))
+		assert.Equal(t, "ACCOUNT#acc-12345", stored["PK"].(*types.AttributeValueMemberS).Value)
+		assert.Equal(t, "geofences", stored["SK"].(*types.AttributeValueMemberS).Value)
+
+		mockClient.On("GetItem", ctx, mock.Anything).Return(&dynamodb.GetItemOutput{Item: stored}, nil).Once()
+		geofences, err := repo.GetGeofences(ctx, "acc-12345")
+		require.NoError(t, err)
+		assert.Equal(t, []models.Geofence{depot}, geofences)
+	})
+
+	t.Run("Rejects invalid geofences", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		err := repo.PutGeofences(ctx, "acc-12345", []models.Geofence{depot, depot})
+		assert.EqualError(t, err, `validation failed: geofences[1]: duplicate geofenceId "depot"`)
+		mockClient.AssertNotCalled(t, "PutItem", mock.Anything, mock.Anything)
+	})
+
+	t.Run("No geofences", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		mockClient.On("GetItem", ctx, mock.Anything).Return(&dynamodb.GetItemOutput{}, nil).Once()
+		geofences, err := repo.GetGeofences(ctx, "acc-12345")
+		require.NoError(t, err)
+		assert.Empty(t, geofences)
+	})
+}
+
+func TestDynamoDBRepositoryGeofencePresence(t *testing.T) {
+	ctx := context.Background()
+	since := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	presence := map[string]models.GeofencePresence{"depot": {Inside: true, PendingSince: &since}}
+
+	t.Run("Get presence", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		item, err := attributevalue.MarshalMap(geofencePresenceRecord{PK: "GEOFENCE_PRESENCE#acc-12345", SK: "loc-001", Presence: presence})
+		require.NoError(t, err)
+		mockClient.On("GetItem", ctx, mock.MatchedBy(func(input *dynamodb.GetItemInput) bool {
+			return input.Key["PK"].(*types.AttributeValueMemberS).Value == "GEOFENCE_PRESENCE#acc-12345" &&
+				input.Key["SK"].(*types.AttributeValueMemberS).Value == "loc-001"
+		})).Return(&dynamodb.GetItemOutput{Item: item}, nil).Once()
+
+		result, err := repo.GetGeofencePresence(ctx, "acc-12345", "loc-001")
+		require.NoError(t, err)
+		assert.Equal(t, presence, result)
+	})
+
+	t.Run("Put presence", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		mockClient.On("PutItem", ctx, mock.MatchedBy(func(input *dynamodb.PutItemInput) bool {
+			return input.Item["PK"].(*types.AttributeValueMemberS).Value == "GEOFENCE_PRESENCE#acc-12345" &&
+				input.Item["SK"].(*types.AttributeValueMemberS).Value == "loc-001"
+		})).Return(&dynamodb.PutItemOutput{}, nil).Once()
+
+		require.NoError(t, repo.PutGeofencePresence(ctx, "acc-12345", "loc-001", presence))
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Empty presence deletes the item", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		mockClient.On("DeleteItem", ctx, mock.Anything).Return(&dynamodb.DeleteItemOutput{}, nil).Once()
+
+		require.NoError(t, repo.PutGeofencePresence(ctx, "acc-12345", "loc-001", nil))
+		mockClient.AssertExpectations(t)
+		mockClient.AssertNotCalled(t, "PutItem", mock.Anything, mock.Anything)
+	})
+}
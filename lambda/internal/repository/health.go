@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// healthCheckPK is the partition probed by health checks. No item is stored
+// there; the read only proves the table answers.
+const healthCheckPK = "HEALTH#"
+
+// HealthChecker is implemented by repositories that can report whether their
+// tables are reachable.
+type HealthChecker interface {
+	CheckHealth(ctx context.Context) []TableHealth
+}
+
+// TableHealth is the result of probing one table.
+type TableHealth struct {
+	Name    string        // Table name, prefixed with its region for residency tables
+	Latency time.Duration // Round trip of the probe
+	Err     error         // Nil when the table answered
+}
+
+// CheckHealth probes the table with a point read of a key that never exists.
+// Unlike DescribeTable it needs no extra IAM permission and exercises the
+// same path as location reads.
+func (r *DynamoDBRepository) CheckHealth(ctx context.Context) []TableHealth {
+	start := time.Now()
+	_, err := r.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(r.tableName),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: healthCheckPK},
+			"SK": &types.AttributeValueMemberS{Value: healthCheckPK},
+		},
+		ProjectionExpression: aws.String("PK"),
+	})
+	if err != nil {
+		err = fmt.Errorf("failed to read table: %w", err)
+	}
+	return []TableHealth{{Name: r.tableName, Latency: time.Since(start), Err: err}}
+}
+
+// CheckHealth probes the home table and the table of every residency region.
+func (r *RoutingRepository) CheckHealth(ctx context.Context) []TableHealth {
+	results := checkHealth(ctx, r.home, "")
+
+	regions := make([]string, 0, len(r.regional))
+	for region := range r.regional {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+	for _, region := range regions {
+		results = append(results, checkHealth(ctx, r.regional[region], region)...)
+	}
+	return results
+}
+
+// checkHealth probes repo, naming its tables after region when one is given.
+func checkHealth(ctx context.Context, repo Repository, region string) []TableHealth {
+	checker, ok := repo.(HealthChecker)
+	if !ok {
+		return []TableHealth{{Name: region, Err: fmt.Errorf("health checks are not supported for this region")}}
+	}
+	results := checker.CheckHealth(ctx)
+	if region != "" {
+		for i := range results {
+			results[i].Name = region + "/" + results[i].Name
+		}
+	}
+	return results
+}
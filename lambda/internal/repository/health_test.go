@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDynamoDBRepositoryCheckHealth(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Reads a key that never exists", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "locations")
+		mockClient.On("GetItem", ctx, mock.MatchedBy(func(input *dynamodb.GetItemInput) bool {
+			pk, ok := input.Key["PK"].(*types.AttributeValueMemberS)
+			return ok && pk.Value == "HEALTH#" && *input.TableName == "locations"
+		})).Return(&dynamodb.GetItemOutput{}, nil).Once()
+
+		results := repo.CheckHealth(ctx)
+		require.Len(t, results, 1)
+		assert.Equal(t, "locations", results[0].Name)
+		assert.NoError(t, results[0].Err)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Reports an unreachable table", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "locations")
+		mockClient.On("GetItem", ctx, mock.Anything).Return(nil, errors.New("ResourceNotFoundException")).Once()
+
+		results := repo.CheckHealth(ctx)
+		require.Len(t, results, 1)
+		assert.EqualError(t, results[0].Err, "failed to read table: ResourceNotFoundException")
+	})
+}
+
+func TestRoutingRepositoryCheckHealth(t *testing.T) {
+	ctx := context.Background()
+	homeClient := new(mockDynamoDBClient)
+	euClient := new(mockDynamoDBClient)
+	repo := NewRoutingRepository(
+		NewDynamoDBRepository(homeClient, "locations"),
+		map[string]Repository{"eu-west-1": NewDynamoDBRepository(euClient, "locations-eu")},
+		nil,
+	)
+	homeClient.On("GetItem", ctx, mock.Anything).Return(&dynamodb.GetItemOutput{}, nil).Once()
+	euClient.On("GetItem", ctx, mock.Anything).Return(nil, errors.New("timeout")).Once()
+
+	results := repo.CheckHealth(ctx)
+	require.Len(t, results, 2)
+	assert.Equal(t, "locations", results[0].Name)
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, "eu-west-1/locations-eu", results[1].Name)
+	assert.EqualError(t, results[1].Err, "failed to read table: timeout")
+}
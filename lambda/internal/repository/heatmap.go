@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/steverhoton/location-lambda/internal/geo"
+	"github.com/steverhoton/location-lambda/internal/models"
+)
+
+const (
+	// MaxHeatmapResolution is the longest geohash LocationHeatmap counts by,
+	// cells about 38 by 19 meters.
+	MaxHeatmapResolution = 8
+	// MaxHeatmapCells caps the cells a heatmap returns, so a fine resolution
+	// over a wide box can't return every point one cell at a time.
+	MaxHeatmapCells = 5000
+	// heatmapFilter selects an account's listed locations pinned within a
+	// latitude band, by their own coordinates or their shop's.
+	heatmapFilter = notMergedFilter + " AND " + listedFilter + " AND (coordinates.latitude BETWEEN :minLat AND :maxLat OR shop.coordinates.latitude BETWEEN :minLat AND :maxLat)"
+	// heatmapProjection reads only the coordinates LocationHeatmap counts.
+	heatmapProjection = "coordinates.latitude, coordinates.longitude, shop.coordinates.latitude, shop.coordinates.longitude"
+)
+
+// HeatmapReader counts an account's locations by geohash cell.
+type HeatmapReader interface {
+	LocationHeatmap(ctx context.Context, accountID string, box geo.BoundingBox, resolution int) (*Heatmap, error)
+}
+
+// HeatmapCell is the number of locations in a geohash cell, and the cell's
+// center to draw it at.
+type HeatmapCell struct {
+	Geohash   string  `json:"geohash"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Count     int     `json:"count"`
+}
+
+// Heatmap is the density of an account's locations within a bounding box.
+type Heatmap struct {
+	Resolution int `json:"resolution"`
+	// Total is the number of locations in the box, the sum of the cells' counts
+	Total int `json:"total"`
+	// Cells are ordered by geohash; cells without locations are left out
+	Cells []HeatmapCell `json:"cells"`
+}
+
+// heatmapRecord is the projection of a location that LocationHeatmap reads.
+type heatmapRecord struct {
+	Coordinates *models.Coordinates `dynamodbav:"coordinates"`
+	Shop        *struct {
+		Coordinates *models.Coordinates `dynamodbav:"coordinates"`
+	} `dynamodbav:"shop"`
+}
+
+// point returns where the record is pinned, or nil when it isn't.
+func (r heatmapRecord) point() *models.Coordinates {
+	if r.Coordinates != nil {
+		return r.Coordinates
+	}
+	if r.Shop != nil {
+		return r.Shop.Coordinates
+	}
+	return nil
+}
+
+// LocationHeatmap counts the account's listed locations within box by the
+// geohash cell, resolution characters long, that holds their coordinates, or
+// their shop's. Locations without coordinates aren't counted. There is no
+// geo index yet, so the account's partition is read with a latitude filter,
+// projected to the coordinates, and the cells are tallied here; only the
+// counts leave the function. It returns an error rather than more than
+// MaxHeatmapCells cells.
+func (r *DynamoDBRepository) LocationHeatmap(ctx context.Context, accountID string, box geo.BoundingBox, resolution int) (*Heatmap, error) {
+	if accountID == "" {
+		return nil, fmt.Errorf("validation failed: accountId is required")
+	}
+	if err := box.Validate(); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	if resolution < 1 || resolution > MaxHeatmapResolution {
+		return nil, fmt.Errorf("validation failed: resolution must be between 1 and %d", MaxHeatmapResolution)
+	}
+
+	input := &dynamodb.QueryInput{
+		TableName:            aws.String(r.tableName),
+		FilterExpression:     aws.String(heatmapFilter),
+		ProjectionExpression: aws.String(heatmapProjection),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":minLat": &types.AttributeValueMemberN{Value: formatFloat(box.MinLatitude)},
+			":maxLat": &types.AttributeValueMemberN{Value: formatFloat(box.MaxLatitude)},
+			":now":    r.nowValue(),
+		},
+	}
+	input.KeyConditionExpression = aws.String(r.locationPartition(":pk", accountID, input.ExpressionAttributeValues))
+
+	counts := map[string]int{}
+	total := 0
+	for {
+		result, err := r.client.Query(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query locations: %w", err)
+		}
+		for _, item := range result.Items {
+			var record heatmapRecord
+			if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal location: %w", err)
+			}
+			point := record.point()
+			if point == nil || !box.Contains(*point) {
+				continue
+			}
+			hash := geo.Geohash(*point, resolution)
+			if _, ok := counts[hash]; !ok && len(counts) == MaxHeatmapCells {
+				return nil, fmt.Errorf("validation failed: the box holds more than %d cells at resolution %d; use a coarser resolution or a smaller box", MaxHeatmapCells, resolution)
+			}
+			counts[hash]++
+			total++
+		}
+		if result.LastEvaluatedKey == nil {
+			break
+		}
+		input.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+
+	cells := make([]HeatmapCell, 0, len(counts))
+	for hash, count := range counts {
+		cell, err := geo.GeohashBox(hash)
+		if err != nil {
+			return nil, err
+		}
+		center := cell.Center()
+		cells = append(cells, HeatmapCell{Geohash: hash, Latitude: center.Latitude, Longitude: center.Longitude, Count: count})
+	}
+	sort.Slice(cells, func(i, j int) bool { return cells[i].Geohash < cells[j].Geohash })
+	return &Heatmap{Resolution: resolution, Total: total, Cells: cells}, nil
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/steverhoton/location-lambda/internal/geo"
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDynamoDBRepositoryLocationHeatmap(t *testing.T) {
+	// Springfield, IL
+	box := geo.BoundingBox{MinLatitude: 39.7, MinLongitude: -89.8, MaxLatitude: 39.9, MaxLongitude: -89.5}
+	item := func(t *testing.T, record locationRecord) map[string]types.AttributeValue {
+		record.PK = "acc-12345"
+		item, err := attributevalue.MarshalMap(record)
+		require.NoError(t, err)
+		return item
+	}
+	pinned := func(t *testing.T, latitude, longitude float64) map[string]types.AttributeValue {
+		return item(t, locationRecord{SK: "loc", LocationType: models.LocationTypeCoordinates, Coordinates: &models.Coordinates{Latitude: latitude, Longitude: longitude}})
+	}
+	shop := func(t *testing.T, latitude, longitude float64) map[string]types.AttributeValue {
+		return item(t, locationRecord{SK: "shop", LocationType: models.LocationTypeShop, Shop: &shopAttribute{
+			Name: "Main Street Store", Coordinates: &models.Coordinates{Latitude: latitude, Longitude: longitude},
+		}})
+	}
+
+	t.Run("Counts locations by geohash cell", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		mockClient.On("Query", ctx, mock.MatchedBy(func(input *dynamodb.QueryInput) bool {
+			return input.ExpressionAttributeValues[":pk"].(*types.AttributeValueMemberS).Value == "acc-12345" &&
+				aws.ToString(input.FilterExpression) == heatmapFilter &&
+				aws.ToString(input.ProjectionExpression) == heatmapProjection &&
+				input.ExpressionAttributeValues[":minLat"].(*types.AttributeValueMemberN).Value == "39.7" &&
+				input.ExpressionAttributeValues[":maxLat"].(*types.AttributeValueMemberN).Value == "39.9"
+		})).Return(&dynamodb.QueryOutput{Items: []map[string]types.AttributeValue{
+			pinned(t, 39.7817, -89.6501),
+			shop(t, 39.7820, -89.6505),
+			pinned(t, 39.8500, -89.5600),
+			// Inside the latitude band but east of the box
+			pinned(t, 39.7817, -89.4000),
+			item(t, locationRecord{SK: "unpinned", LocationType: models.LocationTypeAddress, Address: &models.Address{City: "Springfield"}}),
+		}}, nil).Once()
+
+		heatmap, err := repo.LocationHeatmap(ctx, "acc-12345", box, 5)
+		require.NoError(t, err)
+		assert.Equal(t, 5, heatmap.Resolution)
+		assert.Equal(t, 3, heatmap.Total)
+		require.Len(t, heatmap.Cells, 2)
+		downtown := geo.Geohash(models.Coordinates{Latitude: 39.7817, Longitude: -89.6501}, 5)
+		assert.Equal(t, downtown, heatmap.Cells[0].Geohash)
+		assert.Equal(t, 2, heatmap.Cells[0].Count)
+		cell, err := geo.GeohashBox(downtown)
+		require.NoError(t, err)
+		assert.Equal(t, cell.Center(), models.Coordinates{Latitude: heatmap.Cells[0].Latitude, Longitude: heatmap.Cells[0].Longitude})
+		assert.Equal(t, 1, heatmap.Cells[1].Count)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Refuses too many cells", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		items := make([]map[string]types.AttributeValue, 0, MaxHeatmapCells+1)
+		// Resolution 8 cells are about 0.00017 degrees tall and 0.00034 wide
+		for i := 0; i <= MaxHeatmapCells; i++ {
+			items = append(items, pinned(t, 39.7+float64(i%1000)*0.0002, -89.7+float64(i/1000)*0.0004))
+		}
+		mockClient.On("Query", ctx, mock.Anything).Return(&dynamodb.QueryOutput{Items: items}, nil).Once()
+
+		_, err := repo.LocationHeatmap(ctx, "acc-12345", box, MaxHeatmapResolution)
+		assert.EqualError(t, err, "validation failed: the box holds more than 5000 cells at resolution 8; use a coarser resolution or a smaller box")
+	})
+
+	t.Run("Validates its arguments", func(t *testing.T) {
+		repo := NewDynamoDBRepository(new(mockDynamoDBClient), "test-table")
+
+		tests := []struct {
+			name       string
+			box        geo.BoundingBox
+			resolution int
+			errMsg     string
+		}{
+			{name: "Resolution too fine", box: box, resolution: 9, errMsg: "validation failed: resolution must be between 1 and 8"},
+			{name: "No resolution", box: box, resolution: 0, errMsg: "validation failed: resolution must be between 1 and 8"},
+			{name: "Latitudes reversed", box: geo.BoundingBox{MinLatitude: 40, MaxLatitude: 39}, resolution: 5, errMsg: "validation failed: minLatitude must not be greater than maxLatitude"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := repo.LocationHeatmap(context.Background(), "acc-12345", tt.box, tt.resolution)
+				assert.EqualError(t, err, tt.errMsg)
+			})
+		}
+	})
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// MaxSubAccounts caps the descendants an account's lists can span.
+const MaxSubAccounts = 200
+
+// AccountHierarchy finds the sub-accounts of an account, those whose settings
+// name it, or one of its sub-accounts, as their parentAccountId.
+type AccountHierarchy interface {
+	ChildAccounts(ctx context.Context, accountID string) ([]string, error)
+	SubAccounts(ctx context.Context, accountID string) ([]string, error)
+}
+
+// WithParentAccountIndex enables the account hierarchy with the sparse GSI
+// keyed on the parentAccountId of account settings.
+func WithParentAccountIndex(indexName string) Option {
+	return func(r *DynamoDBRepository) {
+		r.parentAccountIndex = indexName
+	}
+}
+
+// ChildAccounts returns the IDs of the accounts whose parent is accountID,
+// sorted. The index is eventually consistent.
+func (r *DynamoDBRepository) ChildAccounts(ctx context.Context, accountID string) ([]string, error) {
+	if r.parentAccountIndex == "" {
+		return nil, fmt.Errorf("parent account index is not configured")
+	}
+
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		IndexName:              aws.String(r.parentAccountIndex),
+		KeyConditionExpression: aws.String("parentAccountId = :parent"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":parent": &types.AttributeValueMemberS{Value: accountID},
+		},
+	}
+
+	children := []string{}
+	for {
+		result, err := r.client.Query(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query parent account index: %w", err)
+		}
+		for _, item := range result.Items {
+			if pk, ok := item["PK"].(*types.AttributeValueMemberS); ok {
+				children = append(children, strings.TrimPrefix(pk.Value, accountPKPrefix))
+			}
+		}
+		if result.LastEvaluatedKey == nil {
+			sort.Strings(children)
+			return children, nil
+		}
+		input.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+}
+
+// SubAccounts returns every descendant of accountID, breadth first.
+func (r *DynamoDBRepository) SubAccounts(ctx context.Context, accountID string) ([]string, error) {
+	return subAccounts(ctx, accountID, r.ChildAccounts)
+}
+
+// subAccounts walks the hierarchy below accountID breadth first, one child
+// query per account, so siblings keep a stable order between calls. An
+// account is visited once even if settings were saved with a cycle.
+func subAccounts(ctx context.Context, accountID string, children func(context.Context, string) ([]string, error)) ([]string, error) {
+	seen := map[string]bool{accountID: true}
+	descendants := []string{}
+	for queue := []string{accountID}; len(queue) > 0; queue = queue[1:] {
+		found, err := children(ctx, queue[0])
+		if err != nil {
+			return nil, err
+		}
+		for _, child := range found {
+			if seen[child] {
+				continue
+			}
+			if len(descendants) == MaxSubAccounts {
+				return nil, fmt.Errorf("account %s has more than %d sub-accounts", accountID, MaxSubAccounts)
+			}
+			seen[child] = true
+			descendants = append(descendants, child)
+			queue = append(queue, child)
+		}
+	}
+	return descendants, nil
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// settingsKey returns the key attributes of an account's settings item.
+func settingsKey(accountID string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberS{Value: accountPKPrefix + accountID},
+		"SK": &types.AttributeValueMemberS{Value: settingsSK},
+	}
+}
+
+func TestDynamoDBRepositoryChildAccounts(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Queries the index across pages", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table", WithParentAccountIndex("parent-index"))
+
+		matchesQuery := func(first bool) interface{} {
+			return mock.MatchedBy(func(input *dynamodb.QueryInput) bool {
+				return aws.ToString(input.IndexName) == "parent-index" &&
+					input.ExpressionAttributeValues[":parent"].(*types.AttributeValueMemberS).Value == "acc-hq" &&
+					(input.ExclusiveStartKey == nil) == first
+			})
+		}
+		mockClient.On("Query", ctx, matchesQuery(true)).Return(&dynamodb.QueryOutput{
+			Items:            []map[string]types.AttributeValue{settingsKey("acc-west")},
+			LastEvaluatedKey: settingsKey("acc-west"),
+		}, nil).Once()
+		mockClient.On("Query", ctx, matchesQuery(false)).Return(&dynamodb.QueryOutput{
+			Items: []map[string]types.AttributeValue{settingsKey("acc-east")},
+		}, nil).Once()
+
+		children, err := repo.ChildAccounts(ctx, "acc-hq")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"acc-east", "acc-west"}, children)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Index not configured", func(t *testing.T) {
+		repo := NewDynamoDBRepository(new(mockDynamoDBClient), "test-table")
+		_, err := repo.ChildAccounts(ctx, "acc-hq")
+		assert.EqualError(t, err, "parent account index is not configured")
+	})
+}
+
+func TestSubAccounts(t *testing.T) {
+	ctx := context.Background()
+	tree := func(edges map[string][]string) func(context.Context, string) ([]string, error) {
+		return func(_ context.Context, accountID string) ([]string, error) {
+			return edges[accountID], nil
+		}
+	}
+
+	t.Run("Breadth first", func(t *testing.T) {
+		descendants, err := subAccounts(ctx, "acc-hq", tree(map[string][]string{
+			"acc-hq":   {"acc-east", "acc-west"},
+			"acc-east": {"acc-boston"},
+			"acc-west": {"acc-denver"},
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"acc-east", "acc-west", "acc-boston", "acc-denver"}, descendants)
+	})
+
+	t.Run("No sub-accounts", func(t *testing.T) {
+		descendants, err := subAccounts(ctx, "acc-solo", tree(nil))
+		require.NoError(t, err)
+		assert.Empty(t, descendants)
+	})
+
+	t.Run("Cycles are visited once", func(t *testing.T) {
+		descendants, err := subAccounts(ctx, "acc-hq", tree(map[string][]string{
+			"acc-hq":   {"acc-east"},
+			"acc-east": {"acc-hq", "acc-east"},
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"acc-east"}, descendants)
+	})
+
+	t.Run("Too many sub-accounts", func(t *testing.T) {
+		children := make([]string, MaxSubAccounts+1)
+		for i := range children {
+			children[i] = fmt.Sprintf("acc-%03d", i)
+		}
+		_, err := subAccounts(ctx, "acc-hq", tree(map[string][]string{"acc-hq": children}))
+		assert.EqualError(t, err, fmt.Sprintf("account acc-hq has more than %d sub-accounts", MaxSubAccounts))
+	})
+
+	t.Run("Query error", func(t *testing.T) {
+		_, err := subAccounts(ctx, "acc-hq", func(context.Context, string) ([]string, error) {
+			return nil, errors.New("throttled")
+		})
+		assert.EqualError(t, err, "throttled")
+	})
+}
+
+func TestRoutingRepositoryChildAccounts(t *testing.T) {
+	ctx := context.Background()
+	homeClient, euClient := new(mockDynamoDBClient), new(mockDynamoDBClient)
+	repo := NewRoutingRepository(
+		NewDynamoDBRepository(homeClient, "locations", WithParentAccountIndex("parent-index")),
+		map[string]Repository{"eu-west-1": NewDynamoDBRepository(euClient, "locations-eu", WithParentAccountIndex("parent-index"))},
+		map[string]string{"acc-paris": "eu-west-1"},
+	)
+
+	homeClient.On("Query", ctx, mock.Anything).Return(&dynamodb.QueryOutput{
+		Items: []map[string]types.AttributeValue{settingsKey("acc-boston")},
+	}, nil).Once()
+	euClient.On("Query", ctx, mock.Anything).Return(&dynamodb.QueryOutput{
+		Items: []map[string]types.AttributeValue{settingsKey("acc-paris")},
+	}, nil).Once()
+
+	children, err := repo.ChildAccounts(ctx, "acc-hq")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"acc-boston", "acc-paris"}, children)
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go"
+)
+
+// maxFallbackPages bounds the pages, of up to 1 MB each, every segment of a
+// fallback scan reads, so a large table costs a bounded read rather than a
+// full scan.
+const maxFallbackPages = 5
+
+// WithIndexFallback serves reads whose GSI can't be read, because it is
+// backfilling, throttled, or missing, from the base table instead of failing
+// them: the sharded List from the account's partition, and the website and
+// contact finders from a scan of up to segments parallel segments. A segment
+// count of 0 leaves the fallback off.
+func WithIndexFallback(segments int) Option {
+	return func(r *DynamoDBRepository) {
+		r.fallbackSegments = segments
+	}
+}
+
+// indexUnavailable reports whether err means a GSI can't be read for now,
+// rather than that the request itself is wrong.
+func (r *DynamoDBRepository) indexUnavailable(err error) bool {
+	if r.fallbackSegments <= 0 {
+		return false
+	}
+	if isThrottlingError(err) {
+		return true
+	}
+	// Backfilling, missing, and deleting indexes fail validation, naming the index
+	var apiErr smithy.APIError
+	return errors.As(err, &apiErr) && apiErr.ErrorCode() == "ValidationException" &&
+		strings.Contains(strings.ToLower(apiErr.ErrorMessage()), "index")
+}
+
+// scanFallback scans the table in parallel segments for items matching
+// filter, which must hold the read's key condition, returning up to limit
+// locations ordered by SK. Each segment stops after maxFallbackPages pages,
+// so on a large table it can miss matches.
+func (r *DynamoDBRepository) scanFallback(ctx context.Context, filter string, values map[string]types.AttributeValue, limit int) ([]LocationEnvelope, error) {
+	segments := r.fallbackSegments
+	found := make([][]map[string]types.AttributeValue, segments)
+	errs := make([]error, segments)
+	var wg sync.WaitGroup
+	for segment := 0; segment < segments; segment++ {
+		wg.Add(1)
+		go func(segment int) {
+			defer wg.Done()
+			input := &dynamodb.ScanInput{
+				TableName:                 aws.String(r.tableName),
+				FilterExpression:          aws.String(filter),
+				ExpressionAttributeValues: values,
+				Segment:                   aws.Int32(int32(segment)),
+				TotalSegments:             aws.Int32(int32(segments)),
+			}
+			for page := 0; page < maxFallbackPages && len(found[segment]) < limit; page++ {
+				result, err := r.client.Scan(ctx, input)
+				if err != nil {
+					errs[segment] = err
+					return
+				}
+				found[segment] = append(found[segment], result.Items...)
+				if result.LastEvaluatedKey == nil {
+					return
+				}
+				input.ExclusiveStartKey = result.LastEvaluatedKey
+			}
+		}(segment)
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	var items []map[string]types.AttributeValue
+	for _, segmentItems := range found {
+		items = append(items, segmentItems...)
+	}
+	sortKey := func(item map[string]types.AttributeValue) string {
+		if sk, ok := item["SK"].(*types.AttributeValueMemberS); ok {
+			return sk.Value
+		}
+		return ""
+	}
+	sort.Slice(items, func(i, j int) bool { return sortKey(items[i]) < sortKey(items[j]) })
+	if len(items) > limit {
+		items = items[:limit]
+	}
+	markIndexFallback(ctx)
+	return r.itemsToEnvelopes(ctx, items)
+}
@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// backfillingErr is the error DynamoDB returns when querying a GSI that is still being built.
+var backfillingErr = &smithy.GenericAPIError{Code: "ValidationException", Message: "Cannot read from backfilling global secondary index: ContactIndex"}
+
+func TestIndexUnavailable(t *testing.T) {
+	repo := NewDynamoDBRepository(new(mockDynamoDBClient), "test-table", WithIndexFallback(2))
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "Backfilling index", err: backfillingErr, want: true},
+		{name: "Missing index", err: &smithy.GenericAPIError{Code: "ValidationException", Message: "The table does not have the specified index: ContactIndex"}, want: true},
+		{name: "Throttled", err: &types.ProvisionedThroughputExceededException{Message: aws.String("throttled")}, want: true},
+		{name: "Invalid expression", err: &smithy.GenericAPIError{Code: "ValidationException", Message: "Invalid FilterExpression"}},
+		{name: "Other error", err: errors.New("connection reset")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, repo.indexUnavailable(tt.err))
+		})
+	}
+
+	t.Run("Fallback off", func(t *testing.T) {
+		assert.False(t, NewDynamoDBRepository(new(mockDynamoDBClient), "test-table").indexUnavailable(backfillingErr))
+	})
+}
+
+func TestFindByContactIDScanFallback(t *testing.T) {
+	shopItem := func(locationID string) map[string]types.AttributeValue {
+		item := coordinatesItem("acc-12345", locationID)
+		item["accountContact"] = &types.AttributeValueMemberS{Value: "acc-12345#contact-1"}
+		return item
+	}
+
+	t.Run("Scans in parallel segments when the index can't be read", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table", WithContactIndex("ContactIndex"), WithIndexFallback(2))
+		ctx, info := WithReadInfo(context.Background())
+
+		mockClient.On("Query", ctx, mock.Anything).Return(nil, backfillingErr).Once()
+		segment := func(n int32) interface{} {
+			return mock.MatchedBy(func(input *dynamodb.ScanInput) bool {
+				return aws.ToInt32(input.Segment) == n && aws.ToInt32(input.TotalSegments) == 2 &&
+					aws.ToString(input.FilterExpression) == "accountContact = :key AND "+notMergedFilter
+			})
+		}
+		mockClient.On("Scan", ctx, segment(0)).Return(&dynamodb.ScanOutput{Items: []map[string]types.AttributeValue{shopItem("loc-003")}}, nil).Once()
+		// Another account's shop under a key written before account IDs were escaped
+		other := coordinatesItem("acc-12345#x", "loc-002")
+		mockClient.On("Scan", ctx, segment(1)).Return(&dynamodb.ScanOutput{Items: []map[string]types.AttributeValue{shopItem("loc-001"), other}}, nil).Once()
+
+		locations, err := repo.FindByContactID(ctx, "acc-12345", "contact-1")
+		require.NoError(t, err)
+		require.Len(t, locations, 2)
+		assert.Equal(t, "loc-001", locations[0].LocationID)
+		assert.Equal(t, "loc-003", locations[1].LocationID)
+		assert.True(t, info.IndexFallback)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Stops each segment after its page budget", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table", WithContactIndex("ContactIndex"), WithIndexFallback(1))
+		ctx := context.Background()
+
+		mockClient.On("Query", ctx, mock.Anything).Return(nil, backfillingErr).Once()
+		mockClient.On("Scan", ctx, mock.Anything).Return(&dynamodb.ScanOutput{
+			LastEvaluatedKey: map[string]types.AttributeValue{"PK": &types.AttributeValueMemberS{Value: "acc-12345"}},
+		}, nil).Times(maxFallbackPages)
+
+		locations, err := repo.FindByContactID(ctx, "acc-12345", "contact-1")
+		require.NoError(t, err)
+		assert.Empty(t, locations)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Fails without the fallback", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table", WithContactIndex("ContactIndex"))
+		ctx := context.Background()
+		mockClient.On("Query", ctx, mock.Anything).Return(nil, backfillingErr).Once()
+
+		_, err := repo.FindByContactID(ctx, "acc-12345", "contact-1")
+		assert.ErrorContains(t, err, "failed to query contact index")
+		mockClient.AssertNotCalled(t, "Scan", mock.Anything, mock.Anything)
+	})
+}
+
+func TestListShardedFallback(t *testing.T) {
+	ctx := context.Background()
+	cfg := ShardConfig{ShardCount: 2, IndexName: "AccountShardIndex"}
+	partitionQuery := func(startSK string) interface{} {
+		return mock.MatchedBy(func(input *dynamodb.QueryInput) bool {
+			if input.IndexName != nil {
+				return false
+			}
+			if startSK == "" {
+				return input.ExclusiveStartKey == nil
+			}
+			sk, ok := input.ExclusiveStartKey["SK"].(*types.AttributeValueMemberS)
+			return ok && sk.Value == startSK
+		})
+	}
+
+	t.Run("Reads the page from the partition and resumes the shards after it", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table", WithSharding(cfg), WithIndexFallback(2))
+
+		mockClient.On("Query", ctx, matchShard("acc-12345#shard0")).Return(nil, backfillingErr).Once()
+		mockClient.On("Query", ctx, matchShard("acc-12345#shard1")).Return(nil, backfillingErr).Once()
+		mockClient.On("Query", ctx, partitionQuery("")).Return(&dynamodb.QueryOutput{
+			Items:            []map[string]types.AttributeValue{coordinatesItem("acc-12345", "loc-001")},
+			LastEvaluatedKey: map[string]types.AttributeValue{"PK": &types.AttributeValueMemberS{Value: "acc-12345"}, "SK": &types.AttributeValueMemberS{Value: "loc-001"}},
+		}, nil).Once()
+
+		page, err := repo.List(ctx, "acc-12345", &ListOptions{Limit: aws.Int32(1)})
+		require.NoError(t, err)
+		assert.True(t, page.IndexFallback)
+		assert.Equal(t, []string{"loc-001"}, page.LocationIDs())
+		require.NotNil(t, page.NextCursor)
+
+		// The index is back: both shards resume after loc-001
+		resumes := func(key string) interface{} {
+			return mock.MatchedBy(func(input *dynamodb.QueryInput) bool {
+				v, ok := input.ExpressionAttributeValues[":shard"].(*types.AttributeValueMemberS)
+				sk, _ := input.ExclusiveStartKey["SK"].(*types.AttributeValueMemberS)
+				return ok && v.Value == key && sk != nil && sk.Value == "loc-001"
+			})
+		}
+		mockClient.On("Query", ctx, resumes("acc-12345#shard0")).Return(&dynamodb.QueryOutput{
+			Items: []map[string]types.AttributeValue{coordinatesItem("acc-12345", "loc-002")},
+		}, nil).Once()
+		mockClient.On("Query", ctx, resumes("acc-12345#shard1")).Return(&dynamodb.QueryOutput{}, nil).Once()
+
+		page, err = repo.List(ctx, "acc-12345", &ListOptions{Limit: aws.Int32(1), Cursor: page.NextCursor})
+		require.NoError(t, err)
+		assert.False(t, page.IndexFallback)
+		assert.Equal(t, []string{"loc-002"}, page.LocationIDs())
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Resumes a shard cursor from its last location", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table", WithSharding(cfg), WithIndexFallback(2))
+		cursor, err := repo.encodeCursor(&paginationCursor{PK: "acc-12345", SK: "loc-004", Shards: make([]shardCursor, 2)})
+		require.NoError(t, err)
+
+		mockClient.On("Query", ctx, mock.MatchedBy(func(input *dynamodb.QueryInput) bool {
+			return input.IndexName != nil
+		})).Return(nil, &types.ProvisionedThroughputExceededException{Message: aws.String("throttled")}).Twice()
+		mockClient.On("Query", ctx, partitionQuery("loc-004")).Return(&dynamodb.QueryOutput{
+			Items: []map[string]types.AttributeValue{coordinatesItem("acc-12345", "loc-005")},
+		}, nil).Once()
+
+		page, err := repo.List(ctx, "acc-12345", &ListOptions{Cursor: cursor})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"loc-005"}, page.LocationIDs())
+		assert.Nil(t, page.NextCursor)
+		mockClient.AssertExpectations(t)
+	})
+}
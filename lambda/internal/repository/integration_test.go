@@ -0,0 +1,358 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// Index names used by the integration table, matching the Terraform defaults.
+const (
+	integrationAccountIndex    = "AccountIndex"
+	integrationExternalIDIndex = "ExternalIdIndex"
+	integrationLocationIDIndex = "LocationIdIndex"
+	integrationShardIndex      = "AccountShardIndex"
+)
+
+// integrationClient is the DynamoDB Local client shared by the suite.
+var integrationClient *dynamodb.Client
+
+// TestMain starts DynamoDB Local for the suite. DYNAMODB_LOCAL_IMAGE selects
+// another image, such as LocalStack.
+func TestMain(m *testing.M) {
+	ctx := context.Background()
+
+	image := os.Getenv("DYNAMODB_LOCAL_IMAGE")
+	if image == "" {
+		image = "amazon/dynamodb-local:2.5.2"
+	}
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        image,
+			ExposedPorts: []string{"8000/tcp"},
+			WaitingFor:   wait.ForListeningPort("8000/tcp").WithStartupTimeout(time.Minute),
+		},
+		Started: true,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to start DynamoDB Local: %v\n", err)
+		os.Exit(1)
+	}
+
+	code := func() int {
+		defer func() { _ = container.Terminate(ctx) }()
+
+		host, err := container.Host(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to get container host: %v\n", err)
+			return 1
+		}
+		port, err := container.MappedPort(ctx, "8000/tcp")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to get container port: %v\n", err)
+			return 1
+		}
+
+		integrationClient = dynamodb.New(dynamodb.Options{
+			Region:       "us-east-1",
+			Credentials:  credentials.NewStaticCredentialsProvider("local", "local", ""),
+			BaseEndpoint: aws.String(fmt.Sprintf("http://%s:%s", host, port.Port())),
+		})
+		return m.Run()
+	}()
+	os.Exit(code)
+}
+
+// createIntegrationTable creates a table with the key schema and indexes the
+// Terraform module provisions, returning its name.
+func createIntegrationTable(t *testing.T) string {
+	t.Helper()
+	ctx := context.Background()
+	tableName := fmt.Sprintf("locations-%d", time.Now().UnixNano())
+
+	stringAttr := func(name string) types.AttributeDefinition {
+		return types.AttributeDefinition{AttributeName: aws.String(name), AttributeType: types.ScalarAttributeTypeS}
+	}
+	hashKey := func(name string) types.KeySchemaElement {
+		return types.KeySchemaElement{AttributeName: aws.String(name), KeyType: types.KeyTypeHash}
+	}
+	rangeKey := func(name string) types.KeySchemaElement {
+		return types.KeySchemaElement{AttributeName: aws.String(name), KeyType: types.KeyTypeRange}
+	}
+	projectAll := &types.Projection{ProjectionType: types.ProjectionTypeAll}
+
+	_, err := integrationClient.CreateTable(ctx, &dynamodb.CreateTableInput{
+		TableName:   aws.String(tableName),
+		BillingMode: types.BillingModePayPerRequest,
+		AttributeDefinitions: []types.AttributeDefinition{
+			stringAttr("PK"), stringAttr("SK"), stringAttr("accountId"),
+			stringAttr("accountExternalId"), stringAttr("accountShard"),
+		},
+		KeySchema: []types.KeySchemaElement{hashKey("PK"), rangeKey("SK")},
+		GlobalSecondaryIndexes: []types.GlobalSecondaryIndex{
+			{IndexName: aws.String(integrationAccountIndex), KeySchema: []types.KeySchemaElement{hashKey("accountId")}, Projection: projectAll},
+			{IndexName: aws.String(integrationExternalIDIndex), KeySchema: []types.KeySchemaElement{hashKey("accountExternalId")}, Projection: projectAll},
+			{
+				IndexName: aws.String(integrationLocationIDIndex),
+				KeySchema: []types.KeySchemaElement{hashKey("SK")},
+				Projection: &types.Projection{
+					ProjectionType:   types.ProjectionTypeInclude,
+					NonKeyAttributes: []string{"locationType"},
+				},
+			},
+			{IndexName: aws.String(integrationShardIndex), KeySchema: []types.KeySchemaElement{hashKey("accountShard"), rangeKey("SK")}, Projection: projectAll},
+		},
+	})
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_, _ = integrationClient.DeleteTable(context.Background(), &dynamodb.DeleteTableInput{TableName: aws.String(tableName)})
+	})
+	return tableName
+}
+
+// NewIntegrationRepository returns a repository on a fresh DynamoDB Local
+// table, for integration tests outside this package.
+func NewIntegrationRepository(t *testing.T, opts ...Option) *DynamoDBRepository {
+	return NewDynamoDBRepository(integrationClient, createIntegrationTable(t), opts...)
+}
+
+// integrationLocation is a coordinates location in accountID.
+func integrationLocation(accountID string, latitude float64) models.CoordinatesLocation {
+	return models.CoordinatesLocation{
+		LocationBase: models.LocationBase{AccountID: accountID, LocationType: models.LocationTypeCoordinates},
+		Coordinates:  models.Coordinates{Latitude: latitude, Longitude: -74.006},
+	}
+}
+
+// listAll pages through every location in an account with the given page size.
+func listAll(t *testing.T, repo Repository, accountID string, pageSize int32) []string {
+	t.Helper()
+	var ids []string
+	var cursor *string
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 100, "pagination did not terminate")
+		result, err := repo.List(context.Background(), accountID, &ListOptions{Limit: aws.Int32(pageSize), Cursor: cursor})
+		require.NoError(t, err)
+		ids = append(ids, result.LocationIDs()...)
+		if result.NextCursor == nil {
+			return ids
+		}
+		cursor = result.NextCursor
+	}
+}
+
+func TestIntegrationCRUD(t *testing.T) {
+	ctx := context.Background()
+	repo := NewDynamoDBRepository(integrationClient, createIntegrationTable(t))
+
+	created, err := repo.Create(ctx, integrationLocation("acc-1", 40.7128))
+	require.NoError(t, err)
+	locationID := created.LocationID
+
+	got, err := repo.Get(ctx, "acc-1", locationID)
+	require.NoError(t, err)
+	assert.Equal(t, 40.7128, got.Location.(models.CoordinatesLocation).Coordinates.Latitude)
+
+	t.Run("Update replaces the stored record", func(t *testing.T) {
+		require.NoError(t, repo.Update(ctx, integrationLocation("acc-1", 41.5), locationID))
+		got, err := repo.Get(ctx, "acc-1", locationID)
+		require.NoError(t, err)
+		assert.Equal(t, 41.5, got.Location.(models.CoordinatesLocation).Coordinates.Latitude)
+	})
+
+	t.Run("Update requires an existing record", func(t *testing.T) {
+		err := repo.Update(ctx, integrationLocation("acc-1", 41.5), "missing")
+		assert.Error(t, err)
+	})
+
+	t.Run("Another account cannot read or delete the record", func(t *testing.T) {
+		_, err := repo.Get(ctx, "acc-2", locationID)
+		assert.Error(t, err)
+		assert.EqualError(t, repo.Delete(ctx, "acc-2", locationID), "location not found or access denied")
+	})
+
+	t.Run("Delete removes the record once", func(t *testing.T) {
+		require.NoError(t, repo.Delete(ctx, "acc-1", locationID))
+		_, err := repo.Get(ctx, "acc-1", locationID)
+		assert.True(t, errors.Is(err, errLocationNotFound))
+		assert.EqualError(t, repo.Delete(ctx, "acc-1", locationID), "location not found or access denied")
+	})
+}
+
+func TestIntegrationListPagination(t *testing.T) {
+	ctx := context.Background()
+	tableName := createIntegrationTable(t)
+
+	t.Run("Cursors walk every location exactly once", func(t *testing.T) {
+		repo := NewDynamoDBRepository(integrationClient, tableName)
+		created := make(map[string]bool)
+		for i := 0; i < 7; i++ {
+			location, err := repo.Create(ctx, integrationLocation("acc-pages", float64(i)))
+			require.NoError(t, err)
+			created[location.LocationID] = true
+		}
+		// Other accounts and account config items share the table but not the partition
+		_, err := repo.Create(ctx, integrationLocation("acc-other", 1))
+		require.NoError(t, err)
+		require.NoError(t, repo.PutCustomFieldDefinitions(ctx, "acc-pages", []models.CustomFieldDefinition{
+			{Name: "storeNumber", Type: models.CustomFieldTypeString},
+		}))
+
+		for _, pageSize := range []int32{1, 3, 7, 50} {
+			ids := listAll(t, repo, "acc-pages", pageSize)
+			assert.Len(t, ids, len(created), "page size %d", pageSize)
+			for _, id := range ids {
+				assert.True(t, created[id], "page size %d returned unexpected %s", pageSize, id)
+			}
+		}
+	})
+
+	t.Run("A malformed cursor is rejected", func(t *testing.T) {
+		repo := NewDynamoDBRepository(integrationClient, tableName)
+		_, err := repo.List(ctx, "acc-pages", &ListOptions{Cursor: aws.String("not-a-cursor")})
+		assert.ErrorContains(t, err, "failed to decode cursor")
+	})
+
+	t.Run("Sharded listing merges every shard", func(t *testing.T) {
+		repo := NewDynamoDBRepository(integrationClient, tableName, WithSharding(ShardConfig{ShardCount: 4, IndexName: integrationShardIndex}))
+		created := make(map[string]bool)
+		for i := 0; i < 9; i++ {
+			location, err := repo.Create(ctx, integrationLocation("acc-sharded", float64(i)))
+			require.NoError(t, err)
+			created[location.LocationID] = true
+		}
+
+		for _, pageSize := range []int32{2, 5, 20} {
+			ids := listAll(t, repo, "acc-sharded", pageSize)
+			assert.Len(t, ids, len(created), "page size %d", pageSize)
+			for _, id := range ids {
+				assert.True(t, created[id], "page size %d returned unexpected %s", pageSize, id)
+			}
+		}
+	})
+}
+
+func TestIntegrationExternalIDs(t *testing.T) {
+	ctx := context.Background()
+	repo := NewDynamoDBRepository(integrationClient, createIntegrationTable(t))
+
+	withExternalID := func(externalID string, latitude float64) models.CoordinatesLocation {
+		loc := integrationLocation("acc-ext", latitude)
+		loc.ExternalID = externalID
+		return loc
+	}
+
+	created, err := repo.Create(ctx, withExternalID("ERP-1", 1))
+	require.NoError(t, err)
+	first := created.LocationID
+
+	t.Run("A second location cannot claim the same external ID", func(t *testing.T) {
+		_, err := repo.Create(ctx, withExternalID("ERP-1", 2))
+		var conflict *ExternalIDConflictError
+		require.ErrorAs(t, err, &conflict)
+		assert.Equal(t, first, conflict.LocationID)
+	})
+
+	t.Run("Upsert updates the claimed location and creates unclaimed ones", func(t *testing.T) {
+		result, err := repo.Upsert(ctx, withExternalID("ERP-1", 3))
+		require.NoError(t, err)
+		assert.Equal(t, &UpsertResult{LocationID: first}, result)
+
+		result, err = repo.Upsert(ctx, withExternalID("ERP-2", 4))
+		require.NoError(t, err)
+		assert.True(t, result.Created)
+
+		got, err := repo.GetByExternalID(ctx, "acc-ext", "ERP-1")
+		require.NoError(t, err)
+		assert.Equal(t, first, got.LocationID)
+		assert.Equal(t, 3.0, got.Location.(models.CoordinatesLocation).Coordinates.Latitude)
+	})
+
+	t.Run("Changing the external ID moves the claim", func(t *testing.T) {
+		require.NoError(t, repo.Update(ctx, withExternalID("ERP-3", 3), first))
+
+		got, err := repo.GetByExternalID(ctx, "acc-ext", "ERP-3")
+		require.NoError(t, err)
+		assert.Equal(t, first, got.LocationID)
+
+		// The released external ID can be claimed again
+		_, err = repo.Create(ctx, withExternalID("ERP-1", 5))
+		assert.NoError(t, err)
+	})
+
+	t.Run("Deleting a location releases its external ID", func(t *testing.T) {
+		require.NoError(t, repo.Delete(ctx, "acc-ext", first))
+		_, err := repo.Create(ctx, withExternalID("ERP-3", 6))
+		assert.NoError(t, err)
+	})
+}
+
+func TestIntegrationTypeChange(t *testing.T) {
+	ctx := context.Background()
+	repo := NewDynamoDBRepository(integrationClient, createIntegrationTable(t))
+
+	address := models.Address{StreetAddress: "123 Main St", City: "Springfield", PostalCode: "62704", Country: "US"}
+	created, err := repo.Create(ctx, models.AddressLocation{
+		LocationBase: models.LocationBase{AccountID: "acc-type", LocationType: models.LocationTypeAddress, ExternalID: "ERP-TYPE"},
+		Address:      address,
+	})
+	require.NoError(t, err)
+	shop := models.ShopLocation{
+		LocationBase: models.LocationBase{AccountID: "acc-type", LocationType: models.LocationTypeShop, ExternalID: "ERP-TYPE"},
+		Shop:         models.Shop{Name: "Main Street Store", ContactID: "contact-1", Address: address},
+	}
+
+	var typeErr *LocationTypeError
+	require.ErrorAs(t, repo.Update(ctx, shop, created.LocationID), &typeErr)
+
+	change, err := repo.ChangeType(ctx, shop, created.LocationID)
+	require.NoError(t, err)
+	assert.Equal(t, models.LocationTypeAddress, change.FromType)
+
+	got, err := repo.GetByExternalID(ctx, "acc-type", "ERP-TYPE")
+	require.NoError(t, err)
+	assert.Equal(t, created.LocationID, got.LocationID)
+	assert.Equal(t, shop, got.Location)
+}
+
+func TestIntegrationTransfer(t *testing.T) {
+	ctx := context.Background()
+	repo := NewDynamoDBRepository(integrationClient, createIntegrationTable(t), WithLocationIDIndex(integrationLocationIDIndex))
+
+	loc := integrationLocation("acc-from", 10)
+	loc.ExternalID = "ERP-MOVE"
+	created, err := repo.Create(ctx, loc)
+	require.NoError(t, err)
+	locationID := created.LocationID
+
+	require.NoError(t, repo.Transfer(ctx, "acc-from", locationID, "acc-to"))
+
+	_, err = repo.Get(ctx, "acc-from", locationID)
+	assert.True(t, errors.Is(err, errLocationNotFound))
+	got, err := repo.Get(ctx, "acc-to", locationID)
+	require.NoError(t, err)
+	assert.Equal(t, "acc-to", got.Location.GetAccountID())
+
+	byExternalID, err := repo.GetByExternalID(ctx, "acc-to", "ERP-MOVE")
+	require.NoError(t, err)
+	assert.Equal(t, locationID, byExternalID.LocationID)
+
+	// A second transfer from the old owner finds nothing to move
+	assert.EqualError(t, repo.Transfer(ctx, "acc-from", locationID, "acc-to"), "location not found or access denied")
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/steverhoton/location-lambda/internal/canonicaljson"
+	"github.com/steverhoton/location-lambda/internal/models"
+)
+
+// WithContentHashVerification checks each location read against the content
+// hash stored with it, failing reads of records changed outside the
+// repository. Records written before content hashes have none and are not
+// checked.
+func WithContentHashVerification() Option {
+	return func(r *DynamoDBRepository) {
+		r.verifyContentHash = true
+	}
+}
+
+// IntegrityError is returned when a location read no longer matches the
+// content hash stored with it.
+type IntegrityError struct {
+	LocationID string
+}
+
+// Error implements the error interface.
+func (e *IntegrityError) Error() string {
+	return fmt.Sprintf("location %s failed its integrity check: content does not match its hash", e.LocationID)
+}
+
+// PreconditionFailedError is returned when a conditional update's ifMatch no
+// longer matches the stored location's ETag.
+type PreconditionFailedError struct {
+	// ETag is the stored location's current ETag, empty for records written
+	// before content hashes
+	ETag string
+}
+
+// Error implements the error interface.
+func (e *PreconditionFailedError) Error() string {
+	return "location was modified: ifMatch does not match its current etag"
+}
+
+type ifMatchKey struct{}
+
+// WithIfMatch returns a context that makes Update conditional on the stored
+// location still having etag, the ETag it was read with.
+func WithIfMatch(ctx context.Context, etag string) context.Context {
+	return context.WithValue(ctx, ifMatchKey{}, etag)
+}
+
+// ifMatchFrom returns the ETag an update made with ctx expects, if any.
+func ifMatchFrom(ctx context.Context) (string, bool) {
+	etag, ok := ctx.Value(ifMatchKey{}).(string)
+	return etag, ok
+}
+
+// ifMatchCondition returns a condition that the stored record has the ETag
+// ctx expects, adding its value to values, or "" when ctx expects none.
+func ifMatchCondition(ctx context.Context, values map[string]types.AttributeValue) string {
+	etag, ok := ifMatchFrom(ctx)
+	if !ok {
+		return ""
+	}
+	values[":ifMatch"] = &types.AttributeValueMemberS{Value: etag}
+	return " AND contentHash = :ifMatch"
+}
+
+// contentHash returns the SHA-256 of a location's canonical JSON, which the
+// record stores as its content hash and callers see as its ETag.
+func contentHash(location models.Location) (string, error) {
+	hash, err := canonicaljson.Hash(location)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash location: %w", err)
+	}
+	return hash, nil
+}
+
+// setContentHash hashes the location a record holds. The record must be as
+// readers see it, before encryption and overflow.
+func setContentHash(record *locationRecord) error {
+	location, err := record.toLocation()
+	if err != nil {
+		return fmt.Errorf("failed to convert record to location: %w", err)
+	}
+	record.ContentHash, err = contentHash(location)
+	return err
+}
+
+// checkContentHash verifies a hydrated record against its content hash when
+// the repository verifies reads.
+func (r *DynamoDBRepository) checkContentHash(record *locationRecord) error {
+	if !r.verifyContentHash || record.ContentHash == "" {
+		return nil
+	}
+	location, err := record.toLocation()
+	if err != nil {
+		return fmt.Errorf("failed to convert record to location: %w", err)
+	}
+	hash, err := contentHash(location)
+	if err != nil {
+		return err
+	}
+	if hash != record.ContentHash {
+		return &IntegrityError{LocationID: record.SK}
+	}
+	return nil
+}
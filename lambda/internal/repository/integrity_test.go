@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDynamoDBRepositoryContentHash(t *testing.T) {
+	ctx := context.Background()
+	location := models.CoordinatesLocation{
+		LocationBase: models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeCoordinates},
+		Coordinates:  models.Coordinates{Latitude: 40.7128, Longitude: -74.006},
+	}
+	hash, err := contentHash(location)
+	require.NoError(t, err)
+
+	// storedItem is location as Create writes it, content hash included
+	storedItem := func(t *testing.T) map[string]types.AttributeValue {
+		record, err := toLocationRecord(location, "loc-001")
+		require.NoError(t, err)
+		require.NoError(t, setContentHash(record))
+		item, err := attributevalue.MarshalMap(record)
+		require.NoError(t, err)
+		return item
+	}
+
+	t.Run("Create stores the content hash and returns it as the etag", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+		mockClient.On("PutItem", ctx, mock.MatchedBy(func(input *dynamodb.PutItemInput) bool {
+			stored, ok := input.Item["contentHash"].(*types.AttributeValueMemberS)
+			return ok && stored.Value == hash
+		})).Return(&dynamodb.PutItemOutput{}, nil).Once()
+
+		created, err := repo.Create(ctx, location)
+		require.NoError(t, err)
+		assert.Equal(t, hash, created.ETag)
+		assert.Len(t, created.ETag, 64)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Get returns the etag", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table", WithContentHashVerification())
+		mockClient.On("GetItem", ctx, mock.Anything).Return(&dynamodb.GetItemOutput{Item: storedItem(t)}, nil).Once()
+
+		envelope, err := repo.Get(ctx, "acc-12345", "loc-001")
+		require.NoError(t, err)
+		assert.Equal(t, hash, envelope.ETag)
+	})
+
+	t.Run("Verification fails reads of changed records", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table", WithContentHashVerification())
+		item := storedItem(t)
+		item["floor"] = &types.AttributeValueMemberS{Value: "3"}
+		mockClient.On("GetItem", ctx, mock.Anything).Return(&dynamodb.GetItemOutput{Item: item}, nil).Once()
+
+		_, err := repo.Get(ctx, "acc-12345", "loc-001")
+		var integrityErr *IntegrityError
+		require.True(t, errors.As(err, &integrityErr))
+		assert.Equal(t, "loc-001", integrityErr.LocationID)
+	})
+
+	t.Run("Reads are not verified by default or without a hash", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		item := storedItem(t)
+		item["floor"] = &types.AttributeValueMemberS{Value: "3"}
+		mockClient.On("GetItem", ctx, mock.Anything).Return(&dynamodb.GetItemOutput{Item: item}, nil).Once()
+		mockClient.On("GetItem", ctx, mock.Anything).Return(&dynamodb.GetItemOutput{Item: coordinatesItem("acc-12345", "loc-002")}, nil).Once()
+
+		_, err := NewDynamoDBRepository(mockClient, "test-table").Get(ctx, "acc-12345", "loc-001")
+		assert.NoError(t, err)
+		envelope, err := NewDynamoDBRepository(mockClient, "test-table", WithContentHashVerification()).Get(ctx, "acc-12345", "loc-002")
+		require.NoError(t, err)
+		assert.Empty(t, envelope.ETag)
+	})
+
+	t.Run("Update is conditional on ifMatch", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+		ctx := WithIfMatch(ctx, "etag-1")
+		mockClient.On("PutItem", ctx, mock.MatchedBy(func(input *dynamodb.PutItemInput) bool {
+			etag, ok := input.ExpressionAttributeValues[":ifMatch"].(*types.AttributeValueMemberS)
+			return ok && etag.Value == "etag-1" && aws.ToString(input.ConditionExpression) ==
+				"attribute_exists(PK) AND attribute_exists(SK) AND PK = :accountId AND locationType = :locationType AND attribute_not_exists(mergedInto) AND attribute_not_exists(externalId) AND attribute_not_exists(draft) AND contentHash = :ifMatch"
+		})).Return(&dynamodb.PutItemOutput{}, nil).Once()
+
+		require.NoError(t, repo.Update(ctx, location, "loc-001"))
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Update reports a stale ifMatch", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+		ctx := WithIfMatch(ctx, "etag-1")
+		mockClient.On("PutItem", ctx, mock.Anything).Return(nil, &types.ConditionalCheckFailedException{Message: aws.String("The conditional request failed")}).Once()
+		mockClient.On("GetItem", ctx, mock.Anything).Return(&dynamodb.GetItemOutput{Item: storedItem(t)}, nil).Once()
+
+		err := repo.Update(ctx, location, "loc-001")
+		var precondition *PreconditionFailedError
+		require.True(t, errors.As(err, &precondition))
+		assert.Equal(t, hash, precondition.ETag)
+		mockClient.AssertNotCalled(t, "TransactWriteItems", mock.Anything, mock.Anything)
+	})
+}
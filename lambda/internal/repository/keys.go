@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// KeyLayout is how location items are keyed in the table. The table's other
+// items, such as settings under ACCOUNT#accountId or templates under
+// TEMPLATE#accountId, are keyed the same way in every layout.
+type KeyLayout string
+
+const (
+	// KeyLayoutClassic keys a location by its bare IDs: PK accountId and SK
+	// locationId.
+	KeyLayoutClassic KeyLayout = "classic"
+	// KeyLayoutSingleTable keys a location under its account's partition with
+	// entity prefixes: PK ACCOUNT#accountId and SK LOCATION#locationId. The
+	// account's settings already live in that partition, and sub-entities
+	// such as groups, notes, or versions can join it under SK prefixes of
+	// their own, such as GROUP# or NOTE#locationId#, rather than new tables.
+	KeyLayoutSingleTable KeyLayout = "single-table"
+)
+
+// locationSKPrefix starts the sort key of a location in the single-table layout.
+const locationSKPrefix = "LOCATION#"
+
+// ParseKeyLayout returns the named layout, classic when name is empty.
+func ParseKeyLayout(name string) (KeyLayout, error) {
+	switch KeyLayout(name) {
+	case "", KeyLayoutClassic:
+		return KeyLayoutClassic, nil
+	case KeyLayoutSingleTable:
+		return KeyLayoutSingleTable, nil
+	default:
+		return "", fmt.Errorf("unknown key layout %q: must be %s or %s", name, KeyLayoutClassic, KeyLayoutSingleTable)
+	}
+}
+
+// WithKeyLayout keys locations in layout. A table's layout is fixed once it
+// holds locations: the repository reads either layout's items but only
+// writes and queries its own.
+func WithKeyLayout(layout KeyLayout) Option {
+	return func(r *DynamoDBRepository) {
+		r.keyLayout = layout
+	}
+}
+
+// LocationKeys returns the account and location IDs of a location item's PK
+// and SK in either layout, reporting false for the table's other items.
+func LocationKeys(pk, sk string) (accountID, locationID string, ok bool) {
+	if !strings.Contains(pk, "#") {
+		return pk, sk, true
+	}
+	if strings.HasPrefix(pk, accountPKPrefix) && strings.HasPrefix(sk, locationSKPrefix) {
+		return strings.TrimPrefix(pk, accountPKPrefix), strings.TrimPrefix(sk, locationSKPrefix), true
+	}
+	return "", "", false
+}
+
+// locationIDOf returns the location ID of a location's SK in either layout,
+// for projections that leave out PK.
+func locationIDOf(sk string) string {
+	return strings.TrimPrefix(sk, locationSKPrefix)
+}
+
+// accountIndexEscaper escapes the characters of an account ID that would make
+// an account index key ambiguous.
+var accountIndexEscaper = strings.NewReplacer("%", "%25", "#", "%23")
+
+// accountIndexKey joins an account ID and a value into the key of an account
+// scoped GSI, such as the external ID index. The account ID's "#" and "%" are
+// escaped, so the first "#" always ends it and account a#b with value c can
+// no longer share a key with account a and value b#c, whatever the value
+// holds. Account IDs without either character keep their keys.
+func accountIndexKey(accountID, value string) string {
+	return accountIndexEscaper.Replace(accountID) + "#" + value
+}
+
+// ownLocations drops the locations of accounts other than accountID, which an
+// account index can still return under keys written before account IDs were
+// escaped.
+func ownLocations(accountID string, locations []LocationEnvelope) []LocationEnvelope {
+	owned := locations[:0]
+	for _, location := range locations {
+		if location.Location.GetAccountID() == accountID {
+			owned = append(owned, location)
+		}
+	}
+	return owned
+}
+
+// locationPK returns the partition key of an account's locations.
+func (r *DynamoDBRepository) locationPK(accountID string) string {
+	if r.keyLayout == KeyLayoutSingleTable {
+		return accountPKPrefix + accountID
+	}
+	return accountID
+}
+
+// locationSK returns the sort key of a location.
+func (r *DynamoDBRepository) locationSK(locationID string) string {
+	if r.keyLayout == KeyLayoutSingleTable {
+		return locationSKPrefix + locationID
+	}
+	return locationID
+}
+
+// locationKey returns the primary key of a location.
+func (r *DynamoDBRepository) locationKey(accountID, locationID string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberS{Value: r.locationPK(accountID)},
+		"SK": &types.AttributeValueMemberS{Value: r.locationSK(locationID)},
+	}
+}
+
+// locationPartition returns the key condition of a query for an account's
+// locations, binding its values in values with name, such as ":pk", for the
+// partition. In the single-table layout it also limits the sort key to
+// locations, leaving out the account's other items.
+func (r *DynamoDBRepository) locationPartition(name, accountID string, values map[string]types.AttributeValue) string {
+	values[name] = &types.AttributeValueMemberS{Value: r.locationPK(accountID)}
+	if r.keyLayout != KeyLayoutSingleTable {
+		return "PK = " + name
+	}
+	values[":locationPrefix"] = &types.AttributeValueMemberS{Value: locationSKPrefix}
+	return "PK = " + name + " AND begins_with(SK, :locationPrefix)"
+}
+
+// UnmarshalDynamoDBAttributeValue implements attributevalue.Unmarshaler,
+// reading the record's keys as its account and location IDs in either layout.
+func (r *locationRecord) UnmarshalDynamoDBAttributeValue(av types.AttributeValue) error {
+	type plain locationRecord
+	if err := attributevalue.Unmarshal(av, (*plain)(r)); err != nil {
+		return err
+	}
+	if r.PK == "" {
+		// A projection without PK, such as a query's "SK, shop"
+		r.SK = locationIDOf(r.SK)
+	} else if accountID, locationID, ok := LocationKeys(r.PK, r.SK); ok {
+		r.PK, r.SK = accountID, locationID
+	}
+	return nil
+}
+
+// marshalLocation marshals a record with its keys in the repository's layout.
+func (r *DynamoDBRepository) marshalLocation(record *locationRecord) (map[string]types.AttributeValue, error) {
+	av, err := attributevalue.MarshalMap(record)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal location: %w", err)
+	}
+	for name, value := range r.locationKey(record.PK, record.SK) {
+		av[name] = value
+	}
+	return av, nil
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseKeyLayout(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    KeyLayout
+		wantErr string
+	}{
+		{name: "Unset", input: "", want: KeyLayoutClassic},
+		{name: "Classic", input: "classic", want: KeyLayoutClassic},
+		{name: "Single table", input: "single-table", want: KeyLayoutSingleTable},
+		{name: "Unknown", input: "single_table", wantErr: `unknown key layout "single_table": must be classic or single-table`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseKeyLayout(tt.input)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestLocationKeys(t *testing.T) {
+	tests := []struct {
+		name           string
+		pk, sk         string
+		wantAccountID  string
+		wantLocationID string
+		wantOK         bool
+	}{
+		{name: "Classic location", pk: "acc-12345", sk: "loc-001", wantAccountID: "acc-12345", wantLocationID: "loc-001", wantOK: true},
+		{name: "Single-table location", pk: "ACCOUNT#acc-12345", sk: "LOCATION#loc-001", wantAccountID: "acc-12345", wantLocationID: "loc-001", wantOK: true},
+		{name: "Account settings", pk: "ACCOUNT#acc-12345", sk: "SETTINGS"},
+		{name: "Template", pk: "TEMPLATE#acc-12345", sk: "LOCATION#loc-001"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accountID, locationID, ok := LocationKeys(tt.pk, tt.sk)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantAccountID, accountID)
+			assert.Equal(t, tt.wantLocationID, locationID)
+		})
+	}
+}
+
+func TestSingleTableKeyLayout(t *testing.T) {
+	ctx := context.Background()
+	item := map[string]types.AttributeValue{
+		"PK":           &types.AttributeValueMemberS{Value: "ACCOUNT#acc-12345"},
+		"SK":           &types.AttributeValueMemberS{Value: "LOCATION#loc-001"},
+		"locationType": &types.AttributeValueMemberS{Value: "coordinates"},
+		"coordinates": &types.AttributeValueMemberM{
+			Value: map[string]types.AttributeValue{
+				"latitude":  &types.AttributeValueMemberN{Value: "40.7128"},
+				"longitude": &types.AttributeValueMemberN{Value: "-74.0060"},
+			},
+		},
+	}
+
+	t.Run("Gets a location by its prefixed key", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table", WithKeyLayout(KeyLayoutSingleTable))
+		mockClient.On("GetItem", ctx, mock.MatchedBy(func(input *dynamodb.GetItemInput) bool {
+			return input.Key["PK"].(*types.AttributeValueMemberS).Value == "ACCOUNT#acc-12345" &&
+				input.Key["SK"].(*types.AttributeValueMemberS).Value == "LOCATION#loc-001"
+		})).Return(&dynamodb.GetItemOutput{Item: item}, nil).Once()
+
+		envelope, err := repo.Get(ctx, "acc-12345", "loc-001")
+		require.NoError(t, err)
+		assert.Equal(t, "loc-001", envelope.LocationID)
+		assert.IsType(t, models.CoordinatesLocation{}, envelope.Location)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Lists only the partition's locations", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table", WithKeyLayout(KeyLayoutSingleTable))
+		mockClient.On("Query", ctx, mock.MatchedBy(func(input *dynamodb.QueryInput) bool {
+			return aws.ToString(input.KeyConditionExpression) == "PK = :accountId AND begins_with(SK, :locationPrefix)" &&
+				input.ExpressionAttributeValues[":accountId"].(*types.AttributeValueMemberS).Value == "ACCOUNT#acc-12345" &&
+				input.ExpressionAttributeValues[":locationPrefix"].(*types.AttributeValueMemberS).Value == "LOCATION#"
+		})).Return(&dynamodb.QueryOutput{Items: []map[string]types.AttributeValue{item}}, nil).Once()
+
+		result, err := repo.List(ctx, "acc-12345", &ListOptions{})
+		require.NoError(t, err)
+		require.Len(t, result.Items, 1)
+		assert.Equal(t, "loc-001", result.Items[0].LocationID)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Writes locations with prefixed keys", func(t *testing.T) {
+		repo := NewDynamoDBRepository(new(mockDynamoDBClient), "test-table", WithKeyLayout(KeyLayoutSingleTable))
+
+		av, err := repo.marshalLocation(&locationRecord{PK: "acc-12345", SK: "loc-001", LocationType: models.LocationTypeCoordinates})
+		require.NoError(t, err)
+		assert.Equal(t, &types.AttributeValueMemberS{Value: "ACCOUNT#acc-12345"}, av["PK"])
+		assert.Equal(t, &types.AttributeValueMemberS{Value: "LOCATION#loc-001"}, av["SK"])
+	})
+}
+
+func TestAccountIndexKey(t *testing.T) {
+	assert.Equal(t, "acc-12345#ERP-1", accountIndexKey("acc-12345", "ERP-1"))
+	assert.Equal(t, "a%23b#c", accountIndexKey("a#b", "c"))
+	assert.NotEqual(t, accountIndexKey("a#b", "c"), accountIndexKey("a", "b#c"))
+	assert.NotEqual(t, accountIndexKey("a%23b", "c"), accountIndexKey("a#b", "c"))
+}
@@ -0,0 +1,201 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	// mergePKPrefix keeps merge records out of an account's location partition.
+	mergePKPrefix = "MERGE#"
+	// maxMergeDuplicates keeps a merge within one transaction of 100 items:
+	// the survivor, each duplicate, and the merge record.
+	maxMergeDuplicates = 98
+	// notMergedFilter excludes tombstoned duplicates from list queries.
+	notMergedFilter = "attribute_not_exists(mergedInto)"
+)
+
+// MergedError is returned when reading a location that was merged into another.
+type MergedError struct {
+	SurvivorID string
+}
+
+// Error implements the error interface.
+func (e *MergedError) Error() string {
+	return fmt.Sprintf("location has been merged into %s", e.SurvivorID)
+}
+
+// MergeResult records a completed merge of duplicate locations into a survivor.
+type MergeResult struct {
+	MergeID      string    `json:"mergeId" dynamodbav:"mergeId"`
+	AccountID    string    `json:"accountId" dynamodbav:"accountId"`
+	SurvivorID   string    `json:"survivorId" dynamodbav:"survivorId"`
+	DuplicateIDs []string  `json:"duplicateIds" dynamodbav:"duplicateIds"`
+	MergedAt     time.Time `json:"mergedAt" dynamodbav:"mergedAt"`
+	// AddedAttributes lists extendedAttributes keys copied onto the survivor from duplicates.
+	AddedAttributes []string `json:"addedAttributes,omitempty" dynamodbav:"addedAttributes,omitempty"`
+}
+
+// mergeRecord is the DynamoDB item recording a merge.
+type mergeRecord struct {
+	PK string `dynamodbav:"PK"` // MERGE#accountId
+	SK string `dynamodbav:"SK"` // mergeId
+	MergeResult
+}
+
+// Merge folds duplicate locations into a survivor. Extended attributes are
+// unioned onto the survivor, with the survivor's values winning conflicts and
+// earlier duplicates winning over later ones. Each duplicate is tombstoned
+// with a mergedInto pointer, and a merge record is written, all in one
+// transaction.
+func (r *DynamoDBRepository) Merge(ctx context.Context, accountID, survivorID string, duplicateIDs []string) (*MergeResult, error) {
+	if err := validateMerge(survivorID, duplicateIDs); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	survivor, err := r.getRecord(ctx, accountID, survivorID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read survivor %s: %w", survivorID, err)
+	}
+	oldRef := survivor.ExtendedAttributesRef
+	if err := r.hydrateRecord(ctx, survivor); err != nil {
+		return nil, err
+	}
+
+	merged := make(map[string]interface{}, len(survivor.ExtendedAttributes))
+	for key, value := range survivor.ExtendedAttributes {
+		merged[key] = value
+	}
+	var added []string
+	for _, duplicateID := range duplicateIDs {
+		duplicate, err := r.getRecord(ctx, accountID, duplicateID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read duplicate %s: %w", duplicateID, err)
+		}
+		if err := r.hydrateRecord(ctx, duplicate); err != nil {
+			return nil, err
+		}
+		for key, value := range duplicate.ExtendedAttributes {
+			if _, ok := merged[key]; !ok {
+				merged[key] = value
+				added = append(added, key)
+			}
+		}
+	}
+
+	// Rebuild the survivor record so write-time policies apply to the merged attributes
+	location, err := survivor.toLocation()
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert record to location: %w", err)
+	}
+	record, err := toLocationRecord(location, survivorID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert location to record: %w", err)
+	}
+	if len(merged) > 0 {
+		record.ExtendedAttributes = merged
+	}
+	item, err := r.prepareRecord(ctx, record)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &MergeResult{
+		MergeID:         r.ids.NewID(),
+		AccountID:       accountID,
+		SurvivorID:      survivorID,
+		DuplicateIDs:    duplicateIDs,
+		MergedAt:        r.now().UTC(),
+		AddedAttributes: added,
+	}
+	mergeItem, err := attributevalue.MarshalMap(mergeRecord{
+		PK:          mergePKPrefix + accountID,
+		SK:          result.MergeID,
+		MergeResult: *result,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal merge record: %w", err)
+	}
+
+	notMergedCondition := aws.String("attribute_exists(PK) AND " + notMergedFilter)
+	items := []types.TransactWriteItem{
+		{Put: &types.Put{
+			TableName:           aws.String(r.tableName),
+			Item:                item,
+			ConditionExpression: notMergedCondition,
+		}},
+	}
+	for _, duplicateID := range duplicateIDs {
+		items = append(items, types.TransactWriteItem{Update: &types.Update{
+			TableName: aws.String(r.tableName),
+			Key:       r.locationKey(accountID, duplicateID),
+			// Syncing clients see the duplicate deleted as of the merge
+			UpdateExpression:    aws.String("SET mergedInto = :survivor, mergedAt = :mergedAt, syncAccount = :account, updatedAt = :updatedAt"),
+			ConditionExpression: notMergedCondition,
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":survivor":  &types.AttributeValueMemberS{Value: survivorID},
+				":mergedAt":  &types.AttributeValueMemberS{Value: result.MergedAt.Format(time.RFC3339Nano)},
+				":account":   &types.AttributeValueMemberS{Value: accountID},
+				":updatedAt": &types.AttributeValueMemberS{Value: syncTime(result.MergedAt)},
+			},
+		}})
+	}
+	items = append(items, types.TransactWriteItem{Put: &types.Put{
+		TableName: aws.String(r.tableName),
+		Item:      mergeItem,
+	}})
+
+	_, err = r.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: items})
+	if err != nil {
+		r.deleteOverflow(ctx, newOverflowRef(oldRef, record.ExtendedAttributesRef))
+		var tce *types.TransactionCanceledException
+		if errors.As(err, &tce) {
+			return nil, fmt.Errorf("merge conflict: a location was modified or merged concurrently")
+		}
+		return nil, fmt.Errorf("failed to merge locations: %w", err)
+	}
+
+	r.replaceOverflow(ctx, oldRef, record.ExtendedAttributesRef)
+
+	return result, nil
+}
+
+// newOverflowRef returns the overflow key written by a failed write that the
+// previous record did not already own, so it can be cleaned up.
+func newOverflowRef(oldRef, newRef string) string {
+	if newRef == oldRef {
+		return ""
+	}
+	return newRef
+}
+
+// validateMerge checks the survivor and duplicate IDs of a merge request.
+func validateMerge(survivorID string, duplicateIDs []string) error {
+	if survivorID == "" {
+		return errors.New("survivorId is required")
+	}
+	if len(duplicateIDs) == 0 {
+		return errors.New("duplicateIds is required")
+	}
+	if len(duplicateIDs) > maxMergeDuplicates {
+		return fmt.Errorf("at most %d duplicates can be merged at once", maxMergeDuplicates)
+	}
+	seen := make(map[string]bool, len(duplicateIDs))
+	for _, id := range duplicateIDs {
+		if id == survivorID {
+			return errors.New("survivorId cannot also be a duplicate")
+		}
+		if id == "" || seen[id] {
+			return fmt.Errorf("duplicateIds must be unique and non-empty")
+		}
+		seen[id] = true
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// itemWithAttributes builds a coordinates location item carrying extended attributes.
+func itemWithAttributes(t *testing.T, locationID string, attrs map[string]interface{}) map[string]types.AttributeValue {
+	t.Helper()
+	item := coordinatesItem("acc-12345", locationID)
+	av, err := attributevalue.Marshal(attrs)
+	require.NoError(t, err)
+	item["extendedAttributes"] = av
+	return item
+}
+
+// matchGet matches a GetItem call for the given location ID.
+func matchGet(locationID string) interface{} {
+	return mock.MatchedBy(func(input *dynamodb.GetItemInput) bool {
+		sk, ok := input.Key["SK"].(*types.AttributeValueMemberS)
+		return ok && sk.Value == locationID
+	})
+}
+
+func TestDynamoDBRepositoryMerge(t *testing.T) {
+	t.Run("Unions attributes and tombstones duplicates in one transaction", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		mockClient.On("GetItem", ctx, matchGet("loc-001")).Return(&dynamodb.GetItemOutput{
+			Item: itemWithAttributes(t, "loc-001", map[string]interface{}{"color": "red"}),
+		}, nil).Once()
+		mockClient.On("GetItem", ctx, matchGet("loc-002")).Return(&dynamodb.GetItemOutput{
+			Item: itemWithAttributes(t, "loc-002", map[string]interface{}{"color": "blue", "size": "L"}),
+		}, nil).Once()
+		mockClient.On("GetItem", ctx, matchGet("loc-003")).Return(&dynamodb.GetItemOutput{
+			Item: itemWithAttributes(t, "loc-003", map[string]interface{}{"size": "S", "tier": "gold"}),
+		}, nil).Once()
+
+		var transaction *dynamodb.TransactWriteItemsInput
+		mockClient.On("TransactWriteItems", ctx, mock.Anything).Run(func(args mock.Arguments) {
+			transaction = args.Get(1).(*dynamodb.TransactWriteItemsInput)
+		}).Return(&dynamodb.TransactWriteItemsOutput{}, nil).Once()
+
+		result, err := repo.Merge(ctx, "acc-12345", "loc-001", []string{"loc-002", "loc-003"})
+		require.NoError(t, err)
+		assert.NotEmpty(t, result.MergeID)
+		assert.Equal(t, []string{"size", "tier"}, result.AddedAttributes)
+
+		require.Len(t, transaction.TransactItems, 4)
+
+		var survivor locationRecord
+		require.NoError(t, attributevalue.UnmarshalMap(transaction.TransactItems[0].Put.Item, &survivor))
+		assert.Equal(t, map[string]interface{}{"color": "red", "size": "L", "tier": "gold"}, survivor.ExtendedAttributes)
+
+		for i, id := range []string{"loc-002", "loc-003"} {
+			update := transaction.TransactItems[i+1].Update
+			require.NotNil(t, update)
+			assert.Equal(t, id, update.Key["SK"].(*types.AttributeValueMemberS).Value)
+			assert.Equal(t, "loc-001", update.ExpressionAttributeValues[":survivor"].(*types.AttributeValueMemberS).Value)
+			assert.Contains(t, aws.ToString(update.ConditionExpression), notMergedFilter)
+		}
+
+		mergeItem := transaction.TransactItems[3].Put.Item
+		assert.Equal(t, "MERGE#acc-12345", mergeItem["PK"].(*types.AttributeValueMemberS).Value)
+		assert.Equal(t, result.MergeID, mergeItem["SK"].(*types.AttributeValueMemberS).Value)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Rejects invalid requests", func(t *testing.T) {
+		repo := NewDynamoDBRepository(new(mockDynamoDBClient), "test-table")
+		tests := []struct {
+			name         string
+			duplicateIDs []string
+			wantErr      string
+		}{
+			{"no duplicates", nil, "validation failed: duplicateIds is required"},
+			{"survivor listed as duplicate", []string{"loc-001"}, "validation failed: survivorId cannot also be a duplicate"},
+			{"repeated duplicate", []string{"loc-002", "loc-002"}, "validation failed: duplicateIds must be unique and non-empty"},
+			{"too many duplicates", make([]string, maxMergeDuplicates+1), "validation failed: at most 98 duplicates can be merged at once"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := repo.Merge(context.Background(), "acc-12345", "loc-001", tt.duplicateIDs)
+				assert.EqualError(t, err, tt.wantErr)
+			})
+		}
+	})
+
+	t.Run("Already merged duplicate", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		tombstone := coordinatesItem("acc-12345", "loc-002")
+		tombstone["mergedInto"] = &types.AttributeValueMemberS{Value: "loc-009"}
+		mockClient.On("GetItem", ctx, matchGet("loc-001")).Return(&dynamodb.GetItemOutput{
+			Item: coordinatesItem("acc-12345", "loc-001"),
+		}, nil).Once()
+		mockClient.On("GetItem", ctx, matchGet("loc-002")).Return(&dynamodb.GetItemOutput{Item: tombstone}, nil).Once()
+
+		_, err := repo.Merge(ctx, "acc-12345", "loc-001", []string{"loc-002"})
+		assert.EqualError(t, err, "failed to read duplicate loc-002: location has been merged into loc-009")
+		mockClient.AssertNotCalled(t, "TransactWriteItems", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Concurrent modification", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		mockClient.On("GetItem", ctx, matchGet("loc-001")).Return(&dynamodb.GetItemOutput{
+			Item: coordinatesItem("acc-12345", "loc-001"),
+		}, nil).Once()
+		mockClient.On("GetItem", ctx, matchGet("loc-002")).Return(&dynamodb.GetItemOutput{
+			Item: coordinatesItem("acc-12345", "loc-002"),
+		}, nil).Once()
+		mockClient.On("TransactWriteItems", ctx, mock.Anything).
+			Return(nil, &types.TransactionCanceledException{Message: aws.String("conditional check failed")}).Once()
+
+		_, err := repo.Merge(ctx, "acc-12345", "loc-001", []string{"loc-002"})
+		assert.EqualError(t, err, "merge conflict: a location was modified or merged concurrently")
+		mockClient.AssertExpectations(t)
+	})
+}
+
+func TestDynamoDBRepositoryGetMergedLocation(t *testing.T) {
+	ctx := context.Background()
+	mockClient := new(mockDynamoDBClient)
+	repo := NewDynamoDBRepository(mockClient, "test-table")
+
+	tombstone := coordinatesItem("acc-12345", "loc-002")
+	tombstone["mergedInto"] = &types.AttributeValueMemberS{Value: "loc-001"}
+	mockClient.On("GetItem", ctx, mock.Anything).Return(&dynamodb.GetItemOutput{Item: tombstone}, nil).Once()
+
+	_, err := repo.Get(ctx, "acc-12345", "loc-002")
+	var merged *MergedError
+	require.True(t, errors.As(err, &merged))
+	assert.Equal(t, "loc-001", merged.SurvivorID)
+}
@@ -0,0 +1,232 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/steverhoton/location-lambda/internal/geo"
+	"github.com/steverhoton/location-lambda/internal/models"
+)
+
+const (
+	// MaxNearbyRadiusMeters is the widest radius FindShopsNear searches.
+	MaxNearbyRadiusMeters = 100000
+	// maxNearbyShops caps the shops FindShopsNear returns.
+	maxNearbyShops = 50
+	// nearbyShopFilter selects an account's live, published, active shops pinned within a latitude band.
+	nearbyShopFilter = notMergedFilter + " AND " + listedFilter + " AND locationType = :shop AND shop.coordinates.latitude BETWEEN :minLat AND :maxLat"
+	// MaxNearestShops is the largest k FindNearestShops accepts.
+	MaxNearestShops = 50
+	// nearestShopFilter selects an account's live, published, active, pinned shops in a category.
+	nearestShopFilter = notMergedFilter + " AND " + listedFilter + " AND locationType = :shop AND attribute_exists(shop.coordinates) AND contains(shop.categories, :category)"
+)
+
+// NearbyShopFinder finds an account's shops near a point.
+type NearbyShopFinder interface {
+	FindShopsNear(ctx context.Context, accountID string, center models.Coordinates, radiusMeters float64, openAt *time.Time, maxAccuracyMeters *float64) ([]NearbyShop, error)
+}
+
+// NearbyShop is a shop and its distance from the searched point.
+type NearbyShop struct {
+	LocationEnvelope
+	DistanceMeters float64
+}
+
+// FindShopsNear returns the account's shops whose coordinates lie within
+// radiusMeters of center, nearest first, up to 50. Shops without coordinates
+// are never found. There is no geo index yet, so the account's partition is
+// read with a latitude filter and distances are checked here; the cost grows
+// with the account's size rather than the number of matches. With openAt,
+// only shops whose hours have them open at that instant are returned, and
+// with maxAccuracyMeters, only those pinned at least that accurately.
+func (r *DynamoDBRepository) FindShopsNear(ctx context.Context, accountID string, center models.Coordinates, radiusMeters float64, openAt *time.Time, maxAccuracyMeters *float64) ([]NearbyShop, error) {
+	if accountID == "" {
+		return nil, fmt.Errorf("validation failed: accountId is required")
+	}
+	if err := center.Validate(); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	if radiusMeters <= 0 || radiusMeters > MaxNearbyRadiusMeters {
+		return nil, fmt.Errorf("validation failed: radius must be greater than 0 and at most %d meters", MaxNearbyRadiusMeters)
+	}
+	if err := validateMaxAccuracy(maxAccuracyMeters); err != nil {
+		return nil, err
+	}
+
+	box := geo.RadiusBox(center, radiusMeters)
+	input := &dynamodb.QueryInput{
+		TableName:        aws.String(r.tableName),
+		FilterExpression: aws.String(nearbyShopFilter),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":shop":   &types.AttributeValueMemberS{Value: string(models.LocationTypeShop)},
+			":minLat": &types.AttributeValueMemberN{Value: formatFloat(box.MinLatitude)},
+			":maxLat": &types.AttributeValueMemberN{Value: formatFloat(box.MaxLatitude)},
+			":now":    r.nowValue(),
+		},
+	}
+	input.KeyConditionExpression = aws.String(r.locationPartition(":pk", accountID, input.ExpressionAttributeValues))
+
+	shops := []NearbyShop{}
+	for {
+		result, err := r.client.Query(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query shops: %w", err)
+		}
+		for _, item := range result.Items {
+			var record locationRecord
+			if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal location: %w", err)
+			}
+			if record.Shop == nil || record.Shop.Coordinates == nil || !box.Contains(*record.Shop.Coordinates) {
+				continue
+			}
+			distance := geo.Distance(center, *record.Shop.Coordinates)
+			if distance > radiusMeters || !openAtTime(record.Shop, openAt) || !accurateTo(record.Shop.Coordinates, maxAccuracyMeters) {
+				continue
+			}
+			if err := r.hydrateRecord(ctx, &record); err != nil {
+				return nil, err
+			}
+			envelope, err := record.toEnvelope()
+			if err != nil {
+				return nil, err
+			}
+			shops = append(shops, NearbyShop{LocationEnvelope: *envelope, DistanceMeters: distance})
+		}
+		if result.LastEvaluatedKey == nil {
+			break
+		}
+		input.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+
+	sort.SliceStable(shops, func(i, j int) bool { return shops[i].DistanceMeters < shops[j].DistanceMeters })
+	if len(shops) > maxNearbyShops {
+		shops = shops[:maxNearbyShops]
+	}
+	return shops, nil
+}
+
+// NearestShopFinder finds the shops of a category nearest a point.
+type NearestShopFinder interface {
+	FindNearestShops(ctx context.Context, accountID string, center models.Coordinates, category string, k int, openAt *time.Time, maxAccuracyMeters *float64) ([]NearbyShop, error)
+}
+
+// FindNearestShops returns the k shops in category nearest center, nearest
+// first, however far away they are, so fewer than k come back only when the
+// account has fewer pinned shops in the category. Every such shop is read,
+// without a geo index to narrow the search. With openAt, shops closed at that
+// instant are skipped before the k nearest are picked, as are shops pinned
+// less accurately than maxAccuracyMeters.
+func (r *DynamoDBRepository) FindNearestShops(ctx context.Context, accountID string, center models.Coordinates, category string, k int, openAt *time.Time, maxAccuracyMeters *float64) ([]NearbyShop, error) {
+	if accountID == "" || category == "" {
+		return nil, fmt.Errorf("validation failed: accountId and category are required")
+	}
+	if err := center.Validate(); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	if k < 1 || k > MaxNearestShops {
+		return nil, fmt.Errorf("validation failed: k must be between 1 and %d", MaxNearestShops)
+	}
+	if err := validateMaxAccuracy(maxAccuracyMeters); err != nil {
+		return nil, err
+	}
+
+	input := &dynamodb.QueryInput{
+		TableName:        aws.String(r.tableName),
+		FilterExpression: aws.String(nearestShopFilter),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":shop":     &types.AttributeValueMemberS{Value: string(models.LocationTypeShop)},
+			":category": &types.AttributeValueMemberS{Value: category},
+			":now":      r.nowValue(),
+		},
+	}
+	input.KeyConditionExpression = aws.String(r.locationPartition(":pk", accountID, input.ExpressionAttributeValues))
+
+	// nearest holds the k closest records so far, nearest first
+	type candidate struct {
+		record   locationRecord
+		distance float64
+	}
+	nearest := make([]candidate, 0, k)
+	for {
+		result, err := r.client.Query(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query shops: %w", err)
+		}
+		for _, item := range result.Items {
+			var record locationRecord
+			if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal location: %w", err)
+			}
+			if record.Shop == nil || record.Shop.Coordinates == nil || !openAtTime(record.Shop, openAt) || !accurateTo(record.Shop.Coordinates, maxAccuracyMeters) {
+				continue
+			}
+			distance := geo.Distance(center, *record.Shop.Coordinates)
+			if len(nearest) == k && distance >= nearest[k-1].distance {
+				continue
+			}
+			at := sort.Search(len(nearest), func(i int) bool { return nearest[i].distance > distance })
+			if len(nearest) < k {
+				nearest = append(nearest, candidate{})
+			}
+			copy(nearest[at+1:], nearest[at:])
+			nearest[at] = candidate{record: record, distance: distance}
+		}
+		if result.LastEvaluatedKey == nil {
+			break
+		}
+		input.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+
+	shops := make([]NearbyShop, 0, len(nearest))
+	for _, c := range nearest {
+		if err := r.hydrateRecord(ctx, &c.record); err != nil {
+			return nil, err
+		}
+		envelope, err := c.record.toEnvelope()
+		if err != nil {
+			return nil, err
+		}
+		shops = append(shops, NearbyShop{LocationEnvelope: *envelope, DistanceMeters: c.distance})
+	}
+	return shops, nil
+}
+
+// openAtTime reports whether shop is open at, which is always so when at is
+// nil. Shops without hours are never known to be open.
+func openAtTime(shop *shopAttribute, at *time.Time) bool {
+	if at == nil {
+		return true
+	}
+	return shop.Hours != nil && shop.Hours.OpenAt(*at)
+}
+
+// validateMaxAccuracy checks a maxAccuracyMeters filter, which may be nil.
+func validateMaxAccuracy(maxAccuracyMeters *float64) error {
+	if maxAccuracyMeters != nil && *maxAccuracyMeters <= 0 {
+		return fmt.Errorf("validation failed: maxAccuracyMeters must be greater than 0")
+	}
+	return nil
+}
+
+// accurateTo reports whether coordinates have an accuracy of at most
+// maxAccuracyMeters, which is always so when it is nil. Coordinates of
+// unknown accuracy never qualify.
+func accurateTo(coordinates *models.Coordinates, maxAccuracyMeters *float64) bool {
+	if maxAccuracyMeters == nil {
+		return true
+	}
+	return coordinates.Accuracy != nil && *coordinates.Accuracy <= *maxAccuracyMeters
+}
+
+// formatFloat formats f without trailing zeros.
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,240 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDynamoDBRepositoryFindShopsNear(t *testing.T) {
+	// Springfield, IL
+	center := models.Coordinates{Latitude: 39.7817, Longitude: -89.6501}
+	shopItem := func(t *testing.T, locationID string, coordinates *models.Coordinates) map[string]types.AttributeValue {
+		item, err := attributevalue.MarshalMap(locationRecord{
+			PK:           "acc-12345",
+			SK:           locationID,
+			LocationType: models.LocationTypeShop,
+			Shop: &shopAttribute{
+				Name:        "Store " + locationID,
+				ContactID:   "contact-1",
+				Address:     models.Address{StreetAddress: "123 Main St", City: "Springfield", PostalCode: "62704", Country: "US"},
+				Coordinates: coordinates,
+			},
+		})
+		require.NoError(t, err)
+		return item
+	}
+
+	t.Run("Returns shops within the radius, nearest first", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		mockClient.On("Query", ctx, mock.MatchedBy(func(input *dynamodb.QueryInput) bool {
+			minLat, _ := strconv.ParseFloat(input.ExpressionAttributeValues[":minLat"].(*types.AttributeValueMemberN).Value, 64)
+			maxLat, _ := strconv.ParseFloat(input.ExpressionAttributeValues[":maxLat"].(*types.AttributeValueMemberN).Value, 64)
+			return input.ExpressionAttributeValues[":pk"].(*types.AttributeValueMemberS).Value == "acc-12345" &&
+				aws.ToString(input.FilterExpression) == nearbyShopFilter &&
+				minLat < center.Latitude && maxLat > center.Latitude && maxLat-minLat < 0.2
+		})).Return(&dynamodb.QueryOutput{Items: []map[string]types.AttributeValue{
+			// About 3.3 km north
+			shopItem(t, "loc-far", &models.Coordinates{Latitude: 39.8117, Longitude: -89.6501}),
+			// About 1.1 km north
+			shopItem(t, "loc-near", &models.Coordinates{Latitude: 39.7917, Longitude: -89.6501}),
+			// Inside the latitude band but about 8.5 km east
+			shopItem(t, "loc-east", &models.Coordinates{Latitude: 39.7817, Longitude: -89.5501}),
+			shopItem(t, "loc-unpinned", nil),
+		}}, nil).Once()
+
+		shops, err := repo.FindShopsNear(ctx, "acc-12345", center, 5000, nil, nil)
+		require.NoError(t, err)
+		require.Len(t, shops, 2)
+		assert.Equal(t, "loc-near", shops[0].LocationID)
+		assert.InDelta(t, 1112, shops[0].DistanceMeters, 5)
+		assert.Equal(t, "loc-far", shops[1].LocationID)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Skips shops closed or without hours at openAt", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		nearby := &models.Coordinates{Latitude: 39.7917, Longitude: -89.6501}
+		withHours := func(locationID string, hours *models.OperatingHours) map[string]types.AttributeValue {
+			item, err := attributevalue.MarshalMap(locationRecord{
+				PK:           "acc-12345",
+				SK:           locationID,
+				LocationType: models.LocationTypeShop,
+				Shop: &shopAttribute{
+					Name:        "Store " + locationID,
+					ContactID:   "contact-1",
+					Address:     models.Address{StreetAddress: "123 Main St", City: "Springfield", PostalCode: "62704", Country: "US"},
+					Coordinates: nearby,
+					Hours:       hours,
+				},
+			})
+			require.NoError(t, err)
+			return item
+		}
+		weekdays := func(open, close string) *models.OperatingHours {
+			return &models.OperatingHours{TimeZone: "America/Chicago", Weekly: map[string][]models.OpenPeriod{
+				"tuesday": {{Open: open, Close: close}},
+			}}
+		}
+		mockClient.On("Query", ctx, mock.Anything).Return(&dynamodb.QueryOutput{Items: []map[string]types.AttributeValue{
+			withHours("loc-open", weekdays("09:00", "17:00")),
+			withHours("loc-closed", weekdays("13:00", "17:00")),
+			withHours("loc-unknown", nil),
+		}}, nil).Once()
+
+		// Tuesday 10:30 in Springfield
+		openAt := time.Date(2024, time.June, 4, 15, 30, 0, 0, time.UTC)
+		shops, err := repo.FindShopsNear(ctx, "acc-12345", center, 5000, &openAt, nil)
+		require.NoError(t, err)
+		require.Len(t, shops, 1)
+		assert.Equal(t, "loc-open", shops[0].LocationID)
+	})
+
+	t.Run("Skips shops pinned less accurately than maxAccuracyMeters", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		pinned := func(accuracy *float64) *models.Coordinates {
+			return &models.Coordinates{Latitude: 39.7917, Longitude: -89.6501, Accuracy: accuracy}
+		}
+		mockClient.On("Query", ctx, mock.Anything).Return(&dynamodb.QueryOutput{Items: []map[string]types.AttributeValue{
+			shopItem(t, "loc-survey", pinned(aws.Float64(2))),
+			shopItem(t, "loc-phone", pinned(aws.Float64(65))),
+			shopItem(t, "loc-unknown", pinned(nil)),
+		}}, nil).Once()
+
+		shops, err := repo.FindShopsNear(ctx, "acc-12345", center, 5000, nil, aws.Float64(10))
+		require.NoError(t, err)
+		require.Len(t, shops, 1)
+		assert.Equal(t, "loc-survey", shops[0].LocationID)
+	})
+
+	t.Run("Validates the search", func(t *testing.T) {
+		repo := NewDynamoDBRepository(new(mockDynamoDBClient), "test-table")
+
+		_, err := repo.FindShopsNear(context.Background(), "acc-12345", center, 1000, nil, aws.Float64(0))
+		assert.EqualError(t, err, "validation failed: maxAccuracyMeters must be greater than 0")
+		_, err = repo.FindShopsNear(context.Background(), "acc-12345", center, 0, nil, nil)
+		assert.EqualError(t, err, "validation failed: radius must be greater than 0 and at most 100000 meters")
+		_, err = repo.FindShopsNear(context.Background(), "acc-12345", models.Coordinates{Latitude: 91}, 1000, nil, nil)
+		assert.EqualError(t, err, "validation failed: latitude must be between -90 and 90, got 91.000000")
+		_, err = repo.FindShopsNear(context.Background(), "", center, 1000, nil, nil)
+		assert.EqualError(t, err, "validation failed: accountId is required")
+	})
+}
+
+func TestDynamoDBRepositoryFindNearestShops(t *testing.T) {
+	center := models.Coordinates{Latitude: 39.7817, Longitude: -89.6501}
+	pharmacy := func(t *testing.T, locationID string, latitude float64) map[string]types.AttributeValue {
+		item, err := attributevalue.MarshalMap(locationRecord{
+			PK:           "acc-12345",
+			SK:           locationID,
+			LocationType: models.LocationTypeShop,
+			Shop: &shopAttribute{
+				Name:        "Pharmacy " + locationID,
+				ContactID:   "contact-1",
+				Address:     models.Address{StreetAddress: "123 Main St", City: "Springfield", PostalCode: "62704", Country: "US"},
+				Coordinates: &models.Coordinates{Latitude: latitude, Longitude: center.Longitude},
+				Categories:  []string{"446110"},
+			},
+		})
+		require.NoError(t, err)
+		return item
+	}
+
+	t.Run("Keeps the k nearest across pages", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		matchesQuery := func(first bool) interface{} {
+			return mock.MatchedBy(func(input *dynamodb.QueryInput) bool {
+				return aws.ToString(input.FilterExpression) == nearestShopFilter &&
+					input.ExpressionAttributeValues[":category"].(*types.AttributeValueMemberS).Value == "446110" &&
+					(input.ExclusiveStartKey == nil) == first
+			})
+		}
+		lastKey := map[string]types.AttributeValue{"PK": &types.AttributeValueMemberS{Value: "acc-12345"}}
+		mockClient.On("Query", ctx, matchesQuery(true)).Return(&dynamodb.QueryOutput{
+			Items: []map[string]types.AttributeValue{
+				// Hundreds of kilometers away, but still among the nearest two at first
+				pharmacy(t, "loc-far", 42.0),
+				pharmacy(t, "loc-mid", 39.9),
+			},
+			LastEvaluatedKey: lastKey,
+		}, nil).Once()
+		mockClient.On("Query", ctx, matchesQuery(false)).Return(&dynamodb.QueryOutput{
+			Items: []map[string]types.AttributeValue{
+				pharmacy(t, "loc-near", 39.79),
+				pharmacy(t, "loc-farther", 45.0),
+			},
+		}, nil).Once()
+
+		shops, err := repo.FindNearestShops(ctx, "acc-12345", center, "446110", 2, nil, nil)
+		require.NoError(t, err)
+		require.Len(t, shops, 2)
+		assert.Equal(t, "loc-near", shops[0].LocationID)
+		assert.Equal(t, "loc-mid", shops[1].LocationID)
+		assert.Less(t, shops[0].DistanceMeters, shops[1].DistanceMeters)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Returns fewer than k when the account has fewer", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		mockClient.On("Query", ctx, mock.Anything).Return(&dynamodb.QueryOutput{
+			Items: []map[string]types.AttributeValue{pharmacy(t, "loc-far", 42.0)},
+		}, nil).Once()
+
+		shops, err := repo.FindNearestShops(ctx, "acc-12345", center, "446110", 5, nil, nil)
+		require.NoError(t, err)
+		require.Len(t, shops, 1)
+		assert.Equal(t, "loc-far", shops[0].LocationID)
+	})
+
+	t.Run("Validates k", func(t *testing.T) {
+		repo := NewDynamoDBRepository(new(mockDynamoDBClient), "test-table")
+
+		_, err := repo.FindNearestShops(context.Background(), "acc-12345", center, "446110", 51, nil, nil)
+		assert.EqualError(t, err, "validation failed: k must be between 1 and 50")
+		_, err = repo.FindNearestShops(context.Background(), "acc-12345", center, "", 5, nil, nil)
+		assert.EqualError(t, err, "validation failed: accountId and category are required")
+		_, err = repo.FindNearestShops(context.Background(), "acc-12345", center, "446110", 5, nil, aws.Float64(-1))
+		assert.EqualError(t, err, "validation failed: maxAccuracyMeters must be greater than 0")
+	})
+}
+
+func TestDynamoDBRepositoryListByAccuracy(t *testing.T) {
+	ctx := context.Background()
+	mockClient := new(mockDynamoDBClient)
+	repo := NewDynamoDBRepository(mockClient, "test-table")
+
+	mockClient.On("Query", ctx, mock.MatchedBy(func(input *dynamodb.QueryInput) bool {
+		value, ok := input.ExpressionAttributeValues[":maxAccuracy"].(*types.AttributeValueMemberN)
+		return ok && value.Value == "12.5" &&
+			aws.ToString(input.FilterExpression) == notMergedFilter+" AND "+listedFilter+" AND (coordinates.accuracy <= :maxAccuracy OR shop.coordinates.accuracy <= :maxAccuracy)"
+	})).Return(&dynamodb.QueryOutput{}, nil).Once()
+
+	_, err := repo.List(ctx, "acc-12345", &ListOptions{MaxAccuracyMeters: aws.Float64(12.5)})
+	require.NoError(t, err)
+	mockClient.AssertExpectations(t)
+}
@@ -0,0 +1,206 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// DefaultOverflowThresholdBytes leaves headroom below DynamoDB's 400 KB item limit.
+const DefaultOverflowThresholdBytes = 350 * 1024
+
+// OverflowConfig configures storage of oversized extendedAttributes in S3.
+type OverflowConfig struct {
+	// Bucket is the S3 bucket holding overflow payloads.
+	Bucket string
+	// KeyPrefix is prepended to every overflow object key.
+	KeyPrefix string
+	// ThresholdBytes is the estimated item size above which extendedAttributes
+	// are moved to S3. Defaults to DefaultOverflowThresholdBytes.
+	ThresholdBytes int
+}
+
+// overflowEnabled reports whether overflow storage is configured.
+func (r *DynamoDBRepository) overflowEnabled() bool {
+	return r.s3Client != nil && r.overflow.Bucket != ""
+}
+
+// threshold returns the configured overflow threshold.
+func (c OverflowConfig) threshold() int {
+	if c.ThresholdBytes > 0 {
+		return c.ThresholdBytes
+	}
+	return DefaultOverflowThresholdBytes
+}
+
+// overflowKey returns the S3 key of one version of a location's overflow
+// payload. Each write stores a new version, so a write DynamoDB rejects never
+// replaces the payload the stored record points at.
+func (c OverflowConfig) overflowKey(accountID, locationID, version string) string {
+	return fmt.Sprintf("%s%s.json", c.overflowPrefix(accountID, locationID), version)
+}
+
+// overflowPrefix returns the S3 prefix holding every version of a location's
+// overflow payload.
+func (c OverflowConfig) overflowPrefix(accountID, locationID string) string {
+	return fmt.Sprintf("%s%s/%s/", c.KeyPrefix, accountID, locationID)
+}
+
+// legacyOverflowKey returns the single, unversioned key payloads were stored
+// at before versioning; records written then still reference it.
+func (c OverflowConfig) legacyOverflowKey(accountID, locationID string) string {
+	return fmt.Sprintf("%s%s/%s.json", c.KeyPrefix, accountID, locationID)
+}
+
+// marshalRecord marshals a record for DynamoDB, moving extendedAttributes to a
+// new version of the S3 payload when the item would exceed the overflow
+// threshold. Callers delete that version when their write fails, and the
+// version the record replaced once it succeeds.
+func (r *DynamoDBRepository) marshalRecord(ctx context.Context, record *locationRecord) (map[string]types.AttributeValue, error) {
+	av, err := r.marshalLocation(record)
+	if err != nil {
+		return nil, err
+	}
+
+	if !r.overflowEnabled() || len(record.ExtendedAttributes) == 0 || estimateItemSize(av) <= r.overflow.threshold() {
+		return av, nil
+	}
+
+	body, err := json.Marshal(record.ExtendedAttributes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal extended attributes: %w", err)
+	}
+
+	key := r.overflow.overflowKey(record.PK, record.SK, r.ids.NewID())
+	_, err = r.s3Client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(r.overflow.Bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(body),
+		ContentType: aws.String("application/json"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to store extended attributes overflow: %w", err)
+	}
+
+	record.ExtendedAttributes = nil
+	record.ExtendedAttributesRef = key
+
+	return r.marshalLocation(record)
+}
+
+// loadOverflow rehydrates extendedAttributes stored in S3.
+func (r *DynamoDBRepository) loadOverflow(ctx context.Context, record *locationRecord) error {
+	if record.ExtendedAttributesRef == "" {
+		return nil
+	}
+	if !r.overflowEnabled() {
+		return fmt.Errorf("location has overflow payload but overflow storage is not configured")
+	}
+
+	out, err := r.s3Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(r.overflow.Bucket),
+		Key:    aws.String(record.ExtendedAttributesRef),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to load extended attributes overflow: %w", err)
+	}
+	defer out.Body.Close()
+
+	body, err := io.ReadAll(out.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read extended attributes overflow: %w", err)
+	}
+
+	if err := json.Unmarshal(body, &record.ExtendedAttributes); err != nil {
+		return fmt.Errorf("failed to unmarshal extended attributes overflow: %w", err)
+	}
+	return nil
+}
+
+// deleteOverflow removes an overflow payload. Failures are logged rather than
+// returned because the DynamoDB write has already been applied.
+func (r *DynamoDBRepository) deleteOverflow(ctx context.Context, key string) {
+	if key == "" || !r.overflowEnabled() {
+		return
+	}
+	_, err := r.s3Client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(r.overflow.Bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		log.Printf("WARN: Failed to delete overflow payload %s: %v", key, err)
+	}
+}
+
+// replaceOverflow removes the payload a successful write superseded, unless
+// the new record still references it.
+func (r *DynamoDBRepository) replaceOverflow(ctx context.Context, oldRef, newRef string) {
+	if oldRef != newRef {
+		r.deleteOverflow(ctx, oldRef)
+	}
+}
+
+// overflowRef returns the overflow key stored on a raw DynamoDB item, if any.
+func overflowRef(item map[string]types.AttributeValue) string {
+	if v, ok := item["extendedAttributesRef"].(*types.AttributeValueMemberS); ok {
+		return v.Value
+	}
+	return ""
+}
+
+// estimateItemSize approximates the DynamoDB size of an item in bytes.
+func estimateItemSize(item map[string]types.AttributeValue) int {
+	size := 0
+	for name, value := range item {
+		size += len(name) + attributeValueSize(value)
+	}
+	return size
+}
+
+// attributeValueSize approximates the DynamoDB size of a single attribute value.
+func attributeValueSize(value types.AttributeValue) int {
+	switch v := value.(type) {
+	case *types.AttributeValueMemberS:
+		return len(v.Value)
+	case *types.AttributeValueMemberN:
+		return len(v.Value)
+	case *types.AttributeValueMemberB:
+		return len(v.Value)
+	case *types.AttributeValueMemberM:
+		return 3 + estimateItemSize(v.Value)
+	case *types.AttributeValueMemberL:
+		size := 3
+		for _, elem := range v.Value {
+			size += 1 + attributeValueSize(elem)
+		}
+		return size
+	case *types.AttributeValueMemberSS:
+		size := 0
+		for _, s := range v.Value {
+			size += len(s)
+		}
+		return size
+	case *types.AttributeValueMemberNS:
+		size := 0
+		for _, n := range v.Value {
+			size += len(n)
+		}
+		return size
+	case *types.AttributeValueMemberBS:
+		size := 0
+		for _, b := range v.Value {
+			size += len(b)
+		}
+		return size
+	default:
+		// BOOL and NULL occupy a single byte
+		return 1
+	}
+}
@@ -0,0 +1,243 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockS3Client is a mock implementation of the S3 client.
+type mockS3Client struct {
+	mock.Mock
+}
+
+func (m *mockS3Client) PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*s3.PutObjectOutput), args.Error(1)
+}
+
+func (m *mockS3Client) GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*s3.GetObjectOutput), args.Error(1)
+}
+
+func (m *mockS3Client) DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*s3.DeleteObjectOutput), args.Error(1)
+}
+
+func (m *mockS3Client) ListObjectsV2(ctx context.Context, params *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*s3.ListObjectsV2Output), args.Error(1)
+}
+
+func TestEstimateItemSize(t *testing.T) {
+	item := map[string]types.AttributeValue{
+		"PK":   &types.AttributeValueMemberS{Value: "acc-12345"},
+		"n":    &types.AttributeValueMemberN{Value: "12.5"},
+		"flag": &types.AttributeValueMemberBOOL{Value: true},
+		"m": &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+			"k": &types.AttributeValueMemberS{Value: "vv"},
+		}},
+		"l": &types.AttributeValueMemberL{Value: []types.AttributeValue{
+			&types.AttributeValueMemberS{Value: "abc"},
+		}},
+	}
+
+	// PK(2+9) + n(1+4) + flag(4+1) + m(1+3+1+2) + l(1+3+1+3)
+	assert.Equal(t, 36, estimateItemSize(item))
+}
+
+func TestDynamoDBRepositoryOverflow(t *testing.T) {
+	ctx := context.Background()
+	cfg := OverflowConfig{Bucket: "overflow-bucket", KeyPrefix: "overflow/", ThresholdBytes: 300}
+
+	largeLocation := models.CoordinatesLocation{
+		LocationBase: models.LocationBase{
+			AccountID:    "acc-12345",
+			LocationType: models.LocationTypeCoordinates,
+			ExtendedAttributes: map[string]interface{}{
+				"notes": strings.Repeat("x", 500),
+			},
+		},
+		Coordinates: models.Coordinates{Latitude: 40.7128, Longitude: -74.0060},
+	}
+
+	t.Run("Create stores oversized extended attributes in S3", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		mockS3 := new(mockS3Client)
+		repo := NewDynamoDBRepository(mockClient, "test-table", WithOverflow(mockS3, cfg), WithIDGenerator(fixedID("loc-001")))
+
+		mockS3.On("PutObject", ctx, mock.MatchedBy(func(input *s3.PutObjectInput) bool {
+			return aws.ToString(input.Bucket) == "overflow-bucket" &&
+				aws.ToString(input.Key) == "overflow/acc-12345/loc-001/loc-001.json"
+		})).Return(&s3.PutObjectOutput{}, nil).Once()
+		mockClient.On("PutItem", ctx, mock.MatchedBy(func(input *dynamodb.PutItemInput) bool {
+			_, hasAttrs := input.Item["extendedAttributes"]
+			return !hasAttrs && overflowRef(input.Item) != ""
+		})).Return(&dynamodb.PutItemOutput{}, nil).Once()
+
+		_, err := repo.Create(ctx, largeLocation)
+		require.NoError(t, err)
+		mockClient.AssertExpectations(t)
+		mockS3.AssertExpectations(t)
+	})
+
+	t.Run("Small records stay inline", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		mockS3 := new(mockS3Client)
+		repo := NewDynamoDBRepository(mockClient, "test-table", WithOverflow(mockS3, cfg))
+
+		small := largeLocation
+		small.ExtendedAttributes = map[string]interface{}{"notes": "short"}
+		mockClient.On("PutItem", ctx, mock.MatchedBy(func(input *dynamodb.PutItemInput) bool {
+			_, hasAttrs := input.Item["extendedAttributes"]
+			return hasAttrs && overflowRef(input.Item) == ""
+		})).Return(&dynamodb.PutItemOutput{}, nil).Once()
+
+		_, err := repo.Create(ctx, small)
+		require.NoError(t, err)
+		mockClient.AssertExpectations(t)
+		mockS3.AssertNotCalled(t, "PutObject", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Get rehydrates extended attributes from S3", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		mockS3 := new(mockS3Client)
+		repo := NewDynamoDBRepository(mockClient, "test-table", WithOverflow(mockS3, cfg))
+
+		item := coordinatesItem("acc-12345", "loc-001")
+		item["extendedAttributesRef"] = &types.AttributeValueMemberS{Value: "overflow/acc-12345/loc-001.json"}
+		mockClient.On("GetItem", ctx, mock.Anything).Return(&dynamodb.GetItemOutput{Item: item}, nil).Once()
+		mockS3.On("GetObject", ctx, mock.MatchedBy(func(input *s3.GetObjectInput) bool {
+			return aws.ToString(input.Key) == "overflow/acc-12345/loc-001.json"
+		})).Return(&s3.GetObjectOutput{Body: io.NopCloser(bytes.NewReader([]byte(`{"notes":"restored"}`)))}, nil).Once()
+
+		location, err := repo.Get(ctx, "acc-12345", "loc-001")
+		require.NoError(t, err)
+		assert.Equal(t, "restored", location.Location.GetExtendedAttributes()["notes"])
+		mockS3.AssertExpectations(t)
+	})
+
+	t.Run("Get fails when overflow payload cannot be loaded", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		mockS3 := new(mockS3Client)
+		repo := NewDynamoDBRepository(mockClient, "test-table", WithOverflow(mockS3, cfg))
+
+		item := coordinatesItem("acc-12345", "loc-001")
+		item["extendedAttributesRef"] = &types.AttributeValueMemberS{Value: "overflow/acc-12345/loc-001.json"}
+		mockClient.On("GetItem", ctx, mock.Anything).Return(&dynamodb.GetItemOutput{Item: item}, nil).Once()
+		mockS3.On("GetObject", ctx, mock.Anything).Return(nil, errors.New("access denied")).Once()
+
+		location, err := repo.Get(ctx, "acc-12345", "loc-001")
+		assert.Error(t, err)
+		assert.Nil(t, location)
+		assert.Contains(t, err.Error(), "failed to load extended attributes overflow")
+	})
+
+	t.Run("Update writes a new version and removes the one it replaced", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		mockS3 := new(mockS3Client)
+		repo := NewDynamoDBRepository(mockClient, "test-table", WithOverflow(mockS3, cfg), WithIDGenerator(fixedID("v2")))
+
+		old := coordinatesItem("acc-12345", "loc-001")
+		old["extendedAttributesRef"] = &types.AttributeValueMemberS{Value: "overflow/acc-12345/loc-001/v1.json"}
+		mockS3.On("PutObject", ctx, mock.MatchedBy(func(input *s3.PutObjectInput) bool {
+			return aws.ToString(input.Key) == "overflow/acc-12345/loc-001/v2.json"
+		})).Return(&s3.PutObjectOutput{}, nil).Once()
+		mockClient.On("PutItem", ctx, mock.MatchedBy(func(input *dynamodb.PutItemInput) bool {
+			return overflowRef(input.Item) == "overflow/acc-12345/loc-001/v2.json"
+		})).Return(&dynamodb.PutItemOutput{Attributes: old}, nil).Once()
+		mockS3.On("DeleteObject", ctx, mock.MatchedBy(func(input *s3.DeleteObjectInput) bool {
+			return aws.ToString(input.Key) == "overflow/acc-12345/loc-001/v1.json"
+		})).Return(&s3.DeleteObjectOutput{}, nil).Once()
+
+		require.NoError(t, repo.Update(ctx, largeLocation, "loc-001"))
+		mockClient.AssertExpectations(t)
+		mockS3.AssertExpectations(t)
+	})
+
+	t.Run("Rejected update keeps the stored payload", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		mockS3 := new(mockS3Client)
+		ctx := WithIfMatch(ctx, "stale")
+		repo := NewDynamoDBRepository(mockClient, "test-table", WithOverflow(mockS3, cfg), WithIDGenerator(fixedID("v2")))
+
+		stored := coordinatesItem("acc-12345", "loc-001")
+		stored["extendedAttributesRef"] = &types.AttributeValueMemberS{Value: "overflow/acc-12345/loc-001/v1.json"}
+		stored["contentHash"] = &types.AttributeValueMemberS{Value: "current"}
+		mockS3.On("PutObject", ctx, mock.Anything).Return(&s3.PutObjectOutput{}, nil).Once()
+		mockClient.On("PutItem", ctx, mock.Anything).Return(nil, &types.ConditionalCheckFailedException{}).Once()
+		mockClient.On("GetItem", ctx, mock.Anything).Return(&dynamodb.GetItemOutput{Item: stored}, nil).Once()
+		mockS3.On("DeleteObject", ctx, mock.MatchedBy(func(input *s3.DeleteObjectInput) bool {
+			return aws.ToString(input.Key) == "overflow/acc-12345/loc-001/v2.json"
+		})).Return(&s3.DeleteObjectOutput{}, nil).Once()
+
+		err := repo.Update(ctx, largeLocation, "loc-001")
+		var precondition *PreconditionFailedError
+		require.ErrorAs(t, err, &precondition)
+		mockS3.AssertExpectations(t)
+		mockS3.AssertNotCalled(t, "DeleteObject", ctx, mock.MatchedBy(func(input *s3.DeleteObjectInput) bool {
+			return aws.ToString(input.Key) == "overflow/acc-12345/loc-001/v1.json"
+		}))
+	})
+
+	t.Run("Update of a missing location removes the payload it wrote", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		mockS3 := new(mockS3Client)
+		repo := NewDynamoDBRepository(mockClient, "test-table", WithOverflow(mockS3, cfg), WithIDGenerator(fixedID("v1")))
+
+		mockS3.On("PutObject", ctx, mock.Anything).Return(&s3.PutObjectOutput{}, nil).Once()
+		mockClient.On("PutItem", ctx, mock.Anything).Return(nil, &types.ConditionalCheckFailedException{}).Once()
+		mockClient.On("GetItem", ctx, mock.Anything).Return(&dynamodb.GetItemOutput{}, nil).Once()
+		mockS3.On("DeleteObject", ctx, mock.MatchedBy(func(input *s3.DeleteObjectInput) bool {
+			return aws.ToString(input.Key) == "overflow/acc-12345/loc-001/v1.json"
+		})).Return(&s3.DeleteObjectOutput{}, nil).Once()
+
+		err := repo.Update(ctx, largeLocation, "loc-001")
+		assert.EqualError(t, err, "location not found or access denied")
+		mockClient.AssertExpectations(t)
+		mockS3.AssertExpectations(t)
+	})
+
+	t.Run("Delete removes the overflow payload", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		mockS3 := new(mockS3Client)
+		repo := NewDynamoDBRepository(mockClient, "test-table", WithOverflow(mockS3, cfg))
+
+		old := coordinatesItem("acc-12345", "loc-001")
+		old["extendedAttributesRef"] = &types.AttributeValueMemberS{Value: "overflow/acc-12345/loc-001.json"}
+		noMergeReferences(mockClient)
+		mockClient.On("DeleteItem", ctx, mock.Anything).Return(&dynamodb.DeleteItemOutput{Attributes: old}, nil).Once()
+		mockS3.On("DeleteObject", ctx, mock.MatchedBy(func(input *s3.DeleteObjectInput) bool {
+			return aws.ToString(input.Key) == "overflow/acc-12345/loc-001.json"
+		})).Return(&s3.DeleteObjectOutput{}, nil).Once()
+
+		require.NoError(t, repo.Delete(ctx, "acc-12345", "loc-001"))
+		mockS3.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/steverhoton/location-lambda/internal/pii"
+)
+
+// WithPIIPolicy scans extendedAttributes for apparent PII on write and applies
+// the account's policy to any findings.
+func WithPIIPolicy(cfg pii.Config) Option {
+	return func(r *DynamoDBRepository) {
+		r.piiPolicy = cfg
+	}
+}
+
+// applyPIIPolicy rejects, masks, or tags PII found in a record's extended
+// attributes according to the policy for its account.
+func (r *DynamoDBRepository) applyPIIPolicy(record *locationRecord) error {
+	policy := r.piiPolicy.PolicyFor(record.PK)
+	if policy == pii.PolicyOff || len(record.ExtendedAttributes) == 0 {
+		return nil
+	}
+
+	findings := pii.Scan(record.ExtendedAttributes)
+	if len(findings) == 0 {
+		return nil
+	}
+
+	switch policy {
+	case pii.PolicyReject:
+		described := make([]string, len(findings))
+		for i, finding := range findings {
+			described[i] = finding.String()
+		}
+		return fmt.Errorf("extendedAttributes contain apparent PII: %s", strings.Join(described, ", "))
+	case pii.PolicyMask:
+		record.ExtendedAttributes = pii.Mask(record.ExtendedAttributes)
+	case pii.PolicyTag:
+		record.PIIFindings = make([]string, len(findings))
+		for i, finding := range findings {
+			record.PIIFindings[i] = finding.String()
+		}
+	}
+	return nil
+}
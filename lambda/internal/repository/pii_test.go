@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/pii"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDynamoDBRepositoryPIIPolicy(t *testing.T) {
+	cfg := pii.Config{
+		Default: pii.PolicyOff,
+		Accounts: map[string]pii.Policy{
+			"acc-reject": pii.PolicyReject,
+			"acc-mask":   pii.PolicyMask,
+			"acc-tag":    pii.PolicyTag,
+		},
+	}
+
+	newLocation := func(accountID string) models.CoordinatesLocation {
+		return models.CoordinatesLocation{
+			LocationBase: models.LocationBase{
+				AccountID:          accountID,
+				LocationType:       models.LocationTypeCoordinates,
+				ExtendedAttributes: map[string]interface{}{"owner": "jane@example.com"},
+			},
+			Coordinates: models.Coordinates{Latitude: 40.7128, Longitude: -74.0060},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		accountID     string
+		expectedError string
+		checkItem     func(t *testing.T, item map[string]types.AttributeValue)
+	}{
+		{
+			name:          "Reject",
+			accountID:     "acc-reject",
+			expectedError: "validation failed: extendedAttributes contain apparent PII: owner (email)",
+		},
+		{
+			name:      "Mask",
+			accountID: "acc-mask",
+			checkItem: func(t *testing.T, item map[string]types.AttributeValue) {
+				ext := item["extendedAttributes"].(*types.AttributeValueMemberM).Value
+				assert.Equal(t, "j***@example.com", ext["owner"].(*types.AttributeValueMemberS).Value)
+				assert.NotContains(t, item, "piiFindings")
+			},
+		},
+		{
+			name:      "Tag",
+			accountID: "acc-tag",
+			checkItem: func(t *testing.T, item map[string]types.AttributeValue) {
+				ext := item["extendedAttributes"].(*types.AttributeValueMemberM).Value
+				assert.Equal(t, "jane@example.com", ext["owner"].(*types.AttributeValueMemberS).Value)
+				findings := item["piiFindings"].(*types.AttributeValueMemberL).Value
+				require.Len(t, findings, 1)
+				assert.Equal(t, "owner (email)", findings[0].(*types.AttributeValueMemberS).Value)
+			},
+		},
+		{
+			name:      "Off by default",
+			accountID: "acc-other",
+			checkItem: func(t *testing.T, item map[string]types.AttributeValue) {
+				ext := item["extendedAttributes"].(*types.AttributeValueMemberM).Value
+				assert.Equal(t, "jane@example.com", ext["owner"].(*types.AttributeValueMemberS).Value)
+				assert.NotContains(t, item, "piiFindings")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			mockClient := new(mockDynamoDBClient)
+			repo := NewDynamoDBRepository(mockClient, "test-table", WithPIIPolicy(cfg))
+
+			var stored map[string]types.AttributeValue
+			if tt.checkItem != nil {
+				mockClient.On("PutItem", ctx, mock.Anything).Run(func(args mock.Arguments) {
+					stored = args.Get(1).(*dynamodb.PutItemInput).Item
+				}).Return(&dynamodb.PutItemOutput{}, nil).Once()
+			}
+
+			created, err := repo.Create(ctx, newLocation(tt.accountID))
+
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				mockClient.AssertNotCalled(t, "PutItem", mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			tt.checkItem(t, stored)
+			// The returned location is the one stored, masked or not
+			ext := stored["extendedAttributes"].(*types.AttributeValueMemberM).Value
+			assert.Equal(t, ext["owner"].(*types.AttributeValueMemberS).Value, created.Location.GetExtendedAttributes()["owner"])
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/steverhoton/location-lambda/internal/models"
+)
+
+const (
+	// positionPKPrefix keeps recorded positions out of the account's
+	// location partition.
+	positionPKPrefix = "POSITION#"
+	// stopPKPrefix keeps detected stops out of the account's location partition.
+	stopPKPrefix = "STOP#"
+	// sortableTime formats times in sort keys so they sort in time order,
+	// which RFC 3339 with trimmed fractional seconds does not.
+	sortableTime = "2006-01-02T15:04:05.000000000Z"
+)
+
+// DetectedStopListResult represents a page of detected stops.
+type DetectedStopListResult struct {
+	Stops      []models.DetectedStop `json:"stops"`
+	NextCursor *string               `json:"nextCursor,omitempty"`
+}
+
+// PositionHistory defines storage operations for the recent positions of an
+// account's coordinates locations and the stops detected in them.
+type PositionHistory interface {
+	// RecordPosition stores a ping, deleted by the table's TTL once
+	// retention has passed
+	RecordPosition(ctx context.Context, accountID string, ping models.PositionPing, retention time.Duration) error
+	// ListPositions returns an account's pings since a time, oldest first
+	ListPositions(ctx context.Context, accountID string, since time.Time) ([]models.PositionPing, error)
+	// PutDetectedStop stores a stop, replacing the one detected earlier for
+	// the same location and arrival
+	PutDetectedStop(ctx context.Context, stop models.DetectedStop) error
+	// ListDetectedStops lists an account's stops by location and arrival,
+	// only those of locationID when it is set
+	ListDetectedStops(ctx context.Context, accountID, locationID string, options *ListOptions) (*DetectedStopListResult, error)
+}
+
+// positionRecord is the DynamoDB item holding one recorded position.
+type positionRecord struct {
+	PK string `dynamodbav:"PK"` // POSITION#accountId
+	SK string `dynamodbav:"SK"` // at#locationId
+	models.PositionPing
+	ExpiresAt int64 `dynamodbav:"expiresAt"` // Unix seconds
+}
+
+// stopRecord is the DynamoDB item holding one detected stop.
+type stopRecord struct {
+	PK string `dynamodbav:"PK"` // STOP#accountId
+	SK string `dynamodbav:"SK"` // locationId#arrivedAt
+	models.DetectedStop
+}
+
+// RecordPosition stores a ping of a coordinates location. Pings are keyed by
+// time first, so detection reads all of an account's recent pings at once.
+func (r *DynamoDBRepository) RecordPosition(ctx context.Context, accountID string, ping models.PositionPing, retention time.Duration) error {
+	item, err := attributevalue.MarshalMap(positionRecord{
+		PK:           positionPKPrefix + accountID,
+		SK:           ping.At.UTC().Format(sortableTime) + "#" + ping.LocationID,
+		PositionPing: ping,
+		ExpiresAt:    ping.At.Add(retention).Unix(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal position: %w", err)
+	}
+
+	_, err = r.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(r.tableName),
+		Item:      item,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record position: %w", err)
+	}
+
+	return nil
+}
+
+// ListPositions returns an account's pings at or after since, in time order.
+func (r *DynamoDBRepository) ListPositions(ctx context.Context, accountID string, since time.Time) ([]models.PositionPing, error) {
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		KeyConditionExpression: aws.String("PK = :pk AND SK >= :since"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk":    &types.AttributeValueMemberS{Value: positionPKPrefix + accountID},
+			":since": &types.AttributeValueMemberS{Value: since.UTC().Format(sortableTime)},
+		},
+	}
+
+	pings := []models.PositionPing{}
+	for {
+		result, err := r.client.Query(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list positions: %w", err)
+		}
+		for _, item := range result.Items {
+			var record positionRecord
+			if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal position: %w", err)
+			}
+			pings = append(pings, record.PositionPing)
+		}
+		if result.LastEvaluatedKey == nil {
+			return pings, nil
+		}
+		input.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+}
+
+// PutDetectedStop stores a detected stop.
+func (r *DynamoDBRepository) PutDetectedStop(ctx context.Context, stop models.DetectedStop) error {
+	if stop.AccountID == "" || stop.LocationID == "" {
+		return fmt.Errorf("validation failed: accountId and locationId are required")
+	}
+
+	item, err := attributevalue.MarshalMap(stopRecord{
+		PK:           stopPKPrefix + stop.AccountID,
+		SK:           stop.LocationID + "#" + stop.ArrivedAt.UTC().Format(sortableTime),
+		DetectedStop: stop,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal detected stop: %w", err)
+	}
+
+	_, err = r.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(r.tableName),
+		Item:      item,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put detected stop: %w", err)
+	}
+
+	return nil
+}
+
+// ListDetectedStops lists an account's detected stops with cursor-based pagination.
+func (r *DynamoDBRepository) ListDetectedStops(ctx context.Context, accountID, locationID string, options *ListOptions) (*DetectedStopListResult, error) {
+	limit := r.defaultLimit
+	if options != nil && options.Limit != nil {
+		limit = *options.Limit
+	}
+
+	var cursor *paginationCursor
+	if options != nil && options.Cursor != nil {
+		var err error
+		cursor, err = r.decodeCursor(options.Cursor)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode cursor: %w", err)
+		}
+	}
+
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		KeyConditionExpression: aws.String("PK = :pk"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": &types.AttributeValueMemberS{Value: stopPKPrefix + accountID},
+		},
+		Limit:             aws.Int32(limit),
+		ExclusiveStartKey: r.cursorToLastEvaluatedKey(cursor),
+		ScanIndexForward:  aws.Bool(true),
+	}
+	if locationID != "" {
+		input.KeyConditionExpression = aws.String("PK = :pk AND begins_with(SK, :location)")
+		input.ExpressionAttributeValues[":location"] = &types.AttributeValueMemberS{Value: locationID + "#"}
+	}
+
+	result, err := r.client.Query(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list detected stops: %w", err)
+	}
+
+	stops := make([]models.DetectedStop, 0, len(result.Items))
+	for _, item := range result.Items {
+		var record stopRecord
+		if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal detected stop: %w", err)
+		}
+		stops = append(stops, record.DetectedStop)
+	}
+
+	var nextCursor *string
+	if result.LastEvaluatedKey != nil {
+		nextCursor, err = r.encodeCursor(r.lastEvaluatedKeyToCursor(result.LastEvaluatedKey))
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode cursor: %w", err)
+		}
+	}
+
+	return &DetectedStopListResult{
+		Stops:      stops,
+		NextCursor: nextCursor,
+	}, nil
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDynamoDBRepositoryPositions(t *testing.T) {
+	ctx := context.Background()
+	at := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	ping := models.PositionPing{LocationID: "loc-001", Coordinates: models.Coordinates{Latitude: 39.74, Longitude: -104.99}, At: at}
+
+	t.Run("Record a position", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		var stored map[string]types.AttributeValue
+		mockClient.On("PutItem", ctx, mock.Anything).Run(func(args mock.Arguments) {
+			stored = args.Get(1).(*dynamodb.PutItemInput).Item
+		}).Return(&dynamodb.PutItemOutput{}, nil).Once()
+
+		require.NoError(t, repo.RecordPosition(ctx, "acc-12345", ping, 24*time.Hour))
+		assert.Equal(t, "POSITION#acc-12345", stored["PK"].(*types.AttributeValueMemberS).Value)
+		assert.Equal(t, "2024-06-01T12:00:00.000000000Z#loc-001", stored["SK"].(*types.AttributeValueMemberS).Value)
+		assert.Equal(t, "1717329600", stored["expiresAt"].(*types.AttributeValueMemberN).Value)
+	})
+
+	t.Run("List positions since a time", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		item, err := attributevalue.MarshalMap(positionRecord{PK: "POSITION#acc-12345", SK: "2024-06-01T12:00:00.000000000Z#loc-001", PositionPing: ping})
+		require.NoError(t, err)
+		mockClient.On("Query", ctx, mock.MatchedBy(func(input *dynamodb.QueryInput) bool {
+			return *input.KeyConditionExpression == "PK = :pk AND SK >= :since" &&
+				input.ExpressionAttributeValues[":since"].(*types.AttributeValueMemberS).Value == "2024-06-01T00:00:00.000000000Z"
+		})).Return(&dynamodb.QueryOutput{
+			Items:            []map[string]types.AttributeValue{item},
+			LastEvaluatedKey: map[string]types.AttributeValue{"PK": &types.AttributeValueMemberS{Value: "POSITION#acc-12345"}},
+		}, nil).Once()
+		mockClient.On("Query", ctx, mock.Anything).Return(&dynamodb.QueryOutput{}, nil).Once()
+
+		pings, err := repo.ListPositions(ctx, "acc-12345", time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC))
+		require.NoError(t, err)
+		assert.Equal(t, []models.PositionPing{ping}, pings)
+		mockClient.AssertExpectations(t)
+	})
+}
+
+func TestDynamoDBRepositoryDetectedStops(t *testing.T) {
+	ctx := context.Background()
+	stop := models.DetectedStop{
+		AccountID:    "acc-12345",
+		LocationID:   "loc-001",
+		Center:       models.Coordinates{Latitude: 39.77, Longitude: -104.99},
+		ArrivedAt:    time.Date(2024, 6, 1, 8, 16, 0, 0, time.UTC),
+		DepartedAt:   time.Date(2024, 6, 1, 8, 36, 0, 0, time.UTC),
+		DwellSeconds: 1200,
+		Pings:        3,
+		DetectedAt:   time.Date(2024, 6, 2, 0, 0, 0, 0, time.UTC),
+	}
+
+	t.Run("Put and list stops of a location", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		var stored map[string]types.AttributeValue
+		mockClient.On("PutItem", ctx, mock.Anything).Run(func(args mock.Arguments) {
+			stored = args.Get(1).(*dynamodb.PutItemInput).Item
+		}).Return(&dynamodb.PutItemOutput{}, nil).Once()
+		require.NoError(t, repo.PutDetectedStop(ctx, stop))
+		assert.Equal(t, "STOP#acc-12345", stored["PK"].(*types.AttributeValueMemberS).Value)
+		assert.Equal(t, "loc-001#2024-06-01T08:16:00.000000000Z", stored["SK"].(*types.AttributeValueMemberS).Value)
+
+		mockClient.On("Query", ctx, mock.MatchedBy(func(input *dynamodb.QueryInput) bool {
+			return *input.KeyConditionExpression == "PK = :pk AND begins_with(SK, :location)" &&
+				input.ExpressionAttributeValues[":location"].(*types.AttributeValueMemberS).Value == "loc-001#"
+		})).Return(&dynamodb.QueryOutput{Items: []map[string]types.AttributeValue{stored}}, nil).Once()
+
+		result, err := repo.ListDetectedStops(ctx, "acc-12345", "loc-001", nil)
+		require.NoError(t, err)
+		assert.Equal(t, []models.DetectedStop{stop}, result.Stops)
+		assert.Nil(t, result.NextCursor)
+	})
+
+	t.Run("Rejects a stop without a location", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		err := repo.PutDetectedStop(ctx, models.DetectedStop{AccountID: "acc-12345"})
+		assert.EqualError(t, err, "validation failed: accountId and locationId are required")
+		mockClient.AssertNotCalled(t, "PutItem", mock.Anything, mock.Anything)
+	})
+}
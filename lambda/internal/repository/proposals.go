@@ -0,0 +1,349 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// proposalPKPrefix keeps change proposals out of an account's location partition.
+const proposalPKPrefix = "PROPOSAL#"
+
+// ProposalStatus is where a change proposal is in its review.
+type ProposalStatus string
+
+const (
+	// ProposalPending awaits review.
+	ProposalPending ProposalStatus = "pending"
+	// ProposalApproved was approved and its change applied.
+	ProposalApproved ProposalStatus = "approved"
+	// ProposalRejected was rejected without changing the location.
+	ProposalRejected ProposalStatus = "rejected"
+	// ProposalConflicting was approved after its location had changed, so
+	// its change was not applied.
+	ProposalConflicting ProposalStatus = "conflicting"
+)
+
+// LocationProposal is a proposed replacement of a location, applied only
+// once an admin approves it.
+type LocationProposal struct {
+	ProposalID string `json:"proposalId" dynamodbav:"proposalId"`
+	AccountID  string `json:"accountId" dynamodbav:"accountId"`
+	LocationID string `json:"locationId" dynamodbav:"locationId"`
+	// Input is the proposed location, as updateLocation takes it
+	Input map[string]interface{} `json:"input" dynamodbav:"input"`
+	// BaseETag is the location's ETag when the proposal was made; approval
+	// applies the proposal only while the location still has it
+	BaseETag   string         `json:"baseEtag,omitempty" dynamodbav:"baseEtag,omitempty"`
+	Status     ProposalStatus `json:"status" dynamodbav:"status"`
+	ProposedBy string         `json:"proposedBy,omitempty" dynamodbav:"proposedBy,omitempty"`
+	ProposedAt time.Time      `json:"proposedAt" dynamodbav:"proposedAt"`
+	ReviewedBy string         `json:"reviewedBy,omitempty" dynamodbav:"reviewedBy,omitempty"`
+	ReviewedAt *time.Time     `json:"reviewedAt,omitempty" dynamodbav:"reviewedAt,omitempty"`
+	// Comment is the reviewer's note, such as why the proposal was rejected
+	Comment string `json:"comment,omitempty" dynamodbav:"comment,omitempty"`
+}
+
+// ProposalListResult represents a page of change proposals.
+type ProposalListResult struct {
+	Proposals  []LocationProposal `json:"proposals"`
+	NextCursor *string            `json:"nextCursor,omitempty"`
+}
+
+// ProposalStore defines storage operations for location change proposals.
+type ProposalStore interface {
+	CreateProposal(ctx context.Context, proposal LocationProposal) (string, error)
+	GetProposal(ctx context.Context, accountID, proposalID string) (*LocationProposal, error)
+	ListProposals(ctx context.Context, accountID string, status ProposalStatus, options *ListOptions) (*ProposalListResult, error)
+	ReviewProposal(ctx context.Context, accountID, proposalID string, status ProposalStatus, reviewedBy, comment string) (*LocationProposal, error)
+}
+
+// proposalRecord is the DynamoDB item holding a change proposal.
+type proposalRecord struct {
+	PK string `dynamodbav:"PK"` // PROPOSAL#accountId
+	SK string `dynamodbav:"SK"` // proposalId
+	LocationProposal
+	// EncryptedDataKey is the KMS-wrapped data key for the input's encrypted attributes
+	EncryptedDataKey []byte `dynamodbav:"encryptedDataKey,omitempty"`
+	// PIIFindings lists where apparent PII was found when the account policy is "tag"
+	PIIFindings []string `dynamodbav:"piiFindings,omitempty"`
+}
+
+// inputAttributes returns the proposed location's extended attributes as a
+// location record, so they go through the same policies as a location's.
+// The record is keyed by account and proposal, binding ciphertext to both.
+func (p *proposalRecord) inputAttributes() (*locationRecord, bool) {
+	attrs, ok := p.Input["extendedAttributes"].(map[string]interface{})
+	if !ok || len(attrs) == 0 {
+		return nil, false
+	}
+	return &locationRecord{
+		PK:                 p.AccountID,
+		SK:                 p.ProposalID,
+		ExtendedAttributes: attrs,
+	}, true
+}
+
+// setInputAttributes replaces the proposed extended attributes, copying the
+// input so the caller's proposal is left untouched.
+func (p *proposalRecord) setInputAttributes(attrs map[string]interface{}) {
+	input := make(map[string]interface{}, len(p.Input))
+	for key, value := range p.Input {
+		input[key] = value
+	}
+	input["extendedAttributes"] = attrs
+	p.Input = input
+}
+
+// protectProposal applies the account's PII policy to the proposed extended
+// attributes and encrypts the configured ones, as Create does for a location.
+func (r *DynamoDBRepository) protectProposal(ctx context.Context, record *proposalRecord) error {
+	attrs, ok := record.inputAttributes()
+	if !ok {
+		return nil
+	}
+
+	if err := r.applyPIIPolicy(attrs); err != nil {
+		return fmt.Errorf("validation failed: %w", err)
+	}
+	if err := r.encryptAttributes(ctx, attrs); err != nil {
+		return fmt.Errorf("failed to encrypt proposal: %w", err)
+	}
+
+	record.setInputAttributes(attrs.ExtendedAttributes)
+	record.EncryptedDataKey = attrs.EncryptedDataKey
+	record.PIIFindings = attrs.PIIFindings
+	return nil
+}
+
+// revealProposal decrypts the proposed extended attributes of a stored proposal.
+func (r *DynamoDBRepository) revealProposal(ctx context.Context, record proposalRecord) (*LocationProposal, error) {
+	if len(record.EncryptedDataKey) == 0 {
+		return &record.LocationProposal, nil
+	}
+	attrs, ok := record.inputAttributes()
+	if !ok {
+		return &record.LocationProposal, nil
+	}
+
+	attrs.EncryptedDataKey = record.EncryptedDataKey
+	// decryptAttributes works in place, so decrypt a copy of the stored map
+	stored := attrs.ExtendedAttributes
+	attrs.ExtendedAttributes = make(map[string]interface{}, len(stored))
+	for key, value := range stored {
+		attrs.ExtendedAttributes[key] = value
+	}
+	if err := r.decryptAttributes(ctx, attrs); err != nil {
+		return nil, fmt.Errorf("failed to decrypt proposal: %w", err)
+	}
+
+	record.setInputAttributes(attrs.ExtendedAttributes)
+	return &record.LocationProposal, nil
+}
+
+// proposalKey returns the primary key of a proposal item.
+func proposalKey(accountID, proposalID string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberS{Value: proposalPKPrefix + accountID},
+		"SK": &types.AttributeValueMemberS{Value: proposalID},
+	}
+}
+
+// CreateProposal stores a new pending proposal and returns its ID.
+func (r *DynamoDBRepository) CreateProposal(ctx context.Context, proposal LocationProposal) (string, error) {
+	if proposal.AccountID == "" || proposal.LocationID == "" {
+		return "", fmt.Errorf("validation failed: accountId and locationId are required")
+	}
+	if len(proposal.Input) == 0 {
+		return "", fmt.Errorf("validation failed: input is required")
+	}
+
+	// The default version 7 UUIDs sort by creation time, so proposals list oldest first
+	proposal.ProposalID = r.ids.NewID()
+	proposal.Status = ProposalPending
+	proposal.ProposedAt = r.now().UTC()
+	proposal.ReviewedBy = ""
+	proposal.ReviewedAt = nil
+	proposal.Comment = ""
+
+	record := proposalRecord{
+		PK:               proposalPKPrefix + proposal.AccountID,
+		SK:               proposal.ProposalID,
+		LocationProposal: proposal,
+	}
+	if err := r.protectProposal(ctx, &record); err != nil {
+		return "", err
+	}
+
+	item, err := attributevalue.MarshalMap(record)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal proposal: %w", err)
+	}
+
+	_, err = r.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(r.tableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(PK) AND attribute_not_exists(SK)"),
+	})
+	if err != nil {
+		var ccf *types.ConditionalCheckFailedException
+		if errors.As(err, &ccf) {
+			return "", fmt.Errorf("proposal already exists")
+		}
+		return "", fmt.Errorf("failed to create proposal: %w", err)
+	}
+
+	return proposal.ProposalID, nil
+}
+
+// GetProposal retrieves a proposal by account ID and proposal ID.
+func (r *DynamoDBRepository) GetProposal(ctx context.Context, accountID, proposalID string) (*LocationProposal, error) {
+	record, err := r.getProposalRecord(ctx, accountID, proposalID)
+	if err != nil {
+		return nil, err
+	}
+	return r.revealProposal(ctx, *record)
+}
+
+// getProposalRecord reads a proposal item as stored, still encrypted.
+func (r *DynamoDBRepository) getProposalRecord(ctx context.Context, accountID, proposalID string) (*proposalRecord, error) {
+	result, err := r.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(r.tableName),
+		Key:            proposalKey(accountID, proposalID),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get proposal: %w", err)
+	}
+
+	if result.Item == nil {
+		return nil, fmt.Errorf("proposal not found")
+	}
+
+	var record proposalRecord
+	if err := attributevalue.UnmarshalMap(result.Item, &record); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal proposal: %w", err)
+	}
+
+	return &record, nil
+}
+
+// ListProposals lists an account's proposals, oldest first, with
+// cursor-based pagination. A status limits them to proposals in that status;
+// as it filters the page read, pages may hold fewer proposals than the limit.
+func (r *DynamoDBRepository) ListProposals(ctx context.Context, accountID string, status ProposalStatus, options *ListOptions) (*ProposalListResult, error) {
+	limit := r.defaultLimit
+	if options != nil && options.Limit != nil {
+		limit = *options.Limit
+	}
+
+	var cursor *paginationCursor
+	if options != nil && options.Cursor != nil {
+		var err error
+		cursor, err = r.decodeCursor(options.Cursor)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode cursor: %w", err)
+		}
+	}
+
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		KeyConditionExpression: aws.String("PK = :pk"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": &types.AttributeValueMemberS{Value: proposalPKPrefix + accountID},
+		},
+		Limit:             aws.Int32(limit),
+		ExclusiveStartKey: r.cursorToLastEvaluatedKey(cursor),
+		ScanIndexForward:  aws.Bool(true),
+	}
+	if status != "" {
+		input.FilterExpression = aws.String("#status = :status")
+		input.ExpressionAttributeNames = map[string]string{"#status": "status"}
+		input.ExpressionAttributeValues[":status"] = &types.AttributeValueMemberS{Value: string(status)}
+	}
+
+	result, err := r.client.Query(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list proposals: %w", err)
+	}
+
+	proposals := make([]LocationProposal, 0, len(result.Items))
+	for _, item := range result.Items {
+		var record proposalRecord
+		if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal proposal: %w", err)
+		}
+		proposal, err := r.revealProposal(ctx, record)
+		if err != nil {
+			return nil, err
+		}
+		proposals = append(proposals, *proposal)
+	}
+
+	var nextCursor *string
+	if result.LastEvaluatedKey != nil {
+		nextCursor, err = r.encodeCursor(r.lastEvaluatedKeyToCursor(result.LastEvaluatedKey))
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode cursor: %w", err)
+		}
+	}
+
+	return &ProposalListResult{
+		Proposals:  proposals,
+		NextCursor: nextCursor,
+	}, nil
+}
+
+// ReviewProposal records the review of a pending proposal and returns it as
+// reviewed. Only pending proposals can be reviewed, so of two concurrent
+// reviews one fails.
+func (r *DynamoDBRepository) ReviewProposal(ctx context.Context, accountID, proposalID string, status ProposalStatus, reviewedBy, comment string) (*LocationProposal, error) {
+	if status != ProposalApproved && status != ProposalRejected && status != ProposalConflicting {
+		return nil, fmt.Errorf("validation failed: status must be %s, %s, or %s", ProposalApproved, ProposalRejected, ProposalConflicting)
+	}
+
+	// The stored record is rewritten, so its input stays encrypted
+	record, err := r.getProposalRecord(ctx, accountID, proposalID)
+	if err != nil {
+		return nil, err
+	}
+	if record.Status != ProposalPending {
+		return nil, fmt.Errorf("proposal is already %s", record.Status)
+	}
+
+	reviewedAt := r.now().UTC()
+	record.Status = status
+	record.ReviewedBy = reviewedBy
+	record.ReviewedAt = &reviewedAt
+	record.Comment = comment
+
+	item, err := attributevalue.MarshalMap(record)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal proposal: %w", err)
+	}
+
+	_, err = r.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:                aws.String(r.tableName),
+		Item:                     item,
+		ConditionExpression:      aws.String("#status = :pending"),
+		ExpressionAttributeNames: map[string]string{"#status": "status"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pending": &types.AttributeValueMemberS{Value: string(ProposalPending)},
+		},
+	})
+	if err != nil {
+		var ccf *types.ConditionalCheckFailedException
+		if errors.As(err, &ccf) {
+			return nil, fmt.Errorf("proposal was reviewed concurrently")
+		}
+		return nil, fmt.Errorf("failed to review proposal: %w", err)
+	}
+
+	return r.revealProposal(ctx, *record)
+}
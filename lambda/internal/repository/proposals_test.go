@@ -0,0 +1,213 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/steverhoton/location-lambda/internal/pii"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDynamoDBRepositoryCreateProposal(t *testing.T) {
+	t.Run("Stores a pending proposal", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		var stored map[string]types.AttributeValue
+		mockClient.On("PutItem", ctx, mock.Anything).Run(func(args mock.Arguments) {
+			stored = args.Get(1).(*dynamodb.PutItemInput).Item
+		}).Return(&dynamodb.PutItemOutput{}, nil).Once()
+
+		proposalID, err := repo.CreateProposal(ctx, LocationProposal{
+			AccountID:  "acc-12345",
+			LocationID: "loc-001",
+			Input:      map[string]interface{}{"locationType": "coordinates"},
+			Status:     ProposalApproved,
+			ProposedBy: "field-tech",
+		})
+		require.NoError(t, err)
+
+		var record proposalRecord
+		require.NoError(t, attributevalue.UnmarshalMap(stored, &record))
+		assert.Equal(t, "PROPOSAL#acc-12345", record.PK)
+		assert.Equal(t, proposalID, record.SK)
+		assert.Equal(t, ProposalPending, record.Status, "new proposals are always pending")
+		assert.False(t, record.ProposedAt.IsZero())
+	})
+
+	t.Run("Encrypts the proposed attributes as a location's", func(t *testing.T) {
+		ctx := context.Background()
+		dataKey := []byte("0123456789abcdef0123456789abcdef")
+		wrappedKey := []byte("wrapped-data-key")
+		mockClient := new(mockDynamoDBClient)
+		mockKMS := new(mockKMSClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table",
+			WithEncryption(mockKMS, EncryptionConfig{KeyID: "alias/locations", Attributes: []string{"email"}}))
+
+		var stored map[string]types.AttributeValue
+		mockKMS.On("GenerateDataKey", ctx, mock.MatchedBy(func(input *kms.GenerateDataKeyInput) bool {
+			return input.EncryptionContext["accountId"] == "acc-12345"
+		})).Return(&kms.GenerateDataKeyOutput{Plaintext: dataKey, CiphertextBlob: wrappedKey}, nil).Once()
+		mockClient.On("PutItem", ctx, mock.Anything).Run(func(args mock.Arguments) {
+			stored = args.Get(1).(*dynamodb.PutItemInput).Item
+		}).Return(&dynamodb.PutItemOutput{}, nil).Once()
+
+		input := map[string]interface{}{
+			"locationType":       "coordinates",
+			"extendedAttributes": map[string]interface{}{"email": "owner@example.com", "color": "blue"},
+		}
+		proposalID, err := repo.CreateProposal(ctx, LocationProposal{AccountID: "acc-12345", LocationID: "loc-001", Input: input})
+		require.NoError(t, err)
+		assert.Equal(t, "owner@example.com", input["extendedAttributes"].(map[string]interface{})["email"], "the caller's input is left untouched")
+
+		// The stored email is ciphertext while other attributes stay readable
+		ext := stored["input"].(*types.AttributeValueMemberM).Value["extendedAttributes"].(*types.AttributeValueMemberM).Value
+		email := ext["email"].(*types.AttributeValueMemberS).Value
+		assert.True(t, strings.HasPrefix(email, encryptedValuePrefix))
+		assert.NotContains(t, email, "owner@example.com")
+		assert.Equal(t, "blue", ext["color"].(*types.AttributeValueMemberS).Value)
+		assert.Equal(t, wrappedKey, stored["encryptedDataKey"].(*types.AttributeValueMemberB).Value)
+
+		// Reads return the plaintext, and a review keeps the stored ciphertext
+		mockKMS.On("Decrypt", ctx, mock.MatchedBy(func(input *kms.DecryptInput) bool {
+			return string(input.CiphertextBlob) == string(wrappedKey) && input.EncryptionContext["locationId"] == proposalID
+		})).Return(&kms.DecryptOutput{Plaintext: dataKey}, nil).Times(2)
+		mockClient.On("GetItem", ctx, mock.Anything).Return(&dynamodb.GetItemOutput{Item: stored}, nil).Times(2)
+		var put *dynamodb.PutItemInput
+		mockClient.On("PutItem", ctx, mock.Anything).Run(func(args mock.Arguments) {
+			put = args.Get(1).(*dynamodb.PutItemInput)
+		}).Return(&dynamodb.PutItemOutput{}, nil).Once()
+
+		proposal, err := repo.GetProposal(ctx, "acc-12345", proposalID)
+		require.NoError(t, err)
+		assert.Equal(t, "owner@example.com", proposal.Input["extendedAttributes"].(map[string]interface{})["email"])
+
+		reviewed, err := repo.ReviewProposal(ctx, "acc-12345", proposalID, ProposalRejected, "governance-lead", "")
+		require.NoError(t, err)
+		assert.Equal(t, "owner@example.com", reviewed.Input["extendedAttributes"].(map[string]interface{})["email"])
+		reviewedExt := put.Item["input"].(*types.AttributeValueMemberM).Value["extendedAttributes"].(*types.AttributeValueMemberM).Value
+		assert.Equal(t, email, reviewedExt["email"].(*types.AttributeValueMemberS).Value)
+		assert.Equal(t, wrappedKey, put.Item["encryptedDataKey"].(*types.AttributeValueMemberB).Value)
+		mockKMS.AssertExpectations(t)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Applies the account's PII policy", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table",
+			WithPIIPolicy(pii.Config{Default: pii.PolicyReject}))
+
+		_, err := repo.CreateProposal(context.Background(), LocationProposal{
+			AccountID:  "acc-12345",
+			LocationID: "loc-001",
+			Input: map[string]interface{}{
+				"extendedAttributes": map[string]interface{}{"owner": "jane@example.com"},
+			},
+		})
+		assert.EqualError(t, err, "validation failed: extendedAttributes contain apparent PII: owner (email)")
+		mockClient.AssertNotCalled(t, "PutItem", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Requires input", func(t *testing.T) {
+		repo := NewDynamoDBRepository(new(mockDynamoDBClient), "test-table")
+
+		_, err := repo.CreateProposal(context.Background(), LocationProposal{AccountID: "acc-12345", LocationID: "loc-001"})
+		assert.EqualError(t, err, "validation failed: input is required")
+	})
+}
+
+func TestDynamoDBRepositoryListProposals(t *testing.T) {
+	ctx := context.Background()
+	mockClient := new(mockDynamoDBClient)
+	repo := NewDynamoDBRepository(mockClient, "test-table")
+
+	item, err := attributevalue.MarshalMap(proposalRecord{
+		PK:               "PROPOSAL#acc-12345",
+		SK:               "prop-001",
+		LocationProposal: LocationProposal{ProposalID: "prop-001", AccountID: "acc-12345", Status: ProposalPending},
+	})
+	require.NoError(t, err)
+	mockClient.On("Query", ctx, mock.MatchedBy(func(input *dynamodb.QueryInput) bool {
+		return input.ExpressionAttributeValues[":pk"].(*types.AttributeValueMemberS).Value == "PROPOSAL#acc-12345" &&
+			aws.ToString(input.FilterExpression) == "#status = :status" &&
+			input.ExpressionAttributeValues[":status"].(*types.AttributeValueMemberS).Value == "pending"
+	})).Return(&dynamodb.QueryOutput{Items: []map[string]types.AttributeValue{item}}, nil).Once()
+
+	result, err := repo.ListProposals(ctx, "acc-12345", ProposalPending, nil)
+	require.NoError(t, err)
+	require.Len(t, result.Proposals, 1)
+	assert.Equal(t, "prop-001", result.Proposals[0].ProposalID)
+	mockClient.AssertExpectations(t)
+}
+
+func TestDynamoDBRepositoryReviewProposal(t *testing.T) {
+	storedItem := func(t *testing.T, status ProposalStatus) map[string]types.AttributeValue {
+		item, err := attributevalue.MarshalMap(proposalRecord{
+			PK:               "PROPOSAL#acc-12345",
+			SK:               "prop-001",
+			LocationProposal: LocationProposal{ProposalID: "prop-001", AccountID: "acc-12345", LocationID: "loc-001", Status: status, ProposedAt: time.Date(2024, time.June, 4, 12, 0, 0, 0, time.UTC)},
+		})
+		require.NoError(t, err)
+		return item
+	}
+
+	t.Run("Records the review of a pending proposal", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		mockClient.On("GetItem", ctx, mock.Anything).Return(&dynamodb.GetItemOutput{Item: storedItem(t, ProposalPending)}, nil).Once()
+		var put *dynamodb.PutItemInput
+		mockClient.On("PutItem", ctx, mock.Anything).Run(func(args mock.Arguments) {
+			put = args.Get(1).(*dynamodb.PutItemInput)
+		}).Return(&dynamodb.PutItemOutput{}, nil).Once()
+
+		proposal, err := repo.ReviewProposal(ctx, "acc-12345", "prop-001", ProposalRejected, "governance-lead", "wrong site")
+		require.NoError(t, err)
+		assert.Equal(t, ProposalRejected, proposal.Status)
+		assert.Equal(t, "governance-lead", proposal.ReviewedBy)
+		assert.NotNil(t, proposal.ReviewedAt)
+		assert.Equal(t, "#status = :pending", aws.ToString(put.ConditionExpression))
+	})
+
+	t.Run("Refuses a proposal already reviewed", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		mockClient.On("GetItem", ctx, mock.Anything).Return(&dynamodb.GetItemOutput{Item: storedItem(t, ProposalApproved)}, nil).Once()
+
+		_, err := repo.ReviewProposal(ctx, "acc-12345", "prop-001", ProposalRejected, "governance-lead", "")
+		assert.EqualError(t, err, "proposal is already approved")
+		mockClient.AssertNotCalled(t, "PutItem", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Reports a concurrent review", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		mockClient.On("GetItem", ctx, mock.Anything).Return(&dynamodb.GetItemOutput{Item: storedItem(t, ProposalPending)}, nil).Once()
+		mockClient.On("PutItem", ctx, mock.Anything).Return(nil, &types.ConditionalCheckFailedException{}).Once()
+
+		_, err := repo.ReviewProposal(ctx, "acc-12345", "prop-001", ProposalApproved, "governance-lead", "")
+		assert.EqualError(t, err, "proposal was reviewed concurrently")
+	})
+
+	t.Run("Only approves, rejects, or marks conflicting", func(t *testing.T) {
+		repo := NewDynamoDBRepository(new(mockDynamoDBClient), "test-table")
+
+		_, err := repo.ReviewProposal(context.Background(), "acc-12345", "prop-001", ProposalPending, "governance-lead", "")
+		assert.EqualError(t, err, "validation failed: status must be approved, rejected, or conflicting")
+	})
+}
@@ -0,0 +1,245 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// CascadeDeleter deletes a location together with the records pointing at it.
+type CascadeDeleter interface {
+	DeleteCascade(ctx context.Context, accountID, locationID string) ([]string, error)
+}
+
+// ReferenceConflictError is returned when deleting a location that other
+// records still point at, such as duplicates merged into it.
+type ReferenceConflictError struct {
+	LocationID   string
+	ReferencedBy []string
+}
+
+// Error implements the error interface.
+func (e *ReferenceConflictError) Error() string {
+	return fmt.Sprintf("location %s is referenced by merged locations %s", e.LocationID, strings.Join(e.ReferencedBy, ", "))
+}
+
+// mergedInto returns the IDs of tombstones still pointing at locationID.
+// Merge records name every duplicate of a survivor; those since deleted or
+// erased are skipped.
+func (r *DynamoDBRepository) mergedInto(ctx context.Context, accountID, locationID string) ([]string, error) {
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		KeyConditionExpression: aws.String("PK = :pk"),
+		FilterExpression:       aws.String("survivorId = :survivorId"),
+		ProjectionExpression:   aws.String("duplicateIds"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk":         &types.AttributeValueMemberS{Value: mergePKPrefix + accountID},
+			":survivorId": &types.AttributeValueMemberS{Value: locationID},
+		},
+	}
+
+	var referencedBy []string
+	for {
+		result, err := r.client.Query(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query merge records: %w", err)
+		}
+		for _, item := range result.Items {
+			var record struct {
+				DuplicateIDs []string `dynamodbav:"duplicateIds"`
+			}
+			if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal merge record: %w", err)
+			}
+			for _, duplicateID := range record.DuplicateIDs {
+				_, err := r.getRecord(ctx, accountID, duplicateID)
+				var merged *MergedError
+				switch {
+				case errors.As(err, &merged):
+					if merged.SurvivorID == locationID {
+						referencedBy = append(referencedBy, duplicateID)
+					}
+				case err != nil && !errors.Is(err, errLocationNotFound):
+					return nil, err
+				}
+			}
+		}
+		if result.LastEvaluatedKey == nil {
+			return referencedBy, nil
+		}
+		input.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+}
+
+// DeleteCascade deletes a location and the tombstones of duplicates merged
+// into it, following chains of merges, and returns the deleted tombstone IDs.
+func (r *DynamoDBRepository) DeleteCascade(ctx context.Context, accountID, locationID string) ([]string, error) {
+	if _, err := r.getRecord(ctx, accountID, locationID); err != nil {
+		var merged *MergedError
+		if errors.Is(err, errLocationNotFound) || errors.As(err, &merged) {
+			return nil, fmt.Errorf("location not found or access denied")
+		}
+		return nil, err
+	}
+
+	// Collect the tombstones breadth first, each with the location it was merged into
+	type tombstone struct{ id, survivorID string }
+	var tombstones []tombstone
+	survivors := []string{locationID}
+	for len(survivors) > 0 {
+		survivorID := survivors[0]
+		survivors = survivors[1:]
+		referencedBy, err := r.mergedInto(ctx, accountID, survivorID)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range referencedBy {
+			tombstones = append(tombstones, tombstone{id: id, survivorID: survivorID})
+			survivors = append(survivors, id)
+		}
+	}
+
+	deleted := make([]string, 0, len(tombstones))
+	for _, t := range tombstones {
+		if err := r.deleteRecord(ctx, accountID, t.id, t.survivorID); err != nil {
+			return deleted, fmt.Errorf("failed to delete merged location %s: %w", t.id, err)
+		}
+		deleted = append(deleted, t.id)
+	}
+	if err := r.deleteRecord(ctx, accountID, locationID, ""); err != nil {
+		return deleted, err
+	}
+	return deleted, nil
+}
+
+// ReferenceChecker finds records pointing at locations that no longer exist.
+type ReferenceChecker interface {
+	CheckReferences(ctx context.Context, accountID string, repair bool) (*ReferenceReport, error)
+}
+
+// Kinds of reference checked by CheckReferences.
+const (
+	// ReferenceMergedInto is a tombstone's pointer to the location it was merged into.
+	ReferenceMergedInto = "mergedInto"
+	// ReferenceExternalID is an external ID claim's pointer to the location holding it.
+	ReferenceExternalID = "externalId"
+)
+
+// DanglingReference is a record pointing at a location that no longer exists.
+type DanglingReference struct {
+	Kind string `json:"kind"`
+	// From is the tombstone's location ID, or the claimed external ID
+	From string `json:"from"`
+	// To is the missing location ID
+	To       string `json:"to"`
+	Repaired bool   `json:"repaired"`
+}
+
+// ReferenceReport lists the dangling references found in an account.
+type ReferenceReport struct {
+	AccountID string              `json:"accountId"`
+	Checked   int                 `json:"checked"`
+	Dangling  []DanglingReference `json:"dangling"`
+}
+
+// CheckReferences checks every merge tombstone and external ID claim in an
+// account against the location it points at. With repair, tombstones of
+// missing survivors are deleted and claims on missing locations released.
+// Erasing a survivor, or a delete that failed to release its claim, can leave
+// such references behind.
+func (r *DynamoDBRepository) CheckReferences(ctx context.Context, accountID string, repair bool) (*ReferenceReport, error) {
+	report := &ReferenceReport{AccountID: accountID, Dangling: []DanglingReference{}}
+
+	tombstones := &dynamodb.QueryInput{
+		TableName:                 aws.String(r.tableName),
+		FilterExpression:          aws.String("attribute_exists(mergedInto)"),
+		ProjectionExpression:      aws.String("SK, mergedInto"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{},
+	}
+	tombstones.KeyConditionExpression = aws.String(r.locationPartition(":pk", accountID, tombstones.ExpressionAttributeValues))
+	err := r.checkPages(ctx, tombstones, func(item map[string]types.AttributeValue) error {
+		var tombstone struct {
+			LocationID string `dynamodbav:"SK"`
+			MergedInto string `dynamodbav:"mergedInto"`
+		}
+		if err := attributevalue.UnmarshalMap(item, &tombstone); err != nil {
+			return fmt.Errorf("failed to unmarshal merged location: %w", err)
+		}
+		locationID := locationIDOf(tombstone.LocationID)
+		return r.checkReference(ctx, report, accountID, DanglingReference{Kind: ReferenceMergedInto, From: locationID, To: tombstone.MergedInto}, repair, func() error {
+			return r.deleteRecord(ctx, accountID, locationID, tombstone.MergedInto)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	claims := &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		KeyConditionExpression: aws.String("PK = :pk"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": &types.AttributeValueMemberS{Value: externalIDPKPrefix + accountID},
+		},
+	}
+	err = r.checkPages(ctx, claims, func(item map[string]types.AttributeValue) error {
+		var claim externalIDRecord
+		if err := attributevalue.UnmarshalMap(item, &claim); err != nil {
+			return fmt.Errorf("failed to unmarshal externalId claim: %w", err)
+		}
+		return r.checkReference(ctx, report, accountID, DanglingReference{Kind: ReferenceExternalID, From: claim.SK, To: claim.LocationID}, repair, func() error {
+			return r.releaseExternalID(ctx, accountID, claim.SK, claim.LocationID)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// checkPages calls check with every item of a query.
+func (r *DynamoDBRepository) checkPages(ctx context.Context, input *dynamodb.QueryInput, check func(map[string]types.AttributeValue) error) error {
+	for {
+		result, err := r.client.Query(ctx, input)
+		if err != nil {
+			return fmt.Errorf("failed to query references: %w", err)
+		}
+		for _, item := range result.Items {
+			if err := check(item); err != nil {
+				return err
+			}
+		}
+		if result.LastEvaluatedKey == nil {
+			return nil
+		}
+		input.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+}
+
+// checkReference adds ref to the report when its target is missing, running
+// fix first when repairing.
+func (r *DynamoDBRepository) checkReference(ctx context.Context, report *ReferenceReport, accountID string, ref DanglingReference, repair bool, fix func() error) error {
+	report.Checked++
+	_, err := r.getRecord(ctx, accountID, ref.To)
+	var merged *MergedError
+	if err == nil || errors.As(err, &merged) {
+		return nil
+	}
+	if !errors.Is(err, errLocationNotFound) {
+		return err
+	}
+	if repair {
+		if err := fix(); err != nil {
+			return fmt.Errorf("failed to repair %s reference from %s: %w", ref.Kind, ref.From, err)
+		}
+		ref.Repaired = true
+	}
+	report.Dangling = append(report.Dangling, ref)
+	return nil
+}
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// matchMergeQuery matches a merge record query for the given survivor.
+func matchMergeQuery(survivorID string) interface{} {
+	return mock.MatchedBy(func(input *dynamodb.QueryInput) bool {
+		pk, ok := input.ExpressionAttributeValues[":pk"].(*types.AttributeValueMemberS)
+		survivor, _ := input.ExpressionAttributeValues[":survivorId"].(*types.AttributeValueMemberS)
+		return ok && strings.HasPrefix(pk.Value, mergePKPrefix) && (survivorID == "" || survivor.Value == survivorID)
+	})
+}
+
+// noMergeReferences expects one merge record query that finds nothing.
+func noMergeReferences(mockClient *mockDynamoDBClient) {
+	mockClient.On("Query", mock.Anything, matchMergeQuery("")).Return(&dynamodb.QueryOutput{}, nil).Once()
+}
+
+// mergeRecordItem is a merge record listing duplicateIDs.
+func mergeRecordItem(t *testing.T, duplicateIDs ...string) map[string]types.AttributeValue {
+	t.Helper()
+	item, err := attributevalue.MarshalMap(map[string]interface{}{"duplicateIds": duplicateIDs})
+	require.NoError(t, err)
+	return item
+}
+
+// tombstoneItem is a location merged into survivorID.
+func tombstoneItem(locationID, survivorID string) map[string]types.AttributeValue {
+	item := coordinatesItem("acc-12345", locationID)
+	item["mergedInto"] = &types.AttributeValueMemberS{Value: survivorID}
+	return item
+}
+
+// matchDelete matches a DeleteItem call for the given location ID.
+func matchDelete(locationID string) interface{} {
+	return mock.MatchedBy(func(input *dynamodb.DeleteItemInput) bool {
+		sk, ok := input.Key["SK"].(*types.AttributeValueMemberS)
+		return ok && sk.Value == locationID
+	})
+}
+
+func TestDynamoDBRepositoryDeleteReferences(t *testing.T) {
+	t.Run("Refuses to delete a merge survivor", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		mockClient.On("Query", ctx, matchMergeQuery("loc-001")).Return(&dynamodb.QueryOutput{
+			Items: []map[string]types.AttributeValue{mergeRecordItem(t, "loc-002", "loc-003")},
+		}, nil).Once()
+		mockClient.On("GetItem", ctx, matchGet("loc-002")).Return(&dynamodb.GetItemOutput{Item: tombstoneItem("loc-002", "loc-001")}, nil).Once()
+		// Deleted since the merge, so no longer a reference
+		mockClient.On("GetItem", ctx, matchGet("loc-003")).Return(&dynamodb.GetItemOutput{}, nil).Once()
+
+		err := repo.Delete(ctx, "acc-12345", "loc-001")
+		var conflict *ReferenceConflictError
+		require.ErrorAs(t, err, &conflict)
+		assert.Equal(t, []string{"loc-002"}, conflict.ReferencedBy)
+		assert.EqualError(t, err, "location loc-001 is referenced by merged locations loc-002")
+		mockClient.AssertNotCalled(t, "DeleteItem", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Cascade deletes tombstones along merge chains", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		// loc-003 was merged into loc-002, which was later merged into loc-001
+		mockClient.On("GetItem", ctx, matchGet("loc-001")).Return(&dynamodb.GetItemOutput{Item: coordinatesItem("acc-12345", "loc-001")}, nil).Once()
+		mockClient.On("Query", ctx, matchMergeQuery("loc-001")).Return(&dynamodb.QueryOutput{
+			Items: []map[string]types.AttributeValue{mergeRecordItem(t, "loc-002")},
+		}, nil).Once()
+		mockClient.On("GetItem", ctx, matchGet("loc-002")).Return(&dynamodb.GetItemOutput{Item: tombstoneItem("loc-002", "loc-001")}, nil).Once()
+		mockClient.On("Query", ctx, matchMergeQuery("loc-002")).Return(&dynamodb.QueryOutput{
+			Items: []map[string]types.AttributeValue{mergeRecordItem(t, "loc-003")},
+		}, nil).Once()
+		mockClient.On("GetItem", ctx, matchGet("loc-003")).Return(&dynamodb.GetItemOutput{Item: tombstoneItem("loc-003", "loc-002")}, nil).Once()
+		mockClient.On("Query", ctx, matchMergeQuery("loc-003")).Return(&dynamodb.QueryOutput{}, nil).Once()
+
+		mockClient.On("DeleteItem", ctx, mock.MatchedBy(func(input *dynamodb.DeleteItemInput) bool {
+			survivor, ok := input.ExpressionAttributeValues[":survivor"].(*types.AttributeValueMemberS)
+			return input.Key["SK"].(*types.AttributeValueMemberS).Value == "loc-002" && ok && survivor.Value == "loc-001"
+		})).Return(&dynamodb.DeleteItemOutput{}, nil).Once()
+		mockClient.On("DeleteItem", ctx, mock.MatchedBy(func(input *dynamodb.DeleteItemInput) bool {
+			survivor, ok := input.ExpressionAttributeValues[":survivor"].(*types.AttributeValueMemberS)
+			return input.Key["SK"].(*types.AttributeValueMemberS).Value == "loc-003" && ok && survivor.Value == "loc-002"
+		})).Return(&dynamodb.DeleteItemOutput{}, nil).Once()
+		mockClient.On("DeleteItem", ctx, matchDelete("loc-001")).Return(&dynamodb.DeleteItemOutput{}, nil).Once()
+
+		deleted, err := repo.DeleteCascade(ctx, "acc-12345", "loc-001")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"loc-002", "loc-003"}, deleted)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Cascade fails for unknown locations", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		mockClient.On("GetItem", ctx, matchGet("loc-001")).Return(&dynamodb.GetItemOutput{}, nil).Once()
+
+		_, err := repo.DeleteCascade(ctx, "acc-12345", "loc-001")
+		assert.EqualError(t, err, "location not found or access denied")
+		mockClient.AssertNotCalled(t, "DeleteItem", mock.Anything, mock.Anything)
+	})
+}
+
+func TestDynamoDBRepositoryCheckReferences(t *testing.T) {
+	// loc-002 is a tombstone of the deleted loc-001; loc-004 of the live loc-003.
+	// ERP-1 is claimed by the deleted loc-009; ERP-2 by the live loc-003.
+	setup := func(t *testing.T) (*mockDynamoDBClient, *DynamoDBRepository) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		mockClient.On("Query", ctx, mock.MatchedBy(func(input *dynamodb.QueryInput) bool {
+			return input.ExpressionAttributeValues[":pk"].(*types.AttributeValueMemberS).Value == "acc-12345"
+		})).Return(&dynamodb.QueryOutput{Items: []map[string]types.AttributeValue{
+			tombstoneItem("loc-002", "loc-001"),
+			tombstoneItem("loc-004", "loc-003"),
+		}}, nil).Once()
+		mockClient.On("Query", ctx, mock.MatchedBy(func(input *dynamodb.QueryInput) bool {
+			return input.ExpressionAttributeValues[":pk"].(*types.AttributeValueMemberS).Value == "EXTERNALID#acc-12345"
+		})).Return(&dynamodb.QueryOutput{Items: []map[string]types.AttributeValue{
+			claimItem("ERP-1", "loc-009"),
+			claimItem("ERP-2", "loc-003"),
+		}}, nil).Once()
+		mockClient.On("GetItem", ctx, matchGet("loc-001")).Return(&dynamodb.GetItemOutput{}, nil).Once()
+		mockClient.On("GetItem", ctx, matchGet("loc-009")).Return(&dynamodb.GetItemOutput{}, nil).Once()
+		mockClient.On("GetItem", ctx, matchGet("loc-003")).Return(&dynamodb.GetItemOutput{Item: coordinatesItem("acc-12345", "loc-003")}, nil).Twice()
+		return mockClient, repo
+	}
+
+	t.Run("Reports dangling references", func(t *testing.T) {
+		mockClient, repo := setup(t)
+
+		report, err := repo.CheckReferences(context.Background(), "acc-12345", false)
+		require.NoError(t, err)
+		assert.Equal(t, &ReferenceReport{
+			AccountID: "acc-12345",
+			Checked:   4,
+			Dangling: []DanglingReference{
+				{Kind: ReferenceMergedInto, From: "loc-002", To: "loc-001"},
+				{Kind: ReferenceExternalID, From: "ERP-1", To: "loc-009"},
+			},
+		}, report)
+		mockClient.AssertNotCalled(t, "DeleteItem", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Repairs dangling references", func(t *testing.T) {
+		mockClient, repo := setup(t)
+		ctx := context.Background()
+		mockClient.On("DeleteItem", ctx, mock.MatchedBy(func(input *dynamodb.DeleteItemInput) bool {
+			return input.Key["SK"].(*types.AttributeValueMemberS).Value == "loc-002" &&
+				input.ExpressionAttributeValues[":survivor"].(*types.AttributeValueMemberS).Value == "loc-001"
+		})).Return(&dynamodb.DeleteItemOutput{}, nil).Once()
+		mockClient.On("DeleteItem", ctx, mock.MatchedBy(func(input *dynamodb.DeleteItemInput) bool {
+			return input.Key["PK"].(*types.AttributeValueMemberS).Value == "EXTERNALID#acc-12345" &&
+				input.Key["SK"].(*types.AttributeValueMemberS).Value == "ERP-1" &&
+				input.ExpressionAttributeValues[":locationId"].(*types.AttributeValueMemberS).Value == "loc-009"
+		})).Return(&dynamodb.DeleteItemOutput{}, nil).Once()
+
+		report, err := repo.CheckReferences(ctx, "acc-12345", true)
+		require.NoError(t, err)
+		require.Len(t, report.Dangling, 2)
+		assert.True(t, report.Dangling[0].Repaired)
+		assert.True(t, report.Dangling[1].Repaired)
+		mockClient.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// requestPKPrefix keeps recorded request IDs out of an account's location partition.
+const requestPKPrefix = "REQUEST#"
+
+// ReplayError is returned when a request ID was already recorded within the
+// replay window.
+type ReplayError struct {
+	RequestID string
+}
+
+func (e *ReplayError) Error() string {
+	return fmt.Sprintf("request %s was already received; send each mutation with a new request ID", e.RequestID)
+}
+
+// ReplayGuard records the request IDs of an account's mutations so a repeated
+// ID is rejected for a while.
+type ReplayGuard interface {
+	// RecordRequest returns a *ReplayError when requestID was recorded for the
+	// account less than window ago.
+	RecordRequest(ctx context.Context, accountID, requestID string, window time.Duration) error
+	// ReleaseRequest forgets a request ID, so a request that failed can be retried.
+	ReleaseRequest(ctx context.Context, accountID, requestID string) error
+}
+
+// requestRecord is the DynamoDB item recording one request ID. The table's
+// TTL deletes it some time after expiresAt, so expired records are also
+// overwritten rather than treated as replays.
+type requestRecord struct {
+	PK         string    `dynamodbav:"PK"` // REQUEST#accountId
+	SK         string    `dynamodbav:"SK"` // requestId
+	ReceivedAt time.Time `dynamodbav:"receivedAt"`
+	ExpiresAt  int64     `dynamodbav:"expiresAt"` // Unix seconds
+}
+
+// requestKey returns the primary key of a request record.
+func requestKey(accountID, requestID string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberS{Value: requestPKPrefix + accountID},
+		"SK": &types.AttributeValueMemberS{Value: requestID},
+	}
+}
+
+// RecordRequest records a request ID unless it is already recorded and unexpired.
+func (r *DynamoDBRepository) RecordRequest(ctx context.Context, accountID, requestID string, window time.Duration) error {
+	now := r.now().UTC()
+	item, err := attributevalue.MarshalMap(requestRecord{
+		PK:         requestPKPrefix + accountID,
+		SK:         requestID,
+		ReceivedAt: now,
+		ExpiresAt:  now.Add(window).Unix(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request record: %w", err)
+	}
+
+	_, err = r.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(r.tableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(PK) OR expiresAt <= :now"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":now": &types.AttributeValueMemberN{Value: strconv.FormatInt(now.Unix(), 10)},
+		},
+	})
+	if err != nil {
+		var ccf *types.ConditionalCheckFailedException
+		if errors.As(err, &ccf) {
+			return &ReplayError{RequestID: requestID}
+		}
+		return fmt.Errorf("failed to record request: %w", err)
+	}
+	return nil
+}
+
+// ReleaseRequest deletes a request record.
+func (r *DynamoDBRepository) ReleaseRequest(ctx context.Context, accountID, requestID string) error {
+	_, err := r.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(r.tableName),
+		Key:       requestKey(accountID, requestID),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to release request: %w", err)
+	}
+	return nil
+}
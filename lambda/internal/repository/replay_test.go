@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDynamoDBRepositoryReplayGuard(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Records a new request ID", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		var input *dynamodb.PutItemInput
+		mockClient.On("PutItem", ctx, mock.Anything).Run(func(args mock.Arguments) {
+			input = args.Get(1).(*dynamodb.PutItemInput)
+		}).Return(&dynamodb.PutItemOutput{}, nil).Once()
+
+		before := time.Now()
+		require.NoError(t, repo.RecordRequest(ctx, "acc-12345", "req-1", 10*time.Minute))
+		assert.Equal(t, "REQUEST#acc-12345", input.Item["PK"].(*types.AttributeValueMemberS).Value)
+		assert.Equal(t, "req-1", input.Item["SK"].(*types.AttributeValueMemberS).Value)
+		expiresAt, err := strconv.ParseInt(input.Item["expiresAt"].(*types.AttributeValueMemberN).Value, 10, 64)
+		require.NoError(t, err)
+		assert.InDelta(t, before.Add(10*time.Minute).Unix(), expiresAt, 1)
+		assert.Equal(t, "attribute_not_exists(PK) OR expiresAt <= :now", *input.ConditionExpression)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Rejects a replayed request ID", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+		mockClient.On("PutItem", ctx, mock.Anything).Return(nil, &types.ConditionalCheckFailedException{}).Once()
+
+		err := repo.RecordRequest(ctx, "acc-12345", "req-1", 10*time.Minute)
+		var replay *ReplayError
+		require.ErrorAs(t, err, &replay)
+		assert.Equal(t, "req-1", replay.RequestID)
+	})
+
+	t.Run("Releases a request ID", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+		mockClient.On("DeleteItem", ctx, mock.MatchedBy(func(input *dynamodb.DeleteItemInput) bool {
+			return input.Key["PK"].(*types.AttributeValueMemberS).Value == "REQUEST#acc-12345" &&
+				input.Key["SK"].(*types.AttributeValueMemberS).Value == "req-1"
+		})).Return(&dynamodb.DeleteItemOutput{}, nil).Once()
+
+		require.NoError(t, repo.ReleaseRequest(ctx, "acc-12345", "req-1"))
+		mockClient.AssertExpectations(t)
+	})
+}
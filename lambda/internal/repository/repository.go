@@ -7,68 +7,250 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
-	"github.com/google/uuid"
+	"github.com/aws/smithy-go"
 	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/pii"
 )
 
+// errLocationNotFound is returned when no location record exists for a key.
+var errLocationNotFound = errors.New("location not found")
+
 // ListResult represents the result of a paginated list operation.
 type ListResult struct {
-	Locations   []models.Location `json:"locations"`
-	LocationIDs []string          `json:"locationIds"`
-	NextCursor  *string           `json:"nextCursor,omitempty"`
+	Items      []LocationEnvelope `json:"items"`
+	NextCursor *string            `json:"nextCursor,omitempty"`
+	StaleRead  bool               `json:"staleRead,omitempty"`
+	// IndexFallback is true when the page was read without the GSI it
+	// normally uses, which could not be read
+	IndexFallback bool `json:"indexFallback,omitempty"`
+}
+
+// LocationIDs returns the IDs of the listed locations in page order.
+func (r *ListResult) LocationIDs() []string {
+	ids := make([]string, len(r.Items))
+	for i, item := range r.Items {
+		ids[i] = item.LocationID
+	}
+	return ids
+}
+
+// LocationEnvelope carries a stored location together with its ID, which the
+// location models do not hold themselves.
+type LocationEnvelope struct {
+	LocationID string          `json:"locationId"`
+	Location   models.Location `json:"location"`
+	// ETag is the location's content hash, which changes whenever the
+	// location does; Update can be made conditional on it with WithIfMatch
+	ETag string `json:"etag,omitempty"`
 }
 
 // ListOptions contains options for listing operations.
 type ListOptions struct {
 	Limit  *int32  `json:"limit,omitempty"`
 	Cursor *string `json:"cursor,omitempty"`
+	// Category restricts the list to shops listed under it
+	Category string `json:"category,omitempty"`
+	// MissingCoordinates restricts the list to address locations without coordinates
+	MissingCoordinates bool `json:"missingCoordinates,omitempty"`
+	// MinGeocodeConfidence drops address locations without coordinates, or
+	// geocoded with a lower confidence; hand-entered coordinates are kept
+	MinGeocodeConfidence *float64 `json:"minGeocodeConfidence,omitempty"`
+	// Geocoded restricts the list to address locations with geocoded,
+	// unlocked coordinates
+	Geocoded bool `json:"geocoded,omitempty"`
+	// IncludeDrafts lists unpublished drafts along with published locations
+	IncludeDrafts bool `json:"includeDrafts,omitempty"`
+	// IncludeInactive lists locations outside their active window
+	IncludeInactive bool `json:"includeInactive,omitempty"`
+	// ActiveOn restricts the list to events running during its UTC day
+	ActiveOn *time.Time `json:"activeOn,omitempty"`
+	// MaxAccuracyMeters restricts the list to locations whose coordinates
+	// have an accuracy of at most this many meters
+	MaxAccuracyMeters *float64 `json:"maxAccuracyMeters,omitempty"`
 }
 
 // Repository defines the interface for location storage operations.
 type Repository interface {
-	Create(ctx context.Context, location models.Location) (string, error)
-	Get(ctx context.Context, accountID, locationID string) (models.Location, error)
+	Create(ctx context.Context, location models.Location) (*LocationEnvelope, error)
+	Get(ctx context.Context, accountID, locationID string) (*LocationEnvelope, error)
 	Update(ctx context.Context, location models.Location, locationID string) error
 	Delete(ctx context.Context, accountID, locationID string) error
 	List(ctx context.Context, accountID string, options *ListOptions) (*ListResult, error)
+	Erase(ctx context.Context, accountID, locationID string) (*ErasureCertificate, error)
+	Merge(ctx context.Context, accountID, survivorID string, duplicateIDs []string) (*MergeResult, error)
+	Upsert(ctx context.Context, location models.Location) (*UpsertResult, error)
+	GetByExternalID(ctx context.Context, accountID, externalID string) (*LocationEnvelope, error)
 }
 
 // DynamoDBRepository implements Repository using DynamoDB.
 type DynamoDBRepository struct {
-	client       DynamoDBClient
-	tableName    string
-	defaultLimit int32
+	client          DynamoDBClient
+	tableName       string
+	defaultLimit    int32
+	readConsistency ReadConsistency
+	sharding        ShardConfig
+	s3Client        S3Client
+	overflow        OverflowConfig
+	kmsClient       KMSClient
+	encryption      EncryptionConfig
+	piiPolicy       pii.Config
+	externalIDIndex string
+	locationIDIndex string
+	websiteIndex    string
+	contactIndex    string
+	// parentAccountIndex is the GSI finding account settings by parentAccountId
+	parentAccountIndex string
+	// verifyContentHash checks records read against their content hash
+	verifyContentHash bool
+	// syncIndex is the GSI SyncLocations queries, keyed on syncAccount and updatedAt
+	syncIndex string
+	// tombstoneRetention is how long deletions are kept for sync
+	tombstoneRetention time.Duration
+	// keyLayout is how location items are keyed; see KeyLayout
+	keyLayout KeyLayout
+	// fallbackSegments is how many parallel scan segments serve a GSI read
+	// whose index can't be read; 0 fails the read instead
+	fallbackSegments int
+	// scanSegments is how many parallel segments ScanLocations starts a scan with
+	scanSegments int
+	// clock and ids stamp and name new items; see WithClock and WithIDGenerator
+	clock Clock
+	ids   IDGenerator
+}
+
+// Option configures optional DynamoDBRepository behavior.
+type Option func(*DynamoDBRepository)
+
+// WithReadConsistency configures strongly-consistent reads and the throttling fallback.
+func WithReadConsistency(rc ReadConsistency) Option {
+	return func(r *DynamoDBRepository) {
+		r.readConsistency = rc
+	}
+}
+
+// WithSharding enables GSI write sharding for list queries.
+func WithSharding(cfg ShardConfig) Option {
+	return func(r *DynamoDBRepository) {
+		r.sharding = cfg
+	}
+}
+
+// WithOverflow stores extendedAttributes of oversized records in S3.
+func WithOverflow(client S3Client, cfg OverflowConfig) Option {
+	return func(r *DynamoDBRepository) {
+		r.s3Client = client
+		r.overflow = cfg
+	}
+}
+
+// WithEncryption enables client-side envelope encryption of extended attributes.
+func WithEncryption(client KMSClient, cfg EncryptionConfig) Option {
+	return func(r *DynamoDBRepository) {
+		r.kmsClient = client
+		r.encryption = cfg
+	}
+}
+
+// WithExternalIDIndex looks up external IDs with the sparse GSI keyed on accountExternalId.
+func WithExternalIDIndex(indexName string) Option {
+	return func(r *DynamoDBRepository) {
+		r.externalIDIndex = indexName
+	}
+}
+
+// WithDefaultLimit sets the page size used when a list call gives no limit.
+func WithDefaultLimit(limit int32) Option {
+	return func(r *DynamoDBRepository) {
+		r.defaultLimit = limit
+	}
 }
 
 // NewDynamoDBRepository creates a new DynamoDB repository.
-func NewDynamoDBRepository(client DynamoDBClient, tableName string) *DynamoDBRepository {
-	return &DynamoDBRepository{
+func NewDynamoDBRepository(client DynamoDBClient, tableName string, opts ...Option) *DynamoDBRepository {
+	repo := &DynamoDBRepository{
 		client:       client,
 		tableName:    tableName,
 		defaultLimit: 20,
+		clock:        SystemClock,
+		ids:          UUIDv7Generator,
 	}
+	for _, opt := range opts {
+		opt(repo)
+	}
+	return repo
 }
 
 // locationRecord represents a location record in DynamoDB.
 type locationRecord struct {
-	PK                 string                 `dynamodbav:"PK"` // accountId
-	SK                 string                 `dynamodbav:"SK"` // locationId (UUID)
+	PK                 string                 `dynamodbav:"PK"` // accountId, stored as the layout's locationPK
+	SK                 string                 `dynamodbav:"SK"` // locationId (UUID), stored as the layout's locationSK
 	LocationType       models.LocationType    `dynamodbav:"locationType"`
 	ExtendedAttributes map[string]interface{} `dynamodbav:"extendedAttributes,omitempty"`
 	Address            *models.Address        `dynamodbav:"address,omitempty"`
 	Coordinates        *models.Coordinates    `dynamodbav:"coordinates,omitempty"`
-	Shop               *models.Shop           `dynamodbav:"shop,omitempty"`
+	Shop               *shopAttribute         `dynamodbav:"shop,omitempty"`
+	Geocode            *models.GeocodeInfo    `dynamodbav:"geocode,omitempty"` // Provenance of an address location's coordinates
+	CoordinatesLocked  bool                   `dynamodbav:"coordinatesLocked,omitempty"`
+	Units              []models.Unit          `dynamodbav:"units,omitempty"`
+	AccountShard       string                 `dynamodbav:"accountShard,omitempty"` // accountId#shardN when sharding is enabled
+	// ExtendedAttributesRef is the S3 key holding extendedAttributes for oversized records
+	ExtendedAttributesRef string `dynamodbav:"extendedAttributesRef,omitempty"`
+	// EncryptedDataKey is the KMS-wrapped data key for client-side encrypted attributes
+	EncryptedDataKey []byte `dynamodbav:"encryptedDataKey,omitempty"`
+	// PIIFindings lists where apparent PII was found when the account policy is "tag"
+	PIIFindings []string `dynamodbav:"piiFindings,omitempty"`
+	// MergedInto is the surviving locationId once this record was merged as a duplicate
+	MergedInto string `dynamodbav:"mergedInto,omitempty"`
+	// ExternalID is the caller's identifier, claimed by an EXTERNALID# item
+	ExternalID string `dynamodbav:"externalId,omitempty"`
+	// AccountExternalID is accountId#externalId, the sparse external ID index key
+	AccountExternalID string `dynamodbav:"accountExternalId,omitempty"`
+	// AccountWebsite is accountId#host of a shop's website, the sparse website index key
+	AccountWebsite string `dynamodbav:"accountWebsite,omitempty"`
+	// AccountContact is accountId#contactId of a shop's primary contact, the sparse contact index key
+	AccountContact string `dynamodbav:"accountContact,omitempty"`
+	// CustomFields holds values of the account's declared custom fields
+	CustomFields map[string]interface{} `dynamodbav:"customFields,omitempty"`
+	// Draft is set until the location is published
+	Draft bool `dynamodbav:"draft,omitempty"`
+	// ActiveFrom and ActiveUntil bound the location's active window, formatted
+	// by formatScheduleTime so filters can compare them
+	ActiveFrom  string `dynamodbav:"activeFrom,omitempty"`
+	ActiveUntil string `dynamodbav:"activeUntil,omitempty"`
+	// StartsAt and EndsAt are when an event runs, formatted like ActiveFrom
+	StartsAt string `dynamodbav:"startsAt,omitempty"`
+	EndsAt   string `dynamodbav:"endsAt,omitempty"`
+	// Building, Floor, and IndoorCoordinates place a coordinates location indoors
+	Building          string                    `dynamodbav:"building,omitempty"`
+	Floor             string                    `dynamodbav:"floor,omitempty"`
+	IndoorCoordinates *models.IndoorCoordinates `dynamodbav:"indoorCoordinates,omitempty"`
+	// PositionAnomaly marks a coordinates location's flagged position
+	PositionAnomaly *models.PositionAnomaly `dynamodbav:"positionAnomaly,omitempty"`
+	// Addresses holds the location's further addresses by kind
+	Addresses map[models.AddressKind]models.Address `dynamodbav:"addresses,omitempty"`
+	// Recurrence repeats an event within StartsAt and EndsAt
+	Recurrence *models.Recurrence `dynamodbav:"recurrence,omitempty"`
+	// ContentHash is the SHA-256 of the location's canonical JSON as readers
+	// see it, decrypted and with extendedAttributes in place
+	ContentHash string `dynamodbav:"contentHash,omitempty"`
+	// SyncAccount is the accountId again, the sync index key; UpdatedAt,
+	// formatted by syncTime, is when the record was last written
+	SyncAccount string `dynamodbav:"syncAccount,omitempty"`
+	UpdatedAt   string `dynamodbav:"updatedAt,omitempty"`
 }
 
 // paginationCursor represents the cursor for pagination.
 type paginationCursor struct {
-	PK string `json:"pk"` // This is the accountId
-	SK string `json:"sk"` // This is the locationId (UUID)
+	PK     string        `json:"pk"`               // The accountId's locationPK
+	SK     string        `json:"sk"`               // The locationId's locationSK
+	Shards []shardCursor `json:"shards,omitempty"` // Per-shard positions when sharding is enabled
 }
 
 // toLocationRecord converts a Location to a DynamoDB record.
@@ -78,15 +260,35 @@ func toLocationRecord(location models.Location, locationID string) (*locationRec
 		SK:                 locationID,              // locationId (UUID) as SK
 		LocationType:       location.GetLocationType(),
 		ExtendedAttributes: location.GetExtendedAttributes(),
+		ExternalID:         location.GetExternalID(),
+		CustomFields:       location.GetCustomFields(),
+		Draft:              location.IsDraft(),
+		ActiveFrom:         formatScheduleTime(location.GetActiveFrom()),
+		ActiveUntil:        formatScheduleTime(location.GetActiveUntil()),
+		Addresses:          location.GetAddresses(),
 	}
 
 	switch loc := location.(type) {
 	case models.AddressLocation:
 		record.Address = &loc.Address
+		record.Coordinates = loc.Coordinates
+		record.Geocode = loc.Geocode
+		record.CoordinatesLocked = loc.CoordinatesLocked
+		record.Units = loc.Units
 	case models.CoordinatesLocation:
 		record.Coordinates = &loc.Coordinates
+		record.Building = loc.Building
+		record.Floor = loc.Floor
+		record.IndoorCoordinates = loc.IndoorCoordinates
+		record.PositionAnomaly = loc.PositionAnomaly
 	case models.ShopLocation:
-		record.Shop = &loc.Shop
+		record.Shop = (*shopAttribute)(&loc.Shop)
+	case models.EventLocation:
+		record.Address = loc.Address
+		record.Coordinates = loc.Coordinates
+		record.StartsAt = formatScheduleTime(&loc.StartsAt)
+		record.EndsAt = formatScheduleTime(&loc.EndsAt)
+		record.Recurrence = loc.Recurrence
 	default:
 		return nil, errors.New("unknown location type")
 	}
@@ -94,12 +296,46 @@ func toLocationRecord(location models.Location, locationID string) (*locationRec
 	return record, nil
 }
 
+// toEnvelope converts a record to its location and ID.
+func (r *locationRecord) toEnvelope() (*LocationEnvelope, error) {
+	location, err := r.toLocation()
+	if err != nil {
+		return nil, err
+	}
+	return &LocationEnvelope{LocationID: r.SK, Location: location, ETag: r.ContentHash}, nil
+}
+
+// UnmarshalLocationItem converts a stored location item, such as an image from
+// the table's stream, to its location and ID. Overflowed extendedAttributes
+// are not loaded and encrypted ones stay encrypted.
+func UnmarshalLocationItem(item map[string]types.AttributeValue) (*LocationEnvelope, error) {
+	var record locationRecord
+	if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal location: %w", err)
+	}
+	return record.toEnvelope()
+}
+
 // toLocation converts a DynamoDB record to a Location.
 func (r *locationRecord) toLocation() (models.Location, error) {
+	activeFrom, err := parseScheduleTime("activeFrom", r.ActiveFrom)
+	if err != nil {
+		return nil, err
+	}
+	activeUntil, err := parseScheduleTime("activeUntil", r.ActiveUntil)
+	if err != nil {
+		return nil, err
+	}
 	base := models.LocationBase{
 		AccountID:          r.PK, // accountId is now in PK
 		LocationType:       r.LocationType,
 		ExtendedAttributes: r.ExtendedAttributes,
+		ExternalID:         r.ExternalID,
+		CustomFields:       r.CustomFields,
+		Draft:              r.Draft,
+		ActiveFrom:         activeFrom,
+		ActiveUntil:        activeUntil,
+		Addresses:          r.Addresses,
 	}
 
 	switch r.LocationType {
@@ -108,16 +344,24 @@ func (r *locationRecord) toLocation() (models.Location, error) {
 			return nil, errors.New("address is nil for address location type")
 		}
 		return models.AddressLocation{
-			LocationBase: base,
-			Address:      *r.Address,
+			LocationBase:      base,
+			Address:           *r.Address,
+			Coordinates:       r.Coordinates,
+			Geocode:           r.Geocode,
+			CoordinatesLocked: r.CoordinatesLocked,
+			Units:             r.Units,
 		}, nil
 	case models.LocationTypeCoordinates:
 		if r.Coordinates == nil {
 			return nil, errors.New("coordinates is nil for coordinates location type")
 		}
 		return models.CoordinatesLocation{
-			LocationBase: base,
-			Coordinates:  *r.Coordinates,
+			LocationBase:      base,
+			Coordinates:       *r.Coordinates,
+			Building:          r.Building,
+			Floor:             r.Floor,
+			IndoorCoordinates: r.IndoorCoordinates,
+			PositionAnomaly:   r.PositionAnomaly,
 		}, nil
 	case models.LocationTypeShop:
 		if r.Shop == nil {
@@ -125,13 +369,56 @@ func (r *locationRecord) toLocation() (models.Location, error) {
 		}
 		return models.ShopLocation{
 			LocationBase: base,
-			Shop:         *r.Shop,
+			Shop:         models.Shop(*r.Shop),
+		}, nil
+	case models.LocationTypeEvent:
+		startsAt, err := parseScheduleTime("startsAt", r.StartsAt)
+		if err != nil {
+			return nil, err
+		}
+		endsAt, err := parseScheduleTime("endsAt", r.EndsAt)
+		if err != nil {
+			return nil, err
+		}
+		if startsAt == nil || endsAt == nil {
+			return nil, errors.New("startsAt and endsAt are required for event location type")
+		}
+		return models.EventLocation{
+			LocationBase: base,
+			Address:      r.Address,
+			Coordinates:  r.Coordinates,
+			StartsAt:     *startsAt,
+			EndsAt:       *endsAt,
+			Recurrence:   r.Recurrence,
 		}, nil
 	default:
 		return nil, fmt.Errorf("unknown location type: %s", r.LocationType)
 	}
 }
 
+// shouldFallback reports whether a failed strongly-consistent read should be retried
+// with eventual consistency.
+func (r *DynamoDBRepository) shouldFallback(consistentRead *bool, err error) bool {
+	return r.readConsistency.FallbackOnThrottle && aws.ToBool(consistentRead) && isThrottlingError(err)
+}
+
+// isThrottlingError reports whether err indicates DynamoDB throttled the request.
+func isThrottlingError(err error) bool {
+	var ptee *types.ProvisionedThroughputExceededException
+	if errors.As(err, &ptee) {
+		return true
+	}
+	var rle *types.RequestLimitExceeded
+	if errors.As(err, &rle) {
+		return true
+	}
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.ErrorCode() == "ThrottlingException"
+	}
+	return false
+}
+
 // encodeCursor encodes a pagination cursor to base64.
 func (r *DynamoDBRepository) encodeCursor(cursor *paginationCursor) (*string, error) {
 	if cursor == nil {
@@ -173,8 +460,8 @@ func (r *DynamoDBRepository) cursorToLastEvaluatedKey(cursor *paginationCursor)
 	}
 
 	return map[string]types.AttributeValue{
-		"PK": &types.AttributeValueMemberS{Value: cursor.PK}, // PK is the accountId
-		"SK": &types.AttributeValueMemberS{Value: cursor.SK}, // SK is the locationId
+		"PK": &types.AttributeValueMemberS{Value: cursor.PK},
+		"SK": &types.AttributeValueMemberS{Value: cursor.SK},
 	}
 }
 
@@ -188,36 +475,88 @@ func (r *DynamoDBRepository) lastEvaluatedKeyToCursor(lek map[string]types.Attri
 
 	if pk, ok := lek["PK"]; ok {
 		if s, ok := pk.(*types.AttributeValueMemberS); ok {
-			cursor.PK = s.Value // PK contains accountId
+			cursor.PK = s.Value
 		}
 	}
 
 	if sk, ok := lek["SK"]; ok {
 		if s, ok := sk.(*types.AttributeValueMemberS); ok {
-			cursor.SK = s.Value // SK contains locationId
+			cursor.SK = s.Value
 		}
 	}
 
 	return cursor
 }
 
-// Create creates a new location record and returns the location ID.
-func (r *DynamoDBRepository) Create(ctx context.Context, location models.Location) (string, error) {
+// prepareRecord applies write-time policies to a record and marshals it for
+// DynamoDB: shard assignment, PII handling, encryption, and overflow storage.
+func (r *DynamoDBRepository) prepareRecord(ctx context.Context, record *locationRecord) (map[string]types.AttributeValue, error) {
+	if err := r.applyRecordPolicies(record); err != nil {
+		return nil, err
+	}
+	return r.encodeRecord(ctx, record)
+}
+
+// applyRecordPolicies sets the derived keys of a record and applies the PII
+// policy, leaving the record as readers will see it, then hashes its content.
+func (r *DynamoDBRepository) applyRecordPolicies(record *locationRecord) error {
+	record.AccountShard = r.accountShard(record.PK, record.SK)
+	record.AccountExternalID = externalIDIndexKey(record.PK, record.ExternalID)
+	record.AccountWebsite = websiteIndexKey(record)
+	record.AccountContact = contactIndexKey(record)
+	record.SyncAccount = record.PK
+	record.UpdatedAt = syncTime(r.now())
+
+	if err := r.applyPIIPolicy(record); err != nil {
+		return fmt.Errorf("validation failed: %w", err)
+	}
+	return setContentHash(record)
+}
+
+// encodeRecord encrypts and marshals a record, moving oversized attributes to
+// overflow storage. Reads reverse both, so neither changes the stored location.
+func (r *DynamoDBRepository) encodeRecord(ctx context.Context, record *locationRecord) (map[string]types.AttributeValue, error) {
+	if err := r.encryptAttributes(ctx, record); err != nil {
+		return nil, fmt.Errorf("failed to encrypt location: %w", err)
+	}
+
+	return r.marshalRecord(ctx, record)
+}
+
+// Create creates a new location record and returns it as stored, with its
+// new location ID and any masking the PII policy applied.
+func (r *DynamoDBRepository) Create(ctx context.Context, location models.Location) (*LocationEnvelope, error) {
 	if err := location.Validate(); err != nil {
-		return "", fmt.Errorf("validation failed: %w", err)
+		return nil, fmt.Errorf("validation failed: %w", err)
 	}
 
-	// Generate a new UUID for location ID
-	locationID := uuid.New().String()
+	// The default version 7 UUIDs carry their creation time, which LocationStats counts by
+	locationID := r.ids.NewID()
 
 	record, err := toLocationRecord(location, locationID)
 	if err != nil {
-		return "", fmt.Errorf("failed to convert location to record: %w", err)
+		return nil, fmt.Errorf("failed to convert location to record: %w", err)
 	}
 
-	av, err := attributevalue.MarshalMap(record)
+	if err := r.applyRecordPolicies(record); err != nil {
+		return nil, err
+	}
+	stored, err := record.toEnvelope()
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert record to location: %w", err)
+	}
+	av, err := r.encodeRecord(ctx, record)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal location: %w", err)
+		return nil, err
+	}
+
+	// Claim the external ID in the same transaction as the write
+	if record.ExternalID != "" {
+		if err := r.insertWithClaim(ctx, record, av); err != nil {
+			r.deleteOverflow(ctx, record.ExtendedAttributesRef)
+			return nil, err
+		}
+		return stored, nil
 	}
 
 	// Add condition to ensure the item doesn't already exist
@@ -229,35 +568,54 @@ func (r *DynamoDBRepository) Create(ctx context.Context, location models.Locatio
 
 	_, err = r.client.PutItem(ctx, input)
 	if err != nil {
+		r.deleteOverflow(ctx, record.ExtendedAttributesRef)
 		var ccf *types.ConditionalCheckFailedException
 		if errors.As(err, &ccf) {
-			return "", fmt.Errorf("location already exists")
+			return nil, fmt.Errorf("location already exists")
 		}
-		return "", fmt.Errorf("failed to create location: %w", err)
+		return nil, fmt.Errorf("failed to create location: %w", err)
 	}
 
-	return locationID, nil
+	return stored, nil
 }
 
 // Get retrieves a location by account ID and location ID.
-func (r *DynamoDBRepository) Get(ctx context.Context, accountID, locationID string) (models.Location, error) {
-	key := map[string]types.AttributeValue{
-		"PK": &types.AttributeValueMemberS{Value: accountID},  // accountID as PK
-		"SK": &types.AttributeValueMemberS{Value: locationID}, // locationID as SK
+func (r *DynamoDBRepository) Get(ctx context.Context, accountID, locationID string) (*LocationEnvelope, error) {
+	record, err := r.getRecord(ctx, accountID, locationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.hydrateRecord(ctx, record); err != nil {
+		return nil, err
 	}
 
+	return record.toEnvelope()
+}
+
+// getRecord reads the stored record for a location without hydrating it.
+func (r *DynamoDBRepository) getRecord(ctx context.Context, accountID, locationID string) (*locationRecord, error) {
 	input := &dynamodb.GetItemInput{
-		TableName: aws.String(r.tableName),
-		Key:       key,
+		TableName:      aws.String(r.tableName),
+		Key:            r.locationKey(accountID, locationID),
+		ConsistentRead: aws.Bool(r.readConsistency.Get),
 	}
 
 	result, err := r.client.GetItem(ctx, input)
+	if err != nil && r.shouldFallback(input.ConsistentRead, err) {
+		// Retry with eventual consistency and flag the result as potentially stale
+		input.ConsistentRead = aws.Bool(false)
+		result, err = r.client.GetItem(ctx, input)
+		if err == nil {
+			markStaleRead(ctx)
+		}
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get location: %w", err)
 	}
 
 	if result.Item == nil {
-		return nil, fmt.Errorf("location not found")
+		return nil, errLocationNotFound
 	}
 
 	var record locationRecord
@@ -265,10 +623,20 @@ func (r *DynamoDBRepository) Get(ctx context.Context, accountID, locationID stri
 		return nil, fmt.Errorf("failed to unmarshal location: %w", err)
 	}
 
-	return record.toLocation()
+	if record.MergedInto != "" {
+		return nil, &MergedError{SurvivorID: record.MergedInto}
+	}
+
+	return &record, nil
 }
 
-// Update updates an existing location.
+// Update updates an existing location. The write is conditional on the
+// location's account already holding it, so an update cannot move a location
+// between accounts (see Transfer). It returns a *LocationTypeError rather than
+// change the stored location's type; see ChangeType. Nor can an update
+// publish a draft or return a location to draft; see Publish. With a context
+// from WithIfMatch, it returns a *PreconditionFailedError when the location
+// has changed since it was read.
 func (r *DynamoDBRepository) Update(ctx context.Context, location models.Location, locationID string) error {
 	if err := location.Validate(); err != nil {
 		return fmt.Errorf("validation failed: %w", err)
@@ -279,50 +647,76 @@ func (r *DynamoDBRepository) Update(ctx context.Context, location models.Locatio
 		return fmt.Errorf("failed to convert location to record: %w", err)
 	}
 
-	av, err := attributevalue.MarshalMap(record)
+	av, err := r.prepareRecord(ctx, record)
 	if err != nil {
-		return fmt.Errorf("failed to marshal location: %w", err)
+		return err
 	}
 
-	// Add condition to ensure the item exists and belongs to the correct account
+	// Add condition to ensure the item exists, belongs to the correct account, keeps its type and draft state, and was
+	// not merged away. Changing the external ID takes the transactional path below.
+	values := map[string]types.AttributeValue{
+		":accountId":    &types.AttributeValueMemberS{Value: r.locationPK(location.GetAccountID())},
+		":locationType": &types.AttributeValueMemberS{Value: string(record.LocationType)},
+	}
 	input := &dynamodb.PutItemInput{
-		TableName:           aws.String(r.tableName),
-		Item:                av,
-		ConditionExpression: aws.String("attribute_exists(PK) AND attribute_exists(SK) AND PK = :accountId"),
-		ExpressionAttributeValues: map[string]types.AttributeValue{
-			":accountId": &types.AttributeValueMemberS{Value: location.GetAccountID()},
-		},
+		TableName:                 aws.String(r.tableName),
+		Item:                      av,
+		ConditionExpression:       aws.String("attribute_exists(PK) AND attribute_exists(SK) AND PK = :accountId AND locationType = :locationType AND " + notMergedFilter + " AND " + sameExternalID(record.ExternalID, values) + " AND " + sameDraftState(record.Draft) + ifMatchCondition(ctx, values)),
+		ExpressionAttributeValues: values,
+		ReturnValues:              types.ReturnValueAllOld,
 	}
 
-	_, err = r.client.PutItem(ctx, input)
+	result, err := r.client.PutItem(ctx, input)
 	if err != nil {
 		var ccf *types.ConditionalCheckFailedException
 		if errors.As(err, &ccf) {
-			return fmt.Errorf("location not found or access denied")
+			return r.updateExternalID(ctx, record, av)
 		}
+		r.deleteOverflow(ctx, record.ExtendedAttributesRef)
 		return fmt.Errorf("failed to update location: %w", err)
 	}
 
+	if result != nil {
+		r.replaceOverflow(ctx, overflowRef(result.Attributes), record.ExtendedAttributesRef)
+	}
+
 	return nil
 }
 
-// Delete deletes a location.
+// Delete deletes a location. It returns a *ReferenceConflictError while
+// locations merged into it still point at it; see DeleteCascade.
 func (r *DynamoDBRepository) Delete(ctx context.Context, accountID, locationID string) error {
-	key := map[string]types.AttributeValue{
-		"PK": &types.AttributeValueMemberS{Value: accountID},  // accountID as PK
-		"SK": &types.AttributeValueMemberS{Value: locationID}, // locationID as SK
+	referencedBy, err := r.mergedInto(ctx, accountID, locationID)
+	if err != nil {
+		return err
 	}
+	if len(referencedBy) > 0 {
+		return &ReferenceConflictError{LocationID: locationID, ReferencedBy: referencedBy}
+	}
+	return r.deleteRecord(ctx, accountID, locationID, "")
+}
 
+// deleteRecord deletes a location record, releasing its external ID and
+// overflow payload. A non-empty survivorID restricts the delete to a
+// tombstone merged into that location.
+func (r *DynamoDBRepository) deleteRecord(ctx context.Context, accountID, locationID, survivorID string) error {
+	condition := "attribute_exists(PK) AND attribute_exists(SK) AND PK = :accountId"
+	values := map[string]types.AttributeValue{
+		":accountId": &types.AttributeValueMemberS{Value: r.locationPK(accountID)},
+	}
+	if survivorID != "" {
+		condition += " AND mergedInto = :survivor"
+		values[":survivor"] = &types.AttributeValueMemberS{Value: survivorID}
+	}
 	input := &dynamodb.DeleteItemInput{
-		TableName:           aws.String(r.tableName),
-		Key:                 key,
-		ConditionExpression: aws.String("attribute_exists(PK) AND attribute_exists(SK) AND PK = :accountId"),
-		ExpressionAttributeValues: map[string]types.AttributeValue{
-			":accountId": &types.AttributeValueMemberS{Value: accountID},
-		},
+		TableName:                 aws.String(r.tableName),
+		Key:                       r.locationKey(accountID, locationID),
+		ConditionExpression:       aws.String(condition),
+		ExpressionAttributeValues: values,
+		ReturnValues:              types.ReturnValueAllOld,
 	}
 
-	_, err := r.client.DeleteItem(ctx, input)
+	result, err := r.client.DeleteItem(ctx, input)
 	if err != nil {
 		var ccf *types.ConditionalCheckFailedException
 		if errors.As(err, &ccf) {
@@ -331,6 +725,18 @@ func (r *DynamoDBRepository) Delete(ctx context.Context, accountID, locationID s
 		return fmt.Errorf("failed to delete location: %w", err)
 	}
 
+	if result != nil {
+		r.deleteOverflow(ctx, overflowRef(result.Attributes))
+		if externalID := externalIDOf(result.Attributes); externalID != "" {
+			if err := r.releaseExternalID(ctx, accountID, externalID, locationID); err != nil {
+				log.Printf("WARN: %v", err)
+			}
+		}
+	}
+	if err := r.recordDeletion(ctx, accountID, locationID); err != nil {
+		log.Printf("WARN: %v", err)
+	}
+
 	return nil
 }
 
@@ -343,48 +749,58 @@ func (r *DynamoDBRepository) List(ctx context.Context, accountID string, options
 	}
 
 	// Decode cursor if provided
-	var startKey map[string]types.AttributeValue
+	var cursor *paginationCursor
 	if options != nil && options.Cursor != nil {
-		cursor, err := r.decodeCursor(options.Cursor)
+		var err error
+		cursor, err = r.decodeCursor(options.Cursor)
 		if err != nil {
 			return nil, fmt.Errorf("failed to decode cursor: %w", err)
 		}
-		startKey = r.cursorToLastEvaluatedKey(cursor)
 	}
 
-	// Query the main table directly by PK (accountId)
+	filter := newListFilter(options, r.now())
+
+	// Fan out across GSI shards when write sharding is enabled
+	if r.sharding.enabled() {
+		return r.listSharded(ctx, accountID, limit, cursor, filter)
+	}
+	return r.listPartition(ctx, accountID, limit, r.cursorToLastEvaluatedKey(cursor), filter)
+}
+
+// listPartition lists a page of the account's partition of the main table
+// after startKey.
+func (r *DynamoDBRepository) listPartition(ctx context.Context, accountID string, limit int32, startKey map[string]types.AttributeValue, filter listFilter) (*ListResult, error) {
+	values := filter.values(map[string]types.AttributeValue{})
 	input := &dynamodb.QueryInput{
-		TableName:              aws.String(r.tableName),
-		KeyConditionExpression: aws.String("PK = :accountId"),
-		ExpressionAttributeValues: map[string]types.AttributeValue{
-			":accountId": &types.AttributeValueMemberS{Value: accountID},
-		},
-		Limit:             aws.Int32(limit),
-		ExclusiveStartKey: startKey,
-		ScanIndexForward:  aws.Bool(true), // Sort by locationId (SK) ascending for deterministic ordering
+		TableName:                 aws.String(r.tableName),
+		KeyConditionExpression:    aws.String(r.locationPartition(":accountId", accountID, values)),
+		ExpressionAttributeValues: values,
+		Limit:                     aws.Int32(limit),
+		ExclusiveStartKey:         startKey,
+		ScanIndexForward:          aws.Bool(true), // Sort by locationId (SK) ascending for deterministic ordering
+		ConsistentRead:            aws.Bool(r.readConsistency.List),
+		FilterExpression:          aws.String(filter.expression),
 	}
 
+	staleRead := false
 	result, err := r.client.Query(ctx, input)
+	if err != nil && r.shouldFallback(input.ConsistentRead, err) {
+		// Retry with eventual consistency and flag the result as potentially stale
+		input.ConsistentRead = aws.Bool(false)
+		result, err = r.client.Query(ctx, input)
+		if err == nil {
+			staleRead = true
+			markStaleRead(ctx)
+		}
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to list locations: %w", err)
 	}
 
 	// Convert items to locations
-	locations := make([]models.Location, 0, len(result.Items))
-	locationIDs := make([]string, 0, len(result.Items))
-	for _, item := range result.Items {
-		var record locationRecord
-		if err := attributevalue.UnmarshalMap(item, &record); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal location: %w", err)
-		}
-
-		location, err := record.toLocation()
-		if err != nil {
-			return nil, fmt.Errorf("failed to convert record to location: %w", err)
-		}
-
-		locations = append(locations, location)
-		locationIDs = append(locationIDs, record.SK) // SK contains the locationId
+	items, err := r.itemsToEnvelopes(ctx, result.Items)
+	if err != nil {
+		return nil, err
 	}
 
 	// Create next cursor if there are more items
@@ -398,8 +814,42 @@ func (r *DynamoDBRepository) List(ctx context.Context, accountID string, options
 	}
 
 	return &ListResult{
-		Locations:   locations,
-		LocationIDs: locationIDs,
-		NextCursor:  nextCursor,
+		Items:      items,
+		NextCursor: nextCursor,
+		StaleRead:  staleRead,
 	}, nil
 }
+
+// hydrateRecord restores overflow payloads and decrypts attributes of a record
+// read from DynamoDB.
+func (r *DynamoDBRepository) hydrateRecord(ctx context.Context, record *locationRecord) error {
+	if err := r.loadOverflow(ctx, record); err != nil {
+		return err
+	}
+	if err := r.decryptAttributes(ctx, record); err != nil {
+		return err
+	}
+	return r.checkContentHash(record)
+}
+
+// itemsToEnvelopes converts DynamoDB items to locations with their IDs.
+func (r *DynamoDBRepository) itemsToEnvelopes(ctx context.Context, items []map[string]types.AttributeValue) ([]LocationEnvelope, error) {
+	envelopes := make([]LocationEnvelope, 0, len(items))
+	for _, item := range items {
+		var record locationRecord
+		if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal location: %w", err)
+		}
+
+		if err := r.hydrateRecord(ctx, &record); err != nil {
+			return nil, err
+		}
+
+		envelope, err := record.toEnvelope()
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert record to location: %w", err)
+		}
+		envelopes = append(envelopes, *envelope)
+	}
+	return envelopes, nil
+}
@@ -26,6 +26,30 @@ func (m *mockDynamoDBClient) PutItem(ctx context.Context, params *dynamodb.PutIt
 	return args.Get(0).(*dynamodb.PutItemOutput), args.Error(1)
 }
 
+func (m *mockDynamoDBClient) UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dynamodb.UpdateItemOutput), args.Error(1)
+}
+
+func (m *mockDynamoDBClient) Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dynamodb.ScanOutput), args.Error(1)
+}
+
+func (m *mockDynamoDBClient) TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dynamodb.TransactWriteItemsOutput), args.Error(1)
+}
+
 func (m *mockDynamoDBClient) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
 	args := m.Called(ctx, params)
 	if args.Get(0) == nil {
@@ -74,6 +98,7 @@ func TestToLocationRecord(t *testing.T) {
 					PostalCode:    "12345",
 					Country:       "US",
 				},
+				Units: []models.Unit{{Number: "210", Floor: "2"}},
 			},
 			locID:   "loc-001",
 			wantErr: false,
@@ -84,6 +109,7 @@ func TestToLocationRecord(t *testing.T) {
 				assert.NotNil(t, record.Address)
 				assert.Equal(t, "123 Main St", record.Address.StreetAddress)
 				assert.Nil(t, record.Coordinates)
+				assert.Equal(t, []models.Unit{{Number: "210", Floor: "2"}}, record.Units)
 			},
 		},
 		{
@@ -100,6 +126,9 @@ func TestToLocationRecord(t *testing.T) {
 					Latitude:  40.7128,
 					Longitude: -74.0060,
 				},
+				Building:          "Warehouse 3",
+				Floor:             "1",
+				IndoorCoordinates: &models.IndoorCoordinates{X: 120, Y: 45.5, FloorPlanKey: "acc-67890/warehouse-3.png"},
 			},
 			locID:   "loc-002",
 			wantErr: false,
@@ -110,6 +139,31 @@ func TestToLocationRecord(t *testing.T) {
 				assert.NotNil(t, record.Coordinates)
 				assert.Equal(t, 40.7128, record.Coordinates.Latitude)
 				assert.Nil(t, record.Address)
+				assert.Equal(t, "Warehouse 3", record.Building)
+				assert.Equal(t, "1", record.Floor)
+				assert.Equal(t, "acc-67890/warehouse-3.png", record.IndoorCoordinates.FloorPlanKey)
+			},
+		},
+		{
+			name: "Typed addresses",
+			location: models.CoordinatesLocation{
+				LocationBase: models.LocationBase{
+					AccountID:    "acc-67890",
+					LocationType: models.LocationTypeCoordinates,
+					Addresses: map[models.AddressKind]models.Address{
+						models.AddressKindBilling: {StreetAddress: "1 Finance Way", City: "Springfield", PostalCode: "62701", Country: "US"},
+					},
+				},
+				Coordinates: models.Coordinates{Latitude: 40.7128, Longitude: -74.0060},
+			},
+			locID: "loc-003",
+			check: func(t *testing.T, record *locationRecord) {
+				assert.Equal(t, "1 Finance Way", record.Addresses[models.AddressKindBilling].StreetAddress)
+				assert.Nil(t, record.Address)
+
+				location, err := record.toLocation()
+				require.NoError(t, err)
+				assert.Equal(t, record.Addresses, location.GetAddresses())
 			},
 		},
 	}
@@ -248,11 +302,11 @@ func TestDynamoDBRepositoryCreate(t *testing.T) {
 				*input.ConditionExpression == "attribute_not_exists(PK) AND attribute_not_exists(SK)"
 		})).Return(&dynamodb.PutItemOutput{}, nil).Once()
 
-		locationID, err := repo.Create(ctx, location)
-		assert.NoError(t, err)
-		assert.NotEmpty(t, locationID)
+		created, err := repo.Create(ctx, location)
+		require.NoError(t, err)
 		// Verify it's a valid UUID format (36 characters with hyphens)
-		assert.Len(t, locationID, 36)
+		assert.Len(t, created.LocationID, 36)
+		assert.Equal(t, location, created.Location)
 		mockClient.AssertExpectations(t)
 	})
 
@@ -270,9 +324,9 @@ func TestDynamoDBRepositoryCreate(t *testing.T) {
 			},
 		}
 
-		locationID, err := repo.Create(ctx, invalidLocation)
+		created, err := repo.Create(ctx, invalidLocation)
 		assert.Error(t, err)
-		assert.Empty(t, locationID)
+		assert.Nil(t, created)
 		assert.Contains(t, err.Error(), "validation failed")
 	})
 
@@ -282,9 +336,9 @@ func TestDynamoDBRepositoryCreate(t *testing.T) {
 			&types.ConditionalCheckFailedException{Message: aws.String("The conditional request failed")},
 		).Once()
 
-		locationID, err := repo.Create(ctx, location)
+		created, err := repo.Create(ctx, location)
 		assert.Error(t, err)
-		assert.Empty(t, locationID)
+		assert.Nil(t, created)
 		assert.Contains(t, err.Error(), "location already exists")
 		mockClient.AssertExpectations(t)
 	})
@@ -317,10 +371,11 @@ func TestDynamoDBRepositoryGet(t *testing.T) {
 			return *input.TableName == "test-table"
 		})).Return(&dynamodb.GetItemOutput{Item: item}, nil).Once()
 
-		location, err := repo.Get(ctx, accountID, locationID)
+		envelope, err := repo.Get(ctx, accountID, locationID)
 		require.NoError(t, err)
-		require.NotNil(t, location)
-		assert.IsType(t, models.AddressLocation{}, location)
+		require.NotNil(t, envelope)
+		assert.Equal(t, locationID, envelope.LocationID)
+		assert.IsType(t, models.AddressLocation{}, envelope.Location)
 		mockClient.AssertExpectations(t)
 	})
 
@@ -360,9 +415,9 @@ func TestDynamoDBRepositoryUpdate(t *testing.T) {
 		mockClient.On("PutItem", ctx, mock.MatchedBy(func(input *dynamodb.PutItemInput) bool {
 			return *input.TableName == "test-table" &&
 				input.ConditionExpression != nil &&
-				*input.ConditionExpression == "attribute_exists(PK) AND attribute_exists(SK) AND PK = :accountId" &&
+				*input.ConditionExpression == "attribute_exists(PK) AND attribute_exists(SK) AND PK = :accountId AND locationType = :locationType AND attribute_not_exists(mergedInto) AND attribute_not_exists(externalId) AND attribute_not_exists(draft)" &&
 				input.ExpressionAttributeValues != nil &&
-				len(input.ExpressionAttributeValues) == 1
+				len(input.ExpressionAttributeValues) == 2
 		})).Return(&dynamodb.PutItemOutput{}, nil).Once()
 
 		err := repo.Update(ctx, location, locationID)
@@ -375,12 +430,49 @@ func TestDynamoDBRepositoryUpdate(t *testing.T) {
 			nil,
 			&types.ConditionalCheckFailedException{Message: aws.String("The conditional request failed")},
 		).Once()
+		mockClient.On("GetItem", ctx, mock.Anything).Return(&dynamodb.GetItemOutput{}, nil).Once()
 
 		err := repo.Update(ctx, location, locationID)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "location not found")
 		mockClient.AssertExpectations(t)
 	})
+
+	t.Run("Cannot move the location to another account", func(t *testing.T) {
+		moved := location
+		moved.AccountID = "acc-99999"
+		mockClient.On("PutItem", ctx, mock.MatchedBy(func(input *dynamodb.PutItemInput) bool {
+			pk := input.Item["PK"].(*types.AttributeValueMemberS).Value
+			condition := input.ExpressionAttributeValues[":accountId"].(*types.AttributeValueMemberS).Value
+			return pk == "acc-99999" && condition == "acc-99999"
+		})).Return(
+			nil,
+			&types.ConditionalCheckFailedException{Message: aws.String("The conditional request failed")},
+		).Once()
+		mockClient.On("GetItem", ctx, mock.Anything).Return(&dynamodb.GetItemOutput{}, nil).Once()
+
+		err := repo.Update(ctx, moved, locationID)
+		assert.EqualError(t, err, "location not found or access denied")
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Refuses to change the location type", func(t *testing.T) {
+		mockClient.On("PutItem", ctx, mock.Anything).Return(
+			nil,
+			&types.ConditionalCheckFailedException{Message: aws.String("The conditional request failed")},
+		).Once()
+		mockClient.On("GetItem", ctx, mock.Anything).Return(&dynamodb.GetItemOutput{Item: map[string]types.AttributeValue{
+			"PK":           &types.AttributeValueMemberS{Value: "acc-12345"},
+			"SK":           &types.AttributeValueMemberS{Value: locationID},
+			"locationType": &types.AttributeValueMemberS{Value: "coordinates"},
+		}}, nil).Once()
+
+		err := repo.Update(ctx, location, locationID)
+		var typeErr *LocationTypeError
+		require.ErrorAs(t, err, &typeErr)
+		assert.Equal(t, &LocationTypeError{Current: models.LocationTypeCoordinates, Requested: models.LocationTypeAddress}, typeErr)
+		mockClient.AssertExpectations(t)
+	})
 }
 
 func TestDynamoDBRepositoryDelete(t *testing.T) {
@@ -392,6 +484,7 @@ func TestDynamoDBRepositoryDelete(t *testing.T) {
 	locationID := "loc-001"
 
 	t.Run("Successful delete", func(t *testing.T) {
+		noMergeReferences(mockClient)
 		mockClient.On("DeleteItem", ctx, mock.MatchedBy(func(input *dynamodb.DeleteItemInput) bool {
 			return *input.TableName == "test-table" &&
 				input.ConditionExpression != nil &&
@@ -406,6 +499,7 @@ func TestDynamoDBRepositoryDelete(t *testing.T) {
 	})
 
 	t.Run("Item not found", func(t *testing.T) {
+		noMergeReferences(mockClient)
 		mockClient.On("DeleteItem", ctx, mock.Anything).Return(
 			nil,
 			&types.ConditionalCheckFailedException{Message: aws.String("The conditional request failed")},
@@ -461,12 +555,11 @@ func TestDynamoDBRepositoryList(t *testing.T) {
 		result, err := repo.List(ctx, accountID, &ListOptions{})
 		require.NoError(t, err)
 		require.NotNil(t, result)
-		assert.Len(t, result.Locations, 2)
-		assert.Len(t, result.LocationIDs, 2)
-		assert.Equal(t, "loc-001", result.LocationIDs[0])
-		assert.Equal(t, "loc-002", result.LocationIDs[1])
-		assert.IsType(t, models.AddressLocation{}, result.Locations[0])
-		assert.IsType(t, models.CoordinatesLocation{}, result.Locations[1])
+		require.Len(t, result.Items, 2)
+		assert.Equal(t, "loc-001", result.Items[0].LocationID)
+		assert.Equal(t, "loc-002", result.Items[1].LocationID)
+		assert.IsType(t, models.AddressLocation{}, result.Items[0].Location)
+		assert.IsType(t, models.CoordinatesLocation{}, result.Items[1].Location)
 		assert.Nil(t, result.NextCursor)
 		mockClient.AssertExpectations(t)
 	})
@@ -479,9 +572,103 @@ func TestDynamoDBRepositoryList(t *testing.T) {
 		result, err := repo.List(ctx, accountID, &ListOptions{})
 		require.NoError(t, err)
 		require.NotNil(t, result)
-		assert.Empty(t, result.Locations)
-		assert.Empty(t, result.LocationIDs)
+		assert.Empty(t, result.Items)
 		assert.Nil(t, result.NextCursor)
 		mockClient.AssertExpectations(t)
 	})
+
+	t.Run("Uses the configured default limit", func(t *testing.T) {
+		limited := NewDynamoDBRepository(mockClient, "test-table", WithDefaultLimit(50))
+		mockClient.On("Query", ctx, mock.MatchedBy(func(input *dynamodb.QueryInput) bool {
+			return input.Limit != nil && *input.Limit == 50
+		})).Return(&dynamodb.QueryOutput{}, nil).Once()
+
+		_, err := limited.List(ctx, accountID, nil)
+		require.NoError(t, err)
+		mockClient.AssertExpectations(t)
+	})
+}
+
+func TestDynamoDBRepositoryStaleReadFallback(t *testing.T) {
+	ctx := context.Background()
+
+	item := map[string]types.AttributeValue{
+		"PK":           &types.AttributeValueMemberS{Value: "acc-12345"},
+		"SK":           &types.AttributeValueMemberS{Value: "loc-001"},
+		"locationType": &types.AttributeValueMemberS{Value: "coordinates"},
+		"coordinates": &types.AttributeValueMemberM{
+			Value: map[string]types.AttributeValue{
+				"latitude":  &types.AttributeValueMemberN{Value: "40.7128"},
+				"longitude": &types.AttributeValueMemberN{Value: "-74.0060"},
+			},
+		},
+	}
+	throttled := &types.ProvisionedThroughputExceededException{Message: aws.String("throughput exceeded")}
+
+	t.Run("Get falls back to eventual consistency when throttled", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table",
+			WithReadConsistency(ReadConsistency{Get: true, FallbackOnThrottle: true}))
+
+		mockClient.On("GetItem", mock.Anything, mock.MatchedBy(func(input *dynamodb.GetItemInput) bool {
+			return aws.ToBool(input.ConsistentRead)
+		})).Return(nil, throttled).Once()
+		mockClient.On("GetItem", mock.Anything, mock.MatchedBy(func(input *dynamodb.GetItemInput) bool {
+			return !aws.ToBool(input.ConsistentRead)
+		})).Return(&dynamodb.GetItemOutput{Item: item}, nil).Once()
+
+		readCtx, info := WithReadInfo(ctx)
+		envelope, err := repo.Get(readCtx, "acc-12345", "loc-001")
+		require.NoError(t, err)
+		assert.IsType(t, models.CoordinatesLocation{}, envelope.Location)
+		assert.True(t, info.StaleRead)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Get without fallback returns throttling error", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table",
+			WithReadConsistency(ReadConsistency{Get: true}))
+
+		mockClient.On("GetItem", mock.Anything, mock.Anything).Return(nil, throttled).Once()
+
+		readCtx, info := WithReadInfo(ctx)
+		location, err := repo.Get(readCtx, "acc-12345", "loc-001")
+		assert.Error(t, err)
+		assert.Nil(t, location)
+		assert.False(t, info.StaleRead)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("List falls back to eventual consistency when throttled", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table",
+			WithReadConsistency(ReadConsistency{List: true, FallbackOnThrottle: true}))
+
+		mockClient.On("Query", mock.Anything, mock.MatchedBy(func(input *dynamodb.QueryInput) bool {
+			return aws.ToBool(input.ConsistentRead)
+		})).Return(nil, &types.RequestLimitExceeded{Message: aws.String("limit exceeded")}).Once()
+		mockClient.On("Query", mock.Anything, mock.MatchedBy(func(input *dynamodb.QueryInput) bool {
+			return !aws.ToBool(input.ConsistentRead)
+		})).Return(&dynamodb.QueryOutput{Items: []map[string]types.AttributeValue{item}}, nil).Once()
+
+		result, err := repo.List(ctx, "acc-12345", &ListOptions{})
+		require.NoError(t, err)
+		assert.Len(t, result.Items, 1)
+		assert.True(t, result.StaleRead)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Non-throttling errors are not retried", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table",
+			WithReadConsistency(ReadConsistency{List: true, FallbackOnThrottle: true}))
+
+		mockClient.On("Query", mock.Anything, mock.Anything).Return(nil, &types.ResourceNotFoundException{Message: aws.String("no table")}).Once()
+
+		result, err := repo.List(ctx, "acc-12345", &ListOptions{})
+		assert.Error(t, err)
+		assert.Nil(t, result)
+		mockClient.AssertExpectations(t)
+	})
 }
@@ -0,0 +1,53 @@
+package repositorytest
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Clock is a repository.Clock for tests that stands still until advanced.
+type Clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewClock returns a clock stopped at now.
+func NewClock(now time.Time) *Clock {
+	return &Clock{now: now}
+}
+
+// Now returns the clock's current time.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance moves the clock forward by d.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// IDs is a repository.IDGenerator for tests minting prefix-1, prefix-2, and
+// so on.
+type IDs struct {
+	mu     sync.Mutex
+	prefix string
+	next   int
+}
+
+// NewIDs returns a generator of IDs starting with prefix.
+func NewIDs(prefix string) *IDs {
+	return &IDs{prefix: prefix}
+}
+
+// NewID returns the next ID.
+func (g *IDs) NewID() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.next++
+	return fmt.Sprintf("%s-%d", g.prefix, g.next)
+}
@@ -0,0 +1,621 @@
+// Package repositorytest provides a contract test suite that every
+// repository.Repository backend must pass, so alternative backends can show
+// they behave the same as DynamoDB. It also provides a fixed clock and
+// predictable IDs for tests of time-dependent behavior.
+package repositorytest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/google/uuid"
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// RunContractTests runs the repository contract against repo. Each run uses
+// fresh account IDs, so repo may be shared with other tests or earlier runs.
+func RunContractTests(t *testing.T, repo repository.Repository) {
+	t.Helper()
+
+	t.Run("Create and Get", func(t *testing.T) { testCreateGet(t, repo) })
+	t.Run("Update", func(t *testing.T) { testUpdate(t, repo) })
+	t.Run("Delete", func(t *testing.T) { testDelete(t, repo) })
+	t.Run("List", func(t *testing.T) { testList(t, repo) })
+	t.Run("List by category", func(t *testing.T) { testListByCategory(t, repo) })
+	t.Run("List missing coordinates", func(t *testing.T) { testListMissingCoordinates(t, repo) })
+	t.Run("List geocoded", func(t *testing.T) { testListGeocoded(t, repo) })
+	t.Run("List drafts", func(t *testing.T) { testListDrafts(t, repo) })
+	t.Run("List active", func(t *testing.T) { testListActive(t, repo) })
+	t.Run("List events active on a day", func(t *testing.T) { testListActiveOn(t, repo) })
+	t.Run("List by geocode confidence", func(t *testing.T) { testListByGeocodeConfidence(t, repo) })
+	t.Run("List by coordinate accuracy", func(t *testing.T) { testListByAccuracy(t, repo) })
+	t.Run("External ID conflicts", func(t *testing.T) { testExternalIDConflicts(t, repo) })
+}
+
+// newAccountID returns an account ID no other run uses.
+func newAccountID() string {
+	return "contract-" + uuid.New().String()
+}
+
+// coordinates is a coordinates location in accountID.
+func coordinates(accountID string, latitude float64) models.CoordinatesLocation {
+	return models.CoordinatesLocation{
+		LocationBase: models.LocationBase{AccountID: accountID, LocationType: models.LocationTypeCoordinates},
+		Coordinates:  models.Coordinates{Latitude: latitude, Longitude: -74.006},
+	}
+}
+
+// address is an address location in accountID carrying extended attributes.
+func address(accountID string) models.AddressLocation {
+	return models.AddressLocation{
+		LocationBase: models.LocationBase{
+			AccountID:          accountID,
+			LocationType:       models.LocationTypeAddress,
+			ExtendedAttributes: map[string]interface{}{"source": "contract"},
+		},
+		Address: models.Address{
+			StreetAddress: "123 Main St",
+			City:          "Springfield",
+			StateProvince: "IL",
+			PostalCode:    "62704",
+			Country:       "US",
+		},
+	}
+}
+
+// shop is a shop location in accountID listed under categories.
+func shop(accountID string, categories ...string) models.ShopLocation {
+	return models.ShopLocation{
+		LocationBase: models.LocationBase{AccountID: accountID, LocationType: models.LocationTypeShop},
+		Shop: models.Shop{
+			Name:       "Main Street Store",
+			ContactID:  "contact-1",
+			Address:    address(accountID).Address,
+			Categories: categories,
+		},
+	}
+}
+
+// create stores location and returns its ID.
+func create(t *testing.T, repo repository.Repository, location models.Location) string {
+	t.Helper()
+	created, err := repo.Create(context.Background(), location)
+	require.NoError(t, err)
+	return created.LocationID
+}
+
+func testCreateGet(t *testing.T, repo repository.Repository) {
+	ctx := context.Background()
+	accountID := newAccountID()
+
+	t.Run("Round-trips every field", func(t *testing.T) {
+		for _, want := range []models.Location{coordinates(accountID, 40.7128), address(accountID)} {
+			created, err := repo.Create(ctx, want)
+			require.NoError(t, err)
+			require.NotEmpty(t, created.LocationID)
+			assert.Equal(t, want, created.Location)
+
+			got, err := repo.Get(ctx, accountID, created.LocationID)
+			require.NoError(t, err)
+			assert.Equal(t, created, got)
+		}
+	})
+
+	t.Run("Assigns a new ID to every location", func(t *testing.T) {
+		first := create(t, repo, coordinates(accountID, 1))
+		second := create(t, repo, coordinates(accountID, 1))
+		assert.NotEqual(t, first, second)
+	})
+
+	t.Run("Rejects invalid locations", func(t *testing.T) {
+		_, err := repo.Create(ctx, coordinates(accountID, 91))
+		assert.ErrorContains(t, err, "validation failed")
+	})
+
+	t.Run("Get fails for unknown IDs", func(t *testing.T) {
+		_, err := repo.Get(ctx, accountID, uuid.New().String())
+		assert.Error(t, err)
+	})
+
+	t.Run("Get is scoped to the owning account", func(t *testing.T) {
+		locationID := create(t, repo, coordinates(accountID, 2))
+		_, err := repo.Get(ctx, newAccountID(), locationID)
+		assert.Error(t, err)
+	})
+}
+
+func testUpdate(t *testing.T, repo repository.Repository) {
+	ctx := context.Background()
+	accountID := newAccountID()
+
+	locationID := create(t, repo, coordinates(accountID, 10))
+
+	t.Run("Replaces the stored location", func(t *testing.T) {
+		want := coordinates(accountID, 11)
+		require.NoError(t, repo.Update(ctx, want, locationID))
+
+		got, err := repo.Get(ctx, accountID, locationID)
+		require.NoError(t, err)
+		assert.Equal(t, want, got.Location)
+	})
+
+	t.Run("Fails for unknown IDs", func(t *testing.T) {
+		assert.Error(t, repo.Update(ctx, coordinates(accountID, 12), uuid.New().String()))
+	})
+
+	t.Run("Cannot move a location to another account", func(t *testing.T) {
+		assert.Error(t, repo.Update(ctx, coordinates(newAccountID(), 13), locationID))
+
+		got, err := repo.Get(ctx, accountID, locationID)
+		require.NoError(t, err)
+		assert.Equal(t, coordinates(accountID, 11), got.Location)
+	})
+
+	t.Run("Rejects invalid locations", func(t *testing.T) {
+		err := repo.Update(ctx, coordinates(accountID, 91), locationID)
+		assert.ErrorContains(t, err, "validation failed")
+	})
+
+	t.Run("Cannot change the location type", func(t *testing.T) {
+		err := repo.Update(ctx, address(accountID), locationID)
+		var typeErr *repository.LocationTypeError
+		require.True(t, errors.As(err, &typeErr), "got %v", err)
+		assert.Equal(t, models.LocationTypeCoordinates, typeErr.Current)
+
+		got, err := repo.Get(ctx, accountID, locationID)
+		require.NoError(t, err)
+		assert.Equal(t, coordinates(accountID, 11), got.Location)
+	})
+}
+
+func testDelete(t *testing.T, repo repository.Repository) {
+	ctx := context.Background()
+	accountID := newAccountID()
+
+	locationID := create(t, repo, coordinates(accountID, 20))
+
+	t.Run("Is scoped to the owning account", func(t *testing.T) {
+		assert.Error(t, repo.Delete(ctx, newAccountID(), locationID))
+		_, err := repo.Get(ctx, accountID, locationID)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Removes the location once", func(t *testing.T) {
+		require.NoError(t, repo.Delete(ctx, accountID, locationID))
+		_, err := repo.Get(ctx, accountID, locationID)
+		assert.Error(t, err)
+		assert.Error(t, repo.Delete(ctx, accountID, locationID))
+	})
+}
+
+// listPages pages through an account, returning the IDs of every page.
+func listPages(t *testing.T, repo repository.Repository, accountID string, limit int32) [][]string {
+	t.Helper()
+	var pages [][]string
+	var cursor *string
+	for {
+		require.Less(t, len(pages), 100, "pagination did not terminate")
+		result, err := repo.List(context.Background(), accountID, &repository.ListOptions{Limit: aws.Int32(limit), Cursor: cursor})
+		require.NoError(t, err)
+		require.LessOrEqual(t, len(result.Items), int(limit))
+		pages = append(pages, result.LocationIDs())
+		if result.NextCursor == nil {
+			return pages
+		}
+		cursor = result.NextCursor
+	}
+}
+
+func testList(t *testing.T, repo repository.Repository) {
+	ctx := context.Background()
+	accountID := newAccountID()
+
+	created := make(map[string]bool)
+	for i := 0; i < 5; i++ {
+		locationID := create(t, repo, coordinates(accountID, float64(i)))
+		created[locationID] = true
+	}
+	// A location in another account must not appear
+	create(t, repo, coordinates(newAccountID(), 0))
+
+	t.Run("Returns every location of the account exactly once", func(t *testing.T) {
+		for _, limit := range []int32{1, 2, 5, 100} {
+			seen := make(map[string]bool)
+			pages := listPages(t, repo, accountID, limit)
+			for i, page := range pages {
+				// Only the last page may come back empty
+				if i < len(pages)-1 {
+					assert.NotEmpty(t, page, "limit %d: empty page before the end", limit)
+				}
+				for _, id := range page {
+					assert.True(t, created[id], "limit %d: unexpected location %s", limit, id)
+					assert.False(t, seen[id], "limit %d: location %s returned twice", limit, id)
+					seen[id] = true
+				}
+			}
+			assert.Len(t, seen, len(created), "limit %d", limit)
+		}
+	})
+
+	t.Run("Returns locations matching their IDs", func(t *testing.T) {
+		result, err := repo.List(ctx, accountID, &repository.ListOptions{Limit: aws.Int32(100)})
+		require.NoError(t, err)
+		for _, item := range result.Items {
+			got, err := repo.Get(ctx, accountID, item.LocationID)
+			require.NoError(t, err)
+			assert.Equal(t, got, &item)
+		}
+	})
+
+	t.Run("Cursors can be replayed", func(t *testing.T) {
+		first, err := repo.List(ctx, accountID, &repository.ListOptions{Limit: aws.Int32(2)})
+		require.NoError(t, err)
+		require.NotNil(t, first.NextCursor)
+
+		again, err := repo.List(ctx, accountID, &repository.ListOptions{Limit: aws.Int32(2), Cursor: first.NextCursor})
+		require.NoError(t, err)
+		replayed, err := repo.List(ctx, accountID, &repository.ListOptions{Limit: aws.Int32(2), Cursor: first.NextCursor})
+		require.NoError(t, err)
+		assert.Equal(t, again.LocationIDs(), replayed.LocationIDs())
+		assert.NotContains(t, again.LocationIDs(), first.Items[0].LocationID)
+	})
+
+	t.Run("Deleted locations drop out", func(t *testing.T) {
+		result, err := repo.List(ctx, accountID, &repository.ListOptions{Limit: aws.Int32(1)})
+		require.NoError(t, err)
+		require.Len(t, result.Items, 1)
+		deleted := result.Items[0].LocationID
+		require.NoError(t, repo.Delete(ctx, accountID, deleted))
+		delete(created, deleted)
+
+		var remaining []string
+		for _, page := range listPages(t, repo, accountID, 100) {
+			remaining = append(remaining, page...)
+		}
+		assert.Len(t, remaining, len(created))
+		assert.NotContains(t, remaining, deleted)
+	})
+
+	t.Run("Empty accounts have no pages", func(t *testing.T) {
+		result, err := repo.List(ctx, newAccountID(), nil)
+		require.NoError(t, err)
+		assert.Empty(t, result.Items)
+		assert.Nil(t, result.NextCursor)
+	})
+
+	t.Run("Rejects malformed cursors", func(t *testing.T) {
+		_, err := repo.List(ctx, accountID, &repository.ListOptions{Cursor: aws.String("not a cursor")})
+		assert.Error(t, err)
+	})
+}
+
+func testListByCategory(t *testing.T, repo repository.Repository) {
+	ctx := context.Background()
+	accountID := newAccountID()
+
+	cafe := create(t, repo, shop(accountID, "722515"))
+	both := create(t, repo, shop(accountID, "445110", "722515"))
+	create(t, repo, shop(accountID, "445110"))
+	create(t, repo, shop(accountID))
+	create(t, repo, coordinates(accountID, 0))
+
+	// Filtered pages may be short or empty, so collect every page
+	var listed []string
+	var cursor *string
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 100, "pagination did not terminate")
+		result, err := repo.List(ctx, accountID, &repository.ListOptions{Limit: aws.Int32(2), Cursor: cursor, Category: "722515"})
+		require.NoError(t, err)
+		listed = append(listed, result.LocationIDs()...)
+		if result.NextCursor == nil {
+			break
+		}
+		cursor = result.NextCursor
+	}
+	assert.ElementsMatch(t, []string{cafe, both}, listed)
+}
+
+func testListMissingCoordinates(t *testing.T, repo repository.Repository) {
+	ctx := context.Background()
+	accountID := newAccountID()
+
+	ungeocoded := create(t, repo, address(accountID))
+	pinned := address(accountID)
+	pinned.Coordinates = &models.Coordinates{Latitude: 39.7817, Longitude: -89.6501}
+	create(t, repo, pinned)
+	create(t, repo, shop(accountID))
+	create(t, repo, coordinates(accountID, 0))
+
+	var listed []string
+	var cursor *string
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 100, "pagination did not terminate")
+		result, err := repo.List(ctx, accountID, &repository.ListOptions{Limit: aws.Int32(2), Cursor: cursor, MissingCoordinates: true})
+		require.NoError(t, err)
+		listed = append(listed, result.LocationIDs()...)
+		if result.NextCursor == nil {
+			break
+		}
+		cursor = result.NextCursor
+	}
+	assert.Equal(t, []string{ungeocoded}, listed)
+}
+
+func testListGeocoded(t *testing.T, repo repository.Repository) {
+	ctx := context.Background()
+	accountID := newAccountID()
+	pinned := func() models.AddressLocation {
+		location := address(accountID)
+		location.Coordinates = &models.Coordinates{Latitude: 39.7817, Longitude: -89.6501}
+		return location
+	}
+
+	geocoded := pinned()
+	geocoded.Geocode = &models.GeocodeInfo{Provider: "contract", Confidence: 0.9, GeocodedAt: time.Date(2024, time.June, 4, 12, 0, 0, 0, time.UTC)}
+	geocodedID := create(t, repo, geocoded)
+	create(t, repo, pinned())
+	locked := pinned()
+	locked.CoordinatesLocked = true
+	create(t, repo, locked)
+	create(t, repo, address(accountID))
+	create(t, repo, coordinates(accountID, 0))
+
+	var listed []string
+	var cursor *string
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 100, "pagination did not terminate")
+		result, err := repo.List(ctx, accountID, &repository.ListOptions{Limit: aws.Int32(2), Cursor: cursor, Geocoded: true})
+		require.NoError(t, err)
+		listed = append(listed, result.LocationIDs()...)
+		if result.NextCursor == nil {
+			break
+		}
+		cursor = result.NextCursor
+	}
+	assert.Equal(t, []string{geocodedID}, listed)
+}
+
+func testListDrafts(t *testing.T, repo repository.Repository) {
+	ctx := context.Background()
+	accountID := newAccountID()
+
+	publishedID := create(t, repo, coordinates(accountID, 0))
+	draft := coordinates(accountID, 1)
+	draft.Draft = true
+	draftID := create(t, repo, draft)
+
+	list := func(options repository.ListOptions) []string {
+		var listed []string
+		for pages := 0; ; pages++ {
+			require.Less(t, pages, 100, "pagination did not terminate")
+			options.Limit = aws.Int32(1)
+			result, err := repo.List(ctx, accountID, &options)
+			require.NoError(t, err)
+			listed = append(listed, result.LocationIDs()...)
+			if result.NextCursor == nil {
+				break
+			}
+			options.Cursor = result.NextCursor
+		}
+		return listed
+	}
+
+	t.Run("Drafts are left out by default", func(t *testing.T) {
+		assert.Equal(t, []string{publishedID}, list(repository.ListOptions{}))
+	})
+
+	t.Run("Drafts are listed when asked", func(t *testing.T) {
+		assert.ElementsMatch(t, []string{publishedID, draftID}, list(repository.ListOptions{IncludeDrafts: true}))
+	})
+
+	t.Run("Updates keep the draft state", func(t *testing.T) {
+		assert.ErrorContains(t, repo.Update(ctx, coordinates(accountID, 2), draftID), "validation failed")
+		assert.ErrorContains(t, repo.Update(ctx, draft, publishedID), "validation failed")
+
+		draft.Coordinates.Latitude = 3
+		require.NoError(t, repo.Update(ctx, draft, draftID))
+		got, err := repo.Get(ctx, accountID, draftID)
+		require.NoError(t, err)
+		assert.Equal(t, draft, got.Location)
+	})
+}
+
+func testListActive(t *testing.T, repo repository.Repository) {
+	ctx := context.Background()
+	accountID := newAccountID()
+	now := time.Now().UTC().Truncate(time.Second)
+	lastWeek, nextWeek := now.AddDate(0, 0, -7), now.AddDate(0, 0, 7)
+	scheduled := func(latitude float64, from, until *time.Time) models.CoordinatesLocation {
+		location := coordinates(accountID, latitude)
+		location.ActiveFrom, location.ActiveUntil = from, until
+		return location
+	}
+
+	alwaysID := create(t, repo, coordinates(accountID, 0))
+	openID := create(t, repo, scheduled(1, &lastWeek, &nextWeek))
+	endedID := create(t, repo, scheduled(2, nil, &lastWeek))
+	upcomingID := create(t, repo, scheduled(3, &nextWeek, nil))
+
+	list := func(options repository.ListOptions) []string {
+		var listed []string
+		for pages := 0; ; pages++ {
+			require.Less(t, pages, 100, "pagination did not terminate")
+			options.Limit = aws.Int32(1)
+			result, err := repo.List(ctx, accountID, &options)
+			require.NoError(t, err)
+			listed = append(listed, result.LocationIDs()...)
+			if result.NextCursor == nil {
+				break
+			}
+			options.Cursor = result.NextCursor
+		}
+		return listed
+	}
+
+	t.Run("Locations outside their window are left out", func(t *testing.T) {
+		assert.ElementsMatch(t, []string{alwaysID, openID}, list(repository.ListOptions{}))
+	})
+
+	t.Run("Inactive locations are listed when asked", func(t *testing.T) {
+		assert.ElementsMatch(t, []string{alwaysID, openID, endedID, upcomingID}, list(repository.ListOptions{IncludeInactive: true}))
+	})
+
+	t.Run("Window bounds round-trip", func(t *testing.T) {
+		got, err := repo.Get(ctx, accountID, openID)
+		require.NoError(t, err)
+		assert.True(t, lastWeek.Equal(*got.Location.GetActiveFrom()))
+		assert.True(t, nextWeek.Equal(*got.Location.GetActiveUntil()))
+	})
+}
+
+func testListActiveOn(t *testing.T, repo repository.Repository) {
+	ctx := context.Background()
+	accountID := newAccountID()
+	day := func(d int) time.Time { return time.Date(2024, time.June, d, 0, 0, 0, 0, time.UTC) }
+	event := func(startsAt, endsAt time.Time) models.EventLocation {
+		return models.EventLocation{
+			LocationBase: models.LocationBase{AccountID: accountID, LocationType: models.LocationTypeEvent},
+			Coordinates:  &models.Coordinates{Latitude: 41.8781, Longitude: -87.6298},
+			StartsAt:     startsAt,
+			EndsAt:       endsAt,
+		}
+	}
+
+	festival := event(day(3).Add(10*time.Hour), day(5).Add(22*time.Hour))
+	festivalID := create(t, repo, festival)
+	overnightID := create(t, repo, event(day(3).Add(20*time.Hour), day(4).Add(2*time.Hour)))
+	create(t, repo, event(day(1).Add(9*time.Hour), day(2).Add(17*time.Hour)))
+	create(t, repo, event(day(5).Add(9*time.Hour), day(5).Add(17*time.Hour)))
+	create(t, repo, event(day(3), day(4)))
+	create(t, repo, coordinates(accountID, 0))
+
+	var listed []string
+	var cursor *string
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 100, "pagination did not terminate")
+		result, err := repo.List(ctx, accountID, &repository.ListOptions{Limit: aws.Int32(2), Cursor: cursor, ActiveOn: aws.Time(day(4))})
+		require.NoError(t, err)
+		listed = append(listed, result.LocationIDs()...)
+		if result.NextCursor == nil {
+			break
+		}
+		cursor = result.NextCursor
+	}
+	assert.ElementsMatch(t, []string{festivalID, overnightID}, listed)
+
+	got, err := repo.Get(ctx, accountID, festivalID)
+	require.NoError(t, err)
+	assert.Equal(t, festival, got.Location)
+}
+
+func testListByGeocodeConfidence(t *testing.T, repo repository.Repository) {
+	ctx := context.Background()
+	accountID := newAccountID()
+	geocoded := func(confidence float64) models.AddressLocation {
+		location := address(accountID)
+		location.Coordinates = &models.Coordinates{Latitude: 39.7817, Longitude: -89.6501}
+		location.Geocode = &models.GeocodeInfo{Provider: "contract", Confidence: confidence, GeocodedAt: time.Date(2024, time.June, 4, 12, 0, 0, 0, time.UTC)}
+		return location
+	}
+
+	rooftop := create(t, repo, geocoded(0.95))
+	create(t, repo, geocoded(0.4))
+	pinned := address(accountID)
+	pinned.Coordinates = &models.Coordinates{Latitude: 39.7817, Longitude: -89.6501}
+	handEntered := create(t, repo, pinned)
+	create(t, repo, address(accountID))
+	point := create(t, repo, coordinates(accountID, 0))
+
+	var listed []string
+	var cursor *string
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 100, "pagination did not terminate")
+		result, err := repo.List(ctx, accountID, &repository.ListOptions{Limit: aws.Int32(2), Cursor: cursor, MinGeocodeConfidence: aws.Float64(0.8)})
+		require.NoError(t, err)
+		listed = append(listed, result.LocationIDs()...)
+		if result.NextCursor == nil {
+			break
+		}
+		cursor = result.NextCursor
+	}
+	assert.ElementsMatch(t, []string{rooftop, handEntered, point}, listed)
+}
+
+func testListByAccuracy(t *testing.T, repo repository.Repository) {
+	ctx := context.Background()
+	accountID := newAccountID()
+	fix := func(accuracy *float64) models.CoordinatesLocation {
+		location := coordinates(accountID, 0)
+		location.Coordinates.Accuracy = accuracy
+		return location
+	}
+
+	precise := create(t, repo, fix(aws.Float64(3)))
+	exact := create(t, repo, fix(aws.Float64(10)))
+	create(t, repo, fix(aws.Float64(250)))
+	create(t, repo, fix(nil))
+	pinnedShop := shop(accountID, "hardware")
+	pinnedShop.Shop.Coordinates = &models.Coordinates{Latitude: 39.7817, Longitude: -89.6501, Accuracy: aws.Float64(5)}
+	shopID := create(t, repo, pinnedShop)
+	create(t, repo, shop(accountID, "hardware"))
+
+	var listed []string
+	var cursor *string
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 100, "pagination did not terminate")
+		result, err := repo.List(ctx, accountID, &repository.ListOptions{Limit: aws.Int32(2), Cursor: cursor, MaxAccuracyMeters: aws.Float64(10)})
+		require.NoError(t, err)
+		listed = append(listed, result.LocationIDs()...)
+		if result.NextCursor == nil {
+			break
+		}
+		cursor = result.NextCursor
+	}
+	assert.ElementsMatch(t, []string{precise, exact, shopID}, listed)
+}
+
+func testExternalIDConflicts(t *testing.T, repo repository.Repository) {
+	ctx := context.Background()
+	accountID := newAccountID()
+
+	withExternalID := func(accountID, externalID string) models.CoordinatesLocation {
+		loc := coordinates(accountID, 30)
+		loc.ExternalID = externalID
+		return loc
+	}
+
+	holder := create(t, repo, withExternalID(accountID, "ERP-1"))
+
+	t.Run("Create reports the location holding the external ID", func(t *testing.T) {
+		_, err := repo.Create(ctx, withExternalID(accountID, "ERP-1"))
+		var conflict *repository.ExternalIDConflictError
+		require.True(t, errors.As(err, &conflict), "got %v", err)
+		assert.Equal(t, holder, conflict.LocationID)
+	})
+
+	t.Run("Update cannot take another location's external ID", func(t *testing.T) {
+		other := create(t, repo, withExternalID(accountID, "ERP-2"))
+
+		err := repo.Update(ctx, withExternalID(accountID, "ERP-1"), other)
+		var conflict *repository.ExternalIDConflictError
+		require.True(t, errors.As(err, &conflict), "got %v", err)
+		assert.Equal(t, holder, conflict.LocationID)
+	})
+
+	t.Run("External IDs are unique per account", func(t *testing.T) {
+		_, err := repo.Create(ctx, withExternalID(newAccountID(), "ERP-1"))
+		assert.NoError(t, err)
+	})
+
+	t.Run("Deleting the holder releases the external ID", func(t *testing.T) {
+		require.NoError(t, repo.Delete(ctx, accountID, holder))
+		_, err := repo.Create(ctx, withExternalID(accountID, "ERP-1"))
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,254 @@
+package repositorytest
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/repository"
+)
+
+// memoryRepository is a minimal in-memory backend used to check that the
+// contract suite is self-consistent without DynamoDB.
+type memoryRepository struct {
+	mu        sync.Mutex
+	locations map[string]map[string]models.Location // accountId -> locationId -> location
+}
+
+func newMemoryRepository() *memoryRepository {
+	return &memoryRepository{locations: make(map[string]map[string]models.Location)}
+}
+
+// holderOf returns the location in the account holding externalID, if any.
+func (m *memoryRepository) holderOf(accountID, externalID string) string {
+	if externalID == "" {
+		return ""
+	}
+	for id, loc := range m.locations[accountID] {
+		if loc.GetExternalID() == externalID {
+			return id
+		}
+	}
+	return ""
+}
+
+func (m *memoryRepository) Create(_ context.Context, location models.Location) (*repository.LocationEnvelope, error) {
+	if err := location.Validate(); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	accountID := location.GetAccountID()
+	if holder := m.holderOf(accountID, location.GetExternalID()); holder != "" {
+		return nil, &repository.ExternalIDConflictError{ExternalID: location.GetExternalID(), LocationID: holder}
+	}
+	if m.locations[accountID] == nil {
+		m.locations[accountID] = make(map[string]models.Location)
+	}
+	locationID := uuid.New().String()
+	m.locations[accountID][locationID] = location
+	return &repository.LocationEnvelope{LocationID: locationID, Location: location}, nil
+}
+
+func (m *memoryRepository) Get(_ context.Context, accountID, locationID string) (*repository.LocationEnvelope, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	loc, ok := m.locations[accountID][locationID]
+	if !ok {
+		return nil, errors.New("location not found")
+	}
+	return &repository.LocationEnvelope{LocationID: locationID, Location: loc}, nil
+}
+
+func (m *memoryRepository) Update(_ context.Context, location models.Location, locationID string) error {
+	if err := location.Validate(); err != nil {
+		return fmt.Errorf("validation failed: %w", err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	accountID := location.GetAccountID()
+	stored, ok := m.locations[accountID][locationID]
+	if !ok {
+		return errors.New("location not found or access denied")
+	}
+	if stored.GetLocationType() != location.GetLocationType() {
+		return &repository.LocationTypeError{Current: stored.GetLocationType(), Requested: location.GetLocationType()}
+	}
+	if stored.IsDraft() != location.IsDraft() {
+		return errors.New("validation failed: an update cannot change whether a location is a draft")
+	}
+	if holder := m.holderOf(accountID, location.GetExternalID()); holder != "" && holder != locationID {
+		return &repository.ExternalIDConflictError{ExternalID: location.GetExternalID(), LocationID: holder}
+	}
+	m.locations[accountID][locationID] = location
+	return nil
+}
+
+func (m *memoryRepository) Delete(_ context.Context, accountID, locationID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.locations[accountID][locationID]; !ok {
+		return errors.New("location not found or access denied")
+	}
+	delete(m.locations[accountID], locationID)
+	return nil
+}
+
+// List pages in location ID order; the cursor is the last ID returned.
+func (m *memoryRepository) List(_ context.Context, accountID string, options *repository.ListOptions) (*repository.ListResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	limit := 20
+	after := ""
+	if options != nil && options.Limit != nil {
+		limit = int(*options.Limit)
+	}
+	if options != nil && options.Cursor != nil {
+		decoded, err := base64.URLEncoding.DecodeString(*options.Cursor)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode cursor: %w", err)
+		}
+		after = string(decoded)
+	}
+
+	ids := make([]string, 0, len(m.locations[accountID]))
+	for id, location := range m.locations[accountID] {
+		if id > after && listed(location, options) && runsOn(location, options) && inCategory(location, options) && missingCoordinates(location, options) && confidentlyPlaced(location, options) && geocoded(location, options) && accurate(location, options) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	result := &repository.ListResult{Items: []repository.LocationEnvelope{}}
+	if len(ids) > limit {
+		ids = ids[:limit]
+		cursor := base64.URLEncoding.EncodeToString([]byte(ids[limit-1]))
+		result.NextCursor = &cursor
+	}
+	for _, id := range ids {
+		result.Items = append(result.Items, repository.LocationEnvelope{LocationID: id, Location: m.locations[accountID][id]})
+	}
+	return result, nil
+}
+
+// listed reports whether location is published and active now, or options
+// include drafts and inactive locations.
+func listed(location models.Location, options *repository.ListOptions) bool {
+	if location.IsDraft() && (options == nil || !options.IncludeDrafts) {
+		return false
+	}
+	return location.IsActiveAt(time.Now()) || (options != nil && options.IncludeInactive)
+}
+
+// runsOn reports whether location is an event running on the day options
+// filter on, if any.
+func runsOn(location models.Location, options *repository.ListOptions) bool {
+	if options == nil || options.ActiveOn == nil {
+		return true
+	}
+	event, ok := location.(models.EventLocation)
+	return ok && event.RunsOn(*options.ActiveOn)
+}
+
+// inCategory reports whether location is a shop in the category options filter on, if any.
+func inCategory(location models.Location, options *repository.ListOptions) bool {
+	if options == nil || options.Category == "" {
+		return true
+	}
+	shop, ok := location.(models.ShopLocation)
+	if !ok {
+		return false
+	}
+	for _, category := range shop.Shop.Categories {
+		if category == options.Category {
+			return true
+		}
+	}
+	return false
+}
+
+// missingCoordinates reports whether location is an address location without
+// coordinates, when options filter on that.
+func missingCoordinates(location models.Location, options *repository.ListOptions) bool {
+	if options == nil || !options.MissingCoordinates {
+		return true
+	}
+	address, ok := location.(models.AddressLocation)
+	return ok && address.Coordinates == nil
+}
+
+// confidentlyPlaced reports whether location is not an address location, or
+// has hand-entered coordinates or a geocode of at least the confidence
+// options filter on.
+func confidentlyPlaced(location models.Location, options *repository.ListOptions) bool {
+	if options == nil || options.MinGeocodeConfidence == nil {
+		return true
+	}
+	address, ok := location.(models.AddressLocation)
+	if !ok {
+		return true
+	}
+	return address.Coordinates != nil && (address.Geocode == nil || address.Geocode.Confidence >= *options.MinGeocodeConfidence)
+}
+
+// geocoded reports whether location is an address location with geocoded,
+// unlocked coordinates, when options filter on that.
+func geocoded(location models.Location, options *repository.ListOptions) bool {
+	if options == nil || !options.Geocoded {
+		return true
+	}
+	address, ok := location.(models.AddressLocation)
+	return ok && address.Geocode != nil && !address.CoordinatesLocked
+}
+
+// accurate reports whether location has coordinates at least as accurate as
+// options filter on, if they filter on accuracy.
+func accurate(location models.Location, options *repository.ListOptions) bool {
+	if options == nil || options.MaxAccuracyMeters == nil {
+		return true
+	}
+	var coordinates *models.Coordinates
+	switch loc := location.(type) {
+	case models.AddressLocation:
+		coordinates = loc.Coordinates
+	case models.CoordinatesLocation:
+		coordinates = &loc.Coordinates
+	case models.ShopLocation:
+		coordinates = loc.Shop.Coordinates
+	case models.EventLocation:
+		coordinates = loc.Coordinates
+	}
+	return coordinates != nil && coordinates.Accuracy != nil && *coordinates.Accuracy <= *options.MaxAccuracyMeters
+}
+
+func (m *memoryRepository) Erase(context.Context, string, string) (*repository.ErasureCertificate, error) {
+	return nil, errors.New("not supported")
+}
+
+func (m *memoryRepository) Merge(context.Context, string, string, []string) (*repository.MergeResult, error) {
+	return nil, errors.New("not supported")
+}
+
+func (m *memoryRepository) Upsert(context.Context, models.Location) (*repository.UpsertResult, error) {
+	return nil, errors.New("not supported")
+}
+
+func (m *memoryRepository) GetByExternalID(context.Context, string, string) (*repository.LocationEnvelope, error) {
+	return nil, errors.New("not supported")
+}
+
+func TestRunContractTests(t *testing.T) {
+	RunContractTests(t, newMemoryRepository())
+}
@@ -0,0 +1,848 @@
+package repository
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/steverhoton/location-lambda/internal/geo"
+	"github.com/steverhoton/location-lambda/internal/models"
+)
+
+// RoutingRepository routes each operation to a region-specific repository based
+// on the account's data residency, falling back to the home-region repository
+// for accounts without a mapping.
+type RoutingRepository struct {
+	home           Repository
+	regional       map[string]Repository
+	accountRegions map[string]string
+}
+
+// NewRoutingRepository creates a repository that routes accounts listed in
+// accountRegions to the repository registered for that region in regional.
+func NewRoutingRepository(home Repository, regional map[string]Repository, accountRegions map[string]string) *RoutingRepository {
+	return &RoutingRepository{
+		home:           home,
+		regional:       regional,
+		accountRegions: accountRegions,
+	}
+}
+
+// ParseRegionMap parses a comma-separated list of "key:region" pairs, as used for
+// account-to-region and region-to-table mappings.
+func ParseRegionMap(value string) (map[string]string, error) {
+	result := map[string]string{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		key, mapped, ok := strings.Cut(entry, ":")
+		key, mapped = strings.TrimSpace(key), strings.TrimSpace(mapped)
+		if !ok || key == "" || mapped == "" {
+			return nil, fmt.Errorf("invalid mapping: %s", entry)
+		}
+		result[key] = mapped
+	}
+	return result, nil
+}
+
+// repositories returns the home repository followed by the regional ones,
+// ordered by region.
+func (r *RoutingRepository) repositories() []Repository {
+	regions := make([]string, 0, len(r.regional))
+	for region := range r.regional {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+
+	repos := []Repository{r.home}
+	for _, region := range regions {
+		repos = append(repos, r.regional[region])
+	}
+	return repos
+}
+
+// route returns the repository holding an account's data. An account mapped to a
+// region without a registered repository is an error rather than a silent
+// write to the home region.
+func (r *RoutingRepository) route(accountID string) (Repository, error) {
+	region, ok := r.accountRegions[accountID]
+	if !ok {
+		return r.home, nil
+	}
+	repo, ok := r.regional[region]
+	if !ok {
+		return nil, fmt.Errorf("no table configured for data residency region %s", region)
+	}
+	return repo, nil
+}
+
+// Create creates a location in the account's residency region.
+func (r *RoutingRepository) Create(ctx context.Context, location models.Location) (*LocationEnvelope, error) {
+	repo, err := r.route(location.GetAccountID())
+	if err != nil {
+		return nil, err
+	}
+	return repo.Create(ctx, location)
+}
+
+// Get retrieves a location from the account's residency region.
+func (r *RoutingRepository) Get(ctx context.Context, accountID, locationID string) (*LocationEnvelope, error) {
+	repo, err := r.route(accountID)
+	if err != nil {
+		return nil, err
+	}
+	return repo.Get(ctx, accountID, locationID)
+}
+
+// Update updates a location in the account's residency region.
+func (r *RoutingRepository) Update(ctx context.Context, location models.Location, locationID string) error {
+	repo, err := r.route(location.GetAccountID())
+	if err != nil {
+		return err
+	}
+	return repo.Update(ctx, location, locationID)
+}
+
+// ChangeType changes a location's type in the account's residency region.
+func (r *RoutingRepository) ChangeType(ctx context.Context, location models.Location, locationID string) (*TypeChange, error) {
+	repo, err := r.route(location.GetAccountID())
+	if err != nil {
+		return nil, err
+	}
+	changer, ok := repo.(TypeChanger)
+	if !ok {
+		return nil, fmt.Errorf("location type changes are not supported for this account's region")
+	}
+	return changer.ChangeType(ctx, location, locationID)
+}
+
+// Delete deletes a location from the account's residency region.
+func (r *RoutingRepository) Delete(ctx context.Context, accountID, locationID string) error {
+	repo, err := r.route(accountID)
+	if err != nil {
+		return err
+	}
+	return repo.Delete(ctx, accountID, locationID)
+}
+
+// CheckReferences checks an account's references in its residency region.
+func (r *RoutingRepository) CheckReferences(ctx context.Context, accountID string, repair bool) (*ReferenceReport, error) {
+	repo, err := r.route(accountID)
+	if err != nil {
+		return nil, err
+	}
+	checker, ok := repo.(ReferenceChecker)
+	if !ok {
+		return nil, fmt.Errorf("reference checks are not supported for this account's region")
+	}
+	return checker.CheckReferences(ctx, accountID, repair)
+}
+
+// MigrateShopAddresses migrates an account's shops in its residency region.
+func (r *RoutingRepository) MigrateShopAddresses(ctx context.Context, accountID string, dryRun bool) ([]string, error) {
+	repo, err := r.route(accountID)
+	if err != nil {
+		return nil, err
+	}
+	migrator, ok := repo.(ShopMigrator)
+	if !ok {
+		return nil, fmt.Errorf("shop migrations are not supported for this account's region")
+	}
+	return migrator.MigrateShopAddresses(ctx, accountID, dryRun)
+}
+
+// FindByWebsite finds an account's shops by website in its residency region.
+func (r *RoutingRepository) FindByWebsite(ctx context.Context, accountID, website string) ([]LocationEnvelope, error) {
+	repo, err := r.route(accountID)
+	if err != nil {
+		return nil, err
+	}
+	finder, ok := repo.(WebsiteFinder)
+	if !ok {
+		return nil, fmt.Errorf("website search is not supported for this account's region")
+	}
+	return finder.FindByWebsite(ctx, accountID, website)
+}
+
+// LocationStats summarizes an account's locations in its residency region.
+func (r *RoutingRepository) LocationStats(ctx context.Context, accountID string) (*LocationStats, error) {
+	repo, err := r.route(accountID)
+	if err != nil {
+		return nil, err
+	}
+	reader, ok := repo.(StatsReader)
+	if !ok {
+		return nil, fmt.Errorf("location stats are not supported for this account's region")
+	}
+	return reader.LocationStats(ctx, accountID)
+}
+
+// LocationHeatmap counts an account's locations by geohash cell in its residency region.
+func (r *RoutingRepository) LocationHeatmap(ctx context.Context, accountID string, box geo.BoundingBox, resolution int) (*Heatmap, error) {
+	repo, err := r.route(accountID)
+	if err != nil {
+		return nil, err
+	}
+	reader, ok := repo.(HeatmapReader)
+	if !ok {
+		return nil, fmt.Errorf("location heatmaps are not supported for this account's region")
+	}
+	return reader.LocationHeatmap(ctx, accountID, box, resolution)
+}
+
+// FindByContactID finds the shops attached to a contact in the account's residency region.
+func (r *RoutingRepository) FindByContactID(ctx context.Context, accountID, contactID string) ([]LocationEnvelope, error) {
+	repo, err := r.route(accountID)
+	if err != nil {
+		return nil, err
+	}
+	finder, ok := repo.(ContactFinder)
+	if !ok {
+		return nil, fmt.Errorf("contact search is not supported for this account's region")
+	}
+	return finder.FindByContactID(ctx, accountID, contactID)
+}
+
+// SyncLocations returns the changes to an account's locations from the account's residency region.
+func (r *RoutingRepository) SyncLocations(ctx context.Context, accountID, sinceToken string, limit int32) (*SyncResult, error) {
+	repo, err := r.route(accountID)
+	if err != nil {
+		return nil, err
+	}
+	syncer, ok := repo.(LocationSyncer)
+	if !ok {
+		return nil, fmt.Errorf("location sync is not supported for this account's region")
+	}
+	return syncer.SyncLocations(ctx, accountID, sinceToken, limit)
+}
+
+// DeleteCascade deletes a location and its merged duplicates from the account's residency region.
+func (r *RoutingRepository) DeleteCascade(ctx context.Context, accountID, locationID string) ([]string, error) {
+	repo, err := r.route(accountID)
+	if err != nil {
+		return nil, err
+	}
+	deleter, ok := repo.(CascadeDeleter)
+	if !ok {
+		return nil, fmt.Errorf("cascading deletes are not supported for this account's region")
+	}
+	return deleter.DeleteCascade(ctx, accountID, locationID)
+}
+
+// List lists locations from the account's residency region.
+func (r *RoutingRepository) List(ctx context.Context, accountID string, options *ListOptions) (*ListResult, error) {
+	repo, err := r.route(accountID)
+	if err != nil {
+		return nil, err
+	}
+	return repo.List(ctx, accountID, options)
+}
+
+// Erase erases a location's data in the account's residency region.
+func (r *RoutingRepository) Erase(ctx context.Context, accountID, locationID string) (*ErasureCertificate, error) {
+	repo, err := r.route(accountID)
+	if err != nil {
+		return nil, err
+	}
+	return repo.Erase(ctx, accountID, locationID)
+}
+
+// Merge merges duplicate locations in the account's residency region.
+func (r *RoutingRepository) Merge(ctx context.Context, accountID, survivorID string, duplicateIDs []string) (*MergeResult, error) {
+	repo, err := r.route(accountID)
+	if err != nil {
+		return nil, err
+	}
+	return repo.Merge(ctx, accountID, survivorID, duplicateIDs)
+}
+
+// Upsert creates or updates a location by external ID in the account's residency region.
+func (r *RoutingRepository) Upsert(ctx context.Context, location models.Location) (*UpsertResult, error) {
+	repo, err := r.route(location.GetAccountID())
+	if err != nil {
+		return nil, err
+	}
+	return repo.Upsert(ctx, location)
+}
+
+// GetByExternalID retrieves a location by external ID from the account's residency region.
+func (r *RoutingRepository) GetByExternalID(ctx context.Context, accountID, externalID string) (*LocationEnvelope, error) {
+	repo, err := r.route(accountID)
+	if err != nil {
+		return nil, err
+	}
+	return repo.GetByExternalID(ctx, accountID, externalID)
+}
+
+// routeTemplates returns the template store holding an account's templates.
+func (r *RoutingRepository) routeTemplates(accountID string) (TemplateStore, error) {
+	repo, err := r.route(accountID)
+	if err != nil {
+		return nil, err
+	}
+	store, ok := repo.(TemplateStore)
+	if !ok {
+		return nil, fmt.Errorf("templates are not supported for this account's region")
+	}
+	return store, nil
+}
+
+// CreateTemplate stores a template in the account's residency region.
+func (r *RoutingRepository) CreateTemplate(ctx context.Context, template LocationTemplate) (string, error) {
+	store, err := r.routeTemplates(template.AccountID)
+	if err != nil {
+		return "", err
+	}
+	return store.CreateTemplate(ctx, template)
+}
+
+// GetTemplate retrieves a template from the account's residency region.
+func (r *RoutingRepository) GetTemplate(ctx context.Context, accountID, templateID string) (*LocationTemplate, error) {
+	store, err := r.routeTemplates(accountID)
+	if err != nil {
+		return nil, err
+	}
+	return store.GetTemplate(ctx, accountID, templateID)
+}
+
+// ListTemplates lists templates from the account's residency region.
+func (r *RoutingRepository) ListTemplates(ctx context.Context, accountID string, options *ListOptions) (*TemplateListResult, error) {
+	store, err := r.routeTemplates(accountID)
+	if err != nil {
+		return nil, err
+	}
+	return store.ListTemplates(ctx, accountID, options)
+}
+
+// DeleteTemplate deletes a template from the account's residency region.
+func (r *RoutingRepository) DeleteTemplate(ctx context.Context, accountID, templateID string) error {
+	store, err := r.routeTemplates(accountID)
+	if err != nil {
+		return err
+	}
+	return store.DeleteTemplate(ctx, accountID, templateID)
+}
+
+// routeCustomFields returns the custom field store holding an account's definitions.
+func (r *RoutingRepository) routeCustomFields(accountID string) (CustomFieldStore, error) {
+	repo, err := r.route(accountID)
+	if err != nil {
+		return nil, err
+	}
+	store, ok := repo.(CustomFieldStore)
+	if !ok {
+		return nil, fmt.Errorf("custom fields are not supported for this account's region")
+	}
+	return store, nil
+}
+
+// GetCustomFieldDefinitions retrieves custom field definitions from the account's residency region.
+func (r *RoutingRepository) GetCustomFieldDefinitions(ctx context.Context, accountID string) ([]models.CustomFieldDefinition, error) {
+	store, err := r.routeCustomFields(accountID)
+	if err != nil {
+		return nil, err
+	}
+	return store.GetCustomFieldDefinitions(ctx, accountID)
+}
+
+// PutCustomFieldDefinitions stores custom field definitions in the account's residency region.
+func (r *RoutingRepository) PutCustomFieldDefinitions(ctx context.Context, accountID string, definitions []models.CustomFieldDefinition) error {
+	store, err := r.routeCustomFields(accountID)
+	if err != nil {
+		return err
+	}
+	return store.PutCustomFieldDefinitions(ctx, accountID, definitions)
+}
+
+// routeGeofences returns the geofence store holding an account's geofences.
+func (r *RoutingRepository) routeGeofences(accountID string) (GeofenceStore, error) {
+	repo, err := r.route(accountID)
+	if err != nil {
+		return nil, err
+	}
+	store, ok := repo.(GeofenceStore)
+	if !ok {
+		return nil, fmt.Errorf("geofences are not supported for this account's region")
+	}
+	return store, nil
+}
+
+// GetGeofences retrieves geofences from the account's residency region.
+func (r *RoutingRepository) GetGeofences(ctx context.Context, accountID string) ([]models.Geofence, error) {
+	store, err := r.routeGeofences(accountID)
+	if err != nil {
+		return nil, err
+	}
+	return store.GetGeofences(ctx, accountID)
+}
+
+// PutGeofences stores geofences in the account's residency region.
+func (r *RoutingRepository) PutGeofences(ctx context.Context, accountID string, geofences []models.Geofence) error {
+	store, err := r.routeGeofences(accountID)
+	if err != nil {
+		return err
+	}
+	return store.PutGeofences(ctx, accountID, geofences)
+}
+
+// GetGeofencePresence retrieves a location's geofence presence from the account's residency region.
+func (r *RoutingRepository) GetGeofencePresence(ctx context.Context, accountID, locationID string) (map[string]models.GeofencePresence, error) {
+	store, err := r.routeGeofences(accountID)
+	if err != nil {
+		return nil, err
+	}
+	return store.GetGeofencePresence(ctx, accountID, locationID)
+}
+
+// PutGeofencePresence stores a location's geofence presence in the account's residency region.
+func (r *RoutingRepository) PutGeofencePresence(ctx context.Context, accountID, locationID string, presence map[string]models.GeofencePresence) error {
+	store, err := r.routeGeofences(accountID)
+	if err != nil {
+		return err
+	}
+	return store.PutGeofencePresence(ctx, accountID, locationID, presence)
+}
+
+// routePositions returns the position history holding an account's positions and stops.
+func (r *RoutingRepository) routePositions(accountID string) (PositionHistory, error) {
+	repo, err := r.route(accountID)
+	if err != nil {
+		return nil, err
+	}
+	history, ok := repo.(PositionHistory)
+	if !ok {
+		return nil, fmt.Errorf("position history is not supported for this account's region")
+	}
+	return history, nil
+}
+
+// RecordPosition stores a ping in the account's residency region.
+func (r *RoutingRepository) RecordPosition(ctx context.Context, accountID string, ping models.PositionPing, retention time.Duration) error {
+	history, err := r.routePositions(accountID)
+	if err != nil {
+		return err
+	}
+	return history.RecordPosition(ctx, accountID, ping, retention)
+}
+
+// ListPositions retrieves pings from the account's residency region.
+func (r *RoutingRepository) ListPositions(ctx context.Context, accountID string, since time.Time) ([]models.PositionPing, error) {
+	history, err := r.routePositions(accountID)
+	if err != nil {
+		return nil, err
+	}
+	return history.ListPositions(ctx, accountID, since)
+}
+
+// PutDetectedStop stores a stop in its account's residency region.
+func (r *RoutingRepository) PutDetectedStop(ctx context.Context, stop models.DetectedStop) error {
+	history, err := r.routePositions(stop.AccountID)
+	if err != nil {
+		return err
+	}
+	return history.PutDetectedStop(ctx, stop)
+}
+
+// ListDetectedStops lists stops from the account's residency region.
+func (r *RoutingRepository) ListDetectedStops(ctx context.Context, accountID, locationID string, options *ListOptions) (*DetectedStopListResult, error) {
+	history, err := r.routePositions(accountID)
+	if err != nil {
+		return nil, err
+	}
+	return history.ListDetectedStops(ctx, accountID, locationID, options)
+}
+
+// routeSettings returns the settings store holding an account's settings.
+func (r *RoutingRepository) routeSettings(accountID string) (SettingsStore, error) {
+	repo, err := r.route(accountID)
+	if err != nil {
+		return nil, err
+	}
+	store, ok := repo.(SettingsStore)
+	if !ok {
+		return nil, fmt.Errorf("account settings are not supported for this account's region")
+	}
+	return store, nil
+}
+
+// GetAccountSettings retrieves settings from the account's residency region.
+func (r *RoutingRepository) GetAccountSettings(ctx context.Context, accountID string) (*models.AccountSettings, error) {
+	store, err := r.routeSettings(accountID)
+	if err != nil {
+		return nil, err
+	}
+	return store.GetAccountSettings(ctx, accountID)
+}
+
+// PutAccountSettings stores settings in the account's residency region.
+func (r *RoutingRepository) PutAccountSettings(ctx context.Context, settings models.AccountSettings) (*models.AccountSettings, error) {
+	store, err := r.routeSettings(settings.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	return store.PutAccountSettings(ctx, settings)
+}
+
+// routeSigningSecrets returns the store holding an account's signing secret.
+func (r *RoutingRepository) routeSigningSecrets(accountID string) (SigningSecretStore, error) {
+	repo, err := r.route(accountID)
+	if err != nil {
+		return nil, err
+	}
+	store, ok := repo.(SigningSecretStore)
+	if !ok {
+		return nil, fmt.Errorf("request signing is not supported for this account's region")
+	}
+	return store, nil
+}
+
+// GetSigningSecret retrieves a signing secret from the account's residency region.
+func (r *RoutingRepository) GetSigningSecret(ctx context.Context, accountID string) (*SigningSecret, error) {
+	store, err := r.routeSigningSecrets(accountID)
+	if err != nil {
+		return nil, err
+	}
+	return store.GetSigningSecret(ctx, accountID)
+}
+
+// PutSigningSecret stores a signing secret in the account's residency region.
+func (r *RoutingRepository) PutSigningSecret(ctx context.Context, secret SigningSecret) error {
+	store, err := r.routeSigningSecrets(secret.AccountID)
+	if err != nil {
+		return err
+	}
+	return store.PutSigningSecret(ctx, secret)
+}
+
+// DeleteSigningSecret removes a signing secret from the account's residency region.
+func (r *RoutingRepository) DeleteSigningSecret(ctx context.Context, accountID string) error {
+	store, err := r.routeSigningSecrets(accountID)
+	if err != nil {
+		return err
+	}
+	return store.DeleteSigningSecret(ctx, accountID)
+}
+
+// routeReplayGuard returns the replay guard recording an account's requests.
+func (r *RoutingRepository) routeReplayGuard(accountID string) (ReplayGuard, error) {
+	repo, err := r.route(accountID)
+	if err != nil {
+		return nil, err
+	}
+	guard, ok := repo.(ReplayGuard)
+	if !ok {
+		return nil, fmt.Errorf("replay protection is not supported for this account's region")
+	}
+	return guard, nil
+}
+
+// RecordRequest records a request ID in the account's residency region.
+func (r *RoutingRepository) RecordRequest(ctx context.Context, accountID, requestID string, window time.Duration) error {
+	guard, err := r.routeReplayGuard(accountID)
+	if err != nil {
+		return err
+	}
+	return guard.RecordRequest(ctx, accountID, requestID, window)
+}
+
+// ReleaseRequest forgets a request ID in the account's residency region.
+func (r *RoutingRepository) ReleaseRequest(ctx context.Context, accountID, requestID string) error {
+	guard, err := r.routeReplayGuard(accountID)
+	if err != nil {
+		return err
+	}
+	return guard.ReleaseRequest(ctx, accountID, requestID)
+}
+
+// routeUsage returns the usage meter counting an account's operations.
+func (r *RoutingRepository) routeUsage(accountID string) (UsageMeter, error) {
+	repo, err := r.route(accountID)
+	if err != nil {
+		return nil, err
+	}
+	meter, ok := repo.(UsageMeter)
+	if !ok {
+		return nil, fmt.Errorf("usage metering is not supported for this account's region")
+	}
+	return meter, nil
+}
+
+// RecordUsage counts an operation in the account's residency region.
+func (r *RoutingRepository) RecordUsage(ctx context.Context, accountID, operation string, at time.Time) error {
+	meter, err := r.routeUsage(accountID)
+	if err != nil {
+		return err
+	}
+	return meter.RecordUsage(ctx, accountID, operation, at)
+}
+
+// GetUsage returns an account's usage from its residency region.
+func (r *RoutingRepository) GetUsage(ctx context.Context, accountID, period string) (*Usage, error) {
+	meter, err := r.routeUsage(accountID)
+	if err != nil {
+		return nil, err
+	}
+	return meter.GetUsage(ctx, accountID, period)
+}
+
+// routeAdmin returns the admin store of a repository.
+func routeAdmin(repo Repository) (AdminStore, error) {
+	store, ok := repo.(AdminStore)
+	if !ok {
+		return nil, fmt.Errorf("admin operations are not supported for this region")
+	}
+	return store, nil
+}
+
+// FindByID looks a location up in the home region, then in each residency region.
+func (r *RoutingRepository) FindByID(ctx context.Context, locationID string) (*LocationEnvelope, error) {
+	for _, repo := range r.repositories() {
+		store, err := routeAdmin(repo)
+		if err != nil {
+			return nil, err
+		}
+		envelope, err := store.FindByID(ctx, locationID)
+		if errors.Is(err, errLocationNotFound) {
+			continue
+		}
+		return envelope, err
+	}
+	return nil, errLocationNotFound
+}
+
+// Transfer moves a location between accounts stored in the same residency region.
+func (r *RoutingRepository) Transfer(ctx context.Context, fromAccountID, locationID, toAccountID string) error {
+	if r.accountRegions[fromAccountID] != r.accountRegions[toAccountID] {
+		return fmt.Errorf("cannot transfer locations between data residency regions")
+	}
+	repo, err := r.route(fromAccountID)
+	if err != nil {
+		return err
+	}
+	store, err := routeAdmin(repo)
+	if err != nil {
+		return err
+	}
+	return store.Transfer(ctx, fromAccountID, locationID, toAccountID)
+}
+
+// routingScanCursor is the position of a scan across the home and regional
+// tables: the table being read, in repositories order, and its own cursor.
+type routingScanCursor struct {
+	Table  int     `json:"table"`
+	Cursor *string `json:"cursor,omitempty"`
+}
+
+// ScanLocations scans the home table, then each residency region's in turn.
+// A page never spans two tables, so the last page of each can be short.
+func (r *RoutingRepository) ScanLocations(ctx context.Context, limit int32, cursor *string) (*ListResult, error) {
+	var position routingScanCursor
+	if cursor != nil && *cursor != "" {
+		data, err := base64.StdEncoding.DecodeString(*cursor)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode cursor: %w", err)
+		}
+		if err := json.Unmarshal(data, &position); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal cursor: %w", err)
+		}
+	}
+	repos := r.repositories()
+	if position.Table < 0 || position.Table >= len(repos) {
+		return nil, fmt.Errorf("validation failed: cursor names an unknown table")
+	}
+
+	scanner, ok := repos[position.Table].(LocationScanner)
+	if !ok {
+		return nil, fmt.Errorf("scanning locations is not supported for this region")
+	}
+	result, err := scanner.ScanLocations(ctx, limit, position.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	next := routingScanCursor{Table: position.Table, Cursor: result.NextCursor}
+	if next.Cursor == nil {
+		next.Table++
+	}
+	result.NextCursor = nil
+	if next.Table < len(repos) {
+		data, err := json.Marshal(next)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal cursor: %w", err)
+		}
+		encoded := base64.StdEncoding.EncodeToString(data)
+		result.NextCursor = &encoded
+	}
+	return result, nil
+}
+
+// FindShopsNear finds shops near a point in the account's residency region.
+func (r *RoutingRepository) FindShopsNear(ctx context.Context, accountID string, center models.Coordinates, radiusMeters float64, openAt *time.Time, maxAccuracyMeters *float64) ([]NearbyShop, error) {
+	repo, err := r.route(accountID)
+	if err != nil {
+		return nil, err
+	}
+	finder, ok := repo.(NearbyShopFinder)
+	if !ok {
+		return nil, fmt.Errorf("nearby shop search is not supported for this account's region")
+	}
+	return finder.FindShopsNear(ctx, accountID, center, radiusMeters, openAt, maxAccuracyMeters)
+}
+
+// FindDeliveryZones finds delivery zones in the account's residency region.
+func (r *RoutingRepository) FindDeliveryZones(ctx context.Context, accountID string, point models.Coordinates) ([]DeliveryZoneMatch, error) {
+	repo, err := r.route(accountID)
+	if err != nil {
+		return nil, err
+	}
+	finder, ok := repo.(DeliveryZoneFinder)
+	if !ok {
+		return nil, fmt.Errorf("delivery quotes are not supported for this account's region")
+	}
+	return finder.FindDeliveryZones(ctx, accountID, point)
+}
+
+// FindNearestShops finds the nearest shops of a category in the account's residency region.
+func (r *RoutingRepository) FindNearestShops(ctx context.Context, accountID string, center models.Coordinates, category string, k int, openAt *time.Time, maxAccuracyMeters *float64) ([]NearbyShop, error) {
+	repo, err := r.route(accountID)
+	if err != nil {
+		return nil, err
+	}
+	finder, ok := repo.(NearestShopFinder)
+	if !ok {
+		return nil, fmt.Errorf("nearest shop search is not supported for this account's region")
+	}
+	return finder.FindNearestShops(ctx, accountID, center, category, k, openAt, maxAccuracyMeters)
+}
+
+// AssignNearestShops assigns points to the nearest shops in the account's residency region.
+func (r *RoutingRepository) AssignNearestShops(ctx context.Context, accountID string, points []AssignmentPoint, category string, maxDistanceMeters *float64) ([]Assignment, error) {
+	repo, err := r.route(accountID)
+	if err != nil {
+		return nil, err
+	}
+	assigner, ok := repo.(NearestShopAssigner)
+	if !ok {
+		return nil, fmt.Errorf("nearest shop assignment is not supported for this account's region")
+	}
+	return assigner.AssignNearestShops(ctx, accountID, points, category, maxDistanceMeters)
+}
+
+// SetGeocode stores a geocode in the account's residency region.
+func (r *RoutingRepository) SetGeocode(ctx context.Context, accountID, locationID string, address models.Address, coordinates models.Coordinates, info models.GeocodeInfo) (bool, error) {
+	repo, err := r.route(accountID)
+	if err != nil {
+		return false, err
+	}
+	writer, ok := repo.(GeocodeWriter)
+	if !ok {
+		return false, fmt.Errorf("geocode writes are not supported for this account's region")
+	}
+	return writer.SetGeocode(ctx, accountID, locationID, address, coordinates, info)
+}
+
+// RefreshGeocode replaces a geocode in the account's residency region.
+func (r *RoutingRepository) RefreshGeocode(ctx context.Context, accountID, locationID string, previous models.GeocodeInfo, address models.Address, coordinates models.Coordinates, info models.GeocodeInfo) (bool, error) {
+	repo, err := r.route(accountID)
+	if err != nil {
+		return false, err
+	}
+	writer, ok := repo.(GeocodeWriter)
+	if !ok {
+		return false, fmt.Errorf("geocode writes are not supported for this account's region")
+	}
+	return writer.RefreshGeocode(ctx, accountID, locationID, previous, address, coordinates, info)
+}
+
+// routeProposals returns the proposal store holding an account's change proposals.
+func (r *RoutingRepository) routeProposals(accountID string) (ProposalStore, error) {
+	repo, err := r.route(accountID)
+	if err != nil {
+		return nil, err
+	}
+	store, ok := repo.(ProposalStore)
+	if !ok {
+		return nil, fmt.Errorf("change proposals are not supported for this account's region")
+	}
+	return store, nil
+}
+
+// CreateProposal stores a proposal in the account's residency region.
+func (r *RoutingRepository) CreateProposal(ctx context.Context, proposal LocationProposal) (string, error) {
+	store, err := r.routeProposals(proposal.AccountID)
+	if err != nil {
+		return "", err
+	}
+	return store.CreateProposal(ctx, proposal)
+}
+
+// GetProposal retrieves a proposal from the account's residency region.
+func (r *RoutingRepository) GetProposal(ctx context.Context, accountID, proposalID string) (*LocationProposal, error) {
+	store, err := r.routeProposals(accountID)
+	if err != nil {
+		return nil, err
+	}
+	return store.GetProposal(ctx, accountID, proposalID)
+}
+
+// ListProposals lists proposals from the account's residency region.
+func (r *RoutingRepository) ListProposals(ctx context.Context, accountID string, status ProposalStatus, options *ListOptions) (*ProposalListResult, error) {
+	store, err := r.routeProposals(accountID)
+	if err != nil {
+		return nil, err
+	}
+	return store.ListProposals(ctx, accountID, status, options)
+}
+
+// ReviewProposal reviews a proposal in the account's residency region.
+func (r *RoutingRepository) ReviewProposal(ctx context.Context, accountID, proposalID string, status ProposalStatus, reviewedBy, comment string) (*LocationProposal, error) {
+	store, err := r.routeProposals(accountID)
+	if err != nil {
+		return nil, err
+	}
+	return store.ReviewProposal(ctx, accountID, proposalID, status, reviewedBy, comment)
+}
+
+// Publish publishes a draft location in the account's residency region.
+func (r *RoutingRepository) Publish(ctx context.Context, accountID, locationID string) error {
+	repo, err := r.route(accountID)
+	if err != nil {
+		return err
+	}
+	publisher, ok := repo.(DraftPublisher)
+	if !ok {
+		return fmt.Errorf("publishing drafts is not supported for this account's region")
+	}
+	return publisher.Publish(ctx, accountID, locationID)
+}
+
+// ChildAccounts finds an account's children in the home region and every
+// residency region, since each child's settings live in its own region.
+func (r *RoutingRepository) ChildAccounts(ctx context.Context, accountID string) ([]string, error) {
+	children := []string{}
+	for _, repo := range r.repositories() {
+		hierarchy, ok := repo.(AccountHierarchy)
+		if !ok {
+			return nil, fmt.Errorf("account hierarchy is not supported for this region")
+		}
+		found, err := hierarchy.ChildAccounts(ctx, accountID)
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, found...)
+	}
+	sort.Strings(children)
+	return children, nil
+}
+
+// SubAccounts returns every descendant of an account across all regions.
+func (r *RoutingRepository) SubAccounts(ctx context.Context, accountID string) ([]string, error) {
+	return subAccounts(ctx, accountID, r.ChildAccounts)
+}
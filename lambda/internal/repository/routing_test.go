@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRegionMap(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		expected      map[string]string
+		expectedError bool
+	}{
+		{name: "Empty", input: "", expected: map[string]string{}},
+		{
+			name:     "Multiple entries",
+			input:    "acc-1:eu-west-1, acc-2:eu-central-1",
+			expected: map[string]string{"acc-1": "eu-west-1", "acc-2": "eu-central-1"},
+		},
+		{name: "Missing region", input: "acc-1:", expectedError: true},
+		{name: "Missing separator", input: "acc-1", expectedError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ParseRegionMap(tt.input)
+			if tt.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestRoutingRepository(t *testing.T) {
+	ctx := context.Background()
+	homeClient := new(mockDynamoDBClient)
+	euClient := new(mockDynamoDBClient)
+	repo := NewRoutingRepository(
+		NewDynamoDBRepository(homeClient, "locations"),
+		map[string]Repository{"eu-west-1": NewDynamoDBRepository(euClient, "locations-eu")},
+		map[string]string{"acc-eu": "eu-west-1", "acc-ap": "ap-south-1"},
+	)
+
+	item := map[string]types.AttributeValue{
+		"PK":           &types.AttributeValueMemberS{Value: "acc-eu"},
+		"SK":           &types.AttributeValueMemberS{Value: "loc-001"},
+		"locationType": &types.AttributeValueMemberS{Value: "coordinates"},
+		"coordinates": &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+			"latitude":  &types.AttributeValueMemberN{Value: "48.8566"},
+			"longitude": &types.AttributeValueMemberN{Value: "2.3522"},
+		}},
+	}
+
+	t.Run("Mapped account uses regional table", func(t *testing.T) {
+		euClient.On("GetItem", ctx, mock.MatchedBy(func(input *dynamodb.GetItemInput) bool {
+			return *input.TableName == "locations-eu"
+		})).Return(&dynamodb.GetItemOutput{Item: item}, nil).Once()
+
+		envelope, err := repo.Get(ctx, "acc-eu", "loc-001")
+		require.NoError(t, err)
+		assert.Equal(t, "acc-eu", envelope.Location.GetAccountID())
+		euClient.AssertExpectations(t)
+		homeClient.AssertNotCalled(t, "GetItem", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Unmapped account uses home table", func(t *testing.T) {
+		homeClient.On("PutItem", ctx, mock.MatchedBy(func(input *dynamodb.PutItemInput) bool {
+			return *input.TableName == "locations"
+		})).Return(&dynamodb.PutItemOutput{}, nil).Once()
+
+		_, err := repo.Create(ctx, models.CoordinatesLocation{
+			LocationBase: models.LocationBase{AccountID: "acc-us", LocationType: models.LocationTypeCoordinates},
+			Coordinates:  models.Coordinates{Latitude: 40.7128, Longitude: -74.0060},
+		})
+		require.NoError(t, err)
+		homeClient.AssertExpectations(t)
+	})
+
+	t.Run("Region without table is rejected", func(t *testing.T) {
+		err := repo.Delete(ctx, "acc-ap", "loc-001")
+		assert.EqualError(t, err, "no table configured for data residency region ap-south-1")
+	})
+}
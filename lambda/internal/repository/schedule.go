@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// activeFilter excludes locations outside their active window at :now.
+// Window bounds are stored by formatScheduleTime, so they compare as strings.
+const activeFilter = "(attribute_not_exists(activeFrom) OR activeFrom <= :now) AND (attribute_not_exists(activeUntil) OR activeUntil > :now)"
+
+// listedFilter selects the locations apps see: published and active now.
+const listedFilter = publishedFilter + " AND " + activeFilter
+
+// formatScheduleTime formats an active window bound in UTC to the second, a
+// fixed-width form whose string order is time order.
+func formatScheduleTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// parseScheduleTime parses an active window bound stored by formatScheduleTime.
+func parseScheduleTime(name, value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q: %w", name, value, err)
+	}
+	return &t, nil
+}
+
+// nowValue returns the :now value activeFilter compares against.
+func (r *DynamoDBRepository) nowValue() types.AttributeValue {
+	now := r.now()
+	return &types.AttributeValueMemberS{Value: formatScheduleTime(&now)}
+}
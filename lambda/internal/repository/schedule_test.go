@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDynamoDBRepositoryListActive(t *testing.T) {
+	t.Run("Compares the active window with the current time", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		before := time.Now().UTC().Truncate(time.Second)
+		var query *dynamodb.QueryInput
+		mockClient.On("Query", ctx, mock.Anything).Run(func(args mock.Arguments) {
+			query = args.Get(1).(*dynamodb.QueryInput)
+		}).Return(&dynamodb.QueryOutput{}, nil).Once()
+
+		_, err := repo.List(ctx, "acc-12345", nil)
+		require.NoError(t, err)
+		assert.Equal(t, notMergedFilter+" AND "+listedFilter, aws.ToString(query.FilterExpression))
+		now, ok := query.ExpressionAttributeValues[":now"].(*types.AttributeValueMemberS)
+		require.True(t, ok)
+		parsed, err := time.Parse(time.RFC3339, now.Value)
+		require.NoError(t, err)
+		assert.False(t, parsed.Before(before))
+	})
+
+	t.Run("Includes inactive locations when asked", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		mockClient.On("Query", ctx, mock.MatchedBy(func(input *dynamodb.QueryInput) bool {
+			_, hasNow := input.ExpressionAttributeValues[":now"]
+			return !hasNow && aws.ToString(input.FilterExpression) == notMergedFilter+" AND "+publishedFilter
+		})).Return(&dynamodb.QueryOutput{}, nil).Once()
+
+		_, err := repo.List(ctx, "acc-12345", &ListOptions{IncludeInactive: true})
+		require.NoError(t, err)
+		mockClient.AssertExpectations(t)
+	})
+}
+
+func TestLocationRecordActiveWindow(t *testing.T) {
+	chicago, err := time.LoadLocation("America/Chicago")
+	require.NoError(t, err)
+	from := time.Date(2024, time.November, 29, 8, 0, 0, 500, chicago)
+	until := time.Date(2024, time.December, 24, 18, 0, 0, 0, chicago)
+	location := models.CoordinatesLocation{
+		LocationBase: models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeCoordinates, ActiveFrom: &from, ActiveUntil: &until},
+		Coordinates:  models.Coordinates{Latitude: 39.7817, Longitude: -89.6501},
+	}
+
+	t.Run("Stores bounds in UTC to the second", func(t *testing.T) {
+		record, err := toLocationRecord(location, "loc-001")
+		require.NoError(t, err)
+		assert.Equal(t, "2024-11-29T14:00:00Z", record.ActiveFrom)
+		assert.Equal(t, "2024-12-25T00:00:00Z", record.ActiveUntil)
+
+		restored, err := record.toLocation()
+		require.NoError(t, err)
+		assert.True(t, from.Truncate(time.Second).Equal(*restored.GetActiveFrom()))
+		assert.True(t, until.Equal(*restored.GetActiveUntil()))
+	})
+
+	t.Run("Leaves open bounds out", func(t *testing.T) {
+		open := location
+		open.ActiveFrom, open.ActiveUntil = nil, nil
+		record, err := toLocationRecord(open, "loc-001")
+		require.NoError(t, err)
+		assert.Empty(t, record.ActiveFrom)
+		assert.Empty(t, record.ActiveUntil)
+	})
+
+	t.Run("Rejects malformed stored bounds", func(t *testing.T) {
+		record, err := toLocationRecord(location, "loc-001")
+		require.NoError(t, err)
+		record.ActiveUntil = "next week"
+		_, err = record.toLocation()
+		assert.ErrorContains(t, err, "invalid activeUntil")
+	})
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/steverhoton/location-lambda/internal/models"
+)
+
+// settingsSK is the sort key of an account's settings document.
+const settingsSK = "settings"
+
+// SettingsStore defines storage operations for account settings.
+type SettingsStore interface {
+	GetAccountSettings(ctx context.Context, accountID string) (*models.AccountSettings, error)
+	PutAccountSettings(ctx context.Context, settings models.AccountSettings) (*models.AccountSettings, error)
+}
+
+// settingsRecord is the DynamoDB item holding an account's settings.
+type settingsRecord struct {
+	PK string `dynamodbav:"PK"` // ACCOUNT#accountId
+	SK string `dynamodbav:"SK"` // settings
+	models.AccountSettings
+}
+
+// GetAccountSettings returns an account's settings, or the defaults when the
+// account has not saved any.
+func (r *DynamoDBRepository) GetAccountSettings(ctx context.Context, accountID string) (*models.AccountSettings, error) {
+	result, err := r.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(r.tableName),
+		Key:       accountConfigKey(accountID, settingsSK),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account settings: %w", err)
+	}
+
+	if result.Item == nil {
+		settings := models.DefaultAccountSettings(accountID)
+		return &settings, nil
+	}
+
+	var record settingsRecord
+	if err := attributevalue.UnmarshalMap(result.Item, &record); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal account settings: %w", err)
+	}
+
+	return &record.AccountSettings, nil
+}
+
+// PutAccountSettings replaces an account's settings and returns them as stored.
+func (r *DynamoDBRepository) PutAccountSettings(ctx context.Context, settings models.AccountSettings) (*models.AccountSettings, error) {
+	if err := settings.Validate(); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	updatedAt := r.now().UTC()
+	settings.UpdatedAt = &updatedAt
+
+	item, err := attributevalue.MarshalMap(settingsRecord{
+		PK:              accountPKPrefix + settings.AccountID,
+		SK:              settingsSK,
+		AccountSettings: settings,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal account settings: %w", err)
+	}
+
+	_, err = r.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(r.tableName),
+		Item:      item,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to put account settings: %w", err)
+	}
+
+	return &settings, nil
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDynamoDBRepositoryAccountSettings(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Defaults when none are stored", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		mockClient.On("GetItem", ctx, mock.MatchedBy(func(input *dynamodb.GetItemInput) bool {
+			return input.Key["PK"].(*types.AttributeValueMemberS).Value == "ACCOUNT#acc-12345" &&
+				input.Key["SK"].(*types.AttributeValueMemberS).Value == "settings"
+		})).Return(&dynamodb.GetItemOutput{}, nil).Once()
+
+		settings, err := repo.GetAccountSettings(ctx, "acc-12345")
+		require.NoError(t, err)
+		assert.Equal(t, models.DefaultAccountSettings("acc-12345"), *settings)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Stored settings round trip", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		settings := models.DefaultAccountSettings("acc-12345")
+		settings.DefaultCountry = "GB"
+		settings.WebhookURL = "https://hooks.example.com/locations"
+		settings.Quotas.MaxLocations = 500
+
+		var stored map[string]types.AttributeValue
+		mockClient.On("PutItem", ctx, mock.Anything).Run(func(args mock.Arguments) {
+			stored = args.Get(1).(*dynamodb.PutItemInput).Item
+		}).Return(&dynamodb.PutItemOutput{}, nil).Once()
+
+		saved, err := repo.PutAccountSettings(ctx, settings)
+		require.NoError(t, err)
+		require.NotNil(t, saved.UpdatedAt)
+		assert.Equal(t, "ACCOUNT#acc-12345", stored["PK"].(*types.AttributeValueMemberS).Value)
+		assert.Equal(t, "settings", stored["SK"].(*types.AttributeValueMemberS).Value)
+
+		mockClient.On("GetItem", ctx, mock.Anything).Return(&dynamodb.GetItemOutput{Item: stored}, nil).Once()
+
+		loaded, err := repo.GetAccountSettings(ctx, "acc-12345")
+		require.NoError(t, err)
+		assert.Equal(t, "GB", loaded.DefaultCountry)
+		assert.Equal(t, 500, loaded.Quotas.MaxLocations)
+		assert.True(t, saved.UpdatedAt.Equal(*loaded.UpdatedAt))
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Invalid settings are not stored", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		settings := models.DefaultAccountSettings("acc-12345")
+		settings.DefaultUnits = "nautical"
+
+		_, err := repo.PutAccountSettings(ctx, settings)
+		assert.EqualError(t, err, "validation failed: defaultUnits must be metric or imperial")
+		mockClient.AssertNotCalled(t, "PutItem", mock.Anything, mock.Anything)
+	})
+}
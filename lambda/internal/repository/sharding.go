@@ -0,0 +1,236 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ShardConfig configures write sharding of the account GSI.
+//
+// When enabled, every record is written with an accountShard attribute of the
+// form accountId#shardN, spreading a large tenant across ShardCount GSI
+// partitions. List fans out one query per shard and merges the results.
+// The shard count must not change once records have been written with it.
+type ShardConfig struct {
+	// ShardCount is the number of GSI shards per account; 0 or 1 disables sharding.
+	ShardCount int
+	// IndexName is the GSI keyed on accountShard (hash) and SK (range).
+	IndexName string
+}
+
+// enabled reports whether write sharding is active.
+func (c ShardConfig) enabled() bool {
+	return c.ShardCount > 1 && c.IndexName != ""
+}
+
+// shardCursor records the pagination position within a single shard.
+type shardCursor struct {
+	LastSK string `json:"lastSk,omitempty"` // Last locationId returned from this shard
+	Done   bool   `json:"done,omitempty"`   // True when the shard has no more items
+}
+
+// shardPage holds the items fetched from one shard.
+type shardPage struct {
+	shard   int
+	items   []LocationEnvelope
+	hasMore bool
+	lastKey string // SK of the last evaluated item, which may have been filtered out
+	err     error
+}
+
+// shardFor returns the shard number for a location ID.
+func (c ShardConfig) shardFor(locationID string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(locationID))
+	return int(h.Sum32() % uint32(c.ShardCount))
+}
+
+// shardKey formats the GSI partition key for an account shard.
+func shardKey(accountID string, shard int) string {
+	return fmt.Sprintf("%s#shard%d", accountID, shard)
+}
+
+// accountShard returns the accountShard attribute for a record, or an empty
+// string when sharding is disabled.
+func (r *DynamoDBRepository) accountShard(accountID, locationID string) string {
+	if !r.sharding.enabled() {
+		return ""
+	}
+	return shardKey(accountID, r.sharding.shardFor(locationID))
+}
+
+// listSharded queries every shard of an account in parallel and merges the
+// results by locationId so pages are ordered as in the unsharded layout.
+func (r *DynamoDBRepository) listSharded(ctx context.Context, accountID string, limit int32, cursor *paginationCursor, filter listFilter) (*ListResult, error) {
+	positions := make([]shardCursor, r.sharding.ShardCount)
+	switch {
+	case cursor == nil:
+	case len(cursor.Shards) == 0 && cursor.SK != "":
+		// A page served by the partition fallback resumes every shard after its last location
+		_, lastID, _ := LocationKeys(cursor.PK, cursor.SK)
+		for shard := range positions {
+			positions[shard].LastSK = lastID
+		}
+	case len(cursor.Shards) != r.sharding.ShardCount:
+		return nil, fmt.Errorf("cursor does not match shard configuration")
+	default:
+		copy(positions, cursor.Shards)
+	}
+
+	pages := make([]shardPage, r.sharding.ShardCount)
+	var wg sync.WaitGroup
+	for shard := range positions {
+		if positions[shard].Done {
+			pages[shard] = shardPage{shard: shard}
+			continue
+		}
+		wg.Add(1)
+		go func(shard int) {
+			defer wg.Done()
+			pages[shard] = r.queryShard(ctx, accountID, shard, positions[shard].LastSK, limit, filter)
+		}(shard)
+	}
+	wg.Wait()
+
+	// Merge shard pages by locationId
+	type entry struct {
+		shard int
+		LocationEnvelope
+	}
+	var merged []entry
+	for _, page := range pages {
+		if page.err != nil && r.indexUnavailable(page.err) {
+			return r.listShardedFallback(ctx, accountID, limit, cursor, filter)
+		}
+		if page.err != nil {
+			return nil, fmt.Errorf("failed to list locations: %w", page.err)
+		}
+		for _, item := range page.items {
+			merged = append(merged, entry{shard: page.shard, LocationEnvelope: item})
+		}
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].LocationID < merged[j].LocationID })
+
+	if len(merged) > int(limit) {
+		merged = merged[:limit]
+	}
+
+	items := make([]LocationEnvelope, 0, len(merged))
+	consumed := make([]int, r.sharding.ShardCount)
+	for _, e := range merged {
+		items = append(items, e.LocationEnvelope)
+		positions[e.shard].LastSK = e.LocationID
+		consumed[e.shard]++
+	}
+
+	// A shard is exhausted once every fetched item was returned and DynamoDB
+	// reported no further pages.
+	more := false
+	for _, page := range pages {
+		if positions[page.shard].Done {
+			continue
+		}
+		if consumed[page.shard] == len(page.items) && !page.hasMore {
+			positions[page.shard].Done = true
+			continue
+		}
+		// Resume after filtered-out items once every returned item was consumed
+		if consumed[page.shard] == len(page.items) {
+			positions[page.shard].LastSK = page.lastKey
+		}
+		more = true
+	}
+
+	var nextCursor *string
+	if more {
+		// The cursor also records the last location returned, so the next
+		// page can be served by the partition fallback
+		next := &paginationCursor{PK: r.locationPK(accountID), Shards: positions}
+		if cursor != nil {
+			next.SK = cursor.SK
+		}
+		if len(items) > 0 {
+			next.SK = r.locationSK(items[len(items)-1].LocationID)
+		}
+		var err error
+		nextCursor, err = r.encodeCursor(next)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode cursor: %w", err)
+		}
+	}
+
+	return &ListResult{
+		Items:      items,
+		NextCursor: nextCursor,
+	}, nil
+}
+
+// listShardedFallback serves a page of a sharded List from the account's
+// partition of the main table when the shard index can't be read. Pages
+// of both are ordered by location ID, so it resumes after the last location
+// the cursor returned, and its own cursor resumes the shards.
+func (r *DynamoDBRepository) listShardedFallback(ctx context.Context, accountID string, limit int32, cursor *paginationCursor, filter listFilter) (*ListResult, error) {
+	var startKey map[string]types.AttributeValue
+	if cursor != nil && cursor.SK != "" {
+		startKey = r.locationKey(accountID, locationIDOf(cursor.SK))
+	}
+	result, err := r.listPartition(ctx, accountID, limit, startKey, filter)
+	if err != nil {
+		return nil, err
+	}
+	result.IndexFallback = true
+	markIndexFallback(ctx)
+	return result, nil
+}
+
+// queryShard fetches up to limit items from a single shard after lastSK.
+func (r *DynamoDBRepository) queryShard(ctx context.Context, accountID string, shard int, lastSK string, limit int32, filter listFilter) shardPage {
+	key := shardKey(accountID, shard)
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		IndexName:              aws.String(r.sharding.IndexName),
+		KeyConditionExpression: aws.String("accountShard = :shard"),
+		ExpressionAttributeValues: filter.values(map[string]types.AttributeValue{
+			":shard": &types.AttributeValueMemberS{Value: key},
+		}),
+		Limit:            aws.Int32(limit),
+		ScanIndexForward: aws.Bool(true),
+		FilterExpression: aws.String(filter.expression),
+	}
+	if lastSK != "" {
+		input.ExclusiveStartKey = map[string]types.AttributeValue{
+			"PK":           &types.AttributeValueMemberS{Value: r.locationPK(accountID)},
+			"SK":           &types.AttributeValueMemberS{Value: r.locationSK(lastSK)},
+			"accountShard": &types.AttributeValueMemberS{Value: key},
+		}
+	}
+
+	result, err := r.client.Query(ctx, input)
+	if err != nil {
+		return shardPage{shard: shard, err: err}
+	}
+
+	items, err := r.itemsToEnvelopes(ctx, result.Items)
+	if err != nil {
+		return shardPage{shard: shard, err: err}
+	}
+
+	var lastKey string
+	if sk, ok := result.LastEvaluatedKey["SK"].(*types.AttributeValueMemberS); ok {
+		_, lastKey, _ = LocationKeys(r.locationPK(accountID), sk.Value)
+	}
+
+	return shardPage{
+		shard:   shard,
+		items:   items,
+		hasMore: result.LastEvaluatedKey != nil,
+		lastKey: lastKey,
+	}
+}
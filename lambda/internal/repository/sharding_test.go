@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// coordinatesItem builds a minimal coordinates location item for tests.
+func coordinatesItem(accountID, locationID string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"PK":           &types.AttributeValueMemberS{Value: accountID},
+		"SK":           &types.AttributeValueMemberS{Value: locationID},
+		"locationType": &types.AttributeValueMemberS{Value: "coordinates"},
+		"coordinates": &types.AttributeValueMemberM{
+			Value: map[string]types.AttributeValue{
+				"latitude":  &types.AttributeValueMemberN{Value: "40.7128"},
+				"longitude": &types.AttributeValueMemberN{Value: "-74.0060"},
+			},
+		},
+	}
+}
+
+// matchShard matches a query against the given shard partition key.
+func matchShard(key string) interface{} {
+	return mock.MatchedBy(func(input *dynamodb.QueryInput) bool {
+		v, ok := input.ExpressionAttributeValues[":shard"].(*types.AttributeValueMemberS)
+		return ok && v.Value == key && aws.ToString(input.IndexName) == "AccountShardIndex"
+	})
+}
+
+func TestShardConfig(t *testing.T) {
+	cfg := ShardConfig{ShardCount: 4, IndexName: "AccountShardIndex"}
+
+	t.Run("Shard assignment is deterministic and in range", func(t *testing.T) {
+		for _, id := range []string{"loc-001", "loc-002", "loc-003", "550e8400-e29b-41d4-a716-446655440000"} {
+			shard := cfg.shardFor(id)
+			assert.Equal(t, shard, cfg.shardFor(id))
+			assert.GreaterOrEqual(t, shard, 0)
+			assert.Less(t, shard, 4)
+		}
+	})
+
+	t.Run("Sharding disabled", func(t *testing.T) {
+		assert.False(t, ShardConfig{ShardCount: 1, IndexName: "idx"}.enabled())
+		assert.False(t, ShardConfig{ShardCount: 4}.enabled())
+		repo := NewDynamoDBRepository(new(mockDynamoDBClient), "test-table")
+		assert.Empty(t, repo.accountShard("acc-12345", "loc-001"))
+	})
+
+	t.Run("Shard key format", func(t *testing.T) {
+		repo := NewDynamoDBRepository(new(mockDynamoDBClient), "test-table", WithSharding(cfg))
+		assert.Regexp(t, `^acc-12345#shard[0-3]$`, repo.accountShard("acc-12345", "loc-001"))
+	})
+}
+
+func TestDynamoDBRepositoryCreateSharded(t *testing.T) {
+	ctx := context.Background()
+	mockClient := new(mockDynamoDBClient)
+	repo := NewDynamoDBRepository(mockClient, "test-table", WithSharding(ShardConfig{ShardCount: 4, IndexName: "AccountShardIndex"}))
+
+	location := models.CoordinatesLocation{
+		LocationBase: models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeCoordinates},
+		Coordinates:  models.Coordinates{Latitude: 40.7128, Longitude: -74.0060},
+	}
+
+	mockClient.On("PutItem", ctx, mock.MatchedBy(func(input *dynamodb.PutItemInput) bool {
+		v, ok := input.Item["accountShard"].(*types.AttributeValueMemberS)
+		return ok && len(v.Value) > len("acc-12345#shard")
+	})).Return(&dynamodb.PutItemOutput{}, nil).Once()
+
+	_, err := repo.Create(ctx, location)
+	require.NoError(t, err)
+	mockClient.AssertExpectations(t)
+}
+
+func TestDynamoDBRepositoryListSharded(t *testing.T) {
+	ctx := context.Background()
+	accountID := "acc-12345"
+
+	t.Run("Merges shards in locationId order and pages with a cursor", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table", WithSharding(ShardConfig{ShardCount: 2, IndexName: "AccountShardIndex"}))
+
+		mockClient.On("Query", mock.Anything, matchShard("acc-12345#shard0")).Return(&dynamodb.QueryOutput{
+			Items: []map[string]types.AttributeValue{coordinatesItem(accountID, "loc-001"), coordinatesItem(accountID, "loc-004")},
+		}, nil).Once()
+		mockClient.On("Query", mock.Anything, matchShard("acc-12345#shard1")).Return(&dynamodb.QueryOutput{
+			Items: []map[string]types.AttributeValue{coordinatesItem(accountID, "loc-002"), coordinatesItem(accountID, "loc-003")},
+		}, nil).Once()
+
+		result, err := repo.List(ctx, accountID, &ListOptions{Limit: aws.Int32(3)})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"loc-001", "loc-002", "loc-003"}, result.LocationIDs())
+		require.NotNil(t, result.NextCursor)
+		mockClient.AssertExpectations(t)
+
+		// Shard 1 is exhausted, so only shard 0 is queried for the next page
+		mockClient.On("Query", mock.Anything, mock.MatchedBy(func(input *dynamodb.QueryInput) bool {
+			v, ok := input.ExclusiveStartKey["SK"].(*types.AttributeValueMemberS)
+			return ok && v.Value == "loc-001"
+		})).Return(&dynamodb.QueryOutput{
+			Items: []map[string]types.AttributeValue{coordinatesItem(accountID, "loc-004")},
+		}, nil).Once()
+
+		next, err := repo.List(ctx, accountID, &ListOptions{Limit: aws.Int32(3), Cursor: result.NextCursor})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"loc-004"}, next.LocationIDs())
+		assert.Nil(t, next.NextCursor)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Cursor from a different shard count is rejected", func(t *testing.T) {
+		repo := NewDynamoDBRepository(new(mockDynamoDBClient), "test-table", WithSharding(ShardConfig{ShardCount: 3, IndexName: "AccountShardIndex"}))
+		cursor, err := repo.encodeCursor(&paginationCursor{PK: accountID, Shards: make([]shardCursor, 2)})
+		require.NoError(t, err)
+
+		result, err := repo.List(ctx, accountID, &ListOptions{Cursor: cursor})
+		assert.Error(t, err)
+		assert.Nil(t, result)
+	})
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/steverhoton/location-lambda/internal/models"
+)
+
+// legacyShopFilter matches shop items stored before the shop address was nested.
+const legacyShopFilter = "locationType = :shop AND attribute_exists(shop) AND attribute_not_exists(shop.address)"
+
+// shopAttribute is the stored form of models.Shop. Items written before the
+// address was nested keep the address fields directly on the shop map; both
+// shapes read back the same, and writes are always nested.
+type shopAttribute models.Shop
+
+// UnmarshalDynamoDBAttributeValue implements attributevalue.Unmarshaler.
+func (s *shopAttribute) UnmarshalDynamoDBAttributeValue(av types.AttributeValue) error {
+	var shop models.Shop
+	if err := attributevalue.Unmarshal(av, &shop); err != nil {
+		return err
+	}
+	if m, ok := av.(*types.AttributeValueMemberM); ok {
+		if _, nested := m.Value["address"]; !nested {
+			if err := attributevalue.Unmarshal(av, &shop.Address); err != nil {
+				return err
+			}
+		}
+	}
+	*s = shopAttribute(shop)
+	return nil
+}
+
+// ShopMigrator rewrites shops stored with a flat address into the nested shape.
+type ShopMigrator interface {
+	MigrateShopAddresses(ctx context.Context, accountID string, dryRun bool) ([]string, error)
+}
+
+// MigrateShopAddresses rewrites an account's shops whose address is stored
+// flat so the address is nested, and returns their location IDs. Only the
+// shop attribute changes, and each write is conditional on it still being
+// flat, so a concurrent update is never overwritten. With dryRun, the IDs are returned
+// without writing.
+func (r *DynamoDBRepository) MigrateShopAddresses(ctx context.Context, accountID string, dryRun bool) ([]string, error) {
+	input := &dynamodb.QueryInput{
+		TableName:        aws.String(r.tableName),
+		FilterExpression: aws.String(legacyShopFilter),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":shop": &types.AttributeValueMemberS{Value: string(models.LocationTypeShop)},
+		},
+	}
+	input.KeyConditionExpression = aws.String(r.locationPartition(":pk", accountID, input.ExpressionAttributeValues))
+
+	migrated := []string{}
+	for {
+		result, err := r.client.Query(ctx, input)
+		if err != nil {
+			return migrated, fmt.Errorf("failed to query shops: %w", err)
+		}
+		for _, item := range result.Items {
+			var record locationRecord
+			if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+				return migrated, fmt.Errorf("failed to unmarshal shop: %w", err)
+			}
+			if !dryRun {
+				ok, err := r.nestShopAddress(ctx, item, record.Shop)
+				if err != nil {
+					return migrated, fmt.Errorf("failed to migrate shop %s: %w", record.SK, err)
+				}
+				if !ok {
+					continue
+				}
+			}
+			migrated = append(migrated, record.SK)
+		}
+		if result.LastEvaluatedKey == nil {
+			return migrated, nil
+		}
+		input.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+}
+
+// nestShopAddress puts item back with shop in the nested shape, leaving the
+// rest of the item as stored. It reports false when the stored shop was
+// rewritten since item was read.
+func (r *DynamoDBRepository) nestShopAddress(ctx context.Context, item map[string]types.AttributeValue, shop *shopAttribute) (bool, error) {
+	av, err := attributevalue.Marshal(shop)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal shop: %w", err)
+	}
+	item["shop"] = av
+
+	_, err = r.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(r.tableName),
+		Item:                item,
+		ConditionExpression: aws.String(legacyShopFilter),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":shop": &types.AttributeValueMemberS{Value: string(models.LocationTypeShop)},
+		},
+	})
+	if err != nil {
+		var ccf *types.ConditionalCheckFailedException
+		if errors.As(err, &ccf) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// flatShopItem is a shop item written before the shop address was nested.
+func flatShopItem(accountID, locationID string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"PK":           &types.AttributeValueMemberS{Value: accountID},
+		"SK":           &types.AttributeValueMemberS{Value: locationID},
+		"locationType": &types.AttributeValueMemberS{Value: "shop"},
+		"externalId":   &types.AttributeValueMemberS{Value: "ERP-1"},
+		"shop": &types.AttributeValueMemberM{
+			Value: map[string]types.AttributeValue{
+				"name":          &types.AttributeValueMemberS{Value: "Main Street Store"},
+				"contactId":     &types.AttributeValueMemberS{Value: "contact-1"},
+				"streetAddress": &types.AttributeValueMemberS{Value: "123 Main St"},
+				"city":          &types.AttributeValueMemberS{Value: "Springfield"},
+				"postalCode":    &types.AttributeValueMemberS{Value: "62704"},
+				"country":       &types.AttributeValueMemberS{Value: "US"},
+			},
+		},
+	}
+}
+
+func TestShopAttribute(t *testing.T) {
+	want := models.Shop{
+		Name:      "Main Street Store",
+		ContactID: "contact-1",
+		Address:   models.Address{StreetAddress: "123 Main St", City: "Springfield", PostalCode: "62704", Country: "US"},
+	}
+
+	t.Run("Reads flat shops", func(t *testing.T) {
+		var record locationRecord
+		require.NoError(t, attributevalue.UnmarshalMap(flatShopItem("acc-12345", "loc-001"), &record))
+		location, err := record.toLocation()
+		require.NoError(t, err)
+		assert.Equal(t, want, location.(models.ShopLocation).Shop)
+	})
+
+	t.Run("Writes nested shops", func(t *testing.T) {
+		record, err := toLocationRecord(models.ShopLocation{
+			LocationBase: models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeShop},
+			Shop:         want,
+		}, "loc-001")
+		require.NoError(t, err)
+		item, err := attributevalue.MarshalMap(record)
+		require.NoError(t, err)
+
+		shop := item["shop"].(*types.AttributeValueMemberM).Value
+		assert.Contains(t, shop, "address")
+		assert.NotContains(t, shop, "streetAddress")
+
+		var roundTrip locationRecord
+		require.NoError(t, attributevalue.UnmarshalMap(item, &roundTrip))
+		assert.Equal(t, want, models.Shop(*roundTrip.Shop))
+	})
+}
+
+func TestDynamoDBRepositoryMigrateShopAddresses(t *testing.T) {
+	matchLegacyShops := mock.MatchedBy(func(input *dynamodb.QueryInput) bool {
+		return aws.ToString(input.FilterExpression) == legacyShopFilter
+	})
+
+	t.Run("Nests flat addresses, leaving the rest of the item", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		mockClient.On("Query", ctx, matchLegacyShops).Return(&dynamodb.QueryOutput{Items: []map[string]types.AttributeValue{
+			flatShopItem("acc-12345", "loc-001"),
+		}}, nil).Once()
+		var put *dynamodb.PutItemInput
+		mockClient.On("PutItem", ctx, mock.Anything).Run(func(args mock.Arguments) {
+			put = args.Get(1).(*dynamodb.PutItemInput)
+		}).Return(&dynamodb.PutItemOutput{}, nil).Once()
+
+		migrated, err := repo.MigrateShopAddresses(ctx, "acc-12345", false)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"loc-001"}, migrated)
+
+		assert.Equal(t, legacyShopFilter, aws.ToString(put.ConditionExpression))
+		assert.Equal(t, "ERP-1", put.Item["externalId"].(*types.AttributeValueMemberS).Value)
+		shop := put.Item["shop"].(*types.AttributeValueMemberM).Value
+		address := shop["address"].(*types.AttributeValueMemberM).Value
+		assert.Equal(t, "123 Main St", address["streetAddress"].(*types.AttributeValueMemberS).Value)
+		assert.NotContains(t, shop, "streetAddress")
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Dry run writes nothing", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		mockClient.On("Query", ctx, matchLegacyShops).Return(&dynamodb.QueryOutput{Items: []map[string]types.AttributeValue{
+			flatShopItem("acc-12345", "loc-001"),
+		}}, nil).Once()
+
+		migrated, err := repo.MigrateShopAddresses(ctx, "acc-12345", true)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"loc-001"}, migrated)
+		mockClient.AssertNotCalled(t, "PutItem", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Skips shops rewritten since they were read", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		mockClient.On("Query", ctx, matchLegacyShops).Return(&dynamodb.QueryOutput{Items: []map[string]types.AttributeValue{
+			flatShopItem("acc-12345", "loc-001"),
+		}}, nil).Once()
+		mockClient.On("PutItem", ctx, mock.Anything).Return(nil, &types.ConditionalCheckFailedException{}).Once()
+
+		migrated, err := repo.MigrateShopAddresses(ctx, "acc-12345", false)
+		require.NoError(t, err)
+		assert.Empty(t, migrated)
+	})
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// signingSecretSK is the sort key of an account's signing secret, kept apart
+// from its settings so it is never returned with them.
+const signingSecretSK = "signing-secret"
+
+// SigningSecret is the HMAC secret an account's mutations must be signed with.
+type SigningSecret struct {
+	AccountID string    `json:"accountId" dynamodbav:"accountId"`
+	Secret    string    `json:"secret" dynamodbav:"secret"`
+	CreatedAt time.Time `json:"createdAt" dynamodbav:"createdAt"`
+}
+
+// SigningSecretStore defines storage operations for account signing secrets.
+type SigningSecretStore interface {
+	// GetSigningSecret returns nil when the account does not sign its mutations.
+	GetSigningSecret(ctx context.Context, accountID string) (*SigningSecret, error)
+	PutSigningSecret(ctx context.Context, secret SigningSecret) error
+	DeleteSigningSecret(ctx context.Context, accountID string) error
+}
+
+// signingSecretRecord is the DynamoDB item holding an account's signing secret.
+type signingSecretRecord struct {
+	PK string `dynamodbav:"PK"` // ACCOUNT#accountId
+	SK string `dynamodbav:"SK"` // signing-secret
+	SigningSecret
+}
+
+// GetSigningSecret returns an account's signing secret, or nil without one.
+// The read is strongly consistent so a rotated secret applies at once.
+func (r *DynamoDBRepository) GetSigningSecret(ctx context.Context, accountID string) (*SigningSecret, error) {
+	result, err := r.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(r.tableName),
+		Key:            accountConfigKey(accountID, signingSecretSK),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get signing secret: %w", err)
+	}
+	if result.Item == nil {
+		return nil, nil
+	}
+
+	var record signingSecretRecord
+	if err := attributevalue.UnmarshalMap(result.Item, &record); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal signing secret: %w", err)
+	}
+	return &record.SigningSecret, nil
+}
+
+// PutSigningSecret replaces an account's signing secret.
+func (r *DynamoDBRepository) PutSigningSecret(ctx context.Context, secret SigningSecret) error {
+	if secret.AccountID == "" || secret.Secret == "" {
+		return fmt.Errorf("validation failed: accountId and secret are required")
+	}
+
+	item, err := attributevalue.MarshalMap(signingSecretRecord{
+		PK:            accountPKPrefix + secret.AccountID,
+		SK:            signingSecretSK,
+		SigningSecret: secret,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal signing secret: %w", err)
+	}
+
+	_, err = r.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(r.tableName),
+		Item:      item,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put signing secret: %w", err)
+	}
+	return nil
+}
+
+// DeleteSigningSecret removes an account's signing secret, so its mutations
+// no longer need signing.
+func (r *DynamoDBRepository) DeleteSigningSecret(ctx context.Context, accountID string) error {
+	_, err := r.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(r.tableName),
+		Key:       accountConfigKey(accountID, signingSecretSK),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete signing secret: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDynamoDBRepositorySigningSecrets(t *testing.T) {
+	ctx := context.Background()
+	isSecretKey := func(key map[string]types.AttributeValue) bool {
+		return key["PK"].(*types.AttributeValueMemberS).Value == "ACCOUNT#acc-12345" &&
+			key["SK"].(*types.AttributeValueMemberS).Value == "signing-secret"
+	}
+
+	t.Run("None stored", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		mockClient.On("GetItem", ctx, mock.MatchedBy(func(input *dynamodb.GetItemInput) bool {
+			return isSecretKey(input.Key) && *input.ConsistentRead
+		})).Return(&dynamodb.GetItemOutput{}, nil).Once()
+
+		secret, err := repo.GetSigningSecret(ctx, "acc-12345")
+		require.NoError(t, err)
+		assert.Nil(t, secret)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Stored secret round trips", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+		secret := SigningSecret{AccountID: "acc-12345", Secret: "s3cret", CreatedAt: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)}
+
+		var stored map[string]types.AttributeValue
+		mockClient.On("PutItem", ctx, mock.Anything).Run(func(args mock.Arguments) {
+			stored = args.Get(1).(*dynamodb.PutItemInput).Item
+		}).Return(&dynamodb.PutItemOutput{}, nil).Once()
+		require.NoError(t, repo.PutSigningSecret(ctx, secret))
+		assert.True(t, isSecretKey(stored))
+
+		mockClient.On("GetItem", ctx, mock.Anything).Return(&dynamodb.GetItemOutput{Item: stored}, nil).Once()
+		loaded, err := repo.GetSigningSecret(ctx, "acc-12345")
+		require.NoError(t, err)
+		assert.Equal(t, secret, *loaded)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Secret is required", func(t *testing.T) {
+		repo := NewDynamoDBRepository(new(mockDynamoDBClient), "test-table")
+		err := repo.PutSigningSecret(ctx, SigningSecret{AccountID: "acc-12345"})
+		assert.EqualError(t, err, "validation failed: accountId and secret are required")
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		mockClient.On("DeleteItem", ctx, mock.MatchedBy(func(input *dynamodb.DeleteItemInput) bool {
+			return isSecretKey(input.Key)
+		})).Return(&dynamodb.DeleteItemOutput{}, nil).Once()
+
+		require.NoError(t, repo.DeleteSigningSecret(ctx, "acc-12345"))
+		mockClient.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/google/uuid"
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// idCreatedAt returns a version 7 location ID created at t.
+func idCreatedAt(t *testing.T, at time.Time) string {
+	id, err := uuid.NewV7FromReader(constantReader{})
+	require.NoError(t, err)
+	// The first 48 bits of a version 7 UUID are its Unix time in milliseconds
+	ms := at.UnixMilli()
+	for i := 0; i < 6; i++ {
+		id[i] = byte(ms >> (40 - 8*i))
+	}
+	return id.String()
+}
+
+// constantReader supplies the random bits of test UUIDs.
+type constantReader struct{}
+
+func (constantReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0x42
+	}
+	return len(p), nil
+}
+
+func TestDynamoDBRepositoryLocationStats(t *testing.T) {
+	june := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+	july := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	item := func(t *testing.T, record locationRecord) map[string]types.AttributeValue {
+		record.PK = "acc-12345"
+		item, err := attributevalue.MarshalMap(record)
+		require.NoError(t, err)
+		return item
+	}
+	address := &models.Address{StreetAddress: "123 Main St", City: "Springfield", PostalCode: "62704", Country: "US"}
+	records := func(t *testing.T) []map[string]types.AttributeValue {
+		return []map[string]types.AttributeValue{
+			item(t, locationRecord{SK: idCreatedAt(t, june), LocationType: models.LocationTypeAddress, Address: address}),
+			item(t, locationRecord{SK: idCreatedAt(t, july), LocationType: models.LocationTypeShop, Draft: true, Shop: &shopAttribute{
+				Name: "Harbour Store", Address: models.Address{StreetAddress: "1 Quay", City: "Halifax", PostalCode: "B3J", Country: "CA"},
+			}}),
+			item(t, locationRecord{SK: idCreatedAt(t, july), LocationType: models.LocationTypeCoordinates, ActiveFrom: "2999-01-01T00:00:00Z"}),
+			item(t, locationRecord{SK: uuid.New().String(), LocationType: models.LocationTypeEvent, Address: address, ActiveUntil: "2001-01-01T00:00:00Z"}),
+		}
+	}
+
+	t.Run("Counts by type, country, status, and month", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		all := records(t)
+		matchesQuery := func(first bool) interface{} {
+			return mock.MatchedBy(func(input *dynamodb.QueryInput) bool {
+				return input.IndexName == nil &&
+					aws.ToString(input.KeyConditionExpression) == "PK = :key" &&
+					input.ExpressionAttributeValues[":key"].(*types.AttributeValueMemberS).Value == "acc-12345" &&
+					aws.ToString(input.ProjectionExpression) == statsProjection &&
+					aws.ToString(input.FilterExpression) == notMergedFilter &&
+					(input.ExclusiveStartKey == nil) == first
+			})
+		}
+		mockClient.On("Query", ctx, matchesQuery(true)).Return(&dynamodb.QueryOutput{
+			Items:            all[:2],
+			LastEvaluatedKey: map[string]types.AttributeValue{"PK": &types.AttributeValueMemberS{Value: "acc-12345"}},
+		}, nil).Once()
+		mockClient.On("Query", ctx, matchesQuery(false)).Return(&dynamodb.QueryOutput{Items: all[2:]}, nil).Once()
+
+		stats, err := repo.LocationStats(ctx, "acc-12345")
+		require.NoError(t, err)
+		assert.Equal(t, "acc-12345", stats.AccountID)
+		assert.Equal(t, 4, stats.Total)
+		assert.Equal(t, []StatCount{{"address", 1}, {"coordinates", 1}, {"event", 1}, {"shop", 1}}, stats.ByType)
+		assert.Equal(t, []StatCount{{"CA", 1}, {"US", 2}}, stats.ByCountry)
+		assert.Equal(t, []StatCount{{StatusActive, 1}, {StatusDraft, 1}, {StatusExpired, 1}, {StatusScheduled, 1}}, stats.ByStatus)
+		assert.Equal(t, []StatCount{{"2024-06", 1}, {"2024-07", 2}}, stats.CreatedByMonth)
+		assert.Equal(t, 1, stats.Undated)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Queries every shard", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table", WithSharding(ShardConfig{ShardCount: 2, IndexName: "shard-index"}))
+
+		all := records(t)
+		for shard, items := range [][]map[string]types.AttributeValue{all[:3], all[3:]} {
+			key := shardKey("acc-12345", shard)
+			mockClient.On("Query", ctx, mock.MatchedBy(func(input *dynamodb.QueryInput) bool {
+				return aws.ToString(input.IndexName) == "shard-index" &&
+					input.ExpressionAttributeValues[":key"].(*types.AttributeValueMemberS).Value == key
+			})).Return(&dynamodb.QueryOutput{Items: items}, nil).Once()
+		}
+
+		stats, err := repo.LocationStats(ctx, "acc-12345")
+		require.NoError(t, err)
+		assert.Equal(t, 4, stats.Total)
+		assert.Equal(t, []StatCount{{"CA", 1}, {"US", 2}}, stats.ByCountry)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Fails when a query fails", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+		mockClient.On("Query", ctx, mock.Anything).Return(nil, errors.New("throttled")).Once()
+
+		_, err := repo.LocationStats(ctx, "acc-12345")
+		assert.EqualError(t, err, "failed to count locations: throttled")
+	})
+
+	t.Run("Requires an account", func(t *testing.T) {
+		repo := NewDynamoDBRepository(new(mockDynamoDBClient), "test-table")
+
+		_, err := repo.LocationStats(context.Background(), "")
+		assert.EqualError(t, err, "validation failed: accountId is required")
+	})
+}
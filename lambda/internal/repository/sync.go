@@ -0,0 +1,341 @@
+package repository
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/google/uuid"
+)
+
+const (
+	// syncDeletedPKPrefix keeps deletion tombstones out of an account's location partition.
+	syncDeletedPKPrefix = "SYNCDELETED#"
+	// syncOverlap is how far before the newest change it read a sync reads
+	// again, so a write that took its updatedAt before one sync but reached
+	// the index after it is returned by the next.
+	syncOverlap = time.Minute
+	// syncSeenLimit caps the changes a token remembers from its overlap; past
+	// it, the overlap shrinks to the most recent ones.
+	syncSeenLimit = 500
+	// defaultTombstoneRetention is how long deletions are kept for sync
+	// when WithSyncIndex is given none.
+	defaultTombstoneRetention = 30 * 24 * time.Hour
+)
+
+// SyncOperation is the kind of a change returned by SyncLocations.
+type SyncOperation string
+
+const (
+	SyncCreated SyncOperation = "created"
+	SyncUpdated SyncOperation = "updated"
+	SyncDeleted SyncOperation = "deleted"
+)
+
+// SyncChange is one location's latest change since a sync token.
+type SyncChange struct {
+	Operation  SyncOperation `json:"operation"`
+	LocationID string        `json:"locationId"`
+	ChangedAt  time.Time     `json:"changedAt"`
+	// Location is the location as stored, omitted for deletions
+	Location *LocationEnvelope `json:"location,omitempty"`
+}
+
+// SyncResult is a page of changes and the token to sync from next.
+type SyncResult struct {
+	Changes   []SyncChange `json:"changes"`
+	NextToken string       `json:"nextToken,omitempty"`
+	// HasMore is true when further changes are waiting; sync again with
+	// NextToken straight away rather than on the client's usual schedule
+	HasMore bool `json:"hasMore"`
+}
+
+// LocationSyncer returns the changes to an account's locations since a sync token.
+type LocationSyncer interface {
+	SyncLocations(ctx context.Context, accountID, sinceToken string, limit int32) (*SyncResult, error)
+}
+
+// WithSyncIndex enables SyncLocations with the GSI keyed on syncAccount and
+// updatedAt. Deleted locations are tombstoned for tombstoneRetention, or 30
+// days when it is zero; tokens older than that must sync from scratch.
+func WithSyncIndex(indexName string, tombstoneRetention time.Duration) Option {
+	return func(r *DynamoDBRepository) {
+		r.syncIndex = indexName
+		r.tombstoneRetention = tombstoneRetention
+		if r.tombstoneRetention <= 0 {
+			r.tombstoneRetention = defaultTombstoneRetention
+		}
+	}
+}
+
+// syncTokenData is the position a sync token resumes from.
+type syncTokenData struct {
+	// From is where the next query starts, an overlap before the newest
+	// change read; tokens issued before the overlap hold that change itself
+	From string `json:"updatedAt"`
+	// Seen maps the locations read since From to the updatedAt they were
+	// read at, so reading them again returns only those changed since
+	Seen map[string]string `json:"seen,omitempty"`
+	// Horizon is the time up to which the client has every change; a token
+	// expires once tombstones that recent may have been removed
+	Horizon string `json:"horizon"`
+}
+
+// syncTombstone is the DynamoDB item recording a deletion for sync.
+type syncTombstone struct {
+	PK          string `dynamodbav:"PK"` // SYNCDELETED#accountId
+	SK          string `dynamodbav:"SK"` // locationId
+	SyncAccount string `dynamodbav:"syncAccount"`
+	UpdatedAt   string `dynamodbav:"updatedAt"`
+	ExpiresAt   int64  `dynamodbav:"expiresAt"` // Unix seconds
+}
+
+// syncTime formats a change time for the sync index.
+func syncTime(t time.Time) string {
+	return t.UTC().Format(sortableTime)
+}
+
+// recordDeletion tombstones a location removed from accountID, so syncing
+// clients learn of the deletion. It does nothing without a sync index.
+func (r *DynamoDBRepository) recordDeletion(ctx context.Context, accountID, locationID string) error {
+	if r.syncIndex == "" {
+		return nil
+	}
+
+	now := r.now()
+	item, err := attributevalue.MarshalMap(syncTombstone{
+		PK:          syncDeletedPKPrefix + accountID,
+		SK:          locationID,
+		SyncAccount: accountID,
+		UpdatedAt:   syncTime(now),
+		ExpiresAt:   now.Add(r.tombstoneRetention).Unix(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal sync tombstone: %w", err)
+	}
+
+	_, err = r.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(r.tableName),
+		Item:      item,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record deletion of location %s for sync: %w", locationID, err)
+	}
+	return nil
+}
+
+// SyncLocations returns the changes to an account's locations after
+// sinceToken, oldest first, with the token to sync from next. Without a
+// token every location is returned as created. A location changed several
+// times appears once, as its latest state; merged locations are reported as
+// deleted, and drafts are left out until published. As the index is
+// eventually consistent, each sync reads the last minute before the token's
+// position again, skipping the versions the token has already seen.
+func (r *DynamoDBRepository) SyncLocations(ctx context.Context, accountID, sinceToken string, limit int32) (*SyncResult, error) {
+	if r.syncIndex == "" {
+		return nil, fmt.Errorf("sync index is not configured")
+	}
+	if accountID == "" {
+		return nil, fmt.Errorf("validation failed: accountId is required")
+	}
+	if limit <= 0 {
+		limit = r.defaultLimit
+	}
+
+	now := r.now()
+	since, err := decodeSyncToken(sinceToken)
+	if err != nil {
+		return nil, err
+	}
+	if since != nil && since.Horizon < syncTime(now.Add(-r.tombstoneRetention)) {
+		return nil, fmt.Errorf("validation failed: sinceToken has expired; sync again without one")
+	}
+
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		IndexName:              aws.String(r.syncIndex),
+		KeyConditionExpression: aws.String("syncAccount = :account"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":account": &types.AttributeValueMemberS{Value: accountID},
+		},
+	}
+	next := syncTokenData{Seen: map[string]string{}}
+	if since != nil {
+		next.From = since.From
+		for locationID, updatedAt := range since.Seen {
+			next.Seen[locationID] = updatedAt
+		}
+		if since.From != "" {
+			input.KeyConditionExpression = aws.String("syncAccount = :account AND updatedAt >= :from")
+			input.ExpressionAttributeValues[":from"] = &types.AttributeValueMemberS{Value: since.From}
+		}
+	}
+
+	result := &SyncResult{Changes: []SyncChange{}}
+	for {
+		input.Limit = aws.Int32(limit - int32(len(result.Changes)))
+		page, err := r.client.Query(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query sync index: %w", err)
+		}
+		for _, item := range page.Items {
+			var record locationRecord
+			if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal location: %w", err)
+			}
+			if next.Seen[record.SK] == record.UpdatedAt {
+				continue
+			}
+			next.Seen[record.SK] = record.UpdatedAt
+			change, ok, err := r.syncChange(ctx, &record, since)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				result.Changes = append(result.Changes, change)
+			}
+		}
+		if page.LastEvaluatedKey == nil {
+			break
+		}
+		input.ExclusiveStartKey = page.LastEvaluatedKey
+		if len(result.Changes) >= int(limit) {
+			result.HasMore = true
+			break
+		}
+	}
+
+	if since == nil && len(next.Seen) == 0 {
+		// Nothing was found, and an empty token syncs from scratch just the same
+		return result, nil
+	}
+	if err := next.advance(); err != nil {
+		return nil, err
+	}
+	next.Horizon = syncTime(now.Add(-syncOverlap))
+	if result.HasMore {
+		next.Horizon = next.From
+	}
+	if result.NextToken, err = encodeSyncToken(next); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// syncChange converts a sync index item, a location or a tombstone, to the
+// change it reports. It reports false for items a client need not see:
+// drafts, and deletions during a sync from scratch.
+func (r *DynamoDBRepository) syncChange(ctx context.Context, record *locationRecord, since *syncTokenData) (SyncChange, bool, error) {
+	changedAt, err := time.Parse(sortableTime, record.UpdatedAt)
+	if err != nil {
+		return SyncChange{}, false, fmt.Errorf("failed to parse updatedAt of location %s: %w", record.SK, err)
+	}
+	change := SyncChange{LocationID: record.SK, ChangedAt: changedAt}
+
+	if strings.HasPrefix(record.PK, syncDeletedPKPrefix) || record.MergedInto != "" {
+		change.Operation = SyncDeleted
+		return change, since != nil, nil
+	}
+	if record.Draft {
+		return SyncChange{}, false, nil
+	}
+
+	if err := r.hydrateRecord(ctx, record); err != nil {
+		return SyncChange{}, false, err
+	}
+	envelope, err := record.toEnvelope()
+	if err != nil {
+		return SyncChange{}, false, err
+	}
+	change.Location = envelope
+	change.Operation = SyncUpdated
+	if since == nil || createdAfter(record.SK, since.Horizon) {
+		change.Operation = SyncCreated
+	}
+	return change, true, nil
+}
+
+// createdAfter reports whether a location was created after horizon, going
+// by the creation time of its version 7 UUID. Older IDs carry none and are
+// reported as updated, which clients apply the same way.
+func createdAfter(locationID, horizon string) bool {
+	id, err := uuid.Parse(locationID)
+	if err != nil || id.Version() != 7 {
+		return false
+	}
+	sec, nsec := id.Time().UnixTime()
+	return syncTime(time.Unix(sec, nsec)) > horizon
+}
+
+// advance moves From to an overlap before the newest change seen, and
+// forgets the changes before it. When more than syncSeenLimit remain, From
+// moves on to the oldest of the most recent ones, so tokens stay small.
+func (t *syncTokenData) advance() error {
+	newest := t.From
+	for _, updatedAt := range t.Seen {
+		if updatedAt > newest {
+			newest = updatedAt
+		}
+	}
+	if newest == "" {
+		return nil
+	}
+	changedAt, err := time.Parse(sortableTime, newest)
+	if err != nil {
+		return fmt.Errorf("failed to parse sync position %q: %w", newest, err)
+	}
+	if from := syncTime(changedAt.Add(-syncOverlap)); from > t.From {
+		t.From = from
+	}
+
+	if len(t.Seen) > syncSeenLimit {
+		times := make([]string, 0, len(t.Seen))
+		for _, updatedAt := range t.Seen {
+			times = append(times, updatedAt)
+		}
+		sort.Strings(times)
+		if from := times[len(times)-syncSeenLimit]; from > t.From {
+			t.From = from
+		}
+	}
+	for locationID, updatedAt := range t.Seen {
+		if updatedAt < t.From {
+			delete(t.Seen, locationID)
+		}
+	}
+	return nil
+}
+
+// encodeSyncToken encodes a sync position to base64.
+func encodeSyncToken(token syncTokenData) (string, error) {
+	data, err := json.Marshal(token)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal sync token: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// decodeSyncToken decodes a base64 sync token, returning nil for an empty one.
+func decodeSyncToken(token string) (*syncTokenData, error) {
+	if token == "" {
+		return nil, nil
+	}
+
+	data, err := base64.StdEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("validation failed: sinceToken is not a sync token")
+	}
+	var decoded syncTokenData
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded.Horizon == "" {
+		return nil, fmt.Errorf("validation failed: sinceToken is not a sync token")
+	}
+	return &decoded, nil
+}
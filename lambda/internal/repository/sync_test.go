@@ -0,0 +1,284 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/google/uuid"
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyRecordPoliciesSetsSyncKeys(t *testing.T) {
+	repo := NewDynamoDBRepository(new(mockDynamoDBClient), "test-table")
+	record := &locationRecord{PK: "acc-12345", SK: "loc-001", LocationType: models.LocationTypeCoordinates,
+		Coordinates: &models.Coordinates{Latitude: 39.7, Longitude: -104.9}}
+
+	before := syncTime(time.Now())
+	require.NoError(t, repo.applyRecordPolicies(record))
+	assert.Equal(t, "acc-12345", record.SyncAccount)
+	assert.GreaterOrEqual(t, record.UpdatedAt, before)
+	assert.LessOrEqual(t, record.UpdatedAt, syncTime(time.Now()))
+}
+
+func TestDynamoDBRepositorySyncLocations(t *testing.T) {
+	syncItem := func(t *testing.T, record locationRecord) map[string]types.AttributeValue {
+		if record.PK == "" {
+			record.PK = "acc-12345"
+		}
+		record.SyncAccount = "acc-12345"
+		if record.LocationType == "" && record.MergedInto == "" {
+			record.LocationType = models.LocationTypeCoordinates
+			record.Coordinates = &models.Coordinates{Latitude: 39.7, Longitude: -104.9}
+		}
+		item, err := attributevalue.MarshalMap(record)
+		require.NoError(t, err)
+		return item
+	}
+	tombstoneItem := func(t *testing.T, locationID, updatedAt string) map[string]types.AttributeValue {
+		item, err := attributevalue.MarshalMap(syncTombstone{
+			PK: syncDeletedPKPrefix + "acc-12345", SK: locationID, SyncAccount: "acc-12345", UpdatedAt: updatedAt,
+		})
+		require.NoError(t, err)
+		return item
+	}
+	decode := func(t *testing.T, token string) *syncTokenData {
+		decoded, err := decodeSyncToken(token)
+		require.NoError(t, err)
+		require.NotNil(t, decoded)
+		return decoded
+	}
+
+	t.Run("Requires the sync index", func(t *testing.T) {
+		repo := NewDynamoDBRepository(new(mockDynamoDBClient), "test-table")
+		_, err := repo.SyncLocations(context.Background(), "acc-12345", "", 10)
+		assert.EqualError(t, err, "sync index is not configured")
+	})
+
+	t.Run("Syncs from scratch without deletions or drafts", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table", WithSyncIndex("sync-index", 0))
+
+		mockClient.On("Query", ctx, mock.MatchedBy(func(input *dynamodb.QueryInput) bool {
+			return aws.ToString(input.IndexName) == "sync-index" &&
+				aws.ToString(input.KeyConditionExpression) == "syncAccount = :account" &&
+				input.ExpressionAttributeValues[":account"].(*types.AttributeValueMemberS).Value == "acc-12345" &&
+				input.ExclusiveStartKey == nil && aws.ToInt32(input.Limit) == 10
+		})).Return(&dynamodb.QueryOutput{Items: []map[string]types.AttributeValue{
+			syncItem(t, locationRecord{SK: "loc-001", UpdatedAt: "2026-01-01T00:00:00.000000000Z"}),
+			syncItem(t, locationRecord{SK: "loc-002", UpdatedAt: "2026-01-02T00:00:00.000000000Z", Draft: true}),
+			tombstoneItem(t, "loc-003", "2026-01-03T00:00:00.000000000Z"),
+			syncItem(t, locationRecord{SK: "loc-004", UpdatedAt: "2026-01-04T00:00:00.000000000Z", MergedInto: "loc-001"}),
+		}}, nil).Once()
+
+		result, err := repo.SyncLocations(ctx, "acc-12345", "", 10)
+		require.NoError(t, err)
+		require.Len(t, result.Changes, 1)
+		assert.Equal(t, SyncCreated, result.Changes[0].Operation)
+		assert.Equal(t, "loc-001", result.Changes[0].LocationID)
+		assert.Equal(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), result.Changes[0].ChangedAt)
+		require.NotNil(t, result.Changes[0].Location)
+		assert.Equal(t, "acc-12345", result.Changes[0].Location.Location.GetAccountID())
+		assert.False(t, result.HasMore)
+
+		token := decode(t, result.NextToken)
+		assert.Equal(t, syncTokenData{
+			From:    "2026-01-03T23:59:00.000000000Z",
+			Seen:    map[string]string{"loc-004": "2026-01-04T00:00:00.000000000Z"},
+			Horizon: token.Horizon,
+		}, *token)
+		assert.Greater(t, token.Horizon, syncTime(time.Now().Add(-2*time.Minute)))
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Returns no token for an empty account", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table", WithSyncIndex("sync-index", 0))
+		mockClient.On("Query", ctx, mock.Anything).Return(&dynamodb.QueryOutput{}, nil).Once()
+
+		result, err := repo.SyncLocations(ctx, "acc-12345", "", 10)
+		require.NoError(t, err)
+		assert.Equal(t, &SyncResult{Changes: []SyncChange{}}, result)
+	})
+
+	t.Run("Reports changes since the token", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table", WithSyncIndex("sync-index", 0))
+
+		horizon := time.Now().Add(-time.Hour)
+		since, err := encodeSyncToken(syncTokenData{From: syncTime(horizon), Horizon: syncTime(horizon)})
+		require.NoError(t, err)
+		createdID := uuid.Must(uuid.NewV7()).String()
+
+		mockClient.On("Query", ctx, mock.MatchedBy(func(input *dynamodb.QueryInput) bool {
+			return aws.ToString(input.KeyConditionExpression) == "syncAccount = :account AND updatedAt >= :from" &&
+				input.ExpressionAttributeValues[":from"].(*types.AttributeValueMemberS).Value == syncTime(horizon) &&
+				input.ExclusiveStartKey == nil
+		})).Return(&dynamodb.QueryOutput{Items: []map[string]types.AttributeValue{
+			syncItem(t, locationRecord{SK: "loc-001", UpdatedAt: syncTime(horizon.Add(time.Minute))}),
+			syncItem(t, locationRecord{SK: createdID, UpdatedAt: syncTime(horizon.Add(2 * time.Minute))}),
+			tombstoneItem(t, "loc-003", syncTime(horizon.Add(3*time.Minute))),
+			syncItem(t, locationRecord{SK: "loc-004", UpdatedAt: syncTime(horizon.Add(4 * time.Minute)), MergedInto: "loc-001"}),
+		}}, nil).Once()
+
+		result, err := repo.SyncLocations(ctx, "acc-12345", since, 10)
+		require.NoError(t, err)
+		operations := map[string]SyncOperation{}
+		for _, change := range result.Changes {
+			operations[change.LocationID] = change.Operation
+		}
+		assert.Equal(t, map[string]SyncOperation{
+			"loc-001": SyncUpdated, createdID: SyncCreated, "loc-003": SyncDeleted, "loc-004": SyncDeleted,
+		}, operations)
+		assert.Nil(t, result.Changes[2].Location)
+		token := decode(t, result.NextToken)
+		assert.Equal(t, syncTime(horizon.Add(3*time.Minute)), token.From)
+		assert.Equal(t, map[string]string{
+			"loc-003": syncTime(horizon.Add(3 * time.Minute)),
+			"loc-004": syncTime(horizon.Add(4 * time.Minute)),
+		}, token.Seen)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Returns late writes and skips versions already returned", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table", WithSyncIndex("sync-index", 0))
+
+		from := time.Now().Add(-time.Hour)
+		since, err := encodeSyncToken(syncTokenData{
+			From:    syncTime(from),
+			Seen:    map[string]string{"loc-001": syncTime(from.Add(30 * time.Second)), "loc-002": syncTime(from.Add(40 * time.Second))},
+			Horizon: syncTime(from),
+		})
+		require.NoError(t, err)
+
+		// loc-000 reached the index after the last sync read past it, and
+		// loc-002 has changed again since
+		mockClient.On("Query", ctx, mock.Anything).Return(&dynamodb.QueryOutput{Items: []map[string]types.AttributeValue{
+			syncItem(t, locationRecord{SK: "loc-000", UpdatedAt: syncTime(from.Add(10 * time.Second))}),
+			syncItem(t, locationRecord{SK: "loc-001", UpdatedAt: syncTime(from.Add(30 * time.Second))}),
+			syncItem(t, locationRecord{SK: "loc-002", UpdatedAt: syncTime(from.Add(50 * time.Second))}),
+		}}, nil).Once()
+
+		result, err := repo.SyncLocations(ctx, "acc-12345", since, 10)
+		require.NoError(t, err)
+		require.Len(t, result.Changes, 2)
+		assert.Equal(t, "loc-000", result.Changes[0].LocationID)
+		assert.Equal(t, "loc-002", result.Changes[1].LocationID)
+		assert.Equal(t, syncTime(from), decode(t, result.NextToken).From)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Keeps the position and advances the horizon when nothing changed", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table", WithSyncIndex("sync-index", 0))
+		since := syncTokenData{From: syncTime(time.Now().Add(-time.Hour)), Horizon: syncTime(time.Now().Add(-time.Hour))}
+		sinceToken, err := encodeSyncToken(since)
+		require.NoError(t, err)
+		mockClient.On("Query", ctx, mock.Anything).Return(&dynamodb.QueryOutput{}, nil).Once()
+
+		result, err := repo.SyncLocations(ctx, "acc-12345", sinceToken, 10)
+		require.NoError(t, err)
+		assert.Empty(t, result.Changes)
+		token := decode(t, result.NextToken)
+		assert.Equal(t, since.From, token.From)
+		assert.Greater(t, token.Horizon, since.Horizon)
+	})
+
+	t.Run("Fills the page across queries and reports more", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table", WithSyncIndex("sync-index", 0))
+
+		lastKey := map[string]types.AttributeValue{"PK": &types.AttributeValueMemberS{Value: "acc-12345"}}
+		mockClient.On("Query", ctx, mock.MatchedBy(func(input *dynamodb.QueryInput) bool {
+			return input.ExclusiveStartKey == nil && aws.ToInt32(input.Limit) == 2
+		})).Return(&dynamodb.QueryOutput{Items: []map[string]types.AttributeValue{
+			syncItem(t, locationRecord{SK: "loc-001", UpdatedAt: "2026-01-01T00:00:00.000000000Z"}),
+			syncItem(t, locationRecord{SK: "loc-002", UpdatedAt: "2026-01-02T00:00:00.000000000Z", Draft: true}),
+		}, LastEvaluatedKey: lastKey}, nil).Once()
+		mockClient.On("Query", ctx, mock.MatchedBy(func(input *dynamodb.QueryInput) bool {
+			return input.ExclusiveStartKey != nil && aws.ToInt32(input.Limit) == 1
+		})).Return(&dynamodb.QueryOutput{Items: []map[string]types.AttributeValue{
+			syncItem(t, locationRecord{SK: "loc-003", UpdatedAt: "2026-01-03T00:00:00.000000000Z"}),
+		}, LastEvaluatedKey: lastKey}, nil).Once()
+
+		result, err := repo.SyncLocations(ctx, "acc-12345", "", 2)
+		require.NoError(t, err)
+		assert.Len(t, result.Changes, 2)
+		assert.True(t, result.HasMore)
+		token := decode(t, result.NextToken)
+		assert.Equal(t, "2026-01-02T23:59:00.000000000Z", token.From)
+		assert.Equal(t, token.From, token.Horizon)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Rejects expired and malformed tokens", func(t *testing.T) {
+		repo := NewDynamoDBRepository(new(mockDynamoDBClient), "test-table", WithSyncIndex("sync-index", 24*time.Hour))
+		expired, err := encodeSyncToken(syncTokenData{From: syncTime(time.Now().Add(-25 * time.Hour)), Horizon: syncTime(time.Now().Add(-25 * time.Hour))})
+		require.NoError(t, err)
+
+		_, err = repo.SyncLocations(context.Background(), "acc-12345", expired, 10)
+		assert.EqualError(t, err, "validation failed: sinceToken has expired; sync again without one")
+		_, err = repo.SyncLocations(context.Background(), "acc-12345", "not a token", 10)
+		assert.EqualError(t, err, "validation failed: sinceToken is not a sync token")
+	})
+}
+
+func TestSyncTokenAdvance(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Forgets changes before the overlap", func(t *testing.T) {
+		token := syncTokenData{Seen: map[string]string{
+			"loc-001": syncTime(start),
+			"loc-002": syncTime(start.Add(2 * time.Minute)),
+		}}
+		require.NoError(t, token.advance())
+		assert.Equal(t, syncTime(start.Add(time.Minute)), token.From)
+		assert.Equal(t, map[string]string{"loc-002": syncTime(start.Add(2 * time.Minute))}, token.Seen)
+	})
+
+	t.Run("Shrinks the overlap to the most recent changes", func(t *testing.T) {
+		token := syncTokenData{Seen: map[string]string{}}
+		for i := 0; i <= syncSeenLimit; i++ {
+			token.Seen[fmt.Sprintf("loc-%04d", i)] = syncTime(start.Add(time.Duration(i) * time.Millisecond))
+		}
+		require.NoError(t, token.advance())
+		assert.Len(t, token.Seen, syncSeenLimit)
+		assert.Equal(t, syncTime(start.Add(time.Millisecond)), token.From)
+		assert.NotContains(t, token.Seen, "loc-0000")
+	})
+}
+
+func TestDynamoDBRepositoryDeleteRecordsTombstone(t *testing.T) {
+	ctx := context.Background()
+	mockClient := new(mockDynamoDBClient)
+	repo := NewDynamoDBRepository(mockClient, "test-table", WithSyncIndex("sync-index", 24*time.Hour))
+
+	noMergeReferences(mockClient)
+	mockClient.On("DeleteItem", ctx, mock.Anything).Return(&dynamodb.DeleteItemOutput{}, nil).Once()
+	mockClient.On("PutItem", ctx, mock.MatchedBy(func(input *dynamodb.PutItemInput) bool {
+		var tombstone syncTombstone
+		if err := attributevalue.UnmarshalMap(input.Item, &tombstone); err != nil {
+			return false
+		}
+		return tombstone.PK == "SYNCDELETED#acc-12345" && tombstone.SK == "loc-001" &&
+			tombstone.SyncAccount == "acc-12345" && tombstone.ExpiresAt > time.Now().Add(23*time.Hour).Unix()
+	})).Return(&dynamodb.PutItemOutput{}, nil).Once()
+
+	require.NoError(t, repo.Delete(ctx, "acc-12345", "loc-001"))
+	mockClient.AssertExpectations(t)
+}
@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// templatePKPrefix keeps templates out of an account's location partition.
+const templatePKPrefix = "TEMPLATE#"
+
+// LocationTemplate is a named partial location payload stored per account.
+type LocationTemplate struct {
+	TemplateID string                 `json:"templateId" dynamodbav:"templateId"`
+	AccountID  string                 `json:"accountId" dynamodbav:"accountId"`
+	Name       string                 `json:"name" dynamodbav:"name"`
+	Payload    map[string]interface{} `json:"payload" dynamodbav:"payload"`
+	CreatedAt  time.Time              `json:"createdAt" dynamodbav:"createdAt"`
+}
+
+// TemplateListResult represents a page of location templates.
+type TemplateListResult struct {
+	Templates  []LocationTemplate `json:"templates"`
+	NextCursor *string            `json:"nextCursor,omitempty"`
+}
+
+// TemplateStore defines storage operations for location templates.
+type TemplateStore interface {
+	CreateTemplate(ctx context.Context, template LocationTemplate) (string, error)
+	GetTemplate(ctx context.Context, accountID, templateID string) (*LocationTemplate, error)
+	ListTemplates(ctx context.Context, accountID string, options *ListOptions) (*TemplateListResult, error)
+	DeleteTemplate(ctx context.Context, accountID, templateID string) error
+}
+
+// templateRecord is the DynamoDB item holding a location template.
+type templateRecord struct {
+	PK string `dynamodbav:"PK"` // TEMPLATE#accountId
+	SK string `dynamodbav:"SK"` // templateId
+	LocationTemplate
+}
+
+// templateKey returns the primary key of a template item.
+func templateKey(accountID, templateID string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberS{Value: templatePKPrefix + accountID},
+		"SK": &types.AttributeValueMemberS{Value: templateID},
+	}
+}
+
+// CreateTemplate stores a new template and returns its ID.
+func (r *DynamoDBRepository) CreateTemplate(ctx context.Context, template LocationTemplate) (string, error) {
+	if template.AccountID == "" {
+		return "", fmt.Errorf("validation failed: accountId is required")
+	}
+	if strings.TrimSpace(template.Name) == "" {
+		return "", fmt.Errorf("validation failed: name is required")
+	}
+	if len(template.Payload) == 0 {
+		return "", fmt.Errorf("validation failed: payload is required")
+	}
+
+	// The account comes from the template owner, never from the payload
+	payload := make(map[string]interface{}, len(template.Payload))
+	for key, value := range template.Payload {
+		if key != "accountId" {
+			payload[key] = value
+		}
+	}
+
+	template.TemplateID = r.ids.NewID()
+	template.Payload = payload
+	template.CreatedAt = r.now().UTC()
+
+	item, err := attributevalue.MarshalMap(templateRecord{
+		PK:               templatePKPrefix + template.AccountID,
+		SK:               template.TemplateID,
+		LocationTemplate: template,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal template: %w", err)
+	}
+
+	_, err = r.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(r.tableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(PK) AND attribute_not_exists(SK)"),
+	})
+	if err != nil {
+		var ccf *types.ConditionalCheckFailedException
+		if errors.As(err, &ccf) {
+			return "", fmt.Errorf("template already exists")
+		}
+		return "", fmt.Errorf("failed to create template: %w", err)
+	}
+
+	return template.TemplateID, nil
+}
+
+// GetTemplate retrieves a template by account ID and template ID.
+func (r *DynamoDBRepository) GetTemplate(ctx context.Context, accountID, templateID string) (*LocationTemplate, error) {
+	result, err := r.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(r.tableName),
+		Key:       templateKey(accountID, templateID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get template: %w", err)
+	}
+
+	if result.Item == nil {
+		return nil, fmt.Errorf("template not found")
+	}
+
+	var record templateRecord
+	if err := attributevalue.UnmarshalMap(result.Item, &record); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal template: %w", err)
+	}
+
+	return &record.LocationTemplate, nil
+}
+
+// ListTemplates lists an account's templates with cursor-based pagination.
+func (r *DynamoDBRepository) ListTemplates(ctx context.Context, accountID string, options *ListOptions) (*TemplateListResult, error) {
+	limit := r.defaultLimit
+	if options != nil && options.Limit != nil {
+		limit = *options.Limit
+	}
+
+	var cursor *paginationCursor
+	if options != nil && options.Cursor != nil {
+		var err error
+		cursor, err = r.decodeCursor(options.Cursor)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode cursor: %w", err)
+		}
+	}
+
+	result, err := r.client.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		KeyConditionExpression: aws.String("PK = :pk"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": &types.AttributeValueMemberS{Value: templatePKPrefix + accountID},
+		},
+		Limit:             aws.Int32(limit),
+		ExclusiveStartKey: r.cursorToLastEvaluatedKey(cursor),
+		ScanIndexForward:  aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list templates: %w", err)
+	}
+
+	templates := make([]LocationTemplate, 0, len(result.Items))
+	for _, item := range result.Items {
+		var record templateRecord
+		if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal template: %w", err)
+		}
+		templates = append(templates, record.LocationTemplate)
+	}
+
+	var nextCursor *string
+	if result.LastEvaluatedKey != nil {
+		nextCursor, err = r.encodeCursor(r.lastEvaluatedKeyToCursor(result.LastEvaluatedKey))
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode cursor: %w", err)
+		}
+	}
+
+	return &TemplateListResult{
+		Templates:  templates,
+		NextCursor: nextCursor,
+	}, nil
+}
+
+// DeleteTemplate deletes a template.
+func (r *DynamoDBRepository) DeleteTemplate(ctx context.Context, accountID, templateID string) error {
+	_, err := r.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName:           aws.String(r.tableName),
+		Key:                 templateKey(accountID, templateID),
+		ConditionExpression: aws.String("attribute_exists(PK) AND attribute_exists(SK)"),
+	})
+	if err != nil {
+		var ccf *types.ConditionalCheckFailedException
+		if errors.As(err, &ccf) {
+			return fmt.Errorf("template not found or access denied")
+		}
+		return fmt.Errorf("failed to delete template: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDynamoDBRepositoryCreateTemplate(t *testing.T) {
+	tests := []struct {
+		name          string
+		template      LocationTemplate
+		expectedError string
+	}{
+		{
+			name: "Valid template",
+			template: LocationTemplate{
+				AccountID: "acc-12345",
+				Name:      "Standard shop",
+				Payload: map[string]interface{}{
+					"accountId":    "acc-other",
+					"locationType": "shop",
+				},
+			},
+		},
+		{
+			name:          "Missing name",
+			template:      LocationTemplate{AccountID: "acc-12345", Payload: map[string]interface{}{"locationType": "shop"}},
+			expectedError: "validation failed: name is required",
+		},
+		{
+			name:          "Missing payload",
+			template:      LocationTemplate{AccountID: "acc-12345", Name: "Empty"},
+			expectedError: "validation failed: payload is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			mockClient := new(mockDynamoDBClient)
+			repo := NewDynamoDBRepository(mockClient, "test-table")
+
+			var stored map[string]types.AttributeValue
+			if tt.expectedError == "" {
+				mockClient.On("PutItem", ctx, mock.Anything).Run(func(args mock.Arguments) {
+					stored = args.Get(1).(*dynamodb.PutItemInput).Item
+				}).Return(&dynamodb.PutItemOutput{}, nil).Once()
+			}
+
+			templateID, err := repo.CreateTemplate(ctx, tt.template)
+
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotEmpty(t, templateID)
+			assert.Equal(t, "TEMPLATE#acc-12345", stored["PK"].(*types.AttributeValueMemberS).Value)
+			assert.Equal(t, templateID, stored["SK"].(*types.AttributeValueMemberS).Value)
+			payload := stored["payload"].(*types.AttributeValueMemberM).Value
+			assert.NotContains(t, payload, "accountId", "payload must not carry an account")
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDynamoDBRepositoryGetTemplate(t *testing.T) {
+	ctx := context.Background()
+	mockClient := new(mockDynamoDBClient)
+	repo := NewDynamoDBRepository(mockClient, "test-table")
+
+	mockClient.On("GetItem", ctx, mock.MatchedBy(func(input *dynamodb.GetItemInput) bool {
+		return input.Key["PK"].(*types.AttributeValueMemberS).Value == "TEMPLATE#acc-12345"
+	})).Return(&dynamodb.GetItemOutput{Item: map[string]types.AttributeValue{
+		"PK":         &types.AttributeValueMemberS{Value: "TEMPLATE#acc-12345"},
+		"SK":         &types.AttributeValueMemberS{Value: "tpl-001"},
+		"templateId": &types.AttributeValueMemberS{Value: "tpl-001"},
+		"accountId":  &types.AttributeValueMemberS{Value: "acc-12345"},
+		"name":       &types.AttributeValueMemberS{Value: "Standard shop"},
+		"payload": &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+			"locationType": &types.AttributeValueMemberS{Value: "shop"},
+		}},
+	}}, nil).Once()
+	mockClient.On("GetItem", ctx, mock.Anything).Return(&dynamodb.GetItemOutput{}, nil).Once()
+
+	template, err := repo.GetTemplate(ctx, "acc-12345", "tpl-001")
+	require.NoError(t, err)
+	assert.Equal(t, "Standard shop", template.Name)
+	assert.Equal(t, "shop", template.Payload["locationType"])
+
+	_, err = repo.GetTemplate(ctx, "acc-12345", "tpl-missing")
+	assert.EqualError(t, err, "template not found")
+	mockClient.AssertExpectations(t)
+}
+
+func TestDynamoDBRepositoryDeleteTemplate(t *testing.T) {
+	ctx := context.Background()
+	mockClient := new(mockDynamoDBClient)
+	repo := NewDynamoDBRepository(mockClient, "test-table")
+
+	mockClient.On("DeleteItem", ctx, mock.Anything).
+		Return(nil, &types.ConditionalCheckFailedException{Message: aws.String("The conditional request failed")}).Once()
+
+	err := repo.DeleteTemplate(ctx, "acc-12345", "tpl-001")
+	assert.EqualError(t, err, "template not found or access denied")
+	mockClient.AssertExpectations(t)
+}
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/steverhoton/location-lambda/internal/models"
+)
+
+// typeChangePKPrefix keeps type change records out of an account's location partition.
+const typeChangePKPrefix = "TYPECHANGE#"
+
+// TypeChanger converts a location into another type in place.
+type TypeChanger interface {
+	ChangeType(ctx context.Context, location models.Location, locationID string) (*TypeChange, error)
+}
+
+// LocationTypeError is returned when an update would change a location's type.
+// Type changes go through ChangeType, which applies the transition rules.
+type LocationTypeError struct {
+	Current   models.LocationType
+	Requested models.LocationType
+}
+
+// Error implements the error interface.
+func (e *LocationTypeError) Error() string {
+	return fmt.Sprintf("location is of type %s, not %s", e.Current, e.Requested)
+}
+
+// TypeChange records a location converted from one type to another.
+type TypeChange struct {
+	ChangeID   string              `json:"changeId" dynamodbav:"changeId"`
+	AccountID  string              `json:"accountId" dynamodbav:"accountId"`
+	LocationID string              `json:"locationId" dynamodbav:"locationId"`
+	FromType   models.LocationType `json:"fromType" dynamodbav:"fromType"`
+	ToType     models.LocationType `json:"toType" dynamodbav:"toType"`
+	ChangedAt  time.Time           `json:"changedAt" dynamodbav:"changedAt"`
+}
+
+// typeChangeRecord is the DynamoDB item recording a type change.
+type typeChangeRecord struct {
+	PK string `dynamodbav:"PK"` // TYPECHANGE#accountId
+	SK string `dynamodbav:"SK"` // locationId#changedAt, so a location's changes sort together
+	TypeChange
+}
+
+// ChangeType replaces a location with one of another type, keeping its ID,
+// account, external ID, and draft state. The transition must be allowed by
+// models.ValidateTypeTransition. The new record and a type change record are
+// written in one transaction, conditional on the stored type being unchanged
+// and, with a context from WithIfMatch, on the stored ETag.
+func (r *DynamoDBRepository) ChangeType(ctx context.Context, location models.Location, locationID string) (*TypeChange, error) {
+	stored, err := r.getRecord(ctx, location.GetAccountID(), locationID)
+	var merged *MergedError
+	if errors.Is(err, errLocationNotFound) || errors.As(err, &merged) {
+		return nil, fmt.Errorf("location not found or access denied")
+	}
+	if err != nil {
+		return nil, err
+	}
+	current, err := stored.toLocation()
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert record to location: %w", err)
+	}
+	if err := models.ValidateTypeTransition(current, location); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	if stored.Draft != location.IsDraft() {
+		return nil, draftStateError(stored.Draft)
+	}
+	if etag, ok := ifMatchFrom(ctx); ok && stored.ContentHash != etag {
+		return nil, &PreconditionFailedError{ETag: stored.ContentHash}
+	}
+
+	record, err := toLocationRecord(location, locationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert location to record: %w", err)
+	}
+	item, err := r.prepareRecord(ctx, record)
+	if err != nil {
+		return nil, err
+	}
+
+	change := &TypeChange{
+		ChangeID:   r.ids.NewID(),
+		AccountID:  location.GetAccountID(),
+		LocationID: locationID,
+		FromType:   current.GetLocationType(),
+		ToType:     location.GetLocationType(),
+		ChangedAt:  r.now().UTC(),
+	}
+	changeItem, err := attributevalue.MarshalMap(typeChangeRecord{
+		PK:         typeChangePKPrefix + change.AccountID,
+		SK:         locationID + "#" + change.ChangedAt.Format(time.RFC3339Nano),
+		TypeChange: *change,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal type change record: %w", err)
+	}
+
+	values := map[string]types.AttributeValue{
+		":accountId":    &types.AttributeValueMemberS{Value: r.locationPK(change.AccountID)},
+		":locationType": &types.AttributeValueMemberS{Value: string(change.FromType)},
+	}
+	_, err = r.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: []types.TransactWriteItem{
+		{Put: &types.Put{
+			TableName:                 aws.String(r.tableName),
+			Item:                      item,
+			ConditionExpression:       aws.String("attribute_exists(PK) AND PK = :accountId AND locationType = :locationType AND " + notMergedFilter + " AND " + sameExternalID(stored.ExternalID, values) + " AND " + sameDraftState(stored.Draft) + ifMatchCondition(ctx, values)),
+			ExpressionAttributeValues: values,
+		}},
+		{Put: &types.Put{
+			TableName: aws.String(r.tableName),
+			Item:      changeItem,
+		}},
+	}})
+	if err != nil {
+		r.deleteOverflow(ctx, newOverflowRef(stored.ExtendedAttributesRef, record.ExtendedAttributesRef))
+		var tce *types.TransactionCanceledException
+		if errors.As(err, &tce) {
+			return nil, fmt.Errorf("failed to change location type: location was modified concurrently")
+		}
+		return nil, fmt.Errorf("failed to change location type: %w", err)
+	}
+
+	r.replaceOverflow(ctx, stored.ExtendedAttributesRef, record.ExtendedAttributesRef)
+
+	return change, nil
+}
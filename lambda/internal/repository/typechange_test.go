@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDynamoDBRepositoryChangeType(t *testing.T) {
+	address := models.Address{StreetAddress: "123 Main St", City: "Springfield", PostalCode: "62704", Country: "US"}
+	addressItem := func(t *testing.T) map[string]types.AttributeValue {
+		item, err := attributevalue.MarshalMap(locationRecord{
+			PK:           "acc-12345",
+			SK:           "loc-001",
+			LocationType: models.LocationTypeAddress,
+			Address:      &address,
+		})
+		require.NoError(t, err)
+		return item
+	}
+	shop := models.ShopLocation{
+		LocationBase: models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeShop},
+		Shop:         models.Shop{Name: "Main Street Store", ContactID: "contact-1", Address: address},
+	}
+
+	t.Run("Converts the location and records the change in one transaction", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		mockClient.On("GetItem", ctx, matchGet("loc-001")).Return(&dynamodb.GetItemOutput{Item: addressItem(t)}, nil).Once()
+		var transaction *dynamodb.TransactWriteItemsInput
+		mockClient.On("TransactWriteItems", ctx, mock.Anything).Run(func(args mock.Arguments) {
+			transaction = args.Get(1).(*dynamodb.TransactWriteItemsInput)
+		}).Return(&dynamodb.TransactWriteItemsOutput{}, nil).Once()
+
+		change, err := repo.ChangeType(ctx, shop, "loc-001")
+		require.NoError(t, err)
+		assert.NotEmpty(t, change.ChangeID)
+		assert.Equal(t, "loc-001", change.LocationID)
+		assert.Equal(t, models.LocationTypeAddress, change.FromType)
+		assert.Equal(t, models.LocationTypeShop, change.ToType)
+
+		require.Len(t, transaction.TransactItems, 2)
+		put := transaction.TransactItems[0].Put
+		var record locationRecord
+		require.NoError(t, attributevalue.UnmarshalMap(put.Item, &record))
+		assert.Equal(t, "loc-001", record.SK)
+		assert.Equal(t, models.LocationTypeShop, record.LocationType)
+		assert.Contains(t, aws.ToString(put.ConditionExpression), "locationType = :locationType")
+		assert.Equal(t, "address", put.ExpressionAttributeValues[":locationType"].(*types.AttributeValueMemberS).Value)
+
+		changeItem := transaction.TransactItems[1].Put.Item
+		assert.Equal(t, "TYPECHANGE#acc-12345", changeItem["PK"].(*types.AttributeValueMemberS).Value)
+		assert.Contains(t, changeItem["SK"].(*types.AttributeValueMemberS).Value, "loc-001#")
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Rejects transitions the rules do not allow", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		mockClient.On("GetItem", ctx, matchGet("loc-001")).Return(&dynamodb.GetItemOutput{Item: coordinatesItem("acc-12345", "loc-001")}, nil).Once()
+
+		_, err := repo.ChangeType(ctx, shop, "loc-001")
+		assert.EqualError(t, err, "validation failed: locationType cannot change from coordinates to shop")
+		mockClient.AssertNotCalled(t, "TransactWriteItems", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Fails for unknown locations", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		mockClient.On("GetItem", ctx, matchGet("loc-001")).Return(&dynamodb.GetItemOutput{}, nil).Once()
+
+		_, err := repo.ChangeType(ctx, shop, "loc-001")
+		assert.EqualError(t, err, "location not found or access denied")
+	})
+
+	t.Run("Reports concurrent modification", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		mockClient.On("GetItem", ctx, matchGet("loc-001")).Return(&dynamodb.GetItemOutput{Item: addressItem(t)}, nil).Once()
+		mockClient.On("TransactWriteItems", ctx, mock.Anything).Return(nil, &types.TransactionCanceledException{}).Once()
+
+		_, err := repo.ChangeType(ctx, shop, "loc-001")
+		assert.EqualError(t, err, "failed to change location type: location was modified concurrently")
+	})
+}
@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	// usagePKPrefix keeps usage counters out of an account's location partition.
+	usagePKPrefix = "USAGE#"
+	// usageOperationPrefix starts the counter attribute of each operation in a
+	// usage item, keeping operation names clear of the item's own attributes.
+	usageOperationPrefix = "op#"
+	// usageDayLayout is the sort key of a usage item, one per UTC day.
+	usageDayLayout = "2006-01-02"
+	// usageMonthLayout is a period covering a calendar month.
+	usageMonthLayout = "2006-01"
+)
+
+// UsageMeter counts each account's operations per day for billing and plan
+// enforcement.
+type UsageMeter interface {
+	// RecordUsage counts one call of an operation by an account at the given time.
+	RecordUsage(ctx context.Context, accountID, operation string, at time.Time) error
+	// GetUsage returns an account's operation counts over a period, a UTC day
+	// such as 2024-06-01 or a calendar month such as 2024-06.
+	GetUsage(ctx context.Context, accountID, period string) (*Usage, error)
+}
+
+// Usage is an account's operation counts over a period.
+type Usage struct {
+	AccountID  string           `json:"accountId"`
+	Period     string           `json:"period"`
+	Total      int64            `json:"total"`
+	Operations []OperationUsage `json:"operations"` // Sorted by operation
+	Days       []DailyUsage     `json:"days"`       // Days with any usage, in order
+}
+
+// OperationUsage is how many times an operation was called.
+type OperationUsage struct {
+	Operation string `json:"operation"`
+	Count     int64  `json:"count"`
+}
+
+// DailyUsage is an account's operation count on one UTC day.
+type DailyUsage struct {
+	Date  string `json:"date"`
+	Total int64  `json:"total"`
+}
+
+// ValidateUsagePeriod checks that period is a day such as 2024-06-01 or a
+// month such as 2024-06.
+func ValidateUsagePeriod(period string) error {
+	if _, err := time.Parse(usageDayLayout, period); err == nil {
+		return nil
+	}
+	if _, err := time.Parse(usageMonthLayout, period); err == nil {
+		return nil
+	}
+	return fmt.Errorf("validation failed: period must be a day (YYYY-MM-DD) or a month (YYYY-MM), got %q", period)
+}
+
+// RecordUsage atomically increments the operation's counter and the day's
+// total in the account's usage item for the day, creating it on first use.
+func (r *DynamoDBRepository) RecordUsage(ctx context.Context, accountID, operation string, at time.Time) error {
+	_, err := r.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(r.tableName),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: usagePKPrefix + accountID},
+			"SK": &types.AttributeValueMemberS{Value: at.UTC().Format(usageDayLayout)},
+		},
+		UpdateExpression: aws.String("ADD #operation :one, #total :one"),
+		ExpressionAttributeNames: map[string]string{
+			"#operation": usageOperationPrefix + operation,
+			"#total":     "total",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":one": &types.AttributeValueMemberN{Value: "1"},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record usage: %w", err)
+	}
+	return nil
+}
+
+// GetUsage sums the account's usage items in the period.
+func (r *DynamoDBRepository) GetUsage(ctx context.Context, accountID, period string) (*Usage, error) {
+	if err := ValidateUsagePeriod(period); err != nil {
+		return nil, err
+	}
+
+	usage := &Usage{AccountID: accountID, Period: period, Operations: []OperationUsage{}, Days: []DailyUsage{}}
+	operations := map[string]int64{}
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		KeyConditionExpression: aws.String("PK = :pk AND begins_with(SK, :period)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk":     &types.AttributeValueMemberS{Value: usagePKPrefix + accountID},
+			":period": &types.AttributeValueMemberS{Value: period},
+		},
+	}
+	for {
+		result, err := r.client.Query(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get usage: %w", err)
+		}
+		for _, item := range result.Items {
+			day := DailyUsage{}
+			for name, value := range item {
+				switch {
+				case name == "SK":
+					if s, ok := value.(*types.AttributeValueMemberS); ok {
+						day.Date = s.Value
+					}
+				case name == "total":
+					day.Total = usageCount(value)
+				case strings.HasPrefix(name, usageOperationPrefix):
+					operations[strings.TrimPrefix(name, usageOperationPrefix)] += usageCount(value)
+				}
+			}
+			usage.Total += day.Total
+			usage.Days = append(usage.Days, day)
+		}
+		if len(result.LastEvaluatedKey) == 0 {
+			break
+		}
+		input.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+
+	for operation, count := range operations {
+		usage.Operations = append(usage.Operations, OperationUsage{Operation: operation, Count: count})
+	}
+	sort.Slice(usage.Operations, func(i, j int) bool {
+		return usage.Operations[i].Operation < usage.Operations[j].Operation
+	})
+	return usage, nil
+}
+
+// usageCount reads a counter attribute, treating anything else as zero.
+func usageCount(value types.AttributeValue) int64 {
+	n, ok := value.(*types.AttributeValueMemberN)
+	if !ok {
+		return 0
+	}
+	count, _ := strconv.ParseInt(n.Value, 10, 64)
+	return count
+}
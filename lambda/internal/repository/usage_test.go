@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordUsage(t *testing.T) {
+	ctx := context.Background()
+	mockClient := new(mockDynamoDBClient)
+	repo := NewDynamoDBRepository(mockClient, "test-table")
+
+	mockClient.On("UpdateItem", ctx, mock.MatchedBy(func(input *dynamodb.UpdateItemInput) bool {
+		return input.Key["PK"].(*types.AttributeValueMemberS).Value == "USAGE#acc-12345" &&
+			input.Key["SK"].(*types.AttributeValueMemberS).Value == "2024-06-01" &&
+			aws.ToString(input.UpdateExpression) == "ADD #operation :one, #total :one" &&
+			input.ExpressionAttributeNames["#operation"] == "op#getLocation"
+	})).Return(&dynamodb.UpdateItemOutput{}, nil).Once()
+
+	// 23:30 in New York is the next UTC day
+	at := time.Date(2024, 5, 31, 23, 30, 0, 0, time.FixedZone("EDT", -4*60*60))
+	require.NoError(t, repo.RecordUsage(ctx, "acc-12345", "getLocation", at))
+	mockClient.AssertExpectations(t)
+}
+
+func TestGetUsage(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Sums a month's days", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+		day := func(date, total string, operations map[string]string) map[string]types.AttributeValue {
+			item := map[string]types.AttributeValue{
+				"PK":    &types.AttributeValueMemberS{Value: "USAGE#acc-12345"},
+				"SK":    &types.AttributeValueMemberS{Value: date},
+				"total": &types.AttributeValueMemberN{Value: total},
+			}
+			for operation, count := range operations {
+				item["op#"+operation] = &types.AttributeValueMemberN{Value: count}
+			}
+			return item
+		}
+		mockClient.On("Query", ctx, mock.MatchedBy(func(input *dynamodb.QueryInput) bool {
+			return input.ExclusiveStartKey == nil &&
+				input.ExpressionAttributeValues[":period"].(*types.AttributeValueMemberS).Value == "2024-06"
+		})).Return(&dynamodb.QueryOutput{
+			Items:            []map[string]types.AttributeValue{day("2024-06-01", "5", map[string]string{"getLocation": "4", "updateLocation": "1"})},
+			LastEvaluatedKey: map[string]types.AttributeValue{"PK": &types.AttributeValueMemberS{Value: "USAGE#acc-12345"}},
+		}, nil).Once()
+		mockClient.On("Query", ctx, mock.MatchedBy(func(input *dynamodb.QueryInput) bool {
+			return input.ExclusiveStartKey != nil
+		})).Return(&dynamodb.QueryOutput{
+			Items: []map[string]types.AttributeValue{day("2024-06-02", "2", map[string]string{"getLocation": "2"})},
+		}, nil).Once()
+
+		usage, err := repo.GetUsage(ctx, "acc-12345", "2024-06")
+		require.NoError(t, err)
+		assert.Equal(t, &Usage{
+			AccountID: "acc-12345",
+			Period:    "2024-06",
+			Total:     7,
+			Operations: []OperationUsage{
+				{Operation: "getLocation", Count: 6},
+				{Operation: "updateLocation", Count: 1},
+			},
+			Days: []DailyUsage{{Date: "2024-06-01", Total: 5}, {Date: "2024-06-02", Total: 2}},
+		}, usage)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Rejects an invalid period", func(t *testing.T) {
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table")
+
+		_, err := repo.GetUsage(ctx, "acc-12345", "2024")
+		assert.EqualError(t, err, `validation failed: period must be a day (YYYY-MM-DD) or a month (YYYY-MM), got "2024"`)
+		mockClient.AssertNotCalled(t, "Query", mock.Anything, mock.Anything)
+	})
+}
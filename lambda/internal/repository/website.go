@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/steverhoton/location-lambda/internal/models"
+)
+
+// maxWebsiteMatches caps the shops FindByWebsite returns.
+const maxWebsiteMatches = 100
+
+// WebsiteFinder finds an account's shops by their website.
+type WebsiteFinder interface {
+	FindByWebsite(ctx context.Context, accountID, website string) ([]LocationEnvelope, error)
+}
+
+// WithWebsiteIndex enables FindByWebsite with the sparse GSI keyed on accountWebsite.
+func WithWebsiteIndex(indexName string) Option {
+	return func(r *DynamoDBRepository) {
+		r.websiteIndex = indexName
+	}
+}
+
+// websiteIndexKey returns the website index key of a record, or an empty
+// string when it is not a shop with a website, keeping the index sparse.
+func websiteIndexKey(record *locationRecord) string {
+	if record.Shop == nil {
+		return ""
+	}
+	host := models.WebsiteHost(record.Shop.WebsiteURL)
+	if host == "" {
+		return ""
+	}
+	return accountIndexKey(record.PK, host)
+}
+
+// FindByWebsite returns the account's published, active shops whose website
+// is on the same host as website, ignoring a leading "www.", so any page of a
+// shop's site finds it. At most 100 shops are returned. The index is
+// eventually consistent; see WithIndexFallback for when it can't be read.
+func (r *DynamoDBRepository) FindByWebsite(ctx context.Context, accountID, website string) ([]LocationEnvelope, error) {
+	if r.websiteIndex == "" {
+		return nil, fmt.Errorf("website index is not configured")
+	}
+	host := models.WebsiteHost(website)
+	if accountID == "" || host == "" {
+		return nil, fmt.Errorf("validation failed: accountId and an http or https website are required")
+	}
+
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		IndexName:              aws.String(r.websiteIndex),
+		KeyConditionExpression: aws.String("accountWebsite = :key"),
+		FilterExpression:       aws.String(notMergedFilter + " AND " + listedFilter),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":key": &types.AttributeValueMemberS{Value: accountIndexKey(accountID, host)},
+			":now": r.nowValue(),
+		},
+	}
+
+	locations := []LocationEnvelope{}
+	for {
+		result, err := r.client.Query(ctx, input)
+		if err != nil && r.indexUnavailable(err) {
+			found, err := r.scanFallback(ctx, "accountWebsite = :key AND "+aws.ToString(input.FilterExpression), input.ExpressionAttributeValues, maxWebsiteMatches)
+			if err != nil {
+				return nil, err
+			}
+			return ownLocations(accountID, found), nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to query website index: %w", err)
+		}
+		for _, item := range result.Items {
+			var record locationRecord
+			if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal location: %w", err)
+			}
+			if record.PK != accountID {
+				continue
+			}
+			if err := r.hydrateRecord(ctx, &record); err != nil {
+				return nil, err
+			}
+			envelope, err := record.toEnvelope()
+			if err != nil {
+				return nil, err
+			}
+			locations = append(locations, *envelope)
+			if len(locations) == maxWebsiteMatches {
+				return locations, nil
+			}
+		}
+		if result.LastEvaluatedKey == nil {
+			return locations, nil
+		}
+		input.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebsiteIndexKey(t *testing.T) {
+	shop := func(accountID, website string) models.ShopLocation {
+		return models.ShopLocation{
+			LocationBase: models.LocationBase{AccountID: accountID, LocationType: models.LocationTypeShop},
+			Shop: models.Shop{
+				Name:       "Main Street Store",
+				ContactID:  "contact-1",
+				Address:    models.Address{StreetAddress: "123 Main St", City: "Springfield", PostalCode: "62704", Country: "US"},
+				WebsiteURL: website,
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		location models.Location
+		want     string
+	}{
+		{name: "Shop with a website", location: shop("acc-12345", "https://www.Example.com/stores/springfield"), want: "acc-12345#example.com"},
+		{name: "Account ID with a separator", location: shop("acc#12345", "https://example.com/"), want: "acc%2312345#example.com"},
+		{name: "Shop without a website", location: shop("acc-12345", "")},
+		{name: "Address location", location: models.AddressLocation{
+			LocationBase: models.LocationBase{AccountID: "acc-12345", LocationType: models.LocationTypeAddress},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewDynamoDBRepository(new(mockDynamoDBClient), "test-table")
+			record, err := toLocationRecord(tt.location, "loc-001")
+			require.NoError(t, err)
+
+			item, err := repo.prepareRecord(context.Background(), record)
+			require.NoError(t, err)
+			if tt.want == "" {
+				assert.NotContains(t, item, "accountWebsite")
+				return
+			}
+			assert.Equal(t, tt.want, item["accountWebsite"].(*types.AttributeValueMemberS).Value)
+		})
+	}
+}
+
+func TestDynamoDBRepositoryFindByWebsite(t *testing.T) {
+	shopItem := func(t *testing.T, accountID, locationID string) map[string]types.AttributeValue {
+		item, err := attributevalue.MarshalMap(locationRecord{
+			PK:           accountID,
+			SK:           locationID,
+			LocationType: models.LocationTypeShop,
+			Shop: &shopAttribute{
+				Name:       "Main Street Store",
+				ContactID:  "contact-1",
+				Address:    models.Address{StreetAddress: "123 Main St", City: "Springfield", PostalCode: "62704", Country: "US"},
+				WebsiteURL: "https://example.com/",
+			},
+		})
+		require.NoError(t, err)
+		return item
+	}
+
+	t.Run("Queries the index by account and host", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table", WithWebsiteIndex("website-index"))
+
+		mockClient.On("Query", ctx, mock.MatchedBy(func(input *dynamodb.QueryInput) bool {
+			return aws.ToString(input.IndexName) == "website-index" &&
+				input.ExpressionAttributeValues[":key"].(*types.AttributeValueMemberS).Value == "acc-12345#example.com" &&
+				aws.ToString(input.FilterExpression) == notMergedFilter+" AND "+listedFilter
+		})).Return(&dynamodb.QueryOutput{Items: []map[string]types.AttributeValue{
+			shopItem(t, "acc-12345", "loc-001"), shopItem(t, "acc-12345", "loc-002"),
+		}}, nil).Once()
+
+		locations, err := repo.FindByWebsite(ctx, "acc-12345", "http://WWW.example.com/contact?utm_source=x")
+		require.NoError(t, err)
+		require.Len(t, locations, 2)
+		assert.Equal(t, "loc-001", locations[0].LocationID)
+		assert.Equal(t, "https://example.com/", locations[0].Location.(models.ShopLocation).Shop.WebsiteURL)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Skips other accounts' shops", func(t *testing.T) {
+		ctx := context.Background()
+		mockClient := new(mockDynamoDBClient)
+		repo := NewDynamoDBRepository(mockClient, "test-table", WithWebsiteIndex("website-index"))
+
+		// An unescaped key written by account acc-12345#x can't name acc-12345's shops
+		mockClient.On("Query", ctx, mock.Anything).Return(&dynamodb.QueryOutput{Items: []map[string]types.AttributeValue{
+			shopItem(t, "acc-12345#x", "loc-666"), shopItem(t, "acc-12345", "loc-001"),
+		}}, nil).Once()
+
+		locations, err := repo.FindByWebsite(ctx, "acc-12345", "https://example.com/")
+		require.NoError(t, err)
+		require.Len(t, locations, 1)
+		assert.Equal(t, "loc-001", locations[0].LocationID)
+	})
+
+	t.Run("Rejects websites without a host", func(t *testing.T) {
+		repo := NewDynamoDBRepository(new(mockDynamoDBClient), "test-table", WithWebsiteIndex("website-index"))
+
+		_, err := repo.FindByWebsite(context.Background(), "acc-12345", "example.com")
+		assert.EqualError(t, err, "validation failed: accountId and an http or https website are required")
+	})
+
+	t.Run("Requires the index", func(t *testing.T) {
+		repo := NewDynamoDBRepository(new(mockDynamoDBClient), "test-table")
+
+		_, err := repo.FindByWebsite(context.Background(), "acc-12345", "https://example.com")
+		assert.EqualError(t, err, "website index is not configured")
+	})
+}
@@ -0,0 +1,119 @@
+package routing
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/steverhoton/location-lambda/internal/models"
+)
+
+// HTTPClient is the subset of http.Client used by the routing providers.
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// amazonTravelModes maps travel modes to the Routes API's.
+var amazonTravelModes = map[string]string{
+	ModeCar:        "Car",
+	ModeTruck:      "Truck",
+	ModeScooter:    "Scooter",
+	ModePedestrian: "Pedestrian",
+}
+
+// AmazonRoutesProvider calculates routes with the Amazon Location Service
+// Routes v2 API, which needs no route calculator resource.
+type AmazonRoutesProvider struct {
+	httpClient  HTTPClient
+	signer      *v4.Signer
+	credentials aws.CredentialsProvider
+	region      string
+	endpoint    string
+}
+
+// NewAmazonRoutesProvider creates a provider in the configured region.
+func NewAmazonRoutesProvider(cfg aws.Config) *AmazonRoutesProvider {
+	return &AmazonRoutesProvider{
+		httpClient:  http.DefaultClient,
+		signer:      v4.NewSigner(),
+		credentials: cfg.Credentials,
+		region:      cfg.Region,
+		endpoint:    fmt.Sprintf("https://routes.geo.%s.amazonaws.com", cfg.Region),
+	}
+}
+
+// amazonCalculateRoutesRequest is the CalculateRoutes request body.
+type amazonCalculateRoutesRequest struct {
+	Origin      []float64 `json:"Origin"`      // [longitude, latitude]
+	Destination []float64 `json:"Destination"` // [longitude, latitude]
+	TravelMode  string    `json:"TravelMode"`
+}
+
+// amazonCalculateRoutesResponse is the subset of the CalculateRoutes response used here.
+type amazonCalculateRoutesResponse struct {
+	Routes []struct {
+		Summary struct {
+			Distance float64 `json:"Distance"` // meters
+			Duration float64 `json:"Duration"` // seconds
+		} `json:"Summary"`
+	} `json:"Routes"`
+}
+
+// Route calculates the fastest route from one point to another, departing now.
+func (p *AmazonRoutesProvider) Route(ctx context.Context, from, to models.Coordinates, mode string) (*Route, error) {
+	body, err := json.Marshal(amazonCalculateRoutesRequest{
+		Origin:      []float64{from.Longitude, from.Latitude},
+		Destination: []float64{to.Longitude, to.Latitude},
+		TravelMode:  amazonTravelModes[mode],
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal routes request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint+"/v2/routes", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build routes request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	creds, err := p.credentials.Retrieve(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve credentials: %w", err)
+	}
+	hash := sha256.Sum256(body)
+	if err := p.signer.SignHTTP(ctx, creds, req, hex.EncodeToString(hash[:]), "geo-routes", p.region, time.Now()); err != nil {
+		return nil, fmt.Errorf("failed to sign routes request: %w", err)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate route: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read routes response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("routes request failed with status %d: %s", resp.StatusCode, respBody)
+	}
+
+	var calculated amazonCalculateRoutesResponse
+	if err := json.Unmarshal(respBody, &calculated); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal routes response: %w", err)
+	}
+	if len(calculated.Routes) == 0 {
+		return nil, ErrNoRoute
+	}
+	summary := calculated.Routes[0].Summary
+	return &Route{DurationSeconds: summary.Duration, DistanceMeters: summary.Distance}, nil
+}
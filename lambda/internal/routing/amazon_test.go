@@ -0,0 +1,70 @@
+package routing
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestAmazonProvider(t *testing.T, handler http.HandlerFunc) *AmazonRoutesProvider {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	p := NewAmazonRoutesProvider(aws.Config{
+		Region:      "us-west-2",
+		Credentials: credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""),
+	})
+	p.endpoint = server.URL
+	return p
+}
+
+func TestAmazonRoutesProvider(t *testing.T) {
+	from := models.Coordinates{Latitude: 39.6801, Longitude: -105.0202}
+	to := models.Coordinates{Latitude: 39.7392, Longitude: -104.9903}
+
+	t.Run("Returns the route summary", func(t *testing.T) {
+		p := newTestAmazonProvider(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/v2/routes", r.URL.Path)
+			assert.True(t, strings.Contains(r.Header.Get("Authorization"), "/geo-routes/aws4_request"))
+			var req amazonCalculateRoutesRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			assert.Equal(t, []float64{-105.0202, 39.6801}, req.Origin)
+			assert.Equal(t, []float64{-104.9903, 39.7392}, req.Destination)
+			assert.Equal(t, "Truck", req.TravelMode)
+			_, _ = w.Write([]byte(`{"Routes":[{"Summary":{"Distance":8412,"Duration":731}}]}`))
+		})
+
+		route, err := p.Route(context.Background(), from, to, ModeTruck)
+		require.NoError(t, err)
+		assert.Equal(t, &Route{DurationSeconds: 731, DistanceMeters: 8412}, route)
+	})
+
+	t.Run("No route", func(t *testing.T) {
+		p := newTestAmazonProvider(t, func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"Routes":[]}`))
+		})
+
+		_, err := p.Route(context.Background(), from, to, ModeCar)
+		assert.ErrorIs(t, err, ErrNoRoute)
+	})
+
+	t.Run("Provider error", func(t *testing.T) {
+		p := newTestAmazonProvider(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"message":"denied"}`))
+		})
+
+		_, err := p.Route(context.Background(), from, to, ModeCar)
+		assert.EqualError(t, err, `routes request failed with status 403: {"message":"denied"}`)
+	})
+}
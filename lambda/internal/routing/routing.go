@@ -0,0 +1,108 @@
+// Package routing estimates travel time and distance between coordinates.
+package routing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+)
+
+// Travel modes.
+const (
+	ModeCar        = "car"
+	ModeTruck      = "truck"
+	ModeScooter    = "scooter"
+	ModePedestrian = "pedestrian"
+)
+
+// ErrNoRoute is returned when the provider finds no route between two points.
+var ErrNoRoute = errors.New("no route found")
+
+// Route is the provider's fastest route between two points.
+type Route struct {
+	DurationSeconds float64 `json:"durationSeconds"`
+	DistanceMeters  float64 `json:"distanceMeters"`
+}
+
+// Provider calculates routes.
+type Provider interface {
+	Route(ctx context.Context, from, to models.Coordinates, mode string) (*Route, error)
+}
+
+// ValidateMode checks that mode is one of the travel modes.
+func ValidateMode(mode string) error {
+	switch mode {
+	case ModeCar, ModeTruck, ModeScooter, ModePedestrian:
+		return nil
+	}
+	return fmt.Errorf("mode must be one of %s, %s, %s, or %s", ModeCar, ModeTruck, ModeScooter, ModePedestrian)
+}
+
+// cacheEntry is a cached route and when it expires.
+type cacheEntry struct {
+	route   Route
+	expires time.Time
+}
+
+// CachingProvider caches another provider's routes. Origins are rounded to
+// about 100 meters, since a dispatch screen asks again as a vehicle creeps
+// along, and destinations to about 10 meters. Keep the TTL short: routes
+// follow traffic.
+type CachingProvider struct {
+	provider Provider
+	ttl      time.Duration
+	now      func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+// NewCachingProvider wraps provider with a cache of the given TTL.
+func NewCachingProvider(provider Provider, ttl time.Duration) *CachingProvider {
+	return &CachingProvider{
+		provider: provider,
+		ttl:      ttl,
+		now:      time.Now,
+		entries:  make(map[string]cacheEntry),
+	}
+}
+
+// Route returns a cached route when fresh, otherwise queries the provider.
+func (c *CachingProvider) Route(ctx context.Context, from, to models.Coordinates, mode string) (*Route, error) {
+	key := fmt.Sprintf("%s|%s|%s", round(from, 3), round(to, 4), mode)
+	now := c.now()
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && now.Before(entry.expires) {
+		route := entry.route
+		return &route, nil
+	}
+
+	route, err := c.provider.Route(ctx, from, to, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k, e := range c.entries {
+		if !now.Before(e.expires) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = cacheEntry{route: *route, expires: now.Add(c.ttl)}
+	return route, nil
+}
+
+// round formats coordinates rounded to places decimal places.
+func round(coords models.Coordinates, places int) string {
+	scale := math.Pow(10, float64(places))
+	return fmt.Sprintf("%.*f,%.*f", places, math.Round(coords.Latitude*scale)/scale, places, math.Round(coords.Longitude*scale)/scale)
+}
@@ -0,0 +1,71 @@
+package routing
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingProvider returns a fixed route and counts calls.
+type countingProvider struct {
+	calls int
+	err   error
+}
+
+func (p *countingProvider) Route(ctx context.Context, from, to models.Coordinates, mode string) (*Route, error) {
+	p.calls++
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &Route{DurationSeconds: 720, DistanceMeters: 8400}, nil
+}
+
+func TestValidateMode(t *testing.T) {
+	assert.NoError(t, ValidateMode(ModeTruck))
+	assert.EqualError(t, ValidateMode("bicycle"), "mode must be one of car, truck, scooter, or pedestrian")
+}
+
+func TestCachingProvider(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	depot := models.Coordinates{Latitude: 39.7392, Longitude: -104.9903}
+
+	t.Run("Serves nearby origins from cache until the TTL expires", func(t *testing.T) {
+		inner := &countingProvider{}
+		cache := NewCachingProvider(inner, 5*time.Minute)
+		cache.now = func() time.Time { return now }
+
+		route, err := cache.Route(ctx, models.Coordinates{Latitude: 39.6801, Longitude: -105.0202}, depot, ModeCar)
+		require.NoError(t, err)
+		assert.Equal(t, &Route{DurationSeconds: 720, DistanceMeters: 8400}, route)
+
+		_, err = cache.Route(ctx, models.Coordinates{Latitude: 39.6803, Longitude: -105.0198}, depot, ModeCar)
+		require.NoError(t, err)
+		assert.Equal(t, 1, inner.calls)
+
+		_, err = cache.Route(ctx, models.Coordinates{Latitude: 39.6803, Longitude: -105.0198}, depot, ModeTruck)
+		require.NoError(t, err)
+		assert.Equal(t, 2, inner.calls)
+
+		cache.now = func() time.Time { return now.Add(6 * time.Minute) }
+		_, err = cache.Route(ctx, models.Coordinates{Latitude: 39.6801, Longitude: -105.0202}, depot, ModeCar)
+		require.NoError(t, err)
+		assert.Equal(t, 3, inner.calls)
+	})
+
+	t.Run("Errors are not cached", func(t *testing.T) {
+		inner := &countingProvider{err: errors.New("unavailable")}
+		cache := NewCachingProvider(inner, 5*time.Minute)
+
+		_, err := cache.Route(ctx, depot, depot, ModeCar)
+		assert.EqualError(t, err, "unavailable")
+		_, err = cache.Route(ctx, depot, depot, ModeCar)
+		assert.Error(t, err)
+		assert.Equal(t, 2, inner.calls)
+	})
+}
@@ -0,0 +1,110 @@
+package signing
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanonicalize(t *testing.T) {
+	tests := []struct {
+		name      string
+		arguments string
+		expected  string
+	}{
+		{name: "Sorts keys", arguments: `{"b": 1, "a": {"d": true, "c": null}}`, expected: `{"a":{"c":null,"d":true},"b":1}`},
+		{name: "Keeps numbers as written", arguments: `{"latitude": 40.7128000, "big": 12345678901234567890}`, expected: `{"big":12345678901234567890,"latitude":40.7128000}`},
+		{name: "Does not escape HTML", arguments: `{"name": "Fish & <Chips>"}`, expected: `{"name":"Fish & <Chips>"}`},
+		{name: "Keeps array order", arguments: `{"ids": ["b", "a"]}`, expected: `{"ids":["b","a"]}`},
+		{name: "No arguments", arguments: ``, expected: `{}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			canonical, err := Canonicalize(json.RawMessage(tt.arguments))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, string(canonical))
+		})
+	}
+
+	t.Run("Rejects malformed arguments", func(t *testing.T) {
+		_, err := Canonicalize(json.RawMessage(`{"a":`))
+		assert.ErrorContains(t, err, "failed to parse arguments")
+	})
+}
+
+func TestSign(t *testing.T) {
+	// printf '1717200000\ndeleteLocation\n{"accountId":"acc-12345","locationId":"loc-001"}' | openssl dgst -sha256 -hmac secret
+	signature, err := Sign("secret", "1717200000", "deleteLocation", json.RawMessage(`{"locationId": "loc-001", "accountId": "acc-12345"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "70e9e2bd138b6c9d2a1e2a5fb77e25a66a014a0ecbf9cfcf5bd102a2d71ba9c8", signature)
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Unix(1717200000, 0)
+	arguments := json.RawMessage(`{"accountId": "acc-12345", "locationId": "loc-001"}`)
+	sign := func(at time.Time) (string, string) {
+		timestamp := strconv.FormatInt(at.Unix(), 10)
+		signature, err := Sign("secret", timestamp, "deleteLocation", arguments)
+		require.NoError(t, err)
+		return signature, timestamp
+	}
+
+	t.Run("Valid signature", func(t *testing.T) {
+		signature, timestamp := sign(now.Add(-time.Minute))
+		assert.NoError(t, Verify("secret", signature, timestamp, "deleteLocation", arguments, now, 5*time.Minute))
+	})
+
+	t.Run("Unsigned", func(t *testing.T) {
+		err := Verify("secret", "", "", "deleteLocation", arguments, now, 5*time.Minute)
+		assert.EqualError(t, err, "mutation must be signed with the x-signature and x-signature-timestamp headers")
+	})
+
+	t.Run("Stale", func(t *testing.T) {
+		signature, timestamp := sign(now.Add(-10 * time.Minute))
+		err := Verify("secret", signature, timestamp, "deleteLocation", arguments, now, 5*time.Minute)
+		assert.EqualError(t, err, "signature is stale: x-signature-timestamp must be within 5m0s of the current time")
+	})
+
+	t.Run("From the future", func(t *testing.T) {
+		signature, timestamp := sign(now.Add(10 * time.Minute))
+		err := Verify("secret", signature, timestamp, "deleteLocation", arguments, now, 5*time.Minute)
+		assert.ErrorContains(t, err, "signature is stale")
+	})
+
+	t.Run("Malformed timestamp", func(t *testing.T) {
+		err := Verify("secret", "abc", "2024-06-01", "deleteLocation", arguments, now, 5*time.Minute)
+		assert.EqualError(t, err, `x-signature-timestamp must be Unix seconds, got "2024-06-01"`)
+	})
+
+	t.Run("Wrong secret", func(t *testing.T) {
+		signature, timestamp := sign(now)
+		err := Verify("other", signature, timestamp, "deleteLocation", arguments, now, 5*time.Minute)
+		assert.EqualError(t, err, "invalid signature")
+	})
+
+	t.Run("Different field", func(t *testing.T) {
+		signature, timestamp := sign(now)
+		err := Verify("secret", signature, timestamp, "eraseLocationData", arguments, now, 5*time.Minute)
+		assert.EqualError(t, err, "invalid signature")
+	})
+
+	t.Run("Tampered arguments", func(t *testing.T) {
+		signature, timestamp := sign(now)
+		err := Verify("secret", signature, timestamp, "deleteLocation", json.RawMessage(`{"accountId": "acc-12345", "locationId": "loc-002"}`), now, 5*time.Minute)
+		assert.EqualError(t, err, "invalid signature")
+	})
+}
+
+func TestNewSecret(t *testing.T) {
+	first, err := NewSecret()
+	require.NoError(t, err)
+	second, err := NewSecret()
+	require.NoError(t, err)
+	assert.Len(t, first, 64)
+	assert.NotEqual(t, first, second)
+}
@@ -0,0 +1,86 @@
+package staticmap
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+// emptyPayloadHash is the SHA-256 of an empty request body.
+var emptyPayloadHash = func() string {
+	hash := sha256.Sum256(nil)
+	return hex.EncodeToString(hash[:])
+}()
+
+// AmazonProvider presigns Amazon Location Service Maps v2 GetStaticMap URLs
+// with the Lambda's own credentials, so no API key reaches clients. A URL
+// stops working at its expiry or when the signing session expires, whichever
+// comes first.
+type AmazonProvider struct {
+	signer      *v4.Signer
+	credentials aws.CredentialsProvider
+	region      string
+	endpoint    string
+	expiry      time.Duration
+	now         func() time.Time
+}
+
+// NewAmazonProvider creates a provider in the configured region whose URLs are valid for expiry.
+func NewAmazonProvider(cfg aws.Config, expiry time.Duration) *AmazonProvider {
+	return &AmazonProvider{
+		signer:      v4.NewSigner(),
+		credentials: cfg.Credentials,
+		region:      cfg.Region,
+		endpoint:    fmt.Sprintf("https://maps.geo.%s.amazonaws.com", cfg.Region),
+		expiry:      expiry,
+		now:         time.Now,
+	}
+}
+
+// URL returns a presigned static map URL for req.
+func (p *AmazonProvider) URL(ctx context.Context, req Request) (*Image, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	query := url.Values{}
+	query.Set("center", fmt.Sprintf("%s,%s", formatFloat(req.Center.Longitude), formatFloat(req.Center.Latitude)))
+	query.Set("zoom", formatFloat(req.Zoom))
+	query.Set("width", strconv.Itoa(req.Width))
+	query.Set("height", strconv.Itoa(req.Height))
+	query.Set("X-Amz-Expires", strconv.Itoa(int(p.expiry.Seconds())))
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, p.endpoint+"/v2/static/map?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build static map request: %w", err)
+	}
+
+	creds, err := p.credentials.Retrieve(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve credentials: %w", err)
+	}
+	signedAt := p.now()
+	signed, _, err := p.signer.PresignHTTP(ctx, creds, httpReq, emptyPayloadHash, "geo-maps", p.region, signedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to presign static map request: %w", err)
+	}
+
+	expiresAt := signedAt.Add(p.expiry)
+	if creds.CanExpire && creds.Expires.Before(expiresAt) {
+		expiresAt = creds.Expires
+	}
+	return &Image{URL: signed, ExpiresAt: expiresAt.UTC()}, nil
+}
+
+// formatFloat formats f without trailing zeros.
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,69 @@
+package staticmap
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAmazonProvider(t *testing.T) {
+	signedAt := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	req := Request{Center: models.Coordinates{Latitude: 40.7128, Longitude: -74.006}, Width: 300, Height: 200, Zoom: 15}
+
+	newProvider := func(creds aws.CredentialsProvider) *AmazonProvider {
+		p := NewAmazonProvider(aws.Config{Region: "us-east-1", Credentials: creds}, time.Hour)
+		p.now = func() time.Time { return signedAt }
+		return p
+	}
+
+	t.Run("Presigned URL", func(t *testing.T) {
+		p := newProvider(credentials.NewStaticCredentialsProvider("AKID", "SECRET", "TOKEN"))
+
+		image, err := p.URL(context.Background(), req)
+		require.NoError(t, err)
+
+		parsed, err := url.Parse(image.URL)
+		require.NoError(t, err)
+		assert.Equal(t, "maps.geo.us-east-1.amazonaws.com", parsed.Host)
+		assert.Equal(t, "/v2/static/map", parsed.Path)
+
+		query := parsed.Query()
+		assert.Equal(t, "-74.006,40.7128", query.Get("center"))
+		assert.Equal(t, "15", query.Get("zoom"))
+		assert.Equal(t, "300", query.Get("width"))
+		assert.Equal(t, "200", query.Get("height"))
+		assert.Equal(t, "3600", query.Get("X-Amz-Expires"))
+		assert.Equal(t, "AWS4-HMAC-SHA256", query.Get("X-Amz-Algorithm"))
+		assert.Equal(t, "AKID/20240601/us-east-1/geo-maps/aws4_request", query.Get("X-Amz-Credential"))
+		assert.Equal(t, "TOKEN", query.Get("X-Amz-Security-Token"))
+		assert.NotEmpty(t, query.Get("X-Amz-Signature"))
+		assert.Empty(t, query.Get("key"), "no API key is exposed")
+
+		assert.Equal(t, signedAt.Add(time.Hour), image.ExpiresAt)
+	})
+
+	t.Run("Expiry is capped by the signing session", func(t *testing.T) {
+		sessionEnd := signedAt.Add(20 * time.Minute)
+		p := newProvider(aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+			return aws.Credentials{AccessKeyID: "AKID", SecretAccessKey: "SECRET", CanExpire: true, Expires: sessionEnd}, nil
+		}))
+
+		image, err := p.URL(context.Background(), req)
+		require.NoError(t, err)
+		assert.Equal(t, sessionEnd, image.ExpiresAt)
+	})
+
+	t.Run("Invalid request", func(t *testing.T) {
+		p := newProvider(credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""))
+
+		_, err := p.URL(context.Background(), Request{Width: 10, Height: 10})
+		assert.EqualError(t, err, "width and height must be between 64 and 1400")
+	})
+}
@@ -0,0 +1,47 @@
+// Package staticmap builds URLs for static map images centered on a point.
+package staticmap
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+)
+
+// Image size and zoom limits accepted by Request.
+const (
+	MinDimension = 64
+	MaxDimension = 1400
+	MaxZoom      = 20
+)
+
+// Request describes a static map image.
+type Request struct {
+	Center models.Coordinates
+	Width  int // Pixels
+	Height int // Pixels
+	Zoom   float64
+}
+
+// Validate checks the image size and zoom against the supported limits.
+func (r Request) Validate() error {
+	if r.Width < MinDimension || r.Width > MaxDimension || r.Height < MinDimension || r.Height > MaxDimension {
+		return fmt.Errorf("width and height must be between %d and %d", MinDimension, MaxDimension)
+	}
+	if r.Zoom < 0 || r.Zoom > MaxZoom {
+		return fmt.Errorf("zoom must be between 0 and %d", MaxZoom)
+	}
+	return nil
+}
+
+// Image is a URL clients can fetch directly, without provider credentials.
+type Image struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// Provider builds static map image URLs.
+type Provider interface {
+	URL(ctx context.Context, req Request) (*Image, error)
+}
@@ -0,0 +1,37 @@
+package staticmap
+
+import (
+	"testing"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestValidate(t *testing.T) {
+	center := models.Coordinates{Latitude: 40.7128, Longitude: -74.006}
+
+	tests := []struct {
+		name    string
+		req     Request
+		wantErr string
+	}{
+		{name: "Valid", req: Request{Center: center, Width: 300, Height: 200, Zoom: 15}},
+		{name: "Smallest", req: Request{Center: center, Width: 64, Height: 64, Zoom: 0}},
+		{name: "Largest", req: Request{Center: center, Width: 1400, Height: 1400, Zoom: 20}},
+		{name: "Too narrow", req: Request{Center: center, Width: 63, Height: 200, Zoom: 15}, wantErr: "width and height must be between 64 and 1400"},
+		{name: "Too tall", req: Request{Center: center, Width: 300, Height: 1401, Zoom: 15}, wantErr: "width and height must be between 64 and 1400"},
+		{name: "Negative zoom", req: Request{Center: center, Width: 300, Height: 200, Zoom: -1}, wantErr: "zoom must be between 0 and 20"},
+		{name: "Zoom too deep", req: Request{Center: center, Width: 300, Height: 200, Zoom: 21}, wantErr: "zoom must be between 0 and 20"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+// Package stops segments the position history of coordinates locations into
+// trips and the stops between them.
+package stops
+
+import (
+	"time"
+
+	"github.com/steverhoton/location-lambda/internal/geo"
+	"github.com/steverhoton/location-lambda/internal/models"
+)
+
+// Defaults for detection options that are not set.
+const (
+	DefaultRadiusMeters = 100
+	DefaultMinDwell     = 5 * time.Minute
+)
+
+// Options control what counts as a stop.
+type Options struct {
+	// RadiusMeters is how far from where it arrived a location may drift
+	// and still be at the same stop
+	RadiusMeters float64
+	// MinDwell is how long a location must stay within RadiusMeters for
+	// the stay to count as a stop
+	MinDwell time.Duration
+}
+
+// Detect finds the stops in one location's pings, which must be in time
+// order. Consecutive pings within RadiusMeters of the first of them form a
+// cluster, and a cluster spanning at least MinDwell is a stop; everything
+// between stops is a trip. A stop is reported only once the location has
+// left it, and not when it starts at the first ping, which need not be when
+// the location arrived, so detecting over overlapping windows reports each
+// stop the same way every time.
+func Detect(accountID string, pings []models.PositionPing, options Options, now time.Time) []models.DetectedStop {
+	if options.RadiusMeters <= 0 {
+		options.RadiusMeters = DefaultRadiusMeters
+	}
+	if options.MinDwell <= 0 {
+		options.MinDwell = DefaultMinDwell
+	}
+
+	var detected []models.DetectedStop
+	// departed is the last ping of the previous stop, or -1 before the first
+	departed := -1
+	start := 0
+	for i := 1; i < len(pings); i++ {
+		if geo.Distance(pings[start].Coordinates, pings[i].Coordinates) <= options.RadiusMeters {
+			continue
+		}
+		cluster := pings[start:i]
+		if cluster[len(cluster)-1].At.Sub(cluster[0].At) >= options.MinDwell {
+			if start > 0 {
+				stop := newStop(accountID, cluster, now)
+				if departed >= 0 {
+					distance := pathLength(pings[departed : start+1])
+					stop.TripDistanceMeters = &distance
+				}
+				detected = append(detected, stop)
+			}
+			departed = i - 1
+		}
+		start = i
+	}
+	return detected
+}
+
+// newStop summarizes the pings of a stop.
+func newStop(accountID string, cluster []models.PositionPing, now time.Time) models.DetectedStop {
+	var latitude, longitude float64
+	for _, ping := range cluster {
+		latitude += ping.Coordinates.Latitude
+		longitude += ping.Coordinates.Longitude
+	}
+	arrived, departed := cluster[0].At, cluster[len(cluster)-1].At
+	return models.DetectedStop{
+		AccountID:  accountID,
+		LocationID: cluster[0].LocationID,
+		Center: models.Coordinates{
+			Latitude:  latitude / float64(len(cluster)),
+			Longitude: longitude / float64(len(cluster)),
+		},
+		ArrivedAt:    arrived,
+		DepartedAt:   departed,
+		DwellSeconds: departed.Sub(arrived).Seconds(),
+		Pings:        len(cluster),
+		DetectedAt:   now,
+	}
+}
+
+// pathLength returns the distance along pings in order.
+func pathLength(pings []models.PositionPing) float64 {
+	var total float64
+	for i := 1; i < len(pings); i++ {
+		total += geo.Distance(pings[i-1].Coordinates, pings[i].Coordinates)
+	}
+	return total
+}
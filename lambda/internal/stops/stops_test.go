@@ -0,0 +1,81 @@
+package stops
+
+import (
+	"testing"
+	"time"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var departure = time.Date(2024, 6, 1, 8, 0, 0, 0, time.UTC)
+
+// ping places loc-001 at latitude, on a road running north through Denver,
+// minutes after departure.
+func ping(latitude float64, minutes int) models.PositionPing {
+	return models.PositionPing{
+		LocationID:  "loc-001",
+		Coordinates: models.Coordinates{Latitude: latitude, Longitude: -104.99},
+		At:          departure.Add(time.Duration(minutes) * time.Minute),
+	}
+}
+
+func TestDetect(t *testing.T) {
+	detectedAt := time.Date(2024, 6, 2, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Segments a day into trips and stops", func(t *testing.T) {
+		pings := []models.PositionPing{
+			ping(39.7400, 0), ping(39.7401, 5), ping(39.7400, 10), // the depot, arrival unknown
+			ping(39.75, 12), ping(39.76, 14),
+			ping(39.7700, 16), ping(39.7702, 26), ping(39.7701, 36), // a delivery
+			ping(39.7800, 38), ping(39.7801, 39), // a red light
+			ping(39.7900, 41), ping(39.7900, 60), // not departed yet
+		}
+
+		detected := Detect("acc-12345", pings, Options{}, detectedAt)
+		require.Len(t, detected, 1)
+		stop := detected[0]
+		assert.Equal(t, "acc-12345", stop.AccountID)
+		assert.Equal(t, "loc-001", stop.LocationID)
+		assert.Equal(t, departure.Add(16*time.Minute), stop.ArrivedAt)
+		assert.Equal(t, departure.Add(36*time.Minute), stop.DepartedAt)
+		assert.Equal(t, 1200.0, stop.DwellSeconds)
+		assert.Equal(t, 3, stop.Pings)
+		assert.InDelta(t, 39.7701, stop.Center.Latitude, 1e-9)
+		assert.Equal(t, detectedAt, stop.DetectedAt)
+		require.NotNil(t, stop.TripDistanceMeters)
+		assert.InDelta(t, 3335.8, *stop.TripDistanceMeters, 1)
+	})
+
+	t.Run("A stop after the first has no trip distance", func(t *testing.T) {
+		pings := []models.PositionPing{
+			ping(39.74, 0), ping(39.75, 2),
+			ping(39.76, 4), ping(39.76, 20),
+			ping(39.77, 22),
+		}
+
+		detected := Detect("acc-12345", pings, Options{}, detectedAt)
+		require.Len(t, detected, 1)
+		assert.Equal(t, departure.Add(4*time.Minute), detected[0].ArrivedAt)
+		assert.Nil(t, detected[0].TripDistanceMeters)
+	})
+
+	t.Run("Options widen what counts as a stop", func(t *testing.T) {
+		pings := []models.PositionPing{
+			ping(39.74, 0),
+			ping(39.7500, 10), ping(39.7515, 12),
+			ping(39.77, 14),
+		}
+
+		assert.Empty(t, Detect("acc-12345", pings, Options{}, detectedAt))
+
+		detected := Detect("acc-12345", pings, Options{RadiusMeters: 250, MinDwell: time.Minute}, detectedAt)
+		require.Len(t, detected, 1)
+		assert.Equal(t, 2, detected[0].Pings)
+	})
+
+	t.Run("No pings", func(t *testing.T) {
+		assert.Empty(t, Detect("acc-12345", nil, Options{}, detectedAt))
+	})
+}
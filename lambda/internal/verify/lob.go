@@ -0,0 +1,104 @@
+package verify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+)
+
+// ProviderLob identifies the Lob US verification API.
+const ProviderLob = "lob"
+
+// LobVerifier verifies US addresses with the Lob US verification API.
+type LobVerifier struct {
+	httpClient HTTPClient
+	clock      Clock
+	apiKey     string
+	endpoint   string
+}
+
+// NewLobVerifier creates a verifier using a Lob secret API key, stamping
+// verifications with the time from clock.
+func NewLobVerifier(apiKey string, clock Clock) *LobVerifier {
+	return &LobVerifier{
+		httpClient: http.DefaultClient,
+		clock:      clock,
+		apiKey:     apiKey,
+		endpoint:   "https://api.lob.com",
+	}
+}
+
+// lobRequest is the US verification request body.
+type lobRequest struct {
+	PrimaryLine   string `json:"primary_line"`
+	SecondaryLine string `json:"secondary_line,omitempty"`
+	City          string `json:"city"`
+	State         string `json:"state"`
+	ZipCode       string `json:"zip_code"`
+}
+
+// lobResponse is the subset of the US verification response used here.
+type lobResponse struct {
+	PrimaryLine   string `json:"primary_line"`
+	SecondaryLine string `json:"secondary_line"`
+	Components    struct {
+		City         string `json:"city"`
+		State        string `json:"state"`
+		ZipCode      string `json:"zip_code"`
+		ZipCodePlus4 string `json:"zip_code_plus_4"`
+	} `json:"components"`
+	// Deliverability is deliverable, deliverable_unnecessary_unit,
+	// deliverable_incorrect_unit, deliverable_missing_unit, or undeliverable.
+	Deliverability string `json:"deliverability"`
+}
+
+// Verify verifies address. Addresses outside the US are reported as unsupported.
+func (v *LobVerifier) Verify(ctx context.Context, address models.Address) (*models.AddressVerification, error) {
+	if !isUS(address) {
+		return unsupported(ProviderLob, v.clock.Now()), nil
+	}
+
+	body, err := json.Marshal(lobRequest{
+		PrimaryLine:   address.StreetAddress,
+		SecondaryLine: address.StreetAddress2,
+		City:          address.City,
+		State:         address.StateProvince,
+		ZipCode:       address.PostalCode,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal verification request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.endpoint+"/v1/us_verifications", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build verification request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.SetBasicAuth(v.apiKey, "")
+
+	respBody, _, err := do(v.httpClient, req, ProviderLob, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed lobResponse
+	if err := json.Unmarshal(respBody, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal verification response: %w", err)
+	}
+	if !strings.HasPrefix(parsed.Deliverability, "deliverable") {
+		return undeliverable(ProviderLob, v.clock.Now()), nil
+	}
+
+	return deliverable(ProviderLob, v.clock.Now(), address, models.Address{
+		StreetAddress:  parsed.PrimaryLine,
+		StreetAddress2: parsed.SecondaryLine,
+		City:           parsed.Components.City,
+		StateProvince:  parsed.Components.State,
+		PostalCode:     joinZip(parsed.Components.ZipCode, parsed.Components.ZipCodePlus4),
+	}), nil
+}
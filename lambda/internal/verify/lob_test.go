@@ -0,0 +1,51 @@
+package verify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLobVerifier(t *testing.T) {
+	t.Run("Standardizes a deliverable address", func(t *testing.T) {
+		server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/v1/us_verifications", r.URL.Path)
+			user, _, ok := r.BasicAuth()
+			assert.True(t, ok)
+			assert.Equal(t, "test_key", user)
+
+			var req lobRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			assert.Equal(t, "94043", req.ZipCode)
+
+			_, _ = w.Write([]byte(`{"primary_line":"1600 AMPHITHEATRE PKWY","components":{"city":"MOUNTAIN VIEW","state":"CA","zip_code":"94043","zip_code_plus_4":"1351"},"deliverability":"deliverable"}`))
+		})
+		v := NewLobVerifier("test_key", repository.SystemClock)
+		v.endpoint = server.URL
+
+		result, err := v.Verify(context.Background(), testAddress)
+		require.NoError(t, err)
+		assert.Equal(t, models.VerificationStatusCorrected, result.Status)
+		assert.Equal(t, "1600 AMPHITHEATRE PKWY", result.StandardizedAddress.StreetAddress)
+		assert.Equal(t, "94043-1351", result.StandardizedAddress.PostalCode)
+	})
+
+	t.Run("Undeliverable", func(t *testing.T) {
+		server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"primary_line":"","deliverability":"undeliverable"}`))
+		})
+		v := NewLobVerifier("test_key", repository.SystemClock)
+		v.endpoint = server.URL
+
+		result, err := v.Verify(context.Background(), testAddress)
+		require.NoError(t, err)
+		assert.Equal(t, models.VerificationStatusUndeliverable, result.Status)
+		assert.Equal(t, ProviderLob, result.Provider)
+	})
+}
@@ -0,0 +1,96 @@
+package verify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+)
+
+// ProviderSmartyStreets identifies the SmartyStreets US Street API.
+const ProviderSmartyStreets = "smartystreets"
+
+// SmartyStreetsVerifier verifies US addresses with the SmartyStreets US Street API.
+type SmartyStreetsVerifier struct {
+	httpClient HTTPClient
+	clock      Clock
+	authID     string
+	authToken  string
+	endpoint   string
+}
+
+// NewSmartyStreetsVerifier creates a verifier using secret key credentials,
+// stamping verifications with the time from clock.
+func NewSmartyStreetsVerifier(authID, authToken string, clock Clock) *SmartyStreetsVerifier {
+	return &SmartyStreetsVerifier{
+		httpClient: http.DefaultClient,
+		clock:      clock,
+		authID:     authID,
+		authToken:  authToken,
+		endpoint:   "https://us-street.api.smarty.com",
+	}
+}
+
+// smartyCandidate is the subset of a US Street API candidate used here.
+type smartyCandidate struct {
+	DeliveryLine1 string `json:"delivery_line_1"`
+	DeliveryLine2 string `json:"delivery_line_2"`
+	Components    struct {
+		CityName          string `json:"city_name"`
+		StateAbbreviation string `json:"state_abbreviation"`
+		Zipcode           string `json:"zipcode"`
+		Plus4Code         string `json:"plus4_code"`
+	} `json:"components"`
+	Analysis struct {
+		DPVMatchCode string `json:"dpv_match_code"`
+	} `json:"analysis"`
+}
+
+// Verify verifies address. Addresses outside the US are reported as unsupported.
+func (v *SmartyStreetsVerifier) Verify(ctx context.Context, address models.Address) (*models.AddressVerification, error) {
+	if !isUS(address) {
+		return unsupported(ProviderSmartyStreets, v.clock.Now()), nil
+	}
+
+	query := url.Values{}
+	query.Set("auth-id", v.authID)
+	query.Set("auth-token", v.authToken)
+	query.Set("street", address.StreetAddress)
+	query.Set("secondary", address.StreetAddress2)
+	query.Set("city", address.City)
+	query.Set("state", address.StateProvince)
+	query.Set("zipcode", address.PostalCode)
+	query.Set("candidates", "1")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.endpoint+"/street-address?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build verification request: %w", err)
+	}
+
+	body, _, err := do(v.httpClient, req, ProviderSmartyStreets, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []smartyCandidate
+	if err := json.Unmarshal(body, &candidates); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal verification response: %w", err)
+	}
+	// An empty candidate list or an N match code means the address is not deliverable;
+	// S and D are deliverable with an ignored or missing secondary number.
+	if len(candidates) == 0 || candidates[0].Analysis.DPVMatchCode == "" || candidates[0].Analysis.DPVMatchCode == "N" {
+		return undeliverable(ProviderSmartyStreets, v.clock.Now()), nil
+	}
+
+	c := candidates[0]
+	return deliverable(ProviderSmartyStreets, v.clock.Now(), address, models.Address{
+		StreetAddress:  c.DeliveryLine1,
+		StreetAddress2: c.DeliveryLine2,
+		City:           c.Components.CityName,
+		StateProvince:  c.Components.StateAbbreviation,
+		PostalCode:     joinZip(c.Components.Zipcode, c.Components.Plus4Code),
+	}), nil
+}
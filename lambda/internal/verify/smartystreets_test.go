@@ -0,0 +1,68 @@
+package verify
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSmartyStreetsVerifier(t *testing.T) {
+	t.Run("Standardizes a deliverable address", func(t *testing.T) {
+		server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/street-address", r.URL.Path)
+			assert.Equal(t, "id", r.URL.Query().Get("auth-id"))
+			assert.Equal(t, "token", r.URL.Query().Get("auth-token"))
+			assert.Equal(t, "1600 amphitheatre parkway", r.URL.Query().Get("street"))
+			_, _ = w.Write([]byte(`[{"delivery_line_1":"1600 Amphitheatre Pkwy","components":{"city_name":"Mountain View","state_abbreviation":"CA","zipcode":"94043","plus4_code":"1351"},"analysis":{"dpv_match_code":"Y"}}]`))
+		})
+		v := NewSmartyStreetsVerifier("id", "token", repository.SystemClock)
+		v.endpoint = server.URL
+
+		result, err := v.Verify(context.Background(), testAddress)
+		require.NoError(t, err)
+		assert.Equal(t, models.VerificationStatusCorrected, result.Status)
+		assert.Equal(t, ProviderSmartyStreets, result.Provider)
+		assert.Equal(t, "1600 Amphitheatre Pkwy", result.StandardizedAddress.StreetAddress)
+		assert.Equal(t, "94043-1351", result.StandardizedAddress.PostalCode)
+	})
+
+	t.Run("No candidates", func(t *testing.T) {
+		server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`[]`))
+		})
+		v := NewSmartyStreetsVerifier("id", "token", repository.SystemClock)
+		v.endpoint = server.URL
+
+		result, err := v.Verify(context.Background(), testAddress)
+		require.NoError(t, err)
+		assert.Equal(t, models.VerificationStatusUndeliverable, result.Status)
+		assert.Nil(t, result.StandardizedAddress)
+	})
+
+	t.Run("Non-US address is not sent", func(t *testing.T) {
+		v := NewSmartyStreetsVerifier("id", "token", repository.SystemClock)
+		v.endpoint = "http://127.0.0.1:0"
+
+		address := testAddress
+		address.Country = "CA"
+		result, err := v.Verify(context.Background(), address)
+		require.NoError(t, err)
+		assert.Equal(t, models.VerificationStatusUnsupported, result.Status)
+	})
+
+	t.Run("Provider error", func(t *testing.T) {
+		server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		})
+		v := NewSmartyStreetsVerifier("id", "bad", repository.SystemClock)
+		v.endpoint = server.URL
+
+		_, err := v.Verify(context.Background(), testAddress)
+		assert.EqualError(t, err, "smartystreets request failed with status 401: ")
+	})
+}
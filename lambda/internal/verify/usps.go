@@ -0,0 +1,167 @@
+package verify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+)
+
+// ProviderUSPS identifies the USPS Addresses API.
+const ProviderUSPS = "usps"
+
+// tokenRefreshMargin renews USPS access tokens shortly before they expire.
+const tokenRefreshMargin = time.Minute
+
+// USPSVerifier verifies US addresses with the USPS Addresses v3 API, using
+// OAuth client credentials. Access tokens are cached across calls.
+type USPSVerifier struct {
+	httpClient   HTTPClient
+	clock        Clock
+	clientID     string
+	clientSecret string
+	endpoint     string
+
+	mu          sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+// NewUSPSVerifier creates a verifier using USPS API client credentials. Clock
+// stamps verifications and tells when the access token expires.
+func NewUSPSVerifier(clientID, clientSecret string, clock Clock) *USPSVerifier {
+	return &USPSVerifier{
+		httpClient:   http.DefaultClient,
+		clock:        clock,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		endpoint:     "https://apis.usps.com",
+	}
+}
+
+// uspsTokenRequest is the OAuth client credentials request body.
+type uspsTokenRequest struct {
+	GrantType    string `json:"grant_type"`
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+}
+
+// uspsTokenResponse is the subset of the OAuth token response used here.
+type uspsTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"` // Seconds
+}
+
+// uspsAddressResponse is the subset of the address standardization response used here.
+type uspsAddressResponse struct {
+	Address struct {
+		StreetAddress    string `json:"streetAddress"`
+		SecondaryAddress string `json:"secondaryAddress"`
+		City             string `json:"city"`
+		State            string `json:"state"`
+		ZIPCode          string `json:"ZIPCode"`
+		ZIPPlus4         string `json:"ZIPPlus4"`
+	} `json:"address"`
+	AdditionalInfo struct {
+		DPVConfirmation string `json:"DPVConfirmation"`
+	} `json:"additionalInfo"`
+}
+
+// Verify verifies address. Addresses outside the US are reported as unsupported.
+func (v *USPSVerifier) Verify(ctx context.Context, address models.Address) (*models.AddressVerification, error) {
+	if !isUS(address) {
+		return unsupported(ProviderUSPS, v.clock.Now()), nil
+	}
+
+	token, err := v.accessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := url.Values{}
+	query.Set("streetAddress", address.StreetAddress)
+	if address.StreetAddress2 != "" {
+		query.Set("secondaryAddress", address.StreetAddress2)
+	}
+	query.Set("city", address.City)
+	query.Set("state", address.StateProvince)
+	query.Set("ZIPCode", zip5(address.PostalCode))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.endpoint+"/addresses/v3/address?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build verification request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	// The API answers 404 when no address matches
+	body, found, err := do(v.httpClient, req, ProviderUSPS, http.StatusNotFound)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return undeliverable(ProviderUSPS, v.clock.Now()), nil
+	}
+
+	var parsed uspsAddressResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal verification response: %w", err)
+	}
+	if parsed.AdditionalInfo.DPVConfirmation == "" || parsed.AdditionalInfo.DPVConfirmation == "N" {
+		return undeliverable(ProviderUSPS, v.clock.Now()), nil
+	}
+
+	a := parsed.Address
+	return deliverable(ProviderUSPS, v.clock.Now(), address, models.Address{
+		StreetAddress:  a.StreetAddress,
+		StreetAddress2: a.SecondaryAddress,
+		City:           a.City,
+		StateProvince:  a.State,
+		PostalCode:     joinZip(a.ZIPCode, a.ZIPPlus4),
+	}), nil
+}
+
+// accessToken returns a cached access token, requesting a new one when it is near expiry.
+func (v *USPSVerifier) accessToken(ctx context.Context) (string, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.token != "" && v.clock.Now().Add(tokenRefreshMargin).Before(v.tokenExpiry) {
+		return v.token, nil
+	}
+
+	body, err := json.Marshal(uspsTokenRequest{
+		GrantType:    "client_credentials",
+		ClientID:     v.clientID,
+		ClientSecret: v.clientSecret,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal token request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.endpoint+"/oauth2/v3/token", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to build token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	respBody, _, err := do(v.httpClient, req, ProviderUSPS, 0)
+	if err != nil {
+		return "", fmt.Errorf("failed to obtain access token: %w", err)
+	}
+	var parsed uspsTokenResponse
+	if err := json.Unmarshal(respBody, &parsed); err != nil {
+		return "", fmt.Errorf("failed to unmarshal token response: %w", err)
+	}
+	if parsed.AccessToken == "" {
+		return "", fmt.Errorf("failed to obtain access token: response contained no token")
+	}
+
+	v.token = parsed.AccessToken
+	v.tokenExpiry = v.clock.Now().Add(time.Duration(parsed.ExpiresIn) * time.Second)
+	return v.token, nil
+}
@@ -0,0 +1,101 @@
+package verify
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/repository"
+	"github.com/steverhoton/location-lambda/internal/repository/repositorytest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUSPSVerifier(t *testing.T) {
+	t.Run("Caches the access token across verifications", func(t *testing.T) {
+		tokenRequests := 0
+		server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/oauth2/v3/token":
+				tokenRequests++
+				_, _ = w.Write([]byte(`{"access_token":"tok","expires_in":3600}`))
+			case "/addresses/v3/address":
+				assert.Equal(t, "Bearer tok", r.Header.Get("Authorization"))
+				assert.Equal(t, "94043", r.URL.Query().Get("ZIPCode"))
+				_, _ = w.Write([]byte(`{"address":{"streetAddress":"1600 AMPHITHEATRE PARKWAY","city":"MOUNTAIN VIEW","state":"CA","ZIPCode":"94043","ZIPPlus4":"1351"},"additionalInfo":{"DPVConfirmation":"Y"}}`))
+			default:
+				t.Errorf("unexpected path %s", r.URL.Path)
+			}
+		})
+		v := NewUSPSVerifier("client", "secret", repository.SystemClock)
+		v.endpoint = server.URL
+
+		for i := 0; i < 2; i++ {
+			result, err := v.Verify(context.Background(), testAddress)
+			require.NoError(t, err)
+			assert.Equal(t, models.VerificationStatusVerified, result.Status)
+			assert.Equal(t, "94043-1351", result.StandardizedAddress.PostalCode)
+		}
+		assert.Equal(t, 1, tokenRequests)
+	})
+
+	t.Run("Renews the access token by the injected clock", func(t *testing.T) {
+		tokenRequests := 0
+		server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/oauth2/v3/token" {
+				tokenRequests++
+				_, _ = w.Write([]byte(`{"access_token":"tok","expires_in":3600}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"address":{"streetAddress":"1600 AMPHITHEATRE PARKWAY","city":"MOUNTAIN VIEW","state":"CA","ZIPCode":"94043"},"additionalInfo":{"DPVConfirmation":"Y"}}`))
+		})
+		start := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+		clock := repositorytest.NewClock(start)
+		v := NewUSPSVerifier("client", "secret", clock)
+		v.endpoint = server.URL
+
+		result, err := v.Verify(context.Background(), testAddress)
+		require.NoError(t, err)
+		assert.Equal(t, start, result.VerifiedAt)
+
+		clock.Advance(58 * time.Minute)
+		_, err = v.Verify(context.Background(), testAddress)
+		require.NoError(t, err)
+		assert.Equal(t, 1, tokenRequests)
+
+		clock.Advance(time.Minute)
+		_, err = v.Verify(context.Background(), testAddress)
+		require.NoError(t, err)
+		assert.Equal(t, 2, tokenRequests)
+	})
+
+	t.Run("Address not found", func(t *testing.T) {
+		server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/oauth2/v3/token" {
+				_, _ = w.Write([]byte(`{"access_token":"tok","expires_in":3600}`))
+				return
+			}
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":{"message":"Address Not Found."}}`))
+		})
+		v := NewUSPSVerifier("client", "secret", repository.SystemClock)
+		v.endpoint = server.URL
+
+		result, err := v.Verify(context.Background(), testAddress)
+		require.NoError(t, err)
+		assert.Equal(t, models.VerificationStatusUndeliverable, result.Status)
+	})
+
+	t.Run("Token failure", func(t *testing.T) {
+		server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		})
+		v := NewUSPSVerifier("client", "wrong", repository.SystemClock)
+		v.endpoint = server.URL
+
+		_, err := v.Verify(context.Background(), testAddress)
+		assert.ErrorContains(t, err, "failed to obtain access token")
+	})
+}
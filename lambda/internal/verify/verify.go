@@ -0,0 +1,141 @@
+// Package verify checks postal addresses for deliverability with third-party
+// verification providers and returns their standardized form.
+package verify
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+)
+
+// Verifier verifies and standardizes a postal address.
+type Verifier interface {
+	Verify(ctx context.Context, address models.Address) (*models.AddressVerification, error)
+}
+
+// HTTPClient is the subset of http.Client used by the verification providers.
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Credentials holds provider credentials. ID is the SmartyStreets auth ID or
+// USPS client ID; Secret is the SmartyStreets auth token, Lob API key, or
+// USPS client secret.
+type Credentials struct {
+	ID     string
+	Secret string
+}
+
+// Clock tells the time verifications are stamped with and USPS access tokens
+// expire by. repository.Clock satisfies it, so handlers and verifiers can
+// share one.
+type Clock interface {
+	Now() time.Time
+}
+
+// New creates the verifier for a provider name: usps, lob, or smartystreets.
+func New(provider string, creds Credentials, clock Clock) (Verifier, error) {
+	switch strings.ToLower(provider) {
+	case ProviderUSPS:
+		return NewUSPSVerifier(creds.ID, creds.Secret, clock), nil
+	case ProviderLob:
+		return NewLobVerifier(creds.Secret, clock), nil
+	case ProviderSmartyStreets:
+		return NewSmartyStreetsVerifier(creds.ID, creds.Secret, clock), nil
+	default:
+		return nil, fmt.Errorf("unknown address verification provider %q", provider)
+	}
+}
+
+// isUS reports whether an address is in the United States, the only country
+// the supported providers verify.
+func isUS(address models.Address) bool {
+	return strings.EqualFold(address.Country, "US")
+}
+
+// unsupported returns the verification for an address outside provider coverage.
+func unsupported(provider string, verifiedAt time.Time) *models.AddressVerification {
+	return &models.AddressVerification{
+		Status:     models.VerificationStatusUnsupported,
+		Provider:   provider,
+		VerifiedAt: verifiedAt.UTC(),
+	}
+}
+
+// undeliverable returns the verification for an address the provider could not match.
+func undeliverable(provider string, verifiedAt time.Time) *models.AddressVerification {
+	return &models.AddressVerification{
+		Status:     models.VerificationStatusUndeliverable,
+		Provider:   provider,
+		VerifiedAt: verifiedAt.UTC(),
+	}
+}
+
+// deliverable returns the verification for a matched address, marking it
+// corrected when standardization changed anything beyond case or a ZIP+4 suffix.
+func deliverable(provider string, verifiedAt time.Time, input, standardized models.Address) *models.AddressVerification {
+	standardized.Country = "US"
+	status := models.VerificationStatusVerified
+	if !sameAddress(input, standardized) {
+		status = models.VerificationStatusCorrected
+	}
+	return &models.AddressVerification{
+		Status:              status,
+		Provider:            provider,
+		VerifiedAt:          verifiedAt.UTC(),
+		StandardizedAddress: &standardized,
+	}
+}
+
+// sameAddress compares addresses case-insensitively, ignoring ZIP+4 extensions.
+func sameAddress(a, b models.Address) bool {
+	return strings.EqualFold(strings.TrimSpace(a.StreetAddress), b.StreetAddress) &&
+		strings.EqualFold(strings.TrimSpace(a.StreetAddress2), b.StreetAddress2) &&
+		strings.EqualFold(strings.TrimSpace(a.City), b.City) &&
+		strings.EqualFold(strings.TrimSpace(a.StateProvince), b.StateProvince) &&
+		zip5(a.PostalCode) == zip5(b.PostalCode)
+}
+
+// zip5 returns the five-digit ZIP code from a ZIP or ZIP+4.
+func zip5(postalCode string) string {
+	postalCode = strings.TrimSpace(postalCode)
+	if i := strings.Index(postalCode, "-"); i >= 0 {
+		return postalCode[:i]
+	}
+	return postalCode
+}
+
+// joinZip formats a ZIP code with its optional +4 extension.
+func joinZip(zip, plus4 string) string {
+	if plus4 == "" {
+		return zip
+	}
+	return zip + "-" + plus4
+}
+
+// do sends req and returns the response body, treating any status other than
+// 200 or an accepted notFound status as an error.
+func do(client HTTPClient, req *http.Request, provider string, notFound int) ([]byte, bool, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to call %s: %w", provider, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read %s response: %w", provider, err)
+	}
+	if notFound != 0 && resp.StatusCode == notFound {
+		return body, false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, false, fmt.Errorf("%s request failed with status %d: %s", provider, resp.StatusCode, body)
+	}
+	return body, true, nil
+}
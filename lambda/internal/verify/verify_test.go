@@ -0,0 +1,59 @@
+package verify
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/steverhoton/location-lambda/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testAddress is the address sent to providers in tests.
+var testAddress = models.Address{
+	StreetAddress: "1600 amphitheatre parkway",
+	City:          "Mountain View",
+	StateProvince: "CA",
+	PostalCode:    "94043",
+	Country:       "US",
+}
+
+// newTestServer starts a provider stub that is closed when the test ends.
+func newTestServer(t *testing.T, handler http.HandlerFunc) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestNew(t *testing.T) {
+	for _, provider := range []string{"usps", "LOB", "smartystreets"} {
+		v, err := New(provider, Credentials{ID: "id", Secret: "secret"}, repository.SystemClock)
+		require.NoError(t, err)
+		assert.NotNil(t, v)
+	}
+
+	_, err := New("melissa", Credentials{}, repository.SystemClock)
+	assert.EqualError(t, err, `unknown address verification provider "melissa"`)
+}
+
+func TestDeliverable(t *testing.T) {
+	input := models.Address{StreetAddress: "123 main st", City: "springfield", StateProvince: "IL", PostalCode: "62704", Country: "US"}
+	verifiedAt := time.Date(2024, 6, 1, 12, 30, 0, 0, time.UTC)
+
+	t.Run("Case and ZIP+4 differences are not corrections", func(t *testing.T) {
+		result := deliverable(ProviderLob, verifiedAt, input, models.Address{StreetAddress: "123 MAIN ST", City: "SPRINGFIELD", StateProvince: "IL", PostalCode: "62704-1234"})
+		assert.Equal(t, models.VerificationStatusVerified, result.Status)
+		assert.Equal(t, "US", result.StandardizedAddress.Country)
+		assert.Equal(t, "62704-1234", result.StandardizedAddress.PostalCode)
+		assert.Equal(t, verifiedAt, result.VerifiedAt)
+	})
+
+	t.Run("Changed fields are corrections", func(t *testing.T) {
+		result := deliverable(ProviderLob, verifiedAt, input, models.Address{StreetAddress: "123 MAIN ST", City: "SPRINGFIELD", StateProvince: "IL", PostalCode: "62703"})
+		assert.Equal(t, models.VerificationStatusCorrected, result.Status)
+	})
+}
@@ -0,0 +1,129 @@
+package weather
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+)
+
+// HTTPClient is the subset of http.Client used by the weather providers.
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// OpenMeteoProvider reads current conditions from the Open-Meteo forecast API,
+// which needs no API key.
+type OpenMeteoProvider struct {
+	httpClient HTTPClient
+	endpoint   string
+}
+
+// NewOpenMeteoProvider creates an Open-Meteo provider.
+func NewOpenMeteoProvider() *OpenMeteoProvider {
+	return &OpenMeteoProvider{
+		httpClient: http.DefaultClient,
+		endpoint:   "https://api.open-meteo.com",
+	}
+}
+
+// openMeteoResponse is the subset of the forecast response used here. Times
+// are local to the coordinates, without an offset.
+type openMeteoResponse struct {
+	Timezone         string `json:"timezone"`
+	UTCOffsetSeconds int    `json:"utc_offset_seconds"`
+	Current          struct {
+		Time             string  `json:"time"`
+		Temperature      float64 `json:"temperature_2m"`
+		RelativeHumidity float64 `json:"relative_humidity_2m"`
+		WindSpeed        float64 `json:"wind_speed_10m"`
+		Precipitation    float64 `json:"precipitation"`
+		WeatherCode      int     `json:"weather_code"`
+	} `json:"current"`
+	Daily struct {
+		Sunrise []string `json:"sunrise"`
+		Sunset  []string `json:"sunset"`
+	} `json:"daily"`
+}
+
+// Current returns the current conditions at coords.
+func (p *OpenMeteoProvider) Current(ctx context.Context, coords models.Coordinates) (*Conditions, error) {
+	query := url.Values{}
+	query.Set("latitude", strconv.FormatFloat(coords.Latitude, 'f', -1, 64))
+	query.Set("longitude", strconv.FormatFloat(coords.Longitude, 'f', -1, 64))
+	query.Set("current", "temperature_2m,relative_humidity_2m,wind_speed_10m,precipitation,weather_code")
+	query.Set("daily", "sunrise,sunset")
+	query.Set("timezone", "auto")
+	query.Set("forecast_days", "1")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.endpoint+"/v1/forecast?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build weather request: %w", err)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch weather: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read weather response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("weather request failed with status %d: %s", resp.StatusCode, body)
+	}
+
+	var parsed openMeteoResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal weather response: %w", err)
+	}
+	if len(parsed.Daily.Sunrise) == 0 || len(parsed.Daily.Sunset) == 0 {
+		return nil, fmt.Errorf("weather response is missing sunrise and sunset")
+	}
+
+	zone := time.FixedZone(parsed.Timezone, parsed.UTCOffsetSeconds)
+	observedAt, err := parseLocalTime(parsed.Current.Time, zone)
+	if err != nil {
+		return nil, err
+	}
+	sunrise, err := parseLocalTime(parsed.Daily.Sunrise[0], zone)
+	if err != nil {
+		return nil, err
+	}
+	sunset, err := parseLocalTime(parsed.Daily.Sunset[0], zone)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Conditions{
+		TemperatureC:     parsed.Current.Temperature,
+		HumidityPercent:  parsed.Current.RelativeHumidity,
+		WindSpeedKmh:     parsed.Current.WindSpeed,
+		PrecipitationMm:  parsed.Current.Precipitation,
+		WeatherCode:      parsed.Current.WeatherCode,
+		Description:      describeWeatherCode(parsed.Current.WeatherCode),
+		ObservedAt:       observedAt,
+		Sunrise:          sunrise,
+		Sunset:           sunset,
+		Timezone:         parsed.Timezone,
+		UTCOffsetSeconds: parsed.UTCOffsetSeconds,
+		Provider:         "open-meteo",
+	}, nil
+}
+
+// parseLocalTime parses an ISO 8601 local time without offset, such as 2024-06-01T05:47.
+func parseLocalTime(value string, zone *time.Location) (time.Time, error) {
+	t, err := time.ParseInLocation("2006-01-02T15:04", value, zone)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse weather time %q: %w", value, err)
+	}
+	return t, nil
+}
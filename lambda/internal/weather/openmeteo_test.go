@@ -0,0 +1,59 @@
+package weather
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestOpenMeteoProvider(t *testing.T, handler http.HandlerFunc) *OpenMeteoProvider {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	p := NewOpenMeteoProvider()
+	p.endpoint = server.URL
+	return p
+}
+
+func TestOpenMeteoProvider(t *testing.T) {
+	t.Run("Current conditions", func(t *testing.T) {
+		p := newTestOpenMeteoProvider(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/v1/forecast", r.URL.Path)
+			assert.Equal(t, "40.7128", r.URL.Query().Get("latitude"))
+			assert.Equal(t, "-74.006", r.URL.Query().Get("longitude"))
+			assert.Equal(t, "auto", r.URL.Query().Get("timezone"))
+			_, _ = w.Write([]byte(`{
+				"timezone": "America/New_York",
+				"utc_offset_seconds": -14400,
+				"current": {"time": "2024-06-01T14:15", "temperature_2m": 24.3, "relative_humidity_2m": 55, "wind_speed_10m": 12.4, "precipitation": 0, "weather_code": 1},
+				"daily": {"sunrise": ["2024-06-01T05:26"], "sunset": ["2024-06-01T20:23"]}
+			}`))
+		})
+
+		conditions, err := p.Current(context.Background(), models.Coordinates{Latitude: 40.7128, Longitude: -74.006})
+		require.NoError(t, err)
+		assert.Equal(t, 24.3, conditions.TemperatureC)
+		assert.Equal(t, "partly cloudy", conditions.Description)
+		assert.Equal(t, "America/New_York", conditions.Timezone)
+		assert.Equal(t, time.Date(2024, 6, 1, 9, 26, 0, 0, time.UTC), conditions.Sunrise.UTC())
+		assert.Equal(t, time.Date(2024, 6, 2, 0, 23, 0, 0, time.UTC), conditions.Sunset.UTC())
+		assert.Equal(t, "open-meteo", conditions.Provider)
+	})
+
+	t.Run("Service error", func(t *testing.T) {
+		p := newTestOpenMeteoProvider(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":true,"reason":"Latitude must be in range"}`))
+		})
+
+		_, err := p.Current(context.Background(), models.Coordinates{Latitude: 40.7128, Longitude: -74.006})
+		assert.EqualError(t, err, `weather request failed with status 400: {"error":true,"reason":"Latitude must be in range"}`)
+	})
+}
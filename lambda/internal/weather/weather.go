@@ -0,0 +1,119 @@
+// Package weather looks up current conditions and daylight times for coordinates.
+package weather
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+)
+
+// Conditions are the current weather and the day's sunrise and sunset at a point.
+type Conditions struct {
+	TemperatureC     float64   `json:"temperatureC"`
+	HumidityPercent  float64   `json:"humidityPercent"`
+	WindSpeedKmh     float64   `json:"windSpeedKmh"`
+	PrecipitationMm  float64   `json:"precipitationMm"`
+	WeatherCode      int       `json:"weatherCode"` // WMO weather interpretation code
+	Description      string    `json:"description"`
+	ObservedAt       time.Time `json:"observedAt"`
+	Sunrise          time.Time `json:"sunrise"`
+	Sunset           time.Time `json:"sunset"`
+	Timezone         string    `json:"timezone,omitempty"` // IANA zone of the coordinates
+	UTCOffsetSeconds int       `json:"utcOffsetSeconds"`
+	Provider         string    `json:"provider"`
+	CachedAt         time.Time `json:"cachedAt"` // When the provider was queried
+}
+
+// Provider returns current conditions for coordinates.
+type Provider interface {
+	Current(ctx context.Context, coords models.Coordinates) (*Conditions, error)
+}
+
+// cacheEntry is a cached lookup and when it expires.
+type cacheEntry struct {
+	conditions *Conditions
+	expires    time.Time
+}
+
+// CachingProvider caches another provider's results for a short TTL, keyed by
+// coordinates rounded to about 100 meters. The cache lives for the life of the
+// Lambda execution environment.
+type CachingProvider struct {
+	provider Provider
+	ttl      time.Duration
+	now      func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+// NewCachingProvider wraps provider with a cache of the given TTL.
+func NewCachingProvider(provider Provider, ttl time.Duration) *CachingProvider {
+	return &CachingProvider{
+		provider: provider,
+		ttl:      ttl,
+		now:      time.Now,
+		entries:  make(map[string]cacheEntry),
+	}
+}
+
+// Current returns cached conditions when fresh, otherwise queries the provider.
+func (c *CachingProvider) Current(ctx context.Context, coords models.Coordinates) (*Conditions, error) {
+	key := cacheKey(coords)
+	now := c.now()
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && now.Before(entry.expires) {
+		return entry.conditions, nil
+	}
+
+	conditions, err := c.provider.Current(ctx, coords)
+	if err != nil {
+		return nil, err
+	}
+	conditions.CachedAt = now
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// Drop expired entries so the cache stays bounded by recent lookups
+	for k, e := range c.entries {
+		if !now.Before(e.expires) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = cacheEntry{conditions: conditions, expires: now.Add(c.ttl)}
+	return conditions, nil
+}
+
+// cacheKey rounds coordinates to three decimal places.
+func cacheKey(coords models.Coordinates) string {
+	return fmt.Sprintf("%.3f,%.3f", math.Round(coords.Latitude*1000)/1000, math.Round(coords.Longitude*1000)/1000)
+}
+
+// describeWeatherCode returns a short description of a WMO weather code.
+func describeWeatherCode(code int) string {
+	switch {
+	case code == 0:
+		return "clear sky"
+	case code <= 3:
+		return "partly cloudy"
+	case code == 45 || code == 48:
+		return "fog"
+	case code >= 51 && code <= 57:
+		return "drizzle"
+	case code >= 61 && code <= 67, code >= 80 && code <= 82:
+		return "rain"
+	case code >= 71 && code <= 77, code == 85 || code == 86:
+		return "snow"
+	case code >= 95:
+		return "thunderstorm"
+	default:
+		return "unknown"
+	}
+}
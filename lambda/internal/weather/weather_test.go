@@ -0,0 +1,69 @@
+package weather
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/steverhoton/location-lambda/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingProvider returns fixed conditions and counts calls.
+type countingProvider struct {
+	calls int
+	err   error
+}
+
+func (p *countingProvider) Current(ctx context.Context, coords models.Coordinates) (*Conditions, error) {
+	p.calls++
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &Conditions{TemperatureC: 21, Provider: "test"}, nil
+}
+
+func TestCachingProvider(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Serves nearby coordinates from cache until the TTL expires", func(t *testing.T) {
+		inner := &countingProvider{}
+		cache := NewCachingProvider(inner, 5*time.Minute)
+		cache.now = func() time.Time { return now }
+
+		first, err := cache.Current(ctx, models.Coordinates{Latitude: 40.71281, Longitude: -74.00601})
+		require.NoError(t, err)
+		assert.Equal(t, now, first.CachedAt)
+
+		_, err = cache.Current(ctx, models.Coordinates{Latitude: 40.71279, Longitude: -74.00599})
+		require.NoError(t, err)
+		assert.Equal(t, 1, inner.calls)
+
+		cache.now = func() time.Time { return now.Add(6 * time.Minute) }
+		_, err = cache.Current(ctx, models.Coordinates{Latitude: 40.7128, Longitude: -74.006})
+		require.NoError(t, err)
+		assert.Equal(t, 2, inner.calls)
+	})
+
+	t.Run("Errors are not cached", func(t *testing.T) {
+		inner := &countingProvider{err: errors.New("unavailable")}
+		cache := NewCachingProvider(inner, 5*time.Minute)
+
+		_, err := cache.Current(ctx, models.Coordinates{Latitude: 1, Longitude: 1})
+		assert.EqualError(t, err, "unavailable")
+		_, err = cache.Current(ctx, models.Coordinates{Latitude: 1, Longitude: 1})
+		assert.Error(t, err)
+		assert.Equal(t, 2, inner.calls)
+	})
+}
+
+func TestDescribeWeatherCode(t *testing.T) {
+	assert.Equal(t, "clear sky", describeWeatherCode(0))
+	assert.Equal(t, "partly cloudy", describeWeatherCode(2))
+	assert.Equal(t, "rain", describeWeatherCode(63))
+	assert.Equal(t, "snow", describeWeatherCode(86))
+	assert.Equal(t, "thunderstorm", describeWeatherCode(95))
+}
@@ -26,6 +26,113 @@ resource "aws_dynamodb_table" "locations" {
     projection_type = "ALL"
   }
 
+  # Sparse index resolving accountId#externalId to a location; only records
+  # with an external ID carry the key
+  attribute {
+    name = "accountExternalId"
+    type = "S"
+  }
+
+  global_secondary_index {
+    name            = var.dynamodb_external_id_index_name
+    hash_key        = "accountExternalId"
+    projection_type = "ALL"
+  }
+
+  # Sparse index resolving accountId#host to the shops with a website on it
+  attribute {
+    name = "accountWebsite"
+    type = "S"
+  }
+
+  global_secondary_index {
+    name            = var.dynamodb_website_index_name
+    hash_key        = "accountWebsite"
+    projection_type = "ALL"
+  }
+
+  # Sparse index resolving accountId#contactId to the shops with that primary contact
+  attribute {
+    name = "accountContact"
+    type = "S"
+  }
+
+  global_secondary_index {
+    name            = var.dynamodb_contact_index_name
+    hash_key        = "accountContact"
+    projection_type = "ALL"
+  }
+
+  # Sparse index resolving a parent account to its sub-accounts' settings
+  attribute {
+    name = "parentAccountId"
+    type = "S"
+  }
+
+  global_secondary_index {
+    name            = var.dynamodb_parent_account_index_name
+    hash_key        = "parentAccountId"
+    projection_type = "KEYS_ONLY"
+  }
+
+  # Changes to an account's locations in write order for syncLocations;
+  # deletion tombstones carry the same keys
+  attribute {
+    name = "syncAccount"
+    type = "S"
+  }
+
+  attribute {
+    name = "updatedAt"
+    type = "S"
+  }
+
+  global_secondary_index {
+    name            = var.dynamodb_sync_index_name
+    hash_key        = "syncAccount"
+    range_key       = "updatedAt"
+    projection_type = "ALL"
+  }
+
+  # Location ID lookups for admin tooling; locationType tells locations apart
+  # from templates and other items sharing the SK attribute
+  global_secondary_index {
+    name               = var.dynamodb_location_id_index_name
+    hash_key           = "SK"
+    projection_type    = "INCLUDE"
+    non_key_attributes = ["locationType"]
+  }
+
+  # Sharded account index used when write sharding is enabled
+  dynamic "attribute" {
+    for_each = var.dynamodb_shard_count > 1 ? [1] : []
+    content {
+      name = "accountShard"
+      type = "S"
+    }
+  }
+
+  dynamic "global_secondary_index" {
+    for_each = var.dynamodb_shard_count > 1 ? [1] : []
+    content {
+      name            = var.dynamodb_shard_index_name
+      hash_key        = "accountShard"
+      range_key       = "SK"
+      projection_type = "ALL"
+    }
+  }
+
+  # The stream feeds the change export and the IoT Core republish
+  stream_enabled   = local.stream_enabled
+  stream_view_type = local.stream_enabled ? "NEW_AND_OLD_IMAGES" : null
+
+  # Expires the request IDs recorded for replay protection, the recorded
+  # position history, and sync tombstones; no other item carries expiresAt
+  ttl {
+    attribute_name = "expiresAt"
+    enabled        = true
+  }
+
   point_in_time_recovery {
     enabled = true
   }
@@ -0,0 +1,150 @@
+# Flag shops whose primary contact the contacts service deletes. The Lambda
+# receives an AppSync-shaped event for a field that is not in the GraphQL schema.
+resource "aws_cloudwatch_event_rule" "contact_deleted" {
+  count          = var.contacts_event_bus_name != "" ? 1 : 0
+  name           = "${local.function_name_full}-contact-deleted"
+  description    = "Flag shops whose primary contact was deleted"
+  event_bus_name = var.contacts_event_bus_name
+
+  event_pattern = jsonencode({
+    source        = [var.contacts_event_source]
+    "detail-type" = ["ContactDeleted"]
+  })
+
+  tags = local.common_tags
+}
+
+# Events still failing after EventBridge's retries, kept for replay
+resource "aws_sqs_queue" "contact_deleted_dlq" {
+  count                     = length(aws_cloudwatch_event_rule.contact_deleted)
+  name                      = "${local.function_name_full}-contact-deleted-dlq"
+  message_retention_seconds = 1209600
+
+  tags = local.common_tags
+}
+
+resource "aws_sqs_queue_policy" "contact_deleted_dlq" {
+  count     = length(aws_sqs_queue.contact_deleted_dlq)
+  queue_url = aws_sqs_queue.contact_deleted_dlq[0].id
+
+  policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect = "Allow"
+        Principal = {
+          Service = "events.amazonaws.com"
+        }
+        Action   = "sqs:SendMessage"
+        Resource = aws_sqs_queue.contact_deleted_dlq[0].arn
+        Condition = {
+          ArnEquals = {
+            "aws:SourceArn" = aws_cloudwatch_event_rule.contact_deleted[0].arn
+          }
+        }
+      }
+    ]
+  })
+}
+
+resource "aws_cloudwatch_event_target" "contact_deleted" {
+  count          = length(aws_cloudwatch_event_rule.contact_deleted)
+  rule           = aws_cloudwatch_event_rule.contact_deleted[0].name
+  event_bus_name = var.contacts_event_bus_name
+  arn            = aws_lambda_function.location_handler.arn
+
+  input_transformer {
+    input_paths = {
+      accountId = "$.detail.accountId"
+      contactId = "$.detail.contactId"
+    }
+    input_template = <<-EOT
+      {"field": "contactDeleted", "invoker": "eventbridge", "arguments": {"accountId": <accountId>, "contactId": <contactId>}}
+    EOT
+  }
+
+  retry_policy {
+    maximum_event_age_in_seconds = 86400
+    maximum_retry_attempts       = 8
+  }
+
+  dead_letter_config {
+    arn = aws_sqs_queue.contact_deleted_dlq[0].arn
+  }
+}
+
+resource "aws_lambda_permission" "contact_deleted" {
+  count         = length(aws_cloudwatch_event_rule.contact_deleted)
+  statement_id  = "AllowContactDeletedEvents"
+  action        = "lambda:InvokeFunction"
+  function_name = aws_lambda_function.location_handler.function_name
+  principal     = "events.amazonaws.com"
+  source_arn    = aws_cloudwatch_event_rule.contact_deleted[0].arn
+}
+
+# Publish the geofence crossings of position updates
+data "aws_cloudwatch_event_bus" "geofence_events" {
+  count = var.geofence_event_bus_name != "" ? 1 : 0
+  name  = var.geofence_event_bus_name
+}
+
+resource "aws_iam_policy" "lambda_geofence_events_policy" {
+  count       = length(data.aws_cloudwatch_event_bus.geofence_events)
+  name        = "${local.function_name_full}-geofence-events-policy"
+  description = "IAM policy for Lambda to publish geofence events"
+
+  policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect   = "Allow"
+        Action   = "events:PutEvents"
+        Resource = data.aws_cloudwatch_event_bus.geofence_events[0].arn
+      }
+    ]
+  })
+
+  tags = local.common_tags
+}
+
+resource "aws_iam_role_policy_attachment" "lambda_geofence_events_policy_attachment" {
+  count      = length(aws_iam_policy.lambda_geofence_events_policy)
+  role       = aws_iam_role.lambda_execution_role.name
+  policy_arn = aws_iam_policy.lambda_geofence_events_policy[0].arn
+}
+
+# Scheduled stop detection over the recorded position history
+resource "aws_cloudwatch_event_rule" "stop_detection" {
+  count               = var.position_history_retention != "" && length(var.stop_detection_accounts) > 0 ? 1 : 0
+  name                = "${local.function_name_full}-stop-detection"
+  description         = "Segment recent position history into trips and stops"
+  schedule_expression = var.stop_detection_schedule
+
+  tags = local.common_tags
+}
+
+resource "aws_cloudwatch_event_target" "stop_detection" {
+  count = length(aws_cloudwatch_event_rule.stop_detection)
+  rule  = aws_cloudwatch_event_rule.stop_detection[0].name
+  arn   = aws_lambda_function.location_handler.arn
+
+  input = jsonencode({
+    field   = "detectStops"
+    invoker = "eventbridge"
+    arguments = {
+      accountIds   = var.stop_detection_accounts
+      lookback     = var.stop_detection_lookback
+      radiusMeters = var.stop_radius_meters
+      minDwell     = var.stop_min_dwell
+    }
+  })
+}
+
+resource "aws_lambda_permission" "stop_detection" {
+  count         = length(aws_cloudwatch_event_rule.stop_detection)
+  statement_id  = "AllowStopDetectionSchedule"
+  action        = "lambda:InvokeFunction"
+  function_name = aws_lambda_function.location_handler.function_name
+  principal     = "events.amazonaws.com"
+  source_arn    = aws_cloudwatch_event_rule.stop_detection[0].arn
+}
@@ -0,0 +1,123 @@
+# Change data capture: the table's stream invokes the Lambda, which writes
+# location changes to the data lake bucket for Athena and republishes them to
+# IoT Core
+
+resource "aws_lambda_event_source_mapping" "change_export" {
+  count                              = local.stream_enabled ? 1 : 0
+  event_source_arn                   = aws_dynamodb_table.locations.stream_arn
+  function_name                      = aws_lambda_function.location_handler.arn
+  starting_position                  = "TRIM_HORIZON"
+  batch_size                         = 500
+  maximum_batching_window_in_seconds = 60
+  bisect_batch_on_function_error     = true
+  maximum_retry_attempts             = 10
+
+  destination_config {
+    on_failure {
+      destination_arn = aws_sqs_queue.change_export_dlq[0].arn
+    }
+  }
+
+  depends_on = [aws_iam_role_policy_attachment.lambda_change_export_policy_attachment]
+}
+
+# Receives the stream positions of batches that still failed after retries
+resource "aws_sqs_queue" "change_export_dlq" {
+  count                     = local.stream_enabled ? 1 : 0
+  name                      = "${local.function_name_full}-change-export-dlq"
+  message_retention_seconds = 1209600
+
+  tags = local.common_tags
+}
+
+# IAM policy for Lambda to read the stream and, when exporting, write the data lake
+resource "aws_iam_policy" "lambda_change_export_policy" {
+  count       = local.stream_enabled ? 1 : 0
+  name        = "${local.function_name_full}-change-export-policy"
+  description = "IAM policy for Lambda to read the table's stream and write its changes to the data lake bucket"
+
+  policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = concat([
+      {
+        Effect = "Allow"
+        Action = [
+          "dynamodb:DescribeStream",
+          "dynamodb:GetRecords",
+          "dynamodb:GetShardIterator",
+          "dynamodb:ListStreams"
+        ]
+        Resource = aws_dynamodb_table.locations.stream_arn
+      },
+      {
+        Effect   = "Allow"
+        Action   = ["sqs:SendMessage"]
+        Resource = aws_sqs_queue.change_export_dlq[0].arn
+      }
+      ], var.change_export_bucket_name != "" ? [
+      {
+        Effect   = "Allow"
+        Action   = ["s3:PutObject"]
+        Resource = "arn:aws:s3:::${var.change_export_bucket_name}/${var.change_export_prefix}*"
+      }
+    ] : [])
+  })
+
+  tags = local.common_tags
+}
+
+resource "aws_iam_role_policy_attachment" "lambda_change_export_policy_attachment" {
+  count      = local.stream_enabled ? 1 : 0
+  role       = aws_iam_role.lambda_execution_role.name
+  policy_arn = aws_iam_policy.lambda_change_export_policy[0].arn
+}
+
+# Glue table over the flat format, its columns published from the location
+# models by the changeexport package's tests
+locals {
+  change_export_table = jsondecode(file("${path.module}/change_export_table.json"))
+}
+
+resource "aws_glue_catalog_table" "change_export" {
+  count         = var.change_export_bucket_name != "" && var.change_export_format == "flat" && var.change_export_glue_database != "" ? 1 : 0
+  name          = "location_changes"
+  database_name = var.change_export_glue_database
+  table_type    = "EXTERNAL_TABLE"
+
+  parameters = {
+    "EXTERNAL"                  = "TRUE"
+    "classification"            = "json"
+    "projection.enabled"        = "true"
+    "projection.accountid.type" = "injected"
+    "projection.date.type"      = "date"
+    "projection.date.format"    = "yyyy-MM-dd"
+    "projection.date.range"     = "2024-01-01,NOW"
+    "storage.location.template" = "s3://${var.change_export_bucket_name}/${var.change_export_prefix}accountId=$${accountid}/date=$${date}/"
+  }
+
+  dynamic "partition_keys" {
+    for_each = local.change_export_table.partitionKeys
+    content {
+      name = partition_keys.value.name
+      type = partition_keys.value.type
+    }
+  }
+
+  storage_descriptor {
+    location      = "s3://${var.change_export_bucket_name}/${var.change_export_prefix}"
+    input_format  = "org.apache.hadoop.mapred.TextInputFormat"
+    output_format = "org.apache.hadoop.hive.ql.io.HiveIgnoreKeyTextOutputFormat"
+
+    ser_de_info {
+      serialization_library = "org.openx.data.jsonserde.JsonSerDe"
+    }
+
+    dynamic "columns" {
+      for_each = local.change_export_table.columns
+      content {
+        name = columns.value.name
+        type = columns.value.type
+      }
+    }
+  }
+}
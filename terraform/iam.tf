@@ -42,10 +42,14 @@ resource "aws_iam_policy" "lambda_dynamodb_policy" {
           "dynamodb:Query",
           "dynamodb:Scan"
         ]
-        Resource = [
-          aws_dynamodb_table.locations.arn,
-          "${aws_dynamodb_table.locations.arn}/index/*"
-        ]
+        Resource = concat(
+          [
+            aws_dynamodb_table.locations.arn,
+            "${aws_dynamodb_table.locations.arn}/index/*"
+          ],
+          local.regional_table_arns,
+          [for arn in local.regional_table_arns : "${arn}/index/*"]
+        )
       }
     ]
   })
@@ -57,4 +61,33 @@ resource "aws_iam_policy" "lambda_dynamodb_policy" {
 resource "aws_iam_role_policy_attachment" "lambda_dynamodb_policy_attachment" {
   role       = aws_iam_role.lambda_execution_role.name
   policy_arn = aws_iam_policy.lambda_dynamodb_policy.arn
-}
\ No newline at end of file
+}
+
+# IAM policy for client-side envelope encryption of sensitive attributes
+resource "aws_iam_policy" "lambda_kms_policy" {
+  count       = var.encryption_kms_key_arn != "" ? 1 : 0
+  name        = "${local.function_name_full}-kms-policy"
+  description = "IAM policy for Lambda to generate and decrypt data keys"
+
+  policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect = "Allow"
+        Action = [
+          "kms:GenerateDataKey",
+          "kms:Decrypt"
+        ]
+        Resource = var.encryption_kms_key_arn
+      }
+    ]
+  })
+
+  tags = local.common_tags
+}
+
+resource "aws_iam_role_policy_attachment" "lambda_kms_policy_attachment" {
+  count      = var.encryption_kms_key_arn != "" ? 1 : 0
+  role       = aws_iam_role.lambda_execution_role.name
+  policy_arn = aws_iam_policy.lambda_kms_policy[0].arn
+}
@@ -0,0 +1,33 @@
+# IoT Core republish: the stream processor publishes each location change to
+# its account's topic for devices that can't hold GraphQL subscriptions
+
+data "aws_iot_endpoint" "data" {
+  count         = var.enable_iot_publish ? 1 : 0
+  endpoint_type = "iot:Data-ATS"
+}
+
+# IAM policy for Lambda to publish to the account topics
+resource "aws_iam_policy" "lambda_iot_publish_policy" {
+  count       = var.enable_iot_publish ? 1 : 0
+  name        = "${local.function_name_full}-iot-publish-policy"
+  description = "IAM policy for Lambda to republish location changes to IoT Core topics"
+
+  policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect   = "Allow"
+        Action   = ["iot:Publish"]
+        Resource = "arn:aws:iot:${var.aws_region}:${data.aws_caller_identity.current.account_id}:topic/${var.iot_topic_prefix}*"
+      }
+    ]
+  })
+
+  tags = local.common_tags
+}
+
+resource "aws_iam_role_policy_attachment" "lambda_iot_publish_policy_attachment" {
+  count      = var.enable_iot_publish ? 1 : 0
+  role       = aws_iam_role.lambda_execution_role.name
+  policy_arn = aws_iam_policy.lambda_iot_publish_policy[0].arn
+}
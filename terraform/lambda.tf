@@ -39,9 +39,68 @@ resource "aws_lambda_function" "location_handler" {
 
   environment {
     variables = {
-      DYNAMODB_TABLE_NAME = aws_dynamodb_table.locations.name
-      DYNAMODB_GSI_NAME   = var.dynamodb_gsi_name
-      GO_VERSION          = var.go_version
+      DYNAMODB_TABLE_NAME                  = aws_dynamodb_table.locations.name
+      DYNAMODB_GSI_NAME                    = var.dynamodb_gsi_name
+      DYNAMODB_SHARD_COUNT                 = tostring(var.dynamodb_shard_count)
+      DYNAMODB_SHARD_INDEX_NAME            = var.dynamodb_shard_index_name
+      DYNAMODB_KEY_LAYOUT                  = var.dynamodb_key_layout
+      DYNAMODB_INDEX_FALLBACK_SEGMENTS     = tostring(var.dynamodb_index_fallback_segments)
+      DYNAMODB_EXTERNAL_ID_INDEX_NAME      = var.dynamodb_external_id_index_name
+      DYNAMODB_LOCATION_ID_INDEX_NAME      = var.dynamodb_location_id_index_name
+      DYNAMODB_WEBSITE_INDEX_NAME          = var.dynamodb_website_index_name
+      DYNAMODB_CONTACT_INDEX_NAME          = var.dynamodb_contact_index_name
+      DYNAMODB_PARENT_ACCOUNT_INDEX_NAME   = var.dynamodb_parent_account_index_name
+      DYNAMODB_SYNC_INDEX_NAME             = var.dynamodb_sync_index_name
+      SYNC_TOMBSTONE_RETENTION             = var.sync_tombstone_retention
+      OVERFLOW_S3_BUCKET                   = var.enable_payload_overflow ? aws_s3_bucket.overflow[0].bucket : ""
+      OVERFLOW_THRESHOLD_BYTES             = tostring(var.overflow_threshold_bytes)
+      ENCRYPTION_KMS_KEY_ID                = var.encryption_kms_key_arn
+      ENCRYPTED_ATTRIBUTES                 = join(",", var.encrypted_attributes)
+      PII_POLICY                           = var.pii_policy
+      PII_ACCOUNT_POLICIES                 = join(",", [for account, policy in var.pii_account_policies : "${account}:${policy}"])
+      ACCOUNT_REGIONS                      = join(",", [for account, region in var.account_regions : "${account}:${region}"])
+      REGIONAL_TABLES                      = join(",", [for region, table in var.regional_tables : "${region}:${table}"])
+      GEOCODER_PLACE_INDEX                 = var.enable_geocoding ? aws_location_place_index.geocoder[0].index_name : ""
+      ADDRESS_VERIFIER                     = var.address_verifier
+      ADDRESS_VERIFIER_ID                  = var.address_verifier_id
+      ADDRESS_VERIFIER_SECRET              = var.address_verifier_secret
+      PLACES_PROVIDER                      = var.places_provider
+      GOOGLE_PLACES_API_KEY                = var.google_places_api_key
+      WEATHER_PROVIDER                     = var.weather_provider
+      WEATHER_CACHE_TTL                    = var.weather_cache_ttl
+      ELEVATION_PROVIDER                   = var.elevation_provider
+      ELEVATION_CACHE_TTL                  = var.elevation_cache_ttl
+      ROUTING_PROVIDER                     = var.routing_provider
+      ROUTING_CACHE_TTL                    = var.routing_cache_ttl
+      STATIC_MAP_PROVIDER                  = var.static_map_provider
+      STATIC_MAP_URL_EXPIRY                = var.static_map_url_expiry
+      ADMIN_GROUP                          = var.admin_group
+      ADMIN_SCAN_SEGMENTS                  = tostring(var.admin_scan_segments)
+      PERMISSIONS_POLICY                   = var.permissions_policy
+      REQUEST_SIGNING                      = tostring(var.request_signing)
+      REQUEST_SIGNING_MAX_AGE              = var.request_signing_max_age
+      REPLAY_PROTECTION                    = tostring(var.replay_protection)
+      REPLAY_WINDOW                        = var.replay_window
+      VALIDATION_ERROR_INFO                = tostring(var.validation_error_info)
+      VERIFY_CONTENT_HASH                  = tostring(var.verify_content_hash)
+      USAGE_METERING                       = tostring(var.usage_metering)
+      CAPACITY_METRICS                     = tostring(var.capacity_metrics)
+      CAPACITY_METRICS_NAMESPACE           = var.capacity_metrics_namespace
+      CHANGE_EXPORT_BUCKET                 = var.change_export_bucket_name
+      CHANGE_EXPORT_PREFIX                 = var.change_export_prefix
+      CHANGE_EXPORT_FORMAT                 = var.change_export_format
+      IOT_DATA_ENDPOINT                    = var.enable_iot_publish ? data.aws_iot_endpoint.data[0].endpoint_address : ""
+      IOT_TOPIC_PREFIX                     = var.iot_topic_prefix
+      GEOFENCE_EVENT_BUS                   = var.geofence_event_bus_name
+      POSITION_HISTORY_RETENTION           = var.position_history_retention
+      RESPONSE_COMPRESSION_THRESHOLD_BYTES = tostring(var.response_compression_threshold_bytes)
+      LIST_FAST_BUDGET                     = var.list_fast_budget
+      LIST_DEFAULT_LIMIT                   = tostring(var.list_default_limit)
+      LIST_MAX_LIMIT                       = tostring(var.list_max_limit)
+      ASSET_S3_BUCKET                      = var.asset_bucket_name
+      ASSET_CDN_BASE_URL                   = var.asset_cdn_base_url
+      ASSET_URL_EXPIRY                     = var.asset_url_expiry
+      GO_VERSION                           = var.go_version
     }
   }
 
@@ -9,6 +9,17 @@ locals {
     var.additional_tags
   )
 
+  # The table's stream feeds the change export and the IoT Core republish
+  stream_enabled = var.change_export_bucket_name != "" || var.enable_iot_publish
+
   function_name_full = "${var.project}-${var.environment}-${var.lambda_function_name}"
   table_name_full    = "${var.project}-${var.environment}-${var.dynamodb_table_name}"
-}
\ No newline at end of file
+
+  # Data residency tables live in other regions and are managed outside this module
+  regional_table_arns = [
+    for region, table in var.regional_tables :
+    "arn:aws:dynamodb:${region}:${data.aws_caller_identity.current.account_id}:table/${table}"
+  ]
+}
+
+data "aws_caller_identity" "current" {}
\ No newline at end of file
@@ -0,0 +1,161 @@
+# Amazon Location Service place index for geocoding free-text addresses
+resource "aws_location_place_index" "geocoder" {
+  count       = var.enable_geocoding ? 1 : 0
+  index_name  = "${local.function_name_full}-places"
+  data_source = var.geocoding_data_source
+
+  data_source_configuration {
+    intended_use = "Storage"
+  }
+
+  tags = local.common_tags
+}
+
+# IAM policy for Lambda to query the place index
+resource "aws_iam_policy" "lambda_geocoding_policy" {
+  count       = var.enable_geocoding ? 1 : 0
+  name        = "${local.function_name_full}-geocoding-policy"
+  description = "IAM policy for Lambda to geocode with the place index"
+
+  policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect = "Allow"
+        Action = [
+          "geo:SearchPlaceIndexForText"
+        ]
+        Resource = aws_location_place_index.geocoder[0].index_arn
+      }
+    ]
+  })
+
+  tags = local.common_tags
+}
+
+resource "aws_iam_role_policy_attachment" "lambda_geocoding_policy_attachment" {
+  count      = var.enable_geocoding ? 1 : 0
+  role       = aws_iam_role.lambda_execution_role.name
+  policy_arn = aws_iam_policy.lambda_geocoding_policy[0].arn
+}
+
+# Scheduled refresh of stale geocodes. The Lambda receives an AppSync-shaped
+# event for a field that is not in the GraphQL schema.
+resource "aws_cloudwatch_event_rule" "geocode_refresh" {
+  count               = var.enable_geocoding && length(var.geocode_refresh_accounts) > 0 ? 1 : 0
+  name                = "${local.function_name_full}-geocode-refresh"
+  description         = "Re-geocode address locations with old geocodes or changed addresses"
+  schedule_expression = var.geocode_refresh_schedule
+
+  tags = local.common_tags
+}
+
+resource "aws_cloudwatch_event_target" "geocode_refresh" {
+  count = length(aws_cloudwatch_event_rule.geocode_refresh)
+  rule  = aws_cloudwatch_event_rule.geocode_refresh[0].name
+  arn   = aws_lambda_function.location_handler.arn
+
+  input = jsonencode({
+    field   = "refreshStaleGeocodes"
+    invoker = "eventbridge"
+    arguments = {
+      accountIds = var.geocode_refresh_accounts
+      maxAge     = var.geocode_refresh_max_age
+      rate       = var.geocode_refresh_rate
+    }
+  })
+}
+
+resource "aws_lambda_permission" "geocode_refresh" {
+  count         = length(aws_cloudwatch_event_rule.geocode_refresh)
+  statement_id  = "AllowGeocodeRefreshSchedule"
+  action        = "lambda:InvokeFunction"
+  function_name = aws_lambda_function.location_handler.function_name
+  principal     = "events.amazonaws.com"
+  source_arn    = aws_cloudwatch_event_rule.geocode_refresh[0].arn
+}
+
+# IAM policy for Lambda to look up shops with Amazon Location Places
+resource "aws_iam_policy" "lambda_places_policy" {
+  count       = var.places_provider == "amazon" ? 1 : 0
+  name        = "${local.function_name_full}-places-policy"
+  description = "IAM policy for Lambda to enrich shops with Amazon Location Places"
+
+  policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect = "Allow"
+        Action = [
+          "geo-places:Geocode",
+          "geo-places:SearchText"
+        ]
+        Resource = "arn:aws:geo-places:${var.aws_region}::provider/default"
+      }
+    ]
+  })
+
+  tags = local.common_tags
+}
+
+resource "aws_iam_role_policy_attachment" "lambda_places_policy_attachment" {
+  count      = var.places_provider == "amazon" ? 1 : 0
+  role       = aws_iam_role.lambda_execution_role.name
+  policy_arn = aws_iam_policy.lambda_places_policy[0].arn
+}
+
+# IAM policy for Lambda to calculate ETAs with Amazon Location Routes
+resource "aws_iam_policy" "lambda_routing_policy" {
+  count       = var.routing_provider == "amazon" ? 1 : 0
+  name        = "${local.function_name_full}-routing-policy"
+  description = "IAM policy for Lambda to calculate ETAs with Amazon Location Routes"
+
+  policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect = "Allow"
+        Action = [
+          "geo-routes:CalculateRoutes"
+        ]
+        Resource = "arn:aws:geo-routes:${var.aws_region}::provider/default"
+      }
+    ]
+  })
+
+  tags = local.common_tags
+}
+
+resource "aws_iam_role_policy_attachment" "lambda_routing_policy_attachment" {
+  count      = var.routing_provider == "amazon" ? 1 : 0
+  role       = aws_iam_role.lambda_execution_role.name
+  policy_arn = aws_iam_policy.lambda_routing_policy[0].arn
+}
+
+# IAM policy for Lambda to presign Amazon Location static map URLs
+resource "aws_iam_policy" "lambda_static_map_policy" {
+  count       = var.static_map_provider == "amazon" ? 1 : 0
+  name        = "${local.function_name_full}-static-map-policy"
+  description = "IAM policy for Lambda to presign Amazon Location static map URLs"
+
+  policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect = "Allow"
+        Action = [
+          "geo-maps:GetStaticMap"
+        ]
+        Resource = "arn:aws:geo-maps:${var.aws_region}::provider/default"
+      }
+    ]
+  })
+
+  tags = local.common_tags
+}
+
+resource "aws_iam_role_policy_attachment" "lambda_static_map_policy_attachment" {
+  count      = var.static_map_provider == "amazon" ? 1 : 0
+  role       = aws_iam_role.lambda_execution_role.name
+  policy_arn = aws_iam_policy.lambda_static_map_policy[0].arn
+}
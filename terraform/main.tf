@@ -6,6 +6,8 @@
 # - dynamodb.tf   - DynamoDB table and related resources
 # - iam.tf        - IAM roles, policies, and attachments
 # - lambda.tf     - Lambda function and build process
+# - s3.tf         - Optional S3 bucket for oversized payloads
+# - location.tf   - Optional Amazon Location place index for geocoding, Places access for enrichment, and static maps
 # - cloudwatch.tf - CloudWatch logging resources
 # - providers.tf  - Provider configurations
 # - variables.tf  - Input variables
@@ -31,4 +31,18 @@ output "dynamodb_gsi_name" {
 output "lambda_role_arn" {
   description = "ARN of the Lambda execution role"
   value       = aws_iam_role.lambda_execution_role.arn
-}
\ No newline at end of file
+}
+output "contact_deleted_dlq_url" {
+  description = "URL of the queue holding ContactDeleted events that could not be processed"
+  value       = length(aws_sqs_queue.contact_deleted_dlq) > 0 ? aws_sqs_queue.contact_deleted_dlq[0].url : null
+}
+
+output "change_export_dlq_url" {
+  description = "URL of the queue receiving change export batches that failed after retries"
+  value       = length(aws_sqs_queue.change_export_dlq) > 0 ? aws_sqs_queue.change_export_dlq[0].url : null
+}
+
+output "change_export_table_name" {
+  description = "Glue table over the flat change export"
+  value       = length(aws_glue_catalog_table.change_export) > 0 ? "${var.change_export_glue_database}.${aws_glue_catalog_table.change_export[0].name}" : null
+}
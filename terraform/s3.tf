@@ -0,0 +1,95 @@
+# S3 bucket for extendedAttributes of records that exceed the DynamoDB item size threshold
+resource "aws_s3_bucket" "overflow" {
+  count  = var.enable_payload_overflow ? 1 : 0
+  bucket = "${local.table_name_full}-overflow"
+
+  tags = merge(
+    local.common_tags,
+    {
+      Name = "${local.table_name_full}-overflow"
+    }
+  )
+}
+
+resource "aws_s3_bucket_public_access_block" "overflow" {
+  count  = var.enable_payload_overflow ? 1 : 0
+  bucket = aws_s3_bucket.overflow[0].id
+
+  block_public_acls       = true
+  block_public_policy     = true
+  ignore_public_acls      = true
+  restrict_public_buckets = true
+}
+
+resource "aws_s3_bucket_server_side_encryption_configuration" "overflow" {
+  count  = var.enable_payload_overflow ? 1 : 0
+  bucket = aws_s3_bucket.overflow[0].id
+
+  rule {
+    apply_server_side_encryption_by_default {
+      sse_algorithm = "AES256"
+    }
+  }
+}
+
+# IAM policy for Lambda to read and write overflow payloads
+resource "aws_iam_policy" "lambda_overflow_policy" {
+  count       = var.enable_payload_overflow ? 1 : 0
+  name        = "${local.function_name_full}-overflow-policy"
+  description = "IAM policy for Lambda to access the payload overflow bucket"
+
+  policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect = "Allow"
+        Action = [
+          "s3:GetObject",
+          "s3:PutObject",
+          "s3:DeleteObject"
+        ]
+        Resource = "${aws_s3_bucket.overflow[0].arn}/*"
+      },
+      {
+        # Erasure lists every stored version of a location's payload
+        Effect   = "Allow"
+        Action   = ["s3:ListBucket"]
+        Resource = aws_s3_bucket.overflow[0].arn
+      }
+    ]
+  })
+
+  tags = local.common_tags
+}
+
+resource "aws_iam_role_policy_attachment" "lambda_overflow_policy_attachment" {
+  count      = var.enable_payload_overflow ? 1 : 0
+  role       = aws_iam_role.lambda_execution_role.name
+  policy_arn = aws_iam_policy.lambda_overflow_policy[0].arn
+}
+
+# IAM policy for Lambda to presign reads of shop assets
+resource "aws_iam_policy" "lambda_asset_policy" {
+  count       = var.asset_bucket_name != "" && var.asset_cdn_base_url == "" ? 1 : 0
+  name        = "${local.function_name_full}-asset-policy"
+  description = "IAM policy for Lambda to presign reads from the shop asset bucket"
+
+  policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect   = "Allow"
+        Action   = ["s3:GetObject"]
+        Resource = "arn:aws:s3:::${var.asset_bucket_name}/*"
+      }
+    ]
+  })
+
+  tags = local.common_tags
+}
+
+resource "aws_iam_role_policy_attachment" "lambda_asset_policy_attachment" {
+  count      = var.asset_bucket_name != "" && var.asset_cdn_base_url == "" ? 1 : 0
+  role       = aws_iam_role.lambda_execution_role.name
+  policy_arn = aws_iam_policy.lambda_asset_policy[0].arn
+}
@@ -34,6 +34,501 @@ variable "dynamodb_gsi_name" {
   default     = "AccountIndex"
 }
 
+variable "dynamodb_shard_count" {
+  description = "Number of write shards per account for the sharded account index (0 disables sharding)"
+  type        = number
+  default     = 0
+
+  validation {
+    condition     = var.dynamodb_shard_count >= 0 && var.dynamodb_shard_count <= 64
+    error_message = "DynamoDB shard count must be between 0 and 64."
+  }
+}
+
+variable "dynamodb_index_fallback_segments" {
+  description = "Parallel scan segments that serve website and contact lookups while their GSI can't be read (0 fails them instead)"
+  type        = number
+  default     = 4
+
+  validation {
+    condition     = var.dynamodb_index_fallback_segments >= 0 && var.dynamodb_index_fallback_segments <= 16
+    error_message = "DynamoDB index fallback segments must be between 0 and 16."
+  }
+}
+
+variable "dynamodb_key_layout" {
+  description = "How location items are keyed: classic (PK accountId, SK locationId) or single-table (PK ACCOUNT#accountId, SK LOCATION#locationId). Fixed once the table holds locations."
+  type        = string
+  default     = "classic"
+
+  validation {
+    condition     = contains(["classic", "single-table"], var.dynamodb_key_layout)
+    error_message = "DynamoDB key layout must be classic or single-table."
+  }
+}
+
+variable "dynamodb_shard_index_name" {
+  description = "Name of the sharded account Global Secondary Index"
+  type        = string
+  default     = "AccountShardIndex"
+}
+
+variable "dynamodb_external_id_index_name" {
+  description = "Name of the sparse external ID Global Secondary Index"
+  type        = string
+  default     = "ExternalIdIndex"
+}
+
+variable "dynamodb_location_id_index_name" {
+  description = "Name of the Global Secondary Index keyed on location ID, used by admin lookups"
+  type        = string
+  default     = "LocationIdIndex"
+}
+
+variable "dynamodb_website_index_name" {
+  description = "Name of the sparse Global Secondary Index finding shops by website host"
+  type        = string
+  default     = "WebsiteIndex"
+}
+
+variable "dynamodb_contact_index_name" {
+  description = "Name of the sparse Global Secondary Index finding shops by primary contact"
+  type        = string
+  default     = "ContactIndex"
+}
+
+variable "dynamodb_sync_index_name" {
+  description = "Name of the Global Secondary Index listing an account's location changes by updatedAt for syncLocations"
+  type        = string
+  default     = "SyncIndex"
+}
+
+variable "sync_tombstone_retention" {
+  description = "How long deleted locations are remembered for syncLocations, as a Go duration; sync tokens older than this must sync from scratch"
+  type        = string
+  default     = "720h"
+}
+
+variable "dynamodb_parent_account_index_name" {
+  description = "Name of the sparse Global Secondary Index finding sub-accounts by parent account"
+  type        = string
+  default     = "ParentAccountIndex"
+}
+
+variable "contacts_event_bus_name" {
+  description = "EventBridge bus carrying the contacts service's ContactDeleted events; empty leaves deleted contacts on shops"
+  type        = string
+  default     = ""
+}
+
+variable "contacts_event_source" {
+  description = "Source of the contacts service's events on contacts_event_bus_name"
+  type        = string
+  default     = "contacts"
+}
+
+variable "geofence_event_bus_name" {
+  description = "EventBridge bus to publish GeofenceEntered and GeofenceExited events to; empty leaves geofences unevaluated"
+  type        = string
+  default     = ""
+}
+
+variable "position_history_retention" {
+  description = "How long (Go duration) position updates of coordinates locations are kept for stop detection; empty records none"
+  type        = string
+  default     = ""
+}
+
+variable "stop_detection_accounts" {
+  description = "Accounts whose position history is segmented into trips and stops on a schedule; empty disables detection (requires position_history_retention)"
+  type        = list(string)
+  default     = []
+}
+
+variable "stop_detection_schedule" {
+  description = "EventBridge schedule expression for stop detection"
+  type        = string
+  default     = "rate(1 hour)"
+}
+
+variable "stop_detection_lookback" {
+  description = "Position history (Go duration) each detection run reads; keep it above the schedule interval plus the longest stop"
+  type        = string
+  default     = "48h"
+}
+
+variable "stop_radius_meters" {
+  description = "Distance a location may drift from where it arrived and still be at the same stop"
+  type        = number
+  default     = 100
+}
+
+variable "stop_min_dwell" {
+  description = "Time (Go duration) a location must stay within stop_radius_meters for a stop"
+  type        = string
+  default     = "5m"
+}
+
+variable "admin_group" {
+  description = "Cognito group whose members may call the admin* operations (empty to disable them)"
+  type        = string
+  default     = ""
+}
+
+variable "admin_scan_segments" {
+  description = "Parallel scan segments adminListAllLocations splits the table into"
+  type        = number
+  default     = 4
+
+  validation {
+    condition     = var.admin_scan_segments >= 1 && var.admin_scan_segments <= 64
+    error_message = "Admin scan segments must be between 1 and 64."
+  }
+}
+
+variable "permissions_policy" {
+  description = "JSON policy mapping caller roles to the operations they may call (empty allows every operation)"
+  type        = string
+  default     = ""
+}
+
+variable "request_signing" {
+  description = "Let accounts require HMAC-signed mutations by rotating a signing secret"
+  type        = bool
+  default     = false
+}
+
+variable "request_signing_max_age" {
+  description = "How far a mutation signature's timestamp may be from the current time, as a Go duration"
+  type        = string
+  default     = "5m"
+}
+
+variable "replay_protection" {
+  description = "Let accounts reject mutations that repeat a request ID"
+  type        = bool
+  default     = false
+}
+
+variable "replay_window" {
+  description = "How long request IDs are remembered for replay protection, as a Go duration"
+  type        = string
+  default     = "10m"
+}
+
+variable "validation_error_info" {
+  description = "Return validation failures as results with errorInfo, for resolvers whose response mapping template raises them"
+  type        = bool
+  default     = false
+}
+
+variable "verify_content_hash" {
+  description = "Fail reads of locations whose content no longer matches the hash stored with them"
+  type        = bool
+  default     = false
+}
+
+variable "change_export_bucket_name" {
+  description = "Existing S3 bucket to export every location change to for Athena (empty disables the export)"
+  type        = string
+  default     = ""
+}
+
+variable "change_export_prefix" {
+  description = "Key prefix of exported changes in the change export bucket"
+  type        = string
+  default     = "changes/"
+}
+
+variable "change_export_format" {
+  description = "Shape of exported changes: images (DynamoDB images as JSON) or flat (one column per location field)"
+  type        = string
+  default     = "images"
+
+  validation {
+    condition     = contains(["images", "flat"], var.change_export_format)
+    error_message = "change_export_format must be images or flat."
+  }
+}
+
+variable "change_export_glue_database" {
+  description = "Existing Glue database to create the flat change export's table in (empty creates no table)"
+  type        = string
+  default     = ""
+}
+
+variable "usage_metering" {
+  description = "Count each account's operations per day and enable getUsage"
+  type        = bool
+  default     = false
+}
+
+variable "capacity_metrics" {
+  description = "Report each operation's consumed DynamoDB capacity as CloudWatch embedded metrics"
+  type        = bool
+  default     = false
+}
+
+variable "capacity_metrics_namespace" {
+  description = "CloudWatch namespace of the capacity metrics"
+  type        = string
+  default     = "LocationService"
+}
+
+variable "enable_iot_publish" {
+  description = "Republish every location change to per-account AWS IoT Core topics"
+  type        = bool
+  default     = false
+}
+
+variable "iot_topic_prefix" {
+  description = "Prefix of the IoT Core topics changes are republished to, each account's being <prefix><accountId>/changes"
+  type        = string
+  default     = "locations/"
+}
+
+variable "enable_payload_overflow" {
+  description = "Create an S3 bucket for extendedAttributes of oversized location records"
+  type        = bool
+  default     = false
+}
+
+variable "overflow_threshold_bytes" {
+  description = "Estimated item size in bytes above which extendedAttributes are stored in S3"
+  type        = number
+  default     = 358400
+}
+
+variable "encryption_kms_key_arn" {
+  description = "KMS key ARN for client-side encryption of sensitive extended attributes (empty disables)"
+  type        = string
+  default     = ""
+}
+
+variable "encrypted_attributes" {
+  description = "extendedAttributes keys to encrypt client-side"
+  type        = list(string)
+  default     = []
+}
+
+variable "pii_policy" {
+  description = "Default handling of apparent PII in extendedAttributes: off, reject, mask, or tag"
+  type        = string
+  default     = "off"
+
+  validation {
+    condition     = contains(["off", "reject", "mask", "tag"], var.pii_policy)
+    error_message = "PII policy must be one of off, reject, mask, or tag."
+  }
+}
+
+variable "pii_account_policies" {
+  description = "Per-account overrides of pii_policy, keyed by account ID"
+  type        = map(string)
+  default     = {}
+}
+
+variable "account_regions" {
+  description = "Data residency region per account ID; listed accounts are served from the matching regional table"
+  type        = map(string)
+  default     = {}
+}
+
+variable "regional_tables" {
+  description = "DynamoDB table name per data residency region"
+  type        = map(string)
+  default     = {}
+}
+
+variable "enable_geocoding" {
+  description = "Create an Amazon Location place index for geocoding free-text addresses"
+  type        = bool
+  default     = false
+}
+
+variable "geocoding_data_source" {
+  description = "Data provider for the geocoding place index (Esri, Here, or Grab)"
+  type        = string
+  default     = "Esri"
+}
+
+variable "geocode_refresh_accounts" {
+  description = "Accounts whose stale geocodes are refreshed on a schedule; empty disables the refresh (requires enable_geocoding)"
+  type        = list(string)
+  default     = []
+}
+
+variable "geocode_refresh_schedule" {
+  description = "EventBridge schedule expression for the geocode refresh"
+  type        = string
+  default     = "rate(1 day)"
+}
+
+variable "geocode_refresh_max_age" {
+  description = "Age (Go duration) after which a geocode is refreshed; changed addresses are refreshed regardless"
+  type        = string
+  default     = "2160h"
+}
+
+variable "geocode_refresh_rate" {
+  description = "Maximum geocode requests per second during the refresh"
+  type        = number
+  default     = 5
+}
+
+variable "address_verifier" {
+  description = "Address verification provider (usps, lob, or smartystreets); empty disables verification"
+  type        = string
+  default     = ""
+
+  validation {
+    condition     = contains(["", "usps", "lob", "smartystreets"], var.address_verifier)
+    error_message = "Address verifier must be one of: usps, lob, smartystreets, or empty."
+  }
+}
+
+variable "address_verifier_id" {
+  description = "SmartyStreets auth ID or USPS OAuth client ID for address verification"
+  type        = string
+  default     = ""
+  sensitive   = true
+}
+
+variable "address_verifier_secret" {
+  description = "SmartyStreets auth token, Lob secret API key, or USPS OAuth client secret for address verification"
+  type        = string
+  default     = ""
+  sensitive   = true
+}
+
+variable "places_provider" {
+  description = "Places provider for shop enrichment (amazon or google); empty disables enrichment"
+  type        = string
+  default     = ""
+
+  validation {
+    condition     = contains(["", "amazon", "google"], var.places_provider)
+    error_message = "Places provider must be amazon, google, or empty."
+  }
+}
+
+variable "google_places_api_key" {
+  description = "Google Places API key, used when places_provider is google"
+  type        = string
+  default     = ""
+  sensitive   = true
+}
+
+variable "weather_provider" {
+  description = "Weather provider for getLocationContext (open-meteo); empty disables location context"
+  type        = string
+  default     = ""
+
+  validation {
+    condition     = contains(["", "open-meteo"], var.weather_provider)
+    error_message = "Weather provider must be open-meteo or empty."
+  }
+}
+
+variable "weather_cache_ttl" {
+  description = "How long weather lookups are cached per coordinate, as a Go duration"
+  type        = string
+  default     = "10m"
+}
+
+variable "elevation_provider" {
+  description = "Terrain elevation provider that fills in missing altitudes on write (open-meteo); empty disables it"
+  type        = string
+  default     = ""
+
+  validation {
+    condition     = contains(["", "open-meteo"], var.elevation_provider)
+    error_message = "Elevation provider must be open-meteo or empty."
+  }
+}
+
+variable "elevation_cache_ttl" {
+  description = "How long elevation lookups are cached per coordinate, as a Go duration"
+  type        = string
+  default     = "24h"
+}
+
+variable "routing_provider" {
+  description = "Routing provider for etaToLocation (amazon); empty disables ETAs"
+  type        = string
+  default     = ""
+
+  validation {
+    condition     = contains(["", "amazon"], var.routing_provider)
+    error_message = "Routing provider must be amazon or empty."
+  }
+}
+
+variable "routing_cache_ttl" {
+  description = "How long routes are cached per origin, destination, and mode, as a Go duration"
+  type        = string
+  default     = "5m"
+}
+
+variable "static_map_provider" {
+  description = "Static map provider for map thumbnail URLs (amazon); empty disables map thumbnails"
+  type        = string
+  default     = ""
+
+  validation {
+    condition     = contains(["", "amazon"], var.static_map_provider)
+    error_message = "Static map provider must be amazon or empty."
+  }
+}
+
+variable "static_map_url_expiry" {
+  description = "How long map thumbnail URLs stay valid, as a Go duration"
+  type        = string
+  default     = "1h"
+}
+
+variable "asset_bucket_name" {
+  description = "Existing S3 bucket holding shop logos and photos; empty disables includeAssets unless asset_cdn_base_url is set"
+  type        = string
+  default     = ""
+}
+
+variable "asset_cdn_base_url" {
+  description = "Base URL of a CDN serving the asset bucket; when set, asset URLs use it instead of presigned S3 URLs"
+  type        = string
+  default     = ""
+}
+
+variable "asset_url_expiry" {
+  description = "How long presigned asset URLs stay valid, as a Go duration"
+  type        = string
+  default     = "1h"
+}
+
+variable "response_compression_threshold_bytes" {
+  description = "Locations JSON size above which list pages are gzip-compressed for clients that accept it (0 to disable)"
+  type        = number
+  default     = 1048576
+}
+
+variable "list_fast_budget" {
+  description = "Time budget of listLocationsFast, as a Go duration"
+  type        = string
+  default     = "300ms"
+}
+
+variable "list_default_limit" {
+  description = "Page size of the list operations when the request gives no limit"
+  type        = number
+  default     = 20
+}
+
+variable "list_max_limit" {
+  description = "Largest limit a list request may ask for"
+  type        = number
+  default     = 100
+}
+
 variable "lambda_timeout" {
   description = "Lambda function timeout in seconds"
   type        = number